@@ -0,0 +1,23 @@
+package main
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// compressionMiddleware returns a gzip middleware that leaves responses
+// shorter than minLength bytes, every response for exemptPaths and WebSocket
+// upgrades untouched.
+func compressionMiddleware(minLength int, exemptPaths []string) echo.MiddlewareFunc {
+	exempt := make(map[string]bool, len(exemptPaths))
+	for _, path := range exemptPaths {
+		exempt[path] = true
+	}
+
+	return middleware.GzipWithConfig(middleware.GzipConfig{
+		Skipper: func(c echo.Context) bool {
+			return exempt[c.Request().URL.Path] || c.IsWebSocket()
+		},
+		MinLength: minLength,
+	})
+}
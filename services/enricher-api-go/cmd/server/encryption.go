@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"enricher-api-go/internal/config"
+	"enricher-api-go/internal/customer"
+	"enricher-api-go/internal/envelope"
+	"enricher-api-go/internal/health"
+)
+
+// openKMS returns the configured KMS wrapping the data keys
+func openKMS(ctx context.Context, cfg config.EncryptionConfig) (envelope.KMS, error) {
+	if cfg.KMS == config.EncryptionKMSAWS {
+		return envelope.OpenAWSKMS(ctx, envelope.AWSKMSConfig{
+			KeyID:    cfg.AWS.KeyID,
+			Region:   cfg.AWS.Region,
+			Endpoint: cfg.AWS.Endpoint,
+		})
+	}
+	if cfg.KeyFile == "" {
+		return nil, errors.New("ENCRYPTION_KEY_FILE is required for the local KMS")
+	}
+	return envelope.NewLocalKMS(cfg.KeyFile)
+}
+
+// openKeyring unwraps the configured data keys through the KMS. It returns
+// nil when encryption is off.
+func openKeyring(ctx context.Context, cfg config.EncryptionConfig) (*envelope.Keyring, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	kms, err := openKMS(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	wrapped := make([][]byte, len(cfg.DataKeys))
+	for i, key := range cfg.DataKeys {
+		// Validate checked the encoding
+		wrapped[i], _ = base64.StdEncoding.DecodeString(key)
+	}
+	keys, err := envelope.NewKeyring(ctx, kms, wrapped)
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("Encrypting customer personal data", "kms", cfg.KMS, "data_keys", len(wrapped))
+	return keys, nil
+}
+
+// runDataKey handles `enricher-api datakey`, which prints a new data key
+// wrapped by the configured KMS. Put it first in ENCRYPTION_DATA_KEYS to
+// encrypt with it.
+func runDataKey(ctx context.Context, cfg config.EncryptionConfig, out io.Writer) error {
+	kms, err := openKMS(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	_, wrapped, err := kms.GenerateDataKey(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(out, base64.StdEncoding.EncodeToString(wrapped))
+	return nil
+}
+
+// runReencrypt handles `enricher-api reencrypt`, which rewrites the stored
+// customers whose personal data is in plaintext or under a data key other
+// than the first, so the older keys can be dropped. The rewrites are not
+// recorded in the outbox: they change no customer.
+func runReencrypt(ctx context.Context, cfg *config.Config, out io.Writer) error {
+	if !cfg.Encryption.Enabled {
+		return errors.New("encryption is not enabled")
+	}
+	if cfg.Storage.Backend == config.StorageMemory {
+		return fmt.Errorf("re-encryption applies to stored customers, not the %s backend", config.StorageMemory)
+	}
+	keys, err := openKeyring(ctx, cfg.Encryption)
+	if err != nil {
+		return err
+	}
+	repos, closeStorage, err := openRepositories(cfg.Storage, false, &health.Readiness{})
+	if err != nil {
+		return err
+	}
+	defer closeStorage()
+
+	rewritten, err := customer.NewEncryptedRepository(repos.customers, keys).Reencrypt()
+	fmt.Fprintf(out, "Re-encrypted %d customers\n", rewritten)
+	return err
+}
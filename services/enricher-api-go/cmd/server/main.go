@@ -1,63 +1,1470 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
+	"enricher-api-go/internal/admin"
+	"enricher-api-go/internal/apiversion"
+	"enricher-api-go/internal/auth"
+	"enricher-api-go/internal/breaker"
+	"enricher-api-go/internal/bulkhead"
+	"enricher-api-go/internal/cache"
+	"enricher-api-go/internal/category"
+	"enricher-api-go/internal/chaos"
+	"enricher-api-go/internal/config"
+	"enricher-api-go/internal/consumer"
+	"enricher-api-go/internal/currency"
 	"enricher-api-go/internal/customer"
+	"enricher-api-go/internal/deadline"
+	"enricher-api-go/internal/dlq"
+	"enricher-api-go/internal/dynamo"
+	"enricher-api-go/internal/enrichment"
+	"enricher-api-go/internal/envelope"
+	"enricher-api-go/internal/featureflag"
+	"enricher-api-go/internal/health"
+	"enricher-api-go/internal/httpcache"
+	"enricher-api-go/internal/idgen"
+	"enricher-api-go/internal/inventory"
+	"enricher-api-go/internal/jobs"
+	"enricher-api-go/internal/jwtauth"
+	"enricher-api-go/internal/lifecycle"
+	"enricher-api-go/internal/loadshed"
+	"enricher-api-go/internal/logging"
+	"enricher-api-go/internal/loyalty"
+	"enricher-api-go/internal/media"
+	"enricher-api-go/internal/merge"
+	"enricher-api-go/internal/migrations"
+	"enricher-api-go/internal/order"
+	"enricher-api-go/internal/outbox"
+	"enricher-api-go/internal/payloadlog"
+	"enricher-api-go/internal/privacy"
+	"enricher-api-go/internal/problem"
 	"enricher-api-go/internal/product"
+	"enricher-api-go/internal/productimport"
+	"enricher-api-go/internal/ratelimit"
+	"enricher-api-go/internal/retry"
+	"enricher-api-go/internal/seed"
+	"enricher-api-go/internal/servertiming"
+	"enricher-api-go/internal/shipping"
+	"enricher-api-go/internal/sqlite"
+	"enricher-api-go/internal/tax"
+	"enricher-api-go/internal/unitofwork"
+	"enricher-api-go/internal/validation"
+	"enricher-api-go/internal/webhook"
 
+	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	gommonlog "github.com/labstack/gommon/log"
 )
 
 func main() {
+	seedStorage := flag.Bool("seed", false, "write the SEED_FILE fixtures to storage before serving")
+	flag.Parse()
+
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	// Route all logging, including the standard log package, through slog,
+	// at a level the admin API can change by reloading the configuration
+	var logLevel slog.LevelVar
+	logLevel.Set(logging.ParseLevel(cfg.LogLevel))
+	logger := logging.NewLeveled(&logLevel, os.Stdout)
+	slog.SetDefault(logger)
+
+	// `enricher-api migrate` manages the database schema and exits
+	if flag.Arg(0) == "migrate" {
+		if err := runMigrate(context.Background(), cfg.Storage, flag.Args()[1:], os.Stdout); err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+		return
+	}
+
+	// `enricher-api datakey` prints a new wrapped data key and exits
+	if flag.Arg(0) == "datakey" {
+		if err := runDataKey(context.Background(), cfg.Encryption, os.Stdout); err != nil {
+			log.Fatalf("Failed to generate data key: %v", err)
+		}
+		return
+	}
+
+	// `enricher-api reencrypt` rewrites stored customers under the current
+	// data key and exits
+	if flag.Arg(0) == "reencrypt" {
+		if err := runReencrypt(context.Background(), cfg, os.Stdout); err != nil {
+			log.Fatalf("Re-encryption failed: %v", err)
+		}
+		return
+	}
+
 	// Initialize Echo
 	e := echo.New()
+	e.HideBanner = true
+	e.HidePort = true
+	e.Logger.SetLevel(echoLogLevel(cfg.LogLevel))
+	e.Server.ReadTimeout = cfg.Server.ReadTimeout
+	e.Server.WriteTimeout = cfg.Server.WriteTimeout
+	e.Server.IdleTimeout = cfg.Server.IdleTimeout
+	// Rate limits and logs see the client IP only as far as proxies are trusted
+	e.IPExtractor = clientIP(cfg.Server.TrustedProxies)
+
+	// Enforce validate struct tags whenever handlers bind a request
+	requestValidator := validation.New()
+	e.Validator = requestValidator
+	e.Binder = validation.NewBinder(requestValidator)
+
+	// Answer every failure, including those of middleware, with problem details
+	e.HTTPErrorHandler = problem.HTTPErrorHandler
+
+	// Optional behavior the admin API can switch while the server runs
+	flags := featureflag.New(featureFlags(cfg))
 
 	// Middleware
-	e.Use(middleware.Logger())
+	e.Use(logging.Middleware(logger))
 	e.Use(middleware.Recover())
-	e.Use(middleware.CORS())
+	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{AllowOrigins: cfg.CORS.AllowOrigins}))
+	e.Use(compressionMiddleware(cfg.Compression.MinLength, cfg.Compression.ExemptPaths))
+	e.Use(flags.Gate(flagServerTiming, servertiming.Middleware(true)))
+	// Log the bodies of sampled requests, as the handlers see them; streams,
+	// exports and imports are never logged
+	e.Use(flags.Gate(flagPayloadLogging, payloadlog.Middleware(payloadLogConfig(cfg.PayloadLog),
+		"/v1/ws/", "/v1/customers/export", "/v1/products/export", "/v1/products/import", "/health", "/metrics")))
+	// Count requests in flight to shed lists and exports while overloaded;
+	// streams stay open for good and probes must always be answered
+	shedder := newLoadShedder(cfg.LoadShed)
+	if shedder != nil {
+		e.Use(shedder.Middleware("/v1/ws/", "/health", "/metrics", "/admin"))
+	}
+	// Keep each route group to its share of the capacity
+	bulkheads := newBulkheads(cfg.Bulkhead)
+	if len(bulkheads) > 0 {
+		e.Use(bulkhead.Middleware(bulkheads...))
+	}
+	// Streams and imports legitimately outlast a request deadline
+	e.Use(deadline.Middleware(cfg.Server.RequestTimeout,
+		"/v1/ws/", "/v1/customers/export", "/v1/products/export", "/v1/products/import"))
+	// Let clients revalidate reads by ETag; streams, exports and health
+	// checks are never served from a client's copy
+	e.Use(flags.Gate(flagHTTPCache, httpcache.Middleware(cfg.Server.CacheMaxAge,
+		"/v1/ws/", "/v1/customers/export", "/v1/products/export", "/health", "/metrics", "/admin")))
+
+	// Fault injection for resilience testing, applied after routing so faults
+	// can target route patterns; its API is mounted with the admin API
+	var faults *chaos.Handler
+	if cfg.Chaos.Enabled {
+		injector := chaos.NewInjector()
+		e.Use(injector.Middleware("/admin", "/health", "/metrics"))
+
+		faults = chaos.NewHandler(injector)
+		slog.Warn("Fault injection enabled; /admin/faults is exposed")
+	}
+
+	// Resources register here to be released after HTTP connections drain
+	var shutdown lifecycle.Shutdown
+	// Dependencies register here to be checked by the readiness probe
+	var readiness health.Readiness
 
 	// Initialize repositories
-	customerRepo := customer.NewInMemoryRepository()
-	productRepo := product.NewInMemoryRepository()
+	repos, closeStorage, err := openRepositories(cfg.Storage, cfg.Outbox.Enabled || cfg.Webhooks.Enabled, &readiness)
+	if err != nil {
+		log.Fatalf("Failed to initialize storage: %v", err)
+	}
+	// Encrypt customer personal data beneath every other decorator, so seeded
+	// customers are stored encrypted and the cache holds ciphertext
+	keys, err := openKeyring(context.Background(), cfg.Encryption)
+	if err != nil {
+		log.Fatalf("Failed to load encryption keys: %v", err)
+	}
+	if keys != nil {
+		repos.customers = customer.NewEncryptedRepository(repos.customers, keys)
+		if repos.customerHistory != nil {
+			repos.customerHistory = customer.NewEncryptedHistory(repos.customerHistory, keys)
+		}
+	}
+	customerRepo, productRepo, categoryRepo, orderRepo := repos.customers, repos.products, repos.categories, repos.orders
+	deadLetterRepo, webhookRepo, loyaltyRepo, imageRepo := repos.deadLetters, repos.webhooks, repos.loyalty, repos.images
+	mergeRepo := repos.merges
+	shutdown.Register("storage", func(context.Context) error { return closeStorage() })
+	if *seedStorage {
+		if err := runSeed(cfg.Storage.SeedFile, repos); err != nil {
+			log.Fatalf("Failed to seed storage: %v", err)
+		}
+	}
+
+	// Retry transient database failures beneath the breaker, so only calls
+	// that fail on every attempt count against it
+	var retriers []*retry.Retrier
+	if cfg.Storage.Backend != config.StorageMemory && cfg.Storage.Retry.Enabled {
+		storageRetrier := newStorageRetrier(cfg.Storage)
+		customerRepo = customer.NewRetryRepository(customerRepo, storageRetrier)
+		productRepo = product.NewRetryRepository(productRepo, storageRetrier)
+		categoryRepo = category.NewRetryRepository(categoryRepo, storageRetrier)
+		orderRepo = order.NewRetryRepository(orderRepo, storageRetrier)
+		deadLetterRepo = dlq.NewRetryRepository(deadLetterRepo, storageRetrier)
+		webhookRepo = webhook.NewRetryRepository(webhookRepo, storageRetrier)
+		loyaltyRepo = loyalty.NewRetryRepository(loyaltyRepo, storageRetrier)
+		imageRepo = media.NewRetryRepository(imageRepo, storageRetrier)
+		mergeRepo = merge.NewRetryRepository(mergeRepo, storageRetrier)
+		retriers = append(retriers, storageRetrier)
+	}
+
+	// Fail fast while the database is down instead of queueing on timeouts
+	var breakers []*breaker.Breaker
+	if cfg.Storage.Backend != config.StorageMemory && cfg.Storage.CircuitBreaker.Enabled {
+		storageBreaker := newStorageBreaker(cfg.Storage)
+		customerRepo = customer.NewBreakerRepository(customerRepo, storageBreaker)
+		productRepo = product.NewBreakerRepository(productRepo, storageBreaker)
+		categoryRepo = category.NewBreakerRepository(categoryRepo, storageBreaker)
+		orderRepo = order.NewBreakerRepository(orderRepo, storageBreaker)
+		deadLetterRepo = dlq.NewBreakerRepository(deadLetterRepo, storageBreaker)
+		webhookRepo = webhook.NewBreakerRepository(webhookRepo, storageBreaker)
+		loyaltyRepo = loyalty.NewBreakerRepository(loyaltyRepo, storageBreaker)
+		imageRepo = media.NewBreakerRepository(imageRepo, storageBreaker)
+		mergeRepo = merge.NewBreakerRepository(mergeRepo, storageBreaker)
+		breakers = append(breakers, storageBreaker)
+	}
+
+	// Serve hot lookups from a cache outside the breaker, so cached records
+	// are still served while the database is down
+	if store := openCache(cfg.Cache, &shutdown); store != nil {
+		cachedCustomers := customer.NewCachedRepository(customerRepo, store, cfg.Cache.CustomerTTL)
+		cachedProducts := product.NewCachedRepository(productRepo, store, cfg.Cache.ProductTTL)
+		customerRepo, productRepo = cachedCustomers, cachedProducts
+		// A reset replaces the records the cache was filled from
+		invalidateOnReset(repos.datasets, "customers", cachedCustomers)
+		invalidateOnReset(repos.datasets, "products", cachedProducts)
+	}
+	e.GET("/health/dependencies", breaker.DependenciesHandler(breakers...))
+	e.GET("/metrics", metricsHandler(breakers, retriers, bulkheads))
+
+	// Initialize ID generation (shared so sequences stay consistent)
+	idGenerator, err := idgen.New(cfg.IDGenerator, repos.ids)
+	if err != nil {
+		log.Fatalf("Invalid ID generator: %v", err)
+	}
+
+	// A DynamoDB transaction holds a bounded number of items, and with them
+	// bulk writes
+	if cfg.Storage.Backend == config.StorageDynamoDB {
+		cfg.Customer.MaxBulkSize = boundedBulkSize(cfg.Customer.MaxBulkSize, customer.DynamoDBMaxBulkSize)
+		cfg.Product.MaxBulkSize = boundedBulkSize(cfg.Product.MaxBulkSize, product.DynamoDBMaxBulkSize)
+	}
 
 	// Initialize services
-	customerService := customer.NewService(customerRepo)
-	productService := product.NewService(productRepo)
+	loyaltyService := loyalty.NewService(loyaltyRepo, loyalty.WithCustomerCheck(customerCheck(customerRepo)),
+		loyalty.WithTiers(loyalty.Tiers{SilverPoints: cfg.Loyalty.SilverPoints, GoldPoints: cfg.Loyalty.GoldPoints}))
+	customerOptions := append(customerServiceOptions(cfg.Customer), customer.WithIDGenerator(idGenerator), customer.WithLoyaltyTier(loyaltyService.Tier))
+	if repos.customerHistory != nil {
+		customerOptions = append(customerOptions, customer.WithHistory(repos.customerHistory))
+	}
+	customerService := customer.NewService(customerRepo, customerOptions...)
+	mergeService := merge.NewService(mergeRepo, customerRepo, repos.unit, merge.WithOrders(orderRepo), merge.WithLoyalty(loyaltyRepo),
+		merge.WithIDGenerator(idGenerator))
+	privacyOptions := []privacy.Option{privacy.WithOrders(orderRepo), privacy.WithLoyalty(loyaltyRepo), privacy.WithMerges(mergeRepo),
+		privacy.WithConfirmationTTL(cfg.Privacy.ErasureConfirmationTTL)}
+	if repos.customerHistory != nil {
+		privacyOptions = append(privacyOptions, privacy.WithHistory(repos.customerHistory), privacy.WithHistoryRedactor(repos.historyRedactor))
+	}
+	// Erasure also redacts the customer events waiting to be relayed or delivered
+	if repos.events != nil {
+		privacyOptions = append(privacyOptions, privacy.WithOutbox(repos.events))
+	}
+	privacyOptions = append(privacyOptions, privacy.WithDeliveries(webhookRepo))
+	privacyService := privacy.NewService(customerRepo, repos.unit, erasureSecret(cfg.Privacy), privacyOptions...)
+	categoryService := category.NewService(categoryRepo, category.WithIDGenerator(idGenerator), category.WithUsage(categoryUsage(productRepo)))
+	mediaStore, err := openMediaStore(cfg.Media)
+	if err != nil {
+		log.Fatalf("Failed to initialize media store: %v", err)
+	}
+	mediaService := media.NewService(imageRepo, mediaStore, media.WithProductCheck(productCheck(productRepo)),
+		media.WithMaxUploadSize(int64(cfg.Media.MaxUploadSize)), media.WithThumbnailSize(cfg.Media.ThumbnailSize), media.WithIDGenerator(idGenerator))
+	inventoryHub := inventory.NewHub()
+	shutdown.Register("inventory-streams", func(context.Context) error { inventoryHub.Close(); return nil })
+	productService := product.NewService(productRepo, append(productServiceOptions(cfg.Product),
+		product.WithIDGenerator(idGenerator), product.WithCategoryTree(categoryService), product.WithStockObserver(inventoryHub),
+		product.WithImageGallery(mediaService))...)
+	taxCalculator := newTaxCalculator(cfg.Tax)
+	enrichmentService := enrichment.NewService(customerService, productService, enrichment.WithTaxCalculator(taxCalculator))
+	shippingService := shipping.NewService(customerService, productService,
+		shipping.NewTableRate(cfg.Shipping.Carrier, cfg.Shipping.OriginCountry, shipping.DefaultDomesticRates, shipping.DefaultInternationalRates))
+	deadLetterService := dlq.NewService(deadLetterRepo, dlq.WithIDGenerator(idGenerator))
+	orderOptions := []order.Option{order.WithIDGenerator(idGenerator), order.WithDeadLetters(deadLetterService)}
+	if cfg.Order.ReserveStock {
+		orderOptions = append(orderOptions, order.WithStockReservation(repos.unit, productService))
+	}
+	orderService := order.NewService(orderRepo, enrichmentService, orderOptions...)
+	deadLetterService.Handle(dlq.SourceOrder, orderService.ReplayDeadLetter)
+	webhookService := webhook.NewService(webhookRepo, webhook.WithIDGenerator(idGenerator))
+	jobQueue := startJobQueue(cfg.Jobs, enrichmentService, idGenerator, &shutdown)
+	importer := startProductImporter(cfg.Import, productService, idGenerator, &shutdown)
 
 	// Initialize handlers
 	customerHandler := customer.NewHandler(customerService)
+	loyaltyHandler := loyalty.NewHandler(loyaltyService)
+	mergeHandler := merge.NewHandler(mergeService)
+	privacyHandler := privacy.NewHandler(privacyService)
 	productHandler := product.NewHandler(productService)
+	categoryHandler := category.NewHandler(categoryService)
+	enrichmentHandler := enrichment.NewHandler(enrichmentService)
+	taxHandler := tax.NewHandler(taxCalculator)
+	shippingHandler := shipping.NewHandler(shippingService)
+	orderHandler := order.NewHandler(orderService)
+	jobHandler := jobs.NewHandler(jobQueue)
+	deadLetterHandler := dlq.NewHandler(deadLetterService)
+	webhookHandler := webhook.NewHandler(webhookService)
+	inventoryHandler := inventory.NewHandler(inventoryHub, productService)
+	importHandler := productimport.NewHandler(importer)
+	mediaHandler := media.NewHandler(mediaService)
+
+	routes := newRouteAuth(cfg.Auth)
+	var adminHandler *admin.Handler
+	if cfg.Admin.Enabled {
+		adminOptions := []admin.Option{admin.WithReload(config.Load, func(next *config.Config) {
+			logLevel.Set(logging.ParseLevel(next.LogLevel))
+			flags.Reset(featureFlags(next))
+		})}
+		for name, dataset := range repos.datasets {
+			adminOptions = append(adminOptions, admin.WithDataset(name, dataset))
+		}
+		adminHandler = admin.NewHandler(cfg, flags, adminOptions...)
+	}
+	registerAdmin(e, routes, adminHandler, faults)
+
+	registerHealth(e, &readiness)
+	registerRoutes(e, routes, newRateLimit(cfg.RateLimit), shedder, cfg.Server.LookupTimeout, customerHandler, loyaltyHandler, productHandler, categoryHandler, enrichmentHandler, taxHandler, shippingHandler, orderHandler, jobHandler, deadLetterHandler, webhookHandler, inventoryHandler, importHandler, mediaHandler, mergeHandler, privacyHandler)
+	registerMedia(e, cfg.Media)
+	registerDocs(e)
+
+	startOrderConsumer(cfg.Kafka, enrichmentService, deadLetterService, &shutdown, &readiness)
+	webhookPublisher := startWebhookDispatcher(cfg.Webhooks, webhookService, webhookRepo, &shutdown)
+	startOutboxRelay(cfg.Outbox, cfg.Kafka.Brokers, repos.events, webhookPublisher, keys, &shutdown)
+
+	// Start server
+	serverErr := make(chan error, 1)
+	go func() {
+		slog.Info("Starting Enricher API server", "address", cfg.Server.Address())
+		if err := e.Start(cfg.Server.Address()); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+
+	// Wait for SIGINT/SIGTERM or a server failure
+	signals, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	exitCode := 0
+	select {
+	case <-signals.Done():
+		slog.Info("Shutdown signal received, draining connections", "timeout", cfg.Server.ShutdownTimeout.String())
+	case err := <-serverErr:
+		slog.Error("Server failed", "error", err)
+		exitCode = 1
+	}
+	// A second signal terminates immediately
+	stop()
+
+	// Stop accepting requests and let in-flight ones finish, then release
+	// consumers and storage within the same drain window
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+
+	if err := e.Shutdown(ctx); err != nil {
+		slog.Error("Failed to drain HTTP connections", "error", err)
+		exitCode = 1
+	}
+	if err := shutdown.Run(ctx); err != nil {
+		exitCode = 1
+	}
+
+	slog.Info("Enricher API stopped")
+	if exitCode != 0 {
+		os.Exit(exitCode)
+	}
+}
+
+// Token scopes required by the versioned API routes and the admin API
+const (
+	scopeCustomersRead  = "customers:read"
+	scopeCustomersWrite = "customers:write"
+	scopeProductsRead   = "products:read"
+	scopeProductsWrite  = "products:write"
+	scopeOrdersRead     = "orders:read"
+	scopeOrdersWrite    = "orders:write"
+	scopeWebhooksRead   = "webhooks:read"
+	scopeWebhooksWrite  = "webhooks:write"
+	scopeAdmin          = "admin"
+)
+
+// v2ReleasedAt is when /v2 was released, deprecating the v1 routes it
+// supersedes
+var v2ReleasedAt = time.Date(2026, time.October, 16, 0, 0, 0, 0, time.UTC)
+
+// Feature flags, toggled through the admin API
+const (
+	flagHTTPCache      = "httpCache"
+	flagServerTiming   = "serverTiming"
+	flagPayloadLogging = "payloadLogging"
+)
+
+// featureFlags returns the feature flags as configured in cfg
+func featureFlags(cfg *config.Config) map[string]bool {
+	return map[string]bool{
+		flagHTTPCache:      cfg.Server.HTTPCache,
+		flagServerTiming:   cfg.Server.ServerTiming,
+		flagPayloadLogging: cfg.PayloadLog.Enabled,
+	}
+}
+
+// payloadLogConfig returns the payload logging settings of cfg
+func payloadLogConfig(cfg config.PayloadLogConfig) payloadlog.Config {
+	rules := make([]payloadlog.Rule, len(cfg.Redact))
+	for i, rule := range cfg.Redact {
+		rules[i] = payloadlog.Rule{Path: rule.Path, Fields: rule.Fields}
+	}
+	return payloadlog.Config{SampleRate: cfg.SampleRate, MaxBodySize: cfg.MaxBodySize, Rules: rules}
+}
+
+// routeAuth protects the versioned API routes; the zero value allows every request
+type routeAuth struct {
+	authenticate  []echo.MiddlewareFunc
+	requireScopes func(scopes ...string) echo.MiddlewareFunc
+	// rbac is set when callers carry roles, enabling admin-only query options
+	rbac bool
+}
+
+// newRouteAuth validates bearer tokens against the configured JWKS URL when
+// authentication is enabled and enforces roles per HTTP method when RBAC is
+func newRouteAuth(cfg config.AuthConfig) routeAuth {
+	var routes routeAuth
+	var bearer echo.MiddlewareFunc
+	if cfg.Enabled {
+		authenticator := jwtauth.New(jwtauth.Config{
+			JWKSURL:         cfg.JWKSURL,
+			Issuer:          cfg.Issuer,
+			Audience:        cfg.Audience,
+			RefreshInterval: cfg.JWKSRefreshInterval,
+		})
+		bearer = authenticator.Middleware()
+		routes.requireScopes = jwtauth.RequireScopes
+		slog.Info("Bearer token authentication enabled", "jwks_url", cfg.JWKSURL)
+	}
+
+	if !cfg.RBAC {
+		if bearer == nil {
+			slog.Warn("Authentication disabled; /v1 routes are open")
+			return routes
+		}
+		// The authorizer records the token subject for audit fields
+		routes.authenticate = []echo.MiddlewareFunc{auth.New(auth.Config{Bearer: bearer}).Authenticate()}
+		return routes
+	}
+
+	// API key callers carry no scopes; their role alone decides access
+	apiKeys := make([]auth.APIKey, len(cfg.APIKeys))
+	for i, key := range cfg.APIKeys {
+		apiKeys[i] = auth.APIKey{Name: key.Name, Key: key.Key, Role: auth.Role(key.Role)}
+	}
+	authorizer := auth.New(auth.Config{APIKeys: apiKeys, Bearer: bearer})
+	routes.authenticate = []echo.MiddlewareFunc{authorizer.Authenticate(), auth.Authorize()}
+	routes.rbac = true
+	if routes.requireScopes != nil {
+		requireScopes := routes.requireScopes
+		routes.requireScopes = func(scopes ...string) echo.MiddlewareFunc {
+			return auth.BearerOnly(requireScopes(scopes...))
+		}
+	}
+	slog.Info("Role-based access control enabled", "api_keys", len(apiKeys))
+	return routes
+}
+
+// middleware returns the authentication and role middleware, if any
+func (a routeAuth) middleware() []echo.MiddlewareFunc {
+	return a.authenticate
+}
 
-	// Health check endpoint
+// scopes returns middleware requiring every scope, if bearer authentication is enabled
+func (a routeAuth) scopes(scopes ...string) []echo.MiddlewareFunc {
+	if a.requireScopes == nil {
+		return nil
+	}
+	return []echo.MiddlewareFunc{a.requireScopes(scopes...)}
+}
+
+// adminReads returns middleware restricting includeDeleted=true to admins
+// when RBAC is enabled; without roles every caller may read deleted records
+func (a routeAuth) adminReads() []echo.MiddlewareFunc {
+	if !a.rbac {
+		return nil
+	}
+	return []echo.MiddlewareFunc{auth.RequireRole(auth.RoleAdmin, wantsDeleted)}
+}
+
+// wantsDeleted reports whether a request asks for soft-deleted records
+func wantsDeleted(c echo.Context) bool {
+	include, err := strconv.ParseBool(c.QueryParam("includeDeleted"))
+	return err == nil && include
+}
+
+// clientIP reads the client IP from X-Forwarded-For past the trusted proxy
+// ranges, which Validate has checked parse. Without any, forwarding headers
+// can be spoofed, so the IP is that of the connection.
+func clientIP(trustedProxies []string) echo.IPExtractor {
+	if len(trustedProxies) == 0 {
+		return echo.ExtractIPDirect()
+	}
+
+	// Only the configured ranges are trusted, not echo's private defaults
+	options := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+	for _, proxy := range trustedProxies {
+		_, ipRange, _ := net.ParseCIDR(proxy)
+		options = append(options, echo.TrustIPRange(ipRange))
+	}
+	return echo.ExtractIPFromXFFHeader(options...)
+}
+
+// newRateLimit throttles each client of the versioned API routes when enabled
+func newRateLimit(cfg config.RateLimitConfig) []echo.MiddlewareFunc {
+	if !cfg.Enabled {
+		return nil
+	}
+
+	groups := make(map[string]ratelimit.Limit, len(cfg.Groups))
+	for prefix, rule := range cfg.Groups {
+		groups[prefix] = ratelimit.Limit{Rate: rule.RequestsPerSecond, Burst: rule.Burst}
+	}
+	slog.Info("Rate limiting enabled", "requests_per_second", cfg.RequestsPerSecond, "burst", cfg.Burst)
+	return []echo.MiddlewareFunc{ratelimit.Middleware(ratelimit.Config{
+		Default: ratelimit.Limit{Rate: cfg.RequestsPerSecond, Burst: cfg.Burst},
+		Groups:  groups,
+	})}
+}
+
+// newLoadShedder returns the shedder of low-priority requests, or nil when
+// load shedding is off
+func newLoadShedder(cfg config.LoadShedConfig) *loadshed.Shedder {
+	if !cfg.Enabled {
+		return nil
+	}
+	slog.Info("Load shedding enabled", "max_in_flight", cfg.MaxInFlight, "target_latency", cfg.TargetLatency.String())
+	return loadshed.New(loadshed.Config{MaxInFlight: cfg.MaxInFlight, TargetLatency: cfg.TargetLatency, RetryAfter: cfg.RetryAfter})
+}
+
+// newBulkheads returns a bulkhead per configured route group, none when
+// bulkheads are off
+func newBulkheads(cfg config.BulkheadConfig) []*bulkhead.Bulkhead {
+	if !cfg.Enabled {
+		return nil
+	}
+	prefixes := make([]string, 0, len(cfg.Groups))
+	for prefix := range cfg.Groups {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+
+	bulkheads := make([]*bulkhead.Bulkhead, len(prefixes))
+	for i, prefix := range prefixes {
+		bulkheads[i] = bulkhead.New(prefix, cfg.Groups[prefix], cfg.MaxWait)
+	}
+	slog.Info("Bulkheads enabled", "groups", cfg.Groups, "max_wait", cfg.MaxWait.String())
+	return bulkheads
+}
+
+// metricsHandler serves GET /metrics: the state of the breakers, the storage
+// retries and the use of the bulkheads in the Prometheus text exposition
+// format
+func metricsHandler(breakers []*breaker.Breaker, retriers []*retry.Retrier, bulkheads []*bulkhead.Bulkhead) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var body strings.Builder
+		breaker.WriteMetrics(&body, breakers...)
+		retry.WriteMetrics(&body, retriers...)
+		bulkhead.WriteMetrics(&body, bulkheads...)
+		return c.Blob(http.StatusOK, breaker.MIMEMetrics, []byte(body.String()))
+	}
+}
+
+// registerAdmin mounts the admin API and, when faults is set, the fault
+// injection API under /admin/faults; either may be nil. With RBAC they require
+// the admin role; with bearer tokens alone, the admin scope.
+func registerAdmin(e *echo.Echo, routes routeAuth, handler *admin.Handler, faults *chaos.Handler) {
+	if handler == nil && faults == nil {
+		return
+	}
+	adminMiddleware := append([]echo.MiddlewareFunc{}, routes.middleware()...)
+	if routes.rbac {
+		adminMiddleware = append(adminMiddleware, auth.RequireRole(auth.RoleAdmin, func(echo.Context) bool { return true }))
+	} else {
+		adminMiddleware = append(adminMiddleware, routes.scopes(scopeAdmin)...)
+	}
+	if len(adminMiddleware) == 0 {
+		slog.Warn("Admin API enabled without authentication; /admin is open")
+	}
+
+	adminGroup := e.Group("/admin", adminMiddleware...)
+	if handler != nil {
+		adminGroup.GET("/config", handler.GetConfig)
+		adminGroup.POST("/config/reload", handler.ReloadConfig)
+		adminGroup.GET("/flags", handler.ListFlags)
+		adminGroup.PUT("/flags/:name", handler.SetFlag)
+		adminGroup.POST("/reset", handler.ResetData)
+	}
+	if faults != nil {
+		adminGroup.GET("/faults", faults.ListFaults)
+		adminGroup.POST("/faults", faults.SetFault)
+		adminGroup.DELETE("/faults", faults.DeleteFaults)
+	}
+}
+
+// registerHealth mounts the probes: /health/live passes while the process
+// serves HTTP, /health/ready only while every registered dependency is usable.
+// /health predates the split and is kept for existing checks.
+func registerHealth(e *echo.Echo, readiness *health.Readiness) {
 	e.GET("/health", func(c echo.Context) error {
 		return c.JSON(200, map[string]string{
 			"status":  "healthy",
 			"service": "enricher-api-go",
 		})
 	})
+	e.GET("/health/live", health.LiveHandler("enricher-api-go"))
+	e.GET("/health/ready", readiness.Handler())
+}
+
+// registerMedia serves the images of the local media store at its base URL;
+// images in S3 are served by the bucket
+func registerMedia(e *echo.Echo, cfg config.MediaConfig) {
+	if cfg.Store != config.MediaLocal {
+		return
+	}
+	e.Static(cfg.BaseURL, cfg.Dir)
+}
+
+// registerRoutes mounts the versioned API routes. With a shedder, lists and
+// exports are shed while the server is overloaded and the hot lookups gauge
+// whether it is.
+func registerRoutes(e *echo.Echo, auth routeAuth, rateLimit []echo.MiddlewareFunc, shedder *loadshed.Shedder, lookupTimeout time.Duration, customerHandler *customer.Handler, loyaltyHandler *loyalty.Handler, productHandler *product.Handler, categoryHandler *category.Handler, enrichmentHandler *enrichment.Handler, taxHandler *tax.Handler, shippingHandler *shipping.Handler, orderHandler *order.Handler, jobHandler *jobs.Handler, deadLetterHandler *dlq.Handler, webhookHandler *webhook.Handler, inventoryHandler *inventory.Handler, importHandler *productimport.Handler, mediaHandler *media.Handler, mergeHandler *merge.Handler, privacyHandler *privacy.Handler) {
+	// Rate limits run after authentication so API key callers are keyed by
+	// their verified key rather than by whatever header they send
+	apiMiddleware := append(append([]echo.MiddlewareFunc{}, auth.middleware()...), rateLimit...)
+	v1 := apiversion.Group(e, apiversion.V1, apiMiddleware...)
+	v2 := apiversion.Group(e, apiversion.V2, apiMiddleware...)
+
+	// Hot single-record reads get a deadline of their own, shorter than the
+	// request deadline, and are never shed; bulk reads are shed first
+	var protect, shed []echo.MiddlewareFunc
+	if shedder != nil {
+		protect, shed = []echo.MiddlewareFunc{shedder.Protect()}, []echo.MiddlewareFunc{shedder.Shed()}
+	}
+	lookup := func(middleware []echo.MiddlewareFunc) []echo.MiddlewareFunc {
+		return append(append(append([]echo.MiddlewareFunc{}, protect...), deadline.Within(lookupTimeout)), middleware...)
+	}
+	bulkRead := func(middleware []echo.MiddlewareFunc) []echo.MiddlewareFunc {
+		return append(append([]echo.MiddlewareFunc{}, shed...), middleware...)
+	}
+
+	// v1 routes that /v2 supersedes announce their successor
+	superseded := func(middleware []echo.MiddlewareFunc) []echo.MiddlewareFunc {
+		return append([]echo.MiddlewareFunc{apiversion.Deprecated(v2ReleasedAt, apiversion.V2)}, middleware...)
+	}
 
 	// Customer routes
-	customerGroup := e.Group("/v1/customers")
-	customerGroup.GET("", customerHandler.ListCustomers)
-	customerGroup.POST("", customerHandler.CreateCustomer)
-	customerGroup.GET("/:id", customerHandler.GetCustomer)
-	customerGroup.PUT("/:id", customerHandler.UpdateCustomer)
-	customerGroup.DELETE("/:id", customerHandler.DeleteCustomer)
-	customerGroup.GET("/:id/status", customerHandler.CheckCustomerStatus)
+	customersRead, customersWrite := auth.scopes(scopeCustomersRead), auth.scopes(scopeCustomersWrite)
+	customersReadDeleted := append(auth.adminReads(), customersRead...)
+	customerGroup := v1.Group("/customers")
+	customerGroup.GET("", customerHandler.ListCustomers, bulkRead(customersReadDeleted)...)
+	customerGroup.POST("", customerHandler.CreateCustomer, customersWrite...)
+	customerGroup.POST("/batch", customerHandler.BatchGetCustomers, customersRead...)
+	customerGroup.POST("/bulk", customerHandler.BulkWriteCustomers, customersWrite...)
+	customerGroup.GET("/export", customerHandler.ExportCustomers, bulkRead(customersReadDeleted)...)
+	customerGroup.GET("/by-email/:email", customerHandler.GetCustomerByEmail, customersRead...)
+	customerGroup.GET("/:id", customerHandler.GetCustomer, lookup(customersReadDeleted)...)
+	customerGroup.PUT("/:id", customerHandler.UpdateCustomer, customersWrite...)
+	customerGroup.PATCH("/:id", customerHandler.PatchCustomer, customersWrite...)
+	customerGroup.DELETE("/:id", customerHandler.DeleteCustomer, customersWrite...)
+	customerGroup.POST("/:id/restore", customerHandler.RestoreCustomer, customersWrite...)
+	customerGroup.GET("/:id/status", customerHandler.CheckCustomerStatus, customersRead...)
+	customerGroup.GET("/:id/history", customerHandler.GetCustomerHistory, customersRead...)
+	customerGroup.POST("/:id/transitions", customerHandler.TransitionCustomer, customersWrite...)
+	customerGroup.GET("/:id/credit-check", customerHandler.CheckCredit, customersRead...)
+	customerGroup.POST("/:id/credit/reserve", customerHandler.ReserveCredit, customersWrite...)
+	customerGroup.POST("/:id/credit/release", customerHandler.ReleaseCredit, customersWrite...)
+	customerGroup.POST("/:id/segments", customerHandler.AddCustomerSegment, customersWrite...)
+	customerGroup.DELETE("/:id/segments/:segment", customerHandler.RemoveCustomerSegment, customersWrite...)
+	customerGroup.GET("/:id/addresses", customerHandler.ListAddresses, customersRead...)
+	customerGroup.POST("/:id/addresses", customerHandler.CreateAddress, customersWrite...)
+	customerGroup.GET("/:id/addresses/:addressId", customerHandler.GetAddress, customersRead...)
+	customerGroup.PUT("/:id/addresses/:addressId", customerHandler.UpdateAddress, customersWrite...)
+	customerGroup.DELETE("/:id/addresses/:addressId", customerHandler.DeleteAddress, customersWrite...)
+
+	// Loyalty points belong to customers and share their scopes
+	customerGroup.GET("/:id/loyalty", loyaltyHandler.GetAccount, customersRead...)
+	customerGroup.POST("/:id/loyalty/accrue", loyaltyHandler.Accrue, customersWrite...)
+	customerGroup.POST("/:id/loyalty/redeem", loyaltyHandler.Redeem, customersWrite...)
+	// Merges fold a duplicate's records into a customer
+	customerGroup.POST("/:id/merge", mergeHandler.MergeCustomer, customersWrite...)
+	customerGroup.GET("/:id/merges", mergeHandler.ListMerges, customersRead...)
+	// Data subject requests export or erase everything stored about a customer
+	customerGroup.GET("/:id/data-export", privacyHandler.ExportCustomer, customersRead...)
+	customerGroup.POST("/:id/erase", privacyHandler.EraseCustomer, customersWrite...)
 
 	// Product routes
-	productGroup := e.Group("/v1/products")
-	productGroup.GET("", productHandler.ListProducts)
-	productGroup.POST("", productHandler.CreateProduct)
-	productGroup.GET("/:id", productHandler.GetProduct)
-	productGroup.PUT("/:id", productHandler.UpdateProduct)
-	productGroup.DELETE("/:id", productHandler.DeleteProduct)
-	productGroup.GET("/:id/availability", productHandler.CheckProductAvailability)
+	productsRead, productsWrite := auth.scopes(scopeProductsRead), auth.scopes(scopeProductsWrite)
+	productsReadDeleted := append(auth.adminReads(), productsRead...)
+	productGroup := v1.Group("/products")
+	productGroup.GET("", productHandler.ListProducts, superseded(bulkRead(productsReadDeleted))...)
+	productGroup.POST("", productHandler.CreateProduct, productsWrite...)
+	productGroup.POST("/batch", productHandler.BatchGetProducts, productsRead...)
+	productGroup.POST("/availability", productHandler.CheckBulkAvailability, productsRead...)
+	productGroup.POST("/bulk", productHandler.BulkWriteProducts, productsWrite...)
+	productGroup.POST("/import", importHandler.ImportProducts, productsWrite...)
+	productGroup.GET("/export", productHandler.ExportProducts, bulkRead(productsReadDeleted)...)
+	productGroup.GET("/by-barcode/:code", productHandler.GetProductByBarcode, superseded(lookup(productsRead))...)
+	productGroup.GET("/:id", productHandler.GetProduct, superseded(lookup(productsReadDeleted))...)
+	productGroup.PUT("/:id", productHandler.UpdateProduct, productsWrite...)
+	productGroup.PATCH("/:id", productHandler.PatchProduct, productsWrite...)
+	productGroup.DELETE("/:id", productHandler.DeleteProduct, productsWrite...)
+	productGroup.POST("/:id/restore", productHandler.RestoreProduct, productsWrite...)
+	productGroup.POST("/:id/reserve", productHandler.ReserveStock, productsWrite...)
+	productGroup.POST("/:id/release", productHandler.ReleaseStock, productsWrite...)
+	productGroup.GET("/:id/stock-movements", productHandler.ListStockMovements, productsRead...)
+	productGroup.GET("/:id/prices", productHandler.ListPriceChanges, productsRead...)
+	productGroup.POST("/:id/prices", productHandler.SchedulePrice, productsWrite...)
+	productGroup.DELETE("/:id/prices/:changeId", productHandler.CancelPriceChange, productsWrite...)
+	productGroup.GET("/:id/variants", productHandler.ListVariants, productsRead...)
+	productGroup.POST("/:id/variants", productHandler.CreateVariant, productsWrite...)
+	productGroup.GET("/:id/availability", productHandler.CheckProductAvailability, productsRead...)
+	productGroup.GET("/:id/images", mediaHandler.ListImages, productsRead...)
+	productGroup.POST("/:id/images", mediaHandler.UploadImage, productsWrite...)
+	productGroup.DELETE("/:id/images/:imageId", mediaHandler.DeleteImage, productsWrite...)
 
-	// Start server
-	log.Println("Starting Enricher API server on :8080")
-	e.Logger.Fatal(e.Start(":8080"))
+	// Imports started with POST /products/import report their progress under
+	// their own collection, readable by the callers that may start them
+	v1.GET("/product-imports/:id", importHandler.GetImport, productsWrite...)
+
+	// Stock levels are also pushed over a WebSocket as they change
+	v1.GET("/ws/inventory", inventoryHandler.Stream, productsRead...)
+
+	// Variants are addressed by SKU once created
+	skuGroup := v1.Group("/skus")
+	skuGroup.GET("/:sku", productHandler.GetVariant, productsRead...)
+	skuGroup.PUT("/:sku", productHandler.UpdateVariant, productsWrite...)
+	skuGroup.DELETE("/:sku", productHandler.DeleteVariant, productsWrite...)
+
+	// Category routes share the product scopes
+	categoryGroup := v1.Group("/categories")
+	categoryGroup.GET("", categoryHandler.ListCategories, bulkRead(productsRead)...)
+	categoryGroup.POST("", categoryHandler.CreateCategory, productsWrite...)
+	categoryGroup.GET("/:id", categoryHandler.GetCategory, productsRead...)
+	categoryGroup.PUT("/:id", categoryHandler.UpdateCategory, productsWrite...)
+	categoryGroup.DELETE("/:id", categoryHandler.DeleteCategory, productsWrite...)
+
+	// Enrichment routes read both customers and products
+	enrichScopes := auth.scopes(scopeCustomersRead, scopeProductsRead)
+	v1.POST("/enrich", enrichmentHandler.EnrichOrder, enrichScopes...)
+	jobGroup := v1.Group("/enrichment-jobs")
+	jobGroup.POST("", jobHandler.SubmitJob, enrichScopes...)
+	jobGroup.GET("/:id", jobHandler.GetJob, enrichScopes...)
+
+	// Shipping estimates weigh a customer's order lines like an enrichment
+	v1.POST("/shipping/estimate", shippingHandler.Estimate, enrichScopes...)
+
+	// Order routes
+	ordersRead, ordersWrite := auth.scopes(scopeOrdersRead), auth.scopes(scopeOrdersWrite)
+	orderGroup := v1.Group("/orders")
+	orderGroup.GET("", orderHandler.ListOrders, bulkRead(ordersRead)...)
+	orderGroup.POST("", orderHandler.CreateOrder, ordersWrite...)
+	orderGroup.GET("/:id", orderHandler.GetOrder, lookup(ordersRead)...)
+	orderGroup.PUT("/:id", orderHandler.UpdateOrder, ordersWrite...)
+	orderGroup.DELETE("/:id", orderHandler.DeleteOrder, ordersWrite...)
+	orderGroup.POST("/:id/enrich", orderHandler.EnrichOrder, ordersWrite...)
+
+	// Tax quotes price order lines and share the order scopes
+	v1.POST("/tax/quote", taxHandler.Quote, ordersRead...)
+
+	// Dead-letter routes share the order scopes
+	deadLetterGroup := v1.Group("/dlq")
+	deadLetterGroup.GET("", deadLetterHandler.ListEntries, bulkRead(ordersRead)...)
+	deadLetterGroup.GET("/:id", deadLetterHandler.GetEntry, ordersRead...)
+	deadLetterGroup.POST("/:id/retry", deadLetterHandler.RetryEntry, ordersWrite...)
+
+	// Webhook routes
+	webhooksRead, webhooksWrite := auth.scopes(scopeWebhooksRead), auth.scopes(scopeWebhooksWrite)
+	webhookGroup := v1.Group("/webhooks")
+	webhookGroup.GET("", webhookHandler.ListSubscriptions, bulkRead(webhooksRead)...)
+	webhookGroup.POST("", webhookHandler.CreateSubscription, webhooksWrite...)
+	webhookGroup.GET("/:id", webhookHandler.GetSubscription, webhooksRead...)
+	webhookGroup.PUT("/:id", webhookHandler.UpdateSubscription, webhooksWrite...)
+	webhookGroup.DELETE("/:id", webhookHandler.DeleteSubscription, webhooksWrite...)
+	webhookGroup.GET("/:id/deliveries", webhookHandler.ListDeliveries, webhooksRead...)
+
+	// /v2 reshapes product reads; every other resource is served by /v1 only
+	productV2Group := v2.Group("/products")
+	productV2Group.GET("", productHandler.ListProducts, bulkRead(productsReadDeleted)...)
+	productV2Group.GET("/by-barcode/:code", productHandler.GetProductByBarcode, lookup(productsRead)...)
+	productV2Group.GET("/:id", productHandler.GetProduct, lookup(productsReadDeleted)...)
+}
+
+// startOrderConsumer runs the Kafka order consumer in the background when
+// brokers are configured, registering a shutdown hook that stops it and waits
+// for the in-flight message before closing its connections, and a readiness
+// check that fails while it is stopped, retrying a Kafka failure or no broker
+// is reachable. Orders it gives up on are dead-lettered and replayed through
+// it.
+func startOrderConsumer(cfg config.KafkaConfig, enricher enrichment.Service, deadLetters *dlq.DeadLetterService, shutdown *lifecycle.Shutdown, readiness *health.Readiness) {
+	if len(cfg.Brokers) == 0 {
+		return
+	}
+
+	orderConsumer, err := consumer.New(consumer.Config{
+		Brokers:     cfg.Brokers,
+		GroupID:     cfg.GroupID,
+		InputTopic:  cfg.InputTopic,
+		OutputTopic: cfg.OutputTopic,
+	}, enricher)
+	if err != nil {
+		log.Fatalf("Failed to create order consumer: %v", err)
+	}
+	orderConsumer.DeadLetterTo(deadLetters, cfg.MaxAttempts)
+	deadLetters.Handle(dlq.SourceKafka, orderConsumer.ReplayDeadLetter)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := orderConsumer.Run(ctx); err != nil {
+			slog.Error("Order consumer failed", "error", err)
+		}
+	}()
+
+	shutdown.Register("order-consumer", func(shutdownCtx context.Context) error {
+		cancel()
+		waitErr := lifecycle.WaitOrTimeout(shutdownCtx, done)
+		return errors.Join(waitErr, orderConsumer.Close())
+	})
+	readiness.Register("kafka", orderConsumer.Check)
+}
+
+// startOutboxRelay relays the customer and product change events recorded
+// in the outbox in the background: to Kafka when the outbox is enabled, and
+// to webhooks, the webhook publisher if not nil. Customers are published
+// decrypted when keys is not nil. It registers a shutdown hook that stops it
+// and waits for the batch being published. Events recorded but not yet
+// published at shutdown are published on the next start.
+func startOutboxRelay(cfg config.OutboxConfig, brokers []string, store outbox.Store, webhooks outbox.Publisher, keys *envelope.Keyring, shutdown *lifecycle.Shutdown) {
+	var publishers []outbox.Publisher
+	closePublisher := func() error { return nil }
+	if cfg.Enabled {
+		publisher, err := outbox.NewKafkaPublisher(brokers, cfg.Topic)
+		if err != nil {
+			log.Fatalf("Failed to create outbox publisher: %v", err)
+		}
+		publishers, closePublisher = append(publishers, publisher), publisher.Close
+	}
+	if webhooks != nil {
+		publishers = append(publishers, webhooks)
+	}
+	if len(publishers) == 0 {
+		return
+	}
+
+	publisher := outbox.Fanout(publishers...)
+	if keys != nil {
+		publisher = customer.NewDecryptingPublisher(publisher, keys)
+	}
+	relay := outbox.NewRelay(store, publisher, outbox.RelayConfig{
+		PollInterval: cfg.PollInterval,
+		BatchSize:    cfg.BatchSize,
+		Retention:    cfg.Retention,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := relay.Run(ctx); err != nil {
+			slog.Error("Outbox relay failed", "error", err)
+		}
+	}()
+
+	shutdown.Register("outbox-relay", func(shutdownCtx context.Context) error {
+		cancel()
+		waitErr := lifecycle.WaitOrTimeout(shutdownCtx, done)
+		return errors.Join(waitErr, closePublisher())
+	})
+	slog.Info("Outbox relay started", "kafka", cfg.Enabled, "webhooks", webhooks != nil, "poll_interval", cfg.PollInterval.String())
+}
+
+// startWebhookDispatcher runs the webhook dispatcher in the background when
+// webhooks are enabled, registering a shutdown hook that stops it and waits
+// for the deliveries being attempted. It returns the publisher queueing the
+// deliveries of relayed events, or nil when webhooks are disabled.
+func startWebhookDispatcher(cfg config.WebhooksConfig, service *webhook.WebhookService, repo webhook.Repository, shutdown *lifecycle.Shutdown) outbox.Publisher {
+	if !cfg.Enabled {
+		return nil
+	}
+
+	dispatcher := webhook.NewDispatcher(repo, webhook.DispatcherConfig{
+		PollInterval:   cfg.PollInterval,
+		BatchSize:      cfg.BatchSize,
+		MaxAttempts:    cfg.MaxAttempts,
+		InitialBackoff: cfg.InitialBackoff,
+		MaxBackoff:     cfg.MaxBackoff,
+		Timeout:        cfg.Timeout,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := dispatcher.Run(ctx); err != nil {
+			slog.Error("Webhook dispatcher failed", "error", err)
+		}
+	}()
+
+	shutdown.Register("webhook-dispatcher", func(shutdownCtx context.Context) error {
+		cancel()
+		return lifecycle.WaitOrTimeout(shutdownCtx, done)
+	})
+	slog.Info("Webhook dispatcher started", "max_attempts", cfg.MaxAttempts, "poll_interval", cfg.PollInterval.String())
+	return service
+}
+
+// startJobQueue starts the workers of the enrichment job queue, registering
+// a shutdown hook that refuses new jobs and waits for the orders being
+// enriched. Jobs still queued at shutdown are lost.
+func startJobQueue(cfg config.JobsConfig, enricher enrichment.Service, idGenerator idgen.Generator, shutdown *lifecycle.Shutdown) *jobs.Queue {
+	queue := jobs.NewQueue(jobs.Config{
+		Workers:      cfg.Workers,
+		QueueSize:    cfg.QueueSize,
+		MaxBatchSize: cfg.MaxBatchSize,
+		ItemTimeout:  cfg.ItemTimeout,
+		Retention:    cfg.Retention,
+	}, enricher, jobs.WithIDGenerator(idGenerator))
+	queue.Start()
+
+	shutdown.Register("enrichment-jobs", queue.Stop)
+	slog.Info("Enrichment job queue started", "workers", cfg.Workers, "queue_size", cfg.QueueSize)
+	return queue
+}
+
+// startProductImporter starts the worker of background product imports,
+// registering a shutdown hook that refuses new imports and stops the
+// running one after its current row. Imports still queued at shutdown are
+// lost.
+func startProductImporter(cfg config.ImportConfig, products product.Service, idGenerator idgen.Generator, shutdown *lifecycle.Shutdown) *productimport.Importer {
+	importer := productimport.NewImporter(productimport.Config{
+		QueueSize: cfg.QueueSize,
+		MaxRows:   cfg.MaxRows,
+		Retention: cfg.Retention,
+		Dir:       cfg.Dir,
+	}, products, productimport.WithIDGenerator(idGenerator))
+	importer.Start()
+
+	shutdown.Register("product-imports", importer.Stop)
+	slog.Info("Product importer started", "queue_size", cfg.QueueSize, "max_rows", cfg.MaxRows)
+	return importer
+}
+
+// newStorageBreaker creates the circuit breaker shared by the repositories of
+// the configured database backend
+func newStorageBreaker(cfg config.StorageConfig) *breaker.Breaker {
+	slog.Info("Storage circuit breaker enabled", "backend", cfg.Backend,
+		"failure_threshold", cfg.CircuitBreaker.FailureThreshold, "open_timeout", cfg.CircuitBreaker.OpenTimeout.String())
+	return breaker.New(cfg.Backend, breaker.Settings{
+		FailureThreshold: cfg.CircuitBreaker.FailureThreshold,
+		OpenTimeout:      cfg.CircuitBreaker.OpenTimeout,
+		HalfOpenRequests: cfg.CircuitBreaker.HalfOpenRequests,
+	})
+}
+
+// newStorageRetrier returns the retrier shared by every repository of the
+// database backend
+func newStorageRetrier(cfg config.StorageConfig) *retry.Retrier {
+	slog.Info("Storage retries enabled", "backend", cfg.Backend,
+		"max_attempts", cfg.Retry.MaxAttempts, "base_delay", cfg.Retry.BaseDelay.String(), "max_delay", cfg.Retry.MaxDelay.String())
+	return retry.New(cfg.Backend, retry.Policy{
+		MaxAttempts: cfg.Retry.MaxAttempts,
+		BaseDelay:   cfg.Retry.BaseDelay,
+		MaxDelay:    cfg.Retry.MaxDelay,
+	})
+}
+
+// newTaxCalculator returns the configured tax calculator: an external tax
+// service, or flat rates per country or region
+func newTaxCalculator(cfg config.TaxConfig) tax.Calculator {
+	if cfg.Calculator == config.TaxHTTP {
+		slog.Info("Quoting tax with an external tax service", "url", cfg.URL, "timeout", cfg.Timeout.String())
+		return tax.NewHTTPCalculator(cfg.URL, cfg.APIKey, &http.Client{Timeout: cfg.Timeout})
+	}
+	return tax.NewFlatRate(cfg.Rate, cfg.Rates)
+}
+
+// erasureSecret returns the configured secret signing erasure confirmation
+// tokens, or a random one that only this instance can verify
+func erasureSecret(cfg config.PrivacyConfig) []byte {
+	if cfg.ErasureSecret != "" {
+		return []byte(cfg.ErasureSecret)
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		log.Fatalf("Failed to generate erasure secret: %v", err)
+	}
+	slog.Warn("No erasure secret configured; erasures must be confirmed on the instance that requested them")
+	return secret
+}
+
+// openMediaStore returns the configured store of product images: a local
+// directory or an S3 bucket
+func openMediaStore(cfg config.MediaConfig) (media.Store, error) {
+	if cfg.Store == config.MediaS3 {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		// A path base URL, like the default, is the API's own and means
+		// nothing for a bucket, which then serves its objects itself
+		baseURL := cfg.BaseURL
+		if strings.HasPrefix(baseURL, "/") {
+			baseURL = ""
+		}
+		store, err := media.OpenS3Store(ctx, media.S3Config{
+			Bucket:   cfg.S3.Bucket,
+			Region:   cfg.S3.Region,
+			Endpoint: cfg.S3.Endpoint,
+			BaseURL:  baseURL,
+		})
+		if err != nil {
+			return nil, err
+		}
+		slog.Info("Storing product images in S3", "bucket", cfg.S3.Bucket)
+		return store, nil
+	}
+	slog.Info("Storing product images locally", "dir", cfg.Dir, "base_url", cfg.BaseURL)
+	return media.NewLocalStore(cfg.Dir, cfg.BaseURL), nil
+}
+
+// openCache returns the configured lookup cache, or nil when caching is off.
+//
+// The memory backend is a sharded TTL cache private to this instance. The
+// redis backend falls back to an in-process LRU for calls Redis fails, so
+// an outage costs hit rate rather than availability.
+func openCache(cfg config.CacheConfig, shutdown *lifecycle.Shutdown) cache.Store {
+	switch cfg.Backend {
+	case config.CacheMemory:
+		slog.Info("Using in-process lookup cache", "max_entries", cfg.MaxEntries, "shards", cfg.Shards)
+		return cache.NewSharded(cfg.Shards, cfg.MaxEntries)
+	case config.CacheRedis:
+		redis := cache.NewRedis(cache.RedisConfig{
+			Addr:     cfg.Redis.Addr,
+			Password: cfg.Redis.Password,
+			DB:       cfg.Redis.DB,
+			PoolSize: cfg.Redis.PoolSize,
+			Timeout:  cfg.Redis.Timeout,
+		})
+		shutdown.Register("redis-cache", func(context.Context) error { return redis.Close() })
+
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.Redis.Timeout)
+		defer cancel()
+		if err := redis.Ping(ctx); err != nil {
+			slog.Warn("Redis unreachable, caching in process until it recovers", "addr", cfg.Redis.Addr, "error", err)
+		} else {
+			slog.Info("Using Redis lookup cache", "addr", cfg.Redis.Addr)
+		}
+		return cache.NewFallback(redis, cache.NewLRU(cfg.MaxEntries))
+	default:
+		return nil
+	}
+}
+
+// cachedDataset is a dataset read through a cache, whose every entry a reset
+// drops
+type cachedDataset struct {
+	admin.Dataset
+	cache interface{ InvalidateAll() }
+}
+
+// Reset returns the dataset to its seed state, then drops the entries cached
+// from it
+func (d cachedDataset) Reset() {
+	d.Dataset.Reset()
+	d.cache.InvalidateAll()
+}
+
+// invalidateOnReset makes resetting the dataset called name, if there is
+// one, drop every entry of cache
+func invalidateOnReset(datasets map[string]admin.Dataset, name string, cache interface{ InvalidateAll() }) {
+	if dataset, ok := datasets[name]; ok {
+		datasets[name] = cachedDataset{Dataset: dataset, cache: cache}
+	}
+}
+
+// repositories holds the storage-backed repositories of every resource
+type repositories struct {
+	customers  customer.Repository
+	products   product.Repository
+	categories category.Repository
+	orders     order.Repository
+	// deadLetters holds the orders that could not be enriched
+	deadLetters dlq.Repository
+	webhooks    webhook.Repository
+	loyalty     loyalty.Repository
+	images      media.Repository
+	merges      merge.Repository
+	// events holds the customer and product changes to relay; nil unless recorded
+	events outbox.Store
+	// unit groups writes across the repositories
+	unit unitofwork.UnitOfWork
+	// datasets are the repositories the admin API can reset to their seed
+	// data, by name; only in-memory storage has any
+	datasets map[string]admin.Dataset
+	// customerHistory replays customer events; nil unless event sourcing is on
+	customerHistory customer.HistorySource
+	// historyRedactor erases personal data from the customer events
+	// customerHistory replays
+	historyRedactor customer.HistoryRedactor
+	// ids numbers sequential IDs as long as the data lives; nil where the
+	// backend keeps no durable counter
+	ids idgen.Counter
+}
+
+// openRepositories builds the repositories for the configured storage
+// backend and returns a function releasing its resources.
+//
+// The memory backend starts with the fixtures of the seed file, if any, or
+// else with built-in sample data. With event sourcing, customer changes are
+// recorded as events replayable by customer history.
+//
+// The postgres backend connects to the configured database URL and creates
+// its tables if missing. It registers readiness checks that the database
+// answers and that those tables exist.
+//
+// The sqlite backend opens the configured database file and creates its
+// tables if missing. It stores customers and products only; the other
+// resources stay in memory.
+//
+// The dynamodb backend keeps customers and products in the configured table,
+// creating it if missing, and likewise keeps the other resources in memory.
+//
+// With recordEvents, customer and product changes are recorded in an outbox
+// of the same backend, in the same transaction on postgres, for the outbox
+// relay and webhooks.
+//
+// Units of work run in database transactions on postgres and compensate
+// their writes on the other backends.
+func openRepositories(cfg config.StorageConfig, recordEvents bool, readiness *health.Readiness) (repositories, func() error, error) {
+	switch cfg.Backend {
+	case config.StorageMemory:
+		customerRepo := customer.NewInMemoryRepository()
+		productRepo := product.NewInMemoryRepository()
+		categoryRepo := category.NewInMemoryRepository()
+		// A seed file replaces the built-in sample data, also on reset
+		if cfg.SeedFile != "" {
+			fixtures, err := seed.Load(cfg.SeedFile)
+			if err != nil {
+				return repositories{}, nil, err
+			}
+			customerRepo = customer.NewInMemoryRepositoryWith(fixtures.Customers)
+			productRepo = product.NewInMemoryRepositoryWith(fixtures.Products)
+			categoryRepo = category.NewInMemoryRepositoryWith(fixtures.Categories)
+			slog.Info("Seeded in-memory storage", "file", cfg.SeedFile)
+		}
+		orderRepo := order.NewInMemoryRepository()
+		deadLetterRepo := dlq.NewInMemoryRepository()
+		webhookRepo := webhook.NewInMemoryRepository()
+		loyaltyRepo := loyalty.NewInMemoryRepository()
+		imageRepo := media.NewInMemoryRepository()
+		mergeRepo := merge.NewInMemoryRepository()
+		repos := repositories{
+			customers:   customerRepo,
+			products:    productRepo,
+			categories:  categoryRepo,
+			orders:      orderRepo,
+			deadLetters: deadLetterRepo,
+			webhooks:    webhookRepo,
+			loyalty:     loyaltyRepo,
+			images:      imageRepo,
+			merges:      mergeRepo,
+			unit:        unitofwork.Compensating{},
+			// Memory loses its data on restart along with the counter
+			ids: idgen.NewInMemoryCounter(),
+			datasets: map[string]admin.Dataset{
+				"customers":   customerRepo,
+				"products":    productRepo,
+				"categories":  categoryRepo,
+				"orders":      orderRepo,
+				"deadLetters": deadLetterRepo,
+				"webhooks":    webhookRepo,
+				"loyalty":     loyaltyRepo,
+				"images":      imageRepo,
+				"merges":      mergeRepo,
+			},
+		}
+		// Customer writes go through the event log, with customerRepo as its
+		// projection
+		if cfg.EventSourcing.Enabled {
+			eventSourced, err := customer.NewEventSourcedRepository(customerRepo, customer.NewInMemoryEventStore(), cfg.EventSourcing.SnapshotEvery)
+			if err != nil {
+				return repositories{}, nil, err
+			}
+			repos.customers, repos.datasets["customers"], repos.customerHistory = eventSourced, eventSourced, eventSourced
+			repos.historyRedactor = eventSourced
+			slog.Info("Recording customer changes as events", "snapshot_every", cfg.EventSourcing.SnapshotEvery)
+		}
+		if recordEvents {
+			events := outbox.NewInMemoryStore()
+			customerRepo.RecordEventsTo(events)
+			productRepo.RecordEventsTo(events)
+			repos.events = events
+		}
+		return repos, func() error { return nil }, nil
+	case config.StoragePostgres:
+		db, err := sql.Open("pgx", cfg.DatabaseURL)
+		if err != nil {
+			return repositories{}, nil, fmt.Errorf("failed to open postgres: %w", err)
+		}
+		if err := db.Ping(); err != nil {
+			db.Close()
+			return repositories{}, nil, fmt.Errorf("failed to connect to postgres: %w", err)
+		}
+
+		customerRepo := customer.NewPostgresRepository(db)
+		productRepo := product.NewPostgresRepository(db)
+		categoryRepo := category.NewPostgresRepository(db)
+		orderRepo := order.NewPostgresRepository(db)
+		deadLetterRepo := dlq.NewPostgresRepository(db)
+		webhookRepo := webhook.NewPostgresRepository(db)
+		events := outbox.NewPostgresStore(db)
+
+		migrator, err := migrations.New(db)
+		if err != nil {
+			db.Close()
+			return repositories{}, nil, err
+		}
+		if cfg.AutoMigrate {
+			if _, err := migrator.Up(context.Background()); err != nil {
+				db.Close()
+				return repositories{}, nil, err
+			}
+		}
+
+		readiness.Register("postgres", db.PingContext)
+		readiness.Register("migrations", migrator.Check)
+
+		repos := repositories{customers: customerRepo, products: productRepo, categories: categoryRepo, orders: orderRepo,
+			deadLetters: deadLetterRepo, webhooks: webhookRepo, loyalty: loyalty.NewPostgresRepository(db), images: media.NewPostgresRepository(db),
+			merges: merge.NewPostgresRepository(db),
+			unit:   unitofwork.NewSQL(db), ids: idgen.NewPostgresCounter(db)}
+		if recordEvents {
+			customerRepo.RecordEventsTo(events)
+			productRepo.RecordEventsTo(events)
+			repos.events = events
+		}
+
+		slog.Info("Using PostgreSQL storage backend")
+		return repos, db.Close, nil
+	case config.StorageSQLite:
+		db, err := sqlite.Open(cfg.SQLitePath)
+		if err != nil {
+			return repositories{}, nil, err
+		}
+
+		customerRepo := customer.NewSQLiteRepository(db)
+		productRepo := product.NewSQLiteRepository(db)
+		if err := customerRepo.EnsureSchema(); err != nil {
+			db.Close()
+			return repositories{}, nil, err
+		}
+		if err := productRepo.EnsureSchema(); err != nil {
+			db.Close()
+			return repositories{}, nil, err
+		}
+
+		readiness.Register("sqlite", db.PingContext)
+
+		repos := repositories{
+			customers:   customerRepo,
+			products:    productRepo,
+			categories:  category.NewInMemoryRepository(),
+			orders:      order.NewInMemoryRepository(),
+			deadLetters: dlq.NewInMemoryRepository(),
+			webhooks:    webhook.NewInMemoryRepository(),
+			loyalty:     loyalty.NewInMemoryRepository(),
+			images:      media.NewInMemoryRepository(),
+			merges:      merge.NewInMemoryRepository(),
+			unit:        unitofwork.Compensating{},
+		}
+
+		slog.Info("Using SQLite storage backend; categories, orders, dead letters, webhooks, loyalty points, image records and merges stay in memory",
+			"path", cfg.SQLitePath)
+		return repos, db.Close, nil
+	case config.StorageDynamoDB:
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Minute)
+		defer cancel()
+
+		client, err := dynamo.Open(ctx, dynamo.Config{
+			Region:      cfg.DynamoDB.Region,
+			Endpoint:    cfg.DynamoDB.Endpoint,
+			MaxAttempts: cfg.DynamoDB.MaxAttempts,
+			MaxBackoff:  cfg.DynamoDB.MaxBackoff,
+		})
+		if err != nil {
+			return repositories{}, nil, err
+		}
+		table := dynamo.NewTable(client, cfg.DynamoDB.Table)
+		if err := table.Ensure(ctx); err != nil {
+			return repositories{}, nil, err
+		}
+
+		readiness.Register("dynamodb", table.Ping)
+
+		repos := repositories{
+			customers:   customer.NewDynamoDBRepository(table),
+			products:    product.NewDynamoDBRepository(table),
+			categories:  category.NewInMemoryRepository(),
+			orders:      order.NewInMemoryRepository(),
+			deadLetters: dlq.NewInMemoryRepository(),
+			webhooks:    webhook.NewInMemoryRepository(),
+			loyalty:     loyalty.NewInMemoryRepository(),
+			images:      media.NewInMemoryRepository(),
+			merges:      merge.NewInMemoryRepository(),
+			unit:        unitofwork.Compensating{},
+		}
+
+		slog.Info("Using DynamoDB storage backend; categories, orders, dead letters, webhooks, loyalty points, image records and merges stay in memory",
+			"table", cfg.DynamoDB.Table)
+		return repos, func() error { return nil }, nil
+	default:
+		return repositories{}, nil, fmt.Errorf("unknown storage backend %q", cfg.Backend)
+	}
+}
+
+// customerCheck verifies through repo that a customer is live, so points
+// are kept only for customers that exist
+func customerCheck(repo customer.Repository) loyalty.CustomerCheck {
+	return func(ctx context.Context, customerID string) error {
+		_, err := repo.GetByID(customerID)
+		return err
+	}
+}
+
+// productCheck verifies through repo that a product is live, so images are
+// kept only for products that exist
+func productCheck(repo product.Repository) media.ProductCheck {
+	return func(ctx context.Context, productID string) error {
+		_, err := repo.GetByID(productID)
+		return err
+	}
+}
+
+// categoryUsage counts the products, deleted or not, that reference a
+// category, so categories cannot be removed out from under them
+func categoryUsage(repo product.Repository) category.UsageFunc {
+	return func(ctx context.Context, name string) (int, error) {
+		return repo.Count(product.ProductFilter{Category: name, IncludeDeleted: true})
+	}
+}
+
+// echoLogLevel maps a configured log level to the Echo logger level
+func echoLogLevel(level string) gommonlog.Lvl {
+	switch level {
+	case "debug":
+		return gommonlog.DEBUG
+	case "warn":
+		return gommonlog.WARN
+	case "error":
+		return gommonlog.ERROR
+	default:
+		return gommonlog.INFO
+	}
+}
+
+// boundedBulkSize returns the configured bulk size capped at limit; zero
+// selects limit rather than the service default
+func boundedBulkSize(configured, limit int) int {
+	if configured <= 0 || configured > limit {
+		return limit
+	}
+	return configured
+}
+
+// customerServiceOptions builds customer service options from the configuration
+func customerServiceOptions(cfg config.CustomerConfig) []customer.Option {
+	var opts []customer.Option
+
+	if cfg.MaxSegments > 0 {
+		opts = append(opts, customer.WithMaxSegments(cfg.MaxSegments))
+	}
+
+	if cfg.MaxBatchSize > 0 {
+		opts = append(opts, customer.WithMaxBatchSize(cfg.MaxBatchSize))
+	}
+
+	if cfg.MaxBulkSize > 0 {
+		opts = append(opts, customer.WithMaxBulkSize(cfg.MaxBulkSize))
+	}
+
+	opts = append(opts, customer.WithSegmentRules(customer.SegmentRules{
+		VIPCreditLimit: cfg.SegmentRules.VIPCreditLimit,
+		NewFor:         cfg.SegmentRules.NewFor,
+		DormantAfter:   cfg.SegmentRules.DormantAfter,
+	}))
+
+	return opts
+}
+
+// productServiceOptions builds product service options from the configuration.
+//
+// The category allowlist only applies when a category filter mode is set.
+// Configured exchange rates are quoted against the product currency.
+func productServiceOptions(cfg config.ProductConfig) []product.Option {
+	var opts []product.Option
+
+	if cfg.CategoryFilter != "" {
+		opts = append(opts, product.WithCategoryFilter(product.CategoryFilterMode(cfg.CategoryFilter), cfg.Categories))
+	}
+
+	if cfg.MaxSearchLength > 0 {
+		opts = append(opts, product.WithMaxSearchTermLength(cfg.MaxSearchLength))
+	}
+
+	if cfg.MaxBatchSize > 0 {
+		opts = append(opts, product.WithMaxBatchSize(cfg.MaxBatchSize))
+	}
+
+	if cfg.MaxBulkSize > 0 {
+		opts = append(opts, product.WithMaxBulkSize(cfg.MaxBulkSize))
+	}
+
+	base := cfg.Currency
+	if base == "" {
+		base = currency.DefaultCode
+	}
+	var rates currency.RateProvider
+	if len(cfg.ExchangeRates) > 0 {
+		rates = currency.NewStaticRates(base, cfg.ExchangeRates)
+	}
+	opts = append(opts, product.WithCurrency(base, rates))
+
+	opts = append(opts, product.WithMeasures(product.Measures{
+		WeightUnit:     cfg.WeightUnit,
+		LengthUnit:     cfg.LengthUnit,
+		MaxWeightKg:    cfg.MaxWeightKg,
+		MaxDimensionCm: cfg.MaxDimensionCm,
+	}))
+
+	return opts
 }
@@ -1,50 +1,124 @@
 package main
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"enricher-api-go/internal/admin"
+	"enricher-api-go/internal/apiversion"
+	"enricher-api-go/internal/auth"
+	"enricher-api-go/internal/breaker"
+	"enricher-api-go/internal/cache"
+	"enricher-api-go/internal/category"
+	"enricher-api-go/internal/chaos"
+	"enricher-api-go/internal/config"
 	"enricher-api-go/internal/customer"
+	"enricher-api-go/internal/dlq"
+	"enricher-api-go/internal/enrichment"
+	"enricher-api-go/internal/featureflag"
+	"enricher-api-go/internal/health"
+	"enricher-api-go/internal/inventory"
+	"enricher-api-go/internal/jobs"
+	"enricher-api-go/internal/loadshed"
+	"enricher-api-go/internal/loyalty"
+	"enricher-api-go/internal/media"
+	"enricher-api-go/internal/merge"
+	"enricher-api-go/internal/openapi"
+	"enricher-api-go/internal/order"
+	"enricher-api-go/internal/pagination"
+	"enricher-api-go/internal/privacy"
+	"enricher-api-go/internal/problem"
 	"enricher-api-go/internal/product"
+	"enricher-api-go/internal/productimport"
+	"enricher-api-go/internal/retry"
+	"enricher-api-go/internal/shipping"
+	"enricher-api-go/internal/tax"
+	"enricher-api-go/internal/unitofwork"
+	"enricher-api-go/internal/validation"
+	"enricher-api-go/internal/webhook"
 
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/websocket"
 )
 
 func setupTestApp() *echo.Echo {
+	return setupTestAppWithAuth(routeAuth{}, nil, nil)
+}
+
+// setupTestAppWithAuth builds the app with the given route protection
+func setupTestAppWithAuth(auth routeAuth, rateLimit []echo.MiddlewareFunc, shedder *loadshed.Shedder) *echo.Echo {
 	e := echo.New()
+	requestValidator := validation.New()
+	e.Validator = requestValidator
+	e.Binder = validation.NewBinder(requestValidator)
 
 	// Initialize repositories
 	customerRepo := customer.NewInMemoryRepository()
 	productRepo := product.NewInMemoryRepository()
+	categoryRepo := category.NewInMemoryRepository()
+	orderRepo := order.NewInMemoryRepository()
+	loyaltyRepo := loyalty.NewInMemoryRepository()
 
 	// Initialize services
-	customerService := customer.NewService(customerRepo)
-	productService := product.NewService(productRepo)
+	loyaltyService := loyalty.NewService(loyaltyRepo, loyalty.WithCustomerCheck(customerCheck(customerRepo)))
+	customerService := customer.NewService(customerRepo, customer.WithLoyaltyTier(loyaltyService.Tier))
+	mergeRepo := merge.NewInMemoryRepository()
+	mergeService := merge.NewService(mergeRepo, customerRepo, unitofwork.Compensating{}, merge.WithOrders(orderRepo), merge.WithLoyalty(loyaltyRepo))
+	privacyService := privacy.NewService(customerRepo, unitofwork.Compensating{}, []byte("test-erasure-secret"),
+		privacy.WithOrders(orderRepo), privacy.WithLoyalty(loyaltyRepo), privacy.WithMerges(mergeRepo))
+	categoryService := category.NewService(categoryRepo, category.WithUsage(categoryUsage(productRepo)))
+	mediaDir, _ := os.MkdirTemp("", "enricher-media-")
+	mediaService := media.NewService(media.NewInMemoryRepository(), media.NewLocalStore(mediaDir, "/media"), media.WithProductCheck(productCheck(productRepo)))
+	inventoryHub := inventory.NewHub()
+	productService := product.NewService(productRepo, product.WithCategoryTree(categoryService), product.WithStockObserver(inventoryHub), product.WithImageGallery(mediaService))
+	taxCalculator := tax.NewFlatRate(0, map[string]float64{"US-CA": 0.0725})
+	enrichmentService := enrichment.NewService(customerService, productService, enrichment.WithTaxCalculator(taxCalculator))
+	shippingService := shipping.NewService(customerService, productService, shipping.NewTableRate("ground", "US", shipping.DefaultDomesticRates, shipping.DefaultInternationalRates))
+	deadLetterService := dlq.NewService(dlq.NewInMemoryRepository())
+	orderService := order.NewService(orderRepo, enrichmentService, order.WithDeadLetters(deadLetterService))
+	deadLetterService.Handle(dlq.SourceOrder, orderService.ReplayDeadLetter)
+	jobQueue := jobs.NewQueue(jobs.Config{}, enrichmentService)
+	jobQueue.Start()
+	importer := productimport.NewImporter(productimport.Config{}, productService)
+	importer.Start()
 
 	// Initialize handlers
 	customerHandler := customer.NewHandler(customerService)
+	loyaltyHandler := loyalty.NewHandler(loyaltyService)
 	productHandler := product.NewHandler(productService)
+	categoryHandler := category.NewHandler(categoryService)
+	enrichmentHandler := enrichment.NewHandler(enrichmentService)
+	taxHandler := tax.NewHandler(taxCalculator)
+	shippingHandler := shipping.NewHandler(shippingService)
+	orderHandler := order.NewHandler(orderService)
+	jobHandler := jobs.NewHandler(jobQueue)
+	deadLetterHandler := dlq.NewHandler(deadLetterService)
+	webhookHandler := webhook.NewHandler(webhook.NewService(webhook.NewInMemoryRepository()))
+	inventoryHandler := inventory.NewHandler(inventoryHub, productService)
+	importHandler := productimport.NewHandler(importer)
+	mediaHandler := media.NewHandler(mediaService)
+	mergeHandler := merge.NewHandler(mergeService)
+	privacyHandler := privacy.NewHandler(privacyService)
 
-	// Health check endpoint
-	e.GET("/health", func(c echo.Context) error {
-		return c.JSON(200, map[string]string{
-			"status":  "healthy",
-			"service": "enricher-api-go",
-		})
-	})
-
-	// Customer routes
-	customerGroup := e.Group("/v1/customers")
-	customerGroup.GET("", customerHandler.ListCustomers)
-	customerGroup.GET("/:id", customerHandler.GetCustomer)
-
-	// Product routes
-	productGroup := e.Group("/v1/products")
-	productGroup.GET("", productHandler.ListProducts)
-	productGroup.GET("/:id", productHandler.GetProduct)
+	registerHealth(e, &health.Readiness{})
+	registerRoutes(e, auth, rateLimit, shedder, config.Default().Server.LookupTimeout, customerHandler, loyaltyHandler, productHandler, categoryHandler, enrichmentHandler, taxHandler, shippingHandler, orderHandler, jobHandler, deadLetterHandler, webhookHandler, inventoryHandler, importHandler, mediaHandler, mergeHandler, privacyHandler)
+	registerMedia(e, config.MediaConfig{Store: config.MediaLocal, Dir: mediaDir, BaseURL: "/media"})
+	registerDocs(e)
 
 	return e
 }
@@ -68,6 +142,35 @@ func TestHealthEndpoint(t *testing.T) {
 	assert.Equal(t, "enricher-api-go", response["service"])
 }
 
+func TestHealthProbes_StorageDown(t *testing.T) {
+	// Arrange
+	e := echo.New()
+	var readiness health.Readiness
+	readiness.Register("postgres", func(context.Context) error { return errors.New("connection refused") })
+	registerHealth(e, &readiness)
+	serve := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	// Act
+	live := serve("/health/live")
+	ready := serve("/health/ready")
+
+	// Assert
+	assert.Equal(t, http.StatusOK, live.Code)
+	assert.Equal(t, http.StatusServiceUnavailable, ready.Code)
+
+	var report health.Report
+	assert.NoError(t, json.Unmarshal(ready.Body.Bytes(), &report))
+	assert.Equal(t, health.StatusNotReady, report.Status)
+	if assert.Len(t, report.Dependencies, 1) {
+		assert.Equal(t, "postgres", report.Dependencies[0].Name)
+		assert.Equal(t, "connection refused", report.Dependencies[0].Error)
+	}
+}
+
 func TestGetCustomerEndpoint(t *testing.T) {
 	// Arrange
 	e := setupTestApp()
@@ -100,10 +203,10 @@ func TestGetCustomerEndpoint_NotFound(t *testing.T) {
 	// Assert
 	assert.Equal(t, http.StatusNotFound, rec.Code)
 
-	var response map[string]string
+	var response problem.Problem
 	err := json.Unmarshal(rec.Body.Bytes(), &response)
 	assert.NoError(t, err)
-	assert.Equal(t, "Customer not found", response["error"])
+	assert.Equal(t, "Customer not found", response.Detail)
 }
 
 func TestGetProductEndpoint(t *testing.T) {
@@ -126,6 +229,81 @@ func TestGetProductEndpoint(t *testing.T) {
 	assert.Equal(t, 999.00, response.Price)
 }
 
+func TestBulkAvailabilityEndpoint(t *testing.T) {
+	// Arrange
+	e := setupTestApp()
+	check := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v1/products/availability", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Act
+	fulfillable := check(`[{"productId": "product-789", "quantity": 2}, {"productId": "product-123", "quantity": 1}]`)
+	partial := check(`[{"productId": "product-789", "quantity": 2}, {"productId": "product-missing", "quantity": 1}]`)
+	empty := check(`[]`)
+	notAList := check(`{"productId": "product-789", "quantity": 2}`)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, fulfillable.Code, fulfillable.Body.String())
+	var result product.BulkAvailability
+	assert.NoError(t, json.Unmarshal(fulfillable.Body.Bytes(), &result))
+	assert.True(t, result.Fulfillable)
+	assert.Len(t, result.Lines, 2)
+
+	assert.Equal(t, http.StatusOK, partial.Code)
+	assert.NoError(t, json.Unmarshal(partial.Body.Bytes(), &result))
+	assert.False(t, result.Fulfillable)
+	assert.True(t, result.Lines[0].Fulfillable)
+	assert.False(t, result.Lines[1].Found)
+
+	assert.Equal(t, http.StatusBadRequest, empty.Code)
+	assert.Equal(t, http.StatusBadRequest, notAList.Code)
+}
+
+func TestProductEndpoints_V2(t *testing.T) {
+	// Arrange
+	e := setupTestApp()
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	// Act
+	v1 := get("/v1/products/product-789")
+	v2 := get("/v2/products/product-789?currency=EUR")
+	v2List := get("/v2/products?fields=productId,stock&limit=1")
+	v2UnknownField := get("/v2/products?fields=inStock")
+
+	// Assert
+	assert.Equal(t, http.StatusOK, v1.Code)
+	assert.Equal(t, "@1792108800", v1.Header().Get(apiversion.HeaderDeprecation))
+	assert.Equal(t, `</v2/products/product-789>; rel="successor-version"`, v1.Header().Get(apiversion.HeaderLink))
+
+	assert.Equal(t, http.StatusOK, v2.Code)
+	assert.Empty(t, v2.Header().Get(apiversion.HeaderDeprecation))
+	var response product.ProductResponseV2
+	assert.NoError(t, json.Unmarshal(v2.Body.Bytes(), &response))
+	assert.Equal(t, "EUR", response.Price.Currency)
+	assert.Equal(t, 25, response.Stock.Quantity)
+	assert.True(t, response.Stock.Available)
+	assert.NotContains(t, v2.Body.String(), `"inStock"`)
+
+	assert.Equal(t, http.StatusOK, v2List.Code)
+	var list struct {
+		Products []map[string]json.RawMessage `json:"products"`
+	}
+	assert.NoError(t, json.Unmarshal(v2List.Body.Bytes(), &list))
+	if assert.Len(t, list.Products, 1) {
+		assert.Len(t, list.Products[0], 2)
+		assert.Contains(t, list.Products[0], "stock")
+	}
+	assert.Equal(t, http.StatusBadRequest, v2UnknownField.Code)
+}
+
 func TestGetProductEndpoint_NotFound(t *testing.T) {
 	// Arrange
 	e := setupTestApp()
@@ -138,10 +316,10 @@ func TestGetProductEndpoint_NotFound(t *testing.T) {
 	// Assert
 	assert.Equal(t, http.StatusNotFound, rec.Code)
 
-	var response map[string]string
+	var response problem.Problem
 	err := json.Unmarshal(rec.Body.Bytes(), &response)
 	assert.NoError(t, err)
-	assert.Equal(t, "Product not found", response["error"])
+	assert.Equal(t, "Product not found", response.Detail)
 }
 
 func TestListCustomersEndpoint(t *testing.T) {
@@ -168,6 +346,43 @@ func TestListCustomersEndpoint(t *testing.T) {
 	assert.Equal(t, float64(5), count) // Should match sample data count
 }
 
+func TestListCustomersEndpoint_Pagination(t *testing.T) {
+	// Arrange
+	e := setupTestApp()
+	req := httptest.NewRequest(http.MethodGet, "/v1/customers?segment=newsletter&limit=1", nil)
+	rec := httptest.NewRecorder()
+
+	// Act
+	e.ServeHTTP(rec, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var response struct {
+		Customers  []customer.CustomerResponse `json:"customers"`
+		Pagination pagination.Meta             `json:"pagination"`
+	}
+	err := json.Unmarshal(rec.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Len(t, response.Customers, 1)
+	assert.Equal(t, "customer-101", response.Customers[0].CustomerID)
+	assert.Equal(t, 2, response.Pagination.Total)
+	assert.Equal(t, "/v1/customers?limit=1&offset=1&segment=newsletter", response.Pagination.Next)
+}
+
+func TestListCustomersEndpoint_InvalidLimit(t *testing.T) {
+	// Arrange
+	e := setupTestApp()
+	req := httptest.NewRequest(http.MethodGet, "/v1/customers?limit=500", nil)
+	rec := httptest.NewRecorder()
+
+	// Act
+	e.ServeHTTP(rec, req)
+
+	// Assert
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
 func TestListProductsEndpoint(t *testing.T) {
 	// Arrange
 	e := setupTestApp()
@@ -191,3 +406,2022 @@ func TestListProductsEndpoint(t *testing.T) {
 	assert.True(t, exists)
 	assert.Equal(t, float64(5), count) // Should match sample data count
 }
+
+func TestListProductsEndpoint_BlankSearch(t *testing.T) {
+	// Arrange
+	e := setupTestApp()
+	req := httptest.NewRequest(http.MethodGet, "/v1/products?search=%20%20", nil)
+	rec := httptest.NewRecorder()
+
+	// Act
+	e.ServeHTTP(rec, req)
+
+	// Assert
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	var response problem.Problem
+	err := json.Unmarshal(rec.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, "search term required", response.Detail)
+}
+
+func TestCompressionMiddleware(t *testing.T) {
+	// Arrange
+	e := setupTestApp()
+	defaults := config.Default().Compression
+	e.Use(compressionMiddleware(defaults.MinLength, defaults.ExemptPaths))
+	e.GET("/small", func(c echo.Context) error {
+		return c.String(http.StatusOK, "ok")
+	})
+	e.GET("/large", func(c echo.Context) error {
+		return c.String(http.StatusOK, strings.Repeat("enriched ", defaults.MinLength))
+	})
+
+	testCases := []struct {
+		name       string
+		path       string
+		expectGzip bool
+	}{
+		{name: "Below threshold", path: "/small", expectGzip: false},
+		{name: "Exempt route", path: "/health", expectGzip: false},
+		{name: "Above threshold", path: "/large", expectGzip: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			req.Header.Set(echo.HeaderAcceptEncoding, "gzip")
+			rec := httptest.NewRecorder()
+
+			// Act
+			e.ServeHTTP(rec, req)
+
+			// Assert
+			assert.Equal(t, http.StatusOK, rec.Code)
+			if tc.expectGzip {
+				assert.Equal(t, "gzip", rec.Header().Get(echo.HeaderContentEncoding))
+			} else {
+				assert.Empty(t, rec.Header().Get(echo.HeaderContentEncoding))
+			}
+		})
+	}
+}
+
+func TestListProductsEndpoint_CombinedFilters(t *testing.T) {
+	// Arrange
+	e := setupTestApp()
+	req := httptest.NewRequest(http.MethodGet, "/v1/products?category=Electronics&search=with&limit=2", nil)
+	rec := httptest.NewRecorder()
+
+	// Act
+	e.ServeHTTP(rec, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var response struct {
+		Products []product.ProductResponse `json:"products"`
+		Count    int                       `json:"count"`
+	}
+	err := json.Unmarshal(rec.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, response.Count)
+	for _, p := range response.Products {
+		assert.Equal(t, "Electronics", p.Category)
+	}
+}
+
+func TestListProductsEndpoint_Sort(t *testing.T) {
+	// Arrange
+	e := setupTestApp()
+	serve := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	// Act
+	sorted := serve("/v1/products?inStock=true&minPrice=10&maxPrice=1000&sort=price:desc,name:asc")
+	unsortable := serve("/v1/products?sort=description")
+	malformed := serve("/v1/products?sort=price:down")
+
+	// Assert
+	assert.Equal(t, http.StatusOK, sorted.Code)
+	var response struct {
+		Products []product.ProductResponse `json:"products"`
+	}
+	assert.NoError(t, json.Unmarshal(sorted.Body.Bytes(), &response))
+	assert.NotEmpty(t, response.Products)
+	for i, p := range response.Products {
+		assert.True(t, p.InStock && p.Price >= 10 && p.Price <= 1000, "Expected only matching products, got %+v", p)
+		if i > 0 {
+			assert.GreaterOrEqual(t, response.Products[i-1].Price, p.Price, "Expected the most expensive products first")
+		}
+	}
+	assert.Equal(t, http.StatusBadRequest, unsortable.Code)
+	assert.Contains(t, unsortable.Body.String(), "cannot sort on description")
+	assert.Equal(t, http.StatusBadRequest, malformed.Code)
+}
+
+func TestListCustomersEndpoint_StatusAndSort(t *testing.T) {
+	// Arrange
+	e := setupTestApp()
+	serve := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	// Act
+	active := serve("/v1/customers?status=ACTIVE&sort=creditLimit:desc&limit=2")
+	unknown := serve("/v1/customers?status=DORMANT")
+
+	// Assert
+	assert.Equal(t, http.StatusOK, active.Code)
+	var response struct {
+		Customers  []customer.CustomerResponse `json:"customers"`
+		Pagination pagination.Meta             `json:"pagination"`
+	}
+	assert.NoError(t, json.Unmarshal(active.Body.Bytes(), &response))
+	if assert.Len(t, response.Customers, 2) {
+		assert.Equal(t, "customer-456", response.Customers[0].CustomerID)
+		assert.Equal(t, "customer-101", response.Customers[1].CustomerID)
+	}
+	assert.Equal(t, 4, response.Pagination.Total)
+	assert.Equal(t, "/v1/customers?limit=2&offset=2&sort=creditLimit%3Adesc&status=ACTIVE", response.Pagination.Next)
+	assert.Equal(t, http.StatusBadRequest, unknown.Code)
+}
+
+func TestFieldSelection(t *testing.T) {
+	// Arrange
+	e := setupTestApp()
+	serve := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	// Act
+	one := serve("/v1/products/product-123?fields=productId,price,inStock")
+	page := serve("/v1/products?fields=productId&limit=2")
+	status := serve("/v1/customers/customer-456?fields=status")
+	unknown := serve("/v1/customers?fields=customerId,password")
+
+	// Assert
+	assert.Equal(t, http.StatusOK, one.Code)
+	var single map[string]interface{}
+	assert.NoError(t, json.Unmarshal(one.Body.Bytes(), &single))
+	assert.Equal(t, map[string]interface{}{"productId": "product-123", "price": 25.99, "inStock": true}, single)
+
+	assert.Equal(t, http.StatusOK, page.Code)
+	var list struct {
+		Products []map[string]interface{} `json:"products"`
+		Count    int                      `json:"count"`
+	}
+	assert.NoError(t, json.Unmarshal(page.Body.Bytes(), &list))
+	assert.Equal(t, 2, list.Count)
+	for _, p := range list.Products {
+		assert.Len(t, p, 1)
+		assert.Contains(t, p, "productId")
+	}
+
+	assert.Equal(t, http.StatusOK, status.Code)
+	assert.JSONEq(t, `{"status":"ACTIVE"}`, status.Body.String())
+	assert.Equal(t, http.StatusBadRequest, unknown.Code)
+	assert.Contains(t, unknown.Body.String(), "unknown field: password")
+}
+
+func TestEnrichEndpoint(t *testing.T) {
+	// Arrange
+	e := setupTestApp()
+	body := `{"customerId":"customer-456","items":[{"productId":"product-789","quantity":2}]}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/enrich", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+
+	// Act
+	e.ServeHTTP(rec, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var response enrichment.EnrichedOrder
+	err := json.Unmarshal(rec.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, "Jane Doe", response.Customer.Name)
+	assert.Len(t, response.Items, 1)
+	assert.Equal(t, "Laptop", response.Items[0].Name)
+	assert.Equal(t, 1998.0, response.Total)
+}
+
+func TestEnrichEndpoint_UnknownProduct(t *testing.T) {
+	// Arrange
+	e := setupTestApp()
+	body := `{"customerId":"customer-456","items":[{"productId":"product-missing","quantity":1}]}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/enrich", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+
+	// Act
+	e.ServeHTTP(rec, req)
+
+	// Assert
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestBatchGetProductsEndpoint(t *testing.T) {
+	// Arrange
+	e := setupTestApp()
+	body := `{"productIds":["product-789","product-missing","product-789"]}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/products/batch", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+
+	// Act
+	e.ServeHTTP(rec, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var response struct {
+		Products []product.ProductResponse `json:"products"`
+		Missing  []string                  `json:"missing"`
+		Count    int                       `json:"count"`
+	}
+	err := json.Unmarshal(rec.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, response.Count)
+	assert.Equal(t, "product-789", response.Products[0].ProductID)
+	assert.Equal(t, []string{"product-missing"}, response.Missing)
+}
+
+func TestBatchGetProductsEndpoint_Empty(t *testing.T) {
+	// Arrange
+	e := setupTestApp()
+	req := httptest.NewRequest(http.MethodPost, "/v1/products/batch", strings.NewReader(`{"productIds":[]}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+
+	// Act
+	e.ServeHTTP(rec, req)
+
+	// Assert
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestBatchGetCustomersEndpoint(t *testing.T) {
+	// Arrange
+	e := setupTestApp()
+	body := `{"customerIds":["customer-456","customer-missing",""]}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/customers/batch", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+
+	// Act
+	e.ServeHTTP(rec, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var response struct {
+		Customers []customer.CustomerResponse `json:"customers"`
+		Errors    []customer.BatchError       `json:"errors"`
+		Count     int                         `json:"count"`
+	}
+	err := json.Unmarshal(rec.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, response.Count)
+	assert.Equal(t, "Jane Doe", response.Customers[0].Name)
+	assert.Equal(t, []customer.BatchError{
+		{CustomerID: "customer-missing", Error: "customer not found"},
+		{CustomerID: "", Error: "customer ID cannot be empty"},
+	}, response.Errors)
+}
+
+func TestBulkWriteProductsEndpoint(t *testing.T) {
+	// Arrange
+	e := setupTestApp()
+	body := `{"items":[
+		{"product":{"name":"Bulk Lamp","description":"Lamp created in bulk","price":30,"category":"Electronics"}},
+		{"productId":"product-123","product":{"name":"Laptop","description":"Laptop updated in bulk","price":27,"category":"Electronics"}},
+		{"productId":"product-missing","product":{"name":"Ghost","description":"Product that does not exist","price":5,"category":"Electronics"}}
+	]}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/products/bulk", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+
+	// Act
+	e.ServeHTTP(rec, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var response product.BulkResponse
+	err := json.Unmarshal(rec.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, response.Succeeded)
+	assert.Equal(t, 1, response.Failed)
+	assert.Equal(t, product.BulkCreated, response.Results[0].Status)
+	assert.Equal(t, product.BulkUpdated, response.Results[1].Status)
+	assert.Equal(t, 27.0, response.Results[1].Product.Price)
+	assert.Equal(t, product.BulkFailed, response.Results[2].Status)
+}
+
+func TestBulkWriteCustomersEndpoint_Atomic(t *testing.T) {
+	// Arrange
+	e := setupTestApp()
+	body := `{"atomic":true,"items":[
+		{"customer":{"name":"Bulk Buyer","email":"bulk@example.com","status":"ACTIVE"}},
+		{"customer":{"name":""}}
+	]}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/customers/bulk", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+
+	// Act
+	e.ServeHTTP(rec, req)
+
+	// Assert
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	var response customer.BulkResponse
+	err := json.Unmarshal(rec.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.True(t, response.Atomic)
+	assert.Equal(t, 0, response.Succeeded)
+	assert.Equal(t, customer.BulkSkipped, response.Results[0].Status)
+	assert.Equal(t, customer.BulkFailed, response.Results[1].Status)
+}
+
+func TestOpenAPIEndpoint(t *testing.T) {
+	// Arrange
+	e := setupTestApp()
+	req := httptest.NewRequest(http.MethodGet, "/openapi.json", nil)
+	rec := httptest.NewRecorder()
+
+	// Act
+	e.ServeHTTP(rec, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var document openapi.Document
+	err := json.Unmarshal(rec.Body.Bytes(), &document)
+	assert.NoError(t, err)
+	assert.Equal(t, openapi.Version, document.OpenAPI)
+	assert.Contains(t, document.Paths, "/v1/customers/{id}")
+	assert.Contains(t, document.Paths["/v1/products/batch"], "post")
+
+	request := document.Components.Schemas["customer.CustomerRequest"]
+	if assert.NotNil(t, request) {
+		assert.ElementsMatch(t, []string{"name", "status"}, request.Required)
+		assert.Equal(t, []interface{}{"PENDING", "ACTIVE", "SUSPENDED", "CLOSED"}, request.Properties["status"].Enum)
+	}
+}
+
+func TestOpenAPIDocument_DescribesEveryRoute(t *testing.T) {
+	// Arrange
+	e := setupTestApp()
+
+	// Act
+	routes := e.Routes()
+
+	// Assert
+	for _, route := range routes {
+		if route.Method != echo.RouteNotFound && isVersioned(route.Path) {
+			assert.Contains(t, apiEndpoints, route.Method+" "+route.Path, "undocumented route")
+		}
+	}
+}
+
+func TestDocsEndpoint(t *testing.T) {
+	// Arrange
+	e := setupTestApp()
+	req := httptest.NewRequest(http.MethodGet, "/docs/index.html", nil)
+	rec := httptest.NewRecorder()
+
+	// Act
+	e.ServeHTTP(rec, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "/openapi.json")
+}
+
+func TestCreateCustomerEndpoint_ValidationErrors(t *testing.T) {
+	// Arrange
+	e := setupTestApp()
+	body := `{"name":"A","status":"INACTIVE"}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/customers", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+
+	// Act
+	e.ServeHTTP(rec, req)
+
+	// Assert
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	var response problem.Problem
+	err := json.Unmarshal(rec.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, "Validation failed", response.Title)
+	assert.Equal(t, []validation.FieldError{
+		{Field: "name", Rule: "min", Message: "must be at least 2 characters"},
+		{Field: "status", Rule: "oneof", Message: "must be one of PENDING, ACTIVE, SUSPENDED, CLOSED"},
+	}, response.Fields)
+}
+
+func TestRoleBasedAccess_APIKeys(t *testing.T) {
+	// Arrange
+	e := setupTestAppWithAuth(newRouteAuth(config.AuthConfig{
+		RBAC: true,
+		APIKeys: []config.APIKeyConfig{
+			{Name: "dashboard", Role: "reader", Key: "reader-key"},
+			{Name: "ci", Role: "operator", Key: "operator-key"},
+			{Name: "ops", Role: "admin", Key: "admin-key"},
+		},
+	}), nil, nil)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		apiKey     string
+		wantStatus int
+	}{
+		{name: "no credentials", method: http.MethodGet, path: "/v1/products/product-123", wantStatus: http.StatusUnauthorized},
+		{name: "unknown key", method: http.MethodGet, path: "/v1/products/product-123", apiKey: "guess", wantStatus: http.StatusUnauthorized},
+		{name: "reader can GET", method: http.MethodGet, path: "/v1/products/product-123", apiKey: "reader-key", wantStatus: http.StatusOK},
+		{name: "reader cannot PUT", method: http.MethodPut, path: "/v1/products/product-123", apiKey: "reader-key", wantStatus: http.StatusForbidden},
+		{name: "operator cannot DELETE", method: http.MethodDelete, path: "/v1/products/product-123", apiKey: "operator-key", wantStatus: http.StatusForbidden},
+		{name: "admin can DELETE", method: http.MethodDelete, path: "/v1/products/product-123", apiKey: "admin-key", wantStatus: http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(`{}`))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			if tt.apiKey != "" {
+				req.Header.Set(auth.APIKeyHeader, tt.apiKey)
+			}
+			rec := httptest.NewRecorder()
+
+			// Act
+			e.ServeHTTP(rec, req)
+
+			// Assert
+			assert.Equal(t, tt.wantStatus, rec.Code, rec.Body.String())
+		})
+	}
+
+	// Health stays open
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestAdminEndpoints(t *testing.T) {
+	// Arrange
+	cfg := config.Default()
+	cfg.Storage.DatabaseURL = "postgres://enricher:hunter2@db:5432/enricher"
+	flags := featureflag.New(featureFlags(cfg))
+	customers := customer.NewInMemoryRepository()
+	cached := customer.NewCachedRepository(customers, cache.NewLRU(100), time.Minute)
+	datasets := map[string]admin.Dataset{"customers": customers}
+	invalidateOnReset(datasets, "customers", cached)
+	reloaded := config.Default()
+	reloaded.LogLevel, reloaded.Server.ServerTiming = "debug", true
+	var applied *config.Config
+	handler := admin.NewHandler(cfg, flags,
+		admin.WithReload(func() (*config.Config, error) { return reloaded, nil }, func(next *config.Config) { applied = next }),
+		admin.WithDataset("customers", datasets["customers"]))
+
+	e := echo.New()
+	requestValidator := validation.New()
+	e.Validator = requestValidator
+	e.Binder = validation.NewBinder(requestValidator)
+	registerAdmin(e, newRouteAuth(config.AuthConfig{
+		RBAC: true,
+		APIKeys: []config.APIKeyConfig{
+			{Name: "ci", Role: "operator", Key: "operator-key"},
+			{Name: "ops", Role: "admin", Key: "admin-key"},
+		},
+	}), handler, chaos.NewHandler(chaos.NewInjector()))
+	serve := func(method, path, apiKey, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set(auth.APIKeyHeader, apiKey)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+	renamed, _ := cached.GetByID("customer-456")
+	renamed.Name = "Jane Renamed"
+	assert.NoError(t, cached.Update(renamed))
+	_, err := cached.GetByID("customer-456")
+	assert.NoError(t, err)
+	assert.NoError(t, customers.Delete("customer-456"))
+
+	// Act
+	operator := serve(http.MethodGet, "/admin/config", "operator-key", "")
+	shown := serve(http.MethodGet, "/admin/config", "admin-key", "")
+	toggled := serve(http.MethodPut, "/admin/flags/httpCache", "admin-key", `{"enabled": false}`)
+	unknown := serve(http.MethodPut, "/admin/flags/darkMode", "admin-key", `{"enabled": true}`)
+	listed := serve(http.MethodGet, "/admin/flags", "admin-key", "")
+	reload := serve(http.MethodPost, "/admin/config/reload", "admin-key", "")
+	reset := serve(http.MethodPost, "/admin/reset", "admin-key", "")
+	anonymousFaults := serve(http.MethodGet, "/admin/faults", "", "")
+	operatorFault := serve(http.MethodPost, "/admin/faults", "operator-key", `{"route": "/v1/customers/:id", "errorRate": 1}`)
+	faults := serve(http.MethodGet, "/admin/faults", "admin-key", "")
+
+	// Assert
+	assert.Equal(t, http.StatusForbidden, operator.Code)
+	assert.Equal(t, http.StatusOK, shown.Code)
+	assert.Contains(t, shown.Body.String(), `"requestTimeout":"2s"`)
+	assert.NotContains(t, shown.Body.String(), "hunter2")
+	assert.Equal(t, http.StatusOK, toggled.Code)
+	assert.Equal(t, http.StatusNotFound, unknown.Code)
+	assert.JSONEq(t, `{"flags": {"httpCache": false, "serverTiming": false, "payloadLogging": false}}`, listed.Body.String())
+	assert.Equal(t, http.StatusOK, reload.Code)
+	assert.Contains(t, reload.Body.String(), `"logLevel":"debug"`)
+	if assert.NotNil(t, applied) {
+		assert.True(t, applied.Server.ServerTiming)
+		assert.Equal(t, cfg.Storage.DatabaseURL, applied.Storage.DatabaseURL, "Expected settings needing a restart to be kept")
+	}
+	assert.JSONEq(t, `{"reset": ["customers"]}`, reset.Body.String())
+	restored, err := customers.GetByID("customer-456")
+	if assert.NoError(t, err, "Expected the deleted customer to be back after the reset") {
+		assert.Equal(t, "Jane Doe", restored.Name)
+	}
+	served, err := cached.GetByID("customer-456")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Jane Doe", served.Name, "Expected the reset to drop the cached customer")
+	}
+	assert.Equal(t, http.StatusUnauthorized, anonymousFaults.Code)
+	assert.Equal(t, http.StatusForbidden, operatorFault.Code)
+	assert.Equal(t, http.StatusOK, faults.Code)
+}
+
+func TestRateLimit_PerRouteGroup(t *testing.T) {
+	// Arrange
+	e := setupTestAppWithAuth(routeAuth{}, newRateLimit(config.RateLimitConfig{
+		Enabled:       true,
+		RateLimitRule: config.RateLimitRule{RequestsPerSecond: 1, Burst: 2},
+		Groups: map[string]config.RateLimitRule{
+			"/v1/enrich": {RequestsPerSecond: 1, Burst: 1},
+		},
+	}), nil)
+	get := func(path, ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set(echo.HeaderXRealIP, ip)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Act
+	first := get("/v1/products/product-123", "10.0.0.1")
+	second := get("/v1/customers/customer-456", "10.0.0.1")
+	limited := get("/v1/products/product-123", "10.0.0.1")
+	otherClient := get("/v1/products/product-123", "10.0.0.2")
+	enrichReq := httptest.NewRequest(http.MethodPost, "/v1/enrich", strings.NewReader(`{}`))
+	enrichReq.Header.Set(echo.HeaderXRealIP, "10.0.0.1")
+	enrichReq.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	enrichRec := httptest.NewRecorder()
+	e.ServeHTTP(enrichRec, enrichReq)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, "2", first.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, "1", first.Header().Get("X-RateLimit-Remaining"))
+	assert.Equal(t, http.StatusOK, second.Code)
+	assert.Equal(t, http.StatusTooManyRequests, limited.Code)
+	assert.Equal(t, "1", limited.Header().Get(echo.HeaderRetryAfter))
+	assert.Equal(t, http.StatusOK, otherClient.Code, "clients have separate buckets")
+	assert.NotEqual(t, http.StatusTooManyRequests, enrichRec.Code, "route groups have separate buckets")
+	assert.Equal(t, "1", enrichRec.Header().Get("X-RateLimit-Limit"))
+}
+
+func TestClientIP(t *testing.T) {
+	// Arrange
+	clientFrom := func(extractor echo.IPExtractor, remoteAddr, forwardedFor string) string {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set(echo.HeaderXForwardedFor, forwardedFor)
+		req.Header.Set(echo.HeaderXRealIP, forwardedFor)
+		return extractor(req)
+	}
+	direct := clientIP(nil)
+	proxied := clientIP([]string{"10.1.0.0/16"})
+
+	// Act
+	spoofed := clientFrom(direct, "203.0.113.7:4000", "198.51.100.1")
+	forwarded := clientFrom(proxied, "10.1.2.3:4000", "198.51.100.1")
+	untrustedProxy := clientFrom(proxied, "10.2.0.1:4000", "198.51.100.1")
+	forgedHop := clientFrom(proxied, "10.1.2.3:4000", "198.51.100.1, 203.0.113.7")
+
+	// Assert
+	assert.Equal(t, "203.0.113.7", spoofed, "forwarding headers are ignored without trusted proxies")
+	assert.Equal(t, "198.51.100.1", forwarded)
+	assert.Equal(t, "10.2.0.1", untrustedProxy, "private ranges are not trusted by default")
+	assert.Equal(t, "203.0.113.7", forgedHop, "hops before the first untrusted one are not believed")
+}
+
+func TestLoadShed_ShedsBulkReads(t *testing.T) {
+	// Arrange: every request in flight is one too many
+	shedder := loadshed.New(loadshed.Config{MaxInFlight: 0, TargetLatency: time.Second, RetryAfter: time.Second})
+	e := setupTestAppWithAuth(routeAuth{}, nil, shedder)
+	e.Use(shedder.Middleware())
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	// Act
+	list := get("/v1/customers")
+	export := get("/v1/products/export")
+	customerLookup := get("/v1/customers/customer-456")
+	productLookup := get("/v2/products/product-123")
+
+	// Assert
+	assert.Equal(t, http.StatusServiceUnavailable, list.Code)
+	assert.Equal(t, "1", list.Header().Get(echo.HeaderRetryAfter))
+	assert.Equal(t, http.StatusServiceUnavailable, export.Code)
+	assert.Equal(t, http.StatusOK, customerLookup.Code, "lookups are never shed")
+	assert.Equal(t, http.StatusOK, productLookup.Code, "lookups are never shed")
+}
+
+func TestPatchProductEndpoint(t *testing.T) {
+	// Arrange
+	e := setupTestApp()
+	req := httptest.NewRequest(http.MethodPatch, "/v1/products/product-123", strings.NewReader(`{"quantity": 0}`))
+	req.Header.Set(echo.HeaderContentType, validation.MIMEApplicationMergePatchJSON)
+	rec := httptest.NewRecorder()
+
+	// Act
+	e.ServeHTTP(rec, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var response product.ProductResponse
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, 0, response.Quantity)
+	assert.False(t, response.InStock)
+	assert.NotEmpty(t, response.Name, "omitted fields keep their values")
+}
+
+func TestPatchCustomerEndpoint_Invalid(t *testing.T) {
+	// Arrange
+	e := setupTestApp()
+	req := httptest.NewRequest(http.MethodPatch, "/v1/customers/customer-456", strings.NewReader(`{"status": "INACTIVE"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+
+	// Act
+	e.ServeHTTP(rec, req)
+
+	// Assert
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "status")
+}
+
+func TestGetProductEndpoint_Currency(t *testing.T) {
+	// Arrange
+	e := setupTestApp()
+	serve := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	// Act
+	base := serve("/v1/products/product-789")
+	euro := serve("/v1/products/product-789?currency=eur")
+	noRate := serve("/v1/products/product-789?currency=GBP")
+	malformed := serve("/v1/products?currency=euro")
+
+	// Assert
+	assert.Equal(t, http.StatusOK, base.Code)
+	assert.Contains(t, base.Body.String(), `"price":999,"currency":"USD"`)
+	assert.Equal(t, http.StatusOK, euro.Code, euro.Body.String())
+	assert.Contains(t, euro.Body.String(), `"price":929,"currency":"EUR"`)
+	assert.Equal(t, http.StatusBadRequest, noRate.Code)
+	assert.Equal(t, http.StatusBadRequest, malformed.Code)
+}
+
+func TestPriceScheduleEndpoints(t *testing.T) {
+	// Arrange
+	e := setupTestApp()
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+	effectiveAt := time.Now().UTC().Add(24 * time.Hour).Truncate(time.Second)
+	body := fmt.Sprintf(`{"price": 899.00, "effectiveAt": %q}`, effectiveAt.Format(time.RFC3339))
+
+	// Act
+	scheduled := serve(http.MethodPost, "/v1/products/product-789/prices", body)
+	past := serve(http.MethodPost, "/v1/products/product-789/prices", `{"price": 899, "effectiveAt": "2020-01-01T00:00:00Z"}`)
+	current := serve(http.MethodGet, "/v1/products/product-789", "")
+	future := serve(http.MethodGet, "/v1/products/product-789?at="+effectiveAt.Add(time.Minute).Format(time.RFC3339), "")
+	badAt := serve(http.MethodGet, "/v1/products/product-789?at=tomorrow", "")
+	var change product.PriceChange
+	_ = json.Unmarshal(scheduled.Body.Bytes(), &change)
+	listed := serve(http.MethodGet, "/v1/products/product-789/prices", "")
+	cancelled := serve(http.MethodDelete, fmt.Sprintf("/v1/products/product-789/prices/%d", change.ID), "")
+	cancelledAgain := serve(http.MethodDelete, fmt.Sprintf("/v1/products/product-789/prices/%d", change.ID), "")
+
+	// Assert
+	assert.Equal(t, http.StatusCreated, scheduled.Code, scheduled.Body.String())
+	assert.Equal(t, 899.0, change.Price)
+	assert.Equal(t, http.StatusBadRequest, past.Code)
+	assert.Contains(t, current.Body.String(), `"price":999`)
+	assert.Equal(t, http.StatusOK, future.Code, future.Body.String())
+	assert.Contains(t, future.Body.String(), `"price":899`)
+	assert.Equal(t, http.StatusBadRequest, badAt.Code)
+	assert.Equal(t, http.StatusOK, listed.Code)
+	// The starting price and the scheduled change
+	assert.Contains(t, listed.Body.String(), `"count":2`)
+	assert.Equal(t, http.StatusNoContent, cancelled.Code)
+	assert.Equal(t, http.StatusNotFound, cancelledAgain.Code)
+}
+
+func TestVariantEndpoints(t *testing.T) {
+	// Arrange
+	e := setupTestApp()
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+	body := `{"sku": "chair-black", "attributes": {"color": "black"}, "price": 209.99, "quantity": 2}`
+
+	// Act
+	created := serve(http.MethodPost, "/v1/products/product-456/variants", body)
+	duplicate := serve(http.MethodPost, "/v1/products/product-456/variants", body)
+	invalid := serve(http.MethodPost, "/v1/products/product-456/variants", `{"sku": "chair-red", "price": 0}`)
+	missingProduct := serve(http.MethodPost, "/v1/products/product-missing/variants", body)
+	listed := serve(http.MethodGet, "/v1/products/product-456/variants", "")
+	updated := serve(http.MethodPut, "/v1/skus/CHAIR-BLACK", `{"price": 199.99, "quantity": 0}`)
+	found := serve(http.MethodGet, "/v1/skus/chair-black", "")
+	enriched := serve(http.MethodPost, "/v1/enrich", `{"customerId": "customer-456", "items": [{"sku": "CHAIR-BLACK", "quantity": 1}]}`)
+	deleted := serve(http.MethodDelete, "/v1/skus/CHAIR-BLACK", "")
+	gone := serve(http.MethodGet, "/v1/skus/CHAIR-BLACK", "")
+
+	// Assert
+	assert.Equal(t, http.StatusCreated, created.Code, created.Body.String())
+	assert.Contains(t, created.Body.String(), `"sku":"CHAIR-BLACK"`)
+	assert.Equal(t, http.StatusConflict, duplicate.Code)
+	assert.Equal(t, http.StatusBadRequest, invalid.Code)
+	assert.Equal(t, http.StatusNotFound, missingProduct.Code)
+	assert.Contains(t, listed.Body.String(), `"count":1`)
+	assert.Equal(t, http.StatusOK, updated.Code, updated.Body.String())
+	assert.Contains(t, found.Body.String(), `"price":199.99`)
+	assert.Equal(t, http.StatusOK, enriched.Code, enriched.Body.String())
+	assert.Contains(t, enriched.Body.String(), `"sku":"CHAIR-BLACK"`)
+	assert.Contains(t, enriched.Body.String(), `"inStock":false`)
+	assert.Equal(t, http.StatusNoContent, deleted.Code)
+	assert.Equal(t, http.StatusNotFound, gone.Code)
+}
+
+func TestCategoryEndpoints(t *testing.T) {
+	// Arrange
+	e := setupTestApp()
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Act
+	created := serve(http.MethodPost, "/v1/categories", `{"name": "Laptops", "parentId": "category-electronics"}`)
+	var laptops category.Category
+	assert.NoError(t, json.Unmarshal(created.Body.Bytes(), &laptops))
+	duplicate := serve(http.MethodPost, "/v1/categories", `{"name": "Laptops"}`)
+	badParent := serve(http.MethodPost, "/v1/categories", `{"name": "Tablets", "parentId": "category-missing"}`)
+	cycle := serve(http.MethodPut, "/v1/categories/category-electronics", fmt.Sprintf(`{"name": "Electronics", "parentId": %q}`, laptops.CategoryID))
+	ultrabook := serve(http.MethodPost, "/v1/products", `{"name": "Ultrabook", "description": "Thin and light laptop", "price": 1299, "category": "Laptops"}`)
+	unknown := serve(http.MethodPost, "/v1/products", `{"name": "Garden Hose", "description": "Twenty metre hose", "price": 25, "category": "Garden"}`)
+	direct := serve(http.MethodGet, "/v1/products?category=Electronics", "")
+	subtree := serve(http.MethodGet, "/v1/products?category=Electronics&includeSubcategories=true", "")
+	tree := serve(http.MethodGet, "/v1/categories?tree=true", "")
+	inUse := serve(http.MethodDelete, "/v1/categories/category-electronics", "")
+	missing := serve(http.MethodGet, "/v1/categories/category-missing", "")
+
+	// Assert
+	assert.Equal(t, http.StatusCreated, created.Code, created.Body.String())
+	assert.Equal(t, "category-electronics", laptops.ParentID)
+	assert.Equal(t, http.StatusConflict, duplicate.Code)
+	assert.Equal(t, http.StatusBadRequest, badParent.Code)
+	assert.Equal(t, http.StatusBadRequest, cycle.Code)
+	assert.Equal(t, http.StatusCreated, ultrabook.Code, ultrabook.Body.String())
+	assert.Equal(t, http.StatusBadRequest, unknown.Code)
+	assert.Contains(t, unknown.Body.String(), "unknown category")
+	assert.Contains(t, direct.Body.String(), `"count":3`)
+	assert.Contains(t, subtree.Body.String(), `"count":4`)
+	assert.Contains(t, tree.Body.String(), `"children":[{"categoryId":"`+laptops.CategoryID)
+	assert.Equal(t, http.StatusConflict, inUse.Code)
+	assert.Equal(t, http.StatusNotFound, missing.Code)
+}
+
+func TestCustomerTransitionEndpoint(t *testing.T) {
+	// Arrange
+	e := setupTestApp()
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Act
+	suspended := serve(http.MethodPost, "/v1/customers/customer-456/transitions", `{"status": "SUSPENDED"}`)
+	status := serve(http.MethodGet, "/v1/customers/customer-456/status", "")
+	closed := serve(http.MethodPost, "/v1/customers/customer-456/transitions", `{"status": "CLOSED"}`)
+	reopened := serve(http.MethodPost, "/v1/customers/customer-456/transitions", `{"status": "ACTIVE"}`)
+	unknown := serve(http.MethodPost, "/v1/customers/customer-456/transitions", `{"status": "INACTIVE"}`)
+	missing := serve(http.MethodPost, "/v1/customers/customer-missing/transitions", `{"status": "CLOSED"}`)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, suspended.Code, suspended.Body.String())
+	assert.Contains(t, suspended.Body.String(), `"status":"SUSPENDED"`)
+	assert.Contains(t, status.Body.String(), `"allowedTransitions":["ACTIVE","CLOSED"]`)
+	assert.Equal(t, http.StatusOK, closed.Code)
+	assert.Equal(t, http.StatusConflict, reopened.Code)
+	assert.Contains(t, reopened.Body.String(), "a CLOSED customer cannot change status")
+	assert.Equal(t, http.StatusBadRequest, unknown.Code)
+	assert.Equal(t, http.StatusNotFound, missing.Code)
+}
+
+func TestLoyaltyEndpoints(t *testing.T) {
+	// Arrange
+	e := setupTestApp()
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Act
+	before := serve(http.MethodGet, "/v1/customers/customer-456/loyalty", "")
+	accrued := serve(http.MethodPost, "/v1/customers/customer-456/loyalty/accrue", `{"points": 5200}`)
+	redeemed := serve(http.MethodPost, "/v1/customers/customer-456/loyalty/redeem", `{"points": 200}`)
+	overdrawn := serve(http.MethodPost, "/v1/customers/customer-456/loyalty/redeem", `{"points": 5001}`)
+	invalid := serve(http.MethodPost, "/v1/customers/customer-456/loyalty/accrue", `{"points": -5}`)
+	unknown := serve(http.MethodPost, "/v1/customers/customer-missing/loyalty/accrue", `{"points": 10}`)
+	customerRead := serve(http.MethodGet, "/v1/customers/customer-456", "")
+	enriched := serve(http.MethodPost, "/v1/enrich", `{"customerId": "customer-456", "items": [{"productId": "product-123", "quantity": 1}]}`)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, before.Code, before.Body.String())
+	assert.Contains(t, before.Body.String(), `"points":0`)
+	assert.Contains(t, before.Body.String(), `"tier":"BRONZE"`)
+	assert.Equal(t, http.StatusOK, accrued.Code, accrued.Body.String())
+	assert.Contains(t, accrued.Body.String(), `"tier":"GOLD"`)
+	assert.Equal(t, http.StatusOK, redeemed.Code, redeemed.Body.String())
+	var account loyalty.Account
+	assert.NoError(t, json.Unmarshal(redeemed.Body.Bytes(), &account))
+	assert.Equal(t, 5000, account.Points)
+	assert.Equal(t, 5200, account.LifetimePoints)
+	assert.Equal(t, http.StatusConflict, overdrawn.Code)
+	assert.Equal(t, http.StatusBadRequest, invalid.Code)
+	assert.Equal(t, http.StatusNotFound, unknown.Code)
+	assert.Contains(t, customerRead.Body.String(), `"loyaltyTier":"GOLD"`)
+	assert.Equal(t, http.StatusOK, enriched.Code, enriched.Body.String())
+	assert.Contains(t, enriched.Body.String(), `"loyaltyTier":"GOLD"`)
+}
+
+func TestCustomerMergeEndpoints(t *testing.T) {
+	// Arrange
+	e := setupTestApp()
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+	accrued := serve(http.MethodPost, "/v1/customers/customer-101/loyalty/accrue", `{"points": 120}`)
+	assert.Equal(t, http.StatusOK, accrued.Code, accrued.Body.String())
+
+	// Act
+	merged := serve(http.MethodPost, "/v1/customers/customer-456/merge", `{"duplicateId": "customer-101", "strategy": "duplicate"}`)
+	survivor := serve(http.MethodGet, "/v1/customers/customer-456", "")
+	duplicate := serve(http.MethodGet, "/v1/customers/customer-101", "")
+	points := serve(http.MethodGet, "/v1/customers/customer-456/loyalty", "")
+	merges := serve(http.MethodGet, "/v1/customers/customer-101/merges", "")
+	again := serve(http.MethodPost, "/v1/customers/customer-456/merge", `{"duplicateId": "customer-101"}`)
+	itself := serve(http.MethodPost, "/v1/customers/customer-456/merge", `{"duplicateId": "customer-456"}`)
+	badStrategy := serve(http.MethodPost, "/v1/customers/customer-456/merge", `{"duplicateId": "customer-202", "strategy": "oldest"}`)
+	unknown := serve(http.MethodGet, "/v1/customers/customer-missing/merges", "")
+
+	// Assert
+	assert.Equal(t, http.StatusOK, merged.Code, merged.Body.String())
+	var record merge.Merge
+	assert.NoError(t, json.Unmarshal(merged.Body.Bytes(), &record))
+	assert.Equal(t, "customer-456", record.SurvivorID)
+	assert.Equal(t, 120, record.Points)
+	assert.Contains(t, merged.Body.String(), `{"field":"name","kept":"Bob Wilson","discarded":"Jane Doe"}`)
+	assert.Contains(t, survivor.Body.String(), `"name":"Bob Wilson"`)
+	assert.Contains(t, survivor.Body.String(), `"email":"jane.doe@example.com"`)
+	assert.Equal(t, http.StatusNotFound, duplicate.Code)
+	assert.Contains(t, points.Body.String(), `"points":120`)
+	assert.Equal(t, http.StatusOK, merges.Code, merges.Body.String())
+	assert.Contains(t, merges.Body.String(), `"count":1`)
+	assert.Equal(t, http.StatusNotFound, again.Code)
+	assert.Equal(t, http.StatusBadRequest, itself.Code)
+	assert.Equal(t, http.StatusBadRequest, badStrategy.Code)
+	assert.Equal(t, http.StatusNotFound, unknown.Code)
+}
+
+func TestCustomerPrivacyEndpoints(t *testing.T) {
+	// Arrange
+	e := setupTestApp()
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+	created := serve(http.MethodPost, "/v1/customers/customer-456/addresses",
+		`{"type": "shipping", "line1": "1 Main St", "city": "Springfield", "postalCode": "12345", "country": "US"}`)
+	assert.Equal(t, http.StatusCreated, created.Code, created.Body.String())
+
+	// Act
+	export := serve(http.MethodGet, "/v1/customers/customer-456/data-export", "")
+	requested := serve(http.MethodPost, "/v1/customers/customer-456/erase", "")
+	var confirmation privacy.ErasureConfirmation
+	assert.NoError(t, json.Unmarshal(requested.Body.Bytes(), &confirmation))
+	forged := serve(http.MethodPost, "/v1/customers/customer-456/erase", `{"confirmationToken": "1.forged"}`)
+	erased := serve(http.MethodPost, "/v1/customers/customer-456/erase", `{"confirmationToken": "`+confirmation.ConfirmationToken+`"}`)
+	replayed := serve(http.MethodPost, "/v1/customers/customer-456/erase", `{"confirmationToken": "`+confirmation.ConfirmationToken+`"}`)
+	customerRead := serve(http.MethodGet, "/v1/customers/customer-456", "")
+	erasedExport := serve(http.MethodGet, "/v1/customers/customer-456/data-export", "")
+	exposed := serve(http.MethodPost, "/v1/customers/customer-123/erase", "")
+	unknown := serve(http.MethodGet, "/v1/customers/customer-missing/data-export", "")
+
+	// Assert
+	assert.Equal(t, http.StatusOK, export.Code, export.Body.String())
+	assert.Contains(t, export.Body.String(), `"email":"jane.doe@example.com"`)
+	assert.Contains(t, export.Body.String(), `"line1":"1 Main St"`)
+	assert.Equal(t, http.StatusAccepted, requested.Code, requested.Body.String())
+	assert.Len(t, confirmation.AddressIDs, 1)
+	assert.Equal(t, http.StatusBadRequest, forged.Code)
+	assert.Equal(t, http.StatusOK, erased.Code, erased.Body.String())
+	assert.Equal(t, http.StatusBadRequest, replayed.Code)
+	assert.Equal(t, http.StatusNotFound, customerRead.Code)
+	assert.Equal(t, http.StatusOK, erasedExport.Code, erasedExport.Body.String())
+	assert.Contains(t, erasedExport.Body.String(), `"name":"ERASED"`)
+	assert.NotContains(t, erasedExport.Body.String(), "jane.doe@example.com")
+	assert.NotContains(t, erasedExport.Body.String(), "1 Main St")
+	assert.Equal(t, http.StatusConflict, exposed.Code)
+	assert.Equal(t, http.StatusNotFound, unknown.Code)
+}
+
+func TestTaxQuoteEndpoint(t *testing.T) {
+	// Arrange
+	e := setupTestApp()
+	serve := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v1/tax/quote", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Act
+	quoted := serve(`{"address": {"country": "US", "region": "CA"}, "lines": [{"productId": "product-123", "unitPrice": 19.99, "quantity": 2}]}`)
+	untaxed := serve(`{"address": {"country": "FR"}, "lines": [{"productId": "product-123", "unitPrice": 19.99, "quantity": 2}]}`)
+	noCountry := serve(`{"address": {"region": "CA"}, "lines": [{"productId": "product-123", "unitPrice": 19.99, "quantity": 2}]}`)
+	noLines := serve(`{"address": {"country": "US", "region": "CA"}, "lines": []}`)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, quoted.Code, quoted.Body.String())
+	var quote tax.Quote
+	assert.NoError(t, json.Unmarshal(quoted.Body.Bytes(), &quote))
+	assert.Equal(t, "US-CA", quote.Jurisdiction)
+	assert.Equal(t, 0.0725, quote.Lines[0].Rate)
+	assert.Equal(t, 2.9, quote.TotalTax)
+	assert.Equal(t, http.StatusOK, untaxed.Code, untaxed.Body.String())
+	assert.Contains(t, untaxed.Body.String(), `"totalTax":0`)
+	assert.Equal(t, http.StatusBadRequest, noCountry.Code)
+	assert.Equal(t, http.StatusBadRequest, noLines.Code)
+}
+
+func TestShippingEstimateEndpoint(t *testing.T) {
+	// Arrange
+	e := setupTestApp()
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+	created := serve(http.MethodPost, "/v1/products", `{"name": "Shipping Box", "description": "Corrugated box", "price": 4.99, "category": "Electronics", "quantity": 10,
+		"weight": {"value": 1.8, "unit": "kg"}, "dimensions": {"length": 35, "width": 25, "height": 3}}`)
+	assert.Equal(t, http.StatusCreated, created.Code, created.Body.String())
+	var box product.Product
+	assert.NoError(t, json.Unmarshal(created.Body.Bytes(), &box))
+	address := serve(http.MethodPost, "/v1/customers/customer-456/addresses", `{"type": "shipping", "line1": "1 Market St", "city": "San Francisco", "region": "CA", "postalCode": "94105", "country": "US"}`)
+	assert.Equal(t, http.StatusCreated, address.Code, address.Body.String())
+
+	// Act
+	estimated := serve(http.MethodPost, "/v1/shipping/estimate", `{"customerId": "customer-456", "items": [{"productId": "`+box.ProductID+`", "quantity": 2}]}`)
+	unweighed := serve(http.MethodPost, "/v1/shipping/estimate", `{"customerId": "customer-456", "items": [{"productId": "product-123", "quantity": 1}]}`)
+	noItems := serve(http.MethodPost, "/v1/shipping/estimate", `{"customerId": "customer-456", "items": []}`)
+	unknown := serve(http.MethodPost, "/v1/shipping/estimate", `{"customerId": "customer-missing", "items": [{"productId": "`+box.ProductID+`", "quantity": 1}]}`)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, estimated.Code, estimated.Body.String())
+	var estimate shipping.Estimate
+	assert.NoError(t, json.Unmarshal(estimated.Body.Bytes(), &estimate))
+	assert.Equal(t, 3.6, estimate.Shipment.BillableWeightKg)
+	if assert.Len(t, estimate.Options, 2) {
+		assert.Equal(t, "standard", estimate.Options[0].Service)
+		assert.Equal(t, 8.96, estimate.Options[0].Cost)
+	}
+	assert.Equal(t, http.StatusBadRequest, unweighed.Code)
+	assert.Equal(t, http.StatusBadRequest, noItems.Code)
+	assert.Equal(t, http.StatusNotFound, unknown.Code)
+}
+
+func TestProductBarcodeEndpoint(t *testing.T) {
+	// Arrange
+	e := setupTestApp()
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+	tin := `{"name": "Tea Tin", "description": "Airtight tin for loose-leaf tea", "price": 12, "category": "Electronics", "quantity": 10, "barcode": "4006381333931"}`
+	created := serve(http.MethodPost, "/v1/products", tin)
+	assert.Equal(t, http.StatusCreated, created.Code, created.Body.String())
+
+	// Act
+	scanned := serve(http.MethodGet, "/v1/products/by-barcode/4006381333931", "")
+	scannedV2 := serve(http.MethodGet, "/v2/products/by-barcode/4006381333931", "")
+	unknown := serve(http.MethodGet, "/v1/products/by-barcode/96385074", "")
+	invalid := serve(http.MethodGet, "/v1/products/by-barcode/4006381333932", "")
+	duplicate := serve(http.MethodPost, "/v1/products", tin)
+	badCheckDigit := serve(http.MethodPost, "/v1/products", strings.Replace(tin, "4006381333931", "4006381333932", 1))
+
+	// Assert
+	assert.Equal(t, http.StatusOK, scanned.Code, scanned.Body.String())
+	assert.Contains(t, scanned.Body.String(), `"name":"Tea Tin"`)
+	assert.Contains(t, scanned.Body.String(), `"barcode":"4006381333931"`)
+	assert.Equal(t, http.StatusOK, scannedV2.Code, scannedV2.Body.String())
+	assert.Contains(t, scannedV2.Body.String(), `"stock":`)
+	assert.Equal(t, http.StatusNotFound, unknown.Code)
+	assert.Equal(t, http.StatusBadRequest, invalid.Code)
+	assert.Equal(t, http.StatusConflict, duplicate.Code)
+	assert.Equal(t, http.StatusBadRequest, badCheckDigit.Code)
+}
+
+func TestProductImageEndpoints(t *testing.T) {
+	// Arrange
+	e := setupTestApp()
+	var photo bytes.Buffer
+	assert.NoError(t, png.Encode(&photo, image.NewRGBA(image.Rect(0, 0, 800, 400))))
+	upload := func(productID string, file []byte) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		part, err := form.CreateFormFile("file", "photo.png")
+		assert.NoError(t, err)
+		_, err = part.Write(file)
+		assert.NoError(t, err)
+		assert.NoError(t, form.Close())
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/products/"+productID+"/images", &body)
+		req.Header.Set(echo.HeaderContentType, form.FormDataContentType())
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+		return rec
+	}
+
+	// Act
+	uploaded := upload("product-123", photo.Bytes())
+	var img media.Image
+	assert.NoError(t, json.Unmarshal(uploaded.Body.Bytes(), &img))
+	thumbnail := serve(http.MethodGet, img.ThumbnailURL)
+	fetched := serve(http.MethodGet, "/v1/products/product-123")
+	fetchedV2 := serve(http.MethodGet, "/v2/products/product-123")
+	listed := serve(http.MethodGet, "/v1/products/product-123/images")
+	notAnImage := upload("product-123", []byte("name,price\n"))
+	unknownProduct := upload("product-missing", photo.Bytes())
+	deleted := serve(http.MethodDelete, "/v1/products/product-123/images/"+img.ImageID)
+	afterDelete := serve(http.MethodGet, "/v1/products/product-123")
+	deletedAgain := serve(http.MethodDelete, "/v1/products/product-123/images/"+img.ImageID)
+
+	// Assert
+	assert.Equal(t, http.StatusCreated, uploaded.Code, uploaded.Body.String())
+	assert.Equal(t, "image/png", img.ContentType)
+	assert.Equal(t, img.URL, uploaded.Header().Get(echo.HeaderLocation))
+	assert.Equal(t, http.StatusOK, thumbnail.Code)
+	config, _, err := image.DecodeConfig(thumbnail.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, 256, config.Width)
+	assert.Equal(t, 128, config.Height)
+
+	var response product.ProductResponse
+	assert.NoError(t, json.Unmarshal(fetched.Body.Bytes(), &response))
+	if assert.Len(t, response.Images, 1) {
+		assert.Equal(t, img.URL, response.Images[0].URL)
+		assert.Equal(t, img.ThumbnailURL, response.Images[0].ThumbnailURL)
+	}
+	assert.Contains(t, fetchedV2.Body.String(), `"images":[{"imageId":"`+img.ImageID)
+	assert.Contains(t, listed.Body.String(), `"count":1`)
+
+	assert.Equal(t, http.StatusBadRequest, notAnImage.Code)
+	assert.Equal(t, http.StatusNotFound, unknownProduct.Code)
+	assert.Equal(t, http.StatusNoContent, deleted.Code)
+	assert.NotContains(t, afterDelete.Body.String(), `"images"`)
+	assert.Equal(t, http.StatusNotFound, deletedAgain.Code)
+}
+
+func TestCustomerHistoryEndpoint(t *testing.T) {
+	// Arrange
+	eventSourced, err := customer.NewEventSourcedRepository(customer.NewInMemoryRepository(), customer.NewInMemoryEventStore(), 0)
+	assert.NoError(t, err)
+	recorded := echo.New()
+	recorded.Validator = validation.New()
+	recordedHandler := customer.NewHandler(customer.NewService(eventSourced, customer.WithHistory(eventSourced)))
+	recorded.POST("/v1/customers/:id/transitions", recordedHandler.TransitionCustomer)
+	recorded.GET("/v1/customers/:id/history", recordedHandler.GetCustomerHistory)
+	serve := func(e *echo.Echo, method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+	beforeSuspension := time.Now().UTC().Format(time.RFC3339Nano)
+
+	// Act
+	suspended := serve(recorded, http.MethodPost, "/v1/customers/customer-456/transitions", `{"status": "SUSPENDED"}`)
+	history := serve(recorded, http.MethodGet, "/v1/customers/customer-456/history", "")
+	earlier := serve(recorded, http.MethodGet, "/v1/customers/customer-456/history?until="+beforeSuspension, "")
+	malformed := serve(recorded, http.MethodGet, "/v1/customers/customer-456/history?until=yesterday", "")
+	notRecorded := serve(setupTestApp(), http.MethodGet, "/v1/customers/customer-456/history", "")
+
+	// Assert
+	assert.Equal(t, http.StatusOK, suspended.Code, suspended.Body.String())
+	assert.Equal(t, http.StatusOK, history.Code, history.Body.String())
+	var body struct {
+		Events   []customer.Event          `json:"events"`
+		Count    int                       `json:"count"`
+		Customer customer.CustomerResponse `json:"customer"`
+	}
+	assert.NoError(t, json.Unmarshal(history.Body.Bytes(), &body))
+	if assert.Equal(t, 2, body.Count) {
+		assert.Equal(t, customer.EventCustomerStatusChanged, body.Events[1].Type)
+		assert.Equal(t, "ACTIVE", body.Events[1].Data.From)
+	}
+	assert.Equal(t, "SUSPENDED", body.Customer.Status)
+	assert.Equal(t, http.StatusOK, earlier.Code, earlier.Body.String())
+	assert.Contains(t, earlier.Body.String(), `"count":1`)
+	assert.Contains(t, earlier.Body.String(), `"status":"ACTIVE"`)
+	assert.Equal(t, http.StatusBadRequest, malformed.Code)
+	assert.Equal(t, http.StatusNotImplemented, notRecorded.Code)
+}
+
+func TestPointInTimeReads(t *testing.T) {
+	// Arrange
+	eventSourced, err := customer.NewEventSourcedRepository(customer.NewInMemoryRepository(), customer.NewInMemoryEventStore(), 0)
+	assert.NoError(t, err)
+	recorded := echo.New()
+	recordedHandler := customer.NewHandler(customer.NewService(eventSourced, customer.WithHistory(eventSourced)))
+	recorded.POST("/v1/customers/:id/transitions", recordedHandler.TransitionCustomer)
+	recorded.GET("/v1/customers/:id", recordedHandler.GetCustomer)
+	e := setupTestApp()
+	serve := func(e *echo.Echo, method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+	beforeChanges := time.Now().UTC().Format(time.RFC3339Nano)
+	serve(recorded, http.MethodPost, "/v1/customers/customer-456/transitions", `{"status": "SUSPENDED"}`)
+	serve(e, http.MethodPatch, "/v1/products/product-789", `{"price": 49.99}`)
+	future := time.Now().UTC().Add(time.Hour).Format(time.RFC3339)
+
+	// Act
+	customerThen := serve(recorded, http.MethodGet, "/v1/customers/customer-456?asOf="+beforeChanges, "")
+	customerNow := serve(recorded, http.MethodGet, "/v1/customers/customer-456", "")
+	notRecorded := serve(e, http.MethodGet, "/v1/customers/customer-456?asOf="+beforeChanges, "")
+	productThen := serve(e, http.MethodGet, "/v1/products/product-789?asOf="+beforeChanges, "")
+	productNow := serve(e, http.MethodGet, "/v1/products/product-789", "")
+	futureAsOf := serve(e, http.MethodGet, "/v1/products/product-789?asOf="+future, "")
+	combined := serve(e, http.MethodGet, "/v1/products/product-789?asOf="+beforeChanges+"&at="+future, "")
+
+	// Assert
+	assert.Equal(t, http.StatusOK, customerThen.Code, customerThen.Body.String())
+	assert.Contains(t, customerThen.Body.String(), `"status":"ACTIVE"`)
+	assert.Contains(t, customerNow.Body.String(), `"status":"SUSPENDED"`)
+	assert.Equal(t, http.StatusNotImplemented, notRecorded.Code)
+	assert.Contains(t, notRecorded.Body.String(), "asOf requires event sourcing")
+
+	assert.Equal(t, http.StatusOK, productThen.Code, productThen.Body.String())
+	var then, now product.ProductResponse
+	assert.NoError(t, json.Unmarshal(productThen.Body.Bytes(), &then))
+	assert.NoError(t, json.Unmarshal(productNow.Body.Bytes(), &now))
+	assert.NotEqual(t, 49.99, then.Price)
+	assert.Equal(t, 49.99, now.Price)
+	assert.Equal(t, http.StatusBadRequest, futureAsOf.Code)
+	assert.Equal(t, http.StatusBadRequest, combined.Code)
+}
+
+func TestCustomerCreditEndpoints(t *testing.T) {
+	// Arrange
+	e := setupTestApp()
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Act
+	check := serve(http.MethodGet, "/v1/customers/customer-123/credit-check?amount=800", "")
+	reserved := serve(http.MethodPost, "/v1/customers/customer-123/credit/reserve", `{"amount": 700}`)
+	exceeded := serve(http.MethodPost, "/v1/customers/customer-123/credit/reserve", `{"amount": 100}`)
+	released := serve(http.MethodPost, "/v1/customers/customer-123/credit/release", `{"amount": 950}`)
+	belowZero := serve(http.MethodPost, "/v1/customers/customer-123/credit/release", `{"amount": 1}`)
+	missingAmount := serve(http.MethodGet, "/v1/customers/customer-123/credit-check", "")
+	zeroAmount := serve(http.MethodPost, "/v1/customers/customer-123/credit/reserve", `{"amount": 0}`)
+	missing := serve(http.MethodGet, "/v1/customers/customer-missing/credit-check?amount=1", "")
+
+	// Assert
+	assert.Equal(t, http.StatusOK, check.Code, check.Body.String())
+	assert.Contains(t, check.Body.String(), `"availableCredit":750`)
+	assert.Contains(t, check.Body.String(), `"approved":false`)
+	assert.Equal(t, http.StatusOK, reserved.Code, reserved.Body.String())
+	assert.Contains(t, reserved.Body.String(), `"currentExposure":950`)
+	assert.Equal(t, http.StatusConflict, exceeded.Code)
+	assert.Contains(t, exceeded.Body.String(), "credit limit exceeded")
+	assert.Equal(t, http.StatusOK, released.Code, released.Body.String())
+	assert.Contains(t, released.Body.String(), `"currentExposure":0`)
+	assert.Equal(t, http.StatusConflict, belowZero.Code)
+	assert.Equal(t, http.StatusBadRequest, missingAmount.Code)
+	assert.Equal(t, http.StatusBadRequest, zeroAmount.Code)
+	assert.Equal(t, http.StatusNotFound, missing.Code)
+}
+
+func TestCustomerEmailEndpoints(t *testing.T) {
+	// Arrange
+	e := setupTestApp()
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Act
+	created := serve(http.MethodPost, "/v1/customers", `{"name": "Dana Scully", "status": "ACTIVE", "email": "Dana.Scully@example.com", "phone": "+1 202 555 0199"}`)
+	duplicate := serve(http.MethodPost, "/v1/customers", `{"name": "Dana Copy", "status": "ACTIVE", "email": "dana.scully@example.com"}`)
+	invalidPhone := serve(http.MethodPost, "/v1/customers", `{"name": "Dana Copy", "status": "ACTIVE", "phone": "555-0199"}`)
+	taken := serve(http.MethodPatch, "/v1/customers/customer-123", `{"email": "jane.doe@example.com"}`)
+	found := serve(http.MethodGet, "/v1/customers/by-email/dana.scully%40example.com", "")
+	missing := serve(http.MethodGet, "/v1/customers/by-email/nobody@example.com", "")
+	malformed := serve(http.MethodGet, "/v1/customers/by-email/nobody", "")
+
+	// Assert
+	assert.Equal(t, http.StatusCreated, created.Code, created.Body.String())
+	assert.Contains(t, created.Body.String(), `"email":"dana.scully@example.com"`)
+	assert.Contains(t, created.Body.String(), `"phone":"+12025550199"`)
+	assert.Equal(t, http.StatusConflict, duplicate.Code)
+	assert.Equal(t, http.StatusBadRequest, invalidPhone.Code)
+	assert.Equal(t, http.StatusConflict, taken.Code)
+	assert.Equal(t, http.StatusOK, found.Code, found.Body.String())
+	assert.Contains(t, found.Body.String(), `"name":"Dana Scully"`)
+	assert.Equal(t, http.StatusNotFound, missing.Code)
+	assert.Equal(t, http.StatusBadRequest, malformed.Code)
+}
+
+func TestAddressEndpoints(t *testing.T) {
+	// Arrange
+	e := setupTestApp()
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+	shipping := `{"type": "shipping", "line1": "12 Rue de la Paix", "city": "Paris", "postalCode": "75002", "country": "fr"}`
+
+	// Act
+	created := serve(http.MethodPost, "/v1/customers/customer-456/addresses", shipping)
+	var home customer.Address
+	assert.NoError(t, json.Unmarshal(created.Body.Bytes(), &home))
+	invalid := serve(http.MethodPost, "/v1/customers/customer-456/addresses", `{"type": "pickup", "line1": "x", "city": "y", "postalCode": "1", "country": "FRA"}`)
+	unknownCustomer := serve(http.MethodPost, "/v1/customers/customer-missing/addresses", shipping)
+	updated := serve(http.MethodPut, "/v1/customers/customer-456/addresses/"+home.AddressID, strings.Replace(shipping, "75002", "75008", 1))
+	enriched := serve(http.MethodPost, "/v1/enrich", `{"customerId": "customer-456", "items": [{"productId": "product-123", "quantity": 1}]}`)
+	deleted := serve(http.MethodDelete, "/v1/customers/customer-456/addresses/"+home.AddressID, "")
+	list := serve(http.MethodGet, "/v1/customers/customer-456/addresses", "")
+	missing := serve(http.MethodGet, "/v1/customers/customer-456/addresses/"+home.AddressID, "")
+
+	// Assert
+	assert.Equal(t, http.StatusCreated, created.Code, created.Body.String())
+	assert.True(t, home.IsDefault)
+	assert.Equal(t, "FR", home.Country)
+	assert.Equal(t, http.StatusBadRequest, invalid.Code)
+	assert.Contains(t, invalid.Body.String(), `"fields"`)
+	assert.Equal(t, http.StatusNotFound, unknownCustomer.Code)
+	assert.Equal(t, http.StatusOK, updated.Code, updated.Body.String())
+	assert.Contains(t, updated.Body.String(), `"postalCode":"75008"`)
+	assert.Equal(t, http.StatusOK, enriched.Code, enriched.Body.String())
+	assert.Contains(t, enriched.Body.String(), `"shippingAddress":{"addressId":"`+home.AddressID)
+	assert.Equal(t, http.StatusNoContent, deleted.Code)
+	assert.Contains(t, list.Body.String(), `"count":0`)
+	assert.Equal(t, http.StatusNotFound, missing.Code)
+}
+
+func TestReserveAndReleaseEndpoints(t *testing.T) {
+	// Arrange
+	e := setupTestApp()
+	serve := func(path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Act
+	reserved := serve("/v1/products/product-456/reserve", `{"quantity": 8}`)
+	insufficient := serve("/v1/products/product-456/reserve", `{"quantity": 1}`)
+	released := serve("/v1/products/product-456/release", `{"quantity": 2}`)
+	invalid := serve("/v1/products/product-456/reserve", `{"quantity": 0}`)
+	missing := serve("/v1/products/product-missing/release", `{"quantity": 1}`)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, reserved.Code, reserved.Body.String())
+	assert.Contains(t, reserved.Body.String(), `"quantity":0`)
+	assert.Contains(t, reserved.Body.String(), `"inStock":false`)
+	assert.Equal(t, http.StatusConflict, insufficient.Code)
+	assert.Equal(t, http.StatusOK, released.Code)
+	assert.Contains(t, released.Body.String(), `"quantity":2`)
+	assert.Equal(t, http.StatusBadRequest, invalid.Code)
+	assert.Equal(t, http.StatusNotFound, missing.Code)
+}
+
+func TestStockMovementsEndpoint(t *testing.T) {
+	// Arrange
+	e := setupTestApp()
+	for _, body := range []string{`{"quantity": 3, "reason": "order-42"}`, `{"quantity": 1}`} {
+		req := httptest.NewRequest(http.MethodPost, "/v1/products/product-456/reserve", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		e.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	serve := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	// Act
+	first := serve("/v1/products/product-456/stock-movements?limit=1")
+	missing := serve("/v1/products/product-missing/stock-movements")
+	invalid := serve("/v1/products/product-456/stock-movements?limit=0")
+
+	// Assert
+	assert.Equal(t, http.StatusOK, first.Code, first.Body.String())
+	var response struct {
+		Movements  []product.StockMovement `json:"movements"`
+		Pagination pagination.Meta         `json:"pagination"`
+	}
+	assert.NoError(t, json.Unmarshal(first.Body.Bytes(), &response))
+	if assert.Len(t, response.Movements, 1) {
+		assert.Equal(t, -1, response.Movements[0].Delta)
+		assert.Equal(t, 4, response.Movements[0].Quantity)
+		assert.Equal(t, product.ReasonReserve, response.Movements[0].Reason)
+	}
+	assert.Equal(t, 2, response.Pagination.Total)
+	assert.NotEmpty(t, response.Pagination.Next)
+	assert.Equal(t, http.StatusNotFound, missing.Code)
+	assert.Equal(t, http.StatusBadRequest, invalid.Code)
+}
+
+func TestSoftDeleteAndRestoreEndpoints(t *testing.T) {
+	// Arrange
+	e := setupTestApp()
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+		return rec
+	}
+
+	// Act
+	deleted := serve(http.MethodDelete, "/v1/customers/customer-456")
+	hidden := serve(http.MethodGet, "/v1/customers/customer-456")
+	included := serve(http.MethodGet, "/v1/customers/customer-456?includeDeleted=true")
+	restored := serve(http.MethodPost, "/v1/customers/customer-456/restore")
+	conflict := serve(http.MethodPost, "/v1/customers/customer-456/restore")
+	invalid := serve(http.MethodGet, "/v1/products?includeDeleted=maybe")
+
+	// Assert
+	assert.Equal(t, http.StatusNoContent, deleted.Code)
+	assert.Equal(t, http.StatusNotFound, hidden.Code)
+	assert.Equal(t, http.StatusOK, included.Code)
+	assert.Contains(t, included.Body.String(), `"deletedAt"`)
+	assert.Equal(t, http.StatusOK, restored.Code, restored.Body.String())
+	assert.NotContains(t, restored.Body.String(), `"deletedAt"`)
+	assert.Equal(t, http.StatusConflict, conflict.Code)
+	assert.Equal(t, http.StatusBadRequest, invalid.Code)
+}
+
+func TestIncludeDeleted_RequiresAdmin(t *testing.T) {
+	// Arrange
+	e := setupTestAppWithAuth(newRouteAuth(config.AuthConfig{
+		RBAC: true,
+		APIKeys: []config.APIKeyConfig{
+			{Name: "dashboard", Role: "reader", Key: "reader-key"},
+			{Name: "ops", Role: "admin", Key: "admin-key"},
+		},
+	}), nil, nil)
+	get := func(path, apiKey string) int {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set(auth.APIKeyHeader, apiKey)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// Act
+	reader := get("/v1/products", "reader-key")
+	readerFalse := get("/v1/products?includeDeleted=false", "reader-key")
+	readerList := get("/v1/products?includeDeleted=true", "reader-key")
+	readerGet := get("/v1/customers/customer-456?includeDeleted=true", "reader-key")
+	admin := get("/v1/products?includeDeleted=true", "admin-key")
+
+	// Assert
+	assert.Equal(t, http.StatusOK, reader)
+	assert.Equal(t, http.StatusOK, readerFalse)
+	assert.Equal(t, http.StatusForbidden, readerList)
+	assert.Equal(t, http.StatusForbidden, readerGet)
+	assert.Equal(t, http.StatusOK, admin)
+}
+
+func TestCreateProduct_RecordsAuditFields(t *testing.T) {
+	// Arrange
+	e := setupTestAppWithAuth(newRouteAuth(config.AuthConfig{
+		RBAC:    true,
+		APIKeys: []config.APIKeyConfig{{Name: "catalog-sync", Role: "operator", Key: "operator-key"}},
+	}), nil, nil)
+	body := `{"name":"Audit Lamp","description":"Lamp used to test audit fields","price":30,"category":"Electronics"}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/products", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set(auth.APIKeyHeader, "operator-key")
+	rec := httptest.NewRecorder()
+
+	// Act
+	e.ServeHTTP(rec, req)
+
+	// Assert
+	assert.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	var response product.ProductResponse
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, "catalog-sync", response.CreatedBy)
+	assert.Equal(t, "catalog-sync", response.UpdatedBy)
+	assert.False(t, response.CreatedAt.IsZero())
+	assert.Equal(t, response.CreatedAt, response.UpdatedAt)
+}
+
+func TestOpenBreaker_Returns503(t *testing.T) {
+	// Arrange
+	storage := breaker.New("postgres", breaker.Settings{FailureThreshold: 1})
+	_ = storage.Execute(func() error { return errors.New("connection refused") })
+
+	e := echo.New()
+	productService := product.NewService(product.NewBreakerRepository(product.NewInMemoryRepository(), storage))
+	productHandler := product.NewHandler(productService)
+	e.GET("/v1/products/:id", productHandler.GetProduct)
+	e.GET("/health/dependencies", breaker.DependenciesHandler(storage))
+	serve := func(path string) int {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec.Code
+	}
+
+	// Act
+	productStatus := serve("/v1/products/product-789")
+	healthStatus := serve("/health/dependencies")
+
+	// Assert
+	assert.Equal(t, http.StatusServiceUnavailable, productStatus)
+	assert.Equal(t, http.StatusServiceUnavailable, healthStatus)
+}
+
+func TestMetricsHandler(t *testing.T) {
+	// Arrange
+	storage := breaker.New("postgres", breaker.Settings{FailureThreshold: 1})
+	bulkheads := newBulkheads(config.BulkheadConfig{Enabled: true, Groups: map[string]int{"/v1/products": 2, "/v1/customers": 4}})
+	bulkheads[1].Acquire(context.Background())
+	e := echo.New()
+	retrier := newStorageRetrier(config.Default().Storage)
+	e.GET("/metrics", metricsHandler([]*breaker.Breaker{storage}, []*retry.Retrier{retrier}, bulkheads))
+	rec := httptest.NewRecorder()
+
+	// Act
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	// Assert
+	assert.Equal(t, breaker.MIMEMetrics, rec.Header().Get(echo.HeaderContentType))
+	assert.Contains(t, rec.Body.String(), `circuit_breaker_state{name="postgres"} 0`)
+	assert.Contains(t, rec.Body.String(), `storage_retries_total{name="memory"} 0`)
+	assert.Contains(t, rec.Body.String(), `bulkhead_saturation{name="/v1/products"} 0.5`)
+	assert.Contains(t, rec.Body.String(), `bulkhead_saturation{name="/v1/customers"} 0`)
+}
+
+func TestOrderEndpoints(t *testing.T) {
+	// Arrange
+	e := setupTestApp()
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Act
+	created := serve(http.MethodPost, "/v1/orders", `{"customerId": "customer-456", "items": [{"productId": "product-123", "quantity": 2}]}`)
+	var placed order.Order
+	assert.NoError(t, json.Unmarshal(created.Body.Bytes(), &placed))
+	failed := serve(http.MethodPost, "/v1/orders", `{"customerId": "customer-456", "items": [{"productId": "product-missing", "quantity": 1}]}`)
+	invalid := serve(http.MethodPost, "/v1/orders", `{"customerId": "customer-456", "items": [{"productId": "product-123", "quantity": 0}]}`)
+	fetched := serve(http.MethodGet, "/v1/orders/"+placed.OrderID, "")
+	listed := serve(http.MethodGet, "/v1/orders?status=FAILED", "")
+	badStatus := serve(http.MethodGet, "/v1/orders?status=SHIPPED", "")
+	replaced := serve(http.MethodPut, "/v1/orders/"+placed.OrderID, `{"customerId": "customer-456", "items": [{"productId": "product-101", "quantity": 1}]}`)
+	reenriched := serve(http.MethodPost, "/v1/orders/"+placed.OrderID+"/enrich", "")
+	deleted := serve(http.MethodDelete, "/v1/orders/"+placed.OrderID, "")
+	missing := serve(http.MethodGet, "/v1/orders/"+placed.OrderID, "")
+
+	// Assert
+	assert.Equal(t, http.StatusCreated, created.Code, created.Body.String())
+	assert.Equal(t, order.StatusEnriched, placed.Status)
+	if assert.NotNil(t, placed.Enrichment) {
+		assert.Equal(t, 51.98, placed.Enrichment.Total)
+	}
+	assert.Equal(t, http.StatusCreated, failed.Code)
+	assert.Contains(t, failed.Body.String(), `"status":"FAILED"`)
+	assert.Contains(t, failed.Body.String(), `"failureReason":"product not found: product-missing"`)
+	assert.Equal(t, http.StatusBadRequest, invalid.Code)
+	assert.Contains(t, invalid.Body.String(), "items[0].quantity")
+	assert.Equal(t, http.StatusOK, fetched.Code)
+	assert.Contains(t, listed.Body.String(), `"count":1`)
+	assert.Equal(t, http.StatusBadRequest, badStatus.Code)
+	assert.Equal(t, http.StatusOK, replaced.Code)
+	assert.Contains(t, replaced.Body.String(), `"productId":"product-101"`)
+	assert.Equal(t, http.StatusOK, reenriched.Code)
+	assert.Equal(t, http.StatusNoContent, deleted.Code)
+	assert.Equal(t, http.StatusNotFound, missing.Code)
+}
+
+func TestEnrichmentJobEndpoints(t *testing.T) {
+	// Arrange
+	e := setupTestApp()
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Act
+	submitted := serve(http.MethodPost, "/v1/enrichment-jobs", `{"orders": [
+		{"customerId": "customer-456", "items": [{"productId": "product-123", "quantity": 2}]},
+		{"customerId": "customer-456", "items": [{"productId": "product-missing", "quantity": 1}]}
+	]}`)
+	var queued jobs.Job
+	assert.NoError(t, json.Unmarshal(submitted.Body.Bytes(), &queued))
+	var job jobs.Job
+	for deadline := time.Now().Add(5 * time.Second); !job.Done() && time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		fetched := serve(http.MethodGet, "/v1/enrichment-jobs/"+queued.JobID, "")
+		assert.Equal(t, http.StatusOK, fetched.Code)
+		assert.NoError(t, json.Unmarshal(fetched.Body.Bytes(), &job))
+	}
+	empty := serve(http.MethodPost, "/v1/enrichment-jobs", `{"orders": []}`)
+	missing := serve(http.MethodGet, "/v1/enrichment-jobs/job-missing", "")
+
+	// Assert
+	assert.Equal(t, http.StatusAccepted, submitted.Code, submitted.Body.String())
+	assert.Equal(t, "/v1/enrichment-jobs/"+queued.JobID, submitted.Header().Get(echo.HeaderLocation))
+	assert.Equal(t, jobs.StatusCompleted, job.Status)
+	assert.Equal(t, 1, job.Succeeded)
+	if assert.Len(t, job.Items, 2) {
+		assert.Equal(t, 51.98, job.Items[0].Result.Total)
+		assert.Equal(t, "product not found: product-missing", job.Items[1].Error)
+	}
+	assert.Equal(t, http.StatusBadRequest, empty.Code)
+	assert.Equal(t, http.StatusNotFound, missing.Code)
+}
+
+func TestExportProductsEndpoint_CSV(t *testing.T) {
+	// Arrange
+	e := setupTestApp()
+	req := httptest.NewRequest(http.MethodGet, "/v1/products/export?category=Electronics&sort=productId&fields=productId,name", nil)
+	rec := httptest.NewRecorder()
+
+	// Act
+	e.ServeHTTP(rec, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", rec.Header().Get(echo.HeaderContentType))
+	assert.Equal(t, `attachment; filename="products.csv"`, rec.Header().Get(echo.HeaderContentDisposition))
+
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	assert.Equal(t, "productId,name", lines[0])
+	assert.Len(t, lines, 4)
+}
+
+func TestExportCustomersEndpoint_NDJSON(t *testing.T) {
+	// Arrange
+	e := setupTestApp()
+	req := httptest.NewRequest(http.MethodGet, "/v1/customers/export?segment=vip", nil)
+	req.Header.Set(echo.HeaderAccept, "application/x-ndjson")
+	rec := httptest.NewRecorder()
+
+	// Act
+	e.ServeHTTP(rec, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/x-ndjson; charset=utf-8", rec.Header().Get(echo.HeaderContentType))
+
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	for _, line := range lines {
+		var response customer.CustomerResponse
+		assert.NoError(t, json.Unmarshal([]byte(line), &response))
+		assert.Contains(t, response.Segments, "vip")
+	}
+}
+
+func TestExportEndpoints_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		accept string
+		status int
+	}{
+		{name: "unsupported format", target: "/v1/products/export", accept: echo.MIMEApplicationJSON, status: http.StatusNotAcceptable},
+		{name: "invalid product filter", target: "/v1/products/export?minPrice=abc", status: http.StatusBadRequest},
+		{name: "invalid customer filter", target: "/v1/customers/export?status=UNKNOWN", status: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			e := setupTestApp()
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.accept != "" {
+				req.Header.Set(echo.HeaderAccept, tt.accept)
+			}
+			rec := httptest.NewRecorder()
+
+			// Act
+			e.ServeHTTP(rec, req)
+
+			// Assert
+			assert.Equal(t, tt.status, rec.Code)
+			assert.Equal(t, problem.MIMEApplicationProblemJSON, rec.Header().Get(echo.HeaderContentType))
+		})
+	}
+}
+
+func TestProductImportEndpoints(t *testing.T) {
+	// Arrange
+	e := setupTestApp()
+	catalog := "Product Name,Description,Cost,Category,Quantity\n" +
+		"Desk Lamp,Adjustable LED desk lamp,34.99,Electronics,12\n" +
+		"X,Name too short for a product,5,Electronics,1\n"
+	upload := func(fields map[string]string, file string) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		for _, name := range []string{"mapping", "async"} {
+			if value, ok := fields[name]; ok {
+				assert.NoError(t, form.WriteField(name, value))
+			}
+		}
+		part, err := form.CreateFormFile("file", "catalog.csv")
+		assert.NoError(t, err)
+		_, err = part.Write([]byte(file))
+		assert.NoError(t, err)
+		assert.NoError(t, form.Close())
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/products/import", &body)
+		req.Header.Set(echo.HeaderContentType, form.FormDataContentType())
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+	mapping := `{"Product Name": "name", "Cost": "price"}`
+
+	// Act
+	imported := upload(map[string]string{"mapping": mapping}, catalog)
+	submitted := upload(map[string]string{"mapping": mapping, "async": "true"}, catalog)
+	var queued productimport.Import
+	assert.NoError(t, json.Unmarshal(submitted.Body.Bytes(), &queued))
+	var imp productimport.Import
+	for deadline := time.Now().Add(5 * time.Second); !imp.Done() && time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		fetched := get("/v1/product-imports/" + queued.ImportID)
+		assert.Equal(t, http.StatusOK, fetched.Code)
+		assert.NoError(t, json.Unmarshal(fetched.Body.Bytes(), &imp))
+	}
+	unmapped := upload(nil, catalog)
+	missing := get("/v1/product-imports/import-missing")
+
+	// Assert
+	assert.Equal(t, http.StatusOK, imported.Code, imported.Body.String())
+	var report productimport.Report
+	assert.NoError(t, json.Unmarshal(imported.Body.Bytes(), &report))
+	assert.Equal(t, 1, report.Created)
+	if assert.Len(t, report.Errors, 1) {
+		assert.Equal(t, 3, report.Errors[0].Line)
+		assert.Equal(t, "name", report.Errors[0].Fields[0].Field)
+	}
+
+	assert.Equal(t, http.StatusAccepted, submitted.Code, submitted.Body.String())
+	assert.Equal(t, "/v1/product-imports/"+queued.ImportID, submitted.Header().Get(echo.HeaderLocation))
+	assert.Equal(t, productimport.StatusCompleted, imp.Status)
+	assert.Equal(t, 1, imp.Report.Created)
+	assert.Equal(t, 1, imp.Report.Failed)
+
+	assert.Equal(t, http.StatusBadRequest, unmapped.Code)
+	assert.Contains(t, unmapped.Body.String(), "no column fills name, price")
+	assert.Equal(t, http.StatusNotFound, missing.Code)
+}
+
+func TestDeadLetterEndpoints(t *testing.T) {
+	// Arrange
+	e := setupTestApp()
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+	created := serve(http.MethodPost, "/v1/orders", `{"customerId": "customer-456", "items": [{"productId": "product-missing", "quantity": 1}]}`)
+	var failed order.Order
+	assert.NoError(t, json.Unmarshal(created.Body.Bytes(), &failed))
+
+	// Act
+	listed := serve(http.MethodGet, "/v1/dlq?source=order", "")
+	var list struct {
+		Entries []dlq.Entry `json:"entries"`
+	}
+	assert.NoError(t, json.Unmarshal(listed.Body.Bytes(), &list))
+	if !assert.Len(t, list.Entries, 1) {
+		return
+	}
+	entryID := list.Entries[0].EntryID
+	retried := serve(http.MethodPost, "/v1/dlq/"+entryID+"/retry", "")
+	serve(http.MethodPut, "/v1/orders/"+failed.OrderID, `{"customerId": "customer-456", "items": [{"productId": "product-123", "quantity": 1}]}`)
+	fetched := serve(http.MethodGet, "/v1/dlq/"+entryID, "")
+	retriedResolved := serve(http.MethodPost, "/v1/dlq/"+entryID+"/retry", "")
+	openAfter := serve(http.MethodGet, "/v1/dlq", "")
+	badStatus := serve(http.MethodGet, "/v1/dlq?status=CLOSED", "")
+	missing := serve(http.MethodGet, "/v1/dlq/dlq-missing", "")
+
+	// Assert
+	assert.Equal(t, http.StatusOK, listed.Code)
+	assert.Equal(t, failed.OrderID, list.Entries[0].SourceID)
+	assert.Equal(t, "product not found: product-missing", list.Entries[0].FailureReason)
+	assert.Equal(t, http.StatusOK, retried.Code)
+	assert.Contains(t, retried.Body.String(), `"retryCount":1`)
+	assert.Contains(t, fetched.Body.String(), `"status":"RESOLVED"`)
+	assert.Equal(t, http.StatusConflict, retriedResolved.Code)
+	assert.Contains(t, openAfter.Body.String(), `"count":0`)
+	assert.Equal(t, http.StatusBadRequest, badStatus.Code)
+	assert.Equal(t, http.StatusNotFound, missing.Code)
+}
+
+func TestWebhookEndpoints(t *testing.T) {
+	// Arrange
+	e := setupTestApp()
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Act
+	created := serve(http.MethodPost, "/v1/webhooks", `{"url": "https://pricing.example.com/hooks", "events": ["product.stock_changed"]}`)
+	var subscription webhook.Subscription
+	assert.NoError(t, json.Unmarshal(created.Body.Bytes(), &subscription))
+	invalidURL := serve(http.MethodPost, "/v1/webhooks", `{"url": "pricing", "events": ["product.stock_changed"]}`)
+	unknownEvent := serve(http.MethodPost, "/v1/webhooks", `{"url": "https://pricing.example.com/hooks", "events": ["order.shipped"]}`)
+	fetched := serve(http.MethodGet, "/v1/webhooks/"+subscription.SubscriptionID, "")
+	listed := serve(http.MethodGet, "/v1/webhooks?event=product.stock_changed", "")
+	replaced := serve(http.MethodPut, "/v1/webhooks/"+subscription.SubscriptionID,
+		`{"url": "https://pricing.example.com/v2/hooks", "events": ["product.updated"], "active": false}`)
+	deliveries := serve(http.MethodGet, "/v1/webhooks/"+subscription.SubscriptionID+"/deliveries", "")
+	badStatus := serve(http.MethodGet, "/v1/webhooks/"+subscription.SubscriptionID+"/deliveries?status=SENT", "")
+	deleted := serve(http.MethodDelete, "/v1/webhooks/"+subscription.SubscriptionID, "")
+	missing := serve(http.MethodGet, "/v1/webhooks/"+subscription.SubscriptionID+"/deliveries", "")
+
+	// Assert
+	assert.Equal(t, http.StatusCreated, created.Code, created.Body.String())
+	assert.NotEmpty(t, subscription.Secret)
+	assert.True(t, subscription.Active)
+	assert.Equal(t, http.StatusBadRequest, invalidURL.Code)
+	assert.Contains(t, invalidURL.Body.String(), "must be an http or https URL")
+	assert.Equal(t, http.StatusBadRequest, unknownEvent.Code)
+	assert.Contains(t, unknownEvent.Body.String(), "events[0] must be one of")
+	assert.Equal(t, http.StatusOK, fetched.Code)
+	assert.NotContains(t, fetched.Body.String(), "secret")
+	assert.Contains(t, listed.Body.String(), `"count":1`)
+	assert.Equal(t, http.StatusOK, replaced.Code)
+	assert.Contains(t, replaced.Body.String(), `"active":false`)
+	assert.Equal(t, http.StatusOK, deliveries.Code)
+	assert.Contains(t, deliveries.Body.String(), `"count":0`)
+	assert.Equal(t, http.StatusBadRequest, badStatus.Code)
+	assert.Equal(t, http.StatusNoContent, deleted.Code)
+	assert.Equal(t, http.StatusNotFound, missing.Code)
+}
+
+func TestInventoryStream(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(setupTestApp())
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/v1/ws/inventory"
+	get := func(path string) *http.Response {
+		resp, err := http.Get(server.URL + path)
+		assert.NoError(t, err)
+		resp.Body.Close()
+		return resp
+	}
+
+	// Act
+	ws, err := websocket.Dial(wsURL+"?productIds=product-456,product-123", "", server.URL)
+	if err != nil {
+		t.Fatalf("Expected the connection to upgrade, got %v", err)
+	}
+	defer ws.Close()
+	ws.SetDeadline(time.Now().Add(5 * time.Second))
+	var snapshot [2]inventory.Message
+	assert.NoError(t, websocket.JSON.Receive(ws, &snapshot[0]))
+	assert.NoError(t, websocket.JSON.Receive(ws, &snapshot[1]))
+	reserved, err := http.Post(server.URL+"/v1/products/product-456/reserve", echo.MIMEApplicationJSON, strings.NewReader(`{"quantity": 8}`))
+	assert.NoError(t, err)
+	reserved.Body.Close()
+	var changed inventory.Message
+	receiveErr := websocket.JSON.Receive(ws, &changed)
+	plain := get("/v1/ws/inventory?productIds=product-456")
+
+	// Assert
+	assert.Equal(t, inventory.MessageSnapshot, snapshot[0].Type)
+	assert.Equal(t, "product-456", snapshot[0].ProductID)
+	assert.Equal(t, 8, snapshot[0].Quantity)
+	assert.Equal(t, "product-123", snapshot[1].ProductID)
+	assert.Equal(t, http.StatusOK, reserved.StatusCode)
+	assert.NoError(t, receiveErr)
+	assert.Equal(t, inventory.MessageStockChanged, changed.Type)
+	assert.Equal(t, "product-456", changed.ProductID)
+	assert.Equal(t, 0, changed.Quantity)
+	assert.False(t, changed.InStock)
+	assert.Equal(t, http.StatusUpgradeRequired, plain.StatusCode)
+	for _, query := range []string{"", "?productIds=product-missing"} {
+		_, err := websocket.Dial(wsURL+query, "", server.URL)
+		assert.Error(t, err, "Expected %q to be refused", query)
+	}
+}
+
+func TestRunMigrate_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.StorageConfig
+		args    []string
+		wantErr string
+	}{
+		{name: "unknown command", cfg: config.StorageConfig{Backend: config.StoragePostgres}, args: []string{"sideways"}, wantErr: "usage"},
+		{name: "extra arguments", cfg: config.StorageConfig{Backend: config.StoragePostgres}, args: []string{"up", "3"}, wantErr: "usage"},
+		{name: "memory backend", cfg: config.StorageConfig{Backend: config.StorageMemory}, wantErr: "storage backend"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			err := runMigrate(context.Background(), tt.cfg, tt.args, io.Discard)
+
+			// Assert
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestRunReencrypt(t *testing.T) {
+	// Arrange: a customer stored in plaintext before encryption was turned on
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "keys.yaml")
+	if err := os.WriteFile(keyFile, []byte("keys:\n  - id: master\n    key: AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8=\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write key file: %v", err)
+	}
+	cfg := config.Default()
+	cfg.Storage = config.StorageConfig{Backend: config.StorageSQLite, SQLitePath: filepath.Join(dir, "enricher.db")}
+	cfg.Encryption.KeyFile = keyFile
+	repos, closeStorage, err := openRepositories(cfg.Storage, false, &health.Readiness{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	_ = repos.customers.Create(&customer.Customer{CustomerID: "customer-plain", Name: "Dana Scully", Email: "dana@example.com", Status: "ACTIVE"})
+	_ = closeStorage()
+
+	// Act
+	var dataKey, output strings.Builder
+	keyErr := runDataKey(context.Background(), cfg.Encryption, &dataKey)
+	cfg.Encryption.Enabled, cfg.Encryption.DataKeys = true, []string{strings.TrimSpace(dataKey.String())}
+	err = runReencrypt(context.Background(), cfg, &output)
+
+	// Assert
+	assert.NoError(t, keyErr)
+	assert.NoError(t, err)
+	assert.Equal(t, "Re-encrypted 1 customers\n", output.String())
+	repos, closeStorage, _ = openRepositories(cfg.Storage, false, &health.Readiness{})
+	defer closeStorage()
+	stored, _ := repos.customers.GetByID("customer-plain")
+	assert.True(t, strings.HasPrefix(stored.Name, "enc:"), "Expected the name stored encrypted, got %s", stored.Name)
+	keys, _ := openKeyring(context.Background(), cfg.Encryption)
+	decrypted, err := customer.NewEncryptedRepository(repos.customers, keys).GetByEmail("dana@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "Dana Scully", decrypted.Name)
+}
+
+func TestOpenRepositories_SeedFile(t *testing.T) {
+	// Arrange
+	path := filepath.Join(t.TempDir(), "seed.yaml")
+	fixtures := `
+customers:
+  - customerId: customer-seeded
+    name: Walter Skinner
+    status: ACTIVE
+products:
+  - productId: product-seeded
+    name: Flashlight
+    price: 24.5
+    category: Electronics
+    quantity: 7
+`
+	if err := os.WriteFile(path, []byte(fixtures), 0o600); err != nil {
+		t.Fatalf("Failed to write seed file: %v", err)
+	}
+	cfg := config.StorageConfig{Backend: config.StorageMemory, SeedFile: path}
+
+	// Act
+	repos, _, err := openRepositories(cfg, false, &health.Readiness{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	seedErr := runSeed(path, repos)
+
+	// Assert
+	assert.NoError(t, seedErr, "Expected seeding the fixtures again to skip them")
+	seeded, err := repos.customers.GetByID("customer-seeded")
+	assert.NoError(t, err)
+	assert.Equal(t, "Walter Skinner", seeded.Name)
+	_, err = repos.customers.GetByID("customer-456")
+	assert.ErrorIs(t, err, customer.ErrCustomerNotFound, "Expected the seed file to replace the sample customers")
+	count, err := repos.products.Count(product.ProductFilter{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.ErrorContains(t, runSeed("", repos), "SEED_FILE")
+}
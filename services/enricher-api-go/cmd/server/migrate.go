@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"enricher-api-go/internal/config"
+	"enricher-api-go/internal/migrations"
+)
+
+// migrateUsage describes the migrate subcommand
+const migrateUsage = "usage: enricher-api migrate [up|down|status]"
+
+// runMigrate handles `enricher-api migrate [up|down|status]`, which manages
+// the PostgreSQL schema without starting the server: up applies the pending
+// migrations (the default), down reverts the latest one and status lists
+// them all.
+func runMigrate(ctx context.Context, cfg config.StorageConfig, args []string, out io.Writer) error {
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+	}
+	if len(args) > 1 || (command != "up" && command != "down" && command != "status") {
+		return errors.New(migrateUsage)
+	}
+	if cfg.Backend != config.StoragePostgres {
+		return fmt.Errorf("migrations apply to the %s storage backend, not %s", config.StoragePostgres, cfg.Backend)
+	}
+
+	db, err := sql.Open("pgx", cfg.DatabaseURL)
+	if err != nil {
+		return fmt.Errorf("failed to open postgres: %w", err)
+	}
+	defer db.Close()
+
+	migrator, err := migrations.New(db)
+	if err != nil {
+		return err
+	}
+
+	switch command {
+	case "down":
+		reverted, err := migrator.Down(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Reverted %d_%s\n", reverted.Version, reverted.Name)
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		printMigrations(out, statuses)
+	default:
+		applied, err := migrator.Up(ctx)
+		for _, migration := range applied {
+			fmt.Fprintf(out, "Applied %d_%s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Fprintln(out, "Schema is up to date")
+		}
+	}
+	return nil
+}
+
+// printMigrations writes statuses as a table
+func printMigrations(out io.Writer, statuses []migrations.Status) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+	for _, status := range statuses {
+		appliedAt := "pending"
+		if status.AppliedAt != nil {
+			appliedAt = status.AppliedAt.UTC().Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+	}
+	w.Flush()
+}
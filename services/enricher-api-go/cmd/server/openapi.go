@@ -0,0 +1,1223 @@
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"enricher-api-go/internal/apiversion"
+	"enricher-api-go/internal/category"
+	"enricher-api-go/internal/customer"
+	"enricher-api-go/internal/dlq"
+	"enricher-api-go/internal/enrichment"
+	"enricher-api-go/internal/export"
+	"enricher-api-go/internal/fieldset"
+	"enricher-api-go/internal/inventory"
+	"enricher-api-go/internal/jobs"
+	"enricher-api-go/internal/loyalty"
+	"enricher-api-go/internal/media"
+	"enricher-api-go/internal/merge"
+	"enricher-api-go/internal/openapi"
+	"enricher-api-go/internal/order"
+	"enricher-api-go/internal/pagination"
+	"enricher-api-go/internal/privacy"
+	"enricher-api-go/internal/problem"
+	"enricher-api-go/internal/product"
+	"enricher-api-go/internal/productimport"
+	"enricher-api-go/internal/shipping"
+	"enricher-api-go/internal/tax"
+	"enricher-api-go/internal/webhook"
+
+	"github.com/labstack/echo/v4"
+	echoSwagger "github.com/swaggo/echo-swagger"
+)
+
+// apiVersion is the version reported in the OpenAPI document
+const apiVersion = "1.0.0"
+
+// errorBody is the body of every error response
+var errorBody = problem.Problem{}
+
+// Response bodies built from maps in the handlers, described for the spec
+var (
+	productImportForm = struct {
+		File openapi.File `json:"file" validate:"required"`
+		// Mapping is a JSON object of column names to product fields
+		Mapping string `json:"mapping,omitempty"`
+		Async   bool   `json:"async,omitempty"`
+	}{}
+	imageUploadForm = struct {
+		File openapi.File `json:"file" validate:"required"`
+	}{}
+	imageListBody = struct {
+		Images []media.Image `json:"images"`
+		Count  int           `json:"count"`
+	}{}
+	mergeListBody = struct {
+		Merges []merge.Merge `json:"merges"`
+		Count  int           `json:"count"`
+	}{}
+	customerListBody = struct {
+		Customers  []customer.CustomerResponse `json:"customers"`
+		Count      int                         `json:"count"`
+		Pagination pagination.Meta             `json:"pagination"`
+	}{}
+	customerBatchBody = struct {
+		Customers []customer.CustomerResponse `json:"customers"`
+		Errors    []customer.BatchError       `json:"errors"`
+		Count     int                         `json:"count"`
+	}{}
+	customerStatusBody = struct {
+		CustomerID         string   `json:"customerId"`
+		Status             string   `json:"status"`
+		IsActive           bool     `json:"isActive"`
+		AllowedTransitions []string `json:"allowedTransitions"`
+	}{}
+	customerHistoryBody = struct {
+		CustomerID string                    `json:"customerId"`
+		Events     []customer.Event          `json:"events"`
+		Count      int                       `json:"count"`
+		Customer   customer.CustomerResponse `json:"customer"`
+	}{}
+	addressListBody = struct {
+		Addresses []customer.Address `json:"addresses"`
+		Count     int                `json:"count"`
+	}{}
+	productListBody = struct {
+		Products   []product.ProductResponse `json:"products"`
+		Count      int                       `json:"count"`
+		Category   string                    `json:"category"`
+		Pagination pagination.Meta           `json:"pagination"`
+	}{}
+	productListV2Body = struct {
+		Products   []product.ProductResponseV2 `json:"products"`
+		Count      int                         `json:"count"`
+		Category   string                      `json:"category"`
+		Pagination pagination.Meta             `json:"pagination"`
+	}{}
+	productBatchBody = struct {
+		Products []product.ProductResponse `json:"products"`
+		Missing  []string                  `json:"missing"`
+		Count    int                       `json:"count"`
+	}{}
+	stockMovementsBody = struct {
+		Movements  []product.StockMovement `json:"movements"`
+		Count      int                     `json:"count"`
+		Pagination pagination.Meta         `json:"pagination"`
+	}{}
+	priceChangesBody = struct {
+		PriceChanges []product.PriceChange `json:"priceChanges"`
+		Count        int                   `json:"count"`
+	}{}
+	variantListBody = struct {
+		Variants []product.Variant `json:"variants"`
+		Count    int               `json:"count"`
+	}{}
+	categoryListBody = struct {
+		Categories []category.Category `json:"categories"`
+		Count      int                 `json:"count"`
+	}{}
+	orderListBody = struct {
+		Orders     []order.Order   `json:"orders"`
+		Count      int             `json:"count"`
+		Pagination pagination.Meta `json:"pagination"`
+	}{}
+	deadLetterListBody = struct {
+		Entries    []dlq.Entry     `json:"entries"`
+		Count      int             `json:"count"`
+		Pagination pagination.Meta `json:"pagination"`
+	}{}
+	webhookListBody = struct {
+		Subscriptions []webhook.Subscription `json:"subscriptions"`
+		Count         int                    `json:"count"`
+		Pagination    pagination.Meta        `json:"pagination"`
+	}{}
+	webhookDeliveriesBody = struct {
+		Deliveries []webhook.Delivery `json:"deliveries"`
+		Count      int                `json:"count"`
+		Pagination pagination.Meta    `json:"pagination"`
+	}{}
+	availabilityBody = struct {
+		ProductID string `json:"productId"`
+		Quantity  int    `json:"quantity"`
+		InStock   bool   `json:"inStock"`
+		Orderable bool   `json:"orderable"`
+		Available bool   `json:"available"`
+	}{}
+)
+
+var currencyParam = openapi.QueryParam("currency", "string", "ISO 4217 code to price products in (defaults to each product's own currency)")
+
+var includeDeletedParam = openapi.QueryParam("includeDeleted", "boolean", "Also return soft-deleted records (admins only)")
+
+// sortParam describes the sort parameter of a list sortable on fields
+func sortParam(fields []string) openapi.Parameter {
+	return openapi.QueryParam("sort", "string", "Comma-separated fields to sort on, each optionally followed by :asc or :desc; one of "+
+		strings.Join(fields, ", "))
+}
+
+// fieldsParam describes the fields parameter of a read returning sample
+func fieldsParam(sample interface{}) openapi.Parameter {
+	return openapi.QueryParam("fields", "string", "Comma-separated fields to keep in the response; any of "+
+		strings.Join(fieldset.Names(reflect.TypeOf(sample)), ", "))
+}
+
+// productListParams are the filters of the product list of every version
+var productListParams = []openapi.Parameter{
+	openapi.QueryParam("category", "string", "Only list products in this category"),
+	openapi.QueryParam("includeSubcategories", "boolean", "Also list products in the category's subcategories"),
+	openapi.QueryParam("search", "string", "Match name or description, case-insensitively"),
+	openapi.QueryParam("minPrice", "number", "Minimum price, inclusive"),
+	openapi.QueryParam("maxPrice", "number", "Maximum price, inclusive"),
+	openapi.QueryParam("inStock", "boolean", "Only list products with this stock status"),
+	sortParam(product.SortFields),
+	includeDeletedParam,
+	currencyParam,
+}
+
+// productGetParams are the parameters of a product read of every version
+var productGetParams = []openapi.Parameter{
+	includeDeletedParam,
+	currencyParam,
+	openapi.QueryParam("at", "string", "RFC 3339 timestamp to price the product at (defaults to now)"),
+	openapi.QueryParam("asOf", "string", "Past RFC 3339 timestamp to read the product as it was then; 404 if it was deleted at that instant"),
+}
+
+var paginationParams = []openapi.Parameter{
+	openapi.QueryParam("limit", "integer", "Page size, 1-100 (default 20)"),
+	openapi.QueryParam("offset", "integer", "Number of results to skip (default 0)"),
+}
+
+// apiEndpoints describes every versioned route, keyed by "METHOD /echo/path"
+var apiEndpoints = map[string]openapi.Endpoint{
+	"GET /v1/customers": {
+		Summary: "List customers",
+		Tag:     "customers",
+		Query: append([]openapi.Parameter{
+			openapi.QueryParam("segment", "string", "Only list customers tagged with this segment"),
+			openapi.QueryParam("computedSegment", "string", "Only list customers in this computed segment: VIP, NEW or DORMANT"),
+			openapi.QueryParam("status", "string", "Only list customers in this status (PENDING, ACTIVE, SUSPENDED or CLOSED)"),
+			sortParam(customer.SortFields),
+			fieldsParam(customer.CustomerResponse{}),
+			includeDeletedParam,
+		}, paginationParams...),
+		Responses: map[int]interface{}{
+			http.StatusOK:                  customerListBody,
+			http.StatusBadRequest:          errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"GET /v1/customers/export": {
+		Summary: "Export customers as CSV or NDJSON, chosen by the Accept header",
+		Tag:     "customers",
+		Query: []openapi.Parameter{
+			openapi.QueryParam("segment", "string", "Only export customers tagged with this segment"),
+			openapi.QueryParam("computedSegment", "string", "Only export customers in this computed segment: VIP, NEW or DORMANT"),
+			openapi.QueryParam("status", "string", "Only export customers in this status (PENDING, ACTIVE, SUSPENDED or CLOSED)"),
+			sortParam(customer.SortFields),
+			fieldsParam(customer.CustomerResponse{}),
+			includeDeletedParam,
+		},
+		Responses: map[int]interface{}{
+			http.StatusOK:                  customer.CustomerResponse{},
+			http.StatusBadRequest:          errorBody,
+			http.StatusNotAcceptable:       errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+		ResponseMediaTypes: export.MediaTypes,
+	},
+	"POST /v1/customers": {
+		Summary: "Create a customer",
+		Tag:     "customers",
+		Request: customer.CustomerRequest{},
+		Responses: map[int]interface{}{
+			http.StatusCreated:    customer.CustomerResponse{},
+			http.StatusBadRequest: errorBody,
+			http.StatusConflict:   errorBody,
+		},
+	},
+	"POST /v1/customers/batch": {
+		Summary: "Get several customers by ID, reporting unresolved IDs individually",
+		Tag:     "customers",
+		Request: customer.BatchRequest{},
+		Responses: map[int]interface{}{
+			http.StatusOK:                  customerBatchBody,
+			http.StatusBadRequest:          errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"POST /v1/customers/bulk": {
+		Summary: "Create or update several customers, reporting each item's outcome; atomic requests apply all items or none",
+		Tag:     "customers",
+		Request: customer.BulkRequest{},
+		Responses: map[int]interface{}{
+			http.StatusOK:                  customer.BulkResponse{},
+			http.StatusBadRequest:          errorBody,
+			http.StatusUnprocessableEntity: customer.BulkResponse{},
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"GET /v1/customers/by-email/:email": {
+		Summary: "Get a customer by email address",
+		Tag:     "customers",
+		Responses: map[int]interface{}{
+			http.StatusOK:                  customer.CustomerResponse{},
+			http.StatusBadRequest:          errorBody,
+			http.StatusNotFound:            errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"GET /v1/customers/:id": {
+		Summary: "Get a customer",
+		Tag:     "customers",
+		Query: []openapi.Parameter{
+			includeDeletedParam,
+			fieldsParam(customer.CustomerResponse{}),
+			openapi.QueryParam("asOf", "string", "Past RFC 3339 timestamp to read the customer as it was then; only supported with event sourcing on the memory backend, 501 otherwise"),
+		},
+		Responses: map[int]interface{}{
+			http.StatusOK:                  customer.CustomerResponse{},
+			http.StatusBadRequest:          errorBody,
+			http.StatusNotFound:            errorBody,
+			http.StatusInternalServerError: errorBody,
+			http.StatusNotImplemented:      errorBody,
+		},
+	},
+	"PUT /v1/customers/:id": {
+		Summary: "Update a customer",
+		Tag:     "customers",
+		Request: customer.CustomerRequest{},
+		Responses: map[int]interface{}{
+			http.StatusOK:                  customer.CustomerResponse{},
+			http.StatusBadRequest:          errorBody,
+			http.StatusNotFound:            errorBody,
+			http.StatusConflict:            errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"PATCH /v1/customers/:id": {
+		Summary: "Update some fields of a customer",
+		Tag:     "customers",
+		Request: customer.CustomerPatch{},
+		Responses: map[int]interface{}{
+			http.StatusOK:         customer.CustomerResponse{},
+			http.StatusBadRequest: errorBody,
+			http.StatusNotFound:   errorBody,
+			http.StatusConflict:   errorBody,
+		},
+	},
+	"DELETE /v1/customers/:id": {
+		Summary: "Soft-delete a customer",
+		Tag:     "customers",
+		Responses: map[int]interface{}{
+			http.StatusNoContent:           nil,
+			http.StatusNotFound:            errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"POST /v1/customers/:id/restore": {
+		Summary: "Restore a soft-deleted customer",
+		Tag:     "customers",
+		Responses: map[int]interface{}{
+			http.StatusOK:                  customer.CustomerResponse{},
+			http.StatusNotFound:            errorBody,
+			http.StatusConflict:            errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"GET /v1/customers/:id/status": {
+		Summary: "Check whether a customer is active",
+		Tag:     "customers",
+		Responses: map[int]interface{}{
+			http.StatusOK:                  customerStatusBody,
+			http.StatusNotFound:            errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"GET /v1/customers/:id/history": {
+		Summary: "Replay the recorded events of a customer, optionally up to an instant",
+		Tag:     "customers",
+		Query: []openapi.Parameter{
+			openapi.QueryParam("until", "string", "RFC 3339 timestamp to replay the events up to (defaults to now)"),
+		},
+		Responses: map[int]interface{}{
+			http.StatusOK:                  customerHistoryBody,
+			http.StatusBadRequest:          errorBody,
+			http.StatusNotFound:            errorBody,
+			http.StatusNotImplemented:      errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"POST /v1/customers/:id/transitions": {
+		Summary: "Move a customer to another lifecycle status",
+		Tag:     "customers",
+		Request: customer.TransitionRequest{},
+		Responses: map[int]interface{}{
+			http.StatusOK:                  customer.CustomerResponse{},
+			http.StatusBadRequest:          errorBody,
+			http.StatusNotFound:            errorBody,
+			http.StatusConflict:            errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"GET /v1/customers/:id/credit-check": {
+		Summary: "Check whether a customer has enough credit left for an amount",
+		Tag:     "customers",
+		Query: []openapi.Parameter{
+			openapi.QueryParam("amount", "number", "Order value to check against the available credit"),
+		},
+		Responses: map[int]interface{}{
+			http.StatusOK:                  customer.CreditCheck{},
+			http.StatusBadRequest:          errorBody,
+			http.StatusNotFound:            errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"POST /v1/customers/:id/credit/reserve": {
+		Summary: "Atomically add an amount to a customer's credit exposure",
+		Tag:     "customers",
+		Request: customer.CreditRequest{},
+		Responses: map[int]interface{}{
+			http.StatusOK:                  customer.CustomerResponse{},
+			http.StatusBadRequest:          errorBody,
+			http.StatusNotFound:            errorBody,
+			http.StatusConflict:            errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"POST /v1/customers/:id/credit/release": {
+		Summary: "Atomically remove an amount from a customer's credit exposure",
+		Tag:     "customers",
+		Request: customer.CreditRequest{},
+		Responses: map[int]interface{}{
+			http.StatusOK:                  customer.CustomerResponse{},
+			http.StatusBadRequest:          errorBody,
+			http.StatusNotFound:            errorBody,
+			http.StatusConflict:            errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"POST /v1/customers/:id/segments": {
+		Summary: "Tag a customer with a segment",
+		Tag:     "customers",
+		Request: customer.SegmentRequest{},
+		Responses: map[int]interface{}{
+			http.StatusOK:                  customer.CustomerResponse{},
+			http.StatusBadRequest:          errorBody,
+			http.StatusNotFound:            errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"DELETE /v1/customers/:id/segments/:segment": {
+		Summary: "Remove a segment from a customer",
+		Tag:     "customers",
+		Responses: map[int]interface{}{
+			http.StatusOK:                  customer.CustomerResponse{},
+			http.StatusBadRequest:          errorBody,
+			http.StatusNotFound:            errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"GET /v1/customers/:id/addresses": {
+		Summary: "List a customer's addresses",
+		Tag:     "customers",
+		Responses: map[int]interface{}{
+			http.StatusOK:                  addressListBody,
+			http.StatusNotFound:            errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"POST /v1/customers/:id/addresses": {
+		Summary: "Add an address to a customer",
+		Tag:     "customers",
+		Request: customer.AddressRequest{},
+		Responses: map[int]interface{}{
+			http.StatusCreated:             customer.Address{},
+			http.StatusBadRequest:          errorBody,
+			http.StatusNotFound:            errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"GET /v1/customers/:id/addresses/:addressId": {
+		Summary: "Get a customer address",
+		Tag:     "customers",
+		Responses: map[int]interface{}{
+			http.StatusOK:                  customer.Address{},
+			http.StatusNotFound:            errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"PUT /v1/customers/:id/addresses/:addressId": {
+		Summary: "Replace a customer address",
+		Tag:     "customers",
+		Request: customer.AddressRequest{},
+		Responses: map[int]interface{}{
+			http.StatusOK:                  customer.Address{},
+			http.StatusBadRequest:          errorBody,
+			http.StatusNotFound:            errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"DELETE /v1/customers/:id/addresses/:addressId": {
+		Summary: "Delete a customer address",
+		Tag:     "customers",
+		Responses: map[int]interface{}{
+			http.StatusNoContent:           nil,
+			http.StatusNotFound:            errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"GET /v1/customers/:id/loyalty": {
+		Summary: "Get a customer's loyalty points and tier",
+		Tag:     "customers",
+		Responses: map[int]interface{}{
+			http.StatusOK:                  loyalty.Account{},
+			http.StatusNotFound:            errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"POST /v1/customers/:id/loyalty/accrue": {
+		Summary: "Add loyalty points to a customer's balance",
+		Tag:     "customers",
+		Request: loyalty.PointsRequest{},
+		Responses: map[int]interface{}{
+			http.StatusOK:                  loyalty.Account{},
+			http.StatusBadRequest:          errorBody,
+			http.StatusNotFound:            errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"POST /v1/customers/:id/loyalty/redeem": {
+		Summary: "Redeem loyalty points from a customer's balance",
+		Tag:     "customers",
+		Request: loyalty.PointsRequest{},
+		Responses: map[int]interface{}{
+			http.StatusOK:                  loyalty.Account{},
+			http.StatusBadRequest:          errorBody,
+			http.StatusNotFound:            errorBody,
+			http.StatusConflict:            errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"POST /v1/customers/:id/merge": {
+		Summary: "Merge a duplicate customer into this one",
+		Tag:     "customers",
+		Request: merge.MergeRequest{},
+		Responses: map[int]interface{}{
+			http.StatusOK:                  merge.Merge{},
+			http.StatusBadRequest:          errorBody,
+			http.StatusNotFound:            errorBody,
+			http.StatusConflict:            errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"GET /v1/customers/:id/merges": {
+		Summary: "List the merges a customer took part in",
+		Tag:     "customers",
+		Responses: map[int]interface{}{
+			http.StatusOK:                  mergeListBody,
+			http.StatusNotFound:            errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"GET /v1/customers/:id/data-export": {
+		Summary: "Export everything stored about a customer",
+		Tag:     "customers",
+		Responses: map[int]interface{}{
+			http.StatusOK:                  privacy.Export{},
+			http.StatusNotFound:            errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"POST /v1/customers/:id/erase": {
+		Summary: "Request, or confirm with a token, the erasure of a customer's personal data",
+		Tag:     "customers",
+		Request: privacy.ErasureRequest{},
+		Responses: map[int]interface{}{
+			http.StatusOK:                  privacy.Erasure{},
+			http.StatusAccepted:            privacy.ErasureConfirmation{},
+			http.StatusBadRequest:          errorBody,
+			http.StatusNotFound:            errorBody,
+			http.StatusConflict:            errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"GET /v1/products": {
+		Summary: "List products; superseded by GET /v2/products",
+		Tag:     "products",
+		Query: append(append([]openapi.Parameter{fieldsParam(product.ProductResponse{})}, productListParams...),
+			paginationParams...),
+		Responses: map[int]interface{}{
+			http.StatusOK:                  productListBody,
+			http.StatusBadRequest:          errorBody,
+			http.StatusNotFound:            errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+		Deprecated: true,
+	},
+	"GET /v2/products": {
+		Summary: "List products",
+		Tag:     "products",
+		Query: append(append([]openapi.Parameter{fieldsParam(product.ProductResponseV2{})}, productListParams...),
+			paginationParams...),
+		Responses: map[int]interface{}{
+			http.StatusOK:                  productListV2Body,
+			http.StatusBadRequest:          errorBody,
+			http.StatusNotFound:            errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"GET /v1/products/export": {
+		Summary: "Export products as CSV or NDJSON, chosen by the Accept header",
+		Tag:     "products",
+		Query: []openapi.Parameter{
+			openapi.QueryParam("category", "string", "Only export products in this category"),
+			openapi.QueryParam("includeSubcategories", "boolean", "Also export products in the category's subcategories"),
+			openapi.QueryParam("search", "string", "Match name or description, case-insensitively"),
+			openapi.QueryParam("minPrice", "number", "Minimum price, inclusive"),
+			openapi.QueryParam("maxPrice", "number", "Maximum price, inclusive"),
+			openapi.QueryParam("inStock", "boolean", "Only export products with this stock status"),
+			sortParam(product.SortFields),
+			fieldsParam(product.ProductResponse{}),
+			includeDeletedParam,
+			currencyParam,
+		},
+		Responses: map[int]interface{}{
+			http.StatusOK:                  product.ProductResponse{},
+			http.StatusBadRequest:          errorBody,
+			http.StatusNotFound:            errorBody,
+			http.StatusNotAcceptable:       errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+		ResponseMediaTypes: export.MediaTypes,
+	},
+	"POST /v1/products": {
+		Summary: "Create a product",
+		Tag:     "products",
+		Request: product.ProductRequest{},
+		Responses: map[int]interface{}{
+			http.StatusCreated:    product.ProductResponse{},
+			http.StatusBadRequest: errorBody,
+		},
+	},
+	"POST /v1/products/batch": {
+		Summary: "Get several products by ID, listing IDs that were not found",
+		Tag:     "products",
+		Query:   []openapi.Parameter{currencyParam},
+		Request: product.BatchRequest{},
+		Responses: map[int]interface{}{
+			http.StatusOK:                  productBatchBody,
+			http.StatusBadRequest:          errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"POST /v1/products/availability": {
+		Summary: "Check whether every line of an order can be fulfilled",
+		Tag:     "products",
+		Request: []product.AvailabilityLine{},
+		Responses: map[int]interface{}{
+			http.StatusOK:                  product.BulkAvailability{},
+			http.StatusBadRequest:          errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"POST /v1/products/bulk": {
+		Summary: "Create or update several products, reporting each item's outcome; atomic requests apply all items or none",
+		Tag:     "products",
+		Request: product.BulkRequest{},
+		Responses: map[int]interface{}{
+			http.StatusOK:                  product.BulkResponse{},
+			http.StatusBadRequest:          errorBody,
+			http.StatusUnprocessableEntity: product.BulkResponse{},
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"POST /v1/products/import": {
+		Summary:          "Import products from a CSV file, reporting the rows that failed; async imports are answered while queued",
+		Tag:              "products",
+		Request:          productImportForm,
+		RequestMediaType: echo.MIMEMultipartForm,
+		Responses: map[int]interface{}{
+			http.StatusOK:                  productimport.Report{},
+			http.StatusAccepted:            productimport.Import{},
+			http.StatusBadRequest:          errorBody,
+			http.StatusInternalServerError: errorBody,
+			http.StatusServiceUnavailable:  errorBody,
+		},
+	},
+	"GET /v1/product-imports/:id": {
+		Summary: "Get the progress and failed rows of a background product import",
+		Tag:     "products",
+		Responses: map[int]interface{}{
+			http.StatusOK:       productimport.Import{},
+			http.StatusNotFound: errorBody,
+		},
+	},
+	"GET /v1/products/by-barcode/:code": {
+		Summary: "Get the product with a GTIN barcode; superseded by GET /v2/products/by-barcode/{code}",
+		Tag:     "products",
+		Query:   []openapi.Parameter{currencyParam},
+		Responses: map[int]interface{}{
+			http.StatusOK:                  product.ProductResponse{},
+			http.StatusBadRequest:          errorBody,
+			http.StatusNotFound:            errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+		Deprecated: true,
+	},
+	"GET /v2/products/by-barcode/:code": {
+		Summary: "Get the product with a GTIN barcode",
+		Tag:     "products",
+		Query:   []openapi.Parameter{currencyParam},
+		Responses: map[int]interface{}{
+			http.StatusOK:                  product.ProductResponseV2{},
+			http.StatusBadRequest:          errorBody,
+			http.StatusNotFound:            errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"GET /v1/products/:id": {
+		Summary: "Get a product; superseded by GET /v2/products/{id}",
+		Tag:     "products",
+		Query:   append([]openapi.Parameter{fieldsParam(product.ProductResponse{})}, productGetParams...),
+		Responses: map[int]interface{}{
+			http.StatusOK:                  product.ProductResponse{},
+			http.StatusBadRequest:          errorBody,
+			http.StatusNotFound:            errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+		Deprecated: true,
+	},
+	"GET /v2/products/:id": {
+		Summary: "Get a product",
+		Tag:     "products",
+		Query:   append([]openapi.Parameter{fieldsParam(product.ProductResponseV2{})}, productGetParams...),
+		Responses: map[int]interface{}{
+			http.StatusOK:                  product.ProductResponseV2{},
+			http.StatusBadRequest:          errorBody,
+			http.StatusNotFound:            errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"PUT /v1/products/:id": {
+		Summary: "Update a product",
+		Tag:     "products",
+		Request: product.ProductRequest{},
+		Responses: map[int]interface{}{
+			http.StatusOK:                  product.ProductResponse{},
+			http.StatusBadRequest:          errorBody,
+			http.StatusNotFound:            errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"PATCH /v1/products/:id": {
+		Summary: "Update some fields of a product",
+		Tag:     "products",
+		Request: product.ProductPatch{},
+		Responses: map[int]interface{}{
+			http.StatusOK:         product.ProductResponse{},
+			http.StatusBadRequest: errorBody,
+			http.StatusNotFound:   errorBody,
+		},
+	},
+	"DELETE /v1/products/:id": {
+		Summary: "Soft-delete a product",
+		Tag:     "products",
+		Responses: map[int]interface{}{
+			http.StatusNoContent:           nil,
+			http.StatusNotFound:            errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"POST /v1/products/:id/restore": {
+		Summary: "Restore a soft-deleted product",
+		Tag:     "products",
+		Responses: map[int]interface{}{
+			http.StatusOK:                  product.ProductResponse{},
+			http.StatusNotFound:            errorBody,
+			http.StatusConflict:            errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"POST /v1/products/:id/reserve": {
+		Summary: "Take units out of a product's stock",
+		Tag:     "products",
+		Request: product.StockRequest{},
+		Responses: map[int]interface{}{
+			http.StatusOK:                  product.ProductResponse{},
+			http.StatusBadRequest:          errorBody,
+			http.StatusNotFound:            errorBody,
+			http.StatusConflict:            errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"POST /v1/products/:id/release": {
+		Summary: "Return reserved units to a product's stock",
+		Tag:     "products",
+		Request: product.StockRequest{},
+		Responses: map[int]interface{}{
+			http.StatusOK:                  product.ProductResponse{},
+			http.StatusBadRequest:          errorBody,
+			http.StatusNotFound:            errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"GET /v1/products/:id/stock-movements": {
+		Summary: "List a product's stock changes, newest first",
+		Tag:     "products",
+		Query:   paginationParams,
+		Responses: map[int]interface{}{
+			http.StatusOK:                  stockMovementsBody,
+			http.StatusBadRequest:          errorBody,
+			http.StatusNotFound:            errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"GET /v1/products/:id/prices": {
+		Summary: "List a product's past and scheduled price changes",
+		Tag:     "products",
+		Responses: map[int]interface{}{
+			http.StatusOK:                  priceChangesBody,
+			http.StatusNotFound:            errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"POST /v1/products/:id/prices": {
+		Summary: "Schedule a future price change",
+		Tag:     "products",
+		Request: product.PriceChangeRequest{},
+		Responses: map[int]interface{}{
+			http.StatusCreated:             product.PriceChange{},
+			http.StatusBadRequest:          errorBody,
+			http.StatusNotFound:            errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"DELETE /v1/products/:id/prices/:changeId": {
+		Summary: "Cancel a price change that has not taken effect",
+		Tag:     "products",
+		Responses: map[int]interface{}{
+			http.StatusNoContent:           nil,
+			http.StatusNotFound:            errorBody,
+			http.StatusConflict:            errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"GET /v1/products/:id/variants": {
+		Summary: "List a product's variants",
+		Tag:     "products",
+		Responses: map[int]interface{}{
+			http.StatusOK:                  variantListBody,
+			http.StatusNotFound:            errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"POST /v1/products/:id/variants": {
+		Summary: "Add a variant to a product",
+		Tag:     "products",
+		Request: product.VariantRequest{},
+		Responses: map[int]interface{}{
+			http.StatusCreated:             product.Variant{},
+			http.StatusBadRequest:          errorBody,
+			http.StatusNotFound:            errorBody,
+			http.StatusConflict:            errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"GET /v1/skus/:sku": {
+		Summary: "Get a product variant by SKU",
+		Tag:     "products",
+		Responses: map[int]interface{}{
+			http.StatusOK:                  product.Variant{},
+			http.StatusBadRequest:          errorBody,
+			http.StatusNotFound:            errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"PUT /v1/skus/:sku": {
+		Summary: "Update a product variant",
+		Tag:     "products",
+		Request: product.VariantRequest{},
+		Responses: map[int]interface{}{
+			http.StatusOK:                  product.Variant{},
+			http.StatusBadRequest:          errorBody,
+			http.StatusNotFound:            errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"DELETE /v1/skus/:sku": {
+		Summary: "Delete a product variant",
+		Tag:     "products",
+		Responses: map[int]interface{}{
+			http.StatusNoContent:           nil,
+			http.StatusBadRequest:          errorBody,
+			http.StatusNotFound:            errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"GET /v1/products/:id/availability": {
+		Summary: "Check whether a product can be ordered",
+		Tag:     "products",
+		Responses: map[int]interface{}{
+			http.StatusOK:                  availabilityBody,
+			http.StatusNotFound:            errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"GET /v1/products/:id/images": {
+		Summary: "List the uploaded images of a product, oldest first",
+		Tag:     "products",
+		Responses: map[int]interface{}{
+			http.StatusOK:                  imageListBody,
+			http.StatusNotFound:            errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"POST /v1/products/:id/images": {
+		Summary:          "Upload a JPEG, PNG or GIF image of a product, storing it with a thumbnail",
+		Tag:              "products",
+		Request:          imageUploadForm,
+		RequestMediaType: echo.MIMEMultipartForm,
+		Responses: map[int]interface{}{
+			http.StatusCreated:               media.Image{},
+			http.StatusBadRequest:            errorBody,
+			http.StatusNotFound:              errorBody,
+			http.StatusRequestEntityTooLarge: errorBody,
+			http.StatusInternalServerError:   errorBody,
+			http.StatusServiceUnavailable:    errorBody,
+		},
+	},
+	"DELETE /v1/products/:id/images/:imageId": {
+		Summary: "Delete an image of a product and its files",
+		Tag:     "products",
+		Responses: map[int]interface{}{
+			http.StatusNoContent:           nil,
+			http.StatusNotFound:            errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"GET /v1/ws/inventory": {
+		Summary: "Upgrade to a WebSocket streaming the stock levels of products as they change",
+		Tag:     "products",
+		Query: []openapi.Parameter{
+			openapi.QueryParam("productIds", "string", "Comma-separated IDs of the products to follow, at most 100"),
+		},
+		Responses: map[int]interface{}{
+			http.StatusSwitchingProtocols:  inventory.Message{},
+			http.StatusBadRequest:          errorBody,
+			http.StatusNotFound:            errorBody,
+			http.StatusUpgradeRequired:     errorBody,
+			http.StatusInternalServerError: errorBody,
+			http.StatusServiceUnavailable:  errorBody,
+		},
+	},
+	"GET /v1/categories": {
+		Summary: "List categories",
+		Tag:     "categories",
+		Query: []openapi.Parameter{
+			openapi.QueryParam("tree", "boolean", "Nest subcategories under their parents instead of listing flat"),
+		},
+		Responses: map[int]interface{}{
+			http.StatusOK:                  categoryListBody,
+			http.StatusBadRequest:          errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"POST /v1/categories": {
+		Summary: "Create a category",
+		Tag:     "categories",
+		Request: category.CategoryRequest{},
+		Responses: map[int]interface{}{
+			http.StatusCreated:             category.Category{},
+			http.StatusBadRequest:          errorBody,
+			http.StatusConflict:            errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"GET /v1/categories/:id": {
+		Summary: "Get a category",
+		Tag:     "categories",
+		Responses: map[int]interface{}{
+			http.StatusOK:                  category.Category{},
+			http.StatusNotFound:            errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"PUT /v1/categories/:id": {
+		Summary: "Rename or move a category",
+		Tag:     "categories",
+		Request: category.CategoryRequest{},
+		Responses: map[int]interface{}{
+			http.StatusOK:                  category.Category{},
+			http.StatusBadRequest:          errorBody,
+			http.StatusNotFound:            errorBody,
+			http.StatusConflict:            errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"DELETE /v1/categories/:id": {
+		Summary: "Delete a category without subcategories or products",
+		Tag:     "categories",
+		Responses: map[int]interface{}{
+			http.StatusNoContent:           nil,
+			http.StatusNotFound:            errorBody,
+			http.StatusConflict:            errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"POST /v1/enrich": {
+		Summary: "Enrich an order with customer and product details",
+		Tag:     "enrichment",
+		Request: enrichment.OrderRequest{},
+		Responses: map[int]interface{}{
+			http.StatusOK:                  enrichment.EnrichedOrder{},
+			http.StatusBadRequest:          errorBody,
+			http.StatusNotFound:            errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"POST /v1/tax/quote": {
+		Summary: "Quote the tax of order lines shipped to an address",
+		Tag:     "enrichment",
+		Request: tax.QuoteRequest{},
+		Responses: map[int]interface{}{
+			http.StatusOK:                  tax.Quote{},
+			http.StatusBadRequest:          errorBody,
+			http.StatusInternalServerError: errorBody,
+			http.StatusServiceUnavailable:  errorBody,
+		},
+	},
+	"POST /v1/shipping/estimate": {
+		Summary: "Estimate the shipping options of order lines to a customer",
+		Tag:     "enrichment",
+		Request: shipping.EstimateRequest{},
+		Responses: map[int]interface{}{
+			http.StatusOK:                  shipping.Estimate{},
+			http.StatusBadRequest:          errorBody,
+			http.StatusNotFound:            errorBody,
+			http.StatusInternalServerError: errorBody,
+			http.StatusServiceUnavailable:  errorBody,
+		},
+	},
+	"POST /v1/enrichment-jobs": {
+		Summary: "Queue a batch of orders for asynchronous enrichment",
+		Tag:     "enrichment",
+		Request: jobs.JobRequest{},
+		Responses: map[int]interface{}{
+			http.StatusAccepted:            jobs.Job{},
+			http.StatusBadRequest:          errorBody,
+			http.StatusInternalServerError: errorBody,
+			http.StatusServiceUnavailable:  errorBody,
+		},
+	},
+	"GET /v1/enrichment-jobs/:id": {
+		Summary: "Get the progress and results of an enrichment job",
+		Tag:     "enrichment",
+		Responses: map[int]interface{}{
+			http.StatusOK:       jobs.Job{},
+			http.StatusNotFound: errorBody,
+		},
+	},
+	"GET /v1/orders": {
+		Summary: "List orders, newest first",
+		Tag:     "orders",
+		Query: append([]openapi.Parameter{
+			openapi.QueryParam("customerId", "string", "Only list the orders of this customer"),
+			openapi.QueryParam("status", "string", "Only list orders in this enrichment status (PENDING, ENRICHED or FAILED)"),
+		}, paginationParams...),
+		Responses: map[int]interface{}{
+			http.StatusOK:                  orderListBody,
+			http.StatusBadRequest:          errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"POST /v1/orders": {
+		Summary: "Place an order and enrich it",
+		Tag:     "orders",
+		Request: order.OrderRequest{},
+		Responses: map[int]interface{}{
+			http.StatusCreated:             order.Order{},
+			http.StatusBadRequest:          errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"GET /v1/orders/:id": {
+		Summary: "Get an order with its enrichment status",
+		Tag:     "orders",
+		Responses: map[int]interface{}{
+			http.StatusOK:                  order.Order{},
+			http.StatusNotFound:            errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"PUT /v1/orders/:id": {
+		Summary: "Replace an order and enrich it again",
+		Tag:     "orders",
+		Request: order.OrderRequest{},
+		Responses: map[int]interface{}{
+			http.StatusOK:                  order.Order{},
+			http.StatusBadRequest:          errorBody,
+			http.StatusNotFound:            errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"DELETE /v1/orders/:id": {
+		Summary: "Delete an order",
+		Tag:     "orders",
+		Responses: map[int]interface{}{
+			http.StatusNoContent:           nil,
+			http.StatusNotFound:            errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"POST /v1/orders/:id/enrich": {
+		Summary: "Enrich a stored order again",
+		Tag:     "orders",
+		Responses: map[int]interface{}{
+			http.StatusOK:                  order.Order{},
+			http.StatusNotFound:            errorBody,
+			http.StatusInternalServerError: errorBody,
+			http.StatusServiceUnavailable:  errorBody,
+		},
+	},
+	"GET /v1/dlq": {
+		Summary: "List dead-lettered orders, most recent failure first",
+		Tag:     "dlq",
+		Query: append([]openapi.Parameter{
+			openapi.QueryParam("source", "string", "Only list the entries of this source (order or kafka)"),
+			openapi.QueryParam("status", "string", "Only list entries in this status (OPEN, the default, RESOLVED or all)"),
+		}, paginationParams...),
+		Responses: map[int]interface{}{
+			http.StatusOK:                  deadLetterListBody,
+			http.StatusBadRequest:          errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"GET /v1/dlq/:id": {
+		Summary: "Get a dead-lettered order with its failure reason and retry count",
+		Tag:     "dlq",
+		Responses: map[int]interface{}{
+			http.StatusOK:                  dlq.Entry{},
+			http.StatusNotFound:            errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"POST /v1/dlq/:id/retry": {
+		Summary: "Replay a dead-lettered order through its source",
+		Tag:     "dlq",
+		Responses: map[int]interface{}{
+			http.StatusOK:                  dlq.Entry{},
+			http.StatusNotFound:            errorBody,
+			http.StatusConflict:            errorBody,
+			http.StatusInternalServerError: errorBody,
+			http.StatusServiceUnavailable:  errorBody,
+		},
+	},
+	"GET /v1/webhooks": {
+		Summary: "List webhook subscriptions, oldest first",
+		Tag:     "webhooks",
+		Query: append([]openapi.Parameter{
+			openapi.QueryParam("event", "string", "Only list the active subscriptions receiving this event type"),
+		}, paginationParams...),
+		Responses: map[int]interface{}{
+			http.StatusOK:                  webhookListBody,
+			http.StatusBadRequest:          errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"POST /v1/webhooks": {
+		Summary: "Subscribe a callback URL to change events; the response carries the signing secret",
+		Tag:     "webhooks",
+		Request: webhook.SubscriptionRequest{},
+		Responses: map[int]interface{}{
+			http.StatusCreated:             webhook.Subscription{},
+			http.StatusBadRequest:          errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"GET /v1/webhooks/:id": {
+		Summary: "Get a webhook subscription",
+		Tag:     "webhooks",
+		Responses: map[int]interface{}{
+			http.StatusOK:                  webhook.Subscription{},
+			http.StatusNotFound:            errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"PUT /v1/webhooks/:id": {
+		Summary: "Replace a webhook subscription",
+		Tag:     "webhooks",
+		Request: webhook.SubscriptionRequest{},
+		Responses: map[int]interface{}{
+			http.StatusOK:                  webhook.Subscription{},
+			http.StatusBadRequest:          errorBody,
+			http.StatusNotFound:            errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"DELETE /v1/webhooks/:id": {
+		Summary: "Delete a webhook subscription and its delivery log",
+		Tag:     "webhooks",
+		Responses: map[int]interface{}{
+			http.StatusNoContent:           nil,
+			http.StatusNotFound:            errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+	"GET /v1/webhooks/:id/deliveries": {
+		Summary: "List the deliveries of a webhook subscription, newest first",
+		Tag:     "webhooks",
+		Query: append([]openapi.Parameter{
+			openapi.QueryParam("status", "string", "Only list deliveries in this status (PENDING, DELIVERED or FAILED)"),
+		}, paginationParams...),
+		Responses: map[int]interface{}{
+			http.StatusOK:                  webhookDeliveriesBody,
+			http.StatusBadRequest:          errorBody,
+			http.StatusNotFound:            errorBody,
+			http.StatusInternalServerError: errorBody,
+		},
+	},
+}
+
+// apiDocument builds the OpenAPI document for the versioned routes
+// registered on Echo. Routes without an apiEndpoints entry are still listed
+// so the document never silently drops an endpoint.
+func apiDocument(routes []*echo.Route) *openapi.Document {
+	builder := openapi.NewBuilder("Enricher API", apiVersion)
+
+	sort.Slice(routes, func(i, j int) bool {
+		return routes[i].Path+routes[i].Method < routes[j].Path+routes[j].Method
+	})
+	for _, route := range routes {
+		if route.Method == echo.RouteNotFound || !isVersioned(route.Path) {
+			continue
+		}
+		endpoint, ok := apiEndpoints[route.Method+" "+route.Path]
+		if !ok {
+			endpoint = openapi.Endpoint{Responses: map[int]interface{}{http.StatusOK: nil}}
+		}
+		builder.Add(route.Method, route.Path, endpoint)
+	}
+
+	return builder.Document()
+}
+
+// isVersioned reports whether path belongs to a version of the API
+func isVersioned(path string) bool {
+	return strings.HasPrefix(path, apiversion.V1.Prefix()+"/") || strings.HasPrefix(path, apiversion.V2.Prefix()+"/")
+}
+
+// registerDocs serves the OpenAPI document for the routes registered so far
+// at /openapi.json and Swagger UI at /docs
+func registerDocs(e *echo.Echo) {
+	document := apiDocument(e.Routes())
+
+	e.GET("/openapi.json", func(c echo.Context) error {
+		return c.JSON(http.StatusOK, document)
+	})
+	e.GET("/docs", func(c echo.Context) error {
+		return c.Redirect(http.StatusMovedPermanently, "/docs/index.html")
+	})
+	e.GET("/docs/*", echoSwagger.EchoWrapHandler(echoSwagger.URL("/openapi.json")))
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"enricher-api-go/internal/seed"
+)
+
+// runSeed handles the --seed flag, which writes the fixtures of the seed
+// file to the configured storage backend before the server starts. Records
+// already stored are left as they are, so seeding on every start is safe.
+func runSeed(path string, repos repositories) error {
+	if path == "" {
+		return errors.New("--seed requires SEED_FILE")
+	}
+
+	fixtures, err := seed.Load(path)
+	if err != nil {
+		return err
+	}
+	result, err := seed.Apply(fixtures, repos.categories, repos.customers, repos.products)
+	if err != nil {
+		return fmt.Errorf("seeded %d records before failing: %w", result.Created, err)
+	}
+
+	slog.Info("Seeded storage", "file", path, "created", result.Created, "skipped", result.Skipped)
+	return nil
+}
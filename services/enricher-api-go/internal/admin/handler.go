@@ -0,0 +1,194 @@
+// Package admin exposes the /admin API that integration-test environments use
+// to inspect and steer a running server: the effective configuration,
+// feature flags, configuration reloads and resetting in-memory data to its
+// seed state.
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+
+	"enricher-api-go/internal/config"
+	"enricher-api-go/internal/featureflag"
+	"enricher-api-go/internal/logging"
+	"enricher-api-go/internal/problem"
+
+	"github.com/labstack/echo/v4"
+	"gopkg.in/yaml.v3"
+)
+
+// Dataset is data that can be returned to its seed state, such as an
+// in-memory repository
+type Dataset interface {
+	Reset()
+}
+
+// Handler handles the admin API
+type Handler struct {
+	// config is the configuration the server runs with
+	config *config.Config
+	flags  *featureflag.Flags
+	// load reads the configuration again, and apply puts the settings Reload
+	// takes into effect
+	load  func() (*config.Config, error)
+	apply func(cfg *config.Config)
+	// datasets are reset by name
+	datasets map[string]Dataset
+	mutex    sync.Mutex
+}
+
+// Option configures a Handler
+type Option func(*Handler)
+
+// WithReload lets the handler reload the configuration with load, applying
+// the settings that change without a restart with apply
+func WithReload(load func() (*config.Config, error), apply func(cfg *config.Config)) Option {
+	return func(h *Handler) {
+		h.load, h.apply = load, apply
+	}
+}
+
+// WithDataset lets the handler reset dataset, reported under name
+func WithDataset(name string, dataset Dataset) Option {
+	return func(h *Handler) {
+		h.datasets[name] = dataset
+	}
+}
+
+// NewHandler creates an admin handler for the server running with cfg
+func NewHandler(cfg *config.Config, flags *featureflag.Flags, opts ...Option) *Handler {
+	current := *cfg
+	h := &Handler{
+		config:   &current,
+		flags:    flags,
+		datasets: make(map[string]Dataset),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// GetConfig handles GET /admin/config
+//
+// The configuration is keyed as in the YAML file, with secrets redacted.
+func (h *Handler) GetConfig(c echo.Context) error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	return h.sendConfig(c)
+}
+
+// ReloadConfig handles POST /admin/config/reload
+//
+// It reads CONFIG_FILE and the environment again and applies the log level
+// and feature flags, answering with the resulting configuration. Feature
+// flags toggled since are overwritten. Other settings take effect on the
+// next restart. An invalid configuration answers 422 and changes nothing.
+func (h *Handler) ReloadConfig(c echo.Context) error {
+	if h.load == nil {
+		return problem.Write(c, http.StatusNotImplemented, "Configuration reload is not supported")
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	next, err := h.load()
+	if err != nil {
+		return problem.Write(c, http.StatusUnprocessableEntity, err.Error())
+	}
+	h.config.Reload(next)
+	h.apply(h.config)
+	logging.FromContext(c.Request().Context()).Info("Reloaded configuration", "log_level", h.config.LogLevel)
+
+	return h.sendConfig(c)
+}
+
+// sendConfig answers with the redacted configuration; callers hold the mutex
+func (h *Handler) sendConfig(c echo.Context) error {
+	// Round trip through YAML so keys and durations read as in the file
+	data, err := yaml.Marshal(h.config.Redacted())
+	if err != nil {
+		return problem.ServerError(c, err)
+	}
+	var body map[string]interface{}
+	if err := yaml.Unmarshal(data, &body); err != nil {
+		return problem.ServerError(c, err)
+	}
+	return c.JSON(http.StatusOK, body)
+}
+
+// ListFlags handles GET /admin/flags
+//
+// Example response:
+//
+//	{"flags": {"httpCache": true, "serverTiming": false}}
+func (h *Handler) ListFlags(c echo.Context) error {
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"flags": h.flags.All(),
+	})
+}
+
+// FlagRequest sets a feature flag
+type FlagRequest struct {
+	Enabled *bool `json:"enabled" validate:"required"`
+}
+
+// SetFlag handles PUT /admin/flags/:name
+//
+// Example request:
+//
+//	PUT /admin/flags/serverTiming
+//
+//	{"enabled": true}
+func (h *Handler) SetFlag(c echo.Context) error {
+	var req FlagRequest
+	if err := c.Bind(&req); err != nil {
+		return problem.BindError(c, err)
+	}
+
+	name := c.Param("name")
+	if err := h.flags.Set(name, *req.Enabled); err != nil {
+		if errors.Is(err, featureflag.ErrUnknownFlag) {
+			return problem.Write(c, http.StatusNotFound, "Feature flag not found")
+		}
+		return problem.Error(c, err)
+	}
+	logging.FromContext(c.Request().Context()).Info("Set feature flag", "flag", name, "enabled", *req.Enabled)
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"name":    name,
+		"enabled": *req.Enabled,
+	})
+}
+
+// ResetData handles POST /admin/reset
+//
+// Every in-memory dataset returns to its seed state. Storage backends other
+// than memory cannot be reset and answer 409.
+//
+// Example response:
+//
+//	{"reset": ["categories", "customers", "orders", "products"]}
+func (h *Handler) ResetData(c echo.Context) error {
+	if len(h.datasets) == 0 {
+		return problem.Write(c, http.StatusConflict, "Only in-memory storage can be reset")
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	names := make([]string, 0, len(h.datasets))
+	for name, dataset := range h.datasets {
+		dataset.Reset()
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	logging.FromContext(c.Request().Context()).Warn("Reset data to seed state", "datasets", names)
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"reset": names,
+	})
+}
@@ -0,0 +1,118 @@
+// Package apiversion mounts each version of the API under its own path
+// prefix and shapes responses for the version a request was routed to.
+//
+// Handlers are shared between versions; a version only changes how results
+// are rendered, through Mappers. Routes superseded by a newer version are
+// marked with Deprecated, so clients see it on every response.
+//
+// Example usage:
+//
+//	v1 := apiversion.Group(e, apiversion.V1)
+//	v2 := apiversion.Group(e, apiversion.V2)
+//	v1.GET("/products/:id", handler.GetProduct, apiversion.Deprecated(since, apiversion.V2))
+//	v2.GET("/products/:id", handler.GetProduct)
+package apiversion
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Version names a version of the API, which is also its path prefix
+type Version string
+
+// Supported API versions
+const (
+	V1 Version = "v1"
+	V2 Version = "v2"
+)
+
+// Response headers of deprecated routes
+const (
+	// HeaderDeprecation marks a deprecated route (RFC 9745)
+	HeaderDeprecation = "Deprecation"
+	// HeaderLink links the route's successor (RFC 8288)
+	HeaderLink = "Link"
+)
+
+// contextKey is the echo.Context key holding the version of the request
+const contextKey = "apiversion"
+
+// Prefix returns the path prefix of the version, e.g. /v2
+func (v Version) Prefix() string {
+	return "/" + string(v)
+}
+
+// Group mounts a route group for version v, recording v on every request
+// routed through it
+func Group(e *echo.Echo, v Version, m ...echo.MiddlewareFunc) *echo.Group {
+	tag := func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set(contextKey, v)
+			return next(c)
+		}
+	}
+	return e.Group(v.Prefix(), append([]echo.MiddlewareFunc{tag}, m...)...)
+}
+
+// FromContext returns the version the request was routed to; requests
+// outside a version group are V1
+func FromContext(c echo.Context) Version {
+	if v, ok := c.Get(contextKey).(Version); ok {
+		return v
+	}
+	return V1
+}
+
+// Deprecated marks responses of a route as deprecated since the given time
+// and links the same path in the successor version, which must serve it
+func Deprecated(since time.Time, successor Version) echo.MiddlewareFunc {
+	deprecation := "@" + strconv.FormatInt(since.Unix(), 10)
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			path := strings.TrimPrefix(c.Request().URL.Path, FromContext(c).Prefix())
+			header := c.Response().Header()
+			header.Set(HeaderDeprecation, deprecation)
+			header.Add(HeaderLink, fmt.Sprintf(`<%s%s>; rel="successor-version"`, successor.Prefix(), path))
+			return next(c)
+		}
+	}
+}
+
+// Mappers render a value of type T as the response of each version.
+// Versions without a mapper of their own use V1's.
+type Mappers[T any] map[Version]func(T) interface{}
+
+// Map renders value for the version of the request
+func (m Mappers[T]) Map(c echo.Context, value T) interface{} {
+	return m.mapper(c)(value)
+}
+
+// MapAll renders each of values for the version of the request
+func (m Mappers[T]) MapAll(c echo.Context, values []T) []interface{} {
+	mapper := m.mapper(c)
+	mapped := make([]interface{}, len(values))
+	for i, value := range values {
+		mapped[i] = mapper(value)
+	}
+	return mapped
+}
+
+// Sample renders the zero value for the version of the request, describing
+// the shape of its responses
+func (m Mappers[T]) Sample(c echo.Context) interface{} {
+	var zero T
+	return m.Map(c, zero)
+}
+
+// mapper returns the mapper of the request's version
+func (m Mappers[T]) mapper(c echo.Context) func(T) interface{} {
+	if mapper, ok := m[FromContext(c)]; ok {
+		return mapper
+	}
+	return m[V1]
+}
@@ -0,0 +1,87 @@
+package apiversion
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// mappers wrap a name in an object for V2 and leave it as it is otherwise
+var mappers = Mappers[string]{
+	V1: func(name string) interface{} { return name },
+	V2: func(name string) interface{} { return map[string]string{"NAME": name} },
+}
+
+func TestGroup(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		expected string
+	}{
+		{name: "v1", path: "/v1/items", expected: `"widget"`},
+		{name: "v2", path: "/v2/items", expected: `{"NAME":"widget"}`},
+		{name: "unversioned", path: "/items", expected: `"widget"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler := func(c echo.Context) error {
+				return c.JSON(http.StatusOK, mappers.Map(c, "widget"))
+			}
+			e := echo.New()
+			Group(e, V1).GET("/items", handler)
+			Group(e, V2).GET("/items", handler)
+			e.GET("/items", handler)
+			rec := httptest.NewRecorder()
+
+			// Act
+			e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			// Assert
+			if got := rec.Body.String(); got != tt.expected+"\n" {
+				t.Errorf("Expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestDeprecated(t *testing.T) {
+	// Arrange
+	since := time.Date(2026, time.October, 16, 0, 0, 0, 0, time.UTC)
+	e := echo.New()
+	Group(e, V1).GET("/items/:id", func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	}, Deprecated(since, V2))
+	rec := httptest.NewRecorder()
+
+	// Act
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/items/7?verbose=true", nil))
+
+	// Assert
+	if got := rec.Header().Get(HeaderDeprecation); got != "@1792108800" {
+		t.Errorf("Expected Deprecation @1792108800, got %q", got)
+	}
+	if got := rec.Header().Get(HeaderLink); got != `</v2/items/7>; rel="successor-version"` {
+		t.Errorf("Expected a link to the v2 route, got %q", got)
+	}
+}
+
+func TestMappers_Fallback(t *testing.T) {
+	// Arrange
+	v1Only := Mappers[string]{V1: func(name string) interface{} { return "v1:" + name }}
+	e := echo.New()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/v2/items", nil), httptest.NewRecorder())
+	c.Set(contextKey, V2)
+
+	// Act
+	mapped := v1Only.MapAll(c, []string{"a", "b"})
+
+	// Assert
+	if len(mapped) != 2 || mapped[0] != "v1:a" || mapped[1] != "v1:b" {
+		t.Errorf("Expected the V1 mapper for a version without one, got %v", mapped)
+	}
+}
@@ -0,0 +1,102 @@
+// Package apperr classifies domain errors into a few kinds that callers,
+// the HTTP layer above all, can act on without knowing every error of every
+// package.
+//
+// A domain error is declared with New and keeps its own identity, so both
+// errors.Is(err, customer.ErrCustomerNotFound) and errors.Is(err,
+// apperr.ErrNotFound) hold for it, through any wrapping:
+//
+//	var ErrCustomerNotFound = apperr.New(apperr.ErrNotFound, "customer not found")
+//
+//	return fmt.Errorf("failed to get customer: %w", ErrCustomerNotFound)
+package apperr
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Kinds of domain errors
+var (
+	// ErrNotFound is a missing record
+	ErrNotFound = errors.New("not found")
+	// ErrValidation is a request that can never succeed as made
+	ErrValidation = errors.New("validation failed")
+	// ErrConflict is a request the current state of a record does not allow
+	ErrConflict = errors.New("conflict")
+	// ErrUnavailable is a dependency that cannot serve the request right now;
+	// the same request may succeed later
+	ErrUnavailable = errors.New("unavailable")
+)
+
+// kindError is a domain error of a kind
+type kindError struct {
+	kind    error
+	message string
+}
+
+// New returns an error with message that is of kind
+func New(kind error, message string) error {
+	return &kindError{kind: kind, message: message}
+}
+
+// Errorf returns an error of kind formatted like fmt.Errorf, wrapping any %w
+// operands
+func Errorf(kind error, format string, args ...interface{}) error {
+	return &wrapError{kind: kind, err: fmt.Errorf(format, args...)}
+}
+
+func (e *kindError) Error() string {
+	return e.message
+}
+
+// Is reports whether target is the error's kind
+func (e *kindError) Is(target error) bool {
+	return target == e.kind
+}
+
+// wrapError is a formatted error of a kind
+type wrapError struct {
+	kind error
+	err  error
+}
+
+func (e *wrapError) Error() string {
+	return e.err.Error()
+}
+
+// Is reports whether target is the error's kind
+func (e *wrapError) Is(target error) bool {
+	return target == e.kind
+}
+
+func (e *wrapError) Unwrap() error {
+	return e.err
+}
+
+// Kind returns the kind of err, or nil for an error of no kind. Where err
+// wraps errors of several kinds the outermost wins, so a caller can classify
+// an error it passes on by wrapping it with Errorf.
+func Kind(err error) error {
+	switch e := err.(type) {
+	case nil:
+		return nil
+	case *kindError:
+		return e.kind
+	case *wrapError:
+		return e.kind
+	case interface{ Unwrap() []error }:
+		for _, err := range e.Unwrap() {
+			if kind := Kind(err); kind != nil {
+				return kind
+			}
+		}
+		return nil
+	}
+
+	switch err {
+	case ErrNotFound, ErrValidation, ErrConflict, ErrUnavailable:
+		return err
+	}
+	return Kind(errors.Unwrap(err))
+}
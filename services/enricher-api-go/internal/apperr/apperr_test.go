@@ -0,0 +1,69 @@
+package apperr
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestNew_IsItselfAndItsKind(t *testing.T) {
+	// Arrange
+	errMissing := New(ErrNotFound, "order not found")
+
+	// Act
+	err := fmt.Errorf("failed to get order: %w", errMissing)
+
+	// Assert
+	if !errors.Is(err, errMissing) || !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected %v to be both the domain error and ErrNotFound", err)
+	}
+	if errors.Is(err, ErrConflict) || errors.Is(err, New(ErrNotFound, "order not found")) {
+		t.Errorf("Expected %v to match only its own error and kind", err)
+	}
+	if err.Error() != "failed to get order: order not found" {
+		t.Errorf("Expected the domain message, got %q", err.Error())
+	}
+}
+
+func TestErrorf_KeepsWrappedErrors(t *testing.T) {
+	// Arrange
+	errMissing := New(ErrNotFound, "category not found")
+
+	// Act
+	err := Errorf(ErrValidation, "invalid filter: %w", errMissing)
+
+	// Assert
+	if !errors.Is(err, ErrValidation) || !errors.Is(err, errMissing) {
+		t.Errorf("Expected %v to be of its kind and wrap %v", err, errMissing)
+	}
+	if Kind(err) != ErrValidation {
+		t.Errorf("Expected the outermost kind, ErrValidation, got %v", Kind(err))
+	}
+}
+
+func TestKind(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected error
+	}{
+		{name: "not found", err: New(ErrNotFound, "missing"), expected: ErrNotFound},
+		{name: "validation", err: Errorf(ErrValidation, "quantity %d", 0), expected: ErrValidation},
+		{name: "conflict", err: fmt.Errorf("wrapped: %w", New(ErrConflict, "taken")), expected: ErrConflict},
+		{name: "unavailable", err: ErrUnavailable, expected: ErrUnavailable},
+		{name: "joined", err: fmt.Errorf("%w: %w", New(ErrValidation, "invalid filter"), errors.New("cannot sort on name")), expected: ErrValidation},
+		{name: "no kind", err: errors.New("disk full"), expected: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			kind := Kind(tt.err)
+
+			// Assert
+			if kind != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, kind)
+			}
+		})
+	}
+}
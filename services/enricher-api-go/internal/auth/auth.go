@@ -0,0 +1,295 @@
+// Package auth resolves the roles of a caller from its bearer token or API
+// key and enforces role-based access to the API.
+//
+// Roles are ordered: readers may GET, operators may also POST, PUT and
+// PATCH, and admins may also DELETE.
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"enricher-api-go/internal/jwtauth"
+	"enricher-api-go/internal/logging"
+	"enricher-api-go/internal/problem"
+
+	"github.com/labstack/echo/v4"
+)
+
+// APIKeyHeader carries an API key
+const APIKeyHeader = "X-API-Key"
+
+// principalKey is the Echo context key holding the authenticated Principal
+const principalKey = "auth.principal"
+
+// contextKey is the request context key holding the authenticated Principal
+type contextKey struct{}
+
+// Role grants access to a set of HTTP methods
+type Role string
+
+// Supported roles, from least to most privileged
+const (
+	RoleReader   Role = "reader"
+	RoleOperator Role = "operator"
+	RoleAdmin    Role = "admin"
+)
+
+// roleRanks orders the roles; a role includes every lower-ranked role
+var roleRanks = map[Role]int{
+	RoleReader:   1,
+	RoleOperator: 2,
+	RoleAdmin:    3,
+}
+
+var (
+	// ErrMissingCredentials is returned when a request has no API key or bearer token
+	ErrMissingCredentials = errors.New("missing credentials")
+	// ErrInvalidAPIKey is returned when an API key is not configured
+	ErrInvalidAPIKey = errors.New("invalid API key")
+)
+
+// ParseRole converts a configured role name into a Role
+func ParseRole(name string) (Role, error) {
+	role := Role(name)
+	if _, ok := roleRanks[role]; !ok {
+		return "", fmt.Errorf("unknown role %q (expected %s, %s or %s)", name, RoleReader, RoleOperator, RoleAdmin)
+	}
+	return role, nil
+}
+
+// Includes reports whether r grants at least the access of required
+func (r Role) Includes(required Role) bool {
+	rank, ok := roleRanks[r]
+	return ok && rank >= roleRanks[required]
+}
+
+// RequiredRole returns the least privileged role allowed to use method
+func RequiredRole(method string) Role {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return RoleReader
+	case http.MethodDelete:
+		return RoleAdmin
+	default:
+		return RoleOperator
+	}
+}
+
+// Principal is an authenticated caller
+type Principal struct {
+	// Subject identifies the caller: the token subject or the API key name
+	Subject string
+	// Roles are the caller's recognised roles
+	Roles []Role
+	// APIKey is true when the caller authenticated with an API key
+	APIKey bool
+}
+
+// HasRole reports whether any of the principal's roles includes required
+func (p *Principal) HasRole(required Role) bool {
+	for _, role := range p.Roles {
+		if role.Includes(required) {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKey is a static credential granting one role
+type APIKey struct {
+	Name string
+	Key  string
+	Role Role
+}
+
+// Config configures an Authorizer
+type Config struct {
+	// APIKeys are accepted in the X-API-Key header
+	APIKeys []APIKey
+	// Bearer authenticates requests without an API key, typically
+	// jwtauth.Authenticator.Middleware; such requests are rejected if nil
+	Bearer echo.MiddlewareFunc
+}
+
+// Authorizer authenticates callers and checks their roles
+type Authorizer struct {
+	apiKeys []hashedAPIKey
+	bearer  echo.MiddlewareFunc
+}
+
+// hashedAPIKey stores a key digest so lookups compare fixed-length values
+type hashedAPIKey struct {
+	name   string
+	digest [sha256.Size]byte
+	role   Role
+}
+
+// New creates an Authorizer for cfg
+func New(cfg Config) *Authorizer {
+	keys := make([]hashedAPIKey, len(cfg.APIKeys))
+	for i, key := range cfg.APIKeys {
+		keys[i] = hashedAPIKey{name: key.Name, digest: sha256.Sum256([]byte(key.Key)), role: key.Role}
+	}
+	return &Authorizer{apiKeys: keys, bearer: cfg.Bearer}
+}
+
+// Authenticate resolves the caller from the X-API-Key header or, without
+// one, from the bearer token, rejecting unknown callers with 401. The
+// X-API-Key header is ignored when no API keys are configured. The caller is
+// stored in both the Echo context and the request context.
+func (a *Authorizer) Authenticate() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		withClaims := func(c echo.Context) error {
+			claims, ok := jwtauth.ClaimsFrom(c)
+			if !ok {
+				return unauthorized(c, ErrMissingCredentials)
+			}
+			setPrincipal(c, &Principal{Subject: claims.Subject, Roles: rolesFrom(claims.Roles)})
+			return next(c)
+		}
+		var bearer echo.HandlerFunc
+		if a.bearer != nil {
+			bearer = a.bearer(withClaims)
+		}
+
+		return func(c echo.Context) error {
+			if key := c.Request().Header.Get(APIKeyHeader); key != "" && len(a.apiKeys) > 0 {
+				principal, ok := a.lookupAPIKey(key)
+				if !ok {
+					return unauthorized(c, ErrInvalidAPIKey)
+				}
+				setPrincipal(c, principal)
+				return next(c)
+			}
+
+			if bearer == nil {
+				return unauthorized(c, ErrMissingCredentials)
+			}
+			return bearer(c)
+		}
+	}
+}
+
+// Authorize rejects callers whose roles do not allow the request method
+// with 403. It must run after Authorizer.Authenticate.
+func Authorize() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			principal, ok := PrincipalFrom(c)
+			if !ok {
+				return unauthorized(c, ErrMissingCredentials)
+			}
+
+			required := RequiredRole(c.Request().Method)
+			if !principal.HasRole(required) {
+				logging.FromContext(c.Request().Context()).Info("Rejected request lacking role",
+					"subject", principal.Subject, "required_role", string(required))
+				return problem.Write(c, http.StatusForbidden, fmt.Sprintf("%s role required for %s", required, c.Request().Method))
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// RequireRole rejects callers lacking role with 403 on requests for which
+// when returns true, for privileges tied to a query parameter rather than a
+// method. It must run after Authorizer.Authenticate.
+func RequireRole(role Role, when func(c echo.Context) bool) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if !when(c) {
+				return next(c)
+			}
+
+			principal, ok := PrincipalFrom(c)
+			if !ok {
+				return unauthorized(c, ErrMissingCredentials)
+			}
+			if !principal.HasRole(role) {
+				logging.FromContext(c.Request().Context()).Info("Rejected request lacking role",
+					"subject", principal.Subject, "required_role", string(role))
+				return problem.Write(c, http.StatusForbidden, fmt.Sprintf("%s role required", role))
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// BearerOnly applies m only to requests authenticated by bearer token, so
+// token-specific checks such as scopes do not reject API key callers
+func BearerOnly(m echo.MiddlewareFunc) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		guarded := m(next)
+		return func(c echo.Context) error {
+			if principal, ok := PrincipalFrom(c); ok && principal.APIKey {
+				return next(c)
+			}
+			return guarded(c)
+		}
+	}
+}
+
+// PrincipalFrom returns the caller stored by Authorizer.Authenticate
+func PrincipalFrom(c echo.Context) (*Principal, bool) {
+	principal, ok := c.Get(principalKey).(*Principal)
+	return principal, ok
+}
+
+// WithPrincipal returns a copy of ctx carrying principal
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, principal)
+}
+
+// Caller returns the subject of the principal stored in ctx, or "" for
+// unauthenticated requests and background work
+func Caller(ctx context.Context) string {
+	if principal, ok := ctx.Value(contextKey{}).(*Principal); ok {
+		return principal.Subject
+	}
+	return ""
+}
+
+// setPrincipal stores principal for PrincipalFrom and Caller
+func setPrincipal(c echo.Context, principal *Principal) {
+	c.Set(principalKey, principal)
+	c.SetRequest(c.Request().WithContext(WithPrincipal(c.Request().Context(), principal)))
+}
+
+// lookupAPIKey finds the configured key matching key in constant time
+func (a *Authorizer) lookupAPIKey(key string) (*Principal, bool) {
+	digest := sha256.Sum256([]byte(key))
+	var match *hashedAPIKey
+	for i := range a.apiKeys {
+		if subtle.ConstantTimeCompare(digest[:], a.apiKeys[i].digest[:]) == 1 {
+			match = &a.apiKeys[i]
+		}
+	}
+	if match == nil {
+		return nil, false
+	}
+	return &Principal{Subject: match.name, Roles: []Role{match.role}, APIKey: true}, true
+}
+
+// rolesFrom keeps the recognised role names of a token's roles claim
+func rolesFrom(names []string) []Role {
+	var roles []Role
+	for _, name := range names {
+		if role, err := ParseRole(name); err == nil {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
+
+// unauthorized rejects a request with 401
+func unauthorized(c echo.Context, err error) error {
+	logging.FromContext(c.Request().Context()).Info("Rejected unauthenticated request", "error", err)
+	return problem.Write(c, http.StatusUnauthorized, err.Error())
+}
@@ -0,0 +1,172 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestRequiredRole(t *testing.T) {
+	tests := []struct {
+		method string
+		want   Role
+	}{
+		{method: http.MethodGet, want: RoleReader},
+		{method: http.MethodHead, want: RoleReader},
+		{method: http.MethodPost, want: RoleOperator},
+		{method: http.MethodPut, want: RoleOperator},
+		{method: http.MethodPatch, want: RoleOperator},
+		{method: http.MethodDelete, want: RoleAdmin},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			// Act
+			got := RequiredRole(tt.method)
+
+			// Assert
+			if got != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRole_Includes(t *testing.T) {
+	// Assert
+	if !RoleAdmin.Includes(RoleOperator) || !RoleOperator.Includes(RoleReader) {
+		t.Error("Expected higher roles to include lower ones")
+	}
+	if RoleReader.Includes(RoleOperator) || RoleOperator.Includes(RoleAdmin) {
+		t.Error("Expected lower roles not to include higher ones")
+	}
+	if Role("owner").Includes(RoleReader) {
+		t.Error("Expected unknown roles to grant nothing")
+	}
+}
+
+func TestParseRole(t *testing.T) {
+	// Act
+	role, err := ParseRole("operator")
+	_, unknownErr := ParseRole("owner")
+
+	// Assert
+	if err != nil || role != RoleOperator {
+		t.Errorf("Expected operator role, got %q (%v)", role, err)
+	}
+	if unknownErr == nil {
+		t.Error("Expected an error for an unknown role")
+	}
+}
+
+func TestAuthorizer_Middleware(t *testing.T) {
+	// Arrange
+	// The stub bearer middleware accepts any token and grants no roles,
+	// standing in for jwtauth, whose role claims are covered by its own tests
+	bearerCalls := 0
+	bearer := func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			bearerCalls++
+			return c.NoContent(http.StatusUnauthorized)
+		}
+	}
+	authorizer := New(Config{
+		APIKeys: []APIKey{{Name: "ci", Key: "operator-key", Role: RoleOperator}},
+		Bearer:  bearer,
+	})
+
+	e := echo.New()
+	handler := func(c echo.Context) error {
+		principal, _ := PrincipalFrom(c)
+		return c.String(http.StatusOK, principal.Subject)
+	}
+	group := e.Group("", authorizer.Authenticate(), Authorize())
+	group.POST("/products", handler)
+	group.DELETE("/products/:id", handler)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		apiKey     string
+		wantStatus int
+		wantBearer int
+	}{
+		{name: "operator can POST", method: http.MethodPost, path: "/products", apiKey: "operator-key", wantStatus: http.StatusOK},
+		{name: "operator cannot DELETE", method: http.MethodDelete, path: "/products/p1", apiKey: "operator-key", wantStatus: http.StatusForbidden},
+		{name: "unknown API key", method: http.MethodPost, path: "/products", apiKey: "wrong", wantStatus: http.StatusUnauthorized},
+		{name: "no API key falls back to bearer", method: http.MethodPost, path: "/products", wantStatus: http.StatusUnauthorized, wantBearer: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bearerCalls = 0
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.apiKey != "" {
+				req.Header.Set(APIKeyHeader, tt.apiKey)
+			}
+			rec := httptest.NewRecorder()
+
+			// Act
+			e.ServeHTTP(rec, req)
+
+			// Assert
+			if rec.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+			if bearerCalls != tt.wantBearer {
+				t.Errorf("Expected %d bearer calls, got %d", tt.wantBearer, bearerCalls)
+			}
+		})
+	}
+}
+
+func TestBearerOnly_SkipsAPIKeyCallers(t *testing.T) {
+	// Arrange
+	reject := func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			return c.NoContent(http.StatusForbidden)
+		}
+	}
+	e := echo.New()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+	c.Set(principalKey, &Principal{Subject: "ci", Roles: []Role{RoleReader}, APIKey: true})
+
+	// Act
+	called := false
+	err := BearerOnly(reject)(func(c echo.Context) error {
+		called = true
+		return nil
+	})(c)
+
+	// Assert
+	if err != nil || !called {
+		t.Errorf("Expected API key caller to skip the bearer-only check, got called=%v err=%v", called, err)
+	}
+}
+
+func TestAuthenticate_StoresCallerInRequestContext(t *testing.T) {
+	// Arrange
+	authorizer := New(Config{APIKeys: []APIKey{{Name: "ci", Key: "operator-key", Role: RoleOperator}}})
+	e := echo.New()
+	var caller string
+	e.POST("/products", func(c echo.Context) error {
+		caller = Caller(c.Request().Context())
+		return c.NoContent(http.StatusNoContent)
+	}, authorizer.Authenticate())
+	req := httptest.NewRequest(http.MethodPost, "/products", nil)
+	req.Header.Set(APIKeyHeader, "operator-key")
+
+	// Act
+	e.ServeHTTP(httptest.NewRecorder(), req)
+
+	// Assert
+	if caller != "ci" {
+		t.Errorf("Expected caller ci, got %q", caller)
+	}
+	if anonymous := Caller(req.Context()); anonymous != "" {
+		t.Errorf("Expected no caller outside the request, got %q", anonymous)
+	}
+}
@@ -0,0 +1,222 @@
+// Package breaker stops calling a failing dependency for a while so requests
+// fail fast instead of piling up behind timeouts.
+//
+// A Breaker starts closed and lets every call through. After FailureThreshold
+// consecutive failures it opens and rejects calls with ErrOpen. Once
+// OpenTimeout has passed it lets HalfOpenRequests trial calls through: if they
+// all succeed it closes again, and any failure reopens it.
+package breaker
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"enricher-api-go/internal/apperr"
+)
+
+// Defaults applied to zero Settings fields
+const (
+	DefaultFailureThreshold = 5
+	DefaultOpenTimeout      = 30 * time.Second
+	DefaultHalfOpenRequests = 1
+)
+
+// ErrOpen is returned instead of calling the dependency while the breaker is open
+var ErrOpen = apperr.New(apperr.ErrUnavailable, "circuit breaker is open")
+
+// State is the position of a breaker
+type State int
+
+// Breaker states
+const (
+	StateClosed State = iota
+	StateHalfOpen
+	StateOpen
+)
+
+// String returns the lower-case state name
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateHalfOpen:
+		return "half-open"
+	case StateOpen:
+		return "open"
+	default:
+		return fmt.Sprintf("state(%d)", int(s))
+	}
+}
+
+// Settings configures a Breaker
+type Settings struct {
+	// FailureThreshold is how many consecutive failures open the breaker
+	FailureThreshold int
+	// OpenTimeout is how long the breaker stays open before trial calls
+	OpenTimeout time.Duration
+	// HalfOpenRequests is how many trial calls must succeed to close the breaker
+	HalfOpenRequests int
+}
+
+// Stats is a snapshot of a breaker for health checks and metrics
+type Stats struct {
+	Name  string `json:"name"`
+	State State  `json:"-"`
+	// StateName is State as text, for JSON
+	StateName string `json:"state"`
+	// ConsecutiveFailures counts failures since the last success
+	ConsecutiveFailures int `json:"consecutiveFailures"`
+	// Failures, Successes and Rejections are totals since start
+	Failures   uint64 `json:"failures"`
+	Successes  uint64 `json:"successes"`
+	Rejections uint64 `json:"rejections"`
+	// OpenedAt is when the breaker last opened, if it is not closed
+	OpenedAt *time.Time `json:"openedAt,omitempty"`
+}
+
+// Breaker guards calls to one dependency
+type Breaker struct {
+	name     string
+	settings Settings
+	now      func() time.Time
+
+	mutex               sync.Mutex
+	state               State
+	consecutiveFailures int
+	openedAt            time.Time
+	// trials counts calls admitted while half-open; successes closes the breaker
+	trials, trialSuccesses int
+	failures               uint64
+	successes              uint64
+	rejections             uint64
+}
+
+// New creates a closed Breaker, applying the defaults to zero settings
+func New(name string, settings Settings) *Breaker {
+	if settings.FailureThreshold <= 0 {
+		settings.FailureThreshold = DefaultFailureThreshold
+	}
+	if settings.OpenTimeout <= 0 {
+		settings.OpenTimeout = DefaultOpenTimeout
+	}
+	if settings.HalfOpenRequests <= 0 {
+		settings.HalfOpenRequests = DefaultHalfOpenRequests
+	}
+	return &Breaker{name: name, settings: settings, now: time.Now}
+}
+
+// Name returns the dependency the breaker guards
+func (b *Breaker) Name() string {
+	return b.name
+}
+
+// Execute calls fn unless the breaker is open, in which case it returns an
+// error wrapping ErrOpen. Every error returned by fn counts as a failure, so
+// callers should return nil for expected outcomes such as "not found".
+func (b *Breaker) Execute(fn func() error) error {
+	if err := b.admit(); err != nil {
+		return err
+	}
+
+	err := fn()
+	b.record(err == nil)
+	return err
+}
+
+// State returns the current state, moving an expired open breaker to half-open
+func (b *Breaker) State() State {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.refresh()
+	return b.state
+}
+
+// Stats returns a snapshot of the breaker
+func (b *Breaker) Stats() Stats {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.refresh()
+	stats := Stats{
+		Name:                b.name,
+		State:               b.state,
+		StateName:           b.state.String(),
+		ConsecutiveFailures: b.consecutiveFailures,
+		Failures:            b.failures,
+		Successes:           b.successes,
+		Rejections:          b.rejections,
+	}
+	if b.state != StateClosed {
+		openedAt := b.openedAt
+		stats.OpenedAt = &openedAt
+	}
+	return stats
+}
+
+// admit reserves a call or rejects it with ErrOpen
+func (b *Breaker) admit() error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.refresh()
+	switch b.state {
+	case StateOpen:
+		b.rejections++
+		return fmt.Errorf("%s: %w", b.name, ErrOpen)
+	case StateHalfOpen:
+		if b.trials >= b.settings.HalfOpenRequests {
+			b.rejections++
+			return fmt.Errorf("%s: %w", b.name, ErrOpen)
+		}
+		b.trials++
+	}
+	return nil
+}
+
+// record updates the state with the outcome of an admitted call
+func (b *Breaker) record(success bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if success {
+		b.successes++
+		b.consecutiveFailures = 0
+		if b.state == StateHalfOpen {
+			b.trialSuccesses++
+			if b.trialSuccesses >= b.settings.HalfOpenRequests {
+				b.setState(StateClosed)
+			}
+		}
+		return
+	}
+
+	b.failures++
+	b.consecutiveFailures++
+	if b.state == StateHalfOpen || b.consecutiveFailures >= b.settings.FailureThreshold {
+		b.setState(StateOpen)
+	}
+}
+
+// refresh moves an open breaker to half-open once OpenTimeout has passed
+func (b *Breaker) refresh() {
+	if b.state == StateOpen && b.now().Sub(b.openedAt) >= b.settings.OpenTimeout {
+		b.setState(StateHalfOpen)
+	}
+}
+
+// setState switches state, resetting the trial counters
+func (b *Breaker) setState(state State) {
+	if b.state == state {
+		return
+	}
+	slog.Warn("Circuit breaker changed state", "breaker", b.name, "from", b.state.String(), "to", state.String())
+
+	if state == StateOpen {
+		b.openedAt = b.now()
+	}
+	b.state = state
+	b.trials, b.trialSuccesses = 0, 0
+}
@@ -0,0 +1,147 @@
+package breaker
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+var errBackend = errors.New("connection refused")
+
+// newTestBreaker returns a breaker whose clock the test advances
+func newTestBreaker(settings Settings) (*Breaker, *time.Time) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := New("postgres", settings)
+	b.now = func() time.Time { return now }
+	return b, &now
+}
+
+func fail() error    { return errBackend }
+func succeed() error { return nil }
+
+func TestBreaker_OpensAfterConsecutiveFailures(t *testing.T) {
+	// Arrange
+	b, _ := newTestBreaker(Settings{FailureThreshold: 3, OpenTimeout: time.Minute})
+	calls := 0
+
+	// Act
+	_ = b.Execute(fail)
+	_ = b.Execute(succeed) // resets the streak
+	for i := 0; i < 3; i++ {
+		_ = b.Execute(fail)
+	}
+	err := b.Execute(func() error {
+		calls++
+		return nil
+	})
+
+	// Assert
+	if b.State() != StateOpen {
+		t.Fatalf("Expected open breaker, got %s", b.State())
+	}
+	if !errors.Is(err, ErrOpen) || calls != 0 {
+		t.Errorf("Expected ErrOpen without calling through, got %v after %d calls", err, calls)
+	}
+	if stats := b.Stats(); stats.Failures != 4 || stats.Successes != 1 || stats.Rejections != 1 {
+		t.Errorf("Unexpected counters %+v", stats)
+	}
+}
+
+func TestBreaker_HalfOpenTrials(t *testing.T) {
+	tests := []struct {
+		name  string
+		trial func() error
+		want  State
+	}{
+		{name: "successful trials close it", trial: succeed, want: StateClosed},
+		{name: "a failed trial reopens it", trial: fail, want: StateOpen},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			b, now := newTestBreaker(Settings{FailureThreshold: 1, OpenTimeout: time.Minute, HalfOpenRequests: 2})
+			_ = b.Execute(fail)
+			*now = now.Add(time.Minute)
+
+			// Act
+			state := b.State()
+			_ = b.Execute(tt.trial)
+			_ = b.Execute(tt.trial)
+
+			// Assert
+			if state != StateHalfOpen {
+				t.Errorf("Expected half-open after the timeout, got %s", state)
+			}
+			if b.State() != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, b.State())
+			}
+		})
+	}
+}
+
+func TestBreaker_HalfOpenLimitsTrials(t *testing.T) {
+	// Arrange
+	b, now := newTestBreaker(Settings{FailureThreshold: 1, OpenTimeout: time.Second})
+	_ = b.Execute(fail)
+	*now = now.Add(time.Second)
+
+	// Act
+	var rejected error
+	_ = b.Execute(func() error {
+		// A concurrent call while the only trial is in flight
+		rejected = b.Execute(succeed)
+		return nil
+	})
+
+	// Assert
+	if !errors.Is(rejected, ErrOpen) {
+		t.Errorf("Expected calls beyond the trial budget to be rejected, got %v", rejected)
+	}
+	if b.State() != StateClosed {
+		t.Errorf("Expected the successful trial to close the breaker, got %s", b.State())
+	}
+}
+
+func TestHandlers(t *testing.T) {
+	// Arrange
+	healthy, _ := newTestBreaker(Settings{})
+	healthy.name = "redis"
+	failing, _ := newTestBreaker(Settings{FailureThreshold: 1})
+	_ = failing.Execute(fail)
+
+	e := echo.New()
+	e.GET("/health/dependencies", DependenciesHandler(healthy, failing))
+	e.GET("/metrics", MetricsHandler(healthy, failing))
+	serve := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	// Act
+	dependencies := serve("/health/dependencies")
+	metrics := serve("/metrics")
+
+	// Assert
+	if dependencies.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 with an open breaker, got %d", dependencies.Code)
+	}
+	if !strings.Contains(dependencies.Body.String(), `"state":"open"`) {
+		t.Errorf("Expected the open state in %s", dependencies.Body.String())
+	}
+	for _, line := range []string{
+		`circuit_breaker_state{name="redis"} 0`,
+		`circuit_breaker_state{name="postgres"} 2`,
+		`circuit_breaker_failures_total{name="postgres"} 1`,
+	} {
+		if !strings.Contains(metrics.Body.String(), line) {
+			t.Errorf("Expected metrics to contain %q, got:\n%s", line, metrics.Body.String())
+		}
+	}
+}
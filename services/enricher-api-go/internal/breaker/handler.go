@@ -0,0 +1,69 @@
+package breaker
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// MIMEMetrics is the content type of the Prometheus text exposition format
+const MIMEMetrics = "text/plain; version=0.0.4; charset=utf-8"
+
+// DependenciesHandler serves GET /health/dependencies: the state of every
+// breaker, with 503 while any of them is open
+func DependenciesHandler(breakers ...*Breaker) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		status, code := "ok", http.StatusOK
+		dependencies := make([]Stats, len(breakers))
+		for i, b := range breakers {
+			dependencies[i] = b.Stats()
+			if dependencies[i].State == StateOpen {
+				status, code = "unavailable", http.StatusServiceUnavailable
+			}
+		}
+
+		return c.JSON(code, map[string]interface{}{
+			"status":       status,
+			"dependencies": dependencies,
+		})
+	}
+}
+
+// MetricsHandler serves breaker state and counters in the Prometheus text
+// exposition format
+func MetricsHandler(breakers ...*Breaker) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var body strings.Builder
+		WriteMetrics(&body, breakers...)
+		return c.Blob(http.StatusOK, MIMEMetrics, []byte(body.String()))
+	}
+}
+
+// WriteMetrics writes breaker state and counters to w in the Prometheus
+// text exposition format
+func WriteMetrics(w io.Writer, breakers ...*Breaker) {
+	stats := make([]Stats, len(breakers))
+	for i, b := range breakers {
+		stats[i] = b.Stats()
+	}
+
+	metric := func(name, kind, help string, value func(Stats) interface{}) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+		for _, s := range stats {
+			fmt.Fprintf(w, "%s{name=%q} %v\n", name, s.Name, value(s))
+		}
+	}
+	metric("circuit_breaker_state", "gauge", "Circuit breaker state (0 closed, 1 half-open, 2 open).",
+		func(s Stats) interface{} { return int(s.State) })
+	metric("circuit_breaker_consecutive_failures", "gauge", "Failures since the last successful call.",
+		func(s Stats) interface{} { return s.ConsecutiveFailures })
+	metric("circuit_breaker_failures_total", "counter", "Calls that failed.",
+		func(s Stats) interface{} { return s.Failures })
+	metric("circuit_breaker_successes_total", "counter", "Calls that succeeded.",
+		func(s Stats) interface{} { return s.Successes })
+	metric("circuit_breaker_rejections_total", "counter", "Calls rejected while the breaker was open.",
+		func(s Stats) interface{} { return s.Rejections })
+}
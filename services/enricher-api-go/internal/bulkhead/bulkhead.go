@@ -0,0 +1,164 @@
+// Package bulkhead isolates route groups from each other by capping the
+// requests each may have in flight, so a flood of requests to one resource,
+// such as product imports, cannot take the capacity another needs.
+//
+// A request takes a slot of the bulkhead of its route group, waiting a little
+// for one to free up when all are taken, and answers 503 with a Retry-After
+// header if none does. Routes outside every group are not capped.
+//
+// Example usage:
+//
+//	customers := bulkhead.New("/v1/customers", 100, 50*time.Millisecond)
+//	products := bulkhead.New("/v1/products", 50, 50*time.Millisecond)
+//	e.Use(bulkhead.Middleware(customers, products))
+package bulkhead
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"enricher-api-go/internal/logging"
+	"enricher-api-go/internal/problem"
+
+	"github.com/labstack/echo/v4"
+)
+
+// retryAfter is how long rejected clients are told to wait, in seconds
+const retryAfter = "1"
+
+// Stats is a snapshot of a bulkhead
+type Stats struct {
+	Name string
+	// Capacity is the most requests in flight
+	Capacity int
+	// Active is the requests in flight
+	Active int
+	// Waiting is the requests waiting for a slot
+	Waiting int
+	// Rejections counts the requests that got no slot
+	Rejections uint64
+}
+
+// Saturation is the fraction of the capacity in use, from 0 to 1
+func (s Stats) Saturation() float64 {
+	return float64(s.Active) / float64(s.Capacity)
+}
+
+// Bulkhead caps the requests of a route group in flight
+type Bulkhead struct {
+	name    string
+	slots   chan struct{}
+	maxWait time.Duration
+
+	waiting    atomic.Int64
+	rejections atomic.Uint64
+}
+
+// New creates a bulkhead of the routes under the path prefix name, letting
+// maxConcurrent requests in flight and others wait up to maxWait for a slot
+func New(name string, maxConcurrent int, maxWait time.Duration) *Bulkhead {
+	return &Bulkhead{name: name, slots: make(chan struct{}, maxConcurrent), maxWait: maxWait}
+}
+
+// Acquire takes a slot, waiting until one frees up, maxWait passes or ctx
+// is done. It reports whether it got one, which must then be released.
+func (b *Bulkhead) Acquire(ctx context.Context) bool {
+	select {
+	case b.slots <- struct{}{}:
+		return true
+	default:
+	}
+
+	if b.maxWait > 0 {
+		b.waiting.Add(1)
+		defer b.waiting.Add(-1)
+		timer := time.NewTimer(b.maxWait)
+		defer timer.Stop()
+
+		select {
+		case b.slots <- struct{}{}:
+			return true
+		case <-timer.C:
+		case <-ctx.Done():
+		}
+	}
+	b.rejections.Add(1)
+	return false
+}
+
+// Release frees a slot taken by Acquire
+func (b *Bulkhead) Release() {
+	<-b.slots
+}
+
+// Stats returns the current use of the bulkhead
+func (b *Bulkhead) Stats() Stats {
+	return Stats{
+		Name:       b.name,
+		Capacity:   cap(b.slots),
+		Active:     len(b.slots),
+		Waiting:    int(b.waiting.Load()),
+		Rejections: b.rejections.Load(),
+	}
+}
+
+// Middleware runs each request in the bulkhead whose name is the longest
+// prefix of its route, answering 503 with a Retry-After header when the
+// bulkhead has no slot for it
+func Middleware(bulkheads ...*Bulkhead) echo.MiddlewareFunc {
+	// Longest prefix first so nested groups win
+	bulkheads = append([]*Bulkhead(nil), bulkheads...)
+	sort.Slice(bulkheads, func(i, j int) bool {
+		return len(bulkheads[i].name) > len(bulkheads[j].name)
+	})
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			for _, b := range bulkheads {
+				if !strings.HasPrefix(c.Path(), b.name) {
+					continue
+				}
+				if !b.Acquire(c.Request().Context()) {
+					logging.FromContext(c.Request().Context()).Warn("Bulkhead full", "bulkhead", b.name, "route", c.Path())
+					c.Response().Header().Set(echo.HeaderRetryAfter, retryAfter)
+					return problem.Write(c, http.StatusServiceUnavailable, "too many concurrent requests to "+b.name)
+				}
+				defer b.Release()
+				break
+			}
+			return next(c)
+		}
+	}
+}
+
+// WriteMetrics writes the use of bulkheads to w in the Prometheus text
+// exposition format
+func WriteMetrics(w io.Writer, bulkheads ...*Bulkhead) {
+	stats := make([]Stats, len(bulkheads))
+	for i, b := range bulkheads {
+		stats[i] = b.Stats()
+	}
+
+	metric := func(name, kind, help string, value func(Stats) interface{}) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+		for _, s := range stats {
+			fmt.Fprintf(w, "%s{name=%q} %v\n", name, s.Name, value(s))
+		}
+	}
+	metric("bulkhead_capacity", "gauge", "Most requests the bulkhead lets in flight.",
+		func(s Stats) interface{} { return s.Capacity })
+	metric("bulkhead_active", "gauge", "Requests in flight in the bulkhead.",
+		func(s Stats) interface{} { return s.Active })
+	metric("bulkhead_waiting", "gauge", "Requests waiting for a slot of the bulkhead.",
+		func(s Stats) interface{} { return s.Waiting })
+	metric("bulkhead_saturation", "gauge", "Fraction of the bulkhead's capacity in use.",
+		func(s Stats) interface{} { return s.Saturation() })
+	metric("bulkhead_rejections_total", "counter", "Requests rejected because the bulkhead was full.",
+		func(s Stats) interface{} { return s.Rejections })
+}
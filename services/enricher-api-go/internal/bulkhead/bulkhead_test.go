@@ -0,0 +1,146 @@
+package bulkhead
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// newTestServer serves customer and product routes, each in a bulkhead of
+// one slot; product requests block while hold is open
+func newTestServer(customers, products *Bulkhead, hold chan struct{}) *echo.Echo {
+	e := echo.New()
+	e.Use(Middleware(customers, products))
+	e.GET("/v1/customers/:id/status", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+	e.POST("/v1/products/import", func(c echo.Context) error {
+		<-hold
+		return c.NoContent(http.StatusAccepted)
+	})
+	e.GET("/health", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+	return e
+}
+
+func serve(e *echo.Echo, method, path string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+	return rec
+}
+
+// waitActive waits until b has n requests in flight
+func waitActive(t *testing.T, b *Bulkhead, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for b.Stats().Active != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %d requests in flight, got %d", n, b.Stats().Active)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestMiddleware_IsolatesGroups(t *testing.T) {
+	// Arrange: an import holding the only product slot
+	customers := New("/v1/customers", 1, 10*time.Millisecond)
+	products := New("/v1/products", 1, 10*time.Millisecond)
+	hold := make(chan struct{})
+	e := newTestServer(customers, products, hold)
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- serve(e, http.MethodPost, "/v1/products/import") }()
+	waitActive(t, products, 1)
+
+	// Act
+	rejected := serve(e, http.MethodPost, "/v1/products/import")
+	status := serve(e, http.MethodGet, "/v1/customers/customer-456/status")
+	ungrouped := serve(e, http.MethodGet, "/health")
+	close(hold)
+	held := <-done
+	freed := serve(e, http.MethodPost, "/v1/products/import")
+
+	// Assert
+	if rejected.Code != http.StatusServiceUnavailable || rejected.Header().Get(echo.HeaderRetryAfter) != retryAfter {
+		t.Errorf("Expected 503 with Retry-After, got %d %q", rejected.Code, rejected.Header().Get(echo.HeaderRetryAfter))
+	}
+	if status.Code != http.StatusOK || ungrouped.Code != http.StatusOK {
+		t.Errorf("Expected other groups and ungrouped routes served, got %d and %d", status.Code, ungrouped.Code)
+	}
+	if held.Code != http.StatusAccepted || freed.Code != http.StatusAccepted {
+		t.Errorf("Expected imports accepted while a slot is free, got %d and %d", held.Code, freed.Code)
+	}
+	if stats := products.Stats(); stats.Active != 0 || stats.Rejections != 1 {
+		t.Errorf("Expected no product requests in flight and 1 rejected, got %+v", stats)
+	}
+	if stats := customers.Stats(); stats.Active != 0 || stats.Rejections != 0 {
+		t.Errorf("Expected the customer slot released and nothing rejected, got %+v", stats)
+	}
+}
+
+func TestBulkhead_AcquireWaitsForSlot(t *testing.T) {
+	tests := []struct {
+		name     string
+		maxWait  time.Duration
+		release  bool
+		expected bool
+	}{
+		{name: "slot freed while waiting", maxWait: time.Second, release: true, expected: true},
+		{name: "no slot freed", maxWait: 10 * time.Millisecond, expected: false},
+		{name: "no waiting", maxWait: 0, release: true, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			b := New("/v1/products", 1, tt.maxWait)
+			b.Acquire(context.Background())
+			if tt.release {
+				go func() {
+					time.Sleep(5 * time.Millisecond)
+					b.Release()
+				}()
+			}
+
+			// Act
+			acquired := b.Acquire(context.Background())
+
+			// Assert
+			if acquired != tt.expected {
+				t.Errorf("Expected acquired %v, got %v", tt.expected, acquired)
+			}
+		})
+	}
+}
+
+func TestWriteMetrics(t *testing.T) {
+	// Arrange
+	customers := New("/v1/customers", 4, 0)
+	products := New("/v1/products", 2, 0)
+	customers.Acquire(context.Background())
+	products.Acquire(context.Background())
+	products.Acquire(context.Background())
+	products.Acquire(context.Background())
+
+	// Act
+	var body strings.Builder
+	WriteMetrics(&body, customers, products)
+
+	// Assert
+	for _, line := range []string{
+		`bulkhead_capacity{name="/v1/customers"} 4`,
+		`bulkhead_active{name="/v1/products"} 2`,
+		`bulkhead_saturation{name="/v1/customers"} 0.25`,
+		`bulkhead_saturation{name="/v1/products"} 1`,
+		`bulkhead_rejections_total{name="/v1/products"} 1`,
+	} {
+		if !strings.Contains(body.String(), line) {
+			t.Errorf("Expected metrics to contain %q, got:\n%s", line, body.String())
+		}
+	}
+}
@@ -0,0 +1,61 @@
+// Package cache keeps serialized records close to the API so hot lookups skip
+// the storage backend.
+//
+// A Store holds opaque values under string keys with a time to live. Redis
+// shares entries between instances; LRU keeps them in process, either on its
+// own or as the Fallback while Redis is unreachable.
+package cache
+
+import (
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// Store holds values with an expiry. A missing or expired key is a miss, not
+// an error; errors mean the store itself failed.
+type Store interface {
+	Get(key string) (value []byte, ok bool, err error)
+	Set(key string, value []byte, ttl time.Duration) error
+	Delete(keys ...string) error
+}
+
+// Fallback serves every call from primary and repeats it on secondary when
+// primary fails, so a Redis outage degrades to per-instance caching rather
+// than to no caching. Deletes go to both stores, so entries written to
+// secondary during an outage are still invalidated afterwards.
+type Fallback struct {
+	primary   Store
+	secondary Store
+}
+
+// NewFallback creates a Store preferring primary over secondary
+func NewFallback(primary, secondary Store) *Fallback {
+	return &Fallback{primary: primary, secondary: secondary}
+}
+
+// Get reads from primary, or from secondary if primary fails
+func (f *Fallback) Get(key string) ([]byte, bool, error) {
+	value, ok, err := f.primary.Get(key)
+	if err == nil {
+		return value, ok, nil
+	}
+	slog.Warn("Cache unavailable, using fallback", "operation", "get", "error", err)
+	return f.secondary.Get(key)
+}
+
+// Set writes to primary, or to secondary if primary fails
+func (f *Fallback) Set(key string, value []byte, ttl time.Duration) error {
+	err := f.primary.Set(key, value, ttl)
+	if err == nil {
+		return nil
+	}
+	slog.Warn("Cache unavailable, using fallback", "operation", "set", "error", err)
+	return f.secondary.Set(key, value, ttl)
+}
+
+// Delete removes keys from both stores, returning primary's error so callers
+// know an entry may outlive the change that invalidated it
+func (f *Fallback) Delete(keys ...string) error {
+	return errors.Join(f.primary.Delete(keys...), f.secondary.Delete(keys...))
+}
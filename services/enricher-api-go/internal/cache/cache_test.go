@@ -0,0 +1,140 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestLRU_EvictsLeastRecentlyUsed(t *testing.T) {
+	// Arrange
+	lru := NewLRU(2)
+	_ = lru.Set("a", []byte("1"), time.Minute)
+	_ = lru.Set("b", []byte("2"), time.Minute)
+	_, _, _ = lru.Get("a")
+
+	// Act
+	_ = lru.Set("c", []byte("3"), time.Minute)
+
+	// Assert
+	if _, ok, _ := lru.Get("b"); ok {
+		t.Error("Expected the least recently used entry to be evicted")
+	}
+	if value, ok, _ := lru.Get("a"); !ok || string(value) != "1" {
+		t.Errorf("Expected a recently read entry to survive, got %q", value)
+	}
+	if lru.Len() != 2 {
+		t.Errorf("Expected 2 entries, got %d", lru.Len())
+	}
+}
+
+func TestLRU_Expiry(t *testing.T) {
+	// Arrange
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	lru := NewLRU(10)
+	lru.now = func() time.Time { return now }
+	_ = lru.Set("a", []byte("1"), time.Minute)
+	_ = lru.Set("b", []byte("2"), time.Minute)
+
+	// Act
+	now = now.Add(time.Minute)
+	_, expired, _ := lru.Get("a")
+	_ = lru.Delete("b")
+
+	// Assert
+	if expired {
+		t.Error("Expected the entry to expire after its TTL")
+	}
+	if lru.Len() != 0 {
+		t.Errorf("Expected expired and deleted entries to be removed, got %d", lru.Len())
+	}
+}
+
+// TestRedis_RoundTrip runs against the Redis server in REDIS_TEST_ADDR and
+// is skipped when it is not set.
+func TestRedis_RoundTrip(t *testing.T) {
+	addr := os.Getenv("REDIS_TEST_ADDR")
+	if addr == "" {
+		t.Skip("REDIS_TEST_ADDR not set")
+	}
+
+	// Arrange
+	client := NewRedis(RedisConfig{Addr: addr, PoolSize: 1})
+	defer client.Close()
+
+	// Act
+	setErr := client.Set("customer:1", []byte("line\r\nbreak"), 1500*time.Millisecond)
+	value, hit, getErr := client.Get("customer:1")
+	deleteErr := client.Delete("customer:1", "customer:2")
+	_, missAfterDelete, _ := client.Get("customer:1")
+	pingErr := client.Ping(context.Background())
+
+	// Assert
+	if setErr != nil || getErr != nil || deleteErr != nil || pingErr != nil {
+		t.Fatalf("Expected no errors, got %v, %v, %v, %v", setErr, getErr, deleteErr, pingErr)
+	}
+	if !hit || string(value) != "line\r\nbreak" {
+		t.Errorf("Expected the stored value, got %q (hit=%v)", value, hit)
+	}
+	if missAfterDelete {
+		t.Error("Expected a miss after delete")
+	}
+}
+
+func TestRedis_Unreachable(t *testing.T) {
+	// Arrange
+	listener, _ := net.Listen("tcp", "127.0.0.1:0")
+	unreachableAddr := listener.Addr().String()
+	listener.Close()
+	client := NewRedis(RedisConfig{Addr: unreachableAddr, Timeout: 100 * time.Millisecond})
+	defer client.Close()
+
+	// Act
+	_, _, getErr := client.Get("customer:1")
+	setErr := client.Set("customer:1", []byte("value"), time.Minute)
+	pingErr := client.Ping(context.Background())
+
+	// Assert
+	if getErr == nil || setErr == nil || pingErr == nil {
+		t.Errorf("Expected errors for an unreachable server, got %v, %v and %v", getErr, setErr, pingErr)
+	}
+}
+
+// failingStore fails every call
+type failingStore struct{}
+
+func (failingStore) Get(string) ([]byte, bool, error) {
+	return nil, false, errors.New("connection refused")
+}
+func (failingStore) Set(string, []byte, time.Duration) error {
+	return errors.New("connection refused")
+}
+func (failingStore) Delete(...string) error { return errors.New("connection refused") }
+
+func TestFallback_UsesSecondaryWhilePrimaryFails(t *testing.T) {
+	// Arrange
+	secondary := NewLRU(10)
+	store := NewFallback(failingStore{}, secondary)
+
+	// Act
+	setErr := store.Set("product:1", []byte("laptop"), time.Minute)
+	value, hit, getErr := store.Get("product:1")
+	deleteErr := store.Delete("product:1")
+
+	// Assert
+	if setErr != nil || getErr != nil {
+		t.Errorf("Expected the fallback to absorb primary errors, got %v and %v", setErr, getErr)
+	}
+	if !hit || string(value) != "laptop" {
+		t.Errorf("Expected the value from the fallback, got %q", value)
+	}
+	if deleteErr == nil {
+		t.Error("Expected delete to report the primary failure")
+	}
+	if secondary.Len() != 0 {
+		t.Error("Expected delete to clear the fallback too")
+	}
+}
@@ -0,0 +1,54 @@
+package cache
+
+import "sync"
+
+// Group collapses concurrent calls for the same key into one, so a burst of
+// misses for a popular record reaches storage once rather than once per
+// request.
+type Group[K comparable, V any] struct {
+	mutex sync.Mutex
+	calls map[K]*call[V]
+}
+
+// call is a load in flight
+type call[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+// Do runs fn for key unless a call for key is already in flight, in which
+// case it waits for that call and returns its result. shared reports whether
+// the result was handed to more than one caller.
+func (g *Group[K, V]) Do(key K, fn func() (V, error)) (value V, err error, shared bool) {
+	g.mutex.Lock()
+	if g.calls == nil {
+		g.calls = make(map[K]*call[V])
+	}
+	if c, ok := g.calls[key]; ok {
+		g.mutex.Unlock()
+		<-c.done
+		return c.value, c.err, true
+	}
+	c := &call[V]{done: make(chan struct{})}
+	g.calls[key] = c
+	g.mutex.Unlock()
+
+	c.value, c.err = fn()
+
+	g.mutex.Lock()
+	if g.calls[key] == c {
+		delete(g.calls, key)
+	}
+	g.mutex.Unlock()
+	close(c.done)
+	return c.value, c.err, false
+}
+
+// Forget detaches the in-flight call for key, if any, so later calls start a
+// fresh load instead of joining one that may return stale data
+func (g *Group[K, V]) Forget(key K) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	delete(g.calls, key)
+}
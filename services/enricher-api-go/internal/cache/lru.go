@@ -0,0 +1,105 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// DefaultMaxEntries bounds an LRU created with a non-positive capacity
+const DefaultMaxEntries = 10000
+
+// LRU is an in-process Store that evicts the least recently used entry once
+// it holds MaxEntries. It never returns errors.
+type LRU struct {
+	capacity int
+	now      func() time.Time
+
+	mutex   sync.Mutex
+	entries map[string]*list.Element
+	// order holds *lruEntry values, most recently used first
+	order *list.List
+}
+
+type lruEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// NewLRU creates an LRU holding up to capacity entries
+func NewLRU(capacity int) *LRU {
+	if capacity <= 0 {
+		capacity = DefaultMaxEntries
+	}
+	return &LRU{
+		capacity: capacity,
+		now:      time.Now,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Get returns the value stored under key unless it has expired
+func (l *LRU) Get(key string) ([]byte, bool, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	element, ok := l.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	entry := element.Value.(*lruEntry)
+	if !l.now().Before(entry.expires) {
+		l.remove(element)
+		return nil, false, nil
+	}
+	l.order.MoveToFront(element)
+	return entry.value, true, nil
+}
+
+// Set stores value under key for ttl, evicting the least recently used entry
+// when full
+func (l *LRU) Set(key string, value []byte, ttl time.Duration) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	expires := l.now().Add(ttl)
+	if element, ok := l.entries[key]; ok {
+		entry := element.Value.(*lruEntry)
+		entry.value, entry.expires = value, expires
+		l.order.MoveToFront(element)
+		return nil
+	}
+
+	l.entries[key] = l.order.PushFront(&lruEntry{key: key, value: value, expires: expires})
+	if l.order.Len() > l.capacity {
+		l.remove(l.order.Back())
+	}
+	return nil
+}
+
+// Delete removes keys
+func (l *LRU) Delete(keys ...string) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for _, key := range keys {
+		if element, ok := l.entries[key]; ok {
+			l.remove(element)
+		}
+	}
+	return nil
+}
+
+// Len returns the number of entries, including expired ones not yet evicted
+func (l *LRU) Len() int {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.order.Len()
+}
+
+func (l *LRU) remove(element *list.Element) {
+	l.order.Remove(element)
+	delete(l.entries, element.Value.(*lruEntry).key)
+}
@@ -0,0 +1,90 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis client defaults applied to zero RedisConfig fields
+const (
+	DefaultRedisPoolSize = 10
+	DefaultRedisTimeout  = 500 * time.Millisecond
+)
+
+// RedisConfig configures a Redis client
+type RedisConfig struct {
+	// Addr is the host:port of the server
+	Addr     string
+	Password string
+	DB       int
+	// PoolSize caps the connections open at once
+	PoolSize int
+	// Timeout bounds connecting and each command round trip
+	Timeout time.Duration
+}
+
+// Redis is a Store backed by a Redis server
+type Redis struct {
+	client *redis.Client
+}
+
+// NewRedis creates a client for cfg.Addr; connections are opened on demand.
+// Failed commands are not retried, since the cache falls back to another
+// store instead.
+func NewRedis(cfg RedisConfig) *Redis {
+	if cfg.PoolSize <= 0 {
+		cfg.PoolSize = DefaultRedisPoolSize
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultRedisTimeout
+	}
+	return &Redis{client: redis.NewClient(&redis.Options{
+		Addr:         cfg.Addr,
+		Password:     cfg.Password,
+		DB:           cfg.DB,
+		PoolSize:     cfg.PoolSize,
+		DialTimeout:  cfg.Timeout,
+		ReadTimeout:  cfg.Timeout,
+		WriteTimeout: cfg.Timeout,
+		PoolTimeout:  cfg.Timeout,
+		MaxRetries:   -1,
+	})}
+}
+
+// Get returns the value stored under key
+func (r *Redis) Get(key string) ([]byte, bool, error) {
+	value, err := r.client.Get(context.Background(), key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+// Set stores value under key, expiring it after ttl
+func (r *Redis) Set(key string, value []byte, ttl time.Duration) error {
+	return r.client.Set(context.Background(), key, value, ttl).Err()
+}
+
+// Delete removes keys
+func (r *Redis) Delete(keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	return r.client.Del(context.Background(), keys...).Err()
+}
+
+// Ping checks that the server answers, giving up when ctx is done
+func (r *Redis) Ping(ctx context.Context) error {
+	return r.client.Ping(ctx).Err()
+}
+
+// Close closes the pooled connections
+func (r *Redis) Close() error {
+	return r.client.Close()
+}
@@ -0,0 +1,197 @@
+package cache
+
+import (
+	"hash/maphash"
+	"sync"
+	"time"
+)
+
+// DefaultShards is the shard count of a TTL cache created with a
+// non-positive one
+const DefaultShards = 16
+
+// TTL is a generic in-process cache whose entries expire. Keys are spread
+// over shards with separate locks, so lookups of different keys rarely
+// contend, and concurrent GetOrLoad calls for the same missing key share one
+// load.
+//
+// Each shard holds up to its share of maxEntries. A full shard first drops
+// expired entries and then an arbitrary one, which keeps Set constant time
+// at the cost of exact LRU order.
+type TTL[K ~string, V any] struct {
+	shards      []*ttlShard[K, V]
+	seed        maphash.Seed
+	maxPerShard int
+	now         func() time.Time
+	loads       Group[K, V]
+}
+
+type ttlShard[K ~string, V any] struct {
+	mutex   sync.Mutex
+	entries map[K]ttlEntry[V]
+	// deletes counts invalidations, so a load that raced one is not stored
+	deletes uint64
+}
+
+type ttlEntry[V any] struct {
+	value   V
+	expires time.Time
+}
+
+// NewTTL creates a cache of up to maxEntries entries split over shards
+func NewTTL[K ~string, V any](shards, maxEntries int) *TTL[K, V] {
+	if shards <= 0 {
+		shards = DefaultShards
+	}
+	if maxEntries <= 0 {
+		maxEntries = DefaultMaxEntries
+	}
+	c := &TTL[K, V]{
+		shards:      make([]*ttlShard[K, V], shards),
+		seed:        maphash.MakeSeed(),
+		maxPerShard: (maxEntries + shards - 1) / shards,
+		now:         time.Now,
+	}
+	for i := range c.shards {
+		c.shards[i] = &ttlShard[K, V]{entries: make(map[K]ttlEntry[V])}
+	}
+	return c
+}
+
+// Get returns the value stored under key unless it has expired
+func (c *TTL[K, V]) Get(key K) (V, bool) {
+	shard := c.shard(key)
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+
+	entry, ok := shard.entries[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	if !c.now().Before(entry.expires) {
+		delete(shard.entries, key)
+		var zero V
+		return zero, false
+	}
+	return entry.value, true
+}
+
+// Set stores value under key for ttl
+func (c *TTL[K, V]) Set(key K, value V, ttl time.Duration) {
+	shard := c.shard(key)
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+
+	c.store(shard, key, value, ttl)
+}
+
+// Delete removes keys and detaches any load in flight for them
+func (c *TTL[K, V]) Delete(keys ...K) {
+	for _, key := range keys {
+		shard := c.shard(key)
+		shard.mutex.Lock()
+		delete(shard.entries, key)
+		shard.deletes++
+		shard.mutex.Unlock()
+		c.loads.Forget(key)
+	}
+}
+
+// GetOrLoad returns the cached value for key, or calls load once for all
+// concurrent callers and caches its result for ttl. Errors are not cached.
+// A result whose key was deleted while it loaded is returned but not cached,
+// since it may predate the change that caused the delete.
+func (c *TTL[K, V]) GetOrLoad(key K, ttl time.Duration, load func() (V, error)) (V, error) {
+	if value, ok := c.Get(key); ok {
+		return value, nil
+	}
+
+	value, err, _ := c.loads.Do(key, func() (V, error) {
+		shard := c.shard(key)
+		shard.mutex.Lock()
+		deletes := shard.deletes
+		shard.mutex.Unlock()
+
+		value, err := load()
+		if err != nil {
+			return value, err
+		}
+
+		shard.mutex.Lock()
+		if shard.deletes == deletes {
+			c.store(shard, key, value, ttl)
+		}
+		shard.mutex.Unlock()
+		return value, nil
+	})
+	return value, err
+}
+
+// Len returns the number of entries, including expired ones not yet evicted
+func (c *TTL[K, V]) Len() int {
+	total := 0
+	for _, shard := range c.shards {
+		shard.mutex.Lock()
+		total += len(shard.entries)
+		shard.mutex.Unlock()
+	}
+	return total
+}
+
+// store adds an entry to a locked shard, making room first when it is full
+func (c *TTL[K, V]) store(shard *ttlShard[K, V], key K, value V, ttl time.Duration) {
+	now := c.now()
+	if _, exists := shard.entries[key]; !exists && len(shard.entries) >= c.maxPerShard {
+		for k, entry := range shard.entries {
+			if !now.Before(entry.expires) {
+				delete(shard.entries, k)
+			}
+		}
+		for k := range shard.entries {
+			if len(shard.entries) < c.maxPerShard {
+				break
+			}
+			delete(shard.entries, k)
+		}
+	}
+	shard.entries[key] = ttlEntry[V]{value: value, expires: now.Add(ttl)}
+}
+
+func (c *TTL[K, V]) shard(key K) *ttlShard[K, V] {
+	return c.shards[maphash.String(c.seed, string(key))%uint64(len(c.shards))]
+}
+
+// Sharded is a Store kept in a TTL cache, for deployments without Redis. It
+// never returns errors.
+type Sharded struct {
+	cache *TTL[string, []byte]
+}
+
+// NewSharded creates a Store of up to maxEntries entries split over shards
+func NewSharded(shards, maxEntries int) *Sharded {
+	return &Sharded{cache: NewTTL[string, []byte](shards, maxEntries)}
+}
+
+// Get returns the value stored under key unless it has expired
+func (s *Sharded) Get(key string) ([]byte, bool, error) {
+	value, ok := s.cache.Get(key)
+	return value, ok, nil
+}
+
+// Set stores value under key for ttl
+func (s *Sharded) Set(key string, value []byte, ttl time.Duration) error {
+	s.cache.Set(key, value, ttl)
+	return nil
+}
+
+// Delete removes keys
+func (s *Sharded) Delete(keys ...string) error {
+	s.cache.Delete(keys...)
+	return nil
+}
+
+// Len returns the number of entries, including expired ones not yet evicted
+func (s *Sharded) Len() int {
+	return s.cache.Len()
+}
@@ -0,0 +1,100 @@
+package cache
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTTL_ExpiryAndCapacity(t *testing.T) {
+	// Arrange
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewTTL[string, int](4, 8)
+	c.now = func() time.Time { return now }
+
+	// Act
+	for i := 0; i < 100; i++ {
+		c.Set("key-"+strconv.Itoa(i), i, time.Minute)
+	}
+	size := c.Len()
+	c.Set("fresh", 1, time.Minute)
+	now = now.Add(time.Minute)
+	_, expired := c.Get("fresh")
+
+	// Assert
+	if size > 8 {
+		t.Errorf("Expected at most 8 entries, got %d", size)
+	}
+	if expired {
+		t.Error("Expected the entry to expire after its TTL")
+	}
+}
+
+func TestTTL_GetOrLoadSharesOneLoad(t *testing.T) {
+	// Arrange
+	c := NewTTL[string, string](0, 0)
+	var loads atomic.Int32
+	release := make(chan struct{})
+	load := func() (string, error) {
+		loads.Add(1)
+		<-release
+		return "laptop", nil
+	}
+
+	// Act
+	var wg sync.WaitGroup
+	results := make([]string, 10)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _ = c.GetOrLoad("product-789", time.Minute, load)
+		}(i)
+	}
+	for loads.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	cached, hit := c.Get("product-789")
+
+	// Assert
+	if loads.Load() != 1 {
+		t.Errorf("Expected one load, got %d", loads.Load())
+	}
+	for _, result := range results {
+		if result != "laptop" {
+			t.Fatalf("Expected every caller to get the loaded value, got %q", result)
+		}
+	}
+	if !hit || cached != "laptop" {
+		t.Errorf("Expected the loaded value to be cached, got %q", cached)
+	}
+}
+
+func TestTTL_GetOrLoadSkipsStoringInvalidatedLoads(t *testing.T) {
+	// Arrange
+	c := NewTTL[string, string](1, 10)
+	failing := errors.New("connection refused")
+
+	// Act
+	stale, _ := c.GetOrLoad("product-789", time.Minute, func() (string, error) {
+		c.Delete("product-789") // an update lands while the old value loads
+		return "old price", nil
+	})
+	_, staleCached := c.Get("product-789")
+	_, err := c.GetOrLoad("product-123", time.Minute, func() (string, error) { return "", failing })
+	_, errorCached := c.Get("product-123")
+
+	// Assert
+	if stale != "old price" || staleCached {
+		t.Errorf("Expected the raced value to be returned but not cached, got %q (cached=%v)", stale, staleCached)
+	}
+	if !errors.Is(err, failing) || errorCached {
+		t.Errorf("Expected the load error without caching, got %v (cached=%v)", err, errorCached)
+	}
+}
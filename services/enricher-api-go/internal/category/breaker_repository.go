@@ -0,0 +1,88 @@
+package category
+
+import (
+	"errors"
+
+	"enricher-api-go/internal/breaker"
+)
+
+// BreakerRepository guards another Repository with a circuit breaker.
+//
+// Storage failures count against the breaker, while domain outcomes such as
+// ErrCategoryNotFound are returned unchanged without tripping it.
+type BreakerRepository struct {
+	repo    Repository
+	breaker *breaker.Breaker
+}
+
+// NewBreakerRepository wraps repo with b, typically the breaker shared by
+// every repository using the same backend
+func NewBreakerRepository(repo Repository, b *breaker.Breaker) *BreakerRepository {
+	return &BreakerRepository{repo: repo, breaker: b}
+}
+
+// GetByID retrieves a category by ID
+func (r *BreakerRepository) GetByID(categoryID string) (category *Category, err error) {
+	err = r.call(func() error {
+		category, err = r.repo.GetByID(categoryID)
+		return err
+	})
+	return category, err
+}
+
+// GetByName retrieves a category by name
+func (r *BreakerRepository) GetByName(name string) (category *Category, err error) {
+	err = r.call(func() error {
+		category, err = r.repo.GetByName(name)
+		return err
+	})
+	return category, err
+}
+
+// List returns every category
+func (r *BreakerRepository) List() (categories []*Category, err error) {
+	err = r.call(func() error {
+		categories, err = r.repo.List()
+		return err
+	})
+	return categories, err
+}
+
+// Create adds a new category
+func (r *BreakerRepository) Create(category *Category) error {
+	return r.call(func() error { return r.repo.Create(category) })
+}
+
+// Update replaces an existing category
+func (r *BreakerRepository) Update(category *Category) error {
+	return r.call(func() error { return r.repo.Update(category) })
+}
+
+// Delete removes a category
+func (r *BreakerRepository) Delete(categoryID string) error {
+	return r.call(func() error { return r.repo.Delete(categoryID) })
+}
+
+// call runs fn through the breaker, passing domain errors through without
+// counting them as failures
+func (r *BreakerRepository) call(fn func() error) error {
+	var domainErr error
+	err := r.breaker.Execute(func() error {
+		err := fn()
+		if isDomainError(err) {
+			domainErr = err
+			return nil
+		}
+		return err
+	})
+	if domainErr != nil {
+		return domainErr
+	}
+	return err
+}
+
+// isDomainError reports whether err is an expected repository outcome rather
+// than a storage failure
+func isDomainError(err error) bool {
+	return errors.Is(err, ErrCategoryNotFound) || errors.Is(err, ErrCategoryExists)
+}
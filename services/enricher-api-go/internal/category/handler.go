@@ -0,0 +1,143 @@
+package category
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"enricher-api-go/internal/problem"
+	"enricher-api-go/internal/servertiming"
+	"enricher-api-go/internal/validation"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Handler handles HTTP requests for categories
+type Handler struct {
+	service Service
+}
+
+// NewHandler creates a new category handler
+func NewHandler(service Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+// ListCategories handles GET /v1/categories
+//
+// Categories are listed flat, ordered by name; tree=true nests each
+// category's subcategories under it instead.
+func (h *Handler) ListCategories(c echo.Context) error {
+	tree := false
+	if raw := c.QueryParam("tree"); raw != "" {
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return problem.Write(c, http.StatusBadRequest, "tree must be true or false")
+		}
+		tree = parsed
+	}
+
+	stop := servertiming.Start(c, "service")
+	defer stop()
+
+	if tree {
+		roots, err := h.service.Tree(c.Request().Context())
+		if err != nil {
+			return problem.ServerError(c, err)
+		}
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"categories": roots,
+		})
+	}
+
+	categories, err := h.service.ListCategories(c.Request().Context())
+	if err != nil {
+		return problem.ServerError(c, err)
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"categories": categories,
+		"count":      len(categories),
+	})
+}
+
+// GetCategory handles GET /v1/categories/:id
+func (h *Handler) GetCategory(c echo.Context) error {
+	stop := servertiming.Start(c, "service")
+	category, err := h.service.GetCategory(c.Request().Context(), c.Param("id"))
+	stop()
+	if err != nil {
+		return categoryError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, category)
+}
+
+// CreateCategory handles POST /v1/categories
+func (h *Handler) CreateCategory(c echo.Context) error {
+	var req CategoryRequest
+	if err := servertiming.Measure(c, "bind", func() error { return c.Bind(&req) }); err != nil {
+		return problem.BindError(c, err)
+	}
+
+	stop := servertiming.Start(c, "service")
+	category, err := h.service.CreateCategory(c.Request().Context(), req)
+	stop()
+	if err != nil {
+		return categoryError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, category)
+}
+
+// UpdateCategory handles PUT /v1/categories/:id
+func (h *Handler) UpdateCategory(c echo.Context) error {
+	var req CategoryRequest
+	if err := servertiming.Measure(c, "bind", func() error { return c.Bind(&req) }); err != nil {
+		return problem.BindError(c, err)
+	}
+
+	stop := servertiming.Start(c, "service")
+	category, err := h.service.UpdateCategory(c.Request().Context(), c.Param("id"), req)
+	stop()
+	if err != nil {
+		return categoryError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, category)
+}
+
+// DeleteCategory handles DELETE /v1/categories/:id
+//
+// Categories with subcategories or products answer 409.
+func (h *Handler) DeleteCategory(c echo.Context) error {
+	stop := servertiming.Start(c, "service")
+	err := h.service.DeleteCategory(c.Request().Context(), c.Param("id"))
+	stop()
+	if err != nil {
+		return categoryError(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// categoryError answers a failed category operation: 404 for an unknown
+// category, 409 for a taken name or a category in use and 400 for an
+// invalid request or parent
+func categoryError(c echo.Context, err error) error {
+	var validationErr *validation.Error
+	switch {
+	case errors.Is(err, ErrCategoryNotFound):
+		return problem.Write(c, http.StatusNotFound, "Category not found")
+	case errors.Is(err, ErrCategoryExists):
+		return problem.Write(c, http.StatusConflict, "Category already exists")
+	case errors.Is(err, ErrCategoryInUse):
+		return problem.Write(c, http.StatusConflict, err.Error())
+	case errors.As(err, &validationErr):
+		return problem.BindError(c, validationErr)
+	case errors.Is(err, ErrInvalidParent):
+		return problem.Write(c, http.StatusBadRequest, err.Error())
+	default:
+		return problem.ServerError(c, err)
+	}
+}
@@ -0,0 +1,49 @@
+// Package category manages the product category tree for the Resilient
+// Order Enricher API.
+//
+// Categories form a forest: each has an optional parent, and products refer
+// to a category by its unique name.
+package category
+
+import "time"
+
+// Category is a node in the category tree.
+//
+// Example usage:
+//
+//	laptops := &Category{
+//		CategoryID: "category-laptops",
+//		Name:       "Laptops",
+//		ParentID:   "category-electronics",
+//	}
+type Category struct {
+	// CategoryID is the unique identifier for the category
+	CategoryID string `json:"categoryId" db:"category_id"`
+	// Name is the unique name products use to refer to the category
+	Name string `json:"name" db:"name"`
+	// ParentID is the ID of the parent category; empty for a root category
+	ParentID string `json:"parentId,omitempty" db:"parent_id"`
+	// CreatedAt is when the category was created
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	// UpdatedAt is when the category was last changed
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+	// CreatedBy is the authenticated caller that created the category, if any
+	CreatedBy string `json:"createdBy,omitempty" db:"created_by"`
+	// UpdatedBy is the authenticated caller that last changed the category, if any
+	UpdatedBy string `json:"updatedBy,omitempty" db:"updated_by"`
+}
+
+// CategoryRequest is the request body for creating or replacing a category
+type CategoryRequest struct {
+	// Name is the unique name of the category (required, 2-50 characters)
+	Name string `json:"name" validate:"required,min=2,max=50"`
+	// ParentID places the category under another; empty makes it a root
+	ParentID string `json:"parentId,omitempty"`
+}
+
+// Node is a category with its subcategories, as returned by the tree view
+type Node struct {
+	Category
+	// Children are the direct subcategories, ordered by name
+	Children []*Node `json:"children"`
+}
@@ -0,0 +1,151 @@
+package category
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"enricher-api-go/internal/postgres"
+)
+
+// categoryColumns lists the columns read by scanCategory, in order
+const categoryColumns = `category_id, name, parent_id, created_at, updated_at, created_by, updated_by`
+
+// PostgresRepository implements Repository on top of a PostgreSQL database
+type PostgresRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresRepository creates a category repository backed by db.
+//
+// The caller owns db and is responsible for opening and closing it; the
+// categories table must exist (see the migrations package).
+func NewPostgresRepository(db *sql.DB) *PostgresRepository {
+	return &PostgresRepository{db: db}
+}
+
+// GetByID retrieves a category by ID
+func (r *PostgresRepository) GetByID(categoryID string) (*Category, error) {
+	return r.getOne(`SELECT `+categoryColumns+` FROM categories WHERE category_id = $1`, categoryID)
+}
+
+// GetByName retrieves a category by its exact name
+func (r *PostgresRepository) GetByName(name string) (*Category, error) {
+	return r.getOne(`SELECT `+categoryColumns+` FROM categories WHERE name = $1`, name)
+}
+
+// List returns every category ordered by name
+func (r *PostgresRepository) List() ([]*Category, error) {
+	rows, err := r.db.Query(`SELECT ` + categoryColumns + ` FROM categories ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query categories: %w", err)
+	}
+	defer rows.Close()
+
+	categories := make([]*Category, 0)
+	for rows.Next() {
+		category, err := scanCategory(rows)
+		if err != nil {
+			return nil, err
+		}
+		categories = append(categories, category)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read categories: %w", err)
+	}
+	return categories, nil
+}
+
+// Create adds a new category
+func (r *PostgresRepository) Create(category *Category) error {
+	_, err := r.db.Exec(
+		`INSERT INTO categories (category_id, name, parent_id, created_at, updated_at, created_by, updated_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		category.CategoryID, category.Name, nullString(category.ParentID),
+		category.CreatedAt, category.UpdatedAt, category.CreatedBy, category.UpdatedBy,
+	)
+	if postgres.IsUniqueViolation(err) {
+		return ErrCategoryExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to insert category: %w", err)
+	}
+	return nil
+}
+
+// Update replaces an existing category
+func (r *PostgresRepository) Update(category *Category) error {
+	result, err := r.db.Exec(
+		`UPDATE categories SET name = $2, parent_id = $3, updated_at = $4, updated_by = $5
+		WHERE category_id = $1`,
+		category.CategoryID, category.Name, nullString(category.ParentID), category.UpdatedAt, category.UpdatedBy,
+	)
+	if postgres.IsUniqueViolation(err) {
+		return ErrCategoryExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update category: %w", err)
+	}
+	return requireRowAffected(result)
+}
+
+// Delete removes a category
+func (r *PostgresRepository) Delete(categoryID string) error {
+	result, err := r.db.Exec(`DELETE FROM categories WHERE category_id = $1`, categoryID)
+	if err != nil {
+		return fmt.Errorf("failed to delete category: %w", err)
+	}
+	return requireRowAffected(result)
+}
+
+// getOne runs a single-category SELECT, mapping no rows to ErrCategoryNotFound
+func (r *PostgresRepository) getOne(query string, args ...interface{}) (*Category, error) {
+	category, err := scanCategory(r.db.QueryRow(query, args...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrCategoryNotFound
+	}
+	return category, err
+}
+
+// rowScanner is satisfied by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanCategory reads one category row in categoryColumns order
+func scanCategory(row rowScanner) (*Category, error) {
+	var category Category
+	var parentID sql.NullString
+
+	err := row.Scan(
+		&category.CategoryID, &category.Name, &parentID,
+		&category.CreatedAt, &category.UpdatedAt, &category.CreatedBy, &category.UpdatedBy,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan category: %w", err)
+	}
+
+	category.ParentID = parentID.String
+	return &category, nil
+}
+
+// nullString stores an empty string as NULL
+func nullString(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: value != ""}
+}
+
+// requireRowAffected maps an UPDATE/DELETE that touched no rows to ErrCategoryNotFound
+func requireRowAffected(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to read affected rows: %w", err)
+	}
+	if affected == 0 {
+		return ErrCategoryNotFound
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+package category
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrCategoryNotFound is returned when no category has the requested ID or name
+	ErrCategoryNotFound = errors.New("category not found")
+	// ErrCategoryExists is returned when a category's ID or name is taken
+	ErrCategoryExists = errors.New("category already exists")
+)
+
+// Repository defines the interface for category data access.
+//
+// Names are unique across the whole tree. The repository stores parent
+// links as given; the service keeps them pointing at existing categories
+// and free of cycles.
+type Repository interface {
+	GetByID(categoryID string) (*Category, error)
+	GetByName(name string) (*Category, error)
+	List() ([]*Category, error)
+	Create(category *Category) error
+	Update(category *Category) error
+	Delete(categoryID string) error
+}
+
+// InMemoryRepository implements Repository interface using in-memory storage
+type InMemoryRepository struct {
+	categories map[string]*Category
+	// samples are the categories the repository starts with and resets to
+	samples []*Category
+	mutex   sync.RWMutex
+}
+
+// NewInMemoryRepository creates a new in-memory category repository seeded
+// with the categories of the sample products
+func NewInMemoryRepository() *InMemoryRepository {
+	return NewInMemoryRepositoryWith(sampleCategories())
+}
+
+// NewInMemoryRepositoryWith creates an in-memory category repository holding
+// categories, such as fixtures loaded from a seed file; Reset returns to them
+func NewInMemoryRepositoryWith(categories []*Category) *InMemoryRepository {
+	repo := &InMemoryRepository{samples: categories}
+	repo.seed()
+	return repo
+}
+
+// Reset replaces every category with the categories the repository started
+// with
+func (r *InMemoryRepository) Reset() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.seed()
+}
+
+// seed replaces the repository's contents with its samples
+func (r *InMemoryRepository) seed() {
+	r.categories = make(map[string]*Category)
+
+	seededAt := time.Now().UTC()
+	for _, sample := range r.samples {
+		category := *sample
+		if category.CreatedAt.IsZero() {
+			category.CreatedAt = seededAt
+		}
+		if category.UpdatedAt.IsZero() {
+			category.UpdatedAt = category.CreatedAt
+		}
+		r.categories[category.CategoryID] = &category
+	}
+}
+
+// sampleCategories returns the categories an in-memory repository starts with when
+// no seed file is configured
+func sampleCategories() []*Category {
+	return []*Category{
+		{CategoryID: "category-electronics", Name: "Electronics"},
+		{CategoryID: "category-furniture", Name: "Furniture"},
+		{CategoryID: "category-kitchen", Name: "Kitchen"},
+	}
+}
+
+// GetByID retrieves a category by ID
+func (r *InMemoryRepository) GetByID(categoryID string) (*Category, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	category, exists := r.categories[categoryID]
+	if !exists {
+		return nil, ErrCategoryNotFound
+	}
+
+	categoryCopy := *category
+	return &categoryCopy, nil
+}
+
+// GetByName retrieves a category by its exact name
+func (r *InMemoryRepository) GetByName(name string) (*Category, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, category := range r.categories {
+		if category.Name == name {
+			categoryCopy := *category
+			return &categoryCopy, nil
+		}
+	}
+	return nil, ErrCategoryNotFound
+}
+
+// List returns every category ordered by name
+func (r *InMemoryRepository) List() ([]*Category, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	categories := make([]*Category, 0, len(r.categories))
+	for _, category := range r.categories {
+		categoryCopy := *category
+		categories = append(categories, &categoryCopy)
+	}
+	sort.Slice(categories, func(i, j int) bool { return categories[i].Name < categories[j].Name })
+	return categories, nil
+}
+
+// Create adds a new category
+func (r *InMemoryRepository) Create(category *Category) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.categories[category.CategoryID]; exists || r.nameTaken(category) {
+		return ErrCategoryExists
+	}
+
+	categoryCopy := *category
+	r.categories[category.CategoryID] = &categoryCopy
+	return nil
+}
+
+// Update replaces an existing category
+func (r *InMemoryRepository) Update(category *Category) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.categories[category.CategoryID]; !exists {
+		return ErrCategoryNotFound
+	}
+	if r.nameTaken(category) {
+		return ErrCategoryExists
+	}
+
+	categoryCopy := *category
+	r.categories[category.CategoryID] = &categoryCopy
+	return nil
+}
+
+// Delete removes a category
+func (r *InMemoryRepository) Delete(categoryID string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.categories[categoryID]; !exists {
+		return ErrCategoryNotFound
+	}
+	delete(r.categories, categoryID)
+	return nil
+}
+
+// nameTaken reports whether another category already uses category's name;
+// callers hold the lock
+func (r *InMemoryRepository) nameTaken(category *Category) bool {
+	for _, other := range r.categories {
+		if other.Name == category.Name && other.CategoryID != category.CategoryID {
+			return true
+		}
+	}
+	return false
+}
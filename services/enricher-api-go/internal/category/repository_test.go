@@ -0,0 +1,170 @@
+package category
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"testing"
+
+	"enricher-api-go/internal/migrations"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// testRepositoryConformance runs the behavior every Repository implementation
+// must share against a repository created by newRepo.
+func testRepositoryConformance(t *testing.T, newRepo func(t *testing.T) Repository) {
+	t.Run("Create and get", func(t *testing.T) {
+		repo := newRepo(t)
+		root := &Category{CategoryID: "conformance-root", Name: "Conformance Root"}
+		child := &Category{CategoryID: "conformance-child", Name: "Conformance Child", ParentID: "conformance-root"}
+
+		for _, category := range []*Category{root, child} {
+			if err := repo.Create(category); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+		}
+
+		byID, err := repo.GetByID("conformance-child")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if byID.Name != "Conformance Child" || byID.ParentID != "conformance-root" {
+			t.Errorf("Expected stored category, got %+v", byID)
+		}
+
+		byName, err := repo.GetByName("Conformance Root")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if byName.CategoryID != "conformance-root" || byName.ParentID != "" {
+			t.Errorf("Expected root category, got %+v", byName)
+		}
+	})
+
+	t.Run("Duplicate ID or name", func(t *testing.T) {
+		repo := newRepo(t)
+		if err := repo.Create(&Category{CategoryID: "conformance-dup", Name: "Conformance Dup"}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if err := repo.Create(&Category{CategoryID: "conformance-dup", Name: "Other Name"}); !errors.Is(err, ErrCategoryExists) {
+			t.Errorf("Expected ErrCategoryExists for a taken ID, got %v", err)
+		}
+		if err := repo.Create(&Category{CategoryID: "conformance-other", Name: "Conformance Dup"}); !errors.Is(err, ErrCategoryExists) {
+			t.Errorf("Expected ErrCategoryExists for a taken name, got %v", err)
+		}
+	})
+
+	t.Run("Missing", func(t *testing.T) {
+		repo := newRepo(t)
+
+		if _, err := repo.GetByID("conformance-missing"); !errors.Is(err, ErrCategoryNotFound) {
+			t.Errorf("Expected ErrCategoryNotFound from GetByID, got %v", err)
+		}
+		if _, err := repo.GetByName("Conformance Missing"); !errors.Is(err, ErrCategoryNotFound) {
+			t.Errorf("Expected ErrCategoryNotFound from GetByName, got %v", err)
+		}
+		if err := repo.Update(&Category{CategoryID: "conformance-missing", Name: "Conformance Missing"}); !errors.Is(err, ErrCategoryNotFound) {
+			t.Errorf("Expected ErrCategoryNotFound from Update, got %v", err)
+		}
+		if err := repo.Delete("conformance-missing"); !errors.Is(err, ErrCategoryNotFound) {
+			t.Errorf("Expected ErrCategoryNotFound from Delete, got %v", err)
+		}
+	})
+
+	t.Run("Update and delete", func(t *testing.T) {
+		repo := newRepo(t)
+		for _, category := range []*Category{
+			{CategoryID: "conformance-a", Name: "Conformance A"},
+			{CategoryID: "conformance-b", Name: "Conformance B"},
+		} {
+			if err := repo.Create(category); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+		}
+
+		renamed := &Category{CategoryID: "conformance-b", Name: "Conformance B2", ParentID: "conformance-a"}
+		if err := repo.Update(renamed); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if err := repo.Update(&Category{CategoryID: "conformance-b", Name: "Conformance A"}); !errors.Is(err, ErrCategoryExists) {
+			t.Errorf("Expected ErrCategoryExists when renaming to a taken name, got %v", err)
+		}
+
+		retrieved, err := repo.GetByID("conformance-b")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if retrieved.Name != "Conformance B2" || retrieved.ParentID != "conformance-a" {
+			t.Errorf("Expected renamed and moved category, got %+v", retrieved)
+		}
+
+		if err := repo.Delete("conformance-b"); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if _, err := repo.GetByID("conformance-b"); !errors.Is(err, ErrCategoryNotFound) {
+			t.Errorf("Expected ErrCategoryNotFound after delete, got %v", err)
+		}
+	})
+
+	t.Run("List is ordered by name", func(t *testing.T) {
+		repo := newRepo(t)
+		for _, category := range []*Category{
+			{CategoryID: "conformance-z", Name: "Conformance Zeta"},
+			{CategoryID: "conformance-y", Name: "Conformance Alpha"},
+		} {
+			if err := repo.Create(category); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+		}
+
+		categories, err := repo.List()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		for i := 1; i < len(categories); i++ {
+			if categories[i-1].Name > categories[i].Name {
+				t.Fatalf("Expected categories ordered by name, got %q before %q", categories[i-1].Name, categories[i].Name)
+			}
+		}
+	})
+}
+
+func TestInMemoryRepository_Conformance(t *testing.T) {
+	testRepositoryConformance(t, func(t *testing.T) Repository {
+		return NewInMemoryRepository()
+	})
+}
+
+// TestPostgresRepository_Conformance runs against the database in
+// POSTGRES_TEST_DSN and is skipped when it is not set.
+func TestPostgresRepository_Conformance(t *testing.T) {
+	dsn := os.Getenv("POSTGRES_TEST_DSN")
+	if dsn == "" {
+		t.Skip("POSTGRES_TEST_DSN not set")
+	}
+
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := migrations.New(db)
+	if err != nil {
+		t.Fatalf("Failed to load migrations: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+
+	testRepositoryConformance(t, func(t *testing.T) Repository {
+		repo := NewPostgresRepository(db)
+		if _, err := db.Exec(`TRUNCATE categories`); err != nil {
+			t.Fatalf("Failed to reset categories: %v", err)
+		}
+		return repo
+	})
+}
@@ -0,0 +1,59 @@
+package category
+
+import "enricher-api-go/internal/retry"
+
+// RetryRepository retries the calls of another Repository that fail
+// transiently: reads on any transient failure, writes only on failures that
+// show nothing was written.
+type RetryRepository struct {
+	repo    Repository
+	retrier *retry.Retrier
+}
+
+// NewRetryRepository wraps repo with retrier, typically the retrier shared
+// by every repository using the same backend
+func NewRetryRepository(repo Repository, retrier *retry.Retrier) *RetryRepository {
+	return &RetryRepository{repo: repo, retrier: retrier}
+}
+
+// GetByID retrieves a category by ID
+func (r *RetryRepository) GetByID(categoryID string) (category *Category, err error) {
+	err = r.retrier.Read(func() error {
+		category, err = r.repo.GetByID(categoryID)
+		return err
+	})
+	return category, err
+}
+
+// GetByName retrieves a category by name
+func (r *RetryRepository) GetByName(name string) (category *Category, err error) {
+	err = r.retrier.Read(func() error {
+		category, err = r.repo.GetByName(name)
+		return err
+	})
+	return category, err
+}
+
+// List returns every category
+func (r *RetryRepository) List() (categories []*Category, err error) {
+	err = r.retrier.Read(func() error {
+		categories, err = r.repo.List()
+		return err
+	})
+	return categories, err
+}
+
+// Create adds a new category
+func (r *RetryRepository) Create(category *Category) error {
+	return r.retrier.Write(func() error { return r.repo.Create(category) })
+}
+
+// Update replaces an existing category
+func (r *RetryRepository) Update(category *Category) error {
+	return r.retrier.Write(func() error { return r.repo.Update(category) })
+}
+
+// Delete removes a category
+func (r *RetryRepository) Delete(categoryID string) error {
+	return r.retrier.Write(func() error { return r.repo.Delete(categoryID) })
+}
@@ -0,0 +1,349 @@
+package category
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"enricher-api-go/internal/auth"
+	"enricher-api-go/internal/clock"
+	"enricher-api-go/internal/idgen"
+	"enricher-api-go/internal/logging"
+	"enricher-api-go/internal/validation"
+)
+
+var (
+	// ErrInvalidParent is returned when a parent does not exist or would make
+	// a category its own ancestor
+	ErrInvalidParent = errors.New("invalid parent category")
+	// ErrCategoryInUse is returned when deleting or renaming a category that
+	// has subcategories or products
+	ErrCategoryInUse = errors.New("category in use")
+)
+
+// UsageFunc reports how many products reference the category named name
+type UsageFunc func(ctx context.Context, name string) (int, error)
+
+// Service defines the business logic interface for categories
+type Service interface {
+	GetCategory(ctx context.Context, categoryID string) (*Category, error)
+	ListCategories(ctx context.Context) ([]*Category, error)
+	Tree(ctx context.Context) ([]*Node, error)
+	CreateCategory(ctx context.Context, req CategoryRequest) (*Category, error)
+	UpdateCategory(ctx context.Context, categoryID string, req CategoryRequest) (*Category, error)
+	DeleteCategory(ctx context.Context, categoryID string) error
+	Exists(ctx context.Context, name string) (bool, error)
+	Subtree(ctx context.Context, name string) ([]string, error)
+}
+
+// CategoryService implements the Service interface
+type CategoryService struct {
+	repo        Repository
+	usage       UsageFunc
+	idGenerator idgen.Generator
+	clock       clock.Clock
+}
+
+// Option configures optional CategoryService behavior
+type Option func(*CategoryService)
+
+// WithIDGenerator sets the generator used for new category IDs (UUIDs by default)
+func WithIDGenerator(gen idgen.Generator) Option {
+	return func(s *CategoryService) {
+		s.idGenerator = gen
+	}
+}
+
+// WithClock sets the clock used for CreatedAt and UpdatedAt (the UTC wall clock by default)
+func WithClock(c clock.Clock) Option {
+	return func(s *CategoryService) {
+		s.clock = c
+	}
+}
+
+// WithUsage sets how the service counts the products in a category, so
+// categories still referenced by products cannot be deleted or renamed.
+// Without it only subcategories block a delete.
+func WithUsage(usage UsageFunc) Option {
+	return func(s *CategoryService) {
+		s.usage = usage
+	}
+}
+
+// NewService creates a new category service
+func NewService(repo Repository, opts ...Option) *CategoryService {
+	s := &CategoryService{
+		repo:        repo,
+		idGenerator: idgen.UUIDGenerator{},
+		clock:       clock.System{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// GetCategory retrieves a category by ID
+func (s *CategoryService) GetCategory(ctx context.Context, categoryID string) (*Category, error) {
+	logger := logging.FromContext(ctx)
+	logger.Debug("Getting category", "category_id", categoryID)
+
+	if categoryID == "" {
+		return nil, fmt.Errorf("category ID cannot be empty")
+	}
+
+	category, err := s.repo.GetByID(categoryID)
+	if err != nil {
+		if !errors.Is(err, ErrCategoryNotFound) {
+			logger.Error("Failed to get category", "category_id", categoryID, "error", err)
+		}
+		return nil, err
+	}
+
+	return category, nil
+}
+
+// ListCategories returns every category ordered by name
+func (s *CategoryService) ListCategories(ctx context.Context) ([]*Category, error) {
+	categories, err := s.repo.List()
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to list categories", "error", err)
+		return nil, fmt.Errorf("failed to list categories: %w", err)
+	}
+	return categories, nil
+}
+
+// Tree returns the root categories with their subcategories nested below
+// them, each level ordered by name
+func (s *CategoryService) Tree(ctx context.Context) ([]*Node, error) {
+	categories, err := s.ListCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make(map[string]*Node, len(categories))
+	for _, category := range categories {
+		nodes[category.CategoryID] = &Node{Category: *category, Children: []*Node{}}
+	}
+
+	roots := make([]*Node, 0)
+	for _, category := range categories {
+		node := nodes[category.CategoryID]
+		if parent, ok := nodes[category.ParentID]; ok {
+			parent.Children = append(parent.Children, node)
+		} else {
+			roots = append(roots, node)
+		}
+	}
+	return roots, nil
+}
+
+// CreateCategory creates a new category, under ParentID when one is given
+func (s *CategoryService) CreateCategory(ctx context.Context, req CategoryRequest) (*Category, error) {
+	logger := logging.FromContext(ctx)
+	logger.Info("Creating category", "name", req.Name)
+
+	if err := validateCategoryRequest(&req); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	categoryID, err := s.idGenerator.NewID("category")
+	if err != nil {
+		logger.Error("Failed to generate category ID", "error", err)
+		return nil, fmt.Errorf("failed to create category: %w", err)
+	}
+
+	if err := s.checkParent(categoryID, req.ParentID); err != nil {
+		return nil, err
+	}
+
+	now, caller := s.clock.Now(), auth.Caller(ctx)
+	category := &Category{
+		CategoryID: categoryID,
+		Name:       req.Name,
+		ParentID:   req.ParentID,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		CreatedBy:  caller,
+		UpdatedBy:  caller,
+	}
+
+	if err := s.repo.Create(category); err != nil {
+		if !errors.Is(err, ErrCategoryExists) {
+			logger.Error("Failed to create category", "error", err)
+		}
+		return nil, fmt.Errorf("failed to create category: %w", err)
+	}
+
+	logger.Info("Created category", "category_id", categoryID)
+	return category, nil
+}
+
+// UpdateCategory renames or moves a category.
+//
+// A category cannot move below itself or one of its descendants, and
+// cannot be renamed while products still refer to it by its old name.
+func (s *CategoryService) UpdateCategory(ctx context.Context, categoryID string, req CategoryRequest) (*Category, error) {
+	logger := logging.FromContext(ctx)
+	logger.Info("Updating category", "category_id", categoryID)
+
+	if err := validateCategoryRequest(&req); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	category, err := s.GetCategory(ctx, categoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkParent(categoryID, req.ParentID); err != nil {
+		return nil, err
+	}
+
+	if req.Name != category.Name {
+		products, err := s.productCount(ctx, category.Name)
+		if err != nil {
+			return nil, err
+		}
+		if products > 0 {
+			return nil, fmt.Errorf("%w: %d products use %q", ErrCategoryInUse, products, category.Name)
+		}
+	}
+
+	category.Name = req.Name
+	category.ParentID = req.ParentID
+	category.UpdatedAt, category.UpdatedBy = s.clock.Now(), auth.Caller(ctx)
+
+	if err := s.repo.Update(category); err != nil {
+		if !errors.Is(err, ErrCategoryNotFound) && !errors.Is(err, ErrCategoryExists) {
+			logger.Error("Failed to update category", "category_id", categoryID, "error", err)
+		}
+		return nil, fmt.Errorf("failed to update category: %w", err)
+	}
+
+	logger.Info("Updated category", "category_id", categoryID)
+	return category, nil
+}
+
+// DeleteCategory removes a category that has no subcategories and no products
+func (s *CategoryService) DeleteCategory(ctx context.Context, categoryID string) error {
+	logger := logging.FromContext(ctx)
+	logger.Info("Deleting category", "category_id", categoryID)
+
+	category, err := s.GetCategory(ctx, categoryID)
+	if err != nil {
+		return err
+	}
+
+	categories, err := s.ListCategories(ctx)
+	if err != nil {
+		return err
+	}
+	for _, other := range categories {
+		if other.ParentID == categoryID {
+			return fmt.Errorf("%w: %q has subcategories", ErrCategoryInUse, category.Name)
+		}
+	}
+
+	products, err := s.productCount(ctx, category.Name)
+	if err != nil {
+		return err
+	}
+	if products > 0 {
+		return fmt.Errorf("%w: %d products use %q", ErrCategoryInUse, products, category.Name)
+	}
+
+	if err := s.repo.Delete(categoryID); err != nil {
+		if !errors.Is(err, ErrCategoryNotFound) {
+			logger.Error("Failed to delete category", "category_id", categoryID, "error", err)
+		}
+		return fmt.Errorf("failed to delete category: %w", err)
+	}
+
+	logger.Info("Deleted category", "category_id", categoryID)
+	return nil
+}
+
+// Exists reports whether a category is named name
+func (s *CategoryService) Exists(ctx context.Context, name string) (bool, error) {
+	_, err := s.repo.GetByName(name)
+	if errors.Is(err, ErrCategoryNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to look up category", "name", name, "error", err)
+		return false, fmt.Errorf("failed to look up category: %w", err)
+	}
+	return true, nil
+}
+
+// Subtree returns the names of the category named name and all of its
+// descendants, or nil if no category has that name
+func (s *CategoryService) Subtree(ctx context.Context, name string) ([]string, error) {
+	categories, err := s.ListCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	children := make(map[string][]*Category, len(categories))
+	var root *Category
+	for _, category := range categories {
+		children[category.ParentID] = append(children[category.ParentID], category)
+		if category.Name == name {
+			root = category
+		}
+	}
+	if root == nil {
+		return nil, nil
+	}
+
+	names := []string{}
+	queue := []*Category{root}
+	for len(queue) > 0 {
+		category := queue[0]
+		queue = queue[1:]
+		names = append(names, category.Name)
+		queue = append(queue, children[category.CategoryID]...)
+	}
+	return names, nil
+}
+
+// checkParent verifies that parentID, if set, exists and is not categoryID
+// or one of its descendants
+func (s *CategoryService) checkParent(categoryID, parentID string) error {
+	for ancestorID := parentID; ancestorID != ""; {
+		if ancestorID == categoryID {
+			return fmt.Errorf("%w: a category cannot be its own ancestor", ErrInvalidParent)
+		}
+		ancestor, err := s.repo.GetByID(ancestorID)
+		if errors.Is(err, ErrCategoryNotFound) {
+			return fmt.Errorf("%w: %s not found", ErrInvalidParent, ancestorID)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to look up parent category: %w", err)
+		}
+		ancestorID = ancestor.ParentID
+	}
+	return nil
+}
+
+// productCount reports how many products use the category named name, or
+// zero when no usage function is configured
+func (s *CategoryService) productCount(ctx context.Context, name string) (int, error) {
+	if s.usage == nil {
+		return 0, nil
+	}
+	count, err := s.usage(ctx, name)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count products in category: %w", err)
+	}
+	return count, nil
+}
+
+// validateCategoryRequest trims the request and checks its validate tags
+func validateCategoryRequest(req *CategoryRequest) error {
+	req.Name = strings.TrimSpace(req.Name)
+	req.ParentID = strings.TrimSpace(req.ParentID)
+	return validation.Struct(req)
+}
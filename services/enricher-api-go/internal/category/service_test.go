@@ -0,0 +1,162 @@
+package category
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+// newTestTree builds Electronics > Computers > Laptops on top of the seeded
+// categories and returns the service and the IDs of the new categories
+func newTestTree(t *testing.T, opts ...Option) (*CategoryService, string, string) {
+	t.Helper()
+	service := NewService(NewInMemoryRepository(), opts...)
+	ctx := context.Background()
+
+	computers, err := service.CreateCategory(ctx, CategoryRequest{Name: "Computers", ParentID: "category-electronics"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	laptops, err := service.CreateCategory(ctx, CategoryRequest{Name: "Laptops", ParentID: computers.CategoryID})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return service, computers.CategoryID, laptops.CategoryID
+}
+
+func TestCategoryService_CreateCategory(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     CategoryRequest
+		wantErr error
+	}{
+		{name: "root", req: CategoryRequest{Name: "Garden"}},
+		{name: "child", req: CategoryRequest{Name: "Phones", ParentID: "category-electronics"}},
+		{name: "missing parent", req: CategoryRequest{Name: "Phones", ParentID: "category-missing"}, wantErr: ErrInvalidParent},
+		{name: "taken name", req: CategoryRequest{Name: "Kitchen"}, wantErr: ErrCategoryExists},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			service := NewService(NewInMemoryRepository())
+
+			// Act
+			category, err := service.CreateCategory(context.Background(), tt.req)
+
+			// Assert
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if category.Name != tt.req.Name || category.ParentID != tt.req.ParentID {
+				t.Errorf("Expected category from request, got %+v", category)
+			}
+		})
+	}
+}
+
+func TestCategoryService_UpdateCategory_RejectsCycles(t *testing.T) {
+	// Arrange
+	service, computersID, laptopsID := newTestTree(t)
+	ctx := context.Background()
+
+	// Act
+	_, selfErr := service.UpdateCategory(ctx, computersID, CategoryRequest{Name: "Computers", ParentID: computersID})
+	_, descendantErr := service.UpdateCategory(ctx, "category-electronics", CategoryRequest{Name: "Electronics", ParentID: laptopsID})
+	moved, moveErr := service.UpdateCategory(ctx, laptopsID, CategoryRequest{Name: "Laptops", ParentID: "category-electronics"})
+
+	// Assert
+	if !errors.Is(selfErr, ErrInvalidParent) {
+		t.Errorf("Expected ErrInvalidParent moving a category under itself, got %v", selfErr)
+	}
+	if !errors.Is(descendantErr, ErrInvalidParent) {
+		t.Errorf("Expected ErrInvalidParent moving a category under its descendant, got %v", descendantErr)
+	}
+	if moveErr != nil || moved.ParentID != "category-electronics" {
+		t.Errorf("Expected Laptops to move under Electronics, got %+v, %v", moved, moveErr)
+	}
+}
+
+func TestCategoryService_DeleteCategory_InUse(t *testing.T) {
+	// Arrange
+	usage := func(ctx context.Context, name string) (int, error) {
+		if name == "Kitchen" {
+			return 2, nil
+		}
+		return 0, nil
+	}
+	service, computersID, laptopsID := newTestTree(t, WithUsage(usage))
+	ctx := context.Background()
+
+	// Act
+	parentErr := service.DeleteCategory(ctx, computersID)
+	productsErr := service.DeleteCategory(ctx, "category-kitchen")
+	_, renameErr := service.UpdateCategory(ctx, "category-kitchen", CategoryRequest{Name: "Cookware"})
+	leafErr := service.DeleteCategory(ctx, laptopsID)
+
+	// Assert
+	if !errors.Is(parentErr, ErrCategoryInUse) {
+		t.Errorf("Expected ErrCategoryInUse deleting a category with subcategories, got %v", parentErr)
+	}
+	if !errors.Is(productsErr, ErrCategoryInUse) {
+		t.Errorf("Expected ErrCategoryInUse deleting a category with products, got %v", productsErr)
+	}
+	if !errors.Is(renameErr, ErrCategoryInUse) {
+		t.Errorf("Expected ErrCategoryInUse renaming a category with products, got %v", renameErr)
+	}
+	if leafErr != nil {
+		t.Errorf("Expected unused leaf category to be deleted, got %v", leafErr)
+	}
+}
+
+func TestCategoryService_Subtree(t *testing.T) {
+	// Arrange
+	service, _, _ := newTestTree(t)
+	ctx := context.Background()
+
+	// Act
+	electronics, err := service.Subtree(ctx, "Electronics")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	missing, err := service.Subtree(ctx, "Garden")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Assert
+	slices.Sort(electronics)
+	if want := []string{"Computers", "Electronics", "Laptops"}; !slices.Equal(electronics, want) {
+		t.Errorf("Expected subtree %v, got %v", want, electronics)
+	}
+	if missing != nil {
+		t.Errorf("Expected nil subtree for an unknown category, got %v", missing)
+	}
+}
+
+func TestCategoryService_Tree(t *testing.T) {
+	// Arrange
+	service, _, _ := newTestTree(t)
+
+	// Act
+	roots, err := service.Tree(context.Background())
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(roots) != 3 || roots[0].Name != "Electronics" {
+		t.Fatalf("Expected the three seeded roots ordered by name, got %d roots", len(roots))
+	}
+	computers := roots[0].Children
+	if len(computers) != 1 || computers[0].Name != "Computers" || len(computers[0].Children) != 1 || computers[0].Children[0].Name != "Laptops" {
+		t.Errorf("Expected Electronics > Computers > Laptops, got %+v", computers)
+	}
+}
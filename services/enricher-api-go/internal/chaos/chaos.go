@@ -0,0 +1,222 @@
+// Package chaos injects configurable faults into API responses so that
+// downstream clients can exercise their retries, timeouts and circuit breakers
+// against this service.
+//
+// Faults are keyed by route pattern (as registered with Echo, e.g.
+// "/v1/products/:id") and optionally by HTTP method. They are managed at
+// runtime through the admin API in handler.go and applied by Middleware.
+package chaos
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"enricher-api-go/internal/logging"
+	"enricher-api-go/internal/problem"
+
+	"github.com/labstack/echo/v4"
+)
+
+// MaxLatency caps injected latency so a fault cannot hang requests indefinitely
+const MaxLatency = 60 * time.Second
+
+// ErrInvalidFault is returned when a fault definition is out of range
+var ErrInvalidFault = errors.New("invalid fault")
+
+// Fault describes what to inject into requests for one route
+type Fault struct {
+	// Route is the Echo route pattern the fault applies to
+	Route string `json:"route"`
+	// Method restricts the fault to one HTTP method; empty matches any
+	Method string `json:"method,omitempty"`
+	// LatencyMs delays every matching request by this many milliseconds
+	LatencyMs int `json:"latencyMs,omitempty"`
+	// ErrorRate is the fraction (0-1) of matching requests answered with ErrorStatus
+	ErrorRate float64 `json:"errorRate,omitempty"`
+	// ErrorStatus is the status returned for injected errors (default 503)
+	ErrorStatus int `json:"errorStatus,omitempty"`
+	// ResetRate is the fraction (0-1) of matching requests whose connection is reset
+	ResetRate float64 `json:"resetRate,omitempty"`
+}
+
+// key identifies the fault by method and route
+func (f Fault) key() string {
+	return f.Method + " " + f.Route
+}
+
+// normalize upper-cases the method and applies the default error status
+func (f Fault) normalize() Fault {
+	f.Method = strings.ToUpper(strings.TrimSpace(f.Method))
+	if f.ErrorRate > 0 && f.ErrorStatus == 0 {
+		f.ErrorStatus = http.StatusServiceUnavailable
+	}
+	return f
+}
+
+// Validate checks the fault's route, rates, latency and status
+func (f Fault) Validate() error {
+	if !strings.HasPrefix(f.Route, "/") {
+		return fmt.Errorf("%w: route must start with /", ErrInvalidFault)
+	}
+	if f.LatencyMs < 0 || time.Duration(f.LatencyMs)*time.Millisecond > MaxLatency {
+		return fmt.Errorf("%w: latencyMs must be between 0 and %d", ErrInvalidFault, MaxLatency.Milliseconds())
+	}
+	if f.ErrorRate < 0 || f.ErrorRate > 1 {
+		return fmt.Errorf("%w: errorRate must be between 0 and 1", ErrInvalidFault)
+	}
+	if f.ResetRate < 0 || f.ResetRate > 1 {
+		return fmt.Errorf("%w: resetRate must be between 0 and 1", ErrInvalidFault)
+	}
+	if f.ErrorStatus != 0 && (f.ErrorStatus < 400 || f.ErrorStatus > 599) {
+		return fmt.Errorf("%w: errorStatus must be a 4xx or 5xx status", ErrInvalidFault)
+	}
+	return nil
+}
+
+// Injector holds the active faults
+type Injector struct {
+	mu     sync.RWMutex
+	faults map[string]Fault
+	// random returns a number in [0, 1); replaced in tests
+	random func() float64
+}
+
+// NewInjector creates an injector with no active faults
+func NewInjector() *Injector {
+	return &Injector{
+		faults: make(map[string]Fault),
+		random: rand.Float64,
+	}
+}
+
+// Set validates and stores a fault, replacing any fault for the same method and route
+func (i *Injector) Set(fault Fault) (Fault, error) {
+	fault = fault.normalize()
+	if err := fault.Validate(); err != nil {
+		return Fault{}, err
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.faults[fault.key()] = fault
+	return fault, nil
+}
+
+// Remove deletes the fault for method and route and reports whether one existed
+func (i *Injector) Remove(method, route string) bool {
+	key := Fault{Method: method, Route: route}.normalize().key()
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	_, exists := i.faults[key]
+	delete(i.faults, key)
+	return exists
+}
+
+// Clear removes every fault
+func (i *Injector) Clear() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.faults = make(map[string]Fault)
+}
+
+// List returns the active faults ordered by route and method
+func (i *Injector) List() []Fault {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	faults := make([]Fault, 0, len(i.faults))
+	for _, fault := range i.faults {
+		faults = append(faults, fault)
+	}
+	sort.Slice(faults, func(a, b int) bool {
+		if faults[a].Route != faults[b].Route {
+			return faults[a].Route < faults[b].Route
+		}
+		return faults[a].Method < faults[b].Method
+	})
+	return faults
+}
+
+// lookup returns the fault for a request, preferring a method-specific one
+func (i *Injector) lookup(method, route string) (Fault, bool) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	if fault, ok := i.faults[method+" "+route]; ok {
+		return fault, true
+	}
+	fault, ok := i.faults[" "+route]
+	return fault, ok
+}
+
+// Middleware applies active faults to matching requests. Requests whose path
+// starts with one of exemptPrefixes (such as the admin API) are never faulted.
+func (i *Injector) Middleware(exemptPrefixes ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			path := c.Request().URL.Path
+			for _, prefix := range exemptPrefixes {
+				if strings.HasPrefix(path, prefix) {
+					return next(c)
+				}
+			}
+
+			fault, ok := i.lookup(c.Request().Method, c.Path())
+			if !ok {
+				return next(c)
+			}
+
+			logger := logging.FromContext(c.Request().Context()).With("fault_route", fault.Route)
+
+			if fault.LatencyMs > 0 {
+				timer := time.NewTimer(time.Duration(fault.LatencyMs) * time.Millisecond)
+				select {
+				case <-timer.C:
+				case <-c.Request().Context().Done():
+					timer.Stop()
+					return c.Request().Context().Err()
+				}
+			}
+
+			if fault.ResetRate > 0 && i.random() < fault.ResetRate {
+				logger.Info("Injecting connection reset")
+				resetConnection(c)
+				return nil
+			}
+
+			if fault.ErrorRate > 0 && i.random() < fault.ErrorRate {
+				logger.Info("Injecting error response", "status", fault.ErrorStatus)
+				return problem.Write(c, fault.ErrorStatus, "Injected fault")
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// resetConnection aborts the request by closing the underlying TCP connection
+// with SO_LINGER 0, which makes the client observe a connection reset.
+func resetConnection(c echo.Context) {
+	hijacker, ok := c.Response().Writer.(http.Hijacker)
+	if !ok {
+		// Let net/http abort the response without logging a stack trace
+		panic(http.ErrAbortHandler)
+	}
+
+	conn, _, err := hijacker.Hijack()
+	if err != nil {
+		panic(http.ErrAbortHandler)
+	}
+	if tcpConn, ok := conn.(*net.TCPConn); ok {
+		_ = tcpConn.SetLinger(0)
+	}
+	_ = conn.Close()
+}
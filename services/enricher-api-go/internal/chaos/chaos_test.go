@@ -0,0 +1,178 @@
+package chaos
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// setupChaosApp wires the injector, admin API and a sample route
+func setupChaosApp(injector *Injector) *echo.Echo {
+	e := echo.New()
+	e.Use(injector.Middleware("/admin"))
+
+	handler := NewHandler(injector)
+	e.GET("/admin/faults", handler.ListFaults)
+	e.POST("/admin/faults", handler.SetFault)
+	e.DELETE("/admin/faults", handler.DeleteFaults)
+
+	e.GET("/v1/products/:id", func(c echo.Context) error {
+		return c.String(http.StatusOK, c.Param("id"))
+	})
+	return e
+}
+
+func TestInjector_Set_Validation(t *testing.T) {
+	tests := []struct {
+		name  string
+		fault Fault
+	}{
+		{name: "missing route", fault: Fault{ErrorRate: 0.5}},
+		{name: "error rate above one", fault: Fault{Route: "/v1/products/:id", ErrorRate: 1.5}},
+		{name: "negative reset rate", fault: Fault{Route: "/v1/products/:id", ResetRate: -0.1}},
+		{name: "latency too long", fault: Fault{Route: "/v1/products/:id", LatencyMs: 120000}},
+		{name: "success status", fault: Fault{Route: "/v1/products/:id", ErrorRate: 1, ErrorStatus: 200}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			_, err := NewInjector().Set(tt.fault)
+
+			// Assert
+			if !errors.Is(err, ErrInvalidFault) {
+				t.Errorf("Expected ErrInvalidFault, got %v", err)
+			}
+		})
+	}
+}
+
+func TestMiddleware_InjectsErrors(t *testing.T) {
+	// Arrange
+	injector := NewInjector()
+	injector.random = func() float64 { return 0.25 }
+	e := setupChaosApp(injector)
+
+	body := `{"route":"/v1/products/:id","method":"get","errorRate":0.5}`
+	req := httptest.NewRequest(http.MethodPost, "/admin/faults", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected 201 configuring fault, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// Act
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/products/product-123", nil))
+
+	// Assert
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected injected 503, got %d", rec.Code)
+	}
+
+	// Roll above the error rate: the request goes through
+	injector.random = func() float64 { return 0.75 }
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/products/product-123", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected 200 when the roll misses, got %d", rec.Code)
+	}
+}
+
+func TestMiddleware_InjectsLatency(t *testing.T) {
+	// Arrange
+	injector := NewInjector()
+	if _, err := injector.Set(Fault{Route: "/v1/products/:id", LatencyMs: 50}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	e := setupChaosApp(injector)
+
+	// Act
+	start := time.Now()
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/products/product-123", nil))
+	elapsed := time.Since(start)
+
+	// Assert
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected 200, got %d", rec.Code)
+	}
+	if elapsed < 50*time.Millisecond {
+		t.Errorf("Expected at least 50ms of latency, got %s", elapsed)
+	}
+}
+
+func TestMiddleware_ResetsConnections(t *testing.T) {
+	// Arrange
+	injector := NewInjector()
+	if _, err := injector.Set(Fault{Route: "/v1/products/:id", ResetRate: 1}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	server := httptest.NewServer(setupChaosApp(injector))
+	defer server.Close()
+
+	// Act
+	resp, err := http.Get(server.URL + "/v1/products/product-123")
+
+	// Assert
+	if err == nil {
+		resp.Body.Close()
+		t.Fatalf("Expected connection error, got status %d", resp.StatusCode)
+	}
+}
+
+func TestMiddleware_ExemptsAdminRoutes(t *testing.T) {
+	// Arrange
+	injector := NewInjector()
+	if _, err := injector.Set(Fault{Route: "/admin/faults", ErrorRate: 1}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	e := setupChaosApp(injector)
+
+	// Act
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/faults", nil))
+
+	// Assert
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected admin API to stay reachable, got %d", rec.Code)
+	}
+}
+
+func TestHandler_DeleteFaults(t *testing.T) {
+	// Arrange
+	injector := NewInjector()
+	injector.Set(Fault{Route: "/v1/products/:id", Method: "GET", ErrorRate: 1})
+	injector.Set(Fault{Route: "/v1/customers/:id", ErrorRate: 1})
+	e := setupChaosApp(injector)
+
+	// Act
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/admin/faults?route=/v1/products/:id&method=GET", nil))
+
+	// Assert
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("Expected 204, got %d", rec.Code)
+	}
+	if faults := injector.List(); len(faults) != 1 || faults[0].Route != "/v1/customers/:id" {
+		t.Errorf("Expected only the customer fault to remain, got %v", faults)
+	}
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/admin/faults?route=/v1/missing", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 removing unknown fault, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/admin/faults", nil))
+	if rec.Code != http.StatusNoContent || len(injector.List()) != 0 {
+		t.Errorf("Expected all faults cleared, got %d and %v", rec.Code, injector.List())
+	}
+}
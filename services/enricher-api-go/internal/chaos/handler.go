@@ -0,0 +1,66 @@
+package chaos
+
+import (
+	"errors"
+	"net/http"
+
+	"enricher-api-go/internal/problem"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Handler exposes the fault-injection admin API
+type Handler struct {
+	injector *Injector
+}
+
+// NewHandler creates a new chaos admin handler
+func NewHandler(injector *Injector) *Handler {
+	return &Handler{
+		injector: injector,
+	}
+}
+
+// ListFaults handles GET /admin/faults
+func (h *Handler) ListFaults(c echo.Context) error {
+	faults := h.injector.List()
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"faults": faults,
+		"count":  len(faults),
+	})
+}
+
+// SetFault handles POST /admin/faults, creating or replacing the fault for a route
+func (h *Handler) SetFault(c echo.Context) error {
+	var req Fault
+	if err := c.Bind(&req); err != nil {
+		return problem.Write(c, http.StatusBadRequest, "Invalid request body")
+	}
+
+	fault, err := h.injector.Set(req)
+	if err != nil {
+		if errors.Is(err, ErrInvalidFault) {
+			return problem.Write(c, http.StatusBadRequest, err.Error())
+		}
+		return problem.ServerError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, fault)
+}
+
+// DeleteFaults handles DELETE /admin/faults.
+//
+// With ?route= (and optionally ?method=) it removes that fault; without it
+// every fault is cleared.
+func (h *Handler) DeleteFaults(c echo.Context) error {
+	route := c.QueryParam("route")
+	if route == "" {
+		h.injector.Clear()
+		return c.NoContent(http.StatusNoContent)
+	}
+
+	if !h.injector.Remove(c.QueryParam("method"), route) {
+		return problem.Write(c, http.StatusNotFound, "Fault not found")
+	}
+	return c.NoContent(http.StatusNoContent)
+}
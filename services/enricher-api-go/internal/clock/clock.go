@@ -0,0 +1,31 @@
+// Package clock abstracts the current time so services that stamp records
+// can be tested against a fixed instant.
+package clock
+
+import "time"
+
+// Clock reports the current time
+type Clock interface {
+	Now() time.Time
+}
+
+// System is the wall clock, reported in UTC
+type System struct{}
+
+// Now returns the current time in UTC
+func (System) Now() time.Time {
+	return time.Now().UTC()
+}
+
+// Func adapts a function to a Clock
+type Func func() time.Time
+
+// Now calls f
+func (f Func) Now() time.Time {
+	return f()
+}
+
+// Fixed returns a Clock that always reports t
+func Fixed(t time.Time) Clock {
+	return Func(func() time.Time { return t })
+}
@@ -0,0 +1,30 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSystem_ReportsUTC(t *testing.T) {
+	// Act
+	now := System{}.Now()
+
+	// Assert
+	if now.Location() != time.UTC {
+		t.Errorf("Expected UTC, got %s", now.Location())
+	}
+}
+
+func TestFixed(t *testing.T) {
+	// Arrange
+	instant := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	c := Fixed(instant)
+
+	// Act
+	first, second := c.Now(), c.Now()
+
+	// Assert
+	if !first.Equal(instant) || !second.Equal(instant) {
+		t.Errorf("Expected %s twice, got %s and %s", instant, first, second)
+	}
+}
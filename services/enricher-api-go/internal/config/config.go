@@ -0,0 +1,1491 @@
+// Package config loads the enricher API settings from an optional YAML file
+// and environment variables.
+//
+// Settings are resolved in three layers: built-in defaults, then the YAML
+// file named by CONFIG_FILE (if set), then individual environment variables.
+// The merged result is validated before it is returned.
+package config
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Supported storage backends
+const (
+	StorageMemory   = "memory"
+	StoragePostgres = "postgres"
+	StorageSQLite   = "sqlite"
+	StorageDynamoDB = "dynamodb"
+)
+
+// Supported cache backends
+const (
+	CacheNone   = "none"
+	CacheMemory = "memory"
+	CacheRedis  = "redis"
+)
+
+// Config holds every setting of the enricher API
+type Config struct {
+	Server      ServerConfig      `yaml:"server"`
+	Storage     StorageConfig     `yaml:"storage"`
+	Cache       CacheConfig       `yaml:"cache"`
+	LogLevel    string            `yaml:"logLevel"`
+	CORS        CORSConfig        `yaml:"cors"`
+	Compression CompressionConfig `yaml:"compression"`
+	PayloadLog  PayloadLogConfig  `yaml:"payloadLog"`
+	IDGenerator string            `yaml:"idGenerator"`
+	Kafka       KafkaConfig       `yaml:"kafka"`
+	Customer    CustomerConfig    `yaml:"customer"`
+	Product     ProductConfig     `yaml:"product"`
+	Order       OrderConfig       `yaml:"order"`
+	Loyalty     LoyaltyConfig     `yaml:"loyalty"`
+	Tax         TaxConfig         `yaml:"tax"`
+	Shipping    ShippingConfig    `yaml:"shipping"`
+	Chaos       ChaosConfig       `yaml:"chaos"`
+	Admin       AdminConfig       `yaml:"admin"`
+	Auth        AuthConfig        `yaml:"auth"`
+	RateLimit   RateLimitConfig   `yaml:"rateLimit"`
+	LoadShed    LoadShedConfig    `yaml:"loadShed"`
+	Bulkhead    BulkheadConfig    `yaml:"bulkhead"`
+	Jobs        JobsConfig        `yaml:"jobs"`
+	Import      ImportConfig      `yaml:"import"`
+	Media       MediaConfig       `yaml:"media"`
+	Privacy     PrivacyConfig     `yaml:"privacy"`
+	Encryption  EncryptionConfig  `yaml:"encryption"`
+	Outbox      OutboxConfig      `yaml:"outbox"`
+	Webhooks    WebhooksConfig    `yaml:"webhooks"`
+}
+
+// ServerConfig holds the HTTP listener settings
+type ServerConfig struct {
+	Port         int           `yaml:"port"`
+	ReadTimeout  time.Duration `yaml:"readTimeout"`
+	WriteTimeout time.Duration `yaml:"writeTimeout"`
+	IdleTimeout  time.Duration `yaml:"idleTimeout"`
+	// ShutdownTimeout bounds how long in-flight requests and shutdown hooks may take
+	ShutdownTimeout time.Duration `yaml:"shutdownTimeout"`
+	// RequestTimeout bounds each request, answering 504 once it passes; zero
+	// leaves requests unbounded. Streams and imports are exempt.
+	RequestTimeout time.Duration `yaml:"requestTimeout"`
+	// LookupTimeout bounds the hot single-record reads, GET /:id of
+	// customers, products and orders, below RequestTimeout
+	LookupTimeout time.Duration `yaml:"lookupTimeout"`
+	// CacheMaxAge is how long clients may reuse a GET response before
+	// revalidating it with its ETag; zero makes them revalidate every time
+	CacheMaxAge time.Duration `yaml:"cacheMaxAge"`
+	// HTTPCache tags GET responses with ETags and answers conditional
+	// requests; a feature flag the admin API can toggle
+	HTTPCache bool `yaml:"httpCache"`
+	// ServerTiming reports phase durations in the Server-Timing header; a
+	// feature flag the admin API can toggle
+	ServerTiming bool `yaml:"serverTiming"`
+	// TrustedProxies are the CIDR ranges of the proxies in front of the
+	// server. The client IP is read from X-Forwarded-For only past these;
+	// with none, it is the address of the connection.
+	TrustedProxies []string `yaml:"trustedProxies"`
+}
+
+// Address returns the listen address for the configured port
+func (s ServerConfig) Address() string {
+	return fmt.Sprintf(":%d", s.Port)
+}
+
+// StorageConfig selects the repository backend
+type StorageConfig struct {
+	Backend     string `yaml:"backend"`
+	DatabaseURL string `yaml:"databaseUrl"`
+	// SQLitePath is the database file of the sqlite backend, or :memory: for
+	// a database that lives as long as the process
+	SQLitePath string `yaml:"sqlitePath"`
+	// DynamoDB configures the dynamodb backend
+	DynamoDB DynamoDBConfig `yaml:"dynamodb"`
+	// AutoMigrate applies pending schema migrations on start; when false the
+	// schema is migrated with the migrate command and readiness fails until
+	// it is up to date
+	AutoMigrate bool `yaml:"autoMigrate"`
+	// SeedFile is a JSON or YAML fixture file: the data the in-memory backend
+	// starts with instead of the built-in samples, and the data the --seed
+	// flag writes to any backend
+	SeedFile string `yaml:"seedFile"`
+	// EventSourcing records customer changes as replayable events; memory
+	// backend only
+	EventSourcing EventSourcingConfig `yaml:"eventSourcing"`
+	// CircuitBreaker guards database backends; the in-memory backend never fails
+	CircuitBreaker CircuitBreakerConfig `yaml:"circuitBreaker"`
+	// Retry retries database calls that fail transiently, beneath the
+	// circuit breaker
+	Retry RetryConfig `yaml:"retry"`
+}
+
+// EventSourcingConfig records every customer change as an event, so the
+// history of a customer can be read and replayed to any instant
+type EventSourcingConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// SnapshotEvery is how many events of a customer are recorded between
+	// snapshots, which replays start from
+	SnapshotEvery int `yaml:"snapshotEvery"`
+}
+
+// DynamoDBConfig locates the single table of the dynamodb backend
+type DynamoDBConfig struct {
+	// Table is created on start if it does not exist
+	Table string `yaml:"table"`
+	// Region is the AWS region; empty uses AWS_REGION
+	Region string `yaml:"region"`
+	// Endpoint overrides the service endpoint, such as DynamoDB Local
+	Endpoint string `yaml:"endpoint"`
+	// MaxAttempts is the most attempts per request, retrying throttled ones
+	// with exponential backoff capped at MaxBackoff
+	MaxAttempts int           `yaml:"maxAttempts"`
+	MaxBackoff  time.Duration `yaml:"maxBackoff"`
+}
+
+// CircuitBreakerConfig fails storage calls fast after repeated failures
+type CircuitBreakerConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// FailureThreshold is how many consecutive failures open the breaker
+	FailureThreshold int `yaml:"failureThreshold"`
+	// OpenTimeout is how long the breaker stays open before trial calls
+	OpenTimeout time.Duration `yaml:"openTimeout"`
+	// HalfOpenRequests is how many trial calls must succeed to close it again
+	HalfOpenRequests int `yaml:"halfOpenRequests"`
+}
+
+// RetryConfig retries storage calls that fail transiently, with jittered
+// exponential backoff. Writes are retried only when the failure shows
+// nothing was written.
+type RetryConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// MaxAttempts is the most attempts of a call, the first included
+	MaxAttempts int `yaml:"maxAttempts"`
+	// BaseDelay is the most the first retry waits; each later one may wait
+	// twice as long as the one before
+	BaseDelay time.Duration `yaml:"baseDelay"`
+	// MaxDelay caps the wait before any retry
+	MaxDelay time.Duration `yaml:"maxDelay"`
+}
+
+// CacheConfig caches customer and product lookups in front of storage
+type CacheConfig struct {
+	// Backend is none, memory (per instance) or redis (shared)
+	Backend     string        `yaml:"backend"`
+	CustomerTTL time.Duration `yaml:"customerTtl"`
+	ProductTTL  time.Duration `yaml:"productTtl"`
+	// MaxEntries bounds the in-process cache, which also stands in for Redis
+	// while it is unreachable
+	MaxEntries int `yaml:"maxEntries"`
+	// Shards splits the memory backend into independently locked parts
+	Shards int         `yaml:"shards"`
+	Redis  RedisConfig `yaml:"redis"`
+}
+
+// RedisConfig locates the Redis server of the redis cache backend
+type RedisConfig struct {
+	Addr     string `yaml:"addr"`
+	Password string `yaml:"password"`
+	DB       int    `yaml:"db"`
+	PoolSize int    `yaml:"poolSize"`
+	// Timeout bounds connecting and each command
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// CORSConfig lists the origins allowed to call the API from a browser
+type CORSConfig struct {
+	AllowOrigins []string `yaml:"allowOrigins"`
+}
+
+// CompressionConfig controls gzip response compression
+type CompressionConfig struct {
+	MinLength   int      `yaml:"minLength"`
+	ExemptPaths []string `yaml:"exemptPaths"`
+}
+
+// PayloadLogConfig controls logging of request and response bodies, to
+// diagnose enrichment mismatches
+type PayloadLogConfig struct {
+	// Enabled logs the payloads of sampled requests; a feature flag
+	Enabled bool `yaml:"enabled"`
+	// SampleRate is the fraction of requests logged, from 0 to 1
+	SampleRate float64 `yaml:"sampleRate"`
+	// MaxBodySize is the most bytes of each body logged
+	MaxBodySize int `yaml:"maxBodySize"`
+	// Redact selects the fields masked in logged bodies
+	Redact []RedactRule `yaml:"redact"`
+}
+
+// RedactRule masks Fields, JSON keys or dotted paths of keys such as
+// customer.name, in the bodies of requests whose path starts with Path, or
+// of every request when Path is empty
+type RedactRule struct {
+	Path   string   `yaml:"path"`
+	Fields []string `yaml:"fields"`
+}
+
+// KafkaConfig configures the order consumer; it is disabled without brokers.
+// The outbox relay publishes to the same brokers.
+type KafkaConfig struct {
+	Brokers     []string `yaml:"brokers"`
+	GroupID     string   `yaml:"groupId"`
+	InputTopic  string   `yaml:"inputTopic"`
+	OutputTopic string   `yaml:"outputTopic"`
+	// MaxAttempts is how often an order failing transiently is tried before
+	// it is dead-lettered
+	MaxAttempts int `yaml:"maxAttempts"`
+}
+
+// CustomerConfig holds customer service settings; zero values keep the service defaults
+type CustomerConfig struct {
+	MaxSegments  int `yaml:"maxSegments"`
+	MaxBatchSize int `yaml:"maxBatchSize"`
+	MaxBulkSize  int `yaml:"maxBulkSize"`
+	// SegmentRules decide the computed segments of customers
+	SegmentRules SegmentRulesConfig `yaml:"segmentRules"`
+}
+
+// SegmentRulesConfig holds the thresholds of the computed customer
+// segments; unlike other customer settings, a zero value disables its segment
+type SegmentRulesConfig struct {
+	// VIPCreditLimit is the credit limit from which a customer is VIP
+	VIPCreditLimit float64 `yaml:"vipCreditLimit"`
+	// NewFor is how long after its creation a customer is NEW
+	NewFor time.Duration `yaml:"newFor"`
+	// DormantAfter is how long without a change makes a customer DORMANT
+	DormantAfter time.Duration `yaml:"dormantAfter"`
+}
+
+// ProductConfig holds product service settings; zero values keep the service defaults
+type ProductConfig struct {
+	CategoryFilter  string   `yaml:"categoryFilter"`
+	Categories      []string `yaml:"categories"`
+	MaxSearchLength int      `yaml:"maxSearchLength"`
+	MaxBatchSize    int      `yaml:"maxBatchSize"`
+	MaxBulkSize     int      `yaml:"maxBulkSize"`
+	// Currency is the ISO 4217 code of products created without one
+	Currency string `yaml:"currency"`
+	// ExchangeRates maps currency codes to the units one unit of Currency buys
+	ExchangeRates map[string]float64 `yaml:"exchangeRates"`
+	// WeightUnit (kg, g or lb) and LengthUnit (cm, mm or in) read weights and
+	// dimensions given without a unit
+	WeightUnit string `yaml:"weightUnit"`
+	LengthUnit string `yaml:"lengthUnit"`
+	// MaxWeightKg and MaxDimensionCm reject weights and package sides above
+	// them, which usually mean a unit mix-up
+	MaxWeightKg    float64 `yaml:"maxWeightKg"`
+	MaxDimensionCm float64 `yaml:"maxDimensionCm"`
+}
+
+// OrderConfig holds order service settings
+type OrderConfig struct {
+	// ReserveStock stores new orders only together with the reservation of
+	// their stock: atomically on postgres and with compensation elsewhere
+	ReserveStock bool `yaml:"reserveStock"`
+}
+
+// LoyaltyConfig holds the lifetime points from which customers reach the
+// loyalty tiers above BRONZE; a zero value disables its tier
+type LoyaltyConfig struct {
+	SilverPoints int `yaml:"silverPoints"`
+	GoldPoints   int `yaml:"goldPoints"`
+}
+
+// Supported tax calculators
+const (
+	TaxFlat = "flat"
+	TaxHTTP = "http"
+)
+
+// TaxConfig selects the calculator quoting the tax of enriched orders and
+// POST /v1/tax/quote
+type TaxConfig struct {
+	// Calculator is flat (configured rates) or http (an external tax service)
+	Calculator string `yaml:"calculator"`
+	// Rate is the flat rate of addresses without one in Rates, such as 0.2
+	Rate float64 `yaml:"rate"`
+	// Rates maps COUNTRY-REGION or COUNTRY codes, such as US-CA or DE, to
+	// their flat rate
+	Rates map[string]float64 `yaml:"rates"`
+	// URL is where the http calculator posts quote requests
+	URL string `yaml:"url"`
+	// APIKey is sent to the tax service as a bearer token, if set
+	APIKey string `yaml:"apiKey"`
+	// Timeout bounds each call to the tax service
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// ShippingConfig sets up the table-rate carrier pricing
+// POST /v1/shipping/estimate
+type ShippingConfig struct {
+	// Carrier names the carrier in shipping options
+	Carrier string `yaml:"carrier"`
+	// OriginCountry is the two-letter code of the country orders ship from;
+	// other destinations get the international rates
+	OriginCountry string `yaml:"originCountry"`
+}
+
+// ChaosConfig enables fault injection and its /admin/faults API.
+// It must stay disabled in production.
+type ChaosConfig struct {
+	Enabled bool `yaml:"enabled"`
+}
+
+// AdminConfig enables the /admin API, which shows the effective
+// configuration, toggles feature flags, reloads the configuration and resets
+// in-memory data. It is meant for test environments; with authentication
+// enabled it requires the admin role, or the admin scope without RBAC.
+type AdminConfig struct {
+	Enabled bool `yaml:"enabled"`
+}
+
+// AuthConfig enables bearer token authentication of the /v1 routes against
+// the signing keys published by an identity provider
+type AuthConfig struct {
+	Enabled  bool   `yaml:"enabled"`
+	JWKSURL  string `yaml:"jwksUrl"`
+	Issuer   string `yaml:"issuer"`
+	Audience string `yaml:"audience"`
+	// JWKSRefreshInterval is how long fetched signing keys are reused
+	JWKSRefreshInterval time.Duration `yaml:"jwksRefreshInterval"`
+	// RBAC restricts each HTTP method to the reader, operator or admin role
+	RBAC bool `yaml:"rbac"`
+	// APIKeys are accepted in the X-API-Key header when RBAC is enabled
+	APIKeys []APIKeyConfig `yaml:"apiKeys"`
+}
+
+// APIKeyConfig is a static credential granting one role
+type APIKeyConfig struct {
+	Name string `yaml:"name"`
+	Key  string `yaml:"key"`
+	Role string `yaml:"role"`
+}
+
+// RateLimitConfig throttles each client of the /v1 routes with a token bucket
+type RateLimitConfig struct {
+	Enabled       bool `yaml:"enabled"`
+	RateLimitRule `yaml:",inline"`
+	// Groups overrides the limit for routes under a path prefix such as /v1/enrich
+	Groups map[string]RateLimitRule `yaml:"groups"`
+}
+
+// RateLimitRule sizes a client's token bucket
+type RateLimitRule struct {
+	RequestsPerSecond float64 `yaml:"requestsPerSecond"`
+	Burst             int     `yaml:"burst"`
+}
+
+// LoadShedConfig sheds low-priority requests, the lists and exports, while
+// the server is overloaded, to keep the hot lookups fast
+type LoadShedConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// MaxInFlight is how many requests may be in flight before shedding
+	MaxInFlight int `yaml:"maxInFlight"`
+	// TargetLatency is the average latency of the hot lookups above which
+	// requests are shed
+	TargetLatency time.Duration `yaml:"targetLatency"`
+	// RetryAfter is how long shed clients are told to wait
+	RetryAfter time.Duration `yaml:"retryAfter"`
+}
+
+// BulkheadConfig caps the requests in flight of each route group, so a
+// flood of requests to one resource cannot starve the others
+type BulkheadConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// MaxWait is how long a request waits for a free slot before answering 503
+	MaxWait time.Duration `yaml:"maxWait"`
+	// Groups maps path prefixes, such as /v1/products, to the most requests
+	// in flight under them; other routes are not capped
+	Groups map[string]int `yaml:"groups"`
+}
+
+// JobsConfig sizes the asynchronous enrichment job queue
+type JobsConfig struct {
+	// Workers is how many orders are enriched concurrently across all jobs
+	Workers int `yaml:"workers"`
+	// QueueSize is how many jobs may wait before submissions answer 503
+	QueueSize    int `yaml:"queueSize"`
+	MaxBatchSize int `yaml:"maxBatchSize"`
+	// ItemTimeout bounds the enrichment of each order of a job
+	ItemTimeout time.Duration `yaml:"itemTimeout"`
+	// Retention is how long completed jobs can still be fetched
+	Retention time.Duration `yaml:"retention"`
+}
+
+// ImportConfig sizes the background CSV product imports
+type ImportConfig struct {
+	// QueueSize is how many imports may wait before submissions answer 503
+	QueueSize int `yaml:"queueSize"`
+	// MaxRows is the most rows one file may hold; later rows are not imported
+	MaxRows int `yaml:"maxRows"`
+	// Retention is how long completed imports can still be fetched
+	Retention time.Duration `yaml:"retention"`
+	// Dir holds uploads until they are imported (the system temporary directory by default)
+	Dir string `yaml:"dir"`
+}
+
+// Supported media stores
+const (
+	MediaLocal = "local"
+	MediaS3    = "s3"
+)
+
+// MediaConfig selects where uploaded product images and their thumbnails
+// are stored and sizes the uploads
+type MediaConfig struct {
+	// Store is local (a directory the API serves) or s3 (a bucket)
+	Store string `yaml:"store"`
+	// Dir is the directory of the local store
+	Dir string `yaml:"dir"`
+	// BaseURL prefixes image URLs: the path the API serves the local store
+	// at, or a CDN in front of the bucket (the bucket itself when empty)
+	BaseURL string `yaml:"baseUrl"`
+	// MaxUploadSize is the most bytes an uploaded image may have
+	MaxUploadSize int `yaml:"maxUploadSize"`
+	// ThumbnailSize is the most pixels along either side of a thumbnail
+	ThumbnailSize int           `yaml:"thumbnailSize"`
+	S3            MediaS3Config `yaml:"s3"`
+}
+
+// MediaS3Config addresses the bucket of the s3 media store
+type MediaS3Config struct {
+	Bucket string `yaml:"bucket"`
+	// Region is the AWS region; empty uses the SDK's default chain (AWS_REGION)
+	Region string `yaml:"region"`
+	// Endpoint overrides the S3 endpoint, such as MinIO or LocalStack
+	Endpoint string `yaml:"endpoint"`
+}
+
+// PrivacyConfig holds the settings of GDPR data erasure
+type PrivacyConfig struct {
+	// ErasureSecret signs the tokens confirming erasure requests. When empty
+	// a random secret is generated on start, so a token only confirms an
+	// erasure on the instance that issued it.
+	ErasureSecret string `yaml:"erasureSecret"`
+	// ErasureConfirmationTTL is how long an erasure request may be confirmed
+	ErasureConfirmationTTL time.Duration `yaml:"erasureConfirmationTtl"`
+}
+
+// KMS backends wrapping the data keys of field-level encryption
+const (
+	EncryptionKMSLocal = "local"
+	EncryptionKMSAWS   = "aws"
+)
+
+// EncryptionConfig turns on encryption of customer names, emails and phones
+// at rest
+type EncryptionConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// KMS is local (master keys in KeyFile) or aws (a key in AWS KMS)
+	KMS string `yaml:"kms"`
+	// KeyFile holds the master keys of the local KMS
+	KeyFile string              `yaml:"keyFile"`
+	AWS     EncryptionAWSConfig `yaml:"aws"`
+	// DataKeys are the data keys wrapped by the KMS, base64-encoded and
+	// newest first; the first encrypts and every one decrypts
+	DataKeys []string `yaml:"dataKeys"`
+}
+
+// EncryptionAWSConfig addresses the master key of the aws KMS
+type EncryptionAWSConfig struct {
+	// KeyID is the key's ID, ARN or alias
+	KeyID string `yaml:"keyId"`
+	// Region is the AWS region; empty uses the SDK's default chain (AWS_REGION)
+	Region string `yaml:"region"`
+	// Endpoint overrides the KMS endpoint, such as LocalStack
+	Endpoint string `yaml:"endpoint"`
+}
+
+// OutboxConfig relays customer and product change events to Kafka.
+// The relay settings also apply when only webhooks are enabled.
+type OutboxConfig struct {
+	// Enabled records every customer and product change and requires Kafka brokers
+	Enabled bool   `yaml:"enabled"`
+	Topic   string `yaml:"topic"`
+	// PollInterval is how long the relay waits once no events are pending
+	PollInterval time.Duration `yaml:"pollInterval"`
+	BatchSize    int           `yaml:"batchSize"`
+	// Retention is how long published events are kept in the outbox
+	Retention time.Duration `yaml:"retention"`
+}
+
+// WebhooksConfig delivers customer and product change events to subscribed callback URLs
+type WebhooksConfig struct {
+	// Enabled records every customer and product change, as the outbox does,
+	// and serves the /v1/webhooks routes
+	Enabled bool `yaml:"enabled"`
+	// PollInterval is how long the dispatcher waits once no deliveries are due
+	PollInterval time.Duration `yaml:"pollInterval"`
+	BatchSize    int           `yaml:"batchSize"`
+	// MaxAttempts is how many times a delivery is tried before it is FAILED
+	MaxAttempts int `yaml:"maxAttempts"`
+	// InitialBackoff is the wait after the first failed attempt, doubled up to MaxBackoff
+	InitialBackoff time.Duration `yaml:"initialBackoff"`
+	MaxBackoff     time.Duration `yaml:"maxBackoff"`
+	// Timeout bounds each delivery request
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// Default returns the configuration used when nothing is overridden
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Port:         8080,
+			ReadTimeout:  10 * time.Second,
+			WriteTimeout: 30 * time.Second,
+			IdleTimeout:  60 * time.Second,
+			// Below the default 30s Kubernetes termination grace period
+			ShutdownTimeout: 15 * time.Second,
+			RequestTimeout:  2 * time.Second,
+			LookupTimeout:   500 * time.Millisecond,
+			HTTPCache:       true,
+		},
+		Storage: StorageConfig{
+			Backend:    StorageMemory,
+			SQLitePath: "enricher.db",
+			DynamoDB: DynamoDBConfig{
+				Table:       "enricher",
+				MaxAttempts: 8,
+				MaxBackoff:  5 * time.Second,
+			},
+			AutoMigrate: true,
+			EventSourcing: EventSourcingConfig{
+				SnapshotEvery: 20,
+			},
+			CircuitBreaker: CircuitBreakerConfig{
+				Enabled:          true,
+				FailureThreshold: 5,
+				OpenTimeout:      30 * time.Second,
+				HalfOpenRequests: 1,
+			},
+			Retry: RetryConfig{
+				Enabled:     true,
+				MaxAttempts: 3,
+				BaseDelay:   50 * time.Millisecond,
+				MaxDelay:    500 * time.Millisecond,
+			},
+		},
+		Cache: CacheConfig{
+			Backend:     CacheNone,
+			CustomerTTL: time.Minute,
+			ProductTTL:  5 * time.Minute,
+			MaxEntries:  10000,
+			Shards:      16,
+			Redis: RedisConfig{
+				Addr:     "localhost:6379",
+				PoolSize: 10,
+				Timeout:  500 * time.Millisecond,
+			},
+		},
+		Customer: CustomerConfig{
+			SegmentRules: SegmentRulesConfig{
+				VIPCreditLimit: 5000,
+				NewFor:         30 * 24 * time.Hour,
+				DormantAfter:   180 * 24 * time.Hour,
+			},
+		},
+		Product:  ProductConfig{WeightUnit: "kg", LengthUnit: "cm", MaxWeightKg: 1000, MaxDimensionCm: 500},
+		Loyalty:  LoyaltyConfig{SilverPoints: 1000, GoldPoints: 5000},
+		Tax:      TaxConfig{Calculator: TaxFlat, Timeout: 2 * time.Second},
+		Shipping: ShippingConfig{Carrier: "ground", OriginCountry: "US"},
+		LogLevel: "info",
+		CORS:     CORSConfig{AllowOrigins: []string{"*"}},
+		PayloadLog: PayloadLogConfig{
+			SampleRate:  0.1,
+			MaxBodySize: 4096,
+			Redact: []RedactRule{
+				{Fields: []string{"email", "phone", "line1", "line2", "postalCode", "customer.name", "secret", "confirmationToken"}},
+				{Path: "/v1/customers", Fields: []string{"name"}},
+			},
+		},
+		Compression: CompressionConfig{
+			// Gzip framing overhead makes smaller bodies grow
+			MinLength: 1024,
+			// Probes and scrapers hit these constantly and their bodies are tiny
+			ExemptPaths: []string{"/health", "/metrics"},
+		},
+		IDGenerator: "uuid",
+		Kafka:       KafkaConfig{MaxAttempts: 5},
+		Auth:        AuthConfig{JWKSRefreshInterval: 15 * time.Minute},
+		RateLimit:   RateLimitConfig{RateLimitRule: RateLimitRule{RequestsPerSecond: 50, Burst: 100}},
+		LoadShed:    LoadShedConfig{MaxInFlight: 200, TargetLatency: 250 * time.Millisecond, RetryAfter: time.Second},
+		Bulkhead: BulkheadConfig{
+			MaxWait: 50 * time.Millisecond,
+			Groups:  map[string]int{"/v1/customers": 100, "/v1/products": 50},
+		},
+		Jobs: JobsConfig{
+			Workers:      4,
+			QueueSize:    100,
+			MaxBatchSize: 1000,
+			ItemTimeout:  5 * time.Second,
+			Retention:    time.Hour,
+		},
+		Import: ImportConfig{
+			QueueSize: 10,
+			MaxRows:   100000,
+			Retention: time.Hour,
+		},
+		Media: MediaConfig{
+			Store:         MediaLocal,
+			Dir:           "media",
+			BaseURL:       "/media",
+			MaxUploadSize: 10 << 20,
+			ThumbnailSize: 256,
+		},
+		Privacy: PrivacyConfig{
+			ErasureConfirmationTTL: 15 * time.Minute,
+		},
+		Encryption: EncryptionConfig{
+			KMS: EncryptionKMSLocal,
+		},
+		Outbox: OutboxConfig{
+			Topic:        "catalog.events",
+			PollInterval: time.Second,
+			BatchSize:    100,
+			Retention:    24 * time.Hour,
+		},
+		Webhooks: WebhooksConfig{
+			PollInterval:   time.Second,
+			BatchSize:      50,
+			MaxAttempts:    10,
+			InitialBackoff: 30 * time.Second,
+			MaxBackoff:     time.Hour,
+			Timeout:        10 * time.Second,
+		},
+	}
+}
+
+// Load reads the configuration from CONFIG_FILE and the environment
+func Load() (*Config, error) {
+	return LoadFrom(os.Getenv("CONFIG_FILE"), os.LookupEnv)
+}
+
+// LoadFrom layers the YAML file at path (skipped when empty) and the variables
+// returned by lookupEnv over the defaults, then validates the result.
+func LoadFrom(path string, lookupEnv func(string) (string, bool)) (*Config, error) {
+	cfg := Default()
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+		if err := yaml.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+	}
+
+	if err := cfg.applyEnv(lookupEnv); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// applyEnv overrides settings with any environment variables that are set
+func (c *Config) applyEnv(lookupEnv func(string) (string, bool)) error {
+	env := envReader{lookup: lookupEnv}
+
+	env.int("PORT", &c.Server.Port)
+	env.duration("READ_TIMEOUT", &c.Server.ReadTimeout)
+	env.duration("WRITE_TIMEOUT", &c.Server.WriteTimeout)
+	env.duration("IDLE_TIMEOUT", &c.Server.IdleTimeout)
+	env.duration("SHUTDOWN_TIMEOUT", &c.Server.ShutdownTimeout)
+	env.duration("REQUEST_TIMEOUT", &c.Server.RequestTimeout)
+	env.duration("LOOKUP_TIMEOUT", &c.Server.LookupTimeout)
+	env.duration("HTTP_CACHE_MAX_AGE", &c.Server.CacheMaxAge)
+	env.bool("HTTP_CACHE_ENABLED", &c.Server.HTTPCache)
+	env.bool("SERVER_TIMING_ENABLED", &c.Server.ServerTiming)
+	env.list("TRUSTED_PROXIES", &c.Server.TrustedProxies)
+
+	env.string("STORAGE_BACKEND", &c.Storage.Backend)
+	env.string("DATABASE_URL", &c.Storage.DatabaseURL)
+	env.string("SQLITE_PATH", &c.Storage.SQLitePath)
+	env.string("DYNAMODB_TABLE", &c.Storage.DynamoDB.Table)
+	env.string("DYNAMODB_REGION", &c.Storage.DynamoDB.Region)
+	env.string("DYNAMODB_ENDPOINT", &c.Storage.DynamoDB.Endpoint)
+	env.int("DYNAMODB_MAX_ATTEMPTS", &c.Storage.DynamoDB.MaxAttempts)
+	env.duration("DYNAMODB_MAX_BACKOFF", &c.Storage.DynamoDB.MaxBackoff)
+	env.bool("AUTO_MIGRATE", &c.Storage.AutoMigrate)
+	env.string("SEED_FILE", &c.Storage.SeedFile)
+	env.bool("EVENT_SOURCING_ENABLED", &c.Storage.EventSourcing.Enabled)
+	env.int("EVENT_SOURCING_SNAPSHOT_EVERY", &c.Storage.EventSourcing.SnapshotEvery)
+	env.bool("CIRCUIT_BREAKER_ENABLED", &c.Storage.CircuitBreaker.Enabled)
+	env.int("CIRCUIT_BREAKER_FAILURE_THRESHOLD", &c.Storage.CircuitBreaker.FailureThreshold)
+	env.duration("CIRCUIT_BREAKER_OPEN_TIMEOUT", &c.Storage.CircuitBreaker.OpenTimeout)
+	env.int("CIRCUIT_BREAKER_HALF_OPEN_REQUESTS", &c.Storage.CircuitBreaker.HalfOpenRequests)
+	env.bool("STORAGE_RETRY_ENABLED", &c.Storage.Retry.Enabled)
+	env.int("STORAGE_RETRY_MAX_ATTEMPTS", &c.Storage.Retry.MaxAttempts)
+	env.duration("STORAGE_RETRY_BASE_DELAY", &c.Storage.Retry.BaseDelay)
+	env.duration("STORAGE_RETRY_MAX_DELAY", &c.Storage.Retry.MaxDelay)
+
+	env.string("CACHE_BACKEND", &c.Cache.Backend)
+	env.duration("CACHE_CUSTOMER_TTL", &c.Cache.CustomerTTL)
+	env.duration("CACHE_PRODUCT_TTL", &c.Cache.ProductTTL)
+	env.int("CACHE_MAX_ENTRIES", &c.Cache.MaxEntries)
+	env.int("CACHE_SHARDS", &c.Cache.Shards)
+	env.string("REDIS_ADDR", &c.Cache.Redis.Addr)
+	env.string("REDIS_PASSWORD", &c.Cache.Redis.Password)
+	env.int("REDIS_DB", &c.Cache.Redis.DB)
+	env.int("REDIS_POOL_SIZE", &c.Cache.Redis.PoolSize)
+	env.duration("REDIS_TIMEOUT", &c.Cache.Redis.Timeout)
+
+	env.string("LOG_LEVEL", &c.LogLevel)
+	env.list("CORS_ALLOW_ORIGINS", &c.CORS.AllowOrigins)
+
+	env.int("GZIP_MIN_LENGTH", &c.Compression.MinLength)
+	env.list("GZIP_EXEMPT_PATHS", &c.Compression.ExemptPaths)
+	env.bool("PAYLOAD_LOG_ENABLED", &c.PayloadLog.Enabled)
+	env.float("PAYLOAD_LOG_SAMPLE_RATE", &c.PayloadLog.SampleRate)
+	env.int("PAYLOAD_LOG_MAX_BODY_SIZE", &c.PayloadLog.MaxBodySize)
+	env.redactRules("PAYLOAD_LOG_REDACT", &c.PayloadLog.Redact)
+
+	env.string("ID_GENERATOR", &c.IDGenerator)
+
+	env.list("KAFKA_BROKERS", &c.Kafka.Brokers)
+	env.string("KAFKA_GROUP_ID", &c.Kafka.GroupID)
+	env.string("KAFKA_INPUT_TOPIC", &c.Kafka.InputTopic)
+	env.string("KAFKA_OUTPUT_TOPIC", &c.Kafka.OutputTopic)
+	env.int("KAFKA_MAX_ATTEMPTS", &c.Kafka.MaxAttempts)
+
+	env.int("CUSTOMER_MAX_SEGMENTS", &c.Customer.MaxSegments)
+	env.int("CUSTOMER_MAX_BATCH_SIZE", &c.Customer.MaxBatchSize)
+	env.int("CUSTOMER_MAX_BULK_SIZE", &c.Customer.MaxBulkSize)
+	env.float("CUSTOMER_SEGMENT_VIP_CREDIT_LIMIT", &c.Customer.SegmentRules.VIPCreditLimit)
+	env.duration("CUSTOMER_SEGMENT_NEW_FOR", &c.Customer.SegmentRules.NewFor)
+	env.duration("CUSTOMER_SEGMENT_DORMANT_AFTER", &c.Customer.SegmentRules.DormantAfter)
+
+	env.string("PRODUCT_CATEGORY_FILTER", &c.Product.CategoryFilter)
+	env.list("PRODUCT_CATEGORIES", &c.Product.Categories)
+	env.int("PRODUCT_MAX_SEARCH_LENGTH", &c.Product.MaxSearchLength)
+	env.int("PRODUCT_MAX_BATCH_SIZE", &c.Product.MaxBatchSize)
+	env.int("PRODUCT_MAX_BULK_SIZE", &c.Product.MaxBulkSize)
+	env.string("PRODUCT_CURRENCY", &c.Product.Currency)
+	env.rates("PRODUCT_EXCHANGE_RATES", &c.Product.ExchangeRates)
+	env.string("PRODUCT_WEIGHT_UNIT", &c.Product.WeightUnit)
+	env.string("PRODUCT_LENGTH_UNIT", &c.Product.LengthUnit)
+	env.float("PRODUCT_MAX_WEIGHT_KG", &c.Product.MaxWeightKg)
+	env.float("PRODUCT_MAX_DIMENSION_CM", &c.Product.MaxDimensionCm)
+
+	env.bool("ORDER_RESERVE_STOCK", &c.Order.ReserveStock)
+
+	env.int("LOYALTY_SILVER_POINTS", &c.Loyalty.SilverPoints)
+	env.int("LOYALTY_GOLD_POINTS", &c.Loyalty.GoldPoints)
+
+	env.string("TAX_CALCULATOR", &c.Tax.Calculator)
+	env.float("TAX_RATE", &c.Tax.Rate)
+	env.rates("TAX_RATES", &c.Tax.Rates)
+	env.string("TAX_URL", &c.Tax.URL)
+	env.string("TAX_API_KEY", &c.Tax.APIKey)
+	env.duration("TAX_TIMEOUT", &c.Tax.Timeout)
+
+	env.string("SHIPPING_CARRIER", &c.Shipping.Carrier)
+	env.string("SHIPPING_ORIGIN_COUNTRY", &c.Shipping.OriginCountry)
+
+	env.bool("CHAOS_ENABLED", &c.Chaos.Enabled)
+	env.bool("ADMIN_ENABLED", &c.Admin.Enabled)
+
+	env.bool("AUTH_ENABLED", &c.Auth.Enabled)
+	env.string("AUTH_JWKS_URL", &c.Auth.JWKSURL)
+	env.string("AUTH_ISSUER", &c.Auth.Issuer)
+	env.string("AUTH_AUDIENCE", &c.Auth.Audience)
+	env.duration("AUTH_JWKS_REFRESH_INTERVAL", &c.Auth.JWKSRefreshInterval)
+	env.bool("AUTH_RBAC_ENABLED", &c.Auth.RBAC)
+	env.apiKeys("AUTH_API_KEYS", &c.Auth.APIKeys)
+
+	env.bool("RATE_LIMIT_ENABLED", &c.RateLimit.Enabled)
+	env.float("RATE_LIMIT_RPS", &c.RateLimit.RequestsPerSecond)
+	env.int("RATE_LIMIT_BURST", &c.RateLimit.Burst)
+
+	env.bool("LOAD_SHED_ENABLED", &c.LoadShed.Enabled)
+	env.int("LOAD_SHED_MAX_IN_FLIGHT", &c.LoadShed.MaxInFlight)
+	env.duration("LOAD_SHED_TARGET_LATENCY", &c.LoadShed.TargetLatency)
+	env.duration("LOAD_SHED_RETRY_AFTER", &c.LoadShed.RetryAfter)
+
+	env.bool("BULKHEAD_ENABLED", &c.Bulkhead.Enabled)
+	env.duration("BULKHEAD_MAX_WAIT", &c.Bulkhead.MaxWait)
+	env.limits("BULKHEAD_GROUPS", &c.Bulkhead.Groups)
+
+	env.int("JOBS_WORKERS", &c.Jobs.Workers)
+	env.int("JOBS_QUEUE_SIZE", &c.Jobs.QueueSize)
+	env.int("JOBS_MAX_BATCH_SIZE", &c.Jobs.MaxBatchSize)
+	env.duration("JOBS_ITEM_TIMEOUT", &c.Jobs.ItemTimeout)
+	env.duration("JOBS_RETENTION", &c.Jobs.Retention)
+
+	env.int("IMPORT_QUEUE_SIZE", &c.Import.QueueSize)
+	env.int("IMPORT_MAX_ROWS", &c.Import.MaxRows)
+	env.duration("IMPORT_RETENTION", &c.Import.Retention)
+	env.string("IMPORT_DIR", &c.Import.Dir)
+
+	env.string("MEDIA_STORE", &c.Media.Store)
+	env.string("MEDIA_DIR", &c.Media.Dir)
+	env.string("MEDIA_BASE_URL", &c.Media.BaseURL)
+	env.int("MEDIA_MAX_UPLOAD_SIZE", &c.Media.MaxUploadSize)
+	env.int("MEDIA_THUMBNAIL_SIZE", &c.Media.ThumbnailSize)
+	env.string("MEDIA_S3_BUCKET", &c.Media.S3.Bucket)
+	env.string("MEDIA_S3_REGION", &c.Media.S3.Region)
+	env.string("MEDIA_S3_ENDPOINT", &c.Media.S3.Endpoint)
+
+	env.string("ERASURE_SECRET", &c.Privacy.ErasureSecret)
+	env.duration("ERASURE_CONFIRMATION_TTL", &c.Privacy.ErasureConfirmationTTL)
+	env.bool("ENCRYPTION_ENABLED", &c.Encryption.Enabled)
+	env.string("ENCRYPTION_KMS", &c.Encryption.KMS)
+	env.string("ENCRYPTION_KEY_FILE", &c.Encryption.KeyFile)
+	env.string("ENCRYPTION_AWS_KEY_ID", &c.Encryption.AWS.KeyID)
+	env.string("ENCRYPTION_AWS_REGION", &c.Encryption.AWS.Region)
+	env.string("ENCRYPTION_AWS_ENDPOINT", &c.Encryption.AWS.Endpoint)
+	env.list("ENCRYPTION_DATA_KEYS", &c.Encryption.DataKeys)
+
+	env.bool("OUTBOX_ENABLED", &c.Outbox.Enabled)
+	env.string("OUTBOX_TOPIC", &c.Outbox.Topic)
+	env.duration("OUTBOX_POLL_INTERVAL", &c.Outbox.PollInterval)
+	env.int("OUTBOX_BATCH_SIZE", &c.Outbox.BatchSize)
+	env.duration("OUTBOX_RETENTION", &c.Outbox.Retention)
+
+	env.bool("WEBHOOKS_ENABLED", &c.Webhooks.Enabled)
+	env.duration("WEBHOOKS_POLL_INTERVAL", &c.Webhooks.PollInterval)
+	env.int("WEBHOOKS_BATCH_SIZE", &c.Webhooks.BatchSize)
+	env.int("WEBHOOKS_MAX_ATTEMPTS", &c.Webhooks.MaxAttempts)
+	env.duration("WEBHOOKS_INITIAL_BACKOFF", &c.Webhooks.InitialBackoff)
+	env.duration("WEBHOOKS_MAX_BACKOFF", &c.Webhooks.MaxBackoff)
+	env.duration("WEBHOOKS_TIMEOUT", &c.Webhooks.Timeout)
+
+	return errors.Join(env.errs...)
+}
+
+// Validate reports every invalid setting at once
+func (c *Config) Validate() error {
+	var errs []error
+	invalid := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if c.Server.Port < 1 || c.Server.Port > 65535 {
+		invalid("port must be between 1 and 65535, got %d", c.Server.Port)
+	}
+	if c.Server.ReadTimeout < 0 || c.Server.WriteTimeout < 0 || c.Server.IdleTimeout < 0 ||
+		c.Server.RequestTimeout < 0 || c.Server.LookupTimeout < 0 {
+		invalid("server timeouts must not be negative")
+	}
+	if c.Server.CacheMaxAge < 0 {
+		invalid("cache max age must not be negative, got %s", c.Server.CacheMaxAge)
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		invalid("shutdown timeout must be positive, got %s", c.Server.ShutdownTimeout)
+	}
+	for _, proxy := range c.Server.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil {
+			invalid("trusted proxy must be a CIDR range such as 10.0.0.0/8, got %q", proxy)
+		}
+	}
+
+	c.Storage.Backend = strings.ToLower(c.Storage.Backend)
+	switch c.Storage.Backend {
+	case StorageMemory:
+	case StoragePostgres:
+		if c.Storage.DatabaseURL == "" {
+			invalid("DATABASE_URL is required for the postgres storage backend")
+		}
+	case StorageSQLite:
+		if c.Storage.SQLitePath == "" {
+			invalid("SQLITE_PATH is required for the sqlite storage backend")
+		}
+	case StorageDynamoDB:
+		if dynamo := c.Storage.DynamoDB; dynamo.Table == "" {
+			invalid("DYNAMODB_TABLE is required for the dynamodb storage backend")
+		} else if dynamo.MaxAttempts < 1 || dynamo.MaxBackoff <= 0 {
+			invalid("dynamodb max attempts must be at least 1 and max backoff positive, got %d and %s", dynamo.MaxAttempts, dynamo.MaxBackoff)
+		}
+	default:
+		invalid("unknown storage backend %q (expected %s, %s, %s or %s)", c.Storage.Backend, StorageMemory, StoragePostgres, StorageSQLite, StorageDynamoDB)
+	}
+	if breaker := c.Storage.CircuitBreaker; breaker.Enabled {
+		if breaker.FailureThreshold < 1 {
+			invalid("circuit breaker failure threshold must be at least 1, got %d", breaker.FailureThreshold)
+		}
+		if breaker.OpenTimeout <= 0 {
+			invalid("circuit breaker open timeout must be positive, got %s", breaker.OpenTimeout)
+		}
+		if breaker.HalfOpenRequests < 1 {
+			invalid("circuit breaker half-open requests must be at least 1, got %d", breaker.HalfOpenRequests)
+		}
+	}
+	if retry := c.Storage.Retry; retry.Enabled {
+		if retry.MaxAttempts < 1 {
+			invalid("storage retry max attempts must be at least 1, got %d", retry.MaxAttempts)
+		}
+		if retry.BaseDelay <= 0 || retry.MaxDelay < retry.BaseDelay {
+			invalid("storage retry delays must be positive, with the max delay at least the base delay, got %s and %s", retry.BaseDelay, retry.MaxDelay)
+		}
+	}
+
+	if sourcing := c.Storage.EventSourcing; sourcing.Enabled {
+		if c.Storage.Backend != StorageMemory {
+			invalid("event sourcing requires the %s storage backend, got %s", StorageMemory, c.Storage.Backend)
+		}
+		if sourcing.SnapshotEvery < 1 {
+			invalid("event sourcing snapshot interval must be at least 1, got %d", sourcing.SnapshotEvery)
+		}
+	}
+
+	c.Cache.Backend = strings.ToLower(c.Cache.Backend)
+	switch c.Cache.Backend {
+	case CacheNone:
+	case CacheMemory, CacheRedis:
+		if c.Cache.CustomerTTL <= 0 || c.Cache.ProductTTL <= 0 {
+			invalid("cache TTLs must be positive, got %s and %s", c.Cache.CustomerTTL, c.Cache.ProductTTL)
+		}
+		if c.Cache.MaxEntries < 1 {
+			invalid("cache max entries must be at least 1, got %d", c.Cache.MaxEntries)
+		}
+		if c.Cache.Shards < 1 {
+			invalid("cache shards must be at least 1, got %d", c.Cache.Shards)
+		}
+		if c.Cache.Backend == CacheRedis {
+			if c.Cache.Redis.Addr == "" {
+				invalid("REDIS_ADDR is required for the redis cache backend")
+			}
+			if c.Cache.Redis.PoolSize < 1 || c.Cache.Redis.Timeout <= 0 {
+				invalid("redis pool size and timeout must be positive, got %d and %s", c.Cache.Redis.PoolSize, c.Cache.Redis.Timeout)
+			}
+		}
+	default:
+		invalid("unknown cache backend %q (expected %s, %s or %s)", c.Cache.Backend, CacheNone, CacheMemory, CacheRedis)
+	}
+
+	c.LogLevel = strings.ToLower(c.LogLevel)
+	switch c.LogLevel {
+	case "debug", "info", "warn", "error":
+	default:
+		invalid("unknown log level %q (expected debug, info, warn or error)", c.LogLevel)
+	}
+
+	if len(c.CORS.AllowOrigins) == 0 {
+		invalid("at least one CORS origin is required")
+	}
+
+	if c.Compression.MinLength < 0 {
+		invalid("gzip minimum length must not be negative, got %d", c.Compression.MinLength)
+	}
+	if rate := c.PayloadLog.SampleRate; rate < 0 || rate > 1 {
+		invalid("payload log sample rate must be between 0 and 1, got %g", rate)
+	}
+	if c.PayloadLog.MaxBodySize < 1 {
+		invalid("payload log max body size must be at least 1, got %d", c.PayloadLog.MaxBodySize)
+	}
+	for _, rule := range c.PayloadLog.Redact {
+		if rule.Path != "" && !strings.HasPrefix(rule.Path, "/") {
+			invalid("payload log redaction path must start with /, got %q", rule.Path)
+		}
+		for _, field := range rule.Fields {
+			if field == "" || slices.Contains(strings.Split(field, "."), "") {
+				invalid("payload log redaction field %q must be keys joined by dots", field)
+			}
+		}
+	}
+
+	switch c.IDGenerator {
+	case "uuid":
+	case "sequential":
+		// A counter that restarts would hand out IDs already stored
+		if c.Storage.Backend != StorageMemory && c.Storage.Backend != StoragePostgres {
+			invalid("the sequential ID generator requires the %s or %s storage backend, got %s", StorageMemory, StoragePostgres, c.Storage.Backend)
+		}
+	default:
+		invalid("unknown ID generator %q (expected uuid or sequential)", c.IDGenerator)
+	}
+
+	if c.Kafka.MaxAttempts < 1 {
+		invalid("Kafka max attempts must be at least 1, got %d", c.Kafka.MaxAttempts)
+	}
+
+	if c.Customer.MaxSegments < 0 {
+		invalid("customer max segments must not be negative, got %d", c.Customer.MaxSegments)
+	}
+	if c.Customer.MaxBatchSize < 0 {
+		invalid("customer max batch size must not be negative, got %d", c.Customer.MaxBatchSize)
+	}
+	if c.Customer.MaxBulkSize < 0 {
+		invalid("customer max bulk size must not be negative, got %d", c.Customer.MaxBulkSize)
+	}
+	if rules := c.Customer.SegmentRules; rules.VIPCreditLimit < 0 || rules.NewFor < 0 || rules.DormantAfter < 0 {
+		invalid("customer segment rules must not be negative, got %v, %s and %s", rules.VIPCreditLimit, rules.NewFor, rules.DormantAfter)
+	}
+
+	if tiers := c.Loyalty; tiers.SilverPoints < 0 || tiers.GoldPoints < 0 {
+		invalid("loyalty tier points must not be negative, got %d and %d", tiers.SilverPoints, tiers.GoldPoints)
+	} else if tiers.SilverPoints > 0 && tiers.GoldPoints > 0 && tiers.GoldPoints <= tiers.SilverPoints {
+		invalid("loyalty gold points must be above silver points, got %d and %d", tiers.GoldPoints, tiers.SilverPoints)
+	}
+
+	switch c.Product.CategoryFilter {
+	case "", "lenient", "strict":
+	default:
+		invalid("unknown product category filter %q (expected lenient or strict)", c.Product.CategoryFilter)
+	}
+	if c.Product.MaxSearchLength < 0 {
+		invalid("product max search length must not be negative, got %d", c.Product.MaxSearchLength)
+	}
+	if c.Product.MaxBatchSize < 0 {
+		invalid("product max batch size must not be negative, got %d", c.Product.MaxBatchSize)
+	}
+	if c.Product.MaxBulkSize < 0 {
+		invalid("product max bulk size must not be negative, got %d", c.Product.MaxBulkSize)
+	}
+	c.Product.Currency = strings.ToUpper(c.Product.Currency)
+	if c.Product.Currency != "" && !isCurrencyCode(c.Product.Currency) {
+		invalid("product currency %q must be a three-letter ISO 4217 code", c.Product.Currency)
+	}
+	for code, rate := range c.Product.ExchangeRates {
+		if !isCurrencyCode(strings.ToUpper(code)) || rate <= 0 {
+			invalid("exchange rate %s=%g needs a three-letter currency code and a positive rate", code, rate)
+		}
+	}
+	switch c.Product.WeightUnit {
+	case "kg", "g", "lb":
+	default:
+		invalid("unknown product weight unit %q (expected kg, g or lb)", c.Product.WeightUnit)
+	}
+	switch c.Product.LengthUnit {
+	case "cm", "mm", "in":
+	default:
+		invalid("unknown product length unit %q (expected cm, mm or in)", c.Product.LengthUnit)
+	}
+	if c.Product.MaxWeightKg <= 0 || c.Product.MaxDimensionCm <= 0 {
+		invalid("product max weight and dimension must be positive, got %g kg and %g cm", c.Product.MaxWeightKg, c.Product.MaxDimensionCm)
+	}
+
+	switch c.Tax.Calculator {
+	case TaxFlat:
+	case TaxHTTP:
+		if c.Tax.URL == "" {
+			invalid("TAX_URL is required with the http tax calculator")
+		}
+	default:
+		invalid("unknown tax calculator %q (expected flat or http)", c.Tax.Calculator)
+	}
+	if c.Tax.Rate < 0 || c.Tax.Rate >= 1 {
+		invalid("tax rate must be at least 0 and below 1, got %g", c.Tax.Rate)
+	}
+	for code, rate := range c.Tax.Rates {
+		if rate < 0 || rate >= 1 {
+			invalid("tax rate %s=%g must be at least 0 and below 1", code, rate)
+		}
+	}
+	if c.Tax.Timeout <= 0 {
+		invalid("tax timeout must be positive, got %s", c.Tax.Timeout)
+	}
+
+	if strings.TrimSpace(c.Shipping.Carrier) == "" {
+		invalid("shipping carrier must not be empty")
+	}
+	if !isCountryCode(c.Shipping.OriginCountry) {
+		invalid("shipping origin country must be a two-letter country code, got %q", c.Shipping.OriginCountry)
+	}
+
+	if c.Auth.Enabled && c.Auth.JWKSURL == "" {
+		invalid("AUTH_JWKS_URL is required when authentication is enabled")
+	}
+	if c.Auth.JWKSRefreshInterval <= 0 {
+		invalid("JWKS refresh interval must be positive, got %s", c.Auth.JWKSRefreshInterval)
+	}
+	if c.Auth.RBAC && !c.Auth.Enabled && len(c.Auth.APIKeys) == 0 {
+		invalid("RBAC requires bearer authentication or at least one API key")
+	}
+	if len(c.Auth.APIKeys) > 0 && !c.Auth.RBAC {
+		invalid("API keys require RBAC to be enabled")
+	}
+	for i, key := range c.Auth.APIKeys {
+		if key.Name == "" || key.Key == "" {
+			invalid("API key %d needs a name and a key", i+1)
+		}
+		switch key.Role {
+		case "reader", "operator", "admin":
+		default:
+			invalid("unknown role %q for API key %q (expected reader, operator or admin)", key.Role, key.Name)
+		}
+	}
+
+	if c.RateLimit.Enabled {
+		c.RateLimit.RateLimitRule.validate("default", invalid)
+		for prefix, rule := range c.RateLimit.Groups {
+			if !strings.HasPrefix(prefix, "/") {
+				invalid("rate limit group %q must be a path prefix starting with /", prefix)
+			}
+			rule.validate(prefix, invalid)
+		}
+	}
+
+	if c.LoadShed.Enabled {
+		if c.LoadShed.MaxInFlight < 1 {
+			invalid("load shed max in flight must be at least 1, got %d", c.LoadShed.MaxInFlight)
+		}
+		if c.LoadShed.TargetLatency <= 0 || c.LoadShed.RetryAfter <= 0 {
+			invalid("load shed target latency and retry after must be positive")
+		}
+	}
+
+	if c.Bulkhead.Enabled {
+		if c.Bulkhead.MaxWait < 0 {
+			invalid("bulkhead max wait must not be negative, got %s", c.Bulkhead.MaxWait)
+		}
+		for prefix, limit := range c.Bulkhead.Groups {
+			if !strings.HasPrefix(prefix, "/") {
+				invalid("bulkhead group %q must be a path prefix starting with /", prefix)
+			}
+			if limit < 1 {
+				invalid("bulkhead group %s must allow at least 1 request, got %d", prefix, limit)
+			}
+		}
+	}
+
+	if c.Jobs.Workers < 1 || c.Jobs.QueueSize < 1 || c.Jobs.MaxBatchSize < 1 {
+		invalid("job workers, queue size and max batch size must be at least 1, got %d, %d and %d", c.Jobs.Workers, c.Jobs.QueueSize, c.Jobs.MaxBatchSize)
+	}
+	if c.Jobs.ItemTimeout <= 0 || c.Jobs.Retention <= 0 {
+		invalid("job item timeout and retention must be positive, got %s and %s", c.Jobs.ItemTimeout, c.Jobs.Retention)
+	}
+	if c.Import.QueueSize < 1 || c.Import.MaxRows < 1 {
+		invalid("import queue size and max rows must be at least 1, got %d and %d", c.Import.QueueSize, c.Import.MaxRows)
+	}
+	if c.Import.Retention <= 0 {
+		invalid("import retention must be positive, got %s", c.Import.Retention)
+	}
+
+	switch c.Media.Store {
+	case MediaLocal:
+		if c.Media.Dir == "" {
+			invalid("media dir is required for the local store")
+		}
+		if !strings.HasPrefix(c.Media.BaseURL, "/") || c.Media.BaseURL == "/" {
+			invalid("media base URL must be a path below / for the local store, got %q", c.Media.BaseURL)
+		}
+	case MediaS3:
+		if c.Media.S3.Bucket == "" {
+			invalid("MEDIA_S3_BUCKET is required for the s3 store")
+		}
+	default:
+		invalid("unknown media store %q (expected %s or %s)", c.Media.Store, MediaLocal, MediaS3)
+	}
+	if c.Media.MaxUploadSize < 1 || c.Media.ThumbnailSize < 1 {
+		invalid("media max upload size and thumbnail size must be at least 1, got %d and %d", c.Media.MaxUploadSize, c.Media.ThumbnailSize)
+	}
+
+	if secret := c.Privacy.ErasureSecret; secret != "" && len(secret) < 32 {
+		invalid("erasure secret must be at least 32 bytes, got %d", len(secret))
+	}
+	if c.Privacy.ErasureConfirmationTTL <= 0 {
+		invalid("erasure confirmation TTL must be positive, got %s", c.Privacy.ErasureConfirmationTTL)
+	}
+
+	switch c.Encryption.KMS {
+	case EncryptionKMSLocal:
+		if c.Encryption.Enabled && c.Encryption.KeyFile == "" {
+			invalid("ENCRYPTION_KEY_FILE is required for the local KMS")
+		}
+	case EncryptionKMSAWS:
+		if c.Encryption.Enabled && c.Encryption.AWS.KeyID == "" {
+			invalid("ENCRYPTION_AWS_KEY_ID is required for the aws KMS")
+		}
+	default:
+		invalid("unknown encryption KMS %q (expected %s or %s)", c.Encryption.KMS, EncryptionKMSLocal, EncryptionKMSAWS)
+	}
+	if c.Encryption.Enabled && len(c.Encryption.DataKeys) == 0 {
+		invalid("encryption needs at least one data key; generate one with the datakey command")
+	}
+	for i, key := range c.Encryption.DataKeys {
+		if _, err := base64.StdEncoding.DecodeString(key); err != nil || key == "" {
+			invalid("encryption data key %d is not base64", i+1)
+		}
+	}
+
+	if outbox := c.Outbox; outbox.Enabled || c.Webhooks.Enabled {
+		if c.Storage.Backend == StorageSQLite || c.Storage.Backend == StorageDynamoDB {
+			invalid("the outbox and webhooks need the %s or %s storage backend", StorageMemory, StoragePostgres)
+		}
+		if outbox.Enabled && len(c.Kafka.Brokers) == 0 {
+			invalid("KAFKA_BROKERS is required to relay outbox events")
+		}
+		if outbox.Enabled && outbox.Topic == "" {
+			invalid("outbox topic is required")
+		}
+		if outbox.PollInterval <= 0 || outbox.Retention <= 0 {
+			invalid("outbox poll interval and retention must be positive, got %s and %s", outbox.PollInterval, outbox.Retention)
+		}
+		if outbox.BatchSize < 1 {
+			invalid("outbox batch size must be at least 1, got %d", outbox.BatchSize)
+		}
+	}
+
+	if webhooks := c.Webhooks; webhooks.Enabled {
+		if webhooks.PollInterval <= 0 || webhooks.Timeout <= 0 {
+			invalid("webhook poll interval and timeout must be positive, got %s and %s", webhooks.PollInterval, webhooks.Timeout)
+		}
+		if webhooks.BatchSize < 1 || webhooks.MaxAttempts < 1 {
+			invalid("webhook batch size and max attempts must be at least 1, got %d and %d", webhooks.BatchSize, webhooks.MaxAttempts)
+		}
+		if webhooks.InitialBackoff <= 0 || webhooks.MaxBackoff < webhooks.InitialBackoff {
+			invalid("webhook initial backoff must be positive and at most the max backoff, got %s and %s", webhooks.InitialBackoff, webhooks.MaxBackoff)
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// isCurrencyCode reports whether code is three upper-case ASCII letters
+func isCurrencyCode(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// isCountryCode reports whether code is two ASCII letters, such as "US"
+func isCountryCode(code string) bool {
+	if len(code) != 2 {
+		return false
+	}
+	for _, r := range strings.ToUpper(code) {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// redacted stands in for secrets in Redacted
+const redacted = "REDACTED"
+
+// Redacted returns a copy of c safe to show through the admin API, with the
+// database password, the Redis password, API keys, the tax service key and
+// the erasure secret replaced
+func (c Config) Redacted() Config {
+	if c.Storage.DatabaseURL != "" {
+		if u, err := url.Parse(c.Storage.DatabaseURL); err == nil && u.Scheme != "" {
+			c.Storage.DatabaseURL = u.Redacted()
+		} else {
+			c.Storage.DatabaseURL = redacted
+		}
+	}
+	if c.Cache.Redis.Password != "" {
+		c.Cache.Redis.Password = redacted
+	}
+	apiKeys := make([]APIKeyConfig, len(c.Auth.APIKeys))
+	for i, key := range c.Auth.APIKeys {
+		key.Key = redacted
+		apiKeys[i] = key
+	}
+	c.Auth.APIKeys = apiKeys
+	if c.Tax.APIKey != "" {
+		c.Tax.APIKey = redacted
+	}
+	if c.Privacy.ErasureSecret != "" {
+		c.Privacy.ErasureSecret = redacted
+	}
+	return c
+}
+
+// Reload takes from next the settings that apply without a restart: the log
+// level and the feature flags. Every other setting keeps the value the server
+// started with.
+func (c *Config) Reload(next *Config) {
+	c.LogLevel = next.LogLevel
+	c.Server.HTTPCache = next.Server.HTTPCache
+	c.Server.ServerTiming = next.Server.ServerTiming
+	c.PayloadLog.Enabled = next.PayloadLog.Enabled
+}
+
+// validate reports a rule that would never refill or never allow a request
+func (r RateLimitRule) validate(name string, invalid func(format string, args ...interface{})) {
+	if r.RequestsPerSecond <= 0 {
+		invalid("%s rate limit must be positive, got %g requests per second", name, r.RequestsPerSecond)
+	}
+	if r.Burst < 1 {
+		invalid("%s rate limit burst must be at least 1, got %d", name, r.Burst)
+	}
+}
+
+// envReader parses environment variables into settings, collecting errors
+type envReader struct {
+	lookup func(string) (string, bool)
+	errs   []error
+}
+
+func (r *envReader) string(name string, dst *string) {
+	if value, ok := r.lookup(name); ok && value != "" {
+		*dst = value
+	}
+}
+
+func (r *envReader) int(name string, dst *int) {
+	if value, ok := r.lookup(name); ok && value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			r.errs = append(r.errs, fmt.Errorf("invalid %s %q: must be an integer", name, value))
+			return
+		}
+		*dst = parsed
+	}
+}
+
+func (r *envReader) float(name string, dst *float64) {
+	if value, ok := r.lookup(name); ok && value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			r.errs = append(r.errs, fmt.Errorf("invalid %s %q: must be a number", name, value))
+			return
+		}
+		*dst = parsed
+	}
+}
+
+func (r *envReader) bool(name string, dst *bool) {
+	if value, ok := r.lookup(name); ok && value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			r.errs = append(r.errs, fmt.Errorf("invalid %s %q: must be true or false", name, value))
+			return
+		}
+		*dst = parsed
+	}
+}
+
+func (r *envReader) duration(name string, dst *time.Duration) {
+	if value, ok := r.lookup(name); ok && value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			r.errs = append(r.errs, fmt.Errorf("invalid %s %q: must be a duration such as 10s", name, value))
+			return
+		}
+		*dst = parsed
+	}
+}
+
+// list reads a comma-separated value; an explicitly empty variable clears the list
+func (r *envReader) list(name string, dst *[]string) {
+	value, ok := r.lookup(name)
+	if !ok {
+		return
+	}
+
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	*dst = items
+}
+
+// rates reads comma-separated CODE=rate entries; an explicitly empty
+// variable clears the rates
+func (r *envReader) rates(name string, dst *map[string]float64) {
+	if _, ok := r.lookup(name); !ok {
+		return
+	}
+	var entries []string
+	r.list(name, &entries)
+
+	rates := make(map[string]float64, len(entries))
+	for _, entry := range entries {
+		code, value, found := strings.Cut(entry, "=")
+		rate, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if !found || err != nil {
+			r.errs = append(r.errs, fmt.Errorf("invalid %s entry %q: must be CODE=rate", name, entry))
+			continue
+		}
+		rates[strings.TrimSpace(code)] = rate
+	}
+	*dst = rates
+}
+
+// limits reads comma-separated /path=limit entries; an explicitly empty
+// variable clears the limits
+func (r *envReader) limits(name string, dst *map[string]int) {
+	if _, ok := r.lookup(name); !ok {
+		return
+	}
+	var entries []string
+	r.list(name, &entries)
+
+	limits := make(map[string]int, len(entries))
+	for _, entry := range entries {
+		path, value, found := strings.Cut(entry, "=")
+		limit, err := strconv.Atoi(strings.TrimSpace(value))
+		if !found || err != nil {
+			r.errs = append(r.errs, fmt.Errorf("invalid %s entry %q: must be /path=limit", name, entry))
+			continue
+		}
+		limits[strings.TrimSpace(path)] = limit
+	}
+	*dst = limits
+}
+
+// redactRules reads comma-separated field or /path:field entries, grouping
+// the fields of each path into a rule; an explicitly empty variable clears
+// the rules
+func (r *envReader) redactRules(name string, dst *[]RedactRule) {
+	if _, ok := r.lookup(name); !ok {
+		return
+	}
+	var entries []string
+	r.list(name, &entries)
+
+	var rules []RedactRule
+	paths := make(map[string]int)
+	for _, entry := range entries {
+		path, field, found := strings.Cut(entry, ":")
+		if !found {
+			path, field = "", entry
+		}
+		i, seen := paths[path]
+		if !seen {
+			i, paths[path] = len(rules), len(rules)
+			rules = append(rules, RedactRule{Path: path})
+		}
+		rules[i].Fields = append(rules[i].Fields, field)
+	}
+	*dst = rules
+}
+
+// apiKeys reads comma-separated name:role:key entries; an explicitly empty
+// variable clears the keys
+func (r *envReader) apiKeys(name string, dst *[]APIKeyConfig) {
+	if _, ok := r.lookup(name); !ok {
+		return
+	}
+	var entries []string
+	r.list(name, &entries)
+
+	keys := make([]APIKeyConfig, 0, len(entries))
+	for _, entry := range entries {
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) != 3 {
+			r.errs = append(r.errs, fmt.Errorf("invalid %s entry: must be name:role:key", name))
+			continue
+		}
+		keys = append(keys, APIKeyConfig{Name: parts[0], Role: parts[1], Key: parts[2]})
+	}
+	*dst = keys
+}
@@ -0,0 +1,371 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// envMap returns a lookup function over a fixed set of variables
+func envMap(vars map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		value, ok := vars[name]
+		return value, ok
+	}
+}
+
+func TestLoadFrom_Defaults(t *testing.T) {
+	// Act
+	cfg, err := LoadFrom("", envMap(nil))
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if cfg.Server.Address() != ":8080" {
+		t.Errorf("Expected address ':8080', got %s", cfg.Server.Address())
+	}
+
+	if cfg.Server.RequestTimeout != 2*time.Second || cfg.Server.LookupTimeout != 500*time.Millisecond {
+		t.Errorf("Expected 2s request and 500ms lookup deadlines, got %s and %s", cfg.Server.RequestTimeout, cfg.Server.LookupTimeout)
+	}
+
+	if cfg.Storage.Backend != StorageMemory {
+		t.Errorf("Expected memory storage, got %s", cfg.Storage.Backend)
+	}
+
+	if !cfg.Storage.AutoMigrate {
+		t.Error("Expected migrations to run on start by default")
+	}
+
+	if cfg.Storage.SQLitePath != "enricher.db" {
+		t.Errorf("Expected sqlite database 'enricher.db', got %s", cfg.Storage.SQLitePath)
+	}
+
+	if dynamo := cfg.Storage.DynamoDB; dynamo.Table != "enricher" || dynamo.MaxAttempts != 8 || dynamo.MaxBackoff != 5*time.Second {
+		t.Errorf("Expected the 'enricher' DynamoDB table with default retries, got %+v", dynamo)
+	}
+
+	if len(cfg.CORS.AllowOrigins) != 1 || cfg.CORS.AllowOrigins[0] != "*" {
+		t.Errorf("Expected CORS to allow any origin, got %v", cfg.CORS.AllowOrigins)
+	}
+
+	if breaker := cfg.Storage.CircuitBreaker; !breaker.Enabled || breaker.FailureThreshold != 5 || breaker.OpenTimeout != 30*time.Second {
+		t.Errorf("Expected the circuit breaker on with default thresholds, got %+v", breaker)
+	}
+
+	if retry := cfg.Storage.Retry; !retry.Enabled || retry.MaxAttempts != 3 || retry.BaseDelay != 50*time.Millisecond {
+		t.Errorf("Expected storage retries on with default backoff, got %+v", retry)
+	}
+}
+
+func TestLoadFrom_FileThenEnv(t *testing.T) {
+	// Arrange
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	file := `
+server:
+  port: 9090
+  readTimeout: 5s
+logLevel: debug
+cors:
+  allowOrigins: [https://shop.example.com]
+kafka:
+  brokers: [kafka:29092]
+`
+	if err := os.WriteFile(path, []byte(file), 0o600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	env := envMap(map[string]string{
+		"PORT":                   "9191",
+		"LOG_LEVEL":              "WARN",
+		"PRODUCT_CATEGORIES":     "Electronics, Furniture",
+		"PRODUCT_CURRENCY":       "eur",
+		"PRODUCT_EXCHANGE_RATES": "USD=1.09, GBP=0.86",
+		"PRODUCT_WEIGHT_UNIT":    "lb",
+		"ORDER_RESERVE_STOCK":    "true",
+		"SEED_FILE":              "testdata/seed.yaml",
+	})
+
+	// Act
+	cfg, err := LoadFrom(path, env)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if cfg.Server.Port != 9191 {
+		t.Errorf("Expected env to override port to 9191, got %d", cfg.Server.Port)
+	}
+
+	if cfg.Server.ReadTimeout != 5*time.Second {
+		t.Errorf("Expected read timeout from file, got %s", cfg.Server.ReadTimeout)
+	}
+
+	if cfg.Server.WriteTimeout != 30*time.Second {
+		t.Errorf("Expected default write timeout, got %s", cfg.Server.WriteTimeout)
+	}
+
+	if cfg.LogLevel != "warn" {
+		t.Errorf("Expected log level 'warn', got %s", cfg.LogLevel)
+	}
+
+	if cfg.CORS.AllowOrigins[0] != "https://shop.example.com" {
+		t.Errorf("Expected CORS origin from file, got %v", cfg.CORS.AllowOrigins)
+	}
+
+	if cfg.Product.Currency != "EUR" || cfg.Product.ExchangeRates["GBP"] != 0.86 {
+		t.Errorf("Expected EUR with exchange rates from env, got %s %v", cfg.Product.Currency, cfg.Product.ExchangeRates)
+	}
+	if cfg.Product.WeightUnit != "lb" || cfg.Product.LengthUnit != "cm" {
+		t.Errorf("Expected the weight unit from env and the default length unit, got %s and %s", cfg.Product.WeightUnit, cfg.Product.LengthUnit)
+	}
+
+	if len(cfg.Kafka.Brokers) != 1 || len(cfg.Product.Categories) != 2 {
+		t.Errorf("Expected brokers from file and categories from env, got %v and %v", cfg.Kafka.Brokers, cfg.Product.Categories)
+	}
+
+	if !cfg.Order.ReserveStock {
+		t.Error("Expected stock reservation enabled from env")
+	}
+
+	if cfg.Storage.SeedFile != "testdata/seed.yaml" {
+		t.Errorf("Expected seed file from env, got %q", cfg.Storage.SeedFile)
+	}
+}
+
+func TestLoadFrom_ExampleFile(t *testing.T) {
+	// Act
+	cfg, err := LoadFrom("../../config.example.yaml", envMap(nil))
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected the example config to load, got %v", err)
+	}
+
+	if cfg.Customer.MaxSegments != 10 {
+		t.Errorf("Expected max segments 10, got %d", cfg.Customer.MaxSegments)
+	}
+
+	if cfg.RateLimit.Burst != 100 || cfg.RateLimit.Groups["/v1/enrich"].RequestsPerSecond != 20 {
+		t.Errorf("Expected default and /v1/enrich rate limits, got %+v", cfg.RateLimit)
+	}
+}
+
+func TestLoadFrom_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantErr string
+	}{
+		{name: "non-numeric port", env: map[string]string{"PORT": "http"}, wantErr: "PORT"},
+		{name: "port out of range", env: map[string]string{"PORT": "70000"}, wantErr: "port"},
+		{name: "bad duration", env: map[string]string{"READ_TIMEOUT": "ten"}, wantErr: "READ_TIMEOUT"},
+		{name: "negative request timeout", env: map[string]string{"REQUEST_TIMEOUT": "-1s"}, wantErr: "server timeouts"},
+		{name: "negative cache max age", env: map[string]string{"HTTP_CACHE_MAX_AGE": "-1s"}, wantErr: "cache max age"},
+		{name: "zero shutdown timeout", env: map[string]string{"SHUTDOWN_TIMEOUT": "0s"}, wantErr: "shutdown timeout"},
+		{name: "trusted proxy not a range", env: map[string]string{"TRUSTED_PROXIES": "10.0.0.0/8,proxy.internal"}, wantErr: "trusted proxy"},
+		{name: "unknown backend", env: map[string]string{"STORAGE_BACKEND": "mysql"}, wantErr: "storage backend"},
+		{name: "postgres without URL", env: map[string]string{"STORAGE_BACKEND": "postgres"}, wantErr: "DATABASE_URL"},
+		{name: "dynamodb without attempts", env: map[string]string{"STORAGE_BACKEND": "dynamodb", "DYNAMODB_MAX_ATTEMPTS": "0"}, wantErr: "dynamodb max attempts"},
+		{name: "webhooks on dynamodb", env: map[string]string{"STORAGE_BACKEND": "dynamodb", "WEBHOOKS_ENABLED": "true"}, wantErr: "storage backend"},
+		{name: "event sourcing on sqlite", env: map[string]string{"STORAGE_BACKEND": "sqlite", "EVENT_SOURCING_ENABLED": "true"}, wantErr: "event sourcing"},
+		{name: "negative segment rule", env: map[string]string{"CUSTOMER_SEGMENT_DORMANT_AFTER": "-24h"}, wantErr: "segment rules"},
+		{name: "gold below silver", env: map[string]string{"LOYALTY_GOLD_POINTS": "500"}, wantErr: "gold points"},
+		{name: "unknown tax calculator", env: map[string]string{"TAX_CALCULATOR": "avalara"}, wantErr: "tax calculator"},
+		{name: "http tax calculator without URL", env: map[string]string{"TAX_CALCULATOR": "http"}, wantErr: "TAX_URL"},
+		{name: "tax rate above one", env: map[string]string{"TAX_RATES": "DE=19"}, wantErr: "tax rate"},
+		{name: "shipping origin not a country", env: map[string]string{"SHIPPING_ORIGIN_COUNTRY": "USA"}, wantErr: "shipping origin"},
+		{name: "unknown media store", env: map[string]string{"MEDIA_STORE": "gcs"}, wantErr: "media store"},
+		{name: "s3 media store without bucket", env: map[string]string{"MEDIA_STORE": "s3"}, wantErr: "MEDIA_S3_BUCKET"},
+		{name: "local media base URL not a path", env: map[string]string{"MEDIA_BASE_URL": "https://cdn.example.com"}, wantErr: "media base URL"},
+		{name: "short erasure secret", env: map[string]string{"ERASURE_SECRET": "too-short"}, wantErr: "erasure secret"},
+		{name: "zero erasure confirmation TTL", env: map[string]string{"ERASURE_CONFIRMATION_TTL": "0s"}, wantErr: "erasure confirmation"},
+		{name: "payload log sample rate above 1", env: map[string]string{"PAYLOAD_LOG_SAMPLE_RATE": "1.5"}, wantErr: "sample rate"},
+		{name: "zero payload log body size", env: map[string]string{"PAYLOAD_LOG_MAX_BODY_SIZE": "0"}, wantErr: "max body size"},
+		{name: "relative redaction path", env: map[string]string{"PAYLOAD_LOG_REDACT": "v1/customers:name"}, wantErr: "must start with /"},
+		{name: "empty redaction key", env: map[string]string{"PAYLOAD_LOG_REDACT": "customer..name"}, wantErr: "joined by dots"},
+		{name: "unknown encryption KMS", env: map[string]string{"ENCRYPTION_KMS": "vault"}, wantErr: "unknown encryption KMS"},
+		{name: "local KMS without key file", env: map[string]string{"ENCRYPTION_ENABLED": "true", "ENCRYPTION_DATA_KEYS": "a2V5"}, wantErr: "ENCRYPTION_KEY_FILE"},
+		{name: "aws KMS without key ID", env: map[string]string{"ENCRYPTION_ENABLED": "true", "ENCRYPTION_KMS": "aws", "ENCRYPTION_DATA_KEYS": "a2V5"}, wantErr: "ENCRYPTION_AWS_KEY_ID"},
+		{name: "encryption without data keys", env: map[string]string{"ENCRYPTION_ENABLED": "true", "ENCRYPTION_KEY_FILE": "keys.yaml"}, wantErr: "at least one data key"},
+		{name: "data key not base64", env: map[string]string{"ENCRYPTION_DATA_KEYS": "not base64!"}, wantErr: "data key 1"},
+		{name: "zero snapshot interval", env: map[string]string{"EVENT_SOURCING_ENABLED": "true", "EVENT_SOURCING_SNAPSHOT_EVERY": "0"}, wantErr: "snapshot interval"},
+		{name: "webhooks on sqlite", env: map[string]string{"STORAGE_BACKEND": "sqlite", "WEBHOOKS_ENABLED": "true"}, wantErr: "storage backend"},
+		{name: "unknown log level", env: map[string]string{"LOG_LEVEL": "verbose"}, wantErr: "log level"},
+		{name: "no CORS origins", env: map[string]string{"CORS_ALLOW_ORIGINS": ""}, wantErr: "CORS"},
+		{name: "unknown ID generator", env: map[string]string{"ID_GENERATOR": "snowflake"}, wantErr: "ID generator"},
+		{name: "sequential IDs on sqlite", env: map[string]string{"STORAGE_BACKEND": "sqlite", "ID_GENERATOR": "sequential"}, wantErr: "sequential ID generator"},
+		{name: "unknown category filter", env: map[string]string{"PRODUCT_CATEGORY_FILTER": "fuzzy"}, wantErr: "category filter"},
+		{name: "auth without JWKS URL", env: map[string]string{"AUTH_ENABLED": "true"}, wantErr: "AUTH_JWKS_URL"},
+		{name: "RBAC without credentials", env: map[string]string{"AUTH_RBAC_ENABLED": "true"}, wantErr: "RBAC requires"},
+		{name: "malformed API key", env: map[string]string{"AUTH_RBAC_ENABLED": "true", "AUTH_API_KEYS": "ci-secret"}, wantErr: "AUTH_API_KEYS"},
+		{name: "API key with unknown role", env: map[string]string{"AUTH_RBAC_ENABLED": "true", "AUTH_API_KEYS": "ci:owner:secret"}, wantErr: "unknown role"},
+		{name: "non-numeric rate limit", env: map[string]string{"RATE_LIMIT_RPS": "fast"}, wantErr: "RATE_LIMIT_RPS"},
+		{name: "zero rate limit", env: map[string]string{"RATE_LIMIT_ENABLED": "true", "RATE_LIMIT_RPS": "0"}, wantErr: "rate limit must be positive"},
+		{name: "zero load shed concurrency", env: map[string]string{"LOAD_SHED_ENABLED": "true", "LOAD_SHED_MAX_IN_FLIGHT": "0"}, wantErr: "max in flight"},
+		{name: "zero load shed latency", env: map[string]string{"LOAD_SHED_ENABLED": "true", "LOAD_SHED_TARGET_LATENCY": "0s"}, wantErr: "target latency"},
+		{name: "malformed bulkhead group", env: map[string]string{"BULKHEAD_GROUPS": "/v1/products:50"}, wantErr: "BULKHEAD_GROUPS"},
+		{name: "zero bulkhead group", env: map[string]string{"BULKHEAD_ENABLED": "true", "BULKHEAD_GROUPS": "/v1/products=0"}, wantErr: "at least 1 request"},
+		{name: "relative bulkhead group", env: map[string]string{"BULKHEAD_ENABLED": "true", "BULKHEAD_GROUPS": "v1/products=5"}, wantErr: "path prefix"},
+		{name: "zero breaker threshold", env: map[string]string{"CIRCUIT_BREAKER_FAILURE_THRESHOLD": "0"}, wantErr: "failure threshold"},
+		{name: "zero storage retry attempts", env: map[string]string{"STORAGE_RETRY_MAX_ATTEMPTS": "0"}, wantErr: "max attempts"},
+		{name: "storage retry max delay below base", env: map[string]string{"STORAGE_RETRY_BASE_DELAY": "1s", "STORAGE_RETRY_MAX_DELAY": "100ms"}, wantErr: "storage retry delays"},
+		{name: "unknown cache backend", env: map[string]string{"CACHE_BACKEND": "memcached"}, wantErr: "cache backend"},
+		{name: "zero cache TTL", env: map[string]string{"CACHE_BACKEND": "memory", "CACHE_PRODUCT_TTL": "0s"}, wantErr: "cache TTLs"},
+		{name: "zero cache shards", env: map[string]string{"CACHE_BACKEND": "memory", "CACHE_SHARDS": "0"}, wantErr: "cache shards"},
+		{name: "zero redis pool", env: map[string]string{"CACHE_BACKEND": "redis", "REDIS_POOL_SIZE": "0"}, wantErr: "redis pool size"},
+		{name: "malformed product currency", env: map[string]string{"PRODUCT_CURRENCY": "euro"}, wantErr: "product currency"},
+		{name: "malformed exchange rate", env: map[string]string{"PRODUCT_EXCHANGE_RATES": "EUR:0.9"}, wantErr: "PRODUCT_EXCHANGE_RATES"},
+		{name: "non-positive exchange rate", env: map[string]string{"PRODUCT_EXCHANGE_RATES": "EUR=0"}, wantErr: "exchange rate"},
+		{name: "unknown weight unit", env: map[string]string{"PRODUCT_WEIGHT_UNIT": "oz"}, wantErr: "product weight unit"},
+		{name: "unknown length unit", env: map[string]string{"PRODUCT_LENGTH_UNIT": "ft"}, wantErr: "product length unit"},
+		{name: "zero max weight", env: map[string]string{"PRODUCT_MAX_WEIGHT_KG": "0"}, wantErr: "product max weight"},
+		{name: "bad breaker timeout", env: map[string]string{"CIRCUIT_BREAKER_OPEN_TIMEOUT": "soon"}, wantErr: "CIRCUIT_BREAKER_OPEN_TIMEOUT"},
+		{name: "zero Kafka attempts", env: map[string]string{"KAFKA_MAX_ATTEMPTS": "0"}, wantErr: "Kafka max attempts"},
+		{name: "zero job workers", env: map[string]string{"JOBS_WORKERS": "0"}, wantErr: "job workers"},
+		{name: "zero job item timeout", env: map[string]string{"JOBS_ITEM_TIMEOUT": "0s"}, wantErr: "job item timeout"},
+		{name: "zero import max rows", env: map[string]string{"IMPORT_MAX_ROWS": "0"}, wantErr: "import queue size and max rows"},
+		{name: "outbox without brokers", env: map[string]string{"OUTBOX_ENABLED": "true"}, wantErr: "relay outbox events"},
+		{name: "zero outbox batch size", env: map[string]string{"OUTBOX_ENABLED": "true", "KAFKA_BROKERS": "kafka:9092", "OUTBOX_BATCH_SIZE": "0"}, wantErr: "outbox batch size"},
+		{name: "zero webhook attempts", env: map[string]string{"WEBHOOKS_ENABLED": "true", "WEBHOOKS_MAX_ATTEMPTS": "0"}, wantErr: "webhook batch size and max attempts"},
+		{name: "webhook backoff above max", env: map[string]string{"WEBHOOKS_ENABLED": "true", "WEBHOOKS_INITIAL_BACKOFF": "2h"}, wantErr: "webhook initial backoff"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			_, err := LoadFrom("", envMap(tt.env))
+
+			// Assert
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error mentioning %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestLoadFrom_APIKeys(t *testing.T) {
+	// Arrange
+	env := envMap(map[string]string{
+		"AUTH_RBAC_ENABLED": "true",
+		"AUTH_API_KEYS":     "ci:operator:s3cr:et, dashboard:reader:view-key",
+	})
+
+	// Act
+	cfg, err := LoadFrom("", env)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := []APIKeyConfig{
+		{Name: "ci", Role: "operator", Key: "s3cr:et"},
+		{Name: "dashboard", Role: "reader", Key: "view-key"},
+	}
+	if len(cfg.Auth.APIKeys) != len(expected) {
+		t.Fatalf("Expected %d API keys, got %+v", len(expected), cfg.Auth.APIKeys)
+	}
+	for i, key := range expected {
+		if cfg.Auth.APIKeys[i] != key {
+			t.Errorf("Expected %+v, got %+v", key, cfg.Auth.APIKeys[i])
+		}
+	}
+}
+
+func TestLoadFrom_RedactRules(t *testing.T) {
+	// Arrange
+	env := envMap(map[string]string{"PAYLOAD_LOG_REDACT": "email, /v1/customers:name, customer.name, /v1/customers:phone"})
+
+	// Act
+	cfg, err := LoadFrom("", env)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := []RedactRule{
+		{Fields: []string{"email", "customer.name"}},
+		{Path: "/v1/customers", Fields: []string{"name", "phone"}},
+	}
+	if !reflect.DeepEqual(cfg.PayloadLog.Redact, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, cfg.PayloadLog.Redact)
+	}
+}
+
+func TestLoadFrom_BulkheadGroups(t *testing.T) {
+	// Arrange
+	env := envMap(map[string]string{"BULKHEAD_GROUPS": "/v1/customers=80, /v1/products/import=5"})
+
+	// Act
+	cfg, err := LoadFrom("", env)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := map[string]int{"/v1/customers": 80, "/v1/products/import": 5}
+	if !reflect.DeepEqual(cfg.Bulkhead.Groups, expected) {
+		t.Errorf("Expected the variable to replace the default groups with %v, got %v", expected, cfg.Bulkhead.Groups)
+	}
+}
+
+func TestLoadFrom_MissingFile(t *testing.T) {
+	// Act
+	_, err := LoadFrom(filepath.Join(t.TempDir(), "missing.yaml"), envMap(nil))
+
+	// Assert
+	if err == nil {
+		t.Error("Expected error for missing config file")
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	// Arrange
+	cfg := Default()
+	cfg.Storage.DatabaseURL = "postgres://enricher:hunter2@db:5432/enricher"
+	cfg.Cache.Redis.Password = "redis-secret"
+	cfg.Auth.APIKeys = []APIKeyConfig{{Name: "ci", Role: "operator", Key: "s3cret"}}
+	cfg.Tax.APIKey = "tax-secret"
+	cfg.Privacy.ErasureSecret = "erasure-secret-erasure-secret-00"
+
+	// Act
+	shown := cfg.Redacted()
+
+	// Assert
+	if strings.Contains(shown.Storage.DatabaseURL, "hunter2") || !strings.Contains(shown.Storage.DatabaseURL, "db:5432") {
+		t.Errorf("Expected the database password alone to be hidden, got %s", shown.Storage.DatabaseURL)
+	}
+	if shown.Cache.Redis.Password != redacted || shown.Auth.APIKeys[0].Key != redacted || shown.Auth.APIKeys[0].Name != "ci" {
+		t.Errorf("Expected secrets to be redacted, got %q and %+v", shown.Cache.Redis.Password, shown.Auth.APIKeys)
+	}
+	if shown.Tax.APIKey != redacted || shown.Privacy.ErasureSecret != redacted {
+		t.Errorf("Expected the tax service key and erasure secret to be redacted, got %q and %q", shown.Tax.APIKey, shown.Privacy.ErasureSecret)
+	}
+	if cfg.Auth.APIKeys[0].Key != "s3cret" {
+		t.Errorf("Expected the original config to keep its API key, got %q", cfg.Auth.APIKeys[0].Key)
+	}
+}
+
+func TestReload(t *testing.T) {
+	// Arrange
+	cfg := Default()
+	next := Default()
+	next.LogLevel, next.Server.ServerTiming, next.PayloadLog.Enabled, next.Server.Port = "debug", true, true, 9090
+
+	// Act
+	cfg.Reload(next)
+
+	// Assert
+	if cfg.LogLevel != "debug" || !cfg.Server.ServerTiming || !cfg.PayloadLog.Enabled {
+		t.Errorf("Expected the log level and flags to be reloaded, got %s, %t and %t", cfg.LogLevel, cfg.Server.ServerTiming, cfg.PayloadLog.Enabled)
+	}
+	if cfg.Server.Port != 8080 {
+		t.Errorf("Expected the port to need a restart, got %d", cfg.Server.Port)
+	}
+}
@@ -0,0 +1,453 @@
+// Package consumer enriches order events consumed from Kafka and publishes
+// the enriched orders to an output topic.
+//
+// Offsets are committed only after the enriched order has been published, so
+// every message is delivered at least once; downstream consumers must treat
+// enriched orders as idempotent by orderId.
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"enricher-api-go/internal/dlq"
+	"enricher-api-go/internal/enrichment"
+	"enricher-api-go/internal/logging"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Default consumer settings
+const (
+	DefaultGroupID      = "enricher-api-go"
+	DefaultInputTopic   = "orders"
+	DefaultOutputTopic  = "orders.enriched"
+	DefaultRetryBackoff = time.Second
+	DefaultMaxBackoff   = 30 * time.Second
+	DefaultMaxAttempts  = 5
+)
+
+// Config holds the Kafka settings for the consumer
+type Config struct {
+	Brokers     []string
+	GroupID     string
+	InputTopic  string
+	OutputTopic string
+}
+
+// withDefaults fills in unset fields with the package defaults
+func (cfg Config) withDefaults() Config {
+	if cfg.GroupID == "" {
+		cfg.GroupID = DefaultGroupID
+	}
+	if cfg.InputTopic == "" {
+		cfg.InputTopic = DefaultInputTopic
+	}
+	if cfg.OutputTopic == "" {
+		cfg.OutputTopic = DefaultOutputTopic
+	}
+	return cfg
+}
+
+// OrderMessage is a raw order event read from the input topic
+type OrderMessage struct {
+	OrderID    string                 `json:"orderId"`
+	CustomerID string                 `json:"customerId"`
+	Products   []enrichment.OrderItem `json:"products"`
+	// OrderedAt is when the order was placed; the Kafka message time is used
+	// when it is absent
+	OrderedAt *time.Time `json:"orderedAt,omitempty"`
+	// ShippingAddressID selects one of the customer's shipping addresses
+	ShippingAddressID string `json:"shippingAddressId,omitempty"`
+}
+
+// enrichmentRequest returns the order as a request to the enricher
+func (m OrderMessage) enrichmentRequest() enrichment.OrderRequest {
+	return enrichment.OrderRequest{
+		CustomerID:        m.CustomerID,
+		Items:             m.Products,
+		OrderedAt:         m.OrderedAt,
+		ShippingAddressID: m.ShippingAddressID,
+	}
+}
+
+// EnrichedOrderMessage is the event published to the output topic
+type EnrichedOrderMessage struct {
+	OrderID string `json:"orderId"`
+	enrichment.EnrichedOrder
+	EnrichedAt time.Time `json:"enrichedAt"`
+}
+
+// Reader is the subset of *kafka.Reader used by the consumer
+type Reader interface {
+	FetchMessage(ctx context.Context) (kafka.Message, error)
+	CommitMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// Writer is the subset of *kafka.Writer used by the consumer
+type Writer interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// Consumer reads order events, enriches them and publishes the result
+type Consumer struct {
+	reader       Reader
+	writer       Writer
+	enricher     enrichment.Service
+	retryBackoff time.Duration
+	maxBackoff   time.Duration
+	now          func() time.Time
+	// dial checks that a broker accepts connections; nil skips the check
+	dial func(ctx context.Context) error
+	// deadLetters receives the orders the consumer gives up on; nil skips them
+	deadLetters dlq.Recorder
+	maxAttempts int
+
+	mu      sync.Mutex
+	running bool
+	stopErr error
+	// failure is why Kafka last failed the consumer while it retries, nil
+	// once a fetch or commit succeeds
+	failure error
+}
+
+// New creates a consumer backed by a Kafka consumer group reader and writer
+func New(cfg Config, enricher enrichment.Service) (*Consumer, error) {
+	cfg = cfg.withDefaults()
+	if len(cfg.Brokers) == 0 {
+		return nil, errors.New("at least one Kafka broker is required")
+	}
+
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers: cfg.Brokers,
+		GroupID: cfg.GroupID,
+		Topic:   cfg.InputTopic,
+		// Offsets are committed explicitly after publishing
+		CommitInterval: 0,
+		StartOffset:    kafka.FirstOffset,
+	})
+	writer := &kafka.Writer{
+		Addr:         kafka.TCP(cfg.Brokers...),
+		Topic:        cfg.OutputTopic,
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+	}
+
+	c := NewWithClients(reader, writer, enricher)
+	c.dial = dialAny(cfg.Brokers)
+	return c, nil
+}
+
+// NewWithClients creates a consumer using the given reader and writer
+func NewWithClients(reader Reader, writer Writer, enricher enrichment.Service) *Consumer {
+	return &Consumer{
+		reader:       reader,
+		writer:       writer,
+		enricher:     enricher,
+		retryBackoff: DefaultRetryBackoff,
+		maxBackoff:   DefaultMaxBackoff,
+		now:          time.Now,
+	}
+}
+
+// DeadLetterTo dead-letters the orders the consumer cannot enrich, and those
+// still failing transiently after maxAttempts tries (DefaultMaxAttempts when
+// not positive), instead of skipping them or retrying them forever
+func (c *Consumer) DeadLetterTo(recorder dlq.Recorder, maxAttempts int) {
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultMaxAttempts
+	}
+	c.deadLetters, c.maxAttempts = recorder, maxAttempts
+}
+
+// Run consumes messages until ctx is cancelled or the reader is closed.
+//
+// A message whose processing fails transiently is retried with exponential
+// backoff and its offset is not committed until it succeeds. Messages that can
+// never succeed (malformed JSON, invalid orders, unknown customers or products)
+// are logged and committed so they do not block the partition; see
+// DeadLetterTo for keeping their orders instead. Failures to fetch messages or
+// commit offsets are retried with the same backoff, failing Check meanwhile.
+func (c *Consumer) Run(ctx context.Context) error {
+	c.mu.Lock()
+	c.running, c.stopErr, c.failure = true, nil, nil
+	c.mu.Unlock()
+
+	err := c.run(ctx)
+
+	c.mu.Lock()
+	c.running, c.stopErr = false, err
+	c.mu.Unlock()
+	return err
+}
+
+// Check reports whether Run is in progress without failing to reach Kafka
+// and, for consumers created with New, whether a broker accepts connections
+func (c *Consumer) Check(ctx context.Context) error {
+	c.mu.Lock()
+	running, stopErr, failure := c.running, c.stopErr, c.failure
+	c.mu.Unlock()
+
+	if !running {
+		if stopErr != nil {
+			return fmt.Errorf("order consumer stopped: %w", stopErr)
+		}
+		return errors.New("order consumer is not running")
+	}
+	if failure != nil {
+		return fmt.Errorf("order consumer retrying: %w", failure)
+	}
+	if c.dial == nil {
+		return nil
+	}
+	return c.dial(ctx)
+}
+
+// run is the consume loop behind Run
+func (c *Consumer) run(ctx context.Context) error {
+	logger := logging.FromContext(ctx).With("component", "order-consumer")
+	logger.Info("Starting order consumer")
+	ctx = logging.WithLogger(ctx, logger)
+
+	backoff := c.retryBackoff
+	for {
+		msg, err := c.reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				logger.Info("Order consumer stopped")
+				return nil
+			}
+			if errors.Is(err, io.EOF) {
+				// The reader was closed and will not fetch again
+				return fmt.Errorf("failed to fetch message: %w", err)
+			}
+
+			c.fail(fmt.Errorf("failed to fetch message: %w", err))
+			logger.Warn("Failed to fetch message, retrying", "backoff", backoff, "error", err)
+			if !sleep(ctx, backoff) {
+				logger.Info("Order consumer stopped")
+				return nil
+			}
+			backoff = c.nextBackoff(backoff)
+			continue
+		}
+		c.fail(nil)
+		backoff = c.retryBackoff
+
+		msgCtx := logging.WithLogger(ctx, logger.With("partition", msg.Partition, "offset", msg.Offset))
+		if err := c.processWithRetry(msgCtx, msg); err != nil {
+			if ctx.Err() != nil {
+				logger.Info("Order consumer stopped before committing in-flight message", "offset", msg.Offset)
+				return nil
+			}
+			return err
+		}
+
+		if !c.commit(msgCtx, msg) {
+			logger.Info("Order consumer stopped before committing in-flight message", "offset", msg.Offset)
+			return nil
+		}
+	}
+}
+
+// commit commits the offset of msg, retrying failures with backoff; it
+// reports false when ctx ended first
+func (c *Consumer) commit(ctx context.Context, msg kafka.Message) bool {
+	backoff := c.retryBackoff
+	for {
+		err := c.reader.CommitMessages(ctx, msg)
+		if err == nil {
+			c.fail(nil)
+			return true
+		}
+		if ctx.Err() != nil {
+			return false
+		}
+
+		c.fail(fmt.Errorf("failed to commit offset %d: %w", msg.Offset, err))
+		logging.FromContext(ctx).Warn("Failed to commit offset, retrying", "backoff", backoff, "error", err)
+		if !sleep(ctx, backoff) {
+			return false
+		}
+		backoff = c.nextBackoff(backoff)
+	}
+}
+
+// fail records why Kafka is failing the consumer, or clears it when err is nil
+func (c *Consumer) fail(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.failure = err
+}
+
+// nextBackoff doubles backoff, up to maxBackoff
+func (c *Consumer) nextBackoff(backoff time.Duration) time.Duration {
+	return min(backoff*2, c.maxBackoff)
+}
+
+// sleep waits for d, reporting false if ctx ended first
+func sleep(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
+
+// Close releases the reader and writer
+func (c *Consumer) Close() error {
+	return errors.Join(c.reader.Close(), c.writer.Close())
+}
+
+// dialAny returns a check that succeeds once any of brokers accepts a connection
+func dialAny(brokers []string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		var errs []error
+		for _, broker := range brokers {
+			conn, err := kafka.DialContext(ctx, "tcp", broker)
+			if err == nil {
+				return conn.Close()
+			}
+			errs = append(errs, err)
+		}
+		return fmt.Errorf("no Kafka broker reachable: %w", errors.Join(errs...))
+	}
+}
+
+// processWithRetry processes msg, retrying transient failures until ctx ends.
+//
+// With a dead-letter queue, orders that cannot be enriched and those still
+// failing after maxAttempts tries are dead-lettered instead; the message is
+// retried until its dead letter is stored.
+func (c *Consumer) processWithRetry(ctx context.Context, msg kafka.Message) error {
+	order, err := decodeOrder(msg)
+	if err != nil {
+		logging.FromContext(ctx).Warn("Skipping message that cannot be processed", "error", err)
+		return nil
+	}
+
+	ctx = logging.WithLogger(ctx, logging.FromContext(ctx).With("order_id", order.OrderID))
+	logger := logging.FromContext(ctx)
+	backoff := c.retryBackoff
+
+	for attempt := 1; ; attempt++ {
+		err := c.process(ctx, order, msg.Key)
+		if err == nil {
+			return nil
+		}
+
+		var permanent *permanentError
+		isPermanent := errors.As(err, &permanent)
+		switch {
+		case c.deadLetters != nil && (isPermanent || attempt >= c.maxAttempts):
+			if c.deadLetter(ctx, msg, order, err) == nil {
+				return nil
+			}
+		case isPermanent:
+			logger.Warn("Skipping message that cannot be processed", "error", err)
+			return nil
+		}
+
+		logger.Warn("Failed to process message, retrying", "backoff", backoff, "attempt", attempt, "error", err)
+		if !sleep(ctx, backoff) {
+			return ctx.Err()
+		}
+		backoff = c.nextBackoff(backoff)
+	}
+}
+
+// decodeOrder reads the order of msg, taking the message time as the order
+// time when the order has none
+func decodeOrder(msg kafka.Message) (OrderMessage, error) {
+	var order OrderMessage
+	if err := json.Unmarshal(msg.Value, &order); err != nil {
+		return order, fmt.Errorf("malformed order message: %w", err)
+	}
+
+	if order.OrderedAt == nil && !msg.Time.IsZero() {
+		orderedAt := msg.Time
+		order.OrderedAt = &orderedAt
+	}
+	return order, nil
+}
+
+// process enriches a single order and publishes the result under key,
+// unless the order has an ID
+func (c *Consumer) process(ctx context.Context, order OrderMessage, key []byte) error {
+	enriched, err := c.enricher.EnrichOrder(ctx, order.enrichmentRequest())
+	if err != nil {
+		if errors.Is(err, enrichment.ErrInvalidOrder) ||
+			errors.Is(err, enrichment.ErrCustomerNotFound) ||
+			errors.Is(err, enrichment.ErrProductNotFound) {
+			return &permanentError{fmt.Errorf("order %s: %w", order.OrderID, err)}
+		}
+		return fmt.Errorf("failed to enrich order %s: %w", order.OrderID, err)
+	}
+
+	value, err := json.Marshal(EnrichedOrderMessage{
+		OrderID:       order.OrderID,
+		EnrichedOrder: *enriched,
+		EnrichedAt:    c.now().UTC(),
+	})
+	if err != nil {
+		return &permanentError{fmt.Errorf("failed to encode enriched order %s: %w", order.OrderID, err)}
+	}
+
+	if order.OrderID != "" {
+		key = []byte(order.OrderID)
+	}
+
+	if err := c.writer.WriteMessages(ctx, kafka.Message{Key: key, Value: value}); err != nil {
+		return fmt.Errorf("failed to publish enriched order %s: %w", order.OrderID, err)
+	}
+
+	logging.FromContext(ctx).Info("Published enriched order")
+	return nil
+}
+
+// deadLetter records an order the consumer gives up on. Orders without an
+// ID are recorded under their message's topic, partition and offset.
+func (c *Consumer) deadLetter(ctx context.Context, msg kafka.Message, order OrderMessage, cause error) error {
+	sourceID := order.OrderID
+	if sourceID == "" {
+		sourceID = fmt.Sprintf("%s/%d/%d", msg.Topic, msg.Partition, msg.Offset)
+	}
+
+	_, err := c.deadLetters.RecordFailure(ctx, dlq.Failure{
+		Source:   dlq.SourceKafka,
+		SourceID: sourceID,
+		Order:    order.enrichmentRequest(),
+		Reason:   cause.Error(),
+	})
+	return err
+}
+
+// ReplayDeadLetter is the dlq.ReplayFunc of dead-lettered order events: it
+// enriches the order again and publishes it keyed by its order ID
+func (c *Consumer) ReplayDeadLetter(ctx context.Context, entry *dlq.Entry) error {
+	order := OrderMessage{
+		OrderID:           entry.SourceID,
+		CustomerID:        entry.Order.CustomerID,
+		Products:          entry.Order.Items,
+		OrderedAt:         entry.Order.OrderedAt,
+		ShippingAddressID: entry.Order.ShippingAddressID,
+	}
+	return c.process(ctx, order, []byte(entry.SourceID))
+}
+
+// permanentError marks a failure that retrying cannot fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+
+func (e *permanentError) Unwrap() error { return e.err }
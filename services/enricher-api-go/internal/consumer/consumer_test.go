@@ -0,0 +1,384 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"enricher-api-go/internal/customer"
+	"enricher-api-go/internal/dlq"
+	"enricher-api-go/internal/enrichment"
+	"enricher-api-go/internal/product"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// fakeReader serves queued messages and blocks once they are exhausted,
+// failing fetches and commits while failures of each remain
+type fakeReader struct {
+	mu             sync.Mutex
+	messages       []kafka.Message
+	committed      []int64
+	fetchFailures  int
+	commitFailures int
+	fetches        int
+	commits        int
+}
+
+func (r *fakeReader) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	r.mu.Lock()
+	r.fetches++
+	if r.fetchFailures > 0 {
+		r.fetchFailures--
+		r.mu.Unlock()
+		return kafka.Message{}, errors.New("group coordinator not available")
+	}
+	if len(r.messages) > 0 {
+		msg := r.messages[0]
+		r.messages = r.messages[1:]
+		r.mu.Unlock()
+		return msg, nil
+	}
+	r.mu.Unlock()
+
+	<-ctx.Done()
+	return kafka.Message{}, ctx.Err()
+}
+
+func (r *fakeReader) CommitMessages(_ context.Context, msgs ...kafka.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.commits++
+	if r.commitFailures > 0 {
+		r.commitFailures--
+		return errors.New("rebalance in progress")
+	}
+	for _, msg := range msgs {
+		r.committed = append(r.committed, msg.Offset)
+	}
+	return nil
+}
+
+func (r *fakeReader) Close() error { return nil }
+
+func (r *fakeReader) committedOffsets() []int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]int64(nil), r.committed...)
+}
+
+// fakeWriter records published messages, failing while failures remain
+type fakeWriter struct {
+	mu        sync.Mutex
+	failures  int
+	attempts  int
+	published []kafka.Message
+}
+
+func (w *fakeWriter) WriteMessages(_ context.Context, msgs ...kafka.Message) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.attempts++
+	if w.failures > 0 {
+		w.failures--
+		return errors.New("broker unavailable")
+	}
+	w.published = append(w.published, msgs...)
+	return nil
+}
+
+func (w *fakeWriter) Close() error { return nil }
+
+func (w *fakeWriter) publishedMessages() []kafka.Message {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]kafka.Message(nil), w.published...)
+}
+
+func newTestConsumer(reader *fakeReader, writer *fakeWriter) *Consumer {
+	enricher := enrichment.NewService(
+		customer.NewService(customer.NewInMemoryRepository()),
+		product.NewService(product.NewInMemoryRepository()),
+	)
+	c := NewWithClients(reader, writer, enricher)
+	c.retryBackoff = time.Millisecond
+	c.maxBackoff = time.Millisecond
+	c.now = func() time.Time { return time.Date(2025, 1, 8, 10, 30, 0, 0, time.UTC) }
+	return c
+}
+
+// runUntil runs the consumer until cond holds, then stops it
+func runUntil(t *testing.T, c *Consumer, cond func() bool) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- c.Run(ctx) }()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			cancel()
+			t.Fatal("Timed out waiting for consumer")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Expected consumer to stop cleanly, got %v", err)
+	}
+}
+
+func TestConsumer_Run_PublishesEnrichedOrder(t *testing.T) {
+	// Arrange
+	reader := &fakeReader{messages: []kafka.Message{{
+		Offset: 7,
+		Value:  []byte(`{"orderId":"order-1","customerId":"customer-456","products":[{"productId":"product-123","quantity":2}]}`),
+	}}}
+	writer := &fakeWriter{}
+	c := newTestConsumer(reader, writer)
+
+	// Act
+	runUntil(t, c, func() bool { return len(reader.committedOffsets()) == 1 })
+
+	// Assert
+	published := writer.publishedMessages()
+	if len(published) != 1 {
+		t.Fatalf("Expected 1 published message, got %d", len(published))
+	}
+
+	if string(published[0].Key) != "order-1" {
+		t.Errorf("Expected key 'order-1', got %q", published[0].Key)
+	}
+
+	var enriched EnrichedOrderMessage
+	if err := json.Unmarshal(published[0].Value, &enriched); err != nil {
+		t.Fatalf("Expected valid JSON, got %v", err)
+	}
+
+	if enriched.OrderID != "order-1" || enriched.Customer.Name != "Jane Doe" || enriched.Total != 51.98 {
+		t.Errorf("Unexpected enriched order: %+v", enriched)
+	}
+
+	if offsets := reader.committedOffsets(); offsets[0] != 7 {
+		t.Errorf("Expected offset 7 committed, got %v", offsets)
+	}
+}
+
+func TestConsumer_Run_RetriesPublishBeforeCommitting(t *testing.T) {
+	// Arrange
+	reader := &fakeReader{messages: []kafka.Message{{
+		Offset: 3,
+		Value:  []byte(`{"orderId":"order-2","customerId":"customer-123","products":[{"productId":"product-789","quantity":1}]}`),
+	}}}
+	writer := &fakeWriter{failures: 2}
+	c := newTestConsumer(reader, writer)
+
+	// Act
+	runUntil(t, c, func() bool { return len(reader.committedOffsets()) == 1 })
+
+	// Assert
+	if writer.attempts != 3 {
+		t.Errorf("Expected 3 publish attempts, got %d", writer.attempts)
+	}
+
+	if len(writer.publishedMessages()) != 1 {
+		t.Errorf("Expected 1 published message, got %d", len(writer.publishedMessages()))
+	}
+}
+
+func TestConsumer_Run_SkipsPermanentFailures(t *testing.T) {
+	// Arrange
+	reader := &fakeReader{messages: []kafka.Message{
+		{Offset: 1, Value: []byte(`not json`)},
+		{Offset: 2, Value: []byte(`{"orderId":"order-3","customerId":"customer-missing","products":[{"productId":"product-123","quantity":1}]}`)},
+		{Offset: 3, Value: []byte(`{"orderId":"order-4","customerId":"customer-456","products":[]}`)},
+	}}
+	writer := &fakeWriter{}
+	c := newTestConsumer(reader, writer)
+
+	// Act
+	runUntil(t, c, func() bool { return len(reader.committedOffsets()) == 3 })
+
+	// Assert
+	if len(writer.publishedMessages()) != 0 {
+		t.Errorf("Expected nothing published, got %d messages", len(writer.publishedMessages()))
+	}
+}
+
+func TestConsumer_Run_DeadLettersFailures(t *testing.T) {
+	// Arrange
+	reader := &fakeReader{messages: []kafka.Message{
+		{Offset: 1, Value: []byte(`not json`)},
+		{Offset: 2, Value: []byte(`{"orderId":"order-3","customerId":"customer-missing","products":[{"productId":"product-123","quantity":1}]}`)},
+		{Offset: 3, Value: []byte(`{"orderId":"order-6","customerId":"customer-456","products":[{"productId":"product-123","quantity":1}]}`)},
+	}}
+	writer := &fakeWriter{failures: 3}
+	deadLetters := dlq.NewService(dlq.NewInMemoryRepository())
+	c := newTestConsumer(reader, writer)
+	c.DeadLetterTo(deadLetters, 3)
+
+	// Act
+	runUntil(t, c, func() bool { return len(reader.committedOffsets()) == 3 })
+
+	// Assert
+	entries, err := deadLetters.FindEntries(context.Background(), dlq.EntryFilter{Source: dlq.SourceKafka})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected the unknown customer and the unpublished order dead-lettered, got %+v", entries)
+	}
+	reasons := map[string]string{}
+	for _, entry := range entries {
+		reasons[entry.SourceID] = entry.FailureReason
+	}
+	if reasons["order-3"] != "order order-3: customer not found: customer-missing" {
+		t.Errorf("Expected order-3 dead-lettered as rejected, got %q", reasons["order-3"])
+	}
+	if reasons["order-6"] != "failed to publish enriched order order-6: broker unavailable" || writer.attempts != 3 {
+		t.Errorf("Expected order-6 dead-lettered after 3 attempts, got %q after %d", reasons["order-6"], writer.attempts)
+	}
+}
+
+func TestConsumer_ReplayDeadLetter(t *testing.T) {
+	// Arrange
+	writer := &fakeWriter{}
+	c := newTestConsumer(&fakeReader{}, writer)
+	entry := &dlq.Entry{
+		Source:   dlq.SourceKafka,
+		SourceID: "order-7",
+		Order: enrichment.OrderRequest{
+			CustomerID: "customer-456",
+			Items:      []enrichment.OrderItem{{ProductID: "product-123", Quantity: 1}},
+		},
+	}
+
+	// Act
+	err := c.ReplayDeadLetter(context.Background(), entry)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if published := writer.publishedMessages(); len(published) != 1 || string(published[0].Key) != "order-7" {
+		t.Errorf("Expected the order published under its ID, got %+v", published)
+	}
+}
+
+func TestConsumer_Run_StopsWithoutCommittingInFlightMessage(t *testing.T) {
+	// Arrange
+	reader := &fakeReader{messages: []kafka.Message{{
+		Offset: 5,
+		Value:  []byte(`{"orderId":"order-5","customerId":"customer-456","products":[{"productId":"product-123","quantity":1}]}`),
+	}}}
+	writer := &fakeWriter{failures: 1 << 30}
+	c := newTestConsumer(reader, writer)
+
+	// Act
+	runUntil(t, c, func() bool {
+		writer.mu.Lock()
+		defer writer.mu.Unlock()
+		return writer.attempts > 1
+	})
+
+	// Assert
+	if offsets := reader.committedOffsets(); len(offsets) != 0 {
+		t.Errorf("Expected no committed offsets, got %v", offsets)
+	}
+}
+
+func TestConsumer_Run_RetriesFetchAndCommit(t *testing.T) {
+	// Arrange
+	reader := &fakeReader{fetchFailures: 2, commitFailures: 2, messages: []kafka.Message{{
+		Offset: 9,
+		Value:  []byte(`{"orderId":"order-9","customerId":"customer-456","products":[{"productId":"product-123","quantity":1}]}`),
+	}}}
+	writer := &fakeWriter{}
+	c := newTestConsumer(reader, writer)
+
+	// Act
+	runUntil(t, c, func() bool { return len(reader.committedOffsets()) == 1 })
+
+	// Assert
+	if reader.fetches < 3 || reader.commits != 3 {
+		t.Errorf("Expected 3 fetches and 3 commits, got %d and %d", reader.fetches, reader.commits)
+	}
+	if len(writer.publishedMessages()) != 1 {
+		t.Errorf("Expected the order published once, got %d", len(writer.publishedMessages()))
+	}
+}
+
+func TestConsumer_Check_FailsWhileRetryingFetches(t *testing.T) {
+	// Arrange
+	reader := &fakeReader{fetchFailures: 1 << 30}
+	c := newTestConsumer(reader, &fakeWriter{})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+
+	// Act
+	go func() { done <- c.Run(ctx) }()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		reader.mu.Lock()
+		fetches := reader.fetches
+		reader.mu.Unlock()
+		if fetches > 2 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	whileFailing := c.Check(context.Background())
+	cancel()
+	runErr := <-done
+
+	// Assert
+	if whileFailing == nil || !strings.Contains(whileFailing.Error(), "group coordinator not available") {
+		t.Errorf("Expected the check to report the fetch failure, got %v", whileFailing)
+	}
+	if runErr != nil {
+		t.Errorf("Expected the consumer to keep retrying until stopped, got %v", runErr)
+	}
+}
+
+func TestNew_RequiresBrokers(t *testing.T) {
+	// Act
+	_, err := New(Config{}, nil)
+
+	// Assert
+	if err == nil {
+		t.Error("Expected error without brokers")
+	}
+}
+
+func TestConsumer_Check(t *testing.T) {
+	// Arrange
+	c := newTestConsumer(&fakeReader{}, &fakeWriter{})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+
+	// Act
+	beforeRun := c.Check(context.Background())
+	go func() { done <- c.Run(ctx) }()
+	deadline := time.Now().Add(2 * time.Second)
+	for c.Check(context.Background()) != nil && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	whileRunning := c.Check(context.Background())
+	cancel()
+	<-done
+	afterStop := c.Check(context.Background())
+
+	// Assert
+	if beforeRun == nil || afterStop == nil {
+		t.Errorf("Expected the check to fail while not running, got %v and %v", beforeRun, afterStop)
+	}
+	if whileRunning != nil {
+		t.Errorf("Expected the check to pass while running, got %v", whileRunning)
+	}
+}
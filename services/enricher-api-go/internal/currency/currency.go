@@ -0,0 +1,94 @@
+// Package currency validates ISO 4217 currency codes and converts amounts
+// between currencies using a pluggable exchange-rate provider.
+package currency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// DefaultCode is the currency of prices that do not name one
+const DefaultCode = "USD"
+
+// DecimalPlaces is the precision converted amounts are rounded to
+const DecimalPlaces = 2
+
+var (
+	// ErrInvalidCode is returned for codes that are not three ASCII letters
+	ErrInvalidCode = errors.New("invalid currency code")
+	// ErrRateUnavailable is returned when no exchange rate is known for a pair
+	ErrRateUnavailable = errors.New("exchange rate unavailable")
+)
+
+// RateProvider supplies exchange rates. Implementations may call a remote
+// service, so Rate takes a context; a missing pair is reported with an error
+// wrapping ErrRateUnavailable.
+type RateProvider interface {
+	// Rate returns how many units of to one unit of from buys
+	Rate(ctx context.Context, from, to string) (float64, error)
+}
+
+// Normalize upper-cases code and checks that it looks like an ISO 4217 code
+func Normalize(code string) (string, error) {
+	normalized := strings.ToUpper(strings.TrimSpace(code))
+	if len(normalized) != 3 {
+		return "", fmt.Errorf("%w: %q must be three letters", ErrInvalidCode, code)
+	}
+	for _, r := range normalized {
+		if r < 'A' || r > 'Z' {
+			return "", fmt.Errorf("%w: %q must be three letters", ErrInvalidCode, code)
+		}
+	}
+	return normalized, nil
+}
+
+// Convert converts amount from one currency to another with provider,
+// rounding the result to DecimalPlaces
+func Convert(ctx context.Context, provider RateProvider, amount float64, from, to string) (float64, error) {
+	if from == to {
+		return amount, nil
+	}
+	if provider == nil {
+		return 0, fmt.Errorf("%w: %s to %s", ErrRateUnavailable, from, to)
+	}
+
+	rate, err := provider.Rate(ctx, from, to)
+	if err != nil {
+		return 0, err
+	}
+
+	scale := math.Pow10(DecimalPlaces)
+	return math.Round(amount*rate*scale) / scale, nil
+}
+
+// StaticRates is a RateProvider over a fixed table of rates against one base
+// currency, such as rates loaded from configuration. Pairs that do not
+// involve the base are converted through it.
+type StaticRates struct {
+	base  string
+	rates map[string]float64
+}
+
+// NewStaticRates creates a provider where rates[code] is the number of units
+// of code one unit of base buys
+func NewStaticRates(base string, rates map[string]float64) *StaticRates {
+	table := make(map[string]float64, len(rates)+1)
+	for code, rate := range rates {
+		table[strings.ToUpper(code)] = rate
+	}
+	table[base] = 1
+	return &StaticRates{base: base, rates: table}
+}
+
+// Rate returns the rate from one currency to another through the base
+func (r *StaticRates) Rate(_ context.Context, from, to string) (float64, error) {
+	fromRate, fromOK := r.rates[from]
+	toRate, toOK := r.rates[to]
+	if !fromOK || !toOK || fromRate <= 0 {
+		return 0, fmt.Errorf("%w: %s to %s", ErrRateUnavailable, from, to)
+	}
+	return toRate / fromRate, nil
+}
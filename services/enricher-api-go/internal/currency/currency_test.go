@@ -0,0 +1,69 @@
+package currency
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		code    string
+		want    string
+		wantErr bool
+	}{
+		{code: "eur", want: "EUR"},
+		{code: " GBP ", want: "GBP"},
+		{code: "EURO", wantErr: true},
+		{code: "E1R", wantErr: true},
+		{code: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			// Act
+			got, err := Normalize(tt.code)
+
+			// Assert
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidCode) {
+					t.Errorf("Expected ErrInvalidCode, got %q (%v)", got, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("Expected %s, got %q (%v)", tt.want, got, err)
+			}
+		})
+	}
+}
+
+func TestConvert_StaticRates(t *testing.T) {
+	// Arrange
+	rates := NewStaticRates("USD", map[string]float64{"eur": 0.9, "GBP": 0.75})
+	ctx := context.Background()
+
+	// Act
+	toEUR, toEURErr := Convert(ctx, rates, 100, "USD", "EUR")
+	crossed, crossedErr := Convert(ctx, rates, 90, "EUR", "GBP")
+	same, sameErr := Convert(ctx, nil, 12.5, "JPY", "JPY")
+	_, missingErr := Convert(ctx, rates, 10, "USD", "JPY")
+	_, noProviderErr := Convert(ctx, nil, 10, "USD", "EUR")
+
+	// Assert
+	if toEURErr != nil || toEUR != 90 {
+		t.Errorf("Expected 90 EUR, got %v (%v)", toEUR, toEURErr)
+	}
+
+	if crossedErr != nil || crossed != 75 {
+		t.Errorf("Expected 75 GBP through USD, got %v (%v)", crossed, crossedErr)
+	}
+
+	if sameErr != nil || same != 12.5 {
+		t.Errorf("Expected the same amount without a provider, got %v (%v)", same, sameErr)
+	}
+
+	if !errors.Is(missingErr, ErrRateUnavailable) || !errors.Is(noProviderErr, ErrRateUnavailable) {
+		t.Errorf("Expected ErrRateUnavailable, got %v and %v", missingErr, noProviderErr)
+	}
+}
@@ -0,0 +1,191 @@
+package customer
+
+import (
+	"errors"
+
+	"enricher-api-go/internal/breaker"
+)
+
+// BreakerRepository guards another Repository with a circuit breaker.
+//
+// Storage failures count against the breaker, while domain outcomes such as
+// ErrCustomerNotFound are returned unchanged without tripping it. While the
+// breaker is open every call fails immediately with an error wrapping
+// breaker.ErrOpen.
+type BreakerRepository struct {
+	repo    Repository
+	breaker *breaker.Breaker
+}
+
+// NewBreakerRepository wraps repo with b.
+//
+// Args:
+//   - repo: the repository to guard, typically PostgresRepository
+//   - b: the breaker shared by every repository using the same backend
+//
+// Returns:
+//   - *BreakerRepository: the guarded repository
+func NewBreakerRepository(repo Repository, b *breaker.Breaker) *BreakerRepository {
+	return &BreakerRepository{repo: repo, breaker: b}
+}
+
+// GetByID retrieves a live customer by ID
+func (r *BreakerRepository) GetByID(customerID string) (customer *Customer, err error) {
+	err = r.call(func() error {
+		customer, err = r.repo.GetByID(customerID)
+		return err
+	})
+	return customer, err
+}
+
+// GetByIDIncludingDeleted retrieves a customer by ID even if it is soft-deleted
+func (r *BreakerRepository) GetByIDIncludingDeleted(customerID string) (customer *Customer, err error) {
+	err = r.call(func() error {
+		customer, err = r.repo.GetByIDIncludingDeleted(customerID)
+		return err
+	})
+	return customer, err
+}
+
+// GetByIDs retrieves the live customers with the given IDs
+func (r *BreakerRepository) GetByIDs(customerIDs []string) (customers []*Customer, err error) {
+	err = r.call(func() error {
+		customers, err = r.repo.GetByIDs(customerIDs)
+		return err
+	})
+	return customers, err
+}
+
+// GetByEmail retrieves a live customer by email
+func (r *BreakerRepository) GetByEmail(email string) (customer *Customer, err error) {
+	err = r.call(func() error {
+		customer, err = r.repo.GetByEmail(email)
+		return err
+	})
+	return customer, err
+}
+
+// Create adds a new customer
+func (r *BreakerRepository) Create(customer *Customer) error {
+	return r.call(func() error { return r.repo.Create(customer) })
+}
+
+// Update modifies an existing customer
+func (r *BreakerRepository) Update(customer *Customer) error {
+	return r.call(func() error { return r.repo.Update(customer) })
+}
+
+// WriteAll applies every write or none of them
+func (r *BreakerRepository) WriteAll(writes []Write) error {
+	return r.call(func() error { return r.repo.WriteAll(writes) })
+}
+
+// Delete soft-deletes a customer
+func (r *BreakerRepository) Delete(customerID string) error {
+	return r.call(func() error { return r.repo.Delete(customerID) })
+}
+
+// Restore undoes a soft delete
+func (r *BreakerRepository) Restore(customerID string) error {
+	return r.call(func() error { return r.repo.Restore(customerID) })
+}
+
+// List returns all live customers
+func (r *BreakerRepository) List() (customers []*Customer, err error) {
+	err = r.call(func() error {
+		customers, err = r.repo.List()
+		return err
+	})
+	return customers, err
+}
+
+// Find returns the customers matching filter
+func (r *BreakerRepository) Find(filter CustomerFilter) (customers []*Customer, err error) {
+	err = r.call(func() error {
+		customers, err = r.repo.Find(filter)
+		return err
+	})
+	return customers, err
+}
+
+// Count returns the number of customers matching filter
+func (r *BreakerRepository) Count(filter CustomerFilter) (count int, err error) {
+	err = r.call(func() error {
+		count, err = r.repo.Count(filter)
+		return err
+	})
+	return count, err
+}
+
+// AdjustExposure atomically adjusts a customer's exposure
+func (r *BreakerRepository) AdjustExposure(customerID string, change ExposureChange) (customer *Customer, err error) {
+	err = r.call(func() error {
+		customer, err = r.repo.AdjustExposure(customerID, change)
+		return err
+	})
+	return customer, err
+}
+
+// Addresses returns the addresses of a live customer
+func (r *BreakerRepository) Addresses(customerID string) (addresses []*Address, err error) {
+	err = r.call(func() error {
+		addresses, err = r.repo.Addresses(customerID)
+		return err
+	})
+	return addresses, err
+}
+
+// GetAddress retrieves one address of a live customer
+func (r *BreakerRepository) GetAddress(customerID, addressID string) (address *Address, err error) {
+	err = r.call(func() error {
+		address, err = r.repo.GetAddress(customerID, addressID)
+		return err
+	})
+	return address, err
+}
+
+// CreateAddress adds an address to a live customer
+func (r *BreakerRepository) CreateAddress(address *Address) error {
+	return r.call(func() error { return r.repo.CreateAddress(address) })
+}
+
+// UpdateAddress replaces an address of a live customer
+func (r *BreakerRepository) UpdateAddress(address *Address) error {
+	return r.call(func() error { return r.repo.UpdateAddress(address) })
+}
+
+// DeleteAddress removes an address of a live customer
+func (r *BreakerRepository) DeleteAddress(customerID, addressID string) error {
+	return r.call(func() error { return r.repo.DeleteAddress(customerID, addressID) })
+}
+
+// call runs fn through the breaker, passing domain errors through without
+// counting them as failures
+func (r *BreakerRepository) call(fn func() error) error {
+	var domainErr error
+	err := r.breaker.Execute(func() error {
+		err := fn()
+		if isDomainError(err) {
+			domainErr = err
+			return nil
+		}
+		return err
+	})
+	if domainErr != nil {
+		return domainErr
+	}
+	return err
+}
+
+// isDomainError reports whether err is an expected repository outcome rather
+// than a storage failure
+func isDomainError(err error) bool {
+	return errors.Is(err, ErrCustomerNotFound) ||
+		errors.Is(err, ErrCustomerExists) ||
+		errors.Is(err, ErrCustomerNotDeleted) ||
+		errors.Is(err, ErrEmailExists) ||
+		errors.Is(err, ErrCreditLimitExceeded) ||
+		errors.Is(err, ErrExposureBelowZero) ||
+		errors.Is(err, ErrAddressNotFound) ||
+		errors.Is(err, ErrAddressExists)
+}
@@ -0,0 +1,53 @@
+package customer
+
+import (
+	"errors"
+	"testing"
+
+	"enricher-api-go/internal/breaker"
+)
+
+// unavailableRepository fails every lookup like an unreachable database
+type unavailableRepository struct {
+	Repository
+}
+
+func (unavailableRepository) GetByID(string) (*Customer, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestBreakerRepository_DomainErrorsDoNotTrip(t *testing.T) {
+	// Arrange
+	b := breaker.New("postgres", breaker.Settings{FailureThreshold: 1})
+	repo := NewBreakerRepository(NewInMemoryRepository(), b)
+
+	// Act
+	_, err := repo.GetByID("customer-missing")
+
+	// Assert
+	if !errors.Is(err, ErrCustomerNotFound) {
+		t.Errorf("Expected ErrCustomerNotFound, got %v", err)
+	}
+	if b.State() != breaker.StateClosed {
+		t.Errorf("Expected the breaker to stay closed, got %s", b.State())
+	}
+}
+
+func TestBreakerRepository_FailsFastWhenOpen(t *testing.T) {
+	// Arrange
+	b := breaker.New("postgres", breaker.Settings{FailureThreshold: 2})
+	repo := NewBreakerRepository(unavailableRepository{NewInMemoryRepository()}, b)
+
+	// Act
+	_, first := repo.GetByID("customer-456")
+	_, second := repo.GetByID("customer-456")
+	_, rejected := repo.GetByID("customer-456")
+
+	// Assert
+	if first == nil || second == nil || errors.Is(second, breaker.ErrOpen) {
+		t.Errorf("Expected storage errors before the breaker opens, got %v and %v", first, second)
+	}
+	if !errors.Is(rejected, breaker.ErrOpen) {
+		t.Errorf("Expected ErrOpen once the breaker opened, got %v", rejected)
+	}
+}
@@ -0,0 +1,273 @@
+package customer
+
+import (
+	"encoding/json"
+	"log/slog"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"enricher-api-go/internal/cache"
+)
+
+// CachedRepository serves live customer lookups from a cache, reading through
+// to another Repository on a miss.
+//
+// GetByID and GetByIDs are cached; every other read, including email lookups
+// and addresses, goes to the wrapped repository. Writes invalidate the customer's entry after they succeed, so
+// a stale entry can only survive a racing read until its TTL expires. Cache
+// failures are logged and treated as misses. Concurrent misses for the same ID
+// share one read of the wrapped repository.
+type CachedRepository struct {
+	repo  Repository
+	store cache.Store
+	ttl   time.Duration
+	loads cache.Group[string, []byte]
+	// generation is part of every key; InvalidateAll moves to the next one
+	generation atomic.Int64
+}
+
+// NewCachedRepository wraps repo with a read-through cache.
+//
+// Args:
+//   - repo: the repository holding the customers
+//   - store: where serialized customers are cached
+//   - ttl: how long a cached customer is served before it is read again
+//
+// Returns:
+//   - *CachedRepository: the cached repository
+func NewCachedRepository(repo Repository, store cache.Store, ttl time.Duration) *CachedRepository {
+	return &CachedRepository{repo: repo, store: store, ttl: ttl}
+}
+
+// GetByID retrieves a live customer by ID, from the cache when possible
+func (r *CachedRepository) GetByID(customerID string) (*Customer, error) {
+	if customer, ok := r.cached(customerID); ok {
+		return customer, nil
+	}
+
+	// Concurrent misses share one read; callers that joined it decode their
+	// own copy so no two requests hold the same Customer
+	var loaded *Customer
+	data, err, shared := r.loads.Do(customerID, func() ([]byte, error) {
+		customer, err := r.repo.GetByID(customerID)
+		if err != nil {
+			return nil, err
+		}
+		loaded = customer
+		return r.fill(customer), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !shared {
+		return loaded, nil
+	}
+	if customer, ok := decode(data); ok {
+		return customer, nil
+	}
+	return r.repo.GetByID(customerID)
+}
+
+// GetByIDIncludingDeleted retrieves a customer by ID even if it is soft-deleted
+func (r *CachedRepository) GetByIDIncludingDeleted(customerID string) (*Customer, error) {
+	return r.repo.GetByIDIncludingDeleted(customerID)
+}
+
+// GetByEmail retrieves a live customer by email from the wrapped repository
+func (r *CachedRepository) GetByEmail(email string) (*Customer, error) {
+	return r.repo.GetByEmail(email)
+}
+
+// GetByIDs retrieves the live customers with the given IDs, reading only the
+// cache misses from the wrapped repository. Customers are returned in the
+// order of customerIDs.
+func (r *CachedRepository) GetByIDs(customerIDs []string) ([]*Customer, error) {
+	found := make(map[string]*Customer, len(customerIDs))
+	var missing []string
+	for _, customerID := range customerIDs {
+		if customer, ok := r.cached(customerID); ok {
+			found[customerID] = customer
+		} else {
+			missing = append(missing, customerID)
+		}
+	}
+
+	if len(missing) > 0 {
+		customers, err := r.repo.GetByIDs(missing)
+		if err != nil {
+			return nil, err
+		}
+		for _, customer := range customers {
+			found[customer.CustomerID] = customer
+			r.fill(customer)
+		}
+	}
+
+	customers := make([]*Customer, 0, len(found))
+	for _, customerID := range customerIDs {
+		if customer, ok := found[customerID]; ok {
+			customers = append(customers, customer)
+			delete(found, customerID)
+		}
+	}
+	return customers, nil
+}
+
+// Create adds a new customer
+func (r *CachedRepository) Create(customer *Customer) error {
+	return r.repo.Create(customer)
+}
+
+// Update modifies an existing customer and invalidates its entry
+func (r *CachedRepository) Update(customer *Customer) error {
+	if err := r.repo.Update(customer); err != nil {
+		return err
+	}
+	r.invalidate(customer.CustomerID)
+	return nil
+}
+
+// WriteAll applies every write or none of them and invalidates the entries
+// of updated customers
+func (r *CachedRepository) WriteAll(writes []Write) error {
+	if err := r.repo.WriteAll(writes); err != nil {
+		return err
+	}
+	for _, write := range writes {
+		if !write.Create {
+			r.invalidate(write.Customer.CustomerID)
+		}
+	}
+	return nil
+}
+
+// Delete soft-deletes a customer and invalidates its entry
+func (r *CachedRepository) Delete(customerID string) error {
+	if err := r.repo.Delete(customerID); err != nil {
+		return err
+	}
+	r.invalidate(customerID)
+	return nil
+}
+
+// Restore undoes a soft delete and invalidates the customer's entry
+func (r *CachedRepository) Restore(customerID string) error {
+	if err := r.repo.Restore(customerID); err != nil {
+		return err
+	}
+	r.invalidate(customerID)
+	return nil
+}
+
+// List returns all live customers
+func (r *CachedRepository) List() ([]*Customer, error) {
+	return r.repo.List()
+}
+
+// Find returns the customers matching filter
+func (r *CachedRepository) Find(filter CustomerFilter) ([]*Customer, error) {
+	return r.repo.Find(filter)
+}
+
+// Count returns the number of customers matching filter
+func (r *CachedRepository) Count(filter CustomerFilter) (int, error) {
+	return r.repo.Count(filter)
+}
+
+// AdjustExposure adjusts a customer's exposure and invalidates its entry
+func (r *CachedRepository) AdjustExposure(customerID string, change ExposureChange) (*Customer, error) {
+	customer, err := r.repo.AdjustExposure(customerID, change)
+	if err != nil {
+		return nil, err
+	}
+	r.invalidate(customerID)
+	return customer, nil
+}
+
+// Addresses returns the addresses of a live customer
+func (r *CachedRepository) Addresses(customerID string) ([]*Address, error) {
+	return r.repo.Addresses(customerID)
+}
+
+// GetAddress retrieves one address of a live customer
+func (r *CachedRepository) GetAddress(customerID, addressID string) (*Address, error) {
+	return r.repo.GetAddress(customerID, addressID)
+}
+
+// CreateAddress adds an address to a live customer
+func (r *CachedRepository) CreateAddress(address *Address) error {
+	return r.repo.CreateAddress(address)
+}
+
+// UpdateAddress replaces an address of a live customer
+func (r *CachedRepository) UpdateAddress(address *Address) error {
+	return r.repo.UpdateAddress(address)
+}
+
+// DeleteAddress removes an address of a live customer
+func (r *CachedRepository) DeleteAddress(customerID, addressID string) error {
+	return r.repo.DeleteAddress(customerID, addressID)
+}
+
+// InvalidateAll drops every cached customer, such as after the wrapped
+// customers were reset. The entries are left to expire under keys no longer
+// read, so it works on any store; a read in flight may still cache what it
+// read.
+func (r *CachedRepository) InvalidateAll() {
+	r.generation.Add(1)
+}
+
+// cached returns the cached customer, if any
+func (r *CachedRepository) cached(customerID string) (*Customer, bool) {
+	data, ok, err := r.store.Get(r.key(customerID))
+	if err != nil {
+		slog.Warn("Failed to read customer from cache", "customer_id", customerID, "error", err)
+		return nil, false
+	}
+	if !ok {
+		return nil, false
+	}
+
+	customer, ok := decode(data)
+	if !ok {
+		slog.Warn("Discarding undecodable cached customer", "customer_id", customerID)
+	}
+	return customer, ok
+}
+
+// fill caches customer, returning its serialized form or nil if it cannot be encoded
+func (r *CachedRepository) fill(customer *Customer) []byte {
+	data, err := json.Marshal(customer)
+	if err != nil {
+		slog.Warn("Failed to encode customer for the cache", "customer_id", customer.CustomerID, "error", err)
+		return nil
+	}
+	if err := r.store.Set(r.key(customer.CustomerID), data, r.ttl); err != nil {
+		slog.Warn("Failed to cache customer", "customer_id", customer.CustomerID, "error", err)
+	}
+	return data
+}
+
+// invalidate drops the cached customer and detaches any read in flight for it
+func (r *CachedRepository) invalidate(customerID string) {
+	r.loads.Forget(customerID)
+	if err := r.store.Delete(r.key(customerID)); err != nil {
+		slog.Warn("Failed to invalidate cached customer", "customer_id", customerID, "error", err)
+	}
+}
+
+// decode parses a cached customer
+func decode(data []byte) (*Customer, bool) {
+	var customer Customer
+	if err := json.Unmarshal(data, &customer); err != nil {
+		return nil, false
+	}
+	return &customer, true
+}
+
+// key namespaces customer IDs in a store shared with products, under the
+// current generation
+func (r *CachedRepository) key(customerID string) string {
+	return "customer:" + strconv.FormatInt(r.generation.Load(), 10) + ":" + customerID
+}
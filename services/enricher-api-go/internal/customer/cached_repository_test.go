@@ -0,0 +1,148 @@
+package customer
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"enricher-api-go/internal/cache"
+)
+
+// countingRepository counts the lookups that reach the wrapped repository
+type countingRepository struct {
+	Repository
+	gets    int
+	batches [][]string
+}
+
+func (r *countingRepository) GetByID(customerID string) (*Customer, error) {
+	r.gets++
+	return r.Repository.GetByID(customerID)
+}
+
+func (r *countingRepository) GetByIDs(customerIDs []string) ([]*Customer, error) {
+	r.batches = append(r.batches, customerIDs)
+	return r.Repository.GetByIDs(customerIDs)
+}
+
+func TestCachedRepository_ReadsThroughOnce(t *testing.T) {
+	// Arrange
+	backing := &countingRepository{Repository: NewInMemoryRepository()}
+	repo := NewCachedRepository(backing, cache.NewLRU(10), time.Minute)
+
+	// Act
+	first, _ := repo.GetByID("customer-456")
+	second, _ := repo.GetByID("customer-456")
+	batch, err := repo.GetByIDs([]string{"customer-789", "customer-456", "customer-unknown"})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if backing.gets != 1 || first.Name != second.Name {
+		t.Errorf("Expected one backing lookup and equal results, got %d lookups", backing.gets)
+	}
+	if len(backing.batches) != 1 || len(backing.batches[0]) != 2 {
+		t.Errorf("Expected only the cache misses to be fetched, got %v", backing.batches)
+	}
+	if len(batch) != 2 || batch[0].CustomerID != "customer-789" || batch[1].CustomerID != "customer-456" {
+		t.Errorf("Expected known customers in request order, got %+v", batch)
+	}
+}
+
+func TestCachedRepository_WritesInvalidate(t *testing.T) {
+	// Arrange
+	backing := &countingRepository{Repository: NewInMemoryRepository()}
+	repo := NewCachedRepository(backing, cache.NewLRU(10), time.Minute)
+	customer, _ := repo.GetByID("customer-456")
+
+	// Act
+	customer.Status = "SUSPENDED"
+	updateErr := repo.Update(customer)
+	updated, _ := repo.GetByID("customer-456")
+	deleteErr := repo.Delete("customer-456")
+	_, deletedErr := repo.GetByID("customer-456")
+
+	// Assert
+	if updateErr != nil || deleteErr != nil {
+		t.Fatalf("Expected no errors, got %v and %v", updateErr, deleteErr)
+	}
+	if updated.Status != "SUSPENDED" {
+		t.Errorf("Expected the update to be visible, got %s", updated.Status)
+	}
+	if deletedErr != ErrCustomerNotFound {
+		t.Errorf("Expected ErrCustomerNotFound after delete, got %v", deletedErr)
+	}
+}
+
+func TestCachedRepository_InvalidateAll(t *testing.T) {
+	// Arrange
+	inMemory := NewInMemoryRepository()
+	backing := &countingRepository{Repository: inMemory}
+	repo := NewCachedRepository(backing, cache.NewLRU(10), time.Minute)
+	_, _ = repo.GetByID("customer-456")
+	_ = inMemory.Delete("customer-456")
+
+	// Act
+	stale, staleErr := repo.GetByID("customer-456")
+	repo.InvalidateAll()
+	_, err := repo.GetByID("customer-456")
+
+	// Assert
+	if staleErr != nil || stale.CustomerID != "customer-456" {
+		t.Fatalf("Expected the cached customer before invalidating, got %v", staleErr)
+	}
+	if err != ErrCustomerNotFound || backing.gets != 2 {
+		t.Errorf("Expected the lookup to read through after invalidating, got %v after %d lookups", err, backing.gets)
+	}
+}
+
+// blockingRepository holds GetByID until released
+type blockingRepository struct {
+	Repository
+	gets    atomic.Int32
+	release chan struct{}
+}
+
+func (r *blockingRepository) GetByID(customerID string) (*Customer, error) {
+	r.gets.Add(1)
+	<-r.release
+	return r.Repository.GetByID(customerID)
+}
+
+func TestCachedRepository_ConcurrentMissesShareOneRead(t *testing.T) {
+	// Arrange
+	backing := &blockingRepository{Repository: NewInMemoryRepository(), release: make(chan struct{})}
+	repo := NewCachedRepository(backing, cache.NewSharded(4, 100), time.Minute)
+
+	// Act
+	var wg sync.WaitGroup
+	customers := make([]*Customer, 5)
+	for i := range customers {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			customers[i], _ = repo.GetByID("customer-456")
+		}(i)
+	}
+	for backing.gets.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(backing.release)
+	wg.Wait()
+
+	// Assert
+	if backing.gets.Load() != 1 {
+		t.Errorf("Expected one backing read, got %d", backing.gets.Load())
+	}
+	for i, customer := range customers {
+		if customer == nil || customer.Name != "Jane Doe" {
+			t.Fatalf("Expected caller %d to get the customer, got %+v", i, customer)
+		}
+		if i > 0 && customer == customers[0] {
+			t.Error("Expected each caller to get its own copy")
+		}
+	}
+}
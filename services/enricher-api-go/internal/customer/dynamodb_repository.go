@@ -0,0 +1,646 @@
+package customer
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"enricher-api-go/internal/dynamo"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Item layout of customers in the single DynamoDB table: a customer and its
+// addresses share the partition CUSTOMER#<id>, and each email is claimed by
+// an EMAIL#<email> item naming its customer, which makes emails unique.
+const (
+	dynamoProfileSK   = "PROFILE"
+	dynamoAddressSK   = "ADDRESS#"
+	dynamoEmailSK     = "EMAIL"
+	dynamoOwnerAttr   = "customerId"
+	dynamoCustomer    = "customer"
+	dynamoAddress     = "address"
+	dynamoEmailClaim  = "email"
+	dynamoMaxAttempts = 5
+)
+
+// DynamoDBMaxBulkSize is the most writes a WriteAll on DynamoDB can hold: a
+// write stores up to three items (the customer, its new email claim and the
+// release of its old one) and a transaction takes at most
+// dynamo.MaxTransactWrites
+const DynamoDBMaxBulkSize = dynamo.MaxTransactWrites / 3
+
+// DynamoDBRepository implements Repository on a single DynamoDB table. It
+// does not record change events.
+//
+// Writes are optimistic: they condition on the version of the customer they
+// read and start over when it changed in between. Address writes bump the
+// customer's version, serializing them per customer. Listing customers scans
+// the table.
+type DynamoDBRepository struct {
+	table *dynamo.Table
+}
+
+// NewDynamoDBRepository creates a customer repository storing its items in table
+func NewDynamoDBRepository(table *dynamo.Table) *DynamoDBRepository {
+	return &DynamoDBRepository{table: table}
+}
+
+// GetByID retrieves a live customer by ID
+func (r *DynamoDBRepository) GetByID(customerID string) (*Customer, error) {
+	customer, _, err := r.load(context.Background(), customerID)
+	if err != nil {
+		return nil, err
+	}
+	if customer == nil || customer.IsDeleted() {
+		return nil, ErrCustomerNotFound
+	}
+	return customer, nil
+}
+
+// GetByIDIncludingDeleted retrieves a customer by ID even if it is soft-deleted
+func (r *DynamoDBRepository) GetByIDIncludingDeleted(customerID string) (*Customer, error) {
+	customer, _, err := r.load(context.Background(), customerID)
+	if err != nil {
+		return nil, err
+	}
+	if customer == nil {
+		return nil, ErrCustomerNotFound
+	}
+	return customer, nil
+}
+
+// GetByIDs retrieves the live customers with the given IDs, skipping unknown ones
+func (r *DynamoDBRepository) GetByIDs(customerIDs []string) ([]*Customer, error) {
+	keys := make([]dynamo.Key, 0, len(customerIDs))
+	for _, customerID := range slices.Compact(slices.Sorted(slices.Values(customerIDs))) {
+		keys = append(keys, dynamo.Key{PK: customerPK(customerID), SK: dynamoProfileSK})
+	}
+
+	items, err := r.table.BatchGet(context.Background(), keys)
+	if err != nil {
+		return nil, err
+	}
+	customers, err := decodeCustomers(items, CustomerFilter{})
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(customers, CustomerFilter{}.Compare)
+	return customers, nil
+}
+
+// GetByEmail retrieves a live customer by email
+func (r *DynamoDBRepository) GetByEmail(email string) (*Customer, error) {
+	if email == "" {
+		return nil, ErrCustomerNotFound
+	}
+
+	claim, err := r.table.Get(context.Background(), emailPK(email), dynamoEmailSK)
+	if err != nil {
+		return nil, err
+	}
+	if claim == nil {
+		return nil, ErrCustomerNotFound
+	}
+	return r.GetByID(claim.String(dynamoOwnerAttr))
+}
+
+// Create adds a new customer
+func (r *DynamoDBRepository) Create(customer *Customer) error {
+	return unwrapWrite(r.WriteAll([]Write{{Customer: customer, Create: true}}))
+}
+
+// Update modifies an existing customer, leaving its exposure alone
+func (r *DynamoDBRepository) Update(customer *Customer) error {
+	return unwrapWrite(r.WriteAll([]Write{{Customer: customer}}))
+}
+
+// unwrapWrite returns the error of the single write in a WriteAll
+func unwrapWrite(err error) error {
+	var writeErr *WriteError
+	if errors.As(err, &writeErr) {
+		return writeErr.Err
+	}
+	return err
+}
+
+// WriteAll applies every write in one transaction.
+//
+// The writes are first applied in order to the customers and email claims
+// they touch, as read from the table, so a failing write is reported
+// without storing anything; the final state is then written on the
+// condition that none of it changed since.
+func (r *DynamoDBRepository) WriteAll(writes []Write) error {
+	return dynamo.Retry(dynamoMaxAttempts, func() error {
+		return r.writeAll(context.Background(), writes)
+	})
+}
+
+// stagedCustomer is a customer as read by writeAll and as changed by its writes
+type stagedCustomer struct {
+	customer *Customer
+	version  int64
+}
+
+// stagedClaim is the owner of an email as read by writeAll and as changed by its writes
+type stagedClaim struct {
+	stored string
+	owner  string
+}
+
+func (r *DynamoDBRepository) writeAll(ctx context.Context, writes []Write) error {
+	customers := make(map[string]*stagedCustomer)
+	claims := make(map[string]*stagedClaim)
+	var customerIDs, emails []string
+
+	stage := func(customerID string) (*stagedCustomer, error) {
+		if staged, ok := customers[customerID]; ok {
+			return staged, nil
+		}
+		customer, version, err := r.load(ctx, customerID)
+		if err != nil {
+			return nil, err
+		}
+		customers[customerID] = &stagedCustomer{customer: customer, version: version}
+		customerIDs = append(customerIDs, customerID)
+		return customers[customerID], nil
+	}
+	claim := func(email string) (*stagedClaim, error) {
+		if staged, ok := claims[email]; ok {
+			return staged, nil
+		}
+		item, err := r.table.Get(ctx, emailPK(email), dynamoEmailSK)
+		if err != nil {
+			return nil, err
+		}
+		owner := item.String(dynamoOwnerAttr)
+		claims[email] = &stagedClaim{stored: owner, owner: owner}
+		emails = append(emails, email)
+		return claims[email], nil
+	}
+
+	for i, write := range writes {
+		staged, err := stage(write.Customer.CustomerID)
+		if err != nil {
+			return err
+		}
+		current := staged.customer
+
+		next := *write.Customer
+		if write.Create {
+			if current != nil {
+				return &WriteError{Index: i, Err: ErrCustomerExists}
+			}
+		} else {
+			if current == nil || current.IsDeleted() {
+				return &WriteError{Index: i, Err: ErrCustomerNotFound}
+			}
+			next.CurrentExposure = current.CurrentExposure
+			next.CreatedAt, next.CreatedBy = current.CreatedAt, current.CreatedBy
+			next.DeletedAt = nil
+		}
+
+		if next.Email != "" && (current == nil || current.Email != next.Email) {
+			owner, err := claim(next.Email)
+			if err != nil {
+				return err
+			}
+			if owner.owner != "" && owner.owner != next.CustomerID {
+				return &WriteError{Index: i, Err: ErrEmailExists}
+			}
+			owner.owner = next.CustomerID
+		}
+		if current != nil && current.Email != "" && current.Email != next.Email {
+			previous, err := claim(current.Email)
+			if err != nil {
+				return err
+			}
+			previous.owner = ""
+		}
+		staged.customer = &next
+	}
+
+	var items []types.TransactWriteItem
+	for _, customerID := range customerIDs {
+		staged := customers[customerID]
+		item, err := customerItem(staged.customer)
+		if err != nil {
+			return err
+		}
+		items = append(items, r.table.Put(item, staged.version))
+	}
+	for _, email := range emails {
+		switch staged := claims[email]; {
+		case staged.owner == staged.stored:
+		case staged.owner == "":
+			items = append(items, r.table.Delete(emailPK(email), dynamoEmailSK))
+		default:
+			item, err := dynamo.NewItem(emailPK(email), dynamoEmailSK, dynamoEmailClaim, staged.owner)
+			if err != nil {
+				return err
+			}
+			item.SetString(dynamoOwnerAttr, staged.owner)
+			if staged.stored == "" {
+				items = append(items, r.table.PutNew(item))
+			} else {
+				items = append(items, r.table.Replace(item))
+			}
+		}
+	}
+
+	return r.transact(ctx, items)
+}
+
+// Delete soft-deletes a customer by stamping DeletedAt
+func (r *DynamoDBRepository) Delete(customerID string) error {
+	_, err := r.change(customerID, func(customer *Customer) error {
+		if customer.IsDeleted() {
+			return ErrCustomerNotFound
+		}
+		deletedAt := time.Now()
+		customer.DeletedAt = &deletedAt
+		return nil
+	})
+	return err
+}
+
+// Restore clears DeletedAt on a soft-deleted customer
+func (r *DynamoDBRepository) Restore(customerID string) error {
+	_, err := r.change(customerID, func(customer *Customer) error {
+		if !customer.IsDeleted() {
+			return ErrCustomerNotDeleted
+		}
+		customer.DeletedAt = nil
+		return nil
+	})
+	return err
+}
+
+// RewritePersonalData replaces the name, email and phone of a customer, even
+// a soft-deleted one, moving its email claim along in the same transaction
+func (r *DynamoDBRepository) RewritePersonalData(customer *Customer) error {
+	ctx := context.Background()
+	return dynamo.Retry(dynamoMaxAttempts, func() error {
+		stored, version, err := r.load(ctx, customer.CustomerID)
+		if err != nil {
+			return err
+		}
+		if stored == nil {
+			return ErrCustomerNotFound
+		}
+
+		rewritten := *stored
+		rewritten.Name, rewritten.Email, rewritten.Phone = customer.Name, customer.Email, customer.Phone
+		item, err := customerItem(&rewritten)
+		if err != nil {
+			return err
+		}
+		items := []types.TransactWriteItem{r.table.Put(item, version)}
+		if rewritten.Email == stored.Email {
+			return r.transact(ctx, items)
+		}
+
+		if rewritten.Email != "" {
+			claim, err := r.table.Get(ctx, emailPK(rewritten.Email), dynamoEmailSK)
+			if err != nil {
+				return err
+			}
+			if owner := claim.String(dynamoOwnerAttr); owner != "" && owner != rewritten.CustomerID {
+				return ErrEmailExists
+			}
+			if claim, err = dynamo.NewItem(emailPK(rewritten.Email), dynamoEmailSK, dynamoEmailClaim, rewritten.CustomerID); err != nil {
+				return err
+			}
+			claim.SetString(dynamoOwnerAttr, rewritten.CustomerID)
+			items = append(items, r.table.PutNew(claim))
+		}
+		if stored.Email != "" {
+			items = append(items, r.table.Delete(emailPK(stored.Email), dynamoEmailSK))
+		}
+		return r.transact(ctx, items)
+	})
+}
+
+// AdjustExposure adds change.Delta to a live customer's exposure, rounded to
+// cents, on the condition that the customer did not change since it was read
+func (r *DynamoDBRepository) AdjustExposure(customerID string, change ExposureChange) (*Customer, error) {
+	return r.change(customerID, func(customer *Customer) error {
+		if customer.IsDeleted() {
+			return ErrCustomerNotFound
+		}
+
+		exposure := roundAmount(customer.CurrentExposure + change.Delta)
+		if change.Delta > 0 && exposure > customer.CreditLimit {
+			return ErrCreditLimitExceeded
+		}
+		if exposure < 0 {
+			return ErrExposureBelowZero
+		}
+
+		customer.CurrentExposure = exposure
+		customer.UpdatedAt, customer.UpdatedBy = change.UpdatedAt, change.UpdatedBy
+		return nil
+	})
+}
+
+// change reads a customer, lets update modify it and writes it back on the
+// condition that it did not change in between, starting over if it did
+func (r *DynamoDBRepository) change(customerID string, update func(customer *Customer) error) (*Customer, error) {
+	ctx := context.Background()
+
+	var changed *Customer
+	err := dynamo.Retry(dynamoMaxAttempts, func() error {
+		customer, version, err := r.load(ctx, customerID)
+		if err != nil {
+			return err
+		}
+		if customer == nil {
+			return ErrCustomerNotFound
+		}
+		if err := update(customer); err != nil {
+			return err
+		}
+
+		item, err := customerItem(customer)
+		if err != nil {
+			return err
+		}
+		changed = customer
+		return r.transact(ctx, []types.TransactWriteItem{r.table.Put(item, version)})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return changed, nil
+}
+
+// List returns all live customers ordered by ID
+func (r *DynamoDBRepository) List() ([]*Customer, error) {
+	return r.Find(CustomerFilter{})
+}
+
+// Find returns the customers matching filter, ordered by its Sort and then
+// by CustomerID
+func (r *DynamoDBRepository) Find(filter CustomerFilter) ([]*Customer, error) {
+	customers, err := r.scan(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(customers, filter.Compare)
+	return paginate(customers, filter.Limit, filter.Offset), nil
+}
+
+// Count returns the number of customers matching filter, ignoring Limit and Offset
+func (r *DynamoDBRepository) Count(filter CustomerFilter) (int, error) {
+	customers, err := r.scan(filter)
+	if err != nil {
+		return 0, err
+	}
+	return len(customers), nil
+}
+
+// scan returns every stored customer matching filter
+func (r *DynamoDBRepository) scan(filter CustomerFilter) ([]*Customer, error) {
+	items, err := r.table.Scan(context.Background(), dynamoCustomer)
+	if err != nil {
+		return nil, err
+	}
+	return decodeCustomers(items, filter)
+}
+
+// Addresses returns the addresses of a live customer ordered by type, then
+// creation time; unknown and deleted customers have none
+func (r *DynamoDBRepository) Addresses(customerID string) ([]*Address, error) {
+	ctx := context.Background()
+
+	addresses := make([]*Address, 0)
+	customer, _, err := r.load(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	if customer == nil || customer.IsDeleted() {
+		return addresses, nil
+	}
+
+	items, err := r.table.Query(ctx, customerPK(customerID), dynamoAddressSK)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		var address Address
+		if err := item.Decode(&address); err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, &address)
+	}
+
+	slices.SortFunc(addresses, func(a, b *Address) int {
+		if a.Type != b.Type {
+			return strings.Compare(a.Type, b.Type)
+		}
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Compare(b.CreatedAt)
+		}
+		return strings.Compare(a.AddressID, b.AddressID)
+	})
+	return addresses, nil
+}
+
+// GetAddress retrieves one address of a live customer
+func (r *DynamoDBRepository) GetAddress(customerID, addressID string) (*Address, error) {
+	ctx := context.Background()
+
+	customer, _, err := r.load(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	if customer == nil || customer.IsDeleted() {
+		return nil, ErrAddressNotFound
+	}
+	return r.loadAddress(ctx, customerID, addressID)
+}
+
+// CreateAddress adds an address to a live customer
+func (r *DynamoDBRepository) CreateAddress(address *Address) error {
+	return r.writeAddress(address.CustomerID, ErrCustomerNotFound, func(ctx context.Context) (*Address, error) {
+		_, err := r.loadAddress(ctx, address.CustomerID, address.AddressID)
+		if err == nil {
+			return nil, ErrAddressExists
+		}
+		if !errors.Is(err, ErrAddressNotFound) {
+			return nil, err
+		}
+		return address, nil
+	})
+}
+
+// UpdateAddress replaces an address of a live customer
+func (r *DynamoDBRepository) UpdateAddress(address *Address) error {
+	return r.writeAddress(address.CustomerID, ErrAddressNotFound, func(ctx context.Context) (*Address, error) {
+		stored, err := r.loadAddress(ctx, address.CustomerID, address.AddressID)
+		if err != nil {
+			return nil, err
+		}
+
+		updated := *address
+		updated.CreatedAt, updated.CreatedBy = stored.CreatedAt, stored.CreatedBy
+		return &updated, nil
+	})
+}
+
+// DeleteAddress removes an address of a live customer
+func (r *DynamoDBRepository) DeleteAddress(customerID, addressID string) error {
+	ctx := context.Background()
+
+	return dynamo.Retry(dynamoMaxAttempts, func() error {
+		customer, version, err := r.load(ctx, customerID)
+		if err != nil {
+			return err
+		}
+		if customer == nil || customer.IsDeleted() {
+			return ErrAddressNotFound
+		}
+		if _, err := r.loadAddress(ctx, customerID, addressID); err != nil {
+			return err
+		}
+
+		return r.transact(ctx, []types.TransactWriteItem{
+			r.table.Lock(customerPK(customerID), dynamoProfileSK, version),
+			r.table.Delete(customerPK(customerID), dynamoAddressSK+addressID),
+		})
+	})
+}
+
+// writeAddress stores the address returned by prepare for a live customer,
+// failing with missing when the customer is not. The customer's version is
+// bumped in the same transaction, so concurrent writes cannot leave two
+// default addresses of one type. When the address is the default, the
+// customer's other addresses of its type are cleared.
+func (r *DynamoDBRepository) writeAddress(customerID string, missing error, prepare func(ctx context.Context) (*Address, error)) error {
+	ctx := context.Background()
+
+	return dynamo.Retry(dynamoMaxAttempts, func() error {
+		customer, version, err := r.load(ctx, customerID)
+		if err != nil {
+			return err
+		}
+		if customer == nil || customer.IsDeleted() {
+			return missing
+		}
+		address, err := prepare(ctx)
+		if err != nil {
+			return err
+		}
+
+		items := []types.TransactWriteItem{r.table.Lock(customerPK(customerID), dynamoProfileSK, version)}
+		if address.IsDefault {
+			others, err := r.Addresses(customerID)
+			if err != nil {
+				return err
+			}
+			for _, other := range others {
+				if other.Type != address.Type || !other.IsDefault || other.AddressID == address.AddressID {
+					continue
+				}
+				cleared := *other
+				cleared.IsDefault = false
+				item, err := addressItem(&cleared)
+				if err != nil {
+					return err
+				}
+				items = append(items, r.table.Replace(item))
+			}
+		}
+
+		item, err := addressItem(address)
+		if err != nil {
+			return err
+		}
+		return r.transact(ctx, append(items, r.table.Upsert(item)))
+	})
+}
+
+// load reads a customer and its version; it returns nil when there is none
+func (r *DynamoDBRepository) load(ctx context.Context, customerID string) (*Customer, int64, error) {
+	item, err := r.table.Get(ctx, customerPK(customerID), dynamoProfileSK)
+	if err != nil || item == nil {
+		return nil, 0, err
+	}
+
+	var customer Customer
+	if err := item.Decode(&customer); err != nil {
+		return nil, 0, err
+	}
+	return &customer, item.Version(), nil
+}
+
+// loadAddress reads one address of a customer
+func (r *DynamoDBRepository) loadAddress(ctx context.Context, customerID, addressID string) (*Address, error) {
+	item, err := r.table.Get(ctx, customerPK(customerID), dynamoAddressSK+addressID)
+	if err != nil {
+		return nil, err
+	}
+	if item == nil {
+		return nil, ErrAddressNotFound
+	}
+
+	var address Address
+	if err := item.Decode(&address); err != nil {
+		return nil, err
+	}
+	return &address, nil
+}
+
+// transact writes items, reporting any failed condition as a conflict:
+// every condition holds for the state the items were built from
+func (r *DynamoDBRepository) transact(ctx context.Context, items []types.TransactWriteItem) error {
+	err := r.table.Transact(ctx, items)
+	var canceled *dynamo.CanceledError
+	if errors.As(err, &canceled) {
+		return dynamo.ErrConflict
+	}
+	return err
+}
+
+// decodeCustomers decodes customer items, keeping those matching filter
+func decodeCustomers(items []dynamo.Item, filter CustomerFilter) ([]*Customer, error) {
+	customers := make([]*Customer, 0, len(items))
+	for _, item := range items {
+		var customer Customer
+		if err := item.Decode(&customer); err != nil {
+			return nil, err
+		}
+		if filter.Matches(&customer) {
+			customers = append(customers, &customer)
+		}
+	}
+	return customers, nil
+}
+
+// customerItem builds the item storing customer
+func customerItem(customer *Customer) (dynamo.Item, error) {
+	item, err := dynamo.NewItem(customerPK(customer.CustomerID), dynamoProfileSK, dynamoCustomer, customer)
+	if err != nil {
+		return nil, err
+	}
+	if customer.IsDeleted() {
+		item.MarkDeleted()
+	}
+	return item, nil
+}
+
+// addressItem builds the item storing address
+func addressItem(address *Address) (dynamo.Item, error) {
+	return dynamo.NewItem(customerPK(address.CustomerID), dynamoAddressSK+address.AddressID, dynamoAddress, address)
+}
+
+func customerPK(customerID string) string {
+	return "CUSTOMER#" + customerID
+}
+
+func emailPK(email string) string {
+	return "EMAIL#" + email
+}
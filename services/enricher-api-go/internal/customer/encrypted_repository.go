@@ -0,0 +1,484 @@
+package customer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"enricher-api-go/internal/envelope"
+	"enricher-api-go/internal/listing"
+	"enricher-api-go/internal/outbox"
+)
+
+// Names of the encrypted fields, which each value is bound to
+const (
+	fieldName  = "name"
+	fieldEmail = "email"
+	fieldPhone = "phone"
+)
+
+// EncryptedRepository encrypts the personal data of customers, their name,
+// email and phone, before storing them in another Repository and decrypts
+// them again on every read, so the services above it only see plaintext.
+//
+// Names and phones are encrypted with random nonces. Emails are encrypted
+// deterministically, so the wrapped repository still keeps them unique and
+// looks customers up by them; stored customers with the same email can be
+// told apart from the others. Customers stored before encryption was turned
+// on are read as they are until Reencrypt rewrites them.
+//
+// Sorting on name or email cannot be left to the wrapped repository: Find
+// reads every match, decrypts and sorts them, then pages.
+type EncryptedRepository struct {
+	repo Repository
+	keys *envelope.Keyring
+}
+
+// NewEncryptedRepository wraps repo with field-level encryption.
+//
+// Args:
+//   - repo: the repository storing the encrypted customers
+//   - keys: the data keys; the first encrypts
+//
+// Returns:
+//   - *EncryptedRepository: the encrypting repository
+func NewEncryptedRepository(repo Repository, keys *envelope.Keyring) *EncryptedRepository {
+	return &EncryptedRepository{repo: repo, keys: keys}
+}
+
+// GetByID retrieves a live customer by ID
+func (r *EncryptedRepository) GetByID(customerID string) (*Customer, error) {
+	customer, err := r.repo.GetByID(customerID)
+	if err != nil {
+		return nil, err
+	}
+	return decryptCustomer(r.keys, customer)
+}
+
+// GetByIDIncludingDeleted retrieves a customer by ID even if it is soft-deleted
+func (r *EncryptedRepository) GetByIDIncludingDeleted(customerID string) (*Customer, error) {
+	customer, err := r.repo.GetByIDIncludingDeleted(customerID)
+	if err != nil {
+		return nil, err
+	}
+	return decryptCustomer(r.keys, customer)
+}
+
+// GetByIDs retrieves the live customers with the given IDs
+func (r *EncryptedRepository) GetByIDs(customerIDs []string) ([]*Customer, error) {
+	customers, err := r.repo.GetByIDs(customerIDs)
+	if err != nil {
+		return nil, err
+	}
+	return r.decryptAll(customers)
+}
+
+// GetByEmail retrieves a live customer by email, stored under any data key
+// or in plaintext
+func (r *EncryptedRepository) GetByEmail(email string) (*Customer, error) {
+	for _, candidate := range r.keys.DeterministicCandidates(fieldEmail, email) {
+		customer, err := r.repo.GetByEmail(candidate)
+		if errors.Is(err, ErrCustomerNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return decryptCustomer(r.keys, customer)
+	}
+	return nil, ErrCustomerNotFound
+}
+
+// Create encrypts and adds a new customer
+func (r *EncryptedRepository) Create(customer *Customer) error {
+	if err := r.checkEmail(customer); err != nil {
+		return err
+	}
+	encrypted, err := r.encrypt(customer, nil)
+	if err != nil {
+		return err
+	}
+	if err := r.repo.Create(encrypted); err != nil {
+		return err
+	}
+	keepPlaintext(customer, encrypted)
+	return nil
+}
+
+// Update encrypts and replaces an existing customer
+func (r *EncryptedRepository) Update(customer *Customer) error {
+	stored, err := r.stored(customer.CustomerID)
+	if err != nil {
+		return err
+	}
+	if err := r.checkEmail(customer); err != nil {
+		return err
+	}
+	encrypted, err := r.encrypt(customer, stored)
+	if err != nil {
+		return err
+	}
+	if err := r.repo.Update(encrypted); err != nil {
+		return err
+	}
+	keepPlaintext(customer, encrypted)
+	return nil
+}
+
+// WriteAll encrypts the customers of writes and applies every write or none
+// of them
+func (r *EncryptedRepository) WriteAll(writes []Write) error {
+	encrypted := make([]Write, len(writes))
+	for i, write := range writes {
+		var stored *Customer
+		if !write.Create {
+			var err error
+			if stored, err = r.stored(write.Customer.CustomerID); err != nil {
+				return &WriteError{Index: i, Err: err}
+			}
+		}
+		if err := r.checkEmail(write.Customer); err != nil {
+			return &WriteError{Index: i, Err: err}
+		}
+		customer, err := r.encrypt(write.Customer, stored)
+		if err != nil {
+			return &WriteError{Index: i, Err: err}
+		}
+		encrypted[i] = Write{Customer: customer, Create: write.Create}
+	}
+
+	if err := r.repo.WriteAll(encrypted); err != nil {
+		return err
+	}
+	for i, write := range writes {
+		keepPlaintext(write.Customer, encrypted[i].Customer)
+	}
+	return nil
+}
+
+// Delete soft-deletes a customer
+func (r *EncryptedRepository) Delete(customerID string) error {
+	return r.repo.Delete(customerID)
+}
+
+// Restore undoes a soft delete
+func (r *EncryptedRepository) Restore(customerID string) error {
+	return r.repo.Restore(customerID)
+}
+
+// List returns all live customers
+func (r *EncryptedRepository) List() ([]*Customer, error) {
+	customers, err := r.repo.List()
+	if err != nil {
+		return nil, err
+	}
+	return r.decryptAll(customers)
+}
+
+// Find returns the customers matching filter
+func (r *EncryptedRepository) Find(filter CustomerFilter) ([]*Customer, error) {
+	if !sortsEncrypted(filter.Sort) {
+		customers, err := r.repo.Find(filter)
+		if err != nil {
+			return nil, err
+		}
+		return r.decryptAll(customers)
+	}
+
+	// The stored order of encrypted values means nothing, so sort and page
+	// the decrypted matches here
+	all := filter
+	all.Sort, all.Limit, all.Offset = nil, 0, 0
+	customers, err := r.repo.Find(all)
+	if err != nil {
+		return nil, err
+	}
+	if customers, err = r.decryptAll(customers); err != nil {
+		return nil, err
+	}
+	slices.SortFunc(customers, filter.Compare)
+	return paginate(customers, filter.Limit, filter.Offset), nil
+}
+
+// Count returns the number of customers matching filter
+func (r *EncryptedRepository) Count(filter CustomerFilter) (int, error) {
+	return r.repo.Count(filter)
+}
+
+// AdjustExposure adjusts a customer's exposure
+func (r *EncryptedRepository) AdjustExposure(customerID string, change ExposureChange) (*Customer, error) {
+	customer, err := r.repo.AdjustExposure(customerID, change)
+	if err != nil {
+		return nil, err
+	}
+	return decryptCustomer(r.keys, customer)
+}
+
+// Addresses returns the addresses of a live customer
+func (r *EncryptedRepository) Addresses(customerID string) ([]*Address, error) {
+	return r.repo.Addresses(customerID)
+}
+
+// GetAddress retrieves one address of a live customer
+func (r *EncryptedRepository) GetAddress(customerID, addressID string) (*Address, error) {
+	return r.repo.GetAddress(customerID, addressID)
+}
+
+// CreateAddress adds an address to a live customer
+func (r *EncryptedRepository) CreateAddress(address *Address) error {
+	return r.repo.CreateAddress(address)
+}
+
+// UpdateAddress replaces an address of a live customer
+func (r *EncryptedRepository) UpdateAddress(address *Address) error {
+	return r.repo.UpdateAddress(address)
+}
+
+// DeleteAddress removes an address of a live customer
+func (r *EncryptedRepository) DeleteAddress(customerID, addressID string) error {
+	return r.repo.DeleteAddress(customerID, addressID)
+}
+
+// Reencrypt rewrites every stored customer, soft-deleted ones included,
+// whose personal data is in plaintext or encrypted under a data key other
+// than the current one, so older keys can be dropped. The wrapped repository
+// must be a PersonalDataRewriter: only the personal data is written, in
+// place, so customers keep their UpdatedAt and DeletedAt and no change events
+// are recorded. A customer changed while Reencrypt runs may lose the change,
+// so run it while the API takes no writes.
+//
+// Returns:
+//   - int: the number of customers rewritten
+//   - error: the first failure; the customers before it stay rewritten
+func (r *EncryptedRepository) Reencrypt() (int, error) {
+	rewriter, ok := r.repo.(PersonalDataRewriter)
+	if !ok {
+		return 0, fmt.Errorf("re-encryption needs a repository that rewrites personal data in place, got %T", r.repo)
+	}
+	customers, err := r.repo.Find(CustomerFilter{IncludeDeleted: true})
+	if err != nil {
+		return 0, err
+	}
+
+	rewritten := 0
+	for _, stored := range customers {
+		if r.keys.IsCurrent(stored.Name) && r.keys.IsCurrent(stored.Email) && r.keys.IsCurrent(stored.Phone) {
+			continue
+		}
+		customer, err := decryptCustomer(r.keys, stored)
+		if err != nil {
+			return rewritten, err
+		}
+		encrypted, err := r.encrypt(customer, stored)
+		if err != nil {
+			return rewritten, err
+		}
+		if err := rewriter.RewritePersonalData(encrypted); err != nil {
+			return rewritten, fmt.Errorf("failed to re-encrypt customer %s: %w", customer.CustomerID, err)
+		}
+		rewritten++
+	}
+	return rewritten, nil
+}
+
+// stored returns the customer as stored, or nil if there is none; the
+// wrapped repository reports a missing customer itself
+func (r *EncryptedRepository) stored(customerID string) (*Customer, error) {
+	stored, err := r.repo.GetByIDIncludingDeleted(customerID)
+	if errors.Is(err, ErrCustomerNotFound) {
+		return nil, nil
+	}
+	return stored, err
+}
+
+// checkEmail returns ErrEmailExists when another customer has the
+// customer's email stored under an older data key or in plaintext, which
+// the wrapped repository cannot tell is the same email
+func (r *EncryptedRepository) checkEmail(customer *Customer) error {
+	for _, candidate := range r.keys.DeterministicCandidates(fieldEmail, customer.Email)[1:] {
+		owner, err := r.repo.GetByEmail(candidate)
+		if errors.Is(err, ErrCustomerNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if owner.CustomerID != customer.CustomerID {
+			return ErrEmailExists
+		}
+	}
+	return nil
+}
+
+// encrypt returns a copy of customer with its personal data encrypted. A
+// name or phone stored under the current data key that did not change is
+// kept as stored, so unchanged fields do not churn.
+func (r *EncryptedRepository) encrypt(customer, stored *Customer) (*Customer, error) {
+	encrypted := *customer
+	encrypted.Email = r.keys.EncryptDeterministic(fieldEmail, customer.Email)
+
+	var err error
+	if encrypted.Name, err = r.encryptField(fieldName, customer.Name, stored, func(c *Customer) string { return c.Name }); err != nil {
+		return nil, err
+	}
+	if encrypted.Phone, err = r.encryptField(fieldPhone, customer.Phone, stored, func(c *Customer) string { return c.Phone }); err != nil {
+		return nil, err
+	}
+	return &encrypted, nil
+}
+
+// encryptField encrypts value, reusing the field of stored read by get when
+// it is current and holds the same value
+func (r *EncryptedRepository) encryptField(field, value string, stored *Customer, get func(*Customer) string) (string, error) {
+	if stored != nil && r.keys.IsCurrent(get(stored)) {
+		if previous, err := r.keys.Decrypt(field, get(stored)); err == nil && previous == value {
+			return get(stored), nil
+		}
+	}
+	return r.keys.Encrypt(field, value)
+}
+
+// decryptAll decrypts customers
+func (r *EncryptedRepository) decryptAll(customers []*Customer) ([]*Customer, error) {
+	decrypted := make([]*Customer, len(customers))
+	for i, customer := range customers {
+		var err error
+		if decrypted[i], err = decryptCustomer(r.keys, customer); err != nil {
+			return nil, err
+		}
+	}
+	return decrypted, nil
+}
+
+// keepPlaintext copies the fields the wrapped repository set on encrypted,
+// such as CurrentExposure, back to customer, keeping its personal data in
+// plaintext
+func keepPlaintext(customer, encrypted *Customer) {
+	name, email, phone := customer.Name, customer.Email, customer.Phone
+	*customer = *encrypted
+	customer.Name, customer.Email, customer.Phone = name, email, phone
+}
+
+// decryptCustomer returns a copy of customer with its personal data decrypted
+func decryptCustomer(keys *envelope.Keyring, customer *Customer) (*Customer, error) {
+	if customer == nil {
+		return nil, nil
+	}
+	decrypted := *customer
+	var err error
+	if decrypted.Name, decrypted.Email, decrypted.Phone, err = decryptFields(keys, customer.Name, customer.Email, customer.Phone); err != nil {
+		return nil, fmt.Errorf("customer %s: %w", customer.CustomerID, err)
+	}
+	return &decrypted, nil
+}
+
+// decryptFields decrypts a stored name, email and phone
+func decryptFields(keys *envelope.Keyring, name, email, phone string) (string, string, string, error) {
+	name, err := keys.Decrypt(fieldName, name)
+	if err != nil {
+		return "", "", "", err
+	}
+	if email, err = keys.Decrypt(fieldEmail, email); err != nil {
+		return "", "", "", err
+	}
+	if phone, err = keys.Decrypt(fieldPhone, phone); err != nil {
+		return "", "", "", err
+	}
+	return name, email, phone, nil
+}
+
+// sortsEncrypted reports whether sort orders on an encrypted field
+func sortsEncrypted(sort listing.Sort) bool {
+	return slices.ContainsFunc(sort, func(f listing.SortField) bool {
+		return f.Field == fieldName || f.Field == fieldEmail
+	})
+}
+
+// EncryptedHistory decrypts the personal data in the customer histories of
+// another HistorySource whose events an EncryptedRepository recorded.
+// Events are never rewritten, so a history stays readable only while the
+// keyring holds every data key its events were encrypted under.
+type EncryptedHistory struct {
+	source HistorySource
+	keys   *envelope.Keyring
+}
+
+// NewEncryptedHistory wraps source with decryption under keys
+func NewEncryptedHistory(source HistorySource, keys *envelope.Keyring) *EncryptedHistory {
+	return &EncryptedHistory{source: source, keys: keys}
+}
+
+// History returns the decrypted events of a customer that occurred at or
+// before until and the customer they leave
+func (h *EncryptedHistory) History(customerID string, until time.Time) (*History, error) {
+	history, err := h.source.History(customerID, until)
+	if err != nil {
+		return nil, err
+	}
+
+	decrypted := &History{Events: make([]Event, len(history.Events))}
+	if decrypted.Customer, err = decryptCustomer(h.keys, history.Customer); err != nil {
+		return nil, err
+	}
+	for i, event := range history.Events {
+		if event.Data.Customer, err = decryptCustomer(h.keys, event.Data.Customer); err != nil {
+			return nil, err
+		}
+		if event.Data.Profile != nil {
+			profile := *event.Data.Profile
+			if profile.Name, profile.Email, profile.Phone, err = decryptFields(h.keys, profile.Name, profile.Email, profile.Phone); err != nil {
+				return nil, fmt.Errorf("customer %s event %d: %w", customerID, event.Version, err)
+			}
+			event.Data.Profile = &profile
+		}
+		decrypted.Events[i] = event
+	}
+	return decrypted, nil
+}
+
+// decryptingPublisher decrypts the customers in customer events before
+// handing them to another publisher
+type decryptingPublisher struct {
+	next outbox.Publisher
+	keys *envelope.Keyring
+}
+
+// NewDecryptingPublisher returns a publisher that decrypts the personal
+// data of the customers an EncryptedRepository recorded in the outbox
+// before publishing them through next, so consumers of customer events see
+// them as they did before encryption
+func NewDecryptingPublisher(next outbox.Publisher, keys *envelope.Keyring) outbox.Publisher {
+	return &decryptingPublisher{next: next, keys: keys}
+}
+
+// Publish publishes events with their customers decrypted, leaving events
+// themselves untouched for the relay to retry
+func (p *decryptingPublisher) Publish(ctx context.Context, events []*outbox.Event) error {
+	decrypted := make([]*outbox.Event, len(events))
+	for i, event := range events {
+		decrypted[i] = event
+		if event.Aggregate != outbox.AggregateCustomer {
+			continue
+		}
+
+		var customer Customer
+		if err := json.Unmarshal(event.Data, &customer); err != nil {
+			return fmt.Errorf("failed to decode event %s: %w", event.EventID, err)
+		}
+		plain, err := decryptCustomer(p.keys, &customer)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt event %s: %w", event.EventID, err)
+		}
+		data, err := json.Marshal(plain)
+		if err != nil {
+			return fmt.Errorf("failed to encode event %s: %w", event.EventID, err)
+		}
+		copied := *event
+		copied.Data = data
+		decrypted[i] = &copied
+	}
+	return p.next.Publish(ctx, decrypted)
+}
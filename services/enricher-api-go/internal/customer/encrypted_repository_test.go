@@ -0,0 +1,250 @@
+package customer
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"enricher-api-go/internal/envelope"
+	"enricher-api-go/internal/listing"
+	"enricher-api-go/internal/outbox"
+)
+
+// unwrappedKMS hands out data keys unwrapped, which is enough to exercise
+// encryption without a master key
+type unwrappedKMS struct{}
+
+func (unwrappedKMS) GenerateDataKey(ctx context.Context) (plaintext, wrapped []byte, err error) {
+	key := make([]byte, 32)
+	_, err = rand.Read(key)
+	return key, key, err
+}
+
+func (unwrappedKMS) Decrypt(ctx context.Context, wrapped []byte) ([]byte, error) {
+	return wrapped, nil
+}
+
+// newDataKey returns a new wrapped data key
+func newDataKey(t *testing.T) []byte {
+	t.Helper()
+	_, wrapped, err := unwrappedKMS{}.GenerateDataKey(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to generate data key: %v", err)
+	}
+	return wrapped
+}
+
+// newKeyring returns a keyring of the wrapped data keys, current first
+func newKeyring(t *testing.T, wrapped ...[]byte) *envelope.Keyring {
+	t.Helper()
+	keys, err := envelope.NewKeyring(context.Background(), unwrappedKMS{}, wrapped)
+	if err != nil {
+		t.Fatalf("Failed to create keyring: %v", err)
+	}
+	return keys
+}
+
+func TestEncryptedRepository_Conformance(t *testing.T) {
+	testRepositoryConformance(t, func(t *testing.T) Repository {
+		return NewEncryptedRepository(NewInMemoryRepository(), newKeyring(t, newDataKey(t)))
+	})
+}
+
+func TestEncryptedRepository_StoresEncrypted(t *testing.T) {
+	// Arrange
+	backing := NewInMemoryRepositoryWith(nil)
+	repo := NewEncryptedRepository(backing, newKeyring(t, newDataKey(t)))
+	customer := &Customer{CustomerID: "customer-pii", Name: "Jane Doe", Email: "jane.doe@example.com", Phone: "+14155550100", Status: "ACTIVE"}
+
+	// Act
+	err := repo.Create(customer)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if customer.Name != "Jane Doe" || customer.Email != "jane.doe@example.com" {
+		t.Errorf("Expected the caller's customer to stay in plaintext, got %+v", customer)
+	}
+	stored, _ := backing.GetByID("customer-pii")
+	for field, value := range map[string]string{"name": stored.Name, "email": stored.Email, "phone": stored.Phone} {
+		if !strings.HasPrefix(value, "enc:") {
+			t.Errorf("Expected the %s to be stored encrypted, got %s", field, value)
+		}
+	}
+	byEmail, err := repo.GetByEmail("jane.doe@example.com")
+	if err != nil || byEmail.Name != "Jane Doe" || byEmail.Phone != "+14155550100" {
+		t.Errorf("Expected the customer decrypted by email, got %+v (%v)", byEmail, err)
+	}
+	duplicate := &Customer{CustomerID: "customer-dup", Name: "Janet Doe", Email: "jane.doe@example.com", Status: "ACTIVE"}
+	if err := repo.Create(duplicate); !errors.Is(err, ErrEmailExists) {
+		t.Errorf("Expected ErrEmailExists, got %v", err)
+	}
+}
+
+func TestEncryptedRepository_UnchangedFieldsKeepCiphertext(t *testing.T) {
+	// Arrange
+	backing := NewInMemoryRepositoryWith(nil)
+	repo := NewEncryptedRepository(backing, newKeyring(t, newDataKey(t)))
+	_ = repo.Create(&Customer{CustomerID: "customer-pii", Name: "Jane Doe", Phone: "+14155550100", Status: "ACTIVE"})
+	before, _ := backing.GetByID("customer-pii")
+
+	// Act
+	customer, _ := repo.GetByID("customer-pii")
+	customer.Status, customer.Phone = "SUSPENDED", "+14155550199"
+	err := repo.Update(customer)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	after, _ := backing.GetByID("customer-pii")
+	if after.Name != before.Name {
+		t.Error("Expected the unchanged name to keep its ciphertext")
+	}
+	if after.Phone == before.Phone {
+		t.Error("Expected the changed phone to be encrypted again")
+	}
+}
+
+func TestEncryptedRepository_FindSortsPlaintext(t *testing.T) {
+	// Arrange
+	repo := NewEncryptedRepository(NewInMemoryRepositoryWith(nil), newKeyring(t, newDataKey(t)))
+	for _, name := range []string{"Carol", "Alice", "Bob"} {
+		_ = repo.Create(&Customer{CustomerID: "customer-" + name, Name: name, Status: "ACTIVE"})
+	}
+
+	// Act
+	page, err := repo.Find(CustomerFilter{Sort: listing.Sort{{Field: "name"}}, Limit: 2, Offset: 1})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(page) != 2 || page[0].Name != "Bob" || page[1].Name != "Carol" {
+		t.Errorf("Expected Bob and Carol, got %+v", page)
+	}
+}
+
+func TestEncryptedRepository_Rotation(t *testing.T) {
+	// Arrange: a customer encrypted under the old key, sample customers in
+	// plaintext, and a new key put first
+	backing := NewInMemoryRepository()
+	oldKey, newKey := newDataKey(t), newDataKey(t)
+	_ = NewEncryptedRepository(backing, newKeyring(t, oldKey)).Create(&Customer{
+		CustomerID: "customer-pii", Name: "Dana Scully", Email: "dana@example.com", Status: "ACTIVE",
+	})
+	_ = backing.Delete("customer-456")
+	deleted, _ := backing.GetByIDIncludingDeleted("customer-456")
+	events := outbox.NewInMemoryStore()
+	backing.RecordEventsTo(events)
+	repo := NewEncryptedRepository(backing, newKeyring(t, newKey, oldKey))
+
+	// Act
+	byEmail, lookupErr := repo.GetByEmail("dana@example.com")
+	duplicateErr := repo.Create(&Customer{CustomerID: "customer-dup", Name: "Dana Scully", Email: "dana@example.com", Status: "ACTIVE"})
+	rewritten, err := repo.Reencrypt()
+
+	// Assert
+	if lookupErr != nil || byEmail.CustomerID != "customer-pii" {
+		t.Errorf("Expected the email under the old key to be found, got %v", lookupErr)
+	}
+	if !errors.Is(duplicateErr, ErrEmailExists) {
+		t.Errorf("Expected the email under the old key to stay unique, got %v", duplicateErr)
+	}
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	all, _ := backing.Find(CustomerFilter{IncludeDeleted: true})
+	if rewritten != len(all) {
+		t.Errorf("Expected all %d customers rewritten, got %d", len(all), rewritten)
+	}
+	if again, _ := repo.Reencrypt(); again != 0 {
+		t.Errorf("Expected nothing left to rewrite, got %d", again)
+	}
+
+	newOnly := NewEncryptedRepository(backing, newKeyring(t, newKey))
+	customer, err := newOnly.GetByIDIncludingDeleted("customer-456")
+	if err != nil || customer.Name != "Jane Doe" || !customer.IsDeleted() {
+		t.Fatalf("Expected the deleted customer readable without the old key, got %+v (%v)", customer, err)
+	}
+	if !customer.DeletedAt.Equal(*deleted.DeletedAt) || !customer.UpdatedAt.Equal(deleted.UpdatedAt) {
+		t.Errorf("Expected the deleted customer rewritten in place, got deleted at %v, updated at %v", customer.DeletedAt, customer.UpdatedAt)
+	}
+	if recorded := events.Events(); len(recorded) != 0 {
+		t.Errorf("Expected no change events recorded, got %d", len(recorded))
+	}
+	if customer, err := newOnly.GetByEmail("dana@example.com"); err != nil || customer.Name != "Dana Scully" {
+		t.Errorf("Expected Dana Scully without the old key, got %+v (%v)", customer, err)
+	}
+}
+
+func TestEncryptedHistory(t *testing.T) {
+	// Arrange
+	keys := newKeyring(t, newDataKey(t))
+	eventSourced, _ := newEventSourcedRepository(t, DefaultSnapshotEvery)
+	repo := NewEncryptedRepository(eventSourced, keys)
+	created := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)
+	customer := &Customer{CustomerID: "customer-es", Name: "Dana Scully", Email: "dana@example.com", Status: "ACTIVE", CreatedAt: created, UpdatedAt: created}
+	_ = repo.Create(customer)
+	renamed := *customer
+	renamed.Name, renamed.UpdatedAt = "Dana Katherine Scully", created.Add(time.Hour)
+	_ = repo.Update(&renamed)
+
+	// Act
+	history, err := NewEncryptedHistory(eventSourced, keys).History("customer-es", time.Now())
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(history.Events) != 2 || history.Events[0].Data.Customer.Name != "Dana Scully" || history.Events[1].Data.Profile.Name != "Dana Katherine Scully" {
+		t.Errorf("Expected decrypted events, got %+v", history.Events)
+	}
+	if history.Customer.Email != "dana@example.com" {
+		t.Errorf("Expected the replayed customer decrypted, got %+v", history.Customer)
+	}
+}
+
+// capturingPublisher records the events it is asked to publish
+type capturingPublisher struct {
+	events []*outbox.Event
+}
+
+func (p *capturingPublisher) Publish(ctx context.Context, events []*outbox.Event) error {
+	p.events = append(p.events, events...)
+	return nil
+}
+
+func TestDecryptingPublisher(t *testing.T) {
+	// Arrange
+	keys := newKeyring(t, newDataKey(t))
+	name, _ := keys.Encrypt("name", "Jane Doe")
+	data, _ := json.Marshal(&Customer{CustomerID: "customer-pii", Name: name, Status: "ACTIVE"})
+	event := &outbox.Event{EventID: "event-1", Aggregate: outbox.AggregateCustomer, AggregateID: "customer-pii", Data: data}
+	product := &outbox.Event{EventID: "event-2", Aggregate: outbox.AggregateProduct, Data: json.RawMessage(`{"name":"enc:not-ours"}`)}
+	next := &capturingPublisher{}
+
+	// Act
+	err := NewDecryptingPublisher(next, keys).Publish(context.Background(), []*outbox.Event{event, product})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var published Customer
+	_ = json.Unmarshal(next.events[0].Data, &published)
+	if published.Name != "Jane Doe" {
+		t.Errorf("Expected the published customer decrypted, got %s", published.Name)
+	}
+	if string(event.Data) != string(data) {
+		t.Error("Expected the outbox event itself to stay encrypted")
+	}
+	if next.events[1] != product {
+		t.Error("Expected other events to be published untouched")
+	}
+}
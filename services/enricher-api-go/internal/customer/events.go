@@ -0,0 +1,353 @@
+package customer
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"enricher-api-go/internal/apperr"
+)
+
+// Customer event types
+const (
+	// EventCustomerCreated carries the customer as created
+	EventCustomerCreated = "CustomerCreated"
+	// EventCustomerUpdated carries the profile a customer was given
+	EventCustomerUpdated = "CustomerUpdated"
+	// EventCustomerStatusChanged carries a move through the status lifecycle
+	EventCustomerStatusChanged = "CustomerStatusChanged"
+	// EventCustomerExposureAdjusted carries a customer's new credit exposure
+	EventCustomerExposureAdjusted = "CustomerExposureAdjusted"
+	// EventCustomerDeleted marks a soft delete
+	EventCustomerDeleted = "CustomerDeleted"
+	// EventCustomerRestored marks a soft delete undone
+	EventCustomerRestored = "CustomerRestored"
+)
+
+var (
+	// ErrEventVersionConflict is returned when appending events that do not
+	// continue the customer's stream
+	ErrEventVersionConflict = apperr.New(apperr.ErrConflict, "customer event version conflict")
+	// ErrHistoryNotRecorded is returned for customer history when the
+	// repository does not record events
+	ErrHistoryNotRecorded = apperr.New(apperr.ErrUnavailable, "customer history is not recorded")
+)
+
+// Event is one change in the life of a customer. A customer's events are
+// numbered from 1 and replaying them in order rebuilds the customer.
+type Event struct {
+	// CustomerID is the customer the event belongs to
+	CustomerID string `json:"customerId"`
+	// Version is the position of the event in the customer's stream
+	Version int `json:"version"`
+	// Type is one of the Event* constants
+	Type string `json:"type"`
+	// OccurredAt is when the change was made
+	OccurredAt time.Time `json:"occurredAt"`
+	// Actor is the authenticated caller that made the change, if known
+	Actor string `json:"actor,omitempty"`
+	// Data holds the fields the event type sets
+	Data EventData `json:"data"`
+}
+
+// EventData holds the fields an event sets; each event type uses its own
+type EventData struct {
+	// Customer is the customer a CustomerCreated event creates
+	Customer *Customer `json:"customer,omitempty"`
+	// Profile is the profile a CustomerUpdated event sets
+	Profile *Profile `json:"profile,omitempty"`
+	// From and To are the statuses of a CustomerStatusChanged event
+	From string `json:"from,omitempty"`
+	To   string `json:"to,omitempty"`
+	// Exposure is the exposure a CustomerExposureAdjusted event sets
+	Exposure *float64 `json:"exposure,omitempty"`
+}
+
+// Profile is the part of a customer that updates other than status changes
+// and credit adjustments set
+type Profile struct {
+	Name        string   `json:"name"`
+	Email       string   `json:"email,omitempty"`
+	Phone       string   `json:"phone,omitempty"`
+	CreditLimit float64  `json:"creditLimit"`
+	Segments    []string `json:"segments,omitempty"`
+}
+
+// profileOf returns the profile of customer
+func profileOf(customer *Customer) Profile {
+	return Profile{
+		Name:        customer.Name,
+		Email:       customer.Email,
+		Phone:       customer.Phone,
+		CreditLimit: customer.CreditLimit,
+		Segments:    slices.Clone(customer.Segments),
+	}
+}
+
+// equal reports whether two profiles hold the same values
+func (p Profile) equal(other Profile) bool {
+	return p.Name == other.Name && p.Email == other.Email && p.Phone == other.Phone &&
+		p.CreditLimit == other.CreditLimit && slices.Equal(p.Segments, other.Segments)
+}
+
+// Apply returns the customer after the event, leaving state unchanged; state
+// is nil before a CustomerCreated event
+func (e *Event) Apply(state *Customer) (*Customer, error) {
+	if e.Type == EventCustomerCreated {
+		if e.Data.Customer == nil {
+			return nil, fmt.Errorf("event %d of customer %s has no customer", e.Version, e.CustomerID)
+		}
+		return cloneCustomer(e.Data.Customer), nil
+	}
+	if state == nil {
+		return nil, fmt.Errorf("event %d of customer %s precedes its creation", e.Version, e.CustomerID)
+	}
+
+	next := cloneCustomer(state)
+	switch e.Type {
+	case EventCustomerUpdated:
+		if e.Data.Profile == nil {
+			return nil, fmt.Errorf("event %d of customer %s has no profile", e.Version, e.CustomerID)
+		}
+		profile := e.Data.Profile
+		next.Name, next.Email, next.Phone = profile.Name, profile.Email, profile.Phone
+		next.CreditLimit, next.Segments = profile.CreditLimit, slices.Clone(profile.Segments)
+		next.UpdatedAt, next.UpdatedBy = e.OccurredAt, e.Actor
+	case EventCustomerStatusChanged:
+		next.Status = e.Data.To
+		next.UpdatedAt, next.UpdatedBy = e.OccurredAt, e.Actor
+	case EventCustomerExposureAdjusted:
+		if e.Data.Exposure == nil {
+			return nil, fmt.Errorf("event %d of customer %s has no exposure", e.Version, e.CustomerID)
+		}
+		next.CurrentExposure = *e.Data.Exposure
+		next.UpdatedAt, next.UpdatedBy = e.OccurredAt, e.Actor
+	case EventCustomerDeleted:
+		deletedAt := e.OccurredAt
+		next.DeletedAt = &deletedAt
+	case EventCustomerRestored:
+		next.DeletedAt = nil
+	default:
+		return nil, fmt.Errorf("event %d of customer %s has unknown type %q", e.Version, e.CustomerID, e.Type)
+	}
+	return next, nil
+}
+
+// Replay applies events in order to state, which is nil to replay a stream
+// from its start
+func Replay(state *Customer, events []Event) (*Customer, error) {
+	for i := range events {
+		next, err := events[i].Apply(state)
+		if err != nil {
+			return nil, err
+		}
+		state = next
+	}
+	return state, nil
+}
+
+// changeEvents returns the events that take a customer from before to
+// after, unnumbered; before is nil for a new customer. An update that
+// changes nothing but the update time is still recorded as CustomerUpdated.
+func changeEvents(before, after *Customer, now time.Time) []Event {
+	event := func(eventType string, occurredAt time.Time, data EventData) Event {
+		return Event{
+			CustomerID: after.CustomerID,
+			Type:       eventType,
+			OccurredAt: occurredAt,
+			Actor:      after.UpdatedBy,
+			Data:       data,
+		}
+	}
+
+	if before == nil {
+		created := event(EventCustomerCreated, after.CreatedAt, EventData{Customer: cloneCustomer(after)})
+		created.Actor = after.CreatedBy
+		return []Event{created}
+	}
+
+	var events []Event
+	if after.Status != before.Status {
+		events = append(events, event(EventCustomerStatusChanged, after.UpdatedAt, EventData{From: before.Status, To: after.Status}))
+	}
+	if after.CurrentExposure != before.CurrentExposure {
+		exposure := after.CurrentExposure
+		events = append(events, event(EventCustomerExposureAdjusted, after.UpdatedAt, EventData{Exposure: &exposure}))
+	}
+	profile := profileOf(after)
+	if !profile.equal(profileOf(before)) || (len(events) == 0 && !after.UpdatedAt.Equal(before.UpdatedAt)) {
+		events = append(events, event(EventCustomerUpdated, after.UpdatedAt, EventData{Profile: &profile}))
+	}
+
+	switch {
+	case after.IsDeleted() && !before.IsDeleted():
+		events = append(events, event(EventCustomerDeleted, *after.DeletedAt, EventData{}))
+	case !after.IsDeleted() && before.IsDeleted():
+		events = append(events, event(EventCustomerRestored, now, EventData{}))
+	}
+	return events
+}
+
+// redactPersonalData returns event with the name it carries replaced by
+// name and its email and phone cleared
+func redactPersonalData(event Event, name string) Event {
+	if event.Data.Customer != nil {
+		event.Data.Customer = redactCustomer(event.Data.Customer, name)
+	}
+	if event.Data.Profile != nil {
+		redacted := *event.Data.Profile
+		redacted.Name, redacted.Email, redacted.Phone = name, "", ""
+		event.Data.Profile = &redacted
+	}
+	return event
+}
+
+// redactCustomer returns a copy of customer with its name replaced by name
+// and its email and phone cleared
+func redactCustomer(customer *Customer, name string) *Customer {
+	redacted := cloneCustomer(customer)
+	redacted.Name, redacted.Email, redacted.Phone = name, "", ""
+	return redacted
+}
+
+// cloneCustomer returns a copy of customer that shares no slices with it
+func cloneCustomer(customer *Customer) *Customer {
+	clone := *customer
+	clone.Segments = slices.Clone(customer.Segments)
+	if customer.DeletedAt != nil {
+		deletedAt := *customer.DeletedAt
+		clone.DeletedAt = &deletedAt
+	}
+	return &clone
+}
+
+// Snapshot is the state of a customer after a version of its stream, so
+// replays can start from it rather than from the first event
+type Snapshot struct {
+	Customer *Customer
+	Version  int
+	// TakenAt is when the event of Version occurred
+	TakenAt time.Time
+}
+
+// EventStore persists the event streams of customers
+type EventStore interface {
+	// Append adds events to the end of the customer's stream; the first
+	// must be numbered one past the stream's last version
+	Append(customerID string, events []Event) error
+	// Events returns the customer's events after afterVersion, in order
+	Events(customerID string, afterVersion int) ([]Event, error)
+	// Version returns the version of the customer's last event, or 0
+	Version(customerID string) (int, error)
+	// SaveSnapshot stores a snapshot of a customer
+	SaveSnapshot(snapshot Snapshot) error
+	// LatestSnapshot returns the customer's latest snapshot taken at or
+	// before at, or nil if there is none
+	LatestSnapshot(customerID string, at time.Time) (*Snapshot, error)
+	// Redact replaces the name in the customer's events and snapshots with
+	// name and clears their emails and phones
+	Redact(customerID, name string) error
+}
+
+// InMemoryEventStore implements EventStore in memory
+type InMemoryEventStore struct {
+	streams map[string][]Event
+	// snapshots of each customer, oldest first
+	snapshots map[string][]Snapshot
+	mutex     sync.RWMutex
+}
+
+// NewInMemoryEventStore creates an empty in-memory event store
+func NewInMemoryEventStore() *InMemoryEventStore {
+	return &InMemoryEventStore{
+		streams:   make(map[string][]Event),
+		snapshots: make(map[string][]Snapshot),
+	}
+}
+
+// Reset removes every event and snapshot
+func (s *InMemoryEventStore) Reset() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.streams = make(map[string][]Event)
+	s.snapshots = make(map[string][]Snapshot)
+}
+
+// Append adds events to the end of the customer's stream
+func (s *InMemoryEventStore) Append(customerID string, events []Event) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stream := s.streams[customerID]
+	for i, event := range events {
+		if event.CustomerID != customerID || event.Version != len(stream)+i+1 {
+			return ErrEventVersionConflict
+		}
+	}
+	s.streams[customerID] = append(stream, events...)
+	return nil
+}
+
+// Events returns the customer's events after afterVersion
+func (s *InMemoryEventStore) Events(customerID string, afterVersion int) ([]Event, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	stream := s.streams[customerID]
+	if afterVersion >= len(stream) {
+		return []Event{}, nil
+	}
+	return slices.Clone(stream[max(afterVersion, 0):]), nil
+}
+
+// Version returns the version of the customer's last event
+func (s *InMemoryEventStore) Version(customerID string) (int, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return len(s.streams[customerID]), nil
+}
+
+// SaveSnapshot stores a snapshot of a customer
+func (s *InMemoryEventStore) SaveSnapshot(snapshot Snapshot) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	snapshot.Customer = cloneCustomer(snapshot.Customer)
+	s.snapshots[snapshot.Customer.CustomerID] = append(s.snapshots[snapshot.Customer.CustomerID], snapshot)
+	return nil
+}
+
+// Redact replaces the personal data in the customer's events and snapshots
+func (s *InMemoryEventStore) Redact(customerID, name string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for i, event := range s.streams[customerID] {
+		s.streams[customerID][i] = redactPersonalData(event, name)
+	}
+	for i, snapshot := range s.snapshots[customerID] {
+		s.snapshots[customerID][i].Customer = redactCustomer(snapshot.Customer, name)
+	}
+	return nil
+}
+
+// LatestSnapshot returns the customer's latest snapshot taken at or before at
+func (s *InMemoryEventStore) LatestSnapshot(customerID string, at time.Time) (*Snapshot, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	snapshots := s.snapshots[customerID]
+	// Snapshots are appended in version order, so the first one taken after
+	// at follows the one wanted
+	i := sort.Search(len(snapshots), func(i int) bool { return snapshots[i].TakenAt.After(at) })
+	if i == 0 {
+		return nil, nil
+	}
+	snapshot := snapshots[i-1]
+	snapshot.Customer = cloneCustomer(snapshot.Customer)
+	return &snapshot, nil
+}
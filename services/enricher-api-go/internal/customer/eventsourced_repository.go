@@ -0,0 +1,264 @@
+package customer
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultSnapshotEvery is how many events of a customer an
+// EventSourcedRepository records between snapshots by default
+const DefaultSnapshotEvery = 20
+
+// History is the recorded life of a customer up to an instant
+type History struct {
+	// Events are the customer's events, oldest first
+	Events []Event
+	// Customer is the customer as the events leave it
+	Customer *Customer
+}
+
+// HistorySource replays the events of customers
+type HistorySource interface {
+	// History returns the events of a customer that occurred at or before
+	// until and the customer they leave, or ErrCustomerNotFound if the
+	// customer did not exist yet
+	History(customerID string, until time.Time) (*History, error)
+}
+
+// HistoryRedactor erases personal data from the recorded history of customers
+type HistoryRedactor interface {
+	// RedactHistory replaces the name in the customer's recorded events and
+	// snapshots with name and clears their emails and phones, so replaying
+	// them no longer yields its personal data
+	RedactHistory(customerID, name string) error
+}
+
+// EventSourcedRepository records every change of a customer as an event in
+// an EventStore. Its projection, an InMemoryRepository, checks and applies
+// each write and answers reads; the events derived from the change are then
+// appended, with a snapshot every snapshotEvery events, so the history of a
+// customer can be replayed to any instant. Addresses are not recorded.
+type EventSourcedRepository struct {
+	*InMemoryRepository
+	store         EventStore
+	snapshotEvery int
+	// mutex keeps each write and the events recorded for it together
+	mutex sync.Mutex
+}
+
+// NewEventSourcedRepository records the changes of the customers in
+// projection to store, starting with the creation of those it already holds.
+// snapshotEvery below 1 selects DefaultSnapshotEvery.
+func NewEventSourcedRepository(projection *InMemoryRepository, store EventStore, snapshotEvery int) (*EventSourcedRepository, error) {
+	if snapshotEvery < 1 {
+		snapshotEvery = DefaultSnapshotEvery
+	}
+	r := &EventSourcedRepository{InMemoryRepository: projection, store: store, snapshotEvery: snapshotEvery}
+	if err := r.recordExisting(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// recordExisting records the creation of the customers the projection holds
+// without a stream of their own
+func (r *EventSourcedRepository) recordExisting() error {
+	customers, err := r.InMemoryRepository.Find(CustomerFilter{IncludeDeleted: true})
+	if err != nil {
+		return err
+	}
+	for _, customer := range customers {
+		if err := r.record(customer.CustomerID, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Reset returns the projection to its seed customers and records their
+// creation afresh; the store's events are removed if it can reset
+func (r *EventSourcedRepository) Reset() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.InMemoryRepository.Reset()
+	if store, ok := r.store.(interface{ Reset() }); ok {
+		store.Reset()
+	}
+	// The projection was just seeded, so only a failing store can fail here
+	_ = r.recordExisting()
+}
+
+// Create adds a new customer, recording CustomerCreated
+func (r *EventSourcedRepository) Create(customer *Customer) error {
+	return r.write([]string{customer.CustomerID}, func() error {
+		return r.InMemoryRepository.Create(customer)
+	})
+}
+
+// Update modifies an existing customer, recording what changed
+func (r *EventSourcedRepository) Update(customer *Customer) error {
+	return r.write([]string{customer.CustomerID}, func() error {
+		return r.InMemoryRepository.Update(customer)
+	})
+}
+
+// WriteAll applies every write or none of them, recording what changed
+func (r *EventSourcedRepository) WriteAll(writes []Write) error {
+	customerIDs := make([]string, len(writes))
+	for i, write := range writes {
+		customerIDs[i] = write.Customer.CustomerID
+	}
+	return r.write(customerIDs, func() error {
+		return r.InMemoryRepository.WriteAll(writes)
+	})
+}
+
+// Delete soft-deletes a customer, recording CustomerDeleted
+func (r *EventSourcedRepository) Delete(customerID string) error {
+	return r.write([]string{customerID}, func() error {
+		return r.InMemoryRepository.Delete(customerID)
+	})
+}
+
+// Restore undoes a soft delete, recording CustomerRestored
+func (r *EventSourcedRepository) Restore(customerID string) error {
+	return r.write([]string{customerID}, func() error {
+		return r.InMemoryRepository.Restore(customerID)
+	})
+}
+
+// AdjustExposure adds change.Delta to a live customer's exposure, recording
+// CustomerExposureAdjusted
+func (r *EventSourcedRepository) AdjustExposure(customerID string, change ExposureChange) (*Customer, error) {
+	var adjusted *Customer
+	err := r.write([]string{customerID}, func() error {
+		var err error
+		adjusted, err = r.InMemoryRepository.AdjustExposure(customerID, change)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return adjusted, nil
+}
+
+// write applies a write of the projection touching the given customers and
+// records the events of each change
+func (r *EventSourcedRepository) write(customerIDs []string, apply func() error) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	before := make(map[string]*Customer, len(customerIDs))
+	for _, customerID := range customerIDs {
+		if _, seen := before[customerID]; seen {
+			continue
+		}
+		// Unknown customers are recorded as nil, so their write is a creation
+		customer, _ := r.InMemoryRepository.GetByIDIncludingDeleted(customerID)
+		before[customerID] = customer
+	}
+
+	if err := apply(); err != nil {
+		return err
+	}
+
+	recorded := make(map[string]bool, len(customerIDs))
+	for _, customerID := range customerIDs {
+		if recorded[customerID] {
+			continue
+		}
+		recorded[customerID] = true
+		if err := r.record(customerID, before[customerID]); err != nil {
+			return fmt.Errorf("failed to record events of customer %s: %w", customerID, err)
+		}
+	}
+	return nil
+}
+
+// record appends the events taking the customer from before to its current
+// state, taking a snapshot when the stream passes a multiple of
+// snapshotEvery; callers hold the mutex or are constructing r
+func (r *EventSourcedRepository) record(customerID string, before *Customer) error {
+	after, err := r.InMemoryRepository.GetByIDIncludingDeleted(customerID)
+	if err != nil {
+		return err
+	}
+	events := changeEvents(before, after, time.Now().UTC())
+	if len(events) == 0 {
+		return nil
+	}
+
+	version, err := r.store.Version(customerID)
+	if err != nil {
+		return err
+	}
+	if before == nil && version > 0 {
+		// The store already holds the customer's stream
+		return nil
+	}
+	for i := range events {
+		events[i].Version = version + i + 1
+	}
+	if err := r.store.Append(customerID, events); err != nil {
+		return err
+	}
+
+	last := events[len(events)-1]
+	if last.Version/r.snapshotEvery > version/r.snapshotEvery {
+		return r.store.SaveSnapshot(Snapshot{Customer: after, Version: last.Version, TakenAt: last.OccurredAt})
+	}
+	return nil
+}
+
+// History replays the customer's events up to until, starting from the
+// latest snapshot taken by then
+func (r *EventSourcedRepository) History(customerID string, until time.Time) (*History, error) {
+	all, err := r.store.Events(customerID, 0)
+	if err != nil {
+		return nil, err
+	}
+	events := occurredBy(all, until)
+	if len(events) == 0 {
+		return nil, ErrCustomerNotFound
+	}
+
+	var state *Customer
+	var after int
+	snapshot, err := r.store.LatestSnapshot(customerID, until)
+	if err != nil {
+		return nil, err
+	}
+	if snapshot != nil {
+		state, after = snapshot.Customer, snapshot.Version
+	}
+	customer, err := Replay(state, occurredBy(all[after:], until))
+	if err != nil {
+		return nil, err
+	}
+	return &History{Events: events, Customer: customer}, nil
+}
+
+// RedactHistory replaces the personal data in the customer's events and
+// snapshots. The projection is left as it is; erasing the customer itself is
+// an update of its own.
+func (r *EventSourcedRepository) RedactHistory(customerID, name string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if err := r.store.Redact(customerID, name); err != nil {
+		return fmt.Errorf("failed to redact events of customer %s: %w", customerID, err)
+	}
+	return nil
+}
+
+// occurredBy returns the leading events that occurred at or before until
+func occurredBy(events []Event, until time.Time) []Event {
+	for i, event := range events {
+		if event.OccurredAt.After(until) {
+			return events[:i]
+		}
+	}
+	return events
+}
@@ -0,0 +1,166 @@
+package customer
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// newEventSourcedRepository returns an event-sourced repository over the
+// sample customers, snapshotting every snapshotEvery events
+func newEventSourcedRepository(t *testing.T, snapshotEvery int) (*EventSourcedRepository, *InMemoryEventStore) {
+	t.Helper()
+	store := NewInMemoryEventStore()
+	repo, err := NewEventSourcedRepository(NewInMemoryRepository(), store, snapshotEvery)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return repo, store
+}
+
+func TestEventSourcedRepository_Conformance(t *testing.T) {
+	testRepositoryConformance(t, func(t *testing.T) Repository {
+		repo, _ := newEventSourcedRepository(t, DefaultSnapshotEvery)
+		return repo
+	})
+}
+
+func TestEventSourcedRepository_RecordsAndReplays(t *testing.T) {
+	// Arrange
+	repo, store := newEventSourcedRepository(t, 3)
+	created := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)
+	customer := &Customer{CustomerID: "customer-es", Name: "Dana Scully", Status: "ACTIVE", CreditLimit: 1000, CreatedAt: created, UpdatedAt: created}
+	suspended := *customer
+	suspended.Status, suspended.UpdatedAt, suspended.UpdatedBy = "SUSPENDED", created.Add(time.Hour), "ops"
+	renamed := suspended
+	renamed.Name, renamed.Segments, renamed.UpdatedAt = "Dana Katherine Scully", []string{"vip"}, created.Add(2*time.Hour)
+
+	// Act
+	steps := []func() error{
+		func() error { return repo.Create(customer) },
+		func() error { return repo.Update(&suspended) },
+		func() error {
+			_, err := repo.AdjustExposure("customer-es", ExposureChange{Delta: 250, UpdatedAt: created.Add(90 * time.Minute)})
+			return err
+		},
+		func() error { return repo.Update(&renamed) },
+		func() error { return repo.Delete("customer-es") },
+	}
+	for i, step := range steps {
+		if err := step(); err != nil {
+			t.Fatalf("Expected step %d to succeed, got %v", i+1, err)
+		}
+	}
+
+	// Assert
+	events, _ := store.Events("customer-es", 0)
+	var types []string
+	for _, event := range events {
+		types = append(types, event.Type)
+	}
+	expected := []string{EventCustomerCreated, EventCustomerStatusChanged, EventCustomerExposureAdjusted, EventCustomerUpdated, EventCustomerDeleted}
+	if !reflect.DeepEqual(types, expected) {
+		t.Errorf("Expected events %v, got %v", expected, types)
+	}
+
+	stored, _ := repo.GetByIDIncludingDeleted("customer-es")
+	history, err := repo.History("customer-es", time.Now())
+	if err != nil {
+		t.Fatalf("Expected history, got %v", err)
+	}
+	if !reflect.DeepEqual(history.Customer, stored) {
+		t.Errorf("Expected replay to match the stored customer\n%+v, got\n%+v", stored, history.Customer)
+	}
+
+	if snapshot, _ := store.LatestSnapshot("customer-es", time.Now()); snapshot == nil || snapshot.Version != 3 {
+		t.Errorf("Expected a snapshot after the third event, got %+v", snapshot)
+	}
+}
+
+func TestEventSourcedRepository_HistoryUntil(t *testing.T) {
+	// Arrange
+	repo, _ := newEventSourcedRepository(t, 2)
+	created := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)
+	customer := &Customer{CustomerID: "customer-es", Name: "Fox Mulder", Status: "ACTIVE", CreatedAt: created, UpdatedAt: created}
+	if err := repo.Create(customer); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for i, status := range []string{"SUSPENDED", "ACTIVE", "CLOSED"} {
+		next, _ := repo.GetByID("customer-es")
+		next.Status, next.UpdatedAt = status, created.Add(time.Duration(i+1)*24*time.Hour)
+		if err := repo.Update(next); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	tests := []struct {
+		name           string
+		until          time.Time
+		expectedStatus string
+		expectedEvents int
+	}{
+		{name: "at creation", until: created, expectedStatus: "ACTIVE", expectedEvents: 1},
+		{name: "while suspended", until: created.Add(36 * time.Hour), expectedStatus: "SUSPENDED", expectedEvents: 2},
+		{name: "after a snapshot", until: created.Add(60 * time.Hour), expectedStatus: "ACTIVE", expectedEvents: 3},
+		{name: "now", until: time.Now(), expectedStatus: "CLOSED", expectedEvents: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			history, err := repo.History("customer-es", tt.until)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if history.Customer.Status != tt.expectedStatus || len(history.Events) != tt.expectedEvents {
+				t.Errorf("Expected %s after %d events, got %s after %d", tt.expectedStatus, tt.expectedEvents, history.Customer.Status, len(history.Events))
+			}
+		})
+	}
+
+	// Before its creation the customer has no history
+	if _, err := repo.History("customer-es", created.Add(-time.Second)); !errors.Is(err, ErrCustomerNotFound) {
+		t.Errorf("Expected ErrCustomerNotFound before creation, got %v", err)
+	}
+}
+
+func TestEventSourcedRepository_Reset(t *testing.T) {
+	// Arrange
+	repo, store := newEventSourcedRepository(t, DefaultSnapshotEvery)
+	if err := repo.Delete("customer-456"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Act
+	repo.Reset()
+
+	// Assert
+	events, _ := store.Events("customer-456", 0)
+	if len(events) != 1 || events[0].Type != EventCustomerCreated {
+		t.Errorf("Expected only the seeded creation after reset, got %+v", events)
+	}
+	if _, err := repo.GetByID("customer-456"); err != nil {
+		t.Errorf("Expected customer-456 to be live again, got %v", err)
+	}
+}
+
+func TestInMemoryEventStore_Append_VersionConflict(t *testing.T) {
+	// Arrange
+	store := NewInMemoryEventStore()
+	first := Event{CustomerID: "customer-1", Version: 1, Type: EventCustomerCreated}
+
+	// Act
+	err := store.Append("customer-1", []Event{first})
+	conflictErr := store.Append("customer-1", []Event{first})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !errors.Is(conflictErr, ErrEventVersionConflict) {
+		t.Errorf("Expected ErrEventVersionConflict appending version 1 twice, got %v", conflictErr)
+	}
+}
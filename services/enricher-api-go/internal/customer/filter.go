@@ -0,0 +1,100 @@
+package customer
+
+import (
+	"cmp"
+	"strings"
+	"time"
+
+	"enricher-api-go/internal/listing"
+)
+
+// SortFields are the fields customers can be sorted on
+var SortFields = []string{"customerId", "name", "email", "status", "creditLimit", "createdAt", "updatedAt"}
+
+// CustomerFilter describes the criteria for listing customers.
+//
+// Zero values mean "no constraint", so an empty filter matches every
+// customer. Limit and Offset page through the matches, which are ordered by
+// Sort and then by CustomerID so that consecutive pages are stable.
+//
+// Example usage:
+//
+//	filter := CustomerFilter{
+//		Segment: "vip",
+//		Status:  StatusActive,
+//		Sort:    listing.Sort{{Field: "creditLimit", Descending: true}},
+//		Limit:   20,
+//	}
+type CustomerFilter struct {
+	// Segment matches customers tagged with this segment
+	Segment string
+	// Status matches customers in this lifecycle status
+	Status string
+	// ComputedSegment matches customers in this computed segment, such as
+	// SegmentVIP; the service resolves it into the range criteria below
+	// and repositories ignore it
+	ComputedSegment string
+	// MinCreditLimit matches customers with at least this credit limit
+	MinCreditLimit float64
+	// CreatedAfter matches customers created after it
+	CreatedAfter time.Time
+	// UpdatedUntil matches customers last changed at or before it
+	UpdatedUntil time.Time
+	// Sort orders the matches by any of SortFields before CustomerID
+	Sort listing.Sort
+	// Limit caps the number of customers returned; 0 means no limit
+	Limit int
+	// Offset skips the first matches
+	Offset int
+	// IncludeDeleted also matches soft-deleted customers
+	IncludeDeleted bool
+}
+
+// Matches reports whether a customer satisfies the filter's criteria.
+// Limit and Offset are not considered.
+func (f CustomerFilter) Matches(customer *Customer) bool {
+	if customer.IsDeleted() && !f.IncludeDeleted {
+		return false
+	}
+	if f.Status != "" && customer.Status != f.Status {
+		return false
+	}
+	if f.MinCreditLimit > 0 && customer.CreditLimit < f.MinCreditLimit {
+		return false
+	}
+	if !f.CreatedAfter.IsZero() && !customer.CreatedAt.After(f.CreatedAfter) {
+		return false
+	}
+	if !f.UpdatedUntil.IsZero() && customer.UpdatedAt.After(f.UpdatedUntil) {
+		return false
+	}
+	return f.Segment == "" || customer.HasSegment(f.Segment)
+}
+
+// Compare orders a before b, returning a negative number, by the filter's
+// Sort and then by CustomerID
+func (f CustomerFilter) Compare(a, b *Customer) int {
+	return cmp.Or(listing.Compare(f.Sort, a, b, compareField), strings.Compare(a.CustomerID, b.CustomerID))
+}
+
+// compareField compares a and b by one of SortFields
+func compareField(field string, a, b *Customer) int {
+	switch field {
+	case "customerId":
+		return strings.Compare(a.CustomerID, b.CustomerID)
+	case "name":
+		return strings.Compare(a.Name, b.Name)
+	case "email":
+		return strings.Compare(a.Email, b.Email)
+	case "status":
+		return strings.Compare(a.Status, b.Status)
+	case "creditLimit":
+		return cmp.Compare(a.CreditLimit, b.CreditLimit)
+	case "createdAt":
+		return a.CreatedAt.Compare(b.CreatedAt)
+	case "updatedAt":
+		return a.UpdatedAt.Compare(b.UpdatedAt)
+	default:
+		return 0
+	}
+}
@@ -6,7 +6,22 @@
 package customer
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"enricher-api-go/internal/export"
+	"enricher-api-go/internal/fieldset"
+	"enricher-api-go/internal/httpcache"
+	"enricher-api-go/internal/listing"
+	"enricher-api-go/internal/logging"
+	"enricher-api-go/internal/pagination"
+	"enricher-api-go/internal/problem"
+	"enricher-api-go/internal/servertiming"
 
 	"github.com/labstack/echo/v4"
 )
@@ -59,6 +74,13 @@ func NewHandler(service Service) *Handler {
 // Returns:
 //   - error: error if the operation fails
 //
+// Soft-deleted customers are reported as not found unless the query sets
+// includeDeleted=true, which is restricted to admins. An optional fields
+// parameter, e.g. fields=customerId,status, trims the response to those
+// fields. An optional asOf timestamp (RFC 3339) returns the customer as it
+// was at that instant, replayed from its recorded events, so an enrichment
+// can be re-run against the status it originally saw.
+//
 // Example request:
 //
 //	GET /v1/customers/customer-12345
@@ -72,26 +94,217 @@ func NewHandler(service Service) *Handler {
 //	}
 //
 // Error responses:
-//   - 404: Customer not found
+//   - 400: Invalid includeDeleted value, malformed or future asOf, or unknown field
+//   - 404: Customer not found, or not live at asOf
 //   - 500: Internal server error
+//   - 501: asOf given but customer changes are not recorded as events
 func (h *Handler) GetCustomer(c echo.Context) error {
 	customerID := c.Param("id")
 
-	customer, err := h.service.GetCustomer(customerID)
+	fields, err := fieldset.FromQuery(c.QueryParams(), CustomerResponse{})
+	if err != nil {
+		return problem.Write(c, http.StatusBadRequest, err.Error())
+	}
+
+	withDeleted, err := includeDeleted(c)
+	if err != nil {
+		return problem.Write(c, http.StatusBadRequest, err.Error())
+	}
+
+	asOf, err := parseTimeParam(c, "asOf")
 	if err != nil {
-		if err == ErrCustomerNotFound || err.Error() == "failed to get customer: customer not found" {
-			return c.JSON(http.StatusNotFound, map[string]string{
-				"error": "Customer not found",
-			})
+		return problem.Write(c, http.StatusBadRequest, err.Error())
+	}
+
+	get := h.service.GetCustomer
+	switch {
+	case asOf != nil && withDeleted:
+		return problem.Write(c, http.StatusBadRequest, "asOf cannot be combined with includeDeleted")
+	case asOf != nil:
+		get = func(ctx context.Context, customerID string) (*Customer, error) {
+			return h.service.GetCustomerAsOf(ctx, customerID, *asOf)
 		}
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": err.Error(),
-		})
+	case withDeleted:
+		get = h.service.GetCustomerIncludingDeleted
+	}
+
+	stop := servertiming.Start(c, "service")
+	customer, err := get(c.Request().Context(), customerID)
+	stop()
+	if asOf != nil && errors.Is(err, ErrHistoryNotRecorded) {
+		// Customer rows are not versioned: only a replay of events can go back
+		return problem.Write(c, http.StatusNotImplemented, "asOf requires event sourcing, which only the memory backend supports")
+	}
+	if err != nil {
+		return historyError(c, err)
+	}
+
+	body, err := fields.Apply(customer.ToResponse())
+	if err != nil {
+		return problem.Error(c, err)
+	}
+
+	httpcache.SetLastModified(c, customer.UpdatedAt)
+	return c.JSON(http.StatusOK, body)
+}
+
+// GetCustomerByEmail handles GET /v1/customers/by-email/:email requests.
+//
+// The email is matched case-insensitively against live customers and may be
+// percent-encoded.
+//
+// Example request:
+//
+//	GET /v1/customers/by-email/jane.doe@example.com
+//
+// Example response:
+//
+//	{
+//		"customerId": "customer-456",
+//		"name": "Jane Doe",
+//		"status": "ACTIVE",
+//		"email": "jane.doe@example.com"
+//	}
+//
+// Error responses:
+//   - 400: Malformed email
+//   - 404: Customer not found
+//   - 500: Internal server error
+func (h *Handler) GetCustomerByEmail(c echo.Context) error {
+	email, err := url.PathUnescape(c.Param("email"))
+	if err != nil {
+		return problem.Write(c, http.StatusBadRequest, "Invalid email encoding")
+	}
+
+	stop := servertiming.Start(c, "service")
+	customer, err := h.service.GetCustomerByEmail(c.Request().Context(), email)
+	stop()
+	if err != nil {
+		return customerError(c, err)
 	}
 
 	return c.JSON(http.StatusOK, customer.ToResponse())
 }
 
+// BatchGetCustomers handles POST /v1/customers/batch requests.
+//
+// This method resolves up to the configured maximum of customer IDs in one
+// call so bulk jobs avoid a request per order. IDs that cannot be resolved
+// are reported individually under "errors" instead of failing the batch.
+//
+// Args:
+//   - c: Echo context containing the HTTP request and response
+//
+// Returns:
+//   - error: error if the operation fails
+//
+// Example request:
+//
+//	POST /v1/customers/batch
+//	Content-Type: application/json
+//
+//	{
+//		"customerIds": ["customer-456", "customer-missing"]
+//	}
+//
+// Example response:
+//
+//	{
+//		"customers": [{"customerId": "customer-456", "name": "Jane Doe", "status": "ACTIVE"}],
+//		"errors": [{"customerId": "customer-missing", "error": "customer not found"}],
+//		"count": 1
+//	}
+//
+// Error responses:
+//   - 400: Invalid request body, empty batch or too many IDs
+//   - 500: Internal server error
+func (h *Handler) BatchGetCustomers(c echo.Context) error {
+	var req BatchRequest
+	if err := servertiming.Measure(c, "bind", func() error { return c.Bind(&req) }); err != nil {
+		return problem.BindError(c, err)
+	}
+
+	stop := servertiming.Start(c, "service")
+	result, err := h.service.GetCustomers(c.Request().Context(), req.CustomerIDs)
+	stop()
+	if err != nil {
+		return problem.Error(c, err)
+	}
+
+	responses := make([]CustomerResponse, len(result.Customers))
+	for i, customer := range result.Customers {
+		responses[i] = customer.ToResponse()
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"customers": responses,
+		"errors":    result.Errors,
+		"count":     len(responses),
+	})
+}
+
+// BulkWriteCustomers handles POST /v1/customers/bulk requests.
+//
+// This method creates each item without a customerId and updates each item
+// with one, so imports avoid a request per customer. Items succeed or fail
+// independently and are reported in request order, unless atomic is set:
+// then one failing item leaves every customer unchanged, the other items
+// are reported as skipped and the response is 422.
+//
+// Args:
+//   - c: Echo context containing the HTTP request and response
+//
+// Returns:
+//   - error: error if the operation fails
+//
+// Example request:
+//
+//	POST /v1/customers/bulk
+//	Content-Type: application/json
+//
+//	{
+//		"items": [
+//			{"customer": {"name": "Dana White", "status": "ACTIVE"}},
+//			{"customerId": "customer-missing", "customer": {"name": "Ghost", "status": "ACTIVE"}}
+//		]
+//	}
+//
+// Example response:
+//
+//	{
+//		"results": [
+//			{"index": 0, "status": "created", "customerId": "customer-7f3a", "customer": {"customerId": "customer-7f3a", "name": "Dana White", "status": "ACTIVE"}},
+//			{"index": 1, "status": "failed", "customerId": "customer-missing", "error": "customer not found: customer not found"}
+//		],
+//		"succeeded": 1,
+//		"failed": 1,
+//		"atomic": false
+//	}
+//
+// Error responses:
+//   - 400: Invalid request body, no items or too many items
+//   - 422: An item of an atomic request failed, so none was stored
+//   - 500: Internal server error
+func (h *Handler) BulkWriteCustomers(c echo.Context) error {
+	var req BulkRequest
+	if err := servertiming.Measure(c, "bind", func() error { return c.Bind(&req) }); err != nil {
+		return problem.BindError(c, err)
+	}
+
+	stop := servertiming.Start(c, "service")
+	result, err := h.service.BulkWriteCustomers(c.Request().Context(), req)
+	stop()
+	if err != nil {
+		return problem.Error(c, err)
+	}
+
+	status := http.StatusOK
+	if result.Atomic && result.Failed() > 0 {
+		status = http.StatusUnprocessableEntity
+	}
+	return c.JSON(status, result.ToResponse(req))
+}
+
 // CreateCustomer handles POST /v1/customers requests.
 //
 // This method creates a new customer with the provided information and returns
@@ -124,20 +337,24 @@ func (h *Handler) GetCustomer(c echo.Context) error {
 //
 // Error responses:
 //   - 400: Invalid request body or validation error
+//   - 409: Email already in use by another customer
 //   - 500: Internal server error
 func (h *Handler) CreateCustomer(c echo.Context) error {
 	var req CustomerRequest
-	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid request body",
-		})
+	if err := servertiming.Measure(c, "bind", func() error { return c.Bind(&req) }); err != nil {
+		return problem.BindError(c, err)
 	}
 
-	customer, err := h.service.CreateCustomer(req)
+	stop := servertiming.Start(c, "service")
+	customer, err := h.service.CreateCustomer(c.Request().Context(), req)
+	stop()
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": err.Error(),
-		})
+		if errors.Is(err, ErrInvalidTransition) {
+			// The status of a new customer is part of the request, not a
+			// change to a stored customer
+			return problem.Write(c, http.StatusBadRequest, err.Error())
+		}
+		return customerError(c, err)
 	}
 
 	return c.JSON(http.StatusCreated, customer.ToResponse())
@@ -162,7 +379,7 @@ func (h *Handler) CreateCustomer(c echo.Context) error {
 //
 //	{
 //		"name": "John Doe Updated",
-//		"status": "INACTIVE"
+//		"status": "SUSPENDED"
 //	}
 //
 // Example response:
@@ -170,33 +387,78 @@ func (h *Handler) CreateCustomer(c echo.Context) error {
 //	{
 //		"customerId": "customer-12345",
 //		"name": "John Doe Updated",
-//		"status": "INACTIVE"
+//		"status": "SUSPENDED"
 //	}
 //
 // Error responses:
 //   - 400: Invalid request body or validation error
 //   - 404: Customer not found
+//   - 409: Email already in use, or a status change the lifecycle does not allow
 //   - 500: Internal server error
 func (h *Handler) UpdateCustomer(c echo.Context) error {
 	customerID := c.Param("id")
 
 	var req CustomerRequest
-	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid request body",
-		})
+	if err := servertiming.Measure(c, "bind", func() error { return c.Bind(&req) }); err != nil {
+		return problem.BindError(c, err)
 	}
 
-	customer, err := h.service.UpdateCustomer(customerID, req)
+	stop := servertiming.Start(c, "service")
+	customer, err := h.service.UpdateCustomer(c.Request().Context(), customerID, req)
+	stop()
 	if err != nil {
-		if err == ErrCustomerNotFound {
-			return c.JSON(http.StatusNotFound, map[string]string{
-				"error": "Customer not found",
-			})
-		}
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": err.Error(),
-		})
+		return customerError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, customer.ToResponse())
+}
+
+// PatchCustomer handles PATCH /v1/customers/:id requests.
+//
+// This method updates only the fields present in the body, accepting
+// application/json or application/merge-patch+json. Omitted fields keep
+// their current values.
+//
+// Args:
+//   - c: Echo context containing the HTTP request and response
+//
+// Returns:
+//   - error: error if the operation fails
+//
+// Example request:
+//
+//	PATCH /v1/customers/customer-12345
+//	Content-Type: application/merge-patch+json
+//
+//	{
+//		"status": "SUSPENDED"
+//	}
+//
+// Example response:
+//
+//	{
+//		"customerId": "customer-12345",
+//		"name": "John Doe",
+//		"status": "SUSPENDED"
+//	}
+//
+// Error responses:
+//   - 400: Invalid request body or validation error
+//   - 404: Customer not found
+//   - 409: Email already in use, or a status change the lifecycle does not allow
+func (h *Handler) PatchCustomer(c echo.Context) error {
+	customerID := c.Param("id")
+
+	var patch CustomerPatch
+	if err := servertiming.Measure(c, "bind", func() error { return c.Bind(&patch) }); err != nil {
+		return problem.BindError(c, err)
+	}
+
+	stop := servertiming.Start(c, "service")
+	customer, err := h.service.PatchCustomer(c.Request().Context(), customerID, patch)
+	stop()
+	if err != nil {
+		return customerError(c, err)
 	}
 
 	return c.JSON(http.StatusOK, customer.ToResponse())
@@ -204,8 +466,8 @@ func (h *Handler) UpdateCustomer(c echo.Context) error {
 
 // DeleteCustomer handles DELETE /v1/customers/:id requests.
 //
-// This method removes a customer from the system and returns a success
-// response. It handles various error scenarios including customer not found.
+// This method soft-deletes a customer: it disappears from regular reads but
+// can be brought back with POST /v1/customers/:id/restore.
 //
 // Args:
 //   - c: Echo context containing the HTTP request and response
@@ -229,28 +491,115 @@ func (h *Handler) UpdateCustomer(c echo.Context) error {
 func (h *Handler) DeleteCustomer(c echo.Context) error {
 	customerID := c.Param("id")
 
-	err := h.service.DeleteCustomer(customerID)
+	stop := servertiming.Start(c, "service")
+	err := h.service.DeleteCustomer(c.Request().Context(), customerID)
+	stop()
 	if err != nil {
-		if err == ErrCustomerNotFound {
-			return c.JSON(http.StatusNotFound, map[string]string{
-				"error": "Customer not found",
-			})
-		}
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": err.Error(),
-		})
+		return customerError(c, err)
 	}
 
 	return c.NoContent(http.StatusNoContent)
 }
 
-// ListCustomers handles GET /v1/customers
+// RestoreCustomer handles POST /v1/customers/:id/restore requests.
+//
+// This method undoes a soft delete and returns the restored customer.
+//
+// Args:
+//   - c: Echo context containing the HTTP request and response
+//
+// Returns:
+//   - error: error if the operation fails
+//
+// Example request:
+//
+//	POST /v1/customers/customer-12345/restore
+//
+// Example response:
+//
+//	{
+//		"customerId": "customer-12345",
+//		"name": "John Doe",
+//		"status": "ACTIVE"
+//	}
+//
+// Error responses:
+//   - 404: Customer not found
+//   - 409: Customer is not deleted
+//   - 500: Internal server error
+func (h *Handler) RestoreCustomer(c echo.Context) error {
+	customerID := c.Param("id")
+
+	stop := servertiming.Start(c, "service")
+	customer, err := h.service.RestoreCustomer(c.Request().Context(), customerID)
+	stop()
+	if err != nil {
+		if errors.Is(err, ErrCustomerNotDeleted) {
+			return problem.Write(c, http.StatusConflict, "Customer is not deleted")
+		}
+		return customerError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, customer.ToResponse())
+}
+
+// ListCustomers handles GET /v1/customers requests.
+//
+// Customers are returned one page at a time, ordered by ID unless sorted
+// otherwise, and can be narrowed to a single segment, computed segment and
+// status.
+//
+// Query parameters:
+//   - segment: only list customers tagged with this segment
+//   - computedSegment: only list customers in this computed segment: VIP, NEW or DORMANT
+//   - status: only list customers in this status, e.g. ACTIVE
+//   - sort: comma-separated SortFields with an optional direction, e.g. creditLimit:desc,name:asc
+//   - includeDeleted: also list soft-deleted customers (admins only)
+//   - fields: comma-separated fields to keep in each customer, e.g. customerId,status
+//   - limit: page size, 1-100 (default 20)
+//   - offset: number of customers to skip (default 0)
+//
+// Example request:
+//
+//	GET /v1/customers?segment=newsletter&limit=1
+//
+// Example response:
+//
+//	{
+//		"customers": [{"customerId": "customer-101", "name": "Bob Wilson", "status": "ACTIVE"}],
+//		"count": 1,
+//		"pagination": {
+//			"total": 2,
+//			"limit": 1,
+//			"offset": 0,
+//			"next": "/v1/customers?limit=1&offset=1&segment=newsletter"
+//		}
+//	}
+//
+// Error responses:
+//   - 400: Invalid segment, computed segment, status, sort, fields or pagination parameters
+//   - 500: Internal server error
 func (h *Handler) ListCustomers(c echo.Context) error {
-	customers, err := h.service.ListCustomers()
+	filter, err := parseCustomerFilter(c)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": err.Error(),
-		})
+		return problem.Write(c, http.StatusBadRequest, err.Error())
+	}
+
+	fields, err := fieldset.FromQuery(c.QueryParams(), CustomerResponse{})
+	if err != nil {
+		return problem.Write(c, http.StatusBadRequest, err.Error())
+	}
+
+	stop := servertiming.Start(c, "service")
+	customers, err := h.service.FindCustomers(c.Request().Context(), filter)
+	var total int
+	if err == nil {
+		total, err = h.service.CountCustomers(c.Request().Context(), filter)
+	}
+	stop()
+
+	if err != nil {
+		return problem.Error(c, err)
 	}
 
 	responses := make([]CustomerResponse, len(customers))
@@ -258,36 +607,521 @@ func (h *Handler) ListCustomers(c echo.Context) error {
 		responses[i] = customer.ToResponse()
 	}
 
+	items, err := fields.Apply(responses)
+	if err != nil {
+		return problem.Error(c, err)
+	}
+
+	page := pagination.Params{Limit: filter.Limit, Offset: filter.Offset}
 	return c.JSON(http.StatusOK, map[string]interface{}{
-		"customers": responses,
-		"count":     len(responses),
+		"customers":  items,
+		"count":      len(responses),
+		"pagination": pagination.NewMeta(c.Request().URL, page, total),
+	})
+}
+
+// parseCustomerFilter builds a CustomerFilter from the list query parameters
+func parseCustomerFilter(c echo.Context) (CustomerFilter, error) {
+	page, err := pagination.FromQuery(c.QueryParams())
+	if err != nil {
+		return CustomerFilter{}, err
+	}
+
+	withDeleted, err := includeDeleted(c)
+	if err != nil {
+		return CustomerFilter{}, err
+	}
+
+	sort, err := listing.ParseSort(c.QueryParam("sort"))
+	if err != nil {
+		return CustomerFilter{}, err
+	}
+
+	return CustomerFilter{
+		Segment:         c.QueryParam("segment"),
+		Status:          c.QueryParam("status"),
+		ComputedSegment: c.QueryParam("computedSegment"),
+		Sort:            sort,
+		Limit:           page.Limit,
+		Offset:          page.Offset,
+		IncludeDeleted:  withDeleted,
+	}, nil
+}
+
+// ExportCustomers handles GET /v1/customers/export
+//
+// Every customer matching the ListCustomers filters is streamed, a page at a
+// time, as CSV or newline-delimited JSON depending on the Accept header. CSV
+// rows have one column per field, with segments and other nested values as
+// JSON. limit and offset are ignored; sort and fields apply as they do to
+// the list.
+//
+// Example request:
+//
+//	GET /v1/customers/export?status=ACTIVE&fields=customerId,name,email
+//	Accept: text/csv
+//
+// Example response:
+//
+//	customerId,name,email
+//	customer-456,Jane Doe,jane.doe@example.com
+//
+// Error responses:
+//   - 400: Invalid segment, status, sort or fields parameters
+//   - 406: The Accept header allows neither text/csv nor application/x-ndjson
+//   - 500: Internal server error before the first customer was sent; later
+//     errors cut the download short
+func (h *Handler) ExportCustomers(c echo.Context) error {
+	filter, err := parseCustomerFilter(c)
+	if err != nil {
+		return problem.Write(c, http.StatusBadRequest, err.Error())
+	}
+
+	fields, err := fieldset.FromQuery(c.QueryParams(), CustomerResponse{})
+	if err != nil {
+		return problem.Write(c, http.StatusBadRequest, err.Error())
+	}
+
+	stream, err := export.NewStream(c, "customers", CustomerResponse{}, fields)
+	if err != nil {
+		return problem.Write(c, http.StatusNotAcceptable, err.Error())
+	}
+
+	err = h.service.ExportCustomers(c.Request().Context(), filter, func(customers []*Customer) error {
+		for _, customer := range customers {
+			if err := stream.Write(customer.ToResponse()); err != nil {
+				return err
+			}
+		}
+		return stream.Flush()
 	})
+	switch {
+	case err == nil:
+		return stream.Close()
+	case stream.Started():
+		logging.FromContext(c.Request().Context()).Error("Customer export cut short", "error", err)
+		return nil
+	default:
+		return problem.Error(c, err)
+	}
 }
 
 // CheckCustomerStatus handles GET /v1/customers/:id/status
+//
+// Example response:
+//
+//	{
+//		"customerId": "customer-789",
+//		"status": "SUSPENDED",
+//		"isActive": false,
+//		"allowedTransitions": ["ACTIVE", "CLOSED"]
+//	}
 func (h *Handler) CheckCustomerStatus(c echo.Context) error {
 	customerID := c.Param("id")
 
-	isActive, err := h.service.IsCustomerActive(customerID)
+	stop := servertiming.Start(c, "service")
+	customer, err := h.service.GetCustomer(c.Request().Context(), customerID)
+	stop()
 	if err != nil {
-		if err == ErrCustomerNotFound {
-			return c.JSON(http.StatusNotFound, map[string]string{
-				"error": "Customer not found",
-			})
-		}
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": err.Error(),
-		})
+		return customerError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"customerId":         customerID,
+		"status":             customer.Status,
+		"isActive":           customer.IsActive(),
+		"allowedTransitions": AllowedTransitions(customer.Status),
+	})
+}
+
+// TransitionRequest represents the request payload for a customer status transition
+type TransitionRequest struct {
+	// Status is the lifecycle status to move the customer to
+	Status string `json:"status" validate:"required,oneof=PENDING ACTIVE SUSPENDED CLOSED"`
+}
+
+// TransitionCustomer handles POST /v1/customers/:id/transitions
+//
+// Customers move PENDING -> ACTIVE, ACTIVE <-> SUSPENDED, and from any
+// other status to CLOSED, which is final.
+//
+// Example request:
+//
+//	POST /v1/customers/customer-12345/transitions
+//	Content-Type: application/json
+//
+//	{
+//		"status": "SUSPENDED"
+//	}
+//
+// Error responses:
+//   - 400: Invalid request body or unknown status
+//   - 404: Customer not found
+//   - 409: The lifecycle does not allow the transition
+func (h *Handler) TransitionCustomer(c echo.Context) error {
+	var req TransitionRequest
+	if err := servertiming.Measure(c, "bind", func() error { return c.Bind(&req) }); err != nil {
+		return problem.BindError(c, err)
 	}
 
-	status := "INACTIVE"
-	if isActive {
-		status = "ACTIVE"
+	stop := servertiming.Start(c, "service")
+	customer, err := h.service.TransitionCustomer(c.Request().Context(), c.Param("id"), req.Status)
+	stop()
+	if err != nil {
+		// A refused transition names the statuses that are allowed instead
+		return customerError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, customer.ToResponse())
+}
+
+// CheckCredit handles GET /v1/customers/:id/credit-check?amount=X
+//
+// The check only reads the customer's exposure; reserve the credit with
+// POST /v1/customers/:id/credit/reserve once the order is accepted.
+//
+// Example response:
+//
+//	{
+//		"customerId": "customer-123",
+//		"amount": 500,
+//		"creditLimit": 1000,
+//		"currentExposure": 250,
+//		"availableCredit": 750,
+//		"approved": true
+//	}
+//
+// Error responses:
+//   - 400: Missing or invalid amount
+//   - 404: Customer not found
+func (h *Handler) CheckCredit(c echo.Context) error {
+	amount, err := strconv.ParseFloat(c.QueryParam("amount"), 64)
+	if err != nil {
+		return problem.Write(c, http.StatusBadRequest, "amount must be a number")
+	}
+
+	stop := servertiming.Start(c, "service")
+	check, err := h.service.CheckCredit(c.Request().Context(), c.Param("id"), amount)
+	stop()
+	if err != nil {
+		return customerError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, check)
+}
+
+// ReserveCredit handles POST /v1/customers/:id/credit/reserve
+//
+// The amount is added to the customer's exposure atomically, so concurrent
+// reservations can never take the exposure past the credit limit.
+//
+// Example request:
+//
+//	POST /v1/customers/customer-123/credit/reserve
+//	Content-Type: application/json
+//
+//	{
+//		"amount": 149.99
+//	}
+//
+// Error responses:
+//   - 400: Invalid amount
+//   - 404: Customer not found
+//   - 409: The amount exceeds the customer's available credit
+func (h *Handler) ReserveCredit(c echo.Context) error {
+	return h.adjustCredit(c, h.service.ReserveCredit)
+}
+
+// ReleaseCredit handles POST /v1/customers/:id/credit/release
+//
+// Error responses:
+//   - 400: Invalid amount
+//   - 404: Customer not found
+//   - 409: The amount exceeds the customer's current exposure
+func (h *Handler) ReleaseCredit(c echo.Context) error {
+	return h.adjustCredit(c, h.service.ReleaseCredit)
+}
+
+// adjustCredit binds a CreditRequest and applies it with adjust
+func (h *Handler) adjustCredit(c echo.Context, adjust func(context.Context, string, CreditRequest) (*Customer, error)) error {
+	var req CreditRequest
+	if err := servertiming.Measure(c, "bind", func() error { return c.Bind(&req) }); err != nil {
+		return problem.BindError(c, err)
+	}
+
+	stop := servertiming.Start(c, "service")
+	customer, err := adjust(c.Request().Context(), c.Param("id"), req)
+	stop()
+	if err != nil {
+		return customerError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, customer.ToResponse())
+}
+
+// SegmentRequest represents the request payload for adding a customer segment
+type SegmentRequest struct {
+	// Segment is the segment tag to add (lowercase letters, digits or hyphens)
+	Segment string `json:"segment" validate:"required,min=1,max=32"`
+}
+
+// AddCustomerSegment handles POST /v1/customers/:id/segments
+//
+// Example request:
+//
+//	POST /v1/customers/customer-12345/segments
+//	Content-Type: application/json
+//
+//	{
+//		"segment": "vip"
+//	}
+//
+// Error responses:
+//   - 400: Invalid segment or segment limit reached
+//   - 404: Customer not found
+func (h *Handler) AddCustomerSegment(c echo.Context) error {
+	customerID := c.Param("id")
+
+	var req SegmentRequest
+	if err := servertiming.Measure(c, "bind", func() error { return c.Bind(&req) }); err != nil {
+		return problem.BindError(c, err)
+	}
+
+	stop := servertiming.Start(c, "service")
+	customer, err := h.service.AddSegment(c.Request().Context(), customerID, req.Segment)
+	stop()
+	if err != nil {
+		return customerError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, customer.ToResponse())
+}
+
+// RemoveCustomerSegment handles DELETE /v1/customers/:id/segments/:segment
+func (h *Handler) RemoveCustomerSegment(c echo.Context) error {
+	stop := servertiming.Start(c, "service")
+	customer, err := h.service.RemoveSegment(c.Request().Context(), c.Param("id"), c.Param("segment"))
+	stop()
+	if err != nil {
+		return customerError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, customer.ToResponse())
+}
+
+// ListAddresses handles GET /v1/customers/:id/addresses
+//
+// Example response:
+//
+//	{
+//		"addresses": [{"addressId": "address-1", "type": "shipping", "city": "Lyon", "country": "FR", "isDefault": true, ...}],
+//		"count": 1
+//	}
+//
+// Error responses:
+//   - 404: Customer not found
+func (h *Handler) ListAddresses(c echo.Context) error {
+	stop := servertiming.Start(c, "service")
+	addresses, err := h.service.ListAddresses(c.Request().Context(), c.Param("id"))
+	stop()
+	if err != nil {
+		return addressError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"addresses": addresses,
+		"count":     len(addresses),
+	})
+}
+
+// GetAddress handles GET /v1/customers/:id/addresses/:addressId
+func (h *Handler) GetAddress(c echo.Context) error {
+	stop := servertiming.Start(c, "service")
+	address, err := h.service.GetAddress(c.Request().Context(), c.Param("id"), c.Param("addressId"))
+	stop()
+	if err != nil {
+		return addressError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, address)
+}
+
+// CreateAddress handles POST /v1/customers/:id/addresses
+//
+// The first address of each type becomes the default for that type; later
+// addresses only do so when isDefault is set.
+//
+// Example request:
+//
+//	POST /v1/customers/customer-12345/addresses
+//	Content-Type: application/json
+//
+//	{
+//		"type": "shipping",
+//		"line1": "12 Rue de la Paix",
+//		"city": "Paris",
+//		"postalCode": "75002",
+//		"country": "FR"
+//	}
+//
+// Error responses:
+//   - 400: Invalid address
+//   - 404: Customer not found
+func (h *Handler) CreateAddress(c echo.Context) error {
+	var req AddressRequest
+	if err := servertiming.Measure(c, "bind", func() error { return c.Bind(&req) }); err != nil {
+		return problem.BindError(c, err)
+	}
+
+	stop := servertiming.Start(c, "service")
+	address, err := h.service.CreateAddress(c.Request().Context(), c.Param("id"), req)
+	stop()
+	if err != nil {
+		return addressError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, address)
+}
+
+// UpdateAddress handles PUT /v1/customers/:id/addresses/:addressId
+func (h *Handler) UpdateAddress(c echo.Context) error {
+	var req AddressRequest
+	if err := servertiming.Measure(c, "bind", func() error { return c.Bind(&req) }); err != nil {
+		return problem.BindError(c, err)
+	}
+
+	stop := servertiming.Start(c, "service")
+	address, err := h.service.UpdateAddress(c.Request().Context(), c.Param("id"), c.Param("addressId"), req)
+	stop()
+	if err != nil {
+		return addressError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, address)
+}
+
+// DeleteAddress handles DELETE /v1/customers/:id/addresses/:addressId
+func (h *Handler) DeleteAddress(c echo.Context) error {
+	stop := servertiming.Start(c, "service")
+	err := h.service.DeleteAddress(c.Request().Context(), c.Param("id"), c.Param("addressId"))
+	stop()
+	if err != nil {
+		return addressError(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// GetCustomerHistory handles GET /v1/customers/:id/history requests.
+//
+// The response lists the customer's recorded events, oldest first, and the
+// customer they leave. An optional until timestamp (RFC 3339) replays only
+// the events up to that instant, answering what the customer looked like
+// then, e.g. its status when an order was placed. Soft-deleted customers keep
+// their history.
+//
+// Example request:
+//
+//	GET /v1/customers/customer-456/history?until=2026-10-16T09:00:00Z
+//
+// Example response:
+//
+//	{
+//		"customerId": "customer-456",
+//		"events": [
+//			{"customerId": "customer-456", "version": 1, "type": "CustomerCreated", "occurredAt": "2026-10-01T08:00:00Z", "data": {"customer": {...}}},
+//			{"customerId": "customer-456", "version": 2, "type": "CustomerStatusChanged", "occurredAt": "2026-10-12T14:30:00Z", "data": {"from": "ACTIVE", "to": "SUSPENDED"}}
+//		],
+//		"count": 2,
+//		"customer": {"customerId": "customer-456", "status": "SUSPENDED", ...}
+//	}
+//
+// Error responses:
+//   - 400: Malformed until
+//   - 404: Customer not found, or created after until
+//   - 501: Customer changes are not recorded as events
+func (h *Handler) GetCustomerHistory(c echo.Context) error {
+	customerID := c.Param("id")
+
+	until, err := parseTimeParam(c, "until")
+	if err != nil {
+		return problem.Write(c, http.StatusBadRequest, err.Error())
+	}
+
+	stop := servertiming.Start(c, "service")
+	history, err := h.service.CustomerHistory(c.Request().Context(), customerID, until)
+	stop()
+	if err != nil {
+		return historyError(c, err)
 	}
 
 	return c.JSON(http.StatusOK, map[string]interface{}{
 		"customerId": customerID,
-		"status":     status,
-		"isActive":   isActive,
+		"events":     history.Events,
+		"count":      len(history.Events),
+		"customer":   history.Customer.ToResponse(),
 	})
 }
+
+// historyError answers a failed replay of customer events: 501 when events
+// are not recorded, otherwise as customerError does
+func historyError(c echo.Context, err error) error {
+	if errors.Is(err, ErrHistoryNotRecorded) {
+		return problem.Write(c, http.StatusNotImplemented, "Customer history is not recorded")
+	}
+	return customerError(c, err)
+}
+
+// parseTimeParam parses an optional RFC 3339 timestamp query parameter
+func parseTimeParam(c echo.Context, name string) (*time.Time, error) {
+	value := c.QueryParam(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be an RFC 3339 timestamp", name)
+	}
+	return &parsed, nil
+}
+
+// addressError maps address operation errors to HTTP responses
+func addressError(c echo.Context, err error) error {
+	if errors.Is(err, ErrAddressNotFound) {
+		return problem.Write(c, http.StatusNotFound, "Address not found")
+	}
+	return customerError(c, err)
+}
+
+// customerError answers a failed customer operation: 404 for an unknown
+// customer, 409 for an email another customer already has and the status of
+// the error's kind otherwise
+func customerError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, ErrCustomerNotFound):
+		return problem.Write(c, http.StatusNotFound, "Customer not found")
+	case errors.Is(err, ErrEmailExists):
+		return problem.Write(c, http.StatusConflict, "Email already in use")
+	default:
+		return problem.Error(c, err)
+	}
+}
+
+// includeDeleted reads the includeDeleted query parameter.
+//
+// Returns:
+//   - bool: true if soft-deleted customers should be included
+//   - error: ErrInvalidFilter if the value is not a boolean
+func includeDeleted(c echo.Context) (bool, error) {
+	value := c.QueryParam("includeDeleted")
+	if value == "" {
+		return false, nil
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("%w: includeDeleted must be true or false", ErrInvalidFilter)
+	}
+	return parsed, nil
+}
@@ -6,6 +6,14 @@
 // models, and utility methods for customer operations.
 package customer
 
+import (
+	"errors"
+	"math"
+	"time"
+
+	"enricher-api-go/internal/validation"
+)
+
 // Customer represents a customer entity in the system.
 //
 // This struct contains the core customer information including unique
@@ -24,8 +32,34 @@ type Customer struct {
 	CustomerID string `json:"customerId" db:"customer_id"`
 	// Name is the full name of the customer
 	Name string `json:"name" db:"name"`
-	// Status indicates the current status of the customer (ACTIVE, INACTIVE)
+	// Status is the customer's lifecycle status (PENDING, ACTIVE, SUSPENDED, CLOSED)
 	Status string `json:"status" db:"status"`
+	// Email is the customer's lower-cased email address, unique across customers
+	Email string `json:"email,omitempty" db:"email"`
+	// Phone is the customer's phone number in E.164 format
+	Phone string `json:"phone,omitempty" db:"phone"`
+	// CreditLimit is the most the customer may owe on open orders
+	CreditLimit float64 `json:"creditLimit" db:"credit_limit"`
+	// CurrentExposure is what the customer currently owes on open orders
+	CurrentExposure float64 `json:"currentExposure" db:"current_exposure"`
+	// Segments holds marketing segment tags such as "vip" or "churn-risk"
+	Segments []string `json:"segments,omitempty" db:"segments"`
+	// ComputedSegments are the segments, such as SegmentVIP, the service's
+	// SegmentRules place the customer in when it is read; they are not stored
+	ComputedSegments []string `json:"-" db:"-"`
+	// LoyaltyTier is the customer's loyalty tier, such as "GOLD", when the
+	// service was given a TierFunc; it is not stored
+	LoyaltyTier string `json:"-" db:"-"`
+	// CreatedAt is when the customer was created
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	// UpdatedAt is when the customer was last changed
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+	// CreatedBy is the authenticated caller that created the customer, if any
+	CreatedBy string `json:"createdBy,omitempty" db:"created_by"`
+	// UpdatedBy is the authenticated caller that last changed the customer, if any
+	UpdatedBy string `json:"updatedBy,omitempty" db:"updated_by"`
+	// DeletedAt is when the customer was soft-deleted; nil while the customer is live
+	DeletedAt *time.Time `json:"deletedAt,omitempty" db:"deleted_at"`
 }
 
 // CustomerRequest represents the request payload for customer creation and updates.
@@ -42,8 +76,43 @@ type Customer struct {
 type CustomerRequest struct {
 	// Name is the full name of the customer (required, 2-100 characters)
 	Name string `json:"name" validate:"required,min=2,max=100"`
-	// Status indicates the customer status (required, must be ACTIVE or INACTIVE)
-	Status string `json:"status" validate:"required,oneof=ACTIVE INACTIVE"`
+	// Status is the customer's lifecycle status (required, PENDING, ACTIVE,
+	// SUSPENDED or CLOSED); changes must follow the allowed transitions
+	Status string `json:"status" validate:"required,oneof=PENDING ACTIVE SUSPENDED CLOSED"`
+	// Email is the customer's email address (optional, RFC 5322 addr-spec); omit to keep it
+	Email string `json:"email,omitempty"`
+	// Phone is the customer's phone number (optional, E.164 such as +14155550123); omit to keep it
+	Phone string `json:"phone,omitempty"`
+	// CreditLimit optionally sets the customer's credit limit (0 or more); omit to keep it
+	CreditLimit *float64 `json:"creditLimit,omitempty" validate:"omitempty,gte=0"`
+	// Segments optionally replaces the customer's segment tags; omit to keep them
+	Segments []string `json:"segments,omitempty" validate:"omitempty,dive,min=1,max=32"`
+}
+
+// CustomerPatch represents the request payload for a partial customer update.
+//
+// It follows JSON Merge Patch semantics for the top-level fields: fields that
+// are omitted or null keep their current values. An empty segments list
+// clears the customer's segments. The merged result is validated like a
+// CustomerRequest.
+//
+// Example usage:
+//
+//	status := "SUSPENDED"
+//	patch := CustomerPatch{Status: &status}
+type CustomerPatch struct {
+	// Name optionally replaces the full name of the customer
+	Name *string `json:"name,omitempty"`
+	// Status optionally moves the customer to another lifecycle status
+	Status *string `json:"status,omitempty"`
+	// Email optionally replaces the customer's email; an empty string clears it
+	Email *string `json:"email,omitempty"`
+	// Phone optionally replaces the customer's phone; an empty string clears it
+	Phone *string `json:"phone,omitempty"`
+	// CreditLimit optionally replaces the customer's credit limit
+	CreditLimit *float64 `json:"creditLimit,omitempty"`
+	// Segments optionally replaces the customer's segment tags
+	Segments []string `json:"segments,omitempty"`
 }
 
 // CustomerResponse represents the response payload for customer operations.
@@ -63,14 +132,284 @@ type CustomerResponse struct {
 	CustomerID string `json:"customerId"`
 	// Name is the full name of the customer
 	Name string `json:"name"`
-	// Status indicates the current status of the customer
+	// Status is the customer's lifecycle status
 	Status string `json:"status"`
+	// Email is the customer's email address, if known
+	Email string `json:"email,omitempty"`
+	// Phone is the customer's phone number in E.164 format, if known
+	Phone string `json:"phone,omitempty"`
+	// CreditLimit is the most the customer may owe on open orders
+	CreditLimit float64 `json:"creditLimit"`
+	// CurrentExposure is what the customer currently owes on open orders
+	CurrentExposure float64 `json:"currentExposure"`
+	// Segments holds marketing segment tags assigned to the customer
+	Segments []string `json:"segments,omitempty"`
+	// ComputedSegments are the segments the customer's credit limit and
+	// activity place it in, such as VIP, NEW or DORMANT
+	ComputedSegments []string `json:"computedSegments,omitempty"`
+	// LoyaltyTier is the customer's loyalty tier: BRONZE, SILVER or GOLD
+	LoyaltyTier string `json:"loyaltyTier,omitempty"`
+	// CreatedAt is when the customer was created
+	CreatedAt time.Time `json:"createdAt"`
+	// UpdatedAt is when the customer was last changed
+	UpdatedAt time.Time `json:"updatedAt"`
+	// CreatedBy is the caller that created the customer, if authenticated
+	CreatedBy string `json:"createdBy,omitempty"`
+	// UpdatedBy is the caller that last changed the customer, if authenticated
+	UpdatedBy string `json:"updatedBy,omitempty"`
+	// DeletedAt is set when a soft-deleted customer is read with includeDeleted
+	DeletedAt *time.Time `json:"deletedAt,omitempty"`
+}
+
+// BatchRequest represents the request payload for looking up several customers at once.
+//
+// Example usage:
+//
+//	request := BatchRequest{
+//		CustomerIDs: []string{"customer-123", "customer-456"},
+//	}
+type BatchRequest struct {
+	// CustomerIDs lists the customers to retrieve; duplicates are ignored
+	CustomerIDs []string `json:"customerIds"`
+}
+
+// BatchError reports why a single ID in a batch lookup could not be resolved.
+//
+// Example usage:
+//
+//	batchErr := BatchError{
+//		CustomerID: "customer-missing",
+//		Error:      "customer not found",
+//	}
+type BatchError struct {
+	// CustomerID is the requested ID that failed
+	CustomerID string `json:"customerId"`
+	// Error describes the failure, such as "customer not found"
+	Error string `json:"error"`
+}
+
+// BatchResult holds the outcome of a batch customer lookup.
+//
+// Customers and Errors both follow the order in which IDs were requested,
+// so each requested ID appears in exactly one of the two lists.
+type BatchResult struct {
+	// Customers are the customers that were found
+	Customers []*Customer
+	// Errors lists the requested IDs that could not be resolved and why
+	Errors []BatchError
+}
+
+// Outcomes of a bulk item.
+const (
+	// BulkCreated means the item created a customer
+	BulkCreated = "created"
+	// BulkUpdated means the item updated the customer it named
+	BulkUpdated = "updated"
+	// BulkFailed means the item was rejected or could not be stored
+	BulkFailed = "failed"
+	// BulkSkipped means an atomic bulk write was abandoned because of
+	// another item, so this one was not stored either
+	BulkSkipped = "skipped"
+)
+
+// BulkRequest represents the request payload for creating or updating many
+// customers at once.
+//
+// Example usage:
+//
+//	request := BulkRequest{
+//		Atomic: true,
+//		Items: []BulkItem{
+//			{Customer: CustomerRequest{Name: "Jane Smith", Status: "ACTIVE"}},
+//			{CustomerID: "customer-12345", Customer: CustomerRequest{Name: "John Doe", Status: "SUSPENDED"}},
+//		},
+//	}
+type BulkRequest struct {
+	// Items are written in order
+	Items []BulkItem `json:"items"`
+	// Atomic stores every item or none: one failing item fails them all
+	Atomic bool `json:"atomic,omitempty"`
+}
+
+// BulkItem creates a customer, or updates the one named by CustomerID.
+type BulkItem struct {
+	// CustomerID names the customer to update; omit it to create a customer
+	CustomerID string `json:"customerId,omitempty"`
+	// Customer holds the fields of the customer, as for a single create or update
+	Customer CustomerRequest `json:"customer"`
+}
+
+// BulkItemResult is the outcome of one bulk item.
+type BulkItemResult struct {
+	// Status is BulkCreated, BulkUpdated, BulkFailed or BulkSkipped
+	Status string
+	// Customer is the stored customer of a created or updated item
+	Customer *Customer
+	// Err is why a failed item was rejected
+	Err error
+}
+
+// BulkResult holds the outcome of a bulk write, one result per item in
+// request order.
+type BulkResult struct {
+	Items  []BulkItemResult
+	Atomic bool
+}
+
+// Failed returns the number of items that failed.
+func (r *BulkResult) Failed() int {
+	failed := 0
+	for _, item := range r.Items {
+		if item.Status == BulkFailed {
+			failed++
+		}
+	}
+	return failed
+}
+
+// BulkItemResponse represents the outcome of one bulk item in API responses.
+type BulkItemResponse struct {
+	// Index is the position of the item in the request
+	Index int `json:"index"`
+	// Status is created, updated, failed or skipped
+	Status string `json:"status"`
+	// CustomerID identifies the customer written or named by the item
+	CustomerID string `json:"customerId,omitempty"`
+	// Customer is the stored customer of a created or updated item
+	Customer *CustomerResponse `json:"customer,omitempty"`
+	// Error explains why a failed item was rejected
+	Error string `json:"error,omitempty"`
+	// Fields lists the fields of a failed item that did not validate
+	Fields []validation.FieldError `json:"fields,omitempty"`
+}
+
+// BulkResponse represents the response payload of a bulk write.
+type BulkResponse struct {
+	// Results holds one entry per item, in request order
+	Results []BulkItemResponse `json:"results"`
+	// Succeeded and Failed count the items stored and rejected
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+	// Atomic reports whether the items were written all or nothing
+	Atomic bool `json:"atomic"`
+}
+
+// ToResponse converts a bulk result to its API representation, naming each
+// item's customer from req.
+func (r *BulkResult) ToResponse(req BulkRequest) BulkResponse {
+	response := BulkResponse{Results: make([]BulkItemResponse, len(r.Items)), Atomic: r.Atomic}
+	for i, item := range r.Items {
+		result := BulkItemResponse{Index: i, Status: item.Status, CustomerID: req.Items[i].CustomerID}
+		switch item.Status {
+		case BulkCreated, BulkUpdated:
+			customer := item.Customer.ToResponse()
+			result.Customer, result.CustomerID = &customer, item.Customer.CustomerID
+			response.Succeeded++
+		case BulkFailed:
+			result.Error = item.Err.Error()
+			var validationErr *validation.Error
+			if errors.As(item.Err, &validationErr) {
+				result.Fields = validationErr.Fields
+			}
+			response.Failed++
+		}
+		response.Results[i] = result
+	}
+	return response
+}
+
+// Address types accepted by AddressRequest.
+const (
+	// AddressShipping is an address orders are delivered to
+	AddressShipping = "shipping"
+	// AddressBilling is an address invoices are sent to
+	AddressBilling = "billing"
+)
+
+// Address is a postal address in a customer's address book.
+//
+// A customer may keep several addresses of each type; at most one per type
+// is the default, which order enrichment uses when an order does not name
+// an address.
+//
+// Example usage:
+//
+//	address := &Address{
+//		AddressID:  "address-12345",
+//		CustomerID: "customer-12345",
+//		Type:       AddressShipping,
+//		Line1:      "221B Baker Street",
+//		City:       "London",
+//		PostalCode: "NW1 6XE",
+//		Country:    "GB",
+//		IsDefault:  true,
+//	}
+type Address struct {
+	// AddressID is the unique identifier for the address
+	AddressID string `json:"addressId" db:"address_id"`
+	// CustomerID is the customer the address belongs to
+	CustomerID string `json:"customerId" db:"customer_id"`
+	// Type is shipping or billing
+	Type string `json:"type" db:"type"`
+	// Line1 is the street address
+	Line1 string `json:"line1" db:"line1"`
+	// Line2 holds the apartment, suite or building, if any
+	Line2 string `json:"line2,omitempty" db:"line2"`
+	// City is the city or locality
+	City string `json:"city" db:"city"`
+	// Region is the state, province or county, if any
+	Region string `json:"region,omitempty" db:"region"`
+	// PostalCode is the postal or ZIP code
+	PostalCode string `json:"postalCode" db:"postal_code"`
+	// Country is the ISO 3166-1 alpha-2 country code, upper-case
+	Country string `json:"country" db:"country"`
+	// IsDefault marks the customer's default address of this type
+	IsDefault bool `json:"isDefault" db:"is_default"`
+	// CreatedAt is when the address was created
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	// UpdatedAt is when the address was last changed
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+	// CreatedBy is the authenticated caller that created the address, if any
+	CreatedBy string `json:"createdBy,omitempty" db:"created_by"`
+	// UpdatedBy is the authenticated caller that last changed the address, if any
+	UpdatedBy string `json:"updatedBy,omitempty" db:"updated_by"`
+}
+
+// AddressRequest represents the request payload for adding or replacing an address.
+//
+// Example usage:
+//
+//	request := AddressRequest{
+//		Type:       "shipping",
+//		Line1:      "221B Baker Street",
+//		City:       "London",
+//		PostalCode: "NW1 6XE",
+//		Country:    "GB",
+//		IsDefault:  true,
+//	}
+type AddressRequest struct {
+	// Type is shipping or billing (required)
+	Type string `json:"type" validate:"required,oneof=shipping billing"`
+	// Line1 is the street address (required, up to 100 characters)
+	Line1 string `json:"line1" validate:"required,max=100"`
+	// Line2 holds the apartment, suite or building (optional, up to 100 characters)
+	Line2 string `json:"line2,omitempty" validate:"max=100"`
+	// City is the city or locality (required, up to 50 characters)
+	City string `json:"city" validate:"required,max=50"`
+	// Region is the state, province or county (optional, up to 50 characters)
+	Region string `json:"region,omitempty" validate:"max=50"`
+	// PostalCode is the postal or ZIP code (required, up to 20 characters)
+	PostalCode string `json:"postalCode" validate:"required,max=20"`
+	// Country is the ISO 3166-1 alpha-2 country code (required)
+	Country string `json:"country" validate:"required,len=2,alpha"`
+	// IsDefault makes this the customer's default address of its type
+	IsDefault bool `json:"isDefault,omitempty"`
 }
 
 // IsActive checks if the customer is currently active.
 //
-// This method returns true if the customer status is "ACTIVE", false otherwise.
-// It provides a convenient way to check customer status without string comparison.
+// This method returns true if the customer status is ACTIVE; PENDING,
+// SUSPENDED and CLOSED customers are not active.
 //
 // Returns:
 //   - bool: true if customer is active, false otherwise
@@ -82,7 +421,7 @@ type CustomerResponse struct {
 //		// Process active customer
 //	}
 func (c *Customer) IsActive() bool {
-	return c.Status == "ACTIVE"
+	return c.Status == StatusActive
 }
 
 // ToResponse converts a Customer to CustomerResponse.
@@ -103,8 +442,139 @@ func (c *Customer) IsActive() bool {
 //	response := customer.ToResponse()
 func (c *Customer) ToResponse() CustomerResponse {
 	return CustomerResponse{
-		CustomerID: c.CustomerID,
-		Name:       c.Name,
-		Status:     c.Status,
+		CustomerID:       c.CustomerID,
+		Name:             c.Name,
+		Status:           c.Status,
+		Email:            c.Email,
+		Phone:            c.Phone,
+		CreditLimit:      c.CreditLimit,
+		CurrentExposure:  c.CurrentExposure,
+		Segments:         c.Segments,
+		ComputedSegments: c.ComputedSegments,
+		LoyaltyTier:      c.LoyaltyTier,
+		CreatedAt:        c.CreatedAt,
+		UpdatedAt:        c.UpdatedAt,
+		CreatedBy:        c.CreatedBy,
+		UpdatedBy:        c.UpdatedBy,
+		DeletedAt:        c.DeletedAt,
 	}
 }
+
+// apply merges the patch over the customer's current values.
+//
+// Args:
+//   - customer: the customer being patched; it is not modified
+//
+// Returns:
+//   - CustomerRequest: the full request equivalent to the patched customer
+func (p CustomerPatch) apply(customer *Customer) CustomerRequest {
+	req := CustomerRequest{
+		Name:        customer.Name,
+		Status:      customer.Status,
+		Email:       customer.Email,
+		Phone:       customer.Phone,
+		CreditLimit: &customer.CreditLimit,
+		Segments:    customer.Segments,
+	}
+	if p.Name != nil {
+		req.Name = *p.Name
+	}
+	if p.Status != nil {
+		req.Status = *p.Status
+	}
+	if p.Email != nil {
+		req.Email = *p.Email
+	}
+	if p.Phone != nil {
+		req.Phone = *p.Phone
+	}
+	if p.CreditLimit != nil {
+		req.CreditLimit = p.CreditLimit
+	}
+	if p.Segments != nil {
+		req.Segments = p.Segments
+	}
+	return req
+}
+
+// IsDeleted checks if the customer has been soft-deleted.
+//
+// Returns:
+//   - bool: true if DeletedAt is set, false otherwise
+func (c *Customer) IsDeleted() bool {
+	return c.DeletedAt != nil
+}
+
+// HasSegment checks if the customer is tagged with the given segment.
+//
+// Args:
+//   - segment: the segment tag to look for
+//
+// Returns:
+//   - bool: true if the customer carries the segment, false otherwise
+func (c *Customer) HasSegment(segment string) bool {
+	for _, s := range c.Segments {
+		if s == segment {
+			return true
+		}
+	}
+	return false
+}
+
+// CreditRequest represents the request payload for reserving or releasing
+// credit on a customer's exposure.
+//
+// Example usage:
+//
+//	request := CreditRequest{Amount: 149.99}
+type CreditRequest struct {
+	// Amount is the order value to add to or remove from the exposure
+	Amount float64 `json:"amount" validate:"required,gt=0"`
+}
+
+// CreditCheck reports whether a customer has enough credit left for an amount.
+//
+// Example usage:
+//
+//	check := customer.CheckCredit(250)
+//	if !check.Approved {
+//		// Flag the order for review
+//	}
+type CreditCheck struct {
+	// CustomerID is the customer that was checked
+	CustomerID string `json:"customerId"`
+	// Amount is the order value that was checked
+	Amount float64 `json:"amount"`
+	// CreditLimit is the customer's credit limit
+	CreditLimit float64 `json:"creditLimit"`
+	// CurrentExposure is what the customer currently owes on open orders
+	CurrentExposure float64 `json:"currentExposure"`
+	// AvailableCredit is the credit left, never below zero
+	AvailableCredit float64 `json:"availableCredit"`
+	// Approved is true if Amount fits within AvailableCredit
+	Approved bool `json:"approved"`
+}
+
+// CheckCredit checks whether amount fits within the customer's remaining credit.
+//
+// Args:
+//   - amount: the order value to check
+//
+// Returns:
+//   - CreditCheck: the customer's credit position and whether amount is approved
+func (c *Customer) CheckCredit(amount float64) CreditCheck {
+	available := roundAmount(math.Max(0, c.CreditLimit-c.CurrentExposure))
+	return CreditCheck{
+		CustomerID:      c.CustomerID,
+		Amount:          amount,
+		CreditLimit:     c.CreditLimit,
+		CurrentExposure: c.CurrentExposure,
+		AvailableCredit: available,
+		Approved:        roundAmount(amount) <= available,
+	}
+}
+
+// roundAmount rounds a monetary amount to two decimal places
+func roundAmount(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
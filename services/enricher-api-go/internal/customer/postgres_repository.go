@@ -0,0 +1,653 @@
+package customer
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"enricher-api-go/internal/outbox"
+	"enricher-api-go/internal/postgres"
+)
+
+// emailConstraint is the unique index that keeps customer emails unique
+const emailConstraint = "customers_email_key"
+
+// customerColumns lists the columns read by scanCustomer, in order
+const customerColumns = `customer_id, name, status, email, phone, credit_limit, current_exposure, segments,
+	created_at, updated_at, created_by, updated_by, deleted_at`
+
+// notDeleted restricts a query to live customers
+const notDeleted = `deleted_at IS NULL`
+
+// addressColumns lists the columns read by scanAddress, in order, qualified
+// for queries joining liveAddresses
+const addressColumns = `a.address_id, a.customer_id, a.type, a.line1, a.line2, a.city, a.region,
+	a.postal_code, a.country, a.is_default, a.created_at, a.updated_at, a.created_by, a.updated_by`
+
+// liveAddresses joins addresses to their customer, hiding those of deleted customers
+const liveAddresses = `customer_addresses a JOIN customers c ON c.customer_id = a.customer_id AND c.deleted_at IS NULL`
+
+// PostgresRepository implements Repository on top of a PostgreSQL database
+type PostgresRepository struct {
+	db *sql.DB
+	// events records every customer change in the same transaction; nil records none
+	events *outbox.PostgresStore
+}
+
+// NewPostgresRepository creates a customer repository backed by db.
+//
+// The caller owns db and is responsible for opening and closing it; the
+// customers and customer_addresses tables must exist (see the
+// migrations package).
+func NewPostgresRepository(db *sql.DB) *PostgresRepository {
+	return &PostgresRepository{db: db}
+}
+
+// GetByID retrieves a live customer by ID
+func (r *PostgresRepository) GetByID(customerID string) (*Customer, error) {
+	return r.getOne(`SELECT `+customerColumns+` FROM customers WHERE customer_id = $1 AND `+notDeleted, customerID)
+}
+
+// GetByIDIncludingDeleted retrieves a customer by ID even if it is soft-deleted
+func (r *PostgresRepository) GetByIDIncludingDeleted(customerID string) (*Customer, error) {
+	return r.getOne(`SELECT `+customerColumns+` FROM customers WHERE customer_id = $1`, customerID)
+}
+
+// GetByIDs retrieves the live customers with the given IDs, skipping unknown ones
+func (r *PostgresRepository) GetByIDs(customerIDs []string) ([]*Customer, error) {
+	return r.query(
+		`SELECT `+customerColumns+` FROM customers WHERE customer_id = ANY($1) AND `+notDeleted,
+		customerIDs,
+	)
+}
+
+// GetByEmail retrieves a live customer by email
+func (r *PostgresRepository) GetByEmail(email string) (*Customer, error) {
+	if email == "" {
+		return nil, ErrCustomerNotFound
+	}
+	return r.getOne(`SELECT `+customerColumns+` FROM customers WHERE email = $1 AND `+notDeleted, email)
+}
+
+// RecordEventsTo records every change of a customer in store, in the
+// transaction that stores the change. Address changes are not recorded.
+func (r *PostgresRepository) RecordEventsTo(store *outbox.PostgresStore) {
+	r.events = store
+}
+
+// Create adds a new customer
+func (r *PostgresRepository) Create(customer *Customer) error {
+	_, err := r.write(outbox.ActionCreated, func(q querier) (*Customer, error) {
+		return customer, insertCustomer(q, customer)
+	})
+	return err
+}
+
+// Update modifies an existing customer, leaving its exposure alone
+func (r *PostgresRepository) Update(customer *Customer) error {
+	_, err := r.write(outbox.ActionUpdated, func(q querier) (*Customer, error) {
+		return updateCustomer(q, customer)
+	})
+	return err
+}
+
+// WriteAll applies every write in one transaction, recording their events
+// in it when events are recorded
+func (r *PostgresRepository) WriteAll(writes []Write) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin customer bulk write: %w", err)
+	}
+	defer tx.Rollback()
+
+	for i, write := range writes {
+		action, stored := outbox.ActionCreated, write.Customer
+		if write.Create {
+			err = insertCustomer(tx, write.Customer)
+		} else {
+			action = outbox.ActionUpdated
+			stored, err = updateCustomer(tx, write.Customer)
+		}
+		if err == nil && r.events != nil {
+			err = r.events.Record(tx, outbox.Change{
+				Aggregate:   outbox.AggregateCustomer,
+				AggregateID: stored.CustomerID,
+				Action:      action,
+				Data:        stored,
+			})
+		}
+		if err != nil {
+			return &WriteError{Index: i, Err: err}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit customer bulk write: %w", err)
+	}
+	return nil
+}
+
+// insertCustomer adds customer on q
+func insertCustomer(q querier, customer *Customer) error {
+	segments, err := marshalSegments(customer.Segments)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.Exec(
+		`INSERT INTO customers (customer_id, name, status, email, phone, credit_limit, current_exposure, segments,
+			created_at, updated_at, created_by, updated_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+		customer.CustomerID, customer.Name, customer.Status, customer.Email, customer.Phone,
+		customer.CreditLimit, customer.CurrentExposure, segments,
+		customer.CreatedAt, customer.UpdatedAt, customer.CreatedBy, customer.UpdatedBy,
+	)
+	if postgres.IsUniqueViolationOf(err, emailConstraint) {
+		return ErrEmailExists
+	}
+	if postgres.IsUniqueViolation(err) {
+		return ErrCustomerExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to insert customer: %w", err)
+	}
+	return nil
+}
+
+// updateCustomer replaces a live customer on q, leaving its exposure alone,
+// and returns the customer as stored
+func updateCustomer(q querier, customer *Customer) (*Customer, error) {
+	segments, err := marshalSegments(customer.Segments)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := queryOne(q,
+		`UPDATE customers SET name = $2, status = $3, email = $4, phone = $5, credit_limit = $6, segments = $7,
+			updated_at = $8, updated_by = $9
+		WHERE customer_id = $1 AND `+notDeleted+`
+		RETURNING `+customerColumns,
+		customer.CustomerID, customer.Name, customer.Status, customer.Email, customer.Phone, customer.CreditLimit, segments,
+		customer.UpdatedAt, customer.UpdatedBy,
+	)
+	if postgres.IsUniqueViolationOf(err, emailConstraint) {
+		return nil, ErrEmailExists
+	}
+	return updated, err
+}
+
+// Delete soft-deletes a customer by stamping deleted_at
+func (r *PostgresRepository) Delete(customerID string) error {
+	_, err := r.write(outbox.ActionDeleted, func(q querier) (*Customer, error) {
+		return queryOne(q,
+			`UPDATE customers SET deleted_at = now() WHERE customer_id = $1 AND `+notDeleted+` RETURNING `+customerColumns,
+			customerID,
+		)
+	})
+	return err
+}
+
+// Restore clears deleted_at on a soft-deleted customer
+func (r *PostgresRepository) Restore(customerID string) error {
+	_, err := r.write(outbox.ActionRestored, func(q querier) (*Customer, error) {
+		restored, err := queryOne(q,
+			`UPDATE customers SET deleted_at = NULL WHERE customer_id = $1 AND deleted_at IS NOT NULL RETURNING `+customerColumns,
+			customerID,
+		)
+		if !errors.Is(err, ErrCustomerNotFound) {
+			return restored, err
+		}
+
+		// Nothing was restored: tell a live customer apart from a missing one
+		if _, err := r.GetByID(customerID); err != nil {
+			return nil, err
+		}
+		return nil, ErrCustomerNotDeleted
+	})
+	return err
+}
+
+// RewritePersonalData replaces the name, email and phone of a customer, even
+// a soft-deleted one, in a single UPDATE that records no event
+func (r *PostgresRepository) RewritePersonalData(customer *Customer) error {
+	result, err := r.db.Exec(
+		`UPDATE customers SET name = $2, email = $3, phone = $4 WHERE customer_id = $1`,
+		customer.CustomerID, customer.Name, customer.Email, customer.Phone,
+	)
+	if postgres.IsUniqueViolationOf(err, emailConstraint) {
+		return ErrEmailExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to rewrite customer: %w", err)
+	}
+	return requireRowAffected(result)
+}
+
+// AdjustExposure adds change.Delta to a live customer's exposure in a single
+// conditional UPDATE, so concurrent adjustments never overdraw the limit
+func (r *PostgresRepository) AdjustExposure(customerID string, change ExposureChange) (*Customer, error) {
+	return r.write(outbox.ActionUpdated, func(q querier) (*Customer, error) {
+		customer, err := queryOne(q,
+			`UPDATE customers SET current_exposure = round((current_exposure + $2)::numeric, 2)::double precision,
+				updated_at = $3, updated_by = $4
+			WHERE customer_id = $1 AND `+notDeleted+`
+				AND round((current_exposure + $2)::numeric, 2) >= 0
+				AND ($2 <= 0 OR round((current_exposure + $2)::numeric, 2) <= credit_limit::numeric)
+			RETURNING `+customerColumns,
+			customerID, change.Delta, change.UpdatedAt, change.UpdatedBy,
+		)
+		if !errors.Is(err, ErrCustomerNotFound) {
+			return customer, err
+		}
+
+		// Nothing was adjusted: tell a missing customer apart from a refused change
+		if _, err := r.GetByID(customerID); err != nil {
+			return nil, err
+		}
+		if change.Delta > 0 {
+			return nil, ErrCreditLimitExceeded
+		}
+		return nil, ErrExposureBelowZero
+	})
+}
+
+// write runs change and, when events are recorded, records the customer it
+// returns as action in the outbox within the same transaction
+func (r *PostgresRepository) write(action string, change func(q querier) (*Customer, error)) (*Customer, error) {
+	if r.events == nil {
+		return change(r.db)
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin customer write: %w", err)
+	}
+	defer tx.Rollback()
+
+	customer, err := change(tx)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.events.Record(tx, outbox.Change{
+		Aggregate:   outbox.AggregateCustomer,
+		AggregateID: customer.CustomerID,
+		Action:      action,
+		Data:        customer,
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit customer write: %w", err)
+	}
+	return customer, nil
+}
+
+// List returns all live customers ordered by ID
+func (r *PostgresRepository) List() ([]*Customer, error) {
+	return r.Find(CustomerFilter{})
+}
+
+// Find returns the customers matching filter, ordered by its Sort and then
+// by CustomerID
+func (r *PostgresRepository) Find(filter CustomerFilter) ([]*Customer, error) {
+	where, args, err := filterClause(filter)
+	if err != nil {
+		return nil, err
+	}
+	query := `SELECT ` + customerColumns + ` FROM customers` + where + filter.Sort.OrderBy(sortColumns, "customer_id")
+
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(` LIMIT $%d`, len(args))
+	}
+	if filter.Offset > 0 {
+		args = append(args, filter.Offset)
+		query += fmt.Sprintf(` OFFSET $%d`, len(args))
+	}
+
+	return r.query(query, args...)
+}
+
+// Count returns the number of customers matching filter, ignoring Limit and Offset
+func (r *PostgresRepository) Count(filter CustomerFilter) (int, error) {
+	where, args, err := filterClause(filter)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM customers`+where, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count customers: %w", err)
+	}
+	return count, nil
+}
+
+// filterClause builds the WHERE clause and its arguments for filter's criteria.
+// Segment filters use JSONB containment so they are served by the GIN index.
+func filterClause(filter CustomerFilter) (string, []interface{}, error) {
+	var conditions []string
+	var args []interface{}
+
+	if !filter.IncludeDeleted {
+		conditions = append(conditions, notDeleted)
+	}
+
+	if filter.Segment != "" {
+		segment, err := json.Marshal([]string{filter.Segment})
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to encode segment filter: %w", err)
+		}
+		args = append(args, string(segment))
+		conditions = append(conditions, fmt.Sprintf(`segments @> $%d::jsonb`, len(args)))
+	}
+
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conditions = append(conditions, fmt.Sprintf(`status = $%d`, len(args)))
+	}
+
+	if filter.MinCreditLimit > 0 {
+		args = append(args, filter.MinCreditLimit)
+		conditions = append(conditions, fmt.Sprintf(`credit_limit >= $%d`, len(args)))
+	}
+
+	if !filter.CreatedAfter.IsZero() {
+		args = append(args, filter.CreatedAfter)
+		conditions = append(conditions, fmt.Sprintf(`created_at > $%d`, len(args)))
+	}
+
+	if !filter.UpdatedUntil.IsZero() {
+		args = append(args, filter.UpdatedUntil)
+		conditions = append(conditions, fmt.Sprintf(`updated_at <= $%d`, len(args)))
+	}
+
+	if len(conditions) == 0 {
+		return "", nil, nil
+	}
+	return ` WHERE ` + strings.Join(conditions, ` AND `), args, nil
+}
+
+// sortColumns maps SortFields to their columns
+var sortColumns = map[string]string{
+	"customerId":  "customer_id",
+	"name":        "name",
+	"email":       "email",
+	"status":      "status",
+	"creditLimit": "credit_limit",
+	"createdAt":   "created_at",
+	"updatedAt":   "updated_at",
+}
+
+// getOne runs a single-customer SELECT, mapping no rows to ErrCustomerNotFound
+func (r *PostgresRepository) getOne(query string, args ...interface{}) (*Customer, error) {
+	return queryOne(r.db, query, args...)
+}
+
+// querier is satisfied by *sql.DB and *sql.Tx
+type querier interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// queryOne runs a single-customer query on q, mapping no rows to ErrCustomerNotFound
+func queryOne(q querier, query string, args ...interface{}) (*Customer, error) {
+	customer, err := scanCustomer(q.QueryRow(query, args...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrCustomerNotFound
+	}
+	return customer, err
+}
+
+// query runs a customer SELECT and scans every row
+func (r *PostgresRepository) query(query string, args ...interface{}) ([]*Customer, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query customers: %w", err)
+	}
+	defer rows.Close()
+
+	customers := make([]*Customer, 0)
+	for rows.Next() {
+		customer, err := scanCustomer(rows)
+		if err != nil {
+			return nil, err
+		}
+		customers = append(customers, customer)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read customers: %w", err)
+	}
+	return customers, nil
+}
+
+// Addresses returns the addresses of a live customer ordered by type, then
+// creation time
+func (r *PostgresRepository) Addresses(customerID string) ([]*Address, error) {
+	rows, err := r.db.Query(
+		`SELECT `+addressColumns+` FROM `+liveAddresses+`
+		WHERE a.customer_id = $1 ORDER BY a.type, a.created_at, a.address_id`,
+		customerID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query addresses: %w", err)
+	}
+	defer rows.Close()
+
+	addresses := make([]*Address, 0)
+	for rows.Next() {
+		address, err := scanAddress(rows)
+		if err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, address)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read addresses: %w", err)
+	}
+	return addresses, nil
+}
+
+// GetAddress retrieves one address of a live customer
+func (r *PostgresRepository) GetAddress(customerID, addressID string) (*Address, error) {
+	address, err := scanAddress(r.db.QueryRow(
+		`SELECT `+addressColumns+` FROM `+liveAddresses+` WHERE a.customer_id = $1 AND a.address_id = $2`,
+		customerID, addressID,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAddressNotFound
+	}
+	return address, err
+}
+
+// CreateAddress adds an address to a live customer
+func (r *PostgresRepository) CreateAddress(address *Address) error {
+	return r.writeAddress(address, ErrCustomerNotFound, func(tx *sql.Tx) error {
+		_, err := tx.Exec(
+			`INSERT INTO customer_addresses (address_id, customer_id, type, line1, line2, city, region,
+				postal_code, country, is_default, created_at, updated_at, created_by, updated_by)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)`,
+			address.AddressID, address.CustomerID, address.Type, address.Line1, address.Line2, address.City,
+			address.Region, address.PostalCode, address.Country, address.IsDefault,
+			address.CreatedAt, address.UpdatedAt, address.CreatedBy, address.UpdatedBy,
+		)
+		if postgres.IsUniqueViolation(err) {
+			return ErrAddressExists
+		}
+		if err != nil {
+			return fmt.Errorf("failed to insert address: %w", err)
+		}
+		return nil
+	})
+}
+
+// UpdateAddress replaces an address of a live customer
+func (r *PostgresRepository) UpdateAddress(address *Address) error {
+	return r.writeAddress(address, ErrAddressNotFound, func(tx *sql.Tx) error {
+		result, err := tx.Exec(
+			`UPDATE customer_addresses SET type = $3, line1 = $4, line2 = $5, city = $6, region = $7,
+				postal_code = $8, country = $9, is_default = $10, updated_at = $11, updated_by = $12
+			WHERE customer_id = $1 AND address_id = $2`,
+			address.CustomerID, address.AddressID, address.Type, address.Line1, address.Line2, address.City,
+			address.Region, address.PostalCode, address.Country, address.IsDefault,
+			address.UpdatedAt, address.UpdatedBy,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to update address: %w", err)
+		}
+		return requireAddressAffected(result)
+	})
+}
+
+// DeleteAddress removes an address of a live customer
+func (r *PostgresRepository) DeleteAddress(customerID, addressID string) error {
+	result, err := r.db.Exec(
+		`DELETE FROM customer_addresses a USING customers c
+		WHERE a.customer_id = $1 AND a.address_id = $2
+		AND c.customer_id = a.customer_id AND c.deleted_at IS NULL`,
+		customerID, addressID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete address: %w", err)
+	}
+	return requireAddressAffected(result)
+}
+
+// writeAddress runs write in a transaction holding the customer's row lock,
+// so concurrent writes cannot leave two default addresses of one type. A
+// missing or deleted customer fails with missing. When address is the
+// default, the customer's other addresses of its type are cleared first.
+func (r *PostgresRepository) writeAddress(address *Address, missing error, write func(tx *sql.Tx) error) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin address write: %w", err)
+	}
+	defer tx.Rollback()
+
+	var locked string
+	err = tx.QueryRow(
+		`SELECT customer_id FROM customers WHERE customer_id = $1 AND `+notDeleted+` FOR UPDATE`,
+		address.CustomerID,
+	).Scan(&locked)
+	if errors.Is(err, sql.ErrNoRows) {
+		return missing
+	}
+	if err != nil {
+		return fmt.Errorf("failed to lock customer: %w", err)
+	}
+
+	if address.IsDefault {
+		_, err := tx.Exec(
+			`UPDATE customer_addresses SET is_default = false
+			WHERE customer_id = $1 AND type = $2 AND address_id <> $3 AND is_default`,
+			address.CustomerID, address.Type, address.AddressID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to clear default address: %w", err)
+		}
+	}
+
+	if err := write(tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit address write: %w", err)
+	}
+	return nil
+}
+
+// rowScanner is satisfied by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanCustomer reads one customer row
+func scanCustomer(row rowScanner) (*Customer, error) {
+	var customer Customer
+	var segments []byte
+	var deletedAt sql.NullTime
+
+	err := row.Scan(
+		&customer.CustomerID, &customer.Name, &customer.Status, &customer.Email, &customer.Phone,
+		&customer.CreditLimit, &customer.CurrentExposure, &segments,
+		&customer.CreatedAt, &customer.UpdatedAt, &customer.CreatedBy, &customer.UpdatedBy, &deletedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan customer: %w", err)
+	}
+
+	if err := json.Unmarshal(segments, &customer.Segments); err != nil {
+		return nil, fmt.Errorf("failed to decode customer segments: %w", err)
+	}
+	if len(customer.Segments) == 0 {
+		customer.Segments = nil
+	}
+	if deletedAt.Valid {
+		customer.DeletedAt = &deletedAt.Time
+	}
+
+	return &customer, nil
+}
+
+// scanAddress reads one address row in addressColumns order
+func scanAddress(row rowScanner) (*Address, error) {
+	var address Address
+
+	err := row.Scan(
+		&address.AddressID, &address.CustomerID, &address.Type, &address.Line1, &address.Line2,
+		&address.City, &address.Region, &address.PostalCode, &address.Country, &address.IsDefault,
+		&address.CreatedAt, &address.UpdatedAt, &address.CreatedBy, &address.UpdatedBy,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan address: %w", err)
+	}
+
+	return &address, nil
+}
+
+// marshalSegments encodes segments as a JSON array, never null
+func marshalSegments(segments []string) (string, error) {
+	if segments == nil {
+		segments = []string{}
+	}
+
+	encoded, err := json.Marshal(segments)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode customer segments: %w", err)
+	}
+	return string(encoded), nil
+}
+
+// requireRowAffected maps an UPDATE/DELETE that touched no rows to ErrCustomerNotFound
+func requireRowAffected(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to read affected rows: %w", err)
+	}
+	if affected == 0 {
+		return ErrCustomerNotFound
+	}
+	return nil
+}
+
+// requireAddressAffected maps an address UPDATE/DELETE that touched no rows
+// to ErrAddressNotFound
+func requireAddressAffected(result sql.Result) error {
+	if err := requireRowAffected(result); errors.Is(err, ErrCustomerNotFound) {
+		return ErrAddressNotFound
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
@@ -1,52 +1,212 @@
 package customer
 
 import (
-	"errors"
+	"fmt"
+	"slices"
+	"sort"
 	"sync"
+	"time"
+
+	"enricher-api-go/internal/apperr"
+	"enricher-api-go/internal/outbox"
+)
+
+var (
+	// ErrCustomerNotFound is returned when no customer has the requested ID
+	ErrCustomerNotFound = apperr.New(apperr.ErrNotFound, "customer not found")
+	// ErrCustomerExists is returned when creating a customer whose ID is taken
+	ErrCustomerExists = apperr.New(apperr.ErrConflict, "customer already exists")
+	// ErrCustomerNotDeleted is returned when restoring a customer that is not deleted
+	ErrCustomerNotDeleted = apperr.New(apperr.ErrConflict, "customer is not deleted")
+	// ErrEmailExists is returned when storing a customer whose email another customer already has
+	ErrEmailExists = apperr.New(apperr.ErrConflict, "email already in use")
+	// ErrCreditLimitExceeded is returned when an exposure increase would exceed the credit limit
+	ErrCreditLimitExceeded = apperr.New(apperr.ErrConflict, "credit limit exceeded")
+	// ErrExposureBelowZero is returned when an exposure decrease would leave it negative
+	ErrExposureBelowZero = apperr.New(apperr.ErrConflict, "exposure cannot drop below zero")
+	// ErrAddressNotFound is returned when a live customer has no address with the requested ID
+	ErrAddressNotFound = apperr.New(apperr.ErrNotFound, "address not found")
+	// ErrAddressExists is returned when creating an address whose ID is taken
+	ErrAddressExists = apperr.New(apperr.ErrConflict, "address already exists")
 )
 
-var ErrCustomerNotFound = errors.New("customer not found")
+// ExposureChange describes an atomic adjustment of a customer's exposure
+type ExposureChange struct {
+	// Delta is added to the exposure; negative values release credit
+	Delta float64
+	// UpdatedAt and UpdatedBy stamp the customer's audit fields
+	UpdatedAt time.Time
+	UpdatedBy string
+}
 
-// Repository defines the interface for customer data access
+// Write is one customer stored by Repository.WriteAll
+type Write struct {
+	// Customer is stored as given
+	Customer *Customer
+	// Create adds Customer like Repository.Create; otherwise it replaces the
+	// stored customer like Repository.Update
+	Create bool
+}
+
+// WriteError reports the write that failed a Repository.WriteAll
+type WriteError struct {
+	// Index is the position of the failed write
+	Index int
+	Err   error
+}
+
+// Error describes the failed write
+func (e *WriteError) Error() string {
+	return fmt.Sprintf("write %d: %v", e.Index, e.Err)
+}
+
+// Unwrap returns the error of the failed write
+func (e *WriteError) Unwrap() error {
+	return e.Err
+}
+
+// Repository defines the interface for customer data access.
+//
+// Delete is a soft delete: it stamps DeletedAt and keeps the record. Every
+// read except GetByIDIncludingDeleted and a Find or Count with
+// IncludeDeleted skips soft-deleted customers, and Update and Delete treat
+// them as missing.
+//
+// Emails are unique across all customers, soft-deleted ones included, so a
+// restored customer never clashes with a live one. Create and Update return
+// ErrEmailExists for a taken email; customers without an email never clash.
+//
+// Update leaves CurrentExposure alone. AdjustExposure changes it in one step,
+// rounded to cents, so concurrent orders never overdraw a customer: an
+// increase that would exceed CreditLimit fails with ErrCreditLimitExceeded
+// and a decrease below zero with ErrExposureBelowZero, changing nothing.
+// Decreases are allowed even when a lowered limit is already exceeded.
+//
+// WriteAll stores several creates and updates as one unit: either every
+// write is applied, in order, or none is and a *WriteError names the write
+// that failed.
+//
+// Addresses belong to a customer and are hidden while it is soft-deleted.
+// Storing an address with IsDefault set clears the flag on the customer's
+// other addresses of the same type in the same operation, so each customer
+// has at most one default address per type.
 type Repository interface {
 	GetByID(customerID string) (*Customer, error)
+	GetByIDIncludingDeleted(customerID string) (*Customer, error)
+	GetByIDs(customerIDs []string) ([]*Customer, error)
+	GetByEmail(email string) (*Customer, error)
 	Create(customer *Customer) error
 	Update(customer *Customer) error
+	WriteAll(writes []Write) error
 	Delete(customerID string) error
+	Restore(customerID string) error
 	List() ([]*Customer, error)
+	Find(filter CustomerFilter) ([]*Customer, error)
+	Count(filter CustomerFilter) (int, error)
+	AdjustExposure(customerID string, change ExposureChange) (*Customer, error)
+	Addresses(customerID string) ([]*Address, error)
+	GetAddress(customerID, addressID string) (*Address, error)
+	CreateAddress(address *Address) error
+	UpdateAddress(address *Address) error
+	DeleteAddress(customerID, addressID string) error
+}
+
+// PersonalDataRewriter rewrites the stored personal data of customers in
+// place, which re-encryption needs to reach soft-deleted customers too
+type PersonalDataRewriter interface {
+	// RewritePersonalData replaces the name, email and phone stored for the
+	// customer, soft-deleted or not, keeping every other field, DeletedAt and
+	// UpdatedAt included. It records no change event. An email taken by
+	// another customer fails with ErrEmailExists.
+	RewritePersonalData(customer *Customer) error
 }
 
 // InMemoryRepository implements Repository interface using in-memory storage
 type InMemoryRepository struct {
 	customers map[string]*Customer
-	mutex     sync.RWMutex
+	// segmentIndex maps a segment tag to the IDs of customers carrying it
+	segmentIndex map[string]map[string]struct{}
+	// emailIndex maps an email to the ID of the customer that has it
+	emailIndex map[string]string
+	addresses  map[string]*Address
+	// events records every customer change; nil records none
+	events *outbox.InMemoryStore
+	// samples are the customers the repository starts with and resets to
+	samples []*Customer
+	mutex   sync.RWMutex
 }
 
 // NewInMemoryRepository creates a new in-memory customer repository with sample data
 func NewInMemoryRepository() *InMemoryRepository {
-	repo := &InMemoryRepository{
-		customers: make(map[string]*Customer),
-		mutex:     sync.RWMutex{},
-	}
+	return NewInMemoryRepositoryWith(sampleCustomers())
+}
 
-	// Add sample customers
-	sampleCustomers := []*Customer{
-		{CustomerID: "customer-456", Name: "Jane Doe", Status: "ACTIVE"},
-		{CustomerID: "customer-123", Name: "John Smith", Status: "ACTIVE"},
-		{CustomerID: "customer-789", Name: "Alice Johnson", Status: "INACTIVE"},
-		{CustomerID: "customer-101", Name: "Bob Wilson", Status: "ACTIVE"},
-		{CustomerID: "customer-202", Name: "Carol Brown", Status: "ACTIVE"},
-	}
+// NewInMemoryRepositoryWith creates an in-memory customer repository holding
+// customers, such as fixtures loaded from a seed file; Reset returns to them
+func NewInMemoryRepositoryWith(customers []*Customer) *InMemoryRepository {
+	repo := &InMemoryRepository{samples: customers}
+	repo.seed()
+	return repo
+}
+
+// Reset replaces every customer and address with the customers the
+// repository started with
+func (r *InMemoryRepository) Reset() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.seed()
+}
+
+// seed replaces the repository's contents with its samples
+func (r *InMemoryRepository) seed() {
+	r.customers = make(map[string]*Customer)
+	r.segmentIndex = make(map[string]map[string]struct{})
+	r.emailIndex = make(map[string]string)
+	r.addresses = make(map[string]*Address)
 
-	for _, customer := range sampleCustomers {
-		repo.customers[customer.CustomerID] = customer
+	seededAt := time.Now().UTC()
+	for _, sample := range r.samples {
+		customer := *sample
+		customer.Segments = slices.Clone(sample.Segments)
+		if customer.CreatedAt.IsZero() {
+			customer.CreatedAt = seededAt
+		}
+		if customer.UpdatedAt.IsZero() {
+			customer.UpdatedAt = customer.CreatedAt
+		}
+		r.customers[customer.CustomerID] = &customer
+		r.indexSegments(&customer)
+		r.indexEmail(&customer)
 	}
+}
 
-	return repo
+// sampleCustomers returns the customers an in-memory repository starts with when
+// no seed file is configured
+func sampleCustomers() []*Customer {
+	return []*Customer{
+		{CustomerID: "customer-456", Name: "Jane Doe", Status: "ACTIVE", Email: "jane.doe@example.com", Phone: "+14155550123", CreditLimit: 5000, Segments: []string{"vip", "newsletter"}},
+		{CustomerID: "customer-123", Name: "John Smith", Status: "ACTIVE", Email: "john.smith@example.com", CreditLimit: 1000, CurrentExposure: 250},
+		{CustomerID: "customer-789", Name: "Alice Johnson", Status: "SUSPENDED", CreditLimit: 500, CurrentExposure: 500},
+		{CustomerID: "customer-101", Name: "Bob Wilson", Status: "ACTIVE", CreditLimit: 2000, Segments: []string{"newsletter"}},
+		{CustomerID: "customer-202", Name: "Carol Brown", Status: "ACTIVE", CreditLimit: 1500},
+	}
 }
 
-// GetByID retrieves a customer by ID
+// GetByID retrieves a live customer by ID
 func (r *InMemoryRepository) GetByID(customerID string) (*Customer, error) {
+	customer, err := r.GetByIDIncludingDeleted(customerID)
+	if err != nil {
+		return nil, err
+	}
+	if customer.IsDeleted() {
+		return nil, ErrCustomerNotFound
+	}
+	return customer, nil
+}
+
+// GetByIDIncludingDeleted retrieves a customer by ID even if it is soft-deleted
+func (r *InMemoryRepository) GetByIDIncludingDeleted(customerID string) (*Customer, error) {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 
@@ -60,17 +220,80 @@ func (r *InMemoryRepository) GetByID(customerID string) (*Customer, error) {
 	return &customerCopy, nil
 }
 
+// GetByIDs retrieves the customers with the given IDs, skipping unknown ones
+func (r *InMemoryRepository) GetByIDs(customerIDs []string) ([]*Customer, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	customers := make([]*Customer, 0, len(customerIDs))
+	for _, customerID := range customerIDs {
+		if customer, exists := r.customers[customerID]; exists && !customer.IsDeleted() {
+			customerCopy := *customer
+			customers = append(customers, &customerCopy)
+		}
+	}
+
+	return customers, nil
+}
+
+// GetByEmail retrieves a live customer by email
+func (r *InMemoryRepository) GetByEmail(email string) (*Customer, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	customer, exists := r.customers[r.emailIndex[email]]
+	if email == "" || !exists || customer.IsDeleted() {
+		return nil, ErrCustomerNotFound
+	}
+
+	customerCopy := *customer
+	return &customerCopy, nil
+}
+
+// RecordEventsTo records every change of a customer in store, under the
+// lock that stores the change. Address changes are not recorded.
+func (r *InMemoryRepository) RecordEventsTo(store *outbox.InMemoryStore) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.events = store
+}
+
+// record adds an event for a change of customer when events are recorded;
+// callers hold the write lock
+func (r *InMemoryRepository) record(action string, customer *Customer) error {
+	if r.events == nil {
+		return nil
+	}
+	return r.events.Record(outbox.Change{
+		Aggregate:   outbox.AggregateCustomer,
+		AggregateID: customer.CustomerID,
+		Action:      action,
+		Data:        customer,
+	})
+}
+
 // Create adds a new customer
 func (r *InMemoryRepository) Create(customer *Customer) error {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
+	return r.create(customer)
+}
+
+// create adds a new customer; callers hold the write lock
+func (r *InMemoryRepository) create(customer *Customer) error {
 	if _, exists := r.customers[customer.CustomerID]; exists {
-		return errors.New("customer already exists")
+		return ErrCustomerExists
+	}
+	if r.emailTaken(customer) {
+		return ErrEmailExists
 	}
 
 	r.customers[customer.CustomerID] = customer
-	return nil
+	r.indexSegments(customer)
+	r.indexEmail(customer)
+	return r.record(outbox.ActionCreated, customer)
 }
 
 // Update modifies an existing customer
@@ -78,37 +301,400 @@ func (r *InMemoryRepository) Update(customer *Customer) error {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	if _, exists := r.customers[customer.CustomerID]; !exists {
+	return r.update(customer)
+}
+
+// update replaces an existing customer; callers hold the write lock
+func (r *InMemoryRepository) update(customer *Customer) error {
+	existing, exists := r.customers[customer.CustomerID]
+	if !exists || existing.IsDeleted() {
 		return ErrCustomerNotFound
 	}
+	if r.emailTaken(customer) {
+		return ErrEmailExists
+	}
 
+	r.unindexSegments(existing)
+	delete(r.emailIndex, existing.Email)
+	customer.CurrentExposure = existing.CurrentExposure
 	r.customers[customer.CustomerID] = customer
+	r.indexSegments(customer)
+	r.indexEmail(customer)
+	return r.record(outbox.ActionUpdated, customer)
+}
+
+// WriteAll applies every write or, when one would fail, none of them
+func (r *InMemoryRepository) WriteAll(writes []Write) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	// Check every write before applying any, accounting for the customers
+	// and emails written earlier in the same call
+	created := make(map[string]bool, len(writes))
+	// owners maps emails written so far to their customer, or to "" once released
+	owners := make(map[string]string)
+	// emails maps customers written so far to their email
+	emails := make(map[string]string)
+	for i, write := range writes {
+		customer := write.Customer
+		existing, exists := r.customers[customer.CustomerID]
+		switch {
+		case write.Create && (exists || created[customer.CustomerID]):
+			return &WriteError{Index: i, Err: ErrCustomerExists}
+		case !write.Create && !created[customer.CustomerID] && (!exists || existing.IsDeleted()):
+			return &WriteError{Index: i, Err: ErrCustomerNotFound}
+		}
+
+		owner, written := owners[customer.Email]
+		if !written {
+			owner = r.emailIndex[customer.Email]
+		}
+		if customer.Email != "" && owner != "" && owner != customer.CustomerID {
+			return &WriteError{Index: i, Err: ErrEmailExists}
+		}
+
+		previous, written := emails[customer.CustomerID]
+		if !written && exists {
+			previous = existing.Email
+		}
+		if previous != "" {
+			owners[previous] = ""
+		}
+		if customer.Email != "" {
+			owners[customer.Email] = customer.CustomerID
+		}
+		emails[customer.CustomerID] = customer.Email
+		created[customer.CustomerID] = created[customer.CustomerID] || write.Create
+	}
+
+	for i, write := range writes {
+		apply := r.update
+		if write.Create {
+			apply = r.create
+		}
+		if err := apply(write.Customer); err != nil {
+			return &WriteError{Index: i, Err: err}
+		}
+	}
 	return nil
 }
 
-// Delete removes a customer
+// Delete soft-deletes a customer by stamping DeletedAt
 func (r *InMemoryRepository) Delete(customerID string) error {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	if _, exists := r.customers[customerID]; !exists {
+	existing, exists := r.customers[customerID]
+	if !exists || existing.IsDeleted() {
+		return ErrCustomerNotFound
+	}
+
+	deleted := *existing
+	deletedAt := time.Now().UTC()
+	deleted.DeletedAt = &deletedAt
+	r.customers[customerID] = &deleted
+	return r.record(outbox.ActionDeleted, &deleted)
+}
+
+// Restore clears DeletedAt on a soft-deleted customer
+func (r *InMemoryRepository) Restore(customerID string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	existing, exists := r.customers[customerID]
+	if !exists {
+		return ErrCustomerNotFound
+	}
+	if !existing.IsDeleted() {
+		return ErrCustomerNotDeleted
+	}
+
+	restored := *existing
+	restored.DeletedAt = nil
+	r.customers[customerID] = &restored
+	return r.record(outbox.ActionRestored, &restored)
+}
+
+// RewritePersonalData replaces the name, email and phone of a customer, even
+// a soft-deleted one, without recording an event
+func (r *InMemoryRepository) RewritePersonalData(customer *Customer) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	existing, exists := r.customers[customer.CustomerID]
+	if !exists {
 		return ErrCustomerNotFound
 	}
+	if r.emailTaken(customer) {
+		return ErrEmailExists
+	}
 
-	delete(r.customers, customerID)
+	rewritten := *existing
+	rewritten.Name, rewritten.Email, rewritten.Phone = customer.Name, customer.Email, customer.Phone
+	delete(r.emailIndex, existing.Email)
+	r.customers[customer.CustomerID] = &rewritten
+	r.indexEmail(&rewritten)
 	return nil
 }
 
-// List returns all customers
+// List returns all live customers
 func (r *InMemoryRepository) List() ([]*Customer, error) {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 
 	customers := make([]*Customer, 0, len(r.customers))
 	for _, customer := range r.customers {
+		if customer.IsDeleted() {
+			continue
+		}
 		customerCopy := *customer
 		customers = append(customers, &customerCopy)
 	}
 
 	return customers, nil
 }
+
+// Find returns the customers matching filter, ordered by its Sort and then
+// by CustomerID. Segment filters are answered from the segment index.
+func (r *InMemoryRepository) Find(filter CustomerFilter) ([]*Customer, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	customers := make([]*Customer, 0)
+	for _, customer := range r.candidates(filter) {
+		customerCopy := *customer
+		customers = append(customers, &customerCopy)
+	}
+
+	slices.SortFunc(customers, filter.Compare)
+
+	return paginate(customers, filter.Limit, filter.Offset), nil
+}
+
+// Count returns the number of customers matching filter, ignoring Limit and Offset
+func (r *InMemoryRepository) Count(filter CustomerFilter) (int, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return len(r.candidates(filter)), nil
+}
+
+// candidates returns the stored customers matching filter; callers hold the read lock
+func (r *InMemoryRepository) candidates(filter CustomerFilter) []*Customer {
+	var matches []*Customer
+
+	if filter.Segment != "" {
+		for id := range r.segmentIndex[filter.Segment] {
+			if customer := r.customers[id]; filter.Matches(customer) {
+				matches = append(matches, customer)
+			}
+		}
+		return matches
+	}
+
+	for _, customer := range r.customers {
+		if filter.Matches(customer) {
+			matches = append(matches, customer)
+		}
+	}
+	return matches
+}
+
+// AdjustExposure adds change.Delta to a live customer's exposure
+func (r *InMemoryRepository) AdjustExposure(customerID string, change ExposureChange) (*Customer, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	existing, exists := r.customers[customerID]
+	if !exists || existing.IsDeleted() {
+		return nil, ErrCustomerNotFound
+	}
+
+	exposure := roundAmount(existing.CurrentExposure + change.Delta)
+	if change.Delta > 0 && exposure > existing.CreditLimit {
+		return nil, ErrCreditLimitExceeded
+	}
+	if exposure < 0 {
+		return nil, ErrExposureBelowZero
+	}
+
+	adjusted := *existing
+	adjusted.CurrentExposure = exposure
+	adjusted.UpdatedAt, adjusted.UpdatedBy = change.UpdatedAt, change.UpdatedBy
+	r.customers[customerID] = &adjusted
+	if err := r.record(outbox.ActionUpdated, &adjusted); err != nil {
+		return nil, err
+	}
+
+	customerCopy := adjusted
+	return &customerCopy, nil
+}
+
+// Addresses returns the addresses of a live customer ordered by type, then
+// creation time; unknown and deleted customers have none
+func (r *InMemoryRepository) Addresses(customerID string) ([]*Address, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	addresses := make([]*Address, 0)
+	if !r.isLive(customerID) {
+		return addresses, nil
+	}
+	for _, address := range r.addresses {
+		if address.CustomerID == customerID {
+			addressCopy := *address
+			addresses = append(addresses, &addressCopy)
+		}
+	}
+
+	sort.Slice(addresses, func(i, j int) bool {
+		a, b := addresses[i], addresses[j]
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return a.AddressID < b.AddressID
+	})
+	return addresses, nil
+}
+
+// GetAddress retrieves one address of a live customer
+func (r *InMemoryRepository) GetAddress(customerID, addressID string) (*Address, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	address, err := r.liveAddress(customerID, addressID)
+	if err != nil {
+		return nil, err
+	}
+	addressCopy := *address
+	return &addressCopy, nil
+}
+
+// CreateAddress adds an address to a live customer
+func (r *InMemoryRepository) CreateAddress(address *Address) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if !r.isLive(address.CustomerID) {
+		return ErrCustomerNotFound
+	}
+	if _, exists := r.addresses[address.AddressID]; exists {
+		return ErrAddressExists
+	}
+
+	r.storeAddress(address)
+	return nil
+}
+
+// UpdateAddress replaces an address of a live customer
+func (r *InMemoryRepository) UpdateAddress(address *Address) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, err := r.liveAddress(address.CustomerID, address.AddressID); err != nil {
+		return err
+	}
+
+	r.storeAddress(address)
+	return nil
+}
+
+// DeleteAddress removes an address of a live customer
+func (r *InMemoryRepository) DeleteAddress(customerID, addressID string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, err := r.liveAddress(customerID, addressID); err != nil {
+		return err
+	}
+
+	delete(r.addresses, addressID)
+	return nil
+}
+
+// isLive reports whether a customer exists and is not deleted; callers hold the lock
+func (r *InMemoryRepository) isLive(customerID string) bool {
+	customer, exists := r.customers[customerID]
+	return exists && !customer.IsDeleted()
+}
+
+// liveAddress returns the stored address if it belongs to the live customer;
+// callers hold the lock
+func (r *InMemoryRepository) liveAddress(customerID, addressID string) (*Address, error) {
+	address, exists := r.addresses[addressID]
+	if !exists || address.CustomerID != customerID || !r.isLive(customerID) {
+		return nil, ErrAddressNotFound
+	}
+	return address, nil
+}
+
+// storeAddress saves a copy of address, first clearing the default flag on
+// the customer's other addresses of its type if it is the default; callers
+// hold the write lock
+func (r *InMemoryRepository) storeAddress(address *Address) {
+	if address.IsDefault {
+		for id, other := range r.addresses {
+			if other.CustomerID == address.CustomerID && other.Type == address.Type && other.IsDefault && id != address.AddressID {
+				cleared := *other
+				cleared.IsDefault = false
+				r.addresses[id] = &cleared
+			}
+		}
+	}
+
+	addressCopy := *address
+	r.addresses[address.AddressID] = &addressCopy
+}
+
+// paginate returns the window of customers selected by limit and offset
+func paginate(customers []*Customer, limit, offset int) []*Customer {
+	if offset >= len(customers) {
+		return customers[:0]
+	}
+	customers = customers[offset:]
+
+	if limit > 0 && limit < len(customers) {
+		customers = customers[:limit]
+	}
+	return customers
+}
+
+// emailTaken reports whether another customer has the customer's email;
+// callers hold the lock
+func (r *InMemoryRepository) emailTaken(customer *Customer) bool {
+	owner, exists := r.emailIndex[customer.Email]
+	return customer.Email != "" && exists && owner != customer.CustomerID
+}
+
+// indexEmail adds the customer to the email index; callers hold the write lock
+func (r *InMemoryRepository) indexEmail(customer *Customer) {
+	if customer.Email != "" {
+		r.emailIndex[customer.Email] = customer.CustomerID
+	}
+}
+
+// indexSegments adds the customer to the segment index; callers hold the write lock
+func (r *InMemoryRepository) indexSegments(customer *Customer) {
+	for _, segment := range customer.Segments {
+		ids, ok := r.segmentIndex[segment]
+		if !ok {
+			ids = make(map[string]struct{})
+			r.segmentIndex[segment] = ids
+		}
+		ids[customer.CustomerID] = struct{}{}
+	}
+}
+
+// unindexSegments removes the customer from the segment index; callers hold the write lock
+func (r *InMemoryRepository) unindexSegments(customer *Customer) {
+	for _, segment := range customer.Segments {
+		ids := r.segmentIndex[segment]
+		delete(ids, customer.CustomerID)
+		if len(ids) == 0 {
+			delete(r.segmentIndex, segment)
+		}
+	}
+}
@@ -0,0 +1,710 @@
+package customer
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"enricher-api-go/internal/cache"
+	"enricher-api-go/internal/dynamo"
+	"enricher-api-go/internal/listing"
+	"enricher-api-go/internal/migrations"
+	"enricher-api-go/internal/outbox"
+	"enricher-api-go/internal/sqlite"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// testRepositoryConformance runs the behavior every Repository implementation
+// must share against a repository created by newRepo.
+func testRepositoryConformance(t *testing.T, newRepo func(t *testing.T) Repository) {
+	t.Run("Create and GetByID", func(t *testing.T) {
+		repo := newRepo(t)
+		customer := &Customer{CustomerID: "conformance-1", Name: "Dana Scully", Status: "ACTIVE", Segments: []string{"vip"}}
+
+		if err := repo.Create(customer); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		retrieved, err := repo.GetByID("conformance-1")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if retrieved.Name != "Dana Scully" || retrieved.Status != "ACTIVE" || !retrieved.HasSegment("vip") {
+			t.Errorf("Expected stored customer, got %+v", retrieved)
+		}
+	})
+
+	t.Run("Audit fields", func(t *testing.T) {
+		repo := newRepo(t)
+		created := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+		customer := &Customer{
+			CustomerID: "conformance-audit", Name: "Walter Skinner", Status: "ACTIVE",
+			CreatedAt: created, UpdatedAt: created, CreatedBy: "ci", UpdatedBy: "ci",
+		}
+		if err := repo.Create(customer); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		updated := created.Add(time.Hour)
+		customer.Status, customer.UpdatedAt, customer.UpdatedBy = "SUSPENDED", updated, "ops"
+		if err := repo.Update(customer); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		retrieved, err := repo.GetByID("conformance-audit")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if !retrieved.CreatedAt.Equal(created) || retrieved.CreatedBy != "ci" {
+			t.Errorf("Expected creation audit to be kept, got %s by %q", retrieved.CreatedAt, retrieved.CreatedBy)
+		}
+		if !retrieved.UpdatedAt.Equal(updated) || retrieved.UpdatedBy != "ops" {
+			t.Errorf("Expected update audit to be stored, got %s by %q", retrieved.UpdatedAt, retrieved.UpdatedBy)
+		}
+	})
+
+	t.Run("Create duplicate", func(t *testing.T) {
+		repo := newRepo(t)
+		customer := &Customer{CustomerID: "conformance-2", Name: "Fox Mulder", Status: "ACTIVE"}
+
+		if err := repo.Create(customer); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if err := repo.Create(customer); !errors.Is(err, ErrCustomerExists) {
+			t.Errorf("Expected ErrCustomerExists, got %v", err)
+		}
+	})
+
+	t.Run("GetByID missing", func(t *testing.T) {
+		repo := newRepo(t)
+
+		if _, err := repo.GetByID("conformance-missing"); !errors.Is(err, ErrCustomerNotFound) {
+			t.Errorf("Expected ErrCustomerNotFound, got %v", err)
+		}
+	})
+
+	t.Run("GetByIDs", func(t *testing.T) {
+		repo := newRepo(t)
+		for _, customer := range []*Customer{
+			{CustomerID: "conformance-batch-1", Name: "John Doggett", Status: "ACTIVE"},
+			{CustomerID: "conformance-batch-2", Name: "Monica Reyes", Status: "SUSPENDED"},
+		} {
+			if err := repo.Create(customer); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+		}
+
+		customers, err := repo.GetByIDs([]string{"conformance-batch-2", "conformance-missing", "conformance-batch-1"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		found := make(map[string]bool)
+		for _, customer := range customers {
+			found[customer.CustomerID] = true
+		}
+		if len(customers) != 2 || !found["conformance-batch-1"] || !found["conformance-batch-2"] {
+			t.Errorf("Expected both stored customers, got %v", found)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		repo := newRepo(t)
+		if err := repo.Create(&Customer{CustomerID: "conformance-3", Name: "Walter Skinner", Status: "ACTIVE"}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if err := repo.Update(&Customer{CustomerID: "conformance-3", Name: "Walter Skinner", Status: "SUSPENDED", Segments: []string{"churn-risk"}}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		retrieved, err := repo.GetByID("conformance-3")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if retrieved.Status != "SUSPENDED" || !retrieved.HasSegment("churn-risk") {
+			t.Errorf("Expected updated customer, got %+v", retrieved)
+		}
+
+		if err := repo.Update(&Customer{CustomerID: "conformance-missing"}); !errors.Is(err, ErrCustomerNotFound) {
+			t.Errorf("Expected ErrCustomerNotFound updating missing customer, got %v", err)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		repo := newRepo(t)
+		if err := repo.Create(&Customer{CustomerID: "conformance-4", Name: "John Doggett", Status: "ACTIVE"}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if err := repo.Delete("conformance-4"); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if _, err := repo.GetByID("conformance-4"); !errors.Is(err, ErrCustomerNotFound) {
+			t.Errorf("Expected ErrCustomerNotFound after delete, got %v", err)
+		}
+
+		if err := repo.Delete("conformance-4"); !errors.Is(err, ErrCustomerNotFound) {
+			t.Errorf("Expected ErrCustomerNotFound deleting twice, got %v", err)
+		}
+	})
+
+	t.Run("Soft delete and restore", func(t *testing.T) {
+		repo := newRepo(t)
+		if err := repo.Create(&Customer{CustomerID: "conformance-5", Name: "Monica Reyes", Status: "ACTIVE", Segments: []string{"conformance-deleted"}}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if err := repo.Restore("conformance-5"); !errors.Is(err, ErrCustomerNotDeleted) {
+			t.Errorf("Expected ErrCustomerNotDeleted restoring a live customer, got %v", err)
+		}
+		if err := repo.Delete("conformance-5"); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		deleted, err := repo.GetByIDIncludingDeleted("conformance-5")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if !deleted.IsDeleted() {
+			t.Error("Expected DeletedAt to be set")
+		}
+		if err := repo.Update(&Customer{CustomerID: "conformance-5", Name: "Monica Reyes", Status: "SUSPENDED"}); !errors.Is(err, ErrCustomerNotFound) {
+			t.Errorf("Expected ErrCustomerNotFound updating a deleted customer, got %v", err)
+		}
+		if customers, _ := repo.GetByIDs([]string{"conformance-5"}); len(customers) != 0 {
+			t.Errorf("Expected GetByIDs to skip deleted customers, got %v", customers)
+		}
+
+		segment := CustomerFilter{Segment: "conformance-deleted"}
+		if count, _ := repo.Count(segment); count != 0 {
+			t.Errorf("Expected deleted customers not to be counted, got %d", count)
+		}
+		segment.IncludeDeleted = true
+		if found, _ := repo.Find(segment); len(found) != 1 {
+			t.Errorf("Expected IncludeDeleted to find the deleted customer, got %v", found)
+		}
+
+		if err := repo.Restore("conformance-5"); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		restored, err := repo.GetByID("conformance-5")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if restored.IsDeleted() {
+			t.Error("Expected DeletedAt to be cleared")
+		}
+
+		if err := repo.Restore("conformance-missing"); !errors.Is(err, ErrCustomerNotFound) {
+			t.Errorf("Expected ErrCustomerNotFound restoring a missing customer, got %v", err)
+		}
+	})
+
+	t.Run("Rewrite personal data", func(t *testing.T) {
+		repo := newRepo(t)
+		rewriter, ok := repo.(PersonalDataRewriter)
+		if !ok {
+			t.Skip("repository does not rewrite personal data")
+		}
+		for _, customer := range []*Customer{
+			{CustomerID: "conformance-rw1", Name: "Walter Skinner", Email: "skinner@fbi.gov", Status: "ACTIVE"},
+			{CustomerID: "conformance-rw2", Name: "Alex Krycek", Email: "krycek@fbi.gov", Status: "ACTIVE"},
+		} {
+			if err := repo.Create(customer); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+		}
+		if err := repo.Delete("conformance-rw1"); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		deleted, _ := repo.GetByIDIncludingDeleted("conformance-rw1")
+
+		err := rewriter.RewritePersonalData(&Customer{CustomerID: "conformance-rw1", Name: "W. Skinner", Email: "ad@fbi.gov", Phone: "+12025550100"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		rewritten, err := repo.GetByIDIncludingDeleted("conformance-rw1")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if rewritten.Name != "W. Skinner" || rewritten.Email != "ad@fbi.gov" || rewritten.Phone != "+12025550100" || rewritten.Status != "ACTIVE" {
+			t.Errorf("Expected only the personal data rewritten, got %+v", rewritten)
+		}
+		if !rewritten.IsDeleted() || !rewritten.DeletedAt.Equal(*deleted.DeletedAt) || !rewritten.UpdatedAt.Equal(deleted.UpdatedAt) {
+			t.Errorf("Expected the deletion and update times kept, got %v and %v", rewritten.DeletedAt, rewritten.UpdatedAt)
+		}
+
+		if err := repo.Create(&Customer{CustomerID: "conformance-rw3", Name: "Walter Skinner", Email: "skinner@fbi.gov", Status: "ACTIVE"}); err != nil {
+			t.Errorf("Expected the old email released, got %v", err)
+		}
+		if err := rewriter.RewritePersonalData(&Customer{CustomerID: "conformance-rw2", Name: "Alex Krycek", Email: "ad@fbi.gov"}); !errors.Is(err, ErrEmailExists) {
+			t.Errorf("Expected ErrEmailExists for a taken email, got %v", err)
+		}
+		if err := rewriter.RewritePersonalData(&Customer{CustomerID: "conformance-missing", Name: "Nobody"}); !errors.Is(err, ErrCustomerNotFound) {
+			t.Errorf("Expected ErrCustomerNotFound for a missing customer, got %v", err)
+		}
+	})
+
+	t.Run("List, Find and Count", func(t *testing.T) {
+		repo := newRepo(t)
+		before, err := repo.List()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		for _, customer := range []*Customer{
+			{CustomerID: "conformance-5", Name: "Monica Reyes", Status: "ACTIVE", Segments: []string{"conformance-segment"}},
+			{CustomerID: "conformance-6", Name: "Alex Krycek", Status: "SUSPENDED"},
+		} {
+			if err := repo.Create(customer); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+		}
+
+		after, err := repo.List()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(after) != len(before)+2 {
+			t.Errorf("Expected %d customers, got %d", len(before)+2, len(after))
+		}
+
+		segment, err := repo.Find(CustomerFilter{Segment: "conformance-segment"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(segment) != 1 || segment[0].CustomerID != "conformance-5" {
+			t.Errorf("Expected only conformance-5 in segment, got %v", segment)
+		}
+
+		page, err := repo.Find(CustomerFilter{Limit: 1, Offset: len(before) + 1})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(page) != 1 {
+			t.Errorf("Expected a single customer on the last page, got %v", page)
+		}
+
+		total, err := repo.Count(CustomerFilter{Limit: 1})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if total != len(before)+2 {
+			t.Errorf("Expected count %d ignoring limit, got %d", len(before)+2, total)
+		}
+
+		suspended, err := repo.Find(CustomerFilter{Status: "SUSPENDED", Sort: listing.Sort{{Field: "name", Descending: true}}})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if !slices.ContainsFunc(suspended, func(c *Customer) bool { return c.CustomerID == "conformance-6" }) {
+			t.Errorf("Expected conformance-6 among the suspended customers, got %v", suspended)
+		}
+		for i, customer := range suspended {
+			if customer.Status != "SUSPENDED" || i > 0 && suspended[i-1].Name < customer.Name {
+				t.Errorf("Expected only suspended customers, by name descending, got %v", suspended)
+				break
+			}
+		}
+	})
+
+	t.Run("Find by credit limit and activity", func(t *testing.T) {
+		repo := newRepo(t)
+		longAgo := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		recently := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+		for _, customer := range []*Customer{
+			{CustomerID: "conformance-7", Name: "Walter Skinner", Status: "ACTIVE", CreditLimit: 90000, CreatedAt: longAgo, UpdatedAt: longAgo},
+			{CustomerID: "conformance-8", Name: "John Doggett", Status: "ACTIVE", CreditLimit: 10, CreatedAt: recently, UpdatedAt: recently},
+		} {
+			if err := repo.Create(customer); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+		}
+
+		for _, tc := range []struct {
+			name     string
+			filter   CustomerFilter
+			expected string
+		}{
+			{name: "MinCreditLimit", filter: CustomerFilter{MinCreditLimit: 90000}, expected: "conformance-7"},
+			{name: "CreatedAfter", filter: CustomerFilter{CreatedAfter: longAgo, UpdatedUntil: recently}, expected: "conformance-8"},
+			{name: "UpdatedUntil", filter: CustomerFilter{UpdatedUntil: longAgo}, expected: "conformance-7"},
+		} {
+			found, err := repo.Find(tc.filter)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if len(found) != 1 || found[0].CustomerID != tc.expected {
+				t.Errorf("Expected only %s matching %s, got %v", tc.expected, tc.name, found)
+			}
+		}
+	})
+
+	t.Run("Email lookup and uniqueness", func(t *testing.T) {
+		repo := newRepo(t)
+		for _, customer := range []*Customer{
+			{CustomerID: "conformance-mail-1", Name: "Fox Mulder", Status: "ACTIVE", Email: "fox@conformance.test", Phone: "+12025550143"},
+			{CustomerID: "conformance-mail-2", Name: "Jeffrey Spender", Status: "ACTIVE"},
+			{CustomerID: "conformance-mail-3", Name: "Marita Covarrubias", Status: "ACTIVE"},
+		} {
+			if err := repo.Create(customer); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+		}
+
+		retrieved, err := repo.GetByEmail("fox@conformance.test")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if retrieved.CustomerID != "conformance-mail-1" || retrieved.Phone != "+12025550143" {
+			t.Errorf("Expected conformance-mail-1 with its phone, got %+v", retrieved)
+		}
+		if _, err := repo.GetByEmail(""); !errors.Is(err, ErrCustomerNotFound) {
+			t.Errorf("Expected ErrCustomerNotFound for an empty email, got %v", err)
+		}
+
+		taken := &Customer{CustomerID: "conformance-mail-4", Name: "Diana Fowley", Status: "ACTIVE", Email: "fox@conformance.test"}
+		if err := repo.Create(taken); !errors.Is(err, ErrEmailExists) {
+			t.Errorf("Expected ErrEmailExists creating with a taken email, got %v", err)
+		}
+		if err := repo.Update(&Customer{CustomerID: "conformance-mail-2", Name: "Jeffrey Spender", Status: "ACTIVE", Email: "fox@conformance.test"}); !errors.Is(err, ErrEmailExists) {
+			t.Errorf("Expected ErrEmailExists updating to a taken email, got %v", err)
+		}
+
+		moved := &Customer{CustomerID: "conformance-mail-1", Name: "Fox Mulder", Status: "ACTIVE", Email: "mulder@conformance.test"}
+		if err := repo.Update(moved); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if _, err := repo.GetByEmail("fox@conformance.test"); !errors.Is(err, ErrCustomerNotFound) {
+			t.Errorf("Expected the old email to be released, got %v", err)
+		}
+		if err := repo.Update(&Customer{CustomerID: "conformance-mail-3", Name: "Marita Covarrubias", Status: "ACTIVE", Email: "fox@conformance.test"}); err != nil {
+			t.Errorf("Expected a released email to be reusable, got %v", err)
+		}
+
+		if err := repo.Delete("conformance-mail-1"); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if _, err := repo.GetByEmail("mulder@conformance.test"); !errors.Is(err, ErrCustomerNotFound) {
+			t.Errorf("Expected ErrCustomerNotFound for a deleted customer's email, got %v", err)
+		}
+		if err := repo.Create(&Customer{CustomerID: "conformance-mail-5", Name: "Alvin Kersh", Status: "ACTIVE", Email: "mulder@conformance.test"}); !errors.Is(err, ErrEmailExists) {
+			t.Errorf("Expected a deleted customer to keep its email, got %v", err)
+		}
+	})
+
+	t.Run("WriteAll", func(t *testing.T) {
+		repo := newRepo(t)
+		existing := &Customer{CustomerID: "conformance-bulk-1", Name: "Fox Mulder", Status: "ACTIVE", Email: "fox@example.com"}
+		if err := repo.Create(existing); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		// Moving an email to another customer within one call is allowed
+		err := repo.WriteAll([]Write{
+			{Customer: &Customer{CustomerID: "conformance-bulk-1", Name: "Fox Mulder", Status: "ACTIVE", Email: "mulder@example.com"}},
+			{Customer: &Customer{CustomerID: "conformance-bulk-2", Name: "Walter Skinner", Status: "ACTIVE", Email: "fox@example.com"}, Create: true},
+		})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if moved, err := repo.GetByEmail("fox@example.com"); err != nil || moved.CustomerID != "conformance-bulk-2" {
+			t.Errorf("Expected the email to belong to the created customer, got %+v, %v", moved, err)
+		}
+
+		err = repo.WriteAll([]Write{
+			{Customer: &Customer{CustomerID: "conformance-bulk-1", Name: "Fox W. Mulder", Status: "SUSPENDED", Email: "mulder@example.com"}},
+			{Customer: &Customer{CustomerID: "conformance-bulk-3", Name: "John Doggett", Status: "ACTIVE", Email: "mulder@example.com"}, Create: true},
+		})
+		var writeErr *WriteError
+		if !errors.As(err, &writeErr) || writeErr.Index != 1 || !errors.Is(err, ErrEmailExists) {
+			t.Fatalf("Expected ErrEmailExists for write 1, got %v", err)
+		}
+		if unchanged, err := repo.GetByID("conformance-bulk-1"); err != nil || unchanged.Status != "ACTIVE" {
+			t.Errorf("Expected the update rolled back, got %+v, %v", unchanged, err)
+		}
+		if _, err := repo.GetByID("conformance-bulk-3"); !errors.Is(err, ErrCustomerNotFound) {
+			t.Errorf("Expected the create rolled back, got %v", err)
+		}
+	})
+
+	t.Run("Exposure adjustments", func(t *testing.T) {
+		repo := newRepo(t)
+		customer := &Customer{CustomerID: "conformance-credit", Name: "Monica Reyes", Status: "ACTIVE", CreditLimit: 100}
+		if err := repo.Create(customer); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		stamped := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+		reserved, err := repo.AdjustExposure("conformance-credit", ExposureChange{Delta: 60.1, UpdatedAt: stamped, UpdatedBy: "orders"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if reserved.CurrentExposure != 60.1 || !reserved.UpdatedAt.Equal(stamped) || reserved.UpdatedBy != "orders" {
+			t.Errorf("Expected exposure 60.1 stamped by orders, got %+v", reserved)
+		}
+
+		if _, err := repo.AdjustExposure("conformance-credit", ExposureChange{Delta: 39.9}); err != nil {
+			t.Errorf("Expected an increase up to the limit to succeed, got %v", err)
+		}
+		if _, err := repo.AdjustExposure("conformance-credit", ExposureChange{Delta: 0.01}); !errors.Is(err, ErrCreditLimitExceeded) {
+			t.Errorf("Expected ErrCreditLimitExceeded past the limit, got %v", err)
+		}
+		if _, err := repo.AdjustExposure("conformance-credit", ExposureChange{Delta: -100.01}); !errors.Is(err, ErrExposureBelowZero) {
+			t.Errorf("Expected ErrExposureBelowZero below zero, got %v", err)
+		}
+
+		customer.CreditLimit, customer.CurrentExposure = 50, 0
+		if err := repo.Update(customer); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		released, err := repo.AdjustExposure("conformance-credit", ExposureChange{Delta: -30})
+		if err != nil {
+			t.Fatalf("Expected a decrease over a lowered limit to succeed, got %v", err)
+		}
+		if released.CreditLimit != 50 || released.CurrentExposure != 70 {
+			t.Errorf("Expected Update to keep the exposure and change the limit, got %+v", released)
+		}
+
+		if _, err := repo.AdjustExposure("conformance-missing", ExposureChange{Delta: 1}); !errors.Is(err, ErrCustomerNotFound) {
+			t.Errorf("Expected ErrCustomerNotFound, got %v", err)
+		}
+		if err := repo.Delete("conformance-credit"); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if _, err := repo.AdjustExposure("conformance-credit", ExposureChange{Delta: -1}); !errors.Is(err, ErrCustomerNotFound) {
+			t.Errorf("Expected ErrCustomerNotFound for a deleted customer, got %v", err)
+		}
+	})
+
+	t.Run("Addresses", func(t *testing.T) {
+		repo := newRepo(t)
+		if err := repo.Create(&Customer{CustomerID: "conformance-addr", Name: "John Doggett", Status: "ACTIVE"}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		created := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+		address := func(id, addressType string, isDefault bool, offset time.Duration) *Address {
+			return &Address{
+				AddressID: id, CustomerID: "conformance-addr", Type: addressType,
+				Line1: "1 Main St", City: "Springfield", PostalCode: "12345", Country: "US", IsDefault: isDefault,
+				CreatedAt: created.Add(offset), UpdatedAt: created.Add(offset),
+			}
+		}
+
+		for _, a := range []*Address{
+			address("conformance-ship-1", AddressShipping, true, 0),
+			address("conformance-bill-1", AddressBilling, true, time.Minute),
+			address("conformance-ship-2", AddressShipping, true, 2*time.Minute),
+		} {
+			if err := repo.CreateAddress(a); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+		}
+		if err := repo.CreateAddress(address("conformance-ship-1", AddressShipping, false, 0)); !errors.Is(err, ErrAddressExists) {
+			t.Errorf("Expected ErrAddressExists for a taken ID, got %v", err)
+		}
+		orphan := address("conformance-orphan", AddressShipping, false, 0)
+		orphan.CustomerID = "conformance-missing"
+		if err := repo.CreateAddress(orphan); !errors.Is(err, ErrCustomerNotFound) {
+			t.Errorf("Expected ErrCustomerNotFound for an unknown customer, got %v", err)
+		}
+
+		addresses, err := repo.Addresses("conformance-addr")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		var order []string
+		defaults := make(map[string]bool)
+		for _, a := range addresses {
+			order = append(order, a.AddressID)
+			defaults[a.AddressID] = a.IsDefault
+		}
+		if want := []string{"conformance-bill-1", "conformance-ship-1", "conformance-ship-2"}; strings.Join(order, ",") != strings.Join(want, ",") {
+			t.Errorf("Expected addresses ordered by type then creation %v, got %v", want, order)
+		}
+		if defaults["conformance-ship-1"] || !defaults["conformance-ship-2"] || !defaults["conformance-bill-1"] {
+			t.Errorf("Expected one default per type, got %v", defaults)
+		}
+
+		moved := address("conformance-ship-1", AddressShipping, true, 0)
+		moved.City = "Shelbyville"
+		if err := repo.UpdateAddress(moved); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		retrieved, err := repo.GetAddress("conformance-addr", "conformance-ship-1")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if retrieved.City != "Shelbyville" || !retrieved.IsDefault {
+			t.Errorf("Expected updated default address, got %+v", retrieved)
+		}
+		previous, err := repo.GetAddress("conformance-addr", "conformance-ship-2")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if previous.IsDefault {
+			t.Error("Expected the previous default to be cleared")
+		}
+
+		if err := repo.DeleteAddress("conformance-addr", "conformance-ship-2"); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if _, err := repo.GetAddress("conformance-addr", "conformance-ship-2"); !errors.Is(err, ErrAddressNotFound) {
+			t.Errorf("Expected ErrAddressNotFound after delete, got %v", err)
+		}
+		if _, err := repo.GetAddress("conformance-other", "conformance-ship-1"); !errors.Is(err, ErrAddressNotFound) {
+			t.Errorf("Expected ErrAddressNotFound for another customer's address, got %v", err)
+		}
+		if err := repo.UpdateAddress(address("conformance-missing", AddressShipping, false, 0)); !errors.Is(err, ErrAddressNotFound) {
+			t.Errorf("Expected ErrAddressNotFound from UpdateAddress, got %v", err)
+		}
+		if err := repo.DeleteAddress("conformance-addr", "conformance-missing"); !errors.Is(err, ErrAddressNotFound) {
+			t.Errorf("Expected ErrAddressNotFound from DeleteAddress, got %v", err)
+		}
+
+		if err := repo.Delete("conformance-addr"); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if addresses, err := repo.Addresses("conformance-addr"); err != nil || len(addresses) != 0 {
+			t.Errorf("Expected no addresses for a deleted customer, got %v, %v", addresses, err)
+		}
+	})
+}
+
+func TestInMemoryRepository_Conformance(t *testing.T) {
+	testRepositoryConformance(t, func(t *testing.T) Repository {
+		return NewInMemoryRepository()
+	})
+}
+
+func TestInMemoryRepository_RecordsEvents(t *testing.T) {
+	// Arrange
+	repo := NewInMemoryRepository()
+	events := outbox.NewInMemoryStore()
+	repo.RecordEventsTo(events)
+	customer := &Customer{CustomerID: "events-1", Name: "Fox Mulder", Status: "ACTIVE", CreditLimit: 100}
+
+	// Act
+	mustSucceed := func(err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	mustSucceed(repo.Create(customer))
+	mustSucceed(repo.Update(&Customer{CustomerID: "events-1", Name: "Fox W. Mulder", Status: "ACTIVE", CreditLimit: 100}))
+	_, err := repo.AdjustExposure("events-1", ExposureChange{Delta: 40})
+	mustSucceed(err)
+	_, refusedErr := repo.AdjustExposure("events-1", ExposureChange{Delta: 500})
+	mustSucceed(repo.Delete("events-1"))
+	mustSucceed(repo.Restore("events-1"))
+
+	// Assert
+	if !errors.Is(refusedErr, ErrCreditLimitExceeded) {
+		t.Fatalf("Expected the overdraft refused, got %v", refusedErr)
+	}
+	recorded := events.Events()
+	var types []string
+	for _, event := range recorded {
+		types = append(types, event.Type)
+		if event.AggregateID != "events-1" {
+			t.Errorf("Expected events of events-1, got %+v", event)
+		}
+	}
+	if got := strings.Join(types, " "); got != "customer.created customer.updated customer.updated customer.deleted customer.restored" {
+		t.Fatalf("Expected one event per stored change, got %s", got)
+	}
+	if !strings.Contains(string(recorded[2].Data), `"currentExposure":40`) || !strings.Contains(string(recorded[3].Data), `"deletedAt"`) {
+		t.Errorf("Expected events to carry the customer as stored, got %s and %s", recorded[2].Data, recorded[3].Data)
+	}
+}
+
+func TestCachedRepository_Conformance(t *testing.T) {
+	testRepositoryConformance(t, func(t *testing.T) Repository {
+		return NewCachedRepository(NewInMemoryRepository(), cache.NewLRU(100), time.Minute)
+	})
+}
+
+// TestPostgresRepository_Conformance runs against the database in
+// POSTGRES_TEST_DSN and is skipped when it is not set.
+func TestPostgresRepository_Conformance(t *testing.T) {
+	dsn := os.Getenv("POSTGRES_TEST_DSN")
+	if dsn == "" {
+		t.Skip("POSTGRES_TEST_DSN not set")
+	}
+
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := migrations.New(db)
+	if err != nil {
+		t.Fatalf("Failed to load migrations: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+
+	testRepositoryConformance(t, func(t *testing.T) Repository {
+		repo := NewPostgresRepository(db)
+		events := outbox.NewPostgresStore(db)
+		// Record events so changes run in outbox transactions
+		repo.RecordEventsTo(events)
+		if _, err := db.Exec(`TRUNCATE customers, customer_addresses, outbox_events`); err != nil {
+			t.Fatalf("Failed to reset customers: %v", err)
+		}
+		return repo
+	})
+}
+
+func TestSQLiteRepository_Conformance(t *testing.T) {
+	testRepositoryConformance(t, func(t *testing.T) Repository {
+		db, err := sqlite.Open(sqlite.InMemory)
+		if err != nil {
+			t.Fatalf("Failed to open database: %v", err)
+		}
+		t.Cleanup(func() { db.Close() })
+
+		repo := NewSQLiteRepository(db)
+		if err := repo.EnsureSchema(); err != nil {
+			t.Fatalf("Failed to create schema: %v", err)
+		}
+		return repo
+	})
+}
+
+// TestDynamoDBRepository_Conformance runs against the DynamoDB endpoint in
+// DYNAMODB_TEST_ENDPOINT, such as DynamoDB Local, and is skipped when it is
+// not set. Every test gets a table of its own.
+func TestDynamoDBRepository_Conformance(t *testing.T) {
+	endpoint := os.Getenv("DYNAMODB_TEST_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("DYNAMODB_TEST_ENDPOINT not set")
+	}
+
+	ctx := context.Background()
+	client, err := dynamo.Open(ctx, dynamo.Config{Region: "us-east-1", Endpoint: endpoint, MaxAttempts: 3, MaxBackoff: time.Second})
+	if err != nil {
+		t.Fatalf("Failed to open DynamoDB client: %v", err)
+	}
+
+	testRepositoryConformance(t, func(t *testing.T) Repository {
+		table := dynamo.NewTable(client, fmt.Sprintf("customers-%d", time.Now().UnixNano()))
+		if err := table.Ensure(ctx); err != nil {
+			t.Fatalf("Failed to create table: %v", err)
+		}
+		return NewDynamoDBRepository(table)
+	})
+}
@@ -0,0 +1,156 @@
+package customer
+
+import "enricher-api-go/internal/retry"
+
+// RetryRepository retries the calls of another Repository that fail
+// transiently, such as on a dropped database connection.
+//
+// Reads are retried on any transient failure, writes only on failures that
+// show nothing was written. Domain outcomes such as ErrCustomerNotFound are
+// returned at once.
+type RetryRepository struct {
+	repo    Repository
+	retrier *retry.Retrier
+}
+
+// NewRetryRepository wraps repo with retrier.
+//
+// Args:
+//   - repo: the repository to retry, typically PostgresRepository
+//   - retrier: the retrier shared by every repository using the same backend
+//
+// Returns:
+//   - *RetryRepository: the retrying repository
+func NewRetryRepository(repo Repository, retrier *retry.Retrier) *RetryRepository {
+	return &RetryRepository{repo: repo, retrier: retrier}
+}
+
+// GetByID retrieves a live customer by ID
+func (r *RetryRepository) GetByID(customerID string) (customer *Customer, err error) {
+	err = r.retrier.Read(func() error {
+		customer, err = r.repo.GetByID(customerID)
+		return err
+	})
+	return customer, err
+}
+
+// GetByIDIncludingDeleted retrieves a customer by ID even if it is soft-deleted
+func (r *RetryRepository) GetByIDIncludingDeleted(customerID string) (customer *Customer, err error) {
+	err = r.retrier.Read(func() error {
+		customer, err = r.repo.GetByIDIncludingDeleted(customerID)
+		return err
+	})
+	return customer, err
+}
+
+// GetByIDs retrieves the live customers with the given IDs
+func (r *RetryRepository) GetByIDs(customerIDs []string) (customers []*Customer, err error) {
+	err = r.retrier.Read(func() error {
+		customers, err = r.repo.GetByIDs(customerIDs)
+		return err
+	})
+	return customers, err
+}
+
+// GetByEmail retrieves a live customer by email
+func (r *RetryRepository) GetByEmail(email string) (customer *Customer, err error) {
+	err = r.retrier.Read(func() error {
+		customer, err = r.repo.GetByEmail(email)
+		return err
+	})
+	return customer, err
+}
+
+// Create adds a new customer
+func (r *RetryRepository) Create(customer *Customer) error {
+	return r.retrier.Write(func() error { return r.repo.Create(customer) })
+}
+
+// Update modifies an existing customer
+func (r *RetryRepository) Update(customer *Customer) error {
+	return r.retrier.Write(func() error { return r.repo.Update(customer) })
+}
+
+// WriteAll applies every write or none of them
+func (r *RetryRepository) WriteAll(writes []Write) error {
+	return r.retrier.Write(func() error { return r.repo.WriteAll(writes) })
+}
+
+// Delete soft-deletes a customer
+func (r *RetryRepository) Delete(customerID string) error {
+	return r.retrier.Write(func() error { return r.repo.Delete(customerID) })
+}
+
+// Restore undoes a soft delete
+func (r *RetryRepository) Restore(customerID string) error {
+	return r.retrier.Write(func() error { return r.repo.Restore(customerID) })
+}
+
+// List returns all live customers
+func (r *RetryRepository) List() (customers []*Customer, err error) {
+	err = r.retrier.Read(func() error {
+		customers, err = r.repo.List()
+		return err
+	})
+	return customers, err
+}
+
+// Find returns the customers matching filter
+func (r *RetryRepository) Find(filter CustomerFilter) (customers []*Customer, err error) {
+	err = r.retrier.Read(func() error {
+		customers, err = r.repo.Find(filter)
+		return err
+	})
+	return customers, err
+}
+
+// Count returns the number of customers matching filter
+func (r *RetryRepository) Count(filter CustomerFilter) (count int, err error) {
+	err = r.retrier.Read(func() error {
+		count, err = r.repo.Count(filter)
+		return err
+	})
+	return count, err
+}
+
+// AdjustExposure atomically adjusts a customer's exposure
+func (r *RetryRepository) AdjustExposure(customerID string, change ExposureChange) (customer *Customer, err error) {
+	err = r.retrier.Write(func() error {
+		customer, err = r.repo.AdjustExposure(customerID, change)
+		return err
+	})
+	return customer, err
+}
+
+// Addresses returns the addresses of a live customer
+func (r *RetryRepository) Addresses(customerID string) (addresses []*Address, err error) {
+	err = r.retrier.Read(func() error {
+		addresses, err = r.repo.Addresses(customerID)
+		return err
+	})
+	return addresses, err
+}
+
+// GetAddress retrieves one address of a live customer
+func (r *RetryRepository) GetAddress(customerID, addressID string) (address *Address, err error) {
+	err = r.retrier.Read(func() error {
+		address, err = r.repo.GetAddress(customerID, addressID)
+		return err
+	})
+	return address, err
+}
+
+// CreateAddress adds an address to a live customer
+func (r *RetryRepository) CreateAddress(address *Address) error {
+	return r.retrier.Write(func() error { return r.repo.CreateAddress(address) })
+}
+
+// UpdateAddress replaces an address of a live customer
+func (r *RetryRepository) UpdateAddress(address *Address) error {
+	return r.retrier.Write(func() error { return r.repo.UpdateAddress(address) })
+}
+
+// DeleteAddress removes an address of a live customer
+func (r *RetryRepository) DeleteAddress(customerID, addressID string) error {
+	return r.retrier.Write(func() error { return r.repo.DeleteAddress(customerID, addressID) })
+}
@@ -0,0 +1,77 @@
+package customer
+
+import (
+	"errors"
+	"syscall"
+	"testing"
+	"time"
+
+	"enricher-api-go/internal/retry"
+)
+
+// flakyRepository fails its first calls like a database whose connections
+// were just reset
+type flakyRepository struct {
+	Repository
+	failures int
+	calls    int
+}
+
+func (r *flakyRepository) GetByID(customerID string) (*Customer, error) {
+	r.calls++
+	if r.calls <= r.failures {
+		return nil, syscall.ECONNRESET
+	}
+	return r.Repository.GetByID(customerID)
+}
+
+func (r *flakyRepository) Update(customer *Customer) error {
+	r.calls++
+	if r.calls <= r.failures {
+		return syscall.ECONNRESET
+	}
+	return r.Repository.Update(customer)
+}
+
+func TestRetryRepository_Conformance(t *testing.T) {
+	testRepositoryConformance(t, func(t *testing.T) Repository {
+		return NewRetryRepository(NewInMemoryRepository(), retry.New("postgres", retry.Policy{}))
+	})
+}
+
+func TestRetryRepository_RetriesReads(t *testing.T) {
+	// Arrange
+	flaky := &flakyRepository{Repository: NewInMemoryRepository(), failures: 2}
+	retrier := retry.New("postgres", retry.Policy{MaxAttempts: 3, BaseDelay: time.Millisecond})
+	repo := NewRetryRepository(flaky, retrier)
+
+	// Act
+	customer, err := repo.GetByID("customer-456")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if customer.CustomerID != "customer-456" || flaky.calls != 3 {
+		t.Errorf("Expected the customer on the third call, got %+v after %d calls", customer, flaky.calls)
+	}
+	if stats := retrier.Stats(); stats.Retries != 2 || stats.Recovered != 1 {
+		t.Errorf("Expected 2 retries and 1 recovery, got %+v", stats)
+	}
+}
+
+func TestRetryRepository_DoesNotRetryAmbiguousWrites(t *testing.T) {
+	// Arrange
+	backing := NewInMemoryRepository()
+	customer, _ := backing.GetByID("customer-456")
+	flaky := &flakyRepository{Repository: backing, failures: 1}
+	repo := NewRetryRepository(flaky, retry.New("postgres", retry.Policy{MaxAttempts: 3, BaseDelay: time.Millisecond}))
+
+	// Act
+	err := repo.Update(customer)
+
+	// Assert
+	if !errors.Is(err, syscall.ECONNRESET) || flaky.calls != 1 {
+		t.Errorf("Expected a write whose outcome is unknown to fail at once, got %v after %d calls", err, flaky.calls)
+	}
+}
@@ -0,0 +1,82 @@
+package customer
+
+import (
+	"fmt"
+	"time"
+)
+
+// Computed segments.
+//
+// Unlike the segment tags assigned to a customer, these are derived from the
+// customer on every read by the service's SegmentRules, so a customer moves
+// in and out of them without being written.
+const (
+	// SegmentVIP is a customer with a high credit limit
+	SegmentVIP = "VIP"
+	// SegmentNew is a customer created recently
+	SegmentNew = "NEW"
+	// SegmentDormant is a customer that has not changed for a long time,
+	// including credit adjustments made by its orders
+	SegmentDormant = "DORMANT"
+)
+
+// ComputedSegments lists the segments SegmentRules can compute, in the order
+// they are reported
+var ComputedSegments = []string{SegmentVIP, SegmentNew, SegmentDormant}
+
+// SegmentRules decide the computed segments of customers. A zero threshold
+// disables its segment.
+//
+// Example usage:
+//
+//	rules := SegmentRules{VIPCreditLimit: 10000, NewFor: 7 * 24 * time.Hour}
+//	segments := rules.Evaluate(customer, time.Now()) // e.g. ["VIP"]
+type SegmentRules struct {
+	// VIPCreditLimit is the credit limit from which a customer is VIP
+	VIPCreditLimit float64
+	// NewFor is how long after its creation a customer is NEW
+	NewFor time.Duration
+	// DormantAfter is how long without a change makes a customer DORMANT
+	DormantAfter time.Duration
+}
+
+// DefaultSegmentRules are the rules of a service created without
+// WithSegmentRules
+var DefaultSegmentRules = SegmentRules{
+	VIPCreditLimit: 5000,
+	NewFor:         30 * 24 * time.Hour,
+	DormantAfter:   180 * 24 * time.Hour,
+}
+
+// Evaluate returns the computed segments of customer at now, in the order of
+// ComputedSegments, or nil if it is in none
+func (r SegmentRules) Evaluate(customer *Customer, now time.Time) []string {
+	var segments []string
+	if r.VIPCreditLimit > 0 && customer.CreditLimit >= r.VIPCreditLimit {
+		segments = append(segments, SegmentVIP)
+	}
+	if r.NewFor > 0 && customer.CreatedAt.After(now.Add(-r.NewFor)) {
+		segments = append(segments, SegmentNew)
+	}
+	if r.DormantAfter > 0 && !customer.UpdatedAt.After(now.Add(-r.DormantAfter)) {
+		segments = append(segments, SegmentDormant)
+	}
+	return segments
+}
+
+// narrow sets the range criteria of filter that match the customers in
+// segment at now, so repositories can select them without knowing the rules.
+// It fails with ErrInvalidFilter for an unknown or disabled segment.
+func (r SegmentRules) narrow(filter *CustomerFilter, segment string, now time.Time) error {
+	switch {
+	case segment == SegmentVIP && r.VIPCreditLimit > 0:
+		filter.MinCreditLimit = r.VIPCreditLimit
+	case segment == SegmentNew && r.NewFor > 0:
+		filter.CreatedAfter = now.Add(-r.NewFor)
+	case segment == SegmentDormant && r.DormantAfter > 0:
+		filter.UpdatedUntil = now.Add(-r.DormantAfter)
+	default:
+		return fmt.Errorf("%w: unknown computed segment %s", ErrInvalidFilter, segment)
+	}
+	return nil
+}
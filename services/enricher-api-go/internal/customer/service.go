@@ -6,8 +6,64 @@
 package customer
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"math"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+
+	"enricher-api-go/internal/apperr"
+	"enricher-api-go/internal/auth"
+	"enricher-api-go/internal/clock"
+	"enricher-api-go/internal/idgen"
+	"enricher-api-go/internal/logging"
+	"enricher-api-go/internal/validation"
+)
+
+// DefaultMaxSegments is the default maximum number of segments per customer.
+const DefaultMaxSegments = 10
+
+// DefaultMaxBatchSize is the default maximum number of IDs in a batch lookup.
+const DefaultMaxBatchSize = 100
+
+// DefaultMaxBulkSize is the default maximum number of items in a bulk write.
+const DefaultMaxBulkSize = 1000
+
+// ExportPageSize is the number of customers read at a time while exporting.
+const ExportPageSize = 500
+
+var (
+	// ErrInvalidSegment is returned when a segment tag is malformed.
+	ErrInvalidSegment = apperr.New(apperr.ErrValidation, "invalid segment")
+	// ErrTooManySegments is returned when a customer would exceed the segment limit.
+	ErrTooManySegments = apperr.New(apperr.ErrValidation, "too many segments")
+	// ErrInvalidFilter is returned when list filter, sort or pagination values are invalid.
+	ErrInvalidFilter = apperr.New(apperr.ErrValidation, "invalid filter")
+	// ErrInvalidBatch is returned when a batch lookup is empty or too large.
+	ErrInvalidBatch = apperr.New(apperr.ErrValidation, "invalid batch")
+	// ErrInvalidBulk is returned when a bulk write is empty or too large.
+	ErrInvalidBulk = apperr.New(apperr.ErrValidation, "invalid bulk write")
+	// ErrInvalidAddress is returned when an address fails validation.
+	ErrInvalidAddress = apperr.New(apperr.ErrValidation, "invalid address")
+	// ErrInvalidEmail is returned when an email is not an RFC 5322 addr-spec.
+	ErrInvalidEmail = apperr.New(apperr.ErrValidation, "invalid email")
+	// ErrInvalidPhone is returned when a phone number is not in E.164 format.
+	ErrInvalidPhone = apperr.New(apperr.ErrValidation, "invalid phone")
+	// ErrInvalidTransition is returned when a status change is not allowed by the customer lifecycle.
+	ErrInvalidTransition = apperr.New(apperr.ErrConflict, "invalid status transition")
+	// ErrInvalidAmount is returned when a credit amount is not a positive number.
+	ErrInvalidAmount = apperr.New(apperr.ErrValidation, "invalid amount")
+
+	// segmentPattern allows lowercase tags such as "vip" or "churn-risk".
+	segmentPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,31}$`)
+	// phonePattern is E.164: a plus sign and up to 15 digits, without a leading zero.
+	phonePattern = regexp.MustCompile(`^\+[1-9][0-9]{1,14}$`)
+	// phoneSeparators strips the spaces, dots, hyphens and parentheses people
+	// write phone numbers with.
+	phoneSeparators = strings.NewReplacer(" ", "", ".", "", "-", "", "(", "", ")", "")
 )
 
 // Service defines the business logic interface for customer operations.
@@ -18,7 +74,7 @@ import (
 // Example usage:
 //
 //	var customerService Service
-//	customer, err := customerService.GetCustomer("customer-12345")
+//	customer, err := customerService.GetCustomer(ctx, "customer-12345")
 //	if err != nil {
 //		// Handle error
 //	}
@@ -26,59 +82,348 @@ type Service interface {
 	// GetCustomer retrieves a customer by their unique identifier.
 	//
 	// Args:
+	//   - ctx: request context carrying the request-scoped logger
 	//   - customerID: the unique identifier of the customer
 	//
 	// Returns:
 	//   - *Customer: the customer if found
 	//   - error: error if customer not found or other issues occur
-	GetCustomer(customerID string) (*Customer, error)
+	GetCustomer(ctx context.Context, customerID string) (*Customer, error)
+
+	// GetCustomerIncludingDeleted retrieves a customer even if it is soft-deleted.
+	//
+	// Args:
+	//   - ctx: request context carrying the request-scoped logger
+	//   - customerID: the unique identifier of the customer
+	//
+	// Returns:
+	//   - *Customer: the customer if found, with DeletedAt set if soft-deleted
+	//   - error: error if customer not found or other issues occur
+	GetCustomerIncludingDeleted(ctx context.Context, customerID string) (*Customer, error)
+
+	// GetCustomerAsOf retrieves a customer as it was at an instant in the past,
+	// replayed from its recorded events.
+	//
+	// Args:
+	//   - ctx: request context carrying the request-scoped logger
+	//   - customerID: the unique identifier of the customer
+	//   - asOf: the instant to read at; it cannot be in the future
+	//
+	// Returns:
+	//   - *Customer: the customer as it was at asOf
+	//   - error: ErrHistoryNotRecorded if changes are not recorded as events,
+	//     or ErrCustomerNotFound if the customer was not live at asOf
+	GetCustomerAsOf(ctx context.Context, customerID string, asOf time.Time) (*Customer, error)
+
+	// GetCustomerByEmail retrieves a live customer by email address.
+	//
+	// Args:
+	//   - ctx: request context carrying the request-scoped logger
+	//   - email: the email address, matched case-insensitively
+	//
+	// Returns:
+	//   - *Customer: the customer if found
+	//   - error: ErrInvalidEmail if email is malformed, ErrCustomerNotFound if no customer has it
+	GetCustomerByEmail(ctx context.Context, email string) (*Customer, error)
+
+	// GetCustomers retrieves several customers by ID in one repository call.
+	//
+	// Args:
+	//   - ctx: request context carrying the request-scoped logger
+	//   - customerIDs: the unique identifiers of the customers
+	//
+	// Returns:
+	//   - *BatchResult: the customers found and a per-ID error for the rest
+	//   - error: ErrInvalidBatch if the batch is empty or too large, or a retrieval error
+	GetCustomers(ctx context.Context, customerIDs []string) (*BatchResult, error)
 
 	// CreateCustomer creates a new customer with the provided information.
 	//
 	// Args:
+	//   - ctx: request context carrying the request-scoped logger
 	//   - req: CustomerRequest containing customer details
 	//
 	// Returns:
 	//   - *Customer: the newly created customer
 	//   - error: error if creation fails
-	CreateCustomer(req CustomerRequest) (*Customer, error)
+	CreateCustomer(ctx context.Context, req CustomerRequest) (*Customer, error)
 
 	// UpdateCustomer updates an existing customer's information.
 	//
 	// Args:
+	//   - ctx: request context carrying the request-scoped logger
 	//   - customerID: the unique identifier of the customer to update
 	//   - req: CustomerRequest containing updated customer details
 	//
 	// Returns:
 	//   - *Customer: the updated customer
 	//   - error: error if update fails or customer not found
-	UpdateCustomer(customerID string, req CustomerRequest) (*Customer, error)
+	UpdateCustomer(ctx context.Context, customerID string, req CustomerRequest) (*Customer, error)
 
-	// DeleteCustomer removes a customer from the system.
+	// BulkWriteCustomers creates or updates many customers, reporting each item's outcome.
 	//
 	// Args:
+	//   - ctx: request context carrying the request-scoped logger
+	//   - req: BulkRequest listing the items and whether to write them all or nothing
+	//
+	// Returns:
+	//   - *BulkResult: the outcome of every item, in request order
+	//   - error: ErrInvalidBulk if the request is empty or too large, or a storage error
+	BulkWriteCustomers(ctx context.Context, req BulkRequest) (*BulkResult, error)
+
+	// PatchCustomer updates only the fields present in the patch.
+	//
+	// Args:
+	//   - ctx: request context carrying the request-scoped logger
+	//   - customerID: the unique identifier of the customer to update
+	//   - patch: CustomerPatch holding the fields to change
+	//
+	// Returns:
+	//   - *Customer: the updated customer
+	//   - error: error if the merged customer is invalid or the customer is not found
+	PatchCustomer(ctx context.Context, customerID string, patch CustomerPatch) (*Customer, error)
+
+	// TransitionCustomer moves a customer to another lifecycle status.
+	//
+	// Args:
+	//   - ctx: request context carrying the request-scoped logger
+	//   - customerID: the unique identifier of the customer
+	//   - status: the status to move to
+	//
+	// Returns:
+	//   - *Customer: the customer in its new status
+	//   - error: ErrInvalidTransition if the lifecycle does not allow the move,
+	//     or an error if the customer is not found
+	TransitionCustomer(ctx context.Context, customerID, status string) (*Customer, error)
+
+	// DeleteCustomer soft-deletes a customer so it can be restored later.
+	//
+	// Args:
+	//   - ctx: request context carrying the request-scoped logger
 	//   - customerID: the unique identifier of the customer to delete
 	//
 	// Returns:
 	//   - error: error if deletion fails or customer not found
-	DeleteCustomer(customerID string) error
+	DeleteCustomer(ctx context.Context, customerID string) error
+
+	// RestoreCustomer undoes a soft delete.
+	//
+	// Args:
+	//   - ctx: request context carrying the request-scoped logger
+	//   - customerID: the unique identifier of the customer to restore
+	//
+	// Returns:
+	//   - *Customer: the restored customer
+	//   - error: ErrCustomerNotFound, ErrCustomerNotDeleted or a storage error
+	RestoreCustomer(ctx context.Context, customerID string) (*Customer, error)
 
 	// ListCustomers retrieves all customers in the system.
 	//
 	// Returns:
 	//   - []*Customer: list of all customers
 	//   - error: error if retrieval fails
-	ListCustomers() ([]*Customer, error)
+	ListCustomers(ctx context.Context) ([]*Customer, error)
 
 	// IsCustomerActive checks if a customer is currently active.
 	//
 	// Args:
+	//   - ctx: request context carrying the request-scoped logger
 	//   - customerID: the unique identifier of the customer
 	//
 	// Returns:
 	//   - bool: true if customer is active, false otherwise
 	//   - error: error if check fails or customer not found
-	IsCustomerActive(customerID string) (bool, error)
+	IsCustomerActive(ctx context.Context, customerID string) (bool, error)
+
+	// ListCustomersBySegment retrieves all customers tagged with a segment.
+	//
+	// Args:
+	//   - ctx: request context carrying the request-scoped logger
+	//   - segment: the segment tag to filter by
+	//
+	// Returns:
+	//   - []*Customer: customers carrying the segment
+	//   - error: error if the segment is invalid or retrieval fails
+	ListCustomersBySegment(ctx context.Context, segment string) ([]*Customer, error)
+
+	// FindCustomers retrieves one page of the customers matching a filter.
+	//
+	// Args:
+	//   - ctx: request context carrying the request-scoped logger
+	//   - filter: CustomerFilter with the segment, status, sort and pagination criteria
+	//
+	// Returns:
+	//   - []*Customer: the matching customers in the filter's sort order, then by ID
+	//   - error: error if the filter is invalid or retrieval fails
+	FindCustomers(ctx context.Context, filter CustomerFilter) ([]*Customer, error)
+
+	// CountCustomers counts the customers matching a filter across all pages.
+	//
+	// Args:
+	//   - ctx: request context carrying the request-scoped logger
+	//   - filter: CustomerFilter whose Limit and Offset are ignored
+	//
+	// Returns:
+	//   - int: the number of matching customers
+	//   - error: error if the filter is invalid or counting fails
+	CountCustomers(ctx context.Context, filter CustomerFilter) (int, error)
+
+	// ExportCustomers passes every customer matching a filter to emit, a page at a time.
+	//
+	// Args:
+	//   - ctx: request context carrying the request-scoped logger
+	//   - filter: CustomerFilter whose Limit and Offset are ignored
+	//   - emit: called with each page of customers; an error stops the export
+	//
+	// Returns:
+	//   - error: error if the filter is invalid, retrieval fails or emit fails
+	ExportCustomers(ctx context.Context, filter CustomerFilter, emit func([]*Customer) error) error
+
+	// AddSegment tags a customer with a segment.
+	//
+	// Args:
+	//   - ctx: request context carrying the request-scoped logger
+	//   - customerID: the unique identifier of the customer
+	//   - segment: the segment tag to add
+	//
+	// Returns:
+	//   - *Customer: the updated customer
+	//   - error: error if the segment is invalid, the limit is exceeded or the customer is not found
+	AddSegment(ctx context.Context, customerID, segment string) (*Customer, error)
+
+	// RemoveSegment removes a segment tag from a customer.
+	//
+	// Args:
+	//   - ctx: request context carrying the request-scoped logger
+	//   - customerID: the unique identifier of the customer
+	//   - segment: the segment tag to remove
+	//
+	// Returns:
+	//   - *Customer: the updated customer
+	//   - error: error if the customer is not found
+	RemoveSegment(ctx context.Context, customerID, segment string) (*Customer, error)
+
+	// ListAddresses returns a customer's address book.
+	//
+	// Args:
+	//   - ctx: request context carrying the request-scoped logger
+	//   - customerID: the unique identifier of the customer
+	//
+	// Returns:
+	//   - []*Address: the addresses ordered by type, then creation time
+	//   - error: error if the customer is not found or retrieval fails
+	ListAddresses(ctx context.Context, customerID string) ([]*Address, error)
+
+	// GetAddress retrieves one address of a customer.
+	//
+	// Args:
+	//   - ctx: request context carrying the request-scoped logger
+	//   - customerID: the unique identifier of the customer
+	//   - addressID: the unique identifier of the address
+	//
+	// Returns:
+	//   - *Address: the address if found
+	//   - error: ErrAddressNotFound if the customer has no such address
+	GetAddress(ctx context.Context, customerID, addressID string) (*Address, error)
+
+	// DefaultAddress retrieves a customer's default address of one type.
+	//
+	// Args:
+	//   - ctx: request context carrying the request-scoped logger
+	//   - customerID: the unique identifier of the customer
+	//   - addressType: AddressShipping or AddressBilling
+	//
+	// Returns:
+	//   - *Address: the default address
+	//   - error: ErrAddressNotFound if the customer has no default of that type
+	DefaultAddress(ctx context.Context, customerID, addressType string) (*Address, error)
+
+	// CreateAddress adds an address to a customer's address book.
+	//
+	// Args:
+	//   - ctx: request context carrying the request-scoped logger
+	//   - customerID: the unique identifier of the customer
+	//   - req: AddressRequest containing the address details
+	//
+	// Returns:
+	//   - *Address: the newly created address
+	//   - error: error if the request is invalid or the customer is not found
+	CreateAddress(ctx context.Context, customerID string, req AddressRequest) (*Address, error)
+
+	// UpdateAddress replaces an address in a customer's address book.
+	//
+	// Args:
+	//   - ctx: request context carrying the request-scoped logger
+	//   - customerID: the unique identifier of the customer
+	//   - addressID: the unique identifier of the address
+	//   - req: AddressRequest containing the new address details
+	//
+	// Returns:
+	//   - *Address: the updated address
+	//   - error: error if the request is invalid or the address is not found
+	UpdateAddress(ctx context.Context, customerID, addressID string, req AddressRequest) (*Address, error)
+
+	// DeleteAddress removes an address from a customer's address book.
+	//
+	// Args:
+	//   - ctx: request context carrying the request-scoped logger
+	//   - customerID: the unique identifier of the customer
+	//   - addressID: the unique identifier of the address
+	//
+	// Returns:
+	//   - error: ErrAddressNotFound if the customer has no such address
+	DeleteAddress(ctx context.Context, customerID, addressID string) error
+
+	// CheckCredit reports whether a customer has enough credit left for an amount.
+	//
+	// Args:
+	//   - ctx: request context carrying the request-scoped logger
+	//   - customerID: the unique identifier of the customer
+	//   - amount: the order value to check
+	//
+	// Returns:
+	//   - *CreditCheck: the customer's credit position and whether amount is approved
+	//   - error: ErrInvalidAmount if amount is not positive, or an error if the customer is not found
+	CheckCredit(ctx context.Context, customerID string, amount float64) (*CreditCheck, error)
+
+	// ReserveCredit atomically adds an amount to a customer's exposure.
+	//
+	// Args:
+	//   - ctx: request context carrying the request-scoped logger
+	//   - customerID: the unique identifier of the customer
+	//   - req: CreditRequest holding the amount to reserve
+	//
+	// Returns:
+	//   - *Customer: the customer with its new exposure
+	//   - error: ErrCreditLimitExceeded if the amount does not fit within the limit,
+	//     or an error if the request is invalid or the customer is not found
+	ReserveCredit(ctx context.Context, customerID string, req CreditRequest) (*Customer, error)
+
+	// ReleaseCredit atomically removes an amount from a customer's exposure.
+	//
+	// Args:
+	//   - ctx: request context carrying the request-scoped logger
+	//   - customerID: the unique identifier of the customer
+	//   - req: CreditRequest holding the amount to release
+	//
+	// Returns:
+	//   - *Customer: the customer with its new exposure
+	//   - error: ErrExposureBelowZero if the amount exceeds the exposure,
+	//     or an error if the request is invalid or the customer is not found
+	ReleaseCredit(ctx context.Context, customerID string, req CreditRequest) (*Customer, error)
+
+	// CustomerHistory replays the recorded events of a customer.
+	//
+	// Args:
+	//   - ctx: request context carrying the request-scoped logger
+	//   - customerID: the unique identifier of the customer
+	//   - until: the instant to replay to; nil replays every event
+	//
+	// Returns:
+	//   - *History: the customer's events up to until and the customer they leave
+	//   - error: ErrHistoryNotRecorded if changes are not recorded as events,
+	//     or ErrCustomerNotFound if the customer did not exist by until
+	CustomerHistory(ctx context.Context, customerID string, until *time.Time) (*History, error)
 }
 
 // CustomerService implements the Service interface for customer operations.
@@ -90,9 +435,137 @@ type Service interface {
 //
 //	repo := customer.NewRepository()
 //	service := customer.NewService(repo)
-//	customer, err := service.GetCustomer("customer-12345")
+//	customer, err := service.GetCustomer(ctx, "customer-12345")
 type CustomerService struct {
-	repo Repository
+	repo         Repository
+	maxSegments  int
+	maxBatchSize int
+	maxBulkSize  int
+	idGenerator  idgen.Generator
+	clock        clock.Clock
+	// history replays customer events; nil when they are not recorded
+	history      HistorySource
+	segmentRules SegmentRules
+	// loyaltyTier looks up loyalty tiers; nil when they are not kept
+	loyaltyTier TierFunc
+}
+
+// Option configures optional CustomerService behavior.
+type Option func(*CustomerService)
+
+// WithMaxSegments sets the maximum number of segments a customer may carry.
+//
+// Args:
+//   - limit: the maximum segment count; values below 1 keep the default
+//
+// Returns:
+//   - Option: option to pass to NewService
+func WithMaxSegments(limit int) Option {
+	return func(s *CustomerService) {
+		if limit > 0 {
+			s.maxSegments = limit
+		}
+	}
+}
+
+// WithMaxBatchSize sets the maximum number of IDs accepted by GetCustomers.
+//
+// Args:
+//   - limit: the maximum batch size; values below 1 keep the default
+//
+// Returns:
+//   - Option: option to pass to NewService
+func WithMaxBatchSize(limit int) Option {
+	return func(s *CustomerService) {
+		if limit > 0 {
+			s.maxBatchSize = limit
+		}
+	}
+}
+
+// WithMaxBulkSize sets the maximum number of items accepted by BulkWriteCustomers.
+//
+// Args:
+//   - limit: the maximum bulk size; values below 1 keep the default
+//
+// Returns:
+//   - Option: option to pass to NewService
+func WithMaxBulkSize(limit int) Option {
+	return func(s *CustomerService) {
+		if limit > 0 {
+			s.maxBulkSize = limit
+		}
+	}
+}
+
+// WithIDGenerator sets the generator used for new customer IDs.
+//
+// Args:
+//   - gen: the ID generator; UUIDs are used by default
+//
+// Returns:
+//   - Option: option to pass to NewService
+func WithIDGenerator(gen idgen.Generator) Option {
+	return func(s *CustomerService) {
+		s.idGenerator = gen
+	}
+}
+
+// WithClock sets the clock used for CreatedAt and UpdatedAt.
+//
+// Args:
+//   - c: the clock; the UTC wall clock is used by default
+//
+// Returns:
+//   - Option: option to pass to NewService
+func WithClock(c clock.Clock) Option {
+	return func(s *CustomerService) {
+		s.clock = c
+	}
+}
+
+// WithHistory sets the source of customer event histories.
+//
+// Args:
+//   - source: usually the EventSourcedRepository the service writes to
+//
+// Returns:
+//   - Option: option to pass to NewService
+func WithHistory(source HistorySource) Option {
+	return func(s *CustomerService) {
+		s.history = source
+	}
+}
+
+// WithSegmentRules sets the rules that compute the segments of customers.
+//
+// Args:
+//   - rules: the thresholds of each computed segment; zero disables one
+//
+// Returns:
+//   - Option: option to pass to NewService
+func WithSegmentRules(rules SegmentRules) Option {
+	return func(s *CustomerService) {
+		s.segmentRules = rules
+	}
+}
+
+// TierFunc returns the loyalty tier of a customer
+type TierFunc func(ctx context.Context, customerID string) (string, error)
+
+// WithLoyaltyTier sets how GetCustomer looks up the loyalty tier of a
+// customer. A failed lookup is logged and leaves the tier out rather than
+// failing the read.
+//
+// Args:
+//   - tier: usually the Tier method of the loyalty service
+//
+// Returns:
+//   - Option: option to pass to NewService
+func WithLoyaltyTier(tier TierFunc) Option {
+	return func(s *CustomerService) {
+		s.loyaltyTier = tier
+	}
 }
 
 // NewService creates a new customer service instance.
@@ -102,6 +575,7 @@ type CustomerService struct {
 //
 // Args:
 //   - repo: Repository implementation for data access
+//   - opts: optional behavior overrides such as WithMaxSegments
 //
 // Returns:
 //   - *CustomerService: new customer service instance
@@ -110,18 +584,31 @@ type CustomerService struct {
 //
 //	repo := customer.NewRepository()
 //	service := customer.NewService(repo)
-func NewService(repo Repository) *CustomerService {
-	return &CustomerService{
-		repo: repo,
+func NewService(repo Repository, opts ...Option) *CustomerService {
+	s := &CustomerService{
+		repo:         repo,
+		maxSegments:  DefaultMaxSegments,
+		maxBatchSize: DefaultMaxBatchSize,
+		maxBulkSize:  DefaultMaxBulkSize,
+		idGenerator:  idgen.UUIDGenerator{},
+		clock:        clock.System{},
+		segmentRules: DefaultSegmentRules,
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
 }
 
 // GetCustomer retrieves a customer by their unique identifier.
 //
 // This method validates the customer ID and retrieves the customer from
 // the repository. It includes comprehensive error handling and logging.
+// The customer carries its computed segments and, with WithLoyaltyTier, its
+// loyalty tier.
 //
 // Args:
+//   - ctx: request context carrying the request-scoped logger
 //   - customerID: the unique identifier of the customer
 //
 // Returns:
@@ -130,84 +617,273 @@ func NewService(repo Repository) *CustomerService {
 //
 // Example usage:
 //
-//	customer, err := service.GetCustomer("customer-12345")
+//	customer, err := service.GetCustomer(ctx, "customer-12345")
 //	if err != nil {
 //		log.Printf("Failed to get customer: %v", err)
 //		return
 //	}
 //	log.Printf("Retrieved customer: %s", customer.Name)
-func (s *CustomerService) GetCustomer(customerID string) (*Customer, error) {
-	log.Printf("Getting customer with ID: %s", customerID)
+func (s *CustomerService) GetCustomer(ctx context.Context, customerID string) (*Customer, error) {
+	customer, err := s.getCustomer(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	customer = s.withComputedSegments(customer)
+	if s.loyaltyTier != nil {
+		tier, err := s.loyaltyTier(ctx, customerID)
+		if err != nil {
+			logging.FromContext(ctx).Warn("Serving customer without loyalty tier", "customer_id", customerID, "error", err)
+		}
+		customer.LoyaltyTier = tier
+	}
+	return customer, nil
+}
+
+// getCustomer retrieves a live customer as stored, without computed
+// segments, for the writes that change it
+func (s *CustomerService) getCustomer(ctx context.Context, customerID string) (*Customer, error) {
+	logger := logging.FromContext(ctx)
+	logger.Debug("Getting customer", "customer_id", customerID)
 
 	if customerID == "" {
-		return nil, fmt.Errorf("customer ID cannot be empty")
+		return nil, apperr.New(apperr.ErrValidation, "customer ID cannot be empty")
 	}
 
 	customer, err := s.repo.GetByID(customerID)
 	if err != nil {
-		log.Printf("Error getting customer %s: %v", customerID, err)
+		logger.Warn("Failed to get customer", "customer_id", customerID, "error", err)
 		return nil, fmt.Errorf("failed to get customer: %w", err)
 	}
 
-	log.Printf("Successfully retrieved customer: %s", customer.Name)
+	logger.Debug("Retrieved customer", "customer_id", customerID)
 	return customer, nil
 }
 
-// CreateCustomer creates a new customer with the provided information.
+// GetCustomerIncludingDeleted retrieves a customer even if it is soft-deleted.
 //
-// This method validates the customer request, generates a unique ID,
-// creates the customer entity, and persists it to the repository.
+// It backs admin reads with includeDeleted=true; regular reads should use
+// GetCustomer, which hides soft-deleted customers.
 //
 // Args:
-//   - req: CustomerRequest containing customer details
+//   - ctx: request context carrying the request-scoped logger
+//   - customerID: the unique identifier of the customer
 //
 // Returns:
-//   - *Customer: the newly created customer
-//   - error: error if creation fails
-//
-// Example usage:
-//
-//	req := CustomerRequest{
-//		Name:   "John Doe",
-//		Status: "ACTIVE",
-//	}
-//	customer, err := service.CreateCustomer(req)
-//	if err != nil {
-//		log.Printf("Failed to create customer: %v", err)
-//		return
-//	}
-//	log.Printf("Created customer with ID: %s", customer.CustomerID)
-func (s *CustomerService) CreateCustomer(req CustomerRequest) (*Customer, error) {
-	log.Printf("Creating new customer: %s", req.Name)
+//   - *Customer: the customer if found, with DeletedAt set if soft-deleted
+//   - error: error if customer not found or other issues occur
+func (s *CustomerService) GetCustomerIncludingDeleted(ctx context.Context, customerID string) (*Customer, error) {
+	logger := logging.FromContext(ctx)
+	logger.Debug("Getting customer including deleted", "customer_id", customerID)
 
-	if err := s.validateCustomerRequest(req); err != nil {
-		return nil, fmt.Errorf("validation failed: %w", err)
+	if customerID == "" {
+		return nil, apperr.New(apperr.ErrValidation, "customer ID cannot be empty")
 	}
 
-	// Generate a simple ID (in production, use UUID)
-	customerID := fmt.Sprintf("customer-%d", len(req.Name)*100+len(req.Status))
-
-	customer := &Customer{
-		CustomerID: customerID,
-		Name:       req.Name,
-		Status:     req.Status,
+	customer, err := s.repo.GetByIDIncludingDeleted(customerID)
+	if err != nil {
+		logger.Warn("Failed to get customer", "customer_id", customerID, "error", err)
+		return nil, fmt.Errorf("failed to get customer: %w", err)
 	}
 
-	if err := s.repo.Create(customer); err != nil {
-		log.Printf("Error creating customer: %v", err)
-		return nil, fmt.Errorf("failed to create customer: %w", err)
+	return s.withComputedSegments(customer), nil
+}
+
+// GetCustomerAsOf retrieves a customer as it was at asOf, so reads made to
+// reproduce an earlier enrichment see the status and credit of that time.
+// A customer created after asOf, or soft-deleted by then, is not found.
+func (s *CustomerService) GetCustomerAsOf(ctx context.Context, customerID string, asOf time.Time) (*Customer, error) {
+	logger := logging.FromContext(ctx)
+	logger.Debug("Getting customer as of", "customer_id", customerID, "as_of", asOf)
+
+	if asOf.After(s.clock.Now()) {
+		return nil, apperr.New(apperr.ErrValidation, "asOf cannot be in the future")
 	}
 
-	log.Printf("Successfully created customer with ID: %s", customerID)
-	return customer, nil
+	history, err := s.CustomerHistory(ctx, customerID, &asOf)
+	if err != nil {
+		return nil, err
+	}
+	if history.Customer.IsDeleted() {
+		return nil, fmt.Errorf("failed to get customer: %w", ErrCustomerNotFound)
+	}
+	return s.withComputedSegmentsAt(history.Customer, asOf), nil
 }
 
-// UpdateCustomer updates an existing customer's information.
-//
-// This method validates the customer ID and request, checks if the customer
-// exists, updates the customer information, and persists the changes.
+// GetCustomerByEmail retrieves a live customer by email address. The email
+// is normalized like the one on a customer request before the lookup.
 //
 // Args:
+//   - ctx: request context carrying the request-scoped logger
+//   - email: the email address, matched case-insensitively
+//
+// Returns:
+//   - *Customer: the customer if found
+//   - error: ErrInvalidEmail if email is malformed, ErrCustomerNotFound if no customer has it
+func (s *CustomerService) GetCustomerByEmail(ctx context.Context, email string) (*Customer, error) {
+	logger := logging.FromContext(ctx)
+	logger.Debug("Getting customer by email")
+
+	email, err := normalizeEmail(email)
+	if err != nil {
+		return nil, err
+	}
+	if email == "" {
+		return nil, fmt.Errorf("%w: email cannot be empty", ErrInvalidEmail)
+	}
+
+	customer, err := s.repo.GetByEmail(email)
+	if err != nil {
+		if !errors.Is(err, ErrCustomerNotFound) {
+			logger.Error("Failed to get customer by email", "error", err)
+		}
+		return nil, fmt.Errorf("failed to get customer: %w", err)
+	}
+
+	return s.withComputedSegments(customer), nil
+}
+
+// GetCustomers retrieves several customers by ID in one repository call.
+//
+// Duplicate IDs are resolved once. IDs that are blank or do not match a
+// customer are reported individually in the result's Errors rather than
+// failing the whole batch; only empty or oversized batches are rejected.
+//
+// Args:
+//   - ctx: request context carrying the request-scoped logger
+//   - customerIDs: the unique identifiers of the customers
+//
+// Returns:
+//   - *BatchResult: the customers found and a per-ID error for the rest
+//   - error: ErrInvalidBatch if the batch is empty or too large, or a retrieval error
+//
+// Example usage:
+//
+//	result, err := service.GetCustomers(ctx, []string{"customer-123", "customer-456"})
+//	if err != nil {
+//		log.Printf("Failed to get customers: %v", err)
+//		return
+//	}
+//	for _, batchErr := range result.Errors {
+//		log.Printf("Skipping %s: %s", batchErr.CustomerID, batchErr.Error)
+//	}
+func (s *CustomerService) GetCustomers(ctx context.Context, customerIDs []string) (*BatchResult, error) {
+	logger := logging.FromContext(ctx)
+
+	if len(customerIDs) == 0 {
+		return nil, fmt.Errorf("%w: at least one customer ID is required", ErrInvalidBatch)
+	}
+	if len(customerIDs) > s.maxBatchSize {
+		return nil, fmt.Errorf("%w: at most %d customer IDs are allowed", ErrInvalidBatch, s.maxBatchSize)
+	}
+
+	// Resolve each distinct, non-blank ID once, keeping request order
+	seen := make(map[string]bool, len(customerIDs))
+	ids := make([]string, 0, len(customerIDs))
+	for _, customerID := range customerIDs {
+		if !seen[customerID] {
+			seen[customerID] = true
+			ids = append(ids, customerID)
+		}
+	}
+
+	lookup := make([]string, 0, len(ids))
+	for _, customerID := range ids {
+		if strings.TrimSpace(customerID) != "" {
+			lookup = append(lookup, customerID)
+		}
+	}
+	logger.Debug("Getting customers", "count", len(lookup))
+
+	byID := make(map[string]*Customer, len(lookup))
+	if len(lookup) > 0 {
+		customers, err := s.repo.GetByIDs(lookup)
+		if err != nil {
+			logger.Error("Failed to get customers", "error", err)
+			return nil, fmt.Errorf("failed to get customers: %w", err)
+		}
+		for _, customer := range customers {
+			byID[customer.CustomerID] = customer
+		}
+	}
+
+	result := &BatchResult{Customers: make([]*Customer, 0, len(byID)), Errors: make([]BatchError, 0)}
+	for _, customerID := range ids {
+		switch customer, ok := byID[customerID]; {
+		case ok:
+			result.Customers = append(result.Customers, customer)
+		case strings.TrimSpace(customerID) == "":
+			result.Errors = append(result.Errors, BatchError{CustomerID: customerID, Error: "customer ID cannot be empty"})
+		default:
+			result.Errors = append(result.Errors, BatchError{CustomerID: customerID, Error: ErrCustomerNotFound.Error()})
+		}
+	}
+
+	logger.Debug("Retrieved customers", "found", len(result.Customers), "failed", len(result.Errors))
+	result.Customers = s.withAllComputedSegments(result.Customers)
+	return result, nil
+}
+
+// CreateCustomer creates a new customer with the provided information.
+//
+// This method validates the customer request, generates a unique ID,
+// creates the customer entity, and persists it to the repository.
+//
+// Args:
+//   - ctx: request context carrying the request-scoped logger
+//   - req: CustomerRequest containing customer details
+//
+// Returns:
+//   - *Customer: the newly created customer
+//   - error: error if creation fails
+//
+// Example usage:
+//
+//	req := CustomerRequest{
+//		Name:   "John Doe",
+//		Status: "ACTIVE",
+//	}
+//	customer, err := service.CreateCustomer(ctx, req)
+//	if err != nil {
+//		log.Printf("Failed to create customer: %v", err)
+//		return
+//	}
+//	log.Printf("Created customer with ID: %s", customer.CustomerID)
+func (s *CustomerService) CreateCustomer(ctx context.Context, req CustomerRequest) (*Customer, error) {
+	logger := logging.FromContext(ctx)
+	logger.Info("Creating customer", "name", req.Name)
+
+	if err := s.validateCustomerRequest(&req); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	if err := checkInitialStatus(req.Status); err != nil {
+		return nil, err
+	}
+
+	customerID, err := s.idGenerator.NewID("customer")
+	if err != nil {
+		logger.Error("Failed to generate customer ID", "error", err)
+		return nil, fmt.Errorf("failed to create customer: %w", err)
+	}
+
+	customer := newCustomer(customerID, req)
+	s.stampCreated(ctx, customer)
+
+	if err := s.repo.Create(customer); err != nil {
+		logger.Error("Failed to create customer", "error", err)
+		return nil, fmt.Errorf("failed to create customer: %w", err)
+	}
+
+	logger.Info("Created customer", "customer_id", customerID)
+	return s.withComputedSegments(customer), nil
+}
+
+// UpdateCustomer updates an existing customer's information.
+//
+// This method validates the customer ID and request, checks if the customer
+// exists, updates the customer information, and persists the changes.
+//
+// Args:
+//   - ctx: request context carrying the request-scoped logger
 //   - customerID: the unique identifier of the customer to update
 //   - req: CustomerRequest containing updated customer details
 //
@@ -219,78 +895,355 @@ func (s *CustomerService) CreateCustomer(req CustomerRequest) (*Customer, error)
 //
 //	req := CustomerRequest{
 //		Name:   "Jane Smith",
-//		Status: "INACTIVE",
+//		Status: "SUSPENDED",
 //	}
-//	customer, err := service.UpdateCustomer("customer-12345", req)
+//	customer, err := service.UpdateCustomer(ctx, "customer-12345", req)
 //	if err != nil {
 //		log.Printf("Failed to update customer: %v", err)
 //		return
 //	}
 //	log.Printf("Updated customer: %s", customer.Name)
-func (s *CustomerService) UpdateCustomer(customerID string, req CustomerRequest) (*Customer, error) {
-	log.Printf("Updating customer with ID: %s", customerID)
+func (s *CustomerService) UpdateCustomer(ctx context.Context, customerID string, req CustomerRequest) (*Customer, error) {
+	logger := logging.FromContext(ctx)
+	logger.Info("Updating customer", "customer_id", customerID)
 
 	if customerID == "" {
-		return nil, fmt.Errorf("customer ID cannot be empty")
+		return nil, apperr.New(apperr.ErrValidation, "customer ID cannot be empty")
 	}
 
-	if err := s.validateCustomerRequest(req); err != nil {
+	if err := s.validateCustomerRequest(&req); err != nil {
 		return nil, fmt.Errorf("validation failed: %w", err)
 	}
 
 	// Check if customer exists
+	existingCustomer, err := s.repo.GetByID(customerID)
+	if err != nil {
+		return nil, fmt.Errorf("customer not found: %w", err)
+	}
+	if req.Status != existingCustomer.Status {
+		if err := checkTransition(existingCustomer.Status, req.Status); err != nil {
+			return nil, err
+		}
+	}
+
+	applyUpdate(existingCustomer, req)
+	s.stampUpdated(ctx, existingCustomer)
+
+	if err := s.repo.Update(existingCustomer); err != nil {
+		logger.Error("Failed to update customer", "customer_id", customerID, "error", err)
+		return nil, fmt.Errorf("failed to update customer: %w", err)
+	}
+
+	logger.Info("Updated customer", "customer_id", customerID)
+	return s.withComputedSegments(existingCustomer), nil
+}
+
+// BulkWriteCustomers creates or updates many customers in one request.
+//
+// Items without a customer ID are created like CreateCustomer and the
+// others updated like UpdateCustomer. Items succeed or fail on their own
+// unless req.Atomic is set: then any failing item leaves every customer
+// unchanged and the other items are reported as skipped.
+//
+// Args:
+//   - ctx: request context carrying the request-scoped logger
+//   - req: BulkRequest listing the items and whether to write them all or nothing
+//
+// Returns:
+//   - *BulkResult: the outcome of every item, in request order
+//   - error: ErrInvalidBulk if the request is empty or too large, or a storage error
+//
+// Example usage:
+//
+//	result, err := service.BulkWriteCustomers(ctx, BulkRequest{Items: items})
+//	if err != nil {
+//		log.Printf("Failed to write customers: %v", err)
+//		return
+//	}
+//	log.Printf("%d of %d customers failed", result.Failed(), len(items))
+func (s *CustomerService) BulkWriteCustomers(ctx context.Context, req BulkRequest) (*BulkResult, error) {
+	logger := logging.FromContext(ctx)
+
+	if len(req.Items) == 0 {
+		return nil, fmt.Errorf("%w: at least one item is required", ErrInvalidBulk)
+	}
+	if len(req.Items) > s.maxBulkSize {
+		return nil, fmt.Errorf("%w: at most %d items are allowed", ErrInvalidBulk, s.maxBulkSize)
+	}
+	logger.Info("Writing customers in bulk", "count", len(req.Items), "atomic", req.Atomic)
+
+	result := &BulkResult{Items: make([]BulkItemResult, len(req.Items)), Atomic: req.Atomic}
+	writes := make([]Write, len(req.Items))
+	for i, item := range req.Items {
+		write, err := s.prepareWrite(ctx, item)
+		if err != nil {
+			result.Items[i] = BulkItemResult{Status: BulkFailed, Err: err}
+			continue
+		}
+		writes[i] = write
+	}
+
+	if req.Atomic {
+		if err := s.writeAll(result, writes); err != nil {
+			logger.Error("Failed to write customers in bulk", "error", err)
+			return nil, fmt.Errorf("failed to write customers: %w", err)
+		}
+	} else {
+		for i, write := range writes {
+			if result.Items[i].Status == BulkFailed {
+				continue
+			}
+			store := s.repo.Update
+			if write.Create {
+				store = s.repo.Create
+			}
+			if err := store(write.Customer); err != nil {
+				result.Items[i] = BulkItemResult{Status: BulkFailed, Err: fmt.Errorf("failed to store customer: %w", err)}
+				continue
+			}
+			result.Items[i] = stored(write)
+		}
+	}
+
+	logger.Info("Wrote customers in bulk", "count", len(req.Items), "failed", result.Failed())
+	return result, nil
+}
+
+// prepareWrite validates a bulk item and builds the customer it stores.
+func (s *CustomerService) prepareWrite(ctx context.Context, item BulkItem) (Write, error) {
+	req := item.Customer
+	if err := s.validateCustomerRequest(&req); err != nil {
+		return Write{}, fmt.Errorf("validation failed: %w", err)
+	}
+
+	if item.CustomerID == "" {
+		if err := checkInitialStatus(req.Status); err != nil {
+			return Write{}, err
+		}
+		customerID, err := s.idGenerator.NewID("customer")
+		if err != nil {
+			return Write{}, fmt.Errorf("failed to create customer: %w", err)
+		}
+		customer := newCustomer(customerID, req)
+		s.stampCreated(ctx, customer)
+		return Write{Customer: customer, Create: true}, nil
+	}
+
+	customer, err := s.repo.GetByID(item.CustomerID)
+	if err != nil {
+		return Write{}, fmt.Errorf("customer not found: %w", err)
+	}
+	if req.Status != customer.Status {
+		if err := checkTransition(customer.Status, req.Status); err != nil {
+			return Write{}, err
+		}
+	}
+	applyUpdate(customer, req)
+	s.stampUpdated(ctx, customer)
+	return Write{Customer: customer}, nil
+}
+
+// writeAll stores the prepared writes of an atomic bulk write in one
+// repository call, unless an item already failed, and records the outcome
+// of every item in result. Only storage failures not tied to an item are
+// returned.
+func (s *CustomerService) writeAll(result *BulkResult, writes []Write) error {
+	failed := result.Failed() > 0
+	if !failed {
+		err := s.repo.WriteAll(writes)
+		var writeErr *WriteError
+		switch {
+		case errors.As(err, &writeErr):
+			result.Items[writeErr.Index] = BulkItemResult{Status: BulkFailed, Err: fmt.Errorf("failed to store customer: %w", writeErr.Err)}
+			failed = true
+		case err != nil:
+			return err
+		}
+	}
+
+	for i, write := range writes {
+		switch {
+		case result.Items[i].Status == BulkFailed:
+		case failed:
+			result.Items[i] = BulkItemResult{Status: BulkSkipped}
+		default:
+			result.Items[i] = stored(write)
+		}
+	}
+	return nil
+}
+
+// stored reports a bulk item whose write was stored.
+func stored(write Write) BulkItemResult {
+	if write.Create {
+		return BulkItemResult{Status: BulkCreated, Customer: write.Customer}
+	}
+	return BulkItemResult{Status: BulkUpdated, Customer: write.Customer}
+}
+
+// PatchCustomer applies a partial update to an existing customer.
+//
+// The patch is merged over the stored customer and the result is validated
+// exactly like a full update, so a patch can never leave a customer in a
+// state that PUT would reject.
+//
+// Args:
+//   - ctx: request context carrying the request-scoped logger
+//   - customerID: the unique identifier of the customer to update
+//   - patch: CustomerPatch holding the fields to change
+//
+// Returns:
+//   - *Customer: the updated customer
+//   - error: error if validation fails or customer not found
+//
+// Example usage:
+//
+//	status := "SUSPENDED"
+//	customer, err := service.PatchCustomer(ctx, "customer-12345", CustomerPatch{Status: &status})
+func (s *CustomerService) PatchCustomer(ctx context.Context, customerID string, patch CustomerPatch) (*Customer, error) {
+	logger := logging.FromContext(ctx)
+	logger.Info("Patching customer", "customer_id", customerID)
+
+	if customerID == "" {
+		return nil, apperr.New(apperr.ErrValidation, "customer ID cannot be empty")
+	}
+
 	existingCustomer, err := s.repo.GetByID(customerID)
 	if err != nil {
 		return nil, fmt.Errorf("customer not found: %w", err)
 	}
 
-	// Update customer fields
+	req := patch.apply(existingCustomer)
+	if err := s.validateCustomerRequest(&req); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	if req.Status != existingCustomer.Status {
+		if err := checkTransition(existingCustomer.Status, req.Status); err != nil {
+			return nil, err
+		}
+	}
+
 	existingCustomer.Name = req.Name
 	existingCustomer.Status = req.Status
+	existingCustomer.Email = req.Email
+	existingCustomer.Phone = req.Phone
+	existingCustomer.CreditLimit = *req.CreditLimit
+	existingCustomer.Segments = dedupeSegments(req.Segments)
+
+	s.stampUpdated(ctx, existingCustomer)
 
 	if err := s.repo.Update(existingCustomer); err != nil {
-		log.Printf("Error updating customer: %v", err)
+		logger.Error("Failed to patch customer", "customer_id", customerID, "error", err)
 		return nil, fmt.Errorf("failed to update customer: %w", err)
 	}
 
-	log.Printf("Successfully updated customer: %s", customerID)
-	return existingCustomer, nil
+	logger.Info("Patched customer", "customer_id", customerID)
+	return s.withComputedSegments(existingCustomer), nil
 }
 
-// DeleteCustomer removes a customer
-func (s *CustomerService) DeleteCustomer(customerID string) error {
-	log.Printf("Deleting customer with ID: %s", customerID)
+// TransitionCustomer moves a live customer to another lifecycle status.
+//
+// Unlike an update, moving a customer to the status it already has is
+// rejected, so callers learn that the transition did not happen.
+//
+// Args:
+//   - ctx: request context carrying the request-scoped logger
+//   - customerID: the unique identifier of the customer
+//   - status: the status to move to
+//
+// Returns:
+//   - *Customer: the customer in its new status
+//   - error: ErrInvalidTransition if the lifecycle does not allow the move,
+//     or an error if the customer is not found
+func (s *CustomerService) TransitionCustomer(ctx context.Context, customerID, status string) (*Customer, error) {
+	logger := logging.FromContext(ctx)
+	logger.Info("Transitioning customer", "customer_id", customerID, "status", status)
+
+	customer, err := s.getCustomer(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	from := customer.Status
+	if err := checkTransition(from, status); err != nil {
+		return nil, err
+	}
+
+	customer.Status = status
+	s.stampUpdated(ctx, customer)
+
+	if err := s.repo.Update(customer); err != nil {
+		logger.Error("Failed to transition customer", "customer_id", customerID, "error", err)
+		return nil, fmt.Errorf("failed to update customer: %w", err)
+	}
+
+	logger.Info("Transitioned customer", "customer_id", customerID, "from", from, "to", status)
+	return s.withComputedSegments(customer), nil
+}
+
+// DeleteCustomer soft-deletes a customer; RestoreCustomer undoes it
+func (s *CustomerService) DeleteCustomer(ctx context.Context, customerID string) error {
+	logger := logging.FromContext(ctx)
+	logger.Info("Deleting customer", "customer_id", customerID)
 
 	if customerID == "" {
-		return fmt.Errorf("customer ID cannot be empty")
+		return apperr.New(apperr.ErrValidation, "customer ID cannot be empty")
 	}
 
 	if err := s.repo.Delete(customerID); err != nil {
-		log.Printf("Error deleting customer: %v", err)
+		logger.Warn("Failed to delete customer", "customer_id", customerID, "error", err)
 		return fmt.Errorf("failed to delete customer: %w", err)
 	}
 
-	log.Printf("Successfully deleted customer: %s", customerID)
+	logger.Info("Deleted customer", "customer_id", customerID)
 	return nil
 }
 
+// RestoreCustomer undoes a soft delete and returns the restored customer.
+//
+// Args:
+//   - ctx: request context carrying the request-scoped logger
+//   - customerID: the unique identifier of the customer to restore
+//
+// Returns:
+//   - *Customer: the restored customer
+//   - error: ErrCustomerNotFound if no such customer exists, ErrCustomerNotDeleted
+//     if it is not deleted, or a storage error
+func (s *CustomerService) RestoreCustomer(ctx context.Context, customerID string) (*Customer, error) {
+	logger := logging.FromContext(ctx)
+	logger.Info("Restoring customer", "customer_id", customerID)
+
+	if customerID == "" {
+		return nil, apperr.New(apperr.ErrValidation, "customer ID cannot be empty")
+	}
+
+	if err := s.repo.Restore(customerID); err != nil {
+		logger.Warn("Failed to restore customer", "customer_id", customerID, "error", err)
+		return nil, fmt.Errorf("failed to restore customer: %w", err)
+	}
+
+	logger.Info("Restored customer", "customer_id", customerID)
+	return s.GetCustomer(ctx, customerID)
+}
+
 // ListCustomers returns all customers
-func (s *CustomerService) ListCustomers() ([]*Customer, error) {
-	log.Println("Listing all customers")
+func (s *CustomerService) ListCustomers(ctx context.Context) ([]*Customer, error) {
+	logger := logging.FromContext(ctx)
+	logger.Debug("Listing all customers")
 
 	customers, err := s.repo.List()
 	if err != nil {
-		log.Printf("Error listing customers: %v", err)
+		logger.Error("Failed to list customers", "error", err)
 		return nil, fmt.Errorf("failed to list customers: %w", err)
 	}
 
-	log.Printf("Successfully retrieved %d customers", len(customers))
-	return customers, nil
+	logger.Debug("Listed customers", "count", len(customers))
+	return s.withAllComputedSegments(customers), nil
 }
 
 // IsCustomerActive checks if a customer is active
-func (s *CustomerService) IsCustomerActive(customerID string) (bool, error) {
-	customer, err := s.GetCustomer(customerID)
+func (s *CustomerService) IsCustomerActive(ctx context.Context, customerID string) (bool, error) {
+	customer, err := s.getCustomer(ctx, customerID)
 	if err != nil {
 		return false, err
 	}
@@ -298,23 +1251,647 @@ func (s *CustomerService) IsCustomerActive(customerID string) (bool, error) {
 	return customer.IsActive(), nil
 }
 
-// validateCustomerRequest validates the customer request
-func (s *CustomerService) validateCustomerRequest(req CustomerRequest) error {
-	if req.Name == "" {
-		return fmt.Errorf("customer name is required")
+// ListCustomersBySegment returns customers tagged with a segment
+func (s *CustomerService) ListCustomersBySegment(ctx context.Context, segment string) ([]*Customer, error) {
+	return s.FindCustomers(ctx, CustomerFilter{Segment: segment})
+}
+
+// FindCustomers returns one page of the customers matching filter.
+//
+// The segment, when set, must be a valid segment tag, the status a known
+// status and the sort fields among SortFields, and Limit and Offset must not
+// be negative.
+//
+// Example usage:
+//
+//	customers, err := service.FindCustomers(ctx, CustomerFilter{Segment: "vip", Limit: 20})
+//	if err != nil {
+//		log.Printf("Failed to find customers: %v", err)
+//		return
+//	}
+func (s *CustomerService) FindCustomers(ctx context.Context, filter CustomerFilter) ([]*Customer, error) {
+	logger := logging.FromContext(ctx)
+	logger.Debug("Finding customers", "filter", filter)
+
+	filter, err := s.prepareFilter(filter)
+	if err != nil {
+		return nil, err
 	}
 
-	if len(req.Name) < 2 {
-		return fmt.Errorf("customer name must be at least 2 characters")
+	customers, err := s.repo.Find(filter)
+	if err != nil {
+		logger.Error("Failed to find customers", "error", err)
+		return nil, fmt.Errorf("failed to find customers: %w", err)
 	}
 
-	if len(req.Name) > 100 {
-		return fmt.Errorf("customer name must be at most 100 characters")
+	logger.Debug("Found customers", "count", len(customers))
+	return s.withAllComputedSegments(customers), nil
+}
+
+// CountCustomers returns how many customers match filter across all pages.
+//
+// Example usage:
+//
+//	total, err := service.CountCustomers(ctx, CustomerFilter{Segment: "vip"})
+func (s *CustomerService) CountCustomers(ctx context.Context, filter CustomerFilter) (int, error) {
+	logger := logging.FromContext(ctx)
+	filter, err := s.prepareFilter(filter)
+	if err != nil {
+		return 0, err
 	}
 
-	if req.Status != "ACTIVE" && req.Status != "INACTIVE" {
-		return fmt.Errorf("customer status must be either ACTIVE or INACTIVE")
+	count, err := s.repo.Count(filter)
+	if err != nil {
+		logger.Error("Failed to count customers", "error", err)
+		return 0, fmt.Errorf("failed to count customers: %w", err)
 	}
 
+	return count, nil
+}
+
+// ExportCustomers passes every customer matching filter to emit, reading
+// ExportPageSize customers at a time so the whole set is never held in
+// memory. Pages are read by offset, so customers written during the export
+// may be skipped or repeated.
+//
+// Example usage:
+//
+//	err := service.ExportCustomers(ctx, CustomerFilter{Status: "ACTIVE"}, func(customers []*Customer) error {
+//		return writeRows(customers)
+//	})
+func (s *CustomerService) ExportCustomers(ctx context.Context, filter CustomerFilter, emit func([]*Customer) error) error {
+	logger := logging.FromContext(ctx)
+	logger.Info("Exporting customers", "filter", filter)
+
+	filter.Limit, filter.Offset = ExportPageSize, 0
+	filter, err := s.prepareFilter(filter)
+	if err != nil {
+		return err
+	}
+
+	exported := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		customers, err := s.repo.Find(filter)
+		if err != nil {
+			logger.Error("Failed to export customers", "error", err, "exported", exported)
+			return fmt.Errorf("failed to export customers: %w", err)
+		}
+		if len(customers) > 0 {
+			if err := emit(s.withAllComputedSegments(customers)); err != nil {
+				return err
+			}
+		}
+		exported += len(customers)
+
+		if len(customers) < filter.Limit {
+			break
+		}
+		filter.Offset += filter.Limit
+	}
+
+	logger.Info("Exported customers", "count", exported)
 	return nil
 }
+
+// AddSegment tags a customer with a segment; adding an existing segment is a no-op
+func (s *CustomerService) AddSegment(ctx context.Context, customerID, segment string) (*Customer, error) {
+	logger := logging.FromContext(ctx)
+	logger.Info("Adding segment", "customer_id", customerID, "segment", segment)
+
+	if err := validateSegment(segment); err != nil {
+		return nil, err
+	}
+
+	customer, err := s.getCustomer(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if customer.HasSegment(segment) {
+		return s.withComputedSegments(customer), nil
+	}
+
+	if len(customer.Segments) >= s.maxSegments {
+		return nil, fmt.Errorf("%w: customer already has %d segments (max %d)", ErrTooManySegments, len(customer.Segments), s.maxSegments)
+	}
+
+	segments := make([]string, 0, len(customer.Segments)+1)
+	customer.Segments = append(append(segments, customer.Segments...), segment)
+
+	s.stampUpdated(ctx, customer)
+
+	if err := s.repo.Update(customer); err != nil {
+		logger.Error("Failed to add segment", "customer_id", customerID, "segment", segment, "error", err)
+		return nil, fmt.Errorf("failed to add segment: %w", err)
+	}
+
+	return s.withComputedSegments(customer), nil
+}
+
+// RemoveSegment removes a segment tag from a customer; removing an absent segment is a no-op
+func (s *CustomerService) RemoveSegment(ctx context.Context, customerID, segment string) (*Customer, error) {
+	logger := logging.FromContext(ctx)
+	logger.Info("Removing segment", "customer_id", customerID, "segment", segment)
+
+	customer, err := s.getCustomer(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !customer.HasSegment(segment) {
+		return s.withComputedSegments(customer), nil
+	}
+
+	segments := make([]string, 0, len(customer.Segments)-1)
+	for _, existing := range customer.Segments {
+		if existing != segment {
+			segments = append(segments, existing)
+		}
+	}
+	customer.Segments = segments
+
+	s.stampUpdated(ctx, customer)
+
+	if err := s.repo.Update(customer); err != nil {
+		logger.Error("Failed to remove segment", "customer_id", customerID, "segment", segment, "error", err)
+		return nil, fmt.Errorf("failed to remove segment: %w", err)
+	}
+
+	return s.withComputedSegments(customer), nil
+}
+
+// ListAddresses returns the address book of a live customer
+func (s *CustomerService) ListAddresses(ctx context.Context, customerID string) ([]*Address, error) {
+	if _, err := s.GetCustomer(ctx, customerID); err != nil {
+		return nil, err
+	}
+
+	addresses, err := s.repo.Addresses(customerID)
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to list addresses", "customer_id", customerID, "error", err)
+		return nil, fmt.Errorf("failed to list addresses: %w", err)
+	}
+	return addresses, nil
+}
+
+// GetAddress retrieves one address of a live customer
+func (s *CustomerService) GetAddress(ctx context.Context, customerID, addressID string) (*Address, error) {
+	address, err := s.repo.GetAddress(customerID, addressID)
+	if err != nil {
+		if !errors.Is(err, ErrAddressNotFound) {
+			logging.FromContext(ctx).Error("Failed to get address", "customer_id", customerID, "address_id", addressID, "error", err)
+		}
+		return nil, err
+	}
+	return address, nil
+}
+
+// DefaultAddress retrieves the default address of addressType for a live customer
+func (s *CustomerService) DefaultAddress(ctx context.Context, customerID, addressType string) (*Address, error) {
+	addresses, err := s.ListAddresses(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, address := range addresses {
+		if address.Type == addressType && address.IsDefault {
+			return address, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: no default %s address", ErrAddressNotFound, addressType)
+}
+
+// CreateAddress adds an address to a live customer. The address becomes the
+// default for its type when requested or when the customer has no default
+// of that type yet.
+func (s *CustomerService) CreateAddress(ctx context.Context, customerID string, req AddressRequest) (*Address, error) {
+	logger := logging.FromContext(ctx)
+	logger.Info("Creating address", "customer_id", customerID, "type", req.Type)
+
+	if err := validateAddressRequest(&req); err != nil {
+		return nil, err
+	}
+
+	addresses, err := s.ListAddresses(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	addressID, err := s.idGenerator.NewID("address")
+	if err != nil {
+		logger.Error("Failed to generate address ID", "error", err)
+		return nil, fmt.Errorf("failed to create address: %w", err)
+	}
+
+	now, caller := s.clock.Now(), auth.Caller(ctx)
+	address := &Address{
+		AddressID:  addressID,
+		CustomerID: customerID,
+		CreatedAt:  now,
+		CreatedBy:  caller,
+		UpdatedAt:  now,
+		UpdatedBy:  caller,
+	}
+	applyAddressRequest(address, req, addresses)
+
+	if err := s.repo.CreateAddress(address); err != nil {
+		if !errors.Is(err, ErrCustomerNotFound) {
+			logger.Error("Failed to create address", "customer_id", customerID, "error", err)
+		}
+		return nil, fmt.Errorf("failed to create address: %w", err)
+	}
+
+	logger.Info("Created address", "customer_id", customerID, "address_id", addressID)
+	return address, nil
+}
+
+// UpdateAddress replaces an address of a live customer. A default address
+// stays the default of its type until another address is made the default.
+func (s *CustomerService) UpdateAddress(ctx context.Context, customerID, addressID string, req AddressRequest) (*Address, error) {
+	logger := logging.FromContext(ctx)
+	logger.Info("Updating address", "customer_id", customerID, "address_id", addressID)
+
+	if err := validateAddressRequest(&req); err != nil {
+		return nil, err
+	}
+
+	address, err := s.GetAddress(ctx, customerID, addressID)
+	if err != nil {
+		return nil, err
+	}
+
+	addresses, err := s.repo.Addresses(customerID)
+	if err != nil {
+		logger.Error("Failed to list addresses", "customer_id", customerID, "error", err)
+		return nil, fmt.Errorf("failed to update address: %w", err)
+	}
+
+	applyAddressRequest(address, req, addresses)
+	address.UpdatedAt, address.UpdatedBy = s.clock.Now(), auth.Caller(ctx)
+
+	if err := s.repo.UpdateAddress(address); err != nil {
+		if !errors.Is(err, ErrAddressNotFound) {
+			logger.Error("Failed to update address", "customer_id", customerID, "address_id", addressID, "error", err)
+		}
+		return nil, fmt.Errorf("failed to update address: %w", err)
+	}
+
+	return address, nil
+}
+
+// DeleteAddress removes an address of a live customer. Deleting the default
+// leaves its type without one until another address is created or made the
+// default.
+func (s *CustomerService) DeleteAddress(ctx context.Context, customerID, addressID string) error {
+	logger := logging.FromContext(ctx)
+	logger.Info("Deleting address", "customer_id", customerID, "address_id", addressID)
+
+	if err := s.repo.DeleteAddress(customerID, addressID); err != nil {
+		if !errors.Is(err, ErrAddressNotFound) {
+			logger.Error("Failed to delete address", "customer_id", customerID, "address_id", addressID, "error", err)
+		}
+		return fmt.Errorf("failed to delete address: %w", err)
+	}
+	return nil
+}
+
+// CheckCredit reports whether a live customer's remaining credit covers
+// amount. It only reads the exposure; ReserveCredit is what holds the credit.
+func (s *CustomerService) CheckCredit(ctx context.Context, customerID string, amount float64) (*CreditCheck, error) {
+	if err := validateAmount(amount); err != nil {
+		return nil, err
+	}
+
+	customer, err := s.getCustomer(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	check := customer.CheckCredit(amount)
+	return &check, nil
+}
+
+// ReserveCredit adds req.Amount to a live customer's exposure, refusing with
+// ErrCreditLimitExceeded if it would exceed the credit limit
+func (s *CustomerService) ReserveCredit(ctx context.Context, customerID string, req CreditRequest) (*Customer, error) {
+	return s.adjustExposure(ctx, customerID, req, 1)
+}
+
+// ReleaseCredit removes req.Amount from a live customer's exposure, refusing
+// with ErrExposureBelowZero if it would leave the exposure negative
+func (s *CustomerService) ReleaseCredit(ctx context.Context, customerID string, req CreditRequest) (*Customer, error) {
+	return s.adjustExposure(ctx, customerID, req, -1)
+}
+
+// adjustExposure validates req and adjusts the exposure by sign times its amount
+func (s *CustomerService) adjustExposure(ctx context.Context, customerID string, req CreditRequest, sign float64) (*Customer, error) {
+	logger := logging.FromContext(ctx)
+	logger.Info("Adjusting customer exposure", "customer_id", customerID, "delta", sign*req.Amount)
+
+	if err := validation.Struct(req); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidAmount, err)
+	}
+	if err := validateAmount(req.Amount); err != nil {
+		return nil, err
+	}
+
+	customer, err := s.repo.AdjustExposure(customerID, ExposureChange{
+		Delta:     sign * roundAmount(req.Amount),
+		UpdatedAt: s.clock.Now(),
+		UpdatedBy: auth.Caller(ctx),
+	})
+	if err != nil {
+		if !isExposureRefusal(err) {
+			logger.Error("Failed to adjust customer exposure", "customer_id", customerID, "error", err)
+		}
+		return nil, fmt.Errorf("failed to adjust exposure: %w", err)
+	}
+
+	logger.Info("Adjusted customer exposure", "customer_id", customerID, "exposure", customer.CurrentExposure)
+	return s.withComputedSegments(customer), nil
+}
+
+// isExposureRefusal reports whether err is an expected refusal of an exposure
+// adjustment rather than a failure worth logging as an error
+func isExposureRefusal(err error) bool {
+	return errors.Is(err, ErrCustomerNotFound) ||
+		errors.Is(err, ErrCreditLimitExceeded) ||
+		errors.Is(err, ErrExposureBelowZero)
+}
+
+// validateAmount checks that a credit amount is a positive, finite number
+// that is at least one cent
+func validateAmount(amount float64) error {
+	if math.IsNaN(amount) || math.IsInf(amount, 0) || roundAmount(amount) <= 0 {
+		return fmt.Errorf("%w: amount must be a positive number of at least 0.01", ErrInvalidAmount)
+	}
+	return nil
+}
+
+// applyAddressRequest copies a validated request onto address. The address
+// is the default if the request asks for it or no other address of its type
+// among existing is the default.
+func applyAddressRequest(address *Address, req AddressRequest, existing []*Address) {
+	address.Type = req.Type
+	address.Line1 = req.Line1
+	address.Line2 = req.Line2
+	address.City = req.City
+	address.Region = req.Region
+	address.PostalCode = req.PostalCode
+	address.Country = req.Country
+
+	address.IsDefault = true
+	if !req.IsDefault {
+		for _, other := range existing {
+			if other.AddressID != address.AddressID && other.Type == address.Type && other.IsDefault {
+				address.IsDefault = false
+				break
+			}
+		}
+	}
+}
+
+// newCustomer builds a customer from a validated create request.
+func newCustomer(customerID string, req CustomerRequest) *Customer {
+	customer := &Customer{
+		CustomerID: customerID,
+		Name:       req.Name,
+		Status:     req.Status,
+		Email:      req.Email,
+		Phone:      req.Phone,
+		Segments:   dedupeSegments(req.Segments),
+	}
+	if req.CreditLimit != nil {
+		customer.CreditLimit = *req.CreditLimit
+	}
+	return customer
+}
+
+// applyUpdate overwrites customer's fields with a validated update request,
+// keeping the optional fields the request omits.
+func applyUpdate(customer *Customer, req CustomerRequest) {
+	customer.Name = req.Name
+	customer.Status = req.Status
+	if req.Email != "" {
+		customer.Email = req.Email
+	}
+	if req.Phone != "" {
+		customer.Phone = req.Phone
+	}
+	if req.CreditLimit != nil {
+		customer.CreditLimit = *req.CreditLimit
+	}
+	if req.Segments != nil {
+		customer.Segments = dedupeSegments(req.Segments)
+	}
+}
+
+// CustomerHistory replays the recorded events of a customer up to until
+func (s *CustomerService) CustomerHistory(ctx context.Context, customerID string, until *time.Time) (*History, error) {
+	logger := logging.FromContext(ctx)
+	logger.Debug("Getting customer history", "customer_id", customerID)
+
+	if customerID == "" {
+		return nil, apperr.New(apperr.ErrValidation, "customer ID cannot be empty")
+	}
+	if s.history == nil {
+		return nil, ErrHistoryNotRecorded
+	}
+
+	at := s.clock.Now()
+	if until != nil {
+		at = *until
+	}
+	history, err := s.history.History(customerID, at)
+	if err != nil {
+		logger.Warn("Failed to get customer history", "customer_id", customerID, "error", err)
+		return nil, fmt.Errorf("failed to get customer history: %w", err)
+	}
+
+	logger.Debug("Retrieved customer history", "customer_id", customerID, "events", len(history.Events))
+	return history, nil
+}
+
+// withComputedSegments returns a copy of customer carrying its computed
+// segments as of now. The repository's customer, which a cache may share, is
+// left unchanged.
+func (s *CustomerService) withComputedSegments(customer *Customer) *Customer {
+	return s.withComputedSegmentsAt(customer, s.clock.Now())
+}
+
+// withComputedSegmentsAt returns a copy of customer carrying its computed
+// segments as of at
+func (s *CustomerService) withComputedSegmentsAt(customer *Customer, at time.Time) *Customer {
+	segmented := *customer
+	segmented.ComputedSegments = s.segmentRules.Evaluate(customer, at)
+	return &segmented
+}
+
+// withAllComputedSegments replaces each customer with a copy carrying its
+// computed segments as of now, returning customers
+func (s *CustomerService) withAllComputedSegments(customers []*Customer) []*Customer {
+	now := s.clock.Now()
+	for i, customer := range customers {
+		customers[i] = s.withComputedSegmentsAt(customer, now)
+	}
+	return customers
+}
+
+// stampCreated records the creation time and caller on a new customer
+func (s *CustomerService) stampCreated(ctx context.Context, customer *Customer) {
+	now, caller := s.clock.Now(), auth.Caller(ctx)
+	customer.CreatedAt, customer.CreatedBy = now, caller
+	customer.UpdatedAt, customer.UpdatedBy = now, caller
+}
+
+// stampUpdated records the modification time and caller on a changed customer
+func (s *CustomerService) stampUpdated(ctx context.Context, customer *Customer) {
+	customer.UpdatedAt, customer.UpdatedBy = s.clock.Now(), auth.Caller(ctx)
+}
+
+// validateCustomerRequest checks the request's validate tags, then
+// normalizes and checks the contact details and the segment format and limit
+// that tags cannot express
+func (s *CustomerService) validateCustomerRequest(req *CustomerRequest) error {
+	if err := validation.Struct(req); err != nil {
+		return err
+	}
+
+	var err error
+	if req.Email, err = normalizeEmail(req.Email); err != nil {
+		return err
+	}
+	if req.Phone, err = normalizePhone(req.Phone); err != nil {
+		return err
+	}
+	if req.CreditLimit != nil {
+		limit := roundAmount(*req.CreditLimit)
+		req.CreditLimit = &limit
+	}
+
+	for _, segment := range req.Segments {
+		if err := validateSegment(segment); err != nil {
+			return err
+		}
+	}
+
+	if len(dedupeSegments(req.Segments)) > s.maxSegments {
+		return fmt.Errorf("%w: at most %d segments allowed", ErrTooManySegments, s.maxSegments)
+	}
+
+	return nil
+}
+
+// normalizeEmail trims and lower-cases an email and checks that it is a bare
+// RFC 5322 addr-spec, without a display name or angle brackets. An empty
+// email is left empty.
+func normalizeEmail(email string) (string, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" {
+		return "", nil
+	}
+
+	parsed, err := mail.ParseAddress(email)
+	if err != nil || parsed.Address != email {
+		return "", fmt.Errorf("%w: %q is not a valid email address", ErrInvalidEmail, email)
+	}
+	return email, nil
+}
+
+// normalizePhone strips separators from a phone number and checks that it is
+// in E.164 format. An empty phone is left empty.
+func normalizePhone(phone string) (string, error) {
+	phone = phoneSeparators.Replace(strings.TrimSpace(phone))
+	if phone == "" {
+		return "", nil
+	}
+
+	if !phonePattern.MatchString(phone) {
+		return "", fmt.Errorf("%w: %q is not an E.164 number such as +14155550123", ErrInvalidPhone, phone)
+	}
+	return phone, nil
+}
+
+// validateAddressRequest trims the request's fields, upper-cases the country
+// code and checks the validate tags
+func validateAddressRequest(req *AddressRequest) error {
+	for _, field := range []*string{&req.Line1, &req.Line2, &req.City, &req.Region, &req.PostalCode, &req.Country} {
+		*field = strings.TrimSpace(*field)
+	}
+	req.Country = strings.ToUpper(req.Country)
+
+	if err := validation.Struct(req); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidAddress, err)
+	}
+	return nil
+}
+
+// prepareFilter validates filter and resolves its computed segment, as of
+// now, into range criteria repositories can match
+func (s *CustomerService) prepareFilter(filter CustomerFilter) (CustomerFilter, error) {
+	if err := validateFilter(filter); err != nil {
+		return filter, err
+	}
+	if filter.ComputedSegment != "" {
+		if err := s.segmentRules.narrow(&filter, filter.ComputedSegment, s.clock.Now()); err != nil {
+			return filter, err
+		}
+	}
+	return filter, nil
+}
+
+// validateFilter checks the segment, status, sort and pagination values of a filter
+func validateFilter(filter CustomerFilter) error {
+	if filter.Segment != "" {
+		if err := validateSegment(filter.Segment); err != nil {
+			return err
+		}
+	}
+
+	if filter.Limit < 0 {
+		return fmt.Errorf("%w: limit must not be negative", ErrInvalidFilter)
+	}
+
+	if filter.Offset < 0 {
+		return fmt.Errorf("%w: offset must not be negative", ErrInvalidFilter)
+	}
+
+	if _, known := statusTransitions[filter.Status]; filter.Status != "" && !known {
+		return fmt.Errorf("%w: unknown status %s", ErrInvalidFilter, filter.Status)
+	}
+
+	if err := filter.Sort.Validate(SortFields...); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidFilter, err)
+	}
+
+	return nil
+}
+
+// validateSegment checks a segment tag is lowercase alphanumeric with hyphens, 1-32 characters
+func validateSegment(segment string) error {
+	if !segmentPattern.MatchString(segment) {
+		return fmt.Errorf("%w %q: must be 1-32 lowercase letters, digits or hyphens", ErrInvalidSegment, segment)
+	}
+	return nil
+}
+
+// dedupeSegments returns segments without duplicates, preserving order
+func dedupeSegments(segments []string) []string {
+	if segments == nil {
+		return nil
+	}
+
+	seen := make(map[string]bool, len(segments))
+	result := make([]string, 0, len(segments))
+	for _, segment := range segments {
+		if !seen[segment] {
+			seen[segment] = true
+			result = append(result, segment)
+		}
+	}
+	return result
+}
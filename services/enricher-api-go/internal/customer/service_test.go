@@ -1,7 +1,18 @@
 package customer
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
 	"testing"
+	"time"
+
+	"enricher-api-go/internal/apperr"
+	"enricher-api-go/internal/auth"
+	"enricher-api-go/internal/clock"
+	"enricher-api-go/internal/listing"
 )
 
 func TestCustomerService_GetCustomer(t *testing.T) {
@@ -10,7 +21,7 @@ func TestCustomerService_GetCustomer(t *testing.T) {
 	service := NewService(repo)
 
 	// Act
-	customer, err := service.GetCustomer("customer-456")
+	customer, err := service.GetCustomer(context.Background(), "customer-456")
 	// Assert
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
@@ -39,7 +50,7 @@ func TestCustomerService_GetCustomer_NotFound(t *testing.T) {
 	service := NewService(repo)
 
 	// Act
-	customer, err := service.GetCustomer("non-existent")
+	customer, err := service.GetCustomer(context.Background(), "non-existent")
 
 	// Assert
 	if err == nil {
@@ -51,6 +62,122 @@ func TestCustomerService_GetCustomer_NotFound(t *testing.T) {
 	}
 }
 
+func TestCustomerService_GetCustomers(t *testing.T) {
+	// Arrange
+	repo := NewInMemoryRepository()
+	service := NewService(repo)
+
+	// Act
+	result, err := service.GetCustomers(context.Background(), []string{"customer-123", "non-existent", "customer-456", "customer-123", " "})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(result.Customers) != 2 {
+		t.Fatalf("Expected 2 customers, got %d", len(result.Customers))
+	}
+
+	if result.Customers[0].CustomerID != "customer-123" || result.Customers[1].CustomerID != "customer-456" {
+		t.Errorf("Expected customers in request order, got %s, %s", result.Customers[0].CustomerID, result.Customers[1].CustomerID)
+	}
+
+	if len(result.Errors) != 2 {
+		t.Fatalf("Expected 2 errors, got %v", result.Errors)
+	}
+
+	if result.Errors[0] != (BatchError{CustomerID: "non-existent", Error: "customer not found"}) {
+		t.Errorf("Expected not found error, got %+v", result.Errors[0])
+	}
+
+	if result.Errors[1].CustomerID != " " {
+		t.Errorf("Expected blank ID error, got %+v", result.Errors[1])
+	}
+}
+
+func TestCustomerService_GetCustomers_InvalidBatch(t *testing.T) {
+	// Arrange
+	repo := NewInMemoryRepository()
+	service := NewService(repo, WithMaxBatchSize(2))
+
+	// Act
+	_, emptyErr := service.GetCustomers(context.Background(), nil)
+	_, tooLargeErr := service.GetCustomers(context.Background(), []string{"customer-123", "customer-456", "customer-789"})
+
+	// Assert
+	if !errors.Is(emptyErr, ErrInvalidBatch) {
+		t.Errorf("Expected ErrInvalidBatch for empty batch, got %v", emptyErr)
+	}
+
+	if !errors.Is(tooLargeErr, ErrInvalidBatch) {
+		t.Errorf("Expected ErrInvalidBatch for oversized batch, got %v", tooLargeErr)
+	}
+}
+
+func TestCustomerService_BulkWriteCustomers(t *testing.T) {
+	// Arrange
+	repo := NewInMemoryRepository()
+	service := NewService(repo)
+	req := BulkRequest{Items: []BulkItem{
+		{Customer: CustomerRequest{Name: "Dana White", Status: "ACTIVE", Email: "Dana@Example.com"}},
+		{CustomerID: "customer-123", Customer: CustomerRequest{Name: "John Smith", Status: "SUSPENDED"}},
+		{CustomerID: "customer-missing", Customer: CustomerRequest{Name: "Ghost", Status: "ACTIVE"}},
+		{Customer: CustomerRequest{Name: "Eve", Status: "CLOSED"}},
+	}}
+
+	// Act
+	result, err := service.BulkWriteCustomers(context.Background(), req)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	want := []string{BulkCreated, BulkUpdated, BulkFailed, BulkFailed}
+	for i, item := range result.Items {
+		if item.Status != want[i] {
+			t.Errorf("Expected item %d %s, got %s (%v)", i, want[i], item.Status, item.Err)
+		}
+	}
+	if !errors.Is(result.Items[2].Err, ErrCustomerNotFound) || !errors.Is(result.Items[3].Err, ErrInvalidTransition) {
+		t.Errorf("Expected not found and invalid status errors, got %v and %v", result.Items[2].Err, result.Items[3].Err)
+	}
+	if created, err := repo.GetByEmail("dana@example.com"); err != nil || created.Name != "Dana White" {
+		t.Errorf("Expected the created customer stored with a normalized email, got %+v, %v", created, err)
+	}
+	if updated, _ := repo.GetByID("customer-123"); updated.Status != "SUSPENDED" || updated.Email != "john.smith@example.com" {
+		t.Errorf("Expected the status updated and the email kept, got %+v", updated)
+	}
+}
+
+func TestCustomerService_BulkWriteCustomers_Atomic(t *testing.T) {
+	// Arrange
+	repo := NewInMemoryRepository()
+	service := NewService(repo, WithMaxBulkSize(2))
+	req := BulkRequest{Atomic: true, Items: []BulkItem{
+		{Customer: CustomerRequest{Name: "Dana White", Status: "ACTIVE"}},
+		{Customer: CustomerRequest{Name: "Jane Copy", Status: "ACTIVE", Email: "jane.doe@example.com"}},
+	}}
+
+	// Act
+	result, err := service.BulkWriteCustomers(context.Background(), req)
+	_, tooLarge := service.BulkWriteCustomers(context.Background(), BulkRequest{Items: make([]BulkItem, 3)})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Items[0].Status != BulkSkipped || result.Items[1].Status != BulkFailed || !errors.Is(result.Items[1].Err, ErrEmailExists) {
+		t.Errorf("Expected the first item skipped and the taken email failed, got %+v", result.Items)
+	}
+	if customers, _ := repo.List(); len(customers) != 5 {
+		t.Errorf("Expected no customer created, got %d customers", len(customers))
+	}
+	if !errors.Is(tooLarge, ErrInvalidBulk) {
+		t.Errorf("Expected ErrInvalidBulk for too many items, got %v", tooLarge)
+	}
+}
+
 func TestCustomerService_CreateCustomer(t *testing.T) {
 	// Arrange
 	repo := NewInMemoryRepository()
@@ -62,7 +189,7 @@ func TestCustomerService_CreateCustomer(t *testing.T) {
 	}
 
 	// Act
-	customer, err := service.CreateCustomer(req)
+	customer, err := service.CreateCustomer(context.Background(), req)
 	// Assert
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
@@ -81,7 +208,7 @@ func TestCustomerService_CreateCustomer(t *testing.T) {
 	}
 
 	// Verify customer can be retrieved
-	retrievedCustomer, err := service.GetCustomer(customer.CustomerID)
+	retrievedCustomer, err := service.GetCustomer(context.Background(), customer.CustomerID)
 	if err != nil {
 		t.Fatalf("Expected no error retrieving customer, got %v", err)
 	}
@@ -126,7 +253,7 @@ func TestCustomerService_CreateCustomer_ValidationError(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			// Act
-			customer, err := service.CreateCustomer(tc.request)
+			customer, err := service.CreateCustomer(context.Background(), tc.request)
 
 			// Assert
 			if err == nil {
@@ -146,7 +273,7 @@ func TestCustomerService_IsCustomerActive(t *testing.T) {
 	service := NewService(repo)
 
 	// Test active customer
-	isActive, err := service.IsCustomerActive("customer-456")
+	isActive, err := service.IsCustomerActive(context.Background(), "customer-456")
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
@@ -156,7 +283,7 @@ func TestCustomerService_IsCustomerActive(t *testing.T) {
 	}
 
 	// Test inactive customer
-	isActive, err = service.IsCustomerActive("customer-789")
+	isActive, err = service.IsCustomerActive(context.Background(), "customer-789")
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
@@ -173,11 +300,11 @@ func TestCustomerService_UpdateCustomer(t *testing.T) {
 
 	req := CustomerRequest{
 		Name:   "Updated Name",
-		Status: "INACTIVE",
+		Status: "SUSPENDED",
 	}
 
 	// Act
-	customer, err := service.UpdateCustomer("customer-456", req)
+	customer, err := service.UpdateCustomer(context.Background(), "customer-456", req)
 	// Assert
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
@@ -187,12 +314,12 @@ func TestCustomerService_UpdateCustomer(t *testing.T) {
 		t.Errorf("Expected updated name 'Updated Name', got %s", customer.Name)
 	}
 
-	if customer.Status != "INACTIVE" {
-		t.Errorf("Expected updated status 'INACTIVE', got %s", customer.Status)
+	if customer.Status != "SUSPENDED" {
+		t.Errorf("Expected updated status 'SUSPENDED', got %s", customer.Status)
 	}
 
 	// Verify changes persisted
-	retrievedCustomer, err := service.GetCustomer("customer-456")
+	retrievedCustomer, err := service.GetCustomer(context.Background(), "customer-456")
 	if err != nil {
 		t.Fatalf("Expected no error retrieving customer, got %v", err)
 	}
@@ -202,38 +329,146 @@ func TestCustomerService_UpdateCustomer(t *testing.T) {
 	}
 }
 
+func TestCustomerService_PatchCustomer(t *testing.T) {
+	// Arrange
+	repo := NewInMemoryRepository()
+	service := NewService(repo)
+	before, _ := service.GetCustomer(context.Background(), "customer-456")
+	originalName := before.Name
+	status := "SUSPENDED"
+
+	// Act
+	customer, err := service.PatchCustomer(context.Background(), "customer-456", CustomerPatch{Status: &status})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if customer.Status != "SUSPENDED" {
+		t.Errorf("Expected patched status 'SUSPENDED', got %s", customer.Status)
+	}
+
+	if customer.Name != originalName {
+		t.Errorf("Expected name %q to be kept, got %q", originalName, customer.Name)
+	}
+}
+
+func TestCustomerService_PatchCustomer_Invalid(t *testing.T) {
+	// Arrange
+	repo := NewInMemoryRepository()
+	service := NewService(repo)
+	status := "PENDING"
+
+	// Act
+	_, err := service.PatchCustomer(context.Background(), "customer-456", CustomerPatch{Status: &status})
+	_, notFoundErr := service.PatchCustomer(context.Background(), "customer-missing", CustomerPatch{})
+
+	// Assert
+	if err == nil {
+		t.Error("Expected the merged customer to fail validation")
+	}
+
+	if !errors.Is(notFoundErr, ErrCustomerNotFound) {
+		t.Errorf("Expected ErrCustomerNotFound, got %v", notFoundErr)
+	}
+
+	customer, _ := service.GetCustomer(context.Background(), "customer-456")
+	if customer.Status == "PENDING" {
+		t.Error("Expected a rejected patch not to be persisted")
+	}
+}
+
 func TestCustomerService_DeleteCustomer(t *testing.T) {
 	// Arrange
 	repo := NewInMemoryRepository()
 	service := NewService(repo)
 
 	// Verify customer exists first
-	_, err := service.GetCustomer("customer-456")
+	_, err := service.GetCustomer(context.Background(), "customer-456")
 	if err != nil {
 		t.Fatalf("Expected customer to exist, got error: %v", err)
 	}
 
 	// Act
-	err = service.DeleteCustomer("customer-456")
+	err = service.DeleteCustomer(context.Background(), "customer-456")
 	// Assert
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
 
 	// Verify customer no longer exists
-	_, err = service.GetCustomer("customer-456")
+	_, err = service.GetCustomer(context.Background(), "customer-456")
 	if err == nil {
 		t.Fatal("Expected error when getting deleted customer, got nil")
 	}
 }
 
+func TestCustomerService_RestoreCustomer(t *testing.T) {
+	// Arrange
+	service := NewService(NewInMemoryRepository())
+	if err := service.DeleteCustomer(context.Background(), "customer-456"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Act
+	deleted, getErr := service.GetCustomerIncludingDeleted(context.Background(), "customer-456")
+	restored, err := service.RestoreCustomer(context.Background(), "customer-456")
+	_, againErr := service.RestoreCustomer(context.Background(), "customer-456")
+
+	// Assert
+	if getErr != nil || !deleted.IsDeleted() {
+		t.Errorf("Expected the deleted customer to be readable including deleted, got %v", getErr)
+	}
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if restored.IsDeleted() || restored.CustomerID != "customer-456" {
+		t.Errorf("Expected the restored customer, got %+v", restored)
+	}
+	if !errors.Is(againErr, ErrCustomerNotDeleted) {
+		t.Errorf("Expected ErrCustomerNotDeleted, got %v", againErr)
+	}
+}
+
+func TestCustomerService_AuditFields(t *testing.T) {
+	// Arrange
+	now := time.Date(2024, 6, 1, 8, 0, 0, 0, time.UTC)
+	service := NewService(NewInMemoryRepository(), WithClock(clock.Func(func() time.Time { return now })))
+	creator := auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "ci"})
+	editor := auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "ops"})
+
+	// Act
+	created, err := service.CreateCustomer(creator, CustomerRequest{Name: "Audit Customer", Status: "ACTIVE"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	createdAt := now
+	now = now.Add(time.Hour)
+	updated, err := service.AddSegment(editor, created.CustomerID, "vip")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !created.CreatedAt.Equal(createdAt) || created.CreatedBy != "ci" || created.UpdatedBy != "ci" {
+		t.Errorf("Expected creation to be stamped at %s by ci, got %+v", createdAt, created)
+	}
+	if !updated.CreatedAt.Equal(createdAt) || updated.CreatedBy != "ci" {
+		t.Errorf("Expected creation audit to be kept, got %+v", updated)
+	}
+	if !updated.UpdatedAt.Equal(now) || updated.UpdatedBy != "ops" {
+		t.Errorf("Expected update to be stamped at %s by ops, got %+v", now, updated)
+	}
+}
+
 func TestCustomerService_ListCustomers(t *testing.T) {
 	// Arrange
 	repo := NewInMemoryRepository()
 	service := NewService(repo)
 
 	// Act
-	customers, err := service.ListCustomers()
+	customers, err := service.ListCustomers(context.Background())
 	// Assert
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
@@ -249,3 +484,624 @@ func TestCustomerService_ListCustomers(t *testing.T) {
 		t.Errorf("Expected %d customers, got %d", expectedCount, len(customers))
 	}
 }
+
+func TestCustomerService_ListCustomersBySegment(t *testing.T) {
+	// Arrange
+	repo := NewInMemoryRepository()
+	service := NewService(repo)
+
+	// Act
+	customers, err := service.ListCustomersBySegment(context.Background(), "newsletter")
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(customers) != 2 {
+		t.Fatalf("Expected 2 newsletter customers, got %d", len(customers))
+	}
+
+	for _, customer := range customers {
+		if !customer.HasSegment("newsletter") {
+			t.Errorf("Expected customer %s to carry segment 'newsletter'", customer.CustomerID)
+		}
+	}
+
+	// Invalid segment tags are rejected
+	if _, err := service.ListCustomersBySegment(context.Background(), "VIP!"); !errors.Is(err, ErrInvalidSegment) {
+		t.Errorf("Expected ErrInvalidSegment, got %v", err)
+	}
+}
+
+func TestCustomerService_FindCustomers_Paginates(t *testing.T) {
+	// Arrange
+	repo := NewInMemoryRepository()
+	service := NewService(repo)
+	filter := CustomerFilter{Limit: 2, Offset: 2}
+
+	// Act
+	customers, err := service.FindCustomers(context.Background(), filter)
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(customers) != 2 {
+		t.Fatalf("Expected 2 customers, got %d", len(customers))
+	}
+
+	// Pages are ordered by ID: customer-101, customer-123, customer-202, ...
+	if customers[0].CustomerID != "customer-202" || customers[1].CustomerID != "customer-456" {
+		t.Errorf("Expected customer-202 and customer-456, got %s and %s", customers[0].CustomerID, customers[1].CustomerID)
+	}
+
+	total, err := service.CountCustomers(context.Background(), filter)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if total != 5 {
+		t.Errorf("Expected total 5, got %d", total)
+	}
+
+	// Negative pagination values are rejected
+	if _, err := service.FindCustomers(context.Background(), CustomerFilter{Offset: -1}); !errors.Is(err, ErrInvalidFilter) {
+		t.Errorf("Expected ErrInvalidFilter, got %v", err)
+	}
+}
+
+func TestCustomerService_FindCustomers_StatusAndSort(t *testing.T) {
+	// Arrange
+	service := NewService(NewInMemoryRepository())
+	filter := CustomerFilter{Status: StatusActive, Sort: listing.Sort{{Field: "creditLimit", Descending: true}}, Limit: 3}
+
+	// Act
+	customers, err := service.FindCustomers(context.Background(), filter)
+	total, countErr := service.CountCustomers(context.Background(), filter)
+
+	// Assert
+	if err != nil || countErr != nil {
+		t.Fatalf("Expected no error, got %v and %v", err, countErr)
+	}
+	if len(customers) != 3 || customers[0].CustomerID != "customer-456" || customers[1].CustomerID != "customer-101" ||
+		customers[2].CustomerID != "customer-202" {
+		t.Errorf("Expected the active customers with the highest credit limits first, got %v", customers)
+	}
+	if total != 4 {
+		t.Errorf("Expected 4 active customers, got %d", total)
+	}
+
+	// Unknown statuses and unsortable fields are rejected
+	for _, invalid := range []CustomerFilter{{Status: "DORMANT"}, {Sort: listing.Sort{{Field: "phone"}}}} {
+		if _, err := service.FindCustomers(context.Background(), invalid); !errors.Is(err, ErrInvalidFilter) {
+			t.Errorf("Expected ErrInvalidFilter for %+v, got %v", invalid, err)
+		}
+	}
+}
+
+func TestCustomerService_AddAndRemoveSegment(t *testing.T) {
+	// Arrange
+	repo := NewInMemoryRepository()
+	service := NewService(repo)
+
+	// Act
+	customer, err := service.AddSegment(context.Background(), "customer-123", "vip")
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if !customer.HasSegment("vip") {
+		t.Fatal("Expected customer to carry segment 'vip'")
+	}
+
+	vips, err := service.ListCustomersBySegment(context.Background(), "vip")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(vips) != 2 {
+		t.Errorf("Expected 2 vip customers after add, got %d", len(vips))
+	}
+
+	// Act
+	customer, err = service.RemoveSegment(context.Background(), "customer-123", "vip")
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if customer.HasSegment("vip") {
+		t.Error("Expected segment 'vip' to be removed")
+	}
+
+	vips, err = service.ListCustomersBySegment(context.Background(), "vip")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(vips) != 1 {
+		t.Errorf("Expected 1 vip customer after remove, got %d", len(vips))
+	}
+}
+
+func TestCustomerService_AddSegment_Limit(t *testing.T) {
+	// Arrange
+	repo := NewInMemoryRepository()
+	service := NewService(repo, WithMaxSegments(2))
+
+	// customer-456 already carries "vip" and "newsletter"
+
+	// Act
+	_, err := service.AddSegment(context.Background(), "customer-456", "churn-risk")
+
+	// Assert
+	if !errors.Is(err, ErrTooManySegments) {
+		t.Fatalf("Expected ErrTooManySegments, got %v", err)
+	}
+
+	// Re-adding an existing segment does not count against the limit
+	if _, err := service.AddSegment(context.Background(), "customer-456", "vip"); err != nil {
+		t.Errorf("Expected no error re-adding existing segment, got %v", err)
+	}
+}
+
+func TestCustomerService_Addresses_DefaultPerType(t *testing.T) {
+	// Arrange
+	service := NewService(NewInMemoryRepository())
+	ctx := context.Background()
+	req := func(addressType, city string, isDefault bool) AddressRequest {
+		return AddressRequest{Type: addressType, Line1: " 1 Main St ", City: city, PostalCode: "12345", Country: "us", IsDefault: isDefault}
+	}
+
+	// Act
+	home, err := service.CreateAddress(ctx, "customer-123", req(AddressShipping, "Springfield", false))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	office, err := service.CreateAddress(ctx, "customer-123", req(AddressShipping, "Shelbyville", false))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	billing, err := service.CreateAddress(ctx, "customer-123", req(AddressBilling, "Springfield", false))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := service.UpdateAddress(ctx, "customer-123", office.AddressID, req(AddressShipping, "Shelbyville", true)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	shipping, err := service.DefaultAddress(ctx, "customer-123", AddressShipping)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !home.IsDefault || home.Line1 != "1 Main St" || home.Country != "US" {
+		t.Errorf("Expected the first shipping address to be a normalized default, got %+v", home)
+	}
+	if office.IsDefault {
+		t.Error("Expected the second shipping address not to take over the default")
+	}
+	if !billing.IsDefault {
+		t.Error("Expected the first billing address to be the billing default")
+	}
+	if shipping.AddressID != office.AddressID {
+		t.Errorf("Expected %s to become the default shipping address, got %s", office.AddressID, shipping.AddressID)
+	}
+}
+
+func TestCustomerService_Addresses_Errors(t *testing.T) {
+	// Arrange
+	service := NewService(NewInMemoryRepository())
+	ctx := context.Background()
+	valid := AddressRequest{Type: AddressShipping, Line1: "1 Main St", City: "Springfield", PostalCode: "12345", Country: "US"}
+	invalid := valid
+	invalid.Country = "USA"
+
+	// Act
+	_, missingCustomerErr := service.CreateAddress(ctx, "customer-missing", valid)
+	_, invalidErr := service.CreateAddress(ctx, "customer-123", invalid)
+	_, noDefaultErr := service.DefaultAddress(ctx, "customer-123", AddressBilling)
+	deleteErr := service.DeleteAddress(ctx, "customer-123", "address-missing")
+
+	// Assert
+	if !errors.Is(missingCustomerErr, ErrCustomerNotFound) {
+		t.Errorf("Expected ErrCustomerNotFound, got %v", missingCustomerErr)
+	}
+	if !errors.Is(invalidErr, ErrInvalidAddress) {
+		t.Errorf("Expected ErrInvalidAddress for a three-letter country, got %v", invalidErr)
+	}
+	if !errors.Is(noDefaultErr, ErrAddressNotFound) {
+		t.Errorf("Expected ErrAddressNotFound without a billing address, got %v", noDefaultErr)
+	}
+	if !errors.Is(deleteErr, ErrAddressNotFound) {
+		t.Errorf("Expected ErrAddressNotFound deleting a missing address, got %v", deleteErr)
+	}
+}
+
+func TestCustomerService_ContactDetails(t *testing.T) {
+	tests := []struct {
+		name      string
+		email     string
+		phone     string
+		wantEmail string
+		wantPhone string
+		wantErr   error
+	}{
+		{name: "normalized", email: " Fox.Mulder@FBI.gov ", phone: "+1 (202) 555-0143", wantEmail: "fox.mulder@fbi.gov", wantPhone: "+12025550143"},
+		{name: "none", email: "", phone: ""},
+		{name: "display name", email: "Fox Mulder <fox@fbi.gov>", wantErr: ErrInvalidEmail},
+		{name: "missing domain", email: "fox@", wantErr: ErrInvalidEmail},
+		{name: "national phone", phone: "0202 555 0143", wantErr: ErrInvalidPhone},
+		{name: "too long phone", phone: "+1234567890123456", wantErr: ErrInvalidPhone},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			service := NewService(NewInMemoryRepository())
+			req := CustomerRequest{Name: "Fox Mulder", Status: "ACTIVE", Email: tt.email, Phone: tt.phone}
+
+			// Act
+			customer, err := service.CreateCustomer(context.Background(), req)
+
+			// Assert
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if customer.Email != tt.wantEmail || customer.Phone != tt.wantPhone {
+				t.Errorf("Expected email %q and phone %q, got %q and %q", tt.wantEmail, tt.wantPhone, customer.Email, customer.Phone)
+			}
+		})
+	}
+}
+
+func TestCustomerService_GetCustomerByEmail(t *testing.T) {
+	// Arrange
+	service := NewService(NewInMemoryRepository())
+	ctx := context.Background()
+	cleared := ""
+
+	// Act
+	found, err := service.GetCustomerByEmail(ctx, "JANE.DOE@example.com")
+	_, takenErr := service.CreateCustomer(ctx, CustomerRequest{Name: "Jane Copy", Status: "ACTIVE", Email: "jane.doe@example.com"})
+	kept, updateErr := service.UpdateCustomer(ctx, "customer-456", CustomerRequest{Name: "Jane Doe", Status: "ACTIVE"})
+	_, patchErr := service.PatchCustomer(ctx, "customer-456", CustomerPatch{Email: &cleared})
+	_, missingErr := service.GetCustomerByEmail(ctx, "jane.doe@example.com")
+	_, invalidErr := service.GetCustomerByEmail(ctx, "not-an-email")
+
+	// Assert
+	if err != nil || found.CustomerID != "customer-456" {
+		t.Errorf("Expected customer-456 by case-insensitive email, got %+v, %v", found, err)
+	}
+	if !errors.Is(takenErr, ErrEmailExists) {
+		t.Errorf("Expected ErrEmailExists for a taken email, got %v", takenErr)
+	}
+	if updateErr != nil || kept.Email != "jane.doe@example.com" {
+		t.Errorf("Expected an update without email to keep it, got %+v, %v", kept, updateErr)
+	}
+	if patchErr != nil || !errors.Is(missingErr, ErrCustomerNotFound) {
+		t.Errorf("Expected a patch to clear the email, got %v then %v", patchErr, missingErr)
+	}
+	if !errors.Is(invalidErr, ErrInvalidEmail) {
+		t.Errorf("Expected ErrInvalidEmail, got %v", invalidErr)
+	}
+}
+
+func TestCustomerService_TransitionCustomer(t *testing.T) {
+	tests := []struct {
+		name       string
+		customerID string
+		path       []string
+		wantErr    error
+	}{
+		{name: "suspend and reactivate", customerID: "customer-456", path: []string{StatusSuspended, StatusActive}},
+		{name: "close a suspended customer", customerID: "customer-789", path: []string{StatusClosed}},
+		{name: "reopen a closed customer", customerID: "customer-789", path: []string{StatusClosed, StatusActive}, wantErr: ErrInvalidTransition},
+		{name: "back to pending", customerID: "customer-456", path: []string{StatusPending}, wantErr: ErrInvalidTransition},
+		{name: "same status", customerID: "customer-456", path: []string{StatusActive}, wantErr: ErrInvalidTransition},
+		{name: "missing customer", customerID: "customer-missing", path: []string{StatusClosed}, wantErr: ErrCustomerNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			service := NewService(NewInMemoryRepository())
+
+			// Act
+			var customer *Customer
+			var err error
+			for _, status := range tt.path {
+				if customer, err = service.TransitionCustomer(context.Background(), tt.customerID, status); err != nil {
+					break
+				}
+			}
+
+			// Assert
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if want := tt.path[len(tt.path)-1]; customer.Status != want {
+				t.Errorf("Expected status %s, got %s", want, customer.Status)
+			}
+		})
+	}
+}
+
+func TestCustomerService_GetCustomerAsOf(t *testing.T) {
+	// Arrange
+	repo, _ := newEventSourcedRepository(t, DefaultSnapshotEvery)
+	suspendedAt := time.Now().UTC().Add(time.Hour)
+	if _, err := NewService(repo, WithClock(clock.Fixed(suspendedAt))).TransitionCustomer(context.Background(), "customer-456", StatusSuspended); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	service := NewService(repo, WithHistory(repo), WithClock(clock.Fixed(suspendedAt.Add(time.Hour))))
+
+	tests := []struct {
+		name           string
+		service        *CustomerService
+		asOf           time.Time
+		expectedStatus string
+		expectedErr    error
+	}{
+		{name: "before the suspension", service: service, asOf: suspendedAt.Add(-time.Minute), expectedStatus: StatusActive},
+		{name: "after the suspension", service: service, asOf: suspendedAt, expectedStatus: StatusSuspended},
+		{name: "before creation", service: service, asOf: time.Time{}.Add(-time.Hour), expectedErr: ErrCustomerNotFound},
+		{name: "in the future", service: service, asOf: suspendedAt.Add(2 * time.Hour), expectedErr: apperr.ErrValidation},
+		{name: "history not recorded", service: NewService(repo, WithClock(clock.Fixed(suspendedAt))), asOf: suspendedAt, expectedErr: ErrHistoryNotRecorded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			customer, err := tt.service.GetCustomerAsOf(context.Background(), "customer-456", tt.asOf)
+
+			// Assert
+			if tt.expectedErr != nil {
+				if !errors.Is(err, tt.expectedErr) {
+					t.Errorf("Expected %v, got %v", tt.expectedErr, err)
+				}
+				return
+			}
+			if err != nil || customer.Status != tt.expectedStatus {
+				t.Errorf("Expected status %s, got %+v (%v)", tt.expectedStatus, customer, err)
+			}
+		})
+	}
+}
+
+func TestSegmentRules_Evaluate(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		rules    SegmentRules
+		customer Customer
+		expected []string
+	}{
+		{name: "none", rules: DefaultSegmentRules, customer: Customer{CreditLimit: 100, CreatedAt: now.AddDate(-1, 0, 0), UpdatedAt: now.AddDate(0, -1, 0)}},
+		{name: "VIP and NEW", rules: DefaultSegmentRules, customer: Customer{CreditLimit: 5000, CreatedAt: now.AddDate(0, 0, -29), UpdatedAt: now}, expected: []string{SegmentVIP, SegmentNew}},
+		{name: "dormant at the threshold", rules: DefaultSegmentRules, customer: Customer{CreatedAt: now.AddDate(-2, 0, 0), UpdatedAt: now.Add(-DefaultSegmentRules.DormantAfter)}, expected: []string{SegmentDormant}},
+		{name: "disabled rules", rules: SegmentRules{}, customer: Customer{CreditLimit: 90000, CreatedAt: now, UpdatedAt: now.AddDate(-5, 0, 0)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			segments := tt.rules.Evaluate(&tt.customer, now)
+
+			// Assert
+			if !slices.Equal(segments, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, segments)
+			}
+		})
+	}
+}
+
+func TestCustomerService_ComputedSegments(t *testing.T) {
+	// Arrange
+	now := time.Now().UTC()
+	repo := NewInMemoryRepositoryWith([]*Customer{
+		{CustomerID: "customer-vip", Name: "Walter Skinner", Status: StatusActive, CreditLimit: 20000, CreatedAt: now.AddDate(-3, 0, 0), UpdatedAt: now.AddDate(0, 0, -1)},
+		{CustomerID: "customer-dormant", Name: "Alex Krycek", Status: StatusActive, CreditLimit: 100, CreatedAt: now.AddDate(-3, 0, 0), UpdatedAt: now.AddDate(-1, 0, 0)},
+		{CustomerID: "customer-new", Name: "Monica Reyes", Status: StatusActive, CreditLimit: 100, CreatedAt: now.AddDate(0, 0, -2), UpdatedAt: now.AddDate(0, 0, -2)},
+	})
+	service := NewService(repo, WithClock(clock.Fixed(now)))
+
+	// Act
+	vip, err := service.GetCustomer(context.Background(), "customer-vip")
+	dormant, _ := service.FindCustomers(context.Background(), CustomerFilter{ComputedSegment: SegmentDormant})
+	newCount, _ := service.CountCustomers(context.Background(), CustomerFilter{ComputedSegment: SegmentNew})
+	_, unknownErr := service.FindCustomers(context.Background(), CustomerFilter{ComputedSegment: "LOYAL"})
+	_, disabledErr := NewService(repo, WithSegmentRules(SegmentRules{})).FindCustomers(context.Background(), CustomerFilter{ComputedSegment: SegmentVIP})
+
+	// Assert
+	if err != nil || !slices.Equal(vip.ComputedSegments, []string{SegmentVIP}) {
+		t.Errorf("Expected customer-vip in VIP, got %+v (%v)", vip, err)
+	}
+	if stored, _ := repo.GetByID("customer-vip"); stored.ComputedSegments != nil {
+		t.Errorf("Expected the stored customer to stay without computed segments, got %v", stored.ComputedSegments)
+	}
+	if len(dormant) != 1 || dormant[0].CustomerID != "customer-dormant" || !slices.Equal(dormant[0].ComputedSegments, []string{SegmentDormant}) {
+		t.Errorf("Expected only customer-dormant listed as DORMANT, got %+v", dormant)
+	}
+	if newCount != 1 {
+		t.Errorf("Expected 1 NEW customer, got %d", newCount)
+	}
+	if !errors.Is(unknownErr, ErrInvalidFilter) || !errors.Is(disabledErr, ErrInvalidFilter) {
+		t.Errorf("Expected ErrInvalidFilter for unknown and disabled segments, got %v and %v", unknownErr, disabledErr)
+	}
+}
+
+func TestCustomerService_LoyaltyTier(t *testing.T) {
+	// Arrange
+	tiers := map[string]string{"customer-123": "GOLD"}
+	tierOf := func(ctx context.Context, customerID string) (string, error) {
+		if customerID == "customer-456" {
+			return "", errors.New("loyalty storage down")
+		}
+		return tiers[customerID], nil
+	}
+	service := NewService(NewInMemoryRepository(), WithLoyaltyTier(tierOf))
+
+	// Act
+	gold, goldErr := service.GetCustomer(context.Background(), "customer-123")
+	degraded, degradedErr := service.GetCustomer(context.Background(), "customer-456")
+
+	// Assert
+	if goldErr != nil || gold.LoyaltyTier != "GOLD" || gold.ToResponse().LoyaltyTier != "GOLD" {
+		t.Errorf("Expected customer-123 to be GOLD, got %+v (%v)", gold, goldErr)
+	}
+	if degradedErr != nil || degraded.LoyaltyTier != "" {
+		t.Errorf("Expected customer-456 served without a tier, got %+v (%v)", degraded, degradedErr)
+	}
+}
+
+func TestCustomerService_StatusRules(t *testing.T) {
+	// Arrange
+	service := NewService(NewInMemoryRepository())
+	ctx := context.Background()
+
+	// Act
+	pending, createErr := service.CreateCustomer(ctx, CustomerRequest{Name: "New Customer", Status: StatusPending})
+	_, suspendedErr := service.CreateCustomer(ctx, CustomerRequest{Name: "New Customer", Status: StatusSuspended})
+	_, skipErr := service.UpdateCustomer(ctx, pending.CustomerID, CustomerRequest{Name: "New Customer", Status: StatusSuspended})
+	renamed, renameErr := service.UpdateCustomer(ctx, pending.CustomerID, CustomerRequest{Name: "Renamed Customer", Status: StatusPending})
+
+	// Assert
+	if createErr != nil || pending.Status != StatusPending {
+		t.Fatalf("Expected a PENDING customer, got %+v, %v", pending, createErr)
+	}
+	if !errors.Is(suspendedErr, ErrInvalidTransition) {
+		t.Errorf("Expected ErrInvalidTransition creating a SUSPENDED customer, got %v", suspendedErr)
+	}
+	if !errors.Is(skipErr, ErrInvalidTransition) || !strings.Contains(skipErr.Error(), "allowed: ACTIVE, CLOSED") {
+		t.Errorf("Expected ErrInvalidTransition naming the allowed statuses, got %v", skipErr)
+	}
+	if renameErr != nil || renamed.Name != "Renamed Customer" {
+		t.Errorf("Expected an update keeping the status to succeed, got %+v, %v", renamed, renameErr)
+	}
+}
+
+func TestCustomerService_Credit(t *testing.T) {
+	// Arrange
+	service := NewService(NewInMemoryRepository())
+	ctx := context.Background()
+	limit := 300.0
+
+	// Act
+	approved, approvedErr := service.CheckCredit(ctx, "customer-123", 750)
+	declined, declinedErr := service.CheckCredit(ctx, "customer-123", 750.01)
+	_, zeroErr := service.CheckCredit(ctx, "customer-123", 0)
+	reserved, reserveErr := service.ReserveCredit(ctx, "customer-123", CreditRequest{Amount: 700.004})
+	_, overErr := service.ReserveCredit(ctx, "customer-123", CreditRequest{Amount: 50.01})
+	released, releaseErr := service.ReleaseCredit(ctx, "customer-123", CreditRequest{Amount: 450})
+	_, belowZeroErr := service.ReleaseCredit(ctx, "customer-123", CreditRequest{Amount: 500.01})
+	_, invalidErr := service.ReserveCredit(ctx, "customer-123", CreditRequest{Amount: -5})
+	_, missingErr := service.ReserveCredit(ctx, "customer-missing", CreditRequest{Amount: 5})
+	lowered, lowerErr := service.PatchCustomer(ctx, "customer-123", CustomerPatch{CreditLimit: &limit})
+	kept, keepErr := service.UpdateCustomer(ctx, "customer-123", CustomerRequest{Name: "John Smith", Status: StatusActive})
+
+	// Assert
+	if approvedErr != nil || !approved.Approved || approved.AvailableCredit != 750 {
+		t.Errorf("Expected 750 of 1000 available and approved, got %+v, %v", approved, approvedErr)
+	}
+	if declinedErr != nil || declined.Approved {
+		t.Errorf("Expected an amount over the available credit to be declined, got %+v, %v", declined, declinedErr)
+	}
+	if !errors.Is(zeroErr, ErrInvalidAmount) {
+		t.Errorf("Expected ErrInvalidAmount for a zero amount, got %v", zeroErr)
+	}
+	if reserveErr != nil || reserved.CurrentExposure != 950 {
+		t.Errorf("Expected exposure 950 after reserving 700, got %+v, %v", reserved, reserveErr)
+	}
+	if !errors.Is(overErr, ErrCreditLimitExceeded) {
+		t.Errorf("Expected ErrCreditLimitExceeded, got %v", overErr)
+	}
+	if releaseErr != nil || released.CurrentExposure != 500 {
+		t.Errorf("Expected exposure 500 after releasing 450, got %+v, %v", released, releaseErr)
+	}
+	if !errors.Is(belowZeroErr, ErrExposureBelowZero) {
+		t.Errorf("Expected ErrExposureBelowZero, got %v", belowZeroErr)
+	}
+	if !errors.Is(invalidErr, ErrInvalidAmount) {
+		t.Errorf("Expected ErrInvalidAmount for a negative amount, got %v", invalidErr)
+	}
+	if !errors.Is(missingErr, ErrCustomerNotFound) {
+		t.Errorf("Expected ErrCustomerNotFound, got %v", missingErr)
+	}
+	if lowerErr != nil || lowered.CreditLimit != 300 || lowered.CheckCredit(1).Approved {
+		t.Errorf("Expected a lowered limit below the exposure to decline every amount, got %+v, %v", lowered, lowerErr)
+	}
+	if keepErr != nil || kept.CreditLimit != 300 || kept.CurrentExposure != 500 {
+		t.Errorf("Expected an update without a limit to keep the limit and exposure, got %+v, %v", kept, keepErr)
+	}
+}
+
+func TestCustomerService_ExportCustomers(t *testing.T) {
+	// Arrange
+	repo := NewInMemoryRepository()
+	service := NewService(repo)
+	total := ExportPageSize + 3
+	for i := 0; i < total; i++ {
+		err := repo.Create(&Customer{
+			CustomerID: fmt.Sprintf("customer-export-%04d", i),
+			Name:       "Export Customer",
+			Status:     "ACTIVE",
+			Segments:   []string{"export"},
+		})
+		if err != nil {
+			t.Fatalf("Expected no error seeding customer, got %v", err)
+		}
+	}
+
+	// Act
+	var pages []int
+	seen := make(map[string]bool)
+	err := service.ExportCustomers(context.Background(), CustomerFilter{Segment: "export", Limit: 1, Offset: 2}, func(customers []*Customer) error {
+		pages = append(pages, len(customers))
+		for _, customer := range customers {
+			seen[customer.CustomerID] = true
+		}
+		return nil
+	})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(pages) != 2 || pages[0] != ExportPageSize || pages[1] != 3 {
+		t.Errorf("Expected pages of %d and 3 customers, got %v", ExportPageSize, pages)
+	}
+	if len(seen) != total {
+		t.Errorf("Expected %d distinct customers, got %d", total, len(seen))
+	}
+}
+
+func TestCustomerService_ExportCustomers_Errors(t *testing.T) {
+	// Arrange
+	service := NewService(NewInMemoryRepository())
+	errStop := errors.New("client went away")
+
+	// Act
+	invalidErr := service.ExportCustomers(context.Background(), CustomerFilter{Status: "UNKNOWN"}, func([]*Customer) error { return nil })
+	emitErr := service.ExportCustomers(context.Background(), CustomerFilter{}, func([]*Customer) error { return errStop })
+
+	// Assert
+	if !errors.Is(invalidErr, ErrInvalidFilter) {
+		t.Errorf("Expected ErrInvalidFilter, got %v", invalidErr)
+	}
+	if !errors.Is(emitErr, errStop) {
+		t.Errorf("Expected the emit error, got %v", emitErr)
+	}
+}
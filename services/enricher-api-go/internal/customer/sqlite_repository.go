@@ -0,0 +1,500 @@
+package customer
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"enricher-api-go/internal/sqlite"
+)
+
+// SQLiteSchema creates the customers and customer_addresses tables used by
+// SQLiteRepository.
+//
+// It mirrors the PostgreSQL schema: segments are a JSON array in a TEXT
+// column, matched with json_each, and timestamps are stored as UTC text so
+// that they compare in time order.
+const SQLiteSchema = `
+CREATE TABLE IF NOT EXISTS customers (
+	customer_id      TEXT PRIMARY KEY,
+	name             TEXT NOT NULL,
+	status           TEXT NOT NULL,
+	email            TEXT NOT NULL DEFAULT '',
+	phone            TEXT NOT NULL DEFAULT '',
+	credit_limit     REAL NOT NULL DEFAULT 0 CHECK (credit_limit >= 0),
+	current_exposure REAL NOT NULL DEFAULT 0 CHECK (current_exposure >= 0),
+	segments         TEXT NOT NULL DEFAULT '[]',
+	created_at       TIMESTAMP NOT NULL,
+	updated_at       TIMESTAMP NOT NULL,
+	created_by       TEXT NOT NULL DEFAULT '',
+	updated_by       TEXT NOT NULL DEFAULT '',
+	deleted_at       TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS customers_email_key ON customers (email) WHERE email <> '';
+CREATE TABLE IF NOT EXISTS customer_addresses (
+	address_id  TEXT PRIMARY KEY,
+	customer_id TEXT NOT NULL REFERENCES customers (customer_id),
+	type        TEXT NOT NULL,
+	line1       TEXT NOT NULL,
+	line2       TEXT NOT NULL DEFAULT '',
+	city        TEXT NOT NULL,
+	region      TEXT NOT NULL DEFAULT '',
+	postal_code TEXT NOT NULL,
+	country     TEXT NOT NULL,
+	is_default  BOOLEAN NOT NULL DEFAULT 0,
+	created_at  TIMESTAMP NOT NULL,
+	updated_at  TIMESTAMP NOT NULL,
+	created_by  TEXT NOT NULL DEFAULT '',
+	updated_by  TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS customer_addresses_customer_idx ON customer_addresses (customer_id, type);
+`
+
+// SQLiteRepository implements Repository on top of a SQLite database. It
+// does not record change events.
+type SQLiteRepository struct {
+	db *sql.DB
+}
+
+// NewSQLiteRepository creates a customer repository backed by db, as
+// opened by sqlite.Open.
+//
+// The caller owns db and is responsible for closing it; the customers and
+// customer_addresses tables must exist (see SQLiteSchema and EnsureSchema).
+func NewSQLiteRepository(db *sql.DB) *SQLiteRepository {
+	return &SQLiteRepository{db: db}
+}
+
+// EnsureSchema creates the customers and customer_addresses tables and indexes if they do not exist
+func (r *SQLiteRepository) EnsureSchema() error {
+	if _, err := r.db.Exec(SQLiteSchema); err != nil {
+		return fmt.Errorf("failed to create customers schema: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a live customer by ID
+func (r *SQLiteRepository) GetByID(customerID string) (*Customer, error) {
+	return queryOne(r.db, `SELECT `+customerColumns+` FROM customers WHERE customer_id = ? AND `+notDeleted, customerID)
+}
+
+// GetByIDIncludingDeleted retrieves a customer by ID even if it is soft-deleted
+func (r *SQLiteRepository) GetByIDIncludingDeleted(customerID string) (*Customer, error) {
+	return queryOne(r.db, `SELECT `+customerColumns+` FROM customers WHERE customer_id = ?`, customerID)
+}
+
+// GetByIDs retrieves the live customers with the given IDs, skipping unknown ones
+func (r *SQLiteRepository) GetByIDs(customerIDs []string) ([]*Customer, error) {
+	if len(customerIDs) == 0 {
+		return []*Customer{}, nil
+	}
+
+	args := make([]interface{}, len(customerIDs))
+	for i, customerID := range customerIDs {
+		args[i] = customerID
+	}
+	return r.query(
+		`SELECT `+customerColumns+` FROM customers
+		WHERE customer_id IN (`+sqlite.Placeholders(len(args))+`) AND `+notDeleted+` ORDER BY customer_id`,
+		args...,
+	)
+}
+
+// GetByEmail retrieves a live customer by email
+func (r *SQLiteRepository) GetByEmail(email string) (*Customer, error) {
+	if email == "" {
+		return nil, ErrCustomerNotFound
+	}
+	return queryOne(r.db, `SELECT `+customerColumns+` FROM customers WHERE email = ? AND `+notDeleted, email)
+}
+
+// Create adds a new customer
+func (r *SQLiteRepository) Create(customer *Customer) error {
+	return insertSQLiteCustomer(r.db, customer)
+}
+
+// Update modifies an existing customer, leaving its exposure alone
+func (r *SQLiteRepository) Update(customer *Customer) error {
+	_, err := updateSQLiteCustomer(r.db, customer)
+	return err
+}
+
+// WriteAll applies every write in one transaction
+func (r *SQLiteRepository) WriteAll(writes []Write) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin customer bulk write: %w", err)
+	}
+	defer tx.Rollback()
+
+	for i, write := range writes {
+		if write.Create {
+			err = insertSQLiteCustomer(tx, write.Customer)
+		} else {
+			_, err = updateSQLiteCustomer(tx, write.Customer)
+		}
+		if err != nil {
+			return &WriteError{Index: i, Err: err}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit customer bulk write: %w", err)
+	}
+	return nil
+}
+
+// insertSQLiteCustomer adds customer on q
+func insertSQLiteCustomer(q querier, customer *Customer) error {
+	segments, err := marshalSegments(customer.Segments)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.Exec(
+		`INSERT INTO customers (customer_id, name, status, email, phone, credit_limit, current_exposure, segments,
+			created_at, updated_at, created_by, updated_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		customer.CustomerID, customer.Name, customer.Status, customer.Email, customer.Phone,
+		customer.CreditLimit, customer.CurrentExposure, segments,
+		sqlite.Time(customer.CreatedAt), sqlite.Time(customer.UpdatedAt), customer.CreatedBy, customer.UpdatedBy,
+	)
+	if sqlite.IsUniqueViolationOf(err, "customers.email") {
+		return ErrEmailExists
+	}
+	if sqlite.IsUniqueViolation(err) {
+		return ErrCustomerExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to insert customer: %w", err)
+	}
+	return nil
+}
+
+// updateSQLiteCustomer replaces a live customer on q, leaving its exposure
+// alone, and returns the customer as stored
+func updateSQLiteCustomer(q querier, customer *Customer) (*Customer, error) {
+	segments, err := marshalSegments(customer.Segments)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := queryOne(q,
+		`UPDATE customers SET name = ?2, status = ?3, email = ?4, phone = ?5, credit_limit = ?6, segments = ?7,
+			updated_at = ?8, updated_by = ?9
+		WHERE customer_id = ?1 AND `+notDeleted+`
+		RETURNING `+customerColumns,
+		customer.CustomerID, customer.Name, customer.Status, customer.Email, customer.Phone, customer.CreditLimit, segments,
+		sqlite.Time(customer.UpdatedAt), customer.UpdatedBy,
+	)
+	if sqlite.IsUniqueViolationOf(err, "customers.email") {
+		return nil, ErrEmailExists
+	}
+	return updated, err
+}
+
+// Delete soft-deletes a customer by stamping deleted_at
+func (r *SQLiteRepository) Delete(customerID string) error {
+	result, err := r.db.Exec(
+		`UPDATE customers SET deleted_at = ? WHERE customer_id = ? AND `+notDeleted,
+		sqlite.Time(time.Now()), customerID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete customer: %w", err)
+	}
+	return requireRowAffected(result)
+}
+
+// Restore clears deleted_at on a soft-deleted customer
+func (r *SQLiteRepository) Restore(customerID string) error {
+	result, err := r.db.Exec(
+		`UPDATE customers SET deleted_at = NULL WHERE customer_id = ? AND deleted_at IS NOT NULL`,
+		customerID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to restore customer: %w", err)
+	}
+	if err := requireRowAffected(result); !errors.Is(err, ErrCustomerNotFound) {
+		return err
+	}
+
+	// Nothing was restored: tell a live customer apart from a missing one
+	if _, err := r.GetByID(customerID); err != nil {
+		return err
+	}
+	return ErrCustomerNotDeleted
+}
+
+// RewritePersonalData replaces the name, email and phone of a customer, even
+// a soft-deleted one, in a single UPDATE
+func (r *SQLiteRepository) RewritePersonalData(customer *Customer) error {
+	result, err := r.db.Exec(
+		`UPDATE customers SET name = ?2, email = ?3, phone = ?4 WHERE customer_id = ?1`,
+		customer.CustomerID, customer.Name, customer.Email, customer.Phone,
+	)
+	if sqlite.IsUniqueViolationOf(err, "customers.email") {
+		return ErrEmailExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to rewrite customer: %w", err)
+	}
+	return requireRowAffected(result)
+}
+
+// AdjustExposure adds change.Delta to a live customer's exposure in a single
+// conditional UPDATE, so concurrent adjustments never overdraw the limit
+func (r *SQLiteRepository) AdjustExposure(customerID string, change ExposureChange) (*Customer, error) {
+	customer, err := queryOne(r.db,
+		`UPDATE customers SET current_exposure = round(current_exposure + ?2, 2), updated_at = ?3, updated_by = ?4
+		WHERE customer_id = ?1 AND `+notDeleted+`
+			AND round(current_exposure + ?2, 2) >= 0
+			AND (?2 <= 0 OR round(current_exposure + ?2, 2) <= credit_limit)
+		RETURNING `+customerColumns,
+		customerID, change.Delta, sqlite.Time(change.UpdatedAt), change.UpdatedBy,
+	)
+	if !errors.Is(err, ErrCustomerNotFound) {
+		return customer, err
+	}
+
+	// Nothing was adjusted: tell a missing customer apart from a refused change
+	if _, err := r.GetByID(customerID); err != nil {
+		return nil, err
+	}
+	if change.Delta > 0 {
+		return nil, ErrCreditLimitExceeded
+	}
+	return nil, ErrExposureBelowZero
+}
+
+// List returns all live customers ordered by ID
+func (r *SQLiteRepository) List() ([]*Customer, error) {
+	return r.Find(CustomerFilter{})
+}
+
+// Find returns the customers matching filter, ordered by its Sort and then
+// by CustomerID
+func (r *SQLiteRepository) Find(filter CustomerFilter) ([]*Customer, error) {
+	where, args := sqliteFilterClause(filter)
+	query := `SELECT ` + customerColumns + ` FROM customers` + where + filter.Sort.OrderBy(sortColumns, "customer_id")
+
+	// SQLite only takes an OFFSET after a LIMIT, where -1 means none
+	if filter.Limit > 0 || filter.Offset > 0 {
+		limit := -1
+		if filter.Limit > 0 {
+			limit = filter.Limit
+		}
+		query += ` LIMIT ? OFFSET ?`
+		args = append(args, limit, filter.Offset)
+	}
+
+	return r.query(query, args...)
+}
+
+// Count returns the number of customers matching filter, ignoring Limit and Offset
+func (r *SQLiteRepository) Count(filter CustomerFilter) (int, error) {
+	where, args := sqliteFilterClause(filter)
+
+	var count int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM customers`+where, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count customers: %w", err)
+	}
+	return count, nil
+}
+
+// sqliteFilterClause builds the WHERE clause and its arguments for filter's
+// criteria, matching segments with json_each
+func sqliteFilterClause(filter CustomerFilter) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	if !filter.IncludeDeleted {
+		conditions = append(conditions, notDeleted)
+	}
+	if filter.Segment != "" {
+		conditions = append(conditions, `EXISTS (SELECT 1 FROM json_each(customers.segments) WHERE json_each.value = ?)`)
+		args = append(args, filter.Segment)
+	}
+	if filter.Status != "" {
+		conditions = append(conditions, `status = ?`)
+		args = append(args, filter.Status)
+	}
+	if filter.MinCreditLimit > 0 {
+		conditions = append(conditions, `credit_limit >= ?`)
+		args = append(args, filter.MinCreditLimit)
+	}
+	if !filter.CreatedAfter.IsZero() {
+		conditions = append(conditions, `created_at > ?`)
+		args = append(args, sqlite.Time(filter.CreatedAfter))
+	}
+	if !filter.UpdatedUntil.IsZero() {
+		conditions = append(conditions, `updated_at <= ?`)
+		args = append(args, sqlite.Time(filter.UpdatedUntil))
+	}
+
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return ` WHERE ` + strings.Join(conditions, ` AND `), args
+}
+
+// query runs a customer SELECT and scans every row
+func (r *SQLiteRepository) query(query string, args ...interface{}) ([]*Customer, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query customers: %w", err)
+	}
+	defer rows.Close()
+
+	customers := make([]*Customer, 0)
+	for rows.Next() {
+		customer, err := scanCustomer(rows)
+		if err != nil {
+			return nil, err
+		}
+		customers = append(customers, customer)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read customers: %w", err)
+	}
+	return customers, nil
+}
+
+// Addresses returns the addresses of a live customer ordered by type, then
+// creation time
+func (r *SQLiteRepository) Addresses(customerID string) ([]*Address, error) {
+	rows, err := r.db.Query(
+		`SELECT `+addressColumns+` FROM `+liveAddresses+`
+		WHERE a.customer_id = ? ORDER BY a.type, a.created_at, a.address_id`,
+		customerID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query addresses: %w", err)
+	}
+	defer rows.Close()
+
+	addresses := make([]*Address, 0)
+	for rows.Next() {
+		address, err := scanAddress(rows)
+		if err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, address)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read addresses: %w", err)
+	}
+	return addresses, nil
+}
+
+// GetAddress retrieves one address of a live customer
+func (r *SQLiteRepository) GetAddress(customerID, addressID string) (*Address, error) {
+	address, err := scanAddress(r.db.QueryRow(
+		`SELECT `+addressColumns+` FROM `+liveAddresses+` WHERE a.customer_id = ? AND a.address_id = ?`,
+		customerID, addressID,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAddressNotFound
+	}
+	return address, err
+}
+
+// CreateAddress adds an address to a live customer
+func (r *SQLiteRepository) CreateAddress(address *Address) error {
+	return r.writeAddress(address, ErrCustomerNotFound, func(tx *sql.Tx) error {
+		_, err := tx.Exec(
+			`INSERT INTO customer_addresses (address_id, customer_id, type, line1, line2, city, region,
+				postal_code, country, is_default, created_at, updated_at, created_by, updated_by)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			address.AddressID, address.CustomerID, address.Type, address.Line1, address.Line2, address.City,
+			address.Region, address.PostalCode, address.Country, address.IsDefault,
+			sqlite.Time(address.CreatedAt), sqlite.Time(address.UpdatedAt), address.CreatedBy, address.UpdatedBy,
+		)
+		if sqlite.IsUniqueViolation(err) {
+			return ErrAddressExists
+		}
+		if err != nil {
+			return fmt.Errorf("failed to insert address: %w", err)
+		}
+		return nil
+	})
+}
+
+// UpdateAddress replaces an address of a live customer
+func (r *SQLiteRepository) UpdateAddress(address *Address) error {
+	return r.writeAddress(address, ErrAddressNotFound, func(tx *sql.Tx) error {
+		result, err := tx.Exec(
+			`UPDATE customer_addresses SET type = ?3, line1 = ?4, line2 = ?5, city = ?6, region = ?7,
+				postal_code = ?8, country = ?9, is_default = ?10, updated_at = ?11, updated_by = ?12
+			WHERE customer_id = ?1 AND address_id = ?2`,
+			address.CustomerID, address.AddressID, address.Type, address.Line1, address.Line2, address.City,
+			address.Region, address.PostalCode, address.Country, address.IsDefault,
+			sqlite.Time(address.UpdatedAt), address.UpdatedBy,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to update address: %w", err)
+		}
+		return requireAddressAffected(result)
+	})
+}
+
+// DeleteAddress removes an address of a live customer
+func (r *SQLiteRepository) DeleteAddress(customerID, addressID string) error {
+	result, err := r.db.Exec(
+		`DELETE FROM customer_addresses WHERE customer_id = ?1 AND address_id = ?2
+		AND EXISTS (SELECT 1 FROM customers c WHERE c.customer_id = ?1 AND c.deleted_at IS NULL)`,
+		customerID, addressID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete address: %w", err)
+	}
+	return requireAddressAffected(result)
+}
+
+// writeAddress runs write in a transaction, which holds the database write
+// lock from its start, so concurrent writes cannot leave two default
+// addresses of one type. A missing or deleted customer fails with missing.
+// When address is the default, the customer's other addresses of its type
+// are cleared first.
+func (r *SQLiteRepository) writeAddress(address *Address, missing error, write func(tx *sql.Tx) error) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin address write: %w", err)
+	}
+	defer tx.Rollback()
+
+	var found string
+	err = tx.QueryRow(
+		`SELECT customer_id FROM customers WHERE customer_id = ? AND `+notDeleted,
+		address.CustomerID,
+	).Scan(&found)
+	if errors.Is(err, sql.ErrNoRows) {
+		return missing
+	}
+	if err != nil {
+		return fmt.Errorf("failed to look up customer: %w", err)
+	}
+
+	if address.IsDefault {
+		_, err := tx.Exec(
+			`UPDATE customer_addresses SET is_default = 0
+			WHERE customer_id = ? AND type = ? AND address_id <> ? AND is_default`,
+			address.CustomerID, address.Type, address.AddressID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to clear default address: %w", err)
+		}
+	}
+
+	if err := write(tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit address write: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package customer
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Customer lifecycle statuses.
+//
+// New customers start PENDING or ACTIVE. An ACTIVE customer can be
+// SUSPENDED and reactivated, and any customer that is not yet CLOSED can be
+// closed. CLOSED is final.
+const (
+	// StatusPending is a customer that has signed up but cannot order yet
+	StatusPending = "PENDING"
+	// StatusActive is a customer in good standing
+	StatusActive = "ACTIVE"
+	// StatusSuspended is a customer temporarily barred from ordering
+	StatusSuspended = "SUSPENDED"
+	// StatusClosed is a customer whose account has been closed for good
+	StatusClosed = "CLOSED"
+)
+
+// statusTransitions lists the statuses each status may move to
+var statusTransitions = map[string][]string{
+	StatusPending:   {StatusActive, StatusClosed},
+	StatusActive:    {StatusSuspended, StatusClosed},
+	StatusSuspended: {StatusActive, StatusClosed},
+	StatusClosed:    {},
+}
+
+// initialStatuses are the statuses a customer may be created with
+var initialStatuses = []string{StatusPending, StatusActive}
+
+// AllowedTransitions returns the statuses a customer in status may move to.
+//
+// Args:
+//   - status: the customer's current status
+//
+// Returns:
+//   - []string: the reachable statuses; empty for CLOSED and unknown statuses
+func AllowedTransitions(status string) []string {
+	return slices.Clone(statusTransitions[status])
+}
+
+// checkTransition reports whether a customer may move from one status to
+// another.
+//
+// Returns:
+//   - error: ErrInvalidTransition naming the statuses that are allowed instead
+func checkTransition(from, to string) error {
+	if from == to {
+		return fmt.Errorf("%w: customer is already %s", ErrInvalidTransition, to)
+	}
+
+	allowed := statusTransitions[from]
+	if slices.Contains(allowed, to) {
+		return nil
+	}
+	if len(allowed) == 0 {
+		return fmt.Errorf("%w: a %s customer cannot change status", ErrInvalidTransition, from)
+	}
+	return fmt.Errorf("%w: cannot move a %s customer to %s; allowed: %s",
+		ErrInvalidTransition, from, to, strings.Join(allowed, ", "))
+}
+
+// checkInitialStatus reports whether a new customer may start in status.
+//
+// Returns:
+//   - error: ErrInvalidTransition naming the statuses new customers may start in
+func checkInitialStatus(status string) error {
+	if slices.Contains(initialStatuses, status) {
+		return nil
+	}
+	return fmt.Errorf("%w: new customers start %s, not %s",
+		ErrInvalidTransition, strings.Join(initialStatuses, " or "), status)
+}
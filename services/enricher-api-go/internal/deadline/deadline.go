@@ -0,0 +1,276 @@
+// Package deadline bounds how long a request may take.
+//
+// The middleware gives the request context a deadline, so services and the
+// work they wait on give up once it passes, and answers 504 Gateway Timeout
+// as soon as it does, whether or not the handler noticed. The handler writes
+// to a buffer until then: a response it completes in time is sent as
+// written, while a late one, or a failure reported after the deadline, is
+// discarded. A late handler still runs to completion, so writes it applies
+// after the 504 stick. Routes may tighten the deadline with Within.
+//
+// Example usage:
+//
+//	e.Use(deadline.Middleware(2*time.Second, "/v1/ws/"))
+//	e.GET("/v1/products/:id", handler.GetProduct, deadline.Within(500*time.Millisecond))
+package deadline
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"enricher-api-go/internal/logging"
+	"enricher-api-go/internal/problem"
+
+	"github.com/labstack/echo/v4"
+)
+
+// contextKey is the Echo context key holding the request's *guard
+const contextKey = "deadline.guard"
+
+// Middleware bounds every request to timeout, except those whose path starts
+// with one of exemptPrefixes, such as streams. A timeout of zero leaves
+// requests unbounded.
+func Middleware(timeout time.Duration, exemptPrefixes ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if timeout <= 0 {
+				return next(c)
+			}
+			path := c.Request().URL.Path
+			for _, prefix := range exemptPrefixes {
+				if strings.HasPrefix(path, prefix) {
+					return next(c)
+				}
+			}
+			return within(c, timeout, next)
+		}
+	}
+}
+
+// Within bounds the requests of a route to timeout, when that is shorter
+// than the deadline they already have
+func Within(timeout time.Duration) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if timeout <= 0 {
+				return next(c)
+			}
+			g, bounded := c.Get(contextKey).(*guard)
+			if !bounded {
+				return within(c, timeout, next)
+			}
+			if _, current := g.current(); current <= timeout {
+				return next(c)
+			}
+
+			// The enclosing deadline already answers for the response; it
+			// only needs to know the deadline moved
+			ctx, cancel := context.WithTimeout(c.Request().Context(), timeout)
+			defer cancel()
+			c.SetRequest(c.Request().WithContext(ctx))
+			g.tighten(ctx, timeout)
+			return next(c)
+		}
+	}
+}
+
+// guard holds the tightest deadline of a request, which Within may move
+// while the handler runs
+type guard struct {
+	mutex   sync.Mutex
+	ctx     context.Context
+	timeout time.Duration
+	// tightened signals that ctx was replaced
+	tightened chan struct{}
+}
+
+// current returns the context bounding the request and its timeout
+func (g *guard) current() (context.Context, time.Duration) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	return g.ctx, g.timeout
+}
+
+// tighten bounds the request to ctx, whose deadline is timeout from now
+func (g *guard) tighten(ctx context.Context, timeout time.Duration) {
+	g.mutex.Lock()
+	g.ctx, g.timeout = ctx, timeout
+	g.mutex.Unlock()
+
+	select {
+	case g.tightened <- struct{}{}:
+	default:
+	}
+}
+
+// within runs next with the request context bounded to timeout and its
+// response buffered, answering 504 once the deadline passes. It returns only
+// once next does, so the Echo context is never reused under a late handler.
+func within(c echo.Context, timeout time.Duration, next echo.HandlerFunc) error {
+	ctx, cancel := context.WithTimeout(c.Request().Context(), timeout)
+	defer cancel()
+	c.SetRequest(c.Request().WithContext(ctx))
+
+	g := &guard{ctx: ctx, timeout: timeout, tightened: make(chan struct{}, 1)}
+	c.Set(contextKey, g)
+	// The handler goroutine owns c from here on; what the 504 needs is read now
+	timedOut := problem.New(c, http.StatusGatewayTimeout, "")
+	logger := logging.FromContext(ctx)
+
+	res := c.Response()
+	original := res.Writer
+	buffer := &bufferedWriter{header: original.Header().Clone()}
+	res.Writer = buffer
+
+	done := make(chan error, 1)
+	panicked := make(chan any, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				panicked <- p
+			}
+		}()
+		done <- next(c)
+	}()
+
+	for {
+		bound, current := g.current()
+		expired := bound.Done()
+		if err := bound.Err(); err != nil && !errors.Is(err, context.DeadlineExceeded) {
+			// The client went away: nobody waits for a 504, so let the
+			// handler finish as it would without a deadline
+			expired = nil
+		}
+
+		select {
+		case err := <-done:
+			res.Writer = original
+			if !isLateFailure(bound, buffer, err) {
+				return buffer.flushTo(original, err)
+			}
+			settle(res, writeTimeout(original, timedOut, current))
+			logger.Warn("Request deadline exceeded", "timeout", current.String())
+			return nil
+		case p := <-panicked:
+			res.Writer = original
+			panic(p)
+		case <-g.tightened:
+		case <-expired:
+			if !errors.Is(bound.Err(), context.DeadlineExceeded) {
+				continue
+			}
+			size := writeTimeout(original, timedOut, current)
+			logger.Warn("Request deadline exceeded", "timeout", current.String())
+
+			// The late response is discarded once the handler returns
+			select {
+			case <-done:
+			case p := <-panicked:
+				res.Writer = original
+				panic(p)
+			}
+			res.Writer = original
+			settle(res, size)
+			return nil
+		}
+	}
+}
+
+// isLateFailure reports whether a handler that returned err after writing to
+// buffer failed once ctx's deadline had passed
+func isLateFailure(ctx context.Context, buffer *bufferedWriter, err error) bool {
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return false
+	}
+	return buffer.status >= http.StatusInternalServerError || err != nil && buffer.status == 0
+}
+
+// writeTimeout answers 504 on w with p, naming timeout, sends it at once and
+// returns the size of its body
+func writeTimeout(w http.ResponseWriter, p *problem.Problem, timeout time.Duration) int64 {
+	timedOut := *p
+	timedOut.Detail = "Request timed out after " + timeout.String()
+	body, _ := json.Marshal(timedOut)
+
+	header := w.Header()
+	header.Set(echo.HeaderContentType, problem.MIMEApplicationProblemJSON)
+	header.Set(echo.HeaderContentLength, strconv.Itoa(len(body)))
+	w.WriteHeader(http.StatusGatewayTimeout)
+	w.Write(body)
+	http.NewResponseController(w).Flush()
+	return int64(len(body))
+}
+
+// settle records on res that it was answered 504 with a body of size bytes,
+// so middleware further out reports the response the client received
+func settle(res *echo.Response, size int64) {
+	res.Status, res.Size, res.Committed = http.StatusGatewayTimeout, size, true
+}
+
+// bufferedWriter holds a handler's response until it is known to be in time
+type bufferedWriter struct {
+	mutex  sync.Mutex
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+// Header returns the buffered response's header
+func (w *bufferedWriter) Header() http.Header {
+	return w.header
+}
+
+// WriteHeader records status, keeping the first one written
+func (w *bufferedWriter) WriteHeader(status int) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+// Write buffers b
+func (w *bufferedWriter) Write(b []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(b)
+}
+
+// Flush does nothing: the response is sent only once it is complete
+func (w *bufferedWriter) Flush() {}
+
+// flushTo sends the buffered response on w; a handler that wrote nothing
+// leaves err for the error handler to answer
+func (w *bufferedWriter) flushTo(dst http.ResponseWriter, err error) error {
+	if w.status == 0 {
+		return err
+	}
+
+	header := dst.Header()
+	for name := range header {
+		if _, ok := w.header[name]; !ok {
+			header.Del(name)
+		}
+	}
+	for name, values := range w.header {
+		header[name] = values
+	}
+	dst.WriteHeader(w.status)
+	if _, writeErr := dst.Write(w.body.Bytes()); writeErr != nil {
+		return fmt.Errorf("failed to write response: %w", writeErr)
+	}
+	return err
+}
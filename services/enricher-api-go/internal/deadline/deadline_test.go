@@ -0,0 +1,171 @@
+package deadline
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// newTestServer serves /slow, which fails once the request context is done,
+// /stream, exempt from the deadline, and /lookup, bounded to 10ms
+func newTestServer(timeout time.Duration) *echo.Echo {
+	slow := func(c echo.Context) error {
+		select {
+		case <-c.Request().Context().Done():
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": c.Request().Context().Err().Error()})
+		case <-time.After(200 * time.Millisecond):
+			return c.JSON(http.StatusOK, map[string]string{"status": "done"})
+		}
+	}
+
+	e := echo.New()
+	e.Use(Middleware(timeout, "/stream"))
+	e.GET("/slow", slow)
+	e.GET("/stream", slow)
+	e.GET("/lookup", slow, Within(10*time.Millisecond))
+	e.GET("/fails", func(c echo.Context) error {
+		return errors.New("storage unavailable")
+	})
+	return e
+}
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name         string
+		timeout      time.Duration
+		path         string
+		expectedCode int
+		expectedBody string
+	}{
+		{name: "deadline exceeded", timeout: 20 * time.Millisecond, path: "/slow", expectedCode: http.StatusGatewayTimeout, expectedBody: "after 20ms"},
+		{name: "within deadline", timeout: time.Second, path: "/slow", expectedCode: http.StatusOK, expectedBody: `"status":"done"`},
+		{name: "exempt path", timeout: 20 * time.Millisecond, path: "/stream", expectedCode: http.StatusOK, expectedBody: `"status":"done"`},
+		{name: "tighter route deadline", timeout: time.Second, path: "/lookup", expectedCode: http.StatusGatewayTimeout, expectedBody: "after 10ms"},
+		{name: "route deadline without middleware", timeout: 0, path: "/lookup", expectedCode: http.StatusGatewayTimeout, expectedBody: "after 10ms"},
+		{name: "failure before deadline", timeout: time.Second, path: "/fails", expectedCode: http.StatusInternalServerError, expectedBody: `"message"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			e := newTestServer(tt.timeout)
+			rec := httptest.NewRecorder()
+
+			// Act
+			e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			// Assert
+			if rec.Code != tt.expectedCode {
+				t.Errorf("Expected status %d, got %d", tt.expectedCode, rec.Code)
+			}
+			if !strings.Contains(rec.Body.String(), tt.expectedBody) {
+				t.Errorf("Expected body containing %s, got %s", tt.expectedBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestMiddleware_ReportsReplacedStatus(t *testing.T) {
+	// Arrange
+	var status int
+	e := echo.New()
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			err := next(c)
+			status = c.Response().Status
+			return err
+		}
+	})
+	e.Use(Middleware(time.Millisecond))
+	e.GET("/slow", func(c echo.Context) error {
+		<-c.Request().Context().Done()
+		return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": "unavailable"})
+	})
+	rec := httptest.NewRecorder()
+
+	// Act
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/slow", nil))
+
+	// Assert
+	if status != http.StatusGatewayTimeout {
+		t.Errorf("Expected outer middleware to see status 504, got %d", status)
+	}
+	if strings.Contains(rec.Body.String(), "unavailable") {
+		t.Errorf("Expected the handler's body to be discarded, got %s", rec.Body.String())
+	}
+}
+
+// slowRepository takes delay to answer and, like the storage repositories,
+// does not watch the request context
+type slowRepository struct {
+	delay time.Duration
+}
+
+func (r slowRepository) Get(id string) (map[string]string, error) {
+	time.Sleep(r.delay)
+	return map[string]string{"id": id}, nil
+}
+
+func TestMiddleware_AnswersAtDeadline(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		route   []echo.MiddlewareFunc
+	}{
+		{name: "request deadline", timeout: 20 * time.Millisecond},
+		{name: "route deadline", timeout: time.Second, route: []echo.MiddlewareFunc{Within(20 * time.Millisecond)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			repo := slowRepository{delay: 500 * time.Millisecond}
+			finished := make(chan struct{})
+			e := echo.New()
+			e.Use(Middleware(tt.timeout))
+			e.GET("/customers/:id", func(c echo.Context) error {
+				defer close(finished)
+				customer, err := repo.Get(c.Param("id"))
+				if err != nil {
+					return err
+				}
+				return c.JSON(http.StatusOK, customer)
+			}, tt.route...)
+			server := httptest.NewServer(e)
+			defer server.Close()
+
+			// Act
+			started := time.Now()
+			res, err := http.Get(server.URL + "/customers/customer-1")
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			body, err := io.ReadAll(res.Body)
+			res.Body.Close()
+			elapsed := time.Since(started)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("Expected the body, got %v", err)
+			}
+			if res.StatusCode != http.StatusGatewayTimeout || !strings.Contains(string(body), "after 20ms") {
+				t.Errorf("Expected 504 naming the deadline, got %d: %s", res.StatusCode, body)
+			}
+			if elapsed >= repo.delay {
+				t.Errorf("Expected the answer at the deadline, got it after %v", elapsed)
+			}
+			select {
+			case <-finished:
+				t.Error("Expected the 504 before the repository answered")
+			default:
+			}
+			<-finished
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package dlq
+
+import (
+	"errors"
+
+	"enricher-api-go/internal/breaker"
+)
+
+// BreakerRepository guards another Repository with a circuit breaker.
+//
+// Storage failures count against the breaker, while domain outcomes such as
+// ErrEntryNotFound are returned unchanged without tripping it.
+type BreakerRepository struct {
+	repo    Repository
+	breaker *breaker.Breaker
+}
+
+// NewBreakerRepository wraps repo with b, typically the breaker shared by
+// every repository using the same backend
+func NewBreakerRepository(repo Repository, b *breaker.Breaker) *BreakerRepository {
+	return &BreakerRepository{repo: repo, breaker: b}
+}
+
+// GetByID retrieves an entry by ID
+func (r *BreakerRepository) GetByID(entryID string) (entry *Entry, err error) {
+	err = r.call(func() error {
+		entry, err = r.repo.GetByID(entryID)
+		return err
+	})
+	return entry, err
+}
+
+// GetOpen returns the OPEN entry of a source for sourceID
+func (r *BreakerRepository) GetOpen(source, sourceID string) (entry *Entry, err error) {
+	err = r.call(func() error {
+		entry, err = r.repo.GetOpen(source, sourceID)
+		return err
+	})
+	return entry, err
+}
+
+// Find returns the entries matching filter
+func (r *BreakerRepository) Find(filter EntryFilter) (entries []*Entry, err error) {
+	err = r.call(func() error {
+		entries, err = r.repo.Find(filter)
+		return err
+	})
+	return entries, err
+}
+
+// Count returns the number of entries matching filter
+func (r *BreakerRepository) Count(filter EntryFilter) (count int, err error) {
+	err = r.call(func() error {
+		count, err = r.repo.Count(filter)
+		return err
+	})
+	return count, err
+}
+
+// Create adds a new entry
+func (r *BreakerRepository) Create(entry *Entry) error {
+	return r.call(func() error { return r.repo.Create(entry) })
+}
+
+// Update replaces an existing entry
+func (r *BreakerRepository) Update(entry *Entry) error {
+	return r.call(func() error { return r.repo.Update(entry) })
+}
+
+// call runs fn through the breaker, passing domain errors through without
+// counting them as failures
+func (r *BreakerRepository) call(fn func() error) error {
+	var domainErr error
+	err := r.breaker.Execute(func() error {
+		err := fn()
+		if isDomainError(err) {
+			domainErr = err
+			return nil
+		}
+		return err
+	})
+	if domainErr != nil {
+		return domainErr
+	}
+	return err
+}
+
+// isDomainError reports whether err is an expected repository outcome rather
+// than a storage failure
+func isDomainError(err error) bool {
+	return errors.Is(err, ErrEntryNotFound) || errors.Is(err, ErrEntryExists)
+}
@@ -0,0 +1,109 @@
+package dlq
+
+import (
+	"errors"
+	"net/http"
+
+	"enricher-api-go/internal/pagination"
+	"enricher-api-go/internal/problem"
+	"enricher-api-go/internal/servertiming"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Handler handles HTTP requests for the dead-letter queue
+type Handler struct {
+	service Service
+}
+
+// NewHandler creates a new dead-letter handler
+func NewHandler(service Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+// ListEntries handles GET /v1/dlq
+//
+// Entries are listed by most recent failure first and may be narrowed to
+// one source with source and to one status with status. Without status only
+// OPEN entries are listed; status=all lists resolved ones too.
+func (h *Handler) ListEntries(c echo.Context) error {
+	page, err := pagination.FromQuery(c.QueryParams())
+	if err != nil {
+		return problem.Write(c, http.StatusBadRequest, err.Error())
+	}
+
+	filter := EntryFilter{
+		Source: c.QueryParam("source"),
+		Status: c.QueryParam("status"),
+		Limit:  page.Limit,
+		Offset: page.Offset,
+	}
+	switch filter.Status {
+	case "":
+		filter.Status = StatusOpen
+	case "all":
+		filter.Status = ""
+	}
+
+	stop := servertiming.Start(c, "service")
+	entries, err := h.service.FindEntries(c.Request().Context(), filter)
+	var total int
+	if err == nil {
+		total, err = h.service.CountEntries(c.Request().Context(), filter)
+	}
+	stop()
+	if err != nil {
+		return entryError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"entries":    entries,
+		"count":      len(entries),
+		"pagination": pagination.NewMeta(c.Request().URL, page, total),
+	})
+}
+
+// GetEntry handles GET /v1/dlq/:id
+func (h *Handler) GetEntry(c echo.Context) error {
+	stop := servertiming.Start(c, "service")
+	entry, err := h.service.GetEntry(c.Request().Context(), c.Param("id"))
+	stop()
+	if err != nil {
+		return entryError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, entry)
+}
+
+// RetryEntry handles POST /v1/dlq/:id/retry
+//
+// The order is replayed through the source that dead-lettered it. The
+// response is the entry either way: RESOLVED if the order was enriched, or
+// still OPEN with the new failureReason and retryCount.
+func (h *Handler) RetryEntry(c echo.Context) error {
+	stop := servertiming.Start(c, "service")
+	entry, err := h.service.RetryEntry(c.Request().Context(), c.Param("id"))
+	stop()
+	if err != nil {
+		return entryError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, entry)
+}
+
+// entryError answers a failed dead-letter operation: 404 for an unknown
+// entry, 400 for an invalid filter and 409 for an entry that cannot be retried
+func entryError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, ErrEntryNotFound):
+		return problem.Write(c, http.StatusNotFound, "Dead letter not found")
+	case errors.Is(err, ErrInvalidFilter):
+		return problem.Write(c, http.StatusBadRequest, err.Error())
+	case errors.Is(err, ErrEntryResolved), errors.Is(err, ErrNoReplay):
+		return problem.Write(c, http.StatusConflict, err.Error())
+	default:
+		return problem.ServerError(c, err)
+	}
+}
@@ -0,0 +1,78 @@
+// Package dlq keeps the orders that could not be enriched in a dead-letter
+// queue for the Resilient Order Enricher API, so they can be inspected and
+// replayed by hand once the cause has been fixed.
+package dlq
+
+import (
+	"time"
+
+	"enricher-api-go/internal/enrichment"
+)
+
+// Entry statuses
+const (
+	StatusOpen     = "OPEN"
+	StatusResolved = "RESOLVED"
+)
+
+// Sources of dead letters
+const (
+	// SourceOrder marks stored orders that enrichment rejected; SourceID is the order ID
+	SourceOrder = "order"
+	// SourceKafka marks order events the Kafka consumer gave up on; SourceID
+	// is the event's order ID
+	SourceKafka = "kafka"
+)
+
+// Entry is an order that could not be enriched.
+//
+// A source has at most one OPEN entry per SourceID: later failures of the
+// same order increment RetryCount instead of adding entries.
+type Entry struct {
+	EntryID  string `json:"entryId" db:"entry_id"`
+	Source   string `json:"source" db:"source"`
+	SourceID string `json:"sourceId" db:"source_id"`
+	// Order is the enrichment request that failed, replayed on retry
+	Order  enrichment.OrderRequest `json:"order" db:"order_request"`
+	Status string                  `json:"status" db:"status"`
+	// FailureReason is the error of the most recent attempt
+	FailureReason string `json:"failureReason" db:"failure_reason"`
+	// RetryCount is how many times the order failed again after it was dead-lettered
+	RetryCount int `json:"retryCount" db:"retry_count"`
+	// CreatedAt is when the order was dead-lettered
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	// LastFailedAt is when the order most recently failed
+	LastFailedAt time.Time `json:"lastFailedAt" db:"last_failed_at"`
+	// ResolvedAt is when a retry enriched the order
+	ResolvedAt *time.Time `json:"resolvedAt,omitempty" db:"resolved_at"`
+}
+
+// Failure reports an order its source could not enrich
+type Failure struct {
+	Source   string
+	SourceID string
+	Order    enrichment.OrderRequest
+	Reason   string
+}
+
+// EntryFilter describes the criteria for listing entries.
+//
+// Zero values mean "no constraint". Limit and Offset page through the
+// matches, which are ordered by most recent failure first.
+type EntryFilter struct {
+	// Source matches the entries of one source
+	Source string
+	// Status matches entries in one status
+	Status string
+	// Limit caps the number of entries returned; 0 means no limit
+	Limit int
+	// Offset skips the first matches
+	Offset int
+}
+
+// Matches reports whether an entry satisfies the filter's criteria.
+// Limit and Offset are not considered.
+func (f EntryFilter) Matches(entry *Entry) bool {
+	return (f.Source == "" || entry.Source == f.Source) &&
+		(f.Status == "" || entry.Status == f.Status)
+}
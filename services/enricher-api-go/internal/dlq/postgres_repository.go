@@ -0,0 +1,203 @@
+package dlq
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"enricher-api-go/internal/postgres"
+)
+
+// entryColumns lists the columns read by scanEntry, in order
+const entryColumns = `entry_id, source, source_id, order_request, status, failure_reason, retry_count,
+	created_at, last_failed_at, resolved_at`
+
+// PostgresRepository implements Repository on top of a PostgreSQL database
+type PostgresRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresRepository creates a dead-letter repository backed by db.
+//
+// The caller owns db and is responsible for opening and closing it; the
+// dead_letters table must exist (see the migrations package).
+func NewPostgresRepository(db *sql.DB) *PostgresRepository {
+	return &PostgresRepository{db: db}
+}
+
+// GetByID retrieves an entry by ID
+func (r *PostgresRepository) GetByID(entryID string) (*Entry, error) {
+	entry, err := scanEntry(r.db.QueryRow(`SELECT `+entryColumns+` FROM dead_letters WHERE entry_id = $1`, entryID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrEntryNotFound
+	}
+	return entry, err
+}
+
+// GetOpen returns the OPEN entry of a source for sourceID
+func (r *PostgresRepository) GetOpen(source, sourceID string) (*Entry, error) {
+	entry, err := scanEntry(r.db.QueryRow(
+		`SELECT `+entryColumns+` FROM dead_letters WHERE source = $1 AND source_id = $2 AND status = $3`,
+		source, sourceID, StatusOpen,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrEntryNotFound
+	}
+	return entry, err
+}
+
+// Find returns the entries matching filter, most recent failure first
+func (r *PostgresRepository) Find(filter EntryFilter) ([]*Entry, error) {
+	where, args := filterClause(filter)
+	query := `SELECT ` + entryColumns + ` FROM dead_letters` + where + ` ORDER BY last_failed_at DESC, entry_id DESC`
+
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(` LIMIT $%d`, len(args))
+	}
+	if filter.Offset > 0 {
+		args = append(args, filter.Offset)
+		query += fmt.Sprintf(` OFFSET $%d`, len(args))
+	}
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query dead letters: %w", err)
+	}
+	defer rows.Close()
+
+	entries := make([]*Entry, 0)
+	for rows.Next() {
+		entry, err := scanEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read dead letters: %w", err)
+	}
+	return entries, nil
+}
+
+// Count returns the number of entries matching filter, ignoring Limit and Offset
+func (r *PostgresRepository) Count(filter EntryFilter) (int, error) {
+	where, args := filterClause(filter)
+
+	var count int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM dead_letters`+where, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count dead letters: %w", err)
+	}
+	return count, nil
+}
+
+// Create adds a new entry
+func (r *PostgresRepository) Create(entry *Entry) error {
+	order, err := json.Marshal(entry.Order)
+	if err != nil {
+		return fmt.Errorf("failed to encode dead letter order: %w", err)
+	}
+
+	_, err = r.db.Exec(
+		`INSERT INTO dead_letters (entry_id, source, source_id, order_request, status, failure_reason, retry_count,
+			created_at, last_failed_at, resolved_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		entry.EntryID, entry.Source, entry.SourceID, order, entry.Status, entry.FailureReason, entry.RetryCount,
+		entry.CreatedAt, entry.LastFailedAt, entry.ResolvedAt,
+	)
+	if postgres.IsUniqueViolation(err) {
+		return ErrEntryExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to insert dead letter: %w", err)
+	}
+	return nil
+}
+
+// Update replaces an existing entry
+func (r *PostgresRepository) Update(entry *Entry) error {
+	order, err := json.Marshal(entry.Order)
+	if err != nil {
+		return fmt.Errorf("failed to encode dead letter order: %w", err)
+	}
+
+	result, err := r.db.Exec(
+		`UPDATE dead_letters SET order_request = $2, status = $3, failure_reason = $4, retry_count = $5,
+			last_failed_at = $6, resolved_at = $7
+		WHERE entry_id = $1`,
+		entry.EntryID, order, entry.Status, entry.FailureReason, entry.RetryCount, entry.LastFailedAt, entry.ResolvedAt,
+	)
+	if postgres.IsUniqueViolation(err) {
+		return ErrEntryExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update dead letter: %w", err)
+	}
+	return requireRowAffected(result)
+}
+
+// filterClause builds the WHERE clause and its arguments for filter's criteria
+func filterClause(filter EntryFilter) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	if filter.Source != "" {
+		args = append(args, filter.Source)
+		conditions = append(conditions, fmt.Sprintf(`source = $%d`, len(args)))
+	}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conditions = append(conditions, fmt.Sprintf(`status = $%d`, len(args)))
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+	return ` WHERE ` + strings.Join(conditions, ` AND `), args
+}
+
+// rowScanner is satisfied by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanEntry reads one entry row in entryColumns order
+func scanEntry(row rowScanner) (*Entry, error) {
+	var entry Entry
+	var order []byte
+	var resolvedAt sql.NullTime
+
+	err := row.Scan(
+		&entry.EntryID, &entry.Source, &entry.SourceID, &order, &entry.Status, &entry.FailureReason,
+		&entry.RetryCount, &entry.CreatedAt, &entry.LastFailedAt, &resolvedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan dead letter: %w", err)
+	}
+
+	if err := json.Unmarshal(order, &entry.Order); err != nil {
+		return nil, fmt.Errorf("failed to decode dead letter order: %w", err)
+	}
+	if resolvedAt.Valid {
+		entry.ResolvedAt = &resolvedAt.Time
+	}
+	return &entry, nil
+}
+
+// requireRowAffected maps an UPDATE that touched no rows to ErrEntryNotFound
+func requireRowAffected(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to read affected rows: %w", err)
+	}
+	if affected == 0 {
+		return ErrEntryNotFound
+	}
+	return nil
+}
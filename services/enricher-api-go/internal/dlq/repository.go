@@ -0,0 +1,166 @@
+package dlq
+
+import (
+	"errors"
+	"slices"
+	"sort"
+	"sync"
+)
+
+var (
+	// ErrEntryNotFound is returned when no entry has the requested ID
+	ErrEntryNotFound = errors.New("dead letter not found")
+	// ErrEntryExists is returned when an entry's ID is taken, or its source
+	// already has an OPEN entry for the same SourceID
+	ErrEntryExists = errors.New("dead letter already exists")
+)
+
+// Repository defines the interface for dead-letter data access.
+//
+// Find and Count apply an EntryFilter; Find returns the matches by most
+// recent failure first, breaking ties by EntryID so that consecutive pages
+// are stable.
+type Repository interface {
+	GetByID(entryID string) (*Entry, error)
+	// GetOpen returns the OPEN entry of a source for sourceID
+	GetOpen(source, sourceID string) (*Entry, error)
+	Find(filter EntryFilter) ([]*Entry, error)
+	Count(filter EntryFilter) (int, error)
+	Create(entry *Entry) error
+	Update(entry *Entry) error
+}
+
+// InMemoryRepository implements Repository interface using in-memory storage
+type InMemoryRepository struct {
+	entries map[string]*Entry
+	mutex   sync.RWMutex
+}
+
+// NewInMemoryRepository creates a new, empty in-memory dead-letter repository
+func NewInMemoryRepository() *InMemoryRepository {
+	return &InMemoryRepository{
+		entries: make(map[string]*Entry),
+		mutex:   sync.RWMutex{},
+	}
+}
+
+// Reset removes every dead letter
+func (r *InMemoryRepository) Reset() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.entries = make(map[string]*Entry)
+}
+
+// GetByID retrieves an entry by ID
+func (r *InMemoryRepository) GetByID(entryID string) (*Entry, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	entry, exists := r.entries[entryID]
+	if !exists {
+		return nil, ErrEntryNotFound
+	}
+	return copyEntry(entry), nil
+}
+
+// GetOpen returns the OPEN entry of a source for sourceID
+func (r *InMemoryRepository) GetOpen(source, sourceID string) (*Entry, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	if entry := r.findOpen(source, sourceID); entry != nil {
+		return copyEntry(entry), nil
+	}
+	return nil, ErrEntryNotFound
+}
+
+// Find returns the entries matching filter, most recent failure first
+func (r *InMemoryRepository) Find(filter EntryFilter) ([]*Entry, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	entries := make([]*Entry, 0)
+	for _, entry := range r.entries {
+		if filter.Matches(entry) {
+			entries = append(entries, copyEntry(entry))
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].LastFailedAt.Equal(entries[j].LastFailedAt) {
+			return entries[i].LastFailedAt.After(entries[j].LastFailedAt)
+		}
+		return entries[i].EntryID > entries[j].EntryID
+	})
+
+	if filter.Offset >= len(entries) {
+		return []*Entry{}, nil
+	}
+	entries = entries[filter.Offset:]
+	if filter.Limit > 0 && filter.Limit < len(entries) {
+		entries = entries[:filter.Limit]
+	}
+	return entries, nil
+}
+
+// Count returns the number of entries matching filter, ignoring Limit and Offset
+func (r *InMemoryRepository) Count(filter EntryFilter) (int, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	count := 0
+	for _, entry := range r.entries {
+		if filter.Matches(entry) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// Create adds a new entry
+func (r *InMemoryRepository) Create(entry *Entry) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.entries[entry.EntryID]; exists {
+		return ErrEntryExists
+	}
+	if entry.Status == StatusOpen && r.findOpen(entry.Source, entry.SourceID) != nil {
+		return ErrEntryExists
+	}
+	r.entries[entry.EntryID] = copyEntry(entry)
+	return nil
+}
+
+// Update replaces an existing entry
+func (r *InMemoryRepository) Update(entry *Entry) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.entries[entry.EntryID]; !exists {
+		return ErrEntryNotFound
+	}
+	if open := r.findOpen(entry.Source, entry.SourceID); entry.Status == StatusOpen && open != nil && open.EntryID != entry.EntryID {
+		return ErrEntryExists
+	}
+	r.entries[entry.EntryID] = copyEntry(entry)
+	return nil
+}
+
+// findOpen returns the stored OPEN entry of a source for sourceID, if any.
+// The caller must hold the mutex.
+func (r *InMemoryRepository) findOpen(source, sourceID string) *Entry {
+	for _, entry := range r.entries {
+		if entry.Status == StatusOpen && entry.Source == source && entry.SourceID == sourceID {
+			return entry
+		}
+	}
+	return nil
+}
+
+// copyEntry copies an entry so callers cannot change the stored order
+func copyEntry(entry *Entry) *Entry {
+	entryCopy := *entry
+	entryCopy.Order.Items = slices.Clone(entry.Order.Items)
+	return &entryCopy
+}
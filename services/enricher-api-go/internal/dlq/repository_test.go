@@ -0,0 +1,190 @@
+package dlq
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"enricher-api-go/internal/enrichment"
+	"enricher-api-go/internal/migrations"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// testRepositoryConformance runs the behavior every Repository implementation
+// must share against a repository created by newRepo.
+func testRepositoryConformance(t *testing.T, newRepo func(t *testing.T) Repository) {
+	failed := time.Date(2024, 4, 1, 10, 0, 0, 0, time.UTC)
+	order := enrichment.OrderRequest{
+		CustomerID: "customer-missing",
+		Items:      []enrichment.OrderItem{{ProductID: "product-123", Quantity: 2}, {SKU: "MOUSE-BLACK", Quantity: 1}},
+	}
+
+	t.Run("Create and get", func(t *testing.T) {
+		repo := newRepo(t)
+		entry := &Entry{
+			EntryID: "conformance-1", Source: SourceOrder, SourceID: "order-1", Order: order, Status: StatusOpen,
+			FailureReason: "customer not found", CreatedAt: failed, LastFailedAt: failed,
+		}
+
+		if err := repo.Create(entry); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		entry.Order.Items[0].Quantity = 99
+
+		retrieved, err := repo.GetByID("conformance-1")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if retrieved.Source != SourceOrder || retrieved.SourceID != "order-1" || retrieved.Order.CustomerID != "customer-missing" ||
+			len(retrieved.Order.Items) != 2 || retrieved.Order.Items[0].Quantity != 2 || retrieved.Order.Items[1].SKU != "MOUSE-BLACK" {
+			t.Errorf("Expected the stored entry, got %+v", retrieved)
+		}
+		if retrieved.Status != StatusOpen || retrieved.FailureReason != "customer not found" || retrieved.ResolvedAt != nil ||
+			!retrieved.LastFailedAt.Equal(failed) {
+			t.Errorf("Expected an open entry with its failure, got %+v", retrieved)
+		}
+
+		open, err := repo.GetOpen(SourceOrder, "order-1")
+		if err != nil || open.EntryID != "conformance-1" {
+			t.Errorf("Expected the open entry of order-1, got %+v, %v", open, err)
+		}
+		if _, err := repo.GetOpen(SourceKafka, "order-1"); !errors.Is(err, ErrEntryNotFound) {
+			t.Errorf("Expected no open entry for another source, got %v", err)
+		}
+
+		duplicate := &Entry{EntryID: "conformance-2", Source: SourceOrder, SourceID: "order-1", Order: order, Status: StatusOpen,
+			CreatedAt: failed, LastFailedAt: failed}
+		if err := repo.Create(duplicate); !errors.Is(err, ErrEntryExists) {
+			t.Errorf("Expected ErrEntryExists for a second open entry, got %v", err)
+		}
+	})
+
+	t.Run("Resolve and reopen", func(t *testing.T) {
+		repo := newRepo(t)
+		entry := &Entry{EntryID: "conformance-3", Source: SourceKafka, SourceID: "order-3", Order: order, Status: StatusOpen,
+			CreatedAt: failed, LastFailedAt: failed}
+		if err := repo.Create(entry); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		resolvedAt := failed.Add(time.Hour)
+		entry.Status, entry.ResolvedAt, entry.RetryCount = StatusResolved, &resolvedAt, 2
+		if err := repo.Update(entry); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		retrieved, err := repo.GetByID("conformance-3")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if retrieved.Status != StatusResolved || retrieved.RetryCount != 2 || retrieved.ResolvedAt == nil || !retrieved.ResolvedAt.Equal(resolvedAt) {
+			t.Errorf("Expected the resolution to be stored, got %+v", retrieved)
+		}
+		if _, err := repo.GetOpen(SourceKafka, "order-3"); !errors.Is(err, ErrEntryNotFound) {
+			t.Errorf("Expected no open entry once resolved, got %v", err)
+		}
+
+		reopened := &Entry{EntryID: "conformance-4", Source: SourceKafka, SourceID: "order-3", Order: order, Status: StatusOpen,
+			CreatedAt: resolvedAt, LastFailedAt: resolvedAt}
+		if err := repo.Create(reopened); err != nil {
+			t.Errorf("Expected a new open entry after resolution, got %v", err)
+		}
+	})
+
+	t.Run("Missing", func(t *testing.T) {
+		repo := newRepo(t)
+
+		if _, err := repo.GetByID("conformance-missing"); !errors.Is(err, ErrEntryNotFound) {
+			t.Errorf("Expected ErrEntryNotFound, got %v", err)
+		}
+		if err := repo.Update(&Entry{EntryID: "conformance-missing", Status: StatusOpen}); !errors.Is(err, ErrEntryNotFound) {
+			t.Errorf("Expected ErrEntryNotFound on update, got %v", err)
+		}
+	})
+
+	t.Run("Find and Count", func(t *testing.T) {
+		repo := newRepo(t)
+		entries := []*Entry{
+			{EntryID: "conformance-a", Source: SourceOrder, SourceID: "order-a", Status: StatusOpen, LastFailedAt: failed},
+			{EntryID: "conformance-b", Source: SourceKafka, SourceID: "order-b", Status: StatusOpen, LastFailedAt: failed.Add(time.Hour)},
+			{EntryID: "conformance-c", Source: SourceOrder, SourceID: "order-c", Status: StatusResolved, LastFailedAt: failed.Add(2 * time.Hour)},
+			{EntryID: "conformance-d", Source: SourceOrder, SourceID: "order-d", Status: StatusOpen, LastFailedAt: failed.Add(2 * time.Hour)},
+		}
+		for _, entry := range entries {
+			entry.Order, entry.CreatedAt = order, failed
+			if err := repo.Create(entry); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+		}
+
+		all, err := repo.Find(EntryFilter{})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if got := entryIDs(all); len(got) != 4 || got[0] != "conformance-d" || got[1] != "conformance-c" || got[3] != "conformance-a" {
+			t.Errorf("Expected the most recent failure first with ties broken by ID, got %v", got)
+		}
+
+		page, err := repo.Find(EntryFilter{Source: SourceOrder, Status: StatusOpen, Limit: 1, Offset: 1})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if got := entryIDs(page); len(got) != 1 || got[0] != "conformance-a" {
+			t.Errorf("Expected the second open order entry, got %v", got)
+		}
+
+		count, err := repo.Count(EntryFilter{Status: StatusOpen, Limit: 1})
+		if err != nil || count != 3 {
+			t.Errorf("Expected 3 open entries regardless of limit, got %d, %v", count, err)
+		}
+	})
+}
+
+// entryIDs returns the IDs of entries, in order
+func entryIDs(entries []*Entry) []string {
+	ids := make([]string, len(entries))
+	for i, entry := range entries {
+		ids[i] = entry.EntryID
+	}
+	return ids
+}
+
+func TestInMemoryRepository_Conformance(t *testing.T) {
+	testRepositoryConformance(t, func(t *testing.T) Repository {
+		return NewInMemoryRepository()
+	})
+}
+
+// TestPostgresRepository_Conformance runs against the database in
+// POSTGRES_TEST_DSN and is skipped when it is not set.
+func TestPostgresRepository_Conformance(t *testing.T) {
+	dsn := os.Getenv("POSTGRES_TEST_DSN")
+	if dsn == "" {
+		t.Skip("POSTGRES_TEST_DSN not set")
+	}
+
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := migrations.New(db)
+	if err != nil {
+		t.Fatalf("Failed to load migrations: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+
+	testRepositoryConformance(t, func(t *testing.T) Repository {
+		repo := NewPostgresRepository(db)
+		if _, err := db.Exec(`TRUNCATE dead_letters`); err != nil {
+			t.Fatalf("Failed to reset dead letters: %v", err)
+		}
+		return repo
+	})
+}
@@ -0,0 +1,63 @@
+package dlq
+
+import "enricher-api-go/internal/retry"
+
+// RetryRepository retries the calls of another Repository that fail
+// transiently: reads on any transient failure, writes only on failures that
+// show nothing was written.
+type RetryRepository struct {
+	repo    Repository
+	retrier *retry.Retrier
+}
+
+// NewRetryRepository wraps repo with retrier, typically the retrier shared
+// by every repository using the same backend
+func NewRetryRepository(repo Repository, retrier *retry.Retrier) *RetryRepository {
+	return &RetryRepository{repo: repo, retrier: retrier}
+}
+
+// GetByID retrieves an entry by ID
+func (r *RetryRepository) GetByID(entryID string) (entry *Entry, err error) {
+	err = r.retrier.Read(func() error {
+		entry, err = r.repo.GetByID(entryID)
+		return err
+	})
+	return entry, err
+}
+
+// GetOpen returns the OPEN entry of a source for sourceID
+func (r *RetryRepository) GetOpen(source, sourceID string) (entry *Entry, err error) {
+	err = r.retrier.Read(func() error {
+		entry, err = r.repo.GetOpen(source, sourceID)
+		return err
+	})
+	return entry, err
+}
+
+// Find returns the entries matching filter
+func (r *RetryRepository) Find(filter EntryFilter) (entries []*Entry, err error) {
+	err = r.retrier.Read(func() error {
+		entries, err = r.repo.Find(filter)
+		return err
+	})
+	return entries, err
+}
+
+// Count returns the number of entries matching filter
+func (r *RetryRepository) Count(filter EntryFilter) (count int, err error) {
+	err = r.retrier.Read(func() error {
+		count, err = r.repo.Count(filter)
+		return err
+	})
+	return count, err
+}
+
+// Create adds a new entry
+func (r *RetryRepository) Create(entry *Entry) error {
+	return r.retrier.Write(func() error { return r.repo.Create(entry) })
+}
+
+// Update replaces an existing entry
+func (r *RetryRepository) Update(entry *Entry) error {
+	return r.retrier.Write(func() error { return r.repo.Update(entry) })
+}
@@ -0,0 +1,289 @@
+package dlq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"enricher-api-go/internal/clock"
+	"enricher-api-go/internal/idgen"
+	"enricher-api-go/internal/logging"
+)
+
+var (
+	// ErrInvalidFilter is returned when list filters or pagination values are out of range
+	ErrInvalidFilter = errors.New("invalid filter")
+	// ErrEntryResolved is returned when retrying an entry a retry already resolved
+	ErrEntryResolved = errors.New("dead letter already resolved")
+	// ErrNoReplay is returned when retrying an entry whose source cannot replay orders
+	ErrNoReplay = errors.New("dead letter source cannot replay orders")
+)
+
+// statuses lists the statuses entries can be filtered by
+var statuses = []string{StatusOpen, StatusResolved}
+
+// Recorder receives the failures of a source. Sources call Resolve when an
+// order they dead-lettered is later enriched by other means.
+type Recorder interface {
+	RecordFailure(ctx context.Context, failure Failure) (*Entry, error)
+	Resolve(ctx context.Context, source, sourceID string) error
+}
+
+// ReplayFunc enriches a dead-lettered order again through its source,
+// returning why it still fails, if it does
+type ReplayFunc func(ctx context.Context, entry *Entry) error
+
+// Service defines the business logic interface for the dead-letter queue
+type Service interface {
+	Recorder
+	GetEntry(ctx context.Context, entryID string) (*Entry, error)
+	FindEntries(ctx context.Context, filter EntryFilter) ([]*Entry, error)
+	CountEntries(ctx context.Context, filter EntryFilter) (int, error)
+	RetryEntry(ctx context.Context, entryID string) (*Entry, error)
+}
+
+// DeadLetterService implements the Service interface
+type DeadLetterService struct {
+	repo        Repository
+	idGenerator idgen.Generator
+	clock       clock.Clock
+
+	mu        sync.RWMutex
+	replayers map[string]ReplayFunc
+}
+
+// Option configures optional DeadLetterService behavior
+type Option func(*DeadLetterService)
+
+// WithIDGenerator sets the generator used for new entry IDs (UUIDs by default)
+func WithIDGenerator(gen idgen.Generator) Option {
+	return func(s *DeadLetterService) {
+		s.idGenerator = gen
+	}
+}
+
+// WithClock sets the clock used for failure and resolution times (the UTC wall clock by default)
+func WithClock(c clock.Clock) Option {
+	return func(s *DeadLetterService) {
+		s.clock = c
+	}
+}
+
+// NewService creates a new dead-letter service
+func NewService(repo Repository, opts ...Option) *DeadLetterService {
+	s := &DeadLetterService{
+		repo:        repo,
+		idGenerator: idgen.UUIDGenerator{},
+		clock:       clock.System{},
+		replayers:   make(map[string]ReplayFunc),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Handle registers how the entries of source are replayed on retry.
+// Sources are registered after construction because they usually record
+// their failures through the service themselves.
+func (s *DeadLetterService) Handle(source string, replay ReplayFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.replayers[source] = replay
+}
+
+// RecordFailure dead-letters an order, or counts another failure against
+// the OPEN entry its source already has for it
+func (s *DeadLetterService) RecordFailure(ctx context.Context, failure Failure) (*Entry, error) {
+	logger := logging.FromContext(ctx).With("source", failure.Source, "source_id", failure.SourceID)
+
+	if failure.Source == "" || failure.SourceID == "" {
+		return nil, fmt.Errorf("dead letter source and source ID cannot be empty")
+	}
+	// RetryEntry counts the failures of the entry it is replaying itself
+	if replaying(ctx, failure.Source, failure.SourceID) {
+		return s.repo.GetOpen(failure.Source, failure.SourceID)
+	}
+
+	now := s.clock.Now()
+	entry, err := s.repo.GetOpen(failure.Source, failure.SourceID)
+	switch {
+	case err == nil:
+		entry.Order, entry.FailureReason, entry.LastFailedAt = failure.Order, failure.Reason, now
+		entry.RetryCount++
+		err = s.repo.Update(entry)
+	case errors.Is(err, ErrEntryNotFound):
+		var entryID string
+		entryID, err = s.idGenerator.NewID("dlq")
+		if err != nil {
+			break
+		}
+		entry = &Entry{
+			EntryID:       entryID,
+			Source:        failure.Source,
+			SourceID:      failure.SourceID,
+			Order:         failure.Order,
+			Status:        StatusOpen,
+			FailureReason: failure.Reason,
+			CreatedAt:     now,
+			LastFailedAt:  now,
+		}
+		err = s.repo.Create(entry)
+		if errors.Is(err, ErrEntryExists) {
+			// Another failure of the same order was dead-lettered first
+			return s.RecordFailure(ctx, failure)
+		}
+	}
+	if err != nil {
+		logger.Error("Failed to record dead letter", "error", err)
+		return nil, fmt.Errorf("failed to record dead letter: %w", err)
+	}
+
+	logger.Warn("Order dead-lettered", "entry_id", entry.EntryID, "retry_count", entry.RetryCount, "reason", failure.Reason)
+	return entry, nil
+}
+
+// Resolve marks the OPEN entry of a source for sourceID resolved, if it has one
+func (s *DeadLetterService) Resolve(ctx context.Context, source, sourceID string) error {
+	if replaying(ctx, source, sourceID) {
+		return nil
+	}
+
+	entry, err := s.repo.GetOpen(source, sourceID)
+	if errors.Is(err, ErrEntryNotFound) {
+		return nil
+	}
+	if err == nil {
+		err = s.resolve(entry)
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to resolve dead letter", "source", source, "source_id", sourceID, "error", err)
+		return fmt.Errorf("failed to resolve dead letter: %w", err)
+	}
+
+	logging.FromContext(ctx).Info("Dead letter resolved", "entry_id", entry.EntryID)
+	return nil
+}
+
+// GetEntry retrieves an entry by ID
+func (s *DeadLetterService) GetEntry(ctx context.Context, entryID string) (*Entry, error) {
+	logger := logging.FromContext(ctx)
+	logger.Debug("Getting dead letter", "entry_id", entryID)
+
+	entry, err := s.repo.GetByID(entryID)
+	if err != nil {
+		if !errors.Is(err, ErrEntryNotFound) {
+			logger.Error("Failed to get dead letter", "entry_id", entryID, "error", err)
+		}
+		return nil, err
+	}
+	return entry, nil
+}
+
+// FindEntries returns the entries matching filter, most recent failure first
+func (s *DeadLetterService) FindEntries(ctx context.Context, filter EntryFilter) ([]*Entry, error) {
+	if err := validateFilter(filter); err != nil {
+		return nil, err
+	}
+
+	entries, err := s.repo.Find(filter)
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to find dead letters", "error", err)
+		return nil, fmt.Errorf("failed to find dead letters: %w", err)
+	}
+	return entries, nil
+}
+
+// CountEntries returns the number of entries matching filter
+func (s *DeadLetterService) CountEntries(ctx context.Context, filter EntryFilter) (int, error) {
+	if err := validateFilter(filter); err != nil {
+		return 0, err
+	}
+
+	count, err := s.repo.Count(filter)
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to count dead letters", "error", err)
+		return 0, fmt.Errorf("failed to count dead letters: %w", err)
+	}
+	return count, nil
+}
+
+// RetryEntry replays an OPEN entry through its source.
+//
+// An order that is enriched resolves the entry. One that fails again keeps
+// it OPEN with the new failure reason and an incremented RetryCount; that is
+// not an error, as the returned entry reports it.
+func (s *DeadLetterService) RetryEntry(ctx context.Context, entryID string) (*Entry, error) {
+	logger := logging.FromContext(ctx).With("entry_id", entryID)
+	logger.Info("Retrying dead letter")
+
+	entry, err := s.GetEntry(ctx, entryID)
+	if err != nil {
+		return nil, err
+	}
+	if entry.Status == StatusResolved {
+		return nil, ErrEntryResolved
+	}
+
+	s.mu.RLock()
+	replay, ok := s.replayers[entry.Source]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNoReplay, entry.Source)
+	}
+
+	if replayErr := replay(withReplay(ctx, entry), entry); replayErr != nil {
+		logger.Warn("Dead letter failed again", "error", replayErr)
+		entry.FailureReason, entry.LastFailedAt = replayErr.Error(), s.clock.Now()
+		entry.RetryCount++
+		err = s.repo.Update(entry)
+	} else {
+		err = s.resolve(entry)
+	}
+	if err != nil {
+		logger.Error("Failed to save dead letter retry", "error", err)
+		return nil, fmt.Errorf("failed to retry dead letter: %w", err)
+	}
+
+	return entry, nil
+}
+
+// resolve marks entry RESOLVED and saves it
+func (s *DeadLetterService) resolve(entry *Entry) error {
+	resolvedAt := s.clock.Now()
+	entry.Status, entry.ResolvedAt = StatusResolved, &resolvedAt
+	return s.repo.Update(entry)
+}
+
+// replayKey is the context key marking the entry RetryEntry is replaying
+type replayKey struct{}
+
+// withReplay marks ctx as replaying entry, so the failure or success its
+// source reports while replaying is not counted twice
+func withReplay(ctx context.Context, entry *Entry) context.Context {
+	return context.WithValue(ctx, replayKey{}, entry.Source+"/"+entry.SourceID)
+}
+
+// replaying reports whether ctx is replaying the entry of source for sourceID
+func replaying(ctx context.Context, source, sourceID string) bool {
+	key, _ := ctx.Value(replayKey{}).(string)
+	return key == source+"/"+sourceID
+}
+
+// validateFilter checks list filters and pagination values
+func validateFilter(filter EntryFilter) error {
+	if filter.Status != "" && !slices.Contains(statuses, filter.Status) {
+		return fmt.Errorf("%w: status must be one of %s", ErrInvalidFilter, strings.Join(statuses, ", "))
+	}
+	if filter.Limit < 0 {
+		return fmt.Errorf("%w: limit must not be negative", ErrInvalidFilter)
+	}
+	if filter.Offset < 0 {
+		return fmt.Errorf("%w: offset must not be negative", ErrInvalidFilter)
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package dlq
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"enricher-api-go/internal/clock"
+	"enricher-api-go/internal/enrichment"
+)
+
+// failure returns a failure of the order source for orderID
+func failure(orderID, reason string) Failure {
+	return Failure{
+		Source:   SourceOrder,
+		SourceID: orderID,
+		Order:    enrichment.OrderRequest{CustomerID: "customer-missing", Items: []enrichment.OrderItem{{ProductID: "product-123", Quantity: 1}}},
+		Reason:   reason,
+	}
+}
+
+func TestDeadLetterService_RecordFailure(t *testing.T) {
+	// Arrange
+	now := time.Date(2024, 4, 1, 10, 0, 0, 0, time.UTC)
+	service := NewService(NewInMemoryRepository(), WithClock(clock.Fixed(now)))
+	ctx := context.Background()
+
+	// Act
+	first, err := service.RecordFailure(ctx, failure("order-1", "customer not found"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	again, err := service.RecordFailure(ctx, failure("order-1", "product not found"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	other, err := service.RecordFailure(ctx, failure("order-2", "customer not found"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Assert
+	if first.Status != StatusOpen || first.RetryCount != 0 || !first.CreatedAt.Equal(now) {
+		t.Errorf("Expected a new open entry, got %+v", first)
+	}
+	if again.EntryID != first.EntryID || again.RetryCount != 1 || again.FailureReason != "product not found" {
+		t.Errorf("Expected the repeat failure counted against the same entry, got %+v", again)
+	}
+	if other.EntryID == first.EntryID {
+		t.Errorf("Expected another order to get its own entry, got %+v", other)
+	}
+	if count, _ := service.CountEntries(ctx, EntryFilter{Status: StatusOpen}); count != 2 {
+		t.Errorf("Expected 2 open entries, got %d", count)
+	}
+}
+
+func TestDeadLetterService_RetryEntry(t *testing.T) {
+	tests := []struct {
+		name           string
+		replay         ReplayFunc
+		wantStatus     string
+		wantRetryCount int
+		wantReason     string
+	}{
+		{
+			name:       "enriched",
+			replay:     func(context.Context, *Entry) error { return nil },
+			wantStatus: StatusResolved,
+			wantReason: "customer not found",
+		},
+		{
+			name:           "fails again",
+			replay:         func(context.Context, *Entry) error { return errors.New("product not found") },
+			wantStatus:     StatusOpen,
+			wantRetryCount: 1,
+			wantReason:     "product not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			service := NewService(NewInMemoryRepository())
+			ctx := context.Background()
+			entry, err := service.RecordFailure(ctx, failure("order-1", "customer not found"))
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			// Like real sources, the replay reports its outcome through the
+			// service, which the retry must not count twice
+			service.Handle(SourceOrder, func(ctx context.Context, entry *Entry) error {
+				err := tt.replay(ctx, entry)
+				if err != nil {
+					service.RecordFailure(ctx, failure(entry.SourceID, err.Error()))
+				} else {
+					service.Resolve(ctx, entry.Source, entry.SourceID)
+				}
+				return err
+			})
+
+			// Act
+			retried, err := service.RetryEntry(ctx, entry.EntryID)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if retried.Status != tt.wantStatus || retried.RetryCount != tt.wantRetryCount || retried.FailureReason != tt.wantReason {
+				t.Errorf("Expected %s with %d retries and reason %q, got %+v", tt.wantStatus, tt.wantRetryCount, tt.wantReason, retried)
+			}
+			if stored, _ := service.GetEntry(ctx, entry.EntryID); stored.Status != tt.wantStatus || stored.RetryCount != tt.wantRetryCount {
+				t.Errorf("Expected the retry outcome to be stored, got %+v", stored)
+			}
+		})
+	}
+}
+
+func TestDeadLetterService_RetryEntry_Refused(t *testing.T) {
+	// Arrange
+	service := NewService(NewInMemoryRepository())
+	ctx := context.Background()
+	kafkaEntry, _ := service.RecordFailure(ctx, Failure{Source: SourceKafka, SourceID: "order-9", Reason: "customer not found"})
+	resolved, _ := service.RecordFailure(ctx, failure("order-1", "customer not found"))
+	service.Handle(SourceOrder, func(context.Context, *Entry) error { return nil })
+	if _, err := service.RetryEntry(ctx, resolved.EntryID); err != nil {
+		t.Fatalf("Expected the first retry to succeed, got %v", err)
+	}
+
+	// Act
+	_, noReplayErr := service.RetryEntry(ctx, kafkaEntry.EntryID)
+	_, resolvedErr := service.RetryEntry(ctx, resolved.EntryID)
+	_, missingErr := service.RetryEntry(ctx, "dlq-missing")
+
+	// Assert
+	if !errors.Is(noReplayErr, ErrNoReplay) {
+		t.Errorf("Expected ErrNoReplay for a source without a replay, got %v", noReplayErr)
+	}
+	if !errors.Is(resolvedErr, ErrEntryResolved) {
+		t.Errorf("Expected ErrEntryResolved, got %v", resolvedErr)
+	}
+	if !errors.Is(missingErr, ErrEntryNotFound) {
+		t.Errorf("Expected ErrEntryNotFound, got %v", missingErr)
+	}
+}
+
+func TestDeadLetterService_Resolve(t *testing.T) {
+	// Arrange
+	service := NewService(NewInMemoryRepository())
+	ctx := context.Background()
+	entry, _ := service.RecordFailure(ctx, failure("order-1", "customer not found"))
+
+	// Act
+	err := service.Resolve(ctx, SourceOrder, "order-1")
+	unknownErr := service.Resolve(ctx, SourceOrder, "order-unknown")
+
+	// Assert
+	if err != nil || unknownErr != nil {
+		t.Fatalf("Expected no errors, got %v and %v", err, unknownErr)
+	}
+	if resolved, _ := service.GetEntry(ctx, entry.EntryID); resolved.Status != StatusResolved || resolved.ResolvedAt == nil {
+		t.Errorf("Expected the entry resolved, got %+v", resolved)
+	}
+	if next, _ := service.RecordFailure(ctx, failure("order-1", "customer not found")); next.EntryID == entry.EntryID {
+		t.Errorf("Expected a new entry after resolution, got %+v", next)
+	}
+}
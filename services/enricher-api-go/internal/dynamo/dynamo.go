@@ -0,0 +1,497 @@
+// Package dynamo keeps the dynamodb storage backend's entities in a single
+// DynamoDB table, so the service can run serverless without a database
+// server.
+//
+// Every item is addressed by a string partition key (pk) and sort key (sk)
+// and holds its entity's JSON encoding. Entity items carry a version that
+// every write increments and conditions on, so a write based on a stale read
+// fails with ErrConflict and is retried against the current item.
+package dynamo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/ratelimit"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Attribute names shared by every item
+const (
+	attrPK      = "pk"
+	attrSK      = "sk"
+	attrEntity  = "entity"
+	attrData    = "data"
+	attrVersion = "version"
+	attrDeleted = "deleted"
+)
+
+// MaxTransactWrites is the most writes DynamoDB accepts in one transaction
+const MaxTransactWrites = 100
+
+// ErrConflict is returned when an item changed between reading and writing it
+var ErrConflict = errors.New("item changed concurrently")
+
+// API is the subset of the DynamoDB client used by Table; *dynamodb.Client
+// satisfies it
+type API interface {
+	GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	BatchGetItem(ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error)
+	UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error)
+	Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error)
+	Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error)
+	TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error)
+	DescribeTable(ctx context.Context, params *dynamodb.DescribeTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error)
+	CreateTable(ctx context.Context, params *dynamodb.CreateTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.CreateTableOutput, error)
+}
+
+// Config configures the DynamoDB client
+type Config struct {
+	// Region is the AWS region; empty uses the SDK's default chain (AWS_REGION)
+	Region string
+	// Endpoint overrides the service endpoint, such as DynamoDB Local
+	Endpoint string
+	// MaxAttempts is the most attempts per request, retrying throttled ones
+	MaxAttempts int
+	// MaxBackoff caps the exponential backoff between attempts
+	MaxBackoff time.Duration
+}
+
+// Open creates a DynamoDB client with credentials from the SDK's default
+// chain. Throttled requests are retried with exponential backoff and jitter,
+// and never fail fast on the client-side retry quota.
+func Open(ctx context.Context, cfg Config) (*dynamodb.Client, error) {
+	options := []func(*awsconfig.LoadOptions) error{
+		awsconfig.WithRetryer(func() aws.Retryer {
+			return retry.NewStandard(func(o *retry.StandardOptions) {
+				o.MaxAttempts = cfg.MaxAttempts
+				o.MaxBackoff = cfg.MaxBackoff
+				o.Backoff = retry.NewExponentialJitterBackoff(cfg.MaxBackoff)
+				o.RateLimiter = ratelimit.None
+			})
+		}),
+	}
+	if cfg.Region != "" {
+		options = append(options, awsconfig.WithRegion(cfg.Region))
+	}
+
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+	return dynamodb.NewFromConfig(awsCfg, func(o *dynamodb.Options) {
+		if cfg.Endpoint != "" {
+			o.BaseEndpoint = aws.String(cfg.Endpoint)
+		}
+	}), nil
+}
+
+// Table is a single DynamoDB table holding items of several entities
+type Table struct {
+	client API
+	name   string
+}
+
+// NewTable returns the table called name
+func NewTable(client API, name string) *Table {
+	return &Table{client: client, name: name}
+}
+
+// Ensure creates the table with on-demand capacity if it does not exist and
+// waits until it is active
+func (t *Table) Ensure(ctx context.Context) error {
+	err := t.Ping(ctx)
+	var notFound *types.ResourceNotFoundException
+	if !errors.As(err, &notFound) {
+		return err
+	}
+
+	_, err = t.client.CreateTable(ctx, &dynamodb.CreateTableInput{
+		TableName: aws.String(t.name),
+		AttributeDefinitions: []types.AttributeDefinition{
+			{AttributeName: aws.String(attrPK), AttributeType: types.ScalarAttributeTypeS},
+			{AttributeName: aws.String(attrSK), AttributeType: types.ScalarAttributeTypeS},
+		},
+		KeySchema: []types.KeySchemaElement{
+			{AttributeName: aws.String(attrPK), KeyType: types.KeyTypeHash},
+			{AttributeName: aws.String(attrSK), KeyType: types.KeyTypeRange},
+		},
+		BillingMode: types.BillingModePayPerRequest,
+	})
+	var inUse *types.ResourceInUseException
+	if err != nil && !errors.As(err, &inUse) {
+		return fmt.Errorf("failed to create table %s: %w", t.name, err)
+	}
+
+	waiter := dynamodb.NewTableExistsWaiter(t.client)
+	if err := waiter.Wait(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(t.name)}, 2*time.Minute); err != nil {
+		return fmt.Errorf("failed waiting for table %s: %w", t.name, err)
+	}
+	return nil
+}
+
+// Ping reports whether the table can be described
+func (t *Table) Ping(ctx context.Context) error {
+	_, err := t.client.DescribeTable(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(t.name)})
+	if err != nil {
+		return fmt.Errorf("failed to describe table %s: %w", t.name, err)
+	}
+	return nil
+}
+
+// Get reads the item at pk and sk with a consistent read; it returns nil
+// when there is none
+func (t *Table) Get(ctx context.Context, pk, sk string) (Item, error) {
+	out, err := t.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(t.name),
+		Key:            key(pk, sk),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get item %s/%s: %w", pk, sk, err)
+	}
+	if len(out.Item) == 0 {
+		return nil, nil
+	}
+	return out.Item, nil
+}
+
+// Key addresses an item by partition and sort key
+type Key struct {
+	PK string
+	SK string
+}
+
+// BatchGet reads the items at keys with consistent reads, skipping missing
+// ones, in no particular order
+func (t *Table) BatchGet(ctx context.Context, keys []Key) ([]Item, error) {
+	// BatchGetItem takes at most 100 keys per request
+	const batchSize = 100
+
+	items := make([]Item, 0, len(keys))
+	for start := 0; start < len(keys); start += batchSize {
+		batch := keys[start:min(start+batchSize, len(keys))]
+		request := make([]map[string]types.AttributeValue, 0, len(batch))
+		for _, k := range batch {
+			request = append(request, key(k.PK, k.SK))
+		}
+
+		pending := map[string]types.KeysAndAttributes{
+			t.name: {Keys: request, ConsistentRead: aws.Bool(true)},
+		}
+		for len(pending) > 0 {
+			out, err := t.client.BatchGetItem(ctx, &dynamodb.BatchGetItemInput{RequestItems: pending})
+			if err != nil {
+				return nil, fmt.Errorf("failed to batch get items: %w", err)
+			}
+			for _, item := range out.Responses[t.name] {
+				items = append(items, item)
+			}
+			pending = out.UnprocessedKeys
+		}
+	}
+	return items, nil
+}
+
+// Query reads every item in partition pk whose sort key starts with prefix,
+// in sort key order, with consistent reads
+func (t *Table) Query(ctx context.Context, pk, prefix string) ([]Item, error) {
+	var items []Item
+	var start map[string]types.AttributeValue
+	for {
+		out, err := t.client.Query(ctx, &dynamodb.QueryInput{
+			TableName:              aws.String(t.name),
+			KeyConditionExpression: aws.String("pk = :pk AND begins_with(sk, :prefix)"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":pk":     stringValue(pk),
+				":prefix": stringValue(prefix),
+			},
+			ConsistentRead:    aws.Bool(true),
+			ExclusiveStartKey: start,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to query %s: %w", pk, err)
+		}
+		for _, item := range out.Items {
+			items = append(items, item)
+		}
+		if len(out.LastEvaluatedKey) == 0 {
+			return items, nil
+		}
+		start = out.LastEvaluatedKey
+	}
+}
+
+// Scan reads every item of entity in the table with consistent reads
+func (t *Table) Scan(ctx context.Context, entity string) ([]Item, error) {
+	var items []Item
+	var start map[string]types.AttributeValue
+	for {
+		out, err := t.client.Scan(ctx, &dynamodb.ScanInput{
+			TableName:                 aws.String(t.name),
+			FilterExpression:          aws.String("#entity = :entity"),
+			ExpressionAttributeNames:  map[string]string{"#entity": attrEntity},
+			ExpressionAttributeValues: map[string]types.AttributeValue{":entity": stringValue(entity)},
+			ConsistentRead:            aws.Bool(true),
+			ExclusiveStartKey:         start,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan %s items: %w", entity, err)
+		}
+		for _, item := range out.Items {
+			items = append(items, item)
+		}
+		if len(out.LastEvaluatedKey) == 0 {
+			return items, nil
+		}
+		start = out.LastEvaluatedKey
+	}
+}
+
+// NextID increments the counter called name and returns its new value,
+// starting from 1
+func (t *Table) NextID(ctx context.Context, name string) (int64, error) {
+	out, err := t.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(t.name),
+		Key:                       key("COUNTER#"+name, "COUNTER"),
+		UpdateExpression:          aws.String("ADD #value :one"),
+		ExpressionAttributeNames:  map[string]string{"#value": "value"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{":one": numberValue(1)},
+		ReturnValues:              types.ReturnValueUpdatedNew,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to increment counter %s: %w", name, err)
+	}
+	return Item(out.Attributes).Int("value"), nil
+}
+
+// Transact applies writes atomically.
+//
+// It fails with a *CanceledError when a write's condition does not hold,
+// and with ErrConflict when the transaction raced another one.
+func (t *Table) Transact(ctx context.Context, writes []types.TransactWriteItem) error {
+	if len(writes) > MaxTransactWrites {
+		return fmt.Errorf("transaction of %d writes exceeds the limit of %d", len(writes), MaxTransactWrites)
+	}
+
+	_, err := t.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{TransactItems: writes})
+	var canceled *types.TransactionCanceledException
+	if errors.As(err, &canceled) {
+		reasons := make([]string, len(canceled.CancellationReasons))
+		failed := false
+		for i, reason := range canceled.CancellationReasons {
+			reasons[i] = aws.ToString(reason.Code)
+			failed = failed || reasons[i] == "ConditionalCheckFailed"
+		}
+		if !failed {
+			return fmt.Errorf("%w: %v", ErrConflict, reasons)
+		}
+		return &CanceledError{Reasons: reasons}
+	}
+	var conflict *types.TransactionConflictException
+	if errors.As(err, &conflict) {
+		return fmt.Errorf("%w: %v", ErrConflict, err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write transaction: %w", err)
+	}
+	return nil
+}
+
+// CanceledError reports the writes of a transaction whose conditions failed
+type CanceledError struct {
+	// Reasons holds the cancellation code of each write, in order
+	Reasons []string
+}
+
+// Error lists the cancellation reasons
+func (e *CanceledError) Error() string {
+	return fmt.Sprintf("transaction canceled: %v", e.Reasons)
+}
+
+// Failed reports whether the condition of write i failed
+func (e *CanceledError) Failed(i int) bool {
+	return i < len(e.Reasons) && e.Reasons[i] == "ConditionalCheckFailed"
+}
+
+// Put writes item as the version after expected, on the condition that the
+// stored item is still at expected; expected 0 means it must not exist yet
+func (t *Table) Put(item Item, expected int64) types.TransactWriteItem {
+	item[attrVersion] = numberValue(expected + 1)
+	put := &types.Put{
+		TableName:                aws.String(t.name),
+		Item:                     item,
+		ConditionExpression:      aws.String("#version = :expected"),
+		ExpressionAttributeNames: map[string]string{"#version": attrVersion},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":expected": numberValue(expected),
+		},
+	}
+	if expected == 0 {
+		put.ConditionExpression = aws.String("attribute_not_exists(pk)")
+		put.ExpressionAttributeNames = nil
+		put.ExpressionAttributeValues = nil
+	}
+	return types.TransactWriteItem{Put: put}
+}
+
+// PutNew writes item on the condition that no item exists at its key
+func (t *Table) PutNew(item Item) types.TransactWriteItem {
+	return types.TransactWriteItem{Put: &types.Put{
+		TableName:           aws.String(t.name),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(pk)"),
+	}}
+}
+
+// Upsert writes item unconditionally, for items owned by another item the
+// same transaction conditions on
+func (t *Table) Upsert(item Item) types.TransactWriteItem {
+	return types.TransactWriteItem{Put: &types.Put{
+		TableName: aws.String(t.name),
+		Item:      item,
+	}}
+}
+
+// Replace writes item on the condition that an item exists at its key
+func (t *Table) Replace(item Item) types.TransactWriteItem {
+	return types.TransactWriteItem{Put: &types.Put{
+		TableName:           aws.String(t.name),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_exists(pk)"),
+	}}
+}
+
+// Delete removes the item at pk and sk on the condition that it exists
+func (t *Table) Delete(pk, sk string) types.TransactWriteItem {
+	return types.TransactWriteItem{Delete: &types.Delete{
+		TableName:           aws.String(t.name),
+		Key:                 key(pk, sk),
+		ConditionExpression: aws.String("attribute_exists(pk)"),
+	}}
+}
+
+// Lock increments the version of the item at pk and sk on the condition
+// that it is still at expected, serializing writes to items it owns
+func (t *Table) Lock(pk, sk string, expected int64) types.TransactWriteItem {
+	return types.TransactWriteItem{Update: &types.Update{
+		TableName:                aws.String(t.name),
+		Key:                      key(pk, sk),
+		UpdateExpression:         aws.String("SET #version = :next"),
+		ConditionExpression:      aws.String("#version = :expected"),
+		ExpressionAttributeNames: map[string]string{"#version": attrVersion},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":expected": numberValue(expected),
+			":next":     numberValue(expected + 1),
+		},
+	}}
+}
+
+// CheckLive requires the item at pk and sk to exist and not be soft-deleted
+func (t *Table) CheckLive(pk, sk string) types.TransactWriteItem {
+	return types.TransactWriteItem{ConditionCheck: &types.ConditionCheck{
+		TableName:                aws.String(t.name),
+		Key:                      key(pk, sk),
+		ConditionExpression:      aws.String("attribute_exists(pk) AND attribute_not_exists(#deleted)"),
+		ExpressionAttributeNames: map[string]string{"#deleted": attrDeleted},
+	}}
+}
+
+// Item is one stored item
+type Item map[string]types.AttributeValue
+
+// NewItem builds the item of entity at pk and sk holding data's JSON
+// encoding
+func NewItem(pk, sk, entity string, data any) (Item, error) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", entity, err)
+	}
+
+	item := key(pk, sk)
+	item[attrEntity] = stringValue(entity)
+	item[attrData] = stringValue(string(encoded))
+	return item, nil
+}
+
+// MarkDeleted flags the item's entity as soft-deleted, failing CheckLive
+func (i Item) MarkDeleted() {
+	i[attrDeleted] = &types.AttributeValueMemberBOOL{Value: true}
+}
+
+// Decode unmarshals the item's JSON data into dst
+func (i Item) Decode(dst any) error {
+	if err := json.Unmarshal([]byte(i.String(attrData)), dst); err != nil {
+		return fmt.Errorf("failed to decode %s item: %w", i.String(attrEntity), err)
+	}
+	return nil
+}
+
+// Version returns the item's version, 0 if it has none
+func (i Item) Version() int64 {
+	return i.Int(attrVersion)
+}
+
+// String returns the string attribute name, or "" if it is missing
+func (i Item) String(name string) string {
+	if value, ok := i[name].(*types.AttributeValueMemberS); ok {
+		return value.Value
+	}
+	return ""
+}
+
+// SetString sets the string attribute name
+func (i Item) SetString(name, value string) {
+	i[name] = stringValue(value)
+}
+
+// Int returns the number attribute name, or 0 if it is missing
+func (i Item) Int(name string) int64 {
+	if value, ok := i[name].(*types.AttributeValueMemberN); ok {
+		n, _ := strconv.ParseInt(value.Value, 10, 64)
+		return n
+	}
+	return 0
+}
+
+// SetInt sets the number attribute name
+func (i Item) SetInt(name string, value int64) {
+	i[name] = numberValue(value)
+}
+
+// Retry calls attempt until it returns anything but ErrConflict, at most
+// attempts times, backing off exponentially with jitter from 10ms between
+// calls
+func Retry(attempts int, attempt func() error) error {
+	backoff := 10 * time.Millisecond
+	for i := 1; ; i++ {
+		err := attempt()
+		if !errors.Is(err, ErrConflict) || i >= attempts {
+			return err
+		}
+		time.Sleep(backoff/2 + rand.N(backoff/2+1))
+		backoff *= 2
+	}
+}
+
+// key builds the key attributes of an item
+func key(pk, sk string) Item {
+	return Item{attrPK: stringValue(pk), attrSK: stringValue(sk)}
+}
+
+func stringValue(value string) types.AttributeValue {
+	return &types.AttributeValueMemberS{Value: value}
+}
+
+func numberValue(value int64) types.AttributeValue {
+	return &types.AttributeValueMemberN{Value: strconv.FormatInt(value, 10)}
+}
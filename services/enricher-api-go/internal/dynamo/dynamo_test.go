@@ -0,0 +1,140 @@
+package dynamo
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// transactAPI answers TransactWriteItems with err
+type transactAPI struct {
+	API
+	err error
+}
+
+func (a transactAPI) TransactWriteItems(context.Context, *dynamodb.TransactWriteItemsInput, ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	return &dynamodb.TransactWriteItemsOutput{}, a.err
+}
+
+func TestItem_RoundTripsData(t *testing.T) {
+	// Arrange
+	type record struct {
+		Name string `json:"name"`
+	}
+	item, err := NewItem("RECORD#1", "RECORD", "record", record{Name: "Dana"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	item.SetInt("count", 3)
+
+	// Act
+	var decoded record
+	err = item.Decode(&decoded)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if decoded.Name != "Dana" || item.Int("count") != 3 || item.String(attrEntity) != "record" {
+		t.Errorf("Expected the record back with its attributes, got %+v from %v", decoded, item)
+	}
+	if item.Version() != 0 {
+		t.Errorf("Expected a new item to have no version, got %d", item.Version())
+	}
+}
+
+func TestTable_PutConditionsOnVersion(t *testing.T) {
+	table := NewTable(nil, "enricher")
+
+	tests := []struct {
+		name      string
+		expected  int64
+		condition string
+	}{
+		{name: "new item", expected: 0, condition: "attribute_not_exists(pk)"},
+		{name: "stored item", expected: 4, condition: "#version = :expected"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			write := table.Put(key("PK", "SK"), tt.expected)
+
+			// Assert
+			if got := aws.ToString(write.Put.ConditionExpression); got != tt.condition {
+				t.Errorf("Expected condition %q, got %q", tt.condition, got)
+			}
+			if got := Item(write.Put.Item).Version(); got != tt.expected+1 {
+				t.Errorf("Expected version %d, got %d", tt.expected+1, got)
+			}
+		})
+	}
+}
+
+func TestTable_TransactMapsCancellations(t *testing.T) {
+	canceled := func(codes ...string) error {
+		reasons := make([]types.CancellationReason, len(codes))
+		for i, code := range codes {
+			reasons[i].Code = aws.String(code)
+		}
+		return &types.TransactionCanceledException{CancellationReasons: reasons}
+	}
+
+	t.Run("failed condition", func(t *testing.T) {
+		// Act
+		err := NewTable(transactAPI{err: canceled("None", "ConditionalCheckFailed")}, "enricher").Transact(context.Background(), nil)
+
+		// Assert
+		var canceledErr *CanceledError
+		if !errors.As(err, &canceledErr) || canceledErr.Failed(0) || !canceledErr.Failed(1) {
+			t.Errorf("Expected the second write to be reported as failed, got %v", err)
+		}
+	})
+
+	t.Run("conflicting transaction", func(t *testing.T) {
+		// Act
+		err := NewTable(transactAPI{err: canceled("TransactionConflict", "None")}, "enricher").Transact(context.Background(), nil)
+
+		// Assert
+		if !errors.Is(err, ErrConflict) {
+			t.Errorf("Expected ErrConflict, got %v", err)
+		}
+	})
+
+	t.Run("too many writes", func(t *testing.T) {
+		// Act
+		err := NewTable(transactAPI{}, "enricher").Transact(context.Background(), make([]types.TransactWriteItem, MaxTransactWrites+1))
+
+		// Assert
+		if err == nil {
+			t.Error("Expected an error for an oversized transaction")
+		}
+	})
+}
+
+func TestRetry_RetriesConflictsOnly(t *testing.T) {
+	// Arrange
+	errOther := errors.New("throttled")
+	conflicts, others := 0, 0
+
+	// Act
+	conflictErr := Retry(3, func() error {
+		conflicts++
+		return ErrConflict
+	})
+	otherErr := Retry(3, func() error {
+		others++
+		return errOther
+	})
+
+	// Assert
+	if !errors.Is(conflictErr, ErrConflict) || conflicts != 3 {
+		t.Errorf("Expected 3 attempts ending in ErrConflict, got %d and %v", conflicts, conflictErr)
+	}
+	if !errors.Is(otherErr, errOther) || others != 1 {
+		t.Errorf("Expected a single attempt for other errors, got %d and %v", others, otherErr)
+	}
+}
@@ -0,0 +1,47 @@
+package enrichment
+
+import (
+	"errors"
+	"net/http"
+
+	"enricher-api-go/internal/problem"
+	"enricher-api-go/internal/servertiming"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Handler handles HTTP requests for order enrichment
+type Handler struct {
+	service Service
+}
+
+// NewHandler creates a new enrichment handler
+func NewHandler(service Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+// EnrichOrder handles POST /v1/enrich
+func (h *Handler) EnrichOrder(c echo.Context) error {
+	var req OrderRequest
+	if err := servertiming.Measure(c, "bind", func() error { return c.Bind(&req) }); err != nil {
+		return problem.Write(c, http.StatusBadRequest, "Invalid request body")
+	}
+
+	stop := servertiming.Start(c, "service")
+	order, err := h.service.EnrichOrder(c.Request().Context(), req)
+	stop()
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidOrder):
+			return problem.Write(c, http.StatusBadRequest, err.Error())
+		case errors.Is(err, ErrCustomerNotFound), errors.Is(err, ErrProductNotFound):
+			return problem.Write(c, http.StatusNotFound, err.Error())
+		default:
+			return problem.ServerError(c, err)
+		}
+	}
+
+	return c.JSON(http.StatusOK, order)
+}
@@ -0,0 +1,94 @@
+// Package enrichment combines customer and product lookups to enrich
+// incoming orders for the Resilient Order Enricher API.
+package enrichment
+
+import (
+	"time"
+
+	"enricher-api-go/internal/tax"
+)
+
+// OrderItem is a single order line referencing a product by ID or one of
+// its variants by SKU
+type OrderItem struct {
+	ProductID string `json:"productId,omitempty"`
+	// SKU selects a variant; ProductID may then be omitted, and must match
+	// the variant's product when it is not
+	SKU      string `json:"sku,omitempty"`
+	Quantity int    `json:"quantity"`
+}
+
+// OrderRequest is the order payload accepted by POST /v1/enrich
+type OrderRequest struct {
+	CustomerID string      `json:"customerId"`
+	Items      []OrderItem `json:"items"`
+	// OrderedAt prices the items as of when the order was placed; orders
+	// without it are priced now
+	OrderedAt *time.Time `json:"orderedAt,omitempty"`
+	// ShippingAddressID selects one of the customer's shipping addresses;
+	// orders without it ship to the customer's default shipping address
+	ShippingAddressID string `json:"shippingAddressId,omitempty"`
+}
+
+// EnrichedCustomer is the customer information attached to an enriched order
+type EnrichedCustomer struct {
+	CustomerID string `json:"customerId"`
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	Active     bool   `json:"active"`
+	// AvailableCredit is the customer's credit limit less its current exposure
+	AvailableCredit float64 `json:"availableCredit"`
+	// Segments are the segment tags assigned to the customer
+	Segments []string `json:"segments,omitempty"`
+	// ComputedSegments are the segments, such as VIP, the customer is in
+	// when the order is enriched, for downstream pricing to discount by
+	ComputedSegments []string `json:"computedSegments,omitempty"`
+	// LoyaltyTier is the customer's loyalty tier, such as GOLD, for
+	// downstream services to apply its perks
+	LoyaltyTier string `json:"loyaltyTier,omitempty"`
+}
+
+// EnrichedAddress is the shipping address attached to an enriched order so
+// downstream services can compute tax and shipping
+type EnrichedAddress struct {
+	AddressID  string `json:"addressId"`
+	Line1      string `json:"line1"`
+	Line2      string `json:"line2,omitempty"`
+	City       string `json:"city"`
+	Region     string `json:"region,omitempty"`
+	PostalCode string `json:"postalCode"`
+	Country    string `json:"country"`
+}
+
+// EnrichedItem is an order line with the product details and prices resolved.
+// Lines ordered by SKU carry the variant's attributes, price and stock.
+type EnrichedItem struct {
+	ProductID string  `json:"productId"`
+	SKU       string  `json:"sku,omitempty"`
+	Name      string  `json:"name"`
+	Category  string  `json:"category"`
+	UnitPrice float64 `json:"unitPrice"`
+	Quantity  int     `json:"quantity"`
+	LineTotal float64 `json:"lineTotal"`
+	InStock   bool    `json:"inStock"`
+	// AvailableQuantity is the product's stock, which may be below Quantity
+	AvailableQuantity int `json:"availableQuantity"`
+	// Attributes are the variant's attributes, for lines ordered by SKU
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+// EnrichedOrder is the order returned by POST /v1/enrich
+type EnrichedOrder struct {
+	Customer EnrichedCustomer `json:"customer"`
+	// ShippingAddress is omitted when the order names no address and the
+	// customer has no default shipping address
+	ShippingAddress *EnrichedAddress `json:"shippingAddress,omitempty"`
+	Items           []EnrichedItem   `json:"items"`
+	Total           float64          `json:"total"`
+	// ExceedsCredit flags an order whose total is more than the customer's
+	// available credit
+	ExceedsCredit bool `json:"exceedsCredit"`
+	// Tax is the tax breakdown of an order shipped to an address; omitted
+	// when the order has no address or its tax could not be quoted
+	Tax *tax.Quote `json:"tax,omitempty"`
+}
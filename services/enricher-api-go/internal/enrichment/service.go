@@ -0,0 +1,369 @@
+package enrichment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+
+	"enricher-api-go/internal/customer"
+	"enricher-api-go/internal/logging"
+	"enricher-api-go/internal/product"
+	"enricher-api-go/internal/tax"
+)
+
+var (
+	// ErrInvalidOrder is returned when the order payload is malformed
+	ErrInvalidOrder = errors.New("invalid order")
+	// ErrCustomerNotFound is returned when the order references an unknown customer
+	ErrCustomerNotFound = errors.New("customer not found")
+	// ErrProductNotFound is returned when the order references an unknown product
+	ErrProductNotFound = errors.New("product not found")
+)
+
+// Service defines the interface for order enrichment
+type Service interface {
+	EnrichOrder(ctx context.Context, req OrderRequest) (*EnrichedOrder, error)
+}
+
+// EnrichmentService enriches orders using the customer and product services
+type EnrichmentService struct {
+	customers customer.Service
+	products  product.Service
+	// taxes quotes the tax of orders; nil leaves it out
+	taxes tax.Calculator
+}
+
+// Option configures optional EnrichmentService behavior
+type Option func(*EnrichmentService)
+
+// WithTaxCalculator sets the calculator quoting the tax of orders shipped to
+// an address. A failed quote is logged and leaves the tax out rather than
+// failing the enrichment.
+func WithTaxCalculator(calculator tax.Calculator) Option {
+	return func(s *EnrichmentService) {
+		s.taxes = calculator
+	}
+}
+
+// NewService creates a new enrichment service
+func NewService(customers customer.Service, products product.Service, opts ...Option) *EnrichmentService {
+	s := &EnrichmentService{
+		customers: customers,
+		products:  products,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// EnrichOrder validates the order and resolves its customer and products.
+//
+// Items ordered by SKU are first resolved to their variants. The customer
+// and each distinct product are then fetched concurrently; the first lookup
+// failure aborts the enrichment. Products are priced at OrderedAt when the
+// order carries it; variants have a single price. The shipping address is
+// fetched along with the customer, and the tax of an order with one is
+// quoted once its lines are priced. The enrichment is abandoned with ctx's
+// error once ctx is done.
+func (s *EnrichmentService) EnrichOrder(ctx context.Context, req OrderRequest) (*EnrichedOrder, error) {
+	logger := logging.FromContext(ctx)
+	logger.Info("Enriching order", "customer_id", req.CustomerID, "items", len(req.Items))
+
+	if err := validateOrderRequest(req); err != nil {
+		return nil, err
+	}
+
+	// Copy the items so resolving SKUs leaves the caller's order untouched
+	req.Items = append([]OrderItem(nil), req.Items...)
+	variants, err := s.resolveVariants(ctx, req.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	productIDs := distinctProductIDs(req.Items)
+	getProduct := s.products.GetProduct
+	if req.OrderedAt != nil {
+		getProduct = func(ctx context.Context, productID string) (*product.Product, error) {
+			return s.products.GetProductAt(ctx, productID, *req.OrderedAt)
+		}
+	}
+
+	var (
+		wg          sync.WaitGroup
+		mu          sync.Mutex
+		cust        *customer.Customer
+		address     *customer.Address
+		customerErr error
+		products    = make(map[string]*product.Product, len(productIDs))
+		productErrs = make(map[string]error)
+	)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		cust, customerErr = s.customers.GetCustomer(ctx, req.CustomerID)
+		if customerErr == nil {
+			address, customerErr = s.shippingAddress(ctx, req)
+		}
+	}()
+
+	for _, productID := range productIDs {
+		wg.Add(1)
+		go func(productID string) {
+			defer wg.Done()
+			p, err := getProduct(ctx, productID)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				productErrs[productID] = err
+				return
+			}
+			products[productID] = p
+		}(productID)
+	}
+
+	// Stop waiting once the request is abandoned; lookups still running
+	// finish in the background
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		logger.Warn("Abandoned order enrichment", "customer_id", req.CustomerID, "error", ctx.Err())
+		return nil, fmt.Errorf("failed to enrich order: %w", ctx.Err())
+	}
+
+	if customerErr != nil {
+		if errors.Is(customerErr, customer.ErrCustomerNotFound) {
+			return nil, fmt.Errorf("%w: %s", ErrCustomerNotFound, req.CustomerID)
+		}
+		if errors.Is(customerErr, ErrInvalidOrder) {
+			return nil, customerErr
+		}
+		return nil, fmt.Errorf("failed to get customer: %w", customerErr)
+	}
+
+	// Report product failures in request order so errors are deterministic
+	for _, productID := range productIDs {
+		if err, ok := productErrs[productID]; ok {
+			if errors.Is(err, product.ErrProductNotFound) {
+				return nil, fmt.Errorf("%w: %s", ErrProductNotFound, productID)
+			}
+			return nil, fmt.Errorf("failed to get product %s: %w", productID, err)
+		}
+	}
+
+	order := &EnrichedOrder{
+		Customer: EnrichedCustomer{
+			CustomerID:       cust.CustomerID,
+			Name:             cust.Name,
+			Status:           cust.Status,
+			Active:           cust.IsActive(),
+			Segments:         cust.Segments,
+			ComputedSegments: cust.ComputedSegments,
+			LoyaltyTier:      cust.LoyaltyTier,
+		},
+		Items: make([]EnrichedItem, 0, len(req.Items)),
+	}
+	if address != nil {
+		order.ShippingAddress = &EnrichedAddress{
+			AddressID:  address.AddressID,
+			Line1:      address.Line1,
+			Line2:      address.Line2,
+			City:       address.City,
+			Region:     address.Region,
+			PostalCode: address.PostalCode,
+			Country:    address.Country,
+		}
+	}
+
+	for _, item := range req.Items {
+		p := products[item.ProductID]
+		enriched := EnrichedItem{
+			ProductID: p.ProductID,
+			Name:      p.Name,
+			Category:  p.Category,
+			UnitPrice: p.Price,
+			Quantity:  item.Quantity,
+			InStock:   p.InStock(),
+			// Stock is reported, not reserved; callers reserve it separately
+			AvailableQuantity: p.Quantity,
+		}
+		if variant, ok := variants[item.SKU]; ok {
+			enriched.SKU = variant.SKU
+			enriched.Attributes = variant.Attributes
+			enriched.UnitPrice = variant.Price
+			enriched.InStock = variant.InStock()
+			enriched.AvailableQuantity = variant.Quantity
+		}
+		enriched.LineTotal = roundPrice(enriched.UnitPrice * float64(item.Quantity))
+
+		order.Items = append(order.Items, enriched)
+		order.Total += enriched.LineTotal
+	}
+	order.Total = roundPrice(order.Total)
+
+	// Credit is checked, not reserved; callers reserve it separately
+	credit := cust.CheckCredit(order.Total)
+	order.Customer.AvailableCredit = credit.AvailableCredit
+	order.ExceedsCredit = !credit.Approved
+
+	if order.ShippingAddress != nil && s.taxes != nil {
+		order.Tax = s.quoteTax(ctx, order)
+	}
+
+	logger.Info("Enriched order", "customer_id", req.CustomerID, "total", order.Total)
+	return order, nil
+}
+
+// quoteTax quotes the tax of the priced order lines shipped to the order's
+// address, or returns nil when the calculator fails
+func (s *EnrichmentService) quoteTax(ctx context.Context, order *EnrichedOrder) *tax.Quote {
+	req := tax.QuoteRequest{
+		Address: tax.Address{
+			Country:    order.ShippingAddress.Country,
+			Region:     order.ShippingAddress.Region,
+			PostalCode: order.ShippingAddress.PostalCode,
+			City:       order.ShippingAddress.City,
+		},
+		Lines: make([]tax.Line, 0, len(order.Items)),
+	}
+	for _, item := range order.Items {
+		req.Lines = append(req.Lines, tax.Line{ProductID: item.ProductID, Category: item.Category, UnitPrice: item.UnitPrice, Quantity: item.Quantity})
+	}
+
+	quote, err := s.taxes.Quote(ctx, req)
+	if err != nil {
+		logging.FromContext(ctx).Warn("Enriching order without tax", "customer_id", order.Customer.CustomerID, "error", err)
+		return nil
+	}
+	return quote
+}
+
+// shippingAddress returns the shipping address named by the order, or the
+// customer's default shipping address when it names none. A customer without
+// a default yields a nil address; naming an unknown or billing address is an
+// ErrInvalidOrder.
+func (s *EnrichmentService) shippingAddress(ctx context.Context, req OrderRequest) (*customer.Address, error) {
+	if req.ShippingAddressID == "" {
+		address, err := s.customers.DefaultAddress(ctx, req.CustomerID, customer.AddressShipping)
+		if errors.Is(err, customer.ErrAddressNotFound) {
+			return nil, nil
+		}
+		return address, err
+	}
+
+	address, err := s.customers.GetAddress(ctx, req.CustomerID, req.ShippingAddressID)
+	if errors.Is(err, customer.ErrAddressNotFound) {
+		return nil, fmt.Errorf("%w: unknown shipping address %s", ErrInvalidOrder, req.ShippingAddressID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if address.Type != customer.AddressShipping {
+		return nil, fmt.Errorf("%w: address %s is not a shipping address", ErrInvalidOrder, req.ShippingAddressID)
+	}
+	return address, nil
+}
+
+// resolveVariants looks up the variant of every item ordered by SKU,
+// concurrently, and fills in the item's product ID in place. The variants
+// are keyed by the SKU as written in the order.
+func (s *EnrichmentService) resolveVariants(ctx context.Context, items []OrderItem) (map[string]*product.Variant, error) {
+	skus := make([]string, 0)
+	variants := make(map[string]*product.Variant)
+	for _, item := range items {
+		if _, ok := variants[item.SKU]; item.SKU != "" && !ok {
+			variants[item.SKU] = nil
+			skus = append(skus, item.SKU)
+		}
+	}
+	if len(skus) == 0 {
+		return variants, nil
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(skus))
+	found := make([]*product.Variant, len(skus))
+	for i, sku := range skus {
+		wg.Add(1)
+		go func(i int, sku string) {
+			defer wg.Done()
+			found[i], errs[i] = s.products.GetVariant(ctx, sku)
+		}(i, sku)
+	}
+	wg.Wait()
+
+	// Report failures in request order so errors are deterministic
+	for i, sku := range skus {
+		if err := errs[i]; err != nil {
+			if errors.Is(err, product.ErrVariantNotFound) || errors.Is(err, product.ErrInvalidVariant) {
+				return nil, fmt.Errorf("%w: sku %s", ErrProductNotFound, sku)
+			}
+			return nil, fmt.Errorf("failed to get variant %s: %w", sku, err)
+		}
+		variants[sku] = found[i]
+	}
+
+	for i, item := range items {
+		if item.SKU == "" {
+			continue
+		}
+		productID := variants[item.SKU].ProductID
+		if item.ProductID != "" && item.ProductID != productID {
+			return nil, fmt.Errorf("%w: items[%d].sku %s belongs to product %s", ErrInvalidOrder, i, item.SKU, productID)
+		}
+		items[i].ProductID = productID
+	}
+
+	return variants, nil
+}
+
+// validateOrderRequest checks the order has a customer and valid items
+func validateOrderRequest(req OrderRequest) error {
+	if req.CustomerID == "" {
+		return fmt.Errorf("%w: customerId is required", ErrInvalidOrder)
+	}
+	if len(req.Items) == 0 {
+		return fmt.Errorf("%w: at least one item is required", ErrInvalidOrder)
+	}
+
+	for i, item := range req.Items {
+		if item.ProductID == "" && item.SKU == "" {
+			return fmt.Errorf("%w: items[%d].productId or sku is required", ErrInvalidOrder, i)
+		}
+		if item.Quantity <= 0 {
+			return fmt.Errorf("%w: items[%d].quantity must be positive", ErrInvalidOrder, i)
+		}
+	}
+
+	return nil
+}
+
+// distinctProductIDs returns the product IDs referenced by items, in first-seen order
+func distinctProductIDs(items []OrderItem) []string {
+	seen := make(map[string]struct{}, len(items))
+	ids := make([]string, 0, len(items))
+
+	for _, item := range items {
+		if _, ok := seen[item.ProductID]; ok {
+			continue
+		}
+		seen[item.ProductID] = struct{}{}
+		ids = append(ids, item.ProductID)
+	}
+
+	return ids
+}
+
+// roundPrice rounds an amount to two decimal places
+func roundPrice(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
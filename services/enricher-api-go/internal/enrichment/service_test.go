@@ -0,0 +1,368 @@
+package enrichment
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"enricher-api-go/internal/clock"
+	"enricher-api-go/internal/customer"
+	"enricher-api-go/internal/product"
+	"enricher-api-go/internal/tax"
+)
+
+func newTestService() *EnrichmentService {
+	return NewService(
+		customer.NewService(customer.NewInMemoryRepository()),
+		product.NewService(product.NewInMemoryRepository()),
+	)
+}
+
+func TestEnrichmentService_EnrichOrder(t *testing.T) {
+	// Arrange
+	service := newTestService()
+	req := OrderRequest{
+		CustomerID: "customer-456",
+		Items: []OrderItem{
+			{ProductID: "product-123", Quantity: 3},
+			{ProductID: "product-101", Quantity: 2},
+			{ProductID: "product-123", Quantity: 1},
+		},
+	}
+
+	// Act
+	order, err := service.EnrichOrder(context.Background(), req)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if order.Customer.CustomerID != "customer-456" || !order.Customer.Active {
+		t.Errorf("Expected active customer-456, got %+v", order.Customer)
+	}
+
+	// customer-456 has a 5000 credit limit and was seeded just now
+	if !slices.Equal(order.Customer.ComputedSegments, []string{customer.SegmentVIP, customer.SegmentNew}) || !slices.Equal(order.Customer.Segments, []string{"vip", "newsletter"}) {
+		t.Errorf("Expected customer-456's segments, got %+v", order.Customer)
+	}
+
+	if len(order.Items) != 3 {
+		t.Fatalf("Expected 3 items, got %d", len(order.Items))
+	}
+
+	first := order.Items[0]
+	if first.Name != "Wireless Mouse" || first.UnitPrice != 25.99 || first.LineTotal != 77.97 {
+		t.Errorf("Expected Wireless Mouse x3 = 77.97, got %+v", first)
+	}
+
+	if order.Items[2].ProductID != "product-123" || order.Items[2].Quantity != 1 {
+		t.Errorf("Expected items to keep request order, got %+v", order.Items[2])
+	}
+
+	if order.Total != 128.96 {
+		t.Errorf("Expected total 128.96, got %.2f", order.Total)
+	}
+}
+
+func TestEnrichmentService_EnrichOrder_PricedAtOrderTime(t *testing.T) {
+	// Arrange
+	now := time.Date(2024, 6, 1, 8, 0, 0, 0, time.UTC)
+	products := product.NewService(product.NewInMemoryRepository(), product.WithClock(clock.Fixed(now)))
+	saleStart := now.Add(24 * time.Hour)
+	if _, err := products.SchedulePrice(context.Background(), "product-123", product.PriceChangeRequest{Price: 19.99, EffectiveAt: saleStart}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	service := NewService(customer.NewService(customer.NewInMemoryRepository()), products)
+	order := func(orderedAt *time.Time) OrderRequest {
+		return OrderRequest{
+			CustomerID: "customer-456",
+			Items:      []OrderItem{{ProductID: "product-123", Quantity: 2}},
+			OrderedAt:  orderedAt,
+		}
+	}
+	duringSale := saleStart.Add(time.Hour)
+
+	// Act
+	current, err := service.EnrichOrder(context.Background(), order(nil))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	onSale, err := service.EnrichOrder(context.Background(), order(&duringSale))
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if current.Items[0].UnitPrice != 25.99 {
+		t.Errorf("Expected the current price 25.99 without an order time, got %+v", current.Items[0])
+	}
+	if onSale.Items[0].UnitPrice != 19.99 || onSale.Total != 39.98 {
+		t.Errorf("Expected the sale price 19.99 x2 = 39.98, got %+v (total %.2f)", onSale.Items[0], onSale.Total)
+	}
+}
+
+func TestEnrichmentService_EnrichOrder_BySKU(t *testing.T) {
+	// Arrange
+	products := product.NewService(product.NewInMemoryRepository())
+	if _, err := products.CreateVariant(context.Background(), "product-123", product.VariantRequest{
+		SKU: "MOUSE-BLACK", Attributes: map[string]string{"color": "black"}, Price: 27.5, Quantity: 1,
+	}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	service := NewService(customer.NewService(customer.NewInMemoryRepository()), products)
+	items := []OrderItem{
+		{SKU: "mouse-black", Quantity: 2},
+		{ProductID: "product-123", Quantity: 1},
+	}
+
+	// Act
+	order, err := service.EnrichOrder(context.Background(), OrderRequest{CustomerID: "customer-456", Items: items})
+	_, unknownErr := service.EnrichOrder(context.Background(), OrderRequest{
+		CustomerID: "customer-456",
+		Items:      []OrderItem{{SKU: "MOUSE-PINK", Quantity: 1}},
+	})
+	_, mismatchErr := service.EnrichOrder(context.Background(), OrderRequest{
+		CustomerID: "customer-456",
+		Items:      []OrderItem{{ProductID: "product-101", SKU: "MOUSE-BLACK", Quantity: 1}},
+	})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	variantLine := order.Items[0]
+	if variantLine.ProductID != "product-123" || variantLine.SKU != "MOUSE-BLACK" || variantLine.UnitPrice != 27.5 ||
+		variantLine.AvailableQuantity != 1 || variantLine.Attributes["color"] != "black" {
+		t.Errorf("Expected the black mouse variant, got %+v", variantLine)
+	}
+	if order.Items[1].SKU != "" || order.Items[1].UnitPrice != 25.99 {
+		t.Errorf("Expected the plain product line at the product price, got %+v", order.Items[1])
+	}
+	if order.Total != 80.99 {
+		t.Errorf("Expected total 80.99, got %.2f", order.Total)
+	}
+	if items[0].ProductID != "" {
+		t.Errorf("Expected the caller's items to be left untouched, got %+v", items[0])
+	}
+	if !errors.Is(unknownErr, ErrProductNotFound) {
+		t.Errorf("Expected ErrProductNotFound for an unknown SKU, got %v", unknownErr)
+	}
+	if !errors.Is(mismatchErr, ErrInvalidOrder) {
+		t.Errorf("Expected ErrInvalidOrder for a SKU of another product, got %v", mismatchErr)
+	}
+}
+
+func TestEnrichmentService_EnrichOrder_ShippingAddress(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	customers := customer.NewService(customer.NewInMemoryRepository())
+	home, err := customers.CreateAddress(ctx, "customer-456", customer.AddressRequest{
+		Type: customer.AddressShipping, Line1: "12 Rue de la Paix", City: "Paris", PostalCode: "75002", Country: "fr",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	office, err := customers.CreateAddress(ctx, "customer-456", customer.AddressRequest{
+		Type: customer.AddressShipping, Line1: "1 Quai Perrache", City: "Lyon", PostalCode: "69002", Country: "FR",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	billing, err := customers.CreateAddress(ctx, "customer-456", customer.AddressRequest{
+		Type: customer.AddressBilling, Line1: "12 Rue de la Paix", City: "Paris", PostalCode: "75002", Country: "FR",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	service := NewService(customers, product.NewService(product.NewInMemoryRepository()))
+	order := func(customerID, addressID string) OrderRequest {
+		return OrderRequest{
+			CustomerID:        customerID,
+			Items:             []OrderItem{{ProductID: "product-123", Quantity: 1}},
+			ShippingAddressID: addressID,
+		}
+	}
+
+	// Act
+	byDefault, defaultErr := service.EnrichOrder(ctx, order("customer-456", ""))
+	named, namedErr := service.EnrichOrder(ctx, order("customer-456", office.AddressID))
+	noAddress, noAddressErr := service.EnrichOrder(ctx, order("customer-123", ""))
+	_, billingErr := service.EnrichOrder(ctx, order("customer-456", billing.AddressID))
+	_, unknownErr := service.EnrichOrder(ctx, order("customer-456", "address-missing"))
+
+	// Assert
+	if defaultErr != nil || byDefault.ShippingAddress == nil || byDefault.ShippingAddress.AddressID != home.AddressID {
+		t.Errorf("Expected the default shipping address %s, got %+v, %v", home.AddressID, byDefault, defaultErr)
+	} else if byDefault.ShippingAddress.Country != "FR" || byDefault.ShippingAddress.PostalCode != "75002" {
+		t.Errorf("Expected the address's postal fields, got %+v", byDefault.ShippingAddress)
+	}
+	if namedErr != nil || named.ShippingAddress == nil || named.ShippingAddress.City != "Lyon" {
+		t.Errorf("Expected the named shipping address in Lyon, got %+v, %v", named, namedErr)
+	}
+	if noAddressErr != nil || noAddress.ShippingAddress != nil {
+		t.Errorf("Expected no shipping address for a customer without one, got %+v, %v", noAddress, noAddressErr)
+	}
+	if !errors.Is(billingErr, ErrInvalidOrder) {
+		t.Errorf("Expected ErrInvalidOrder for a billing address, got %v", billingErr)
+	}
+	if !errors.Is(unknownErr, ErrInvalidOrder) {
+		t.Errorf("Expected ErrInvalidOrder for an unknown address, got %v", unknownErr)
+	}
+}
+
+// failingCalculator is a tax.Calculator that cannot reach its tax service
+type failingCalculator struct{}
+
+func (failingCalculator) Quote(context.Context, tax.QuoteRequest) (*tax.Quote, error) {
+	return nil, tax.ErrUnavailable
+}
+
+func TestEnrichmentService_EnrichOrder_Tax(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	customers := customer.NewService(customer.NewInMemoryRepository())
+	if _, err := customers.CreateAddress(ctx, "customer-456", customer.AddressRequest{
+		Type: customer.AddressShipping, Line1: "1 Market St", City: "San Francisco", Region: "CA", PostalCode: "94105", Country: "US",
+	}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	products := product.NewService(product.NewInMemoryRepository())
+	calculator := tax.NewFlatRate(0, map[string]float64{"US-CA": 0.0725})
+	taxed := NewService(customers, products, WithTaxCalculator(calculator))
+	failing := NewService(customers, products, WithTaxCalculator(failingCalculator{}))
+	order := func(customerID string) OrderRequest {
+		return OrderRequest{CustomerID: customerID, Items: []OrderItem{{ProductID: "product-123", Quantity: 2}}}
+	}
+
+	// Act
+	withAddress, withAddressErr := taxed.EnrichOrder(ctx, order("customer-456"))
+	noAddress, noAddressErr := taxed.EnrichOrder(ctx, order("customer-123"))
+	degraded, degradedErr := failing.EnrichOrder(ctx, order("customer-456"))
+
+	// Assert
+	if withAddressErr != nil || withAddress.Tax == nil {
+		t.Fatalf("Expected a tax breakdown, got %+v, %v", withAddress, withAddressErr)
+	}
+	if withAddress.Tax.Jurisdiction != "US-CA" || len(withAddress.Tax.Lines) != 1 || withAddress.Tax.TotalTax != 3.77 {
+		t.Errorf("Expected 3.77 of California tax on 51.98, got %+v", withAddress.Tax)
+	}
+	if noAddressErr != nil || noAddress.Tax != nil {
+		t.Errorf("Expected no tax without a shipping address, got %+v, %v", noAddress, noAddressErr)
+	}
+	if degradedErr != nil || degraded.Tax != nil {
+		t.Errorf("Expected the order without tax when the calculator fails, got %+v, %v", degraded, degradedErr)
+	}
+}
+
+func TestEnrichmentService_EnrichOrder_Credit(t *testing.T) {
+	// Arrange
+	service := newTestService()
+	order := func(quantity int) OrderRequest {
+		return OrderRequest{
+			CustomerID: "customer-123",
+			Items:      []OrderItem{{ProductID: "product-123", Quantity: quantity}},
+		}
+	}
+
+	// Act
+	within, withinErr := service.EnrichOrder(context.Background(), order(28))
+	over, overErr := service.EnrichOrder(context.Background(), order(29))
+
+	// Assert
+	if withinErr != nil || within.ExceedsCredit || within.Customer.AvailableCredit != 750 {
+		t.Errorf("Expected 727.72 to fit within 750 of available credit, got %+v, %v", within, withinErr)
+	}
+	if overErr != nil || !over.ExceedsCredit {
+		t.Errorf("Expected 753.71 to exceed the available credit, got %+v, %v", over, overErr)
+	}
+}
+
+func TestEnrichmentService_EnrichOrder_InactiveCustomerAndOutOfStock(t *testing.T) {
+	// Arrange
+	service := newTestService()
+	req := OrderRequest{
+		CustomerID: "customer-789",
+		Items:      []OrderItem{{ProductID: "product-202", Quantity: 1}},
+	}
+
+	// Act
+	order, err := service.EnrichOrder(context.Background(), req)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if order.Customer.Active || order.Customer.Status != "SUSPENDED" {
+		t.Errorf("Expected inactive customer, got %+v", order.Customer)
+	}
+
+	if order.Items[0].InStock {
+		t.Error("Expected product-202 to be out of stock")
+	}
+}
+
+func TestEnrichmentService_EnrichOrder_NotFound(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     OrderRequest
+		wantErr error
+	}{
+		{
+			name:    "unknown customer",
+			req:     OrderRequest{CustomerID: "customer-missing", Items: []OrderItem{{ProductID: "product-123", Quantity: 1}}},
+			wantErr: ErrCustomerNotFound,
+		},
+		{
+			name:    "unknown product",
+			req:     OrderRequest{CustomerID: "customer-456", Items: []OrderItem{{ProductID: "product-123", Quantity: 1}, {ProductID: "product-missing", Quantity: 1}}},
+			wantErr: ErrProductNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			service := newTestService()
+
+			// Act
+			_, err := service.EnrichOrder(context.Background(), tt.req)
+
+			// Assert
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestEnrichmentService_EnrichOrder_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		req  OrderRequest
+	}{
+		{name: "missing customer", req: OrderRequest{Items: []OrderItem{{ProductID: "product-123", Quantity: 1}}}},
+		{name: "no items", req: OrderRequest{CustomerID: "customer-456"}},
+		{name: "missing product ID", req: OrderRequest{CustomerID: "customer-456", Items: []OrderItem{{Quantity: 1}}}},
+		{name: "zero quantity", req: OrderRequest{CustomerID: "customer-456", Items: []OrderItem{{ProductID: "product-123"}}}},
+		{name: "negative quantity", req: OrderRequest{CustomerID: "customer-456", Items: []OrderItem{{ProductID: "product-123", Quantity: -2}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			service := newTestService()
+
+			// Act
+			_, err := service.EnrichOrder(context.Background(), tt.req)
+
+			// Assert
+			if !errors.Is(err, ErrInvalidOrder) {
+				t.Errorf("Expected ErrInvalidOrder, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package envelope
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/kms/types"
+)
+
+// KMSAPI is the part of the AWS KMS client AWSKMS uses, so tests can stand
+// in for KMS
+type KMSAPI interface {
+	GenerateDataKey(ctx context.Context, params *kms.GenerateDataKeyInput, optFns ...func(*kms.Options)) (*kms.GenerateDataKeyOutput, error)
+	Decrypt(ctx context.Context, params *kms.DecryptInput, optFns ...func(*kms.Options)) (*kms.DecryptOutput, error)
+}
+
+// AWSKMSConfig locates the master key of an AWSKMS
+type AWSKMSConfig struct {
+	// KeyID is the master key's ID, ARN or alias, such as alias/enricher-pii
+	KeyID string
+	// Region is the key's region; the SDK's default when empty
+	Region string
+	// Endpoint overrides the KMS endpoint, such as a LocalStack URL
+	Endpoint string
+}
+
+// AWSKMS wraps data keys under a master key held in AWS KMS.
+//
+// KMS records the master key in each wrapped data key, so data keys wrapped
+// under a key that has since been rotated, automatically or by pointing an
+// alias at a new key, still unwrap as long as the caller may use the key
+// that wrapped them.
+type AWSKMS struct {
+	client KMSAPI
+	keyID  string
+}
+
+// OpenAWSKMS creates a KMS client with credentials from the SDK's default
+// chain and returns a KMS wrapping data keys under cfg.KeyID through it
+func OpenAWSKMS(ctx context.Context, cfg AWSKMSConfig) (*AWSKMS, error) {
+	var options []func(*awsconfig.LoadOptions) error
+	if cfg.Region != "" {
+		options = append(options, awsconfig.WithRegion(cfg.Region))
+	}
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	client := kms.NewFromConfig(awsCfg, func(o *kms.Options) {
+		if cfg.Endpoint != "" {
+			o.BaseEndpoint = aws.String(cfg.Endpoint)
+		}
+	})
+	return NewAWSKMS(client, cfg.KeyID), nil
+}
+
+// NewAWSKMS creates a KMS wrapping data keys under keyID through client
+func NewAWSKMS(client KMSAPI, keyID string) *AWSKMS {
+	return &AWSKMS{client: client, keyID: keyID}
+}
+
+// GenerateDataKey asks KMS for a new 256-bit data key under the master key
+func (k *AWSKMS) GenerateDataKey(ctx context.Context) (plaintext, wrapped []byte, err error) {
+	out, err := k.client.GenerateDataKey(ctx, &kms.GenerateDataKeyInput{
+		KeyId:   aws.String(k.keyID),
+		KeySpec: types.DataKeySpecAes256,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate data key with %s: %w", k.keyID, err)
+	}
+	return out.Plaintext, out.CiphertextBlob, nil
+}
+
+// Decrypt asks KMS to unwrap a data key
+func (k *AWSKMS) Decrypt(ctx context.Context, wrapped []byte) ([]byte, error) {
+	out, err := k.client.Decrypt(ctx, &kms.DecryptInput{CiphertextBlob: wrapped})
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+	return out.Plaintext, nil
+}
@@ -0,0 +1,205 @@
+// Package envelope encrypts individual fields of records at rest for the
+// Resilient Order Enricher API, using envelope encryption.
+//
+// Fields are encrypted with AES-256-GCM under data keys. A data key is
+// itself stored only wrapped by a master key held in a KMS, so reading the
+// stored records and the configured data keys is not enough to decrypt them;
+// the KMS must unwrap the data keys too. The KMS is pluggable: a local key
+// file for development and single-host deployments, or AWS KMS.
+//
+// A Keyring holds the unwrapped data keys. The first encrypts and every one
+// decrypts, so keys rotate by putting a new data key first and re-encrypting
+// the stored records under it before the old key is dropped.
+package envelope
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// prefix starts every encrypted value; values without it are plaintext,
+// written before encryption was turned on
+const prefix = "enc:"
+
+// ErrUnknownKey is returned when decrypting a value encrypted under a data
+// key the keyring does not hold
+var ErrUnknownKey = errors.New("value encrypted under an unknown data key")
+
+// KMS wraps data keys under a master key it holds and unwraps them again
+type KMS interface {
+	// GenerateDataKey returns a new 256-bit data key, in plaintext and
+	// wrapped under the current master key
+	GenerateDataKey(ctx context.Context) (plaintext, wrapped []byte, err error)
+	// Decrypt unwraps a data key wrapped by GenerateDataKey under any master
+	// key the KMS still holds
+	Decrypt(ctx context.Context, wrapped []byte) ([]byte, error)
+}
+
+// dataKey is an unwrapped data key
+type dataKey struct {
+	// id names the key in the values it encrypts: a hash of the wrapped key
+	id   string
+	aead cipher.AEAD
+	// nonceKey derives the nonces of deterministic encryption
+	nonceKey []byte
+}
+
+// Keyring encrypts and decrypts field values under unwrapped data keys.
+//
+// Encrypted values read "enc:<key id>:<base64 nonce and ciphertext>". Each
+// value is bound to the name of its field, so a value copied into another
+// field fails to decrypt. Empty values are left empty.
+type Keyring struct {
+	keys []*dataKey
+}
+
+// NewKeyring unwraps the data keys in wrapped through kms. The first key
+// encrypts; every key decrypts.
+func NewKeyring(ctx context.Context, kms KMS, wrapped [][]byte) (*Keyring, error) {
+	if len(wrapped) == 0 {
+		return nil, errors.New("no data keys to unwrap")
+	}
+
+	keyring := &Keyring{}
+	for _, blob := range wrapped {
+		plaintext, err := kms.Decrypt(ctx, blob)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unwrap data key: %w", err)
+		}
+		key, err := newDataKey(keyID(blob), plaintext)
+		if err != nil {
+			return nil, err
+		}
+		keyring.keys = append(keyring.keys, key)
+	}
+	return keyring, nil
+}
+
+// newDataKey prepares a 256-bit data key for use
+func newDataKey(id string, plaintext []byte) (*dataKey, error) {
+	if len(plaintext) != 32 {
+		return nil, fmt.Errorf("data key %s has %d bytes, want 32", id, len(plaintext))
+	}
+	block, err := aes.NewCipher(plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load data key %s: %w", id, err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load data key %s: %w", id, err)
+	}
+	mac := hmac.New(sha256.New, plaintext)
+	mac.Write([]byte("deterministic nonce"))
+	return &dataKey{id: id, aead: aead, nonceKey: mac.Sum(nil)}, nil
+}
+
+// keyID returns the ID of the data key wrapped as wrapped
+func keyID(wrapped []byte) string {
+	sum := sha256.Sum256(wrapped)
+	return hex.EncodeToString(sum[:4])
+}
+
+// Encrypt encrypts the value of field under the current data key with a
+// random nonce, so equal values encrypt differently
+func (k *Keyring) Encrypt(field, value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	nonce := make([]byte, k.keys[0].aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return k.keys[0].seal(nonce, field, value), nil
+}
+
+// EncryptDeterministic encrypts the value of field under the current data
+// key so that equal values encrypt the same, for fields looked up or kept
+// unique by their stored value. It reveals which stored values are equal.
+func (k *Keyring) EncryptDeterministic(field, value string) string {
+	if value == "" {
+		return ""
+	}
+	return k.keys[0].sealDeterministic(field, value)
+}
+
+// DeterministicCandidates returns value encrypted deterministically under
+// every data key, current first, followed by value itself: the forms a
+// stored value may take while keys rotate
+func (k *Keyring) DeterministicCandidates(field, value string) []string {
+	if value == "" {
+		return []string{""}
+	}
+	candidates := make([]string, 0, len(k.keys)+1)
+	for _, key := range k.keys {
+		candidates = append(candidates, key.sealDeterministic(field, value))
+	}
+	return append(candidates, value)
+}
+
+// Decrypt returns the value of field that stored encrypts. Values written
+// before encryption was turned on are returned as they are.
+func (k *Keyring) Decrypt(field, stored string) (string, error) {
+	if !strings.HasPrefix(stored, prefix) {
+		return stored, nil
+	}
+	id, encoded, ok := strings.Cut(strings.TrimPrefix(stored, prefix), ":")
+	if !ok {
+		return "", fmt.Errorf("malformed encrypted %s", field)
+	}
+	key := k.key(id)
+	if key == nil {
+		return "", fmt.Errorf("failed to decrypt %s: %w %s", field, ErrUnknownKey, id)
+	}
+
+	sealed, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < key.aead.NonceSize() {
+		return "", fmt.Errorf("malformed encrypted %s", field)
+	}
+	nonce, ciphertext := sealed[:key.aead.NonceSize()], sealed[key.aead.NonceSize():]
+	plaintext, err := key.aead.Open(nil, nonce, ciphertext, []byte(field))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt %s: %w", field, err)
+	}
+	return string(plaintext), nil
+}
+
+// IsCurrent reports whether stored is empty or encrypted under the current
+// data key, so re-encryption can skip it
+func (k *Keyring) IsCurrent(stored string) bool {
+	return stored == "" || strings.HasPrefix(stored, prefix+k.keys[0].id+":")
+}
+
+// key returns the data key with id, or nil
+func (k *Keyring) key(id string) *dataKey {
+	for _, key := range k.keys {
+		if key.id == id {
+			return key
+		}
+	}
+	return nil
+}
+
+// seal encrypts the value of field with nonce
+func (d *dataKey) seal(nonce []byte, field, value string) string {
+	sealed := d.aead.Seal(append([]byte(nil), nonce...), nonce, []byte(value), []byte(field))
+	return prefix + d.id + ":" + base64.RawURLEncoding.EncodeToString(sealed)
+}
+
+// sealDeterministic encrypts the value of field with a nonce derived from
+// both, like a synthetic IV
+func (d *dataKey) sealDeterministic(field, value string) string {
+	mac := hmac.New(sha256.New, d.nonceKey)
+	mac.Write([]byte(field))
+	mac.Write([]byte{0})
+	mac.Write([]byte(value))
+	return d.seal(mac.Sum(nil)[:d.aead.NonceSize()], field, value)
+}
@@ -0,0 +1,143 @@
+package envelope
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newTestKMS returns a LocalKMS with master keys named ids, newest first
+func newTestKMS(t *testing.T, ids ...string) *LocalKMS {
+	t.Helper()
+	keys := map[string]string{
+		"master-new": "AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8=",
+		"master-old": "HxwdHhsYGRoXFBUWExAREg8MDQ4LCAkKBwQFBgMAAQI=",
+	}
+	file := "keys:\n"
+	for _, id := range ids {
+		file += "  - id: " + id + "\n    key: " + keys[id] + "\n"
+	}
+	path := filepath.Join(t.TempDir(), "keys.yaml")
+	if err := os.WriteFile(path, []byte(file), 0o600); err != nil {
+		t.Fatalf("Failed to write key file: %v", err)
+	}
+	kms, err := NewLocalKMS(path)
+	if err != nil {
+		t.Fatalf("Failed to read key file: %v", err)
+	}
+	return kms
+}
+
+// newTestKeyring returns a keyring of the data keys wrapped by kms, current first
+func newTestKeyring(t *testing.T, kms KMS, wrapped ...[]byte) *Keyring {
+	t.Helper()
+	keyring, err := NewKeyring(context.Background(), kms, wrapped)
+	if err != nil {
+		t.Fatalf("Failed to create keyring: %v", err)
+	}
+	return keyring
+}
+
+// wrapDataKey returns a new data key wrapped by kms
+func wrapDataKey(t *testing.T, kms KMS) []byte {
+	t.Helper()
+	_, wrapped, err := kms.GenerateDataKey(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to generate data key: %v", err)
+	}
+	return wrapped
+}
+
+func TestKeyring_Encrypt(t *testing.T) {
+	// Arrange
+	kms := newTestKMS(t, "master-new")
+	keyring := newTestKeyring(t, kms, wrapDataKey(t, kms))
+
+	// Act
+	first, err := keyring.Encrypt("name", "Jane Doe")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	second, _ := keyring.Encrypt("name", "Jane Doe")
+	decrypted, err := keyring.Decrypt("name", first)
+
+	// Assert
+	if err != nil || decrypted != "Jane Doe" {
+		t.Errorf("Expected Jane Doe, got %q (%v)", decrypted, err)
+	}
+	if !strings.HasPrefix(first, "enc:") || strings.Contains(first, "Jane") {
+		t.Errorf("Expected an encrypted value, got %s", first)
+	}
+	if first == second {
+		t.Error("Expected equal values to encrypt differently")
+	}
+	if _, err := keyring.Decrypt("phone", first); err == nil {
+		t.Error("Expected a value moved to another field not to decrypt")
+	}
+}
+
+func TestKeyring_EncryptDeterministic(t *testing.T) {
+	// Arrange
+	kms := newTestKMS(t, "master-new")
+	keyring := newTestKeyring(t, kms, wrapDataKey(t, kms))
+
+	// Act
+	first := keyring.EncryptDeterministic("email", "jane.doe@example.com")
+	second := keyring.EncryptDeterministic("email", "jane.doe@example.com")
+	other := keyring.EncryptDeterministic("email", "john.smith@example.com")
+
+	// Assert
+	if first != second || first == other {
+		t.Errorf("Expected equal values alone to encrypt the same, got %s, %s and %s", first, second, other)
+	}
+	if decrypted, err := keyring.Decrypt("email", first); err != nil || decrypted != "jane.doe@example.com" {
+		t.Errorf("Expected the email back, got %q (%v)", decrypted, err)
+	}
+	if keyring.EncryptDeterministic("email", "") != "" {
+		t.Error("Expected an empty value to stay empty")
+	}
+}
+
+func TestKeyring_Rotation(t *testing.T) {
+	// Arrange: a value encrypted before a new data key was put first
+	kms := newTestKMS(t, "master-new")
+	oldKey, newKey := wrapDataKey(t, kms), wrapDataKey(t, kms)
+	before := newTestKeyring(t, kms, oldKey)
+	stored, _ := before.Encrypt("name", "Jane Doe")
+	storedEmail := before.EncryptDeterministic("email", "jane.doe@example.com")
+
+	// Act
+	after := newTestKeyring(t, kms, newKey, oldKey)
+
+	// Assert
+	if decrypted, err := after.Decrypt("name", stored); err != nil || decrypted != "Jane Doe" {
+		t.Errorf("Expected the old key to still decrypt, got %q (%v)", decrypted, err)
+	}
+	if after.IsCurrent(stored) || !before.IsCurrent(stored) {
+		t.Error("Expected the value to be current only before the rotation")
+	}
+	candidates := after.DeterministicCandidates("email", "jane.doe@example.com")
+	if len(candidates) != 3 || candidates[1] != storedEmail || candidates[2] != "jane.doe@example.com" {
+		t.Errorf("Expected the email under both keys and in plaintext, got %v", candidates)
+	}
+	if _, err := newTestKeyring(t, kms, newKey).Decrypt("name", stored); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("Expected ErrUnknownKey once the old key is dropped, got %v", err)
+	}
+}
+
+func TestKeyring_Decrypt_Plaintext(t *testing.T) {
+	// Arrange
+	kms := newTestKMS(t, "master-new")
+	keyring := newTestKeyring(t, kms, wrapDataKey(t, kms))
+
+	// Act
+	decrypted, err := keyring.Decrypt("name", "Jane Doe")
+
+	// Assert
+	if err != nil || decrypted != "Jane Doe" {
+		t.Errorf("Expected a value stored before encryption back as it is, got %q (%v)", decrypted, err)
+	}
+}
@@ -0,0 +1,108 @@
+package envelope
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+)
+
+func TestLocalKMS_MasterKeyRotation(t *testing.T) {
+	// Arrange: a data key wrapped before master-new was added first
+	ctx := context.Background()
+	plaintext, wrapped, err := newTestKMS(t, "master-old").GenerateDataKey(ctx)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	rotated := newTestKMS(t, "master-new", "master-old")
+
+	// Act
+	unwrapped, err := rotated.Decrypt(ctx, wrapped)
+	_, rewrapped, _ := rotated.GenerateDataKey(ctx)
+
+	// Assert
+	if err != nil || !bytes.Equal(unwrapped, plaintext) {
+		t.Errorf("Expected the old master key to still unwrap, got %v", err)
+	}
+	if _, err := newTestKMS(t, "master-old").Decrypt(ctx, rewrapped); err == nil {
+		t.Error("Expected new data keys to be wrapped by master-new")
+	}
+	if _, err := newTestKMS(t, "master-new").Decrypt(ctx, wrapped); err == nil {
+		t.Error("Expected an unwrap to fail once its master key is dropped")
+	}
+}
+
+func TestNewLocalKMS_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+	}{
+		{name: "no keys", file: "keys: []\n"},
+		{name: "short key", file: "keys:\n  - id: master\n    key: c2hvcnQ=\n"},
+		{name: "no ID", file: "keys:\n  - key: AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8=\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			path := filepath.Join(t.TempDir(), "keys.yaml")
+			if err := os.WriteFile(path, []byte(tt.file), 0o600); err != nil {
+				t.Fatalf("Failed to write key file: %v", err)
+			}
+
+			// Act
+			_, err := NewLocalKMS(path)
+
+			// Assert
+			if err == nil {
+				t.Error("Expected an invalid key file to be rejected")
+			}
+		})
+	}
+}
+
+// fakeKMS wraps data keys by prefixing them with the key ID, like a KMS
+// that records its master key in the blob
+type fakeKMS struct {
+	keyIDs []string
+}
+
+func (f *fakeKMS) GenerateDataKey(ctx context.Context, params *kms.GenerateDataKeyInput, optFns ...func(*kms.Options)) (*kms.GenerateDataKeyOutput, error) {
+	f.keyIDs = append(f.keyIDs, aws.ToString(params.KeyId))
+	plaintext := bytes.Repeat([]byte{7}, 32)
+	return &kms.GenerateDataKeyOutput{Plaintext: plaintext, CiphertextBlob: append([]byte(aws.ToString(params.KeyId)+":"), plaintext...)}, nil
+}
+
+func (f *fakeKMS) Decrypt(ctx context.Context, params *kms.DecryptInput, optFns ...func(*kms.Options)) (*kms.DecryptOutput, error) {
+	_, plaintext, _ := bytes.Cut(params.CiphertextBlob, []byte(":"))
+	return &kms.DecryptOutput{Plaintext: plaintext}, nil
+}
+
+func TestAWSKMS(t *testing.T) {
+	// Arrange
+	client := &fakeKMS{}
+	awsKMS := NewAWSKMS(client, "alias/enricher-pii")
+
+	// Act
+	plaintext, wrapped, err := awsKMS.GenerateDataKey(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	keyring, err := NewKeyring(context.Background(), awsKMS, [][]byte{wrapped})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected the data key to unwrap, got %v", err)
+	}
+	if len(client.keyIDs) != 1 || client.keyIDs[0] != "alias/enricher-pii" || len(plaintext) != 32 {
+		t.Errorf("Expected a 256-bit key under alias/enricher-pii, got %v", client.keyIDs)
+	}
+	stored, _ := keyring.Encrypt("name", "Jane Doe")
+	if decrypted, _ := keyring.Decrypt("name", stored); decrypted != "Jane Doe" {
+		t.Errorf("Expected Jane Doe, got %q", decrypted)
+	}
+}
@@ -0,0 +1,126 @@
+package envelope
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// localKeyFile is the layout of a LocalKMS key file
+type localKeyFile struct {
+	// Keys are the master keys, newest first
+	Keys []struct {
+		ID  string `yaml:"id"`
+		Key string `yaml:"key"`
+	} `yaml:"keys"`
+}
+
+// masterKey is a master key of a LocalKMS
+type masterKey struct {
+	id   string
+	aead cipher.AEAD
+}
+
+// LocalKMS wraps data keys under master keys read from a local key file.
+//
+// The file lists the master keys newest first, each a base64-encoded
+// 256-bit key with an ID:
+//
+//	keys:
+//	  - id: master-2026-03
+//	    key: 3q2+7w...
+//	  - id: master-2025-09
+//	    key: yv66vg...
+//
+// The first key wraps new data keys; every key unwraps the data keys it
+// wrapped, so a master key rotates by adding the new one first and keeping
+// the old one until no configured data key is wrapped by it.
+type LocalKMS struct {
+	keys []*masterKey
+}
+
+// NewLocalKMS reads the master keys in the key file at path
+func NewLocalKMS(path string) (*LocalKMS, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+	var file localKeyFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse key file %s: %w", path, err)
+	}
+	if len(file.Keys) == 0 {
+		return nil, fmt.Errorf("key file %s holds no keys", path)
+	}
+
+	kms := &LocalKMS{}
+	for _, entry := range file.Keys {
+		if entry.ID == "" || len(entry.ID) > 255 {
+			return nil, fmt.Errorf("key file %s: master key IDs must have 1 to 255 bytes", path)
+		}
+		key, err := base64.StdEncoding.DecodeString(entry.Key)
+		if err != nil || len(key) != 32 {
+			return nil, fmt.Errorf("key file %s: master key %s must be 32 bytes in base64", path, entry.ID)
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("key file %s: master key %s: %w", path, entry.ID, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("key file %s: master key %s: %w", path, entry.ID, err)
+		}
+		kms.keys = append(kms.keys, &masterKey{id: entry.ID, aead: aead})
+	}
+	return kms, nil
+}
+
+// GenerateDataKey returns a new data key wrapped under the first master
+// key: its ID's length and bytes, then the nonce and the sealed key
+func (k *LocalKMS) GenerateDataKey(ctx context.Context) (plaintext, wrapped []byte, err error) {
+	plaintext = make([]byte, 32)
+	if _, err := rand.Read(plaintext); err != nil {
+		return nil, nil, fmt.Errorf("failed to generate data key: %w", err)
+	}
+	master := k.keys[0]
+	nonce := make([]byte, master.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	wrapped = append([]byte{byte(len(master.id))}, master.id...)
+	wrapped = append(wrapped, nonce...)
+	wrapped = master.aead.Seal(wrapped, nonce, plaintext, []byte(master.id))
+	return plaintext, wrapped, nil
+}
+
+// Decrypt unwraps a data key wrapped by GenerateDataKey
+func (k *LocalKMS) Decrypt(ctx context.Context, wrapped []byte) ([]byte, error) {
+	if len(wrapped) == 0 || len(wrapped) < 1+int(wrapped[0]) {
+		return nil, errors.New("malformed wrapped data key")
+	}
+	id := string(wrapped[1 : 1+wrapped[0]])
+	rest := wrapped[1+len(id):]
+
+	for _, master := range k.keys {
+		if master.id != id {
+			continue
+		}
+		if len(rest) < master.aead.NonceSize() {
+			return nil, errors.New("malformed wrapped data key")
+		}
+		plaintext, err := master.aead.Open(nil, rest[:master.aead.NonceSize()], rest[master.aead.NonceSize():], []byte(id))
+		if err != nil {
+			return nil, fmt.Errorf("failed to unwrap data key with master key %s: %w", id, err)
+		}
+		return plaintext, nil
+	}
+	return nil, fmt.Errorf("data key wrapped by unknown master key %s", id)
+}
@@ -0,0 +1,253 @@
+// Package export streams whole datasets as CSV or newline-delimited JSON
+// for the export endpoints, choosing the format from the Accept header.
+//
+// Records are written and flushed as they are produced, so an export of any
+// size is sent without holding it in memory.
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"enricher-api-go/internal/fieldset"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Export media types
+const (
+	MIMETextCSV           = "text/csv"
+	MIMEApplicationNDJSON = "application/x-ndjson"
+)
+
+// MediaTypes lists the export formats in order of preference
+var MediaTypes = []string{MIMETextCSV, MIMEApplicationNDJSON}
+
+// ErrNotAcceptable is returned when the Accept header allows no export format
+var ErrNotAcceptable = errors.New("not acceptable")
+
+// Negotiate returns the export media type accept prefers, CSV when it has
+// no preference. Media ranges are weighed by their q parameter; ties go to
+// the earlier range.
+func Negotiate(accept string) (string, error) {
+	if strings.TrimSpace(accept) == "" {
+		return MIMETextCSV, nil
+	}
+
+	best, bestQ := "", 0.0
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mediaType, params, _ := strings.Cut(mediaRange, ";")
+		mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+		q := quality(params)
+		if q <= bestQ {
+			continue
+		}
+
+		switch mediaType {
+		case MIMETextCSV, MIMEApplicationNDJSON:
+			best, bestQ = mediaType, q
+		case "application/ndjson", "application/jsonl":
+			best, bestQ = MIMEApplicationNDJSON, q
+		case "*/*", "text/*":
+			best, bestQ = MIMETextCSV, q
+		case "application/*":
+			best, bestQ = MIMEApplicationNDJSON, q
+		}
+	}
+
+	if best == "" {
+		return "", fmt.Errorf("%w: exports are available as %s", ErrNotAcceptable, strings.Join(MediaTypes, " or "))
+	}
+	return best, nil
+}
+
+// quality returns the q parameter of a media range, 1 when it has none
+func quality(params string) float64 {
+	for _, param := range strings.Split(params, ";") {
+		name, value, _ := strings.Cut(param, "=")
+		if strings.TrimSpace(name) != "q" {
+			continue
+		}
+		q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return 0
+		}
+		return q
+	}
+	return 1
+}
+
+// Writer encodes records one per line, as CSV rows under a header of their
+// JSON field names or as JSON objects
+type Writer struct {
+	fields  fieldset.Set
+	columns []string
+	csv     *csv.Writer
+	json    *json.Encoder
+}
+
+// NewWriter creates a writer of mediaType records to w. Records have the
+// type of sample, and only their fields of fields are written; an empty set
+// writes every field.
+func NewWriter(w io.Writer, mediaType string, sample interface{}, fields fieldset.Set) *Writer {
+	writer := &Writer{fields: fields, columns: fields}
+	if len(writer.columns) == 0 {
+		writer.columns = fieldset.Names(reflect.TypeOf(sample))
+	}
+	if mediaType == MIMETextCSV {
+		writer.csv = csv.NewWriter(w)
+	} else {
+		writer.json = json.NewEncoder(w)
+	}
+	return writer
+}
+
+// WriteHeader writes the CSV header row; NDJSON has none
+func (w *Writer) WriteHeader() error {
+	if w.csv == nil {
+		return nil
+	}
+	return w.csv.Write(w.columns)
+}
+
+// Write encodes one record
+func (w *Writer) Write(record interface{}) error {
+	if w.csv == nil {
+		selected, err := w.fields.Apply(record)
+		if err != nil {
+			return err
+		}
+		return w.json.Encode(selected)
+	}
+
+	encoded, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode record: %w", err)
+	}
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(encoded, &object); err != nil {
+		return fmt.Errorf("failed to encode record: %w", err)
+	}
+
+	row := make([]string, len(w.columns))
+	for i, column := range w.columns {
+		row[i] = cell(object[column])
+	}
+	return w.csv.Write(row)
+}
+
+// Flush writes any buffered rows
+func (w *Writer) Flush() error {
+	if w.csv == nil {
+		return nil
+	}
+	w.csv.Flush()
+	return w.csv.Error()
+}
+
+// cell formats a JSON value as a CSV cell: strings unquoted, numbers and
+// booleans as written, null as empty and objects and arrays as compact JSON
+func cell(raw json.RawMessage) string {
+	raw = bytes.TrimSpace(raw)
+	switch {
+	case len(raw) == 0 || string(raw) == "null":
+		return ""
+	case raw[0] == '"':
+		var s string
+		if err := json.Unmarshal(raw, &s); err == nil {
+			return s
+		}
+	case raw[0] == '{' || raw[0] == '[':
+		var compacted bytes.Buffer
+		if err := json.Compact(&compacted, raw); err == nil {
+			return compacted.String()
+		}
+	}
+	return string(raw)
+}
+
+// Stream answers an export request, sending the response headers with the
+// first record so that errors raised before it can still be answered
+// normally
+type Stream struct {
+	c         echo.Context
+	name      string
+	mediaType string
+	writer    *Writer
+	started   bool
+}
+
+// NewStream negotiates the format of an export of records like sample,
+// named name in the attachment filename. It fails with ErrNotAcceptable
+// when the request accepts no export format.
+func NewStream(c echo.Context, name string, sample interface{}, fields fieldset.Set) (*Stream, error) {
+	mediaType, err := Negotiate(c.Request().Header.Get(echo.HeaderAccept))
+	if err != nil {
+		return nil, err
+	}
+	return &Stream{
+		c:         c,
+		name:      name,
+		mediaType: mediaType,
+		writer:    NewWriter(c.Response(), mediaType, sample, fields),
+	}, nil
+}
+
+// Started reports whether the response has been sent, after which errors
+// can only cut the export short
+func (s *Stream) Started() bool {
+	return s.started
+}
+
+// Write sends one record, starting the response if needed
+func (s *Stream) Write(record interface{}) error {
+	if err := s.start(); err != nil {
+		return err
+	}
+	return s.writer.Write(record)
+}
+
+// Flush sends the records written so far to the client
+func (s *Stream) Flush() error {
+	if err := s.writer.Flush(); err != nil {
+		return err
+	}
+	if s.started {
+		s.c.Response().Flush()
+	}
+	return nil
+}
+
+// Close finishes the export, starting the response if no record was written
+func (s *Stream) Close() error {
+	if err := s.start(); err != nil {
+		return err
+	}
+	return s.Flush()
+}
+
+// start sends the response headers and the CSV header row once
+func (s *Stream) start() error {
+	if s.started {
+		return nil
+	}
+	s.started = true
+
+	extension := "csv"
+	if s.mediaType == MIMEApplicationNDJSON {
+		extension = "ndjson"
+	}
+	header := s.c.Response().Header()
+	header.Set(echo.HeaderContentType, s.mediaType+"; charset=utf-8")
+	header.Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", s.name+"."+extension))
+	s.c.Response().WriteHeader(http.StatusOK)
+	return s.writer.WriteHeader()
+}
@@ -0,0 +1,122 @@
+package export
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"enricher-api-go/internal/fieldset"
+)
+
+type record struct {
+	ID       string            `json:"id"`
+	Name     string            `json:"name"`
+	Price    float64           `json:"price"`
+	Tags     []string          `json:"tags,omitempty"`
+	Weight   *struct{ Kg int } `json:"weight,omitempty"`
+	Internal string            `json:"-"`
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name    string
+		accept  string
+		want    string
+		wantErr bool
+	}{
+		{name: "no preference", accept: "", want: MIMETextCSV},
+		{name: "any", accept: "*/*", want: MIMETextCSV},
+		{name: "csv", accept: "text/csv", want: MIMETextCSV},
+		{name: "ndjson", accept: "application/x-ndjson", want: MIMEApplicationNDJSON},
+		{name: "ndjson alias", accept: "application/ndjson", want: MIMEApplicationNDJSON},
+		{name: "weighted", accept: "text/csv;q=0.5, application/x-ndjson", want: MIMEApplicationNDJSON},
+		{name: "browser", accept: "text/html,application/xhtml+xml,*/*;q=0.8", want: MIMETextCSV},
+		{name: "unsupported", accept: "application/json", wantErr: true},
+		{name: "refused", accept: "text/csv;q=0", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			got, err := Negotiate(tt.accept)
+
+			// Assert
+			if tt.wantErr {
+				if !errors.Is(err, ErrNotAcceptable) {
+					t.Errorf("Expected ErrNotAcceptable, got %q, %v", got, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("Expected %s, got %q, %v", tt.want, got, err)
+			}
+		})
+	}
+}
+
+func TestWriter(t *testing.T) {
+	records := []record{
+		{ID: "a", Name: "Lamp, desk", Price: 34.99, Tags: []string{"home"}, Weight: &struct{ Kg int }{Kg: 2}},
+		{ID: "b", Name: "Mouse", Price: 19.5},
+	}
+
+	tests := []struct {
+		name      string
+		mediaType string
+		fields    fieldset.Set
+		want      string
+	}{
+		{
+			name:      "csv",
+			mediaType: MIMETextCSV,
+			want: "id,name,price,tags,weight\n" +
+				"a,\"Lamp, desk\",34.99,\"[\"\"home\"\"]\",\"{\"\"Kg\"\":2}\"\n" +
+				"b,Mouse,19.5,,\n",
+		},
+		{
+			name:      "csv with fields",
+			mediaType: MIMETextCSV,
+			fields:    fieldset.Set{"price", "id"},
+			want:      "price,id\n34.99,a\n19.5,b\n",
+		},
+		{
+			name:      "ndjson",
+			mediaType: MIMEApplicationNDJSON,
+			want: `{"id":"a","name":"Lamp, desk","price":34.99,"tags":["home"],"weight":{"Kg":2}}` + "\n" +
+				`{"id":"b","name":"Mouse","price":19.5}` + "\n",
+		},
+		{
+			name:      "ndjson with fields",
+			mediaType: MIMEApplicationNDJSON,
+			fields:    fieldset.Set{"id"},
+			want:      `{"id":"a"}` + "\n" + `{"id":"b"}` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			var out bytes.Buffer
+			writer := NewWriter(&out, tt.mediaType, record{}, tt.fields)
+
+			// Act
+			err := writer.WriteHeader()
+			for _, r := range records {
+				if err == nil {
+					err = writer.Write(r)
+				}
+			}
+			if err == nil {
+				err = writer.Flush()
+			}
+
+			// Assert
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("Expected\n%s\ngot\n%s", tt.want, out.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+// Package featureflag switches optional behavior on and off while the server
+// runs.
+//
+// Flags start from the configuration and can be toggled through the admin
+// API; middleware behind a flag is wrapped with Gate, which checks the flag
+// on every request.
+//
+// Example usage:
+//
+//	flags := featureflag.New(map[string]bool{"serverTiming": cfg.Server.ServerTiming})
+//	e.Use(flags.Gate("serverTiming", servertiming.Middleware(true)))
+//
+//	flags.Set("serverTiming", true)
+package featureflag
+
+import (
+	"maps"
+	"sync"
+
+	"enricher-api-go/internal/apperr"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ErrUnknownFlag is returned when setting a flag that was never declared
+var ErrUnknownFlag = apperr.New(apperr.ErrNotFound, "unknown feature flag")
+
+// Flags holds the current value of every declared flag
+type Flags struct {
+	values map[string]bool
+	mutex  sync.RWMutex
+}
+
+// New declares the flags in values, with their initial settings
+func New(values map[string]bool) *Flags {
+	return &Flags{values: maps.Clone(values)}
+}
+
+// Enabled reports whether the flag name is on; undeclared flags are off
+func (f *Flags) Enabled(name string) bool {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	return f.values[name]
+}
+
+// Set turns the declared flag name on or off
+func (f *Flags) Set(name string, enabled bool) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if _, ok := f.values[name]; !ok {
+		return ErrUnknownFlag
+	}
+	f.values[name] = enabled
+	return nil
+}
+
+// Reset sets every flag named in values, such as after the configuration is
+// reloaded; flags not declared by New are ignored
+func (f *Flags) Reset(values map[string]bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	for name, enabled := range values {
+		if _, ok := f.values[name]; ok {
+			f.values[name] = enabled
+		}
+	}
+}
+
+// All returns a snapshot of every flag
+func (f *Flags) All() map[string]bool {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	return maps.Clone(f.values)
+}
+
+// Gate applies m only while the flag name is on
+func (f *Flags) Gate(name string, m echo.MiddlewareFunc) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		gated := m(next)
+		return func(c echo.Context) error {
+			if f.Enabled(name) {
+				return gated(c)
+			}
+			return next(c)
+		}
+	}
+}
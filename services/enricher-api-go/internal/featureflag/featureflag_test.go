@@ -0,0 +1,70 @@
+package featureflag
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestFlags_Set(t *testing.T) {
+	// Arrange
+	flags := New(map[string]bool{"serverTiming": false})
+
+	// Act
+	err := flags.Set("serverTiming", true)
+	unknownErr := flags.Set("darkMode", true)
+
+	// Assert
+	if err != nil || !flags.Enabled("serverTiming") {
+		t.Errorf("Expected serverTiming to be enabled, got %v", err)
+	}
+	if !errors.Is(unknownErr, ErrUnknownFlag) || flags.Enabled("darkMode") {
+		t.Errorf("Expected ErrUnknownFlag for an undeclared flag, got %v", unknownErr)
+	}
+}
+
+func TestFlags_Reset(t *testing.T) {
+	// Arrange
+	flags := New(map[string]bool{"serverTiming": true, "httpCache": true})
+
+	// Act
+	flags.Reset(map[string]bool{"serverTiming": false, "darkMode": true})
+
+	// Assert
+	expected := map[string]bool{"serverTiming": false, "httpCache": true}
+	all := flags.All()
+	if len(all) != len(expected) || all["serverTiming"] || !all["httpCache"] {
+		t.Errorf("Expected %v, got %v", expected, all)
+	}
+}
+
+func TestFlags_Gate(t *testing.T) {
+	// Arrange
+	flags := New(map[string]bool{"tagged": false})
+	e := echo.New()
+	e.Use(flags.Gate("tagged", func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Response().Header().Set("X-Tagged", "true")
+			return next(c)
+		}
+	}))
+	e.GET("/", func(c echo.Context) error { return c.NoContent(http.StatusNoContent) })
+	serve := func() string {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		return rec.Header().Get("X-Tagged")
+	}
+
+	// Act
+	off := serve()
+	flags.Set("tagged", true)
+	on := serve()
+
+	// Assert
+	if off != "" || on != "true" {
+		t.Errorf("Expected the middleware to run only while the flag is on, got %q off and %q on", off, on)
+	}
+}
@@ -0,0 +1,117 @@
+// Package fieldset trims JSON responses to the fields a client selects with
+// the fields query parameter, e.g. ?fields=productId,price,inStock, so
+// callers that need a few fields are not sent whole records.
+package fieldset
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// ErrUnknownField is returned when a selected field is not in the response
+var ErrUnknownField = errors.New("unknown field")
+
+// Set holds the top-level JSON fields selected for a response. An empty Set
+// selects every field.
+type Set []string
+
+// FromQuery reads the comma-separated fields parameter of query, rejecting
+// names that are not JSON fields of sample, a value of the response type.
+// Blank and repeated names are dropped.
+func FromQuery(query url.Values, sample interface{}) (Set, error) {
+	raw := query.Get("fields")
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+
+	known := Names(reflect.TypeOf(sample))
+	var set Set
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || slices.Contains(set, name) {
+			continue
+		}
+		if !slices.Contains(known, name) {
+			return nil, fmt.Errorf("%w: %s; fields must be among %s", ErrUnknownField, name, strings.Join(known, ", "))
+		}
+		set = append(set, name)
+	}
+	return set, nil
+}
+
+// Apply returns v, a struct or a slice of structs, as JSON objects holding
+// only the fields of s. Selected fields a value omits stay omitted. With an
+// empty set v is returned unchanged.
+func (s Set) Apply(v interface{}) (interface{}, error) {
+	if len(s) == 0 {
+		return v, nil
+	}
+
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode response: %w", err)
+	}
+
+	if bytes.HasPrefix(bytes.TrimSpace(encoded), []byte("[")) {
+		var objects []map[string]json.RawMessage
+		if err := json.Unmarshal(encoded, &objects); err != nil {
+			return nil, fmt.Errorf("failed to select fields: %w", err)
+		}
+		for _, object := range objects {
+			s.keep(object)
+		}
+		return objects, nil
+	}
+
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(encoded, &object); err != nil {
+		return nil, fmt.Errorf("failed to select fields: %w", err)
+	}
+	s.keep(object)
+	return object, nil
+}
+
+// keep deletes the fields of object that are not in s
+func (s Set) keep(object map[string]json.RawMessage) {
+	for name := range object {
+		if !slices.Contains(s, name) {
+			delete(object, name)
+		}
+	}
+}
+
+// Names returns the JSON field names of struct type t, in declaration order
+// and with embedded structs flattened the way encoding/json does
+func Names(t reflect.Type) []string {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || !field.IsExported() && !field.Anonymous {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			names = append(names, Names(field.Type)...)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		names = append(names, name)
+	}
+	return names
+}
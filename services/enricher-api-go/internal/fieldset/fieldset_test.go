@@ -0,0 +1,117 @@
+package fieldset
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+type base struct {
+	ID string `json:"id"`
+}
+
+type item struct {
+	base
+	Name     string  `json:"name"`
+	Price    float64 `json:"price"`
+	Note     string  `json:"note,omitempty"`
+	Secret   string  `json:"-"`
+	Untagged int
+}
+
+func TestNames(t *testing.T) {
+	// Act
+	names := Names(reflect.TypeOf(&item{}))
+
+	// Assert
+	want := []string{"id", "name", "price", "note", "Untagged"}
+	if !slices.Equal(names, want) {
+		t.Errorf("Expected %v, got %v", want, names)
+	}
+}
+
+func TestFromQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    Set
+		wantErr bool
+	}{
+		{name: "absent", raw: ""},
+		{name: "several fields", raw: "price, id,price,", want: Set{"price", "id"}},
+		{name: "unknown field", raw: "id,cost", wantErr: true},
+		{name: "ignored field", raw: "Secret", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			set, err := FromQuery(url.Values{"fields": {tt.raw}}, item{})
+
+			// Assert
+			if tt.wantErr {
+				if !errors.Is(err, ErrUnknownField) {
+					t.Errorf("Expected ErrUnknownField, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if !slices.Equal(set, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, set)
+			}
+		})
+	}
+}
+
+func TestSet_Apply(t *testing.T) {
+	// Arrange
+	items := []item{
+		{base: base{ID: "a"}, Name: "Laptop", Price: 999.99, Note: "refurbished"},
+		{base: base{ID: "b"}, Name: "Mouse", Price: 19.99},
+	}
+
+	// Act
+	one, err := Set{"id", "note"}.Apply(items[0])
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	many, err := Set{"id", "note"}.Apply(items)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	all, err := Set(nil).Apply(items)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Assert
+	assertJSON(t, one, `{"id":"a","note":"refurbished"}`)
+	assertJSON(t, many, `[{"id":"a","note":"refurbished"},{"id":"b"}]`)
+	if !reflect.DeepEqual(all, items) {
+		t.Errorf("Expected an empty set to return the value unchanged, got %v", all)
+	}
+}
+
+// assertJSON checks that v encodes to the same JSON as want
+func assertJSON(t *testing.T, v interface{}, want string) {
+	t.Helper()
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var got, expected interface{}
+	if err := json.Unmarshal(encoded, &got); err != nil {
+		t.Fatalf("Expected valid JSON, got %v", err)
+	}
+	if err := json.Unmarshal([]byte(want), &expected); err != nil {
+		t.Fatalf("Expected valid JSON, got %v", err)
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %s, got %s", want, encoded)
+	}
+}
@@ -0,0 +1,125 @@
+// Package health serves the liveness and readiness probes.
+//
+// Liveness only says the process is serving HTTP, so an orchestrator restarts
+// it when it hangs. Readiness runs the registered dependency checks, so traffic
+// is held back while storage, consumers or migrations are not usable.
+package health
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// DefaultTimeout bounds each readiness check
+const DefaultTimeout = 2 * time.Second
+
+// Check reports whether a dependency is usable, giving up when ctx is done
+type Check func(ctx context.Context) error
+
+// Status of a probe or dependency
+const (
+	StatusUp       = "up"
+	StatusDown     = "down"
+	StatusReady    = "ready"
+	StatusNotReady = "not ready"
+)
+
+// Dependency is the outcome of one readiness check
+type Dependency struct {
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration int64  `json:"durationMs"`
+}
+
+// Report is the body of the readiness probe
+type Report struct {
+	Status       string       `json:"status"`
+	Dependencies []Dependency `json:"dependencies"`
+}
+
+// Readiness collects named checks; the zero value has none and is always ready
+type Readiness struct {
+	// Timeout bounds each check; DefaultTimeout if zero
+	Timeout time.Duration
+
+	mu     sync.Mutex
+	names  []string
+	checks []Check
+}
+
+// Register adds a named check to run on every readiness probe
+func (r *Readiness) Register(name string, check Check) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.names = append(r.names, name)
+	r.checks = append(r.checks, check)
+}
+
+// Run executes every check concurrently and reports them in registration
+// order. The report is ready only if every check passed.
+func (r *Readiness) Run(ctx context.Context) Report {
+	r.mu.Lock()
+	names, checks := r.names, r.checks
+	r.mu.Unlock()
+
+	timeout := r.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	report := Report{Status: StatusReady, Dependencies: make([]Dependency, len(checks))}
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := check(checkCtx)
+			dependency := Dependency{Name: names[i], Status: StatusUp, Duration: time.Since(start).Milliseconds()}
+			if err != nil {
+				dependency.Status, dependency.Error = StatusDown, err.Error()
+			}
+			report.Dependencies[i] = dependency
+		}(i, check)
+	}
+	wg.Wait()
+
+	for _, dependency := range report.Dependencies {
+		if dependency.Status != StatusUp {
+			report.Status = StatusNotReady
+		}
+	}
+	return report
+}
+
+// Handler serves the readiness probe, answering 503 while any check fails
+func (r *Readiness) Handler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		report := r.Run(c.Request().Context())
+		code := http.StatusOK
+		if report.Status != StatusReady {
+			code = http.StatusServiceUnavailable
+		}
+		return c.JSON(code, report)
+	}
+}
+
+// LiveHandler serves the liveness probe, which passes whenever the process
+// can answer HTTP requests
+func LiveHandler(service string) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		return c.JSON(http.StatusOK, map[string]string{
+			"status":  StatusUp,
+			"service": service,
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+func serveReadiness(t *testing.T, readiness *Readiness) (int, Report) {
+	t.Helper()
+	e := echo.New()
+	e.GET("/health/ready", readiness.Handler())
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+
+	var report Report
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatalf("Failed to decode readiness report: %v", err)
+	}
+	return rec.Code, report
+}
+
+func TestReadiness_AllUp(t *testing.T) {
+	// Arrange
+	var readiness Readiness
+	readiness.Register("postgres", func(context.Context) error { return nil })
+	readiness.Register("migrations", func(context.Context) error { return nil })
+
+	// Act
+	code, report := serveReadiness(t, &readiness)
+
+	// Assert
+	if code != http.StatusOK || report.Status != StatusReady {
+		t.Errorf("Expected 200 and ready, got %d and %q", code, report.Status)
+	}
+	if len(report.Dependencies) != 2 || report.Dependencies[0].Name != "postgres" || report.Dependencies[1].Name != "migrations" {
+		t.Errorf("Expected dependencies in registration order, got %+v", report.Dependencies)
+	}
+}
+
+func TestReadiness_FailingAndSlowChecks(t *testing.T) {
+	// Arrange
+	readiness := Readiness{Timeout: 10 * time.Millisecond}
+	readiness.Register("postgres", func(context.Context) error { return errors.New("connection refused") })
+	readiness.Register("kafka", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	readiness.Register("migrations", func(context.Context) error { return nil })
+
+	// Act
+	code, report := serveReadiness(t, &readiness)
+
+	// Assert
+	if code != http.StatusServiceUnavailable || report.Status != StatusNotReady {
+		t.Errorf("Expected 503 and not ready, got %d and %q", code, report.Status)
+	}
+	expected := []Dependency{
+		{Name: "postgres", Status: StatusDown, Error: "connection refused"},
+		{Name: "kafka", Status: StatusDown, Error: context.DeadlineExceeded.Error()},
+		{Name: "migrations", Status: StatusUp},
+	}
+	for i, want := range expected {
+		got := report.Dependencies[i]
+		if got.Name != want.Name || got.Status != want.Status || got.Error != want.Error {
+			t.Errorf("Expected %+v, got %+v", want, got)
+		}
+	}
+}
+
+func TestReadiness_NoChecks(t *testing.T) {
+	// Act
+	code, report := serveReadiness(t, &Readiness{})
+
+	// Assert
+	if code != http.StatusOK || len(report.Dependencies) != 0 {
+		t.Errorf("Expected ready with no dependencies, got %d and %+v", code, report)
+	}
+}
@@ -0,0 +1,176 @@
+// Package httpcache lets clients revalidate GET responses instead of
+// downloading them again.
+//
+// The middleware buffers each successful GET response and tags it with a
+// strong ETag, a hash of its body, and a Cache-Control header. A request whose
+// If-None-Match names that ETag, or whose If-Modified-Since is no earlier than
+// the Last-Modified time the handler set with SetLastModified, is answered
+// 304 Not Modified without a body.
+//
+// Example usage:
+//
+//	e.Use(httpcache.Middleware(0, "/v1/ws/", "/health"))
+//
+//	func (h *Handler) GetProduct(c echo.Context) error {
+//		...
+//		httpcache.SetLastModified(c, product.UpdatedAt)
+//		return c.JSON(http.StatusOK, product.ToResponse())
+//	}
+package httpcache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Header names not declared by Echo
+const (
+	HeaderETag        = "ETag"
+	HeaderIfNoneMatch = "If-None-Match"
+)
+
+// Middleware tags successful GET responses with an ETag and a Cache-Control
+// header letting clients reuse them for maxAge, and answers conditional
+// requests for unchanged responses with 304. Requests whose path starts with
+// one of exemptPrefixes, such as streams, are passed through untouched.
+func Middleware(maxAge time.Duration, exemptPrefixes ...string) echo.MiddlewareFunc {
+	// Responses depend on the caller's credentials, so shared caches must not
+	// keep them
+	cacheControl := "private, no-cache"
+	if maxAge > 0 {
+		cacheControl = fmt.Sprintf("private, max-age=%d", int(maxAge.Seconds()))
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if c.Request().Method != http.MethodGet {
+				return next(c)
+			}
+			path := c.Request().URL.Path
+			for _, prefix := range exemptPrefixes {
+				if strings.HasPrefix(path, prefix) {
+					return next(c)
+				}
+			}
+
+			res := c.Response()
+			w := &writer{ResponseWriter: res.Writer}
+			res.Writer = w
+			err := next(c)
+			res.Writer = w.ResponseWriter
+
+			if w.status == 0 {
+				// Nothing was written; the error handler answers instead
+				return err
+			}
+			if w.status != http.StatusOK {
+				w.flush(w.status)
+				return err
+			}
+
+			header := res.Header()
+			if header.Get(echo.HeaderCacheControl) == "" {
+				header.Set(echo.HeaderCacheControl, cacheControl)
+			}
+			if header.Get(HeaderETag) == "" {
+				header.Set(HeaderETag, ETag(w.body.Bytes()))
+			}
+
+			if notModified(c.Request(), header) {
+				header.Del(echo.HeaderContentType)
+				header.Del(echo.HeaderContentLength)
+				w.body.Reset()
+				// Report the status the client received
+				res.Status = http.StatusNotModified
+				w.flush(http.StatusNotModified)
+				return err
+			}
+			w.flush(http.StatusOK)
+			return err
+		}
+	}
+}
+
+// ETag returns the strong entity tag of a response body
+func ETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// SetLastModified records when the resource in the response last changed,
+// so clients can revalidate it with If-Modified-Since. A zero time is
+// ignored.
+func SetLastModified(c echo.Context, modified time.Time) {
+	if modified.IsZero() {
+		return
+	}
+	c.Response().Header().Set(echo.HeaderLastModified, modified.UTC().Format(http.TimeFormat))
+}
+
+// notModified reports whether the conditional headers of req show the client
+// already has the response described by header. If-None-Match takes
+// precedence over If-Modified-Since, as RFC 9110 requires.
+func notModified(req *http.Request, header http.Header) bool {
+	if match := req.Header.Get(HeaderIfNoneMatch); match != "" {
+		etag := strings.TrimPrefix(header.Get(HeaderETag), "W/")
+		for _, candidate := range strings.Split(match, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+				return true
+			}
+		}
+		return false
+	}
+
+	since, err := http.ParseTime(req.Header.Get(echo.HeaderIfModifiedSince))
+	if err != nil {
+		return false
+	}
+	modified, err := http.ParseTime(header.Get(echo.HeaderLastModified))
+	if err != nil {
+		return false
+	}
+	return !modified.After(since)
+}
+
+// writer holds back a response until its ETag is known
+type writer struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+// WriteHeader records status, to be written once the body is complete
+func (w *writer) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+// Write buffers b
+func (w *writer) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(b)
+}
+
+// flush writes status and the buffered body to the wrapped writer
+func (w *writer) flush(status int) {
+	w.ResponseWriter.WriteHeader(status)
+	if w.body.Len() > 0 {
+		w.ResponseWriter.Write(w.body.Bytes())
+	}
+}
+
+// Unwrap returns the wrapped writer, for http.ResponseController
+func (w *writer) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
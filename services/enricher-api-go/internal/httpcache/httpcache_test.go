@@ -0,0 +1,110 @@
+package httpcache
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// modified is when the test product last changed
+var modified = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// newTestServer serves /products/1, last modified at modified, /missing,
+// which answers 404, and /stream, exempt from caching
+func newTestServer(maxAge time.Duration) *echo.Echo {
+	product := func(c echo.Context) error {
+		SetLastModified(c, modified)
+		return c.JSON(http.StatusOK, map[string]string{"productId": "product-1"})
+	}
+
+	e := echo.New()
+	e.Use(Middleware(maxAge, "/stream"))
+	e.GET("/products/1", product)
+	e.GET("/stream", product)
+	e.GET("/missing", func(c echo.Context) error {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "not found"})
+	})
+	return e
+}
+
+func TestMiddleware(t *testing.T) {
+	etag := ETag([]byte(`{"productId":"product-1"}` + "\n"))
+
+	tests := []struct {
+		name         string
+		path         string
+		header       map[string]string
+		expectedCode int
+		expectedETag string
+		expectedBody bool
+	}{
+		{name: "unconditional", path: "/products/1", expectedCode: http.StatusOK, expectedETag: etag, expectedBody: true},
+		{name: "matching etag", path: "/products/1", header: map[string]string{HeaderIfNoneMatch: etag}, expectedCode: http.StatusNotModified, expectedETag: etag},
+		{name: "weak etag in list", path: "/products/1", header: map[string]string{HeaderIfNoneMatch: `"stale", W/` + etag}, expectedCode: http.StatusNotModified, expectedETag: etag},
+		{name: "stale etag", path: "/products/1", header: map[string]string{HeaderIfNoneMatch: `"stale"`}, expectedCode: http.StatusOK, expectedETag: etag, expectedBody: true},
+		{name: "stale etag overrides date", path: "/products/1", header: map[string]string{HeaderIfNoneMatch: `"stale"`, echo.HeaderIfModifiedSince: modified.Format(http.TimeFormat)}, expectedCode: http.StatusOK, expectedETag: etag, expectedBody: true},
+		{name: "unmodified since", path: "/products/1", header: map[string]string{echo.HeaderIfModifiedSince: modified.Format(http.TimeFormat)}, expectedCode: http.StatusNotModified, expectedETag: etag},
+		{name: "modified since", path: "/products/1", header: map[string]string{echo.HeaderIfModifiedSince: modified.Add(-time.Hour).Format(http.TimeFormat)}, expectedCode: http.StatusOK, expectedETag: etag, expectedBody: true},
+		{name: "error response", path: "/missing", header: map[string]string{HeaderIfNoneMatch: "*"}, expectedCode: http.StatusNotFound, expectedBody: true},
+		{name: "exempt path", path: "/stream", header: map[string]string{HeaderIfNoneMatch: etag}, expectedCode: http.StatusOK, expectedBody: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			e := newTestServer(0)
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			for name, value := range tt.header {
+				req.Header.Set(name, value)
+			}
+			rec := httptest.NewRecorder()
+
+			// Act
+			e.ServeHTTP(rec, req)
+
+			// Assert
+			if rec.Code != tt.expectedCode {
+				t.Errorf("Expected status %d, got %d", tt.expectedCode, rec.Code)
+			}
+			if got := rec.Header().Get(HeaderETag); got != tt.expectedETag {
+				t.Errorf("Expected ETag %q, got %q", tt.expectedETag, got)
+			}
+			if hasBody := rec.Body.Len() > 0; hasBody != tt.expectedBody {
+				t.Errorf("Expected body %t, got %q", tt.expectedBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestMiddleware_CacheControl(t *testing.T) {
+	tests := []struct {
+		name     string
+		maxAge   time.Duration
+		expected string
+	}{
+		{name: "revalidate", maxAge: 0, expected: "private, no-cache"},
+		{name: "max age", maxAge: time.Minute, expected: "private, max-age=60"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			e := newTestServer(tt.maxAge)
+			rec := httptest.NewRecorder()
+
+			// Act
+			e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/products/1", nil))
+
+			// Assert
+			if got := rec.Header().Get(echo.HeaderCacheControl); got != tt.expected {
+				t.Errorf("Expected Cache-Control %q, got %q", tt.expected, got)
+			}
+			if got := rec.Header().Get(echo.HeaderLastModified); got != "Sun, 01 Mar 2026 12:00:00 GMT" {
+				t.Errorf("Expected Last-Modified of the product, got %q", got)
+			}
+		})
+	}
+}
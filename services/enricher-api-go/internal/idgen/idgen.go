@@ -0,0 +1,113 @@
+// Package idgen provides identifier generators for entities created through
+// the API.
+//
+// Two strategies are available: UUIDGenerator produces random, collision-free
+// identifiers such as "product-0b6a...", and SequentialGenerator produces
+// human-readable, monotonically increasing identifiers such as
+// "product-000123" backed by a Counter.
+//
+// Example usage:
+//
+//	gen := idgen.NewSequentialGenerator(idgen.NewInMemoryCounter())
+//	id, err := gen.NewID("product") // "product-000001"
+package idgen
+
+import (
+	"crypto/rand"
+	"fmt"
+	"sync"
+)
+
+// Strategy names accepted by New.
+const (
+	StrategyUUID       = "uuid"
+	StrategySequential = "sequential"
+)
+
+// Generator produces unique identifiers with a given prefix.
+type Generator interface {
+	// NewID returns a new identifier of the form "<prefix>-<suffix>".
+	NewID(prefix string) (string, error)
+}
+
+// New returns the generator for a strategy name; sequential generators draw
+// from counter, which must outlive the stored IDs, so that a restart does
+// not hand out IDs already taken.
+func New(strategy string, counter Counter) (Generator, error) {
+	switch strategy {
+	case "", StrategyUUID:
+		return UUIDGenerator{}, nil
+	case StrategySequential:
+		if counter == nil {
+			return nil, fmt.Errorf("the %s ID generator needs a counter", StrategySequential)
+		}
+		return NewSequentialGenerator(counter), nil
+	default:
+		return nil, fmt.Errorf("unknown ID generator %q (expected %s or %s)", strategy, StrategyUUID, StrategySequential)
+	}
+}
+
+// UUIDGenerator produces identifiers suffixed with a random version 4 UUID.
+type UUIDGenerator struct{}
+
+// NewID returns "<prefix>-<uuid>".
+func (UUIDGenerator) NewID(prefix string) (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("failed to generate UUID: %w", err)
+	}
+
+	b[6] = (b[6] & 0x0f) | 0x40 // version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // RFC 4122 variant
+
+	return fmt.Sprintf("%s-%x-%x-%x-%x-%x", prefix, b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
+
+// Counter hands out increasing values per named sequence. Implementations
+// must be safe for concurrent use and never return the same value twice for
+// a sequence.
+type Counter interface {
+	// Next returns the next value of the named sequence, starting at 1.
+	Next(sequence string) (int64, error)
+}
+
+// InMemoryCounter is a Counter kept in process memory; values restart at 1
+// when the process restarts.
+type InMemoryCounter struct {
+	mutex  sync.Mutex
+	values map[string]int64
+}
+
+// NewInMemoryCounter creates an empty in-memory counter.
+func NewInMemoryCounter() *InMemoryCounter {
+	return &InMemoryCounter{values: make(map[string]int64)}
+}
+
+// Next returns the next value of the named sequence.
+func (c *InMemoryCounter) Next(sequence string) (int64, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.values[sequence]++
+	return c.values[sequence], nil
+}
+
+// SequentialGenerator produces identifiers from a Counter, using the prefix
+// as the sequence name, zero-padded to at least six digits.
+type SequentialGenerator struct {
+	counter Counter
+}
+
+// NewSequentialGenerator creates a generator backed by counter.
+func NewSequentialGenerator(counter Counter) *SequentialGenerator {
+	return &SequentialGenerator{counter: counter}
+}
+
+// NewID returns "<prefix>-<counter value>", e.g. "product-000123".
+func (g *SequentialGenerator) NewID(prefix string) (string, error) {
+	value, err := g.counter.Next(prefix)
+	if err != nil {
+		return "", fmt.Errorf("failed to advance %s sequence: %w", prefix, err)
+	}
+	return fmt.Sprintf("%s-%06d", prefix, value), nil
+}
@@ -0,0 +1,106 @@
+package idgen
+
+import (
+	"database/sql"
+	"os"
+	"regexp"
+	"testing"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+func TestUUIDGenerator_NewID(t *testing.T) {
+	// Arrange
+	gen := UUIDGenerator{}
+	pattern := regexp.MustCompile(`^product-[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+	// Act
+	first, err := gen.NewID("product")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	second, err := gen.NewID("product")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Assert
+	if !pattern.MatchString(first) {
+		t.Errorf("Expected a prefixed version 4 UUID, got %s", first)
+	}
+
+	if first == second {
+		t.Errorf("Expected distinct IDs, got %s twice", first)
+	}
+}
+
+func TestSequentialGenerator_NewID(t *testing.T) {
+	// Arrange
+	gen := NewSequentialGenerator(NewInMemoryCounter())
+
+	// Act
+	ids := make([]string, 0, 3)
+	for _, prefix := range []string{"product", "product", "customer"} {
+		id, err := gen.NewID(prefix)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		ids = append(ids, id)
+	}
+
+	// Assert
+	expected := []string{"product-000001", "product-000002", "customer-000001"}
+	for i, id := range ids {
+		if id != expected[i] {
+			t.Errorf("Expected %s, got %s", expected[i], id)
+		}
+	}
+}
+
+func TestNew_UnknownStrategy(t *testing.T) {
+	if _, err := New("snowflake", NewInMemoryCounter()); err == nil {
+		t.Fatal("Expected error for unknown strategy, got nil")
+	}
+}
+
+func TestNew_SequentialWithoutCounter(t *testing.T) {
+	if _, err := New(StrategySequential, nil); err == nil {
+		t.Fatal("Expected error for a sequential generator without a counter, got nil")
+	}
+}
+
+// TestPostgresCounter runs against the database in POSTGRES_TEST_DSN and is
+// skipped when it is not set.
+func TestPostgresCounter(t *testing.T) {
+	dsn := os.Getenv("POSTGRES_TEST_DSN")
+	if dsn == "" {
+		t.Skip("POSTGRES_TEST_DSN not set")
+	}
+
+	// Arrange
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if _, err := db.Exec(`DROP SEQUENCE IF EXISTS id_counter_test_seq`); err != nil {
+		t.Fatalf("Failed to reset sequence: %v", err)
+	}
+
+	// Act
+	first, firstErr := NewPostgresCounter(db).Next("counter_test")
+	// A new counter stands in for a restarted server
+	second, secondErr := NewPostgresCounter(db).Next("counter_test")
+	_, invalidErr := NewPostgresCounter(db).Next("counter; DROP TABLE products")
+
+	// Assert
+	if firstErr != nil || secondErr != nil {
+		t.Fatalf("Expected no errors, got %v and %v", firstErr, secondErr)
+	}
+	if first != 1 || second != 2 {
+		t.Errorf("Expected 1 then 2 across counters, got %d and %d", first, second)
+	}
+	if invalidErr == nil {
+		t.Error("Expected an error for an unsafe sequence name")
+	}
+}
@@ -0,0 +1,52 @@
+package idgen
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"sync"
+
+	"enricher-api-go/internal/postgres"
+)
+
+// sequenceName restricts sequence names to those safe to embed in SQL
+var sequenceName = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// PostgresCounter is a Counter backed by one PostgreSQL sequence per named
+// sequence, created on first use. Values survive restarts and are shared by
+// every server using the database.
+type PostgresCounter struct {
+	db *sql.DB
+	// created holds the sequences known to exist
+	created sync.Map
+}
+
+// NewPostgresCounter creates a counter keeping its sequences in db.
+func NewPostgresCounter(db *sql.DB) *PostgresCounter {
+	return &PostgresCounter{db: db}
+}
+
+// Next returns the next value of the named sequence, advancing it with
+// nextval so concurrent callers never get the same value.
+func (c *PostgresCounter) Next(sequence string) (int64, error) {
+	if !sequenceName.MatchString(sequence) {
+		return 0, fmt.Errorf("invalid sequence name %q", sequence)
+	}
+	name := "id_" + sequence + "_seq"
+
+	if _, ok := c.created.Load(name); !ok {
+		// Concurrent creations of the same sequence can still collide on the
+		// catalog, which leaves it created all the same
+		_, err := c.db.Exec(`CREATE SEQUENCE IF NOT EXISTS ` + name)
+		if err != nil && !postgres.IsUniqueViolation(err) {
+			return 0, fmt.Errorf("failed to create sequence %s: %w", name, err)
+		}
+		c.created.Store(name, struct{}{})
+	}
+
+	var value int64
+	if err := c.db.QueryRow(`SELECT nextval($1::text::regclass)`, name).Scan(&value); err != nil {
+		return 0, fmt.Errorf("failed to advance sequence %s: %w", name, err)
+	}
+	return value, nil
+}
@@ -0,0 +1,203 @@
+package inventory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"enricher-api-go/internal/logging"
+	"enricher-api-go/internal/problem"
+	"enricher-api-go/internal/product"
+
+	"github.com/labstack/echo/v4"
+	"golang.org/x/net/websocket"
+)
+
+// MaxProducts bounds the products one stream can follow
+const MaxProducts = 100
+
+// Message types sent over a stream
+const (
+	// MessageSnapshot carries a product's stock level when the stream opens
+	MessageSnapshot = "snapshot"
+	// MessageStockChanged carries a product's stock level after it changed
+	MessageStockChanged = "stock_changed"
+	// MessageHeartbeat is sent on an idle stream so proxies keep it open
+	MessageHeartbeat = "heartbeat"
+)
+
+const (
+	// heartbeatInterval is how long a stream may stay idle before a heartbeat
+	heartbeatInterval = 30 * time.Second
+	// writeTimeout bounds sending one message before the client is dropped
+	writeTimeout = 10 * time.Second
+	// maxClientMessage bounds the messages read from clients, which are ignored
+	maxClientMessage = 1 << 10
+)
+
+// Message is one JSON text frame of a stream: its type and, except for
+// heartbeats, a product's availability.
+//
+// Example message:
+//
+//	{"type": "stock_changed", "productId": "product-12345", "quantity": 0, "inStock": false, "orderable": false}
+type Message struct {
+	// Type is snapshot, stock_changed or heartbeat
+	Type string `json:"type"`
+	*product.Availability
+}
+
+// Handler streams stock levels over WebSocket connections
+type Handler struct {
+	hub      *Hub
+	products product.Service
+}
+
+// NewHandler creates a new inventory handler streaming the changes
+// reported to hub, starting from the levels in products
+func NewHandler(hub *Hub, products product.Service) *Handler {
+	return &Handler{
+		hub:      hub,
+		products: products,
+	}
+}
+
+// Stream handles GET /v1/ws/inventory
+//
+// The request upgrades to a WebSocket that first sends a snapshot of each
+// product in productIds, a comma-separated list, then a stock_changed
+// message whenever one's quantity changes. Changes made while a client is
+// slow to read are merged, so it always catches up on the latest level.
+// Messages sent by the client are ignored.
+//
+// Example request:
+//
+//	GET /v1/ws/inventory?productIds=product-12345,product-67890
+//	Connection: Upgrade
+//	Upgrade: websocket
+func (h *Handler) Stream(c echo.Context) error {
+	if !c.IsWebSocket() {
+		return problem.Write(c, http.StatusUpgradeRequired, "WebSocket upgrade required")
+	}
+
+	productIDs, err := parseProductIDs(c.QueryParam("productIds"))
+	if err != nil {
+		return problem.Write(c, http.StatusBadRequest, err.Error())
+	}
+
+	// Subscribe before reading the snapshot so no change in between is missed
+	sub, err := h.hub.Subscribe(productIDs)
+	if err != nil {
+		return problem.Write(c, http.StatusServiceUnavailable, "Inventory stream is shutting down")
+	}
+
+	snapshot := make([]*product.Availability, len(productIDs))
+	for i, productID := range productIDs {
+		snapshot[i], err = h.products.CheckAvailability(c.Request().Context(), productID)
+		if err != nil {
+			sub.Close()
+			return availabilityError(c, productID, err)
+		}
+	}
+
+	ctx := context.WithoutCancel(c.Request().Context())
+	server := websocket.Server{Handler: func(ws *websocket.Conn) {
+		h.stream(ctx, ws, sub, snapshot)
+	}}
+	server.ServeHTTP(c.Response(), c.Request())
+	return nil
+}
+
+// stream sends snapshot and then the changes of sub until the client
+// disconnects, a send fails or the hub is closed
+func (h *Handler) stream(ctx context.Context, ws *websocket.Conn, sub *Subscription, snapshot []*product.Availability) {
+	defer sub.Close()
+	logger := logging.FromContext(ctx)
+	logger.Info("Inventory stream opened", "products", len(snapshot))
+
+	// The connection outlives the server's request deadlines
+	ws.SetDeadline(time.Time{})
+	ws.MaxPayloadBytes = maxClientMessage
+
+	disconnected := make(chan struct{})
+	go func() {
+		defer close(disconnected)
+		var discard []byte
+		for websocket.Message.Receive(ws, &discard) == nil {
+		}
+	}()
+
+	send := func(messageType string, availability *product.Availability) bool {
+		ws.SetWriteDeadline(time.Now().Add(writeTimeout))
+		if err := websocket.JSON.Send(ws, Message{Type: messageType, Availability: availability}); err != nil {
+			logger.Info("Inventory stream dropped", "error", err)
+			return false
+		}
+		return true
+	}
+
+	for _, availability := range snapshot {
+		if !send(MessageSnapshot, availability) {
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-disconnected:
+			logger.Info("Inventory stream closed by client")
+			return
+		case <-sub.Done():
+			logger.Info("Inventory stream closed by server")
+			return
+		case <-heartbeat.C:
+			if !send(MessageHeartbeat, nil) {
+				return
+			}
+		case <-sub.Ready():
+			for _, availability := range sub.Next() {
+				if !send(MessageStockChanged, availability) {
+					return
+				}
+			}
+			heartbeat.Reset(heartbeatInterval)
+		}
+	}
+}
+
+// parseProductIDs splits a comma-separated list of product IDs, dropping
+// blanks and duplicates
+func parseProductIDs(raw string) ([]string, error) {
+	seen := make(map[string]bool)
+	var productIDs []string
+	for _, productID := range strings.Split(raw, ",") {
+		productID = strings.TrimSpace(productID)
+		if productID == "" || seen[productID] {
+			continue
+		}
+		seen[productID] = true
+		productIDs = append(productIDs, productID)
+	}
+
+	switch {
+	case len(productIDs) == 0:
+		return nil, errors.New("productIds is required")
+	case len(productIDs) > MaxProducts:
+		return nil, fmt.Errorf("productIds must list at most %d products, got %d", MaxProducts, len(productIDs))
+	}
+	return productIDs, nil
+}
+
+// availabilityError answers a failed snapshot: 404 for an unknown product
+// and a server error otherwise
+func availabilityError(c echo.Context, productID string, err error) error {
+	if errors.Is(err, product.ErrProductNotFound) {
+		return problem.Write(c, http.StatusNotFound, "Product not found: "+productID)
+	}
+	return problem.ServerError(c, err)
+}
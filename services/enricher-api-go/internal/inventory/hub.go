@@ -0,0 +1,165 @@
+// Package inventory streams product stock levels to subscribers as they
+// change, so dashboards can follow them without polling.
+//
+// The product service reports each change to a Hub, which fans it out to the
+// subscriptions of that product. Changes are only seen by the replica that
+// made them.
+package inventory
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"enricher-api-go/internal/product"
+)
+
+// ErrClosed is returned when subscribing to a hub that has been closed
+var ErrClosed = errors.New("inventory hub closed")
+
+// Hub fans stock-level changes out to the subscriptions of each product.
+// It implements product.StockObserver.
+type Hub struct {
+	mu            sync.Mutex
+	subscriptions map[string]map[*Subscription]struct{}
+	closed        bool
+}
+
+// NewHub creates a hub without subscriptions
+func NewHub() *Hub {
+	return &Hub{
+		subscriptions: make(map[string]map[*Subscription]struct{}),
+	}
+}
+
+// Subscribe returns a subscription to the stock levels of productIDs,
+// failing with ErrClosed once the hub is closed
+func (h *Hub) Subscribe(productIDs []string) (*Subscription, error) {
+	sub := &Subscription{
+		hub:        h,
+		productIDs: productIDs,
+		pending:    make(map[string]*product.Availability),
+		ready:      make(chan struct{}, 1),
+		done:       make(chan struct{}),
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return nil, ErrClosed
+	}
+	for _, productID := range productIDs {
+		if h.subscriptions[productID] == nil {
+			h.subscriptions[productID] = make(map[*Subscription]struct{})
+		}
+		h.subscriptions[productID][sub] = struct{}{}
+	}
+	return sub, nil
+}
+
+// StockChanged queues availability for every subscription to its product.
+// It never blocks on a slow subscriber: a change not yet taken replaces the
+// earlier one of the same product.
+func (h *Hub) StockChanged(_ context.Context, availability *product.Availability) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for sub := range h.subscriptions[availability.ProductID] {
+		sub.queue(availability)
+	}
+}
+
+// Close ends every subscription and rejects new ones
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return
+	}
+	h.closed = true
+	for _, subs := range h.subscriptions {
+		for sub := range subs {
+			sub.end()
+		}
+	}
+	h.subscriptions = make(map[string]map[*Subscription]struct{})
+}
+
+// unsubscribe removes sub from the products it follows
+func (h *Hub) unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, productID := range sub.productIDs {
+		delete(h.subscriptions[productID], sub)
+		if len(h.subscriptions[productID]) == 0 {
+			delete(h.subscriptions, productID)
+		}
+	}
+}
+
+// Subscription receives the stock-level changes of a set of products
+type Subscription struct {
+	hub        *Hub
+	productIDs []string
+
+	mu      sync.Mutex
+	pending map[string]*product.Availability
+	order   []string
+	ready   chan struct{}
+	done    chan struct{}
+	ended   bool
+}
+
+// Ready is signalled when changes are waiting to be taken with Next
+func (s *Subscription) Ready() <-chan struct{} {
+	return s.ready
+}
+
+// Done is closed once the subscription has ended
+func (s *Subscription) Done() <-chan struct{} {
+	return s.done
+}
+
+// Next takes the latest availability of each product that changed since the
+// last call, in the order the products first changed
+func (s *Subscription) Next() []*product.Availability {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	changes := make([]*product.Availability, len(s.order))
+	for i, productID := range s.order {
+		changes[i] = s.pending[productID]
+	}
+	s.pending, s.order = make(map[string]*product.Availability), nil
+	return changes
+}
+
+// Close ends the subscription; calling it again is harmless
+func (s *Subscription) Close() {
+	s.hub.unsubscribe(s)
+	s.end()
+}
+
+// queue records availability as the latest change of its product and
+// signals Ready
+func (s *Subscription) queue(availability *product.Availability) {
+	s.mu.Lock()
+	if _, ok := s.pending[availability.ProductID]; !ok {
+		s.order = append(s.order, availability.ProductID)
+	}
+	s.pending[availability.ProductID] = availability
+	s.mu.Unlock()
+
+	select {
+	case s.ready <- struct{}{}:
+	default:
+	}
+}
+
+// end closes Done once
+func (s *Subscription) end() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.ended {
+		s.ended = true
+		close(s.done)
+	}
+}
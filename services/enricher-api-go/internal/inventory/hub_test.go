@@ -0,0 +1,107 @@
+package inventory
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"enricher-api-go/internal/product"
+)
+
+// level returns the availability of productID with quantity units
+func level(productID string, quantity int) *product.Availability {
+	return &product.Availability{ProductID: productID, Quantity: quantity, InStock: quantity > 0, Orderable: quantity > 0}
+}
+
+// ready reports whether sub has changes waiting
+func ready(sub *Subscription) bool {
+	select {
+	case <-sub.Ready():
+		return true
+	default:
+		return false
+	}
+}
+
+func TestHub_StockChanged(t *testing.T) {
+	// Arrange
+	hub := NewHub()
+	ctx := context.Background()
+	laptops, err := hub.Subscribe([]string{"product-123", "product-456"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	mice, err := hub.Subscribe([]string{"product-789"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Act
+	hub.StockChanged(ctx, level("product-456", 3))
+	hub.StockChanged(ctx, level("product-123", 10))
+	hub.StockChanged(ctx, level("product-456", 0))
+	hub.StockChanged(ctx, level("product-999", 1))
+
+	// Assert
+	if !ready(laptops) {
+		t.Fatal("Expected the subscription to the changed products signalled")
+	}
+	changes := laptops.Next()
+	if len(changes) != 2 || changes[0].ProductID != "product-456" || changes[0].Quantity != 0 || changes[1].ProductID != "product-123" {
+		t.Errorf("Expected the latest level of each product in the order they first changed, got %+v", changes)
+	}
+	if ready(laptops) || len(laptops.Next()) != 0 {
+		t.Error("Expected no changes left once taken")
+	}
+	if ready(mice) {
+		t.Error("Expected no changes for a subscription to other products")
+	}
+}
+
+func TestSubscription_Close(t *testing.T) {
+	// Arrange
+	hub := NewHub()
+	sub, err := hub.Subscribe([]string{"product-123"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Act
+	sub.Close()
+	sub.Close()
+	hub.StockChanged(context.Background(), level("product-123", 5))
+
+	// Assert
+	select {
+	case <-sub.Done():
+	default:
+		t.Error("Expected the subscription ended")
+	}
+	if ready(sub) || len(hub.subscriptions) != 0 {
+		t.Errorf("Expected the subscription removed from the hub, got %v", hub.subscriptions)
+	}
+}
+
+func TestHub_Close(t *testing.T) {
+	// Arrange
+	hub := NewHub()
+	sub, err := hub.Subscribe([]string{"product-123"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Act
+	hub.Close()
+	_, subscribeErr := hub.Subscribe([]string{"product-123"})
+	sub.Close()
+
+	// Assert
+	select {
+	case <-sub.Done():
+	default:
+		t.Error("Expected open subscriptions ended")
+	}
+	if !errors.Is(subscribeErr, ErrClosed) {
+		t.Errorf("Expected ErrClosed, got %v", subscribeErr)
+	}
+}
@@ -0,0 +1,88 @@
+package jobs
+
+import (
+	"errors"
+	"net/http"
+
+	"enricher-api-go/internal/problem"
+	"enricher-api-go/internal/servertiming"
+	"enricher-api-go/internal/validation"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Handler handles HTTP requests for enrichment jobs
+type Handler struct {
+	service Service
+}
+
+// NewHandler creates a new enrichment job handler
+func NewHandler(service Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+// SubmitJob handles POST /v1/enrichment-jobs
+//
+// The job is answered with 202 while still QUEUED, with its URL in the
+// Location header; poll that URL until the job is COMPLETED.
+//
+// Example request:
+//
+//	POST /v1/enrichment-jobs
+//	Content-Type: application/json
+//
+//	{
+//		"orders": [
+//			{"customerId": "customer-456", "items": [{"productId": "product-123", "quantity": 2}]},
+//			{"customerId": "customer-123", "items": [{"sku": "product-123-blk", "quantity": 1}]}
+//		]
+//	}
+func (h *Handler) SubmitJob(c echo.Context) error {
+	var req JobRequest
+	if err := servertiming.Measure(c, "bind", func() error { return c.Bind(&req) }); err != nil {
+		return problem.BindError(c, err)
+	}
+
+	stop := servertiming.Start(c, "service")
+	job, err := h.service.SubmitJob(c.Request().Context(), req)
+	stop()
+	if err != nil {
+		return jobError(c, err)
+	}
+
+	c.Response().Header().Set(echo.HeaderLocation, c.Path()+"/"+job.JobID)
+	return c.JSON(http.StatusAccepted, job)
+}
+
+// GetJob handles GET /v1/enrichment-jobs/:id, reporting the job's progress
+// and the outcome of every order attempted so far
+func (h *Handler) GetJob(c echo.Context) error {
+	stop := servertiming.Start(c, "service")
+	job, err := h.service.GetJob(c.Request().Context(), c.Param("id"))
+	stop()
+	if err != nil {
+		return jobError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, job)
+}
+
+// jobError answers a failed job operation: 404 for an unknown or expired
+// job, 400 for an invalid batch and 503 while the queue cannot take more jobs
+func jobError(c echo.Context, err error) error {
+	var validationErr *validation.Error
+	switch {
+	case errors.Is(err, ErrJobNotFound):
+		return problem.Write(c, http.StatusNotFound, "Enrichment job not found")
+	case errors.As(err, &validationErr):
+		return problem.BindError(c, validationErr)
+	case errors.Is(err, ErrInvalidJob):
+		return problem.Write(c, http.StatusBadRequest, err.Error())
+	case errors.Is(err, ErrQueueFull), errors.Is(err, ErrQueueStopped):
+		return problem.Write(c, http.StatusServiceUnavailable, err.Error())
+	default:
+		return problem.ServerError(c, err)
+	}
+}
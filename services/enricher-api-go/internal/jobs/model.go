@@ -0,0 +1,78 @@
+// Package jobs enriches batches of orders asynchronously for the Resilient
+// Order Enricher API.
+//
+// A submitted batch becomes a job that is queued and answered right away; a
+// pool of workers then enriches its orders concurrently while callers poll
+// the job for progress, per-order failures and results.
+package jobs
+
+import (
+	"log/slog"
+	"time"
+
+	"enricher-api-go/internal/enrichment"
+)
+
+// Job statuses
+const (
+	StatusQueued    = "QUEUED"
+	StatusRunning   = "RUNNING"
+	StatusCompleted = "COMPLETED"
+)
+
+// Item statuses
+const (
+	ItemPending  = "PENDING"
+	ItemEnriched = "ENRICHED"
+	ItemFailed   = "FAILED"
+)
+
+// JobRequest is the batch accepted by POST /v1/enrichment-jobs
+type JobRequest struct {
+	Orders []enrichment.OrderRequest `json:"orders" validate:"required,min=1"`
+}
+
+// Item is the outcome of enriching one order of a job
+type Item struct {
+	// Index is the order's position in the submitted batch
+	Index  int    `json:"index"`
+	Status string `json:"status"`
+	// Result is set once the order is ENRICHED
+	Result *enrichment.EnrichedOrder `json:"result,omitempty"`
+	// Error explains why a FAILED order could not be enriched
+	Error string `json:"error,omitempty"`
+
+	order enrichment.OrderRequest
+}
+
+// Job is a batch of orders enriched in the background. A job is COMPLETED
+// once every order has been attempted, whether or not some of them failed.
+type Job struct {
+	JobID     string `json:"jobId"`
+	Status    string `json:"status"`
+	Total     int    `json:"total"`
+	Processed int    `json:"processed"`
+	Succeeded int    `json:"succeeded"`
+	Failed    int    `json:"failed"`
+	Items     []Item `json:"items"`
+
+	CreatedAt   time.Time  `json:"createdAt"`
+	StartedAt   *time.Time `json:"startedAt,omitempty"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+	CreatedBy   string     `json:"createdBy,omitempty"`
+
+	logger *slog.Logger
+}
+
+// Done reports whether every order of the job has been attempted
+func (j *Job) Done() bool {
+	return j.Status == StatusCompleted
+}
+
+// snapshot returns a copy of the job that later progress does not change
+func (j *Job) snapshot() *Job {
+	snapshot := *j
+	snapshot.Items = make([]Item, len(j.Items))
+	copy(snapshot.Items, j.Items)
+	return &snapshot
+}
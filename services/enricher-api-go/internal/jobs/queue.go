@@ -0,0 +1,321 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"enricher-api-go/internal/auth"
+	"enricher-api-go/internal/clock"
+	"enricher-api-go/internal/enrichment"
+	"enricher-api-go/internal/idgen"
+	"enricher-api-go/internal/lifecycle"
+	"enricher-api-go/internal/logging"
+	"enricher-api-go/internal/validation"
+)
+
+// Default queue settings
+const (
+	DefaultWorkers      = 4
+	DefaultQueueSize    = 100
+	DefaultMaxBatchSize = 1000
+	DefaultItemTimeout  = 5 * time.Second
+	DefaultRetention    = time.Hour
+)
+
+var (
+	// ErrJobNotFound is returned when a job does not exist or has expired
+	ErrJobNotFound = errors.New("enrichment job not found")
+	// ErrInvalidJob is returned when a batch is empty, too large or malformed
+	ErrInvalidJob = errors.New("invalid enrichment job")
+	// ErrQueueFull is returned when too many jobs are already waiting
+	ErrQueueFull = errors.New("enrichment job queue is full")
+	// ErrQueueStopped is returned when jobs are submitted during shutdown
+	ErrQueueStopped = errors.New("enrichment job queue is stopped")
+)
+
+// Config sizes the queue; zero values keep the package defaults
+type Config struct {
+	// Workers is how many orders are enriched concurrently across all jobs
+	Workers int
+	// QueueSize is how many jobs may wait for a worker before submissions are refused
+	QueueSize int
+	// MaxBatchSize is the most orders one job may hold
+	MaxBatchSize int
+	// ItemTimeout bounds the enrichment of each order
+	ItemTimeout time.Duration
+	// Retention is how long completed jobs can still be fetched
+	Retention time.Duration
+}
+
+// withDefaults fills in unset fields with the package defaults
+func (cfg Config) withDefaults() Config {
+	if cfg.Workers <= 0 {
+		cfg.Workers = DefaultWorkers
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = DefaultQueueSize
+	}
+	if cfg.MaxBatchSize <= 0 {
+		cfg.MaxBatchSize = DefaultMaxBatchSize
+	}
+	if cfg.ItemTimeout <= 0 {
+		cfg.ItemTimeout = DefaultItemTimeout
+	}
+	if cfg.Retention <= 0 {
+		cfg.Retention = DefaultRetention
+	}
+	return cfg
+}
+
+// Service defines the business logic interface for enrichment jobs
+type Service interface {
+	SubmitJob(ctx context.Context, req JobRequest) (*Job, error)
+	GetJob(ctx context.Context, jobID string) (*Job, error)
+}
+
+// Queue implements the Service interface, enriching the orders of submitted
+// jobs on a fixed pool of workers. Jobs are kept in memory, so they do not
+// survive a restart.
+type Queue struct {
+	cfg         Config
+	enricher    enrichment.Service
+	idGenerator idgen.Generator
+	clock       clock.Clock
+
+	mu      sync.Mutex
+	jobs    map[string]*Job
+	stopped bool
+
+	pending chan *Job
+	tasks   chan task
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+// task is one order of a job waiting for a worker
+type task struct {
+	job   *Job
+	index int
+}
+
+// Option configures optional Queue behavior
+type Option func(*Queue)
+
+// WithIDGenerator sets the generator used for new job IDs (UUIDs by default)
+func WithIDGenerator(gen idgen.Generator) Option {
+	return func(q *Queue) {
+		q.idGenerator = gen
+	}
+}
+
+// WithClock sets the clock used for job timestamps and expiry (the UTC wall clock by default)
+func WithClock(c clock.Clock) Option {
+	return func(q *Queue) {
+		q.clock = c
+	}
+}
+
+// NewQueue creates a job queue that enriches orders with enricher. Jobs are
+// accepted right away but only processed once Start has been called.
+func NewQueue(cfg Config, enricher enrichment.Service, opts ...Option) *Queue {
+	cfg = cfg.withDefaults()
+	q := &Queue{
+		cfg:         cfg,
+		enricher:    enricher,
+		idGenerator: idgen.UUIDGenerator{},
+		clock:       clock.System{},
+		jobs:        make(map[string]*Job),
+		pending:     make(chan *Job, cfg.QueueSize),
+		tasks:       make(chan task),
+		done:        make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(q)
+	}
+	return q
+}
+
+// Start launches the workers. Jobs are taken in submission order, and the
+// orders of a job are handed out in batch order, so a small job queued
+// behind a large one waits for the large one's orders to be picked up.
+func (q *Queue) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	q.cancel = cancel
+
+	var workers sync.WaitGroup
+	for i := 0; i < q.cfg.Workers; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for t := range q.tasks {
+				q.process(t)
+			}
+		}()
+	}
+
+	go func() {
+		defer close(q.done)
+		q.dispatch(ctx)
+		close(q.tasks)
+		workers.Wait()
+	}()
+}
+
+// Stop refuses new jobs, lets the workers finish the orders they are
+// enriching and waits for them until ctx ends. Orders not yet picked up stay
+// PENDING.
+func (q *Queue) Stop(ctx context.Context) error {
+	q.mu.Lock()
+	q.stopped = true
+	q.mu.Unlock()
+
+	if q.cancel == nil {
+		return nil
+	}
+	q.cancel()
+	return lifecycle.WaitOrTimeout(ctx, q.done)
+}
+
+// SubmitJob queues a batch of orders for enrichment and returns the QUEUED job
+func (q *Queue) SubmitJob(ctx context.Context, req JobRequest) (*Job, error) {
+	logger := logging.FromContext(ctx)
+	logger.Info("Submitting enrichment job", "orders", len(req.Orders))
+
+	if err := validation.Struct(req); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidJob, err)
+	}
+	if len(req.Orders) > q.cfg.MaxBatchSize {
+		return nil, fmt.Errorf("%w: a job may hold at most %d orders, got %d", ErrInvalidJob, q.cfg.MaxBatchSize, len(req.Orders))
+	}
+
+	jobID, err := q.idGenerator.NewID("job")
+	if err != nil {
+		logger.Error("Failed to generate job ID", "error", err)
+		return nil, fmt.Errorf("failed to submit enrichment job: %w", err)
+	}
+
+	now := q.clock.Now()
+	job := &Job{
+		JobID:     jobID,
+		Status:    StatusQueued,
+		Total:     len(req.Orders),
+		Items:     make([]Item, len(req.Orders)),
+		CreatedAt: now,
+		CreatedBy: auth.Caller(ctx),
+		// The job's logs keep the submitting request's ID alongside its own
+		logger: logger.With("job_id", jobID),
+	}
+	for i, order := range req.Orders {
+		job.Items[i] = Item{Index: i, Status: ItemPending, order: order}
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.stopped {
+		return nil, ErrQueueStopped
+	}
+	q.expire(now)
+
+	select {
+	case q.pending <- job:
+	default:
+		logger.Warn("Enrichment job queue is full", "queue_size", q.cfg.QueueSize)
+		return nil, ErrQueueFull
+	}
+	q.jobs[jobID] = job
+
+	logger.Info("Enrichment job queued", "job_id", jobID)
+	return job.snapshot(), nil
+}
+
+// GetJob returns the current progress of a job
+func (q *Queue) GetJob(ctx context.Context, jobID string) (*Job, error) {
+	logging.FromContext(ctx).Debug("Getting enrichment job", "job_id", jobID)
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.expire(q.clock.Now())
+	job, ok := q.jobs[jobID]
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+	return job.snapshot(), nil
+}
+
+// dispatch hands the orders of each queued job to the workers until ctx ends
+func (q *Queue) dispatch(ctx context.Context) {
+	for {
+		var job *Job
+		select {
+		case job = <-q.pending:
+		case <-ctx.Done():
+			return
+		}
+
+		q.mu.Lock()
+		startedAt := q.clock.Now()
+		job.Status = StatusRunning
+		job.StartedAt = &startedAt
+		q.mu.Unlock()
+
+		for i := range job.Items {
+			select {
+			case q.tasks <- task{job: job, index: i}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// process enriches one order and records the outcome on its job
+func (q *Queue) process(t task) {
+	q.mu.Lock()
+	order := t.job.Items[t.index].order
+	q.mu.Unlock()
+
+	// Orders already handed out are finished even during shutdown; the
+	// item timeout alone bounds them
+	itemCtx, cancel := context.WithTimeout(logging.WithLogger(context.Background(), t.job.logger), q.cfg.ItemTimeout)
+	result, err := q.enricher.EnrichOrder(itemCtx, order)
+	cancel()
+	if errors.Is(err, context.DeadlineExceeded) {
+		err = fmt.Errorf("enrichment timed out after %s", q.cfg.ItemTimeout)
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	item, job := &t.job.Items[t.index], t.job
+	job.Processed++
+	if err != nil {
+		t.job.logger.Warn("Failed to enrich job order", "index", t.index, "error", err)
+		item.Status, item.Error = ItemFailed, err.Error()
+		job.Failed++
+	} else {
+		item.Status, item.Result = ItemEnriched, result
+		job.Succeeded++
+	}
+
+	if job.Processed == job.Total {
+		completedAt := q.clock.Now()
+		job.Status = StatusCompleted
+		job.CompletedAt = &completedAt
+		t.job.logger.Info("Enrichment job completed", "succeeded", job.Succeeded, "failed", job.Failed)
+	}
+}
+
+// expire forgets jobs completed more than the retention period before now.
+// The caller must hold q.mu.
+func (q *Queue) expire(now time.Time) {
+	for jobID, job := range q.jobs {
+		if job.Done() && now.Sub(*job.CompletedAt) > q.cfg.Retention {
+			delete(q.jobs, jobID)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"enricher-api-go/internal/clock"
+	"enricher-api-go/internal/enrichment"
+)
+
+// enricherFunc adapts a function to enrichment.Service
+type enricherFunc func(ctx context.Context, req enrichment.OrderRequest) (*enrichment.EnrichedOrder, error)
+
+func (f enricherFunc) EnrichOrder(ctx context.Context, req enrichment.OrderRequest) (*enrichment.EnrichedOrder, error) {
+	return f(ctx, req)
+}
+
+// totalByCustomer enriches every order to a total of 1, failing orders for
+// unknown-customer
+var totalByCustomer = enricherFunc(func(_ context.Context, req enrichment.OrderRequest) (*enrichment.EnrichedOrder, error) {
+	if req.CustomerID == "unknown-customer" {
+		return nil, enrichment.ErrCustomerNotFound
+	}
+	return &enrichment.EnrichedOrder{Customer: enrichment.EnrichedCustomer{CustomerID: req.CustomerID}, Total: 1}, nil
+})
+
+// batch returns a job request with one order per customer ID
+func batch(customerIDs ...string) JobRequest {
+	req := JobRequest{}
+	for _, customerID := range customerIDs {
+		req.Orders = append(req.Orders, enrichment.OrderRequest{
+			CustomerID: customerID,
+			Items:      []enrichment.OrderItem{{ProductID: "product-123", Quantity: 1}},
+		})
+	}
+	return req
+}
+
+// waitForJob polls the queue until the job completes
+func waitForJob(t *testing.T, queue *Queue, jobID string) *Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		job, err := queue.GetJob(context.Background(), jobID)
+		if err != nil {
+			t.Fatalf("Expected the job, got %v", err)
+		}
+		if job.Done() {
+			return job
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("Job %s did not complete in time", jobID)
+	return nil
+}
+
+// stopQueue stops the queue when the test ends
+func stopQueue(t *testing.T, queue *Queue) {
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := queue.Stop(ctx); err != nil {
+			t.Errorf("Expected the queue to stop, got %v", err)
+		}
+	})
+}
+
+func TestQueue_EnrichesBatch(t *testing.T) {
+	// Arrange
+	queue := NewQueue(Config{Workers: 3}, totalByCustomer)
+	queue.Start()
+	stopQueue(t, queue)
+
+	// Act
+	submitted, err := queue.SubmitJob(context.Background(), batch("customer-456", "unknown-customer", "customer-123"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	job := waitForJob(t, queue, submitted.JobID)
+
+	// Assert
+	if submitted.Status != StatusQueued || submitted.Total != 3 {
+		t.Errorf("Expected a QUEUED job of 3 orders, got %+v", submitted)
+	}
+	if job.Processed != 3 || job.Succeeded != 2 || job.Failed != 1 {
+		t.Errorf("Expected 2 of 3 orders enriched, got %+v", job)
+	}
+	if job.StartedAt == nil || job.CompletedAt == nil {
+		t.Errorf("Expected start and completion times, got %+v", job)
+	}
+
+	failed := job.Items[1]
+	if failed.Status != ItemFailed || failed.Error != enrichment.ErrCustomerNotFound.Error() || failed.Result != nil {
+		t.Errorf("Expected the second order to fail with its reason, got %+v", failed)
+	}
+	for _, i := range []int{0, 2} {
+		if item := job.Items[i]; item.Status != ItemEnriched || item.Result == nil || item.Index != i {
+			t.Errorf("Expected order %d enriched, got %+v", i, item)
+		}
+	}
+}
+
+func TestQueue_EnrichesConcurrently(t *testing.T) {
+	// Arrange
+	var running, peak atomic.Int32
+	release := make(chan struct{})
+	enricher := enricherFunc(func(ctx context.Context, req enrichment.OrderRequest) (*enrichment.EnrichedOrder, error) {
+		current := running.Add(1)
+		defer running.Add(-1)
+		for {
+			seen := peak.Load()
+			if current <= seen || peak.CompareAndSwap(seen, current) {
+				break
+			}
+		}
+		<-release
+		return &enrichment.EnrichedOrder{}, nil
+	})
+	queue := NewQueue(Config{Workers: 2}, enricher)
+	queue.Start()
+	stopQueue(t, queue)
+
+	// Act
+	submitted, err := queue.SubmitJob(context.Background(), batch("a", "b", "c", "d"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for peak.Load() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	job := waitForJob(t, queue, submitted.JobID)
+
+	// Assert
+	if peak.Load() != 2 {
+		t.Errorf("Expected 2 orders enriched at once, got %d", peak.Load())
+	}
+	if job.Succeeded != 4 {
+		t.Errorf("Expected every order enriched, got %+v", job)
+	}
+}
+
+func TestQueue_ItemTimeout(t *testing.T) {
+	// Arrange
+	enricher := enricherFunc(func(ctx context.Context, req enrichment.OrderRequest) (*enrichment.EnrichedOrder, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	queue := NewQueue(Config{ItemTimeout: 10 * time.Millisecond}, enricher)
+	queue.Start()
+	stopQueue(t, queue)
+
+	// Act
+	submitted, err := queue.SubmitJob(context.Background(), batch("customer-456"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	job := waitForJob(t, queue, submitted.JobID)
+
+	// Assert
+	if item := job.Items[0]; item.Status != ItemFailed || item.Error != "enrichment timed out after 10ms" {
+		t.Errorf("Expected the order to time out, got %+v", item)
+	}
+}
+
+func TestQueue_SubmitJob_Refused(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		req     JobRequest
+		stopped bool
+		wantErr error
+	}{
+		{name: "empty batch", req: JobRequest{}, wantErr: ErrInvalidJob},
+		{name: "batch too large", cfg: Config{MaxBatchSize: 2}, req: batch("a", "b", "c"), wantErr: ErrInvalidJob},
+		{name: "queue full", cfg: Config{QueueSize: 1}, req: batch("a"), wantErr: ErrQueueFull},
+		{name: "stopped", req: batch("a"), stopped: true, wantErr: ErrQueueStopped},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange: the queue is never started, so jobs stay queued
+			queue := NewQueue(tt.cfg, totalByCustomer)
+			if tt.wantErr == ErrQueueFull {
+				if _, err := queue.SubmitJob(context.Background(), tt.req); err != nil {
+					t.Fatalf("Expected the first job to be queued, got %v", err)
+				}
+			}
+			if tt.stopped {
+				queue.Stop(context.Background())
+			}
+
+			// Act
+			_, err := queue.SubmitJob(context.Background(), tt.req)
+
+			// Assert
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestQueue_ExpiresCompletedJobs(t *testing.T) {
+	// Arrange
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	var offset atomic.Int64
+	queue := NewQueue(Config{Retention: time.Hour}, totalByCustomer,
+		WithClock(clock.Func(func() time.Time { return now.Add(time.Duration(offset.Load())) })))
+	queue.Start()
+	stopQueue(t, queue)
+
+	submitted, err := queue.SubmitJob(context.Background(), batch("customer-456"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	waitForJob(t, queue, submitted.JobID)
+
+	// Act
+	offset.Store(int64(time.Hour + time.Second))
+	_, err = queue.GetJob(context.Background(), submitted.JobID)
+
+	// Assert
+	if !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("Expected the job to have expired, got %v", err)
+	}
+}
@@ -0,0 +1,234 @@
+package jwtauth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"enricher-api-go/internal/cache"
+)
+
+// ErrUnknownKey is returned when no published key matches a token's key ID
+var ErrUnknownKey = errors.New("unknown signing key")
+
+// minUnknownKeyRefresh limits how often tokens with unknown key IDs can
+// force a JWKS fetch, so forged kids cannot hammer the identity provider
+const minUnknownKeyRefresh = 30 * time.Second
+
+// failedRefreshBackoff is how long a failed JWKS fetch is trusted before the
+// next one, so an unreachable identity provider is not asked once per request
+const failedRefreshBackoff = 5 * time.Second
+
+// KeySet fetches and caches the signing keys published at a JWKS URL.
+//
+// Keys are reused until refreshInterval has passed. A token signed with a key
+// ID that is not cached triggers an early refresh so rotated keys are picked
+// up without waiting for the interval. Fetches run outside the lock, one at a
+// time, and concurrent callers share their result. A failed fetch keeps the
+// last good keys in service and is not retried for failedRefreshBackoff.
+type KeySet struct {
+	url             string
+	client          *http.Client
+	refreshInterval time.Duration
+	now             func() time.Time
+	fetches         cache.Group[string, map[string]interface{}]
+
+	mutex     sync.Mutex
+	keys      map[string]interface{}
+	fetchedAt time.Time
+	// attemptedAt is when the last fetch finished, and failure why it failed
+	attemptedAt time.Time
+	failure     error
+}
+
+// NewKeySet creates a KeySet for url; keys are fetched on first use
+func NewKeySet(url string, client *http.Client, refreshInterval time.Duration) *KeySet {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &KeySet{
+		url:             url,
+		client:          client,
+		refreshInterval: refreshInterval,
+		now:             time.Now,
+	}
+}
+
+// Key returns the public key with the given key ID
+func (k *KeySet) Key(ctx context.Context, kid string) (interface{}, error) {
+	k.mutex.Lock()
+	now := k.now()
+	key, cached := k.keys[kid]
+	stale := k.keys == nil || now.Sub(k.fetchedAt) >= k.refreshInterval
+	sinceAttempt := now.Sub(k.attemptedAt)
+	failure := k.failure
+	k.mutex.Unlock()
+
+	if cached && !stale {
+		return key, nil
+	}
+
+	var due bool
+	switch {
+	case failure != nil:
+		due = sinceAttempt >= failedRefreshBackoff
+	case stale:
+		due = true
+	default:
+		due = sinceAttempt >= minUnknownKeyRefresh
+	}
+	if !due {
+		if cached {
+			return key, nil
+		}
+		if failure != nil {
+			return nil, failure
+		}
+		return nil, fmt.Errorf("%w %q", ErrUnknownKey, kid)
+	}
+
+	// The fetch is shared, so one caller giving up must not fail it for the
+	// others; the client's timeout bounds it instead
+	keys, err, _ := k.fetches.Do(k.url, func() (map[string]interface{}, error) {
+		return k.refresh(context.WithoutCancel(ctx))
+	})
+	if err != nil {
+		// Keep serving known keys when the identity provider is unreachable
+		if cached {
+			return key, nil
+		}
+		return nil, err
+	}
+
+	if key, ok := keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("%w %q", ErrUnknownKey, kid)
+}
+
+// refresh fetches the keys currently published and records the attempt,
+// replacing the cached keys when it succeeds
+func (k *KeySet) refresh(ctx context.Context) (map[string]interface{}, error) {
+	keys, err := k.fetch(ctx)
+
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	k.attemptedAt, k.failure = k.now(), err
+	if err != nil {
+		return nil, err
+	}
+	k.keys, k.fetchedAt = keys, k.attemptedAt
+	return keys, nil
+}
+
+// jsonWebKey holds the JWK members needed for RSA and EC signature keys
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// fetch reads the keys currently published. Keys that are not for
+// signatures or use unsupported types are skipped.
+func (k *KeySet) fetch(ctx context.Context) (map[string]interface{}, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, k.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build JWKS request: %w", err)
+	}
+
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch JWKS: unexpected status %d", resp.StatusCode)
+	}
+
+	var document struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&document); err != nil {
+		return nil, fmt.Errorf("failed to decode JWKS: %w", err)
+	}
+
+	keys := make(map[string]interface{}, len(document.Keys))
+	for _, jwk := range document.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+
+	return keys, nil
+}
+
+// publicKey decodes the JWK into an *rsa.PublicKey or *ecdsa.PublicKey
+func (j jsonWebKey) publicKey() (interface{}, error) {
+	switch j.Kty {
+	case "RSA":
+		n, err := decodeBigInt(j.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(j.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() {
+			return nil, errors.New("RSA exponent too large")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch j.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", j.Crv)
+		}
+		x, err := decodeBigInt(j.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(j.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", j.Kty)
+	}
+}
+
+// decodeBigInt decodes a base64url-encoded big-endian integer
+func decodeBigInt(encoded string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key parameter: %w", err)
+	}
+	return new(big.Int).SetBytes(data), nil
+}
@@ -0,0 +1,208 @@
+// Package jwtauth authenticates OAuth2 bearer tokens (JWTs) against the
+// signing keys published at a JWKS URL and enforces per-route scopes.
+package jwtauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"enricher-api-go/internal/logging"
+	"enricher-api-go/internal/problem"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+)
+
+// DefaultRefreshInterval is how long fetched JWKS keys are reused by default
+const DefaultRefreshInterval = 15 * time.Minute
+
+// clockSkew tolerates small clock differences with the identity provider
+const clockSkew = 30 * time.Second
+
+// claimsKey is the Echo context key holding authenticated Claims
+const claimsKey = "jwtauth.claims"
+
+var (
+	// ErrMissingToken is returned when a request carries no bearer token
+	ErrMissingToken = errors.New("missing bearer token")
+	// ErrInvalidToken is returned when a token fails signature or claim checks
+	ErrInvalidToken = errors.New("invalid token")
+)
+
+// signingMethods lists the asymmetric algorithms accepted for tokens.
+// HMAC is excluded so a public key can never be used as a shared secret.
+var signingMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+// Config configures an Authenticator
+type Config struct {
+	// JWKSURL is where the identity provider publishes its signing keys
+	JWKSURL string
+	// Issuer, when set, must match the token's iss claim
+	Issuer string
+	// Audience, when set, must appear in the token's aud claim
+	Audience string
+	// RefreshInterval is how long fetched keys are reused (DefaultRefreshInterval if zero)
+	RefreshInterval time.Duration
+	// HTTPClient fetches the JWKS; a client with a 10s timeout is used if nil
+	HTTPClient *http.Client
+}
+
+// Claims are the registered JWT claims plus the granted scopes
+type Claims struct {
+	jwt.RegisteredClaims
+	// Scope is the OAuth2 space-delimited scope claim
+	Scope string `json:"scope,omitempty"`
+	// Scp is the list form of the scope claim used by some providers
+	Scp scopeList `json:"scp,omitempty"`
+	// Roles lists the caller's roles for role-based access control
+	Roles scopeList `json:"roles,omitempty"`
+}
+
+// Scopes returns every scope granted by the scope and scp claims
+func (c *Claims) Scopes() []string {
+	return append(strings.Fields(c.Scope), c.Scp...)
+}
+
+// HasScope reports whether scope was granted
+func (c *Claims) HasScope(scope string) bool {
+	for _, granted := range c.Scopes() {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// scopeList decodes a claim given as either a JSON array or a space-delimited string
+type scopeList []string
+
+// UnmarshalJSON accepts ["a","b"] or "a b"
+func (s *scopeList) UnmarshalJSON(data []byte) error {
+	var list []string
+	if err := json.Unmarshal(data, &list); err == nil {
+		*s = list
+		return nil
+	}
+
+	var joined string
+	if err := json.Unmarshal(data, &joined); err != nil {
+		return fmt.Errorf("claim must be a string or list of strings: %w", err)
+	}
+	*s = strings.Fields(joined)
+	return nil
+}
+
+// Authenticator verifies bearer tokens
+type Authenticator struct {
+	keys   *KeySet
+	parser *jwt.Parser
+}
+
+// New creates an Authenticator for cfg
+func New(cfg Config) *Authenticator {
+	refreshInterval := cfg.RefreshInterval
+	if refreshInterval <= 0 {
+		refreshInterval = DefaultRefreshInterval
+	}
+
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods(signingMethods),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(clockSkew),
+	}
+	if cfg.Issuer != "" {
+		options = append(options, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		options = append(options, jwt.WithAudience(cfg.Audience))
+	}
+
+	return &Authenticator{
+		keys:   NewKeySet(cfg.JWKSURL, cfg.HTTPClient, refreshInterval),
+		parser: jwt.NewParser(options...),
+	}
+}
+
+// Authenticate verifies token's signature, expiry, issuer and audience
+func (a *Authenticator) Authenticate(ctx context.Context, token string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := a.parser.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return a.keys.Key(ctx, kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	return claims, nil
+}
+
+// Middleware rejects requests without a valid bearer token with 401 and
+// stores the token's claims for RequireScopes and ClaimsFrom
+func (a *Authenticator) Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			token, err := bearerToken(c.Request())
+			if err == nil {
+				var claims *Claims
+				claims, err = a.Authenticate(c.Request().Context(), token)
+				if err == nil {
+					c.Set(claimsKey, claims)
+					return next(c)
+				}
+			}
+
+			logging.FromContext(c.Request().Context()).Info("Rejected unauthenticated request", "error", err)
+			if errors.Is(err, ErrMissingToken) {
+				c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Bearer`)
+			} else {
+				c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Bearer error="invalid_token"`)
+			}
+			return problem.Write(c, http.StatusUnauthorized, err.Error())
+		}
+	}
+}
+
+// RequireScopes rejects requests whose token lacks any of scopes with 403.
+// It must run after Authenticator.Middleware.
+func RequireScopes(scopes ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			claims, ok := ClaimsFrom(c)
+			if !ok {
+				return problem.Write(c, http.StatusUnauthorized, ErrMissingToken.Error())
+			}
+
+			for _, scope := range scopes {
+				if !claims.HasScope(scope) {
+					logging.FromContext(c.Request().Context()).Info("Rejected request lacking scope",
+						"subject", claims.Subject, "scope", scope)
+					c.Response().Header().Set(echo.HeaderWWWAuthenticate,
+						fmt.Sprintf(`Bearer error="insufficient_scope", scope=%q`, strings.Join(scopes, " ")))
+					return problem.Write(c, http.StatusForbidden, "missing required scope "+scope)
+				}
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// ClaimsFrom returns the claims stored by Authenticator.Middleware
+func ClaimsFrom(c echo.Context) (*Claims, bool) {
+	claims, ok := c.Get(claimsKey).(*Claims)
+	return claims, ok
+}
+
+// bearerToken extracts the token from an "Authorization: Bearer" header
+func bearerToken(r *http.Request) (string, error) {
+	scheme, token, found := strings.Cut(r.Header.Get(echo.HeaderAuthorization), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		return "", ErrMissingToken
+	}
+	return strings.TrimSpace(token), nil
+}
@@ -0,0 +1,295 @@
+package jwtauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+)
+
+// testIdentityProvider publishes an RSA signing key and issues tokens. While
+// failing is set its JWKS endpoint answers 503, and while gate is set each
+// fetch waits for it.
+type testIdentityProvider struct {
+	key     *rsa.PrivateKey
+	kid     string
+	server  *httptest.Server
+	fetches atomic.Int32
+	failing atomic.Bool
+	gate    chan struct{}
+}
+
+func newTestIdentityProvider(t *testing.T) *testIdentityProvider {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	idp := &testIdentityProvider{key: key, kid: "key-1"}
+	idp.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		idp.fetches.Add(1)
+		if idp.gate != nil {
+			<-idp.gate
+		}
+		if idp.failing.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": idp.kid,
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	}))
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+func (idp *testIdentityProvider) token(t *testing.T, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(idp.key)
+	if err != nil {
+		t.Fatalf("Failed to sign token: %v", err)
+	}
+	return signed
+}
+
+func validClaims(scope string) jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub":   "client-1",
+		"iss":   "https://idp.test/",
+		"aud":   "enricher-api",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"scope": scope,
+	}
+}
+
+func TestAuthenticator_Authenticate(t *testing.T) {
+	// Arrange
+	idp := newTestIdentityProvider(t)
+	authenticator := New(Config{JWKSURL: idp.server.URL, Issuer: "https://idp.test/", Audience: "enricher-api"})
+
+	expired := validClaims("customers:read")
+	expired["exp"] = time.Now().Add(-time.Hour).Unix()
+	wrongAudience := validClaims("customers:read")
+	wrongAudience["aud"] = "another-api"
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{name: "valid token", token: idp.token(t, idp.kid, validClaims("customers:read"))},
+		{name: "expired token", token: idp.token(t, idp.kid, expired), wantErr: true},
+		{name: "wrong audience", token: idp.token(t, idp.kid, wrongAudience), wantErr: true},
+		{name: "unknown key ID", token: idp.token(t, "key-2", validClaims("customers:read")), wantErr: true},
+		{name: "malformed token", token: "not-a-jwt", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			claims, err := authenticator.Authenticate(context.Background(), tt.token)
+
+			// Assert
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidToken) {
+					t.Errorf("Expected ErrInvalidToken, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if claims.Subject != "client-1" || !claims.HasScope("customers:read") {
+				t.Errorf("Expected subject and scope from token, got %+v", claims)
+			}
+		})
+	}
+}
+
+func TestKeySet_CachesKeys(t *testing.T) {
+	// Arrange
+	idp := newTestIdentityProvider(t)
+	keys := NewKeySet(idp.server.URL, nil, time.Hour)
+
+	// Act
+	for i := 0; i < 3; i++ {
+		if _, err := keys.Key(context.Background(), idp.kid); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	_, unknownErr := keys.Key(context.Background(), "key-2")
+
+	// Assert
+	if fetches := idp.fetches.Load(); fetches != 1 {
+		t.Errorf("Expected one JWKS fetch, got %d", fetches)
+	}
+	if !errors.Is(unknownErr, ErrUnknownKey) {
+		t.Errorf("Expected ErrUnknownKey, got %v", unknownErr)
+	}
+}
+
+func TestKeySet_KeepsKeysWhileRefreshFails(t *testing.T) {
+	// Arrange
+	idp := newTestIdentityProvider(t)
+	keys := NewKeySet(idp.server.URL, nil, time.Minute)
+	clock := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	keys.now = func() time.Time { return clock }
+	if _, err := keys.Key(context.Background(), idp.kid); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	idp.failing.Store(true)
+
+	// Act
+	clock = clock.Add(2 * time.Minute)
+	stale, staleErr := keys.Key(context.Background(), idp.kid)
+	_, againErr := keys.Key(context.Background(), idp.kid)
+	_, unknownErr := keys.Key(context.Background(), "key-2")
+	fetchesWhileBackingOff := idp.fetches.Load()
+	clock = clock.Add(failedRefreshBackoff)
+	idp.failing.Store(false)
+	_, recoveredErr := keys.Key(context.Background(), idp.kid)
+
+	// Assert
+	if staleErr != nil || againErr != nil || stale == nil {
+		t.Errorf("Expected the last good key served, got %v and %v", staleErr, againErr)
+	}
+	if unknownErr == nil || errors.Is(unknownErr, ErrUnknownKey) {
+		t.Errorf("Expected the fetch failure for an unknown key, got %v", unknownErr)
+	}
+	if fetchesWhileBackingOff != 2 {
+		t.Errorf("Expected one failed fetch, then none while backing off, got %d fetches", fetchesWhileBackingOff)
+	}
+	if recoveredErr != nil || idp.fetches.Load() != 3 {
+		t.Errorf("Expected a new fetch once the backoff passed, got %d fetches, %v", idp.fetches.Load(), recoveredErr)
+	}
+}
+
+func TestKeySet_FetchesOutsideTheLock(t *testing.T) {
+	// Arrange
+	idp := newTestIdentityProvider(t)
+	keys := NewKeySet(idp.server.URL, nil, time.Hour)
+	if _, err := keys.Key(context.Background(), idp.kid); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	keys.now = func() time.Time { return time.Now().Add(time.Minute) }
+	idp.gate = make(chan struct{})
+
+	// Act: tokens with unknown key IDs wait on one fetch
+	results := make(chan error, 3)
+	for i := 0; i < 3; i++ {
+		go func() {
+			_, err := keys.Key(context.Background(), "key-2")
+			results <- err
+		}()
+	}
+	for idp.fetches.Load() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	served := make(chan error, 1)
+	go func() {
+		_, err := keys.Key(context.Background(), idp.kid)
+		served <- err
+	}()
+
+	// Assert
+	select {
+	case err := <-served:
+		if err != nil {
+			t.Errorf("Expected the cached key, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("Expected the cached key served while a fetch is in flight")
+	}
+	close(idp.gate)
+	for i := 0; i < 3; i++ {
+		if err := <-results; !errors.Is(err, ErrUnknownKey) {
+			t.Errorf("Expected ErrUnknownKey, got %v", err)
+		}
+	}
+	if fetches := idp.fetches.Load(); fetches != 2 {
+		t.Errorf("Expected the concurrent refreshes to share one fetch, got %d fetches", fetches)
+	}
+}
+
+func TestMiddleware_RequireScopes(t *testing.T) {
+	// Arrange
+	idp := newTestIdentityProvider(t)
+	authenticator := New(Config{JWKSURL: idp.server.URL})
+
+	e := echo.New()
+	group := e.Group("/v1", authenticator.Middleware())
+	group.GET("/customers", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}, RequireScopes("customers:read"))
+
+	tests := []struct {
+		name       string
+		header     string
+		wantStatus int
+	}{
+		{name: "no token", header: "", wantStatus: http.StatusUnauthorized},
+		{name: "invalid token", header: "Bearer not-a-jwt", wantStatus: http.StatusUnauthorized},
+		{name: "missing scope", header: "Bearer " + idp.token(t, idp.kid, validClaims("products:read")), wantStatus: http.StatusForbidden},
+		{name: "granted scope", header: "Bearer " + idp.token(t, idp.kid, validClaims("products:read customers:read")), wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v1/customers", nil)
+			if tt.header != "" {
+				req.Header.Set(echo.HeaderAuthorization, tt.header)
+			}
+			rec := httptest.NewRecorder()
+
+			// Act
+			e.ServeHTTP(rec, req)
+
+			// Assert
+			if rec.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+			if rec.Code != http.StatusOK && rec.Header().Get(echo.HeaderWWWAuthenticate) == "" {
+				t.Error("Expected WWW-Authenticate header on rejection")
+			}
+		})
+	}
+}
+
+func TestClaims_ScpAndRoles(t *testing.T) {
+	// Arrange
+	var claims Claims
+
+	// Act
+	err := json.Unmarshal([]byte(`{"scp":["customers:read","products:write"],"roles":"reader operator"}`), &claims)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !claims.HasScope("products:write") || claims.HasScope("products:read") {
+		t.Errorf("Expected scopes from scp list, got %v", claims.Scopes())
+	}
+	if len(claims.Roles) != 2 || claims.Roles[1] != "operator" {
+		t.Errorf("Expected roles from roles claim, got %v", claims.Roles)
+	}
+}
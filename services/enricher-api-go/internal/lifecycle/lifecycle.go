@@ -0,0 +1,66 @@
+// Package lifecycle coordinates the orderly shutdown of long-lived resources
+// such as repositories and background consumers.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"enricher-api-go/internal/logging"
+)
+
+// Hook releases a resource, giving up when ctx is done
+type Hook func(ctx context.Context) error
+
+// Shutdown collects hooks and runs them in reverse registration order, so a
+// resource registered after its dependencies is stopped before them.
+type Shutdown struct {
+	mu    sync.Mutex
+	names []string
+	hooks []Hook
+}
+
+// Register adds a named hook to run at shutdown
+func (s *Shutdown) Register(name string, hook Hook) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.names = append(s.names, name)
+	s.hooks = append(s.hooks, hook)
+}
+
+// Run executes every registered hook, most recent first, and returns the
+// joined errors. Hooks still run after an earlier one fails, and each hook is
+// removed once it has run so calling Run again is harmless.
+func (s *Shutdown) Run(ctx context.Context) error {
+	s.mu.Lock()
+	names, hooks := s.names, s.hooks
+	s.names, s.hooks = nil, nil
+	s.mu.Unlock()
+
+	logger := logging.FromContext(ctx)
+
+	var errs []error
+	for i := len(hooks) - 1; i >= 0; i-- {
+		logger.Info("Stopping", "component", names[i])
+		if err := hooks[i](ctx); err != nil {
+			logger.Error("Failed to stop cleanly", "component", names[i], "error", err)
+			errs = append(errs, fmt.Errorf("%s: %w", names[i], err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// WaitOrTimeout blocks until done is closed or ctx ends, returning ctx's error
+// in the latter case
+func WaitOrTimeout(ctx context.Context, done <-chan struct{}) error {
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
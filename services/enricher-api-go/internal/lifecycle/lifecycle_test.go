@@ -0,0 +1,86 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestShutdown_RunsHooksInReverseOrder(t *testing.T) {
+	// Arrange
+	var shutdown Shutdown
+	var order []string
+	for _, name := range []string{"storage", "consumer", "cache"} {
+		name := name
+		shutdown.Register(name, func(context.Context) error {
+			order = append(order, name)
+			return nil
+		})
+	}
+
+	// Act
+	err := shutdown.Run(context.Background())
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := []string{"cache", "consumer", "storage"}
+	for i := range expected {
+		if order[i] != expected[i] {
+			t.Fatalf("Expected order %v, got %v", expected, order)
+		}
+	}
+}
+
+func TestShutdown_ContinuesAfterFailure(t *testing.T) {
+	// Arrange
+	var shutdown Shutdown
+	closed := false
+	shutdown.Register("storage", func(context.Context) error {
+		closed = true
+		return nil
+	})
+	shutdown.Register("consumer", func(context.Context) error {
+		return errors.New("commit failed")
+	})
+
+	// Act
+	err := shutdown.Run(context.Background())
+
+	// Assert
+	if err == nil || err.Error() != "consumer: commit failed" {
+		t.Errorf("Expected consumer error, got %v", err)
+	}
+
+	if !closed {
+		t.Error("Expected storage hook to run after consumer failure")
+	}
+
+	// Hooks only run once
+	if err := shutdown.Run(context.Background()); err != nil {
+		t.Errorf("Expected second run to be a no-op, got %v", err)
+	}
+}
+
+func TestWaitOrTimeout(t *testing.T) {
+	// Arrange
+	done := make(chan struct{})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	// Act
+	err := WaitOrTimeout(ctx, done)
+
+	// Assert
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+
+	close(done)
+	if err := WaitOrTimeout(context.Background(), done); err != nil {
+		t.Errorf("Expected nil once done is closed, got %v", err)
+	}
+}
@@ -0,0 +1,128 @@
+// Package listing parses the sort order shared by the list endpoints and
+// applies it in memory or as an SQL ORDER BY clause.
+package listing
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ErrInvalidSort is returned when a sort is malformed or names a field that
+// cannot be sorted on
+var ErrInvalidSort = errors.New("invalid sort")
+
+// Sort directions
+const (
+	Ascending  = "asc"
+	Descending = "desc"
+)
+
+// SortField orders results by one field
+type SortField struct {
+	// Field is the JSON name of the field, e.g. price
+	Field string
+	// Descending puts the highest values first
+	Descending bool
+}
+
+// Sort orders results by each field in turn, so later fields only break
+// ties of earlier ones. The zero value keeps a list's default order.
+type Sort []SortField
+
+// ParseSort parses a sort query parameter: comma-separated fields, each
+// optionally followed by a colon and asc (the default) or desc, e.g.
+// "price:desc,name:asc". An empty value returns an empty Sort.
+func ParseSort(raw string) (Sort, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+
+	var sort Sort
+	for _, part := range strings.Split(raw, ",") {
+		field, direction, _ := strings.Cut(strings.TrimSpace(part), ":")
+		if field == "" {
+			return nil, fmt.Errorf("%w: sort fields must not be blank", ErrInvalidSort)
+		}
+
+		switch strings.ToLower(direction) {
+		case "", Ascending:
+			sort = append(sort, SortField{Field: field})
+		case Descending:
+			sort = append(sort, SortField{Field: field, Descending: true})
+		default:
+			return nil, fmt.Errorf("%w: %s must be sorted asc or desc, got %q", ErrInvalidSort, field, direction)
+		}
+	}
+	return sort, nil
+}
+
+// Validate checks that s names each field at most once and only fields in
+// allowed
+func (s Sort) Validate(allowed ...string) error {
+	seen := make(map[string]bool, len(s))
+	for _, f := range s {
+		if !slices.Contains(allowed, f.Field) {
+			return fmt.Errorf("%w: cannot sort on %s; allowed: %s", ErrInvalidSort, f.Field, strings.Join(allowed, ", "))
+		}
+		if seen[f.Field] {
+			return fmt.Errorf("%w: %s is sorted on more than once", ErrInvalidSort, f.Field)
+		}
+		seen[f.Field] = true
+	}
+	return nil
+}
+
+// String formats s the way ParseSort reads it
+func (s Sort) String() string {
+	parts := make([]string, len(s))
+	for i, f := range s {
+		direction := Ascending
+		if f.Descending {
+			direction = Descending
+		}
+		parts[i] = f.Field + ":" + direction
+	}
+	return strings.Join(parts, ",")
+}
+
+// Compare orders a and b by s, comparing one field at a time with compare,
+// which returns a negative number, zero or a positive number as a's value
+// of field is less than, equal to or greater than b's. It returns 0 when
+// every field ties, leaving the caller to break the tie.
+func Compare[T any](s Sort, a, b T, compare func(field string, a, b T) int) int {
+	for _, f := range s {
+		if c := compare(f.Field, a, b); c != 0 {
+			if f.Descending {
+				return -c
+			}
+			return c
+		}
+	}
+	return 0
+}
+
+// OrderBy returns an ORDER BY clause sorting by s, with each field mapped
+// to its column, then by tiebreak so pages stay stable. Fields without a
+// column are skipped; callers validate s first.
+func (s Sort) OrderBy(columns map[string]string, tiebreak string) string {
+	terms := make([]string, 0, len(s)+1)
+	for _, f := range s {
+		column, ok := columns[f.Field]
+		if !ok {
+			continue
+		}
+		if column == tiebreak {
+			tiebreak = ""
+		}
+		if f.Descending {
+			column += " DESC"
+		}
+		terms = append(terms, column)
+	}
+	if tiebreak != "" {
+		terms = append(terms, tiebreak)
+	}
+	return ` ORDER BY ` + strings.Join(terms, `, `)
+}
@@ -0,0 +1,111 @@
+package listing
+
+import (
+	"cmp"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestParseSort(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    Sort
+		wantErr bool
+	}{
+		{name: "empty", raw: ""},
+		{name: "default direction", raw: "name", want: Sort{{Field: "name"}}},
+		{name: "several fields", raw: "price:desc, name:ASC", want: Sort{{Field: "price", Descending: true}, {Field: "name"}}},
+		{name: "blank field", raw: "price:desc,", wantErr: true},
+		{name: "unknown direction", raw: "price:down", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			sort, err := ParseSort(tt.raw)
+
+			// Assert
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidSort) {
+					t.Errorf("Expected ErrInvalidSort, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if !slices.Equal(sort, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, sort)
+			}
+		})
+	}
+}
+
+func TestSort_Validate(t *testing.T) {
+	// Arrange
+	allowed := []string{"name", "price"}
+
+	// Act
+	valid := Sort{{Field: "price", Descending: true}, {Field: "name"}}.Validate(allowed...)
+	unknown := Sort{{Field: "weight"}}.Validate(allowed...)
+	repeated := Sort{{Field: "price"}, {Field: "price", Descending: true}}.Validate(allowed...)
+
+	// Assert
+	if valid != nil {
+		t.Errorf("Expected no error, got %v", valid)
+	}
+	if !errors.Is(unknown, ErrInvalidSort) || !errors.Is(repeated, ErrInvalidSort) {
+		t.Errorf("Expected ErrInvalidSort for unknown and repeated fields, got %v and %v", unknown, repeated)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	// Arrange
+	type item struct {
+		name  string
+		price float64
+	}
+	items := []item{{"b", 10}, {"a", 5}, {"c", 10}, {"a", 10}}
+	sort := Sort{{Field: "price", Descending: true}, {Field: "name"}}
+	compare := func(field string, a, b item) int {
+		if field == "price" {
+			return cmp.Compare(a.price, b.price)
+		}
+		return cmp.Compare(a.name, b.name)
+	}
+
+	// Act
+	slices.SortFunc(items, func(a, b item) int { return Compare(sort, a, b, compare) })
+
+	// Assert
+	want := []item{{"a", 10}, {"b", 10}, {"c", 10}, {"a", 5}}
+	if !slices.Equal(items, want) {
+		t.Errorf("Expected %v, got %v", want, items)
+	}
+}
+
+func TestSort_OrderBy(t *testing.T) {
+	// Arrange
+	columns := map[string]string{"productId": "product_id", "price": "price", "name": "name"}
+
+	tests := []struct {
+		sort Sort
+		want string
+	}{
+		{sort: nil, want: " ORDER BY product_id"},
+		{sort: Sort{{Field: "price", Descending: true}, {Field: "name"}}, want: " ORDER BY price DESC, name, product_id"},
+		{sort: Sort{{Field: "productId", Descending: true}}, want: " ORDER BY product_id DESC"},
+	}
+
+	for _, tt := range tests {
+		// Act
+		got := tt.sort.OrderBy(columns, "product_id")
+
+		// Assert
+		if got != tt.want {
+			t.Errorf("Expected %q for %v, got %q", tt.want, tt.sort, got)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+// Package loadshed turns away low-priority requests while the server is
+// overloaded, so the capacity left goes to the requests that matter most.
+//
+// The Shedder counts the requests in flight and averages the latency of the
+// protected routes. Once too many requests are in flight, or the protected
+// routes slow down past a target, the routes marked low priority answer 503
+// with a Retry-After header instead of running. Shedding stops as soon as
+// the load drops back.
+//
+// Example usage:
+//
+//	shedder := loadshed.New(loadshed.Config{MaxInFlight: 200, TargetLatency: 250 * time.Millisecond, RetryAfter: time.Second})
+//	e.Use(shedder.Middleware("/health", "/metrics"))
+//	e.GET("/v1/products", handler.ListProducts, shedder.Shed())
+//	e.GET("/v1/products/:id", handler.GetProduct, shedder.Protect())
+package loadshed
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"enricher-api-go/internal/logging"
+	"enricher-api-go/internal/problem"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// smoothing is the weight of each new latency in the average
+	smoothing = 0.2
+	// staleAfter is how long the average latency counts without a new
+	// protected request; idle protected routes are not slow ones
+	staleAfter = 5 * time.Second
+)
+
+// Config sets when requests are shed
+type Config struct {
+	// MaxInFlight is how many requests may be in flight before low-priority
+	// ones are shed
+	MaxInFlight int
+	// TargetLatency is the average latency of protected requests above which
+	// low-priority ones are shed
+	TargetLatency time.Duration
+	// RetryAfter is how long shed clients are told to wait
+	RetryAfter time.Duration
+}
+
+// Stats is a snapshot of a Shedder
+type Stats struct {
+	InFlight int
+	// Latency is the average latency of recent protected requests
+	Latency time.Duration
+	// Shed counts the requests turned away
+	Shed int64
+}
+
+// Shedder tracks the load of the server and sheds low-priority requests
+// while it is too high
+type Shedder struct {
+	cfg Config
+	now func() time.Time
+
+	inFlight atomic.Int64
+	shed     atomic.Int64
+
+	mutex     sync.Mutex
+	latency   float64
+	sampledAt time.Time
+}
+
+// New creates a Shedder
+func New(cfg Config) *Shedder {
+	return &Shedder{cfg: cfg, now: time.Now}
+}
+
+// Middleware counts the requests in flight, except those whose path starts
+// with one of exemptPrefixes, such as streams that stay open indefinitely
+func (s *Shedder) Middleware(exemptPrefixes ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			path := c.Request().URL.Path
+			for _, prefix := range exemptPrefixes {
+				if strings.HasPrefix(path, prefix) {
+					return next(c)
+				}
+			}
+			s.inFlight.Add(1)
+			defer s.inFlight.Add(-1)
+			return next(c)
+		}
+	}
+}
+
+// Protect marks the requests of a route as the ones shedding protects; their
+// latency tells whether the server is keeping up
+func (s *Shedder) Protect() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := s.now()
+			err := next(c)
+			s.observe(s.now().Sub(start))
+			return err
+		}
+	}
+}
+
+// Shed marks the requests of a route as low priority, answering 503 with a
+// Retry-After header while the server is overloaded
+func (s *Shedder) Shed() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			overloaded, reason := s.Overloaded()
+			if !overloaded {
+				return next(c)
+			}
+
+			s.shed.Add(1)
+			stats := s.Stats()
+			logging.FromContext(c.Request().Context()).Warn("Request shed", "route", c.Path(), "reason", reason,
+				"in_flight", stats.InFlight, "latency", stats.Latency.String())
+			c.Response().Header().Set(echo.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(s.cfg.RetryAfter.Seconds()))))
+			return problem.Write(c, http.StatusServiceUnavailable, "server overloaded, "+reason)
+		}
+	}
+}
+
+// Overloaded reports whether low-priority requests are shed, and why
+func (s *Shedder) Overloaded() (bool, string) {
+	if s.inFlight.Load() > int64(s.cfg.MaxInFlight) {
+		return true, "too many requests in flight"
+	}
+	if s.currentLatency() > s.cfg.TargetLatency {
+		return true, "requests are slow"
+	}
+	return false, ""
+}
+
+// Stats returns the current load and the requests shed so far
+func (s *Shedder) Stats() Stats {
+	return Stats{
+		InFlight: int(s.inFlight.Load()),
+		Latency:  s.currentLatency(),
+		Shed:     s.shed.Load(),
+	}
+}
+
+// observe adds the latency of a protected request to the average
+func (s *Shedder) observe(latency time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := s.now()
+	if now.Sub(s.sampledAt) > staleAfter {
+		s.latency = float64(latency)
+	} else {
+		s.latency += smoothing * (float64(latency) - s.latency)
+	}
+	s.sampledAt = now
+}
+
+// currentLatency returns the average latency, or zero once it is stale
+func (s *Shedder) currentLatency() time.Duration {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.now().Sub(s.sampledAt) > staleAfter {
+		return 0
+	}
+	return time.Duration(s.latency)
+}
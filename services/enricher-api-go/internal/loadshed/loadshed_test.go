@@ -0,0 +1,156 @@
+package loadshed
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// fakeClock is advanced manually by tests
+type fakeClock struct {
+	now time.Time
+}
+
+func (f *fakeClock) Now() time.Time { return f.now }
+
+// newTestServer serves a low-priority /items list, a protected /items/:id
+// lookup that takes lookupLatency on clock, if any, and blocks while hold is
+// open, and an exempt /stream that blocks the same way
+func newTestServer(shedder *Shedder, clock *fakeClock, lookupLatency time.Duration, hold chan struct{}) *echo.Echo {
+	blocking := func(c echo.Context) error {
+		<-hold
+		return c.NoContent(http.StatusOK)
+	}
+
+	e := echo.New()
+	e.Use(shedder.Middleware("/stream"))
+	e.GET("/items", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}, shedder.Shed())
+	e.GET("/items/:id", func(c echo.Context) error {
+		if clock != nil {
+			clock.now = clock.now.Add(lookupLatency)
+		}
+		return blocking(c)
+	}, shedder.Protect())
+	e.GET("/stream", blocking)
+	return e
+}
+
+func newTestShedder(cfg Config) (*Shedder, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2026, 10, 1, 10, 0, 0, 0, time.UTC)}
+	shedder := New(cfg)
+	shedder.now = clock.Now
+	return shedder, clock
+}
+
+func get(e *echo.Echo, path string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	return rec
+}
+
+// waitInFlight waits until shedder counts n requests in flight
+func waitInFlight(t *testing.T, shedder *Shedder, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for shedder.Stats().InFlight != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %d requests in flight, got %d", n, shedder.Stats().InFlight)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestShedder_ShedsWhenTooManyInFlight(t *testing.T) {
+	// Arrange: one lookup in flight, the most allowed, and a stream that
+	// does not count
+	shedder := New(Config{MaxInFlight: 1, TargetLatency: time.Second, RetryAfter: 1500 * time.Millisecond})
+	hold := make(chan struct{})
+	e := newTestServer(shedder, nil, 0, hold)
+	done := make(chan struct{})
+	for _, path := range []string{"/items/1", "/stream"} {
+		go func() {
+			get(e, path)
+			done <- struct{}{}
+		}()
+	}
+	waitInFlight(t, shedder, 1)
+
+	// Act
+	shed := get(e, "/items")
+	lookup := make(chan *httptest.ResponseRecorder)
+	go func() { lookup <- get(e, "/items/2") }()
+	waitInFlight(t, shedder, 2)
+	close(hold)
+	protected := <-lookup
+	<-done
+	<-done
+	recovered := get(e, "/items")
+
+	// Assert
+	if shed.Code != http.StatusServiceUnavailable || shed.Header().Get(echo.HeaderRetryAfter) != "2" {
+		t.Errorf("Expected 503 with Retry-After 2, got %d %q", shed.Code, shed.Header().Get(echo.HeaderRetryAfter))
+	}
+	if protected.Code != http.StatusOK {
+		t.Errorf("Expected the protected lookup to be served, got %d", protected.Code)
+	}
+	if recovered.Code != http.StatusOK {
+		t.Errorf("Expected shedding to stop once the load drops, got %d", recovered.Code)
+	}
+	if stats := shedder.Stats(); stats.Shed != 1 || stats.InFlight != 0 {
+		t.Errorf("Expected 1 request shed and none in flight, got %+v", stats)
+	}
+}
+
+func TestShedder_ShedsWhenProtectedRequestsAreSlow(t *testing.T) {
+	tests := []struct {
+		name     string
+		latency  time.Duration
+		idle     time.Duration
+		expected int
+	}{
+		{name: "fast lookups", latency: 100 * time.Millisecond, expected: http.StatusOK},
+		{name: "slow lookups", latency: 400 * time.Millisecond, expected: http.StatusServiceUnavailable},
+		{name: "slow lookups long ago", latency: 400 * time.Millisecond, idle: time.Minute, expected: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			shedder, clock := newTestShedder(Config{MaxInFlight: 100, TargetLatency: 250 * time.Millisecond, RetryAfter: time.Second})
+			hold := make(chan struct{})
+			close(hold)
+			e := newTestServer(shedder, clock, tt.latency, hold)
+			for i := 0; i < 3; i++ {
+				get(e, "/items/1")
+			}
+			clock.now = clock.now.Add(tt.idle)
+
+			// Act
+			rec := get(e, "/items")
+
+			// Assert
+			if rec.Code != tt.expected {
+				t.Errorf("Expected %d, got %d", tt.expected, rec.Code)
+			}
+		})
+	}
+}
+
+func TestShedder_Observe(t *testing.T) {
+	// Arrange
+	shedder, _ := newTestShedder(Config{})
+
+	// Act
+	shedder.observe(100 * time.Millisecond)
+	shedder.observe(600 * time.Millisecond)
+
+	// Assert
+	if latency := shedder.Stats().Latency; latency != 200*time.Millisecond {
+		t.Errorf("Expected an average of 200ms, got %s", latency)
+	}
+}
@@ -0,0 +1,121 @@
+// Package logging provides the structured logger used across the enricher API.
+//
+// Loggers travel in the request context: Middleware derives a logger tagged
+// with the request's X-Request-ID and stores it in the context, and services
+// retrieve it with FromContext so every line they write carries the ID.
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// maxRequestIDLength bounds client-supplied request IDs so they cannot bloat logs
+const maxRequestIDLength = 128
+
+type contextKey struct{}
+
+// New creates a JSON logger writing to w at the given level
+// (debug, info, warn or error; anything else means info).
+func New(level string, w io.Writer) *slog.Logger {
+	return NewLeveled(ParseLevel(level), w)
+}
+
+// NewLeveled creates a JSON logger writing to w at level; pass a
+// *slog.LevelVar to change the level while the logger is in use
+func NewLeveled(level slog.Leveler, w io.Writer) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level}))
+}
+
+// ParseLevel maps a configured level name to a slog level
+func ParseLevel(level string) slog.Level {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// WithLogger returns a copy of ctx carrying logger
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext returns the logger stored in ctx, or the default logger
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(contextKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// Middleware assigns every request an ID, echoes it in the X-Request-ID
+// response header, stores a logger tagged with it in the request context and
+// logs the completed request.
+//
+// A valid X-Request-ID sent by the client is reused so IDs propagate across
+// services; otherwise a random one is generated.
+func Middleware(base *slog.Logger) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+
+			requestID := req.Header.Get(echo.HeaderXRequestID)
+			if !validRequestID(requestID) {
+				requestID = newRequestID()
+			}
+			c.Response().Header().Set(echo.HeaderXRequestID, requestID)
+
+			logger := base.With("request_id", requestID)
+			c.SetRequest(req.WithContext(WithLogger(req.Context(), logger)))
+
+			start := time.Now()
+			err := next(c)
+			if err != nil {
+				c.Error(err)
+			}
+
+			logger.Info("Request completed",
+				"method", req.Method,
+				"path", req.URL.Path,
+				"status", c.Response().Status,
+				"duration_ms", time.Since(start).Milliseconds(),
+			)
+			return nil
+		}
+	}
+}
+
+// validRequestID accepts non-empty printable ASCII IDs of bounded length
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		if r < 0x21 || r > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID returns a random 128-bit hex ID
+func newRequestID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,103 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+// serve runs one request through Middleware and a handler that logs via the context
+func serve(t *testing.T, requestID string) (*httptest.ResponseRecorder, []map[string]interface{}) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	e := echo.New()
+	e.Use(Middleware(New("debug", &buf)))
+	e.GET("/ping", func(c echo.Context) error {
+		FromContext(c.Request().Context()).Info("Handling ping")
+		return c.String(http.StatusOK, "pong")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+	if requestID != "" {
+		req.Header.Set(echo.HeaderXRequestID, requestID)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	var entries []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("Expected JSON log line, got %q", line)
+		}
+		entries = append(entries, entry)
+	}
+
+	return rec, entries
+}
+
+func TestMiddleware_GeneratesRequestID(t *testing.T) {
+	// Act
+	rec, entries := serve(t, "")
+
+	// Assert
+	requestID := rec.Header().Get(echo.HeaderXRequestID)
+	if len(requestID) != 32 {
+		t.Fatalf("Expected a generated 32-character request ID, got %q", requestID)
+	}
+
+	if len(entries) != 2 {
+		t.Fatalf("Expected handler and access log lines, got %d", len(entries))
+	}
+
+	for _, entry := range entries {
+		if entry["request_id"] != requestID {
+			t.Errorf("Expected request_id %s on %v", requestID, entry)
+		}
+	}
+
+	if entries[1]["msg"] != "Request completed" || entries[1]["status"] != float64(http.StatusOK) {
+		t.Errorf("Unexpected access log line: %v", entries[1])
+	}
+}
+
+func TestMiddleware_PropagatesRequestID(t *testing.T) {
+	// Act
+	rec, entries := serve(t, "upstream-42")
+
+	// Assert
+	if got := rec.Header().Get(echo.HeaderXRequestID); got != "upstream-42" {
+		t.Errorf("Expected request ID 'upstream-42', got %q", got)
+	}
+
+	if entries[0]["request_id"] != "upstream-42" {
+		t.Errorf("Expected propagated request_id on log line, got %v", entries[0])
+	}
+}
+
+func TestMiddleware_ReplacesInvalidRequestID(t *testing.T) {
+	// Act
+	rec, _ := serve(t, strings.Repeat("x", maxRequestIDLength+1))
+
+	// Assert
+	if got := rec.Header().Get(echo.HeaderXRequestID); len(got) != 32 {
+		t.Errorf("Expected oversized request ID to be replaced, got %q", got)
+	}
+}
+
+func TestFromContext_DefaultsToDefaultLogger(t *testing.T) {
+	// Act
+	logger := FromContext(context.Background())
+
+	// Assert
+	if logger == nil {
+		t.Fatal("Expected a logger, got nil")
+	}
+}
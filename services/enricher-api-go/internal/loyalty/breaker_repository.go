@@ -0,0 +1,65 @@
+package loyalty
+
+import (
+	"errors"
+	"time"
+
+	"enricher-api-go/internal/breaker"
+)
+
+// BreakerRepository guards another Repository with a circuit breaker.
+//
+// Storage failures count against the breaker, while domain outcomes such as
+// ErrInsufficientPoints are returned unchanged without tripping it.
+type BreakerRepository struct {
+	repo    Repository
+	breaker *breaker.Breaker
+}
+
+// NewBreakerRepository wraps repo with b, typically the breaker shared by
+// every repository using the same backend
+func NewBreakerRepository(repo Repository, b *breaker.Breaker) *BreakerRepository {
+	return &BreakerRepository{repo: repo, breaker: b}
+}
+
+// GetByCustomerID retrieves the account of a customer
+func (r *BreakerRepository) GetByCustomerID(customerID string) (account *Account, err error) {
+	err = r.call(func() error {
+		account, err = r.repo.GetByCustomerID(customerID)
+		return err
+	})
+	return account, err
+}
+
+// Adjust accrues positive points and redeems negative ones
+func (r *BreakerRepository) Adjust(customerID string, points int, at time.Time) (account *Account, err error) {
+	err = r.call(func() error {
+		account, err = r.repo.Adjust(customerID, points, at)
+		return err
+	})
+	return account, err
+}
+
+// call runs fn through the breaker, passing domain errors through without
+// counting them as failures
+func (r *BreakerRepository) call(fn func() error) error {
+	var domainErr error
+	err := r.breaker.Execute(func() error {
+		err := fn()
+		if isDomainError(err) {
+			domainErr = err
+			return nil
+		}
+		return err
+	})
+	if domainErr != nil {
+		return domainErr
+	}
+	return err
+}
+
+// isDomainError reports whether err is an expected repository outcome rather
+// than a storage failure
+func isDomainError(err error) bool {
+	return errors.Is(err, ErrAccountNotFound) || errors.Is(err, ErrInsufficientPoints)
+}
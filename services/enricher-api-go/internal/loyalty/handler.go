@@ -0,0 +1,93 @@
+package loyalty
+
+import (
+	"context"
+	"net/http"
+
+	"enricher-api-go/internal/problem"
+	"enricher-api-go/internal/servertiming"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Handler handles HTTP requests for loyalty points
+type Handler struct {
+	service Service
+}
+
+// NewHandler creates a new loyalty handler
+func NewHandler(service Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+// GetAccount handles GET /v1/customers/:id/loyalty
+//
+// Example response:
+//
+//	{
+//		"customerId": "customer-123",
+//		"points": 350,
+//		"lifetimePoints": 1200,
+//		"tier": "SILVER",
+//		...
+//	}
+//
+// Error responses:
+//   - 404: Customer not found
+func (h *Handler) GetAccount(c echo.Context) error {
+	stop := servertiming.Start(c, "service")
+	account, err := h.service.GetAccount(c.Request().Context(), c.Param("id"))
+	stop()
+	if err != nil {
+		return problem.Error(c, err)
+	}
+
+	return c.JSON(http.StatusOK, account)
+}
+
+// Accrue handles POST /v1/customers/:id/loyalty/accrue
+//
+// Example request:
+//
+//	POST /v1/customers/customer-123/loyalty/accrue
+//	Content-Type: application/json
+//
+//	{
+//		"points": 150
+//	}
+//
+// Error responses:
+//   - 400: Invalid points
+//   - 404: Customer not found
+func (h *Handler) Accrue(c echo.Context) error {
+	return h.adjust(c, h.service.Accrue)
+}
+
+// Redeem handles POST /v1/customers/:id/loyalty/redeem
+//
+// Error responses:
+//   - 400: Invalid points
+//   - 404: Customer not found
+//   - 409: The points exceed the customer's balance
+func (h *Handler) Redeem(c echo.Context) error {
+	return h.adjust(c, h.service.Redeem)
+}
+
+// adjust binds a PointsRequest and applies it with apply
+func (h *Handler) adjust(c echo.Context, apply func(context.Context, string, PointsRequest) (*Account, error)) error {
+	var req PointsRequest
+	if err := servertiming.Measure(c, "bind", func() error { return c.Bind(&req) }); err != nil {
+		return problem.BindError(c, err)
+	}
+
+	stop := servertiming.Start(c, "service")
+	account, err := apply(c.Request().Context(), c.Param("id"), req)
+	stop()
+	if err != nil {
+		return problem.Error(c, err)
+	}
+
+	return c.JSON(http.StatusOK, account)
+}
@@ -0,0 +1,80 @@
+// Package loyalty keeps the loyalty points of customers and the tiers they
+// earn for the Resilient Order Enricher API.
+//
+// Customers accrue points, typically for their orders, and redeem them
+// later. Their tier follows the points they have accrued over their
+// lifetime, so redeeming points never demotes a customer.
+package loyalty
+
+import "time"
+
+// Loyalty tiers, from lowest to highest
+const (
+	// TierBronze is the tier of every customer, including one without points
+	TierBronze = "BRONZE"
+	// TierSilver is the tier of customers past the silver threshold
+	TierSilver = "SILVER"
+	// TierGold is the tier of customers past the gold threshold
+	TierGold = "GOLD"
+)
+
+// Account is the loyalty points balance of a customer.
+//
+// Example usage:
+//
+//	account := &Account{
+//		CustomerID:     "customer-456",
+//		Points:         350,
+//		LifetimePoints: 1200,
+//		Tier:           TierSilver,
+//	}
+type Account struct {
+	// CustomerID is the customer the points belong to
+	CustomerID string `json:"customerId" db:"customer_id"`
+	// Points is the balance left to redeem
+	Points int `json:"points" db:"points"`
+	// LifetimePoints is every point ever accrued, redeemed or not
+	LifetimePoints int `json:"lifetimePoints" db:"lifetime_points"`
+	// Tier is the tier LifetimePoints earn; it is not stored
+	Tier string `json:"tier" db:"-"`
+	// CreatedAt is when the customer first accrued points; zero for a
+	// customer that never has
+	CreatedAt time.Time `json:"createdAt,omitempty" db:"created_at"`
+	// UpdatedAt is when the balance last changed
+	UpdatedAt time.Time `json:"updatedAt,omitempty" db:"updated_at"`
+}
+
+// PointsRequest is the request body for accruing or redeeming points
+type PointsRequest struct {
+	// Points is the number of points to accrue or redeem
+	Points int `json:"points" validate:"required,gt=0"`
+}
+
+// Tiers are the lifetime points from which customers reach the tiers above
+// TierBronze. A zero threshold disables its tier.
+//
+// Example usage:
+//
+//	tiers := Tiers{SilverPoints: 500, GoldPoints: 2500}
+//	tier := tiers.Of(800) // TierSilver
+type Tiers struct {
+	// SilverPoints is the lifetime points from which a customer is SILVER
+	SilverPoints int
+	// GoldPoints is the lifetime points from which a customer is GOLD
+	GoldPoints int
+}
+
+// DefaultTiers are the tiers of a service created without WithTiers
+var DefaultTiers = Tiers{SilverPoints: 1000, GoldPoints: 5000}
+
+// Of returns the tier earned by lifetimePoints
+func (t Tiers) Of(lifetimePoints int) string {
+	switch {
+	case t.GoldPoints > 0 && lifetimePoints >= t.GoldPoints:
+		return TierGold
+	case t.SilverPoints > 0 && lifetimePoints >= t.SilverPoints:
+		return TierSilver
+	default:
+		return TierBronze
+	}
+}
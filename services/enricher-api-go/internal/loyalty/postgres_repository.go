@@ -0,0 +1,80 @@
+package loyalty
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// accountColumns lists the columns read by scanAccount, in order
+const accountColumns = `customer_id, points, lifetime_points, created_at, updated_at`
+
+// PostgresRepository implements Repository on top of a PostgreSQL database
+type PostgresRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresRepository creates a loyalty repository backed by db.
+//
+// The caller owns db and is responsible for opening and closing it; the
+// loyalty_accounts table must exist (see the migrations package).
+func NewPostgresRepository(db *sql.DB) *PostgresRepository {
+	return &PostgresRepository{db: db}
+}
+
+// GetByCustomerID retrieves the account of a customer
+func (r *PostgresRepository) GetByCustomerID(customerID string) (*Account, error) {
+	account, err := scanAccount(r.db.QueryRow(`SELECT `+accountColumns+` FROM loyalty_accounts WHERE customer_id = $1`, customerID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAccountNotFound
+	}
+	return account, err
+}
+
+// Adjust accrues positive points, upserting the account, and redeems
+// negative ones with a conditional update
+func (r *PostgresRepository) Adjust(customerID string, points int, at time.Time) (*Account, error) {
+	var row *sql.Row
+	if points > 0 {
+		row = r.db.QueryRow(
+			`INSERT INTO loyalty_accounts (customer_id, points, lifetime_points, created_at, updated_at)
+			VALUES ($1, $2, $2, $3, $3)
+			ON CONFLICT (customer_id) DO UPDATE SET
+				points = loyalty_accounts.points + EXCLUDED.points,
+				lifetime_points = loyalty_accounts.lifetime_points + EXCLUDED.points,
+				updated_at = EXCLUDED.updated_at
+			RETURNING `+accountColumns,
+			customerID, points, at,
+		)
+	} else {
+		row = r.db.QueryRow(
+			`UPDATE loyalty_accounts SET points = points + $2, updated_at = $3
+			WHERE customer_id = $1 AND points + $2 >= 0
+			RETURNING `+accountColumns,
+			customerID, points, at,
+		)
+	}
+
+	account, err := scanAccount(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInsufficientPoints
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to adjust loyalty points: %w", err)
+	}
+	return account, nil
+}
+
+// scanAccount reads one account row in accountColumns order
+func scanAccount(row *sql.Row) (*Account, error) {
+	var account Account
+	err := row.Scan(&account.CustomerID, &account.Points, &account.LifetimePoints, &account.CreatedAt, &account.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan loyalty account: %w", err)
+	}
+	return &account, nil
+}
@@ -0,0 +1,92 @@
+package loyalty
+
+import (
+	"sync"
+	"time"
+
+	"enricher-api-go/internal/apperr"
+)
+
+var (
+	// ErrAccountNotFound is returned when a customer has never accrued points
+	ErrAccountNotFound = apperr.New(apperr.ErrNotFound, "loyalty account not found")
+	// ErrInsufficientPoints is returned when redeeming more points than the
+	// balance holds
+	ErrInsufficientPoints = apperr.New(apperr.ErrConflict, "insufficient loyalty points")
+)
+
+// Repository defines the interface for loyalty account data access.
+//
+// Adjust changes a balance in one step, so concurrent accruals and
+// redemptions never lose points or overdraw an account. Positive points
+// accrue, creating the account on the first accrual and adding to
+// LifetimePoints; negative points redeem, failing with ErrInsufficientPoints
+// and changing nothing when the balance, or the account, is missing them.
+// Repositories leave Tier empty.
+type Repository interface {
+	GetByCustomerID(customerID string) (*Account, error)
+	Adjust(customerID string, points int, at time.Time) (*Account, error)
+}
+
+// InMemoryRepository implements Repository interface using in-memory storage
+type InMemoryRepository struct {
+	accounts map[string]*Account
+	mutex    sync.RWMutex
+}
+
+// NewInMemoryRepository creates a new, empty in-memory loyalty repository
+func NewInMemoryRepository() *InMemoryRepository {
+	return &InMemoryRepository{
+		accounts: make(map[string]*Account),
+		mutex:    sync.RWMutex{},
+	}
+}
+
+// Reset removes every account
+func (r *InMemoryRepository) Reset() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.accounts = make(map[string]*Account)
+}
+
+// GetByCustomerID retrieves the account of a customer
+func (r *InMemoryRepository) GetByCustomerID(customerID string) (*Account, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	account, exists := r.accounts[customerID]
+	if !exists {
+		return nil, ErrAccountNotFound
+	}
+	accountCopy := *account
+	return &accountCopy, nil
+}
+
+// Adjust accrues positive points and redeems negative ones
+func (r *InMemoryRepository) Adjust(customerID string, points int, at time.Time) (*Account, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	account, exists := r.accounts[customerID]
+	if !exists {
+		if points < 0 {
+			return nil, ErrInsufficientPoints
+		}
+		account = &Account{CustomerID: customerID, CreatedAt: at}
+	}
+	if account.Points+points < 0 {
+		return nil, ErrInsufficientPoints
+	}
+
+	adjusted := *account
+	adjusted.Points += points
+	if points > 0 {
+		adjusted.LifetimePoints += points
+	}
+	adjusted.UpdatedAt = at
+	r.accounts[customerID] = &adjusted
+
+	accountCopy := adjusted
+	return &accountCopy, nil
+}
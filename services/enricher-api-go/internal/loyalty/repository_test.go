@@ -0,0 +1,137 @@
+package loyalty
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"enricher-api-go/internal/migrations"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// testRepositoryConformance runs the behavior every Repository implementation
+// must share against a repository created by newRepo.
+func testRepositoryConformance(t *testing.T, newRepo func(t *testing.T) Repository) {
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Accrue and redeem", func(t *testing.T) {
+		repo := newRepo(t)
+
+		if _, err := repo.Adjust("customer-conformance", 300, at); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if _, err := repo.Adjust("customer-conformance", -120, at.Add(time.Hour)); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		account, err := repo.GetByCustomerID("customer-conformance")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if account.Points != 180 || account.LifetimePoints != 300 {
+			t.Errorf("Expected 180 points of 300 accrued, got %+v", account)
+		}
+		if !account.CreatedAt.Equal(at) || !account.UpdatedAt.Equal(at.Add(time.Hour)) {
+			t.Errorf("Expected created at the first accrual and updated at the redemption, got %+v", account)
+		}
+	})
+
+	t.Run("Insufficient points", func(t *testing.T) {
+		repo := newRepo(t)
+		if _, err := repo.Adjust("customer-conformance", 100, at); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if _, err := repo.Adjust("customer-conformance", -101, at); !errors.Is(err, ErrInsufficientPoints) {
+			t.Errorf("Expected ErrInsufficientPoints, got %v", err)
+		}
+		if _, err := repo.Adjust("customer-without-points", -1, at); !errors.Is(err, ErrInsufficientPoints) {
+			t.Errorf("Expected ErrInsufficientPoints without an account, got %v", err)
+		}
+
+		account, err := repo.GetByCustomerID("customer-conformance")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if account.Points != 100 {
+			t.Errorf("Expected the balance unchanged, got %d", account.Points)
+		}
+	})
+
+	t.Run("Missing", func(t *testing.T) {
+		repo := newRepo(t)
+
+		if _, err := repo.GetByCustomerID("customer-without-points"); !errors.Is(err, ErrAccountNotFound) {
+			t.Errorf("Expected ErrAccountNotFound, got %v", err)
+		}
+	})
+
+	t.Run("Concurrent redemptions", func(t *testing.T) {
+		repo := newRepo(t)
+		if _, err := repo.Adjust("customer-conformance", 10, at); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		var wg sync.WaitGroup
+		var mutex sync.Mutex
+		redeemed := 0
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := repo.Adjust("customer-conformance", -1, at); err == nil {
+					mutex.Lock()
+					redeemed++
+					mutex.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+
+		if redeemed != 10 {
+			t.Errorf("Expected exactly 10 redemptions to succeed, got %d", redeemed)
+		}
+	})
+}
+
+func TestInMemoryRepository_Conformance(t *testing.T) {
+	testRepositoryConformance(t, func(t *testing.T) Repository {
+		return NewInMemoryRepository()
+	})
+}
+
+// TestPostgresRepository_Conformance runs against the database in
+// POSTGRES_TEST_DSN and is skipped when it is not set.
+func TestPostgresRepository_Conformance(t *testing.T) {
+	dsn := os.Getenv("POSTGRES_TEST_DSN")
+	if dsn == "" {
+		t.Skip("POSTGRES_TEST_DSN not set")
+	}
+
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := migrations.New(db)
+	if err != nil {
+		t.Fatalf("Failed to load migrations: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+
+	testRepositoryConformance(t, func(t *testing.T) Repository {
+		repo := NewPostgresRepository(db)
+		if _, err := db.Exec(`TRUNCATE loyalty_accounts`); err != nil {
+			t.Fatalf("Failed to reset loyalty accounts: %v", err)
+		}
+		return repo
+	})
+}
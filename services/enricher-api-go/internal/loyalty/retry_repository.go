@@ -0,0 +1,39 @@
+package loyalty
+
+import (
+	"time"
+
+	"enricher-api-go/internal/retry"
+)
+
+// RetryRepository retries the calls of another Repository that fail
+// transiently: reads on any transient failure, writes only on failures that
+// show nothing was written.
+type RetryRepository struct {
+	repo    Repository
+	retrier *retry.Retrier
+}
+
+// NewRetryRepository wraps repo with retrier, typically the retrier shared
+// by every repository using the same backend
+func NewRetryRepository(repo Repository, retrier *retry.Retrier) *RetryRepository {
+	return &RetryRepository{repo: repo, retrier: retrier}
+}
+
+// GetByCustomerID retrieves the account of a customer
+func (r *RetryRepository) GetByCustomerID(customerID string) (account *Account, err error) {
+	err = r.retrier.Read(func() error {
+		account, err = r.repo.GetByCustomerID(customerID)
+		return err
+	})
+	return account, err
+}
+
+// Adjust accrues positive points and redeems negative ones
+func (r *RetryRepository) Adjust(customerID string, points int, at time.Time) (account *Account, err error) {
+	err = r.retrier.Write(func() error {
+		account, err = r.repo.Adjust(customerID, points, at)
+		return err
+	})
+	return account, err
+}
@@ -0,0 +1,177 @@
+package loyalty
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"enricher-api-go/internal/apperr"
+	"enricher-api-go/internal/clock"
+	"enricher-api-go/internal/logging"
+	"enricher-api-go/internal/validation"
+)
+
+// CustomerCheck verifies that customerID is a live customer, returning the
+// customer lookup's error, such as customer.ErrCustomerNotFound, if not
+type CustomerCheck func(ctx context.Context, customerID string) error
+
+// Service defines the business logic interface for loyalty points
+type Service interface {
+	GetAccount(ctx context.Context, customerID string) (*Account, error)
+	Accrue(ctx context.Context, customerID string, req PointsRequest) (*Account, error)
+	Redeem(ctx context.Context, customerID string, req PointsRequest) (*Account, error)
+	Tier(ctx context.Context, customerID string) (string, error)
+}
+
+// LoyaltyService implements the Service interface
+type LoyaltyService struct {
+	repo          Repository
+	customerCheck CustomerCheck
+	tiers         Tiers
+	clock         clock.Clock
+}
+
+// Option configures optional LoyaltyService behavior
+type Option func(*LoyaltyService)
+
+// WithClock sets the clock used for CreatedAt and UpdatedAt (the UTC wall clock by default)
+func WithClock(c clock.Clock) Option {
+	return func(s *LoyaltyService) {
+		s.clock = c
+	}
+}
+
+// WithTiers sets the lifetime points from which customers reach each tier
+// (DefaultTiers by default)
+func WithTiers(tiers Tiers) Option {
+	return func(s *LoyaltyService) {
+		s.tiers = tiers
+	}
+}
+
+// WithCustomerCheck sets how the service verifies that a customer exists
+// before reading or changing its points. Without it points are kept for any
+// customer ID.
+func WithCustomerCheck(check CustomerCheck) Option {
+	return func(s *LoyaltyService) {
+		s.customerCheck = check
+	}
+}
+
+// NewService creates a new loyalty service
+func NewService(repo Repository, opts ...Option) *LoyaltyService {
+	s := &LoyaltyService{
+		repo:  repo,
+		tiers: DefaultTiers,
+		clock: clock.System{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// GetAccount returns the points and tier of a customer; a customer that has
+// never accrued points has an empty TierBronze account
+func (s *LoyaltyService) GetAccount(ctx context.Context, customerID string) (*Account, error) {
+	logger := logging.FromContext(ctx)
+	logger.Debug("Getting loyalty account", "customer_id", customerID)
+
+	if err := s.checkCustomer(ctx, customerID); err != nil {
+		return nil, err
+	}
+
+	account, err := s.account(customerID)
+	if err != nil {
+		logger.Error("Failed to get loyalty account", "customer_id", customerID, "error", err)
+		return nil, err
+	}
+	return account, nil
+}
+
+// Accrue adds points to a customer's balance and lifetime points, which may
+// promote it to a higher tier
+func (s *LoyaltyService) Accrue(ctx context.Context, customerID string, req PointsRequest) (*Account, error) {
+	logger := logging.FromContext(ctx)
+	logger.Info("Accruing loyalty points", "customer_id", customerID, "points", req.Points)
+
+	account, err := s.adjust(ctx, customerID, req, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("Accrued loyalty points", "customer_id", customerID, "points", account.Points, "tier", account.Tier)
+	return account, nil
+}
+
+// Redeem takes points from a customer's balance. Its tier, earned by
+// lifetime points, is kept.
+func (s *LoyaltyService) Redeem(ctx context.Context, customerID string, req PointsRequest) (*Account, error) {
+	logger := logging.FromContext(ctx)
+	logger.Info("Redeeming loyalty points", "customer_id", customerID, "points", req.Points)
+
+	account, err := s.adjust(ctx, customerID, req, -1)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("Redeemed loyalty points", "customer_id", customerID, "points", account.Points)
+	return account, nil
+}
+
+// Tier returns the tier of a customer, TierBronze if it has never accrued
+// points. It does not check that the customer exists.
+func (s *LoyaltyService) Tier(ctx context.Context, customerID string) (string, error) {
+	account, err := s.account(customerID)
+	if err != nil {
+		logging.FromContext(ctx).Warn("Failed to get loyalty tier", "customer_id", customerID, "error", err)
+		return "", err
+	}
+	return account.Tier, nil
+}
+
+// adjust validates req and applies its points to a customer's balance, with
+// sign 1 to accrue and -1 to redeem
+func (s *LoyaltyService) adjust(ctx context.Context, customerID string, req PointsRequest, sign int) (*Account, error) {
+	if err := validation.Struct(&req); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	if err := s.checkCustomer(ctx, customerID); err != nil {
+		return nil, err
+	}
+
+	account, err := s.repo.Adjust(customerID, sign*req.Points, s.clock.Now())
+	if err != nil {
+		if !errors.Is(err, ErrInsufficientPoints) {
+			logging.FromContext(ctx).Error("Failed to adjust loyalty points", "customer_id", customerID, "error", err)
+		}
+		return nil, fmt.Errorf("failed to adjust loyalty points: %w", err)
+	}
+	account.Tier = s.tiers.Of(account.LifetimePoints)
+	return account, nil
+}
+
+// account reads a customer's account with its tier, or an empty one if the
+// customer has never accrued points
+func (s *LoyaltyService) account(customerID string) (*Account, error) {
+	account, err := s.repo.GetByCustomerID(customerID)
+	if errors.Is(err, ErrAccountNotFound) {
+		account, err = &Account{CustomerID: customerID}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get loyalty account: %w", err)
+	}
+	account.Tier = s.tiers.Of(account.LifetimePoints)
+	return account, nil
+}
+
+// checkCustomer verifies that customerID names a live customer
+func (s *LoyaltyService) checkCustomer(ctx context.Context, customerID string) error {
+	if customerID == "" {
+		return apperr.New(apperr.ErrValidation, "customer ID cannot be empty")
+	}
+	if s.customerCheck == nil {
+		return nil
+	}
+	return s.customerCheck(ctx, customerID)
+}
@@ -0,0 +1,164 @@
+package loyalty
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"enricher-api-go/internal/apperr"
+	"enricher-api-go/internal/clock"
+	"enricher-api-go/internal/validation"
+)
+
+// errUnknownCustomer stands in for the customer lookup's not-found error
+var errUnknownCustomer = apperr.New(apperr.ErrNotFound, "customer not found")
+
+// knownCustomers is a CustomerCheck accepting only the given customer IDs
+func knownCustomers(customerIDs ...string) CustomerCheck {
+	return func(ctx context.Context, customerID string) error {
+		for _, known := range customerIDs {
+			if customerID == known {
+				return nil
+			}
+		}
+		return errUnknownCustomer
+	}
+}
+
+func TestTiers_Of(t *testing.T) {
+	tests := []struct {
+		name   string
+		tiers  Tiers
+		points int
+		want   string
+	}{
+		{name: "no points", tiers: DefaultTiers, points: 0, want: TierBronze},
+		{name: "below silver", tiers: DefaultTiers, points: 999, want: TierBronze},
+		{name: "silver", tiers: DefaultTiers, points: 1000, want: TierSilver},
+		{name: "gold", tiers: DefaultTiers, points: 5000, want: TierGold},
+		{name: "silver disabled", tiers: Tiers{GoldPoints: 5000}, points: 4999, want: TierBronze},
+		{name: "gold disabled", tiers: Tiers{SilverPoints: 1000}, points: 100000, want: TierSilver},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			tier := tt.tiers.Of(tt.points)
+
+			// Assert
+			if tier != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, tier)
+			}
+		})
+	}
+}
+
+func TestLoyaltyService_AccrueAndRedeem(t *testing.T) {
+	// Arrange
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	service := NewService(NewInMemoryRepository(), WithClock(clock.Fixed(now)), WithCustomerCheck(knownCustomers("customer-123")))
+	ctx := context.Background()
+
+	// Act
+	accrued, accrueErr := service.Accrue(ctx, "customer-123", PointsRequest{Points: 1200})
+	redeemed, redeemErr := service.Redeem(ctx, "customer-123", PointsRequest{Points: 1000})
+
+	// Assert
+	if accrueErr != nil || redeemErr != nil {
+		t.Fatalf("Expected no errors, got %v and %v", accrueErr, redeemErr)
+	}
+	if accrued.Points != 1200 || accrued.Tier != TierSilver || !accrued.UpdatedAt.Equal(now) {
+		t.Errorf("Expected a SILVER account with 1200 points, got %+v", accrued)
+	}
+	if redeemed.Points != 200 || redeemed.LifetimePoints != 1200 || redeemed.Tier != TierSilver {
+		t.Errorf("Expected 200 points left and the tier kept, got %+v", redeemed)
+	}
+}
+
+func TestLoyaltyService_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		act     func(service *LoyaltyService) error
+		wantErr error
+		// wantInvalid expects the fields of a *validation.Error instead
+		wantInvalid bool
+	}{
+		{
+			name: "redeem more than the balance",
+			act: func(service *LoyaltyService) error {
+				_, err := service.Redeem(context.Background(), "customer-123", PointsRequest{Points: 1})
+				return err
+			},
+			wantErr: ErrInsufficientPoints,
+		},
+		{
+			name: "unknown customer",
+			act: func(service *LoyaltyService) error {
+				_, err := service.Accrue(context.Background(), "customer-missing", PointsRequest{Points: 10})
+				return err
+			},
+			wantErr: errUnknownCustomer,
+		},
+		{
+			name: "no points",
+			act: func(service *LoyaltyService) error {
+				_, err := service.Accrue(context.Background(), "customer-123", PointsRequest{})
+				return err
+			},
+			wantInvalid: true,
+		},
+		{
+			name: "empty customer ID",
+			act: func(service *LoyaltyService) error {
+				_, err := service.GetAccount(context.Background(), "")
+				return err
+			},
+			wantErr: apperr.ErrValidation,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			service := NewService(NewInMemoryRepository(), WithCustomerCheck(knownCustomers("customer-123")))
+
+			// Act
+			err := tt.act(service)
+
+			// Assert
+			if tt.wantInvalid {
+				var validationErr *validation.Error
+				if !errors.As(err, &validationErr) {
+					t.Errorf("Expected a validation error, got %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestLoyaltyService_GetAccount_WithoutPoints(t *testing.T) {
+	// Arrange
+	service := NewService(NewInMemoryRepository(), WithTiers(Tiers{SilverPoints: 100, GoldPoints: 500}))
+	ctx := context.Background()
+
+	// Act
+	empty, emptyErr := service.GetAccount(ctx, "customer-123")
+	_, _ = service.Accrue(ctx, "customer-456", PointsRequest{Points: 500})
+	tier, tierErr := service.Tier(ctx, "customer-456")
+
+	// Assert
+	if emptyErr != nil || tierErr != nil {
+		t.Fatalf("Expected no errors, got %v and %v", emptyErr, tierErr)
+	}
+	if empty.CustomerID != "customer-123" || empty.Points != 0 || empty.Tier != TierBronze {
+		t.Errorf("Expected an empty BRONZE account, got %+v", empty)
+	}
+	if tier != TierGold {
+		t.Errorf("Expected GOLD under the configured tiers, got %s", tier)
+	}
+}
@@ -0,0 +1,72 @@
+package media
+
+import (
+	"errors"
+
+	"enricher-api-go/internal/breaker"
+)
+
+// BreakerRepository guards another Repository with a circuit breaker.
+//
+// Storage failures count against the breaker, while domain outcomes such as
+// ErrImageNotFound are returned unchanged without tripping it.
+type BreakerRepository struct {
+	repo    Repository
+	breaker *breaker.Breaker
+}
+
+// NewBreakerRepository wraps repo with b, typically the breaker shared by
+// every repository using the same backend
+func NewBreakerRepository(repo Repository, b *breaker.Breaker) *BreakerRepository {
+	return &BreakerRepository{repo: repo, breaker: b}
+}
+
+// Create stores a new image record
+func (r *BreakerRepository) Create(image *Image) error {
+	return r.call(func() error {
+		return r.repo.Create(image)
+	})
+}
+
+// GetByID retrieves an image record by ID
+func (r *BreakerRepository) GetByID(imageID string) (image *Image, err error) {
+	err = r.call(func() error {
+		image, err = r.repo.GetByID(imageID)
+		return err
+	})
+	return image, err
+}
+
+// ListByProducts returns the image records of productIDs, oldest first
+func (r *BreakerRepository) ListByProducts(productIDs []string) (images []*Image, err error) {
+	err = r.call(func() error {
+		images, err = r.repo.ListByProducts(productIDs)
+		return err
+	})
+	return images, err
+}
+
+// Delete removes an image record
+func (r *BreakerRepository) Delete(imageID string) error {
+	return r.call(func() error {
+		return r.repo.Delete(imageID)
+	})
+}
+
+// call runs fn through the breaker, passing domain errors through without
+// counting them as failures
+func (r *BreakerRepository) call(fn func() error) error {
+	var domainErr error
+	err := r.breaker.Execute(func() error {
+		err := fn()
+		if errors.Is(err, ErrImageNotFound) {
+			domainErr = err
+			return nil
+		}
+		return err
+	})
+	if domainErr != nil {
+		return domainErr
+	}
+	return err
+}
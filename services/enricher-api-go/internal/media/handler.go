@@ -0,0 +1,128 @@
+package media
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"enricher-api-go/internal/problem"
+	"enricher-api-go/internal/servertiming"
+
+	"github.com/labstack/echo/v4"
+)
+
+// errNoFile is answered when an upload has no file part
+var errNoFile = errors.New("expected a multipart/form-data upload with a file field")
+
+// Handler handles HTTP requests for product images
+type Handler struct {
+	service Service
+}
+
+// NewHandler creates a new media handler
+func NewHandler(service Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+// UploadImage handles POST /v1/products/:id/images
+//
+// The body is a multipart/form-data upload whose file field holds a JPEG,
+// PNG or GIF image. The image and its thumbnail are stored and their URLs
+// answered with 201; the image is then listed in the product's responses.
+//
+// Example request:
+//
+//	curl -X POST http://localhost:8080/v1/products/product-123/images \
+//		-F file=@laptop.jpg
+//
+// Example response:
+//
+//	{
+//		"imageId": "image-789",
+//		"productId": "product-123",
+//		"contentType": "image/jpeg",
+//		"url": "/media/products/product-123/image-789.jpg",
+//		"thumbnailUrl": "/media/products/product-123/image-789-thumb.jpg",
+//		...
+//	}
+//
+// Error responses:
+//   - 400: No file, or not a JPEG, PNG or GIF image
+//   - 404: Product not found
+//   - 413: The image exceeds the maximum upload size
+//   - 503: The media store is unavailable
+func (h *Handler) UploadImage(c echo.Context) error {
+	reader, err := c.Request().MultipartReader()
+	if err != nil {
+		return problem.Write(c, http.StatusBadRequest, errNoFile.Error())
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			return problem.Write(c, http.StatusBadRequest, errNoFile.Error())
+		}
+		if err != nil {
+			return problem.Write(c, http.StatusBadRequest, "Invalid multipart body")
+		}
+		if part.FormName() != "file" {
+			part.Close()
+			continue
+		}
+		defer part.Close()
+
+		stop := servertiming.Start(c, "service")
+		image, err := h.service.UploadImage(c.Request().Context(), c.Param("id"), part)
+		stop()
+		if err != nil {
+			return imageError(c, err)
+		}
+
+		c.Response().Header().Set(echo.HeaderLocation, image.URL)
+		return c.JSON(http.StatusCreated, image)
+	}
+}
+
+// ListImages handles GET /v1/products/:id/images
+//
+// Error responses:
+//   - 404: Product not found
+func (h *Handler) ListImages(c echo.Context) error {
+	stop := servertiming.Start(c, "service")
+	images, err := h.service.ListImages(c.Request().Context(), c.Param("id"))
+	stop()
+	if err != nil {
+		return imageError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"images": images,
+		"count":  len(images),
+	})
+}
+
+// DeleteImage handles DELETE /v1/products/:id/images/:imageId
+//
+// Error responses:
+//   - 404: Product or image not found
+func (h *Handler) DeleteImage(c echo.Context) error {
+	stop := servertiming.Start(c, "service")
+	err := h.service.DeleteImage(c.Request().Context(), c.Param("id"), c.Param("imageId"))
+	stop()
+	if err != nil {
+		return imageError(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// imageError answers a failed image operation: 413 for an oversized upload
+// and the status of the error's kind otherwise
+func imageError(c echo.Context, err error) error {
+	if errors.Is(err, ErrImageTooLarge) {
+		return problem.Write(c, http.StatusRequestEntityTooLarge, err.Error())
+	}
+	return problem.Error(c, err)
+}
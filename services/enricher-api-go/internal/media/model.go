@@ -0,0 +1,47 @@
+// Package media keeps the images uploaded for products of the Resilient
+// Order Enricher API.
+//
+// An upload is checked to be a JPEG, PNG or GIF image and stored, together
+// with a downscaled thumbnail, in a Store: a local directory the API serves
+// itself or an S3 bucket. Image records keep the keys of both files and are
+// resolved to URLs when read, so a store can move behind a new base URL
+// without rewriting them.
+package media
+
+import "time"
+
+// Image is an uploaded image of a product.
+//
+// Example usage:
+//
+//	image := &Image{
+//		ImageID:     "image-789",
+//		ProductID:   "product-123",
+//		ContentType: "image/jpeg",
+//		Size:        482113,
+//		Width:       1600,
+//		Height:      1200,
+//	}
+type Image struct {
+	// ImageID is the unique identifier for the image
+	ImageID string `json:"imageId" db:"image_id"`
+	// ProductID is the product the image shows
+	ProductID string `json:"productId" db:"product_id"`
+	// ContentType is the detected format of the image, such as image/png
+	ContentType string `json:"contentType" db:"content_type"`
+	// Size is the length of the image in bytes
+	Size int64 `json:"size" db:"size"`
+	// Width and Height are the size of the image in pixels
+	Width  int `json:"width" db:"width"`
+	Height int `json:"height" db:"height"`
+	// Key and ThumbnailKey address the image and its thumbnail in the store
+	Key          string `json:"-" db:"key"`
+	ThumbnailKey string `json:"-" db:"thumbnail_key"`
+	// URL and ThumbnailURL are where the store serves them; they are not stored
+	URL          string `json:"url" db:"-"`
+	ThumbnailURL string `json:"thumbnailUrl" db:"-"`
+	// CreatedAt is when the image was uploaded
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	// CreatedBy is the caller that uploaded the image, if authenticated
+	CreatedBy string `json:"createdBy,omitempty" db:"created_by"`
+}
@@ -0,0 +1,101 @@
+package media
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// imageColumns lists the columns read by scanImage, in order
+const imageColumns = `image_id, product_id, content_type, size, width, height, key, thumbnail_key, created_at, created_by`
+
+// PostgresRepository implements Repository on top of a PostgreSQL database
+type PostgresRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresRepository creates an image repository backed by db.
+//
+// The caller owns db and is responsible for opening and closing it; the
+// product_images table must exist (see the migrations package).
+func NewPostgresRepository(db *sql.DB) *PostgresRepository {
+	return &PostgresRepository{db: db}
+}
+
+// Create stores a new image record
+func (r *PostgresRepository) Create(image *Image) error {
+	_, err := r.db.Exec(
+		`INSERT INTO product_images (`+imageColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		image.ImageID, image.ProductID, image.ContentType, image.Size, image.Width, image.Height,
+		image.Key, image.ThumbnailKey, image.CreatedAt, image.CreatedBy,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create image: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves an image record by ID
+func (r *PostgresRepository) GetByID(imageID string) (*Image, error) {
+	image, err := scanImage(r.db.QueryRow(`SELECT `+imageColumns+` FROM product_images WHERE image_id = $1`, imageID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrImageNotFound
+	}
+	return image, err
+}
+
+// ListByProducts returns the image records of productIDs, oldest first
+func (r *PostgresRepository) ListByProducts(productIDs []string) ([]*Image, error) {
+	rows, err := r.db.Query(
+		`SELECT `+imageColumns+` FROM product_images WHERE product_id = ANY($1) ORDER BY created_at, image_id`,
+		productIDs,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list images: %w", err)
+	}
+	defer rows.Close()
+
+	images := make([]*Image, 0)
+	for rows.Next() {
+		image, err := scanImage(rows)
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, image)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list images: %w", err)
+	}
+	return images, nil
+}
+
+// Delete removes an image record
+func (r *PostgresRepository) Delete(imageID string) error {
+	result, err := r.db.Exec(`DELETE FROM product_images WHERE image_id = $1`, imageID)
+	if err != nil {
+		return fmt.Errorf("failed to delete image: %w", err)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrImageNotFound
+	}
+	return nil
+}
+
+// rowScanner is satisfied by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanImage reads one image row in imageColumns order
+func scanImage(row rowScanner) (*Image, error) {
+	var image Image
+	err := row.Scan(&image.ImageID, &image.ProductID, &image.ContentType, &image.Size, &image.Width, &image.Height,
+		&image.Key, &image.ThumbnailKey, &image.CreatedAt, &image.CreatedBy)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan image: %w", err)
+	}
+	return &image, nil
+}
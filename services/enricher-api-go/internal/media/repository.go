@@ -0,0 +1,106 @@
+package media
+
+import (
+	"sort"
+	"sync"
+
+	"enricher-api-go/internal/apperr"
+)
+
+// ErrImageNotFound is returned when no image of the product has the requested ID
+var ErrImageNotFound = apperr.New(apperr.ErrNotFound, "image not found")
+
+// Repository defines the interface for image record data access.
+//
+// Records hold the store keys of an image and its thumbnail and leave URL
+// and ThumbnailURL empty. ListByProducts returns the images of every listed
+// product, oldest first.
+type Repository interface {
+	Create(image *Image) error
+	GetByID(imageID string) (*Image, error)
+	ListByProducts(productIDs []string) ([]*Image, error)
+	Delete(imageID string) error
+}
+
+// InMemoryRepository implements Repository interface using in-memory storage
+type InMemoryRepository struct {
+	images map[string]*Image
+	mutex  sync.RWMutex
+}
+
+// NewInMemoryRepository creates a new, empty in-memory image repository
+func NewInMemoryRepository() *InMemoryRepository {
+	return &InMemoryRepository{
+		images: make(map[string]*Image),
+		mutex:  sync.RWMutex{},
+	}
+}
+
+// Reset removes every image record; the stored files are left in place
+func (r *InMemoryRepository) Reset() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.images = make(map[string]*Image)
+}
+
+// Create stores a new image record
+func (r *InMemoryRepository) Create(image *Image) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	imageCopy := *image
+	r.images[image.ImageID] = &imageCopy
+	return nil
+}
+
+// GetByID retrieves an image record by ID
+func (r *InMemoryRepository) GetByID(imageID string) (*Image, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	image, exists := r.images[imageID]
+	if !exists {
+		return nil, ErrImageNotFound
+	}
+	imageCopy := *image
+	return &imageCopy, nil
+}
+
+// ListByProducts returns the image records of productIDs, oldest first
+func (r *InMemoryRepository) ListByProducts(productIDs []string) ([]*Image, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	wanted := make(map[string]bool, len(productIDs))
+	for _, productID := range productIDs {
+		wanted[productID] = true
+	}
+
+	images := make([]*Image, 0)
+	for _, image := range r.images {
+		if wanted[image.ProductID] {
+			imageCopy := *image
+			images = append(images, &imageCopy)
+		}
+	}
+	sort.Slice(images, func(i, j int) bool {
+		if !images[i].CreatedAt.Equal(images[j].CreatedAt) {
+			return images[i].CreatedAt.Before(images[j].CreatedAt)
+		}
+		return images[i].ImageID < images[j].ImageID
+	})
+	return images, nil
+}
+
+// Delete removes an image record
+func (r *InMemoryRepository) Delete(imageID string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.images[imageID]; !exists {
+		return ErrImageNotFound
+	}
+	delete(r.images, imageID)
+	return nil
+}
@@ -0,0 +1,131 @@
+package media
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"enricher-api-go/internal/migrations"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// testRepositoryConformance runs the behavior every Repository implementation
+// must share against a repository created by newRepo.
+func testRepositoryConformance(t *testing.T, newRepo func(t *testing.T) Repository) {
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	newImage := func(imageID, productID string, createdAt time.Time) *Image {
+		return &Image{
+			ImageID:      imageID,
+			ProductID:    productID,
+			ContentType:  "image/png",
+			Size:         2048,
+			Width:        640,
+			Height:       480,
+			Key:          "products/" + productID + "/" + imageID + ".png",
+			ThumbnailKey: "products/" + productID + "/" + imageID + "-thumb.png",
+			CreatedAt:    createdAt,
+			CreatedBy:    "user-1",
+		}
+	}
+
+	t.Run("Create and get", func(t *testing.T) {
+		repo := newRepo(t)
+		if err := repo.Create(newImage("image-conformance-1", "product-conformance", at)); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		image, err := repo.GetByID("image-conformance-1")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		want := newImage("image-conformance-1", "product-conformance", at)
+		image.CreatedAt = image.CreatedAt.UTC()
+		if *image != *want {
+			t.Errorf("Expected %+v, got %+v", want, image)
+		}
+	})
+
+	t.Run("List by products", func(t *testing.T) {
+		repo := newRepo(t)
+		for _, image := range []*Image{
+			newImage("image-conformance-2", "product-conformance", at.Add(time.Minute)),
+			newImage("image-conformance-1", "product-conformance", at),
+			newImage("image-conformance-3", "product-other", at),
+			newImage("image-conformance-4", "product-unlisted", at),
+		} {
+			if err := repo.Create(image); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+		}
+
+		images, err := repo.ListByProducts([]string{"product-conformance", "product-other"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		var ids []string
+		for _, image := range images {
+			ids = append(ids, image.ImageID)
+		}
+		if len(ids) != 3 || ids[0] != "image-conformance-1" || ids[1] != "image-conformance-3" || ids[2] != "image-conformance-2" {
+			t.Errorf("Expected the listed products' images oldest first, got %v", ids)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		repo := newRepo(t)
+		if err := repo.Create(newImage("image-conformance-1", "product-conformance", at)); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if err := repo.Delete("image-conformance-1"); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if _, err := repo.GetByID("image-conformance-1"); !errors.Is(err, ErrImageNotFound) {
+			t.Errorf("Expected ErrImageNotFound after deleting, got %v", err)
+		}
+		if err := repo.Delete("image-conformance-1"); !errors.Is(err, ErrImageNotFound) {
+			t.Errorf("Expected ErrImageNotFound deleting twice, got %v", err)
+		}
+	})
+}
+
+func TestInMemoryRepository_Conformance(t *testing.T) {
+	testRepositoryConformance(t, func(t *testing.T) Repository {
+		return NewInMemoryRepository()
+	})
+}
+
+// TestPostgresRepository_Conformance runs against the database in
+// POSTGRES_TEST_DSN and is skipped when it is not set.
+func TestPostgresRepository_Conformance(t *testing.T) {
+	dsn := os.Getenv("POSTGRES_TEST_DSN")
+	if dsn == "" {
+		t.Skip("POSTGRES_TEST_DSN not set")
+	}
+
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := migrations.New(db)
+	if err != nil {
+		t.Fatalf("Failed to load migrations: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+
+	testRepositoryConformance(t, func(t *testing.T) Repository {
+		repo := NewPostgresRepository(db)
+		if _, err := db.Exec(`TRUNCATE product_images`); err != nil {
+			t.Fatalf("Failed to reset product images: %v", err)
+		}
+		return repo
+	})
+}
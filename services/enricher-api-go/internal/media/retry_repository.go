@@ -0,0 +1,49 @@
+package media
+
+import "enricher-api-go/internal/retry"
+
+// RetryRepository retries the calls of another Repository that fail
+// transiently: reads on any transient failure, writes only on failures that
+// show nothing was written.
+type RetryRepository struct {
+	repo    Repository
+	retrier *retry.Retrier
+}
+
+// NewRetryRepository wraps repo with retrier, typically the retrier shared
+// by every repository using the same backend
+func NewRetryRepository(repo Repository, retrier *retry.Retrier) *RetryRepository {
+	return &RetryRepository{repo: repo, retrier: retrier}
+}
+
+// Create stores a new image record
+func (r *RetryRepository) Create(image *Image) error {
+	return r.retrier.Write(func() error {
+		return r.repo.Create(image)
+	})
+}
+
+// GetByID retrieves an image record by ID
+func (r *RetryRepository) GetByID(imageID string) (image *Image, err error) {
+	err = r.retrier.Read(func() error {
+		image, err = r.repo.GetByID(imageID)
+		return err
+	})
+	return image, err
+}
+
+// ListByProducts returns the image records of productIDs, oldest first
+func (r *RetryRepository) ListByProducts(productIDs []string) (images []*Image, err error) {
+	err = r.retrier.Read(func() error {
+		images, err = r.repo.ListByProducts(productIDs)
+		return err
+	})
+	return images, err
+}
+
+// Delete removes an image record
+func (r *RetryRepository) Delete(imageID string) error {
+	return r.retrier.Write(func() error {
+		return r.repo.Delete(imageID)
+	})
+}
@@ -0,0 +1,109 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// S3API is the subset of the S3 client used by S3Store; *s3.Client
+// satisfies it
+type S3API interface {
+	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+	DeleteObject(ctx context.Context, params *s3.DeleteObjectInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectOutput, error)
+}
+
+// S3Config configures the S3 client and where stored images are served
+type S3Config struct {
+	// Bucket holds the images
+	Bucket string
+	// Region is the AWS region; empty uses the SDK's default chain (AWS_REGION)
+	Region string
+	// Endpoint overrides the service endpoint, such as MinIO or LocalStack,
+	// and addresses the bucket by path
+	Endpoint string
+	// BaseURL prefixes keys in image URLs, such as a CDN in front of the
+	// bucket; empty links to the bucket itself
+	BaseURL string
+}
+
+// S3Store keeps images as objects of an S3 bucket
+type S3Store struct {
+	client  S3API
+	bucket  string
+	baseURL string
+}
+
+// OpenS3Store creates an S3 client with credentials from the SDK's default
+// chain and returns a store of cfg.Bucket through it
+func OpenS3Store(ctx context.Context, cfg S3Config) (*S3Store, error) {
+	var options []func(*awsconfig.LoadOptions) error
+	if cfg.Region != "" {
+		options = append(options, awsconfig.WithRegion(cfg.Region))
+	}
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	client := s3.NewFromConfig(awsCfg, func(o *s3.Options) {
+		if cfg.Endpoint != "" {
+			o.BaseEndpoint = aws.String(cfg.Endpoint)
+			o.UsePathStyle = true
+		}
+	})
+
+	baseURL := cfg.BaseURL
+	switch {
+	case baseURL != "":
+	case cfg.Endpoint != "":
+		baseURL = strings.TrimSuffix(cfg.Endpoint, "/") + "/" + cfg.Bucket
+	default:
+		baseURL = fmt.Sprintf("https://%s.s3.%s.amazonaws.com", cfg.Bucket, awsCfg.Region)
+	}
+	return NewS3Store(client, cfg.Bucket, baseURL), nil
+}
+
+// NewS3Store creates a store of bucket through client, linking to baseURL
+func NewS3Store(client S3API, bucket, baseURL string) *S3Store {
+	return &S3Store{client: client, bucket: bucket, baseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+// Put uploads body as the object key
+func (s *S3Store) Put(ctx context.Context, key, contentType string, body []byte) error {
+	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:        aws.String(s.bucket),
+		Key:           aws.String(key),
+		Body:          bytes.NewReader(body),
+		ContentLength: aws.Int64(int64(len(body))),
+		ContentType:   aws.String(contentType),
+		// Keys are never reused, so the content under a key never changes
+		CacheControl: aws.String("public, max-age=31536000, immutable"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload %s to bucket %s: %w", key, s.bucket, err)
+	}
+	return nil
+}
+
+// Delete removes the object key; S3 reports success for a missing object
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete %s from bucket %s: %w", key, s.bucket, err)
+	}
+	return nil
+}
+
+// URL returns the base URL joined with key
+func (s *S3Store) URL(key string) string {
+	return s.baseURL + "/" + key
+}
@@ -0,0 +1,290 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+
+	"enricher-api-go/internal/apperr"
+	"enricher-api-go/internal/auth"
+	"enricher-api-go/internal/clock"
+	"enricher-api-go/internal/idgen"
+	"enricher-api-go/internal/logging"
+	"enricher-api-go/internal/product"
+)
+
+// DefaultMaxUploadSize is the default maximum size of an uploaded image in bytes
+const DefaultMaxUploadSize = 10 << 20
+
+// DefaultThumbnailSize is the default maximum width and height of a thumbnail in pixels
+const DefaultThumbnailSize = 256
+
+var (
+	// ErrInvalidImage is returned when an upload is not a JPEG, PNG or GIF
+	// image, or is too large to decode
+	ErrInvalidImage = apperr.New(apperr.ErrValidation, "invalid image")
+	// ErrImageTooLarge is returned when an upload exceeds the maximum size
+	ErrImageTooLarge = apperr.New(apperr.ErrValidation, "image too large")
+	// ErrStoreUnavailable is returned when the store fails to keep or remove
+	// an image
+	ErrStoreUnavailable = apperr.New(apperr.ErrUnavailable, "media store unavailable")
+)
+
+// ProductCheck verifies that productID is a live product, returning the
+// product lookup's error, such as product.ErrProductNotFound, if not
+type ProductCheck func(ctx context.Context, productID string) error
+
+// Service defines the business logic interface for product images
+type Service interface {
+	UploadImage(ctx context.Context, productID string, body io.Reader) (*Image, error)
+	ListImages(ctx context.Context, productID string) ([]*Image, error)
+	DeleteImage(ctx context.Context, productID, imageID string) error
+	ImagesOf(ctx context.Context, productIDs []string) (map[string][]product.Image, error)
+}
+
+// MediaService implements the Service interface, and product.ImageGallery to
+// link images from product responses
+type MediaService struct {
+	repo          Repository
+	store         Store
+	productCheck  ProductCheck
+	maxUploadSize int64
+	thumbnailSize int
+	idGenerator   idgen.Generator
+	clock         clock.Clock
+}
+
+// Option configures optional MediaService behavior
+type Option func(*MediaService)
+
+// WithProductCheck sets how the service verifies that a product exists
+// before reading or changing its images. Without it images are kept for
+// any product ID.
+func WithProductCheck(check ProductCheck) Option {
+	return func(s *MediaService) {
+		s.productCheck = check
+	}
+}
+
+// WithMaxUploadSize sets the maximum size of an uploaded image in bytes
+func WithMaxUploadSize(limit int64) Option {
+	return func(s *MediaService) {
+		if limit > 0 {
+			s.maxUploadSize = limit
+		}
+	}
+}
+
+// WithThumbnailSize sets the maximum width and height of thumbnails in pixels
+func WithThumbnailSize(size int) Option {
+	return func(s *MediaService) {
+		if size > 0 {
+			s.thumbnailSize = size
+		}
+	}
+}
+
+// WithIDGenerator sets the generator used for new image IDs (UUIDs by default)
+func WithIDGenerator(gen idgen.Generator) Option {
+	return func(s *MediaService) {
+		s.idGenerator = gen
+	}
+}
+
+// WithClock sets the clock used for CreatedAt (the UTC wall clock by default)
+func WithClock(c clock.Clock) Option {
+	return func(s *MediaService) {
+		s.clock = c
+	}
+}
+
+// NewService creates a new media service keeping image records in repo and
+// their files in store
+func NewService(repo Repository, store Store, opts ...Option) *MediaService {
+	s := &MediaService{
+		repo:          repo,
+		store:         store,
+		maxUploadSize: DefaultMaxUploadSize,
+		thumbnailSize: DefaultThumbnailSize,
+		idGenerator:   idgen.UUIDGenerator{},
+		clock:         clock.System{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// UploadImage stores the image read from body for a product, along with a
+// thumbnail fitting in the configured size.
+//
+// The format is detected from the content, whatever the upload claims, and
+// must be JPEG, PNG or GIF. The image is stored as uploaded and its
+// thumbnail as JPEG for JPEG images and PNG otherwise. When a step fails,
+// the files already stored are removed again.
+func (s *MediaService) UploadImage(ctx context.Context, productID string, body io.Reader) (*Image, error) {
+	logger := logging.FromContext(ctx)
+	logger.Info("Uploading product image", "product_id", productID)
+
+	if err := s.checkProduct(ctx, productID); err != nil {
+		return nil, err
+	}
+
+	data, err := io.ReadAll(io.LimitReader(body, s.maxUploadSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image: %w", err)
+	}
+	if int64(len(data)) > s.maxUploadSize {
+		return nil, fmt.Errorf("%w: images must be at most %d bytes", ErrImageTooLarge, s.maxUploadSize)
+	}
+
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: expected a JPEG, PNG or GIF image", ErrInvalidImage)
+	}
+	if config.Width*config.Height > MaxPixels {
+		return nil, fmt.Errorf("%w: images must have at most %d pixels, got %dx%d", ErrInvalidImage, MaxPixels, config.Width, config.Height)
+	}
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s image is corrupt", ErrInvalidImage, format)
+	}
+	thumb, thumbFormat, err := encodeThumbnail(thumbnail(decoded, s.thumbnailSize), format)
+	if err != nil {
+		return nil, err
+	}
+
+	imageID, err := s.idGenerator.NewID("image")
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate image ID: %w", err)
+	}
+	prefix := "products/" + productID + "/" + imageID
+	img := &Image{
+		ImageID:      imageID,
+		ProductID:    productID,
+		ContentType:  contentTypes[format].mediaType,
+		Size:         int64(len(data)),
+		Width:        config.Width,
+		Height:       config.Height,
+		Key:          prefix + contentTypes[format].extension,
+		ThumbnailKey: prefix + "-thumb" + contentTypes[thumbFormat].extension,
+		CreatedAt:    s.clock.Now(),
+		CreatedBy:    auth.Caller(ctx),
+	}
+
+	if err := s.store.Put(ctx, img.Key, img.ContentType, data); err != nil {
+		logger.Error("Failed to store product image", "product_id", productID, "error", err)
+		return nil, fmt.Errorf("%w: %v", ErrStoreUnavailable, err)
+	}
+	if err := s.store.Put(ctx, img.ThumbnailKey, contentTypes[thumbFormat].mediaType, thumb); err != nil {
+		logger.Error("Failed to store product thumbnail", "product_id", productID, "error", err)
+		s.removeFiles(ctx, img.Key)
+		return nil, fmt.Errorf("%w: %v", ErrStoreUnavailable, err)
+	}
+	if err := s.repo.Create(img); err != nil {
+		logger.Error("Failed to record product image", "product_id", productID, "error", err)
+		s.removeFiles(ctx, img.Key, img.ThumbnailKey)
+		return nil, fmt.Errorf("failed to create image: %w", err)
+	}
+
+	logger.Info("Uploaded product image", "product_id", productID, "image_id", imageID, "size", img.Size)
+	return s.withURLs(img), nil
+}
+
+// ListImages returns the images of a product, oldest first
+func (s *MediaService) ListImages(ctx context.Context, productID string) ([]*Image, error) {
+	if err := s.checkProduct(ctx, productID); err != nil {
+		return nil, err
+	}
+
+	images, err := s.repo.ListByProducts([]string{productID})
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to list product images", "product_id", productID, "error", err)
+		return nil, fmt.Errorf("failed to list images: %w", err)
+	}
+	for i, img := range images {
+		images[i] = s.withURLs(img)
+	}
+	return images, nil
+}
+
+// DeleteImage removes an image of a product and then its files. Files the
+// store fails to remove are logged and left behind; the image is gone either
+// way.
+func (s *MediaService) DeleteImage(ctx context.Context, productID, imageID string) error {
+	logger := logging.FromContext(ctx)
+	logger.Info("Deleting product image", "product_id", productID, "image_id", imageID)
+
+	if err := s.checkProduct(ctx, productID); err != nil {
+		return err
+	}
+
+	img, err := s.repo.GetByID(imageID)
+	if err == nil && img.ProductID != productID {
+		err = ErrImageNotFound
+	}
+	if err == nil {
+		err = s.repo.Delete(imageID)
+	}
+	if err != nil {
+		if !errors.Is(err, ErrImageNotFound) {
+			logger.Error("Failed to delete product image", "image_id", imageID, "error", err)
+		}
+		return fmt.Errorf("failed to delete image: %w", err)
+	}
+
+	s.removeFiles(ctx, img.Key, img.ThumbnailKey)
+	return nil
+}
+
+// ImagesOf returns the image links of each of productIDs that has images,
+// keyed by product ID. It does not check that the products exist.
+func (s *MediaService) ImagesOf(ctx context.Context, productIDs []string) (map[string][]product.Image, error) {
+	images, err := s.repo.ListByProducts(productIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list images: %w", err)
+	}
+
+	links := make(map[string][]product.Image)
+	for _, img := range images {
+		img = s.withURLs(img)
+		links[img.ProductID] = append(links[img.ProductID], product.Image{
+			ImageID:      img.ImageID,
+			URL:          img.URL,
+			ThumbnailURL: img.ThumbnailURL,
+			Width:        img.Width,
+			Height:       img.Height,
+		})
+	}
+	return links, nil
+}
+
+// withURLs sets where the store serves an image and its thumbnail
+func (s *MediaService) withURLs(img *Image) *Image {
+	img.URL = s.store.URL(img.Key)
+	img.ThumbnailURL = s.store.URL(img.ThumbnailKey)
+	return img
+}
+
+// removeFiles deletes stored files, logging those the store fails to remove
+func (s *MediaService) removeFiles(ctx context.Context, keys ...string) {
+	for _, key := range keys {
+		if err := s.store.Delete(ctx, key); err != nil {
+			logging.FromContext(ctx).Warn("Failed to remove image file", "key", key, "error", err)
+		}
+	}
+}
+
+// checkProduct verifies that productID names a live product
+func (s *MediaService) checkProduct(ctx context.Context, productID string) error {
+	if productID == "" {
+		return apperr.New(apperr.ErrValidation, "product ID cannot be empty")
+	}
+	if s.productCheck == nil {
+		return nil
+	}
+	return s.productCheck(ctx, productID)
+}
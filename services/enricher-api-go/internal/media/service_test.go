@@ -0,0 +1,324 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"enricher-api-go/internal/apperr"
+	"enricher-api-go/internal/clock"
+	"enricher-api-go/internal/idgen"
+	"enricher-api-go/internal/product"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// knownProducts returns a ProductCheck accepting only productIDs
+func knownProducts(productIDs ...string) ProductCheck {
+	return func(ctx context.Context, productID string) error {
+		for _, known := range productIDs {
+			if productID == known {
+				return nil
+			}
+		}
+		return product.ErrProductNotFound
+	}
+}
+
+// encodePNG returns a width×height PNG image
+func encodePNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		img.Set(x, height/2, color.RGBA{R: 200, A: 255})
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("Failed to encode PNG: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// failingStore is a Store whose writes fail after the first putsBefore
+type failingStore struct {
+	*LocalStore
+	putsBefore int
+}
+
+func (s *failingStore) Put(ctx context.Context, key, contentType string, body []byte) error {
+	if s.putsBefore == 0 {
+		return errors.New("bucket unreachable")
+	}
+	s.putsBefore--
+	return s.LocalStore.Put(ctx, key, contentType, body)
+}
+
+func newTestService(t *testing.T, store Store, opts ...Option) *MediaService {
+	t.Helper()
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	opts = append([]Option{
+		WithProductCheck(knownProducts("product-123")),
+		WithIDGenerator(idgen.NewSequentialGenerator(idgen.NewInMemoryCounter())),
+		WithClock(clock.Fixed(at)),
+	}, opts...)
+	return NewService(NewInMemoryRepository(), store, opts...)
+}
+
+func TestMediaService_UploadImage(t *testing.T) {
+	// Arrange
+	store := NewLocalStore(t.TempDir(), "/media")
+	service := newTestService(t, store, WithThumbnailSize(64))
+
+	// Act
+	img, err := service.UploadImage(context.Background(), "product-123", bytes.NewReader(encodePNG(t, 400, 200)))
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if img.ContentType != "image/png" || img.Width != 400 || img.Height != 200 {
+		t.Errorf("Expected a 400x200 image/png, got %+v", img)
+	}
+	if img.URL != "/media/products/product-123/"+img.ImageID+".png" {
+		t.Errorf("Expected the image served under /media, got %s", img.URL)
+	}
+
+	original, err := os.ReadFile(filepath.Join(store.Dir(), filepath.FromSlash(img.Key)))
+	if err != nil || int64(len(original)) != img.Size {
+		t.Errorf("Expected the upload stored as is, got %d bytes and %v", len(original), err)
+	}
+	thumb, err := os.ReadFile(filepath.Join(store.Dir(), filepath.FromSlash(img.ThumbnailKey)))
+	if err != nil {
+		t.Fatalf("Expected the thumbnail stored, got %v", err)
+	}
+	config, format, err := image.DecodeConfig(bytes.NewReader(thumb))
+	if err != nil || format != "png" || config.Width != 64 || config.Height != 32 {
+		t.Errorf("Expected a 64x32 png thumbnail, got %s %dx%d (%v)", format, config.Width, config.Height, err)
+	}
+}
+
+func TestMediaService_UploadImage_JPEGThumbnail(t *testing.T) {
+	// Arrange
+	service := newTestService(t, NewLocalStore(t.TempDir(), "/media"), WithThumbnailSize(50))
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, 100, 300)), nil); err != nil {
+		t.Fatalf("Failed to encode JPEG: %v", err)
+	}
+
+	// Act
+	img, err := service.UploadImage(context.Background(), "product-123", &buf)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if img.ContentType != "image/jpeg" || !strings.HasSuffix(img.ThumbnailURL, "-thumb.jpg") {
+		t.Errorf("Expected a JPEG image with a JPEG thumbnail, got %+v", img)
+	}
+}
+
+func TestMediaService_UploadImage_Errors(t *testing.T) {
+	tests := []struct {
+		name      string
+		productID string
+		body      []byte
+		wantErr   error
+		wantKind  error
+	}{
+		{name: "unknown product", productID: "product-999", body: []byte("irrelevant"), wantErr: product.ErrProductNotFound, wantKind: apperr.ErrNotFound},
+		{name: "not an image", productID: "product-123", body: []byte("%PDF-1.7"), wantErr: ErrInvalidImage, wantKind: apperr.ErrValidation},
+		{name: "truncated image", productID: "product-123", body: []byte("\x89PNG\r\n\x1a\n"), wantErr: ErrInvalidImage, wantKind: apperr.ErrValidation},
+		{name: "too large", productID: "product-123", body: bytes.Repeat([]byte{0}, 1025), wantErr: ErrImageTooLarge, wantKind: apperr.ErrValidation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			store := NewLocalStore(t.TempDir(), "/media")
+			service := newTestService(t, store, WithMaxUploadSize(1024))
+
+			// Act
+			_, err := service.UploadImage(context.Background(), tt.productID, bytes.NewReader(tt.body))
+
+			// Assert
+			if !errors.Is(err, tt.wantErr) || !errors.Is(err, tt.wantKind) {
+				t.Errorf("Expected %v of kind %v, got %v", tt.wantErr, tt.wantKind, err)
+			}
+			if entries, _ := os.ReadDir(store.Dir()); len(entries) != 0 {
+				t.Errorf("Expected nothing stored, got %d entries", len(entries))
+			}
+		})
+	}
+}
+
+func TestMediaService_UploadImage_StoreFailure(t *testing.T) {
+	// Arrange: the image is stored but its thumbnail is not
+	store := &failingStore{LocalStore: NewLocalStore(t.TempDir(), "/media"), putsBefore: 1}
+	service := newTestService(t, store)
+
+	// Act
+	_, err := service.UploadImage(context.Background(), "product-123", bytes.NewReader(encodePNG(t, 10, 10)))
+
+	// Assert
+	if !errors.Is(err, ErrStoreUnavailable) || !errors.Is(err, apperr.ErrUnavailable) {
+		t.Errorf("Expected ErrStoreUnavailable, got %v", err)
+	}
+	files, _ := filepath.Glob(filepath.Join(store.Dir(), "products", "product-123", "*"))
+	if len(files) != 0 {
+		t.Errorf("Expected the stored image removed again, got %v", files)
+	}
+	if images, _ := service.ListImages(context.Background(), "product-123"); len(images) != 0 {
+		t.Errorf("Expected no image recorded, got %d", len(images))
+	}
+}
+
+func TestMediaService_ListAndDelete(t *testing.T) {
+	// Arrange
+	store := NewLocalStore(t.TempDir(), "https://cdn.example.com/")
+	service := newTestService(t, store, WithProductCheck(knownProducts("product-123", "product-456")))
+	ctx := context.Background()
+	first, err := service.UploadImage(ctx, "product-123", bytes.NewReader(encodePNG(t, 20, 10)))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	second, err := service.UploadImage(ctx, "product-123", bytes.NewReader(encodePNG(t, 30, 10)))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Act
+	images, err := service.ListImages(ctx, "product-123")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(images) != 2 || images[0].ImageID != first.ImageID || images[1].ImageID != second.ImageID {
+		t.Fatalf("Expected both images oldest first, got %+v", images)
+	}
+	if !strings.HasPrefix(images[0].URL, "https://cdn.example.com/products/") {
+		t.Errorf("Expected URLs under the base URL, got %s", images[0].URL)
+	}
+
+	links, err := service.ImagesOf(ctx, []string{"product-123", "product-456"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(links["product-123"]) != 2 || links["product-123"][1].Width != 30 || len(links["product-456"]) != 0 {
+		t.Errorf("Expected two links for product-123 only, got %+v", links)
+	}
+
+	// Act: another product cannot delete the image
+	err = service.DeleteImage(ctx, "product-456", first.ImageID)
+
+	// Assert
+	if !errors.Is(err, ErrImageNotFound) {
+		t.Errorf("Expected ErrImageNotFound for another product's image, got %v", err)
+	}
+
+	// Act
+	err = service.DeleteImage(ctx, "product-123", first.ImageID)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for _, key := range []string{first.Key, first.ThumbnailKey} {
+		if _, err := os.Stat(filepath.Join(store.Dir(), filepath.FromSlash(key))); !os.IsNotExist(err) {
+			t.Errorf("Expected %s removed, got %v", key, err)
+		}
+	}
+	if images, _ := service.ListImages(ctx, "product-123"); len(images) != 1 {
+		t.Errorf("Expected one image left, got %d", len(images))
+	}
+	if err := service.DeleteImage(ctx, "product-123", first.ImageID); !errors.Is(err, ErrImageNotFound) {
+		t.Errorf("Expected ErrImageNotFound deleting twice, got %v", err)
+	}
+}
+
+func TestLocalStore_RejectsEscapingKeys(t *testing.T) {
+	// Arrange
+	store := NewLocalStore(t.TempDir(), "/media")
+
+	// Act
+	err := store.Put(context.Background(), "../outside.png", "image/png", []byte("x"))
+
+	// Assert
+	if err == nil {
+		t.Error("Expected an error for a key outside the directory")
+	}
+}
+
+// fakeS3 records the objects put into and deleted from a bucket
+type fakeS3 struct {
+	objects map[string][]byte
+	types   map[string]string
+	err     error
+}
+
+func (f *fakeS3) PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	body, _ := io.ReadAll(params.Body)
+	f.objects[*params.Bucket+"/"+*params.Key] = body
+	f.types[*params.Bucket+"/"+*params.Key] = *params.ContentType
+	return &s3.PutObjectOutput{}, nil
+}
+
+func (f *fakeS3) DeleteObject(ctx context.Context, params *s3.DeleteObjectInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	delete(f.objects, *params.Bucket+"/"+*params.Key)
+	return &s3.DeleteObjectOutput{}, nil
+}
+
+func TestS3Store(t *testing.T) {
+	// Arrange
+	client := &fakeS3{objects: make(map[string][]byte), types: make(map[string]string)}
+	store := NewS3Store(client, "catalog-images", "https://catalog-images.s3.eu-west-1.amazonaws.com/")
+	ctx := context.Background()
+
+	// Act
+	err := store.Put(ctx, "products/product-123/image-1.png", "image/png", []byte("png"))
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if string(client.objects["catalog-images/products/product-123/image-1.png"]) != "png" ||
+		client.types["catalog-images/products/product-123/image-1.png"] != "image/png" {
+		t.Errorf("Expected the object uploaded with its content type, got %v", client.objects)
+	}
+	if url := store.URL("products/product-123/image-1.png"); url != "https://catalog-images.s3.eu-west-1.amazonaws.com/products/product-123/image-1.png" {
+		t.Errorf("Expected the object URL under the base URL, got %s", url)
+	}
+
+	// Act
+	err = store.Delete(ctx, "products/product-123/image-1.png")
+
+	// Assert
+	if err != nil || len(client.objects) != 0 {
+		t.Errorf("Expected the object deleted, got %v and %v", client.objects, err)
+	}
+
+	// Act
+	client.err = errors.New("access denied")
+	err = store.Put(ctx, "products/product-123/image-2.png", "image/png", []byte("png"))
+
+	// Assert
+	if err == nil || !strings.Contains(err.Error(), "catalog-images") {
+		t.Errorf("Expected the upload error naming the bucket, got %v", err)
+	}
+}
@@ -0,0 +1,95 @@
+package media
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Store keeps the bytes of images under keys such as
+// products/product-123/image-789.jpg
+type Store interface {
+	// Put stores body under key, replacing anything stored there
+	Put(ctx context.Context, key, contentType string, body []byte) error
+	// Delete removes what is stored under key; a missing key is not an error
+	Delete(ctx context.Context, key string) error
+	// URL returns where the content under key is served
+	URL(key string) string
+}
+
+// LocalStore keeps images as files under a directory, to be served at a
+// base URL such as /media (see Static)
+type LocalStore struct {
+	dir     string
+	baseURL string
+}
+
+// NewLocalStore creates a store writing under dir, which is created on the
+// first write, and linking to baseURL
+func NewLocalStore(dir, baseURL string) *LocalStore {
+	return &LocalStore{dir: dir, baseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+// Dir returns the directory the store writes under
+func (s *LocalStore) Dir() string {
+	return s.dir
+}
+
+// Put writes body to a temporary file and renames it into place, so readers
+// never see a partial image
+func (s *LocalStore) Put(ctx context.Context, key, contentType string, body []byte) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create media directory: %w", err)
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to store %s: %w", key, err)
+	}
+	defer os.Remove(file.Name())
+	_, err = file.Write(body)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(file.Name(), 0o644)
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to store %s: %w", key, err)
+	}
+	return nil
+}
+
+// Delete removes the file of key
+func (s *LocalStore) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete %s: %w", key, err)
+	}
+	return nil
+}
+
+// URL returns the base URL joined with key
+func (s *LocalStore) URL(key string) string {
+	return s.baseURL + "/" + key
+}
+
+// path returns the file of key, refusing keys that would leave the directory
+func (s *LocalStore) path(key string) (string, error) {
+	if !filepath.IsLocal(filepath.FromSlash(key)) {
+		return "", fmt.Errorf("invalid media key %q", key)
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
@@ -0,0 +1,69 @@
+package media
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	// Registers GIF decoding; JPEG and PNG register through their encoders
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// MaxPixels bounds the pixels of an image decoded to make its thumbnail, so a
+// small but highly compressed upload cannot exhaust memory
+const MaxPixels = 50_000_000
+
+// Decoded image formats, named as image.Decode names them
+const (
+	formatJPEG = "jpeg"
+	formatPNG  = "png"
+	formatGIF  = "gif"
+)
+
+// contentTypes maps the accepted formats to their media type and file
+// extension
+var contentTypes = map[string]struct{ mediaType, extension string }{
+	formatJPEG: {"image/jpeg", ".jpg"},
+	formatPNG:  {"image/png", ".png"},
+	formatGIF:  {"image/gif", ".gif"},
+}
+
+// thumbnail scales img down to fit in size×size pixels, keeping its aspect
+// ratio; an image that already fits is returned unchanged
+func thumbnail(img image.Image, size int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= size && height <= size {
+		return img
+	}
+
+	if width >= height {
+		width, height = size, max(1, height*size/width)
+	} else {
+		width, height = max(1, width*size/height), size
+	}
+	scaled := image.NewRGBA(image.Rect(0, 0, width, height))
+	xdraw.CatmullRom.Scale(scaled, scaled.Bounds(), img, bounds, xdraw.Src, nil)
+	return scaled
+}
+
+// encodeThumbnail encodes a thumbnail of an image in format: JPEG for JPEG
+// images and PNG, which keeps transparency, for the others. It returns the
+// encoded thumbnail and its format.
+func encodeThumbnail(img image.Image, format string) ([]byte, string, error) {
+	var buf bytes.Buffer
+	var err error
+	if format == formatJPEG {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85})
+	} else {
+		format = formatPNG
+		err = png.Encode(&buf, img)
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to encode thumbnail: %w", err)
+	}
+	return buf.Bytes(), format, nil
+}
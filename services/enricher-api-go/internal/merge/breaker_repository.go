@@ -0,0 +1,63 @@
+package merge
+
+import (
+	"errors"
+
+	"enricher-api-go/internal/breaker"
+)
+
+// BreakerRepository guards another Repository with a circuit breaker.
+//
+// Storage failures count against the breaker, while ErrMergeNotFound is
+// returned unchanged without tripping it.
+type BreakerRepository struct {
+	repo    Repository
+	breaker *breaker.Breaker
+}
+
+// NewBreakerRepository wraps repo with b, typically the breaker shared by
+// every repository using the same backend
+func NewBreakerRepository(repo Repository, b *breaker.Breaker) *BreakerRepository {
+	return &BreakerRepository{repo: repo, breaker: b}
+}
+
+// Create stores a new merge record
+func (r *BreakerRepository) Create(merge *Merge) error {
+	return r.call(func() error {
+		return r.repo.Create(merge)
+	})
+}
+
+// ListByCustomer returns the merges of a customer, oldest first
+func (r *BreakerRepository) ListByCustomer(customerID string) (merges []*Merge, err error) {
+	err = r.call(func() error {
+		merges, err = r.repo.ListByCustomer(customerID)
+		return err
+	})
+	return merges, err
+}
+
+// UpdateConflicts replaces the conflicts recorded for a merge
+func (r *BreakerRepository) UpdateConflicts(mergeID string, conflicts []Conflict) error {
+	return r.call(func() error {
+		return r.repo.UpdateConflicts(mergeID, conflicts)
+	})
+}
+
+// call runs fn through the breaker, passing ErrMergeNotFound through without
+// counting it as a failure
+func (r *BreakerRepository) call(fn func() error) error {
+	var notFound error
+	err := r.breaker.Execute(func() error {
+		err := fn()
+		if errors.Is(err, ErrMergeNotFound) {
+			notFound = err
+			return nil
+		}
+		return err
+	})
+	if notFound != nil {
+		return notFound
+	}
+	return err
+}
@@ -0,0 +1,92 @@
+package merge
+
+import (
+	"net/http"
+
+	"enricher-api-go/internal/problem"
+	"enricher-api-go/internal/servertiming"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Handler handles HTTP requests for customer merges
+type Handler struct {
+	service Service
+}
+
+// NewHandler creates a new merge handler
+func NewHandler(service Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+// MergeCustomer handles POST /v1/customers/:id/merge
+//
+// The customer in the path survives; the duplicate in the body is merged
+// into it and soft-deleted.
+//
+// Example request:
+//
+//	POST /v1/customers/customer-123/merge
+//	Content-Type: application/json
+//
+//	{
+//		"duplicateId": "customer-456",
+//		"strategy": "newest"
+//	}
+//
+// Example response:
+//
+//	{
+//		"mergeId": "merge-789",
+//		"survivorId": "customer-123",
+//		"duplicateId": "customer-456",
+//		"strategy": "newest",
+//		"conflicts": [{"field": "phone", "kept": "+14155550123", "discarded": "+14155550199"}],
+//		"orderIds": ["order-12345"],
+//		"points": 350,
+//		...
+//	}
+//
+// Error responses:
+//   - 400: No duplicate, an unknown strategy, or the survivor itself
+//   - 404: Survivor or duplicate not found
+//   - 409: The survivor's credit limit cannot cover the duplicate's exposure
+func (h *Handler) MergeCustomer(c echo.Context) error {
+	var req MergeRequest
+	if err := servertiming.Measure(c, "bind", func() error { return c.Bind(&req) }); err != nil {
+		return problem.BindError(c, err)
+	}
+
+	stop := servertiming.Start(c, "service")
+	merge, err := h.service.MergeCustomer(c.Request().Context(), c.Param("id"), req)
+	stop()
+	if err != nil {
+		return problem.Error(c, err)
+	}
+
+	return c.JSON(http.StatusOK, merge)
+}
+
+// ListMerges handles GET /v1/customers/:id/merges
+//
+// It answers the merges the customer took part in, as survivor or as
+// duplicate, oldest first. Merged-away customers are deleted but still
+// listed.
+//
+// Error responses:
+//   - 404: Customer not found
+func (h *Handler) ListMerges(c echo.Context) error {
+	stop := servertiming.Start(c, "service")
+	merges, err := h.service.ListMerges(c.Request().Context(), c.Param("id"))
+	stop()
+	if err != nil {
+		return problem.Error(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"merges": merges,
+		"count":  len(merges),
+	})
+}
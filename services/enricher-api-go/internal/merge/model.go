@@ -0,0 +1,86 @@
+// Package merge merges duplicate customers for the Resilient Order Enricher
+// API and keeps an audit trail of the merges.
+//
+// A merge folds a duplicate customer into a survivor: the survivor's
+// profile is resolved field by field under a strategy, the duplicate's
+// addresses, orders, credit exposure and loyalty points move to the
+// survivor, and the duplicate is soft-deleted. Every merge is recorded with
+// the conflicts it resolved and what it moved.
+package merge
+
+import "time"
+
+// Conflict-resolution strategies, deciding which customer's value a field
+// keeps when both customers have a different one
+const (
+	// StrategySurvivor keeps the survivor's values
+	StrategySurvivor = "survivor"
+	// StrategyDuplicate keeps the duplicate's values
+	StrategyDuplicate = "duplicate"
+	// StrategyNewest keeps the values of the customer updated last
+	StrategyNewest = "newest"
+)
+
+// Merge records a duplicate customer merged into a survivor.
+//
+// Example usage:
+//
+//	merge := &Merge{
+//		MergeID:     "merge-12345",
+//		SurvivorID:  "customer-123",
+//		DuplicateID: "customer-456",
+//		Strategy:    StrategySurvivor,
+//		OrderIDs:    []string{"order-789"},
+//		Points:      350,
+//	}
+type Merge struct {
+	// MergeID is the unique identifier for the merge
+	MergeID string `json:"mergeId" db:"merge_id"`
+	// SurvivorID is the customer the duplicate was merged into
+	SurvivorID string `json:"survivorId" db:"survivor_id"`
+	// DuplicateID is the customer merged away, soft-deleted by the merge
+	DuplicateID string `json:"duplicateId" db:"duplicate_id"`
+	// Strategy is the conflict-resolution strategy the merge applied
+	Strategy string `json:"strategy" db:"strategy"`
+	// Conflicts are the fields both customers had different values for
+	Conflicts []Conflict `json:"conflicts" db:"conflicts"`
+	// AddressIDs are the addresses moved from the duplicate to the survivor
+	AddressIDs []string `json:"addressIds" db:"address_ids"`
+	// OrderIDs are the orders moved from the duplicate to the survivor
+	OrderIDs []string `json:"orderIds" db:"order_ids"`
+	// Exposure is the credit exposure moved from the duplicate
+	Exposure float64 `json:"exposure" db:"exposure"`
+	// Points is the loyalty points balance moved from the duplicate
+	Points int `json:"points" db:"points"`
+	// MergedAt is when the merge was made
+	MergedAt time.Time `json:"mergedAt" db:"merged_at"`
+	// MergedBy is the authenticated caller that made the merge, if any
+	MergedBy string `json:"mergedBy,omitempty" db:"merged_by"`
+}
+
+// Conflict is a field that the survivor and the duplicate had different
+// values for, and how the merge resolved it
+type Conflict struct {
+	// Field is the JSON name of the customer field, such as "email"
+	Field string `json:"field"`
+	// Kept is the value the survivor ended up with
+	Kept interface{} `json:"kept"`
+	// Discarded is the value that was dropped
+	Discarded interface{} `json:"discarded"`
+}
+
+// MergeRequest is the request body for merging a duplicate into a survivor
+//
+// Example usage:
+//
+//	request := MergeRequest{
+//		DuplicateID: "customer-456",
+//		Strategy:    StrategyNewest,
+//	}
+type MergeRequest struct {
+	// DuplicateID is the customer to merge away (required)
+	DuplicateID string `json:"duplicateId" validate:"required"`
+	// Strategy resolves conflicting fields: survivor (the default),
+	// duplicate or newest
+	Strategy string `json:"strategy,omitempty" validate:"omitempty,oneof=survivor duplicate newest"`
+}
@@ -0,0 +1,135 @@
+package merge
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// mergeColumns lists the columns read by scanMerge, in order
+const mergeColumns = `merge_id, survivor_id, duplicate_id, strategy, conflicts, address_ids, order_ids,
+	exposure, points, merged_at, merged_by`
+
+// PostgresRepository implements Repository on top of a PostgreSQL database
+type PostgresRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresRepository creates a merge repository backed by db.
+//
+// The caller owns db and is responsible for opening and closing it; the
+// customer_merges table must exist (see the migrations package).
+func NewPostgresRepository(db *sql.DB) *PostgresRepository {
+	return &PostgresRepository{db: db}
+}
+
+// Create stores a new merge record
+func (r *PostgresRepository) Create(merge *Merge) error {
+	conflicts, err := json.Marshal(nonNil(merge.Conflicts))
+	if err != nil {
+		return fmt.Errorf("failed to encode merge conflicts: %w", err)
+	}
+	addressIDs, err := json.Marshal(nonNil(merge.AddressIDs))
+	if err != nil {
+		return fmt.Errorf("failed to encode merged addresses: %w", err)
+	}
+	orderIDs, err := json.Marshal(nonNil(merge.OrderIDs))
+	if err != nil {
+		return fmt.Errorf("failed to encode merged orders: %w", err)
+	}
+
+	_, err = r.db.Exec(
+		`INSERT INTO customer_merges (`+mergeColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+		merge.MergeID, merge.SurvivorID, merge.DuplicateID, merge.Strategy, conflicts, addressIDs, orderIDs,
+		merge.Exposure, merge.Points, merge.MergedAt, merge.MergedBy,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create merge: %w", err)
+	}
+	return nil
+}
+
+// ListByCustomer returns the merges of a customer, oldest first
+func (r *PostgresRepository) ListByCustomer(customerID string) ([]*Merge, error) {
+	rows, err := r.db.Query(
+		`SELECT `+mergeColumns+` FROM customer_merges WHERE survivor_id = $1 OR duplicate_id = $1 ORDER BY merged_at, merge_id`,
+		customerID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list merges: %w", err)
+	}
+	defer rows.Close()
+
+	merges := make([]*Merge, 0)
+	for rows.Next() {
+		merge, err := scanMerge(rows)
+		if err != nil {
+			return nil, err
+		}
+		merges = append(merges, merge)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list merges: %w", err)
+	}
+	return merges, nil
+}
+
+// UpdateConflicts replaces the conflicts recorded for a merge
+func (r *PostgresRepository) UpdateConflicts(mergeID string, conflicts []Conflict) error {
+	encoded, err := json.Marshal(nonNil(conflicts))
+	if err != nil {
+		return fmt.Errorf("failed to encode merge conflicts: %w", err)
+	}
+
+	result, err := r.db.Exec(`UPDATE customer_merges SET conflicts = $2 WHERE merge_id = $1`, mergeID, encoded)
+	if err != nil {
+		return fmt.Errorf("failed to update merge conflicts: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to update merge conflicts: %w", err)
+	}
+	if affected == 0 {
+		return ErrMergeNotFound
+	}
+	return nil
+}
+
+// nonNil returns values, or an empty slice for nil so it is stored as [] rather than null
+func nonNil[T any](values []T) []T {
+	if values == nil {
+		return []T{}
+	}
+	return values
+}
+
+// rowScanner is satisfied by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanMerge reads one merge row in mergeColumns order
+func scanMerge(row rowScanner) (*Merge, error) {
+	var merge Merge
+	var conflicts, addressIDs, orderIDs []byte
+	err := row.Scan(&merge.MergeID, &merge.SurvivorID, &merge.DuplicateID, &merge.Strategy, &conflicts, &addressIDs, &orderIDs,
+		&merge.Exposure, &merge.Points, &merge.MergedAt, &merge.MergedBy)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan merge: %w", err)
+	}
+
+	if err := json.Unmarshal(conflicts, &merge.Conflicts); err != nil {
+		return nil, fmt.Errorf("failed to decode merge conflicts: %w", err)
+	}
+	if err := json.Unmarshal(addressIDs, &merge.AddressIDs); err != nil {
+		return nil, fmt.Errorf("failed to decode merged addresses: %w", err)
+	}
+	if err := json.Unmarshal(orderIDs, &merge.OrderIDs); err != nil {
+		return nil, fmt.Errorf("failed to decode merged orders: %w", err)
+	}
+	return &merge, nil
+}
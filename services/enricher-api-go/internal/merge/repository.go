@@ -0,0 +1,94 @@
+package merge
+
+import (
+	"slices"
+	"sort"
+	"sync"
+
+	"enricher-api-go/internal/apperr"
+)
+
+// ErrMergeNotFound is returned when no merge has the requested ID
+var ErrMergeNotFound = apperr.New(apperr.ErrNotFound, "merge not found")
+
+// Repository defines the interface for merge record data access.
+//
+// Records are written once and only their conflicts change afterwards, when
+// UpdateConflicts redacts the personal data of an erased customer.
+// ListByCustomer returns the merges a customer took part in, as survivor or
+// as duplicate, oldest first.
+type Repository interface {
+	Create(merge *Merge) error
+	ListByCustomer(customerID string) ([]*Merge, error)
+	UpdateConflicts(mergeID string, conflicts []Conflict) error
+}
+
+// InMemoryRepository implements Repository interface using in-memory storage
+type InMemoryRepository struct {
+	merges []*Merge
+	mutex  sync.RWMutex
+}
+
+// NewInMemoryRepository creates a new, empty in-memory merge repository
+func NewInMemoryRepository() *InMemoryRepository {
+	return &InMemoryRepository{
+		mutex: sync.RWMutex{},
+	}
+}
+
+// Reset removes every merge record
+func (r *InMemoryRepository) Reset() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.merges = nil
+}
+
+// Create stores a new merge record
+func (r *InMemoryRepository) Create(merge *Merge) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.merges = append(r.merges, copyMerge(merge))
+	return nil
+}
+
+// ListByCustomer returns the merges of a customer, oldest first
+func (r *InMemoryRepository) ListByCustomer(customerID string) ([]*Merge, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	merges := make([]*Merge, 0)
+	for _, merge := range r.merges {
+		if merge.SurvivorID == customerID || merge.DuplicateID == customerID {
+			merges = append(merges, copyMerge(merge))
+		}
+	}
+	sort.SliceStable(merges, func(i, j int) bool {
+		return merges[i].MergedAt.Before(merges[j].MergedAt)
+	})
+	return merges, nil
+}
+
+// UpdateConflicts replaces the conflicts recorded for a merge
+func (r *InMemoryRepository) UpdateConflicts(mergeID string, conflicts []Conflict) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, merge := range r.merges {
+		if merge.MergeID == mergeID {
+			merge.Conflicts = slices.Clone(conflicts)
+			return nil
+		}
+	}
+	return ErrMergeNotFound
+}
+
+// copyMerge returns a copy of merge sharing none of its slices
+func copyMerge(merge *Merge) *Merge {
+	mergeCopy := *merge
+	mergeCopy.Conflicts = slices.Clone(merge.Conflicts)
+	mergeCopy.AddressIDs = slices.Clone(merge.AddressIDs)
+	mergeCopy.OrderIDs = slices.Clone(merge.OrderIDs)
+	return &mergeCopy
+}
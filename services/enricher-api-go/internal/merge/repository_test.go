@@ -0,0 +1,137 @@
+package merge
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"enricher-api-go/internal/migrations"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// testRepositoryConformance runs the behavior every Repository implementation
+// must share against a repository created by newRepo.
+func testRepositoryConformance(t *testing.T, newRepo func(t *testing.T) Repository) {
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	newMerge := func(mergeID, survivorID, duplicateID string, mergedAt time.Time) *Merge {
+		return &Merge{
+			MergeID:     mergeID,
+			SurvivorID:  survivorID,
+			DuplicateID: duplicateID,
+			Strategy:    StrategyNewest,
+			Conflicts:   []Conflict{{Field: "name", Kept: "Jane Smith", Discarded: "Jane Smyth"}},
+			AddressIDs:  []string{"address-conformance"},
+			OrderIDs:    []string{},
+			Exposure:    125.5,
+			Points:      350,
+			MergedAt:    mergedAt,
+			MergedBy:    "user-1",
+		}
+	}
+
+	t.Run("Create and list", func(t *testing.T) {
+		repo := newRepo(t)
+		if err := repo.Create(newMerge("merge-conformance-1", "customer-survivor", "customer-duplicate", at)); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		merges, err := repo.ListByCustomer("customer-survivor")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(merges) != 1 {
+			t.Fatalf("Expected 1 merge, got %d", len(merges))
+		}
+		want := newMerge("merge-conformance-1", "customer-survivor", "customer-duplicate", at)
+		merges[0].MergedAt = merges[0].MergedAt.UTC()
+		if !reflect.DeepEqual(merges[0], want) {
+			t.Errorf("Expected %+v, got %+v", want, merges[0])
+		}
+	})
+
+	t.Run("List by survivor or duplicate", func(t *testing.T) {
+		repo := newRepo(t)
+		for _, merge := range []*Merge{
+			newMerge("merge-conformance-2", "customer-a", "customer-b", at.Add(time.Minute)),
+			newMerge("merge-conformance-1", "customer-b", "customer-c", at),
+			newMerge("merge-conformance-3", "customer-d", "customer-e", at),
+		} {
+			if err := repo.Create(merge); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+		}
+
+		merges, err := repo.ListByCustomer("customer-b")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(merges) != 2 || merges[0].MergeID != "merge-conformance-1" || merges[1].MergeID != "merge-conformance-2" {
+			t.Errorf("Expected the customer's merges oldest first, got %+v", merges)
+		}
+		if merges, _ := repo.ListByCustomer("customer-unmerged"); len(merges) != 0 {
+			t.Errorf("Expected no merges, got %+v", merges)
+		}
+	})
+
+	t.Run("Update conflicts", func(t *testing.T) {
+		repo := newRepo(t)
+		if err := repo.Create(newMerge("merge-conformance-1", "customer-survivor", "customer-duplicate", at)); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		redacted := []Conflict{{Field: "name", Kept: nil, Discarded: nil}}
+		if err := repo.UpdateConflicts("merge-conformance-1", redacted); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		merges, _ := repo.ListByCustomer("customer-duplicate")
+		if len(merges) != 1 || !reflect.DeepEqual(merges[0].Conflicts, redacted) {
+			t.Errorf("Expected the redacted conflicts, got %+v", merges)
+		}
+		if err := repo.UpdateConflicts("merge-missing", redacted); !errors.Is(err, ErrMergeNotFound) {
+			t.Errorf("Expected ErrMergeNotFound, got %v", err)
+		}
+	})
+}
+
+func TestInMemoryRepository_Conformance(t *testing.T) {
+	testRepositoryConformance(t, func(t *testing.T) Repository {
+		return NewInMemoryRepository()
+	})
+}
+
+// TestPostgresRepository_Conformance runs against the database in
+// POSTGRES_TEST_DSN and is skipped when it is not set.
+func TestPostgresRepository_Conformance(t *testing.T) {
+	dsn := os.Getenv("POSTGRES_TEST_DSN")
+	if dsn == "" {
+		t.Skip("POSTGRES_TEST_DSN not set")
+	}
+
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := migrations.New(db)
+	if err != nil {
+		t.Fatalf("Failed to load migrations: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+
+	testRepositoryConformance(t, func(t *testing.T) Repository {
+		repo := NewPostgresRepository(db)
+		if _, err := db.Exec(`TRUNCATE customer_merges`); err != nil {
+			t.Fatalf("Failed to reset customer merges: %v", err)
+		}
+		return repo
+	})
+}
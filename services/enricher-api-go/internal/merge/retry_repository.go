@@ -0,0 +1,40 @@
+package merge
+
+import "enricher-api-go/internal/retry"
+
+// RetryRepository retries the calls of another Repository that fail
+// transiently: reads on any transient failure, writes only on failures that
+// show nothing was written.
+type RetryRepository struct {
+	repo    Repository
+	retrier *retry.Retrier
+}
+
+// NewRetryRepository wraps repo with retrier, typically the retrier shared
+// by every repository using the same backend
+func NewRetryRepository(repo Repository, retrier *retry.Retrier) *RetryRepository {
+	return &RetryRepository{repo: repo, retrier: retrier}
+}
+
+// Create stores a new merge record
+func (r *RetryRepository) Create(merge *Merge) error {
+	return r.retrier.Write(func() error {
+		return r.repo.Create(merge)
+	})
+}
+
+// ListByCustomer returns the merges of a customer, oldest first
+func (r *RetryRepository) ListByCustomer(customerID string) (merges []*Merge, err error) {
+	err = r.retrier.Read(func() error {
+		merges, err = r.repo.ListByCustomer(customerID)
+		return err
+	})
+	return merges, err
+}
+
+// UpdateConflicts replaces the conflicts recorded for a merge
+func (r *RetryRepository) UpdateConflicts(mergeID string, conflicts []Conflict) error {
+	return r.retrier.Write(func() error {
+		return r.repo.UpdateConflicts(mergeID, conflicts)
+	})
+}
@@ -0,0 +1,395 @@
+package merge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"enricher-api-go/internal/apperr"
+	"enricher-api-go/internal/auth"
+	"enricher-api-go/internal/clock"
+	"enricher-api-go/internal/customer"
+	"enricher-api-go/internal/idgen"
+	"enricher-api-go/internal/logging"
+	"enricher-api-go/internal/loyalty"
+	"enricher-api-go/internal/order"
+	"enricher-api-go/internal/unitofwork"
+	"enricher-api-go/internal/validation"
+)
+
+// ErrSelfMerge is returned when merging a customer into itself
+var ErrSelfMerge = apperr.New(apperr.ErrValidation, "cannot merge a customer into itself")
+
+// Service defines the business logic interface for customer merges
+type Service interface {
+	MergeCustomer(ctx context.Context, survivorID string, req MergeRequest) (*Merge, error)
+	ListMerges(ctx context.Context, customerID string) ([]*Merge, error)
+}
+
+// MergeService implements the Service interface
+type MergeService struct {
+	repo        Repository
+	customers   customer.Repository
+	orders      order.Repository
+	points      loyalty.Repository
+	unit        unitofwork.UnitOfWork
+	idGenerator idgen.Generator
+	clock       clock.Clock
+}
+
+// Option configures optional MergeService behavior
+type Option func(*MergeService)
+
+// WithOrders sets the orders a merge moves to the survivor. Without it
+// orders are left with the duplicate.
+func WithOrders(orders order.Repository) Option {
+	return func(s *MergeService) {
+		s.orders = orders
+	}
+}
+
+// WithLoyalty sets the loyalty accounts whose points a merge moves to the
+// survivor. Without it points are left with the duplicate.
+func WithLoyalty(points loyalty.Repository) Option {
+	return func(s *MergeService) {
+		s.points = points
+	}
+}
+
+// WithIDGenerator sets the generator used for new merge IDs (UUIDs by default)
+func WithIDGenerator(gen idgen.Generator) Option {
+	return func(s *MergeService) {
+		s.idGenerator = gen
+	}
+}
+
+// WithClock sets the clock used for MergedAt and the customers' UpdatedAt
+// (the UTC wall clock by default)
+func WithClock(c clock.Clock) Option {
+	return func(s *MergeService) {
+		s.clock = c
+	}
+}
+
+// NewService creates a new merge service recording merges in repo and
+// rewriting customers through customers. The writes of a merge run as one
+// unit of work of unit, so a merge failing midway is undone.
+func NewService(repo Repository, customers customer.Repository, unit unitofwork.UnitOfWork, opts ...Option) *MergeService {
+	s := &MergeService{
+		repo:        repo,
+		customers:   customers,
+		unit:        unit,
+		idGenerator: idgen.UUIDGenerator{},
+		clock:       clock.System{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// MergeCustomer merges the duplicate named by req into the survivor and
+// records the merge.
+//
+// The survivor keeps its ID and status. Its name, email, phone and credit
+// limit are resolved by req's strategy: a field only one customer has is
+// kept whatever the strategy, and a field both have with different values
+// is a conflict, recorded with the value kept and the one discarded. The
+// survivor carries the segments of both.
+//
+// The duplicate's addresses, orders, credit exposure and loyalty points
+// balance then move to the survivor, and the duplicate is soft-deleted. A
+// survivor whose credit limit cannot take on the duplicate's exposure fails
+// the merge with customer.ErrCreditLimitExceeded, and any failure undoes the
+// writes made so far.
+func (s *MergeService) MergeCustomer(ctx context.Context, survivorID string, req MergeRequest) (*Merge, error) {
+	logger := logging.FromContext(ctx)
+	logger.Info("Merging customers", "survivor_id", survivorID, "duplicate_id", req.DuplicateID, "strategy", req.Strategy)
+
+	if err := validation.Struct(&req); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	if req.Strategy == "" {
+		req.Strategy = StrategySurvivor
+	}
+	if survivorID == req.DuplicateID {
+		return nil, ErrSelfMerge
+	}
+
+	survivor, err := s.customers.GetByID(survivorID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get survivor: %w", err)
+	}
+	duplicate, err := s.customers.GetByID(req.DuplicateID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get duplicate: %w", err)
+	}
+
+	mergeID, err := s.idGenerator.NewID("merge")
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate merge ID: %w", err)
+	}
+	merge := &Merge{
+		MergeID:     mergeID,
+		SurvivorID:  survivorID,
+		DuplicateID: req.DuplicateID,
+		Strategy:    req.Strategy,
+		AddressIDs:  []string{},
+		OrderIDs:    []string{},
+		MergedAt:    s.clock.Now(),
+		MergedBy:    auth.Caller(ctx),
+	}
+	merged, conflicts := resolve(survivor, duplicate, req.Strategy)
+	merged.UpdatedAt, merged.UpdatedBy = merge.MergedAt, merge.MergedBy
+	merge.Conflicts = conflicts
+
+	err = s.unit.Do(ctx, func(ctx context.Context) error {
+		tx := unitofwork.FromContext(ctx)
+		if err := s.mergeProfile(tx, survivor, duplicate, merged); err != nil {
+			return err
+		}
+		if err := s.moveAddresses(tx, merge); err != nil {
+			return err
+		}
+		if err := s.moveOrders(tx, merge); err != nil {
+			return err
+		}
+		if err := s.moveExposure(tx, merge, duplicate.CurrentExposure); err != nil {
+			return err
+		}
+		if err := s.movePoints(tx, merge); err != nil {
+			return err
+		}
+
+		if err := s.customers.Delete(duplicate.CustomerID); err != nil {
+			return fmt.Errorf("failed to delete duplicate: %w", err)
+		}
+		tx.OnRollback(func() error { return s.customers.Restore(duplicate.CustomerID) })
+
+		if err := s.repo.Create(merge); err != nil {
+			return fmt.Errorf("failed to record merge: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		if !isRefusal(err) {
+			logger.Error("Failed to merge customers", "survivor_id", survivorID, "duplicate_id", req.DuplicateID, "error", err)
+		}
+		return nil, err
+	}
+
+	logger.Info("Merged customers", "merge_id", mergeID, "survivor_id", survivorID, "duplicate_id", req.DuplicateID,
+		"conflicts", len(merge.Conflicts), "addresses", len(merge.AddressIDs), "orders", len(merge.OrderIDs), "points", merge.Points)
+	return merge, nil
+}
+
+// ListMerges returns the merges a customer took part in, as survivor or as
+// duplicate, oldest first. Merged-away duplicates are deleted, so their
+// merges are found whether the customer is live or not.
+func (s *MergeService) ListMerges(ctx context.Context, customerID string) ([]*Merge, error) {
+	if _, err := s.customers.GetByIDIncludingDeleted(customerID); err != nil {
+		return nil, fmt.Errorf("failed to get customer: %w", err)
+	}
+
+	merges, err := s.repo.ListByCustomer(customerID)
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to list merges", "customer_id", customerID, "error", err)
+		return nil, fmt.Errorf("failed to list merges: %w", err)
+	}
+	return merges, nil
+}
+
+// mergeProfile stores the survivor's merged profile. An email the survivor
+// takes over is first released by the duplicate, since emails stay taken by
+// deleted customers.
+func (s *MergeService) mergeProfile(tx *unitofwork.Tx, survivor, duplicate, merged *customer.Customer) error {
+	if duplicate.Email != "" && merged.Email == duplicate.Email {
+		released := *duplicate
+		released.Email = ""
+		if err := s.customers.Update(&released); err != nil {
+			return fmt.Errorf("failed to release duplicate email: %w", err)
+		}
+		tx.OnRollback(func() error { return s.customers.Update(duplicate) })
+	}
+
+	if err := s.customers.Update(merged); err != nil {
+		return fmt.Errorf("failed to update survivor: %w", err)
+	}
+	tx.OnRollback(func() error { return s.customers.Update(survivor) })
+	return nil
+}
+
+// moveAddresses moves the duplicate's addresses to the survivor under the
+// same IDs, so orders shipping to them still resolve. A moved address stays
+// the default of its type only if the survivor has no default of that type.
+func (s *MergeService) moveAddresses(tx *unitofwork.Tx, merge *Merge) error {
+	addresses, err := s.customers.Addresses(merge.DuplicateID)
+	if err != nil {
+		return fmt.Errorf("failed to list duplicate addresses: %w", err)
+	}
+	existing, err := s.customers.Addresses(merge.SurvivorID)
+	if err != nil {
+		return fmt.Errorf("failed to list survivor addresses: %w", err)
+	}
+	defaults := make(map[string]bool)
+	for _, address := range existing {
+		if address.IsDefault {
+			defaults[address.Type] = true
+		}
+	}
+
+	for _, address := range addresses {
+		if err := s.customers.DeleteAddress(merge.DuplicateID, address.AddressID); err != nil {
+			return fmt.Errorf("failed to move address %s: %w", address.AddressID, err)
+		}
+		tx.OnRollback(func() error { return s.customers.CreateAddress(address) })
+
+		moved := *address
+		moved.CustomerID = merge.SurvivorID
+		moved.IsDefault = address.IsDefault && !defaults[address.Type]
+		moved.UpdatedAt, moved.UpdatedBy = merge.MergedAt, merge.MergedBy
+		if err := s.customers.CreateAddress(&moved); err != nil {
+			return fmt.Errorf("failed to move address %s: %w", address.AddressID, err)
+		}
+		tx.OnRollback(func() error { return s.customers.DeleteAddress(merge.SurvivorID, address.AddressID) })
+		merge.AddressIDs = append(merge.AddressIDs, address.AddressID)
+	}
+	return nil
+}
+
+// moveOrders reassigns the duplicate's orders to the survivor
+func (s *MergeService) moveOrders(tx *unitofwork.Tx, merge *Merge) error {
+	if s.orders == nil {
+		return nil
+	}
+
+	orders, err := s.orders.Find(order.OrderFilter{CustomerID: merge.DuplicateID})
+	if err != nil {
+		return fmt.Errorf("failed to list duplicate orders: %w", err)
+	}
+	for _, original := range orders {
+		moved := *original
+		moved.CustomerID = merge.SurvivorID
+		moved.UpdatedAt, moved.UpdatedBy = merge.MergedAt, merge.MergedBy
+		if err := s.orders.Update(&moved); err != nil {
+			return fmt.Errorf("failed to move order %s: %w", original.OrderID, err)
+		}
+		tx.OnRollback(func() error { return s.orders.Update(original) })
+		merge.OrderIDs = append(merge.OrderIDs, original.OrderID)
+	}
+	return nil
+}
+
+// moveExposure moves the credit the duplicate owes on open orders to the
+// survivor, whose limit must cover it
+func (s *MergeService) moveExposure(tx *unitofwork.Tx, merge *Merge, exposure float64) error {
+	if exposure <= 0 {
+		return nil
+	}
+
+	release := customer.ExposureChange{Delta: -exposure, UpdatedAt: merge.MergedAt, UpdatedBy: merge.MergedBy}
+	reserve := customer.ExposureChange{Delta: exposure, UpdatedAt: merge.MergedAt, UpdatedBy: merge.MergedBy}
+	if _, err := s.customers.AdjustExposure(merge.DuplicateID, release); err != nil {
+		return fmt.Errorf("failed to release duplicate exposure: %w", err)
+	}
+	tx.OnRollback(func() error {
+		_, err := s.customers.AdjustExposure(merge.DuplicateID, reserve)
+		return err
+	})
+	if _, err := s.customers.AdjustExposure(merge.SurvivorID, reserve); err != nil {
+		return fmt.Errorf("failed to move exposure to survivor: %w", err)
+	}
+	tx.OnRollback(func() error {
+		_, err := s.customers.AdjustExposure(merge.SurvivorID, release)
+		return err
+	})
+	merge.Exposure = exposure
+	return nil
+}
+
+// movePoints moves the duplicate's loyalty points balance to the survivor,
+// where it counts toward the survivor's tier
+func (s *MergeService) movePoints(tx *unitofwork.Tx, merge *Merge) error {
+	if s.points == nil {
+		return nil
+	}
+
+	account, err := s.points.GetByCustomerID(merge.DuplicateID)
+	if errors.Is(err, loyalty.ErrAccountNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get duplicate loyalty account: %w", err)
+	}
+	points := account.Points
+	if points == 0 {
+		return nil
+	}
+
+	if _, err := s.points.Adjust(merge.DuplicateID, -points, merge.MergedAt); err != nil {
+		return fmt.Errorf("failed to redeem duplicate points: %w", err)
+	}
+	tx.OnRollback(func() error {
+		_, err := s.points.Adjust(merge.DuplicateID, points, merge.MergedAt)
+		return err
+	})
+	if _, err := s.points.Adjust(merge.SurvivorID, points, merge.MergedAt); err != nil {
+		return fmt.Errorf("failed to accrue points to survivor: %w", err)
+	}
+	tx.OnRollback(func() error {
+		_, err := s.points.Adjust(merge.SurvivorID, -points, merge.MergedAt)
+		return err
+	})
+	merge.Points = points
+	return nil
+}
+
+// resolve returns the survivor with the duplicate's profile merged in by
+// strategy, and the conflicts the strategy resolved
+func resolve(survivor, duplicate *customer.Customer, strategy string) (*customer.Customer, []Conflict) {
+	// winner's values are kept in a conflict
+	winner, loser := survivor, duplicate
+	switch strategy {
+	case StrategyDuplicate:
+		winner, loser = duplicate, survivor
+	case StrategyNewest:
+		if duplicate.UpdatedAt.After(survivor.UpdatedAt) {
+			winner, loser = duplicate, survivor
+		}
+	}
+
+	merged := *survivor
+	conflicts := make([]Conflict, 0)
+	pick := func(field, kept, discarded string) string {
+		switch {
+		case kept == "":
+			return discarded
+		case discarded != "" && discarded != kept:
+			conflicts = append(conflicts, Conflict{Field: field, Kept: kept, Discarded: discarded})
+		}
+		return kept
+	}
+	merged.Name = pick("name", winner.Name, loser.Name)
+	merged.Email = pick("email", winner.Email, loser.Email)
+	merged.Phone = pick("phone", winner.Phone, loser.Phone)
+	merged.CreditLimit = winner.CreditLimit
+	if winner.CreditLimit != loser.CreditLimit {
+		conflicts = append(conflicts, Conflict{Field: "creditLimit", Kept: winner.CreditLimit, Discarded: loser.CreditLimit})
+	}
+
+	merged.Segments = slices.Clone(survivor.Segments)
+	for _, segment := range duplicate.Segments {
+		if !slices.Contains(merged.Segments, segment) {
+			merged.Segments = append(merged.Segments, segment)
+		}
+	}
+	return &merged, conflicts
+}
+
+// isRefusal reports whether err is a merge the request or the customers'
+// state rules out, rather than a failure to log
+func isRefusal(err error) bool {
+	kind := apperr.Kind(err)
+	return kind != nil && kind != apperr.ErrUnavailable
+}
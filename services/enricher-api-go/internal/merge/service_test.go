@@ -0,0 +1,254 @@
+package merge
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"enricher-api-go/internal/clock"
+	"enricher-api-go/internal/customer"
+	"enricher-api-go/internal/idgen"
+	"enricher-api-go/internal/loyalty"
+	"enricher-api-go/internal/order"
+	"enricher-api-go/internal/unitofwork"
+	"enricher-api-go/internal/validation"
+)
+
+var mergedAt = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// fixture holds the repositories a merge rewrites: customer-123 survives,
+// customer-456 is its duplicate with an address, an order, exposure and
+// loyalty points
+type fixture struct {
+	merges    *InMemoryRepository
+	customers *customer.InMemoryRepository
+	orders    *order.InMemoryRepository
+	points    *loyalty.InMemoryRepository
+	service   *MergeService
+}
+
+func newFixture(t *testing.T, survivorLimit float64) *fixture {
+	t.Helper()
+	f := &fixture{
+		merges: NewInMemoryRepository(),
+		customers: customer.NewInMemoryRepositoryWith([]*customer.Customer{
+			{CustomerID: "customer-123", Name: "Jane Smith", Status: customer.StatusActive, Phone: "+14155550123",
+				CreditLimit: survivorLimit, Segments: []string{"vip"}, UpdatedAt: mergedAt.Add(-48 * time.Hour)},
+			{CustomerID: "customer-456", Name: "Jane Smyth", Status: customer.StatusActive, Email: "jane@example.com",
+				Phone: "+14155550199", CreditLimit: 500, Segments: []string{"newsletter", "vip"}, UpdatedAt: mergedAt.Add(-time.Hour)},
+		}),
+		orders: order.NewInMemoryRepository(),
+		points: loyalty.NewInMemoryRepository(),
+	}
+	for _, address := range []*customer.Address{
+		{AddressID: "address-1", CustomerID: "customer-123", Type: customer.AddressShipping, Line1: "1 Main St", City: "Springfield", PostalCode: "12345", Country: "US", IsDefault: true},
+		{AddressID: "address-2", CustomerID: "customer-456", Type: customer.AddressShipping, Line1: "2 Elm St", City: "Springfield", PostalCode: "12345", Country: "US", IsDefault: true},
+	} {
+		if err := f.customers.CreateAddress(address); err != nil {
+			t.Fatalf("Failed to create address: %v", err)
+		}
+	}
+	if _, err := f.customers.AdjustExposure("customer-456", customer.ExposureChange{Delta: 200, UpdatedAt: mergedAt.Add(-time.Hour)}); err != nil {
+		t.Fatalf("Failed to reserve credit: %v", err)
+	}
+	if err := f.orders.Create(&order.Order{OrderID: "order-789", CustomerID: "customer-456", Status: order.StatusPending,
+		Items: []order.Item{{ProductID: "product-789", Quantity: 1}}}); err != nil {
+		t.Fatalf("Failed to create order: %v", err)
+	}
+	if _, err := f.points.Adjust("customer-456", 350, mergedAt); err != nil {
+		t.Fatalf("Failed to accrue points: %v", err)
+	}
+
+	f.service = NewService(f.merges, f.customers, unitofwork.Compensating{},
+		WithOrders(f.orders), WithLoyalty(f.points), WithClock(clock.Fixed(mergedAt)),
+		WithIDGenerator(idgen.NewSequentialGenerator(idgen.NewInMemoryCounter())))
+	return f
+}
+
+func TestMergeService_MergeCustomer(t *testing.T) {
+	// Arrange
+	f := newFixture(t, 1000)
+	ctx := context.Background()
+
+	// Act
+	merge, err := f.service.MergeCustomer(ctx, "customer-123", MergeRequest{DuplicateID: "customer-456"})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if merge.Strategy != StrategySurvivor || merge.Exposure != 200 || merge.Points != 350 || !merge.MergedAt.Equal(mergedAt) {
+		t.Errorf("Expected a survivor-strategy merge moving 200 exposure and 350 points, got %+v", merge)
+	}
+	if !slices.Equal(merge.AddressIDs, []string{"address-2"}) || !slices.Equal(merge.OrderIDs, []string{"order-789"}) {
+		t.Errorf("Expected address-2 and order-789 moved, got %v and %v", merge.AddressIDs, merge.OrderIDs)
+	}
+	var fields []string
+	for _, conflict := range merge.Conflicts {
+		fields = append(fields, conflict.Field)
+	}
+	if !slices.Equal(fields, []string{"name", "phone", "creditLimit"}) {
+		t.Errorf("Expected conflicts on name, phone and credit limit, got %+v", merge.Conflicts)
+	}
+
+	survivor, err := f.customers.GetByID("customer-123")
+	if err != nil {
+		t.Fatalf("Expected the survivor to be live, got %v", err)
+	}
+	if survivor.Name != "Jane Smith" || survivor.Phone != "+14155550123" || survivor.Email != "jane@example.com" || survivor.CreditLimit != 1000 {
+		t.Errorf("Expected the survivor's values with the duplicate's email filled in, got %+v", survivor)
+	}
+	if survivor.CurrentExposure != 200 || !slices.Equal(survivor.Segments, []string{"vip", "newsletter"}) {
+		t.Errorf("Expected the duplicate's exposure and segments, got %+v", survivor)
+	}
+	if _, err := f.customers.GetByID("customer-456"); !errors.Is(err, customer.ErrCustomerNotFound) {
+		t.Errorf("Expected the duplicate to be deleted, got %v", err)
+	}
+
+	addresses, _ := f.customers.Addresses("customer-123")
+	if len(addresses) != 2 {
+		t.Fatalf("Expected both addresses on the survivor, got %d", len(addresses))
+	}
+	for _, address := range addresses {
+		if address.AddressID == "address-2" && address.IsDefault {
+			t.Error("Expected the moved address not to replace the survivor's default")
+		}
+	}
+	if moved, _ := f.orders.GetByID("order-789"); moved.CustomerID != "customer-123" {
+		t.Errorf("Expected the order moved to the survivor, got %s", moved.CustomerID)
+	}
+	if account, _ := f.points.GetByCustomerID("customer-123"); account.Points != 350 {
+		t.Errorf("Expected the survivor to hold 350 points, got %+v", account)
+	}
+
+	for _, customerID := range []string{"customer-123", "customer-456"} {
+		merges, err := f.service.ListMerges(ctx, customerID)
+		if err != nil || len(merges) != 1 || merges[0].MergeID != merge.MergeID {
+			t.Errorf("Expected the merge listed for %s, got %v (%v)", customerID, merges, err)
+		}
+	}
+}
+
+func TestMergeService_MergeCustomer_Strategies(t *testing.T) {
+	tests := []struct {
+		strategy  string
+		wantName  string
+		wantLimit float64
+	}{
+		{strategy: "", wantName: "Jane Smith", wantLimit: 1000},
+		{strategy: StrategySurvivor, wantName: "Jane Smith", wantLimit: 1000},
+		{strategy: StrategyDuplicate, wantName: "Jane Smyth", wantLimit: 500},
+		{strategy: StrategyNewest, wantName: "Jane Smyth", wantLimit: 500},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			// Arrange
+			f := newFixture(t, 1000)
+
+			// Act
+			merge, err := f.service.MergeCustomer(context.Background(), "customer-123", MergeRequest{DuplicateID: "customer-456", Strategy: tt.strategy})
+
+			// Assert
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			survivor, _ := f.customers.GetByID("customer-123")
+			if survivor.Name != tt.wantName || survivor.CreditLimit != tt.wantLimit {
+				t.Errorf("Expected %s with a limit of %v, got %+v", tt.wantName, tt.wantLimit, survivor)
+			}
+			if merge.Conflicts[0].Kept != tt.wantName {
+				t.Errorf("Expected the name conflict to keep %s, got %+v", tt.wantName, merge.Conflicts[0])
+			}
+		})
+	}
+}
+
+func TestMergeService_MergeCustomer_Errors(t *testing.T) {
+	tests := []struct {
+		name        string
+		survivorID  string
+		req         MergeRequest
+		expectedErr error
+		// wantInvalid expects the fields of a *validation.Error instead
+		wantInvalid bool
+	}{
+		{name: "no duplicate", survivorID: "customer-123", req: MergeRequest{}, wantInvalid: true},
+		{name: "unknown strategy", survivorID: "customer-123", req: MergeRequest{DuplicateID: "customer-456", Strategy: "oldest"}, wantInvalid: true},
+		{name: "into itself", survivorID: "customer-123", req: MergeRequest{DuplicateID: "customer-123"}, expectedErr: ErrSelfMerge},
+		{name: "unknown survivor", survivorID: "customer-missing", req: MergeRequest{DuplicateID: "customer-456"}, expectedErr: customer.ErrCustomerNotFound},
+		{name: "unknown duplicate", survivorID: "customer-123", req: MergeRequest{DuplicateID: "customer-missing"}, expectedErr: customer.ErrCustomerNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			f := newFixture(t, 1000)
+
+			// Act
+			_, err := f.service.MergeCustomer(context.Background(), tt.survivorID, tt.req)
+
+			// Assert
+			if tt.wantInvalid {
+				var validationErr *validation.Error
+				if !errors.As(err, &validationErr) || len(validationErr.Fields) == 0 {
+					t.Errorf("Expected a validation error naming the fields, got %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.expectedErr) {
+				t.Errorf("Expected %v, got %v", tt.expectedErr, err)
+			}
+			if merges, _ := f.merges.ListByCustomer("customer-123"); len(merges) != 0 {
+				t.Errorf("Expected no merge recorded, got %v", merges)
+			}
+		})
+	}
+}
+
+func TestMergeService_MergeCustomer_UndoesFailedMerge(t *testing.T) {
+	// Arrange: the survivor's limit cannot take on the duplicate's exposure
+	f := newFixture(t, 150)
+
+	// Act
+	_, err := f.service.MergeCustomer(context.Background(), "customer-123", MergeRequest{DuplicateID: "customer-456"})
+
+	// Assert
+	if !errors.Is(err, customer.ErrCreditLimitExceeded) {
+		t.Fatalf("Expected ErrCreditLimitExceeded, got %v", err)
+	}
+	duplicate, err := f.customers.GetByID("customer-456")
+	if err != nil {
+		t.Fatalf("Expected the duplicate to stay live, got %v", err)
+	}
+	if duplicate.Email != "jane@example.com" || duplicate.CurrentExposure != 200 {
+		t.Errorf("Expected the duplicate's email and exposure restored, got %+v", duplicate)
+	}
+	if survivor, _ := f.customers.GetByID("customer-123"); survivor.Email != "" || survivor.CurrentExposure != 0 || survivor.Name != "Jane Smith" {
+		t.Errorf("Expected the survivor unchanged, got %+v", survivor)
+	}
+	if addresses, _ := f.customers.Addresses("customer-456"); len(addresses) != 1 || !addresses[0].IsDefault {
+		t.Errorf("Expected the duplicate's default address back, got %v", addresses)
+	}
+	if moved, _ := f.orders.GetByID("order-789"); moved.CustomerID != "customer-456" {
+		t.Errorf("Expected the order back with the duplicate, got %s", moved.CustomerID)
+	}
+	if merges, _ := f.merges.ListByCustomer("customer-123"); len(merges) != 0 {
+		t.Errorf("Expected no merge recorded, got %v", merges)
+	}
+}
+
+func TestMergeService_ListMerges_UnknownCustomer(t *testing.T) {
+	// Arrange
+	f := newFixture(t, 1000)
+
+	// Act
+	_, err := f.service.ListMerges(context.Background(), "customer-missing")
+
+	// Assert
+	if !errors.Is(err, customer.ErrCustomerNotFound) {
+		t.Errorf("Expected ErrCustomerNotFound, got %v", err)
+	}
+}
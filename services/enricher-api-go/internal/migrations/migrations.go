@@ -0,0 +1,208 @@
+// Package migrations manages the PostgreSQL schema with versioned SQL
+// migrations embedded in the binary, applied by goose.
+//
+// Migrations live in sql/ as files named <version>_<name>.sql, holding the
+// statements to apply after a "-- +goose Up" line and those that undo them
+// after a "-- +goose Down" line. Versions are applied in ascending order and
+// recorded in the goose_db_version table. Released migrations must never be
+// edited; change the schema by adding a file with the next version.
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"time"
+
+	"enricher-api-go/internal/logging"
+
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/lock"
+)
+
+// files holds the migrations shipped with the binary
+//
+//go:embed sql/*.sql
+var files embed.FS
+
+var (
+	// ErrPending is returned by Check while migrations are waiting to be applied
+	ErrPending = errors.New("pending migrations")
+	// ErrNoMigration is returned by Down when no migration is applied
+	ErrNoMigration = errors.New("no migration to revert")
+)
+
+// Migration identifies one versioned schema change
+type Migration struct {
+	Version int64
+	Name    string
+}
+
+// Status reports whether a migration is applied
+type Status struct {
+	Version int64  `json:"version"`
+	Name    string `json:"name"`
+	// AppliedAt is nil while the migration is pending
+	AppliedAt *time.Time `json:"appliedAt,omitempty"`
+}
+
+// Migrator applies the embedded migrations to a PostgreSQL database. Each
+// migration runs in its own transaction, and a session advisory lock is held
+// while migrating so instances started together apply each migration once.
+type Migrator struct {
+	provider *goose.Provider
+}
+
+// Option configures optional Migrator behavior
+type Option func(*options)
+
+type options struct {
+	fsys fs.FS
+}
+
+// WithFS sets the directory of migration files to apply (the embedded ones
+// by default)
+func WithFS(fsys fs.FS) Option {
+	return func(o *options) {
+		o.fsys = fsys
+	}
+}
+
+// New creates a migrator for db. It fails when the migration files are not
+// named <version>_<name>.sql.
+func New(db *sql.DB, opts ...Option) (*Migrator, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.fsys == nil {
+		sub, err := fs.Sub(files, "sql")
+		if err != nil {
+			return nil, err
+		}
+		o.fsys = sub
+	}
+
+	locker, err := lock.NewPostgresSessionLocker()
+	if err != nil {
+		return nil, err
+	}
+	provider, err := goose.NewProvider(goose.DialectPostgres, db, o.fsys,
+		goose.WithSessionLocker(locker),
+		goose.WithDisableGlobalRegistry(true),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load migrations: %w", err)
+	}
+	return &Migrator{provider: provider}, nil
+}
+
+// Migrations lists the known migrations in version order
+func (m *Migrator) Migrations() []Migration {
+	sources := m.provider.ListSources()
+	migrations := make([]Migration, 0, len(sources))
+	for _, source := range sources {
+		migrations = append(migrations, migrationOf(source))
+	}
+	return migrations
+}
+
+// Up applies every pending migration in version order and returns those it
+// applied. It stops at the first failure, keeping the ones before it, and
+// refuses to apply a migration older than the latest applied one.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	logger := logging.FromContext(ctx)
+
+	results, err := m.provider.Up(ctx)
+	var partial *goose.PartialError
+	if errors.As(err, &partial) {
+		results = partial.Applied
+	}
+
+	done := make([]Migration, 0, len(results))
+	for _, result := range results {
+		migration := migrationOf(result.Source)
+		logger.Info("Applied migration", "version", migration.Version, "name", migration.Name)
+		done = append(done, migration)
+	}
+	if err != nil {
+		logger.Error("Failed to migrate", "error", err, "applied", len(done))
+		return done, err
+	}
+
+	logger.Info("Schema is up to date", "applied", len(done), "version", m.latest())
+	return done, nil
+}
+
+// Down reverts the latest applied migration and returns it
+func (m *Migrator) Down(ctx context.Context) (*Migration, error) {
+	result, err := m.provider.Down(ctx)
+	if errors.Is(err, goose.ErrNoNextVersion) {
+		return nil, ErrNoMigration
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	reverted := migrationOf(result.Source)
+	logging.FromContext(ctx).Info("Reverted migration", "version", reverted.Version, "name", reverted.Name)
+	return &reverted, nil
+}
+
+// Status lists every known migration with when it was applied
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	results, err := m.provider.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(results))
+	for _, result := range results {
+		migration := migrationOf(result.Source)
+		status := Status{Version: migration.Version, Name: migration.Name}
+		if result.State == goose.StateApplied {
+			appliedAt := result.AppliedAt
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Check fails unless every known migration is applied, for readiness
+// probes of instances that do not migrate on start
+func (m *Migrator) Check(ctx context.Context) error {
+	pending, err := m.provider.HasPending(ctx)
+	if err != nil {
+		return err
+	}
+	if pending {
+		current, target, err := m.provider.GetVersions(ctx)
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("%w: the database is at version %d of %d", ErrPending, current, target)
+	}
+	return nil
+}
+
+// latest returns the newest known version, 0 when there are none
+func (m *Migrator) latest() int64 {
+	sources := m.provider.ListSources()
+	if len(sources) == 0 {
+		return 0
+	}
+	return sources[len(sources)-1].Version
+}
+
+// migrationOf reads the name of a migration from its <version>_<name>.sql
+// file name
+func migrationOf(source *goose.Source) Migration {
+	_, name, _ := strings.Cut(strings.TrimSuffix(path.Base(source.Path), ".sql"), "_")
+	return Migration{Version: source.Version, Name: name}
+}
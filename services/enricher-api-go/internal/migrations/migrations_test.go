@@ -0,0 +1,136 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+	"testing/fstest"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// openUnused returns a handle that is never connected, since listing the
+// migrations does not touch the database
+func openUnused(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("pgx", "postgres://localhost/unused")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestNew(t *testing.T) {
+	// Arrange
+	fsys := fstest.MapFS{
+		"00002_add_index.sql": {Data: []byte("-- +goose Up\nCREATE INDEX items_name_idx ON items (name);\n")},
+		"00001_create_items.sql": {Data: []byte("-- Items\n-- +goose Up\nCREATE TABLE items (name TEXT);\n\n" +
+			"-- +goose Down\nDROP TABLE items;\n")},
+		"README.md": {Data: []byte("not a migration")},
+	}
+
+	// Act
+	m, err := New(openUnused(t), WithFS(fsys))
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	migrations := m.Migrations()
+	if len(migrations) != 2 {
+		t.Fatalf("Expected 2 migrations, got %+v", migrations)
+	}
+	if migrations[0] != (Migration{Version: 1, Name: "create_items"}) || migrations[1] != (Migration{Version: 2, Name: "add_index"}) {
+		t.Errorf("Expected create_items then add_index, got %+v", migrations)
+	}
+}
+
+func TestNew_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		files fstest.MapFS
+	}{
+		{name: "no version", files: fstest.MapFS{"create_items.sql": {Data: []byte("-- +goose Up\nSELECT 1;\n")}}},
+		{name: "duplicate version", files: fstest.MapFS{
+			"1_create_items.sql":   {Data: []byte("-- +goose Up\nSELECT 1;\n")},
+			"01_create_orders.sql": {Data: []byte("-- +goose Up\nSELECT 1;\n")},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			_, err := New(openUnused(t), WithFS(tt.files))
+
+			// Assert
+			if err == nil {
+				t.Error("Expected an error, got nil")
+			}
+		})
+	}
+}
+
+func TestNew_Embedded(t *testing.T) {
+	// Act
+	m, err := New(openUnused(t))
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected the embedded migrations to load, got %v", err)
+	}
+	migrations := m.Migrations()
+	if len(migrations) == 0 || migrations[0] != (Migration{Version: 1, Name: "initial_schema"}) {
+		t.Errorf("Expected the baseline migration first, got %+v", migrations)
+	}
+	for i, migration := range migrations {
+		if migration.Version != int64(i+1) {
+			t.Errorf("Expected version %d at %d, got %d", i+1, i, migration.Version)
+		}
+	}
+}
+
+// TestMigrator_Postgres runs against the database in POSTGRES_TEST_DSN and
+// is skipped when it is not set.
+func TestMigrator_Postgres(t *testing.T) {
+	dsn := os.Getenv("POSTGRES_TEST_DSN")
+	if dsn == "" {
+		t.Skip("POSTGRES_TEST_DSN not set")
+	}
+
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	m, err := New(db)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	ctx := context.Background()
+
+	// Act
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatalf("Expected the migrations to apply, got %v", err)
+	}
+	again, err := m.Up(ctx)
+
+	// Assert
+	if err != nil || len(again) != 0 {
+		t.Errorf("Expected a second run to apply nothing, got %v and %v", again, err)
+	}
+	if err := m.Check(ctx); err != nil {
+		t.Errorf("Expected no pending migrations, got %v", err)
+	}
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for _, status := range statuses {
+		if status.AppliedAt == nil {
+			t.Errorf("Expected migration %d to be applied", status.Version)
+		}
+	}
+}
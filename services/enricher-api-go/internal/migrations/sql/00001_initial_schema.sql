@@ -0,0 +1,224 @@
+-- Baseline schema, matching what the repositories' EnsureSchema created
+-- before migrations were introduced. Every statement is idempotent so
+-- databases set up that way adopt it without changes.
+
+-- +goose Up
+-- Customers
+CREATE TABLE IF NOT EXISTS customers (
+	customer_id TEXT PRIMARY KEY,
+	name        TEXT NOT NULL,
+	status      TEXT NOT NULL,
+	email       TEXT NOT NULL DEFAULT '',
+	phone       TEXT NOT NULL DEFAULT '',
+	credit_limit     DOUBLE PRECISION NOT NULL DEFAULT 0 CHECK (credit_limit >= 0),
+	current_exposure DOUBLE PRECISION NOT NULL DEFAULT 0 CHECK (current_exposure >= 0),
+	segments    JSONB NOT NULL DEFAULT '[]'::jsonb,
+	created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+	updated_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+	created_by  TEXT NOT NULL DEFAULT '',
+	updated_by  TEXT NOT NULL DEFAULT '',
+	deleted_at  TIMESTAMPTZ
+);
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT now();
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ NOT NULL DEFAULT now();
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS created_by TEXT NOT NULL DEFAULT '';
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS updated_by TEXT NOT NULL DEFAULT '';
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS email TEXT NOT NULL DEFAULT '';
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS phone TEXT NOT NULL DEFAULT '';
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS credit_limit DOUBLE PRECISION NOT NULL DEFAULT 0 CHECK (credit_limit >= 0);
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS current_exposure DOUBLE PRECISION NOT NULL DEFAULT 0 CHECK (current_exposure >= 0);
+CREATE INDEX IF NOT EXISTS customers_segments_idx ON customers USING GIN (segments);
+CREATE UNIQUE INDEX IF NOT EXISTS customers_email_key ON customers (email) WHERE email <> '';
+UPDATE customers SET status = 'SUSPENDED' WHERE status = 'INACTIVE';
+CREATE TABLE IF NOT EXISTS customer_addresses (
+	address_id  TEXT PRIMARY KEY,
+	customer_id TEXT NOT NULL REFERENCES customers (customer_id),
+	type        TEXT NOT NULL,
+	line1       TEXT NOT NULL,
+	line2       TEXT NOT NULL DEFAULT '',
+	city        TEXT NOT NULL,
+	region      TEXT NOT NULL DEFAULT '',
+	postal_code TEXT NOT NULL,
+	country     TEXT NOT NULL,
+	is_default  BOOLEAN NOT NULL DEFAULT false,
+	created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+	updated_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+	created_by  TEXT NOT NULL DEFAULT '',
+	updated_by  TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS customer_addresses_customer_idx ON customer_addresses (customer_id, type);
+
+-- Products
+CREATE TABLE IF NOT EXISTS products (
+	product_id  TEXT PRIMARY KEY,
+	name        TEXT NOT NULL,
+	description TEXT NOT NULL,
+	price       DOUBLE PRECISION NOT NULL,
+	currency    TEXT NOT NULL DEFAULT 'USD',
+	prices      JSONB,
+	category    TEXT NOT NULL,
+	quantity    INTEGER NOT NULL DEFAULT 0 CHECK (quantity >= 0),
+	weight      JSONB,
+	dimensions  JSONB,
+	created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+	updated_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+	created_by  TEXT NOT NULL DEFAULT '',
+	updated_by  TEXT NOT NULL DEFAULT '',
+	deleted_at  TIMESTAMPTZ
+);
+ALTER TABLE products ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT now();
+ALTER TABLE products ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ NOT NULL DEFAULT now();
+ALTER TABLE products ADD COLUMN IF NOT EXISTS created_by TEXT NOT NULL DEFAULT '';
+ALTER TABLE products ADD COLUMN IF NOT EXISTS updated_by TEXT NOT NULL DEFAULT '';
+ALTER TABLE products ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS currency TEXT NOT NULL DEFAULT 'USD';
+ALTER TABLE products ADD COLUMN IF NOT EXISTS prices JSONB;
+-- +goose StatementBegin
+DO $$
+BEGIN
+	IF EXISTS (SELECT 1 FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = 'products' AND column_name = 'in_stock') THEN
+		ALTER TABLE products ADD COLUMN IF NOT EXISTS quantity INTEGER NOT NULL DEFAULT 0 CHECK (quantity >= 0);
+		UPDATE products SET quantity = 1 WHERE in_stock;
+		ALTER TABLE products DROP COLUMN in_stock;
+	END IF;
+END $$;
+-- +goose StatementEnd
+CREATE INDEX IF NOT EXISTS products_category_idx ON products (category);
+CREATE TABLE IF NOT EXISTS stock_movements (
+	id          BIGSERIAL PRIMARY KEY,
+	product_id  TEXT NOT NULL REFERENCES products (product_id),
+	delta       INTEGER NOT NULL,
+	quantity    INTEGER NOT NULL,
+	reason      TEXT NOT NULL,
+	actor       TEXT NOT NULL DEFAULT '',
+	created_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS stock_movements_product_idx ON stock_movements (product_id, id);
+CREATE TABLE IF NOT EXISTS price_changes (
+	id           BIGSERIAL PRIMARY KEY,
+	product_id   TEXT NOT NULL REFERENCES products (product_id),
+	price        DOUBLE PRECISION NOT NULL,
+	effective_at TIMESTAMPTZ NOT NULL,
+	created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+	created_by   TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS price_changes_product_idx ON price_changes (product_id, effective_at);
+CREATE TABLE IF NOT EXISTS product_variants (
+	sku         TEXT PRIMARY KEY,
+	product_id  TEXT NOT NULL REFERENCES products (product_id),
+	attributes  JSONB,
+	price       DOUBLE PRECISION NOT NULL,
+	quantity    INTEGER NOT NULL DEFAULT 0 CHECK (quantity >= 0),
+	created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+	updated_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+	created_by  TEXT NOT NULL DEFAULT '',
+	updated_by  TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS product_variants_product_idx ON product_variants (product_id);
+
+-- Categories
+CREATE TABLE IF NOT EXISTS categories (
+	category_id TEXT PRIMARY KEY,
+	name        TEXT NOT NULL UNIQUE,
+	parent_id   TEXT REFERENCES categories (category_id),
+	created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+	updated_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+	created_by  TEXT NOT NULL DEFAULT '',
+	updated_by  TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS categories_parent_idx ON categories (parent_id);
+
+-- Orders
+CREATE TABLE IF NOT EXISTS orders (
+	order_id            TEXT PRIMARY KEY,
+	customer_id         TEXT NOT NULL,
+	items               JSONB NOT NULL,
+	shipping_address_id TEXT NOT NULL DEFAULT '',
+	status              TEXT NOT NULL,
+	enrichment          JSONB,
+	failure_reason      TEXT NOT NULL DEFAULT '',
+	enriched_at         TIMESTAMPTZ,
+	created_at          TIMESTAMPTZ NOT NULL DEFAULT now(),
+	updated_at          TIMESTAMPTZ NOT NULL DEFAULT now(),
+	created_by          TEXT NOT NULL DEFAULT '',
+	updated_by          TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS orders_customer_idx ON orders (customer_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS orders_status_idx ON orders (status, created_at DESC);
+
+-- Dead letters
+CREATE TABLE IF NOT EXISTS dead_letters (
+	entry_id       TEXT PRIMARY KEY,
+	source         TEXT NOT NULL,
+	source_id      TEXT NOT NULL,
+	order_request  JSONB NOT NULL,
+	status         TEXT NOT NULL,
+	failure_reason TEXT NOT NULL DEFAULT '',
+	retry_count    INTEGER NOT NULL DEFAULT 0,
+	created_at     TIMESTAMPTZ NOT NULL DEFAULT now(),
+	last_failed_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	resolved_at    TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX IF NOT EXISTS dead_letters_open_idx ON dead_letters (source, source_id) WHERE status = 'OPEN';
+CREATE INDEX IF NOT EXISTS dead_letters_status_idx ON dead_letters (status, last_failed_at DESC);
+
+-- Webhooks
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+	subscription_id TEXT PRIMARY KEY,
+	url             TEXT NOT NULL,
+	events          JSONB NOT NULL DEFAULT '[]'::jsonb,
+	secret          TEXT NOT NULL,
+	active          BOOLEAN NOT NULL DEFAULT TRUE,
+	created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+	updated_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+	created_by      TEXT NOT NULL DEFAULT '',
+	updated_by      TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS webhook_subscriptions_events_idx ON webhook_subscriptions USING GIN (events);
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+	delivery_id     TEXT PRIMARY KEY,
+	subscription_id TEXT NOT NULL REFERENCES webhook_subscriptions (subscription_id) ON DELETE CASCADE,
+	event_id        TEXT NOT NULL,
+	event_type      TEXT NOT NULL,
+	payload         JSONB NOT NULL,
+	status          TEXT NOT NULL,
+	attempts        INTEGER NOT NULL DEFAULT 0,
+	next_attempt_at TIMESTAMPTZ NOT NULL,
+	last_attempt_at TIMESTAMPTZ,
+	response_status INTEGER NOT NULL DEFAULT 0,
+	last_error      TEXT NOT NULL DEFAULT '',
+	created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+	delivered_at    TIMESTAMPTZ,
+	UNIQUE (subscription_id, event_id)
+);
+CREATE INDEX IF NOT EXISTS webhook_deliveries_subscription_idx ON webhook_deliveries (subscription_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS webhook_deliveries_due_idx ON webhook_deliveries (next_attempt_at) WHERE status = 'PENDING';
+
+-- Outbox
+CREATE TABLE IF NOT EXISTS outbox_events (
+	sequence     BIGSERIAL PRIMARY KEY,
+	event_id     TEXT NOT NULL UNIQUE,
+	type         TEXT NOT NULL,
+	aggregate    TEXT NOT NULL,
+	aggregate_id TEXT NOT NULL,
+	data         JSONB NOT NULL,
+	occurred_at  TIMESTAMPTZ NOT NULL,
+	published_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS outbox_events_pending_idx ON outbox_events (sequence) WHERE published_at IS NULL;
+
+-- +goose Down
+DROP TABLE IF EXISTS outbox_events;
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhook_subscriptions;
+DROP TABLE IF EXISTS dead_letters;
+DROP TABLE IF EXISTS orders;
+DROP TABLE IF EXISTS categories;
+DROP TABLE IF EXISTS product_variants;
+DROP TABLE IF EXISTS price_changes;
+DROP TABLE IF EXISTS stock_movements;
+DROP TABLE IF EXISTS products;
+DROP TABLE IF EXISTS customer_addresses;
+DROP TABLE IF EXISTS customers;
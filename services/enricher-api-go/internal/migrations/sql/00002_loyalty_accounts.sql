@@ -0,0 +1,13 @@
+-- Loyalty points balances of customers
+
+-- +goose Up
+CREATE TABLE IF NOT EXISTS loyalty_accounts (
+	customer_id     TEXT PRIMARY KEY,
+	points          INTEGER NOT NULL DEFAULT 0 CHECK (points >= 0),
+	lifetime_points INTEGER NOT NULL DEFAULT 0,
+	created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+	updated_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS loyalty_accounts;
@@ -0,0 +1,9 @@
+-- Barcodes of products, looked up by warehouse scanners
+
+-- +goose Up
+ALTER TABLE products ADD COLUMN IF NOT EXISTS barcode TEXT NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS products_barcode_idx ON products (barcode) WHERE barcode <> '';
+
+-- +goose Down
+DROP INDEX IF EXISTS products_barcode_idx;
+ALTER TABLE products DROP COLUMN IF EXISTS barcode;
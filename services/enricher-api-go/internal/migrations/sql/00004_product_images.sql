@@ -0,0 +1,19 @@
+-- Images uploaded for products; the files themselves live in the media store
+
+-- +goose Up
+CREATE TABLE IF NOT EXISTS product_images (
+	image_id      TEXT PRIMARY KEY,
+	product_id    TEXT NOT NULL,
+	content_type  TEXT NOT NULL,
+	size          BIGINT NOT NULL,
+	width         INTEGER NOT NULL,
+	height        INTEGER NOT NULL,
+	key           TEXT NOT NULL,
+	thumbnail_key TEXT NOT NULL,
+	created_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+	created_by    TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS product_images_product_idx ON product_images (product_id, created_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS product_images;
@@ -0,0 +1,21 @@
+-- Audit trail of duplicate customers merged into survivors
+
+-- +goose Up
+CREATE TABLE IF NOT EXISTS customer_merges (
+	merge_id     TEXT PRIMARY KEY,
+	survivor_id  TEXT NOT NULL,
+	duplicate_id TEXT NOT NULL,
+	strategy     TEXT NOT NULL,
+	conflicts    JSONB NOT NULL,
+	address_ids  JSONB NOT NULL,
+	order_ids    JSONB NOT NULL,
+	exposure     DOUBLE PRECISION NOT NULL DEFAULT 0,
+	points       INTEGER NOT NULL DEFAULT 0,
+	merged_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+	merged_by    TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS customer_merges_survivor_idx ON customer_merges (survivor_id, merged_at);
+CREATE INDEX IF NOT EXISTS customer_merges_duplicate_idx ON customer_merges (duplicate_id, merged_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS customer_merges;
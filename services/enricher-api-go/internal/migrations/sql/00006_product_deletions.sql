@@ -0,0 +1,16 @@
+-- Intervals during which products were soft-deleted, so reads as of a past
+-- instant can tell whether a product was deleted then
+
+-- +goose Up
+CREATE TABLE IF NOT EXISTS product_deletions (
+	id          BIGSERIAL PRIMARY KEY,
+	product_id  TEXT NOT NULL REFERENCES products (product_id),
+	deleted_at  TIMESTAMPTZ NOT NULL,
+	restored_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS product_deletions_product_idx ON product_deletions (product_id, deleted_at);
+INSERT INTO product_deletions (product_id, deleted_at)
+SELECT product_id, deleted_at FROM products WHERE deleted_at IS NOT NULL;
+
+-- +goose Down
+DROP TABLE IF EXISTS product_deletions;
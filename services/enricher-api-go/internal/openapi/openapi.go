@@ -0,0 +1,230 @@
+// Package openapi builds an OpenAPI 3 document from the routes registered on
+// Echo and the Go types their handlers bind and return.
+//
+// Schemas are generated by reflection from json struct tags, and validate
+// tags are translated into the matching JSON Schema constraints, so the
+// document follows the code instead of a handwritten file.
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Version is the OpenAPI specification version of generated documents
+const Version = "3.0.3"
+
+// Document is the root of an OpenAPI document
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+// Info describes the API
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// PathItem maps lowercase HTTP methods to the operations of one path
+type PathItem map[string]*Operation
+
+// Operation describes one API endpoint
+type Operation struct {
+	OperationID string              `json:"operationId,omitempty"`
+	Summary     string              `json:"summary,omitempty"`
+	Tags        []string            `json:"tags,omitempty"`
+	Parameters  []Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]Response `json:"responses"`
+	Deprecated  bool                `json:"deprecated,omitempty"`
+}
+
+// Parameter describes a path or query parameter
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody describes the body an operation accepts
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response describes one response of an operation
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType holds the schema of a body for one content type
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Components holds the reusable schemas referenced by operations
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+// Schema is the subset of the OpenAPI schema object used by generated documents
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	ExclusiveMinimum     bool               `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     bool               `json:"exclusiveMaximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+}
+
+// ProblemMediaType is the media type of error response bodies
+const ProblemMediaType = "application/problem+json"
+
+// Endpoint describes the inputs and outputs of one route.
+//
+// Request and Responses hold zero values of the Go types the handler binds
+// and returns, such as CustomerRequest{}; a nil response means no body.
+// RequestMediaType is the media type of Request, application/json when empty,
+// and ResponseMediaTypes those of successful response bodies. Error bodies
+// are documented as ProblemMediaType. Deprecated marks a route superseded by
+// a newer API version.
+type Endpoint struct {
+	Summary            string
+	Tag                string
+	Query              []Parameter
+	Request            interface{}
+	RequestMediaType   string
+	Responses          map[int]interface{}
+	ResponseMediaTypes []string
+	Deprecated         bool
+}
+
+// File marks an uploaded file field of a multipart/form-data request body
+type File struct{}
+
+// QueryParam returns an optional query parameter of the given JSON type
+func QueryParam(name, schemaType, description string) Parameter {
+	return Parameter{Name: name, In: "query", Description: description, Schema: &Schema{Type: schemaType}}
+}
+
+// Builder assembles a Document, generating component schemas on demand
+type Builder struct {
+	doc *Document
+}
+
+// NewBuilder starts an empty document for the named API
+func NewBuilder(title, version string) *Builder {
+	return &Builder{doc: &Document{
+		OpenAPI:    Version,
+		Info:       Info{Title: title, Version: version},
+		Paths:      make(map[string]PathItem),
+		Components: Components{Schemas: make(map[string]*Schema)},
+	}}
+}
+
+// echoParam matches Echo path parameters such as :id
+var echoParam = regexp.MustCompile(`:([A-Za-z0-9_]+)`)
+
+// Add documents the route with the given method and Echo path
+func (b *Builder) Add(method, path string, endpoint Endpoint) {
+	op := &Operation{
+		OperationID: operationID(method, path),
+		Summary:     endpoint.Summary,
+		Responses:   make(map[string]Response, len(endpoint.Responses)),
+		Deprecated:  endpoint.Deprecated,
+	}
+	if endpoint.Tag != "" {
+		op.Tags = []string{endpoint.Tag}
+	}
+
+	for _, match := range echoParam.FindAllStringSubmatch(path, -1) {
+		op.Parameters = append(op.Parameters, Parameter{
+			Name:     match[1],
+			In:       "path",
+			Required: true,
+			Schema:   &Schema{Type: "string"},
+		})
+	}
+	op.Parameters = append(op.Parameters, endpoint.Query...)
+
+	if endpoint.Request != nil {
+		content := jsonContent(b.SchemaFor(endpoint.Request))
+		if endpoint.RequestMediaType != "" {
+			content = map[string]MediaType{endpoint.RequestMediaType: content["application/json"]}
+		}
+		op.RequestBody = &RequestBody{Required: true, Content: content}
+	}
+
+	for status, body := range endpoint.Responses {
+		response := Response{Description: http.StatusText(status)}
+		if body != nil {
+			response.Content = jsonContent(b.SchemaFor(body))
+		}
+		if body != nil && status >= 400 {
+			response.Content = map[string]MediaType{ProblemMediaType: response.Content["application/json"]}
+		}
+		if body != nil && status < 300 && len(endpoint.ResponseMediaTypes) > 0 {
+			schema := response.Content["application/json"].Schema
+			response.Content = make(map[string]MediaType, len(endpoint.ResponseMediaTypes))
+			for _, mediaType := range endpoint.ResponseMediaTypes {
+				response.Content[mediaType] = MediaType{Schema: schema}
+			}
+		}
+		op.Responses[strconv.Itoa(status)] = response
+	}
+
+	openAPIPath := echoParam.ReplaceAllString(path, "{$1}")
+	item, ok := b.doc.Paths[openAPIPath]
+	if !ok {
+		item = make(PathItem)
+		b.doc.Paths[openAPIPath] = item
+	}
+	item[strings.ToLower(method)] = op
+}
+
+// Document returns the assembled document
+func (b *Builder) Document() *Document {
+	return b.doc
+}
+
+// SchemaFor returns the schema of v's type, registering named struct types
+// as components and referencing them
+func (b *Builder) SchemaFor(v interface{}) *Schema {
+	return b.schemaOf(reflect.TypeOf(v))
+}
+
+// jsonContent wraps schema as an application/json body
+func jsonContent(schema *Schema) map[string]MediaType {
+	return map[string]MediaType{"application/json": {Schema: schema}}
+}
+
+// operationID derives a stable identifier such as get_v1_customers_id
+func operationID(method, path string) string {
+	parts := []string{strings.ToLower(method)}
+	for _, segment := range strings.Split(path, "/") {
+		segment = strings.TrimPrefix(segment, ":")
+		if segment != "" {
+			parts = append(parts, segment)
+		}
+	}
+	return strings.Join(parts, "_")
+}
@@ -0,0 +1,156 @@
+package openapi
+
+import (
+	"net/http"
+	"testing"
+)
+
+type testAddress struct {
+	City string `json:"city" validate:"required"`
+}
+
+type testRequest struct {
+	Name    string       `json:"name" validate:"required,min=2,max=100"`
+	Price   float64      `json:"price" validate:"required,gt=0"`
+	Status  string       `json:"status" validate:"oneof=ACTIVE INACTIVE"`
+	Tags    []string     `json:"tags,omitempty" validate:"omitempty,max=3,dive,min=1,max=32"`
+	Address *testAddress `json:"address,omitempty"`
+	Secret  string       `json:"-"`
+	hidden  string
+}
+
+func TestBuilder_SchemaFor_ValidateTags(t *testing.T) {
+	// Arrange
+	builder := NewBuilder("Test API", "1.0.0")
+
+	// Act
+	ref := builder.SchemaFor(testRequest{})
+
+	// Assert
+	if ref.Ref != "#/components/schemas/openapi.testRequest" {
+		t.Fatalf("Expected component reference, got %q", ref.Ref)
+	}
+
+	schema := builder.Document().Components.Schemas["openapi.testRequest"]
+	if len(schema.Required) != 2 || schema.Required[0] != "name" || schema.Required[1] != "price" {
+		t.Errorf("Expected required [name price], got %v", schema.Required)
+	}
+
+	name := schema.Properties["name"]
+	if name.Type != "string" || *name.MinLength != 2 || *name.MaxLength != 100 {
+		t.Errorf("Expected string of length 2-100, got %+v", name)
+	}
+
+	price := schema.Properties["price"]
+	if price.Type != "number" || *price.Minimum != 0 || !price.ExclusiveMinimum {
+		t.Errorf("Expected exclusive minimum 0, got %+v", price)
+	}
+
+	if status := schema.Properties["status"]; len(status.Enum) != 2 || status.Enum[0] != "ACTIVE" {
+		t.Errorf("Expected enum [ACTIVE INACTIVE], got %v", status.Enum)
+	}
+
+	tags := schema.Properties["tags"]
+	if tags.Type != "array" || *tags.MaxItems != 3 || *tags.Items.MaxLength != 32 {
+		t.Errorf("Expected at most 3 tags of up to 32 characters, got %+v", tags)
+	}
+
+	if address := schema.Properties["address"]; address.Ref != "#/components/schemas/openapi.testAddress" {
+		t.Errorf("Expected address reference, got %+v", address)
+	}
+
+	if _, exists := schema.Properties["Secret"]; exists {
+		t.Error("Expected json:\"-\" field to be skipped")
+	}
+	if _, exists := schema.Properties["hidden"]; exists {
+		t.Error("Expected unexported field to be skipped")
+	}
+}
+
+func TestBuilder_Add(t *testing.T) {
+	// Arrange
+	builder := NewBuilder("Test API", "1.0.0")
+
+	// Act
+	builder.Add(http.MethodDelete, "/v1/items/:id/tags/:tag", Endpoint{
+		Summary:   "Remove a tag",
+		Tag:       "items",
+		Query:     []Parameter{QueryParam("force", "boolean", "")},
+		Responses: map[int]interface{}{http.StatusNoContent: nil},
+	})
+
+	// Assert
+	item, exists := builder.Document().Paths["/v1/items/{id}/tags/{tag}"]
+	if !exists {
+		t.Fatalf("Expected OpenAPI path template, got %v", builder.Document().Paths)
+	}
+
+	op := item["delete"]
+	if op == nil {
+		t.Fatal("Expected delete operation")
+	}
+
+	if op.OperationID != "delete_v1_items_id_tags_tag" {
+		t.Errorf("Expected generated operation ID, got %q", op.OperationID)
+	}
+
+	if len(op.Parameters) != 3 || op.Parameters[0].Name != "id" || op.Parameters[1].Name != "tag" || op.Parameters[2].In != "query" {
+		t.Errorf("Expected path parameters followed by query parameters, got %+v", op.Parameters)
+	}
+
+	if response := op.Responses["204"]; response.Description != "No Content" || response.Content != nil {
+		t.Errorf("Expected empty 204 response, got %+v", response)
+	}
+}
+
+func TestBuilder_Add_MultipartRequest(t *testing.T) {
+	// Arrange
+	builder := NewBuilder("Test API", "1.0.0")
+	form := struct {
+		File  File `json:"file" validate:"required"`
+		Async bool `json:"async,omitempty"`
+	}{}
+
+	// Act
+	builder.Add(http.MethodPost, "/v1/items/import", Endpoint{
+		Request:          form,
+		RequestMediaType: "multipart/form-data",
+		Responses:        map[int]interface{}{http.StatusOK: nil},
+	})
+
+	// Assert
+	body := builder.Document().Paths["/v1/items/import"]["post"].RequestBody
+	media, exists := body.Content["multipart/form-data"]
+	if !exists || len(body.Content) != 1 {
+		t.Fatalf("Expected a multipart/form-data body, got %+v", body.Content)
+	}
+	if file := media.Schema.Properties["file"]; file.Type != "string" || file.Format != "binary" {
+		t.Errorf("Expected the file field as a binary string, got %+v", file)
+	}
+}
+
+func TestBuilder_Add_ResponseMediaTypes(t *testing.T) {
+	// Arrange
+	builder := NewBuilder("Test API", "1.0.0")
+	type item struct {
+		ID string `json:"id"`
+	}
+	errorBody := struct {
+		Title string `json:"title"`
+	}{}
+
+	// Act
+	builder.Add(http.MethodGet, "/v1/items/export", Endpoint{
+		Responses:          map[int]interface{}{http.StatusOK: item{}, http.StatusBadRequest: errorBody},
+		ResponseMediaTypes: []string{"text/csv", "application/x-ndjson"},
+	})
+
+	// Assert
+	responses := builder.Document().Paths["/v1/items/export"]["get"].Responses
+	if content := responses["200"].Content; len(content) != 2 || content["text/csv"].Schema == nil || content["application/x-ndjson"].Schema == nil {
+		t.Errorf("Expected CSV and NDJSON success bodies, got %+v", content)
+	}
+	if content := responses["400"].Content; len(content) != 1 || content[ProblemMediaType].Schema == nil {
+		t.Errorf("Expected a problem details error body, got %+v", content)
+	}
+}
@@ -0,0 +1,242 @@
+package openapi
+
+import (
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	fileType = reflect.TypeOf(File{})
+)
+
+// schemaOf builds the schema for t. Named struct types are registered under
+// components as "<package>.<Type>" and returned as references.
+func (b *Builder) schemaOf(t reflect.Type) *Schema {
+	if t == nil {
+		return &Schema{}
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == fileType:
+		return &Schema{Type: "string", Format: "binary"}
+	case t.Kind() == reflect.Struct && t.Name() != "":
+		name := componentName(t)
+		if _, exists := b.doc.Components.Schemas[name]; !exists {
+			// Reserve the name first so self-referencing types terminate
+			b.doc.Components.Schemas[name] = &Schema{}
+			b.doc.Components.Schemas[name] = b.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: b.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: b.schemaOf(t.Elem())}
+	case reflect.Struct:
+		return b.structSchema(t)
+	default:
+		return &Schema{}
+	}
+}
+
+// structSchema builds an object schema from t's exported, JSON-visible
+// fields, flattening embedded structs the way encoding/json does
+func (b *Builder) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, skip := jsonName(field)
+		if skip {
+			continue
+		}
+
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			embedded := b.structSchema(fieldType)
+			for property, propertySchema := range embedded.Properties {
+				schema.Properties[property] = propertySchema
+			}
+			schema.Required = append(schema.Required, embedded.Required...)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property := b.schemaOf(field.Type)
+		if applyValidateTag(property, field.Type, field.Tag.Get("validate")) {
+			schema.Required = append(schema.Required, name)
+		}
+		schema.Properties[name] = property
+	}
+
+	return schema
+}
+
+// jsonName returns the JSON property name of field and whether encoding/json
+// skips it
+func jsonName(field reflect.StructField) (string, bool) {
+	if !field.IsExported() && !field.Anonymous {
+		return "", true
+	}
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", true
+	}
+	name, _, _ := strings.Cut(tag, ",")
+	return name, false
+}
+
+// componentName qualifies a type name with its package, e.g. customer.BatchRequest
+func componentName(t reflect.Type) string {
+	return path.Base(t.PkgPath()) + "." + t.Name()
+}
+
+// applyValidateTag translates go-playground/validator rules into schema
+// constraints and reports whether the field is required. Rules after "dive"
+// apply to the items of a slice; unknown rules are ignored.
+func applyValidateTag(schema *Schema, t reflect.Type, tag string) bool {
+	if tag == "" || schema.Ref != "" {
+		return strings.Contains(","+tag+",", ",required,")
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	required := false
+	rules := strings.Split(tag, ",")
+	for i, rule := range rules {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			required = true
+		case "dive":
+			if schema.Items != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+				applyValidateTag(schema.Items, t.Elem(), strings.Join(rules[i+1:], ","))
+			}
+			return required
+		case "min", "gte":
+			setLowerBound(schema, t, param, false)
+		case "max", "lte":
+			setUpperBound(schema, t, param, false)
+		case "gt":
+			setLowerBound(schema, t, param, true)
+		case "lt":
+			setUpperBound(schema, t, param, true)
+		case "len":
+			setLowerBound(schema, t, param, false)
+			setUpperBound(schema, t, param, false)
+		case "oneof":
+			for _, value := range strings.Fields(param) {
+				schema.Enum = append(schema.Enum, enumValue(t, value))
+			}
+		case "email":
+			schema.Format = "email"
+		case "url", "uri":
+			schema.Format = "uri"
+		case "uuid", "uuid4":
+			schema.Format = "uuid"
+		}
+	}
+	return required
+}
+
+// setLowerBound applies a min/gt rule as a length, item count or value bound
+func setLowerBound(schema *Schema, t reflect.Type, param string, exclusive bool) {
+	switch t.Kind() {
+	case reflect.String:
+		if n, err := strconv.Atoi(param); err == nil {
+			if exclusive {
+				n++
+			}
+			schema.MinLength = &n
+		}
+	case reflect.Slice, reflect.Array, reflect.Map:
+		if n, err := strconv.Atoi(param); err == nil {
+			if exclusive {
+				n++
+			}
+			schema.MinItems = &n
+		}
+	default:
+		if value, err := strconv.ParseFloat(param, 64); err == nil {
+			schema.Minimum = &value
+			schema.ExclusiveMinimum = exclusive
+		}
+	}
+}
+
+// setUpperBound applies a max/lt rule as a length, item count or value bound
+func setUpperBound(schema *Schema, t reflect.Type, param string, exclusive bool) {
+	switch t.Kind() {
+	case reflect.String:
+		if n, err := strconv.Atoi(param); err == nil {
+			if exclusive {
+				n--
+			}
+			schema.MaxLength = &n
+		}
+	case reflect.Slice, reflect.Array, reflect.Map:
+		if n, err := strconv.Atoi(param); err == nil {
+			if exclusive {
+				n--
+			}
+			schema.MaxItems = &n
+		}
+	default:
+		if value, err := strconv.ParseFloat(param, 64); err == nil {
+			schema.Maximum = &value
+			schema.ExclusiveMaximum = exclusive
+		}
+	}
+}
+
+// enumValue converts a oneof value to the field's JSON type
+func enumValue(t reflect.Type, value string) interface{} {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return n
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if n, err := strconv.ParseUint(value, 10, 64); err == nil {
+			return n
+		}
+	case reflect.Float32, reflect.Float64:
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	}
+	return value
+}
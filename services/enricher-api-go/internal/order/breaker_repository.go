@@ -0,0 +1,103 @@
+package order
+
+import (
+	"errors"
+
+	"enricher-api-go/internal/breaker"
+	"enricher-api-go/internal/unitofwork"
+)
+
+// BreakerRepository guards another Repository with a circuit breaker.
+//
+// Storage failures count against the breaker, while domain outcomes such as
+// ErrOrderNotFound are returned unchanged without tripping it.
+type BreakerRepository struct {
+	repo    Repository
+	breaker *breaker.Breaker
+}
+
+// NewBreakerRepository wraps repo with b, typically the breaker shared by
+// every repository using the same backend
+func NewBreakerRepository(repo Repository, b *breaker.Breaker) *BreakerRepository {
+	return &BreakerRepository{repo: repo, breaker: b}
+}
+
+// GetByID retrieves an order by ID
+func (r *BreakerRepository) GetByID(orderID string) (order *Order, err error) {
+	err = r.call(func() error {
+		order, err = r.repo.GetByID(orderID)
+		return err
+	})
+	return order, err
+}
+
+// Find returns the orders matching filter
+func (r *BreakerRepository) Find(filter OrderFilter) (orders []*Order, err error) {
+	err = r.call(func() error {
+		orders, err = r.repo.Find(filter)
+		return err
+	})
+	return orders, err
+}
+
+// Count returns the number of orders matching filter
+func (r *BreakerRepository) Count(filter OrderFilter) (count int, err error) {
+	err = r.call(func() error {
+		count, err = r.repo.Count(filter)
+		return err
+	})
+	return count, err
+}
+
+// Create adds a new order
+func (r *BreakerRepository) Create(order *Order) error {
+	return r.call(func() error { return r.repo.Create(order) })
+}
+
+// createTx adds a new order within the unit's database transaction when
+// the guarded repository can join it
+func (r *BreakerRepository) createTx(tx *unitofwork.Tx, order *Order) (joined bool, err error) {
+	repo, ok := r.repo.(txCreator)
+	if !ok {
+		return false, nil
+	}
+	err = r.call(func() error {
+		joined, err = repo.createTx(tx, order)
+		return err
+	})
+	return joined, err
+}
+
+// Update replaces an existing order
+func (r *BreakerRepository) Update(order *Order) error {
+	return r.call(func() error { return r.repo.Update(order) })
+}
+
+// Delete removes an order
+func (r *BreakerRepository) Delete(orderID string) error {
+	return r.call(func() error { return r.repo.Delete(orderID) })
+}
+
+// call runs fn through the breaker, passing domain errors through without
+// counting them as failures
+func (r *BreakerRepository) call(fn func() error) error {
+	var domainErr error
+	err := r.breaker.Execute(func() error {
+		err := fn()
+		if isDomainError(err) {
+			domainErr = err
+			return nil
+		}
+		return err
+	})
+	if domainErr != nil {
+		return domainErr
+	}
+	return err
+}
+
+// isDomainError reports whether err is an expected repository outcome rather
+// than a storage failure
+func isDomainError(err error) bool {
+	return errors.Is(err, ErrOrderNotFound) || errors.Is(err, ErrOrderExists)
+}
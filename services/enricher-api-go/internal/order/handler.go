@@ -0,0 +1,177 @@
+package order
+
+import (
+	"errors"
+	"net/http"
+
+	"enricher-api-go/internal/httpcache"
+	"enricher-api-go/internal/pagination"
+	"enricher-api-go/internal/problem"
+	"enricher-api-go/internal/product"
+	"enricher-api-go/internal/servertiming"
+	"enricher-api-go/internal/validation"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Handler handles HTTP requests for orders
+type Handler struct {
+	service Service
+}
+
+// NewHandler creates a new order handler
+func NewHandler(service Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+// ListOrders handles GET /v1/orders
+//
+// Orders are listed newest first and may be narrowed to one customer with
+// customerId and to one enrichment status with status.
+func (h *Handler) ListOrders(c echo.Context) error {
+	page, err := pagination.FromQuery(c.QueryParams())
+	if err != nil {
+		return problem.Write(c, http.StatusBadRequest, err.Error())
+	}
+
+	filter := OrderFilter{
+		CustomerID: c.QueryParam("customerId"),
+		Status:     c.QueryParam("status"),
+		Limit:      page.Limit,
+		Offset:     page.Offset,
+	}
+
+	stop := servertiming.Start(c, "service")
+	orders, err := h.service.FindOrders(c.Request().Context(), filter)
+	var total int
+	if err == nil {
+		total, err = h.service.CountOrders(c.Request().Context(), filter)
+	}
+	stop()
+	if err != nil {
+		return orderError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"orders":     orders,
+		"count":      len(orders),
+		"pagination": pagination.NewMeta(c.Request().URL, page, total),
+	})
+}
+
+// GetOrder handles GET /v1/orders/:id
+func (h *Handler) GetOrder(c echo.Context) error {
+	stop := servertiming.Start(c, "service")
+	order, err := h.service.GetOrder(c.Request().Context(), c.Param("id"))
+	stop()
+	if err != nil {
+		return orderError(c, err)
+	}
+
+	httpcache.SetLastModified(c, order.UpdatedAt)
+	return c.JSON(http.StatusOK, order)
+}
+
+// CreateOrder handles POST /v1/orders
+//
+// The order is enriched before the response, which carries its status:
+// ENRICHED with the enriched order, FAILED with the reason, or PENDING if
+// enrichment was interrupted and should be retried with
+// POST /v1/orders/:id/enrich.
+//
+// When stock is reserved for new orders, an order whose stock cannot be
+// reserved is not stored: 409 for insufficient stock and 422 for an unknown
+// product.
+//
+// Example request:
+//
+//	POST /v1/orders
+//	Content-Type: application/json
+//
+//	{
+//		"customerId": "customer-456",
+//		"items": [{"productId": "product-123", "quantity": 2}]
+//	}
+func (h *Handler) CreateOrder(c echo.Context) error {
+	var req OrderRequest
+	if err := servertiming.Measure(c, "bind", func() error { return c.Bind(&req) }); err != nil {
+		return problem.BindError(c, err)
+	}
+
+	stop := servertiming.Start(c, "service")
+	order, err := h.service.CreateOrder(c.Request().Context(), req)
+	stop()
+	if err != nil {
+		return orderError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, order)
+}
+
+// UpdateOrder handles PUT /v1/orders/:id, replacing the order and enriching it again
+func (h *Handler) UpdateOrder(c echo.Context) error {
+	var req OrderRequest
+	if err := servertiming.Measure(c, "bind", func() error { return c.Bind(&req) }); err != nil {
+		return problem.BindError(c, err)
+	}
+
+	stop := servertiming.Start(c, "service")
+	order, err := h.service.UpdateOrder(c.Request().Context(), c.Param("id"), req)
+	stop()
+	if err != nil {
+		return orderError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, order)
+}
+
+// DeleteOrder handles DELETE /v1/orders/:id
+func (h *Handler) DeleteOrder(c echo.Context) error {
+	stop := servertiming.Start(c, "service")
+	err := h.service.DeleteOrder(c.Request().Context(), c.Param("id"))
+	stop()
+	if err != nil {
+		return orderError(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// EnrichOrder handles POST /v1/orders/:id/enrich
+//
+// The order is enriched again whatever its status. A storage failure while
+// enriching answers 500, or 503 while a circuit breaker is open, and leaves
+// the order unchanged.
+func (h *Handler) EnrichOrder(c echo.Context) error {
+	stop := servertiming.Start(c, "service")
+	order, err := h.service.EnrichOrder(c.Request().Context(), c.Param("id"))
+	stop()
+	if err != nil {
+		return orderError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, order)
+}
+
+// orderError answers a failed order operation: 404 for an unknown order,
+// 400 for an invalid request or filter, 409 for stock that cannot be
+// reserved and 422 for an unknown product to reserve
+func orderError(c echo.Context, err error) error {
+	var validationErr *validation.Error
+	switch {
+	case errors.Is(err, ErrOrderNotFound):
+		return problem.Write(c, http.StatusNotFound, "Order not found")
+	case errors.Is(err, product.ErrInsufficientStock):
+		return problem.Write(c, http.StatusConflict, "Insufficient stock")
+	case errors.Is(err, product.ErrProductNotFound):
+		return problem.Write(c, http.StatusUnprocessableEntity, "Product not found")
+	case errors.As(err, &validationErr):
+		return problem.BindError(c, validationErr)
+	case errors.Is(err, ErrInvalidOrder), errors.Is(err, ErrInvalidFilter):
+		return problem.Write(c, http.StatusBadRequest, err.Error())
+	default:
+		return problem.ServerError(c, err)
+	}
+}
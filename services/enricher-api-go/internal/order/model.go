@@ -0,0 +1,105 @@
+// Package order stores customer orders and tracks their enrichment for the
+// Resilient Order Enricher API.
+//
+// Orders are accepted PENDING and enriched with their customer and product
+// details through the enrichment service. An order that cannot be enriched,
+// for example because it names an unknown product, is FAILED with the
+// reason, and can be corrected or enriched again later.
+package order
+
+import (
+	"time"
+
+	"enricher-api-go/internal/enrichment"
+)
+
+// Enrichment statuses of an order
+const (
+	// StatusPending is an order that has not been enriched yet, including one
+	// whose enrichment was interrupted by a storage failure
+	StatusPending = "PENDING"
+	// StatusEnriched is an order whose customer and products were resolved
+	StatusEnriched = "ENRICHED"
+	// StatusFailed is an order that cannot be enriched as it stands
+	StatusFailed = "FAILED"
+)
+
+// Item is an order line referencing a product by ID or one of its variants by SKU
+type Item struct {
+	// ProductID is the ordered product; may be omitted when SKU is set
+	ProductID string `json:"productId,omitempty"`
+	// SKU selects a variant of the product
+	SKU string `json:"sku,omitempty"`
+	// Quantity is the number of units ordered
+	Quantity int `json:"quantity" validate:"gt=0"`
+}
+
+// Order is a customer order and the outcome of its enrichment.
+//
+// Example usage:
+//
+//	order := &Order{
+//		OrderID:    "order-12345",
+//		CustomerID: "customer-456",
+//		Items:      []Item{{ProductID: "product-123", Quantity: 2}},
+//		Status:     StatusPending,
+//	}
+type Order struct {
+	// OrderID is the unique identifier for the order
+	OrderID string `json:"orderId" db:"order_id"`
+	// CustomerID is the customer placing the order
+	CustomerID string `json:"customerId" db:"customer_id"`
+	// Items are the order lines, in the order they were given
+	Items []Item `json:"items" db:"items"`
+	// ShippingAddressID selects one of the customer's shipping addresses;
+	// empty ships to the customer's default shipping address
+	ShippingAddressID string `json:"shippingAddressId,omitempty" db:"shipping_address_id"`
+	// Status is the enrichment status: PENDING, ENRICHED or FAILED
+	Status string `json:"status" db:"status"`
+	// Enrichment is the enriched order, set while the order is ENRICHED
+	Enrichment *enrichment.EnrichedOrder `json:"enrichment,omitempty" db:"enrichment"`
+	// FailureReason explains why a FAILED order could not be enriched
+	FailureReason string `json:"failureReason,omitempty" db:"failure_reason"`
+	// EnrichedAt is when the order was last enriched successfully
+	EnrichedAt *time.Time `json:"enrichedAt,omitempty" db:"enriched_at"`
+	// CreatedAt is when the order was placed; items are priced as of then
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	// UpdatedAt is when the order was last changed
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+	// CreatedBy is the authenticated caller that placed the order, if any
+	CreatedBy string `json:"createdBy,omitempty" db:"created_by"`
+	// UpdatedBy is the authenticated caller that last changed the order, if any
+	UpdatedBy string `json:"updatedBy,omitempty" db:"updated_by"`
+}
+
+// OrderRequest is the request body for placing or replacing an order
+type OrderRequest struct {
+	// CustomerID is the customer placing the order (required)
+	CustomerID string `json:"customerId" validate:"required"`
+	// Items are the order lines (at least one)
+	Items []Item `json:"items" validate:"required,min=1,dive"`
+	// ShippingAddressID optionally selects one of the customer's shipping addresses
+	ShippingAddressID string `json:"shippingAddressId,omitempty"`
+}
+
+// OrderFilter describes the criteria for listing orders.
+//
+// Zero values mean "no constraint". Limit and Offset page through the
+// matches, which are ordered newest first.
+type OrderFilter struct {
+	// CustomerID matches the orders of one customer
+	CustomerID string
+	// Status matches orders in one enrichment status
+	Status string
+	// Limit caps the number of orders returned; 0 means no limit
+	Limit int
+	// Offset skips the first matches
+	Offset int
+}
+
+// Matches reports whether an order satisfies the filter's criteria.
+// Limit and Offset are not considered.
+func (f OrderFilter) Matches(order *Order) bool {
+	return (f.CustomerID == "" || order.CustomerID == f.CustomerID) &&
+		(f.Status == "" || order.Status == f.Status)
+}
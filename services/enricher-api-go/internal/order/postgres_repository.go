@@ -0,0 +1,241 @@
+package order
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"enricher-api-go/internal/enrichment"
+	"enricher-api-go/internal/postgres"
+	"enricher-api-go/internal/unitofwork"
+)
+
+// orderColumns lists the columns read by scanOrder, in order
+const orderColumns = `order_id, customer_id, items, shipping_address_id, status, enrichment, failure_reason,
+	enriched_at, created_at, updated_at, created_by, updated_by`
+
+// PostgresRepository implements Repository on top of a PostgreSQL database
+type PostgresRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresRepository creates an order repository backed by db.
+//
+// The caller owns db and is responsible for opening and closing it; the
+// orders table must exist (see the migrations package).
+func NewPostgresRepository(db *sql.DB) *PostgresRepository {
+	return &PostgresRepository{db: db}
+}
+
+// GetByID retrieves an order by ID
+func (r *PostgresRepository) GetByID(orderID string) (*Order, error) {
+	order, err := scanOrder(r.db.QueryRow(`SELECT `+orderColumns+` FROM orders WHERE order_id = $1`, orderID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrOrderNotFound
+	}
+	return order, err
+}
+
+// Find returns the orders matching filter, newest first
+func (r *PostgresRepository) Find(filter OrderFilter) ([]*Order, error) {
+	where, args := filterClause(filter)
+	query := `SELECT ` + orderColumns + ` FROM orders` + where + ` ORDER BY created_at DESC, order_id DESC`
+
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(` LIMIT $%d`, len(args))
+	}
+	if filter.Offset > 0 {
+		args = append(args, filter.Offset)
+		query += fmt.Sprintf(` OFFSET $%d`, len(args))
+	}
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query orders: %w", err)
+	}
+	defer rows.Close()
+
+	orders := make([]*Order, 0)
+	for rows.Next() {
+		order, err := scanOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read orders: %w", err)
+	}
+	return orders, nil
+}
+
+// Count returns the number of orders matching filter, ignoring Limit and Offset
+func (r *PostgresRepository) Count(filter OrderFilter) (int, error) {
+	where, args := filterClause(filter)
+
+	var count int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM orders`+where, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count orders: %w", err)
+	}
+	return count, nil
+}
+
+// Create adds a new order
+func (r *PostgresRepository) Create(order *Order) error {
+	return insertOrder(r.db, order)
+}
+
+// createTx adds a new order within the unit's database transaction
+func (r *PostgresRepository) createTx(tx *unitofwork.Tx, order *Order) (bool, error) {
+	if tx.SQL() == nil {
+		return false, nil
+	}
+	return true, insertOrder(tx.SQL(), order)
+}
+
+// execer is satisfied by *sql.DB and *sql.Tx
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// insertOrder adds a new order on e
+func insertOrder(e execer, order *Order) error {
+	items, enriched, err := marshalDocuments(order)
+	if err != nil {
+		return err
+	}
+
+	_, err = e.Exec(
+		`INSERT INTO orders (order_id, customer_id, items, shipping_address_id, status, enrichment, failure_reason,
+			enriched_at, created_at, updated_at, created_by, updated_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+		order.OrderID, order.CustomerID, items, order.ShippingAddressID, order.Status, enriched, order.FailureReason,
+		order.EnrichedAt, order.CreatedAt, order.UpdatedAt, order.CreatedBy, order.UpdatedBy,
+	)
+	if postgres.IsUniqueViolation(err) {
+		return ErrOrderExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to insert order: %w", err)
+	}
+	return nil
+}
+
+// Update replaces an existing order
+func (r *PostgresRepository) Update(order *Order) error {
+	items, enriched, err := marshalDocuments(order)
+	if err != nil {
+		return err
+	}
+
+	result, err := r.db.Exec(
+		`UPDATE orders SET customer_id = $2, items = $3, shipping_address_id = $4, status = $5, enrichment = $6,
+			failure_reason = $7, enriched_at = $8, updated_at = $9, updated_by = $10
+		WHERE order_id = $1`,
+		order.OrderID, order.CustomerID, items, order.ShippingAddressID, order.Status, enriched,
+		order.FailureReason, order.EnrichedAt, order.UpdatedAt, order.UpdatedBy,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update order: %w", err)
+	}
+	return requireRowAffected(result)
+}
+
+// Delete removes an order
+func (r *PostgresRepository) Delete(orderID string) error {
+	result, err := r.db.Exec(`DELETE FROM orders WHERE order_id = $1`, orderID)
+	if err != nil {
+		return fmt.Errorf("failed to delete order: %w", err)
+	}
+	return requireRowAffected(result)
+}
+
+// filterClause builds the WHERE clause and its arguments for filter's criteria
+func filterClause(filter OrderFilter) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	if filter.CustomerID != "" {
+		args = append(args, filter.CustomerID)
+		conditions = append(conditions, fmt.Sprintf(`customer_id = $%d`, len(args)))
+	}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conditions = append(conditions, fmt.Sprintf(`status = $%d`, len(args)))
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+	return ` WHERE ` + strings.Join(conditions, ` AND `), args
+}
+
+// rowScanner is satisfied by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanOrder reads one order row in orderColumns order
+func scanOrder(row rowScanner) (*Order, error) {
+	var order Order
+	var items, enriched []byte
+	var enrichedAt sql.NullTime
+
+	err := row.Scan(
+		&order.OrderID, &order.CustomerID, &items, &order.ShippingAddressID, &order.Status, &enriched,
+		&order.FailureReason, &enrichedAt, &order.CreatedAt, &order.UpdatedAt, &order.CreatedBy, &order.UpdatedBy,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan order: %w", err)
+	}
+
+	if err := json.Unmarshal(items, &order.Items); err != nil {
+		return nil, fmt.Errorf("failed to decode order items: %w", err)
+	}
+	if enriched != nil {
+		order.Enrichment = &enrichment.EnrichedOrder{}
+		if err := json.Unmarshal(enriched, order.Enrichment); err != nil {
+			return nil, fmt.Errorf("failed to decode enriched order: %w", err)
+		}
+	}
+	if enrichedAt.Valid {
+		order.EnrichedAt = &enrichedAt.Time
+	}
+	return &order, nil
+}
+
+// marshalDocuments encodes an order's items and enriched order as JSON,
+// storing a missing enriched order as NULL
+func marshalDocuments(order *Order) ([]byte, []byte, error) {
+	items, err := json.Marshal(order.Items)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode order items: %w", err)
+	}
+	if order.Enrichment == nil {
+		return items, nil, nil
+	}
+	enriched, err := json.Marshal(order.Enrichment)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode enriched order: %w", err)
+	}
+	return items, enriched, nil
+}
+
+// requireRowAffected maps an UPDATE/DELETE that touched no rows to ErrOrderNotFound
+func requireRowAffected(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to read affected rows: %w", err)
+	}
+	if affected == 0 {
+		return ErrOrderNotFound
+	}
+	return nil
+}
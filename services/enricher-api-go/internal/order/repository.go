@@ -0,0 +1,172 @@
+package order
+
+import (
+	"errors"
+	"slices"
+	"sort"
+	"sync"
+
+	"enricher-api-go/internal/unitofwork"
+)
+
+var (
+	// ErrOrderNotFound is returned when no order has the requested ID
+	ErrOrderNotFound = errors.New("order not found")
+	// ErrOrderExists is returned when an order's ID is taken
+	ErrOrderExists = errors.New("order already exists")
+)
+
+// Repository defines the interface for order data access.
+//
+// Find and Count apply an OrderFilter; Find returns the matches newest first,
+// breaking ties by OrderID so that consecutive pages are stable.
+type Repository interface {
+	GetByID(orderID string) (*Order, error)
+	Find(filter OrderFilter) ([]*Order, error)
+	Count(filter OrderFilter) (int, error)
+	Create(order *Order) error
+	Update(order *Order) error
+	Delete(orderID string) error
+}
+
+// txCreator is implemented by repositories that can create an order within
+// a unit of work's database transaction; joined reports whether they did
+type txCreator interface {
+	createTx(tx *unitofwork.Tx, order *Order) (joined bool, err error)
+}
+
+// createIn adds order as part of tx: within its database transaction when
+// repo can join it, and otherwise right away, deleting it again if the unit
+// fails
+func createIn(tx *unitofwork.Tx, repo Repository, order *Order) error {
+	if creator, ok := repo.(txCreator); ok {
+		if joined, err := creator.createTx(tx, order); joined {
+			return err
+		}
+	}
+
+	if err := repo.Create(order); err != nil {
+		return err
+	}
+	tx.OnRollback(func() error { return repo.Delete(order.OrderID) })
+	return nil
+}
+
+// InMemoryRepository implements Repository interface using in-memory storage
+type InMemoryRepository struct {
+	orders map[string]*Order
+	mutex  sync.RWMutex
+}
+
+// NewInMemoryRepository creates a new, empty in-memory order repository
+func NewInMemoryRepository() *InMemoryRepository {
+	return &InMemoryRepository{
+		orders: make(map[string]*Order),
+		mutex:  sync.RWMutex{},
+	}
+}
+
+// Reset removes every order
+func (r *InMemoryRepository) Reset() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.orders = make(map[string]*Order)
+}
+
+// GetByID retrieves an order by ID
+func (r *InMemoryRepository) GetByID(orderID string) (*Order, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	order, exists := r.orders[orderID]
+	if !exists {
+		return nil, ErrOrderNotFound
+	}
+	return copyOrder(order), nil
+}
+
+// Find returns the orders matching filter, newest first
+func (r *InMemoryRepository) Find(filter OrderFilter) ([]*Order, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	orders := make([]*Order, 0)
+	for _, order := range r.orders {
+		if filter.Matches(order) {
+			orders = append(orders, copyOrder(order))
+		}
+	}
+	sort.Slice(orders, func(i, j int) bool {
+		if !orders[i].CreatedAt.Equal(orders[j].CreatedAt) {
+			return orders[i].CreatedAt.After(orders[j].CreatedAt)
+		}
+		return orders[i].OrderID > orders[j].OrderID
+	})
+
+	if filter.Offset >= len(orders) {
+		return []*Order{}, nil
+	}
+	orders = orders[filter.Offset:]
+	if filter.Limit > 0 && filter.Limit < len(orders) {
+		orders = orders[:filter.Limit]
+	}
+	return orders, nil
+}
+
+// Count returns the number of orders matching filter, ignoring Limit and Offset
+func (r *InMemoryRepository) Count(filter OrderFilter) (int, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	count := 0
+	for _, order := range r.orders {
+		if filter.Matches(order) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// Create adds a new order
+func (r *InMemoryRepository) Create(order *Order) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.orders[order.OrderID]; exists {
+		return ErrOrderExists
+	}
+	r.orders[order.OrderID] = copyOrder(order)
+	return nil
+}
+
+// Update replaces an existing order
+func (r *InMemoryRepository) Update(order *Order) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.orders[order.OrderID]; !exists {
+		return ErrOrderNotFound
+	}
+	r.orders[order.OrderID] = copyOrder(order)
+	return nil
+}
+
+// Delete removes an order
+func (r *InMemoryRepository) Delete(orderID string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.orders[orderID]; !exists {
+		return ErrOrderNotFound
+	}
+	delete(r.orders, orderID)
+	return nil
+}
+
+// copyOrder copies an order so callers cannot change the stored items
+func copyOrder(order *Order) *Order {
+	orderCopy := *order
+	orderCopy.Items = slices.Clone(order.Items)
+	return &orderCopy
+}
@@ -0,0 +1,199 @@
+package order
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"enricher-api-go/internal/enrichment"
+	"enricher-api-go/internal/migrations"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// testRepositoryConformance runs the behavior every Repository implementation
+// must share against a repository created by newRepo.
+func testRepositoryConformance(t *testing.T, newRepo func(t *testing.T) Repository) {
+	placed := time.Date(2024, 4, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("Create and get", func(t *testing.T) {
+		repo := newRepo(t)
+		order := &Order{
+			OrderID: "conformance-1", CustomerID: "customer-456", Status: StatusPending,
+			Items:             []Item{{ProductID: "product-123", Quantity: 2}, {SKU: "MOUSE-BLACK", Quantity: 1}},
+			ShippingAddressID: "address-1", CreatedAt: placed, UpdatedAt: placed, CreatedBy: "ci", UpdatedBy: "ci",
+		}
+
+		if err := repo.Create(order); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		order.Items[0].Quantity = 99
+
+		retrieved, err := repo.GetByID("conformance-1")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if retrieved.CustomerID != "customer-456" || len(retrieved.Items) != 2 || retrieved.Items[0].Quantity != 2 ||
+			retrieved.Items[1].SKU != "MOUSE-BLACK" || retrieved.ShippingAddressID != "address-1" {
+			t.Errorf("Expected the stored order, got %+v", retrieved)
+		}
+		if retrieved.Status != StatusPending || retrieved.Enrichment != nil || retrieved.EnrichedAt != nil {
+			t.Errorf("Expected a pending order without enrichment, got %+v", retrieved)
+		}
+		if !retrieved.CreatedAt.Equal(placed) || retrieved.CreatedBy != "ci" {
+			t.Errorf("Expected the audit fields to be stored, got %+v", retrieved)
+		}
+
+		if err := repo.Create(&Order{OrderID: "conformance-1", CustomerID: "customer-123", Status: StatusPending}); !errors.Is(err, ErrOrderExists) {
+			t.Errorf("Expected ErrOrderExists, got %v", err)
+		}
+	})
+
+	t.Run("Update with enrichment", func(t *testing.T) {
+		repo := newRepo(t)
+		order := &Order{OrderID: "conformance-2", CustomerID: "customer-456", Status: StatusPending,
+			Items: []Item{{ProductID: "product-123", Quantity: 1}}, CreatedAt: placed, UpdatedAt: placed}
+		if err := repo.Create(order); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		enrichedAt := placed.Add(time.Minute)
+		order.Status, order.EnrichedAt = StatusEnriched, &enrichedAt
+		order.Enrichment = &enrichment.EnrichedOrder{
+			Customer: enrichment.EnrichedCustomer{CustomerID: "customer-456", Name: "Jane Doe"},
+			Items:    []enrichment.EnrichedItem{{ProductID: "product-123", UnitPrice: 25.99, Quantity: 1, LineTotal: 25.99}},
+			Total:    25.99,
+		}
+		if err := repo.Update(order); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		retrieved, err := repo.GetByID("conformance-2")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if retrieved.Status != StatusEnriched || retrieved.Enrichment == nil || retrieved.Enrichment.Total != 25.99 ||
+			retrieved.EnrichedAt == nil || !retrieved.EnrichedAt.Equal(enrichedAt) {
+			t.Errorf("Expected the enriched order to be stored, got %+v", retrieved)
+		}
+
+		order.Status, order.Enrichment, order.EnrichedAt, order.FailureReason = StatusFailed, nil, nil, "product not found"
+		if err := repo.Update(order); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		failed, err := repo.GetByID("conformance-2")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if failed.Status != StatusFailed || failed.Enrichment != nil || failed.FailureReason != "product not found" {
+			t.Errorf("Expected the failure to replace the enrichment, got %+v", failed)
+		}
+	})
+
+	t.Run("Missing", func(t *testing.T) {
+		repo := newRepo(t)
+
+		if _, err := repo.GetByID("conformance-missing"); !errors.Is(err, ErrOrderNotFound) {
+			t.Errorf("Expected ErrOrderNotFound, got %v", err)
+		}
+		if err := repo.Update(&Order{OrderID: "conformance-missing", Status: StatusPending}); !errors.Is(err, ErrOrderNotFound) {
+			t.Errorf("Expected ErrOrderNotFound on update, got %v", err)
+		}
+		if err := repo.Delete("conformance-missing"); !errors.Is(err, ErrOrderNotFound) {
+			t.Errorf("Expected ErrOrderNotFound on delete, got %v", err)
+		}
+	})
+
+	t.Run("Find, Count and Delete", func(t *testing.T) {
+		repo := newRepo(t)
+		orders := []*Order{
+			{OrderID: "conformance-a", CustomerID: "customer-456", Status: StatusEnriched, CreatedAt: placed},
+			{OrderID: "conformance-b", CustomerID: "customer-123", Status: StatusFailed, CreatedAt: placed.Add(time.Hour)},
+			{OrderID: "conformance-c", CustomerID: "customer-456", Status: StatusPending, CreatedAt: placed.Add(2 * time.Hour)},
+			{OrderID: "conformance-d", CustomerID: "customer-456", Status: StatusEnriched, CreatedAt: placed.Add(2 * time.Hour)},
+		}
+		for _, order := range orders {
+			order.Items, order.UpdatedAt = []Item{{ProductID: "product-123", Quantity: 1}}, order.CreatedAt
+			if err := repo.Create(order); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+		}
+
+		all, err := repo.Find(OrderFilter{})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if got := orderIDs(all); len(got) != 4 || got[0] != "conformance-d" || got[1] != "conformance-c" || got[3] != "conformance-a" {
+			t.Errorf("Expected newest first with ties broken by ID, got %v", got)
+		}
+
+		page, err := repo.Find(OrderFilter{CustomerID: "customer-456", Limit: 1, Offset: 1})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if got := orderIDs(page); len(got) != 1 || got[0] != "conformance-c" {
+			t.Errorf("Expected the second order of customer-456, got %v", got)
+		}
+
+		count, err := repo.Count(OrderFilter{CustomerID: "customer-456", Status: StatusEnriched, Limit: 1})
+		if err != nil || count != 2 {
+			t.Errorf("Expected 2 enriched orders for customer-456 regardless of limit, got %d, %v", count, err)
+		}
+
+		if err := repo.Delete("conformance-a"); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if _, err := repo.GetByID("conformance-a"); !errors.Is(err, ErrOrderNotFound) {
+			t.Errorf("Expected ErrOrderNotFound after delete, got %v", err)
+		}
+	})
+}
+
+// orderIDs returns the IDs of orders, in order
+func orderIDs(orders []*Order) []string {
+	ids := make([]string, len(orders))
+	for i, order := range orders {
+		ids[i] = order.OrderID
+	}
+	return ids
+}
+
+func TestInMemoryRepository_Conformance(t *testing.T) {
+	testRepositoryConformance(t, func(t *testing.T) Repository {
+		return NewInMemoryRepository()
+	})
+}
+
+// TestPostgresRepository_Conformance runs against the database in
+// POSTGRES_TEST_DSN and is skipped when it is not set.
+func TestPostgresRepository_Conformance(t *testing.T) {
+	dsn := os.Getenv("POSTGRES_TEST_DSN")
+	if dsn == "" {
+		t.Skip("POSTGRES_TEST_DSN not set")
+	}
+
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := migrations.New(db)
+	if err != nil {
+		t.Fatalf("Failed to load migrations: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+
+	testRepositoryConformance(t, func(t *testing.T) Repository {
+		repo := NewPostgresRepository(db)
+		if _, err := db.Exec(`TRUNCATE orders`); err != nil {
+			t.Fatalf("Failed to reset orders: %v", err)
+		}
+		return repo
+	})
+}
@@ -0,0 +1,73 @@
+package order
+
+import (
+	"enricher-api-go/internal/retry"
+	"enricher-api-go/internal/unitofwork"
+)
+
+// RetryRepository retries the calls of another Repository that fail
+// transiently: reads on any transient failure, writes only on failures that
+// show nothing was written.
+type RetryRepository struct {
+	repo    Repository
+	retrier *retry.Retrier
+}
+
+// NewRetryRepository wraps repo with retrier, typically the retrier shared
+// by every repository using the same backend
+func NewRetryRepository(repo Repository, retrier *retry.Retrier) *RetryRepository {
+	return &RetryRepository{repo: repo, retrier: retrier}
+}
+
+// GetByID retrieves an order by ID
+func (r *RetryRepository) GetByID(orderID string) (order *Order, err error) {
+	err = r.retrier.Read(func() error {
+		order, err = r.repo.GetByID(orderID)
+		return err
+	})
+	return order, err
+}
+
+// Find returns the orders matching filter
+func (r *RetryRepository) Find(filter OrderFilter) (orders []*Order, err error) {
+	err = r.retrier.Read(func() error {
+		orders, err = r.repo.Find(filter)
+		return err
+	})
+	return orders, err
+}
+
+// Count returns the number of orders matching filter
+func (r *RetryRepository) Count(filter OrderFilter) (count int, err error) {
+	err = r.retrier.Read(func() error {
+		count, err = r.repo.Count(filter)
+		return err
+	})
+	return count, err
+}
+
+// Create adds a new order
+func (r *RetryRepository) Create(order *Order) error {
+	return r.retrier.Write(func() error { return r.repo.Create(order) })
+}
+
+// createTx adds a new order within the unit's database transaction when
+// the wrapped repository can join it. It is not retried: a failure aborts
+// the transaction, which the unit of work then rolls back.
+func (r *RetryRepository) createTx(tx *unitofwork.Tx, order *Order) (bool, error) {
+	repo, ok := r.repo.(txCreator)
+	if !ok {
+		return false, nil
+	}
+	return repo.createTx(tx, order)
+}
+
+// Update replaces an existing order
+func (r *RetryRepository) Update(order *Order) error {
+	return r.retrier.Write(func() error { return r.repo.Update(order) })
+}
+
+// Delete removes an order
+func (r *RetryRepository) Delete(orderID string) error {
+	return r.retrier.Write(func() error { return r.repo.Delete(orderID) })
+}
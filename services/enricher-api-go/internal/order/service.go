@@ -0,0 +1,439 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"enricher-api-go/internal/auth"
+	"enricher-api-go/internal/clock"
+	"enricher-api-go/internal/dlq"
+	"enricher-api-go/internal/enrichment"
+	"enricher-api-go/internal/idgen"
+	"enricher-api-go/internal/logging"
+	"enricher-api-go/internal/product"
+	"enricher-api-go/internal/unitofwork"
+	"enricher-api-go/internal/validation"
+)
+
+var (
+	// ErrInvalidOrder is returned when an order request fails validation
+	ErrInvalidOrder = errors.New("invalid order")
+	// ErrInvalidFilter is returned when list filters or pagination values are out of range
+	ErrInvalidFilter = errors.New("invalid filter")
+)
+
+// statuses lists the enrichment statuses orders can be filtered by
+var statuses = []string{StatusPending, StatusEnriched, StatusFailed}
+
+// Service defines the business logic interface for orders
+type Service interface {
+	GetOrder(ctx context.Context, orderID string) (*Order, error)
+	FindOrders(ctx context.Context, filter OrderFilter) ([]*Order, error)
+	CountOrders(ctx context.Context, filter OrderFilter) (int, error)
+	CreateOrder(ctx context.Context, req OrderRequest) (*Order, error)
+	UpdateOrder(ctx context.Context, orderID string, req OrderRequest) (*Order, error)
+	DeleteOrder(ctx context.Context, orderID string) error
+	EnrichOrder(ctx context.Context, orderID string) (*Order, error)
+}
+
+// StockReserver takes units out of a product's stock; the product service
+// implements it
+type StockReserver interface {
+	ReserveStock(ctx context.Context, productID string, req product.StockRequest) (*product.Product, error)
+}
+
+// OrderService implements the Service interface
+type OrderService struct {
+	repo        Repository
+	enricher    enrichment.Service
+	idGenerator idgen.Generator
+	clock       clock.Clock
+	deadLetters dlq.Recorder
+	// unit and stock reserve the stock of new orders; nil reserves none
+	unit  unitofwork.UnitOfWork
+	stock StockReserver
+}
+
+// Option configures optional OrderService behavior
+type Option func(*OrderService)
+
+// WithIDGenerator sets the generator used for new order IDs (UUIDs by default)
+func WithIDGenerator(gen idgen.Generator) Option {
+	return func(s *OrderService) {
+		s.idGenerator = gen
+	}
+}
+
+// WithClock sets the clock used for CreatedAt, UpdatedAt and EnrichedAt (the UTC wall clock by default)
+func WithClock(c clock.Clock) Option {
+	return func(s *OrderService) {
+		s.clock = c
+	}
+}
+
+// WithDeadLetters dead-letters orders when they become FAILED and resolves
+// their entries once they are enriched
+func WithDeadLetters(recorder dlq.Recorder) Option {
+	return func(s *OrderService) {
+		s.deadLetters = recorder
+	}
+}
+
+// WithStockReservation reserves the stock of every product line of a new
+// order in the unit of work that stores it, so the order is only stored if
+// all of its stock is reserved. Lines ordering a variant by SKU are not
+// reserved: variants have stock of their own.
+func WithStockReservation(unit unitofwork.UnitOfWork, stock StockReserver) Option {
+	return func(s *OrderService) {
+		s.unit, s.stock = unit, stock
+	}
+}
+
+// NewService creates a new order service that enriches orders with enricher
+func NewService(repo Repository, enricher enrichment.Service, opts ...Option) *OrderService {
+	s := &OrderService{
+		repo:        repo,
+		enricher:    enricher,
+		idGenerator: idgen.UUIDGenerator{},
+		clock:       clock.System{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// GetOrder retrieves an order by ID
+func (s *OrderService) GetOrder(ctx context.Context, orderID string) (*Order, error) {
+	logger := logging.FromContext(ctx)
+	logger.Debug("Getting order", "order_id", orderID)
+
+	if orderID == "" {
+		return nil, fmt.Errorf("order ID cannot be empty")
+	}
+
+	order, err := s.repo.GetByID(orderID)
+	if err != nil {
+		if !errors.Is(err, ErrOrderNotFound) {
+			logger.Error("Failed to get order", "order_id", orderID, "error", err)
+		}
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// FindOrders returns the orders matching filter, newest first
+func (s *OrderService) FindOrders(ctx context.Context, filter OrderFilter) ([]*Order, error) {
+	if err := validateFilter(filter); err != nil {
+		return nil, err
+	}
+
+	orders, err := s.repo.Find(filter)
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to find orders", "error", err)
+		return nil, fmt.Errorf("failed to find orders: %w", err)
+	}
+	return orders, nil
+}
+
+// CountOrders returns the number of orders matching filter
+func (s *OrderService) CountOrders(ctx context.Context, filter OrderFilter) (int, error) {
+	if err := validateFilter(filter); err != nil {
+		return 0, err
+	}
+
+	count, err := s.repo.Count(filter)
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to count orders", "error", err)
+		return 0, fmt.Errorf("failed to count orders: %w", err)
+	}
+	return count, nil
+}
+
+// CreateOrder stores a new order and enriches it right away.
+//
+// The order is stored PENDING first, so it is kept even if enrichment is
+// interrupted by a storage failure; it then stays PENDING until EnrichOrder
+// is called again. An order that cannot be enriched as it stands is stored
+// FAILED with the reason.
+//
+// With stock reservation the order is stored together with its
+// reservations, failing with product.ErrInsufficientStock or
+// product.ErrProductNotFound, storing nothing, when one of them fails.
+func (s *OrderService) CreateOrder(ctx context.Context, req OrderRequest) (*Order, error) {
+	logger := logging.FromContext(ctx)
+	logger.Info("Creating order", "customer_id", req.CustomerID, "items", len(req.Items))
+
+	if err := validateOrderRequest(&req); err != nil {
+		return nil, err
+	}
+
+	orderID, err := s.idGenerator.NewID("order")
+	if err != nil {
+		logger.Error("Failed to generate order ID", "error", err)
+		return nil, fmt.Errorf("failed to create order: %w", err)
+	}
+
+	now, caller := s.clock.Now(), auth.Caller(ctx)
+	order := &Order{
+		OrderID:           orderID,
+		CustomerID:        req.CustomerID,
+		Items:             req.Items,
+		ShippingAddressID: req.ShippingAddressID,
+		Status:            StatusPending,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+		CreatedBy:         caller,
+		UpdatedBy:         caller,
+	}
+
+	if err := s.create(ctx, order); err != nil {
+		if isStockRefusal(err) {
+			logger.Warn("Order refused", "error", err)
+		} else {
+			logger.Error("Failed to create order", "error", err)
+		}
+		return nil, fmt.Errorf("failed to create order: %w", err)
+	}
+	logger.Info("Created order", "order_id", orderID)
+
+	return s.enrichPending(ctx, order), nil
+}
+
+// create stores a new order along with the reservation of its stock, if
+// reserved
+func (s *OrderService) create(ctx context.Context, order *Order) error {
+	if s.unit == nil {
+		return s.repo.Create(order)
+	}
+
+	return s.unit.Do(ctx, func(ctx context.Context) error {
+		if err := createIn(unitofwork.FromContext(ctx), s.repo, order); err != nil {
+			return err
+		}
+		for _, item := range order.Items {
+			if item.SKU != "" {
+				continue
+			}
+			_, err := s.stock.ReserveStock(ctx, item.ProductID, product.StockRequest{
+				Quantity: item.Quantity,
+				Reason:   "order " + order.OrderID,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// UpdateOrder replaces an order's customer, items and shipping address and
+// enriches it again, like CreateOrder. Items keep being priced as of when
+// the order was placed.
+func (s *OrderService) UpdateOrder(ctx context.Context, orderID string, req OrderRequest) (*Order, error) {
+	logger := logging.FromContext(ctx)
+	logger.Info("Updating order", "order_id", orderID)
+
+	if err := validateOrderRequest(&req); err != nil {
+		return nil, err
+	}
+
+	order, err := s.GetOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	order.CustomerID = req.CustomerID
+	order.Items = req.Items
+	order.ShippingAddressID = req.ShippingAddressID
+	order.Status, order.Enrichment, order.FailureReason = StatusPending, nil, ""
+	order.UpdatedAt, order.UpdatedBy = s.clock.Now(), auth.Caller(ctx)
+
+	if err := s.repo.Update(order); err != nil {
+		if !errors.Is(err, ErrOrderNotFound) {
+			logger.Error("Failed to update order", "order_id", orderID, "error", err)
+		}
+		return nil, fmt.Errorf("failed to update order: %w", err)
+	}
+	logger.Info("Updated order", "order_id", orderID)
+
+	return s.enrichPending(ctx, order), nil
+}
+
+// DeleteOrder removes an order
+func (s *OrderService) DeleteOrder(ctx context.Context, orderID string) error {
+	logger := logging.FromContext(ctx)
+	logger.Info("Deleting order", "order_id", orderID)
+
+	if err := s.repo.Delete(orderID); err != nil {
+		if !errors.Is(err, ErrOrderNotFound) {
+			logger.Error("Failed to delete order", "order_id", orderID, "error", err)
+		}
+		return fmt.Errorf("failed to delete order: %w", err)
+	}
+
+	logger.Info("Deleted order", "order_id", orderID)
+	return nil
+}
+
+// EnrichOrder enriches a stored order again, whatever its status, so PENDING
+// orders can be retried, FAILED ones rechecked once their customer or
+// products are fixed, and ENRICHED ones refreshed.
+//
+// Unlike CreateOrder, a storage failure during enrichment is returned and
+// the order is left unchanged.
+func (s *OrderService) EnrichOrder(ctx context.Context, orderID string) (*Order, error) {
+	order, err := s.GetOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.enrich(ctx, order); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// enrichPending enriches a freshly stored order, leaving it PENDING if the
+// enrichment or the save is interrupted by a storage failure
+func (s *OrderService) enrichPending(ctx context.Context, order *Order) *Order {
+	pending := *order
+	if err := s.enrich(ctx, order); err != nil {
+		logging.FromContext(ctx).Warn("Order left pending", "order_id", order.OrderID, "error", err)
+		return &pending
+	}
+	return order
+}
+
+// enrich runs the order through the enricher and saves the outcome: ENRICHED
+// with the enriched order, or FAILED with the reason when the enricher
+// rejects the order. Other enricher errors are returned without saving.
+func (s *OrderService) enrich(ctx context.Context, order *Order) error {
+	logger := logging.FromContext(ctx)
+
+	enriched, err := s.enricher.EnrichOrder(ctx, enrichmentRequest(order))
+	switch {
+	case err == nil:
+		now := s.clock.Now()
+		order.Status, order.Enrichment, order.FailureReason = StatusEnriched, enriched, ""
+		order.EnrichedAt = &now
+	case isRejection(err):
+		order.Status, order.Enrichment, order.FailureReason = StatusFailed, nil, err.Error()
+	default:
+		return fmt.Errorf("failed to enrich order: %w", err)
+	}
+	order.UpdatedAt = s.clock.Now()
+
+	if err := s.repo.Update(order); err != nil {
+		if !errors.Is(err, ErrOrderNotFound) {
+			logger.Error("Failed to save enriched order", "order_id", order.OrderID, "error", err)
+		}
+		return fmt.Errorf("failed to save enriched order: %w", err)
+	}
+
+	logger.Info("Enriched order", "order_id", order.OrderID, "status", order.Status)
+	s.reportOutcome(ctx, order)
+	return nil
+}
+
+// reportOutcome dead-letters a FAILED order and resolves the entry of an
+// ENRICHED one. The order is saved either way, so dead-letter failures are
+// only logged.
+func (s *OrderService) reportOutcome(ctx context.Context, order *Order) {
+	if s.deadLetters == nil {
+		return
+	}
+	if order.Status == StatusFailed {
+		_, _ = s.deadLetters.RecordFailure(ctx, dlq.Failure{
+			Source:   dlq.SourceOrder,
+			SourceID: order.OrderID,
+			Order:    enrichmentRequest(order),
+			Reason:   order.FailureReason,
+		})
+		return
+	}
+	_ = s.deadLetters.Resolve(ctx, dlq.SourceOrder, order.OrderID)
+}
+
+// ReplayDeadLetter is the dlq.ReplayFunc of dead-lettered orders: it enriches
+// the stored order again and reports why it still fails, if it does
+func (s *OrderService) ReplayDeadLetter(ctx context.Context, entry *dlq.Entry) error {
+	order, err := s.EnrichOrder(ctx, entry.SourceID)
+	if err != nil {
+		return err
+	}
+	if order.Status == StatusFailed {
+		return errors.New(order.FailureReason)
+	}
+	return nil
+}
+
+// enrichmentRequest builds the enricher's request for order, priced as of
+// when the order was placed
+func enrichmentRequest(order *Order) enrichment.OrderRequest {
+	items := make([]enrichment.OrderItem, len(order.Items))
+	for i, item := range order.Items {
+		items[i] = enrichment.OrderItem{ProductID: item.ProductID, SKU: item.SKU, Quantity: item.Quantity}
+	}
+
+	orderedAt := order.CreatedAt
+	return enrichment.OrderRequest{
+		CustomerID:        order.CustomerID,
+		Items:             items,
+		OrderedAt:         &orderedAt,
+		ShippingAddressID: order.ShippingAddressID,
+	}
+}
+
+// isRejection reports whether the enricher rejected the order itself, as
+// opposed to failing to reach its customers or products
+func isRejection(err error) bool {
+	return errors.Is(err, enrichment.ErrInvalidOrder) ||
+		errors.Is(err, enrichment.ErrCustomerNotFound) ||
+		errors.Is(err, enrichment.ErrProductNotFound)
+}
+
+// isStockRefusal reports whether err refuses the stock reservation of an
+// order, as opposed to a storage failure
+func isStockRefusal(err error) bool {
+	return errors.Is(err, product.ErrInsufficientStock) || errors.Is(err, product.ErrProductNotFound)
+}
+
+// validateOrderRequest trims the request and checks its validate tags and
+// that every item names a product or a SKU
+func validateOrderRequest(req *OrderRequest) error {
+	req.CustomerID = strings.TrimSpace(req.CustomerID)
+	req.ShippingAddressID = strings.TrimSpace(req.ShippingAddressID)
+	req.Items = slices.Clone(req.Items)
+	for i := range req.Items {
+		req.Items[i].ProductID = strings.TrimSpace(req.Items[i].ProductID)
+		req.Items[i].SKU = strings.TrimSpace(req.Items[i].SKU)
+	}
+
+	if err := validation.Struct(req); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidOrder, err)
+	}
+	for i, item := range req.Items {
+		if item.ProductID == "" && item.SKU == "" {
+			return fmt.Errorf("%w: items[%d].productId or sku is required", ErrInvalidOrder, i)
+		}
+	}
+	return nil
+}
+
+// validateFilter checks the status and pagination values of a list filter
+func validateFilter(filter OrderFilter) error {
+	if filter.Status != "" && !slices.Contains(statuses, filter.Status) {
+		return fmt.Errorf("%w: status must be one of %s", ErrInvalidFilter, strings.Join(statuses, ", "))
+	}
+	if filter.Limit < 0 {
+		return fmt.Errorf("%w: limit must not be negative", ErrInvalidFilter)
+	}
+	if filter.Offset < 0 {
+		return fmt.Errorf("%w: offset must not be negative", ErrInvalidFilter)
+	}
+	return nil
+}
@@ -0,0 +1,288 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"enricher-api-go/internal/breaker"
+	"enricher-api-go/internal/clock"
+	"enricher-api-go/internal/customer"
+	"enricher-api-go/internal/dlq"
+	"enricher-api-go/internal/enrichment"
+	"enricher-api-go/internal/product"
+	"enricher-api-go/internal/unitofwork"
+)
+
+// enricherFunc adapts a function to enrichment.Service
+type enricherFunc func(ctx context.Context, req enrichment.OrderRequest) (*enrichment.EnrichedOrder, error)
+
+func (f enricherFunc) EnrichOrder(ctx context.Context, req enrichment.OrderRequest) (*enrichment.EnrichedOrder, error) {
+	return f(ctx, req)
+}
+
+// newTestService builds an order service enriching against the seeded
+// customers and products
+func newTestService(opts ...Option) *OrderService {
+	enricher := enrichment.NewService(
+		customer.NewService(customer.NewInMemoryRepository()),
+		product.NewService(product.NewInMemoryRepository()),
+	)
+	return NewService(NewInMemoryRepository(), enricher, opts...)
+}
+
+func TestOrderService_CreateOrder(t *testing.T) {
+	tests := []struct {
+		name       string
+		req        OrderRequest
+		wantStatus string
+		wantErr    error
+	}{
+		{
+			name:       "enriched",
+			req:        OrderRequest{CustomerID: "customer-456", Items: []Item{{ProductID: "product-123", Quantity: 2}}},
+			wantStatus: StatusEnriched,
+		},
+		{
+			name:       "unknown product",
+			req:        OrderRequest{CustomerID: "customer-456", Items: []Item{{ProductID: "product-missing", Quantity: 1}}},
+			wantStatus: StatusFailed,
+		},
+		{
+			name:       "unknown customer",
+			req:        OrderRequest{CustomerID: "customer-missing", Items: []Item{{ProductID: "product-123", Quantity: 1}}},
+			wantStatus: StatusFailed,
+		},
+		{name: "no items", req: OrderRequest{CustomerID: "customer-456"}, wantErr: ErrInvalidOrder},
+		{name: "zero quantity", req: OrderRequest{CustomerID: "customer-456", Items: []Item{{ProductID: "product-123"}}}, wantErr: ErrInvalidOrder},
+		{name: "no product or SKU", req: OrderRequest{CustomerID: "customer-456", Items: []Item{{Quantity: 1}}}, wantErr: ErrInvalidOrder},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			service := newTestService()
+
+			// Act
+			order, err := service.CreateOrder(context.Background(), tt.req)
+
+			// Assert
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if order.Status != tt.wantStatus {
+				t.Errorf("Expected status %s, got %+v", tt.wantStatus, order)
+			}
+
+			stored, err := service.GetOrder(context.Background(), order.OrderID)
+			if err != nil || stored.Status != tt.wantStatus {
+				t.Errorf("Expected the %s order to be stored, got %+v, %v", tt.wantStatus, stored, err)
+			}
+		})
+	}
+}
+
+func TestOrderService_ReservesStock(t *testing.T) {
+	tests := []struct {
+		name          string
+		items         []Item
+		wantErr       error
+		wantRemaining int
+	}{
+		{name: "reserved", items: []Item{{ProductID: "product-123", Quantity: 2}}, wantRemaining: 148},
+		{
+			// The reservation of the first line is compensated
+			name:          "insufficient stock",
+			items:         []Item{{ProductID: "product-123", Quantity: 2}, {ProductID: "product-456", Quantity: 9}},
+			wantErr:       product.ErrInsufficientStock,
+			wantRemaining: 150,
+		},
+		{
+			name:          "unknown product",
+			items:         []Item{{ProductID: "product-123", Quantity: 2}, {ProductID: "product-missing", Quantity: 1}},
+			wantErr:       product.ErrProductNotFound,
+			wantRemaining: 150,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			products := product.NewService(product.NewInMemoryRepository())
+			enricher := enrichment.NewService(customer.NewService(customer.NewInMemoryRepository()), products)
+			repo := NewInMemoryRepository()
+			service := NewService(repo, enricher, WithStockReservation(unitofwork.Compensating{}, products))
+
+			// Act
+			_, err := service.CreateOrder(context.Background(), OrderRequest{CustomerID: "customer-456", Items: tt.items})
+
+			// Assert
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Expected %v, got %v", tt.wantErr, err)
+			}
+			stored, _ := repo.Count(OrderFilter{})
+			if wantStored := map[bool]int{true: 1, false: 0}[tt.wantErr == nil]; stored != wantStored {
+				t.Errorf("Expected %d stored orders, got %d", wantStored, stored)
+			}
+			remaining, _ := products.GetProduct(context.Background(), "product-123")
+			if remaining.Quantity != tt.wantRemaining {
+				t.Errorf("Expected %d units of product-123 left, got %d", tt.wantRemaining, remaining.Quantity)
+			}
+		})
+	}
+}
+
+func TestOrderService_EnrichmentOutcome(t *testing.T) {
+	// Arrange
+	placed := time.Date(2024, 6, 1, 8, 0, 0, 0, time.UTC)
+	service := newTestService(WithClock(clock.Fixed(placed)))
+	ctx := context.Background()
+
+	// Act
+	enriched, enrichedErr := service.CreateOrder(ctx, OrderRequest{
+		CustomerID: "customer-456",
+		Items:      []Item{{ProductID: "product-123", Quantity: 2}},
+	})
+	failed, failedErr := service.CreateOrder(ctx, OrderRequest{
+		CustomerID: "customer-456",
+		Items:      []Item{{ProductID: "product-missing", Quantity: 1}},
+	})
+
+	// Assert
+	if enrichedErr != nil || enriched.Enrichment == nil || enriched.Enrichment.Total != 51.98 {
+		t.Fatalf("Expected an enriched order totalling 51.98, got %+v, %v", enriched, enrichedErr)
+	}
+	if enriched.EnrichedAt == nil || !enriched.EnrichedAt.Equal(placed) || enriched.FailureReason != "" {
+		t.Errorf("Expected the enrichment time and no failure, got %+v", enriched)
+	}
+	if failedErr != nil || failed.Enrichment != nil || failed.FailureReason != "product not found: product-missing" {
+		t.Errorf("Expected the enricher's reason on a failed order, got %+v, %v", failed, failedErr)
+	}
+}
+
+func TestOrderService_StorageFailureLeavesOrderPending(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	available := false
+	enricher := enricherFunc(func(ctx context.Context, req enrichment.OrderRequest) (*enrichment.EnrichedOrder, error) {
+		if !available {
+			return nil, fmt.Errorf("failed to get customer: %w", breaker.ErrOpen)
+		}
+		return &enrichment.EnrichedOrder{Customer: enrichment.EnrichedCustomer{CustomerID: req.CustomerID}, Total: 10}, nil
+	})
+	service := NewService(NewInMemoryRepository(), enricher)
+
+	// Act
+	pending, createErr := service.CreateOrder(ctx, OrderRequest{CustomerID: "customer-456", Items: []Item{{ProductID: "product-123", Quantity: 1}}})
+	_, retryErr := service.EnrichOrder(ctx, pending.OrderID)
+	available = true
+	retried, recoveredErr := service.EnrichOrder(ctx, pending.OrderID)
+
+	// Assert
+	if createErr != nil || pending.Status != StatusPending {
+		t.Fatalf("Expected the order to be accepted PENDING, got %+v, %v", pending, createErr)
+	}
+	if !errors.Is(retryErr, breaker.ErrOpen) {
+		t.Errorf("Expected an explicit enrichment to report the storage failure, got %v", retryErr)
+	}
+	if recoveredErr != nil || retried.Status != StatusEnriched || retried.Enrichment.Total != 10 {
+		t.Errorf("Expected the retry to enrich the order, got %+v, %v", retried, recoveredErr)
+	}
+}
+
+func TestOrderService_UpdateOrder(t *testing.T) {
+	// Arrange
+	service := newTestService()
+	ctx := context.Background()
+	failed, err := service.CreateOrder(ctx, OrderRequest{CustomerID: "customer-456", Items: []Item{{ProductID: "product-missing", Quantity: 1}}})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Act
+	fixed, fixErr := service.UpdateOrder(ctx, failed.OrderID, OrderRequest{CustomerID: "customer-456", Items: []Item{{ProductID: "product-101", Quantity: 1}}})
+	_, missingErr := service.UpdateOrder(ctx, "order-missing", OrderRequest{CustomerID: "customer-456", Items: []Item{{ProductID: "product-101", Quantity: 1}}})
+	deleteErr := service.DeleteOrder(ctx, failed.OrderID)
+	_, deletedErr := service.GetOrder(ctx, failed.OrderID)
+
+	// Assert
+	if fixErr != nil || fixed.Status != StatusEnriched || fixed.FailureReason != "" || fixed.Items[0].ProductID != "product-101" {
+		t.Errorf("Expected the corrected order to be enriched, got %+v, %v", fixed, fixErr)
+	}
+	if !errors.Is(missingErr, ErrOrderNotFound) {
+		t.Errorf("Expected ErrOrderNotFound, got %v", missingErr)
+	}
+	if deleteErr != nil || !errors.Is(deletedErr, ErrOrderNotFound) {
+		t.Errorf("Expected the order to be deleted, got %v, %v", deleteErr, deletedErr)
+	}
+}
+
+func TestOrderService_FindOrders(t *testing.T) {
+	// Arrange
+	service := newTestService()
+	ctx := context.Background()
+	for _, productID := range []string{"product-123", "product-missing", "product-101"} {
+		if _, err := service.CreateOrder(ctx, OrderRequest{CustomerID: "customer-456", Items: []Item{{ProductID: productID, Quantity: 1}}}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	// Act
+	enriched, findErr := service.FindOrders(ctx, OrderFilter{CustomerID: "customer-456", Status: StatusEnriched})
+	failed, countErr := service.CountOrders(ctx, OrderFilter{Status: StatusFailed})
+	_, statusErr := service.FindOrders(ctx, OrderFilter{Status: "SHIPPED"})
+
+	// Assert
+	if findErr != nil || len(enriched) != 2 {
+		t.Errorf("Expected 2 enriched orders, got %d, %v", len(enriched), findErr)
+	}
+	if countErr != nil || failed != 1 {
+		t.Errorf("Expected 1 failed order, got %d, %v", failed, countErr)
+	}
+	if !errors.Is(statusErr, ErrInvalidFilter) {
+		t.Errorf("Expected ErrInvalidFilter for an unknown status, got %v", statusErr)
+	}
+}
+
+func TestOrderService_DeadLetters(t *testing.T) {
+	// Arrange
+	deadLetters := dlq.NewService(dlq.NewInMemoryRepository())
+	service := newTestService(WithDeadLetters(deadLetters))
+	deadLetters.Handle(dlq.SourceOrder, service.ReplayDeadLetter)
+	ctx := context.Background()
+	open := dlq.EntryFilter{Source: dlq.SourceOrder, Status: dlq.StatusOpen}
+
+	// Act
+	failed, err := service.CreateOrder(ctx, OrderRequest{CustomerID: "customer-456", Items: []Item{{ProductID: "product-missing", Quantity: 1}}})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	entries, _ := deadLetters.FindEntries(ctx, open)
+	if len(entries) != 1 {
+		t.Fatalf("Expected the failed order dead-lettered, got %+v", entries)
+	}
+	retried, retryErr := deadLetters.RetryEntry(ctx, entries[0].EntryID)
+	_, updateErr := service.UpdateOrder(ctx, failed.OrderID, OrderRequest{CustomerID: "customer-456", Items: []Item{{ProductID: "product-123", Quantity: 1}}})
+
+	// Assert
+	if entry := entries[0]; entry.SourceID != failed.OrderID || entry.FailureReason != "product not found: product-missing" || entry.Order.OrderedAt == nil {
+		t.Errorf("Expected the entry to carry the order and its reason, got %+v", entry)
+	}
+	if retryErr != nil || retried.Status != dlq.StatusOpen || retried.RetryCount != 1 {
+		t.Errorf("Expected the retry to fail once more, got %+v, %v", retried, retryErr)
+	}
+	if updateErr != nil {
+		t.Fatalf("Expected no error, got %v", updateErr)
+	}
+	if count, _ := deadLetters.CountEntries(ctx, open); count != 0 {
+		t.Errorf("Expected enriching the fixed order to resolve its entry, got %d open", count)
+	}
+}
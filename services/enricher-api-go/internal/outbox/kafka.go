@@ -0,0 +1,74 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// DefaultTopic is the Kafka topic events are published to
+const DefaultTopic = "catalog.events"
+
+// Writer is the subset of *kafka.Writer used by KafkaPublisher
+type Writer interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// KafkaPublisher publishes events to a Kafka topic, keyed by aggregate ID so
+// the events of one customer or product stay in order on one partition
+type KafkaPublisher struct {
+	writer Writer
+}
+
+// NewKafkaPublisher creates a publisher writing to topic on brokers
+func NewKafkaPublisher(brokers []string, topic string) (*KafkaPublisher, error) {
+	if len(brokers) == 0 {
+		return nil, errors.New("at least one Kafka broker is required")
+	}
+	if topic == "" {
+		topic = DefaultTopic
+	}
+
+	return NewKafkaPublisherWithWriter(&kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Topic:        topic,
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+	}), nil
+}
+
+// NewKafkaPublisherWithWriter creates a publisher using an existing writer
+func NewKafkaPublisherWithWriter(writer Writer) *KafkaPublisher {
+	return &KafkaPublisher{writer: writer}
+}
+
+// Publish writes events as JSON messages carrying their type in the
+// event-type header
+func (p *KafkaPublisher) Publish(ctx context.Context, events []*Event) error {
+	msgs := make([]kafka.Message, len(events))
+	for i, event := range events {
+		value, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to encode event %s: %w", event.EventID, err)
+		}
+		msgs[i] = kafka.Message{
+			Key:     []byte(event.AggregateID),
+			Value:   value,
+			Headers: []kafka.Header{{Key: "event-type", Value: []byte(event.Type)}},
+		}
+	}
+
+	if err := p.writer.WriteMessages(ctx, msgs...); err != nil {
+		return fmt.Errorf("failed to publish events: %w", err)
+	}
+	return nil
+}
+
+// Close releases the writer's connections
+func (p *KafkaPublisher) Close() error {
+	return p.writer.Close()
+}
@@ -0,0 +1,78 @@
+// Package outbox records customer and product changes as events in the
+// same step as the change itself, and relays them to a message broker.
+//
+// Repositories write an event with every change they store: in the same
+// transaction on database backends, under the same lock in memory. A Relay
+// later publishes pending events in the order they were recorded and marks
+// them published. An event is therefore never lost once its change is
+// stored, but a relay stopped between publishing and marking republishes
+// it, so consumers must treat events as idempotent by eventId.
+package outbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"enricher-api-go/internal/idgen"
+)
+
+// Aggregates whose changes are recorded
+const (
+	AggregateCustomer = "customer"
+	AggregateProduct  = "product"
+)
+
+// Actions a change event reports
+const (
+	ActionCreated  = "created"
+	ActionUpdated  = "updated"
+	ActionDeleted  = "deleted"
+	ActionRestored = "restored"
+	// ActionStockChanged reports a reservation or release of product stock
+	ActionStockChanged = "stock_changed"
+)
+
+// Change describes a stored change of an aggregate
+type Change struct {
+	Aggregate   string
+	AggregateID string
+	Action      string
+	// Data is the aggregate as stored after the change
+	Data interface{}
+}
+
+// Event is a recorded change, as published
+type Event struct {
+	EventID string `json:"eventId"`
+	// Type is the aggregate and action, such as customer.created
+	Type        string          `json:"type"`
+	Aggregate   string          `json:"aggregate"`
+	AggregateID string          `json:"aggregateId"`
+	Data        json.RawMessage `json:"data"`
+	OccurredAt  time.Time       `json:"occurredAt"`
+	// PublishedAt is set once the relay has published the event
+	PublishedAt *time.Time `json:"-"`
+}
+
+// newEvent builds the event recording change at occurredAt
+func newEvent(change Change, occurredAt time.Time) (*Event, error) {
+	data, err := json.Marshal(change.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s event: %w", change.Aggregate, err)
+	}
+
+	eventID, err := idgen.UUIDGenerator{}.NewID("evt")
+	if err != nil {
+		return nil, err
+	}
+
+	return &Event{
+		EventID:     eventID,
+		Type:        change.Aggregate + "." + change.Action,
+		Aggregate:   change.Aggregate,
+		AggregateID: change.AggregateID,
+		Data:        data,
+		OccurredAt:  occurredAt,
+	}, nil
+}
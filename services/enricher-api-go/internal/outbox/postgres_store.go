@@ -0,0 +1,177 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// publishLock is the advisory lock key held while a relay publishes, so
+// relays of several instances never publish events out of order
+const publishLock = 7420113
+
+// PostgresStore implements Store on top of a PostgreSQL database
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore creates an outbox backed by db.
+//
+// The caller owns db and is responsible for opening and closing it; the
+// outbox_events table must exist (see the migrations package).
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// Record inserts an event for change as part of tx, so the event is stored
+// if and only if the change is committed
+func (s *PostgresStore) Record(tx *sql.Tx, change Change) error {
+	event, err := newEvent(change, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO outbox_events (event_id, type, aggregate, aggregate_id, data, occurred_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		event.EventID, event.Type, event.Aggregate, event.AggregateID, []byte(event.Data), event.OccurredAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record %s event: %w", event.Type, err)
+	}
+	return nil
+}
+
+// PublishPending publishes the oldest unpublished events, at most limit.
+// It publishes nothing while another relay holds the publish lock.
+func (s *PostgresStore) PublishPending(ctx context.Context, limit int, publish PublishFunc) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin outbox transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var locked bool
+	if err := tx.QueryRowContext(ctx, `SELECT pg_try_advisory_xact_lock($1)`, publishLock).Scan(&locked); err != nil {
+		return 0, fmt.Errorf("failed to take outbox publish lock: %w", err)
+	}
+	if !locked {
+		return 0, nil
+	}
+
+	pending, err := s.pending(ctx, tx, limit)
+	if err != nil || len(pending) == 0 {
+		return 0, err
+	}
+	if err := publish(ctx, pending); err != nil {
+		return 0, err
+	}
+
+	eventIDs := make([]string, len(pending))
+	for i, event := range pending {
+		eventIDs[i] = event.EventID
+	}
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE outbox_events SET published_at = $2 WHERE event_id = ANY($1)`,
+		eventIDs, time.Now().UTC(),
+	); err != nil {
+		return 0, fmt.Errorf("failed to mark events published: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit published events: %w", err)
+	}
+	return len(pending), nil
+}
+
+// pending reads the oldest unpublished events, at most limit
+func (s *PostgresStore) pending(ctx context.Context, tx *sql.Tx, limit int) ([]*Event, error) {
+	rows, err := tx.QueryContext(ctx,
+		`SELECT event_id, type, aggregate, aggregate_id, data, occurred_at FROM outbox_events
+		WHERE published_at IS NULL ORDER BY sequence LIMIT $1`,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pending events: %w", err)
+	}
+	defer rows.Close()
+
+	events := make([]*Event, 0)
+	for rows.Next() {
+		var event Event
+		var data []byte
+		if err := rows.Scan(&event.EventID, &event.Type, &event.Aggregate, &event.AggregateID, &data, &event.OccurredAt); err != nil {
+			return nil, fmt.Errorf("failed to scan event: %w", err)
+		}
+		event.Data = data
+		events = append(events, &event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read pending events: %w", err)
+	}
+	return events, nil
+}
+
+// DeletePublished removes the events published before before
+func (s *PostgresStore) DeletePublished(ctx context.Context, before time.Time) (int, error) {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM outbox_events WHERE published_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete published events: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read affected rows: %w", err)
+	}
+	return int(deleted), nil
+}
+
+// Redact rewrites the data of the events held of an aggregate in one
+// transaction, so either all of them are rewritten or none is
+func (s *PostgresStore) Redact(ctx context.Context, aggregate, aggregateID string, redact RedactFunc) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin outbox transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx,
+		`SELECT event_id, data FROM outbox_events WHERE aggregate = $1 AND aggregate_id = $2 ORDER BY sequence FOR UPDATE`,
+		aggregate, aggregateID,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query %s events: %w", aggregate, err)
+	}
+	type stored struct {
+		eventID string
+		data    []byte
+	}
+	var events []stored
+	for rows.Next() {
+		var event stored
+		if err := rows.Scan(&event.eventID, &event.data); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan event: %w", err)
+		}
+		events = append(events, event)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to read %s events: %w", aggregate, err)
+	}
+
+	for _, event := range events {
+		redacted, err := redact(event.data)
+		if err != nil {
+			return 0, fmt.Errorf("failed to redact event %s: %w", event.eventID, err)
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE outbox_events SET data = $2 WHERE event_id = $1`, event.eventID, []byte(redacted)); err != nil {
+			return 0, fmt.Errorf("failed to redact event %s: %w", event.eventID, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit redacted events: %w", err)
+	}
+	return len(events), nil
+}
@@ -0,0 +1,133 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"enricher-api-go/internal/clock"
+	"enricher-api-go/internal/logging"
+)
+
+// Default relay settings
+const (
+	DefaultPollInterval = time.Second
+	DefaultBatchSize    = 100
+	DefaultRetention    = 24 * time.Hour
+)
+
+// Publisher delivers events to a message broker
+type Publisher interface {
+	Publish(ctx context.Context, events []*Event) error
+}
+
+// fanout publishes to several publishers in turn
+type fanout []Publisher
+
+// Fanout returns a publisher that publishes every batch to each of
+// publishers in turn. A batch one of them fails is retried on all of them,
+// so each must accept a batch it has already published.
+func Fanout(publishers ...Publisher) Publisher {
+	if len(publishers) == 1 {
+		return publishers[0]
+	}
+	return fanout(publishers)
+}
+
+// Publish publishes events to every publisher, stopping at the first failure
+func (f fanout) Publish(ctx context.Context, events []*Event) error {
+	for _, publisher := range f {
+		if err := publisher.Publish(ctx, events); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RelayConfig holds the relay settings; zero values take the package defaults
+type RelayConfig struct {
+	// PollInterval is how long the relay waits once no events are pending
+	PollInterval time.Duration
+	// BatchSize bounds the events published at once
+	BatchSize int
+	// Retention is how long published events are kept before they are deleted
+	Retention time.Duration
+}
+
+// withDefaults fills in unset fields with the package defaults
+func (cfg RelayConfig) withDefaults() RelayConfig {
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = DefaultPollInterval
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = DefaultBatchSize
+	}
+	if cfg.Retention <= 0 {
+		cfg.Retention = DefaultRetention
+	}
+	return cfg
+}
+
+// Relay publishes the events of a store until stopped
+type Relay struct {
+	store     Store
+	publisher Publisher
+	cfg       RelayConfig
+	clock     clock.Clock
+}
+
+// NewRelay creates a relay publishing the events of store with publisher
+func NewRelay(store Store, publisher Publisher, cfg RelayConfig) *Relay {
+	return &Relay{
+		store:     store,
+		publisher: publisher,
+		cfg:       cfg.withDefaults(),
+		clock:     clock.System{},
+	}
+}
+
+// Run publishes pending events until ctx is cancelled, then returns nil.
+//
+// Batches are published back to back while a full batch was pending, and
+// every PollInterval otherwise. A batch that fails to publish stays pending
+// and is retried on the next poll, so events are delayed but never dropped
+// while the broker is down.
+func (r *Relay) Run(ctx context.Context) error {
+	logger := logging.FromContext(ctx)
+	ticker := time.NewTicker(r.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		published, err := r.Poll(ctx)
+		if err != nil && ctx.Err() == nil {
+			logger.Warn("Failed to publish outbox events, retrying", "error", err, "retry_in", r.cfg.PollInterval.String())
+		}
+		if err == nil && published == r.cfg.BatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll publishes one batch of pending events and deletes the published
+// events past their retention, returning how many events it published
+func (r *Relay) Poll(ctx context.Context) (int, error) {
+	published, err := r.store.PublishPending(ctx, r.cfg.BatchSize, r.publisher.Publish)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted, err := r.store.DeletePublished(ctx, r.clock.Now().Add(-r.cfg.Retention))
+	if err != nil {
+		return published, err
+	}
+
+	if published > 0 || deleted > 0 {
+		logging.FromContext(ctx).Debug("Relayed outbox events", "published", published, "deleted", deleted)
+	}
+	return published, nil
+}
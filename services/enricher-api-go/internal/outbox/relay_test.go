@@ -0,0 +1,164 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"enricher-api-go/internal/clock"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// fakeWriter records written messages, failing while failures remain
+type fakeWriter struct {
+	mu        sync.Mutex
+	failures  int
+	published []kafka.Message
+}
+
+func (w *fakeWriter) WriteMessages(_ context.Context, msgs ...kafka.Message) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.failures > 0 {
+		w.failures--
+		return errors.New("broker unavailable")
+	}
+	w.published = append(w.published, msgs...)
+	return nil
+}
+
+func (w *fakeWriter) Close() error { return nil }
+
+func (w *fakeWriter) publishedMessages() []kafka.Message {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]kafka.Message(nil), w.published...)
+}
+
+// recordProducts records a created event for each product ID
+func recordProducts(t *testing.T, store *InMemoryStore, productIDs ...string) {
+	t.Helper()
+	for _, productID := range productIDs {
+		change := Change{Aggregate: AggregateProduct, AggregateID: productID, Action: ActionCreated,
+			Data: map[string]string{"productId": productID}}
+		if err := store.Record(change); err != nil {
+			t.Fatalf("Failed to record change: %v", err)
+		}
+	}
+}
+
+func TestRelay_Poll(t *testing.T) {
+	// Arrange
+	store := NewInMemoryStore()
+	writer := &fakeWriter{failures: 1}
+	relay := NewRelay(store, NewKafkaPublisherWithWriter(writer), RelayConfig{BatchSize: 10})
+	recordProducts(t, store, "product-1", "product-2")
+	ctx := context.Background()
+
+	// Act
+	_, failedErr := relay.Poll(ctx)
+	published, err := relay.Poll(ctx)
+
+	// Assert
+	if failedErr == nil {
+		t.Errorf("Expected the broker failure reported")
+	}
+	if err != nil || published != 2 {
+		t.Fatalf("Expected both events published on the next poll, got %d, %v", published, err)
+	}
+	msgs := writer.publishedMessages()
+	if len(msgs) != 2 || string(msgs[0].Key) != "product-1" || string(msgs[1].Key) != "product-2" {
+		t.Fatalf("Expected the events keyed by product in order, got %v", msgs)
+	}
+	if len(msgs[0].Headers) != 1 || string(msgs[0].Headers[0].Value) != "product.created" {
+		t.Errorf("Expected the event type header, got %v", msgs[0].Headers)
+	}
+	var event Event
+	if err := json.Unmarshal(msgs[0].Value, &event); err != nil {
+		t.Fatalf("Expected a JSON event, got %v", err)
+	}
+	if event.EventID == "" || event.Type != "product.created" || event.AggregateID != "product-1" ||
+		string(event.Data) != `{"productId":"product-1"}` {
+		t.Errorf("Expected the recorded event, got %+v", event)
+	}
+}
+
+func TestRelay_Poll_DeletesExpiredEvents(t *testing.T) {
+	// Arrange
+	store := NewInMemoryStore()
+	relay := NewRelay(store, NewKafkaPublisherWithWriter(&fakeWriter{}), RelayConfig{Retention: time.Hour})
+	recordProducts(t, store, "product-1")
+	if _, err := relay.Poll(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	relay.clock = clock.Fixed(time.Now().Add(2 * time.Hour))
+
+	// Act
+	_, err := relay.Poll(context.Background())
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if events := store.Events(); len(events) != 0 {
+		t.Errorf("Expected the published event deleted after its retention, got %v", events)
+	}
+}
+
+func TestRelay_Run(t *testing.T) {
+	// Arrange
+	store := NewInMemoryStore()
+	writer := &fakeWriter{}
+	relay := NewRelay(store, NewKafkaPublisherWithWriter(writer), RelayConfig{PollInterval: 5 * time.Millisecond, BatchSize: 2})
+	recordProducts(t, store, "product-1", "product-2", "product-3")
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+
+	// Act
+	go func() { done <- relay.Run(ctx) }()
+	recordProducts(t, store, "product-4")
+	deadline := time.Now().Add(5 * time.Second)
+	for len(writer.publishedMessages()) < 4 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+
+	// Assert
+	if err := <-done; err != nil {
+		t.Errorf("Expected Run to stop cleanly, got %v", err)
+	}
+	if msgs := writer.publishedMessages(); len(msgs) != 4 || string(msgs[3].Key) != "product-4" {
+		t.Errorf("Expected every event published in order, got %d messages", len(msgs))
+	}
+}
+
+func TestFanout(t *testing.T) {
+	// Arrange
+	store := NewInMemoryStore()
+	kafkaWriter, failingWriter := &fakeWriter{}, &fakeWriter{failures: 1}
+	relay := NewRelay(store, Fanout(NewKafkaPublisherWithWriter(kafkaWriter), NewKafkaPublisherWithWriter(failingWriter)), RelayConfig{})
+	recordProducts(t, store, "product-1")
+	ctx := context.Background()
+
+	// Act
+	_, failedErr := relay.Poll(ctx)
+	published, err := relay.Poll(ctx)
+
+	// Assert
+	if failedErr == nil {
+		t.Errorf("Expected the failure of the second publisher reported")
+	}
+	if err != nil || published != 1 {
+		t.Fatalf("Expected the event published on the next poll, got %d, %v", published, err)
+	}
+	if got := len(kafkaWriter.publishedMessages()); got != 2 {
+		t.Errorf("Expected the batch published again to the first publisher, got %d messages", got)
+	}
+	if got := len(failingWriter.publishedMessages()); got != 1 {
+		t.Errorf("Expected the batch published once to the second publisher, got %d messages", got)
+	}
+}
@@ -0,0 +1,152 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// PublishFunc publishes a batch of events, failing if any was not published
+type PublishFunc func(ctx context.Context, events []*Event) error
+
+// RedactFunc returns the data of an event with the fields it must no longer
+// hold removed
+type RedactFunc func(data json.RawMessage) (json.RawMessage, error)
+
+// Store holds recorded events until they are published.
+//
+// PublishPending passes the oldest unpublished events, at most limit, to
+// publish and marks them published only if it succeeds, returning how many
+// were. Only one caller publishes at a time, so events are published in the
+// order they were recorded even when several relays share a store.
+// DeletePublished removes the events published before a time. Redact
+// rewrites the data of every event held of an aggregate, published or not,
+// such as to erase the personal data of a customer, returning how many it
+// rewrote.
+type Store interface {
+	PublishPending(ctx context.Context, limit int, publish PublishFunc) (int, error)
+	DeletePublished(ctx context.Context, before time.Time) (int, error)
+	Redact(ctx context.Context, aggregate, aggregateID string, redact RedactFunc) (int, error)
+}
+
+// InMemoryStore implements Store for the in-memory repositories
+type InMemoryStore struct {
+	events []*Event
+	mutex  sync.Mutex
+	// publishing serializes PublishPending without holding mutex, so
+	// repositories keep recording while a batch is published
+	publishing sync.Mutex
+}
+
+// NewInMemoryStore creates an empty in-memory outbox
+func NewInMemoryStore() *InMemoryStore {
+	return &InMemoryStore{}
+}
+
+// Record appends an event for change. Repositories call it while holding
+// the lock under which they stored the change.
+func (s *InMemoryStore) Record(change Change) error {
+	event, err := newEvent(change, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.events = append(s.events, event)
+	return nil
+}
+
+// Events returns copies of every event held, oldest first
+func (s *InMemoryStore) Events() []*Event {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	events := make([]*Event, len(s.events))
+	for i, event := range s.events {
+		eventCopy := *event
+		events[i] = &eventCopy
+	}
+	return events
+}
+
+// PublishPending publishes the oldest unpublished events, at most limit
+func (s *InMemoryStore) PublishPending(ctx context.Context, limit int, publish PublishFunc) (int, error) {
+	s.publishing.Lock()
+	defer s.publishing.Unlock()
+
+	s.mutex.Lock()
+	pending := make([]*Event, 0, limit)
+	for _, event := range s.events {
+		if len(pending) == limit {
+			break
+		}
+		if event.PublishedAt == nil {
+			eventCopy := *event
+			pending = append(pending, &eventCopy)
+		}
+	}
+	s.mutex.Unlock()
+
+	if len(pending) == 0 {
+		return 0, nil
+	}
+	if err := publish(ctx, pending); err != nil {
+		return 0, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	publishedAt := time.Now().UTC()
+	published := make(map[string]bool, len(pending))
+	for _, event := range pending {
+		published[event.EventID] = true
+	}
+	for _, event := range s.events {
+		if published[event.EventID] {
+			event.PublishedAt = &publishedAt
+		}
+	}
+	return len(pending), nil
+}
+
+// DeletePublished removes the events published before before
+func (s *InMemoryStore) DeletePublished(ctx context.Context, before time.Time) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	kept := s.events[:0]
+	for _, event := range s.events {
+		if event.PublishedAt == nil || !event.PublishedAt.Before(before) {
+			kept = append(kept, event)
+		}
+	}
+	deleted := len(s.events) - len(kept)
+	clear(s.events[len(kept):])
+	s.events = kept
+	return deleted, nil
+}
+
+// Redact rewrites the data of the events held of an aggregate
+func (s *InMemoryStore) Redact(ctx context.Context, aggregate, aggregateID string, redact RedactFunc) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	redacted := 0
+	for _, event := range s.events {
+		if event.Aggregate != aggregate || event.AggregateID != aggregateID {
+			continue
+		}
+		data, err := redact(event.Data)
+		if err != nil {
+			return redacted, fmt.Errorf("failed to redact event %s: %w", event.EventID, err)
+		}
+		event.Data = data
+		redacted++
+	}
+	return redacted, nil
+}
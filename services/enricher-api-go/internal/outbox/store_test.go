@@ -0,0 +1,204 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"enricher-api-go/internal/migrations"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// recordFunc stores a change in the outbox under test
+type recordFunc func(t *testing.T, change Change)
+
+// testStoreConformance runs the behavior every Store implementation must
+// share against a store created by newStore, along with how it records
+func testStoreConformance(t *testing.T, newStore func(t *testing.T) (Store, recordFunc)) {
+	ctx := context.Background()
+	change := func(customerID, action string) Change {
+		return Change{Aggregate: AggregateCustomer, AggregateID: customerID, Action: action,
+			Data: map[string]string{"customerId": customerID}}
+	}
+
+	t.Run("Publishes in order", func(t *testing.T) {
+		store, record := newStore(t)
+		record(t, change("customer-1", ActionCreated))
+		record(t, change("customer-2", ActionCreated))
+		record(t, change("customer-1", ActionDeleted))
+
+		var batches [][]*Event
+		collect := func(_ context.Context, events []*Event) error {
+			batches = append(batches, events)
+			return nil
+		}
+		first, err := store.PublishPending(ctx, 2, collect)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		second, err := store.PublishPending(ctx, 2, collect)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		none, err := store.PublishPending(ctx, 2, collect)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if first != 2 || second != 1 || none != 0 || len(batches) != 2 {
+			t.Fatalf("Expected batches of 2 and 1, got %d, %d and %d in %d batches", first, second, none, len(batches))
+		}
+		if got := eventTypes(append(batches[0], batches[1]...)); got != "customer.created customer.created customer.deleted" {
+			t.Errorf("Expected the events in recorded order, got %s", got)
+		}
+		event := batches[0][0]
+		if event.EventID == "" || event.AggregateID != "customer-1" || string(event.Data) != `{"customerId":"customer-1"}` ||
+			event.OccurredAt.IsZero() {
+			t.Errorf("Expected the recorded change, got %+v", event)
+		}
+	})
+
+	t.Run("Keeps failed batches pending", func(t *testing.T) {
+		store, record := newStore(t)
+		record(t, change("customer-1", ActionUpdated))
+
+		published, err := store.PublishPending(ctx, 10, func(context.Context, []*Event) error {
+			return errors.New("broker unavailable")
+		})
+		if err == nil || published != 0 {
+			t.Fatalf("Expected the publish error, got %d, %v", published, err)
+		}
+
+		var retried []*Event
+		published, err = store.PublishPending(ctx, 10, func(_ context.Context, events []*Event) error {
+			retried = events
+			return nil
+		})
+		if err != nil || published != 1 || len(retried) != 1 || retried[0].Type != "customer.updated" {
+			t.Errorf("Expected the event published on retry, got %d, %v, %v", published, retried, err)
+		}
+	})
+
+	t.Run("Redacts the events of an aggregate", func(t *testing.T) {
+		store, record := newStore(t)
+		record(t, change("customer-1", ActionCreated))
+		if _, err := store.PublishPending(ctx, 10, func(context.Context, []*Event) error { return nil }); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		record(t, change("customer-1", ActionUpdated))
+		record(t, change("customer-2", ActionUpdated))
+
+		redacted, err := store.Redact(ctx, AggregateCustomer, "customer-1", func(json.RawMessage) (json.RawMessage, error) {
+			return json.RawMessage(`{"customerId":"redacted"}`), nil
+		})
+		if err != nil || redacted != 2 {
+			t.Fatalf("Expected both events of the customer redacted, got %d, %v", redacted, err)
+		}
+
+		var pending []*Event
+		if _, err := store.PublishPending(ctx, 10, func(_ context.Context, events []*Event) error {
+			pending = events
+			return nil
+		}); err != nil || len(pending) != 2 {
+			t.Fatalf("Expected 2 pending events, got %v, %v", pending, err)
+		}
+		if string(pending[0].Data) != `{"customerId":"redacted"}` || string(pending[1].Data) != `{"customerId":"customer-2"}` {
+			t.Errorf("Expected only the customer's event redacted, got %s and %s", pending[0].Data, pending[1].Data)
+		}
+	})
+
+	t.Run("Deletes published events", func(t *testing.T) {
+		store, record := newStore(t)
+		record(t, change("customer-1", ActionCreated))
+		if _, err := store.PublishPending(ctx, 10, func(context.Context, []*Event) error { return nil }); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		record(t, change("customer-2", ActionCreated))
+
+		kept, err := store.DeletePublished(ctx, time.Now().Add(-time.Hour))
+		if err != nil || kept != 0 {
+			t.Errorf("Expected recent events kept, got %d, %v", kept, err)
+		}
+		deleted, err := store.DeletePublished(ctx, time.Now().Add(time.Hour))
+		if err != nil || deleted != 1 {
+			t.Errorf("Expected the published event deleted, got %d, %v", deleted, err)
+		}
+
+		var pending []*Event
+		if _, err := store.PublishPending(ctx, 10, func(_ context.Context, events []*Event) error {
+			pending = events
+			return nil
+		}); err != nil || len(pending) != 1 || pending[0].AggregateID != "customer-2" {
+			t.Errorf("Expected the unpublished event kept, got %v, %v", pending, err)
+		}
+	})
+}
+
+// eventTypes joins the types of events, in order
+func eventTypes(events []*Event) string {
+	types := make([]string, len(events))
+	for i, event := range events {
+		types[i] = event.Type
+	}
+	return strings.Join(types, " ")
+}
+
+func TestInMemoryStore_Conformance(t *testing.T) {
+	testStoreConformance(t, func(t *testing.T) (Store, recordFunc) {
+		store := NewInMemoryStore()
+		return store, func(t *testing.T, change Change) {
+			if err := store.Record(change); err != nil {
+				t.Fatalf("Failed to record change: %v", err)
+			}
+		}
+	})
+}
+
+// TestPostgresStore_Conformance runs against the database in
+// POSTGRES_TEST_DSN and is skipped when it is not set.
+func TestPostgresStore_Conformance(t *testing.T) {
+	dsn := os.Getenv("POSTGRES_TEST_DSN")
+	if dsn == "" {
+		t.Skip("POSTGRES_TEST_DSN not set")
+	}
+
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := migrations.New(db)
+	if err != nil {
+		t.Fatalf("Failed to load migrations: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+
+	testStoreConformance(t, func(t *testing.T) (Store, recordFunc) {
+		store := NewPostgresStore(db)
+		if _, err := db.Exec(`TRUNCATE outbox_events`); err != nil {
+			t.Fatalf("Failed to reset outbox: %v", err)
+		}
+		return store, func(t *testing.T, change Change) {
+			tx, err := db.Begin()
+			if err != nil {
+				t.Fatalf("Failed to begin transaction: %v", err)
+			}
+			if err := store.Record(tx, change); err != nil {
+				tx.Rollback()
+				t.Fatalf("Failed to record change: %v", err)
+			}
+			if err := tx.Commit(); err != nil {
+				t.Fatalf("Failed to commit change: %v", err)
+			}
+		}
+	})
+}
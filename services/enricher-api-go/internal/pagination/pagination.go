@@ -0,0 +1,83 @@
+// Package pagination parses limit/offset query parameters and builds the
+// paging metadata shared by the list endpoints.
+package pagination
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+const (
+	// DefaultLimit is the page size used when ?limit= is omitted
+	DefaultLimit = 20
+	// MaxLimit is the largest page size a client may request
+	MaxLimit = 100
+)
+
+// ErrInvalidParams is returned when limit or offset are malformed or out of range
+var ErrInvalidParams = errors.New("invalid pagination parameters")
+
+// Params selects a window of a result set
+type Params struct {
+	Limit  int
+	Offset int
+}
+
+// Meta is the paging metadata returned alongside a page of results
+type Meta struct {
+	// Total is the number of matches across all pages
+	Total int `json:"total"`
+	// Limit is the page size that was applied
+	Limit int `json:"limit"`
+	// Offset is the number of matches skipped before this page
+	Offset int `json:"offset"`
+	// Next links to the following page and is empty on the last page
+	Next string `json:"next,omitempty"`
+}
+
+// FromQuery reads limit and offset from query, defaulting limit to DefaultLimit
+func FromQuery(query url.Values) (Params, error) {
+	params := Params{Limit: DefaultLimit}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > MaxLimit {
+			return params, fmt.Errorf("%w: limit must be an integer between 1 and %d", ErrInvalidParams, MaxLimit)
+		}
+		params.Limit = limit
+	}
+
+	if value := query.Get("offset"); value != "" {
+		offset, err := strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			return params, fmt.Errorf("%w: offset must be a non-negative integer", ErrInvalidParams)
+		}
+		params.Offset = offset
+	}
+
+	return params, nil
+}
+
+// NewMeta builds the metadata for the page selected by params out of total
+// matches. The next link keeps every query parameter of requestURL and only
+// advances the offset.
+func NewMeta(requestURL *url.URL, params Params, total int) Meta {
+	meta := Meta{
+		Total:  total,
+		Limit:  params.Limit,
+		Offset: params.Offset,
+	}
+
+	if next := params.Offset + params.Limit; next < total {
+		query := requestURL.Query()
+		query.Set("limit", strconv.Itoa(params.Limit))
+		query.Set("offset", strconv.Itoa(next))
+
+		link := url.URL{Path: requestURL.Path, RawQuery: query.Encode()}
+		meta.Next = link.String()
+	}
+
+	return meta
+}
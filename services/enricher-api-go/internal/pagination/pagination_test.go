@@ -0,0 +1,70 @@
+package pagination
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+)
+
+func TestFromQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    Params
+		wantErr bool
+	}{
+		{name: "defaults", query: "", want: Params{Limit: DefaultLimit}},
+		{name: "explicit", query: "limit=5&offset=10", want: Params{Limit: 5, Offset: 10}},
+		{name: "max limit", query: "limit=100", want: Params{Limit: MaxLimit}},
+		{name: "limit too large", query: "limit=101", wantErr: true},
+		{name: "zero limit", query: "limit=0", wantErr: true},
+		{name: "non-numeric limit", query: "limit=ten", wantErr: true},
+		{name: "negative offset", query: "offset=-1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			query, _ := url.ParseQuery(tt.query)
+
+			// Act
+			params, err := FromQuery(query)
+
+			// Assert
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidParams) {
+					t.Errorf("Expected ErrInvalidParams, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if params != tt.want {
+				t.Errorf("Expected %+v, got %+v", tt.want, params)
+			}
+		})
+	}
+}
+
+func TestNewMeta(t *testing.T) {
+	// Arrange
+	requestURL, _ := url.Parse("/v1/products?category=Electronics&limit=2")
+
+	// Act
+	first := NewMeta(requestURL, Params{Limit: 2, Offset: 0}, 5)
+	last := NewMeta(requestURL, Params{Limit: 2, Offset: 4}, 5)
+
+	// Assert
+	if first.Total != 5 || first.Limit != 2 || first.Offset != 0 {
+		t.Errorf("Unexpected metadata: %+v", first)
+	}
+
+	if first.Next != "/v1/products?category=Electronics&limit=2&offset=2" {
+		t.Errorf("Unexpected next link: %s", first.Next)
+	}
+
+	if last.Next != "" {
+		t.Errorf("Expected no next link on the last page, got %s", last.Next)
+	}
+}
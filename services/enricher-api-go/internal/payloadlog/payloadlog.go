@@ -0,0 +1,187 @@
+// Package payloadlog logs the request and response bodies of a sample of
+// requests, with personal data redacted, to diagnose what a client sent and
+// what the API answered.
+//
+// Bodies are logged up to a maximum size. JSON bodies are redacted field by
+// field under the configured rules and logged as JSON; truncated and other
+// textual bodies are redacted by pattern and logged as text, and binary ones
+// only by type and size. Email addresses keep their domain wherever they
+// appear.
+//
+// Example usage:
+//
+//	e.Use(payloadlog.Middleware(payloadlog.Config{
+//		SampleRate:  0.1,
+//		MaxBodySize: 4096,
+//		Rules: []payloadlog.Rule{
+//			{Fields: []string{"email", "customer.name"}},
+//			{Path: "/v1/customers", Fields: []string{"name"}},
+//		},
+//	}, "/v1/ws/", "/health"))
+package payloadlog
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"mime"
+	"net/http"
+	"strings"
+
+	"enricher-api-go/internal/logging"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Config controls which requests are logged and how
+type Config struct {
+	// SampleRate is the fraction of requests logged, from 0 to 1
+	SampleRate float64
+	// MaxBodySize is the most bytes of each body logged
+	MaxBodySize int
+	// Rules select the fields redacted
+	Rules []Rule
+	// Random returns a number in [0, 1) to sample requests by; nil uses
+	// math/rand
+	Random func() float64
+}
+
+// Rule redacts Fields of the bodies of requests whose path starts with Path,
+// or of every request when Path is empty.
+//
+// A field is a JSON key, matched at any depth, or a dotted path of keys
+// ending at one: customer.name redacts the name of any object under a
+// customer key. Arrays do not count as keys, so email also redacts the
+// emails of a list of customers.
+type Rule struct {
+	Path   string
+	Fields []string
+}
+
+// Middleware logs the bodies of a sample of requests and of their responses,
+// including error responses, through the request's logger. Requests whose
+// path starts with one of exemptPrefixes, such as streams, are never logged.
+func Middleware(cfg Config, exemptPrefixes ...string) echo.MiddlewareFunc {
+	random := cfg.Random
+	if random == nil {
+		random = rand.Float64
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			for _, prefix := range exemptPrefixes {
+				if strings.HasPrefix(req.URL.Path, prefix) {
+					return next(c)
+				}
+			}
+			if random() >= cfg.SampleRate {
+				return next(c)
+			}
+
+			// Read the start of the body up front, so it is logged even if
+			// the handler never reads it, and hand the handler all of it
+			var requestBody []byte
+			if req.Body != nil && req.Body != http.NoBody {
+				var err error
+				requestBody, err = io.ReadAll(io.LimitReader(req.Body, int64(cfg.MaxBodySize)+1))
+				if err != nil {
+					return err
+				}
+				req.Body = &replayedBody{Reader: io.MultiReader(bytes.NewReader(requestBody), req.Body), Closer: req.Body}
+			}
+
+			res := c.Response()
+			w := &writer{ResponseWriter: res.Writer, limit: cfg.MaxBodySize}
+			res.Writer = w
+			err := next(c)
+			// Answer errors here, so the error response is logged too
+			if err != nil {
+				c.Error(err)
+			}
+			res.Writer = w.ResponseWriter
+
+			redactor := newRedactor(cfg.Rules, req.URL.Path)
+			attrs := []any{"method", req.Method, "path", req.URL.Path, "status", res.Status}
+			attrs = append(attrs, redactor.body("request", req.Header.Get(echo.HeaderContentType), requestBody, cfg.MaxBodySize)...)
+			attrs = append(attrs, redactor.body("response", res.Header().Get(echo.HeaderContentType), w.body.Bytes(), cfg.MaxBodySize)...)
+			logging.FromContext(req.Context()).Info("Request payload", attrs...)
+			return nil
+		}
+	}
+}
+
+// body returns the log attributes of a request or response body: the body
+// redacted, or a description of it, and whether it was truncated
+func (r *redactor) body(name, contentType string, body []byte, limit int) []any {
+	if len(body) == 0 {
+		return nil
+	}
+	truncated := len(body) > limit
+	if truncated {
+		body = body[:limit]
+	}
+
+	attrs := []any{name + "_body", r.redact(contentType, body, truncated)}
+	if truncated {
+		attrs = append(attrs, name+"_body_truncated", true)
+	}
+	return attrs
+}
+
+// redact returns body redacted as JSON when it is complete JSON, as text
+// when it is textual, or else a description of its type and size
+func (r *redactor) redact(contentType string, body []byte, truncated bool) slog.Value {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if !textual(mediaType) {
+		return slog.StringValue(fmt.Sprintf("[%d bytes of %s]", len(body), mediaType))
+	}
+	if !truncated && (mediaType == echo.MIMEApplicationJSON || strings.HasSuffix(mediaType, "+json")) {
+		if redacted, ok := r.json(body); ok {
+			return slog.AnyValue(redacted)
+		}
+	}
+	return slog.StringValue(r.text(string(body)))
+}
+
+// textual reports whether bodies of mediaType are text worth logging
+func textual(mediaType string) bool {
+	return strings.HasPrefix(mediaType, "text/") ||
+		mediaType == echo.MIMEApplicationJSON || strings.HasSuffix(mediaType, "+json") ||
+		mediaType == echo.MIMEApplicationXML || strings.HasSuffix(mediaType, "+xml") ||
+		mediaType == echo.MIMEApplicationForm
+}
+
+// replayedBody reads a request body whose start was read already
+type replayedBody struct {
+	io.Reader
+	io.Closer
+}
+
+// writer copies the start of a response while writing it through
+type writer struct {
+	http.ResponseWriter
+	limit int
+	body  bytes.Buffer
+}
+
+// Write writes b, keeping it while the copy is within one byte over the
+// limit, so truncation shows
+func (w *writer) Write(b []byte) (int, error) {
+	if room := w.limit + 1 - w.body.Len(); room > 0 {
+		w.body.Write(b[:min(room, len(b))])
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush sends buffered data to the client, for streamed responses
+func (w *writer) Flush() {
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap returns the wrapped writer, for http.ResponseController
+func (w *writer) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,214 @@
+package payloadlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"enricher-api-go/internal/logging"
+
+	"github.com/labstack/echo/v4"
+)
+
+// testRules redact like the default configuration
+var testRules = []Rule{
+	{Fields: []string{"email", "customer.name"}},
+	{Path: "/v1/customers", Fields: []string{"name"}},
+}
+
+// newTestServer serves an echoing customer endpoint, an enrichment, an
+// image, a failure and a stream, logging payloads to out with sampled
+// deciding whether a request is sampled
+func newTestServer(out io.Writer, maxBodySize int, sampled bool) *echo.Echo {
+	random := func() float64 { return 0.99 }
+	if sampled {
+		random = func() float64 { return 0 }
+	}
+
+	e := echo.New()
+	e.Use(logging.Middleware(logging.New("info", out)))
+	e.Use(Middleware(Config{SampleRate: 0.5, MaxBodySize: maxBodySize, Rules: testRules, Random: random}, "/stream"))
+	e.POST("/v1/customers", func(c echo.Context) error {
+		var body map[string]any
+		if err := c.Bind(&body); err != nil {
+			return err
+		}
+		return c.JSON(http.StatusCreated, body)
+	})
+	e.POST("/v1/enrich", func(c echo.Context) error {
+		return c.JSONBlob(http.StatusOK, []byte(`{"customer":{"name":"Jane Doe","status":"ACTIVE"},"items":[{"name":"Kite","quantity":2}]}`))
+	})
+	e.GET("/image", func(c echo.Context) error {
+		return c.Blob(http.StatusOK, "image/png", []byte{0x89, 'P', 'N', 'G'})
+	})
+	e.GET("/fails", func(c echo.Context) error {
+		return echo.NewHTTPError(http.StatusConflict, "email jane.doe@example.com already in use")
+	})
+	e.GET("/stream", func(c echo.Context) error {
+		return c.String(http.StatusOK, "streamed")
+	})
+	return e
+}
+
+// payloadLog returns the payload log line written to out, or nil
+func payloadLog(t *testing.T, out *bytes.Buffer) map[string]any {
+	t.Helper()
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("Expected JSON log lines, got %s", line)
+		}
+		if entry["msg"] == "Request payload" {
+			return entry
+		}
+	}
+	return nil
+}
+
+// serve sends a request with a JSON body, if any, and returns the response
+func serve(e *echo.Echo, method, path, body string) *httptest.ResponseRecorder {
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	if body != "" {
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestMiddleware_RedactsJSON(t *testing.T) {
+	// Arrange
+	var out bytes.Buffer
+	e := newTestServer(&out, 4096, true)
+
+	// Act
+	rec := serve(e, http.MethodPost, "/v1/customers", `{"name":"Jane Doe","email":"jane.doe@example.com","status":"ACTIVE"}`)
+
+	// Assert
+	if rec.Code != http.StatusCreated || !strings.Contains(rec.Body.String(), "Jane Doe") {
+		t.Fatalf("Expected the handler to get the whole body, got %d %s", rec.Code, rec.Body.String())
+	}
+	entry := payloadLog(t, &out)
+	if entry == nil {
+		t.Fatalf("Expected a payload log line, got %s", out.String())
+	}
+	expected := map[string]any{"name": "***", "email": "***@example.com", "status": "ACTIVE"}
+	for _, body := range []string{"request_body", "response_body"} {
+		logged, _ := entry[body].(map[string]any)
+		for key, value := range expected {
+			if logged[key] != value {
+				t.Errorf("Expected %s %s to be logged as %v, got %v", body, key, value, logged[key])
+			}
+		}
+	}
+	if entry["request_id"] == nil || entry["status"] != float64(http.StatusCreated) {
+		t.Errorf("Expected the request ID and status, got %v", entry)
+	}
+}
+
+func TestMiddleware_ScopesRules(t *testing.T) {
+	// Arrange
+	var out bytes.Buffer
+	e := newTestServer(&out, 4096, true)
+
+	// Act
+	serve(e, http.MethodPost, "/v1/enrich", "")
+
+	// Assert
+	logged, _ := payloadLog(t, &out)["response_body"].(map[string]any)
+	customer, _ := logged["customer"].(map[string]any)
+	items, _ := logged["items"].([]any)
+	if customer["name"] != "***" || customer["status"] != "ACTIVE" {
+		t.Errorf("Expected the customer's name masked, got %v", customer)
+	}
+	if len(items) != 1 || items[0].(map[string]any)["name"] != "Kite" {
+		t.Errorf("Expected product names outside /v1/customers kept, got %v", items)
+	}
+}
+
+func TestMiddleware_TruncatesAndDescribes(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		body     string
+		field    string
+		expected string
+	}{
+		{
+			name: "truncated JSON", method: http.MethodPost, path: "/v1/customers",
+			body: `{"email":"jane.doe@example.com","name":"Jane Doe","segments":["vip"]}`, field: "request_body",
+			expected: `{"email":"***@example.com","name":"***"`,
+		},
+		{name: "binary body", method: http.MethodGet, path: "/image", field: "response_body", expected: "[4 bytes of image/png]"},
+		{name: "error response", method: http.MethodGet, path: "/fails", field: "response_body", expected: `{"message":"email ***@example.com`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			var out bytes.Buffer
+			e := newTestServer(&out, 48, true)
+
+			// Act
+			serve(e, tt.method, tt.path, tt.body)
+
+			// Assert
+			entry := payloadLog(t, &out)
+			if logged, _ := entry[tt.field].(string); !strings.HasPrefix(logged, tt.expected) {
+				t.Errorf("Expected %s to start with %s, got %v", tt.field, tt.expected, entry[tt.field])
+			}
+		})
+	}
+}
+
+func TestMiddleware_SkipsUnsampledAndExempt(t *testing.T) {
+	tests := []struct {
+		name    string
+		sampled bool
+		path    string
+	}{
+		{name: "not sampled", sampled: false, path: "/image"},
+		{name: "exempt path", sampled: true, path: "/stream"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			var out bytes.Buffer
+			e := newTestServer(&out, 4096, tt.sampled)
+
+			// Act
+			rec := serve(e, http.MethodGet, tt.path, "")
+
+			// Assert
+			if rec.Code != http.StatusOK {
+				t.Errorf("Expected 200, got %d", rec.Code)
+			}
+			if entry := payloadLog(t, &out); entry != nil {
+				t.Errorf("Expected no payload log line, got %v", entry)
+			}
+		})
+	}
+}
+
+func TestMask(t *testing.T) {
+	// Act
+	masks := []any{mask("jane.doe@example.com"), mask("Jane Doe"), mask(""), mask(nil), mask(json.Number("42"))}
+
+	// Assert
+	expected := []any{"***@example.com", "***", "", nil, "***"}
+	for i := range expected {
+		if masks[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected[i], masks[i])
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package payloadlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// masked replaces redacted values
+const masked = "***"
+
+// emailPattern matches email addresses, capturing the domain they keep
+var emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@([A-Za-z0-9\-]+(?:\.[A-Za-z0-9\-]+)+)`)
+
+// redactor redacts the fields the rules select for one request path
+type redactor struct {
+	// fields are the fields to redact, each split into its keys
+	fields [][]string
+	// textFields matches a redacted key and its string value in JSON text
+	textFields *regexp.Regexp
+}
+
+// newRedactor returns a redactor of the fields of the rules matching path
+func newRedactor(rules []Rule, path string) *redactor {
+	r := &redactor{}
+	var keys []string
+	for _, rule := range rules {
+		if !strings.HasPrefix(path, rule.Path) {
+			continue
+		}
+		for _, field := range rule.Fields {
+			parts := strings.Split(field, ".")
+			r.fields = append(r.fields, parts)
+			keys = append(keys, regexp.QuoteMeta(parts[len(parts)-1]))
+		}
+	}
+	if len(keys) > 0 {
+		r.textFields = regexp.MustCompile(`("(?:` + strings.Join(keys, "|") + `)"\s*:\s*)"((?:[^"\\]|\\.)*)"?`)
+	}
+	return r
+}
+
+// json returns body with the selected fields masked, or false if body is
+// not JSON
+func (r *redactor) json(body []byte) (json.RawMessage, bool) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, false
+	}
+	redacted, err := json.Marshal(r.walk(value, nil))
+	if err != nil {
+		return nil, false
+	}
+	return redacted, true
+}
+
+// walk masks the selected fields of value, found under the keys of path
+func (r *redactor) walk(value any, path []string) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, field := range v {
+			keyPath := append(path[:len(path):len(path)], key)
+			if r.selects(keyPath) {
+				v[key] = mask(field)
+				continue
+			}
+			v[key] = r.walk(field, keyPath)
+		}
+		return v
+	case []any:
+		for i, item := range v {
+			v[i] = r.walk(item, path)
+		}
+		return v
+	case string:
+		return emailPattern.ReplaceAllString(v, masked+"@$1")
+	default:
+		return v
+	}
+}
+
+// selects reports whether a field ends the keys of path
+func (r *redactor) selects(path []string) bool {
+	for _, field := range r.fields {
+		if len(field) <= len(path) && slices.Equal(field, path[len(path)-len(field):]) {
+			return true
+		}
+	}
+	return false
+}
+
+// text masks the string values of the selected keys, matched by their last
+// key alone, and the email addresses in body
+func (r *redactor) text(body string) string {
+	if r.textFields != nil {
+		body = r.textFields.ReplaceAllStringFunc(body, func(match string) string {
+			groups := r.textFields.FindStringSubmatch(match)
+			return groups[1] + `"` + mask(groups[2]).(string) + `"`
+		})
+	}
+	return emailPattern.ReplaceAllString(body, masked+"@$1")
+}
+
+// mask hides a redacted value; an email keeps its domain, and empty and
+// null values stay as they are since they reveal nothing
+func mask(value any) any {
+	s, ok := value.(string)
+	switch {
+	case value == nil || (ok && s == ""):
+		return value
+	case ok:
+		if at := strings.LastIndex(s, "@"); at > 0 {
+			return masked + s[at:]
+		}
+	}
+	return masked
+}
@@ -0,0 +1,40 @@
+// Package postgres holds what the PostgreSQL repositories of the postgres
+// storage backend share, such as recognizing constraint violations.
+package postgres
+
+import (
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// SQLSTATE codes of the constraint violations repositories map to errors
+const (
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
+)
+
+// IsUniqueViolation reports whether err is a PostgreSQL unique constraint
+// violation
+func IsUniqueViolation(err error) bool {
+	return isViolation(err, uniqueViolation)
+}
+
+// IsUniqueViolationOf reports whether err is a violation of the named unique
+// constraint
+func IsUniqueViolationOf(err error, constraint string) bool {
+	var pgErr *pgconn.PgError
+	return IsUniqueViolation(err) && errors.As(err, &pgErr) && pgErr.ConstraintName == constraint
+}
+
+// IsForeignKeyViolation reports whether err is a PostgreSQL foreign key
+// violation
+func IsForeignKeyViolation(err error) bool {
+	return isViolation(err, foreignKeyViolation)
+}
+
+// isViolation reports whether err is a PostgreSQL error with code
+func isViolation(err error, code string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == code
+}
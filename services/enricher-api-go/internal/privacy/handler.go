@@ -0,0 +1,96 @@
+package privacy
+
+import (
+	"net/http"
+
+	"enricher-api-go/internal/problem"
+	"enricher-api-go/internal/servertiming"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Handler handles HTTP requests for customer data exports and erasures
+type Handler struct {
+	service Service
+}
+
+// NewHandler creates a new privacy handler
+func NewHandler(service Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+// ExportCustomer handles GET /v1/customers/:id/data-export
+//
+// It answers everything stored about the customer, soft-deleted or not:
+// the customer, its addresses, orders, loyalty account, merges and, when
+// event sourcing is on, its recorded changes.
+//
+// Error responses:
+//   - 404: Customer not found
+func (h *Handler) ExportCustomer(c echo.Context) error {
+	stop := servertiming.Start(c, "service")
+	export, err := h.service.Export(c.Request().Context(), c.Param("id"))
+	stop()
+	if err != nil {
+		return problem.Error(c, err)
+	}
+
+	return c.JSON(http.StatusOK, export)
+}
+
+// EraseCustomer handles POST /v1/customers/:id/erase
+//
+// A request without a confirmation token answers 202 with a token and what
+// the erasure will anonymize; repeating the request with the token before
+// it expires erases the customer.
+//
+// Example request:
+//
+//	POST /v1/customers/customer-456/erase
+//	Content-Type: application/json
+//
+//	{
+//		"confirmationToken": "1772370000.5d41402abc4b2a76b9719d911017c592..."
+//	}
+//
+// Example response:
+//
+//	{
+//		"customerId": "customer-456",
+//		"addressIds": ["address-1"],
+//		"orderIds": ["order-12345"],
+//		"mergeIds": [],
+//		"erasedAt": "2026-03-01T12:00:00Z"
+//	}
+//
+// Error responses:
+//   - 400: The token is invalid, expired, or the customer changed since it was issued
+//   - 404: Customer not found
+//   - 409: The customer still owes credit on open orders
+func (h *Handler) EraseCustomer(c echo.Context) error {
+	var req ErasureRequest
+	if err := servertiming.Measure(c, "bind", func() error { return c.Bind(&req) }); err != nil {
+		return problem.BindError(c, err)
+	}
+
+	ctx := c.Request().Context()
+	if req.ConfirmationToken == "" {
+		stop := servertiming.Start(c, "service")
+		confirmation, err := h.service.RequestErasure(ctx, c.Param("id"))
+		stop()
+		if err != nil {
+			return problem.Error(c, err)
+		}
+		return c.JSON(http.StatusAccepted, confirmation)
+	}
+
+	stop := servertiming.Start(c, "service")
+	erasure, err := h.service.Erase(ctx, c.Param("id"), req)
+	stop()
+	if err != nil {
+		return problem.Error(c, err)
+	}
+	return c.JSON(http.StatusOK, erasure)
+}
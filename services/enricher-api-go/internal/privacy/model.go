@@ -0,0 +1,98 @@
+// Package privacy answers data subject requests for the Resilient Order
+// Enricher API: it exports everything stored about a customer and erases a
+// customer's personal data.
+//
+// Erasure anonymizes rather than deletes. The customer, its addresses and
+// its orders keep their IDs, so historical enriched orders still refer to
+// records that exist, while the names, contact details and address lines
+// they held are replaced. An erasure must be confirmed with a short-lived
+// token issued for the customer, so a single mistaken call cannot erase
+// anyone.
+package privacy
+
+import (
+	"time"
+
+	"enricher-api-go/internal/customer"
+	"enricher-api-go/internal/loyalty"
+	"enricher-api-go/internal/merge"
+	"enricher-api-go/internal/order"
+)
+
+// Erased replaces the names and first address lines an erasure anonymizes
+const Erased = "ERASED"
+
+// Export is everything stored about a customer.
+//
+// Example usage:
+//
+//	export := &Export{
+//		Customer:  &customer.Customer{CustomerID: "customer-456", Name: "Jane Doe"},
+//		Addresses: []*customer.Address{},
+//		Orders:    []*order.Order{{OrderID: "order-12345", CustomerID: "customer-456"}},
+//	}
+type Export struct {
+	// Customer is the customer, soft-deleted or not
+	Customer *customer.Customer `json:"customer"`
+	// Addresses are the customer's addresses; soft-deleted customers have none
+	Addresses []*customer.Address `json:"addresses"`
+	// Orders are the customer's orders with their enrichment
+	Orders []*order.Order `json:"orders"`
+	// Loyalty is the customer's loyalty account, if it has one
+	Loyalty *loyalty.Account `json:"loyalty,omitempty"`
+	// Merges are the merges the customer took part in
+	Merges []*merge.Merge `json:"merges"`
+	// Events are the customer's recorded changes, oldest first, when event
+	// sourcing is on
+	Events []customer.Event `json:"events,omitempty"`
+	// ExportedAt is when the export was made
+	ExportedAt time.Time `json:"exportedAt"`
+}
+
+// ErasureRequest is the request body for erasing a customer. A request
+// without a confirmation token asks for one.
+//
+// Example usage:
+//
+//	request := ErasureRequest{
+//		ConfirmationToken: "1772370000.5d41402abc4b2a76b9719d911017c592",
+//	}
+type ErasureRequest struct {
+	// ConfirmationToken is the token issued for the customer by a previous
+	// request without one
+	ConfirmationToken string `json:"confirmationToken,omitempty"`
+}
+
+// ErasureConfirmation is issued for a requested erasure and lists what
+// confirming it will anonymize
+type ErasureConfirmation struct {
+	// CustomerID is the customer to erase
+	CustomerID string `json:"customerId"`
+	// ConfirmationToken confirms the erasure until ExpiresAt, as long as the
+	// customer does not change in the meantime
+	ConfirmationToken string `json:"confirmationToken"`
+	// ExpiresAt is when the token stops confirming the erasure
+	ExpiresAt time.Time `json:"expiresAt"`
+	// AddressIDs are the addresses the erasure will anonymize
+	AddressIDs []string `json:"addressIds"`
+	// OrderIDs are the enriched orders the erasure will anonymize
+	OrderIDs []string `json:"orderIds"`
+	// MergeIDs are the merges whose conflicts the erasure will redact
+	MergeIDs []string `json:"mergeIds"`
+}
+
+// Erasure records a customer's personal data erased
+type Erasure struct {
+	// CustomerID is the erased customer, now closed and soft-deleted
+	CustomerID string `json:"customerId"`
+	// AddressIDs are the addresses anonymized
+	AddressIDs []string `json:"addressIds"`
+	// OrderIDs are the enriched orders anonymized
+	OrderIDs []string `json:"orderIds"`
+	// MergeIDs are the merges whose conflicts were redacted
+	MergeIDs []string `json:"mergeIds"`
+	// ErasedAt is when the erasure was made
+	ErasedAt time.Time `json:"erasedAt"`
+	// ErasedBy is the authenticated caller that confirmed the erasure, if any
+	ErasedBy string `json:"erasedBy,omitempty"`
+}
@@ -0,0 +1,531 @@
+package privacy
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"enricher-api-go/internal/apperr"
+	"enricher-api-go/internal/auth"
+	"enricher-api-go/internal/clock"
+	"enricher-api-go/internal/customer"
+	"enricher-api-go/internal/enrichment"
+	"enricher-api-go/internal/logging"
+	"enricher-api-go/internal/loyalty"
+	"enricher-api-go/internal/merge"
+	"enricher-api-go/internal/order"
+	"enricher-api-go/internal/outbox"
+	"enricher-api-go/internal/unitofwork"
+	"enricher-api-go/internal/webhook"
+)
+
+var (
+	// ErrConfirmationInvalid is returned when erasing with a token that was
+	// not issued for the customer, or for a customer changed since
+	ErrConfirmationInvalid = apperr.New(apperr.ErrValidation, "invalid erasure confirmation token")
+	// ErrConfirmationExpired is returned when erasing with an expired token
+	ErrConfirmationExpired = apperr.New(apperr.ErrValidation, "erasure confirmation token has expired")
+	// ErrOpenExposure is returned when erasing a customer that still owes
+	// credit on open orders
+	ErrOpenExposure = apperr.New(apperr.ErrConflict, "customer has open orders holding credit")
+)
+
+// DefaultConfirmationTTL is how long an erasure may be confirmed unless
+// WithConfirmationTTL says otherwise
+const DefaultConfirmationTTL = 15 * time.Minute
+
+// personalConflictFields are the merge conflict fields holding personal data
+var personalConflictFields = map[string]bool{"name": true, "email": true, "phone": true}
+
+// Service defines the business logic interface for data subject requests
+type Service interface {
+	Export(ctx context.Context, customerID string) (*Export, error)
+	RequestErasure(ctx context.Context, customerID string) (*ErasureConfirmation, error)
+	Erase(ctx context.Context, customerID string, req ErasureRequest) (*Erasure, error)
+}
+
+// PrivacyService implements the Service interface
+type PrivacyService struct {
+	customers       customer.Repository
+	orders          order.Repository
+	points          loyalty.Repository
+	merges          merge.Repository
+	history         customer.HistorySource
+	historyRedactor customer.HistoryRedactor
+	events          outbox.Store
+	deliveries      webhook.Repository
+	unit            unitofwork.UnitOfWork
+	secret          []byte
+	confirmationTTL time.Duration
+	clock           clock.Clock
+}
+
+// Option configures optional PrivacyService behavior
+type Option func(*PrivacyService)
+
+// WithOrders sets the orders exported and anonymized with their customer.
+// Without it orders are left out.
+func WithOrders(orders order.Repository) Option {
+	return func(s *PrivacyService) {
+		s.orders = orders
+	}
+}
+
+// WithLoyalty sets the loyalty accounts exported with their customer
+func WithLoyalty(points loyalty.Repository) Option {
+	return func(s *PrivacyService) {
+		s.points = points
+	}
+}
+
+// WithMerges sets the merge records exported with their customers and
+// redacted when one is erased. Without it merges are left out.
+func WithMerges(merges merge.Repository) Option {
+	return func(s *PrivacyService) {
+		s.merges = merges
+	}
+}
+
+// WithHistory sets the source of the customer events an export includes
+func WithHistory(history customer.HistorySource) Option {
+	return func(s *PrivacyService) {
+		s.history = history
+	}
+}
+
+// WithHistoryRedactor sets the recorded customer events an erasure redacts
+func WithHistoryRedactor(redactor customer.HistoryRedactor) Option {
+	return func(s *PrivacyService) {
+		s.historyRedactor = redactor
+	}
+}
+
+// WithOutbox sets the outbox whose customer events, published or not, an
+// erasure redacts
+func WithOutbox(events outbox.Store) Option {
+	return func(s *PrivacyService) {
+		s.events = events
+	}
+}
+
+// WithDeliveries sets the webhook deliveries whose customer events an
+// erasure redacts
+func WithDeliveries(deliveries webhook.Repository) Option {
+	return func(s *PrivacyService) {
+		s.deliveries = deliveries
+	}
+}
+
+// WithConfirmationTTL sets how long an erasure may be confirmed
+// (DefaultConfirmationTTL by default)
+func WithConfirmationTTL(ttl time.Duration) Option {
+	return func(s *PrivacyService) {
+		s.confirmationTTL = ttl
+	}
+}
+
+// WithClock sets the clock used for exports, token expiry and the erased
+// records' UpdatedAt (the UTC wall clock by default)
+func WithClock(c clock.Clock) Option {
+	return func(s *PrivacyService) {
+		s.clock = c
+	}
+}
+
+// NewService creates a new privacy service for the customers in customers.
+// Erasure confirmation tokens are signed with secret, and the writes of an
+// erasure run as one unit of work of unit, so an erasure failing midway is
+// undone.
+func NewService(customers customer.Repository, unit unitofwork.UnitOfWork, secret []byte, opts ...Option) *PrivacyService {
+	s := &PrivacyService{
+		customers:       customers,
+		unit:            unit,
+		secret:          secret,
+		confirmationTTL: DefaultConfirmationTTL,
+		clock:           clock.System{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Export returns everything stored about a customer, soft-deleted or not
+func (s *PrivacyService) Export(ctx context.Context, customerID string) (*Export, error) {
+	logger := logging.FromContext(ctx)
+	logger.Info("Exporting customer data", "customer_id", customerID)
+
+	found, err := s.customers.GetByIDIncludingDeleted(customerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get customer: %w", err)
+	}
+
+	export := &Export{Customer: found, Orders: []*order.Order{}, Merges: []*merge.Merge{}, ExportedAt: s.clock.Now()}
+	if export.Addresses, err = s.customers.Addresses(customerID); err != nil {
+		return nil, s.exportFailed(ctx, customerID, fmt.Errorf("failed to list addresses: %w", err))
+	}
+	if s.orders != nil {
+		if export.Orders, err = s.orders.Find(order.OrderFilter{CustomerID: customerID}); err != nil {
+			return nil, s.exportFailed(ctx, customerID, fmt.Errorf("failed to list orders: %w", err))
+		}
+	}
+	if s.points != nil {
+		account, err := s.points.GetByCustomerID(customerID)
+		if err != nil && !errors.Is(err, loyalty.ErrAccountNotFound) {
+			return nil, s.exportFailed(ctx, customerID, fmt.Errorf("failed to get loyalty account: %w", err))
+		}
+		export.Loyalty = account
+	}
+	if s.merges != nil {
+		if export.Merges, err = s.merges.ListByCustomer(customerID); err != nil {
+			return nil, s.exportFailed(ctx, customerID, fmt.Errorf("failed to list merges: %w", err))
+		}
+	}
+	if s.history != nil {
+		history, err := s.history.History(customerID, export.ExportedAt)
+		if err != nil && !errors.Is(err, customer.ErrCustomerNotFound) {
+			return nil, s.exportFailed(ctx, customerID, fmt.Errorf("failed to replay customer history: %w", err))
+		}
+		if history != nil {
+			export.Events = history.Events
+		}
+	}
+
+	logger.Info("Exported customer data", "customer_id", customerID,
+		"addresses", len(export.Addresses), "orders", len(export.Orders), "merges", len(export.Merges))
+	return export, nil
+}
+
+// RequestErasure issues the token that confirms erasing a customer and
+// lists what the erasure will anonymize. The token expires after the
+// confirmation TTL and stops confirming the erasure as soon as the customer
+// changes.
+//
+// A customer that still owes credit on open orders cannot be erased and
+// fails with ErrOpenExposure.
+func (s *PrivacyService) RequestErasure(ctx context.Context, customerID string) (*ErasureConfirmation, error) {
+	logging.FromContext(ctx).Info("Requesting customer erasure", "customer_id", customerID)
+
+	found, err := s.erasable(customerID)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := s.plan(customerID)
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to plan customer erasure", "customer_id", customerID, "error", err)
+		return nil, err
+	}
+
+	expiresAt := s.clock.Now().Add(s.confirmationTTL).Truncate(time.Second)
+	return &ErasureConfirmation{
+		CustomerID:        customerID,
+		ConfirmationToken: s.sign(found, expiresAt),
+		ExpiresAt:         expiresAt,
+		AddressIDs:        plan.addressIDs(),
+		OrderIDs:          plan.orderIDs(),
+		MergeIDs:          plan.mergeIDs(),
+	}, nil
+}
+
+// Erase anonymizes the personal data of a customer once req confirms it
+// with a token from RequestErasure.
+//
+// The customer's name becomes Erased and its email, phone and segments are
+// cleared; it is closed and soft-deleted. Its addresses keep only their
+// country, with Erased as the first line. Its enriched orders keep their
+// items and totals while the customer name and shipping address they were
+// enriched with are anonymized the same way, and the names, emails and
+// phones recorded as merge conflicts are redacted. Every record keeps its
+// ID. Any failure undoes the writes made so far.
+//
+// Once those writes are stored, the copies of the customer's personal data
+// kept elsewhere are redacted the same way: its recorded events and
+// snapshots, and the customer events in the outbox and in webhook
+// deliveries. Redactions cannot be undone, so a failing one leaves the
+// customer erased and fails the call; erasing it again completes them.
+func (s *PrivacyService) Erase(ctx context.Context, customerID string, req ErasureRequest) (*Erasure, error) {
+	logger := logging.FromContext(ctx)
+	logger.Info("Erasing customer", "customer_id", customerID)
+
+	found, err := s.erasable(customerID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.verify(found, req.ConfirmationToken); err != nil {
+		return nil, err
+	}
+
+	erasure := &Erasure{CustomerID: customerID, ErasedAt: s.clock.Now(), ErasedBy: auth.Caller(ctx)}
+	err = s.unit.Do(ctx, func(ctx context.Context) error {
+		tx := unitofwork.FromContext(ctx)
+		// a soft-deleted customer's addresses are only reachable while it is live
+		if found.DeletedAt != nil {
+			if err := s.customers.Restore(customerID); err != nil {
+				return fmt.Errorf("failed to restore customer: %w", err)
+			}
+			tx.OnRollback(func() error { return s.customers.Delete(customerID) })
+		}
+
+		plan, err := s.plan(customerID)
+		if err != nil {
+			return err
+		}
+		if err := s.eraseProfile(tx, found, erasure); err != nil {
+			return err
+		}
+		if err := s.eraseAddresses(tx, plan.addresses, erasure); err != nil {
+			return err
+		}
+		if err := s.eraseOrders(tx, plan.orders, erasure); err != nil {
+			return err
+		}
+		if err := s.redactMerges(tx, plan.merges, erasure); err != nil {
+			return err
+		}
+
+		if err := s.customers.Delete(customerID); err != nil {
+			return fmt.Errorf("failed to delete customer: %w", err)
+		}
+		tx.OnRollback(func() error { return s.customers.Restore(customerID) })
+		return nil
+	})
+	if err != nil {
+		logger.Error("Failed to erase customer", "customer_id", customerID, "error", err)
+		return nil, err
+	}
+	if err := s.redactCopies(ctx, customerID); err != nil {
+		logger.Error("Failed to redact customer events", "customer_id", customerID, "error", err)
+		return nil, err
+	}
+
+	logger.Info("Erased customer", "customer_id", customerID,
+		"addresses", len(erasure.AddressIDs), "orders", len(erasure.OrderIDs), "merges", len(erasure.MergeIDs))
+	return erasure, nil
+}
+
+// erasable returns the customer, soft-deleted or not, if it can be erased
+func (s *PrivacyService) erasable(customerID string) (*customer.Customer, error) {
+	found, err := s.customers.GetByIDIncludingDeleted(customerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get customer: %w", err)
+	}
+	if found.CurrentExposure > 0 {
+		return nil, ErrOpenExposure
+	}
+	return found, nil
+}
+
+// erasurePlan holds the records an erasure anonymizes
+type erasurePlan struct {
+	addresses []*customer.Address
+	orders    []*order.Order
+	merges    []*merge.Merge
+}
+
+// plan lists the records erasing a customer anonymizes: its addresses, its
+// enriched orders and the merges it took part in
+func (s *PrivacyService) plan(customerID string) (*erasurePlan, error) {
+	plan := &erasurePlan{}
+	var err error
+	if plan.addresses, err = s.customers.Addresses(customerID); err != nil {
+		return nil, fmt.Errorf("failed to list addresses: %w", err)
+	}
+	if s.orders != nil {
+		orders, err := s.orders.Find(order.OrderFilter{CustomerID: customerID})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list orders: %w", err)
+		}
+		for _, found := range orders {
+			if found.Enrichment != nil {
+				plan.orders = append(plan.orders, found)
+			}
+		}
+	}
+	if s.merges != nil {
+		if plan.merges, err = s.merges.ListByCustomer(customerID); err != nil {
+			return nil, fmt.Errorf("failed to list merges: %w", err)
+		}
+	}
+	return plan, nil
+}
+
+// addressIDs returns the IDs of the planned addresses
+func (p *erasurePlan) addressIDs() []string {
+	ids := make([]string, 0, len(p.addresses))
+	for _, address := range p.addresses {
+		ids = append(ids, address.AddressID)
+	}
+	return ids
+}
+
+// orderIDs returns the IDs of the planned orders
+func (p *erasurePlan) orderIDs() []string {
+	ids := make([]string, 0, len(p.orders))
+	for _, found := range p.orders {
+		ids = append(ids, found.OrderID)
+	}
+	return ids
+}
+
+// mergeIDs returns the IDs of the planned merges
+func (p *erasurePlan) mergeIDs() []string {
+	ids := make([]string, 0, len(p.merges))
+	for _, found := range p.merges {
+		ids = append(ids, found.MergeID)
+	}
+	return ids
+}
+
+// eraseProfile anonymizes and closes the customer
+func (s *PrivacyService) eraseProfile(tx *unitofwork.Tx, original *customer.Customer, erasure *Erasure) error {
+	erased := *original
+	erased.Name, erased.Email, erased.Phone = Erased, "", ""
+	erased.Segments = nil
+	erased.Status = customer.StatusClosed
+	erased.DeletedAt = nil
+	erased.UpdatedAt, erased.UpdatedBy = erasure.ErasedAt, erasure.ErasedBy
+	if err := s.customers.Update(&erased); err != nil {
+		return fmt.Errorf("failed to erase customer: %w", err)
+	}
+
+	restored := *original
+	restored.DeletedAt = nil
+	tx.OnRollback(func() error { return s.customers.Update(&restored) })
+	return nil
+}
+
+// eraseAddresses anonymizes the customer's addresses, keeping their country
+func (s *PrivacyService) eraseAddresses(tx *unitofwork.Tx, addresses []*customer.Address, erasure *Erasure) error {
+	erasure.AddressIDs = make([]string, 0, len(addresses))
+	for _, original := range addresses {
+		erased := *original
+		erased.Line1, erased.Line2, erased.City, erased.Region, erased.PostalCode = Erased, "", "", "", ""
+		erased.UpdatedAt, erased.UpdatedBy = erasure.ErasedAt, erasure.ErasedBy
+		if err := s.customers.UpdateAddress(&erased); err != nil {
+			return fmt.Errorf("failed to erase address %s: %w", original.AddressID, err)
+		}
+		tx.OnRollback(func() error { return s.customers.UpdateAddress(original) })
+		erasure.AddressIDs = append(erasure.AddressIDs, original.AddressID)
+	}
+	return nil
+}
+
+// eraseOrders anonymizes the customer and shipping address the orders were
+// enriched with
+func (s *PrivacyService) eraseOrders(tx *unitofwork.Tx, orders []*order.Order, erasure *Erasure) error {
+	erasure.OrderIDs = make([]string, 0, len(orders))
+	for _, original := range orders {
+		enriched := *original.Enrichment
+		enriched.Customer.Name = Erased
+		if address := original.Enrichment.ShippingAddress; address != nil {
+			enriched.ShippingAddress = &enrichment.EnrichedAddress{AddressID: address.AddressID, Line1: Erased, Country: address.Country}
+		}
+
+		erased := *original
+		erased.Enrichment = &enriched
+		erased.UpdatedAt, erased.UpdatedBy = erasure.ErasedAt, erasure.ErasedBy
+		if err := s.orders.Update(&erased); err != nil {
+			return fmt.Errorf("failed to erase order %s: %w", original.OrderID, err)
+		}
+		tx.OnRollback(func() error { return s.orders.Update(original) })
+		erasure.OrderIDs = append(erasure.OrderIDs, original.OrderID)
+	}
+	return nil
+}
+
+// redactMerges clears the names, emails and phones recorded as conflicts
+// of the customer's merges
+func (s *PrivacyService) redactMerges(tx *unitofwork.Tx, merges []*merge.Merge, erasure *Erasure) error {
+	erasure.MergeIDs = make([]string, 0, len(merges))
+	for _, original := range merges {
+		redacted := make([]merge.Conflict, len(original.Conflicts))
+		for i, conflict := range original.Conflicts {
+			if personalConflictFields[conflict.Field] {
+				conflict.Kept, conflict.Discarded = nil, nil
+			}
+			redacted[i] = conflict
+		}
+		if err := s.merges.UpdateConflicts(original.MergeID, redacted); err != nil {
+			return fmt.Errorf("failed to redact merge %s: %w", original.MergeID, err)
+		}
+		tx.OnRollback(func() error { return s.merges.UpdateConflicts(original.MergeID, original.Conflicts) })
+		erasure.MergeIDs = append(erasure.MergeIDs, original.MergeID)
+	}
+	return nil
+}
+
+// redactCopies redacts the personal data of the customer in its recorded
+// events, the outbox and webhook deliveries
+func (s *PrivacyService) redactCopies(ctx context.Context, customerID string) error {
+	if s.historyRedactor != nil {
+		if err := s.historyRedactor.RedactHistory(customerID, Erased); err != nil {
+			return fmt.Errorf("failed to redact customer history: %w", err)
+		}
+	}
+	if s.events != nil {
+		if _, err := s.events.Redact(ctx, outbox.AggregateCustomer, customerID, redactCustomerData); err != nil {
+			return fmt.Errorf("failed to redact outbox events: %w", err)
+		}
+	}
+	if s.deliveries != nil {
+		if _, err := s.deliveries.RedactDeliveries(outbox.AggregateCustomer, customerID, redactCustomerData); err != nil {
+			return fmt.Errorf("failed to redact webhook deliveries: %w", err)
+		}
+	}
+	return nil
+}
+
+// redactCustomerData replaces the name of the customer a customer event
+// carries with Erased and clears its email and phone
+func redactCustomerData(data json.RawMessage) (json.RawMessage, error) {
+	var recorded customer.Customer
+	if err := json.Unmarshal(data, &recorded); err != nil {
+		return nil, fmt.Errorf("failed to decode customer: %w", err)
+	}
+	recorded.Name, recorded.Email, recorded.Phone = Erased, "", ""
+	return json.Marshal(&recorded)
+}
+
+// sign returns the token confirming the erasure of the customer as it is
+// now until expiresAt: the expiry in Unix seconds and an HMAC-SHA256 of the
+// customer ID, its last update and the expiry
+func (s *PrivacyService) sign(found *customer.Customer, expiresAt time.Time) string {
+	expiry := strconv.FormatInt(expiresAt.Unix(), 10)
+	return expiry + "." + s.mac(found, expiry)
+}
+
+// verify checks that token confirms erasing the customer as it is now
+func (s *PrivacyService) verify(found *customer.Customer, token string) error {
+	expiry, mac, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(mac), []byte(s.mac(found, expiry))) {
+		return ErrConfirmationInvalid
+	}
+	seconds, err := strconv.ParseInt(expiry, 10, 64)
+	if err != nil {
+		return ErrConfirmationInvalid
+	}
+	if !s.clock.Now().Before(time.Unix(seconds, 0)) {
+		return ErrConfirmationExpired
+	}
+	return nil
+}
+
+// mac returns the hex HMAC-SHA256 binding a token's expiry to the customer
+func (s *PrivacyService) mac(found *customer.Customer, expiry string) string {
+	h := hmac.New(sha256.New, s.secret)
+	fmt.Fprintf(h, "%s\n%d\n%s", found.CustomerID, found.UpdatedAt.UnixNano(), expiry)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// exportFailed logs a failed export and returns err
+func (s *PrivacyService) exportFailed(ctx context.Context, customerID string, err error) error {
+	logging.FromContext(ctx).Error("Failed to export customer data", "customer_id", customerID, "error", err)
+	return err
+}
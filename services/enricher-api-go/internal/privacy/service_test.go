@@ -0,0 +1,368 @@
+package privacy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"enricher-api-go/internal/clock"
+	"enricher-api-go/internal/customer"
+	"enricher-api-go/internal/enrichment"
+	"enricher-api-go/internal/loyalty"
+	"enricher-api-go/internal/merge"
+	"enricher-api-go/internal/order"
+	"enricher-api-go/internal/outbox"
+	"enricher-api-go/internal/unitofwork"
+	"enricher-api-go/internal/webhook"
+)
+
+var requestedAt = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// fixture holds the records of customer-456: an address, an enriched order
+// shipping to it, loyalty points and a merge with customer-123
+type fixture struct {
+	customers *customer.InMemoryRepository
+	orders    *order.InMemoryRepository
+	points    *loyalty.InMemoryRepository
+	merges    *merge.InMemoryRepository
+	// now is the time the service's clock reports
+	now     time.Time
+	service *PrivacyService
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	f := &fixture{
+		customers: customer.NewInMemoryRepositoryWith([]*customer.Customer{
+			{CustomerID: "customer-456", Name: "Jane Doe", Status: customer.StatusActive, Email: "jane.doe@example.com",
+				Phone: "+14155550123", CreditLimit: 1000, Segments: []string{"vip"}, CreatedAt: requestedAt.Add(-48 * time.Hour),
+				UpdatedAt: requestedAt.Add(-time.Hour)},
+		}),
+		orders: order.NewInMemoryRepository(),
+		points: loyalty.NewInMemoryRepository(),
+		merges: merge.NewInMemoryRepository(),
+		now:    requestedAt,
+	}
+	if err := f.customers.CreateAddress(&customer.Address{AddressID: "address-1", CustomerID: "customer-456", Type: customer.AddressShipping,
+		Line1: "1 Main St", City: "Springfield", Region: "IL", PostalCode: "12345", Country: "US", IsDefault: true}); err != nil {
+		t.Fatalf("Failed to create address: %v", err)
+	}
+	for _, created := range []*order.Order{
+		{OrderID: "order-1", CustomerID: "customer-456", Status: order.StatusEnriched, ShippingAddressID: "address-1",
+			Items: []order.Item{{ProductID: "product-789", Quantity: 1}},
+			Enrichment: &enrichment.EnrichedOrder{
+				Customer:        enrichment.EnrichedCustomer{CustomerID: "customer-456", Name: "Jane Doe", Status: customer.StatusActive},
+				ShippingAddress: &enrichment.EnrichedAddress{AddressID: "address-1", Line1: "1 Main St", City: "Springfield", PostalCode: "12345", Country: "US"},
+				Total:           49.99,
+			}},
+		{OrderID: "order-2", CustomerID: "customer-456", Status: order.StatusPending, Items: []order.Item{{ProductID: "product-789", Quantity: 1}}},
+	} {
+		if err := f.orders.Create(created); err != nil {
+			t.Fatalf("Failed to create order: %v", err)
+		}
+	}
+	if _, err := f.points.Adjust("customer-456", 350, requestedAt); err != nil {
+		t.Fatalf("Failed to accrue points: %v", err)
+	}
+	if err := f.merges.Create(&merge.Merge{MergeID: "merge-1", SurvivorID: "customer-456", DuplicateID: "customer-123", Strategy: merge.StrategySurvivor,
+		Conflicts: []merge.Conflict{{Field: "name", Kept: "Jane Doe", Discarded: "Jane Do"}, {Field: "creditLimit", Kept: 1000.0, Discarded: 500.0}},
+		MergedAt:  requestedAt.Add(-24 * time.Hour)}); err != nil {
+		t.Fatalf("Failed to record merge: %v", err)
+	}
+
+	f.service = NewService(f.customers, unitofwork.Compensating{}, []byte("test-erasure-secret"),
+		WithOrders(f.orders), WithLoyalty(f.points), WithMerges(f.merges), WithClock(f.clock()))
+	return f
+}
+
+// clock reports f.now
+func (f *fixture) clock() clock.Clock {
+	return clock.Func(func() time.Time { return f.now })
+}
+
+func TestPrivacyService_Export(t *testing.T) {
+	// Arrange
+	f := newFixture(t)
+
+	// Act
+	export, err := f.service.Export(context.Background(), "customer-456")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if export.Customer.Email != "jane.doe@example.com" || !export.ExportedAt.Equal(requestedAt) {
+		t.Errorf("Expected the customer exported now, got %+v at %v", export.Customer, export.ExportedAt)
+	}
+	if len(export.Addresses) != 1 || len(export.Orders) != 2 || len(export.Merges) != 1 {
+		t.Errorf("Expected 1 address, 2 orders and 1 merge, got %d, %d and %d", len(export.Addresses), len(export.Orders), len(export.Merges))
+	}
+	if export.Loyalty == nil || export.Loyalty.Points != 350 {
+		t.Errorf("Expected the loyalty account with 350 points, got %+v", export.Loyalty)
+	}
+}
+
+func TestPrivacyService_Export_UnknownCustomer(t *testing.T) {
+	// Arrange
+	f := newFixture(t)
+
+	// Act
+	_, err := f.service.Export(context.Background(), "customer-missing")
+
+	// Assert
+	if !errors.Is(err, customer.ErrCustomerNotFound) {
+		t.Errorf("Expected ErrCustomerNotFound, got %v", err)
+	}
+}
+
+func TestPrivacyService_Erase(t *testing.T) {
+	// Arrange
+	f := newFixture(t)
+	ctx := context.Background()
+	confirmation, err := f.service.RequestErasure(ctx, "customer-456")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Act
+	erasure, err := f.service.Erase(ctx, "customer-456", ErasureRequest{ConfirmationToken: confirmation.ConfirmationToken})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !slices.Equal(confirmation.OrderIDs, []string{"order-1"}) || !slices.Equal(erasure.OrderIDs, confirmation.OrderIDs) {
+		t.Errorf("Expected the enriched order planned and erased, got %v and %v", confirmation.OrderIDs, erasure.OrderIDs)
+	}
+	if !slices.Equal(erasure.AddressIDs, []string{"address-1"}) || !slices.Equal(erasure.MergeIDs, []string{"merge-1"}) {
+		t.Errorf("Expected address-1 and merge-1 erased, got %v and %v", erasure.AddressIDs, erasure.MergeIDs)
+	}
+
+	erased, err := f.customers.GetByIDIncludingDeleted("customer-456")
+	if err != nil {
+		t.Fatalf("Expected the customer kept, got %v", err)
+	}
+	if erased.Name != Erased || erased.Email != "" || erased.Phone != "" || len(erased.Segments) != 0 {
+		t.Errorf("Expected the customer's personal data erased, got %+v", erased)
+	}
+	if erased.Status != customer.StatusClosed || erased.DeletedAt == nil {
+		t.Errorf("Expected the customer closed and deleted, got %+v", erased)
+	}
+	if _, err := f.customers.GetByEmail("jane.doe@example.com"); !errors.Is(err, customer.ErrCustomerNotFound) {
+		t.Errorf("Expected the email released, got %v", err)
+	}
+
+	enriched, _ := f.orders.GetByID("order-1")
+	if enriched.CustomerID != "customer-456" || enriched.Enrichment.Customer.Name != Erased || enriched.Enrichment.Total != 49.99 {
+		t.Errorf("Expected the order kept with an anonymized customer, got %+v", enriched.Enrichment)
+	}
+	if address := enriched.Enrichment.ShippingAddress; address.AddressID != "address-1" || address.Line1 != Erased || address.City != "" || address.Country != "US" {
+		t.Errorf("Expected the shipping address anonymized but for its country, got %+v", address)
+	}
+
+	merges, _ := f.merges.ListByCustomer("customer-456")
+	if conflicts := merges[0].Conflicts; conflicts[0].Kept != nil || conflicts[0].Discarded != nil || conflicts[1].Kept != 1000.0 {
+		t.Errorf("Expected the name conflict redacted and the credit limit kept, got %+v", conflicts)
+	}
+}
+
+func TestPrivacyService_Erase_DeletedCustomer(t *testing.T) {
+	// Arrange
+	f := newFixture(t)
+	ctx := context.Background()
+	if err := f.customers.Delete("customer-456"); err != nil {
+		t.Fatalf("Failed to delete customer: %v", err)
+	}
+	confirmation, err := f.service.RequestErasure(ctx, "customer-456")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Act
+	erasure, err := f.service.Erase(ctx, "customer-456", ErasureRequest{ConfirmationToken: confirmation.ConfirmationToken})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !slices.Equal(erasure.AddressIDs, []string{"address-1"}) {
+		t.Errorf("Expected the deleted customer's address erased, got %v", erasure.AddressIDs)
+	}
+	if erased, _ := f.customers.GetByIDIncludingDeleted("customer-456"); erased.Name != Erased || erased.DeletedAt == nil {
+		t.Errorf("Expected the customer erased and still deleted, got %+v", erased)
+	}
+}
+
+func TestPrivacyService_Erase_RedactsRecordedEvents(t *testing.T) {
+	// Arrange: the customer's changes recorded as events, in the outbox and
+	// in a webhook delivery
+	f := newFixture(t)
+	ctx := context.Background()
+	events := outbox.NewInMemoryStore()
+	f.customers.RecordEventsTo(events)
+	eventSourced, err := customer.NewEventSourcedRepository(f.customers, customer.NewInMemoryEventStore(), 2)
+	if err != nil {
+		t.Fatalf("Failed to record customer events: %v", err)
+	}
+	updated, _ := eventSourced.GetByID("customer-456")
+	updated.Phone, updated.UpdatedAt = "+14155550199", requestedAt.Add(-time.Minute)
+	if err := eventSourced.Update(updated); err != nil {
+		t.Fatalf("Failed to update customer: %v", err)
+	}
+	deliveries := webhook.NewInMemoryRepository()
+	if err := deliveries.CreateSubscription(&webhook.Subscription{SubscriptionID: "whsub-1", URL: "https://example.com/hooks",
+		Events: []string{"customer.updated"}, Active: true}); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+	if _, err := events.PublishPending(ctx, 10, webhook.NewService(deliveries).Publish); err != nil {
+		t.Fatalf("Failed to queue deliveries: %v", err)
+	}
+
+	service := NewService(eventSourced, unitofwork.Compensating{}, []byte("test-erasure-secret"), WithHistory(eventSourced),
+		WithHistoryRedactor(eventSourced), WithOutbox(events), WithDeliveries(deliveries), WithClock(f.clock()))
+	customers := customer.NewService(eventSourced, customer.WithHistory(eventSourced), customer.WithClock(f.clock()))
+	confirmation, err := service.RequestErasure(ctx, "customer-456")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Act
+	_, err = service.Erase(ctx, "customer-456", ErasureRequest{ConfirmationToken: confirmation.ConfirmationToken})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	history, err := customers.CustomerHistory(ctx, "customer-456", nil)
+	if err != nil {
+		t.Fatalf("Expected the history kept, got %v", err)
+	}
+	asOf, err := customers.GetCustomerAsOf(ctx, "customer-456", requestedAt.Add(-30*time.Second))
+	if err != nil {
+		t.Fatalf("Expected the customer as it was before the erasure, got %v", err)
+	}
+	delivered, _ := deliveries.FindDeliveries(webhook.DeliveryFilter{})
+	for name, record := range map[string]any{
+		"history": history, "asOf": asOf, "outbox": events.Events(), "deliveries": delivered,
+	} {
+		encoded, _ := json.Marshal(record)
+		for _, personal := range []string{"Jane Doe", "jane.doe@example.com", "+14155550123", "+14155550199"} {
+			if strings.Contains(string(encoded), personal) {
+				t.Errorf("Expected %s to hold no personal data, found %q in %s", name, personal, encoded)
+			}
+		}
+	}
+	if asOf.Name != Erased || asOf.CreditLimit != 1000 {
+		t.Errorf("Expected the customer as of before the erasure redacted but otherwise kept, got %+v", asOf)
+	}
+	if len(delivered) == 0 {
+		t.Error("Expected the customer events delivered before the erasure kept")
+	}
+}
+
+func TestPrivacyService_Erase_Confirmation(t *testing.T) {
+	tests := []struct {
+		name        string
+		token       func(f *fixture, issued string) string
+		expectedErr error
+	}{
+		{name: "malformed", token: func(*fixture, string) string { return "not-a-token" }, expectedErr: ErrConfirmationInvalid},
+		{name: "forged", token: func(_ *fixture, issued string) string { return issued[:len(issued)-1] + "0" }, expectedErr: ErrConfirmationInvalid},
+		{name: "another secret", token: func(f *fixture, _ string) string {
+			other := NewService(f.customers, unitofwork.Compensating{}, []byte("another-secret"), WithClock(f.clock()))
+			confirmation, _ := other.RequestErasure(context.Background(), "customer-456")
+			return confirmation.ConfirmationToken
+		}, expectedErr: ErrConfirmationInvalid},
+		{name: "customer changed since", token: func(f *fixture, issued string) string {
+			changed, _ := f.customers.GetByID("customer-456")
+			changed.Phone, changed.UpdatedAt = "+14155550199", requestedAt
+			f.customers.Update(changed)
+			return issued
+		}, expectedErr: ErrConfirmationInvalid},
+		{name: "expired", token: func(f *fixture, issued string) string {
+			f.now = requestedAt.Add(DefaultConfirmationTTL)
+			return issued
+		}, expectedErr: ErrConfirmationExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			f := newFixture(t)
+			confirmation, err := f.service.RequestErasure(context.Background(), "customer-456")
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			token := tt.token(f, confirmation.ConfirmationToken)
+
+			// Act
+			_, err = f.service.Erase(context.Background(), "customer-456", ErasureRequest{ConfirmationToken: token})
+
+			// Assert
+			if !errors.Is(err, tt.expectedErr) {
+				t.Errorf("Expected %v, got %v", tt.expectedErr, err)
+			}
+			if kept, _ := f.customers.GetByID("customer-456"); kept == nil || kept.Name != "Jane Doe" {
+				t.Errorf("Expected the customer untouched, got %+v", kept)
+			}
+		})
+	}
+}
+
+func TestPrivacyService_RequestErasure_OpenExposure(t *testing.T) {
+	// Arrange
+	f := newFixture(t)
+	if _, err := f.customers.AdjustExposure("customer-456", customer.ExposureChange{Delta: 49.99}); err != nil {
+		t.Fatalf("Failed to reserve credit: %v", err)
+	}
+
+	// Act
+	_, err := f.service.RequestErasure(context.Background(), "customer-456")
+
+	// Assert
+	if !errors.Is(err, ErrOpenExposure) {
+		t.Errorf("Expected ErrOpenExposure, got %v", err)
+	}
+}
+
+// failingOrders fails every order update
+type failingOrders struct {
+	*order.InMemoryRepository
+}
+
+func (failingOrders) Update(*order.Order) error {
+	return errors.New("database is down")
+}
+
+func TestPrivacyService_Erase_UndoesFailedErasure(t *testing.T) {
+	// Arrange
+	f := newFixture(t)
+	service := NewService(f.customers, unitofwork.Compensating{}, []byte("test-erasure-secret"),
+		WithOrders(failingOrders{f.orders}), WithMerges(f.merges), WithClock(f.clock()))
+	confirmation, err := service.RequestErasure(context.Background(), "customer-456")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Act
+	_, err = service.Erase(context.Background(), "customer-456", ErasureRequest{ConfirmationToken: confirmation.ConfirmationToken})
+
+	// Assert
+	if err == nil {
+		t.Fatal("Expected the erasure to fail")
+	}
+	restored, err := f.customers.GetByID("customer-456")
+	if err != nil {
+		t.Fatalf("Expected the customer to stay live, got %v", err)
+	}
+	if restored.Name != "Jane Doe" || restored.Email != "jane.doe@example.com" || restored.Status != customer.StatusActive {
+		t.Errorf("Expected the customer's personal data restored, got %+v", restored)
+	}
+	if address, _ := f.customers.GetAddress("customer-456", "address-1"); address.Line1 != "1 Main St" {
+		t.Errorf("Expected the address restored, got %+v", address)
+	}
+}
@@ -0,0 +1,167 @@
+// Package problem answers failed requests with RFC 7807 problem details,
+// served as application/problem+json.
+//
+// Handlers answer domain errors with Error, which maps their apperr kind to
+// a status, or call Write for a status and detail of their own; failures
+// they do not expect go through ServerError, and request bodies that fail to
+// bind or validate through BindError. Installed as the Echo error handler,
+// HTTPErrorHandler answers errors returned by handlers and middleware the
+// same way.
+//
+// Example response:
+//
+//	HTTP/1.1 404 Not Found
+//	Content-Type: application/problem+json
+//
+//	{
+//		"type": "about:blank",
+//		"title": "Not Found",
+//		"status": 404,
+//		"detail": "Customer not found",
+//		"instance": "/v1/customers/customer-999",
+//		"traceId": "4bf92f3577b34da6a3ce929d0e0e4736"
+//	}
+package problem
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"enricher-api-go/internal/apperr"
+	"enricher-api-go/internal/logging"
+	"enricher-api-go/internal/validation"
+
+	"github.com/labstack/echo/v4"
+)
+
+// MIMEApplicationProblemJSON is the media type of problem responses
+const MIMEApplicationProblemJSON = "application/problem+json"
+
+// Problem types other than about:blank, whose title is the status text
+const (
+	// TypeValidation is a request body whose fields failed validation
+	TypeValidation = "/problems/validation"
+)
+
+// Problem describes why a request failed
+type Problem struct {
+	// Type identifies the kind of problem; about:blank when the status says
+	// it all
+	Type string `json:"type"`
+	// Title summarizes the kind of problem, the same for every occurrence
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	// Detail explains this occurrence
+	Detail string `json:"detail,omitempty"`
+	// Instance is the path of the failed request
+	Instance string `json:"instance,omitempty"`
+	// TraceID is the request ID, as logged and echoed in X-Request-ID
+	TraceID string `json:"traceId,omitempty"`
+	// Fields lists the fields that failed validation
+	Fields []validation.FieldError `json:"fields,omitempty"`
+}
+
+// New describes a failure of the request in c with status
+func New(c echo.Context, status int, detail string) *Problem {
+	return &Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   detail,
+		Instance: c.Request().URL.Path,
+		TraceID:  c.Response().Header().Get(echo.HeaderXRequestID),
+	}
+}
+
+// Write answers the request in c with a problem of the given status
+func Write(c echo.Context, status int, detail string) error {
+	return Send(c, New(c, status, detail))
+}
+
+// Send answers the request in c with p
+func Send(c echo.Context, p *Problem) error {
+	c.Response().Header().Set(echo.HeaderContentType, MIMEApplicationProblemJSON)
+	return c.JSON(p.Status, p)
+}
+
+// Error answers a failed request with the status of the error's kind: 400
+// for apperr.ErrValidation, listing the fields of a *validation.Error, 404
+// for apperr.ErrNotFound and 409 for apperr.ErrConflict. Errors of no such
+// kind are answered by ServerError.
+func Error(c echo.Context, err error) error {
+	var validationErr *validation.Error
+	if errors.As(err, &validationErr) {
+		return BindError(c, validationErr)
+	}
+
+	switch apperr.Kind(err) {
+	case apperr.ErrValidation:
+		return Write(c, http.StatusBadRequest, err.Error())
+	case apperr.ErrNotFound:
+		return Write(c, http.StatusNotFound, err.Error())
+	case apperr.ErrConflict:
+		return Write(c, http.StatusConflict, err.Error())
+	default:
+		return ServerError(c, err)
+	}
+}
+
+// ServerError answers an unexpected failure: 503 for apperr.ErrUnavailable,
+// such as an open circuit breaker, so clients back off and retry, 504 once
+// the request's deadline has passed and 500 otherwise
+func ServerError(c echo.Context, err error) error {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, apperr.ErrUnavailable):
+		status = http.StatusServiceUnavailable
+	case errors.Is(err, context.DeadlineExceeded):
+		status = http.StatusGatewayTimeout
+	}
+	return Write(c, status, err.Error())
+}
+
+// BindError answers a request body that failed to bind or validate.
+// Validation failures list every offending field so clients can correct
+// them in one round trip.
+//
+// Example response:
+//
+//	{
+//		"type": "/problems/validation",
+//		"title": "Validation failed",
+//		"status": 400,
+//		"fields": [{"field": "name", "rule": "min", "message": "must be at least 2 characters"}],
+//		...
+//	}
+func BindError(c echo.Context, err error) error {
+	var validationErr *validation.Error
+	if errors.As(err, &validationErr) {
+		p := New(c, http.StatusBadRequest, validationErr.Error())
+		p.Type, p.Title, p.Fields = TypeValidation, "Validation failed", validationErr.Fields
+		return Send(c, p)
+	}
+	return Write(c, http.StatusBadRequest, "Invalid request body")
+}
+
+// HTTPErrorHandler answers an error returned by a handler or middleware,
+// using the status and message of an *echo.HTTPError and Error otherwise
+func HTTPErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		detail, ok := httpErr.Message.(string)
+		if !ok {
+			detail = http.StatusText(httpErr.Code)
+		}
+		err = Write(c, httpErr.Code, detail)
+	} else {
+		err = Error(c, err)
+	}
+	if err != nil {
+		logging.FromContext(c.Request().Context()).Error("Failed to write error response", "error", err)
+	}
+}
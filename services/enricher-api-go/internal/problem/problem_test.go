@@ -0,0 +1,153 @@
+package problem
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"enricher-api-go/internal/apperr"
+	"enricher-api-go/internal/breaker"
+	"enricher-api-go/internal/validation"
+
+	"github.com/labstack/echo/v4"
+)
+
+// serve answers a GET of /orders/order-1 with handler, tagged with a request ID
+func serve(handler echo.HandlerFunc) (*httptest.ResponseRecorder, Problem) {
+	e := echo.New()
+	e.HTTPErrorHandler = HTTPErrorHandler
+	e.GET("/orders/:id", func(c echo.Context) error {
+		c.Response().Header().Set(echo.HeaderXRequestID, "req-1")
+		return handler(c)
+	})
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/orders/order-1", nil))
+
+	var p Problem
+	json.Unmarshal(rec.Body.Bytes(), &p)
+	return rec, p
+}
+
+func TestWrite(t *testing.T) {
+	// Act
+	rec, p := serve(func(c echo.Context) error {
+		return Write(c, http.StatusNotFound, "Order not found")
+	})
+
+	// Assert
+	if got := rec.Header().Get(echo.HeaderContentType); got != MIMEApplicationProblemJSON {
+		t.Errorf("Expected content type %s, got %s", MIMEApplicationProblemJSON, got)
+	}
+	expected := Problem{
+		Type:     "about:blank",
+		Title:    "Not Found",
+		Status:   http.StatusNotFound,
+		Detail:   "Order not found",
+		Instance: "/orders/order-1",
+		TraceID:  "req-1",
+	}
+	if rec.Code != http.StatusNotFound || fmt.Sprint(p) != fmt.Sprint(expected) {
+		t.Errorf("Expected %+v, got %d %+v", expected, rec.Code, p)
+	}
+}
+
+func TestError_MapsKinds(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+	}{
+		{name: "validation", err: apperr.New(apperr.ErrValidation, "quantity must be at least 1"), expectedStatus: http.StatusBadRequest},
+		{name: "field validation", err: &validation.Error{Fields: []validation.FieldError{{Field: "name"}}}, expectedStatus: http.StatusBadRequest},
+		{name: "not found", err: fmt.Errorf("failed to get order: %w", apperr.New(apperr.ErrNotFound, "order not found")), expectedStatus: http.StatusNotFound},
+		{name: "conflict", err: apperr.Errorf(apperr.ErrConflict, "order %s is already shipped", "order-1"), expectedStatus: http.StatusConflict},
+		{name: "unavailable", err: breaker.ErrOpen, expectedStatus: http.StatusServiceUnavailable},
+		{name: "no kind", err: errors.New("disk full"), expectedStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			rec, p := serve(func(c echo.Context) error { return Error(c, tt.err) })
+
+			// Assert
+			if rec.Code != tt.expectedStatus || p.Status != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d with %+v", tt.expectedStatus, rec.Code, p)
+			}
+		})
+	}
+}
+
+func TestServerError(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+	}{
+		{name: "unexpected", err: errors.New("disk full"), expectedStatus: http.StatusInternalServerError},
+		{name: "open breaker", err: fmt.Errorf("failed to get order: %w", breaker.ErrOpen), expectedStatus: http.StatusServiceUnavailable},
+		{name: "deadline", err: fmt.Errorf("failed to get order: %w", context.DeadlineExceeded), expectedStatus: http.StatusGatewayTimeout},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			rec, p := serve(func(c echo.Context) error { return ServerError(c, tt.err) })
+
+			// Assert
+			if rec.Code != tt.expectedStatus || p.Status != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d with %+v", tt.expectedStatus, rec.Code, p)
+			}
+			if p.Detail != tt.err.Error() {
+				t.Errorf("Expected detail %q, got %q", tt.err.Error(), p.Detail)
+			}
+		})
+	}
+}
+
+func TestBindError_ListsFields(t *testing.T) {
+	// Arrange
+	fields := []validation.FieldError{{Field: "name", Rule: "min", Message: "must be at least 2 characters"}}
+
+	// Act
+	rec, p := serve(func(c echo.Context) error {
+		return BindError(c, &validation.Error{Fields: fields})
+	})
+
+	// Assert
+	if rec.Code != http.StatusBadRequest || p.Type != TypeValidation || p.Title != "Validation failed" {
+		t.Errorf("Expected a 400 validation problem, got %d %+v", rec.Code, p)
+	}
+	if len(p.Fields) != 1 || p.Fields[0] != fields[0] {
+		t.Errorf("Expected fields %v, got %v", fields, p.Fields)
+	}
+}
+
+func TestHTTPErrorHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+		expectedDetail string
+	}{
+		{name: "http error", err: echo.NewHTTPError(http.StatusForbidden, "admin role required"), expectedStatus: http.StatusForbidden, expectedDetail: "admin role required"},
+		{name: "other error", err: errors.New("boom"), expectedStatus: http.StatusInternalServerError, expectedDetail: "boom"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			rec, p := serve(func(c echo.Context) error { return tt.err })
+
+			// Assert
+			if rec.Code != tt.expectedStatus || p.Detail != tt.expectedDetail {
+				t.Errorf("Expected %d with detail %q, got %d %+v", tt.expectedStatus, tt.expectedDetail, rec.Code, p)
+			}
+		})
+	}
+}
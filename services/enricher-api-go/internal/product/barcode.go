@@ -0,0 +1,61 @@
+package product
+
+import (
+	"fmt"
+	"strings"
+
+	"enricher-api-go/internal/apperr"
+)
+
+var (
+	// ErrInvalidBarcode is returned when a barcode is not a GTIN with a valid
+	// check digit
+	ErrInvalidBarcode = apperr.New(apperr.ErrValidation, "invalid barcode")
+	// ErrBarcodeExists is returned when storing a product whose barcode
+	// another live product already has
+	ErrBarcodeExists = apperr.New(apperr.ErrConflict, "barcode already in use")
+)
+
+// NormalizeBarcode trims a GTIN barcode (GTIN-8, UPC-A, EAN-13 or GTIN-14)
+// and checks its check digit. Barcodes are kept as scanned, so a UPC-A code
+// and the EAN-13 with a leading zero are different barcodes.
+//
+// Example usage:
+//
+//	code, err := NormalizeBarcode(" 4006381333931 ") // "4006381333931", nil
+//	_, err = NormalizeBarcode("4006381333932")     // ErrInvalidBarcode
+func NormalizeBarcode(code string) (string, error) {
+	code = strings.TrimSpace(code)
+	switch len(code) {
+	case 8, 12, 13, 14:
+	default:
+		return "", fmt.Errorf("%w: %q must have 8, 12, 13 or 14 digits", ErrInvalidBarcode, code)
+	}
+
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return "", fmt.Errorf("%w: %q must only contain digits", ErrInvalidBarcode, code)
+		}
+	}
+
+	last := len(code) - 1
+	if want := gtinCheckDigit(code[:last]); int(code[last]-'0') != want {
+		return "", fmt.Errorf("%w: %q should end in check digit %d", ErrInvalidBarcode, code, want)
+	}
+	return code, nil
+}
+
+// gtinCheckDigit returns the GTIN check digit of digits: weighting them 3
+// and 1 alternately from the right, the digit that rounds their sum up to
+// a multiple of 10
+func gtinCheckDigit(digits string) int {
+	sum := 0
+	for i := len(digits) - 1; i >= 0; i-- {
+		digit := int(digits[i] - '0')
+		if (len(digits)-1-i)%2 == 0 {
+			digit *= 3
+		}
+		sum += digit
+	}
+	return (10 - sum%10) % 10
+}
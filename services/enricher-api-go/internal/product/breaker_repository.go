@@ -0,0 +1,239 @@
+package product
+
+import (
+	"errors"
+	"time"
+
+	"enricher-api-go/internal/breaker"
+	"enricher-api-go/internal/pagination"
+	"enricher-api-go/internal/unitofwork"
+)
+
+// BreakerRepository guards another Repository with a circuit breaker.
+//
+// Storage failures count against the breaker, while domain outcomes such as
+// ErrProductNotFound are returned unchanged without tripping it. While the
+// breaker is open every call fails immediately with an error wrapping
+// breaker.ErrOpen.
+type BreakerRepository struct {
+	repo    Repository
+	breaker *breaker.Breaker
+}
+
+// NewBreakerRepository wraps repo with b, which is shared by every
+// repository using the same backend
+func NewBreakerRepository(repo Repository, b *breaker.Breaker) *BreakerRepository {
+	return &BreakerRepository{repo: repo, breaker: b}
+}
+
+// GetByID retrieves a live product by ID
+func (r *BreakerRepository) GetByID(productID string) (product *Product, err error) {
+	err = r.call(func() error {
+		product, err = r.repo.GetByID(productID)
+		return err
+	})
+	return product, err
+}
+
+// GetByIDIncludingDeleted retrieves a product by ID even if it is soft-deleted
+func (r *BreakerRepository) GetByIDIncludingDeleted(productID string) (product *Product, err error) {
+	err = r.call(func() error {
+		product, err = r.repo.GetByIDIncludingDeleted(productID)
+		return err
+	})
+	return product, err
+}
+
+// GetByIDs retrieves the live products with the given IDs
+func (r *BreakerRepository) GetByIDs(productIDs []string) (products []*Product, err error) {
+	err = r.call(func() error {
+		products, err = r.repo.GetByIDs(productIDs)
+		return err
+	})
+	return products, err
+}
+
+// Create adds a new product
+func (r *BreakerRepository) Create(product *Product) error {
+	return r.call(func() error { return r.repo.Create(product) })
+}
+
+// Update modifies an existing product
+func (r *BreakerRepository) Update(product *Product) error {
+	return r.call(func() error { return r.repo.Update(product) })
+}
+
+// WriteAll applies every write or none of them
+func (r *BreakerRepository) WriteAll(writes []Write) error {
+	return r.call(func() error { return r.repo.WriteAll(writes) })
+}
+
+// Delete soft-deletes a product
+func (r *BreakerRepository) Delete(productID string, now time.Time) error {
+	return r.call(func() error { return r.repo.Delete(productID, now) })
+}
+
+// Restore undoes a soft delete
+func (r *BreakerRepository) Restore(productID string, now time.Time) error {
+	return r.call(func() error { return r.repo.Restore(productID, now) })
+}
+
+// Deletions returns the intervals a product was soft-deleted
+func (r *BreakerRepository) Deletions(productID string) (deletions []*Deletion, err error) {
+	err = r.call(func() error {
+		deletions, err = r.repo.Deletions(productID)
+		return err
+	})
+	return deletions, err
+}
+
+// AdjustQuantity atomically changes a product's quantity
+func (r *BreakerRepository) AdjustQuantity(productID string, change StockChange) (product *Product, err error) {
+	err = r.call(func() error {
+		product, err = r.repo.AdjustQuantity(productID, change)
+		return err
+	})
+	return product, err
+}
+
+// adjustQuantityTx adjusts the quantity within the unit's database
+// transaction when the guarded repository can join it
+func (r *BreakerRepository) adjustQuantityTx(tx *unitofwork.Tx, productID string, change StockChange) (product *Product, joined bool, err error) {
+	repo, ok := r.repo.(txAdjuster)
+	if !ok {
+		return nil, false, nil
+	}
+	err = r.call(func() error {
+		product, joined, err = repo.adjustQuantityTx(tx, productID, change)
+		return err
+	})
+	return product, joined, err
+}
+
+// StockMovements returns a page of a product's stock movements
+func (r *BreakerRepository) StockMovements(productID string, page pagination.Params) (movements []*StockMovement, total int, err error) {
+	err = r.call(func() error {
+		movements, total, err = r.repo.StockMovements(productID, page)
+		return err
+	})
+	return movements, total, err
+}
+
+// RecordPriceChange adds a price change to a product's schedule
+func (r *BreakerRepository) RecordPriceChange(change *PriceChange) error {
+	return r.call(func() error { return r.repo.RecordPriceChange(change) })
+}
+
+// PriceChanges returns a product's price changes in effective order
+func (r *BreakerRepository) PriceChanges(productID string) (changes []*PriceChange, err error) {
+	err = r.call(func() error {
+		changes, err = r.repo.PriceChanges(productID)
+		return err
+	})
+	return changes, err
+}
+
+// EffectivePrices returns the price valid at at for each product with a price change
+func (r *BreakerRepository) EffectivePrices(productIDs []string, at time.Time) (prices map[string]float64, err error) {
+	err = r.call(func() error {
+		prices, err = r.repo.EffectivePrices(productIDs, at)
+		return err
+	})
+	return prices, err
+}
+
+// CancelPriceChange removes a price change that is not yet effective
+func (r *BreakerRepository) CancelPriceChange(productID string, changeID int64, now time.Time) error {
+	return r.call(func() error { return r.repo.CancelPriceChange(productID, changeID, now) })
+}
+
+// Variants returns a live product's variants
+func (r *BreakerRepository) Variants(productID string) (variants []*Variant, err error) {
+	err = r.call(func() error {
+		variants, err = r.repo.Variants(productID)
+		return err
+	})
+	return variants, err
+}
+
+// GetVariant retrieves a variant by SKU
+func (r *BreakerRepository) GetVariant(sku string) (variant *Variant, err error) {
+	err = r.call(func() error {
+		variant, err = r.repo.GetVariant(sku)
+		return err
+	})
+	return variant, err
+}
+
+// CreateVariant adds a variant to a live product
+func (r *BreakerRepository) CreateVariant(variant *Variant) error {
+	return r.call(func() error { return r.repo.CreateVariant(variant) })
+}
+
+// UpdateVariant replaces an existing variant
+func (r *BreakerRepository) UpdateVariant(variant *Variant) error {
+	return r.call(func() error { return r.repo.UpdateVariant(variant) })
+}
+
+// DeleteVariant removes a variant
+func (r *BreakerRepository) DeleteVariant(sku string) error {
+	return r.call(func() error { return r.repo.DeleteVariant(sku) })
+}
+
+// List returns all live products
+func (r *BreakerRepository) List() (products []*Product, err error) {
+	err = r.call(func() error {
+		products, err = r.repo.List()
+		return err
+	})
+	return products, err
+}
+
+// Find returns the products matching filter
+func (r *BreakerRepository) Find(filter ProductFilter) (products []*Product, err error) {
+	err = r.call(func() error {
+		products, err = r.repo.Find(filter)
+		return err
+	})
+	return products, err
+}
+
+// Count returns the number of products matching filter
+func (r *BreakerRepository) Count(filter ProductFilter) (count int, err error) {
+	err = r.call(func() error {
+		count, err = r.repo.Count(filter)
+		return err
+	})
+	return count, err
+}
+
+// call runs fn through the breaker, passing domain errors through without
+// counting them as failures
+func (r *BreakerRepository) call(fn func() error) error {
+	var domainErr error
+	err := r.breaker.Execute(func() error {
+		err := fn()
+		if isDomainError(err) {
+			domainErr = err
+			return nil
+		}
+		return err
+	})
+	if domainErr != nil {
+		return domainErr
+	}
+	return err
+}
+
+// isDomainError reports whether err is an expected repository outcome rather
+// than a storage failure
+func isDomainError(err error) bool {
+	return errors.Is(err, ErrProductNotFound) ||
+		errors.Is(err, ErrProductExists) ||
+		errors.Is(err, ErrProductNotDeleted) ||
+		errors.Is(err, ErrInsufficientStock) ||
+		errors.Is(err, ErrPriceChangeNotFound) ||
+		errors.Is(err, ErrPriceChangeEffective) ||
+		errors.Is(err, ErrVariantNotFound) ||
+		errors.Is(err, ErrVariantExists)
+}
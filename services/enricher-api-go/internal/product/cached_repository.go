@@ -0,0 +1,309 @@
+package product
+
+import (
+	"encoding/json"
+	"log/slog"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"enricher-api-go/internal/cache"
+	"enricher-api-go/internal/pagination"
+	"enricher-api-go/internal/unitofwork"
+)
+
+// CachedRepository serves live product lookups from a cache, reading through
+// to another Repository on a miss.
+//
+// GetByID and GetByIDs are cached; every other read goes to the wrapped
+// repository. Writes invalidate the product's entry after they succeed, so
+// a stale entry can only survive a racing read until its TTL expires. Cache
+// failures are logged and treated as misses. Concurrent misses for the same ID
+// share one read of the wrapped repository.
+type CachedRepository struct {
+	repo  Repository
+	store cache.Store
+	ttl   time.Duration
+	loads cache.Group[string, []byte]
+	// generation is part of every key; InvalidateAll moves to the next one
+	generation atomic.Int64
+}
+
+// NewCachedRepository wraps repo with a read-through cache whose entries are
+// served for ttl
+func NewCachedRepository(repo Repository, store cache.Store, ttl time.Duration) *CachedRepository {
+	return &CachedRepository{repo: repo, store: store, ttl: ttl}
+}
+
+// GetByID retrieves a live product by ID, from the cache when possible
+func (r *CachedRepository) GetByID(productID string) (*Product, error) {
+	if product, ok := r.cached(productID); ok {
+		return product, nil
+	}
+
+	// Concurrent misses share one read; callers that joined it decode their
+	// own copy so no two requests hold the same Product
+	var loaded *Product
+	data, err, shared := r.loads.Do(productID, func() ([]byte, error) {
+		product, err := r.repo.GetByID(productID)
+		if err != nil {
+			return nil, err
+		}
+		loaded = product
+		return r.fill(product), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !shared {
+		return loaded, nil
+	}
+	if product, ok := decode(data); ok {
+		return product, nil
+	}
+	return r.repo.GetByID(productID)
+}
+
+// GetByIDIncludingDeleted retrieves a product by ID even if it is soft-deleted
+func (r *CachedRepository) GetByIDIncludingDeleted(productID string) (*Product, error) {
+	return r.repo.GetByIDIncludingDeleted(productID)
+}
+
+// GetByIDs retrieves the live products with the given IDs, reading only the
+// cache misses from the wrapped repository. Products are returned in the
+// order of productIDs.
+func (r *CachedRepository) GetByIDs(productIDs []string) ([]*Product, error) {
+	found := make(map[string]*Product, len(productIDs))
+	var missing []string
+	for _, productID := range productIDs {
+		if product, ok := r.cached(productID); ok {
+			found[productID] = product
+		} else {
+			missing = append(missing, productID)
+		}
+	}
+
+	if len(missing) > 0 {
+		products, err := r.repo.GetByIDs(missing)
+		if err != nil {
+			return nil, err
+		}
+		for _, product := range products {
+			found[product.ProductID] = product
+			r.fill(product)
+		}
+	}
+
+	products := make([]*Product, 0, len(found))
+	for _, productID := range productIDs {
+		if product, ok := found[productID]; ok {
+			products = append(products, product)
+			delete(found, productID)
+		}
+	}
+	return products, nil
+}
+
+// Create adds a new product
+func (r *CachedRepository) Create(product *Product) error {
+	return r.repo.Create(product)
+}
+
+// Update modifies an existing product and invalidates its entry
+func (r *CachedRepository) Update(product *Product) error {
+	if err := r.repo.Update(product); err != nil {
+		return err
+	}
+	r.invalidate(product.ProductID)
+	return nil
+}
+
+// WriteAll applies every write or none of them and invalidates the entries
+// of updated products
+func (r *CachedRepository) WriteAll(writes []Write) error {
+	if err := r.repo.WriteAll(writes); err != nil {
+		return err
+	}
+	for _, write := range writes {
+		if !write.Create {
+			r.invalidate(write.Product.ProductID)
+		}
+	}
+	return nil
+}
+
+// Delete soft-deletes a product and invalidates its entry
+func (r *CachedRepository) Delete(productID string, now time.Time) error {
+	if err := r.repo.Delete(productID, now); err != nil {
+		return err
+	}
+	r.invalidate(productID)
+	return nil
+}
+
+// Restore undoes a soft delete and invalidates the product's entry
+func (r *CachedRepository) Restore(productID string, now time.Time) error {
+	if err := r.repo.Restore(productID, now); err != nil {
+		return err
+	}
+	r.invalidate(productID)
+	return nil
+}
+
+// Deletions returns the intervals a product was soft-deleted, uncached
+func (r *CachedRepository) Deletions(productID string) ([]*Deletion, error) {
+	return r.repo.Deletions(productID)
+}
+
+// AdjustQuantity atomically changes a product's quantity and invalidates its entry
+func (r *CachedRepository) AdjustQuantity(productID string, change StockChange) (*Product, error) {
+	product, err := r.repo.AdjustQuantity(productID, change)
+	if err != nil {
+		return nil, err
+	}
+	r.invalidate(productID)
+	return product, nil
+}
+
+// adjustQuantityTx adjusts the quantity within the unit's database
+// transaction when the wrapped repository can join it, invalidating the
+// product's entry once the unit is committed
+func (r *CachedRepository) adjustQuantityTx(tx *unitofwork.Tx, productID string, change StockChange) (*Product, bool, error) {
+	repo, ok := r.repo.(txAdjuster)
+	if !ok {
+		return nil, false, nil
+	}
+	product, joined, err := repo.adjustQuantityTx(tx, productID, change)
+	if joined && err == nil {
+		tx.OnCommit(func() { r.invalidate(productID) })
+	}
+	return product, joined, err
+}
+
+// StockMovements returns a page of a product's stock movements, uncached
+func (r *CachedRepository) StockMovements(productID string, page pagination.Params) ([]*StockMovement, int, error) {
+	return r.repo.StockMovements(productID, page)
+}
+
+// RecordPriceChange adds a price change to a product's schedule. Cached
+// products keep their stored price, which the schedule does not change.
+func (r *CachedRepository) RecordPriceChange(change *PriceChange) error {
+	return r.repo.RecordPriceChange(change)
+}
+
+// PriceChanges returns a product's price changes, uncached
+func (r *CachedRepository) PriceChanges(productID string) ([]*PriceChange, error) {
+	return r.repo.PriceChanges(productID)
+}
+
+// EffectivePrices returns the price valid at at for each product, uncached
+func (r *CachedRepository) EffectivePrices(productIDs []string, at time.Time) (map[string]float64, error) {
+	return r.repo.EffectivePrices(productIDs, at)
+}
+
+// CancelPriceChange removes a price change that is not yet effective
+func (r *CachedRepository) CancelPriceChange(productID string, changeID int64, now time.Time) error {
+	return r.repo.CancelPriceChange(productID, changeID, now)
+}
+
+// Variants returns a live product's variants
+func (r *CachedRepository) Variants(productID string) ([]*Variant, error) {
+	return r.repo.Variants(productID)
+}
+
+// GetVariant retrieves a variant by SKU; variants are not cached
+func (r *CachedRepository) GetVariant(sku string) (*Variant, error) {
+	return r.repo.GetVariant(sku)
+}
+
+// CreateVariant adds a variant to a live product
+func (r *CachedRepository) CreateVariant(variant *Variant) error {
+	return r.repo.CreateVariant(variant)
+}
+
+// UpdateVariant replaces an existing variant
+func (r *CachedRepository) UpdateVariant(variant *Variant) error {
+	return r.repo.UpdateVariant(variant)
+}
+
+// DeleteVariant removes a variant
+func (r *CachedRepository) DeleteVariant(sku string) error {
+	return r.repo.DeleteVariant(sku)
+}
+
+// List returns all live products
+func (r *CachedRepository) List() ([]*Product, error) {
+	return r.repo.List()
+}
+
+// Find returns the products matching filter
+func (r *CachedRepository) Find(filter ProductFilter) ([]*Product, error) {
+	return r.repo.Find(filter)
+}
+
+// Count returns the number of products matching filter
+func (r *CachedRepository) Count(filter ProductFilter) (int, error) {
+	return r.repo.Count(filter)
+}
+
+// InvalidateAll drops every cached product, such as after the wrapped
+// products were reset. The entries are left to expire under keys no longer
+// read, so it works on any store; a read in flight may still cache what it
+// read.
+func (r *CachedRepository) InvalidateAll() {
+	r.generation.Add(1)
+}
+
+// cached returns the cached product, if any
+func (r *CachedRepository) cached(productID string) (*Product, bool) {
+	data, ok, err := r.store.Get(r.key(productID))
+	if err != nil {
+		slog.Warn("Failed to read product from cache", "product_id", productID, "error", err)
+		return nil, false
+	}
+	if !ok {
+		return nil, false
+	}
+
+	product, ok := decode(data)
+	if !ok {
+		slog.Warn("Discarding undecodable cached product", "product_id", productID)
+	}
+	return product, ok
+}
+
+// fill caches product, returning its serialized form or nil if it cannot be encoded
+func (r *CachedRepository) fill(product *Product) []byte {
+	data, err := json.Marshal(product)
+	if err != nil {
+		slog.Warn("Failed to encode product for the cache", "product_id", product.ProductID, "error", err)
+		return nil
+	}
+	if err := r.store.Set(r.key(product.ProductID), data, r.ttl); err != nil {
+		slog.Warn("Failed to cache product", "product_id", product.ProductID, "error", err)
+	}
+	return data
+}
+
+// invalidate drops the cached product and detaches any read in flight for it
+func (r *CachedRepository) invalidate(productID string) {
+	r.loads.Forget(productID)
+	if err := r.store.Delete(r.key(productID)); err != nil {
+		slog.Warn("Failed to invalidate cached product", "product_id", productID, "error", err)
+	}
+}
+
+// decode parses a cached product
+func decode(data []byte) (*Product, bool) {
+	var product Product
+	if err := json.Unmarshal(data, &product); err != nil {
+		return nil, false
+	}
+	return &product, true
+}
+
+// key namespaces product IDs in a store shared with customers, under the
+// current generation
+func (r *CachedRepository) key(productID string) string {
+	return "product:" + strconv.FormatInt(r.generation.Load(), 10) + ":" + productID
+}
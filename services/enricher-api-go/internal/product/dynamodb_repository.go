@@ -0,0 +1,772 @@
+package product
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"enricher-api-go/internal/dynamo"
+	"enricher-api-go/internal/pagination"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Item layout of products in the single DynamoDB table: a product, its stock
+// movements, price changes, deletions and variants share the partition PRODUCT#<id>,
+// and each SKU is claimed by a SKU#<sku> item naming its product, which
+// makes SKUs unique across products.
+const (
+	dynamoProductSK   = "PRODUCT"
+	dynamoMovementSK  = "MOVEMENT#"
+	dynamoPriceSK     = "PRICE#"
+	dynamoDeletionSK  = "DELETION#"
+	dynamoVariantSK   = "VARIANT#"
+	dynamoSKUSK       = "SKU"
+	dynamoOwnerAttr   = "productId"
+	dynamoMovements   = "movements"
+	dynamoDeletions   = "deletions"
+	dynamoProduct     = "product"
+	dynamoMovement    = "movement"
+	dynamoPriceChange = "price_change"
+	dynamoDeletion    = "deletion"
+	dynamoVariant     = "variant"
+	dynamoSKUClaim    = "sku"
+	dynamoMaxAttempts = 5
+)
+
+// DynamoDBMaxBulkSize is the most writes a WriteAll on DynamoDB can hold: a
+// write stores up to three items (the product, a stock movement and a price
+// change) and a transaction takes at most dynamo.MaxTransactWrites
+const DynamoDBMaxBulkSize = dynamo.MaxTransactWrites / 3
+
+// DynamoDBRepository implements Repository on a single DynamoDB table. It
+// does not record change events.
+//
+// Writes are optimistic: they condition on the version of the product they
+// read and start over when it changed in between. Stock movements are
+// numbered by a counter on their product, so they are written in order.
+// Listing products scans the table.
+type DynamoDBRepository struct {
+	table *dynamo.Table
+}
+
+// NewDynamoDBRepository creates a product repository storing its items in table
+func NewDynamoDBRepository(table *dynamo.Table) *DynamoDBRepository {
+	return &DynamoDBRepository{table: table}
+}
+
+// GetByID retrieves a live product by ID
+func (r *DynamoDBRepository) GetByID(productID string) (*Product, error) {
+	product, err := r.GetByIDIncludingDeleted(productID)
+	if err != nil {
+		return nil, err
+	}
+	if product.IsDeleted() {
+		return nil, ErrProductNotFound
+	}
+	return product, nil
+}
+
+// GetByIDIncludingDeleted retrieves a product by ID even if it is soft-deleted
+func (r *DynamoDBRepository) GetByIDIncludingDeleted(productID string) (*Product, error) {
+	staged, err := r.load(context.Background(), productID)
+	if err != nil {
+		return nil, err
+	}
+	if staged.product == nil {
+		return nil, ErrProductNotFound
+	}
+	return staged.product, nil
+}
+
+// GetByIDs retrieves the live products with the given IDs, skipping unknown ones
+func (r *DynamoDBRepository) GetByIDs(productIDs []string) ([]*Product, error) {
+	keys := make([]dynamo.Key, 0, len(productIDs))
+	for _, productID := range slices.Compact(slices.Sorted(slices.Values(productIDs))) {
+		keys = append(keys, dynamo.Key{PK: productPK(productID), SK: dynamoProductSK})
+	}
+
+	items, err := r.table.BatchGet(context.Background(), keys)
+	if err != nil {
+		return nil, err
+	}
+	products, err := decodeProducts(items, ProductFilter{})
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(products, ProductFilter{}.Compare)
+	return products, nil
+}
+
+// Create adds a new product
+func (r *DynamoDBRepository) Create(product *Product) error {
+	return unwrapWrite(r.WriteAll([]Write{{Product: product, Create: true}}))
+}
+
+// Update modifies an existing product
+func (r *DynamoDBRepository) Update(product *Product) error {
+	return unwrapWrite(r.WriteAll([]Write{{Product: product}}))
+}
+
+// unwrapWrite returns the error of the single write in a WriteAll
+func unwrapWrite(err error) error {
+	var writeErr *WriteError
+	if errors.As(err, &writeErr) {
+		return writeErr.Err
+	}
+	return err
+}
+
+// WriteAll applies every write in one transaction.
+//
+// The writes are first applied in order to the products they touch, as read
+// from the table, so a failing write is reported without storing anything;
+// the final state is then written on the condition that none of it changed
+// since. It holds at most DynamoDBMaxBulkSize writes.
+func (r *DynamoDBRepository) WriteAll(writes []Write) error {
+	return dynamo.Retry(dynamoMaxAttempts, func() error {
+		return r.writeAll(context.Background(), writes)
+	})
+}
+
+// stagedProduct is a product as read from the table and as changed since
+type stagedProduct struct {
+	product *Product
+	version int64
+	// movements counts the product's stock movements, numbering the next one
+	movements int64
+	// deletions counts the product's deletions, numbering the next one
+	deletions int64
+	// deletion is the product's latest deletion when it was opened or
+	// closed since
+	deletion *Deletion
+	// prices holds the product's price changes in recording order
+	prices []*PriceChange
+	// added holds the movements and price changes to write with the product
+	addedMovements []*StockMovement
+	addedPrices    []*PriceChange
+}
+
+// move records a movement of a non-zero delta
+func (s *stagedProduct) move(delta int, reason, actor string, at time.Time) {
+	if delta == 0 {
+		return
+	}
+	s.addedMovements = append(s.addedMovements, &StockMovement{
+		ProductID: s.product.ProductID,
+		Delta:     delta,
+		Quantity:  s.product.Quantity,
+		Reason:    reason,
+		Actor:     actor,
+		CreatedAt: at,
+	})
+}
+
+// reprice records a price change effective at at
+func (s *stagedProduct) reprice(price float64, actor string, at time.Time) {
+	change := &PriceChange{
+		ProductID:   s.product.ProductID,
+		Price:       price,
+		EffectiveAt: at,
+		CreatedAt:   at,
+		CreatedBy:   actor,
+	}
+	s.prices = append(s.prices, change)
+	s.addedPrices = append(s.addedPrices, change)
+}
+
+// items builds the writes storing the staged product and what was added
+// to it, assigning IDs to the added price changes
+func (r *DynamoDBRepository) items(ctx context.Context, s *stagedProduct) ([]types.TransactWriteItem, error) {
+	var writes []types.TransactWriteItem
+	for _, change := range s.addedPrices {
+		id, err := r.table.NextID(ctx, "price_changes")
+		if err != nil {
+			return nil, err
+		}
+		change.ID = id
+
+		item, err := priceChangeItem(change)
+		if err != nil {
+			return nil, err
+		}
+		writes = append(writes, r.table.PutNew(item))
+	}
+	for _, movement := range s.addedMovements {
+		s.movements++
+		item, err := dynamo.NewItem(productPK(movement.ProductID), movementSK(s.movements), dynamoMovement, movement)
+		if err != nil {
+			return nil, err
+		}
+		writes = append(writes, r.table.PutNew(item))
+	}
+
+	if s.deletion != nil {
+		item, err := dynamo.NewItem(productPK(s.product.ProductID), deletionSK(s.deletions), dynamoDeletion, s.deletion)
+		if err != nil {
+			return nil, err
+		}
+		writes = append(writes, r.table.Upsert(item))
+	}
+
+	item, err := dynamo.NewItem(productPK(s.product.ProductID), dynamoProductSK, dynamoProduct, s.product)
+	if err != nil {
+		return nil, err
+	}
+	if s.product.IsDeleted() {
+		item.MarkDeleted()
+	}
+	item.SetInt(dynamoMovements, s.movements)
+	item.SetInt(dynamoDeletions, s.deletions)
+	return append(writes, r.table.Put(item, s.version)), nil
+}
+
+func (r *DynamoDBRepository) writeAll(ctx context.Context, writes []Write) error {
+	staged := make(map[string]*stagedProduct)
+	var productIDs []string
+
+	for i, write := range writes {
+		productID := write.Product.ProductID
+		s, ok := staged[productID]
+		if !ok {
+			var err error
+			if s, err = r.load(ctx, productID); err != nil {
+				return err
+			}
+			if s.product != nil {
+				if s.prices, err = r.priceChanges(ctx, productID); err != nil {
+					return err
+				}
+			}
+			staged[productID] = s
+			productIDs = append(productIDs, productID)
+		}
+		current := s.product
+
+		next := *write.Product
+		s.product = &next
+		if write.Create {
+			if current != nil {
+				return &WriteError{Index: i, Err: ErrProductExists}
+			}
+			s.move(next.Quantity, ReasonCreate, next.CreatedBy, next.CreatedAt)
+			s.reprice(next.Price, next.CreatedBy, next.CreatedAt)
+			continue
+		}
+
+		if current == nil || current.IsDeleted() {
+			return &WriteError{Index: i, Err: ErrProductNotFound}
+		}
+		s.move(next.Quantity-current.Quantity, ReasonUpdate, next.UpdatedBy, next.UpdatedAt)
+		previousPrice := current.Price
+		if latest := latestPriceChange(s.prices, next.UpdatedAt); latest != nil {
+			previousPrice = latest.Price
+		}
+		if next.Price != previousPrice {
+			s.reprice(next.Price, next.UpdatedBy, next.UpdatedAt)
+		}
+	}
+
+	var items []types.TransactWriteItem
+	for _, productID := range productIDs {
+		productItems, err := r.items(ctx, staged[productID])
+		if err != nil {
+			return err
+		}
+		items = append(items, productItems...)
+	}
+	return r.transact(ctx, items)
+}
+
+// Delete soft-deletes a product by stamping DeletedAt with now
+func (r *DynamoDBRepository) Delete(productID string, now time.Time) error {
+	_, err := r.change(productID, func(s *stagedProduct) error {
+		if s.product.IsDeleted() {
+			return ErrProductNotFound
+		}
+		deletedAt := now.UTC()
+		s.product.DeletedAt = &deletedAt
+		s.deletions++
+		s.deletion = &Deletion{DeletedAt: deletedAt}
+		return nil
+	})
+	return err
+}
+
+// Restore clears DeletedAt on a soft-deleted product and closes its deletion
+// at now
+func (r *DynamoDBRepository) Restore(productID string, now time.Time) error {
+	_, err := r.change(productID, func(s *stagedProduct) error {
+		if !s.product.IsDeleted() {
+			return ErrProductNotDeleted
+		}
+		if s.deletions == 0 {
+			// Deleted before deletions were recorded
+			s.deletions++
+		}
+		restoredAt := now.UTC()
+		s.deletion = &Deletion{DeletedAt: *s.product.DeletedAt, RestoredAt: &restoredAt}
+		s.product.DeletedAt = nil
+		return nil
+	})
+	return err
+}
+
+// Deletions returns the intervals a product was soft-deleted, oldest first
+func (r *DynamoDBRepository) Deletions(productID string) ([]*Deletion, error) {
+	if _, err := r.GetByIDIncludingDeleted(productID); err != nil {
+		return nil, err
+	}
+
+	items, err := r.table.Query(context.Background(), productPK(productID), dynamoDeletionSK)
+	if err != nil {
+		return nil, err
+	}
+
+	deletions := make([]*Deletion, 0, len(items))
+	for _, item := range items {
+		var deletion Deletion
+		if err := item.Decode(&deletion); err != nil {
+			return nil, err
+		}
+		deletions = append(deletions, &deletion)
+	}
+	return deletions, nil
+}
+
+// AdjustQuantity adds change.Delta to a live product's quantity on the
+// condition that the product did not change since it was read
+func (r *DynamoDBRepository) AdjustQuantity(productID string, change StockChange) (*Product, error) {
+	return r.change(productID, func(s *stagedProduct) error {
+		if s.product.IsDeleted() {
+			return ErrProductNotFound
+		}
+		if s.product.Quantity+change.Delta < 0 {
+			return ErrInsufficientStock
+		}
+
+		s.product.Quantity += change.Delta
+		s.product.UpdatedAt, s.product.UpdatedBy = change.UpdatedAt, change.UpdatedBy
+		s.move(change.Delta, change.Reason, change.UpdatedBy, change.UpdatedAt)
+		return nil
+	})
+}
+
+// change reads a product, lets update modify it and writes it back on the
+// condition that it did not change in between, starting over if it did
+func (r *DynamoDBRepository) change(productID string, update func(s *stagedProduct) error) (*Product, error) {
+	ctx := context.Background()
+
+	var changed *Product
+	err := dynamo.Retry(dynamoMaxAttempts, func() error {
+		s, err := r.load(ctx, productID)
+		if err != nil {
+			return err
+		}
+		if s.product == nil {
+			return ErrProductNotFound
+		}
+		if err := update(s); err != nil {
+			return err
+		}
+
+		items, err := r.items(ctx, s)
+		if err != nil {
+			return err
+		}
+		changed = s.product
+		return r.transact(ctx, items)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return changed, nil
+}
+
+// StockMovements returns a page of a live product's stock movements, newest first
+func (r *DynamoDBRepository) StockMovements(productID string, page pagination.Params) ([]*StockMovement, int, error) {
+	if _, err := r.GetByID(productID); err != nil {
+		return nil, 0, err
+	}
+
+	items, err := r.table.Query(context.Background(), productPK(productID), dynamoMovementSK)
+	if err != nil {
+		return nil, 0, err
+	}
+	movements := make([]*StockMovement, 0, len(items))
+	for _, item := range slices.Backward(items) {
+		var movement StockMovement
+		if err := item.Decode(&movement); err != nil {
+			return nil, 0, err
+		}
+		movements = append(movements, &movement)
+	}
+
+	return paginate(movements, page.Limit, page.Offset), len(movements), nil
+}
+
+// RecordPriceChange adds a price change to a live product's schedule,
+// assigning its ID
+func (r *DynamoDBRepository) RecordPriceChange(change *PriceChange) error {
+	ctx := context.Background()
+
+	id, err := r.table.NextID(ctx, "price_changes")
+	if err != nil {
+		return err
+	}
+	change.ID = id
+
+	item, err := priceChangeItem(change)
+	if err != nil {
+		return err
+	}
+	err = r.table.Transact(ctx, []types.TransactWriteItem{
+		r.table.CheckLive(productPK(change.ProductID), dynamoProductSK),
+		r.table.PutNew(item),
+	})
+	var canceled *dynamo.CanceledError
+	if errors.As(err, &canceled) {
+		return ErrProductNotFound
+	}
+	return err
+}
+
+// PriceChanges returns a live product's price changes in effective order
+func (r *DynamoDBRepository) PriceChanges(productID string) ([]*PriceChange, error) {
+	if _, err := r.GetByID(productID); err != nil {
+		return nil, err
+	}
+
+	changes, err := r.priceChanges(context.Background(), productID)
+	if err != nil {
+		return nil, err
+	}
+	slices.SortStableFunc(changes, func(a, b *PriceChange) int {
+		return a.EffectiveAt.Compare(b.EffectiveAt)
+	})
+	return changes, nil
+}
+
+// EffectivePrices returns the price valid at at for each product that has a
+// price change effective by then
+func (r *DynamoDBRepository) EffectivePrices(productIDs []string, at time.Time) (map[string]float64, error) {
+	prices := make(map[string]float64, len(productIDs))
+	for _, productID := range productIDs {
+		changes, err := r.priceChanges(context.Background(), productID)
+		if err != nil {
+			return nil, err
+		}
+		if latest := latestPriceChange(changes, at); latest != nil {
+			prices[productID] = latest.Price
+		}
+	}
+	return prices, nil
+}
+
+// CancelPriceChange removes a price change that is not yet effective at now
+func (r *DynamoDBRepository) CancelPriceChange(productID string, changeID int64, now time.Time) error {
+	ctx := context.Background()
+
+	if _, err := r.GetByID(productID); err != nil {
+		return err
+	}
+	item, err := r.table.Get(ctx, productPK(productID), priceChangeSK(changeID))
+	if err != nil {
+		return err
+	}
+	if item == nil {
+		return ErrPriceChangeNotFound
+	}
+	var change PriceChange
+	if err := item.Decode(&change); err != nil {
+		return err
+	}
+	if !change.EffectiveAt.After(now) {
+		return ErrPriceChangeEffective
+	}
+
+	err = r.table.Transact(ctx, []types.TransactWriteItem{
+		r.table.CheckLive(productPK(productID), dynamoProductSK),
+		r.table.Delete(productPK(productID), priceChangeSK(changeID)),
+	})
+	var canceled *dynamo.CanceledError
+	if errors.As(err, &canceled) {
+		if canceled.Failed(0) {
+			return ErrProductNotFound
+		}
+		return ErrPriceChangeNotFound
+	}
+	return err
+}
+
+// Variants returns a live product's variants ordered by SKU
+func (r *DynamoDBRepository) Variants(productID string) ([]*Variant, error) {
+	if _, err := r.GetByID(productID); err != nil {
+		return nil, err
+	}
+
+	items, err := r.table.Query(context.Background(), productPK(productID), dynamoVariantSK)
+	if err != nil {
+		return nil, err
+	}
+	variants := make([]*Variant, 0, len(items))
+	for _, item := range items {
+		var variant Variant
+		if err := item.Decode(&variant); err != nil {
+			return nil, err
+		}
+		variants = append(variants, &variant)
+	}
+	return variants, nil
+}
+
+// GetVariant retrieves a variant of a live product by SKU
+func (r *DynamoDBRepository) GetVariant(sku string) (*Variant, error) {
+	ctx := context.Background()
+
+	claim, err := r.table.Get(ctx, skuPK(sku), dynamoSKUSK)
+	if err != nil {
+		return nil, err
+	}
+	if claim == nil {
+		return nil, ErrVariantNotFound
+	}
+	productID := claim.String(dynamoOwnerAttr)
+	if _, err := r.GetByID(productID); err != nil {
+		if errors.Is(err, ErrProductNotFound) {
+			return nil, ErrVariantNotFound
+		}
+		return nil, err
+	}
+
+	item, err := r.table.Get(ctx, productPK(productID), dynamoVariantSK+sku)
+	if err != nil {
+		return nil, err
+	}
+	if item == nil {
+		return nil, ErrVariantNotFound
+	}
+	var variant Variant
+	if err := item.Decode(&variant); err != nil {
+		return nil, err
+	}
+	return &variant, nil
+}
+
+// CreateVariant adds a variant to a live product
+func (r *DynamoDBRepository) CreateVariant(variant *Variant) error {
+	claim, err := dynamo.NewItem(skuPK(variant.SKU), dynamoSKUSK, dynamoSKUClaim, variant.ProductID)
+	if err != nil {
+		return err
+	}
+	claim.SetString(dynamoOwnerAttr, variant.ProductID)
+	item, err := variantItem(variant)
+	if err != nil {
+		return err
+	}
+
+	err = r.table.Transact(context.Background(), []types.TransactWriteItem{
+		r.table.CheckLive(productPK(variant.ProductID), dynamoProductSK),
+		r.table.PutNew(claim),
+		r.table.PutNew(item),
+	})
+	var canceled *dynamo.CanceledError
+	if errors.As(err, &canceled) {
+		if canceled.Failed(0) {
+			return ErrProductNotFound
+		}
+		return ErrVariantExists
+	}
+	return err
+}
+
+// UpdateVariant replaces an existing variant; its SKU and product do not change
+func (r *DynamoDBRepository) UpdateVariant(variant *Variant) error {
+	existing, err := r.GetVariant(variant.SKU)
+	if err != nil {
+		return err
+	}
+
+	updated := *variant
+	updated.ProductID = existing.ProductID
+	updated.CreatedAt, updated.CreatedBy = existing.CreatedAt, existing.CreatedBy
+	item, err := variantItem(&updated)
+	if err != nil {
+		return err
+	}
+
+	return r.writeVariant([]types.TransactWriteItem{
+		r.table.CheckLive(productPK(existing.ProductID), dynamoProductSK),
+		r.table.Replace(item),
+	})
+}
+
+// DeleteVariant removes a variant
+func (r *DynamoDBRepository) DeleteVariant(sku string) error {
+	existing, err := r.GetVariant(sku)
+	if err != nil {
+		return err
+	}
+
+	return r.writeVariant([]types.TransactWriteItem{
+		r.table.CheckLive(productPK(existing.ProductID), dynamoProductSK),
+		r.table.Delete(skuPK(sku), dynamoSKUSK),
+		r.table.Delete(productPK(existing.ProductID), dynamoVariantSK+sku),
+	})
+}
+
+// writeVariant writes items changing an existing variant, reporting a
+// failed condition as the variant or its product having gone
+func (r *DynamoDBRepository) writeVariant(items []types.TransactWriteItem) error {
+	err := r.table.Transact(context.Background(), items)
+	var canceled *dynamo.CanceledError
+	if errors.As(err, &canceled) {
+		return ErrVariantNotFound
+	}
+	return err
+}
+
+// List returns all live products ordered by ID
+func (r *DynamoDBRepository) List() ([]*Product, error) {
+	return r.Find(ProductFilter{})
+}
+
+// Find returns the products matching filter, ordered by its Sort and then
+// by ProductID
+func (r *DynamoDBRepository) Find(filter ProductFilter) ([]*Product, error) {
+	products, err := r.scan(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(products, filter.Compare)
+	return paginate(products, filter.Limit, filter.Offset), nil
+}
+
+// Count returns the number of products matching filter, ignoring Limit and Offset
+func (r *DynamoDBRepository) Count(filter ProductFilter) (int, error) {
+	products, err := r.scan(filter)
+	if err != nil {
+		return 0, err
+	}
+	return len(products), nil
+}
+
+// scan returns every stored product matching filter
+func (r *DynamoDBRepository) scan(filter ProductFilter) ([]*Product, error) {
+	items, err := r.table.Scan(context.Background(), dynamoProduct)
+	if err != nil {
+		return nil, err
+	}
+	return decodeProducts(items, filter)
+}
+
+// load reads a product with its version and movement and deletion counts; the staged
+// product is nil when there is none
+func (r *DynamoDBRepository) load(ctx context.Context, productID string) (*stagedProduct, error) {
+	item, err := r.table.Get(ctx, productPK(productID), dynamoProductSK)
+	if err != nil || item == nil {
+		return &stagedProduct{}, err
+	}
+
+	var product Product
+	if err := item.Decode(&product); err != nil {
+		return nil, err
+	}
+	return &stagedProduct{
+		product:   &product,
+		version:   item.Version(),
+		movements: item.Int(dynamoMovements),
+		deletions: item.Int(dynamoDeletions),
+	}, nil
+}
+
+// priceChanges reads a product's price changes in recording order
+func (r *DynamoDBRepository) priceChanges(ctx context.Context, productID string) ([]*PriceChange, error) {
+	items, err := r.table.Query(ctx, productPK(productID), dynamoPriceSK)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]*PriceChange, 0, len(items))
+	for _, item := range items {
+		var change PriceChange
+		if err := item.Decode(&change); err != nil {
+			return nil, err
+		}
+		changes = append(changes, &change)
+	}
+	return changes, nil
+}
+
+// transact writes items, reporting any failed condition as a conflict:
+// every condition holds for the state the items were built from
+func (r *DynamoDBRepository) transact(ctx context.Context, items []types.TransactWriteItem) error {
+	err := r.table.Transact(ctx, items)
+	var canceled *dynamo.CanceledError
+	if errors.As(err, &canceled) {
+		return dynamo.ErrConflict
+	}
+	return err
+}
+
+// latestPriceChange returns the change in effect at at from changes in
+// recording order, nil if there is none
+func latestPriceChange(changes []*PriceChange, at time.Time) *PriceChange {
+	var latest *PriceChange
+	for _, change := range changes {
+		// Later-recorded changes win ties, so scan in recording order
+		if !change.EffectiveAt.After(at) && (latest == nil || !change.EffectiveAt.Before(latest.EffectiveAt)) {
+			latest = change
+		}
+	}
+	return latest
+}
+
+// decodeProducts decodes product items, keeping those matching filter
+func decodeProducts(items []dynamo.Item, filter ProductFilter) ([]*Product, error) {
+	products := make([]*Product, 0, len(items))
+	for _, item := range items {
+		var product Product
+		if err := item.Decode(&product); err != nil {
+			return nil, err
+		}
+		if filter.Matches(&product) {
+			products = append(products, &product)
+		}
+	}
+	return products, nil
+}
+
+// priceChangeItem builds the item storing change
+func priceChangeItem(change *PriceChange) (dynamo.Item, error) {
+	return dynamo.NewItem(productPK(change.ProductID), priceChangeSK(change.ID), dynamoPriceChange, change)
+}
+
+// variantItem builds the item storing variant
+func variantItem(variant *Variant) (dynamo.Item, error) {
+	return dynamo.NewItem(productPK(variant.ProductID), dynamoVariantSK+variant.SKU, dynamoVariant, variant)
+}
+
+func productPK(productID string) string {
+	return "PRODUCT#" + productID
+}
+
+func skuPK(sku string) string {
+	return "SKU#" + sku
+}
+
+// movementSK, priceChangeSK and deletionSK zero-pad numbers so sort keys order numerically
+func movementSK(n int64) string {
+	return fmt.Sprintf("%s%020d", dynamoMovementSK, n)
+}
+
+func priceChangeSK(id int64) string {
+	return fmt.Sprintf("%s%020d", dynamoPriceSK, id)
+}
+
+func deletionSK(n int64) string {
+	return fmt.Sprintf("%s%020d", dynamoDeletionSK, n)
+}
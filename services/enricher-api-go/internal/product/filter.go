@@ -0,0 +1,127 @@
+package product
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	"enricher-api-go/internal/listing"
+)
+
+// SortFields are the fields products can be sorted on
+var SortFields = []string{"productId", "name", "category", "price", "quantity", "createdAt", "updatedAt"}
+
+// ProductFilter describes the criteria for listing products.
+//
+// Zero values mean "no constraint", so an empty filter matches every product.
+// All criteria compose: a product must satisfy every set field to match.
+// Limit and Offset page through the matches, which are ordered by Sort and
+// then by ProductID so that consecutive pages are stable.
+//
+// Example usage:
+//
+//	inStock := true
+//	filter := ProductFilter{
+//		Category: "Electronics",
+//		Search:   "wireless",
+//		InStock:  &inStock,
+//		Sort:     listing.Sort{{Field: "price", Descending: true}},
+//		Limit:    20,
+//	}
+type ProductFilter struct {
+	// Category matches products in exactly this category
+	Category string
+	// Categories matches products in any of these categories
+	Categories []string
+	// Subcategories widens Category to its whole subtree when the service
+	// has a category tree
+	Subcategories bool
+	// Search matches products whose name or description contains the term, case-insensitively
+	Search string
+	// MinPrice matches products priced at or above the value
+	MinPrice *float64
+	// MaxPrice matches products priced at or below the value
+	MaxPrice *float64
+	// InStock matches products with the given stock status
+	InStock *bool
+	// Barcode matches products with exactly this barcode
+	Barcode string
+	// IncludeDeleted also matches soft-deleted products
+	IncludeDeleted bool
+	// Sort orders the matches by any of SortFields before ProductID
+	Sort listing.Sort
+	// Limit caps the number of products returned; 0 means no limit
+	Limit int
+	// Offset skips the first matches
+	Offset int
+}
+
+// Matches reports whether a product satisfies every criterion of the filter.
+// Limit and Offset are not considered.
+func (f ProductFilter) Matches(p *Product) bool {
+	if p.IsDeleted() && !f.IncludeDeleted {
+		return false
+	}
+
+	if f.Category != "" && p.Category != f.Category {
+		return false
+	}
+
+	if len(f.Categories) > 0 && !slices.Contains(f.Categories, p.Category) {
+		return false
+	}
+
+	if f.Search != "" {
+		needle := strings.ToLower(f.Search)
+		if !strings.Contains(strings.ToLower(p.Name), needle) &&
+			!strings.Contains(strings.ToLower(p.Description), needle) {
+			return false
+		}
+	}
+
+	if f.MinPrice != nil && p.Price < *f.MinPrice {
+		return false
+	}
+
+	if f.MaxPrice != nil && p.Price > *f.MaxPrice {
+		return false
+	}
+
+	if f.InStock != nil && p.InStock() != *f.InStock {
+		return false
+	}
+
+	if f.Barcode != "" && p.Barcode != f.Barcode {
+		return false
+	}
+
+	return true
+}
+
+// Compare orders a before b, returning a negative number, by the filter's
+// Sort and then by ProductID
+func (f ProductFilter) Compare(a, b *Product) int {
+	return cmp.Or(listing.Compare(f.Sort, a, b, compareField), strings.Compare(a.ProductID, b.ProductID))
+}
+
+// compareField compares a and b by one of SortFields
+func compareField(field string, a, b *Product) int {
+	switch field {
+	case "productId":
+		return strings.Compare(a.ProductID, b.ProductID)
+	case "name":
+		return strings.Compare(a.Name, b.Name)
+	case "category":
+		return strings.Compare(a.Category, b.Category)
+	case "price":
+		return cmp.Compare(a.Price, b.Price)
+	case "quantity":
+		return cmp.Compare(a.Quantity, b.Quantity)
+	case "createdAt":
+		return a.CreatedAt.Compare(b.CreatedAt)
+	case "updatedAt":
+		return a.UpdatedAt.Compare(b.UpdatedAt)
+	default:
+		return 0
+	}
+}
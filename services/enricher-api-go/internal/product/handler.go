@@ -1,7 +1,23 @@
 package product
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"enricher-api-go/internal/apiversion"
+	"enricher-api-go/internal/currency"
+	"enricher-api-go/internal/export"
+	"enricher-api-go/internal/fieldset"
+	"enricher-api-go/internal/listing"
+	"enricher-api-go/internal/logging"
+	"enricher-api-go/internal/pagination"
+	"enricher-api-go/internal/problem"
+	"enricher-api-go/internal/servertiming"
 
 	"github.com/labstack/echo/v4"
 )
@@ -18,65 +34,221 @@ func NewHandler(service Service) *Handler {
 	}
 }
 
-// GetProduct handles GET /v1/products/:id
+// responseMappers render product responses for each API version
+var responseMappers = apiversion.Mappers[ProductResponse]{
+	apiversion.V1: func(r ProductResponse) interface{} { return r },
+	apiversion.V2: func(r ProductResponse) interface{} { return r.V2() },
+}
+
+// GetProduct handles GET /v1/products/:id and GET /v2/products/:id, which
+// differ only in the shape of the product (see ProductResponseV2)
+//
+// Soft-deleted products are reported as not found unless the query sets
+// includeDeleted=true, which is restricted to admins. An optional currency
+// query parameter prices the product in that currency, and an optional at
+// timestamp (RFC 3339) prices it as of that instant instead of now. An
+// optional asOf timestamp, which cannot be in the future, reads the product
+// as it was then: priced from its price history, and not found unless it was
+// live at that instant, so an enrichment can be re-run against the price it
+// originally saw. An optional fields parameter, e.g. fields=productId,price,inStock, trims the
+// response to those fields.
+//
+// The response has an ETag but no Last-Modified time: scheduled prices and
+// exchange rates change it without changing the product.
 func (h *Handler) GetProduct(c echo.Context) error {
 	productID := c.Param("id")
 
-	product, err := h.service.GetProduct(productID)
+	fields, err := fieldset.FromQuery(c.QueryParams(), responseMappers.Sample(c))
+	if err != nil {
+		return problem.Write(c, http.StatusBadRequest, err.Error())
+	}
+
+	withDeleted, err := includeDeleted(c)
+	if err != nil {
+		return problem.Write(c, http.StatusBadRequest, err.Error())
+	}
+
+	at, err := parseTimeParam(c, "at")
 	if err != nil {
-		if err == ErrProductNotFound || err.Error() == "failed to get product: product not found" {
-			return c.JSON(http.StatusNotFound, map[string]string{
-				"error": "Product not found",
-			})
+		return problem.Write(c, http.StatusBadRequest, err.Error())
+	}
+
+	asOf, err := parseTimeParam(c, "asOf")
+	if err != nil {
+		return problem.Write(c, http.StatusBadRequest, err.Error())
+	}
+
+	get := h.service.GetProduct
+	switch {
+	case asOf != nil && (withDeleted || at != nil):
+		return problem.Write(c, http.StatusBadRequest, "asOf cannot be combined with includeDeleted or at")
+	case asOf != nil:
+		get = func(ctx context.Context, productID string) (*Product, error) {
+			return h.service.GetProductAsOf(ctx, productID, *asOf)
+		}
+	case withDeleted:
+		get = h.service.GetProductIncludingDeleted
+	case at != nil:
+		get = func(ctx context.Context, productID string) (*Product, error) {
+			return h.service.GetProductAt(ctx, productID, *at)
 		}
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": err.Error(),
-		})
 	}
 
-	return c.JSON(http.StatusOK, product.ToResponse())
+	stop := servertiming.Start(c, "service")
+	product, err := get(c.Request().Context(), productID)
+	stop()
+	if err != nil {
+		return productError(c, err)
+	}
+
+	responses, err := h.responses(c, []*Product{product})
+	if err != nil {
+		return currencyError(c, err)
+	}
+
+	body, err := fields.Apply(responseMappers.Map(c, responses[0]))
+	if err != nil {
+		return problem.ServerError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, body)
+}
+
+// GetProductByBarcode handles GET /v1/products/by-barcode/:code and GET
+// /v2/products/by-barcode/:code, looking up the live product a warehouse
+// scanner read. An optional currency query parameter prices the product in
+// that currency.
+//
+// Error responses:
+//   - 400: The code is not a GTIN with a valid check digit
+//   - 404: No live product has the barcode
+func (h *Handler) GetProductByBarcode(c echo.Context) error {
+	stop := servertiming.Start(c, "service")
+	product, err := h.service.GetProductByBarcode(c.Request().Context(), c.Param("code"))
+	stop()
+	if err != nil {
+		return productError(c, err)
+	}
+
+	responses, err := h.responses(c, []*Product{product})
+	if err != nil {
+		return currencyError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, responseMappers.Map(c, responses[0]))
+}
+
+// BatchGetProducts handles POST /v1/products/batch
+//
+// The body lists up to the configured maximum of product IDs; the response
+// carries the products that were found and the IDs that were not. An
+// optional currency query parameter prices them in that currency.
+func (h *Handler) BatchGetProducts(c echo.Context) error {
+	var req BatchRequest
+	if err := servertiming.Measure(c, "bind", func() error { return c.Bind(&req) }); err != nil {
+		return bindError(c, err)
+	}
+
+	stop := servertiming.Start(c, "service")
+	result, err := h.service.GetProducts(c.Request().Context(), req.ProductIDs)
+	stop()
+	if err != nil {
+		return problem.Error(c, err)
+	}
+
+	responses, err := h.responses(c, result.Products)
+	if err != nil {
+		return currencyError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"products": responses,
+		"missing":  result.Missing,
+		"count":    len(responses),
+	})
 }
 
 // CreateProduct handles POST /v1/products
 func (h *Handler) CreateProduct(c echo.Context) error {
 	var req ProductRequest
-	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid request body",
-		})
+	if err := servertiming.Measure(c, "bind", func() error { return c.Bind(&req) }); err != nil {
+		return bindError(c, err)
 	}
 
-	product, err := h.service.CreateProduct(req)
+	stop := servertiming.Start(c, "service")
+	product, err := h.service.CreateProduct(c.Request().Context(), req)
+	stop()
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": err.Error(),
-		})
+		return problem.Error(c, err)
 	}
 
 	return c.JSON(http.StatusCreated, product.ToResponse())
 }
 
+// BulkWriteProducts handles POST /v1/products/bulk
+//
+// Each item without a productId creates a product and each item with one
+// updates it; the response reports every item in request order. Items
+// succeed or fail independently and the response is 200, unless atomic is
+// set: then one failing item leaves every product unchanged, the others
+// are reported as skipped and the response is 422.
+func (h *Handler) BulkWriteProducts(c echo.Context) error {
+	var req BulkRequest
+	if err := servertiming.Measure(c, "bind", func() error { return c.Bind(&req) }); err != nil {
+		return bindError(c, err)
+	}
+
+	stop := servertiming.Start(c, "service")
+	result, err := h.service.BulkWriteProducts(c.Request().Context(), req)
+	stop()
+	if err != nil {
+		return problem.Error(c, err)
+	}
+
+	status := http.StatusOK
+	if result.Atomic && result.Failed() > 0 {
+		status = http.StatusUnprocessableEntity
+	}
+	return c.JSON(status, result.ToResponse(req))
+}
+
 // UpdateProduct handles PUT /v1/products/:id
 func (h *Handler) UpdateProduct(c echo.Context) error {
 	productID := c.Param("id")
 
 	var req ProductRequest
-	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid request body",
-		})
+	if err := servertiming.Measure(c, "bind", func() error { return c.Bind(&req) }); err != nil {
+		return bindError(c, err)
 	}
 
-	product, err := h.service.UpdateProduct(productID, req)
+	stop := servertiming.Start(c, "service")
+	product, err := h.service.UpdateProduct(c.Request().Context(), productID, req)
+	stop()
 	if err != nil {
-		if err == ErrProductNotFound {
-			return c.JSON(http.StatusNotFound, map[string]string{
-				"error": "Product not found",
-			})
-		}
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": err.Error(),
-		})
+		return productError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, product.ToResponse())
+}
+
+// PatchProduct handles PATCH /v1/products/:id
+//
+// Only the fields present in the body change, so callers can set the
+// quantity without resending the product. Both application/json and
+// application/merge-patch+json bodies are accepted.
+func (h *Handler) PatchProduct(c echo.Context) error {
+	productID := c.Param("id")
+
+	var patch ProductPatch
+	if err := servertiming.Measure(c, "bind", func() error { return c.Bind(&patch) }); err != nil {
+		return bindError(c, err)
+	}
+
+	stop := servertiming.Start(c, "service")
+	product, err := h.service.PatchProduct(c.Request().Context(), productID, patch)
+	stop()
+	if err != nil {
+		return productError(c, err)
 	}
 
 	return c.JSON(http.StatusOK, product.ToResponse())
@@ -86,71 +258,579 @@ func (h *Handler) UpdateProduct(c echo.Context) error {
 func (h *Handler) DeleteProduct(c echo.Context) error {
 	productID := c.Param("id")
 
-	err := h.service.DeleteProduct(productID)
+	stop := servertiming.Start(c, "service")
+	err := h.service.DeleteProduct(c.Request().Context(), productID)
+	stop()
+	if err != nil {
+		return productError(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// RestoreProduct handles POST /v1/products/:id/restore
+//
+// It undoes a soft delete, answering 409 if the product is not deleted.
+func (h *Handler) RestoreProduct(c echo.Context) error {
+	productID := c.Param("id")
+
+	stop := servertiming.Start(c, "service")
+	product, err := h.service.RestoreProduct(c.Request().Context(), productID)
+	stop()
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrProductNotDeleted):
+			return problem.Write(c, http.StatusConflict, "Product is not deleted")
+		default:
+			return productError(c, err)
+		}
+	}
+
+	return c.JSON(http.StatusOK, product.ToResponse())
+}
+
+// ReserveStock handles POST /v1/products/:id/reserve
+//
+// It takes the requested quantity out of stock, answering 409 when fewer
+// units are available.
+func (h *Handler) ReserveStock(c echo.Context) error {
+	return h.adjustStock(c, h.service.ReserveStock)
+}
+
+// ReleaseStock handles POST /v1/products/:id/release
+//
+// It returns the requested quantity to stock.
+func (h *Handler) ReleaseStock(c echo.Context) error {
+	return h.adjustStock(c, h.service.ReleaseStock)
+}
+
+// adjustStock binds a StockRequest and applies it with adjust
+func (h *Handler) adjustStock(c echo.Context, adjust func(ctx context.Context, productID string, req StockRequest) (*Product, error)) error {
+	productID := c.Param("id")
+
+	var req StockRequest
+	if err := servertiming.Measure(c, "bind", func() error { return c.Bind(&req) }); err != nil {
+		return bindError(c, err)
+	}
+
+	stop := servertiming.Start(c, "service")
+	product, err := adjust(c.Request().Context(), productID, req)
+	stop()
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInsufficientStock):
+			return problem.Write(c, http.StatusConflict, "Insufficient stock")
+		default:
+			return productError(c, err)
+		}
+	}
+
+	return c.JSON(http.StatusOK, product.ToResponse())
+}
+
+// ListStockMovements handles GET /v1/products/:id/stock-movements
+//
+// Movements are returned newest first, paged with limit and offset like the
+// product list.
+func (h *Handler) ListStockMovements(c echo.Context) error {
+	productID := c.Param("id")
+
+	page, err := pagination.FromQuery(c.QueryParams())
+	if err != nil {
+		return problem.Write(c, http.StatusBadRequest, err.Error())
+	}
+
+	stop := servertiming.Start(c, "service")
+	movements, total, err := h.service.ListStockMovements(c.Request().Context(), productID, page)
+	stop()
+	if err != nil {
+		return productError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"movements":  movements,
+		"count":      len(movements),
+		"pagination": pagination.NewMeta(c.Request().URL, page, total),
+	})
+}
+
+// ListPriceChanges handles GET /v1/products/:id/prices
+//
+// It returns the product's past and scheduled price changes in the order
+// they take effect.
+func (h *Handler) ListPriceChanges(c echo.Context) error {
+	productID := c.Param("id")
+
+	stop := servertiming.Start(c, "service")
+	changes, err := h.service.ListPriceChanges(c.Request().Context(), productID)
+	stop()
+	if err != nil {
+		return productError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"priceChanges": changes,
+		"count":        len(changes),
+	})
+}
+
+// SchedulePrice handles POST /v1/products/:id/prices
+//
+// The body sets a price and the future instant it takes effect at.
+func (h *Handler) SchedulePrice(c echo.Context) error {
+	productID := c.Param("id")
+
+	var req PriceChangeRequest
+	if err := servertiming.Measure(c, "bind", func() error { return c.Bind(&req) }); err != nil {
+		return bindError(c, err)
+	}
+
+	stop := servertiming.Start(c, "service")
+	change, err := h.service.SchedulePrice(c.Request().Context(), productID, req)
+	stop()
+	if err != nil {
+		return productError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, change)
+}
+
+// CancelPriceChange handles DELETE /v1/products/:id/prices/:changeId
+//
+// Only changes that have not taken effect can be cancelled; others answer 409.
+func (h *Handler) CancelPriceChange(c echo.Context) error {
+	productID := c.Param("id")
+
+	changeID, err := strconv.ParseInt(c.Param("changeId"), 10, 64)
 	if err != nil {
-		if err == ErrProductNotFound {
-			return c.JSON(http.StatusNotFound, map[string]string{
-				"error": "Product not found",
-			})
+		return problem.Write(c, http.StatusNotFound, "Price change not found")
+	}
+
+	stop := servertiming.Start(c, "service")
+	err = h.service.CancelPriceChange(c.Request().Context(), productID, changeID)
+	stop()
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrPriceChangeNotFound):
+			return problem.Write(c, http.StatusNotFound, "Price change not found")
+		case errors.Is(err, ErrPriceChangeEffective):
+			return problem.Write(c, http.StatusConflict, "Price change already in effect")
+		default:
+			return productError(c, err)
 		}
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": err.Error(),
-		})
 	}
 
 	return c.NoContent(http.StatusNoContent)
 }
 
-// ListProducts handles GET /v1/products
+// ListVariants handles GET /v1/products/:id/variants
+func (h *Handler) ListVariants(c echo.Context) error {
+	stop := servertiming.Start(c, "service")
+	variants, err := h.service.ListVariants(c.Request().Context(), c.Param("id"))
+	stop()
+	if err != nil {
+		return variantError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"variants": variants,
+		"count":    len(variants),
+	})
+}
+
+// CreateVariant handles POST /v1/products/:id/variants
+//
+// SKUs are unique across all products; a taken SKU answers 409.
+func (h *Handler) CreateVariant(c echo.Context) error {
+	var req VariantRequest
+	if err := servertiming.Measure(c, "bind", func() error { return c.Bind(&req) }); err != nil {
+		return bindError(c, err)
+	}
+
+	stop := servertiming.Start(c, "service")
+	variant, err := h.service.CreateVariant(c.Request().Context(), c.Param("id"), req)
+	stop()
+	if err != nil {
+		return variantError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, variant)
+}
+
+// GetVariant handles GET /v1/skus/:sku
+func (h *Handler) GetVariant(c echo.Context) error {
+	stop := servertiming.Start(c, "service")
+	variant, err := h.service.GetVariant(c.Request().Context(), c.Param("sku"))
+	stop()
+	if err != nil {
+		return variantError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, variant)
+}
+
+// UpdateVariant handles PUT /v1/skus/:sku
+func (h *Handler) UpdateVariant(c echo.Context) error {
+	var req VariantRequest
+	if err := servertiming.Measure(c, "bind", func() error { return c.Bind(&req) }); err != nil {
+		return bindError(c, err)
+	}
+
+	stop := servertiming.Start(c, "service")
+	variant, err := h.service.UpdateVariant(c.Request().Context(), c.Param("sku"), req)
+	stop()
+	if err != nil {
+		return variantError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, variant)
+}
+
+// DeleteVariant handles DELETE /v1/skus/:sku
+func (h *Handler) DeleteVariant(c echo.Context) error {
+	stop := servertiming.Start(c, "service")
+	err := h.service.DeleteVariant(c.Request().Context(), c.Param("sku"))
+	stop()
+	if err != nil {
+		return variantError(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// variantError answers a failed variant operation: 404 for an unknown
+// product or SKU, 409 for a taken SKU and 400 for an invalid request
+func variantError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, ErrVariantNotFound):
+		return problem.Write(c, http.StatusNotFound, "Variant not found")
+	case errors.Is(err, ErrVariantExists):
+		return problem.Write(c, http.StatusConflict, "Variant already exists")
+	default:
+		return productError(c, err)
+	}
+}
+
+// ListProducts handles GET /v1/products and GET /v2/products.
+//
+// Query parameters category, includeSubcategories, search, minPrice,
+// maxPrice, inStock, includeDeleted (admins only), sort, limit and offset
+// are combined into one ProductFilter, so every filter composes with the
+// others and pagination applies to all of them. sort takes fields of
+// SortFields with an optional direction, e.g. sort=price:desc,name:asc, and
+// defaults to productId. includeSubcategories=true also
+// lists the products of every category below category. Pages default to
+// pagination.DefaultLimit products and the response carries the total match
+// count and a link to the next page. An optional currency parameter prices
+// the page in that currency; price filters still apply to stored prices.
+// An optional fields parameter trims each listed product to those fields.
 func (h *Handler) ListProducts(c echo.Context) error {
-	category := c.QueryParam("category")
+	filter, err := parseProductFilter(c)
+	if err != nil {
+		return problem.Write(c, http.StatusBadRequest, err.Error())
+	}
 
-	var products []*Product
-	var err error
+	fields, err := fieldset.FromQuery(c.QueryParams(), responseMappers.Sample(c))
+	if err != nil {
+		return problem.Write(c, http.StatusBadRequest, err.Error())
+	}
 
-	if category != "" {
-		products, err = h.service.GetProductsByCategory(category)
-	} else {
-		products, err = h.service.ListProducts()
+	stop := servertiming.Start(c, "service")
+	products, err := h.service.FindProducts(c.Request().Context(), filter)
+	var total int
+	if err == nil {
+		total, err = h.service.CountProducts(c.Request().Context(), filter)
+	}
+	stop()
+	if err != nil {
+		return filterError(c, err)
 	}
 
+	responses, err := h.responses(c, products)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": err.Error(),
-		})
+		return currencyError(c, err)
 	}
 
-	responses := make([]ProductResponse, len(products))
-	for i, product := range products {
-		responses[i] = product.ToResponse()
+	items, err := fields.Apply(responseMappers.MapAll(c, responses))
+	if err != nil {
+		return problem.ServerError(c, err)
 	}
 
+	page := pagination.Params{Limit: filter.Limit, Offset: filter.Offset}
 	return c.JSON(http.StatusOK, map[string]interface{}{
-		"products": responses,
-		"count":    len(responses),
-		"category": category,
+		"products":   items,
+		"count":      len(responses),
+		"category":   filter.Category,
+		"pagination": pagination.NewMeta(c.Request().URL, page, total),
+	})
+}
+
+// ExportProducts handles GET /v1/products/export.
+//
+// Every product matching the ListProducts filters is streamed, a page at a
+// time, as CSV or newline-delimited JSON depending on the Accept header (CSV
+// by default; 406 when neither is accepted). limit and offset are ignored,
+// while sort, currency and fields apply as they do to the list. Errors found
+// before the first product are answered as usual; later ones cut the
+// download short.
+func (h *Handler) ExportProducts(c echo.Context) error {
+	filter, err := parseProductFilter(c)
+	if err != nil {
+		return problem.Write(c, http.StatusBadRequest, err.Error())
+	}
+
+	fields, err := fieldset.FromQuery(c.QueryParams(), ProductResponse{})
+	if err != nil {
+		return problem.Write(c, http.StatusBadRequest, err.Error())
+	}
+
+	stream, err := export.NewStream(c, "products", ProductResponse{}, fields)
+	if err != nil {
+		return problem.Write(c, http.StatusNotAcceptable, err.Error())
+	}
+
+	err = h.service.ExportProducts(c.Request().Context(), filter, func(products []*Product) error {
+		responses, err := h.responses(c, products)
+		if err != nil {
+			return err
+		}
+		for _, response := range responses {
+			if err := stream.Write(response); err != nil {
+				return err
+			}
+		}
+		return stream.Flush()
 	})
+	switch {
+	case err == nil:
+		return stream.Close()
+	case stream.Started():
+		logging.FromContext(c.Request().Context()).Error("Product export cut short", "error", err)
+		return nil
+	case errors.Is(err, currency.ErrInvalidCode), errors.Is(err, currency.ErrRateUnavailable):
+		return currencyError(c, err)
+	default:
+		return filterError(c, err)
+	}
+}
+
+// parseProductFilter builds a ProductFilter from the list query parameters
+func parseProductFilter(c echo.Context) (ProductFilter, error) {
+	filter := ProductFilter{
+		Category: c.QueryParam("category"),
+		Search:   c.QueryParam("search"),
+	}
+
+	if c.QueryParams().Has("search") && strings.TrimSpace(filter.Search) == "" {
+		return filter, ErrSearchTermRequired
+	}
+
+	var err error
+	if filter.MinPrice, err = parseFloatParam(c, "minPrice"); err != nil {
+		return filter, err
+	}
+	if filter.MaxPrice, err = parseFloatParam(c, "maxPrice"); err != nil {
+		return filter, err
+	}
+
+	if value := c.QueryParam("inStock"); value != "" {
+		inStock, err := strconv.ParseBool(value)
+		if err != nil {
+			return filter, fmt.Errorf("%w: inStock must be true or false", ErrInvalidFilter)
+		}
+		filter.InStock = &inStock
+	}
+
+	if value := c.QueryParam("includeSubcategories"); value != "" {
+		if filter.Subcategories, err = strconv.ParseBool(value); err != nil {
+			return filter, fmt.Errorf("%w: includeSubcategories must be true or false", ErrInvalidFilter)
+		}
+	}
+
+	if filter.IncludeDeleted, err = includeDeleted(c); err != nil {
+		return filter, err
+	}
+
+	if filter.Sort, err = listing.ParseSort(c.QueryParam("sort")); err != nil {
+		return filter, err
+	}
+
+	page, err := pagination.FromQuery(c.QueryParams())
+	if err != nil {
+		return filter, err
+	}
+	filter.Limit, filter.Offset = page.Limit, page.Offset
+
+	return filter, nil
+}
+
+// includeDeleted reads the includeDeleted query parameter
+func includeDeleted(c echo.Context) (bool, error) {
+	value := c.QueryParam("includeDeleted")
+	if value == "" {
+		return false, nil
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("%w: includeDeleted must be true or false", ErrInvalidFilter)
+	}
+	return parsed, nil
+}
+
+// parseFloatParam parses an optional numeric query parameter
+func parseFloatParam(c echo.Context, name string) (*float64, error) {
+	value := c.QueryParam(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s must be a number", ErrInvalidFilter, name)
+	}
+	return &parsed, nil
+}
+
+// parseTimeParam parses an optional RFC 3339 timestamp query parameter
+func parseTimeParam(c echo.Context, name string) (*time.Time, error) {
+	value := c.QueryParam(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be an RFC 3339 timestamp", name)
+	}
+	return &parsed, nil
 }
 
 // CheckProductAvailability handles GET /v1/products/:id/availability
+//
+// The response reports quantity, inStock (stock status) and orderable
+// (passes IsValid) separately; available mirrors orderable for existing clients.
 func (h *Handler) CheckProductAvailability(c echo.Context) error {
 	productID := c.Param("id")
 
-	isAvailable, err := h.service.IsProductAvailable(productID)
+	stop := servertiming.Start(c, "service")
+	availability, err := h.service.CheckAvailability(c.Request().Context(), productID)
+	stop()
 	if err != nil {
-		if err == ErrProductNotFound {
-			return c.JSON(http.StatusNotFound, map[string]string{
-				"error": "Product not found",
-			})
-		}
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": err.Error(),
-		})
+		return productError(c, err)
 	}
 
 	return c.JSON(http.StatusOK, map[string]interface{}{
-		"productId": productID,
-		"available": isAvailable,
-		"inStock":   isAvailable,
+		"productId": availability.ProductID,
+		"quantity":  availability.Quantity,
+		"inStock":   availability.InStock,
+		"orderable": availability.Orderable,
+		"available": availability.Orderable,
 	})
 }
+
+// CheckBulkAvailability handles POST /v1/products/availability
+//
+// The body lists the lines of an order, [{"productId": ..., "quantity": ...}],
+// so a whole order is validated in one call. The response reports each line
+// in request order and whether the order as a whole can be fulfilled; unknown
+// products are reported as not found rather than failing the request.
+//
+// Example response:
+//
+//	{
+//		"lines": [
+//			{"productId": "product-123", "requested": 2, "found": true, "quantity": 10, "inStock": true, "orderable": true, "fulfillable": true},
+//			{"productId": "product-missing", "requested": 1, "found": false, "quantity": 0, "inStock": false, "orderable": false, "fulfillable": false}
+//		],
+//		"fulfillable": false
+//	}
+//
+// Error responses:
+//   - 400: Malformed body, no lines, too many lines, or a line without a
+//     product ID or positive quantity
+func (h *Handler) CheckBulkAvailability(c echo.Context) error {
+	var lines []AvailabilityLine
+	if err := servertiming.Measure(c, "bind", func() error { return c.Bind(&lines) }); err != nil {
+		return bindError(c, err)
+	}
+
+	stop := servertiming.Start(c, "service")
+	result, err := h.service.CheckBulkAvailability(c.Request().Context(), lines)
+	stop()
+	if err != nil {
+		return problem.Error(c, err)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// responses converts products to responses with their images, priced in the
+// currency query parameter when it is set
+func (h *Handler) responses(c echo.Context, products []*Product) ([]ProductResponse, error) {
+	responses := make([]ProductResponse, len(products))
+	productIDs := make([]string, len(products))
+	for i, product := range products {
+		responses[i] = product.ToResponse()
+		productIDs[i] = product.ProductID
+	}
+
+	images := h.service.ProductImages(c.Request().Context(), productIDs)
+	for i := range responses {
+		responses[i].Images = images[responses[i].ProductID]
+	}
+
+	if c.QueryParam("currency") == "" {
+		return responses, nil
+	}
+	code, err := currency.Normalize(c.QueryParam("currency"))
+	if err != nil {
+		return nil, err
+	}
+
+	for i, product := range products {
+		price, err := h.service.PriceIn(c.Request().Context(), product, code)
+		if err != nil {
+			return nil, err
+		}
+		responses[i].Price, responses[i].Currency = price, code
+	}
+	return responses, nil
+}
+
+// productError answers a failed product operation: 404 for an unknown
+// product and the status of the error's kind otherwise
+func productError(c echo.Context, err error) error {
+	if errors.Is(err, ErrProductNotFound) {
+		return problem.Write(c, http.StatusNotFound, "Product not found")
+	}
+	return problem.Error(c, err)
+}
+
+// filterError answers a failed product search: 404 for an unknown category,
+// 400 for an invalid filter and a server error otherwise
+func filterError(c echo.Context, err error) error {
+	if errors.Is(err, ErrUnknownCategory) {
+		return problem.Write(c, http.StatusNotFound, "Category not found")
+	}
+	return problem.Error(c, err)
+}
+
+// currencyError answers a failed pricing with 400 when the currency is
+// malformed or has no exchange rate, and as a server error otherwise
+func currencyError(c echo.Context, err error) error {
+	if errors.Is(err, currency.ErrInvalidCode) || errors.Is(err, currency.ErrRateUnavailable) {
+		return problem.Write(c, http.StatusBadRequest, err.Error())
+	}
+	return problem.ServerError(c, err)
+}
+
+// bindError reports a request body that failed to bind or validate,
+// surfacing price format problems and failed fields to the caller
+func bindError(c echo.Context, err error) error {
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) && errors.Is(httpErr.Internal, ErrInvalidPrice) {
+		return problem.Write(c, http.StatusBadRequest, httpErr.Internal.Error())
+	}
+	return problem.BindError(c, err)
+}
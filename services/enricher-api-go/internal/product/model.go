@@ -6,10 +6,20 @@
 // models, and utility methods for product operations.
 package product
 
+import (
+	"errors"
+	"maps"
+	"slices"
+	"time"
+
+	"enricher-api-go/internal/apperr"
+	"enricher-api-go/internal/validation"
+)
+
 // Product represents a product entity in the system.
 //
 // This struct contains the core product information including unique
-// identifier, name, description, price, category, and stock status.
+// identifier, name, description, price, category, and stock quantity.
 // It is used for both internal business logic and external API responses.
 //
 // Example usage:
@@ -19,8 +29,9 @@ package product
 //		Name:        "Gaming Laptop",
 //		Description: "High-performance gaming laptop with RTX graphics",
 //		Price:       1299.99,
+//		Currency:    "USD",
 //		Category:    "Electronics",
-//		InStock:     true,
+//		Quantity:    12,
 //	}
 type Product struct {
 	// ProductID is the unique identifier for the product
@@ -29,12 +40,95 @@ type Product struct {
 	Name string `json:"name" db:"name"`
 	// Description is the detailed description of the product
 	Description string `json:"description" db:"description"`
-	// Price is the price of the product in the base currency
+	// Price is the price of the product in Currency
 	Price float64 `json:"price" db:"price"`
+	// Currency is the ISO 4217 code of Price
+	Currency string `json:"currency" db:"currency"`
+	// Prices holds explicit prices in other currencies, keyed by ISO 4217
+	// code; currencies missing here are converted from Price
+	Prices map[string]float64 `json:"prices,omitempty" db:"prices"`
 	// Category is the category or type of the product
 	Category string `json:"category" db:"category"`
-	// InStock indicates whether the product is currently in stock
-	InStock bool `json:"inStock" db:"in_stock"`
+	// Quantity is the number of units available to order
+	Quantity int `json:"quantity" db:"quantity"`
+	// Weight is the optional shipping weight of the product
+	Weight *Weight `json:"weight,omitempty" db:"weight"`
+	// Dimensions is the optional package size of the product
+	Dimensions *Dimensions `json:"dimensions,omitempty" db:"dimensions"`
+	// Barcode is the optional GTIN (UPC or EAN) printed on the product
+	Barcode string `json:"barcode,omitempty" db:"barcode"`
+	// CreatedAt is when the product was created
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	// UpdatedAt is when the product was last changed
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+	// CreatedBy is the authenticated caller that created the product, if any
+	CreatedBy string `json:"createdBy,omitempty" db:"created_by"`
+	// UpdatedBy is the authenticated caller that last changed the product, if any
+	UpdatedBy string `json:"updatedBy,omitempty" db:"updated_by"`
+	// DeletedAt is set when the product has been soft-deleted
+	DeletedAt *time.Time `json:"deletedAt,omitempty" db:"deleted_at"`
+}
+
+// Weight units accepted on product requests.
+const (
+	WeightUnitKilogram = "kg"
+	WeightUnitGram     = "g"
+	WeightUnitPound    = "lb"
+)
+
+// Length units accepted on product requests.
+const (
+	LengthUnitCentimeter = "cm"
+	LengthUnitMillimeter = "mm"
+	LengthUnitInch       = "in"
+)
+
+// Defaults of Measures applied to product weight and dimensions
+const (
+	DefaultWeightUnit     = WeightUnitKilogram
+	DefaultLengthUnit     = LengthUnitCentimeter
+	DefaultMaxWeightKg    = 1000.0
+	DefaultMaxDimensionCm = 500.0
+)
+
+// Measures configures product weight and dimensions.
+//
+// An empty unit on a request falls back to the configured unit. The maxima
+// reject values that no parcel carrier would accept, which almost always
+// indicates a unit mix-up by the caller.
+type Measures struct {
+	WeightUnit     string
+	LengthUnit     string
+	MaxWeightKg    float64
+	MaxDimensionCm float64
+}
+
+// Weight represents the shipping weight of a product in a given unit.
+//
+// Example usage:
+//
+//	weight := &Weight{Value: 1.8, Unit: "kg"}
+type Weight struct {
+	// Value is the weight expressed in Unit
+	Value float64 `json:"value"`
+	// Unit is one of kg, g or lb (defaults to kg)
+	Unit string `json:"unit"`
+}
+
+// Dimensions represents the package size of a product in a given unit.
+//
+// Example usage:
+//
+//	dims := &Dimensions{Length: 35, Width: 25, Height: 3, Unit: "cm"}
+type Dimensions struct {
+	// Length is the longest side expressed in Unit
+	Length float64 `json:"length"`
+	// Width is the second side expressed in Unit
+	Width float64 `json:"width"`
+	// Height is the third side expressed in Unit
+	Height float64 `json:"height"`
+	// Unit is one of cm, mm or in (defaults to cm)
+	Unit string `json:"unit"`
 }
 
 // ProductRequest represents the request payload for product creation and updates.
@@ -49,7 +143,7 @@ type Product struct {
 //		Description: "High-performance gaming laptop with RTX graphics",
 //		Price:       1299.99,
 //		Category:    "Electronics",
-//		InStock:     true,
+//		Quantity:    12,
 //	}
 type ProductRequest struct {
 	// Name is the name of the product (required, 2-100 characters)
@@ -58,10 +152,97 @@ type ProductRequest struct {
 	Description string `json:"description" validate:"required,min=10,max=500"`
 	// Price is the price of the product (required, must be greater than 0)
 	Price float64 `json:"price" validate:"required,gt=0"`
+	// Currency is the ISO 4217 code of Price (defaults to the service currency)
+	Currency string `json:"currency,omitempty"`
+	// Prices holds optional explicit prices keyed by ISO 4217 code
+	Prices map[string]float64 `json:"prices,omitempty"`
 	// Category is the category of the product (required, 2-50 characters)
 	Category string `json:"category" validate:"required,min=2,max=50"`
-	// InStock indicates whether the product is currently in stock
-	InStock bool `json:"inStock"`
+	// Quantity is the number of units available to order (0 or more)
+	Quantity int `json:"quantity" validate:"min=0"`
+	// Weight is the optional shipping weight of the product
+	Weight *Weight `json:"weight,omitempty"`
+	// Dimensions is the optional package size of the product
+	Dimensions *Dimensions `json:"dimensions,omitempty"`
+	// Barcode is the optional GTIN-8, UPC-A, EAN-13 or GTIN-14 of the
+	// product, with a valid check digit
+	Barcode string `json:"barcode,omitempty"`
+}
+
+// ProductPatch is the request body for a partial product update.
+//
+// Fields that are omitted or null keep their current values, following JSON
+// Merge Patch for top-level fields. Prices are merged per currency: a null
+// entry removes that currency's explicit price, and an empty barcode removes
+// the barcode. The merged product is validated like a ProductRequest.
+type ProductPatch struct {
+	Name        *string             `json:"name,omitempty"`
+	Description *string             `json:"description,omitempty"`
+	Price       *float64            `json:"price,omitempty"`
+	Currency    *string             `json:"currency,omitempty"`
+	Prices      map[string]*float64 `json:"prices,omitempty"`
+	Category    *string             `json:"category,omitempty"`
+	Quantity    *int                `json:"quantity,omitempty"`
+	Weight      *Weight             `json:"weight,omitempty"`
+	Dimensions  *Dimensions         `json:"dimensions,omitempty"`
+	Barcode     *string             `json:"barcode,omitempty"`
+}
+
+// apply returns the full request equivalent to patching product
+func (p ProductPatch) apply(product *Product) ProductRequest {
+	req := ProductRequest{
+		Name:        product.Name,
+		Description: product.Description,
+		Price:       product.Price,
+		Currency:    product.Currency,
+		Prices:      product.Prices,
+		Category:    product.Category,
+		Quantity:    product.Quantity,
+		Weight:      product.Weight,
+		Dimensions:  product.Dimensions,
+		Barcode:     product.Barcode,
+	}
+	if p.Name != nil {
+		req.Name = *p.Name
+	}
+	if p.Description != nil {
+		req.Description = *p.Description
+	}
+	if p.Price != nil {
+		req.Price = *p.Price
+	}
+	if p.Currency != nil {
+		req.Currency = *p.Currency
+	}
+	if len(p.Prices) > 0 {
+		req.Prices = make(map[string]float64, len(product.Prices)+len(p.Prices))
+		for code, price := range product.Prices {
+			req.Prices[code] = price
+		}
+		for code, price := range p.Prices {
+			if price == nil {
+				delete(req.Prices, code)
+			} else {
+				req.Prices[code] = *price
+			}
+		}
+	}
+	if p.Category != nil {
+		req.Category = *p.Category
+	}
+	if p.Quantity != nil {
+		req.Quantity = *p.Quantity
+	}
+	if p.Weight != nil {
+		req.Weight = p.Weight
+	}
+	if p.Dimensions != nil {
+		req.Dimensions = p.Dimensions
+	}
+	if p.Barcode != nil {
+		req.Barcode = *p.Barcode
+	}
+	return req
 }
 
 // ProductResponse represents the response payload for product operations.
@@ -76,7 +257,9 @@ type ProductRequest struct {
 //		Name:        "Gaming Laptop",
 //		Description: "High-performance gaming laptop with RTX graphics",
 //		Price:       1299.99,
+//		Currency:    "USD",
 //		Category:    "Electronics",
+//		Quantity:    12,
 //		InStock:     true,
 //	}
 type ProductResponse struct {
@@ -86,12 +269,435 @@ type ProductResponse struct {
 	Name string `json:"name"`
 	// Description is the detailed description of the product
 	Description string `json:"description"`
-	// Price is the price of the product in the base currency
+	// Price is the price of the product in Currency
 	Price float64 `json:"price"`
+	// Currency is the ISO 4217 code of Price
+	Currency string `json:"currency"`
+	// Prices holds explicit prices in other currencies, keyed by ISO 4217 code
+	Prices map[string]float64 `json:"prices,omitempty"`
 	// Category is the category or type of the product
 	Category string `json:"category"`
+	// Quantity is the number of units available to order
+	Quantity int `json:"quantity"`
+	// InStock reports whether Quantity is positive, for clients that predate it
+	InStock bool `json:"inStock"`
+	// Weight is the optional shipping weight of the product
+	Weight *Weight `json:"weight,omitempty"`
+	// Dimensions is the optional package size of the product
+	Dimensions *Dimensions `json:"dimensions,omitempty"`
+	// Barcode is the optional GTIN printed on the product
+	Barcode string `json:"barcode,omitempty"`
+	// Images are the uploaded images of the product, oldest first
+	Images []Image `json:"images,omitempty"`
+	// CreatedAt is when the product was created
+	CreatedAt time.Time `json:"createdAt"`
+	// UpdatedAt is when the product was last changed
+	UpdatedAt time.Time `json:"updatedAt"`
+	// CreatedBy is the caller that created the product, if authenticated
+	CreatedBy string `json:"createdBy,omitempty"`
+	// UpdatedBy is the caller that last changed the product, if authenticated
+	UpdatedBy string `json:"updatedBy,omitempty"`
+	// DeletedAt is set when the product has been soft-deleted
+	DeletedAt *time.Time `json:"deletedAt,omitempty"`
+}
+
+// ProductResponseV2 represents a product in /v2 responses.
+//
+// Prices are amounts paired with their currency, and stock is grouped with
+// its status; the inStock flag v1 keeps for older clients is dropped.
+//
+// Example usage:
+//
+//	response := ProductResponseV2{
+//		ProductID: "product-12345",
+//		Name:      "Gaming Laptop",
+//		Price:     Money{Amount: 1299.99, Currency: "USD"},
+//		Prices:    []Money{{Amount: 1199.99, Currency: "EUR"}},
+//		Category:  "Electronics",
+//		Stock:     StockLevel{Quantity: 12, Available: true},
+//	}
+type ProductResponseV2 struct {
+	// ProductID is the unique identifier for the product
+	ProductID string `json:"productId"`
+	// Name is the name of the product
+	Name string `json:"name"`
+	// Description is the detailed description of the product
+	Description string `json:"description"`
+	// Price is the price of the product, in the requested currency if any
+	Price Money `json:"price"`
+	// Prices holds explicit prices in other currencies, ordered by currency
+	Prices []Money `json:"prices,omitempty"`
+	// Category is the category or type of the product
+	Category string `json:"category"`
+	// Stock is the stock level of the product
+	Stock StockLevel `json:"stock"`
+	// Weight is the optional shipping weight of the product
+	Weight *Weight `json:"weight,omitempty"`
+	// Dimensions is the optional package size of the product
+	Dimensions *Dimensions `json:"dimensions,omitempty"`
+	// Barcode is the optional GTIN printed on the product
+	Barcode string `json:"barcode,omitempty"`
+	// Images are the uploaded images of the product, oldest first
+	Images []Image `json:"images,omitempty"`
+	// CreatedAt is when the product was created
+	CreatedAt time.Time `json:"createdAt"`
+	// UpdatedAt is when the product was last changed
+	UpdatedAt time.Time `json:"updatedAt"`
+	// CreatedBy is the caller that created the product, if authenticated
+	CreatedBy string `json:"createdBy,omitempty"`
+	// UpdatedBy is the caller that last changed the product, if authenticated
+	UpdatedBy string `json:"updatedBy,omitempty"`
+	// DeletedAt is set when the product has been soft-deleted
+	DeletedAt *time.Time `json:"deletedAt,omitempty"`
+}
+
+// Image links to an uploaded image of a product and its thumbnail
+type Image struct {
+	// ImageID is the unique identifier for the image
+	ImageID string `json:"imageId"`
+	// URL is where the image is served
+	URL string `json:"url"`
+	// ThumbnailURL is where a downscaled copy of the image is served
+	ThumbnailURL string `json:"thumbnailUrl"`
+	// Width and Height are the size of the image in pixels
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// Money is an amount in a currency
+type Money struct {
+	// Amount is the amount in Currency
+	Amount float64 `json:"amount"`
+	// Currency is the ISO 4217 code of Amount
+	Currency string `json:"currency"`
+}
+
+// StockLevel is the stock of a product in /v2 responses
+type StockLevel struct {
+	// Quantity is the number of units available to order
+	Quantity int `json:"quantity"`
+	// Available reports whether Quantity is positive
+	Available bool `json:"available"`
+}
+
+// Availability describes whether a product can be ordered.
+//
+// InStock reports the stock status alone, while Orderable additionally
+// requires the product to pass IsValid. A product can be in stock but not
+// orderable, for example when its name or price is missing.
+//
+// Example usage:
+//
+//	availability := Availability{
+//		ProductID: "product-12345",
+//		Quantity:  12,
+//		InStock:   true,
+//		Orderable: true,
+//	}
+type Availability struct {
+	// ProductID is the unique identifier for the product
+	ProductID string `json:"productId"`
+	// Quantity is the number of units available to order
+	Quantity int `json:"quantity"`
+	// InStock indicates whether the product is currently in stock
+	InStock bool `json:"inStock"`
+	// Orderable indicates whether the product passes IsValid and can be ordered
+	Orderable bool `json:"orderable"`
+}
+
+// AvailabilityLine is one line of a bulk availability check: a quantity
+// of a product an order needs
+type AvailabilityLine struct {
+	// ProductID is the product the line orders
+	ProductID string `json:"productId"`
+	// Quantity is the number of units the line needs, at least 1
+	Quantity int `json:"quantity"`
+}
+
+// LineAvailability reports whether one line of a bulk availability check
+// can be fulfilled.
+//
+// Lines naming the same product share its stock, so each is fulfillable
+// only if the product covers the quantities of all of them together.
+type LineAvailability struct {
+	// ProductID is the product the line orders
+	ProductID string `json:"productId"`
+	// Requested is the number of units the line needs
+	Requested int `json:"requested"`
+	// Found reports whether the product exists and is not deleted
+	Found bool `json:"found"`
+	// Quantity is the number of units of the product available to order
+	Quantity int `json:"quantity"`
 	// InStock indicates whether the product is currently in stock
 	InStock bool `json:"inStock"`
+	// Orderable indicates whether the product passes IsValid and can be ordered
+	Orderable bool `json:"orderable"`
+	// Fulfillable reports whether the product is orderable and has enough
+	// stock for every line that orders it
+	Fulfillable bool `json:"fulfillable"`
+}
+
+// BulkAvailability is the outcome of a bulk availability check
+type BulkAvailability struct {
+	// Lines report each requested line, in request order
+	Lines []LineAvailability `json:"lines"`
+	// Fulfillable reports whether every line is fulfillable
+	Fulfillable bool `json:"fulfillable"`
+}
+
+// StockRequest is the request body for reserving or releasing stock
+type StockRequest struct {
+	// Quantity is the number of units to reserve or release
+	Quantity int `json:"quantity" validate:"required,gt=0"`
+	// Reason is recorded on the stock movement and defaults to the action
+	Reason string `json:"reason,omitempty" validate:"max=200"`
+}
+
+// Default stock movement reasons for each kind of change
+const (
+	ReasonCreate  = "create"
+	ReasonUpdate  = "update"
+	ReasonReserve = "reserve"
+	ReasonRelease = "release"
+	// ReasonCompensate undoes an adjustment of a unit of work that failed
+	ReasonCompensate = "compensate"
+)
+
+// StockMovement records one change to a product's quantity.
+//
+// Movements are written by the repository together with the change itself,
+// so the history always adds up to the current quantity of products created
+// after it was introduced.
+type StockMovement struct {
+	// ProductID is the product whose stock changed
+	ProductID string `json:"productId"`
+	// Delta is the change in quantity: negative when stock was taken out
+	Delta int `json:"delta"`
+	// Quantity is the stock level after the change
+	Quantity int `json:"quantity"`
+	// Reason explains the change, e.g. "reserve" or a caller-supplied note
+	Reason string `json:"reason"`
+	// Actor is the caller that made the change, if authenticated
+	Actor string `json:"actor,omitempty"`
+	// CreatedAt is when the change happened
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// PriceChange is a product price that takes effect at EffectiveAt.
+//
+// Changes with EffectiveAt in the future are scheduled; past ones form the
+// price history. Creating a product or changing its price directly records
+// a change effective immediately, so the latest change not after a given
+// instant is the price valid then.
+type PriceChange struct {
+	// ID identifies the change within its product's schedule
+	ID int64 `json:"id"`
+	// ProductID is the product whose price changes
+	ProductID string `json:"productId"`
+	// Price is the new price in the product's currency
+	Price float64 `json:"price"`
+	// EffectiveAt is when Price starts to apply
+	EffectiveAt time.Time `json:"effectiveAt"`
+	// CreatedAt is when the change was recorded
+	CreatedAt time.Time `json:"createdAt"`
+	// CreatedBy is the caller that recorded the change, if authenticated
+	CreatedBy string `json:"createdBy,omitempty"`
+}
+
+// PriceChangeRequest is the request body for scheduling a price change
+type PriceChangeRequest struct {
+	// Price is the new price (required, must be greater than 0)
+	Price float64 `json:"price" validate:"required,gt=0"`
+	// EffectiveAt is when the price applies and must be in the future
+	EffectiveAt time.Time `json:"effectiveAt" validate:"required"`
+}
+
+// Deletion is an interval during which a product was soft-deleted. The
+// product's current deletion, if any, has no RestoredAt.
+type Deletion struct {
+	// DeletedAt is when the product was deleted
+	DeletedAt time.Time `json:"deletedAt"`
+	// RestoredAt is when the product was restored, nil while it is deleted
+	RestoredAt *time.Time `json:"restoredAt,omitempty"`
+}
+
+// Covers reports whether the product was deleted at at
+func (d *Deletion) Covers(at time.Time) bool {
+	return !d.DeletedAt.After(at) && (d.RestoredAt == nil || d.RestoredAt.After(at))
+}
+
+// Variant is a sellable version of a product, such as one size or color,
+// identified by a SKU that is unique across all products.
+//
+// A variant has its own price, in the product's currency, and its own stock.
+type Variant struct {
+	// SKU is the stock keeping unit, stored upper-case
+	SKU string `json:"sku"`
+	// ProductID is the product the variant belongs to
+	ProductID string `json:"productId"`
+	// Attributes distinguish the variant, e.g. {"size": "M", "color": "red"}
+	Attributes map[string]string `json:"attributes,omitempty"`
+	// Price is the price of the variant in the product's currency
+	Price float64 `json:"price"`
+	// Quantity is the number of units of the variant available to order
+	Quantity int `json:"quantity"`
+	// CreatedAt is when the variant was created
+	CreatedAt time.Time `json:"createdAt"`
+	// UpdatedAt is when the variant was last changed
+	UpdatedAt time.Time `json:"updatedAt"`
+	// CreatedBy is the authenticated caller that created the variant, if any
+	CreatedBy string `json:"createdBy,omitempty"`
+	// UpdatedBy is the authenticated caller that last changed the variant, if any
+	UpdatedBy string `json:"updatedBy,omitempty"`
+}
+
+// VariantRequest is the request body for creating or replacing a variant
+type VariantRequest struct {
+	// SKU identifies the variant (required on create, 1-64 characters without
+	// spaces or slashes); on update it defaults to the SKU in the path
+	SKU string `json:"sku,omitempty" validate:"max=64"`
+	// Attributes distinguish the variant (up to 10, keys up to 50 and values up to 100 characters)
+	Attributes map[string]string `json:"attributes,omitempty" validate:"max=10,dive,keys,required,max=50,endkeys,required,max=100"`
+	// Price is the price of the variant (required, must be greater than 0)
+	Price float64 `json:"price" validate:"required,gt=0"`
+	// Quantity is the number of units available to order (0 or more)
+	Quantity int `json:"quantity" validate:"min=0"`
+}
+
+// BatchRequest is the request body for looking up several products at once
+type BatchRequest struct {
+	// ProductIDs lists the products to retrieve; duplicates are ignored
+	ProductIDs []string `json:"productIds"`
+}
+
+// BatchResult holds the outcome of a batch product lookup.
+//
+// Products are returned in the order they were requested; IDs with no
+// matching product are listed in Missing, also in request order.
+type BatchResult struct {
+	// Products are the products that were found
+	Products []*Product
+	// Missing lists the requested IDs that did not match a product
+	Missing []string
+}
+
+// Outcomes of a bulk item
+const (
+	// BulkCreated means the item created a product
+	BulkCreated = "created"
+	// BulkUpdated means the item updated the product it named
+	BulkUpdated = "updated"
+	// BulkFailed means the item was rejected or could not be stored
+	BulkFailed = "failed"
+	// BulkSkipped means an atomic bulk write was abandoned because of
+	// another item, so this one was not stored either
+	BulkSkipped = "skipped"
+)
+
+// BulkRequest is the request body for creating or updating many products at
+// once.
+//
+// Example request:
+//
+//	{
+//		"atomic": true,
+//		"items": [
+//			{"product": {"name": "Gaming Laptop", "description": "High-performance gaming laptop", "price": 1299.99, "category": "Electronics"}},
+//			{"productId": "product-12345", "product": {"name": "Wireless Mouse", "description": "Ergonomic wireless mouse", "price": 24.99, "category": "Electronics", "quantity": 40}}
+//		]
+//	}
+type BulkRequest struct {
+	// Items are written in order
+	Items []BulkItem `json:"items"`
+	// Atomic stores every item or none: one failing item fails them all
+	Atomic bool `json:"atomic,omitempty"`
+}
+
+// BulkItem creates a product, or replaces the one named by ProductID
+type BulkItem struct {
+	// ProductID names the product to update; omit it to create a product
+	ProductID string `json:"productId,omitempty"`
+	// Product holds the fields of the product, as for a single create or update
+	Product ProductRequest `json:"product"`
+}
+
+// BulkItemResult is the outcome of one bulk item
+type BulkItemResult struct {
+	// Status is BulkCreated, BulkUpdated, BulkFailed or BulkSkipped
+	Status string
+	// Product is the stored product of a created or updated item
+	Product *Product
+	// Err is why a failed item was rejected
+	Err error
+}
+
+// BulkResult holds the outcome of a bulk write, one result per item in
+// request order
+type BulkResult struct {
+	Items  []BulkItemResult
+	Atomic bool
+}
+
+// Failed returns the number of items that failed
+func (r *BulkResult) Failed() int {
+	failed := 0
+	for _, item := range r.Items {
+		if item.Status == BulkFailed {
+			failed++
+		}
+	}
+	return failed
+}
+
+// BulkItemResponse is the outcome of one bulk item as returned by the API
+type BulkItemResponse struct {
+	// Index is the position of the item in the request
+	Index int `json:"index"`
+	// Status is created, updated, failed or skipped
+	Status string `json:"status"`
+	// ProductID identifies the product written or named by the item
+	ProductID string `json:"productId,omitempty"`
+	// Product is the stored product of a created or updated item
+	Product *ProductResponse `json:"product,omitempty"`
+	// Error explains why a failed item was rejected
+	Error string `json:"error,omitempty"`
+	// Fields lists the fields of a failed item that did not validate
+	Fields []validation.FieldError `json:"fields,omitempty"`
+}
+
+// BulkResponse reports a bulk write item by item
+type BulkResponse struct {
+	// Results holds one entry per item, in request order
+	Results []BulkItemResponse `json:"results"`
+	// Succeeded and Failed count the items stored and rejected
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+	// Atomic reports whether the items were written all or nothing
+	Atomic bool `json:"atomic"`
+}
+
+// ToResponse converts a bulk result to the API representation, naming each
+// item's product from req
+func (r *BulkResult) ToResponse(req BulkRequest) BulkResponse {
+	response := BulkResponse{Results: make([]BulkItemResponse, len(r.Items)), Atomic: r.Atomic}
+	for i, item := range r.Items {
+		result := BulkItemResponse{Index: i, Status: item.Status, ProductID: req.Items[i].ProductID}
+		switch item.Status {
+		case BulkCreated, BulkUpdated:
+			product := item.Product.ToResponse()
+			result.Product, result.ProductID = &product, item.Product.ProductID
+			response.Succeeded++
+		case BulkFailed:
+			result.Error = item.Err.Error()
+			var validationErr *validation.Error
+			if errors.As(item.Err, &validationErr) {
+				result.Fields = validationErr.Fields
+			}
+			response.Failed++
+		}
+		response.Results[i] = result
+	}
+	return response
 }
 
 // IsValid checks if the product is valid for order processing.
@@ -105,15 +711,23 @@ type ProductResponse struct {
 // Example usage:
 //
 //	product := &Product{
-//		Name:    "Gaming Laptop",
-//		Price:   1299.99,
-//		InStock: true,
+//		Name:     "Gaming Laptop",
+//		Price:    1299.99,
+//		Quantity: 12,
 //	}
 //	if product.IsValid() {
 //		// Process valid product
 //	}
 func (p *Product) IsValid() bool {
-	return p.Name != "" && p.Price > 0 && p.InStock
+	return p.Name != "" && p.Price > 0 && p.InStock()
+}
+
+// InStock reports whether at least one unit is available.
+//
+// Returns:
+//   - bool: true if Quantity is positive, false otherwise
+func (p *Product) InStock() bool {
+	return p.Quantity > 0
 }
 
 // ToResponse converts a Product to ProductResponse.
@@ -132,7 +746,7 @@ func (p *Product) IsValid() bool {
 //		Description: "High-performance gaming laptop with RTX graphics",
 //		Price:       1299.99,
 //		Category:    "Electronics",
-//		InStock:     true,
+//		Quantity:    12,
 //	}
 //	response := product.ToResponse()
 func (p *Product) ToResponse() ProductResponse {
@@ -141,7 +755,106 @@ func (p *Product) ToResponse() ProductResponse {
 		Name:        p.Name,
 		Description: p.Description,
 		Price:       p.Price,
+		Currency:    p.Currency,
+		Prices:      p.Prices,
 		Category:    p.Category,
-		InStock:     p.InStock,
+		Quantity:    p.Quantity,
+		InStock:     p.InStock(),
+		Weight:      p.Weight,
+		Dimensions:  p.Dimensions,
+		Barcode:     p.Barcode,
+		CreatedAt:   p.CreatedAt,
+		UpdatedAt:   p.UpdatedAt,
+		CreatedBy:   p.CreatedBy,
+		UpdatedBy:   p.UpdatedBy,
+		DeletedAt:   p.DeletedAt,
+	}
+}
+
+// V2 converts the response to its /v2 representation
+func (r ProductResponse) V2() ProductResponseV2 {
+	var prices []Money
+	for _, code := range slices.Sorted(maps.Keys(r.Prices)) {
+		prices = append(prices, Money{Amount: r.Prices[code], Currency: code})
+	}
+
+	return ProductResponseV2{
+		ProductID:   r.ProductID,
+		Name:        r.Name,
+		Description: r.Description,
+		Price:       Money{Amount: r.Price, Currency: r.Currency},
+		Prices:      prices,
+		Category:    r.Category,
+		Stock:       StockLevel{Quantity: r.Quantity, Available: r.InStock},
+		Weight:      r.Weight,
+		Dimensions:  r.Dimensions,
+		Barcode:     r.Barcode,
+		Images:      r.Images,
+		CreatedAt:   r.CreatedAt,
+		UpdatedAt:   r.UpdatedAt,
+		CreatedBy:   r.CreatedBy,
+		UpdatedBy:   r.UpdatedBy,
+		DeletedAt:   r.DeletedAt,
+	}
+}
+
+// InStock reports whether any units of the variant are available
+func (v *Variant) InStock() bool {
+	return v.Quantity > 0
+}
+
+// IsDeleted reports whether the product has been soft-deleted.
+//
+// Returns:
+//   - bool: true if DeletedAt is set, false otherwise
+func (p *Product) IsDeleted() bool {
+	return p.DeletedAt != nil
+}
+
+// Kilograms returns the weight converted to kilograms.
+//
+// Returns:
+//   - float64: the weight in kilograms
+//   - error: error if the unit is not supported
+func (w *Weight) Kilograms() (float64, error) {
+	unit := w.Unit
+	if unit == "" {
+		unit = DefaultWeightUnit
+	}
+
+	switch unit {
+	case WeightUnitKilogram:
+		return w.Value, nil
+	case WeightUnitGram:
+		return w.Value / 1000, nil
+	case WeightUnitPound:
+		return w.Value * 0.45359237, nil
+	default:
+		return 0, apperr.Errorf(apperr.ErrValidation, "unsupported weight unit %q", unit)
+	}
+}
+
+// Centimeters returns the length, width and height converted to centimeters.
+//
+// Returns:
+//   - [3]float64: length, width and height in centimeters
+//   - error: error if the unit is not supported
+func (d *Dimensions) Centimeters() ([3]float64, error) {
+	unit := d.Unit
+	if unit == "" {
+		unit = DefaultLengthUnit
+	}
+
+	var factor float64
+	switch unit {
+	case LengthUnitCentimeter:
+		factor = 1
+	case LengthUnitMillimeter:
+		factor = 0.1
+	case LengthUnitInch:
+		factor = 2.54
+	default:
+		return [3]float64{}, apperr.Errorf(apperr.ErrValidation, "unsupported length unit %q", unit)
 	}
+	return [3]float64{d.Length * factor, d.Width * factor, d.Height * factor}, nil
 }
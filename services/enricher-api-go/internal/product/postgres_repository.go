@@ -0,0 +1,888 @@
+package product
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"enricher-api-go/internal/outbox"
+	"enricher-api-go/internal/pagination"
+	"enricher-api-go/internal/postgres"
+	"enricher-api-go/internal/unitofwork"
+)
+
+// variantColumns lists the columns read by scanVariant, in order, qualified
+// so they can be selected from a join with products
+const variantColumns = `product_variants.sku, product_variants.product_id, product_variants.attributes,
+	product_variants.price, product_variants.quantity, product_variants.created_at,
+	product_variants.updated_at, product_variants.created_by, product_variants.updated_by`
+
+// liveVariants joins variants to their product, skipping those of deleted products
+const liveVariants = `product_variants JOIN products ON products.product_id = product_variants.product_id
+	AND products.deleted_at IS NULL`
+
+// priceChangeColumns lists the columns read into a PriceChange, in order
+const priceChangeColumns = `id, product_id, price, effective_at, created_at, created_by`
+
+// productColumns lists the columns read by scanProduct, in order
+const productColumns = `product_id, name, description, price, currency, prices, category, quantity,
+	weight, dimensions, barcode, created_at, updated_at, created_by, updated_by, deleted_at`
+
+// notDeleted restricts a query to live products
+const notDeleted = `deleted_at IS NULL`
+
+// PostgresRepository implements Repository on top of a PostgreSQL database
+type PostgresRepository struct {
+	db *sql.DB
+	// events records every product change in the same transaction; nil records none
+	events *outbox.PostgresStore
+}
+
+// NewPostgresRepository creates a product repository backed by db.
+//
+// The caller owns db and is responsible for opening and closing it; the
+// products table must exist (see the migrations package).
+func NewPostgresRepository(db *sql.DB) *PostgresRepository {
+	return &PostgresRepository{db: db}
+}
+
+// GetByID retrieves a live product by ID
+func (r *PostgresRepository) GetByID(productID string) (*Product, error) {
+	return r.getOne(`SELECT `+productColumns+` FROM products WHERE product_id = $1 AND `+notDeleted, productID)
+}
+
+// GetByIDIncludingDeleted retrieves a product by ID even if it is soft-deleted
+func (r *PostgresRepository) GetByIDIncludingDeleted(productID string) (*Product, error) {
+	return r.getOne(`SELECT `+productColumns+` FROM products WHERE product_id = $1`, productID)
+}
+
+// GetByIDs retrieves the live products with the given IDs, skipping unknown ones
+func (r *PostgresRepository) GetByIDs(productIDs []string) ([]*Product, error) {
+	return r.query(`SELECT `+productColumns+` FROM products WHERE product_id = ANY($1) AND `+notDeleted, productIDs)
+}
+
+// RecordEventsTo records every change of a product in store, in the
+// transaction that stores the change. Price schedules and variants are not
+// recorded.
+func (r *PostgresRepository) RecordEventsTo(store *outbox.PostgresStore) {
+	r.events = store
+}
+
+// Create adds a new product, recording its initial stock as a movement and
+// its price as a price change in the same statement
+func (r *PostgresRepository) Create(product *Product) error {
+	_, err := r.write(outbox.ActionCreated, func(q querier) (*Product, error) {
+		return product, insertProduct(q, product)
+	})
+	return err
+}
+
+// Update modifies an existing product. A changed quantity, or a price other
+// than the one in effect, is recorded in the same statement.
+func (r *PostgresRepository) Update(product *Product) error {
+	_, err := r.write(outbox.ActionUpdated, func(q querier) (*Product, error) {
+		return product, updateProduct(q, product)
+	})
+	return err
+}
+
+// WriteAll applies every write in one transaction, recording their events
+// in it when events are recorded
+func (r *PostgresRepository) WriteAll(writes []Write) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin product bulk write: %w", err)
+	}
+	defer tx.Rollback()
+
+	for i, write := range writes {
+		action, store := outbox.ActionUpdated, updateProduct
+		if write.Create {
+			action, store = outbox.ActionCreated, insertProduct
+		}
+		err := store(tx, write.Product)
+		if err == nil && r.events != nil {
+			err = r.events.Record(tx, outbox.Change{
+				Aggregate:   outbox.AggregateProduct,
+				AggregateID: write.Product.ProductID,
+				Action:      action,
+				Data:        write.Product,
+			})
+		}
+		if err != nil {
+			return &WriteError{Index: i, Err: err}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit product bulk write: %w", err)
+	}
+	return nil
+}
+
+// insertProduct adds product on q, recording its initial stock and price
+func insertProduct(q querier, product *Product) error {
+	prices, weight, dimensions, err := marshalJSONColumns(product)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.Exec(
+		`WITH created AS (
+			INSERT INTO products (product_id, name, description, price, currency, prices, category, quantity,
+				weight, dimensions, barcode, created_at, updated_at, created_by, updated_by)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+			RETURNING product_id, price, quantity, created_by, created_at
+		), movement AS (
+			INSERT INTO stock_movements (product_id, delta, quantity, reason, actor, created_at)
+			SELECT product_id, quantity, quantity, $16, created_by, created_at FROM created WHERE quantity <> 0
+		)
+		INSERT INTO price_changes (product_id, price, effective_at, created_at, created_by)
+		SELECT product_id, price, created_at, created_at, created_by FROM created`,
+		product.ProductID, product.Name, product.Description, product.Price, product.Currency, prices,
+		product.Category, product.Quantity, weight, dimensions, product.Barcode,
+		product.CreatedAt, product.UpdatedAt, product.CreatedBy, product.UpdatedBy, ReasonCreate,
+	)
+	if postgres.IsUniqueViolation(err) {
+		return ErrProductExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to insert product: %w", err)
+	}
+	return nil
+}
+
+// updateProduct replaces a live product on q, recording a changed quantity
+// and a price other than the one in effect
+func updateProduct(q querier, product *Product) error {
+	prices, weight, dimensions, err := marshalJSONColumns(product)
+	if err != nil {
+		return err
+	}
+
+	var updated int
+	err = q.QueryRow(
+		`WITH previous AS (
+			SELECT product_id, quantity, price FROM products WHERE product_id = $1 AND `+notDeleted+` FOR UPDATE
+		), updated AS (
+			UPDATE products
+			SET name = $2, description = $3, price = $4, currency = $5, prices = $6, category = $7, quantity = $8,
+				weight = $9, dimensions = $10, barcode = $11, updated_at = $12, updated_by = $13
+			FROM previous WHERE products.product_id = previous.product_id
+			RETURNING products.product_id, products.quantity, previous.quantity AS previous_quantity,
+				products.price, COALESCE((
+					SELECT price FROM price_changes
+					WHERE price_changes.product_id = previous.product_id AND effective_at <= $12
+					ORDER BY effective_at DESC, id DESC LIMIT 1
+				), previous.price) AS previous_price
+		), movement AS (
+			INSERT INTO stock_movements (product_id, delta, quantity, reason, actor, created_at)
+			SELECT product_id, quantity - previous_quantity, quantity, $14, $13, $12
+			FROM updated WHERE quantity <> previous_quantity
+		), price_change AS (
+			INSERT INTO price_changes (product_id, price, effective_at, created_at, created_by)
+			SELECT product_id, price, $12, $12, $13 FROM updated WHERE price <> previous_price
+		)
+		SELECT COUNT(*) FROM updated`,
+		product.ProductID, product.Name, product.Description, product.Price, product.Currency, prices,
+		product.Category, product.Quantity, weight, dimensions, product.Barcode,
+		product.UpdatedAt, product.UpdatedBy, ReasonUpdate,
+	).Scan(&updated)
+	if err != nil {
+		return fmt.Errorf("failed to update product: %w", err)
+	}
+	if updated == 0 {
+		return ErrProductNotFound
+	}
+	return nil
+}
+
+// Delete soft-deletes a product by stamping deleted_at with now and opens
+// its deletion in the same statement
+func (r *PostgresRepository) Delete(productID string, now time.Time) error {
+	_, err := r.write(outbox.ActionDeleted, func(q querier) (*Product, error) {
+		return queryOne(q,
+			`WITH deleted AS (
+				UPDATE products SET deleted_at = $2 WHERE product_id = $1 AND `+notDeleted+`
+				RETURNING `+productColumns+`
+			), deletion AS (
+				INSERT INTO product_deletions (product_id, deleted_at)
+				SELECT product_id, deleted_at FROM deleted
+			)
+			SELECT `+productColumns+` FROM deleted`,
+			productID, now.UTC(),
+		)
+	})
+	return err
+}
+
+// Restore clears deleted_at on a soft-deleted product and closes its
+// deletion at now in the same statement
+func (r *PostgresRepository) Restore(productID string, now time.Time) error {
+	_, err := r.write(outbox.ActionRestored, func(q querier) (*Product, error) {
+		restored, err := queryOne(q,
+			`WITH restored AS (
+				UPDATE products SET deleted_at = NULL WHERE product_id = $1 AND deleted_at IS NOT NULL
+				RETURNING `+productColumns+`
+			), deletion AS (
+				UPDATE product_deletions SET restored_at = $2
+				FROM restored WHERE product_deletions.product_id = restored.product_id
+				AND product_deletions.restored_at IS NULL
+			)
+			SELECT `+productColumns+` FROM restored`,
+			productID, now.UTC(),
+		)
+		if !errors.Is(err, ErrProductNotFound) {
+			return restored, err
+		}
+
+		// Nothing was restored: tell a live product apart from a missing one
+		if _, err := r.GetByID(productID); err != nil {
+			return nil, err
+		}
+		return nil, ErrProductNotDeleted
+	})
+	return err
+}
+
+// Deletions returns the intervals a product was soft-deleted, oldest first
+func (r *PostgresRepository) Deletions(productID string) ([]*Deletion, error) {
+	if _, err := r.GetByIDIncludingDeleted(productID); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(
+		`SELECT deleted_at, restored_at FROM product_deletions WHERE product_id = $1 ORDER BY deleted_at, id`,
+		productID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query product deletions: %w", err)
+	}
+	defer rows.Close()
+
+	deletions := make([]*Deletion, 0)
+	for rows.Next() {
+		var deletion Deletion
+		if err := rows.Scan(&deletion.DeletedAt, &deletion.RestoredAt); err != nil {
+			return nil, fmt.Errorf("failed to scan product deletion: %w", err)
+		}
+		deletions = append(deletions, &deletion)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read product deletions: %w", err)
+	}
+	return deletions, nil
+}
+
+// AdjustQuantity adds change.Delta to a live product's quantity in a single
+// conditional UPDATE, so concurrent reservations cannot oversell, and records
+// the movement in the same statement
+func (r *PostgresRepository) AdjustQuantity(productID string, change StockChange) (*Product, error) {
+	return r.write(outbox.ActionStockChanged, func(q querier) (*Product, error) {
+		return adjustQuantity(q, productID, change)
+	})
+}
+
+// adjustQuantityTx adjusts the quantity like AdjustQuantity within the
+// unit's database transaction, recording its event there
+func (r *PostgresRepository) adjustQuantityTx(tx *unitofwork.Tx, productID string, change StockChange) (*Product, bool, error) {
+	if tx.SQL() == nil {
+		return nil, false, nil
+	}
+
+	product, err := adjustQuantity(tx.SQL(), productID, change)
+	if err != nil {
+		return nil, true, err
+	}
+	if r.events != nil {
+		if err := r.events.Record(tx.SQL(), outbox.Change{
+			Aggregate:   outbox.AggregateProduct,
+			AggregateID: product.ProductID,
+			Action:      outbox.ActionStockChanged,
+			Data:        product,
+		}); err != nil {
+			return nil, true, err
+		}
+	}
+	return product, true, nil
+}
+
+// adjustQuantity adds change.Delta to a live product's quantity on q
+func adjustQuantity(q querier, productID string, change StockChange) (*Product, error) {
+	product, err := queryOne(q,
+		`WITH adjusted AS (
+			UPDATE products SET quantity = quantity + $2, updated_at = $3, updated_by = $4
+			WHERE product_id = $1 AND quantity + $2 >= 0 AND `+notDeleted+`
+			RETURNING `+productColumns+`
+		), movement AS (
+			INSERT INTO stock_movements (product_id, delta, quantity, reason, actor, created_at)
+			SELECT product_id, $2, quantity, $5, $4, $3 FROM adjusted
+		)
+		SELECT `+productColumns+` FROM adjusted`,
+		productID, change.Delta, change.UpdatedAt, change.UpdatedBy, change.Reason,
+	)
+	if !errors.Is(err, ErrProductNotFound) {
+		return product, err
+	}
+
+	// Nothing was updated: tell a short product apart from a missing one
+	if _, err := queryOne(q, `SELECT `+productColumns+` FROM products WHERE product_id = $1 AND `+notDeleted, productID); err != nil {
+		return nil, err
+	}
+	return nil, ErrInsufficientStock
+}
+
+// write runs change and, when events are recorded, records the product it
+// returns as action in the outbox within the same transaction
+func (r *PostgresRepository) write(action string, change func(q querier) (*Product, error)) (*Product, error) {
+	if r.events == nil {
+		return change(r.db)
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin product write: %w", err)
+	}
+	defer tx.Rollback()
+
+	product, err := change(tx)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.events.Record(tx, outbox.Change{
+		Aggregate:   outbox.AggregateProduct,
+		AggregateID: product.ProductID,
+		Action:      action,
+		Data:        product,
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit product write: %w", err)
+	}
+	return product, nil
+}
+
+// StockMovements returns a page of a live product's stock movements, newest first
+func (r *PostgresRepository) StockMovements(productID string, page pagination.Params) ([]*StockMovement, int, error) {
+	if _, err := r.GetByID(productID); err != nil {
+		return nil, 0, err
+	}
+
+	var total int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM stock_movements WHERE product_id = $1`, productID).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count stock movements: %w", err)
+	}
+
+	query := `SELECT product_id, delta, quantity, reason, actor, created_at FROM stock_movements
+		WHERE product_id = $1 ORDER BY id DESC OFFSET $2`
+	args := []interface{}{productID, page.Offset}
+	if page.Limit > 0 {
+		query += ` LIMIT $3`
+		args = append(args, page.Limit)
+	}
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query stock movements: %w", err)
+	}
+	defer rows.Close()
+
+	movements := make([]*StockMovement, 0)
+	for rows.Next() {
+		var movement StockMovement
+		err := rows.Scan(&movement.ProductID, &movement.Delta, &movement.Quantity,
+			&movement.Reason, &movement.Actor, &movement.CreatedAt)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan stock movement: %w", err)
+		}
+		movements = append(movements, &movement)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to read stock movements: %w", err)
+	}
+	return movements, total, nil
+}
+
+// RecordPriceChange adds a price change to a live product's schedule,
+// assigning its ID
+func (r *PostgresRepository) RecordPriceChange(change *PriceChange) error {
+	err := r.db.QueryRow(
+		`INSERT INTO price_changes (product_id, price, effective_at, created_at, created_by)
+		SELECT product_id, $2, $3, $4, $5 FROM products WHERE product_id = $1 AND `+notDeleted+`
+		RETURNING id`,
+		change.ProductID, change.Price, change.EffectiveAt, change.CreatedAt, change.CreatedBy,
+	).Scan(&change.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrProductNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to record price change: %w", err)
+	}
+	return nil
+}
+
+// PriceChanges returns a live product's price changes in effective order
+func (r *PostgresRepository) PriceChanges(productID string) ([]*PriceChange, error) {
+	if _, err := r.GetByID(productID); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(
+		`SELECT `+priceChangeColumns+` FROM price_changes WHERE product_id = $1 ORDER BY effective_at, id`,
+		productID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query price changes: %w", err)
+	}
+	defer rows.Close()
+
+	changes := make([]*PriceChange, 0)
+	for rows.Next() {
+		var change PriceChange
+		err := rows.Scan(&change.ID, &change.ProductID, &change.Price,
+			&change.EffectiveAt, &change.CreatedAt, &change.CreatedBy)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan price change: %w", err)
+		}
+		changes = append(changes, &change)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read price changes: %w", err)
+	}
+	return changes, nil
+}
+
+// EffectivePrices returns the price valid at at for each product that has a
+// price change effective by then
+func (r *PostgresRepository) EffectivePrices(productIDs []string, at time.Time) (map[string]float64, error) {
+	rows, err := r.db.Query(
+		`SELECT DISTINCT ON (product_id) product_id, price FROM price_changes
+		WHERE product_id = ANY($1) AND effective_at <= $2
+		ORDER BY product_id, effective_at DESC, id DESC`,
+		productIDs, at,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query effective prices: %w", err)
+	}
+	defer rows.Close()
+
+	prices := make(map[string]float64, len(productIDs))
+	for rows.Next() {
+		var productID string
+		var price float64
+		if err := rows.Scan(&productID, &price); err != nil {
+			return nil, fmt.Errorf("failed to scan effective price: %w", err)
+		}
+		prices[productID] = price
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read effective prices: %w", err)
+	}
+	return prices, nil
+}
+
+// CancelPriceChange removes a price change that is not yet effective at now
+func (r *PostgresRepository) CancelPriceChange(productID string, changeID int64, now time.Time) error {
+	result, err := r.db.Exec(
+		`DELETE FROM price_changes WHERE id = $1 AND product_id = $2 AND effective_at > $3
+		AND EXISTS (SELECT 1 FROM products WHERE product_id = $2 AND `+notDeleted+`)`,
+		changeID, productID, now,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to cancel price change: %w", err)
+	}
+	if err := requireRowAffected(result); !errors.Is(err, ErrProductNotFound) {
+		return err
+	}
+
+	// Nothing was deleted: tell a missing product or change from an effective one
+	if _, err := r.GetByID(productID); err != nil {
+		return err
+	}
+	var effective bool
+	err = r.db.QueryRow(`SELECT effective_at <= $3 FROM price_changes WHERE id = $1 AND product_id = $2`,
+		changeID, productID, now).Scan(&effective)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrPriceChangeNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read price change: %w", err)
+	}
+	if effective {
+		return ErrPriceChangeEffective
+	}
+	return ErrPriceChangeNotFound
+}
+
+// Variants returns a live product's variants ordered by SKU
+func (r *PostgresRepository) Variants(productID string) ([]*Variant, error) {
+	if _, err := r.GetByID(productID); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(
+		`SELECT `+variantColumns+` FROM product_variants WHERE product_id = $1 ORDER BY sku`,
+		productID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query variants: %w", err)
+	}
+	defer rows.Close()
+
+	variants := make([]*Variant, 0)
+	for rows.Next() {
+		variant, err := scanVariant(rows)
+		if err != nil {
+			return nil, err
+		}
+		variants = append(variants, variant)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read variants: %w", err)
+	}
+	return variants, nil
+}
+
+// GetVariant retrieves a variant of a live product by SKU
+func (r *PostgresRepository) GetVariant(sku string) (*Variant, error) {
+	variant, err := scanVariant(r.db.QueryRow(
+		`SELECT `+variantColumns+` FROM `+liveVariants+` WHERE product_variants.sku = $1`,
+		sku,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrVariantNotFound
+	}
+	return variant, err
+}
+
+// CreateVariant adds a variant to a live product
+func (r *PostgresRepository) CreateVariant(variant *Variant) error {
+	attributes, err := marshalAttributes(variant)
+	if err != nil {
+		return err
+	}
+
+	result, err := r.db.Exec(
+		`INSERT INTO product_variants (sku, product_id, attributes, price, quantity,
+			created_at, updated_at, created_by, updated_by)
+		SELECT $1, product_id, $3, $4, $5, $6, $7, $8, $9 FROM products
+		WHERE product_id = $2 AND `+notDeleted,
+		variant.SKU, variant.ProductID, attributes, variant.Price, variant.Quantity,
+		variant.CreatedAt, variant.UpdatedAt, variant.CreatedBy, variant.UpdatedBy,
+	)
+	if postgres.IsUniqueViolation(err) {
+		return ErrVariantExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to insert variant: %w", err)
+	}
+	return requireRowAffected(result)
+}
+
+// UpdateVariant replaces an existing variant; its SKU and product do not change
+func (r *PostgresRepository) UpdateVariant(variant *Variant) error {
+	attributes, err := marshalAttributes(variant)
+	if err != nil {
+		return err
+	}
+
+	result, err := r.db.Exec(
+		`UPDATE product_variants SET attributes = $2, price = $3, quantity = $4, updated_at = $5, updated_by = $6
+		FROM products WHERE product_variants.sku = $1
+		AND products.product_id = product_variants.product_id AND products.deleted_at IS NULL`,
+		variant.SKU, attributes, variant.Price, variant.Quantity, variant.UpdatedAt, variant.UpdatedBy,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update variant: %w", err)
+	}
+	return requireVariantAffected(result)
+}
+
+// DeleteVariant removes a variant
+func (r *PostgresRepository) DeleteVariant(sku string) error {
+	result, err := r.db.Exec(
+		`DELETE FROM product_variants USING products WHERE product_variants.sku = $1
+		AND products.product_id = product_variants.product_id AND products.deleted_at IS NULL`,
+		sku,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete variant: %w", err)
+	}
+	return requireVariantAffected(result)
+}
+
+// List returns all live products ordered by ID
+func (r *PostgresRepository) List() ([]*Product, error) {
+	return r.Find(ProductFilter{})
+}
+
+// Find returns the products matching filter, ordered by ProductID
+func (r *PostgresRepository) Find(filter ProductFilter) ([]*Product, error) {
+	where, args := filterClause(filter)
+	query := `SELECT ` + productColumns + ` FROM products` + where + filter.Sort.OrderBy(sortColumns, "product_id")
+
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(` LIMIT $%d`, len(args))
+	}
+	if filter.Offset > 0 {
+		args = append(args, filter.Offset)
+		query += fmt.Sprintf(` OFFSET $%d`, len(args))
+	}
+
+	return r.query(query, args...)
+}
+
+// Count returns the number of products matching filter, ignoring Limit and Offset
+func (r *PostgresRepository) Count(filter ProductFilter) (int, error) {
+	where, args := filterClause(filter)
+
+	var count int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM products`+where, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count products: %w", err)
+	}
+	return count, nil
+}
+
+// sortColumns maps SortFields to their columns
+var sortColumns = map[string]string{
+	"productId": "product_id",
+	"name":      "name",
+	"category":  "category",
+	"price":     "price",
+	"quantity":  "quantity",
+	"createdAt": "created_at",
+	"updatedAt": "updated_at",
+}
+
+// filterClause builds the WHERE clause and its arguments for filter's criteria
+func filterClause(filter ProductFilter) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	if !filter.IncludeDeleted {
+		conditions = append(conditions, notDeleted)
+	}
+	addCondition := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.Category != "" {
+		addCondition("category = $%d", filter.Category)
+	}
+	if len(filter.Categories) > 0 {
+		addCondition("category = ANY($%d)", filter.Categories)
+	}
+	if filter.Search != "" {
+		addCondition("(name ILIKE $%[1]d OR description ILIKE $%[1]d)", "%"+escapeLike(filter.Search)+"%")
+	}
+	if filter.MinPrice != nil {
+		addCondition("price >= $%d", *filter.MinPrice)
+	}
+	if filter.MaxPrice != nil {
+		addCondition("price <= $%d", *filter.MaxPrice)
+	}
+	if filter.InStock != nil {
+		addCondition("(quantity > 0) = $%d", *filter.InStock)
+	}
+	if filter.Barcode != "" {
+		addCondition("barcode = $%d", filter.Barcode)
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+	return ` WHERE ` + strings.Join(conditions, " AND "), args
+}
+
+// getOne runs a single-product SELECT, mapping no rows to ErrProductNotFound
+func (r *PostgresRepository) getOne(query string, args ...interface{}) (*Product, error) {
+	return queryOne(r.db, query, args...)
+}
+
+// querier is satisfied by *sql.DB and *sql.Tx
+type querier interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// queryOne runs a single-product query on q, mapping no rows to ErrProductNotFound
+func queryOne(q querier, query string, args ...interface{}) (*Product, error) {
+	product, err := scanProduct(q.QueryRow(query, args...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrProductNotFound
+	}
+	return product, err
+}
+
+// query runs a product SELECT and scans every row
+func (r *PostgresRepository) query(query string, args ...interface{}) ([]*Product, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query products: %w", err)
+	}
+	defer rows.Close()
+
+	products := make([]*Product, 0)
+	for rows.Next() {
+		product, err := scanProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, product)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read products: %w", err)
+	}
+	return products, nil
+}
+
+// rowScanner is satisfied by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanProduct reads one product row in productColumns order
+func scanProduct(row rowScanner) (*Product, error) {
+	var product Product
+	var prices, weight, dimensions []byte
+	var deletedAt sql.NullTime
+
+	err := row.Scan(
+		&product.ProductID, &product.Name, &product.Description, &product.Price, &product.Currency, &prices,
+		&product.Category, &product.Quantity, &weight, &dimensions, &product.Barcode,
+		&product.CreatedAt, &product.UpdatedAt, &product.CreatedBy, &product.UpdatedBy, &deletedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan product: %w", err)
+	}
+
+	if prices != nil {
+		if err := json.Unmarshal(prices, &product.Prices); err != nil {
+			return nil, fmt.Errorf("failed to decode product prices: %w", err)
+		}
+	}
+	if weight != nil {
+		if err := json.Unmarshal(weight, &product.Weight); err != nil {
+			return nil, fmt.Errorf("failed to decode product weight: %w", err)
+		}
+	}
+	if dimensions != nil {
+		if err := json.Unmarshal(dimensions, &product.Dimensions); err != nil {
+			return nil, fmt.Errorf("failed to decode product dimensions: %w", err)
+		}
+	}
+	if deletedAt.Valid {
+		product.DeletedAt = &deletedAt.Time
+	}
+
+	return &product, nil
+}
+
+// scanVariant reads one variant row in variantColumns order
+func scanVariant(row rowScanner) (*Variant, error) {
+	var variant Variant
+	var attributes []byte
+
+	err := row.Scan(
+		&variant.SKU, &variant.ProductID, &attributes, &variant.Price, &variant.Quantity,
+		&variant.CreatedAt, &variant.UpdatedAt, &variant.CreatedBy, &variant.UpdatedBy,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan variant: %w", err)
+	}
+
+	if attributes != nil {
+		if err := json.Unmarshal(attributes, &variant.Attributes); err != nil {
+			return nil, fmt.Errorf("failed to decode variant attributes: %w", err)
+		}
+	}
+	return &variant, nil
+}
+
+// marshalAttributes encodes a variant's optional attributes, using NULL when unset
+func marshalAttributes(variant *Variant) (interface{}, error) {
+	if len(variant.Attributes) == 0 {
+		return nil, nil
+	}
+	encoded, err := json.Marshal(variant.Attributes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode variant attributes: %w", err)
+	}
+	return string(encoded), nil
+}
+
+// marshalJSONColumns encodes optional prices, weight and dimensions, using
+// NULL when unset
+func marshalJSONColumns(product *Product) (prices, weight, dimensions interface{}, err error) {
+	if len(product.Prices) > 0 {
+		encoded, err := json.Marshal(product.Prices)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to encode product prices: %w", err)
+		}
+		prices = string(encoded)
+	}
+
+	if product.Weight != nil {
+		encoded, err := json.Marshal(product.Weight)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to encode product weight: %w", err)
+		}
+		weight = string(encoded)
+	}
+
+	if product.Dimensions != nil {
+		encoded, err := json.Marshal(product.Dimensions)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to encode product dimensions: %w", err)
+		}
+		dimensions = string(encoded)
+	}
+
+	return prices, weight, dimensions, nil
+}
+
+// escapeLike escapes LIKE wildcards so search terms match literally
+func escapeLike(term string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(term)
+}
+
+// requireRowAffected maps an UPDATE/DELETE that touched no rows to ErrProductNotFound
+func requireRowAffected(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to read affected rows: %w", err)
+	}
+	if affected == 0 {
+		return ErrProductNotFound
+	}
+	return nil
+}
+
+// requireVariantAffected maps a variant UPDATE/DELETE that touched no rows to
+// ErrVariantNotFound
+func requireVariantAffected(result sql.Result) error {
+	if err := requireRowAffected(result); errors.Is(err, ErrProductNotFound) {
+		return ErrVariantNotFound
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package product
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"enricher-api-go/internal/apperr"
+)
+
+// PriceDecimalPlaces is the number of decimal places allowed by the base currency
+const PriceDecimalPlaces = 2
+
+// ErrInvalidPrice is returned when a price in a request body is malformed
+var ErrInvalidPrice = apperr.New(apperr.ErrValidation, "invalid price")
+
+// UnmarshalJSON decodes a ProductRequest, validating the raw price literal.
+//
+// Prices must be plain JSON numbers: scientific notation such as 1e3 and
+// quoted strings are rejected. Trailing zeros beyond the currency's decimal
+// places are accepted and normalized (999.000 becomes 999.00), but any
+// further non-zero precision (999.001) is rejected.
+func (r *ProductRequest) UnmarshalJSON(data []byte) error {
+	type alias ProductRequest
+	aux := struct {
+		*alias
+		Price json.RawMessage `json:"price"`
+	}{alias: (*alias)(r)}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	if len(aux.Price) == 0 || string(aux.Price) == "null" {
+		return nil
+	}
+
+	price, err := ParsePrice(string(aux.Price))
+	if err != nil {
+		return err
+	}
+	r.Price = price
+	return nil
+}
+
+// UnmarshalJSON decodes a ProductPatch, validating a present price literal
+// with the same rules as ProductRequest
+func (p *ProductPatch) UnmarshalJSON(data []byte) error {
+	type alias ProductPatch
+	aux := struct {
+		*alias
+		Price json.RawMessage `json:"price"`
+	}{alias: (*alias)(p)}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	if len(aux.Price) == 0 || string(aux.Price) == "null" {
+		return nil
+	}
+
+	price, err := ParsePrice(string(aux.Price))
+	if err != nil {
+		return err
+	}
+	p.Price = &price
+	return nil
+}
+
+// UnmarshalJSON decodes a PriceChangeRequest, validating the price literal
+// with the same rules as ProductRequest
+func (r *PriceChangeRequest) UnmarshalJSON(data []byte) error {
+	type alias PriceChangeRequest
+	aux := struct {
+		*alias
+		Price json.RawMessage `json:"price"`
+	}{alias: (*alias)(r)}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	if len(aux.Price) == 0 || string(aux.Price) == "null" {
+		return nil
+	}
+
+	price, err := ParsePrice(string(aux.Price))
+	if err != nil {
+		return err
+	}
+	r.Price = price
+	return nil
+}
+
+// UnmarshalJSON decodes a VariantRequest, validating the price literal with
+// the same rules as ProductRequest
+func (r *VariantRequest) UnmarshalJSON(data []byte) error {
+	type alias VariantRequest
+	aux := struct {
+		*alias
+		Price json.RawMessage `json:"price"`
+	}{alias: (*alias)(r)}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	if len(aux.Price) == 0 || string(aux.Price) == "null" {
+		return nil
+	}
+
+	price, err := ParsePrice(string(aux.Price))
+	if err != nil {
+		return err
+	}
+	r.Price = price
+	return nil
+}
+
+// ParsePrice validates and normalizes a raw price literal, such as a JSON
+// number or a CSV cell
+func ParsePrice(raw string) (float64, error) {
+	literal := strings.TrimSpace(raw)
+
+	if strings.HasPrefix(literal, `"`) {
+		return 0, fmt.Errorf("%w: must be a JSON number, got %s", ErrInvalidPrice, literal)
+	}
+
+	if strings.ContainsAny(literal, "eE") {
+		return 0, fmt.Errorf("%w: scientific notation is not allowed, got %s", ErrInvalidPrice, literal)
+	}
+
+	value, err := strconv.ParseFloat(literal, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: malformed number %s", ErrInvalidPrice, literal)
+	}
+
+	if dot := strings.IndexByte(literal, '.'); dot >= 0 {
+		fraction := strings.TrimRight(literal[dot+1:], "0")
+		if len(fraction) > PriceDecimalPlaces {
+			return 0, fmt.Errorf("%w: at most %d decimal places allowed, got %s", ErrInvalidPrice, PriceDecimalPlaces, literal)
+		}
+	}
+
+	scale := math.Pow10(PriceDecimalPlaces)
+	return math.Round(value*scale) / scale, nil
+}
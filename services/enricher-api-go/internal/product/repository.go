@@ -1,44 +1,248 @@
 package product
 
 import (
-	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
 	"sync"
+	"time"
+
+	"enricher-api-go/internal/apperr"
+	"enricher-api-go/internal/currency"
+	"enricher-api-go/internal/outbox"
+	"enricher-api-go/internal/pagination"
+	"enricher-api-go/internal/unitofwork"
+)
+
+var (
+	// ErrProductNotFound is returned when no product has the requested ID
+	ErrProductNotFound = apperr.New(apperr.ErrNotFound, "product not found")
+	// ErrProductExists is returned when creating a product whose ID is taken
+	ErrProductExists = apperr.New(apperr.ErrConflict, "product already exists")
+	// ErrProductNotDeleted is returned when restoring a product that is not deleted
+	ErrProductNotDeleted = apperr.New(apperr.ErrConflict, "product is not deleted")
+	// ErrInsufficientStock is returned when a reservation exceeds the available quantity
+	ErrInsufficientStock = apperr.New(apperr.ErrConflict, "insufficient stock")
+	// ErrPriceChangeNotFound is returned when a product has no price change with the requested ID
+	ErrPriceChangeNotFound = apperr.New(apperr.ErrNotFound, "price change not found")
+	// ErrPriceChangeEffective is returned when cancelling a price change that already applies
+	ErrPriceChangeEffective = apperr.New(apperr.ErrConflict, "price change already in effect")
+	// ErrVariantNotFound is returned when no variant of a live product has the requested SKU
+	ErrVariantNotFound = apperr.New(apperr.ErrNotFound, "variant not found")
+	// ErrVariantExists is returned when creating a variant whose SKU is taken
+	ErrVariantExists = apperr.New(apperr.ErrConflict, "variant already exists")
 )
 
-var ErrProductNotFound = errors.New("product not found")
+// StockChange is an atomic adjustment of a product's quantity
+type StockChange struct {
+	// Delta is added to the quantity: negative to reserve, positive to release
+	Delta int
+	// UpdatedAt and UpdatedBy stamp the product like any other change
+	UpdatedAt time.Time
+	UpdatedBy string
+	// Reason is recorded on the resulting StockMovement
+	Reason string
+}
+
+// Write is one product stored by Repository.WriteAll
+type Write struct {
+	// Product is stored as given
+	Product *Product
+	// Create adds Product like Repository.Create; otherwise it replaces the
+	// stored product like Repository.Update
+	Create bool
+}
+
+// WriteError reports the write that failed a Repository.WriteAll
+type WriteError struct {
+	// Index is the position of the failed write
+	Index int
+	Err   error
+}
 
-// Repository defines the interface for product data access
+// Error describes the failed write
+func (e *WriteError) Error() string {
+	return fmt.Sprintf("write %d: %v", e.Index, e.Err)
+}
+
+// Unwrap returns the error of the failed write
+func (e *WriteError) Unwrap() error {
+	return e.Err
+}
+
+// Repository defines the interface for product data access.
+//
+// Delete is a soft delete: it stamps DeletedAt and keeps the record. Every
+// read except GetByIDIncludingDeleted and a Find or Count with
+// IncludeDeleted skips soft-deleted products, and Update and Delete treat
+// them as missing.
+//
+// AdjustQuantity applies a StockChange in one step, so concurrent
+// reservations never oversell: it fails with ErrInsufficientStock, changing
+// nothing, when the quantity would drop below zero.
+//
+// Every write that changes a quantity (Create with stock, Update to a new
+// quantity and AdjustQuantity) records a StockMovement in the same step.
+// StockMovements returns a product's movements newest first, along with
+// their total count.
+//
+// Create, and an Update that moves the price away from the one in effect,
+// likewise record a PriceChange effective at CreatedAt or UpdatedAt. EffectivePrices resolves the price
+// valid at an instant from those changes; products with none keep their
+// stored price.
+//
+// Delete and Restore likewise open and close a Deletion at now, so
+// Deletions can tell whether a product was deleted at a past instant.
+//
+// Variants belong to a product and are keyed by a SKU unique across all
+// products. Variants of a soft-deleted product are hidden with it.
+//
+// WriteAll stores several creates and updates as one unit: either every
+// write is applied, in order, or none is and a *WriteError names the write
+// that failed.
 type Repository interface {
 	GetByID(productID string) (*Product, error)
+	GetByIDIncludingDeleted(productID string) (*Product, error)
+	GetByIDs(productIDs []string) ([]*Product, error)
 	Create(product *Product) error
 	Update(product *Product) error
-	Delete(productID string) error
+	WriteAll(writes []Write) error
+	Delete(productID string, now time.Time) error
+	Restore(productID string, now time.Time) error
+	Deletions(productID string) ([]*Deletion, error)
+	AdjustQuantity(productID string, change StockChange) (*Product, error)
+	StockMovements(productID string, page pagination.Params) ([]*StockMovement, int, error)
+	RecordPriceChange(change *PriceChange) error
+	PriceChanges(productID string) ([]*PriceChange, error)
+	EffectivePrices(productIDs []string, at time.Time) (map[string]float64, error)
+	CancelPriceChange(productID string, changeID int64, now time.Time) error
+	Variants(productID string) ([]*Variant, error)
+	GetVariant(sku string) (*Variant, error)
+	CreateVariant(variant *Variant) error
+	UpdateVariant(variant *Variant) error
+	DeleteVariant(sku string) error
 	List() ([]*Product, error)
-	GetByCategory(category string) ([]*Product, error)
+	Find(filter ProductFilter) ([]*Product, error)
+	Count(filter ProductFilter) (int, error)
+}
+
+// txAdjuster is implemented by repositories that can adjust a quantity
+// within a unit of work's database transaction; joined reports whether
+// they did
+type txAdjuster interface {
+	adjustQuantityTx(tx *unitofwork.Tx, productID string, change StockChange) (product *Product, joined bool, err error)
+}
+
+// adjustQuantityIn applies change as part of tx: within its database
+// transaction when repo can join it, and otherwise right away with an
+// opposite adjustment to compensate it if the unit fails
+func adjustQuantityIn(tx *unitofwork.Tx, repo Repository, productID string, change StockChange) (*Product, error) {
+	if adjuster, ok := repo.(txAdjuster); ok {
+		if product, joined, err := adjuster.adjustQuantityTx(tx, productID, change); joined {
+			return product, err
+		}
+	}
+
+	product, err := repo.AdjustQuantity(productID, change)
+	if err != nil {
+		return nil, err
+	}
+	tx.OnRollback(func() error {
+		undo := change
+		undo.Delta, undo.Reason = -change.Delta, ReasonCompensate
+		_, err := repo.AdjustQuantity(productID, undo)
+		return err
+	})
+	return product, nil
 }
 
 // InMemoryRepository implements Repository interface using in-memory storage
 type InMemoryRepository struct {
-	products map[string]*Product
-	mutex    sync.RWMutex
+	products      map[string]*Product
+	movements     map[string][]*StockMovement
+	priceChanges  map[string][]*PriceChange
+	priceChangeID int64
+	deletions     map[string][]*Deletion
+	variants      map[string]*Variant
+	// events records every product change; nil records none
+	events *outbox.InMemoryStore
+	// samples are the products the repository starts with and resets to
+	samples []*Product
+	mutex   sync.RWMutex
 }
 
 // NewInMemoryRepository creates a new in-memory product repository with sample data
 func NewInMemoryRepository() *InMemoryRepository {
-	repo := &InMemoryRepository{
-		products: make(map[string]*Product),
-		mutex:    sync.RWMutex{},
+	return NewInMemoryRepositoryWith(sampleProducts())
+}
+
+// NewInMemoryRepositoryWith creates an in-memory product repository holding
+// products, such as fixtures loaded from a seed file; Reset returns to them
+func NewInMemoryRepositoryWith(products []*Product) *InMemoryRepository {
+	repo := &InMemoryRepository{samples: products}
+	repo.seed()
+	return repo
+}
+
+// Reset replaces every product, with its stock movements, price changes,
+// deletions and variants, with the products the repository started with
+func (r *InMemoryRepository) Reset() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.seed()
+}
+
+// seed replaces the repository's contents with its samples
+func (r *InMemoryRepository) seed() {
+	r.products = make(map[string]*Product)
+	r.movements = make(map[string][]*StockMovement)
+	r.priceChanges = make(map[string][]*PriceChange)
+	r.priceChangeID = 0
+	r.deletions = make(map[string][]*Deletion)
+	r.variants = make(map[string]*Variant)
+
+	seededAt := time.Now().UTC()
+	for _, sample := range r.samples {
+		product := *sample
+		product.Prices = maps.Clone(sample.Prices)
+		if product.CreatedAt.IsZero() {
+			product.CreatedAt = seededAt
+		}
+		if product.UpdatedAt.IsZero() {
+			product.UpdatedAt = product.CreatedAt
+		}
+		if product.Currency == "" {
+			product.Currency = currency.DefaultCode
+		}
+		r.products[product.ProductID] = &product
+		// As on create, the starting price opens the product's price history
+		r.appendPriceChange(&PriceChange{
+			ProductID:   product.ProductID,
+			Price:       product.Price,
+			EffectiveAt: product.CreatedAt,
+			CreatedAt:   product.CreatedAt,
+			CreatedBy:   product.CreatedBy,
+		})
+		if product.DeletedAt != nil {
+			r.deletions[product.ProductID] = []*Deletion{{DeletedAt: *product.DeletedAt}}
+		}
 	}
+}
 
-	// Add sample products
-	sampleProducts := []*Product{
+// sampleProducts returns the products an in-memory repository starts with when
+// no seed file is configured
+func sampleProducts() []*Product {
+	return []*Product{
 		{
 			ProductID:   "product-789",
 			Name:        "Laptop",
 			Description: "14-inch ultrabook with 16GB RAM",
 			Price:       999.00,
+			Prices:      map[string]float64{"EUR": 929.00},
 			Category:    "Electronics",
-			InStock:     true,
+			Quantity:    25,
 		},
 		{
 			ProductID:   "product-123",
@@ -46,7 +250,7 @@ func NewInMemoryRepository() *InMemoryRepository {
 			Description: "Ergonomic wireless mouse with USB receiver",
 			Price:       25.99,
 			Category:    "Electronics",
-			InStock:     true,
+			Quantity:    150,
 		},
 		{
 			ProductID:   "product-456",
@@ -54,7 +258,7 @@ func NewInMemoryRepository() *InMemoryRepository {
 			Description: "Comfortable ergonomic office chair",
 			Price:       199.99,
 			Category:    "Furniture",
-			InStock:     true,
+			Quantity:    8,
 		},
 		{
 			ProductID:   "product-101",
@@ -62,7 +266,7 @@ func NewInMemoryRepository() *InMemoryRepository {
 			Description: "Ceramic coffee mug 350ml",
 			Price:       12.50,
 			Category:    "Kitchen",
-			InStock:     true,
+			Quantity:    60,
 		},
 		{
 			ProductID:   "product-202",
@@ -70,19 +274,25 @@ func NewInMemoryRepository() *InMemoryRepository {
 			Description: "LED desk lamp with adjustable brightness",
 			Price:       45.00,
 			Category:    "Electronics",
-			InStock:     false,
+			Quantity:    0,
 		},
 	}
+}
 
-	for _, product := range sampleProducts {
-		repo.products[product.ProductID] = product
+// GetByID retrieves a live product by ID
+func (r *InMemoryRepository) GetByID(productID string) (*Product, error) {
+	product, err := r.GetByIDIncludingDeleted(productID)
+	if err != nil {
+		return nil, err
 	}
-
-	return repo
+	if product.IsDeleted() {
+		return nil, ErrProductNotFound
+	}
+	return product, nil
 }
 
-// GetByID retrieves a product by ID
-func (r *InMemoryRepository) GetByID(productID string) (*Product, error) {
+// GetByIDIncludingDeleted retrieves a product by ID even if it is soft-deleted
+func (r *InMemoryRepository) GetByIDIncludingDeleted(productID string) (*Product, error) {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 
@@ -96,17 +306,69 @@ func (r *InMemoryRepository) GetByID(productID string) (*Product, error) {
 	return &productCopy, nil
 }
 
+// GetByIDs retrieves the live products with the given IDs, skipping unknown ones
+func (r *InMemoryRepository) GetByIDs(productIDs []string) ([]*Product, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	products := make([]*Product, 0, len(productIDs))
+	for _, productID := range productIDs {
+		if product, exists := r.products[productID]; exists && !product.IsDeleted() {
+			productCopy := *product
+			products = append(products, &productCopy)
+		}
+	}
+
+	return products, nil
+}
+
+// RecordEventsTo records every change of a product in store, under the lock
+// that stores the change. Price schedules and variants are not recorded.
+func (r *InMemoryRepository) RecordEventsTo(store *outbox.InMemoryStore) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.events = store
+}
+
+// record adds an event for a change of product when events are recorded;
+// callers hold the write lock
+func (r *InMemoryRepository) record(action string, product *Product) error {
+	if r.events == nil {
+		return nil
+	}
+	return r.events.Record(outbox.Change{
+		Aggregate:   outbox.AggregateProduct,
+		AggregateID: product.ProductID,
+		Action:      action,
+		Data:        product,
+	})
+}
+
 // Create adds a new product
 func (r *InMemoryRepository) Create(product *Product) error {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
+	return r.create(product)
+}
+
+// create adds a new product; callers hold the write lock
+func (r *InMemoryRepository) create(product *Product) error {
 	if _, exists := r.products[product.ProductID]; exists {
-		return errors.New("product already exists")
+		return ErrProductExists
 	}
 
 	r.products[product.ProductID] = product
-	return nil
+	r.recordMovement(product, product.Quantity, ReasonCreate, product.CreatedBy, product.CreatedAt)
+	r.appendPriceChange(&PriceChange{
+		ProductID:   product.ProductID,
+		Price:       product.Price,
+		EffectiveAt: product.CreatedAt,
+		CreatedAt:   product.CreatedAt,
+		CreatedBy:   product.CreatedBy,
+	})
+	return r.record(outbox.ActionCreated, product)
 }
 
 // Update modifies an existing product
@@ -114,34 +376,380 @@ func (r *InMemoryRepository) Update(product *Product) error {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	if _, exists := r.products[product.ProductID]; !exists {
+	return r.update(product)
+}
+
+// update replaces an existing product; callers hold the write lock
+func (r *InMemoryRepository) update(product *Product) error {
+	existing, exists := r.products[product.ProductID]
+	if !exists || existing.IsDeleted() {
 		return ErrProductNotFound
 	}
 
 	r.products[product.ProductID] = product
+	r.recordMovement(product, product.Quantity-existing.Quantity, ReasonUpdate, product.UpdatedBy, product.UpdatedAt)
+	previousPrice := existing.Price
+	if latest, ok := r.latestPriceChange(product.ProductID, product.UpdatedAt); ok {
+		previousPrice = latest.Price
+	}
+	if product.Price != previousPrice {
+		r.appendPriceChange(&PriceChange{
+			ProductID:   product.ProductID,
+			Price:       product.Price,
+			EffectiveAt: product.UpdatedAt,
+			CreatedAt:   product.UpdatedAt,
+			CreatedBy:   product.UpdatedBy,
+		})
+	}
+	return r.record(outbox.ActionUpdated, product)
+}
+
+// WriteAll applies every write or, when one would fail, none of them
+func (r *InMemoryRepository) WriteAll(writes []Write) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	// Check every write before applying any, accounting for products
+	// created earlier in the same call
+	created := make(map[string]bool, len(writes))
+	for i, write := range writes {
+		productID := write.Product.ProductID
+		existing, exists := r.products[productID]
+		exists = exists || created[productID]
+		switch {
+		case write.Create && exists:
+			return &WriteError{Index: i, Err: ErrProductExists}
+		case !write.Create && (!exists || !created[productID] && existing.IsDeleted()):
+			return &WriteError{Index: i, Err: ErrProductNotFound}
+		}
+		created[productID] = created[productID] || write.Create
+	}
+
+	for i, write := range writes {
+		apply := r.update
+		if write.Create {
+			apply = r.create
+		}
+		if err := apply(write.Product); err != nil {
+			return &WriteError{Index: i, Err: err}
+		}
+	}
 	return nil
 }
 
-// Delete removes a product
-func (r *InMemoryRepository) Delete(productID string) error {
+// Delete soft-deletes a product by stamping DeletedAt with now
+func (r *InMemoryRepository) Delete(productID string, now time.Time) error {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
+	existing, exists := r.products[productID]
+	if !exists || existing.IsDeleted() {
+		return ErrProductNotFound
+	}
+
+	deleted := *existing
+	deletedAt := now.UTC()
+	deleted.DeletedAt = &deletedAt
+	r.products[productID] = &deleted
+	r.deletions[productID] = append(r.deletions[productID], &Deletion{DeletedAt: deletedAt})
+	return r.record(outbox.ActionDeleted, &deleted)
+}
+
+// Restore clears DeletedAt on a soft-deleted product, closing its deletion
+// at now
+func (r *InMemoryRepository) Restore(productID string, now time.Time) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	existing, exists := r.products[productID]
+	if !exists {
+		return ErrProductNotFound
+	}
+	if !existing.IsDeleted() {
+		return ErrProductNotDeleted
+	}
+
+	restored := *existing
+	restored.DeletedAt = nil
+	r.products[productID] = &restored
+	if deletions := r.deletions[productID]; len(deletions) > 0 {
+		restoredAt := now.UTC()
+		closed := *deletions[len(deletions)-1]
+		closed.RestoredAt = &restoredAt
+		deletions[len(deletions)-1] = &closed
+	}
+	return r.record(outbox.ActionRestored, &restored)
+}
+
+// Deletions returns the intervals a product was soft-deleted, oldest first
+func (r *InMemoryRepository) Deletions(productID string) ([]*Deletion, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
 	if _, exists := r.products[productID]; !exists {
+		return nil, ErrProductNotFound
+	}
+	return slices.Clone(r.deletions[productID]), nil
+}
+
+// AdjustQuantity adds change.Delta to a live product's quantity
+func (r *InMemoryRepository) AdjustQuantity(productID string, change StockChange) (*Product, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	existing, exists := r.products[productID]
+	if !exists || existing.IsDeleted() {
+		return nil, ErrProductNotFound
+	}
+	if existing.Quantity+change.Delta < 0 {
+		return nil, ErrInsufficientStock
+	}
+
+	adjusted := *existing
+	adjusted.Quantity += change.Delta
+	adjusted.UpdatedAt, adjusted.UpdatedBy = change.UpdatedAt, change.UpdatedBy
+	r.products[productID] = &adjusted
+	r.recordMovement(&adjusted, change.Delta, change.Reason, change.UpdatedBy, change.UpdatedAt)
+	if err := r.record(outbox.ActionStockChanged, &adjusted); err != nil {
+		return nil, err
+	}
+
+	productCopy := adjusted
+	return &productCopy, nil
+}
+
+// StockMovements returns a page of a live product's stock movements, newest first
+func (r *InMemoryRepository) StockMovements(productID string, page pagination.Params) ([]*StockMovement, int, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	if product, exists := r.products[productID]; !exists || product.IsDeleted() {
+		return nil, 0, ErrProductNotFound
+	}
+
+	recorded := r.movements[productID]
+	movements := make([]*StockMovement, 0, len(recorded))
+	for i := len(recorded) - 1; i >= 0; i-- {
+		movementCopy := *recorded[i]
+		movements = append(movements, &movementCopy)
+	}
+
+	return paginate(movements, page.Limit, page.Offset), len(recorded), nil
+}
+
+// RecordPriceChange adds a price change to a live product's schedule,
+// assigning its ID
+func (r *InMemoryRepository) RecordPriceChange(change *PriceChange) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if product, exists := r.products[change.ProductID]; !exists || product.IsDeleted() {
 		return ErrProductNotFound
 	}
 
-	delete(r.products, productID)
+	r.appendPriceChange(change)
 	return nil
 }
 
-// List returns all products
+// PriceChanges returns a live product's price changes in effective order
+func (r *InMemoryRepository) PriceChanges(productID string) ([]*PriceChange, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	if product, exists := r.products[productID]; !exists || product.IsDeleted() {
+		return nil, ErrProductNotFound
+	}
+
+	changes := make([]*PriceChange, 0, len(r.priceChanges[productID]))
+	for _, change := range r.priceChanges[productID] {
+		changeCopy := *change
+		changes = append(changes, &changeCopy)
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].EffectiveAt.Before(changes[j].EffectiveAt)
+	})
+	return changes, nil
+}
+
+// EffectivePrices returns the price valid at at for each product that has a
+// price change effective by then
+func (r *InMemoryRepository) EffectivePrices(productIDs []string, at time.Time) (map[string]float64, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	prices := make(map[string]float64, len(productIDs))
+	for _, productID := range productIDs {
+		if latest, ok := r.latestPriceChange(productID, at); ok {
+			prices[productID] = latest.Price
+		}
+	}
+
+	return prices, nil
+}
+
+// latestPriceChange returns the product's price change in effect at at;
+// callers hold the lock
+func (r *InMemoryRepository) latestPriceChange(productID string, at time.Time) (*PriceChange, bool) {
+	var latest *PriceChange
+	for _, change := range r.priceChanges[productID] {
+		// Later-recorded changes win ties, so scan in recording order
+		if !change.EffectiveAt.After(at) && (latest == nil || !change.EffectiveAt.Before(latest.EffectiveAt)) {
+			latest = change
+		}
+	}
+	return latest, latest != nil
+}
+
+// CancelPriceChange removes a price change that is not yet effective at now
+func (r *InMemoryRepository) CancelPriceChange(productID string, changeID int64, now time.Time) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if product, exists := r.products[productID]; !exists || product.IsDeleted() {
+		return ErrProductNotFound
+	}
+
+	changes := r.priceChanges[productID]
+	for i, change := range changes {
+		if change.ID != changeID {
+			continue
+		}
+		if !change.EffectiveAt.After(now) {
+			return ErrPriceChangeEffective
+		}
+		r.priceChanges[productID] = append(changes[:i:i], changes[i+1:]...)
+		return nil
+	}
+
+	return ErrPriceChangeNotFound
+}
+
+// Variants returns a live product's variants ordered by SKU
+func (r *InMemoryRepository) Variants(productID string) ([]*Variant, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	if product, exists := r.products[productID]; !exists || product.IsDeleted() {
+		return nil, ErrProductNotFound
+	}
+
+	variants := make([]*Variant, 0)
+	for _, variant := range r.variants {
+		if variant.ProductID == productID {
+			variantCopy := *variant
+			variants = append(variants, &variantCopy)
+		}
+	}
+	sort.Slice(variants, func(i, j int) bool { return variants[i].SKU < variants[j].SKU })
+	return variants, nil
+}
+
+// GetVariant retrieves a variant of a live product by SKU
+func (r *InMemoryRepository) GetVariant(sku string) (*Variant, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	variant, err := r.liveVariant(sku)
+	if err != nil {
+		return nil, err
+	}
+	variantCopy := *variant
+	return &variantCopy, nil
+}
+
+// CreateVariant adds a variant to a live product
+func (r *InMemoryRepository) CreateVariant(variant *Variant) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if product, exists := r.products[variant.ProductID]; !exists || product.IsDeleted() {
+		return ErrProductNotFound
+	}
+	if _, exists := r.variants[variant.SKU]; exists {
+		return ErrVariantExists
+	}
+
+	variantCopy := *variant
+	r.variants[variant.SKU] = &variantCopy
+	return nil
+}
+
+// UpdateVariant replaces an existing variant; its SKU and product do not change
+func (r *InMemoryRepository) UpdateVariant(variant *Variant) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	existing, err := r.liveVariant(variant.SKU)
+	if err != nil {
+		return err
+	}
+
+	variantCopy := *variant
+	variantCopy.ProductID = existing.ProductID
+	r.variants[variant.SKU] = &variantCopy
+	return nil
+}
+
+// DeleteVariant removes a variant
+func (r *InMemoryRepository) DeleteVariant(sku string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, err := r.liveVariant(sku); err != nil {
+		return err
+	}
+	delete(r.variants, sku)
+	return nil
+}
+
+// liveVariant returns the stored variant with sku if its product is live;
+// callers hold the lock
+func (r *InMemoryRepository) liveVariant(sku string) (*Variant, error) {
+	variant, exists := r.variants[sku]
+	if !exists {
+		return nil, ErrVariantNotFound
+	}
+	if product, exists := r.products[variant.ProductID]; !exists || product.IsDeleted() {
+		return nil, ErrVariantNotFound
+	}
+	return variant, nil
+}
+
+// appendPriceChange assigns change an ID and stores it; callers hold the write lock
+func (r *InMemoryRepository) appendPriceChange(change *PriceChange) {
+	r.priceChangeID++
+	change.ID = r.priceChangeID
+
+	changeCopy := *change
+	r.priceChanges[change.ProductID] = append(r.priceChanges[change.ProductID], &changeCopy)
+}
+
+// recordMovement appends a movement for a non-zero delta; callers hold the write lock
+func (r *InMemoryRepository) recordMovement(product *Product, delta int, reason, actor string, at time.Time) {
+	if delta == 0 {
+		return
+	}
+	r.movements[product.ProductID] = append(r.movements[product.ProductID], &StockMovement{
+		ProductID: product.ProductID,
+		Delta:     delta,
+		Quantity:  product.Quantity,
+		Reason:    reason,
+		Actor:     actor,
+		CreatedAt: at,
+	})
+}
+
+// List returns all live products
 func (r *InMemoryRepository) List() ([]*Product, error) {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 
 	products := make([]*Product, 0, len(r.products))
 	for _, product := range r.products {
+		if product.IsDeleted() {
+			continue
+		}
 		productCopy := *product
 		products = append(products, &productCopy)
 	}
@@ -149,18 +757,48 @@ func (r *InMemoryRepository) List() ([]*Product, error) {
 	return products, nil
 }
 
-// GetByCategory returns products filtered by category
-func (r *InMemoryRepository) GetByCategory(category string) ([]*Product, error) {
+// Find returns the products matching filter, ordered by ProductID
+func (r *InMemoryRepository) Find(filter ProductFilter) ([]*Product, error) {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 
-	var products []*Product
+	products := make([]*Product, 0)
 	for _, product := range r.products {
-		if product.Category == category {
+		if filter.Matches(product) {
 			productCopy := *product
 			products = append(products, &productCopy)
 		}
 	}
 
-	return products, nil
+	slices.SortFunc(products, filter.Compare)
+
+	return paginate(products, filter.Limit, filter.Offset), nil
+}
+
+// Count returns the number of products matching filter, ignoring Limit and Offset
+func (r *InMemoryRepository) Count(filter ProductFilter) (int, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	count := 0
+	for _, product := range r.products {
+		if filter.Matches(product) {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// paginate returns the window of items selected by limit and offset
+func paginate[T any](items []T, limit, offset int) []T {
+	if offset >= len(items) {
+		return items[:0]
+	}
+	items = items[offset:]
+
+	if limit > 0 && limit < len(items) {
+		items = items[:limit]
+	}
+	return items
 }
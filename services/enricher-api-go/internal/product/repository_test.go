@@ -0,0 +1,703 @@
+package product
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"enricher-api-go/internal/cache"
+	"enricher-api-go/internal/dynamo"
+	"enricher-api-go/internal/listing"
+	"enricher-api-go/internal/migrations"
+	"enricher-api-go/internal/outbox"
+	"enricher-api-go/internal/pagination"
+	"enricher-api-go/internal/sqlite"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// testRepositoryConformance runs the behavior every Repository implementation
+// must share against a repository created by newRepo.
+func testRepositoryConformance(t *testing.T, newRepo func(t *testing.T) Repository) {
+	newProduct := func(id, name, category string, price float64, quantity int) *Product {
+		return &Product{
+			ProductID:   id,
+			Name:        name,
+			Description: name + " for conformance testing",
+			Price:       price,
+			Category:    category,
+			Quantity:    quantity,
+		}
+	}
+
+	t.Run("Create and GetByID", func(t *testing.T) {
+		repo := newRepo(t)
+		product := newProduct("conformance-1", "Kettle", "Conformance", 30, 10)
+		product.Currency, product.Prices = "EUR", map[string]float64{"USD": 33}
+		product.Weight = &Weight{Value: 1.2, Unit: WeightUnitKilogram}
+		product.Dimensions = &Dimensions{Length: 25, Width: 20, Height: 22, Unit: LengthUnitCentimeter}
+
+		if err := repo.Create(product); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		retrieved, err := repo.GetByID("conformance-1")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if retrieved.Name != "Kettle" || retrieved.Price != 30 || retrieved.Quantity != 10 {
+			t.Errorf("Expected stored product, got %+v", retrieved)
+		}
+		if retrieved.Currency != "EUR" || retrieved.Prices["USD"] != 33 {
+			t.Errorf("Expected EUR pricing with a USD price, got %s %v", retrieved.Currency, retrieved.Prices)
+		}
+		if retrieved.Weight == nil || *retrieved.Weight != *product.Weight {
+			t.Errorf("Expected weight %+v, got %+v", product.Weight, retrieved.Weight)
+		}
+		if retrieved.Dimensions == nil || *retrieved.Dimensions != *product.Dimensions {
+			t.Errorf("Expected dimensions %+v, got %+v", product.Dimensions, retrieved.Dimensions)
+		}
+	})
+
+	t.Run("Audit fields", func(t *testing.T) {
+		repo := newRepo(t)
+		created := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+		product := newProduct("conformance-audit", "Waffle Iron", "Conformance", 45, 10)
+		product.CreatedAt, product.UpdatedAt = created, created
+		product.CreatedBy, product.UpdatedBy = "ci", "ci"
+		if err := repo.Create(product); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		updated := created.Add(time.Hour)
+		product.Price, product.UpdatedAt, product.UpdatedBy = 50, updated, "ops"
+		if err := repo.Update(product); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		retrieved, err := repo.GetByID("conformance-audit")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if !retrieved.CreatedAt.Equal(created) || retrieved.CreatedBy != "ci" {
+			t.Errorf("Expected creation audit to be kept, got %s by %q", retrieved.CreatedAt, retrieved.CreatedBy)
+		}
+		if !retrieved.UpdatedAt.Equal(updated) || retrieved.UpdatedBy != "ops" {
+			t.Errorf("Expected update audit to be stored, got %s by %q", retrieved.UpdatedAt, retrieved.UpdatedBy)
+		}
+	})
+
+	t.Run("Create duplicate", func(t *testing.T) {
+		repo := newRepo(t)
+		product := newProduct("conformance-2", "Toaster", "Conformance", 40, 10)
+
+		if err := repo.Create(product); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if err := repo.Create(product); !errors.Is(err, ErrProductExists) {
+			t.Errorf("Expected ErrProductExists, got %v", err)
+		}
+	})
+
+	t.Run("GetByID missing", func(t *testing.T) {
+		repo := newRepo(t)
+
+		if _, err := repo.GetByID("conformance-missing"); !errors.Is(err, ErrProductNotFound) {
+			t.Errorf("Expected ErrProductNotFound, got %v", err)
+		}
+	})
+
+	t.Run("GetByIDs", func(t *testing.T) {
+		repo := newRepo(t)
+		for _, product := range []*Product{
+			newProduct("conformance-batch-1", "Mug", "Conformance", 8, 10),
+			newProduct("conformance-batch-2", "Bowl", "Conformance", 12, 10),
+		} {
+			if err := repo.Create(product); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+		}
+
+		products, err := repo.GetByIDs([]string{"conformance-batch-2", "conformance-missing", "conformance-batch-1"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		found := make(map[string]bool)
+		for _, product := range products {
+			found[product.ProductID] = true
+		}
+		if len(products) != 2 || !found["conformance-batch-1"] || !found["conformance-batch-2"] {
+			t.Errorf("Expected both stored products, got %v", found)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		repo := newRepo(t)
+		if err := repo.Create(newProduct("conformance-3", "Blender", "Conformance", 60, 10)); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if err := repo.Update(newProduct("conformance-3", "Blender Pro", "Conformance", 80, 0)); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		retrieved, err := repo.GetByID("conformance-3")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if retrieved.Name != "Blender Pro" || retrieved.Price != 80 || retrieved.Quantity != 0 {
+			t.Errorf("Expected updated product, got %+v", retrieved)
+		}
+
+		if err := repo.Update(newProduct("conformance-missing", "Ghost", "Conformance", 1, 10)); !errors.Is(err, ErrProductNotFound) {
+			t.Errorf("Expected ErrProductNotFound updating missing product, got %v", err)
+		}
+	})
+
+	t.Run("WriteAll", func(t *testing.T) {
+		repo := newRepo(t)
+		if err := repo.Create(newProduct("conformance-bulk-1", "Grinder", "Conformance", 70, 5)); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		err := repo.WriteAll([]Write{
+			{Product: newProduct("conformance-bulk-2", "Scale", "Conformance", 20, 3), Create: true},
+			{Product: newProduct("conformance-bulk-1", "Grinder Pro", "Conformance", 90, 5)},
+		})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		updated, err := repo.GetByID("conformance-bulk-1")
+		if err != nil || updated.Name != "Grinder Pro" {
+			t.Errorf("Expected the update applied, got %+v, %v", updated, err)
+		}
+		if _, err := repo.GetByID("conformance-bulk-2"); err != nil {
+			t.Errorf("Expected the create applied, got %v", err)
+		}
+
+		err = repo.WriteAll([]Write{
+			{Product: newProduct("conformance-bulk-3", "Sieve", "Conformance", 10, 1), Create: true},
+			{Product: newProduct("conformance-bulk-1", "Grinder Max", "Conformance", 99, 5)},
+			{Product: newProduct("conformance-bulk-missing", "Ghost", "Conformance", 1, 1)},
+		})
+		var writeErr *WriteError
+		if !errors.As(err, &writeErr) || writeErr.Index != 2 || !errors.Is(err, ErrProductNotFound) {
+			t.Fatalf("Expected ErrProductNotFound for write 2, got %v", err)
+		}
+		if _, err := repo.GetByID("conformance-bulk-3"); !errors.Is(err, ErrProductNotFound) {
+			t.Errorf("Expected the create rolled back, got %v", err)
+		}
+		if unchanged, err := repo.GetByID("conformance-bulk-1"); err != nil || unchanged.Name != "Grinder Pro" {
+			t.Errorf("Expected the update rolled back, got %+v, %v", unchanged, err)
+		}
+	})
+
+	t.Run("AdjustQuantity", func(t *testing.T) {
+		repo := newRepo(t)
+		if err := repo.Create(newProduct("conformance-stock", "Kettle", "Conformance", 30, 3)); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		reserved, err := repo.AdjustQuantity("conformance-stock", StockChange{Delta: -3, UpdatedBy: "ci"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if reserved.Quantity != 0 || reserved.UpdatedBy != "ci" {
+			t.Errorf("Expected quantity 0 updated by ci, got %+v", reserved)
+		}
+
+		if _, err := repo.AdjustQuantity("conformance-stock", StockChange{Delta: -1}); !errors.Is(err, ErrInsufficientStock) {
+			t.Errorf("Expected ErrInsufficientStock, got %v", err)
+		}
+
+		released, err := repo.AdjustQuantity("conformance-stock", StockChange{Delta: 2})
+		if err != nil || released.Quantity != 2 {
+			t.Errorf("Expected quantity 2 after release, got %+v (%v)", released, err)
+		}
+
+		retrieved, err := repo.GetByID("conformance-stock")
+		if err != nil || retrieved.Quantity != 2 {
+			t.Errorf("Expected stored quantity 2, got %+v (%v)", retrieved, err)
+		}
+
+		if _, err := repo.AdjustQuantity("conformance-missing", StockChange{Delta: 1}); !errors.Is(err, ErrProductNotFound) {
+			t.Errorf("Expected ErrProductNotFound, got %v", err)
+		}
+	})
+
+	t.Run("StockMovements", func(t *testing.T) {
+		repo := newRepo(t)
+		product := newProduct("conformance-history", "Kettle", "Conformance", 30, 5)
+		product.CreatedBy = "seed"
+		if err := repo.Create(product); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if _, err := repo.AdjustQuantity("conformance-history", StockChange{Delta: -2, UpdatedBy: "ci", Reason: ReasonReserve}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if _, err := repo.AdjustQuantity("conformance-history", StockChange{Delta: -9, Reason: ReasonReserve}); !errors.Is(err, ErrInsufficientStock) {
+			t.Fatalf("Expected ErrInsufficientStock, got %v", err)
+		}
+		renamed := newProduct("conformance-history", "Kettle Pro", "Conformance", 30, 3)
+		if err := repo.Update(renamed); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		restocked := newProduct("conformance-history", "Kettle Pro", "Conformance", 30, 10)
+		restocked.UpdatedBy = "warehouse"
+		if err := repo.Update(restocked); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		movements, total, err := repo.StockMovements("conformance-history", pagination.Params{Limit: 2})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if total != 3 || len(movements) != 2 {
+			t.Fatalf("Expected 2 of 3 movements, got %d of %d", len(movements), total)
+		}
+		if m := movements[0]; m.Delta != 7 || m.Quantity != 10 || m.Reason != ReasonUpdate || m.Actor != "warehouse" {
+			t.Errorf("Expected the restock first, got %+v", m)
+		}
+		if m := movements[1]; m.Delta != -2 || m.Quantity != 3 || m.Reason != ReasonReserve || m.Actor != "ci" {
+			t.Errorf("Expected the reservation second, got %+v", m)
+		}
+
+		older, _, err := repo.StockMovements("conformance-history", pagination.Params{Limit: 2, Offset: 2})
+		if err != nil || len(older) != 1 || older[0].Delta != 5 || older[0].Reason != ReasonCreate || older[0].Actor != "seed" {
+			t.Errorf("Expected the initial stock last, got %v (%v)", older, err)
+		}
+
+		if _, _, err := repo.StockMovements("conformance-missing", pagination.Params{Limit: 10}); !errors.Is(err, ErrProductNotFound) {
+			t.Errorf("Expected ErrProductNotFound, got %v", err)
+		}
+	})
+
+	t.Run("Price changes", func(t *testing.T) {
+		repo := newRepo(t)
+		createdAt := time.Date(2024, 6, 1, 8, 0, 0, 0, time.UTC)
+		product := newProduct("conformance-pricing", "Toaster", "Conformance", 40, 1)
+		product.CreatedAt, product.UpdatedAt = createdAt, createdAt
+		if err := repo.Create(product); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		scheduled := &PriceChange{ProductID: "conformance-pricing", Price: 35, EffectiveAt: createdAt.Add(48 * time.Hour), CreatedBy: "ci"}
+		if err := repo.RecordPriceChange(scheduled); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		repriced := newProduct("conformance-pricing", "Toaster", "Conformance", 45, 1)
+		repriced.UpdatedAt = createdAt.Add(24 * time.Hour)
+		if err := repo.Update(repriced); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		changes, err := repo.PriceChanges("conformance-pricing")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(changes) != 3 || changes[0].Price != 40 || changes[1].Price != 45 || changes[2].Price != 35 || changes[2].ID != scheduled.ID {
+			t.Fatalf("Expected prices 40, 45 and the scheduled 35 in effective order, got %+v", changes)
+		}
+
+		for _, tt := range []struct {
+			at   time.Time
+			want float64
+		}{
+			{at: createdAt, want: 40},
+			{at: createdAt.Add(36 * time.Hour), want: 45},
+			{at: createdAt.Add(72 * time.Hour), want: 35},
+		} {
+			prices, err := repo.EffectivePrices([]string{"conformance-pricing", "conformance-missing"}, tt.at)
+			if err != nil || len(prices) != 1 || prices["conformance-pricing"] != tt.want {
+				t.Errorf("Expected price %g at %s, got %v (%v)", tt.want, tt.at, prices, err)
+			}
+		}
+
+		if err := repo.CancelPriceChange("conformance-pricing", changes[1].ID, createdAt.Add(36*time.Hour)); !errors.Is(err, ErrPriceChangeEffective) {
+			t.Errorf("Expected ErrPriceChangeEffective, got %v", err)
+		}
+		if err := repo.CancelPriceChange("conformance-pricing", scheduled.ID, createdAt.Add(36*time.Hour)); err != nil {
+			t.Errorf("Expected the scheduled change to be cancelled, got %v", err)
+		}
+		if err := repo.CancelPriceChange("conformance-pricing", scheduled.ID, createdAt); !errors.Is(err, ErrPriceChangeNotFound) {
+			t.Errorf("Expected ErrPriceChangeNotFound, got %v", err)
+		}
+		if err := repo.RecordPriceChange(&PriceChange{ProductID: "conformance-missing", Price: 1}); !errors.Is(err, ErrProductNotFound) {
+			t.Errorf("Expected ErrProductNotFound, got %v", err)
+		}
+	})
+
+	t.Run("Variants", func(t *testing.T) {
+		repo := newRepo(t)
+		if err := repo.Create(newProduct("conformance-shirt", "Shirt", "Conformance", 20, 0)); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		medium := &Variant{SKU: "SHIRT-M", ProductID: "conformance-shirt", Attributes: map[string]string{"size": "M"}, Price: 20, Quantity: 5}
+		large := &Variant{SKU: "SHIRT-L", ProductID: "conformance-shirt", Attributes: map[string]string{"size": "L"}, Price: 22, Quantity: 0}
+		for _, variant := range []*Variant{medium, large} {
+			if err := repo.CreateVariant(variant); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+		}
+
+		if err := repo.CreateVariant(&Variant{SKU: "SHIRT-M", ProductID: "conformance-shirt", Price: 1}); !errors.Is(err, ErrVariantExists) {
+			t.Errorf("Expected ErrVariantExists, got %v", err)
+		}
+		if err := repo.CreateVariant(&Variant{SKU: "GHOST-1", ProductID: "conformance-missing", Price: 1}); !errors.Is(err, ErrProductNotFound) {
+			t.Errorf("Expected ErrProductNotFound, got %v", err)
+		}
+
+		variants, err := repo.Variants("conformance-shirt")
+		if err != nil || len(variants) != 2 || variants[0].SKU != "SHIRT-L" || variants[1].Attributes["size"] != "M" {
+			t.Errorf("Expected SHIRT-L then SHIRT-M, got %+v (%v)", variants, err)
+		}
+
+		medium.Price, medium.Quantity = 18, 4
+		if err := repo.UpdateVariant(medium); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		retrieved, err := repo.GetVariant("SHIRT-M")
+		if err != nil || retrieved.Price != 18 || retrieved.Quantity != 4 || retrieved.ProductID != "conformance-shirt" {
+			t.Errorf("Expected the updated variant, got %+v (%v)", retrieved, err)
+		}
+
+		if err := repo.DeleteVariant("SHIRT-L"); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if _, err := repo.GetVariant("SHIRT-L"); !errors.Is(err, ErrVariantNotFound) {
+			t.Errorf("Expected ErrVariantNotFound after delete, got %v", err)
+		}
+		if err := repo.UpdateVariant(&Variant{SKU: "SHIRT-L", Price: 1}); !errors.Is(err, ErrVariantNotFound) {
+			t.Errorf("Expected ErrVariantNotFound, got %v", err)
+		}
+
+		if err := repo.Delete("conformance-shirt", time.Now()); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if _, err := repo.GetVariant("SHIRT-M"); !errors.Is(err, ErrVariantNotFound) {
+			t.Errorf("Expected variants of a deleted product to be hidden, got %v", err)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		repo := newRepo(t)
+		if err := repo.Create(newProduct("conformance-4", "Grill", "Conformance", 120, 10)); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if err := repo.Delete("conformance-4", time.Now()); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if _, err := repo.GetByID("conformance-4"); !errors.Is(err, ErrProductNotFound) {
+			t.Errorf("Expected ErrProductNotFound after delete, got %v", err)
+		}
+
+		if err := repo.Delete("conformance-4", time.Now()); !errors.Is(err, ErrProductNotFound) {
+			t.Errorf("Expected ErrProductNotFound deleting twice, got %v", err)
+		}
+	})
+
+	t.Run("Soft delete and restore", func(t *testing.T) {
+		repo := newRepo(t)
+		if err := repo.Create(newProduct("conformance-9", "Smoker", "Conformance Deleted", 300, 10)); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		deletedAt := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+		restoredAt := deletedAt.Add(time.Hour)
+		if err := repo.Restore("conformance-9", deletedAt); !errors.Is(err, ErrProductNotDeleted) {
+			t.Errorf("Expected ErrProductNotDeleted restoring a live product, got %v", err)
+		}
+		if err := repo.Delete("conformance-9", deletedAt); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		deleted, err := repo.GetByIDIncludingDeleted("conformance-9")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if !deleted.IsDeleted() || !deleted.DeletedAt.Equal(deletedAt) {
+			t.Errorf("Expected DeletedAt to be %v, got %v", deletedAt, deleted.DeletedAt)
+		}
+		if err := repo.Update(newProduct("conformance-9", "Smoker XL", "Conformance Deleted", 350, 10)); !errors.Is(err, ErrProductNotFound) {
+			t.Errorf("Expected ErrProductNotFound updating a deleted product, got %v", err)
+		}
+		if products, _ := repo.GetByIDs([]string{"conformance-9"}); len(products) != 0 {
+			t.Errorf("Expected GetByIDs to skip deleted products, got %v", products)
+		}
+
+		category := ProductFilter{Category: "Conformance Deleted"}
+		if count, _ := repo.Count(category); count != 0 {
+			t.Errorf("Expected deleted products not to be counted, got %d", count)
+		}
+		category.IncludeDeleted = true
+		if found, _ := repo.Find(category); len(found) != 1 {
+			t.Errorf("Expected IncludeDeleted to find the deleted product, got %v", found)
+		}
+
+		if err := repo.Restore("conformance-9", restoredAt); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		restored, err := repo.GetByID("conformance-9")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if restored.IsDeleted() {
+			t.Error("Expected DeletedAt to be cleared")
+		}
+		deletions, err := repo.Deletions("conformance-9")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(deletions) != 1 || !deletions[0].DeletedAt.Equal(deletedAt) || deletions[0].RestoredAt == nil || !deletions[0].RestoredAt.Equal(restoredAt) {
+			t.Fatalf("Expected one deletion from %v to %v, got %+v", deletedAt, restoredAt, deletions)
+		}
+		if !deletions[0].Covers(deletedAt) || deletions[0].Covers(restoredAt) {
+			t.Errorf("Expected the deletion to cover its start and not its end, got %+v", deletions[0])
+		}
+
+		if err := repo.Restore("conformance-missing", time.Now()); !errors.Is(err, ErrProductNotFound) {
+			t.Errorf("Expected ErrProductNotFound restoring a missing product, got %v", err)
+		}
+	})
+
+	t.Run("Find", func(t *testing.T) {
+		repo := newRepo(t)
+		for _, product := range []*Product{
+			newProduct("conformance-5", "Espresso Machine", "Conformance", 250, 10),
+			newProduct("conformance-6", "Espresso Cups", "Conformance", 20, 10),
+			newProduct("conformance-7", "Milk Frother", "Conformance", 35, 0),
+			newProduct("conformance-8", "Espresso Beans", "Conformance Pantry", 15, 10),
+		} {
+			if err := repo.Create(product); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+		}
+
+		maxPrice := 100.0
+		inStock := true
+		products, err := repo.Find(ProductFilter{
+			Category: "Conformance",
+			Search:   "ESPRESSO",
+			MaxPrice: &maxPrice,
+			InStock:  &inStock,
+		})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(products) != 1 || products[0].ProductID != "conformance-6" {
+			t.Errorf("Expected only conformance-6, got %v", products)
+		}
+
+		page, err := repo.Find(ProductFilter{Category: "Conformance", Limit: 2, Offset: 1})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(page) != 2 || page[0].ProductID != "conformance-6" || page[1].ProductID != "conformance-7" {
+			t.Errorf("Expected conformance-6 and conformance-7, got %v", page)
+		}
+
+		total, err := repo.Count(ProductFilter{Category: "Conformance", Limit: 2, Offset: 1})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if total != 3 {
+			t.Errorf("Expected count 3 ignoring pagination, got %d", total)
+		}
+
+		anyOf, err := repo.Count(ProductFilter{Categories: []string{"Conformance", "Conformance Pantry"}})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if anyOf != 4 {
+			t.Errorf("Expected count 4 across both categories, got %d", anyOf)
+		}
+
+		sorted, err := repo.Find(ProductFilter{
+			Categories: []string{"Conformance", "Conformance Pantry"},
+			Sort:       listing.Sort{{Field: "quantity", Descending: true}, {Field: "price"}},
+			Limit:      3,
+		})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if got := productIDs(sorted); len(got) != 3 || got[0] != "conformance-8" || got[1] != "conformance-6" || got[2] != "conformance-5" {
+			t.Errorf("Expected the stocked products cheapest first, got %v", got)
+		}
+	})
+
+	t.Run("Barcode", func(t *testing.T) {
+		repo := newRepo(t)
+		scanned := newProduct("conformance-barcode", "Tea Tin", "Conformance", 12, 10)
+		scanned.Barcode = "4006381333931"
+		for _, product := range []*Product{scanned, newProduct("conformance-plain", "Tea Spoon", "Conformance", 3, 10)} {
+			if err := repo.Create(product); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+		}
+
+		found, err := repo.Find(ProductFilter{Barcode: "4006381333931"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if got := productIDs(found); len(got) != 1 || got[0] != "conformance-barcode" || found[0].Barcode != "4006381333931" {
+			t.Errorf("Expected only conformance-barcode, got %+v", found)
+		}
+
+		scanned.Barcode = "96385074"
+		if err := repo.Update(scanned); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		retrieved, err := repo.GetByID("conformance-barcode")
+		if err != nil || retrieved.Barcode != "96385074" {
+			t.Errorf("Expected the updated barcode, got %+v, %v", retrieved, err)
+		}
+		if previous, err := repo.Count(ProductFilter{Barcode: "4006381333931"}); err != nil || previous != 0 {
+			t.Errorf("Expected the previous barcode to match nothing, got %d, %v", previous, err)
+		}
+	})
+}
+
+// productIDs returns the IDs of products, in order
+func productIDs(products []*Product) []string {
+	ids := make([]string, len(products))
+	for i, product := range products {
+		ids[i] = product.ProductID
+	}
+	return ids
+}
+
+func TestInMemoryRepository_Conformance(t *testing.T) {
+	testRepositoryConformance(t, func(t *testing.T) Repository {
+		return NewInMemoryRepository()
+	})
+}
+
+func TestInMemoryRepository_RecordsEvents(t *testing.T) {
+	// Arrange
+	repo := NewInMemoryRepository()
+	events := outbox.NewInMemoryStore()
+	repo.RecordEventsTo(events)
+	product := &Product{ProductID: "events-1", Name: "Desk Lamp", Price: 25, Quantity: 3}
+
+	// Act
+	mustSucceed := func(err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	mustSucceed(repo.Create(product))
+	mustSucceed(repo.Update(&Product{ProductID: "events-1", Name: "Desk Lamp", Price: 30, Quantity: 3}))
+	_, err := repo.AdjustQuantity("events-1", StockChange{Delta: -2, Reason: ReasonReserve})
+	mustSucceed(err)
+	_, shortErr := repo.AdjustQuantity("events-1", StockChange{Delta: -5, Reason: ReasonReserve})
+	mustSucceed(repo.Delete("events-1", time.Now()))
+	mustSucceed(repo.Restore("events-1", time.Now()))
+
+	// Assert
+	if !errors.Is(shortErr, ErrInsufficientStock) {
+		t.Fatalf("Expected the oversell refused, got %v", shortErr)
+	}
+	recorded := events.Events()
+	var types []string
+	for _, event := range recorded {
+		types = append(types, event.Type)
+		if event.AggregateID != "events-1" {
+			t.Errorf("Expected events of events-1, got %+v", event)
+		}
+	}
+	if got := strings.Join(types, " "); got != "product.created product.updated product.stock_changed product.deleted product.restored" {
+		t.Fatalf("Expected one event per stored change, got %s", got)
+	}
+	if !strings.Contains(string(recorded[2].Data), `"quantity":1`) || !strings.Contains(string(recorded[3].Data), `"deletedAt"`) {
+		t.Errorf("Expected events to carry the product as stored, got %s and %s", recorded[2].Data, recorded[3].Data)
+	}
+}
+
+func TestCachedRepository_Conformance(t *testing.T) {
+	testRepositoryConformance(t, func(t *testing.T) Repository {
+		return NewCachedRepository(NewInMemoryRepository(), cache.NewLRU(100), time.Minute)
+	})
+}
+
+// TestPostgresRepository_Conformance runs against the database in
+// POSTGRES_TEST_DSN and is skipped when it is not set.
+func TestPostgresRepository_Conformance(t *testing.T) {
+	dsn := os.Getenv("POSTGRES_TEST_DSN")
+	if dsn == "" {
+		t.Skip("POSTGRES_TEST_DSN not set")
+	}
+
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := migrations.New(db)
+	if err != nil {
+		t.Fatalf("Failed to load migrations: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+
+	testRepositoryConformance(t, func(t *testing.T) Repository {
+		repo := NewPostgresRepository(db)
+		events := outbox.NewPostgresStore(db)
+		// Record events so changes run in outbox transactions
+		repo.RecordEventsTo(events)
+		if _, err := db.Exec(`TRUNCATE products, stock_movements, price_changes, product_deletions, product_variants, outbox_events`); err != nil {
+			t.Fatalf("Failed to reset products: %v", err)
+		}
+		return repo
+	})
+}
+
+func TestSQLiteRepository_Conformance(t *testing.T) {
+	testRepositoryConformance(t, func(t *testing.T) Repository {
+		db, err := sqlite.Open(sqlite.InMemory)
+		if err != nil {
+			t.Fatalf("Failed to open database: %v", err)
+		}
+		t.Cleanup(func() { db.Close() })
+
+		repo := NewSQLiteRepository(db)
+		if err := repo.EnsureSchema(); err != nil {
+			t.Fatalf("Failed to create schema: %v", err)
+		}
+		return repo
+	})
+}
+
+// TestDynamoDBRepository_Conformance runs against the DynamoDB endpoint in
+// DYNAMODB_TEST_ENDPOINT, such as DynamoDB Local, and is skipped when it is
+// not set. Every test gets a table of its own.
+func TestDynamoDBRepository_Conformance(t *testing.T) {
+	endpoint := os.Getenv("DYNAMODB_TEST_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("DYNAMODB_TEST_ENDPOINT not set")
+	}
+
+	ctx := context.Background()
+	client, err := dynamo.Open(ctx, dynamo.Config{Region: "us-east-1", Endpoint: endpoint, MaxAttempts: 3, MaxBackoff: time.Second})
+	if err != nil {
+		t.Fatalf("Failed to open DynamoDB client: %v", err)
+	}
+
+	testRepositoryConformance(t, func(t *testing.T) Repository {
+		table := dynamo.NewTable(client, fmt.Sprintf("products-%d", time.Now().UnixNano()))
+		if err := table.Ensure(ctx); err != nil {
+			t.Fatalf("Failed to create table: %v", err)
+		}
+		return NewDynamoDBRepository(table)
+	})
+}
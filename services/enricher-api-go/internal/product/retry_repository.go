@@ -0,0 +1,201 @@
+package product
+
+import (
+	"time"
+
+	"enricher-api-go/internal/pagination"
+	"enricher-api-go/internal/retry"
+	"enricher-api-go/internal/unitofwork"
+)
+
+// RetryRepository retries the calls of another Repository that fail
+// transiently: reads on any transient failure, writes only on failures that
+// show nothing was written.
+type RetryRepository struct {
+	repo    Repository
+	retrier *retry.Retrier
+}
+
+// NewRetryRepository wraps repo with retrier, typically the retrier shared
+// by every repository using the same backend
+func NewRetryRepository(repo Repository, retrier *retry.Retrier) *RetryRepository {
+	return &RetryRepository{repo: repo, retrier: retrier}
+}
+
+// GetByID retrieves a live product by ID
+func (r *RetryRepository) GetByID(productID string) (product *Product, err error) {
+	err = r.retrier.Read(func() error {
+		product, err = r.repo.GetByID(productID)
+		return err
+	})
+	return product, err
+}
+
+// GetByIDIncludingDeleted retrieves a product by ID even if it is soft-deleted
+func (r *RetryRepository) GetByIDIncludingDeleted(productID string) (product *Product, err error) {
+	err = r.retrier.Read(func() error {
+		product, err = r.repo.GetByIDIncludingDeleted(productID)
+		return err
+	})
+	return product, err
+}
+
+// GetByIDs retrieves the live products with the given IDs
+func (r *RetryRepository) GetByIDs(productIDs []string) (products []*Product, err error) {
+	err = r.retrier.Read(func() error {
+		products, err = r.repo.GetByIDs(productIDs)
+		return err
+	})
+	return products, err
+}
+
+// Create adds a new product
+func (r *RetryRepository) Create(product *Product) error {
+	return r.retrier.Write(func() error { return r.repo.Create(product) })
+}
+
+// Update modifies an existing product
+func (r *RetryRepository) Update(product *Product) error {
+	return r.retrier.Write(func() error { return r.repo.Update(product) })
+}
+
+// WriteAll applies every write or none of them
+func (r *RetryRepository) WriteAll(writes []Write) error {
+	return r.retrier.Write(func() error { return r.repo.WriteAll(writes) })
+}
+
+// Delete soft-deletes a product
+func (r *RetryRepository) Delete(productID string, now time.Time) error {
+	return r.retrier.Write(func() error { return r.repo.Delete(productID, now) })
+}
+
+// Restore undoes a soft delete
+func (r *RetryRepository) Restore(productID string, now time.Time) error {
+	return r.retrier.Write(func() error { return r.repo.Restore(productID, now) })
+}
+
+// Deletions returns the intervals a product was soft-deleted
+func (r *RetryRepository) Deletions(productID string) (deletions []*Deletion, err error) {
+	err = r.retrier.Read(func() error {
+		deletions, err = r.repo.Deletions(productID)
+		return err
+	})
+	return deletions, err
+}
+
+// AdjustQuantity atomically changes a product's quantity
+func (r *RetryRepository) AdjustQuantity(productID string, change StockChange) (product *Product, err error) {
+	err = r.retrier.Write(func() error {
+		product, err = r.repo.AdjustQuantity(productID, change)
+		return err
+	})
+	return product, err
+}
+
+// adjustQuantityTx adjusts the quantity within the unit's database
+// transaction when the wrapped repository can join it. It is not retried: a
+// failure aborts the transaction, which the unit of work then rolls back.
+func (r *RetryRepository) adjustQuantityTx(tx *unitofwork.Tx, productID string, change StockChange) (*Product, bool, error) {
+	repo, ok := r.repo.(txAdjuster)
+	if !ok {
+		return nil, false, nil
+	}
+	return repo.adjustQuantityTx(tx, productID, change)
+}
+
+// StockMovements returns a page of a product's stock movements
+func (r *RetryRepository) StockMovements(productID string, page pagination.Params) (movements []*StockMovement, total int, err error) {
+	err = r.retrier.Read(func() error {
+		movements, total, err = r.repo.StockMovements(productID, page)
+		return err
+	})
+	return movements, total, err
+}
+
+// RecordPriceChange adds a price change to a product's schedule
+func (r *RetryRepository) RecordPriceChange(change *PriceChange) error {
+	return r.retrier.Write(func() error { return r.repo.RecordPriceChange(change) })
+}
+
+// PriceChanges returns a product's price changes in effective order
+func (r *RetryRepository) PriceChanges(productID string) (changes []*PriceChange, err error) {
+	err = r.retrier.Read(func() error {
+		changes, err = r.repo.PriceChanges(productID)
+		return err
+	})
+	return changes, err
+}
+
+// EffectivePrices returns the price valid at at for each product with a price change
+func (r *RetryRepository) EffectivePrices(productIDs []string, at time.Time) (prices map[string]float64, err error) {
+	err = r.retrier.Read(func() error {
+		prices, err = r.repo.EffectivePrices(productIDs, at)
+		return err
+	})
+	return prices, err
+}
+
+// CancelPriceChange removes a price change that is not yet effective
+func (r *RetryRepository) CancelPriceChange(productID string, changeID int64, now time.Time) error {
+	return r.retrier.Write(func() error { return r.repo.CancelPriceChange(productID, changeID, now) })
+}
+
+// Variants returns a live product's variants
+func (r *RetryRepository) Variants(productID string) (variants []*Variant, err error) {
+	err = r.retrier.Read(func() error {
+		variants, err = r.repo.Variants(productID)
+		return err
+	})
+	return variants, err
+}
+
+// GetVariant retrieves a variant by SKU
+func (r *RetryRepository) GetVariant(sku string) (variant *Variant, err error) {
+	err = r.retrier.Read(func() error {
+		variant, err = r.repo.GetVariant(sku)
+		return err
+	})
+	return variant, err
+}
+
+// CreateVariant adds a variant to a live product
+func (r *RetryRepository) CreateVariant(variant *Variant) error {
+	return r.retrier.Write(func() error { return r.repo.CreateVariant(variant) })
+}
+
+// UpdateVariant replaces an existing variant
+func (r *RetryRepository) UpdateVariant(variant *Variant) error {
+	return r.retrier.Write(func() error { return r.repo.UpdateVariant(variant) })
+}
+
+// DeleteVariant removes a variant
+func (r *RetryRepository) DeleteVariant(sku string) error {
+	return r.retrier.Write(func() error { return r.repo.DeleteVariant(sku) })
+}
+
+// List returns all live products
+func (r *RetryRepository) List() (products []*Product, err error) {
+	err = r.retrier.Read(func() error {
+		products, err = r.repo.List()
+		return err
+	})
+	return products, err
+}
+
+// Find returns the products matching filter
+func (r *RetryRepository) Find(filter ProductFilter) (products []*Product, err error) {
+	err = r.retrier.Read(func() error {
+		products, err = r.repo.Find(filter)
+		return err
+	})
+	return products, err
+}
+
+// Count returns the number of products matching filter
+func (r *RetryRepository) Count(filter ProductFilter) (count int, err error) {
+	err = r.retrier.Read(func() error {
+		count, err = r.repo.Count(filter)
+		return err
+	})
+	return count, err
+}
@@ -1,89 +1,558 @@
 package product
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"enricher-api-go/internal/apperr"
+	"enricher-api-go/internal/auth"
+	"enricher-api-go/internal/clock"
+	"enricher-api-go/internal/currency"
+	"enricher-api-go/internal/idgen"
+	"enricher-api-go/internal/logging"
+	"enricher-api-go/internal/pagination"
+	"enricher-api-go/internal/unitofwork"
+	"enricher-api-go/internal/validation"
+)
+
+// DefaultMaxSearchTermLength is the default maximum length of a search term in characters
+const DefaultMaxSearchTermLength = 100
+
+// DefaultMaxBatchSize is the default maximum number of IDs in a batch lookup
+const DefaultMaxBatchSize = 100
+
+// ExportPageSize is the number of products read at a time while exporting
+const ExportPageSize = 500
+
+// DefaultMaxBulkSize is the default maximum number of items in a bulk write
+const DefaultMaxBulkSize = 1000
+
+var (
+	// ErrSearchTermRequired is returned when a search term is blank
+	ErrSearchTermRequired = apperr.New(apperr.ErrValidation, "search term required")
+	// ErrSearchTermTooLong is returned when a search term exceeds the configured maximum
+	ErrSearchTermTooLong = apperr.New(apperr.ErrValidation, "search term too long")
+	// ErrInvalidFilter is returned when list filter values are out of range
+	ErrInvalidFilter = apperr.New(apperr.ErrValidation, "invalid filter")
+	// ErrInvalidBatch is returned when a batch lookup is empty, too large or has blank IDs
+	ErrInvalidBatch = apperr.New(apperr.ErrValidation, "invalid batch")
+	// ErrInvalidBulk is returned when a bulk write is empty or too large
+	ErrInvalidBulk = apperr.New(apperr.ErrValidation, "invalid bulk write")
+	// ErrInvalidQuantity is returned when reserving or releasing fewer than one unit
+	ErrInvalidQuantity = apperr.New(apperr.ErrValidation, "invalid quantity")
+	// ErrInvalidPriceChange is returned when scheduling a price change that is not in the future
+	ErrInvalidPriceChange = apperr.New(apperr.ErrValidation, "invalid price change")
+	// ErrInvalidVariant is returned when a variant's SKU is malformed or changed
+	ErrInvalidVariant = apperr.New(apperr.ErrValidation, "invalid variant")
+)
+
+// ErrUnknownCategory is returned by category filters in strict mode when the
+// requested category is not known.
+var ErrUnknownCategory = apperr.New(apperr.ErrNotFound, "unknown category")
+
+// CategoryTree is the category hierarchy products are validated against
+type CategoryTree interface {
+	// Exists reports whether a category is named name
+	Exists(ctx context.Context, name string) (bool, error)
+	// Subtree returns name and the names of all its descendants, or nil if
+	// no category has that name
+	Subtree(ctx context.Context, name string) ([]string, error)
+}
+
+// StockObserver is told about a product's stock level after it changes
+type StockObserver interface {
+	// StockChanged receives the availability of a product whose quantity changed
+	StockChanged(ctx context.Context, availability *Availability)
+}
+
+// ImageGallery holds the uploaded images of products
+type ImageGallery interface {
+	// ImagesOf returns the images of each of productIDs that has any,
+	// keyed by product ID
+	ImagesOf(ctx context.Context, productIDs []string) (map[string][]Image, error)
+}
+
+// CategoryFilterMode controls how filtering by an unknown category behaves
+type CategoryFilterMode string
+
+const (
+	// CategoryFilterLenient returns an empty list for unknown categories
+	CategoryFilterLenient CategoryFilterMode = "lenient"
+	// CategoryFilterStrict rejects unknown categories with ErrUnknownCategory
+	CategoryFilterStrict CategoryFilterMode = "strict"
 )
 
 // Service defines the business logic interface for products
 type Service interface {
-	GetProduct(productID string) (*Product, error)
-	CreateProduct(req ProductRequest) (*Product, error)
-	UpdateProduct(productID string, req ProductRequest) (*Product, error)
-	DeleteProduct(productID string) error
-	ListProducts() ([]*Product, error)
-	GetProductsByCategory(category string) ([]*Product, error)
-	IsProductAvailable(productID string) (bool, error)
+	GetProduct(ctx context.Context, productID string) (*Product, error)
+	GetProductAt(ctx context.Context, productID string, at time.Time) (*Product, error)
+	GetProductAsOf(ctx context.Context, productID string, asOf time.Time) (*Product, error)
+	GetProductIncludingDeleted(ctx context.Context, productID string) (*Product, error)
+	GetProductByBarcode(ctx context.Context, code string) (*Product, error)
+	GetProducts(ctx context.Context, productIDs []string) (*BatchResult, error)
+	CreateProduct(ctx context.Context, req ProductRequest) (*Product, error)
+	UpdateProduct(ctx context.Context, productID string, req ProductRequest) (*Product, error)
+	BulkWriteProducts(ctx context.Context, req BulkRequest) (*BulkResult, error)
+	PatchProduct(ctx context.Context, productID string, patch ProductPatch) (*Product, error)
+	DeleteProduct(ctx context.Context, productID string) error
+	RestoreProduct(ctx context.Context, productID string) (*Product, error)
+	ReserveStock(ctx context.Context, productID string, req StockRequest) (*Product, error)
+	ReleaseStock(ctx context.Context, productID string, req StockRequest) (*Product, error)
+	PriceIn(ctx context.Context, product *Product, code string) (float64, error)
+	ListStockMovements(ctx context.Context, productID string, page pagination.Params) ([]*StockMovement, int, error)
+	SchedulePrice(ctx context.Context, productID string, req PriceChangeRequest) (*PriceChange, error)
+	ListPriceChanges(ctx context.Context, productID string) ([]*PriceChange, error)
+	CancelPriceChange(ctx context.Context, productID string, changeID int64) error
+	ListVariants(ctx context.Context, productID string) ([]*Variant, error)
+	GetVariant(ctx context.Context, sku string) (*Variant, error)
+	CreateVariant(ctx context.Context, productID string, req VariantRequest) (*Variant, error)
+	UpdateVariant(ctx context.Context, sku string, req VariantRequest) (*Variant, error)
+	DeleteVariant(ctx context.Context, sku string) error
+	ListProducts(ctx context.Context) ([]*Product, error)
+	GetProductsByCategory(ctx context.Context, category string) ([]*Product, error)
+	SearchProducts(ctx context.Context, term string) ([]*Product, error)
+	FindProducts(ctx context.Context, filter ProductFilter) ([]*Product, error)
+	CountProducts(ctx context.Context, filter ProductFilter) (int, error)
+	ExportProducts(ctx context.Context, filter ProductFilter, emit func([]*Product) error) error
+	IsProductAvailable(ctx context.Context, productID string) (bool, error)
+	CheckAvailability(ctx context.Context, productID string) (*Availability, error)
+	CheckBulkAvailability(ctx context.Context, lines []AvailabilityLine) (*BulkAvailability, error)
+	ProductImages(ctx context.Context, productIDs []string) map[string][]Image
 }
 
 // ProductService implements the Service interface
 type ProductService struct {
-	repo Repository
+	repo            Repository
+	categoryMode    CategoryFilterMode
+	knownCategories map[string]bool
+	categories      CategoryTree
+	maxSearchLength int
+	maxBatchSize    int
+	maxBulkSize     int
+	idGenerator     idgen.Generator
+	clock           clock.Clock
+	currency        string
+	rates           currency.RateProvider
+	stockObserver   StockObserver
+	images          ImageGallery
+	measures        Measures
+}
+
+// Option configures optional ProductService behavior
+type Option func(*ProductService)
+
+// WithCategoryFilter sets how unknown categories are handled when filtering.
+//
+// In strict mode a category is known if it appears in allowlist, or, when
+// allowlist is empty, if at least one stored product uses it.
+func WithCategoryFilter(mode CategoryFilterMode, allowlist []string) Option {
+	return func(s *ProductService) {
+		s.categoryMode = mode
+		s.knownCategories = nil
+		if len(allowlist) > 0 {
+			s.knownCategories = make(map[string]bool, len(allowlist))
+			for _, category := range allowlist {
+				s.knownCategories[category] = true
+			}
+		}
+	}
+}
+
+// WithCategoryTree makes products reference categories in tree: creates and
+// updates naming an unknown category fail with ErrUnknownCategory, strict
+// filtering without an allowlist checks the tree, and filters can widen a
+// category to its subcategories.
+func WithCategoryTree(tree CategoryTree) Option {
+	return func(s *ProductService) {
+		s.categories = tree
+	}
+}
+
+// WithMaxSearchTermLength caps the length of search terms; values below 1 keep the default
+func WithMaxSearchTermLength(limit int) Option {
+	return func(s *ProductService) {
+		if limit > 0 {
+			s.maxSearchLength = limit
+		}
+	}
+}
+
+// WithMaxBatchSize caps the number of IDs in a batch lookup; values below 1 keep the default
+func WithMaxBatchSize(limit int) Option {
+	return func(s *ProductService) {
+		if limit > 0 {
+			s.maxBatchSize = limit
+		}
+	}
+}
+
+// WithMaxBulkSize caps the number of items in a bulk write; values below 1 keep the default
+func WithMaxBulkSize(limit int) Option {
+	return func(s *ProductService) {
+		if limit > 0 {
+			s.maxBulkSize = limit
+		}
+	}
+}
+
+// WithIDGenerator sets the generator used for new product IDs (UUIDs by default)
+func WithIDGenerator(gen idgen.Generator) Option {
+	return func(s *ProductService) {
+		s.idGenerator = gen
+	}
+}
+
+// WithClock sets the clock used for CreatedAt and UpdatedAt (the UTC wall clock by default)
+func WithClock(c clock.Clock) Option {
+	return func(s *ProductService) {
+		s.clock = c
+	}
+}
+
+// WithCurrency sets the currency of products created without one and the
+// exchange-rate provider used to price products in other currencies. An
+// empty code keeps currency.DefaultCode; a nil provider allows only the
+// product's own currency and its explicit prices.
+func WithCurrency(code string, rates currency.RateProvider) Option {
+	return func(s *ProductService) {
+		if code != "" {
+			s.currency = code
+		}
+		s.rates = rates
+	}
+}
+
+// WithStockObserver reports every change to a product's quantity, whether
+// reserved, released or set by an update, to observer once it is stored
+func WithStockObserver(observer StockObserver) Option {
+	return func(s *ProductService) {
+		s.stockObserver = observer
+	}
+}
+
+// WithImageGallery sets where the images linked from product responses are
+// kept. Without it products have no images.
+func WithImageGallery(gallery ImageGallery) Option {
+	return func(s *ProductService) {
+		s.images = gallery
+	}
+}
+
+// WithMeasures sets the units of weights and dimensions given without one
+// and the maxima they must stay within. Empty units and non-positive maxima
+// keep their defaults.
+func WithMeasures(measures Measures) Option {
+	return func(s *ProductService) {
+		if measures.WeightUnit != "" {
+			s.measures.WeightUnit = measures.WeightUnit
+		}
+		if measures.LengthUnit != "" {
+			s.measures.LengthUnit = measures.LengthUnit
+		}
+		if measures.MaxWeightKg > 0 {
+			s.measures.MaxWeightKg = measures.MaxWeightKg
+		}
+		if measures.MaxDimensionCm > 0 {
+			s.measures.MaxDimensionCm = measures.MaxDimensionCm
+		}
+	}
 }
 
 // NewService creates a new product service
-func NewService(repo Repository) *ProductService {
-	return &ProductService{
-		repo: repo,
+func NewService(repo Repository, opts ...Option) *ProductService {
+	s := &ProductService{
+		repo:            repo,
+		categoryMode:    CategoryFilterLenient,
+		maxSearchLength: DefaultMaxSearchTermLength,
+		maxBatchSize:    DefaultMaxBatchSize,
+		maxBulkSize:     DefaultMaxBulkSize,
+		idGenerator:     idgen.UUIDGenerator{},
+		clock:           clock.System{},
+		currency:        currency.DefaultCode,
+		measures: Measures{
+			WeightUnit:     DefaultWeightUnit,
+			LengthUnit:     DefaultLengthUnit,
+			MaxWeightKg:    DefaultMaxWeightKg,
+			MaxDimensionCm: DefaultMaxDimensionCm,
+		},
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// GetProduct retrieves a product by ID, priced as of now
+func (s *ProductService) GetProduct(ctx context.Context, productID string) (*Product, error) {
+	return s.GetProductAt(ctx, productID, s.clock.Now())
 }
 
-// GetProduct retrieves a product by ID
-func (s *ProductService) GetProduct(productID string) (*Product, error) {
-	log.Printf("Getting product with ID: %s", productID)
+// GetProductAt retrieves a product by ID with the price that was, or will
+// be, in effect at at
+func (s *ProductService) GetProductAt(ctx context.Context, productID string, at time.Time) (*Product, error) {
+	logger := logging.FromContext(ctx)
+	logger.Debug("Getting product", "product_id", productID, "at", at)
 
 	if productID == "" {
-		return nil, fmt.Errorf("product ID cannot be empty")
+		return nil, apperr.New(apperr.ErrValidation, "product ID cannot be empty")
 	}
 
 	product, err := s.repo.GetByID(productID)
 	if err != nil {
-		log.Printf("Error getting product %s: %v", productID, err)
+		logger.Warn("Failed to get product", "product_id", productID, "error", err)
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+
+	products := []*Product{product}
+	if err := s.applyEffectivePrices(products, at); err != nil {
+		logger.Error("Failed to resolve product price", "product_id", productID, "error", err)
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+
+	logger.Debug("Retrieved product", "product_id", productID)
+	return products[0], nil
+}
+
+// GetProductAsOf retrieves a product as it was priced at asOf, so reads made
+// to reproduce an earlier enrichment see the price of that time. Prices are
+// versioned by the price change history; other fields are current. A product
+// created after asOf, or soft-deleted at that instant, is not found, even if
+// it has been restored since.
+func (s *ProductService) GetProductAsOf(ctx context.Context, productID string, asOf time.Time) (*Product, error) {
+	logger := logging.FromContext(ctx)
+	logger.Debug("Getting product as of", "product_id", productID, "as_of", asOf)
+
+	if productID == "" {
+		return nil, apperr.New(apperr.ErrValidation, "product ID cannot be empty")
+	}
+	if asOf.After(s.clock.Now()) {
+		return nil, apperr.New(apperr.ErrValidation, "asOf cannot be in the future")
+	}
+
+	product, err := s.repo.GetByIDIncludingDeleted(productID)
+	if err != nil {
+		logger.Warn("Failed to get product", "product_id", productID, "error", err)
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+	if product.CreatedAt.After(asOf) || (product.DeletedAt != nil && !product.DeletedAt.After(asOf)) {
+		return nil, fmt.Errorf("failed to get product: %w", ErrProductNotFound)
+	}
+
+	deletions, err := s.repo.Deletions(productID)
+	if err != nil {
+		logger.Error("Failed to get product deletions", "product_id", productID, "error", err)
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+	for _, deletion := range deletions {
+		if deletion.Covers(asOf) {
+			return nil, fmt.Errorf("failed to get product: %w", ErrProductNotFound)
+		}
+	}
+
+	products := []*Product{product}
+	if err := s.applyEffectivePrices(products, asOf); err != nil {
+		logger.Error("Failed to resolve product price", "product_id", productID, "error", err)
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+	return products[0], nil
+}
+
+// applyEffectivePrices replaces each product's stored price with the one in
+// effect at at. Products are copied before changing, as repositories may
+// share them with a cache.
+func (s *ProductService) applyEffectivePrices(products []*Product, at time.Time) error {
+	if len(products) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(products))
+	for i, product := range products {
+		ids[i] = product.ProductID
+	}
+
+	prices, err := s.repo.EffectivePrices(ids, at)
+	if err != nil {
+		return fmt.Errorf("failed to resolve effective prices: %w", err)
+	}
+
+	for i, product := range products {
+		if price, ok := prices[product.ProductID]; ok && price != product.Price {
+			priced := *product
+			priced.Price = price
+			products[i] = &priced
+		}
+	}
+	return nil
+}
+
+// GetProductIncludingDeleted retrieves a product by ID even if it is
+// soft-deleted; regular reads use GetProduct, which hides deleted products
+func (s *ProductService) GetProductIncludingDeleted(ctx context.Context, productID string) (*Product, error) {
+	logger := logging.FromContext(ctx)
+	logger.Debug("Getting product including deleted", "product_id", productID)
+
+	if productID == "" {
+		return nil, apperr.New(apperr.ErrValidation, "product ID cannot be empty")
+	}
+
+	product, err := s.repo.GetByIDIncludingDeleted(productID)
+	if err != nil {
+		logger.Warn("Failed to get product", "product_id", productID, "error", err)
 		return nil, fmt.Errorf("failed to get product: %w", err)
 	}
 
-	log.Printf("Successfully retrieved product: %s", product.Name)
 	return product, nil
 }
 
+// GetProductByBarcode retrieves the live product with a barcode, priced as
+// of now, for warehouse scanners. Malformed barcodes are an
+// ErrInvalidBarcode and unknown ones an ErrProductNotFound.
+func (s *ProductService) GetProductByBarcode(ctx context.Context, code string) (*Product, error) {
+	logger := logging.FromContext(ctx)
+	logger.Debug("Getting product by barcode", "barcode", code)
+
+	code, err := NormalizeBarcode(code)
+	if err != nil {
+		return nil, err
+	}
+
+	products, err := s.repo.Find(ProductFilter{Barcode: code, Limit: 1})
+	if err == nil && len(products) == 0 {
+		err = fmt.Errorf("%w: no product has barcode %s", ErrProductNotFound, code)
+	}
+	if err == nil {
+		err = s.applyEffectivePrices(products, s.clock.Now())
+	}
+	if err != nil {
+		logger.Warn("Failed to get product by barcode", "barcode", code, "error", err)
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+
+	logger.Debug("Retrieved product", "product_id", products[0].ProductID, "barcode", code)
+	return products[0], nil
+}
+
+// GetProducts retrieves several products in one repository round trip.
+//
+// Duplicate IDs are looked up once. Empty batches, blank IDs and batches
+// larger than the configured maximum are rejected with ErrInvalidBatch.
+func (s *ProductService) GetProducts(ctx context.Context, productIDs []string) (*BatchResult, error) {
+	logger := logging.FromContext(ctx)
+
+	ids, err := s.prepareBatch(productIDs)
+	if err != nil {
+		return nil, err
+	}
+	logger.Debug("Getting products", "count", len(ids))
+
+	products, err := s.repo.GetByIDs(ids)
+	if err == nil {
+		err = s.applyEffectivePrices(products, s.clock.Now())
+	}
+	if err != nil {
+		logger.Error("Failed to get products", "error", err)
+		return nil, fmt.Errorf("failed to get products: %w", err)
+	}
+
+	byID := make(map[string]*Product, len(products))
+	for _, product := range products {
+		byID[product.ProductID] = product
+	}
+
+	result := &BatchResult{Products: make([]*Product, 0, len(products)), Missing: make([]string, 0)}
+	for _, productID := range ids {
+		if product, ok := byID[productID]; ok {
+			result.Products = append(result.Products, product)
+		} else {
+			result.Missing = append(result.Missing, productID)
+		}
+	}
+
+	logger.Debug("Retrieved products", "found", len(result.Products), "missing", len(result.Missing))
+	return result, nil
+}
+
+// prepareBatch validates a batch of product IDs and removes duplicates,
+// keeping the first occurrence of each
+func (s *ProductService) prepareBatch(productIDs []string) ([]string, error) {
+	if len(productIDs) == 0 {
+		return nil, fmt.Errorf("%w: at least one product ID is required", ErrInvalidBatch)
+	}
+	if len(productIDs) > s.maxBatchSize {
+		return nil, fmt.Errorf("%w: at most %d product IDs are allowed", ErrInvalidBatch, s.maxBatchSize)
+	}
+
+	seen := make(map[string]bool, len(productIDs))
+	ids := make([]string, 0, len(productIDs))
+	for _, productID := range productIDs {
+		if strings.TrimSpace(productID) == "" {
+			return nil, fmt.Errorf("%w: product IDs cannot be empty", ErrInvalidBatch)
+		}
+		if !seen[productID] {
+			seen[productID] = true
+			ids = append(ids, productID)
+		}
+	}
+
+	return ids, nil
+}
+
 // CreateProduct creates a new product
-func (s *ProductService) CreateProduct(req ProductRequest) (*Product, error) {
-	log.Printf("Creating new product: %s", req.Name)
+func (s *ProductService) CreateProduct(ctx context.Context, req ProductRequest) (*Product, error) {
+	logger := logging.FromContext(ctx)
+	logger.Info("Creating product", "name", req.Name)
 
-	if err := s.validateProductRequest(req); err != nil {
+	if err := s.validateProductRequest(ctx, &req); err != nil {
 		return nil, fmt.Errorf("validation failed: %w", err)
 	}
 
-	// Generate a simple ID (in production, use UUID)
-	productID := fmt.Sprintf("product-%d", len(req.Name)*100+int(req.Price))
+	productID, err := s.idGenerator.NewID("product")
+	if err != nil {
+		logger.Error("Failed to generate product ID", "error", err)
+		return nil, fmt.Errorf("failed to create product: %w", err)
+	}
 
 	product := &Product{
 		ProductID:   productID,
 		Name:        req.Name,
 		Description: req.Description,
 		Price:       req.Price,
+		Currency:    req.Currency,
+		Prices:      req.Prices,
 		Category:    req.Category,
-		InStock:     req.InStock,
+		Quantity:    req.Quantity,
+		Weight:      s.normalizeWeight(req.Weight),
+		Dimensions:  s.normalizeDimensions(req.Dimensions),
+		Barcode:     req.Barcode,
 	}
+	s.stampCreated(ctx, product)
 
+	if err := s.checkBarcode(product); err != nil {
+		return nil, fmt.Errorf("failed to create product: %w", err)
+	}
 	if err := s.repo.Create(product); err != nil {
-		log.Printf("Error creating product: %v", err)
+		logger.Error("Failed to create product", "error", err)
 		return nil, fmt.Errorf("failed to create product: %w", err)
 	}
 
-	log.Printf("Successfully created product with ID: %s", productID)
+	logger.Info("Created product", "product_id", productID)
 	return product, nil
 }
 
 // UpdateProduct updates an existing product
-func (s *ProductService) UpdateProduct(productID string, req ProductRequest) (*Product, error) {
-	log.Printf("Updating product with ID: %s", productID)
+func (s *ProductService) UpdateProduct(ctx context.Context, productID string, req ProductRequest) (*Product, error) {
+	logger := logging.FromContext(ctx)
+	logger.Info("Updating product", "product_id", productID)
 
 	if productID == "" {
-		return nil, fmt.Errorf("product ID cannot be empty")
+		return nil, apperr.New(apperr.ErrValidation, "product ID cannot be empty")
 	}
 
-	if err := s.validateProductRequest(req); err != nil {
+	if err := s.validateProductRequest(ctx, &req); err != nil {
 		return nil, fmt.Errorf("validation failed: %w", err)
 	}
 
@@ -93,74 +562,792 @@ func (s *ProductService) UpdateProduct(productID string, req ProductRequest) (*P
 		return nil, fmt.Errorf("product not found: %w", err)
 	}
 
-	// Update product fields
-	existingProduct.Name = req.Name
-	existingProduct.Description = req.Description
-	existingProduct.Price = req.Price
-	existingProduct.Category = req.Category
-	existingProduct.InStock = req.InStock
+	previousQuantity := existingProduct.Quantity
+	s.applyRequest(existingProduct, req)
+	s.stampUpdated(ctx, existingProduct)
 
+	if err := s.checkBarcode(existingProduct); err != nil {
+		return nil, fmt.Errorf("failed to update product: %w", err)
+	}
 	if err := s.repo.Update(existingProduct); err != nil {
-		log.Printf("Error updating product: %v", err)
+		logger.Error("Failed to update product", "product_id", productID, "error", err)
 		return nil, fmt.Errorf("failed to update product: %w", err)
 	}
+	if existingProduct.Quantity != previousQuantity {
+		s.notifyStock(ctx, existingProduct)
+	}
 
-	log.Printf("Successfully updated product: %s", productID)
+	logger.Info("Updated product", "product_id", productID)
 	return existingProduct, nil
 }
 
-// DeleteProduct removes a product
-func (s *ProductService) DeleteProduct(productID string) error {
-	log.Printf("Deleting product with ID: %s", productID)
+// BulkWriteProducts creates the items of req without a product ID and
+// updates the others, reporting each item's outcome in request order.
+//
+// Items succeed or fail on their own unless req.Atomic is set: then any
+// failing item leaves every product unchanged and the others are reported
+// as skipped. Empty requests and requests larger than the configured
+// maximum are rejected with ErrInvalidBulk.
+func (s *ProductService) BulkWriteProducts(ctx context.Context, req BulkRequest) (*BulkResult, error) {
+	logger := logging.FromContext(ctx)
+
+	if len(req.Items) == 0 {
+		return nil, fmt.Errorf("%w: at least one item is required", ErrInvalidBulk)
+	}
+	if len(req.Items) > s.maxBulkSize {
+		return nil, fmt.Errorf("%w: at most %d items are allowed", ErrInvalidBulk, s.maxBulkSize)
+	}
+	logger.Info("Writing products in bulk", "count", len(req.Items), "atomic", req.Atomic)
+
+	result := &BulkResult{Items: make([]BulkItemResult, len(req.Items)), Atomic: req.Atomic}
+	writes := make([]Write, len(req.Items))
+	previousQuantities := make([]int, len(req.Items))
+	for i, item := range req.Items {
+		write, previousQuantity, err := s.prepareWrite(ctx, item)
+		if err != nil {
+			result.Items[i] = BulkItemResult{Status: BulkFailed, Err: err}
+			continue
+		}
+		writes[i], previousQuantities[i] = write, previousQuantity
+	}
+
+	if req.Atomic {
+		if err := s.writeAll(result, writes); err != nil {
+			logger.Error("Failed to write products in bulk", "error", err)
+			return nil, fmt.Errorf("failed to write products: %w", err)
+		}
+	} else {
+		for i, write := range writes {
+			if result.Items[i].Status == BulkFailed {
+				continue
+			}
+			store := s.repo.Update
+			if write.Create {
+				store = s.repo.Create
+			}
+			if err := store(write.Product); err != nil {
+				result.Items[i] = BulkItemResult{Status: BulkFailed, Err: fmt.Errorf("failed to store product: %w", err)}
+				continue
+			}
+			result.Items[i] = stored(write)
+		}
+	}
+
+	for i, item := range result.Items {
+		if item.Status == BulkUpdated && item.Product.Quantity != previousQuantities[i] {
+			s.notifyStock(ctx, item.Product)
+		}
+	}
+
+	logger.Info("Wrote products in bulk", "count", len(req.Items), "failed", result.Failed())
+	return result, nil
+}
+
+// prepareWrite validates a bulk item and builds the product it stores,
+// along with the stored quantity of a product it updates
+func (s *ProductService) prepareWrite(ctx context.Context, item BulkItem) (Write, int, error) {
+	req := item.Product
+	if err := s.validateProductRequest(ctx, &req); err != nil {
+		return Write{}, 0, fmt.Errorf("validation failed: %w", err)
+	}
+
+	if item.ProductID == "" {
+		productID, err := s.idGenerator.NewID("product")
+		if err != nil {
+			return Write{}, 0, fmt.Errorf("failed to create product: %w", err)
+		}
+		product := &Product{ProductID: productID}
+		s.applyRequest(product, req)
+		s.stampCreated(ctx, product)
+		if err := s.checkBarcode(product); err != nil {
+			return Write{}, 0, err
+		}
+		return Write{Product: product, Create: true}, 0, nil
+	}
+
+	product, err := s.repo.GetByID(item.ProductID)
+	if err != nil {
+		return Write{}, 0, fmt.Errorf("product not found: %w", err)
+	}
+	previousQuantity := product.Quantity
+	s.applyRequest(product, req)
+	s.stampUpdated(ctx, product)
+	if err := s.checkBarcode(product); err != nil {
+		return Write{}, 0, err
+	}
+	return Write{Product: product}, previousQuantity, nil
+}
+
+// writeAll stores the prepared writes of an atomic bulk write in one
+// repository call, unless an item already failed, and records the outcome
+// of every item in result. Only storage failures not tied to an item are
+// returned.
+func (s *ProductService) writeAll(result *BulkResult, writes []Write) error {
+	failed := result.Failed() > 0
+	if !failed {
+		err := s.repo.WriteAll(writes)
+		var writeErr *WriteError
+		switch {
+		case errors.As(err, &writeErr):
+			result.Items[writeErr.Index] = BulkItemResult{Status: BulkFailed, Err: fmt.Errorf("failed to store product: %w", writeErr.Err)}
+			failed = true
+		case err != nil:
+			return err
+		}
+	}
+
+	for i, write := range writes {
+		switch {
+		case result.Items[i].Status == BulkFailed:
+		case failed:
+			result.Items[i] = BulkItemResult{Status: BulkSkipped}
+		default:
+			result.Items[i] = stored(write)
+		}
+	}
+	return nil
+}
+
+// stored reports a bulk item whose write was stored
+func stored(write Write) BulkItemResult {
+	if write.Create {
+		return BulkItemResult{Status: BulkCreated, Product: write.Product}
+	}
+	return BulkItemResult{Status: BulkUpdated, Product: write.Product}
+}
+
+// PatchProduct updates only the fields present in patch. The merged product
+// is validated like a full update; an absent price keeps the one in effect.
+func (s *ProductService) PatchProduct(ctx context.Context, productID string, patch ProductPatch) (*Product, error) {
+	logger := logging.FromContext(ctx)
+	logger.Info("Patching product", "product_id", productID)
 
 	if productID == "" {
-		return fmt.Errorf("product ID cannot be empty")
+		return nil, apperr.New(apperr.ErrValidation, "product ID cannot be empty")
 	}
 
-	if err := s.repo.Delete(productID); err != nil {
-		log.Printf("Error deleting product: %v", err)
+	existingProduct, err := s.GetProduct(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("product not found: %w", err)
+	}
+
+	req := patch.apply(existingProduct)
+	if err := s.validateProductRequest(ctx, &req); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	previousQuantity := existingProduct.Quantity
+	s.applyRequest(existingProduct, req)
+	s.stampUpdated(ctx, existingProduct)
+
+	if err := s.checkBarcode(existingProduct); err != nil {
+		return nil, fmt.Errorf("failed to update product: %w", err)
+	}
+	if err := s.repo.Update(existingProduct); err != nil {
+		logger.Error("Failed to patch product", "product_id", productID, "error", err)
+		return nil, fmt.Errorf("failed to update product: %w", err)
+	}
+	if existingProduct.Quantity != previousQuantity {
+		s.notifyStock(ctx, existingProduct)
+	}
+
+	logger.Info("Patched product", "product_id", productID)
+	return existingProduct, nil
+}
+
+// stampCreated records the creation time and caller on a new product
+func (s *ProductService) stampCreated(ctx context.Context, product *Product) {
+	now, caller := s.clock.Now(), auth.Caller(ctx)
+	product.CreatedAt, product.CreatedBy = now, caller
+	product.UpdatedAt, product.UpdatedBy = now, caller
+}
+
+// stampUpdated records the modification time and caller on a changed product
+func (s *ProductService) stampUpdated(ctx context.Context, product *Product) {
+	product.UpdatedAt, product.UpdatedBy = s.clock.Now(), auth.Caller(ctx)
+}
+
+// applyRequest overwrites product's fields with a validated request
+func (s *ProductService) applyRequest(product *Product, req ProductRequest) {
+	product.Name = req.Name
+	product.Description = req.Description
+	product.Price = req.Price
+	product.Currency = req.Currency
+	product.Prices = req.Prices
+	product.Category = req.Category
+	product.Quantity = req.Quantity
+	product.Weight = s.normalizeWeight(req.Weight)
+	product.Dimensions = s.normalizeDimensions(req.Dimensions)
+	product.Barcode = req.Barcode
+}
+
+// checkBarcode returns ErrBarcodeExists when another live product has
+// product's barcode. The check reads before the write, so two concurrent
+// writes of one new barcode, or two items of one bulk write, may both pass.
+func (s *ProductService) checkBarcode(product *Product) error {
+	if product.Barcode == "" {
+		return nil
+	}
+
+	owners, err := s.repo.Find(ProductFilter{Barcode: product.Barcode})
+	if err != nil {
+		return fmt.Errorf("failed to check barcode: %w", err)
+	}
+	for _, owner := range owners {
+		if owner.ProductID != product.ProductID {
+			return fmt.Errorf("%w: %s is the barcode of %s", ErrBarcodeExists, product.Barcode, owner.ProductID)
+		}
+	}
+	return nil
+}
+
+// DeleteProduct soft-deletes a product; RestoreProduct undoes it
+func (s *ProductService) DeleteProduct(ctx context.Context, productID string) error {
+	logger := logging.FromContext(ctx)
+	logger.Info("Deleting product", "product_id", productID)
+
+	if productID == "" {
+		return apperr.New(apperr.ErrValidation, "product ID cannot be empty")
+	}
+
+	if err := s.repo.Delete(productID, s.clock.Now()); err != nil {
+		logger.Warn("Failed to delete product", "product_id", productID, "error", err)
 		return fmt.Errorf("failed to delete product: %w", err)
 	}
 
-	log.Printf("Successfully deleted product: %s", productID)
+	logger.Info("Deleted product", "product_id", productID)
 	return nil
 }
 
+// RestoreProduct undoes a soft delete and returns the restored product.
+// It fails with ErrProductNotFound if no such product exists and with
+// ErrProductNotDeleted if the product is not deleted.
+func (s *ProductService) RestoreProduct(ctx context.Context, productID string) (*Product, error) {
+	logger := logging.FromContext(ctx)
+	logger.Info("Restoring product", "product_id", productID)
+
+	if productID == "" {
+		return nil, apperr.New(apperr.ErrValidation, "product ID cannot be empty")
+	}
+
+	if err := s.repo.Restore(productID, s.clock.Now()); err != nil {
+		logger.Warn("Failed to restore product", "product_id", productID, "error", err)
+		return nil, fmt.Errorf("failed to restore product: %w", err)
+	}
+
+	logger.Info("Restored product", "product_id", productID)
+	return s.GetProduct(ctx, productID)
+}
+
+// ReserveStock takes req.Quantity units out of a product's stock, failing
+// with ErrInsufficientStock if fewer are available. Concurrent reservations
+// are applied atomically by the repository.
+//
+// Called within a unit of work (see unitofwork), the reservation is part of
+// the unit and the stock observer only hears of it once the unit commits.
+func (s *ProductService) ReserveStock(ctx context.Context, productID string, req StockRequest) (*Product, error) {
+	return s.adjustStock(ctx, productID, req, -req.Quantity, ReasonReserve)
+}
+
+// ReleaseStock returns req.Quantity previously reserved units to a product's stock
+func (s *ProductService) ReleaseStock(ctx context.Context, productID string, req StockRequest) (*Product, error) {
+	return s.adjustStock(ctx, productID, req, req.Quantity, ReasonRelease)
+}
+
+// adjustStock validates req and applies delta to the product's stock,
+// recording req.Reason or else defaultReason on the movement
+func (s *ProductService) adjustStock(ctx context.Context, productID string, req StockRequest, delta int, defaultReason string) (*Product, error) {
+	logger := logging.FromContext(ctx).With("product_id", productID, "quantity", req.Quantity, "action", defaultReason)
+	logger.Info("Adjusting stock")
+
+	if productID == "" {
+		return nil, apperr.New(apperr.ErrValidation, "product ID cannot be empty")
+	}
+	if req.Quantity < 1 {
+		return nil, fmt.Errorf("%w: quantity must be at least 1, got %d", ErrInvalidQuantity, req.Quantity)
+	}
+
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		reason = defaultReason
+	}
+
+	change := StockChange{
+		Delta:     delta,
+		UpdatedAt: s.clock.Now(),
+		UpdatedBy: auth.Caller(ctx),
+		Reason:    reason,
+	}
+	var product *Product
+	var err error
+	tx := unitofwork.FromContext(ctx)
+	if tx != nil {
+		product, err = adjustQuantityIn(tx, s.repo, productID, change)
+	} else {
+		product, err = s.repo.AdjustQuantity(productID, change)
+	}
+	if err != nil {
+		logger.Warn("Failed to adjust stock", "error", err)
+		return nil, fmt.Errorf("failed to adjust stock: %w", err)
+	}
+
+	if tx != nil {
+		tx.OnCommit(func() { s.notifyStock(ctx, product) })
+	} else {
+		s.notifyStock(ctx, product)
+	}
+
+	logger.Info("Adjusted stock", "remaining", product.Quantity)
+	return product, nil
+}
+
+// notifyStock reports product's new stock level to the stock observer, if any
+func (s *ProductService) notifyStock(ctx context.Context, product *Product) {
+	if s.stockObserver != nil {
+		s.stockObserver.StockChanged(ctx, availabilityOf(product))
+	}
+}
+
+// ListStockMovements returns a page of a product's stock movements, newest
+// first, and their total count
+func (s *ProductService) ListStockMovements(ctx context.Context, productID string, page pagination.Params) ([]*StockMovement, int, error) {
+	logger := logging.FromContext(ctx).With("product_id", productID)
+	logger.Debug("Listing stock movements")
+
+	movements, total, err := s.repo.StockMovements(productID, page)
+	if err != nil {
+		logger.Warn("Failed to list stock movements", "error", err)
+		return nil, 0, fmt.Errorf("failed to list stock movements: %w", err)
+	}
+
+	logger.Debug("Listed stock movements", "count", len(movements), "total", total)
+	return movements, total, nil
+}
+
+// SchedulePrice records a price change that takes effect at req.EffectiveAt,
+// which must be in the future. Changes effective now are made with
+// UpdateProduct or PatchProduct.
+func (s *ProductService) SchedulePrice(ctx context.Context, productID string, req PriceChangeRequest) (*PriceChange, error) {
+	logger := logging.FromContext(ctx).With("product_id", productID)
+	logger.Info("Scheduling price change", "price", req.Price, "effective_at", req.EffectiveAt)
+
+	if productID == "" {
+		return nil, apperr.New(apperr.ErrValidation, "product ID cannot be empty")
+	}
+	if err := validation.Struct(&req); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	now := s.clock.Now()
+	if !req.EffectiveAt.After(now) {
+		return nil, fmt.Errorf("%w: effectiveAt must be in the future", ErrInvalidPriceChange)
+	}
+
+	change := &PriceChange{
+		ProductID:   productID,
+		Price:       req.Price,
+		EffectiveAt: req.EffectiveAt.UTC(),
+		CreatedAt:   now,
+		CreatedBy:   auth.Caller(ctx),
+	}
+	if err := s.repo.RecordPriceChange(change); err != nil {
+		logger.Warn("Failed to schedule price change", "error", err)
+		return nil, fmt.Errorf("failed to schedule price change: %w", err)
+	}
+
+	logger.Info("Scheduled price change", "change_id", change.ID)
+	return change, nil
+}
+
+// ListPriceChanges returns a product's past and scheduled price changes in
+// effective order
+func (s *ProductService) ListPriceChanges(ctx context.Context, productID string) ([]*PriceChange, error) {
+	logger := logging.FromContext(ctx).With("product_id", productID)
+	logger.Debug("Listing price changes")
+
+	changes, err := s.repo.PriceChanges(productID)
+	if err != nil {
+		logger.Warn("Failed to list price changes", "error", err)
+		return nil, fmt.Errorf("failed to list price changes: %w", err)
+	}
+
+	return changes, nil
+}
+
+// CancelPriceChange removes a scheduled price change. It fails with
+// ErrPriceChangeEffective once the change has taken effect.
+func (s *ProductService) CancelPriceChange(ctx context.Context, productID string, changeID int64) error {
+	logger := logging.FromContext(ctx).With("product_id", productID, "change_id", changeID)
+	logger.Info("Cancelling price change")
+
+	if err := s.repo.CancelPriceChange(productID, changeID, s.clock.Now()); err != nil {
+		logger.Warn("Failed to cancel price change", "error", err)
+		return fmt.Errorf("failed to cancel price change: %w", err)
+	}
+
+	logger.Info("Cancelled price change")
+	return nil
+}
+
+// ListVariants returns a product's variants ordered by SKU
+func (s *ProductService) ListVariants(ctx context.Context, productID string) ([]*Variant, error) {
+	logger := logging.FromContext(ctx).With("product_id", productID)
+	logger.Debug("Listing variants")
+
+	variants, err := s.repo.Variants(productID)
+	if err != nil {
+		logger.Warn("Failed to list variants", "error", err)
+		return nil, fmt.Errorf("failed to list variants: %w", err)
+	}
+
+	return variants, nil
+}
+
+// GetVariant retrieves a variant by SKU, which is matched case-insensitively
+func (s *ProductService) GetVariant(ctx context.Context, sku string) (*Variant, error) {
+	logger := logging.FromContext(ctx).With("sku", sku)
+	logger.Debug("Getting variant")
+
+	sku, err := normalizeSKU(sku)
+	if err != nil {
+		return nil, err
+	}
+
+	variant, err := s.repo.GetVariant(sku)
+	if err != nil {
+		logger.Warn("Failed to get variant", "error", err)
+		return nil, fmt.Errorf("failed to get variant: %w", err)
+	}
+
+	return variant, nil
+}
+
+// CreateVariant adds a variant to a product. SKUs are stored upper-case and
+// must be unique across all products.
+func (s *ProductService) CreateVariant(ctx context.Context, productID string, req VariantRequest) (*Variant, error) {
+	logger := logging.FromContext(ctx).With("product_id", productID)
+	logger.Info("Creating variant", "sku", req.SKU)
+
+	if productID == "" {
+		return nil, apperr.New(apperr.ErrValidation, "product ID cannot be empty")
+	}
+	if err := validateVariantRequest(&req); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	now, caller := s.clock.Now(), auth.Caller(ctx)
+	variant := &Variant{
+		SKU:        req.SKU,
+		ProductID:  productID,
+		Attributes: req.Attributes,
+		Price:      req.Price,
+		Quantity:   req.Quantity,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		CreatedBy:  caller,
+		UpdatedBy:  caller,
+	}
+	if err := s.repo.CreateVariant(variant); err != nil {
+		logger.Warn("Failed to create variant", "sku", req.SKU, "error", err)
+		return nil, fmt.Errorf("failed to create variant: %w", err)
+	}
+
+	logger.Info("Created variant", "sku", variant.SKU)
+	return variant, nil
+}
+
+// UpdateVariant replaces a variant's attributes, price and stock. The
+// request's SKU defaults to sku and cannot differ from it.
+func (s *ProductService) UpdateVariant(ctx context.Context, sku string, req VariantRequest) (*Variant, error) {
+	logger := logging.FromContext(ctx).With("sku", sku)
+	logger.Info("Updating variant")
+
+	sku, err := normalizeSKU(sku)
+	if err != nil {
+		return nil, err
+	}
+	if req.SKU == "" {
+		req.SKU = sku
+	}
+	if err := validateVariantRequest(&req); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	if req.SKU != sku {
+		return nil, fmt.Errorf("%w: sku cannot be changed", ErrInvalidVariant)
+	}
+
+	existing, err := s.repo.GetVariant(sku)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update variant: %w", err)
+	}
+
+	existing.Attributes = req.Attributes
+	existing.Price = req.Price
+	existing.Quantity = req.Quantity
+	existing.UpdatedAt, existing.UpdatedBy = s.clock.Now(), auth.Caller(ctx)
+	if err := s.repo.UpdateVariant(existing); err != nil {
+		logger.Warn("Failed to update variant", "error", err)
+		return nil, fmt.Errorf("failed to update variant: %w", err)
+	}
+
+	logger.Info("Updated variant")
+	return existing, nil
+}
+
+// DeleteVariant removes a variant
+func (s *ProductService) DeleteVariant(ctx context.Context, sku string) error {
+	logger := logging.FromContext(ctx).With("sku", sku)
+	logger.Info("Deleting variant")
+
+	sku, err := normalizeSKU(sku)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.DeleteVariant(sku); err != nil {
+		logger.Warn("Failed to delete variant", "error", err)
+		return fmt.Errorf("failed to delete variant: %w", err)
+	}
+
+	logger.Info("Deleted variant")
+	return nil
+}
+
+// validateVariantRequest normalizes the required SKU, then checks the
+// request's validate tags
+func validateVariantRequest(req *VariantRequest) error {
+	sku, err := normalizeSKU(req.SKU)
+	if err != nil {
+		return err
+	}
+	req.SKU = sku
+	if len(req.Attributes) == 0 {
+		req.Attributes = nil
+	}
+	return validation.Struct(req)
+}
+
+// normalizeSKU trims and upper-cases a SKU, rejecting blank ones and those
+// containing whitespace or slashes, which cannot appear in a URL path segment
+func normalizeSKU(sku string) (string, error) {
+	sku = strings.ToUpper(strings.TrimSpace(sku))
+	if sku == "" {
+		return "", fmt.Errorf("%w: sku cannot be empty", ErrInvalidVariant)
+	}
+	if strings.ContainsAny(sku, " \t\n/") {
+		return "", fmt.Errorf("%w: sku must not contain whitespace or slashes", ErrInvalidVariant)
+	}
+	return sku, nil
+}
+
 // ListProducts returns all products
-func (s *ProductService) ListProducts() ([]*Product, error) {
-	log.Println("Listing all products")
+func (s *ProductService) ListProducts(ctx context.Context) ([]*Product, error) {
+	logger := logging.FromContext(ctx)
+	logger.Debug("Listing all products")
 
 	products, err := s.repo.List()
+	if err == nil {
+		err = s.applyEffectivePrices(products, s.clock.Now())
+	}
 	if err != nil {
-		log.Printf("Error listing products: %v", err)
+		logger.Error("Failed to list products", "error", err)
 		return nil, fmt.Errorf("failed to list products: %w", err)
 	}
 
-	log.Printf("Successfully retrieved %d products", len(products))
+	logger.Debug("Listed products", "count", len(products))
 	return products, nil
 }
 
 // GetProductsByCategory returns products filtered by category
-func (s *ProductService) GetProductsByCategory(category string) ([]*Product, error) {
-	log.Printf("Getting products by category: %s", category)
-
+func (s *ProductService) GetProductsByCategory(ctx context.Context, category string) ([]*Product, error) {
 	if category == "" {
-		return nil, fmt.Errorf("category cannot be empty")
+		return nil, apperr.New(apperr.ErrValidation, "category cannot be empty")
+	}
+
+	return s.FindProducts(ctx, ProductFilter{Category: category})
+}
+
+// SearchProducts returns products whose name or description matches term.
+//
+// The term is trimmed before searching; blank terms are rejected with
+// ErrSearchTermRequired and terms longer than the configured maximum with
+// ErrSearchTermTooLong.
+func (s *ProductService) SearchProducts(ctx context.Context, term string) ([]*Product, error) {
+	if strings.TrimSpace(term) == "" {
+		return nil, ErrSearchTermRequired
+	}
+
+	return s.FindProducts(ctx, ProductFilter{Search: term})
+}
+
+// FindProducts returns the products matching every criterion of filter.
+//
+// The search term is trimmed and length-checked, unknown categories are
+// handled per the configured CategoryFilterMode, and invalid ranges or
+// pagination values are rejected with ErrInvalidFilter. Price ranges match
+// stored prices; the products returned carry the price in effect now.
+func (s *ProductService) FindProducts(ctx context.Context, filter ProductFilter) ([]*Product, error) {
+	logger := logging.FromContext(ctx)
+	filter, err := s.prepareFilter(ctx, filter)
+	if err != nil {
+		return nil, err
 	}
+	logger.Debug("Finding products", "filter", filter)
 
-	products, err := s.repo.GetByCategory(category)
+	products, err := s.repo.Find(filter)
+	if err == nil {
+		err = s.applyEffectivePrices(products, s.clock.Now())
+	}
 	if err != nil {
-		log.Printf("Error getting products by category: %v", err)
-		return nil, fmt.Errorf("failed to get products by category: %w", err)
+		logger.Error("Failed to find products", "error", err)
+		return nil, fmt.Errorf("failed to find products: %w", err)
 	}
 
-	log.Printf("Successfully retrieved %d products for category: %s", len(products), category)
+	logger.Debug("Found products", "count", len(products))
 	return products, nil
 }
 
+// CountProducts returns how many products match filter across all pages
+func (s *ProductService) CountProducts(ctx context.Context, filter ProductFilter) (int, error) {
+	logger := logging.FromContext(ctx)
+	filter, err := s.prepareFilter(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+
+	count, err := s.repo.Count(filter)
+	if err != nil {
+		logger.Error("Failed to count products", "error", err)
+		return 0, fmt.Errorf("failed to count products: %w", err)
+	}
+
+	return count, nil
+}
+
+// ExportProducts passes every product matching filter to emit, reading
+// ExportPageSize products at a time so the whole catalog is never held in
+// memory. Limit and Offset of filter are ignored. Pages are read by offset,
+// so products written during the export may be skipped or repeated.
+func (s *ProductService) ExportProducts(ctx context.Context, filter ProductFilter, emit func([]*Product) error) error {
+	logger := logging.FromContext(ctx)
+	logger.Info("Exporting products", "filter", filter)
+
+	filter.Limit, filter.Offset = ExportPageSize, 0
+	filter, err := s.prepareFilter(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	exported := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		products, err := s.repo.Find(filter)
+		if err == nil {
+			err = s.applyEffectivePrices(products, s.clock.Now())
+		}
+		if err != nil {
+			logger.Error("Failed to export products", "error", err, "exported", exported)
+			return fmt.Errorf("failed to export products: %w", err)
+		}
+		if len(products) > 0 {
+			if err := emit(products); err != nil {
+				return err
+			}
+		}
+		exported += len(products)
+
+		if len(products) < filter.Limit {
+			break
+		}
+		filter.Offset += filter.Limit
+	}
+
+	logger.Info("Exported products", "count", exported)
+	return nil
+}
+
+// prepareFilter normalizes and validates filter, rejecting unknown
+// categories in strict mode and widening Category to its subtree when
+// Subcategories is set
+func (s *ProductService) prepareFilter(ctx context.Context, filter ProductFilter) (ProductFilter, error) {
+	filter.Search = strings.TrimSpace(filter.Search)
+
+	if err := s.validateFilter(filter); err != nil {
+		return filter, err
+	}
+
+	if filter.Category != "" && s.categoryMode == CategoryFilterStrict {
+		known, err := s.isKnownCategory(ctx, filter.Category)
+		if err != nil {
+			return filter, err
+		}
+		if !known {
+			logging.FromContext(ctx).Info("Rejecting unknown category", "category", filter.Category)
+			return filter, fmt.Errorf("%w: %s", ErrUnknownCategory, filter.Category)
+		}
+	}
+
+	if filter.Category != "" && filter.Subcategories && s.categories != nil {
+		names, err := s.categories.Subtree(ctx, filter.Category)
+		if err != nil {
+			return filter, err
+		}
+		if names != nil {
+			filter.Category, filter.Categories = "", names
+		}
+	}
+
+	return filter, nil
+}
+
+// validateFilter checks the search term, price range and pagination of a filter
+func (s *ProductService) validateFilter(filter ProductFilter) error {
+	if utf8.RuneCountInString(filter.Search) > s.maxSearchLength {
+		return fmt.Errorf("%w: must be at most %d characters", ErrSearchTermTooLong, s.maxSearchLength)
+	}
+
+	if filter.MinPrice != nil && filter.MaxPrice != nil && *filter.MinPrice > *filter.MaxPrice {
+		return fmt.Errorf("%w: minPrice must not exceed maxPrice", ErrInvalidFilter)
+	}
+
+	if filter.Limit < 0 {
+		return fmt.Errorf("%w: limit must not be negative", ErrInvalidFilter)
+	}
+
+	if filter.Offset < 0 {
+		return fmt.Errorf("%w: offset must not be negative", ErrInvalidFilter)
+	}
+
+	if err := filter.Sort.Validate(SortFields...); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidFilter, err)
+	}
+
+	return nil
+}
+
+// isKnownCategory reports whether category is in the allowlist, or, without
+// an allowlist, whether it is in the category tree or any product uses it
+func (s *ProductService) isKnownCategory(ctx context.Context, category string) (bool, error) {
+	if s.knownCategories != nil {
+		return s.knownCategories[category], nil
+	}
+	if s.categories != nil {
+		return s.categories.Exists(ctx, category)
+	}
+
+	products, err := s.repo.Find(ProductFilter{Category: category, Limit: 1})
+	if err != nil {
+		return false, fmt.Errorf("failed to look up category: %w", err)
+	}
+	return len(products) > 0, nil
+}
+
 // IsProductAvailable checks if a product is available
-func (s *ProductService) IsProductAvailable(productID string) (bool, error) {
-	product, err := s.GetProduct(productID)
+func (s *ProductService) IsProductAvailable(ctx context.Context, productID string) (bool, error) {
+	product, err := s.GetProduct(ctx, productID)
 	if err != nil {
 		return false, err
 	}
@@ -168,47 +1355,269 @@ func (s *ProductService) IsProductAvailable(productID string) (bool, error) {
 	return product.IsValid(), nil
 }
 
-// validateProductRequest validates the product request
-func (s *ProductService) validateProductRequest(req ProductRequest) error {
-	if req.Name == "" {
-		return fmt.Errorf("product name is required")
+// CheckAvailability reports the stock status and orderability of a product
+func (s *ProductService) CheckAvailability(ctx context.Context, productID string) (*Availability, error) {
+	product, err := s.GetProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	return availabilityOf(product), nil
+}
+
+// CheckBulkAvailability reports whether each line of an order can be
+// fulfilled, looking up all of its products in one repository call. Up to
+// the configured maximum batch size of lines are allowed; each needs a
+// product ID and a positive quantity, or the check fails with
+// ErrInvalidBatch. Unknown and deleted products are reported as not found
+// rather than failing the check.
+func (s *ProductService) CheckBulkAvailability(ctx context.Context, lines []AvailabilityLine) (*BulkAvailability, error) {
+	logger := logging.FromContext(ctx)
+
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("%w: at least one line is required", ErrInvalidBatch)
+	}
+	if len(lines) > s.maxBatchSize {
+		return nil, fmt.Errorf("%w: at most %d lines are allowed", ErrInvalidBatch, s.maxBatchSize)
+	}
+
+	demand := make(map[string]int, len(lines))
+	ids := make([]string, 0, len(lines))
+	for i, line := range lines {
+		if strings.TrimSpace(line.ProductID) == "" {
+			return nil, fmt.Errorf("%w: line %d has no product ID", ErrInvalidBatch, i+1)
+		}
+		if line.Quantity < 1 {
+			return nil, fmt.Errorf("%w: line %d must request at least 1 unit", ErrInvalidBatch, i+1)
+		}
+		if _, seen := demand[line.ProductID]; !seen {
+			ids = append(ids, line.ProductID)
+		}
+		demand[line.ProductID] += line.Quantity
+	}
+	logger.Debug("Checking availability", "lines", len(lines), "products", len(ids))
+
+	products, err := s.repo.GetByIDs(ids)
+	if err != nil {
+		logger.Error("Failed to check availability", "error", err)
+		return nil, fmt.Errorf("failed to check availability: %w", err)
+	}
+	byID := make(map[string]*Product, len(products))
+	for _, product := range products {
+		byID[product.ProductID] = product
+	}
+
+	result := &BulkAvailability{Lines: make([]LineAvailability, len(lines)), Fulfillable: true}
+	for i, line := range lines {
+		report := LineAvailability{ProductID: line.ProductID, Requested: line.Quantity}
+		if product, ok := byID[line.ProductID]; ok {
+			availability := availabilityOf(product)
+			report.Found = true
+			report.Quantity, report.InStock, report.Orderable = availability.Quantity, availability.InStock, availability.Orderable
+			report.Fulfillable = availability.Orderable && availability.Quantity >= demand[line.ProductID]
+		}
+		result.Lines[i] = report
+		result.Fulfillable = result.Fulfillable && report.Fulfillable
+	}
+
+	logger.Debug("Checked availability", "fulfillable", result.Fulfillable)
+	return result, nil
+}
+
+// availabilityOf reports the stock status and orderability of product
+func availabilityOf(product *Product) *Availability {
+	return &Availability{
+		ProductID: product.ProductID,
+		Quantity:  product.Quantity,
+		InStock:   product.InStock(),
+		Orderable: product.IsValid(),
 	}
+}
 
-	if len(req.Name) < 2 {
-		return fmt.Errorf("product name must be at least 2 characters")
+// validateProductRequest checks the request's validate tags, that its
+// category exists when a category tree is configured, then the weight and
+// dimension limits that depend on units and the barcode's check digit
+func (s *ProductService) validateProductRequest(ctx context.Context, req *ProductRequest) error {
+	if err := validation.Struct(req); err != nil {
+		return err
 	}
 
-	if len(req.Name) > 100 {
-		return fmt.Errorf("product name must be at most 100 characters")
+	if s.categories != nil {
+		exists, err := s.categories.Exists(ctx, req.Category)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			// The category is part of the request, not the resource it names
+			return apperr.Errorf(apperr.ErrValidation, "%w: %s", ErrUnknownCategory, req.Category)
+		}
 	}
 
-	if req.Description == "" {
-		return fmt.Errorf("product description is required")
+	if err := s.normalizePricing(req); err != nil {
+		return err
 	}
 
-	if len(req.Description) < 10 {
-		return fmt.Errorf("product description must be at least 10 characters")
+	if err := s.validateWeight(req.Weight); err != nil {
+		return err
 	}
 
-	if len(req.Description) > 500 {
-		return fmt.Errorf("product description must be at most 500 characters")
+	if err := s.validateDimensions(req.Dimensions); err != nil {
+		return err
 	}
 
-	if req.Price <= 0 {
-		return fmt.Errorf("product price must be greater than 0")
+	if req.Barcode != "" {
+		code, err := NormalizeBarcode(req.Barcode)
+		if err != nil {
+			return err
+		}
+		req.Barcode = code
 	}
+	return nil
+}
 
-	if req.Category == "" {
-		return fmt.Errorf("product category is required")
+// normalizePricing defaults and upper-cases the currency codes of req and
+// checks its explicit prices
+func (s *ProductService) normalizePricing(req *ProductRequest) error {
+	if req.Currency == "" {
+		req.Currency = s.currency
 	}
+	code, err := currency.Normalize(req.Currency)
+	if err != nil {
+		return err
+	}
+	req.Currency = code
 
-	if len(req.Category) < 2 {
-		return fmt.Errorf("product category must be at least 2 characters")
+	if len(req.Prices) == 0 {
+		req.Prices = nil
+		return nil
 	}
 
-	if len(req.Category) > 50 {
-		return fmt.Errorf("product category must be at most 50 characters")
+	prices := make(map[string]float64, len(req.Prices))
+	for rawCode, price := range req.Prices {
+		code, err := currency.Normalize(rawCode)
+		if err != nil {
+			return err
+		}
+		if code == req.Currency {
+			return fmt.Errorf("%w: prices must not repeat the product currency %s", ErrInvalidPrice, code)
+		}
+		if price <= 0 {
+			return fmt.Errorf("%w: %s price must be greater than 0", ErrInvalidPrice, code)
+		}
+		prices[code] = price
+	}
+	req.Prices = prices
+	return nil
+}
+
+// PriceIn returns product's price in code, which must already be normalized:
+// its own price for its currency, an explicit price from Prices, or else
+// Price converted with the exchange-rate provider. A currency with no rate
+// fails with currency.ErrRateUnavailable.
+func (s *ProductService) PriceIn(ctx context.Context, product *Product, code string) (float64, error) {
+	if price, ok := product.Prices[code]; ok {
+		return price, nil
+	}
+
+	from := product.Currency
+	if from == "" {
+		from = s.currency
+	}
+
+	price, err := currency.Convert(ctx, s.rates, product.Price, from, code)
+	if err != nil {
+		logging.FromContext(ctx).Debug("Failed to convert price", "product_id", product.ProductID, "currency", code, "error", err)
+		return 0, fmt.Errorf("failed to price product %s: %w", product.ProductID, err)
+	}
+	return price, nil
+}
+
+// ProductImages returns the images of each of productIDs that has any, keyed
+// by product ID. Images are decoration: when the gallery fails the failure is
+// logged and the products are answered without images.
+func (s *ProductService) ProductImages(ctx context.Context, productIDs []string) map[string][]Image {
+	if s.images == nil || len(productIDs) == 0 {
+		return nil
+	}
+
+	images, err := s.images.ImagesOf(ctx, productIDs)
+	if err != nil {
+		logging.FromContext(ctx).Warn("Answering products without images", "products", len(productIDs), "error", err)
+		return nil
+	}
+	return images
+}
+
+// validateWeight checks an optional weight is non-negative and within the
+// configured maximum
+func (s *ProductService) validateWeight(weight *Weight) error {
+	if weight == nil {
+		return nil
+	}
+
+	if weight.Value < 0 {
+		return apperr.New(apperr.ErrValidation, "product weight must not be negative")
+	}
+
+	kg, err := s.normalizeWeight(weight).Kilograms()
+	if err != nil {
+		return fmt.Errorf("product weight: %w", err)
+	}
+
+	if kg > s.measures.MaxWeightKg {
+		return apperr.Errorf(apperr.ErrValidation, "product weight must be at most %g kg", s.measures.MaxWeightKg)
+	}
+
+	return nil
+}
+
+// validateDimensions checks optional dimensions are non-negative and within
+// the configured maximum
+func (s *ProductService) validateDimensions(dims *Dimensions) error {
+	if dims == nil {
+		return nil
+	}
+
+	if dims.Length < 0 || dims.Width < 0 || dims.Height < 0 {
+		return apperr.New(apperr.ErrValidation, "product dimensions must not be negative")
+	}
+
+	cm, err := s.normalizeDimensions(dims).Centimeters()
+	if err != nil {
+		return fmt.Errorf("product dimensions: %w", err)
+	}
+
+	for _, side := range cm {
+		if side > s.measures.MaxDimensionCm {
+			return apperr.Errorf(apperr.ErrValidation, "product dimensions must be at most %g cm per side", s.measures.MaxDimensionCm)
+		}
 	}
 
 	return nil
 }
+
+// normalizeWeight copies a request weight, filling in the configured unit
+func (s *ProductService) normalizeWeight(weight *Weight) *Weight {
+	if weight == nil {
+		return nil
+	}
+
+	normalized := *weight
+	if normalized.Unit == "" {
+		normalized.Unit = s.measures.WeightUnit
+	}
+	return &normalized
+}
+
+// normalizeDimensions copies request dimensions, filling in the configured unit
+func (s *ProductService) normalizeDimensions(dims *Dimensions) *Dimensions {
+	if dims == nil {
+		return nil
+	}
+
+	normalized := *dims
+	if normalized.Unit == "" {
+		normalized.Unit = s.measures.LengthUnit
+	}
+	return &normalized
+}
@@ -1,7 +1,25 @@
 package product
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"enricher-api-go/internal/apperr"
+	"enricher-api-go/internal/auth"
+	"enricher-api-go/internal/clock"
+	"enricher-api-go/internal/currency"
+	"enricher-api-go/internal/idgen"
+	"enricher-api-go/internal/listing"
+	"enricher-api-go/internal/pagination"
 )
 
 func TestProductService_GetProduct(t *testing.T) {
@@ -10,7 +28,7 @@ func TestProductService_GetProduct(t *testing.T) {
 	service := NewService(repo)
 
 	// Act
-	product, err := service.GetProduct("product-789")
+	product, err := service.GetProduct(context.Background(), "product-789")
 	// Assert
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
@@ -32,7 +50,7 @@ func TestProductService_GetProduct(t *testing.T) {
 		t.Errorf("Expected product price 999.00, got %.2f", product.Price)
 	}
 
-	if !product.InStock {
+	if !product.InStock() {
 		t.Error("Expected product to be in stock")
 	}
 }
@@ -43,7 +61,7 @@ func TestProductService_GetProduct_NotFound(t *testing.T) {
 	service := NewService(repo)
 
 	// Act
-	product, err := service.GetProduct("non-existent")
+	product, err := service.GetProduct(context.Background(), "non-existent")
 
 	// Assert
 	if err == nil {
@@ -65,11 +83,11 @@ func TestProductService_CreateProduct(t *testing.T) {
 		Description: "A test product for unit testing",
 		Price:       29.99,
 		Category:    "Test",
-		InStock:     true,
+		Quantity:    10,
 	}
 
 	// Act
-	product, err := service.CreateProduct(req)
+	product, err := service.CreateProduct(context.Background(), req)
 	// Assert
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
@@ -88,7 +106,7 @@ func TestProductService_CreateProduct(t *testing.T) {
 	}
 
 	// Verify product can be retrieved
-	retrievedProduct, err := service.GetProduct(product.ProductID)
+	retrievedProduct, err := service.GetProduct(context.Background(), product.ProductID)
 	if err != nil {
 		t.Fatalf("Expected no error retrieving product, got %v", err)
 	}
@@ -98,6 +116,51 @@ func TestProductService_CreateProduct(t *testing.T) {
 	}
 }
 
+func TestProductService_CreateProduct_SequentialIDsUnderConcurrency(t *testing.T) {
+	// Arrange
+	repo := NewInMemoryRepository()
+	service := NewService(repo, WithIDGenerator(idgen.NewSequentialGenerator(idgen.NewInMemoryCounter())))
+
+	const creators = 200
+	ids := make([]string, creators)
+	errs := make([]error, creators)
+
+	// Act
+	var wg sync.WaitGroup
+	for i := 0; i < creators; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			product, err := service.CreateProduct(context.Background(), ProductRequest{
+				Name:        "Concurrent Product",
+				Description: "Created concurrently for ID testing",
+				Price:       9.99,
+				Category:    "Test",
+			})
+			errs[i] = err
+			if product != nil {
+				ids[i] = product.ProductID
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// Assert
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("Expected no error for creator %d, got %v", i, err)
+		}
+	}
+
+	sort.Strings(ids)
+	for i, id := range ids {
+		expected := fmt.Sprintf("product-%06d", i+1)
+		if id != expected {
+			t.Fatalf("Expected contiguous unique IDs, got %s at position %d (want %s)", id, i, expected)
+		}
+	}
+}
+
 func TestProductService_CreateProduct_ValidationError(t *testing.T) {
 	// Arrange
 	repo := NewInMemoryRepository()
@@ -114,7 +177,7 @@ func TestProductService_CreateProduct_ValidationError(t *testing.T) {
 				Description: "Valid description here",
 				Price:       29.99,
 				Category:    "Test",
-				InStock:     true,
+				Quantity:    10,
 			},
 		},
 		{
@@ -124,7 +187,7 @@ func TestProductService_CreateProduct_ValidationError(t *testing.T) {
 				Description: "Valid description here",
 				Price:       -10.00,
 				Category:    "Test",
-				InStock:     true,
+				Quantity:    10,
 			},
 		},
 		{
@@ -134,7 +197,7 @@ func TestProductService_CreateProduct_ValidationError(t *testing.T) {
 				Description: "Short",
 				Price:       29.99,
 				Category:    "Test",
-				InStock:     true,
+				Quantity:    10,
 			},
 		},
 		{
@@ -144,7 +207,7 @@ func TestProductService_CreateProduct_ValidationError(t *testing.T) {
 				Description: "Valid description here",
 				Price:       29.99,
 				Category:    "",
-				InStock:     true,
+				Quantity:    10,
 			},
 		},
 	}
@@ -152,7 +215,215 @@ func TestProductService_CreateProduct_ValidationError(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			// Act
-			product, err := service.CreateProduct(tc.request)
+			product, err := service.CreateProduct(context.Background(), tc.request)
+
+			// Assert
+			if err == nil {
+				t.Fatal("Expected validation error, got nil")
+			}
+
+			if product != nil {
+				t.Fatal("Expected nil product, got result")
+			}
+		})
+	}
+}
+
+func TestProductService_CreateProduct_WeightAndDimensions(t *testing.T) {
+	// Arrange
+	repo := NewInMemoryRepository()
+	service := NewService(repo)
+
+	req := ProductRequest{
+		Name:        "Shipping Box",
+		Description: "Corrugated box for parcel shipments",
+		Price:       4.99,
+		Category:    "Packaging",
+		Quantity:    10,
+		Weight:      &Weight{Value: 350, Unit: WeightUnitGram},
+		Dimensions:  &Dimensions{Length: 30, Width: 20, Height: 10},
+	}
+
+	// Act
+	product, err := service.CreateProduct(context.Background(), req)
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if product.Dimensions == nil || product.Dimensions.Unit != DefaultLengthUnit {
+		t.Fatalf("Expected dimensions with default unit %q, got %+v", DefaultLengthUnit, product.Dimensions)
+	}
+
+	kg, err := product.Weight.Kilograms()
+	if err != nil {
+		t.Fatalf("Expected no error converting weight, got %v", err)
+	}
+
+	if kg != 0.35 {
+		t.Errorf("Expected weight 0.35 kg, got %v", kg)
+	}
+}
+
+func TestNormalizeBarcode(t *testing.T) {
+	tests := []struct {
+		name    string
+		code    string
+		want    string
+		wantErr bool
+	}{
+		{name: "EAN-13", code: "4006381333931", want: "4006381333931"},
+		{name: "UPC-A", code: "036000291452", want: "036000291452"},
+		{name: "GTIN-8", code: "96385074", want: "96385074"},
+		{name: "GTIN-14", code: "10614141000415", want: "10614141000415"},
+		{name: "surrounding spaces", code: " 4006381333931 ", want: "4006381333931"},
+		{name: "wrong check digit", code: "4006381333932", wantErr: true},
+		{name: "wrong length", code: "400638133393", wantErr: true},
+		{name: "not digits", code: "40063813339A1", wantErr: true},
+		{name: "empty", code: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			got, err := NormalizeBarcode(tt.code)
+
+			// Assert
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidBarcode) || !errors.Is(err, apperr.ErrValidation) {
+					t.Errorf("Expected ErrInvalidBarcode, got %q, %v", got, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("Expected %s, got %q, %v", tt.want, got, err)
+			}
+		})
+	}
+}
+
+func TestProductService_GetProductByBarcode(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	service := NewService(NewInMemoryRepository())
+	req := ProductRequest{
+		Name:        "Tea Tin",
+		Description: "Airtight tin for loose-leaf tea",
+		Price:       12,
+		Category:    "Kitchen",
+		Quantity:    10,
+		Barcode:     " 4006381333931",
+	}
+	tin, err := service.CreateProduct(ctx, req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Act
+	found, foundErr := service.GetProductByBarcode(ctx, "4006381333931")
+	_, unknownErr := service.GetProductByBarcode(ctx, "96385074")
+	_, invalidErr := service.GetProductByBarcode(ctx, "4006381333932")
+	_, duplicateErr := service.CreateProduct(ctx, req)
+	updated, updateErr := service.UpdateProduct(ctx, tin.ProductID, req)
+	cleared := ""
+	patched, patchErr := service.PatchProduct(ctx, tin.ProductID, ProductPatch{Barcode: &cleared})
+	_, clearedErr := service.GetProductByBarcode(ctx, "4006381333931")
+
+	// Assert
+	if tin.Barcode != "4006381333931" {
+		t.Errorf("Expected the trimmed barcode to be stored, got %q", tin.Barcode)
+	}
+	if foundErr != nil || found.ProductID != tin.ProductID {
+		t.Errorf("Expected %s, got %+v, %v", tin.ProductID, found, foundErr)
+	}
+	if !errors.Is(unknownErr, ErrProductNotFound) {
+		t.Errorf("Expected ErrProductNotFound for an unknown barcode, got %v", unknownErr)
+	}
+	if !errors.Is(invalidErr, ErrInvalidBarcode) {
+		t.Errorf("Expected ErrInvalidBarcode for a bad check digit, got %v", invalidErr)
+	}
+	if !errors.Is(duplicateErr, ErrBarcodeExists) || !errors.Is(duplicateErr, apperr.ErrConflict) {
+		t.Errorf("Expected ErrBarcodeExists for a second product with the barcode, got %v", duplicateErr)
+	}
+	if updateErr != nil || updated.Barcode != "4006381333931" {
+		t.Errorf("Expected a product to keep its own barcode, got %+v, %v", updated, updateErr)
+	}
+	if patchErr != nil || patched.Barcode != "" || !errors.Is(clearedErr, ErrProductNotFound) {
+		t.Errorf("Expected an empty barcode to remove it, got %+v, %v, %v", patched, patchErr, clearedErr)
+	}
+}
+
+func TestProductService_CreateProduct_ConfiguredMeasures(t *testing.T) {
+	// Arrange
+	service := NewService(NewInMemoryRepository(),
+		WithMeasures(Measures{WeightUnit: WeightUnitPound, LengthUnit: LengthUnitInch, MaxWeightKg: 10, MaxDimensionCm: 100}))
+	req := ProductRequest{
+		Name:        "Shipping Box",
+		Description: "Corrugated box for parcel shipments",
+		Price:       4.99,
+		Category:    "Packaging",
+		Quantity:    10,
+		Weight:      &Weight{Value: 20},
+		Dimensions:  &Dimensions{Length: 30, Width: 20, Height: 10},
+	}
+	tooHeavy := req
+	tooHeavy.Weight = &Weight{Value: 25}
+	tooLong := req
+	tooLong.Dimensions = &Dimensions{Length: 40, Width: 20, Height: 10}
+
+	// Act
+	product, err := service.CreateProduct(context.Background(), req)
+	_, heavyErr := service.CreateProduct(context.Background(), tooHeavy)
+	_, longErr := service.CreateProduct(context.Background(), tooLong)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if product.Weight.Unit != WeightUnitPound || product.Dimensions.Unit != LengthUnitInch {
+		t.Errorf("Expected the configured units, got %q and %q", product.Weight.Unit, product.Dimensions.Unit)
+	}
+	if !errors.Is(heavyErr, apperr.ErrValidation) || !strings.Contains(heavyErr.Error(), "at most 10 kg") {
+		t.Errorf("Expected 25 lb to exceed the 10 kg maximum, got %v", heavyErr)
+	}
+	if !errors.Is(longErr, apperr.ErrValidation) || !strings.Contains(longErr.Error(), "at most 100 cm") {
+		t.Errorf("Expected 40 in to exceed the 100 cm maximum, got %v", longErr)
+	}
+}
+
+func TestProductService_CreateProduct_InvalidWeightAndDimensions(t *testing.T) {
+	// Arrange
+	repo := NewInMemoryRepository()
+	service := NewService(repo)
+
+	base := ProductRequest{
+		Name:        "Shipping Box",
+		Description: "Corrugated box for parcel shipments",
+		Price:       4.99,
+		Category:    "Packaging",
+		Quantity:    10,
+	}
+
+	testCases := []struct {
+		name       string
+		weight     *Weight
+		dimensions *Dimensions
+	}{
+		{name: "Negative dimension", dimensions: &Dimensions{Length: 30, Width: -1, Height: 10}},
+		{name: "Unsupported unit", dimensions: &Dimensions{Length: 30, Width: 20, Height: 10, Unit: "m"}},
+		{name: "Dimension above maximum", dimensions: &Dimensions{Length: 300, Width: 20, Height: 10, Unit: LengthUnitInch}},
+		{name: "Negative weight", weight: &Weight{Value: -2}},
+		{name: "Weight above maximum", weight: &Weight{Value: 5000, Unit: WeightUnitPound}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := base
+			req.Weight = tc.weight
+			req.Dimensions = tc.dimensions
+
+			// Act
+			product, err := service.CreateProduct(context.Background(), req)
 
 			// Assert
 			if err == nil {
@@ -172,7 +443,7 @@ func TestProductService_IsProductAvailable(t *testing.T) {
 	service := NewService(repo)
 
 	// Test available product (in stock)
-	isAvailable, err := service.IsProductAvailable("product-789")
+	isAvailable, err := service.IsProductAvailable(context.Background(), "product-789")
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
@@ -182,7 +453,7 @@ func TestProductService_IsProductAvailable(t *testing.T) {
 	}
 
 	// Test unavailable product (out of stock)
-	isAvailable, err = service.IsProductAvailable("product-202")
+	isAvailable, err = service.IsProductAvailable(context.Background(), "product-202")
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
@@ -192,13 +463,54 @@ func TestProductService_IsProductAvailable(t *testing.T) {
 	}
 }
 
+func TestProductService_CheckAvailability_InStockButNotOrderable(t *testing.T) {
+	// Arrange
+	repo := NewInMemoryRepository()
+	service := NewService(repo)
+
+	err := repo.Create(&Product{
+		ProductID: "product-unnamed",
+		Price:     10.00,
+		Category:  "Kitchen",
+		Quantity:  3,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error seeding product, got %v", err)
+	}
+
+	// Act
+	availability, err := service.CheckAvailability(context.Background(), "product-unnamed")
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if !availability.InStock {
+		t.Error("Expected product to be in stock")
+	}
+
+	if availability.Orderable {
+		t.Error("Expected product without a name not to be orderable")
+	}
+
+	// Out-of-stock sample product is neither in stock nor orderable
+	availability, err = service.CheckAvailability(context.Background(), "product-202")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if availability.InStock || availability.Orderable {
+		t.Errorf("Expected product-202 to be out of stock and not orderable, got %+v", availability)
+	}
+}
+
 func TestProductService_GetProductsByCategory(t *testing.T) {
 	// Arrange
 	repo := NewInMemoryRepository()
 	service := NewService(repo)
 
 	// Act
-	products, err := service.GetProductsByCategory("Electronics")
+	products, err := service.GetProductsByCategory(context.Background(), "Electronics")
 	// Assert
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
@@ -222,93 +534,1137 @@ func TestProductService_GetProductsByCategory(t *testing.T) {
 	}
 }
 
-func TestProductService_UpdateProduct(t *testing.T) {
-	// Arrange
-	repo := NewInMemoryRepository()
-	service := NewService(repo)
+func TestProductService_GetProductsByCategory_UnknownCategory(t *testing.T) {
+	testCases := []struct {
+		name      string
+		opts      []Option
+		expectErr bool
+	}{
+		{name: "Lenient default", opts: nil, expectErr: false},
+		{name: "Strict with stored categories", opts: []Option{WithCategoryFilter(CategoryFilterStrict, nil)}, expectErr: true},
+		{name: "Strict with allowlist", opts: []Option{WithCategoryFilter(CategoryFilterStrict, []string{"Electronics", "Garden"})}, expectErr: true},
+	}
 
-	req := ProductRequest{
-		Name:        "Updated Product",
-		Description: "This product has been updated for testing",
-		Price:       1299.99,
-		Category:    "Updated",
-		InStock:     false,
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			service := NewService(NewInMemoryRepository(), tc.opts...)
+
+			// Act
+			products, err := service.GetProductsByCategory(context.Background(), "Electronix")
+
+			// Assert
+			if tc.expectErr {
+				if !errors.Is(err, ErrUnknownCategory) {
+					t.Fatalf("Expected ErrUnknownCategory, got %v", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			if len(products) != 0 {
+				t.Errorf("Expected no products, got %d", len(products))
+			}
+		})
 	}
+}
+
+func TestProductService_GetProductsByCategory_StrictAllowlist(t *testing.T) {
+	// Arrange
+	service := NewService(NewInMemoryRepository(), WithCategoryFilter(CategoryFilterStrict, []string{"Garden"}))
 
 	// Act
-	product, err := service.UpdateProduct("product-789", req)
+	products, err := service.GetProductsByCategory(context.Background(), "Garden")
 	// Assert
 	if err != nil {
-		t.Fatalf("Expected no error, got %v", err)
+		t.Fatalf("Expected allowlisted category without products to succeed, got %v", err)
 	}
 
-	if product.Name != "Updated Product" {
-		t.Errorf("Expected updated name 'Updated Product', got %s", product.Name)
+	if len(products) != 0 {
+		t.Errorf("Expected no products, got %d", len(products))
 	}
+}
 
-	if product.Price != 1299.99 {
-		t.Errorf("Expected updated price 1299.99, got %.2f", product.Price)
+// stubCategoryTree is a CategoryTree mapping each category to its subtree
+type stubCategoryTree map[string][]string
+
+func (t stubCategoryTree) Exists(ctx context.Context, name string) (bool, error) {
+	_, ok := t[name]
+	return ok, nil
+}
+
+func (t stubCategoryTree) Subtree(ctx context.Context, name string) ([]string, error) {
+	return t[name], nil
+}
+
+func TestProductService_CategoryTree(t *testing.T) {
+	// Arrange
+	tree := stubCategoryTree{
+		"Home":      {"Home", "Furniture", "Kitchen"},
+		"Furniture": {"Furniture"},
+		"Kitchen":   {"Kitchen"},
 	}
+	service := NewService(NewInMemoryRepository(), WithCategoryTree(tree), WithCategoryFilter(CategoryFilterStrict, nil))
+	ctx := context.Background()
+	req := ProductRequest{Name: "Garden Hose", Description: "Twenty metre hose", Price: 25, Category: "Garden"}
 
-	if product.InStock {
-		t.Error("Expected product to be out of stock")
+	// Act
+	_, createErr := service.CreateProduct(ctx, req)
+	direct, directErr := service.FindProducts(ctx, ProductFilter{Category: "Home"})
+	subtree, subtreeErr := service.FindProducts(ctx, ProductFilter{Category: "Home", Subcategories: true})
+	_, unknownErr := service.FindProducts(ctx, ProductFilter{Category: "Garden", Subcategories: true})
+
+	// Assert
+	if !errors.Is(createErr, ErrUnknownCategory) {
+		t.Errorf("Expected ErrUnknownCategory creating a product in an unknown category, got %v", createErr)
+	}
+	if directErr != nil || len(direct) != 0 {
+		t.Errorf("Expected no products directly in Home, got %d, %v", len(direct), directErr)
+	}
+	if subtreeErr != nil {
+		t.Fatalf("Expected no error, got %v", subtreeErr)
 	}
+	if len(subtree) != 2 {
+		t.Errorf("Expected the Furniture and Kitchen products under Home, got %d", len(subtree))
+	}
+	if !errors.Is(unknownErr, ErrUnknownCategory) {
+		t.Errorf("Expected ErrUnknownCategory for an unknown category in strict mode, got %v", unknownErr)
+	}
+}
 
-	// Verify changes persisted
-	retrievedProduct, err := service.GetProduct("product-789")
-	if err != nil {
-		t.Fatalf("Expected no error retrieving product, got %v", err)
+func TestProductService_SearchProducts(t *testing.T) {
+	// Arrange
+	repo := NewInMemoryRepository()
+	service := NewService(repo, WithMaxSearchTermLength(10))
+
+	testCases := []struct {
+		name        string
+		term        string
+		expectErr   error
+		expectCount int
+	}{
+		{name: "Blank term", term: "   ", expectErr: ErrSearchTermRequired},
+		{name: "Over-length term", term: "ergonomic office", expectErr: ErrSearchTermTooLong},
+		{name: "Valid term is trimmed and case-insensitive", term: "  ERGONOMIC ", expectCount: 2},
 	}
 
-	if retrievedProduct.Name != "Updated Product" {
-		t.Errorf("Expected persisted name 'Updated Product', got %s", retrievedProduct.Name)
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Act
+			products, err := service.SearchProducts(context.Background(), tc.term)
+
+			// Assert
+			if tc.expectErr != nil {
+				if !errors.Is(err, tc.expectErr) {
+					t.Fatalf("Expected %v, got %v", tc.expectErr, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			if len(products) != tc.expectCount {
+				t.Errorf("Expected %d products, got %d", tc.expectCount, len(products))
+			}
+		})
 	}
 }
 
-func TestProductService_DeleteProduct(t *testing.T) {
+func TestProductService_FindProducts_CombinedFilters(t *testing.T) {
 	// Arrange
 	repo := NewInMemoryRepository()
 	service := NewService(repo)
 
-	// Verify product exists first
-	_, err := service.GetProduct("product-789")
+	// Electronics products mentioning "with": product-123 (mouse), product-202 (lamp), product-789 (laptop)
+	filter := ProductFilter{Category: "Electronics", Search: "with", Limit: 2}
+
+	// Act
+	firstPage, err := service.FindProducts(context.Background(), filter)
+	// Assert
 	if err != nil {
-		t.Fatalf("Expected product to exist, got error: %v", err)
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := []string{"product-123", "product-202"}
+	if len(firstPage) != len(expected) {
+		t.Fatalf("Expected %d products, got %d", len(expected), len(firstPage))
+	}
+
+	for i, product := range firstPage {
+		if product.ProductID != expected[i] {
+			t.Errorf("Expected product %s at position %d, got %s", expected[i], i, product.ProductID)
+		}
 	}
 
 	// Act
-	err = service.DeleteProduct("product-789")
+	filter.Offset = 2
+	secondPage, err := service.FindProducts(context.Background(), filter)
 	// Assert
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
 
-	// Verify product no longer exists
-	_, err = service.GetProduct("product-789")
-	if err == nil {
-		t.Fatal("Expected error when getting deleted product, got nil")
+	if len(secondPage) != 1 || secondPage[0].ProductID != "product-789" {
+		t.Errorf("Expected second page to hold only product-789, got %v", secondPage)
 	}
 }
 
-func TestProductService_ListProducts(t *testing.T) {
+func TestProductService_FindProducts_InvalidFilter(t *testing.T) {
+	// Arrange
+	repo := NewInMemoryRepository()
+	service := NewService(repo)
+
+	minPrice, maxPrice := 100.0, 10.0
+
+	// Act
+	_, err := service.FindProducts(context.Background(), ProductFilter{MinPrice: &minPrice, MaxPrice: &maxPrice})
+	_, sortErr := service.FindProducts(context.Background(), ProductFilter{Sort: listing.Sort{{Field: "description"}}})
+
+	// Assert
+	if !errors.Is(err, ErrInvalidFilter) {
+		t.Fatalf("Expected ErrInvalidFilter, got %v", err)
+	}
+	if !errors.Is(sortErr, ErrInvalidFilter) || !strings.Contains(sortErr.Error(), "cannot sort on description") {
+		t.Errorf("Expected ErrInvalidFilter for an unsortable field, got %v", sortErr)
+	}
+}
+
+func TestProductService_GetProducts(t *testing.T) {
 	// Arrange
 	repo := NewInMemoryRepository()
 	service := NewService(repo)
 
 	// Act
-	products, err := service.ListProducts()
+	result, err := service.GetProducts(context.Background(), []string{"product-123", "product-missing", "product-789", "product-123"})
+
 	// Assert
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
 
-	if len(products) == 0 {
-		t.Fatal("Expected products to be returned")
+	if len(result.Products) != 2 {
+		t.Fatalf("Expected 2 products, got %d", len(result.Products))
 	}
 
-	// Should have the sample products from repository initialization
-	expectedCount := 5 // Based on NewInMemoryRepository sample data
-	if len(products) != expectedCount {
-		t.Errorf("Expected %d products, got %d", expectedCount, len(products))
+	if result.Products[0].ProductID != "product-123" || result.Products[1].ProductID != "product-789" {
+		t.Errorf("Expected products in request order, got %s, %s", result.Products[0].ProductID, result.Products[1].ProductID)
+	}
+
+	if len(result.Missing) != 1 || result.Missing[0] != "product-missing" {
+		t.Errorf("Expected missing [product-missing], got %v", result.Missing)
+	}
+}
+
+func TestProductService_GetProducts_InvalidBatch(t *testing.T) {
+	// Arrange
+	repo := NewInMemoryRepository()
+	service := NewService(repo, WithMaxBatchSize(2))
+
+	testCases := []struct {
+		name string
+		ids  []string
+	}{
+		{name: "Empty batch", ids: nil},
+		{name: "Blank ID", ids: []string{"product-789", " "}},
+		{name: "Too many IDs", ids: []string{"product-789", "product-123", "product-456"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Act
+			_, err := service.GetProducts(context.Background(), tc.ids)
+
+			// Assert
+			if !errors.Is(err, ErrInvalidBatch) {
+				t.Errorf("Expected ErrInvalidBatch, got %v", err)
+			}
+		})
+	}
+}
+
+func TestProductService_CheckBulkAvailability(t *testing.T) {
+	tests := []struct {
+		name                string
+		lines               []AvailabilityLine
+		expectedLines       []bool
+		expectedFulfillable bool
+	}{
+		{
+			name:                "every line in stock",
+			lines:               []AvailabilityLine{{ProductID: "product-789", Quantity: 25}, {ProductID: "product-123", Quantity: 1}},
+			expectedLines:       []bool{true, true},
+			expectedFulfillable: true,
+		},
+		{
+			name:          "out of stock and missing products",
+			lines:         []AvailabilityLine{{ProductID: "product-789", Quantity: 1}, {ProductID: "product-202", Quantity: 1}, {ProductID: "product-missing", Quantity: 1}},
+			expectedLines: []bool{true, false, false},
+		},
+		{
+			name:          "lines of one product share its stock",
+			lines:         []AvailabilityLine{{ProductID: "product-456", Quantity: 5}, {ProductID: "product-456", Quantity: 5}},
+			expectedLines: []bool{false, false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			service := NewService(NewInMemoryRepository())
+
+			// Act
+			result, err := service.CheckBulkAvailability(context.Background(), tt.lines)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			got := make([]bool, len(result.Lines))
+			for i, line := range result.Lines {
+				got[i] = line.Fulfillable
+			}
+			if !slices.Equal(got, tt.expectedLines) || result.Fulfillable != tt.expectedFulfillable {
+				t.Errorf("Expected lines %v and fulfillable %v, got %v and %v", tt.expectedLines, tt.expectedFulfillable, got, result.Fulfillable)
+			}
+		})
+	}
+}
+
+func TestProductService_CheckBulkAvailability_InvalidLines(t *testing.T) {
+	// Arrange
+	service := NewService(NewInMemoryRepository(), WithMaxBatchSize(2))
+
+	testCases := []struct {
+		name  string
+		lines []AvailabilityLine
+	}{
+		{name: "No lines", lines: nil},
+		{name: "Blank ID", lines: []AvailabilityLine{{ProductID: " ", Quantity: 1}}},
+		{name: "Zero quantity", lines: []AvailabilityLine{{ProductID: "product-789"}}},
+		{name: "Too many lines", lines: []AvailabilityLine{{ProductID: "product-789", Quantity: 1}, {ProductID: "product-123", Quantity: 1}, {ProductID: "product-456", Quantity: 1}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Act
+			_, err := service.CheckBulkAvailability(context.Background(), tc.lines)
+
+			// Assert
+			if !errors.Is(err, ErrInvalidBatch) {
+				t.Errorf("Expected ErrInvalidBatch, got %v", err)
+			}
+		})
+	}
+}
+
+func TestProductService_BulkWriteProducts(t *testing.T) {
+	// Arrange
+	repo := NewInMemoryRepository()
+	service := NewService(repo)
+	valid := ProductRequest{Name: "Desk Lamp", Description: "Adjustable LED desk lamp", Price: 39.99, Category: "Home", Quantity: 5}
+	invalid := ProductRequest{Name: "X", Description: "Too short", Price: 0, Category: "Home"}
+	req := BulkRequest{Items: []BulkItem{
+		{Product: valid},
+		{ProductID: "product-789", Product: ProductRequest{Name: "Wireless Mouse", Description: "Ergonomic wireless mouse", Price: 24.99, Category: "Electronics", Quantity: 3}},
+		{Product: invalid},
+		{ProductID: "product-missing", Product: valid},
+	}}
+
+	// Act
+	result, err := service.BulkWriteProducts(context.Background(), req)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	statuses := make([]string, len(result.Items))
+	for i, item := range result.Items {
+		statuses[i] = item.Status
+	}
+	want := []string{BulkCreated, BulkUpdated, BulkFailed, BulkFailed}
+	if !slices.Equal(statuses, want) {
+		t.Fatalf("Expected %v, got %v", want, statuses)
+	}
+	if !errors.Is(result.Items[3].Err, ErrProductNotFound) {
+		t.Errorf("Expected ErrProductNotFound for the missing product, got %v", result.Items[3].Err)
+	}
+	if _, err := repo.GetByID(result.Items[0].Product.ProductID); err != nil {
+		t.Errorf("Expected the created product stored, got %v", err)
+	}
+	if updated, _ := repo.GetByID("product-789"); updated.Price != 24.99 {
+		t.Errorf("Expected the updated price stored, got %+v", updated)
+	}
+}
+
+func TestProductService_BulkWriteProducts_Atomic(t *testing.T) {
+	// Arrange
+	repo := NewInMemoryRepository()
+	service := NewService(repo)
+	before, _ := repo.List()
+	req := BulkRequest{Atomic: true, Items: []BulkItem{
+		{Product: ProductRequest{Name: "Desk Lamp", Description: "Adjustable LED desk lamp", Price: 39.99, Category: "Home"}},
+		{ProductID: "product-missing", Product: ProductRequest{Name: "Ghost", Description: "A product that does not exist", Price: 1, Category: "Home"}},
+	}}
+
+	// Act
+	result, err := service.BulkWriteProducts(context.Background(), req)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Items[0].Status != BulkSkipped || result.Items[1].Status != BulkFailed {
+		t.Errorf("Expected the valid item skipped and the missing one failed, got %+v", result.Items)
+	}
+	if after, _ := repo.List(); len(after) != len(before) {
+		t.Errorf("Expected no product created, got %d products instead of %d", len(after), len(before))
+	}
+
+	_, err = service.BulkWriteProducts(context.Background(), BulkRequest{})
+	if !errors.Is(err, ErrInvalidBulk) {
+		t.Errorf("Expected ErrInvalidBulk for an empty request, got %v", err)
+	}
+}
+
+func TestProductService_PatchProduct(t *testing.T) {
+	// Arrange
+	repo := NewInMemoryRepository()
+	service := NewService(repo)
+	before, _ := service.GetProduct(context.Background(), "product-123")
+	original := *before
+	quantity := original.Quantity + 5
+
+	// Act
+	product, err := service.PatchProduct(context.Background(), "product-123", ProductPatch{Quantity: &quantity})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if product.Quantity != quantity {
+		t.Errorf("Expected quantity %d, got %d", quantity, product.Quantity)
+	}
+
+	if product.Name != original.Name || product.Price != original.Price || product.Category != original.Category {
+		t.Errorf("Expected other fields to be kept, got %+v", product)
+	}
+}
+
+func TestProductService_PatchProduct_Invalid(t *testing.T) {
+	// Arrange
+	repo := NewInMemoryRepository()
+	service := NewService(repo)
+	price := -5.0
+
+	// Act
+	_, err := service.PatchProduct(context.Background(), "product-123", ProductPatch{Price: &price})
+	_, notFoundErr := service.PatchProduct(context.Background(), "product-missing", ProductPatch{})
+
+	// Assert
+	if err == nil {
+		t.Error("Expected the merged product to fail validation")
+	}
+
+	if !errors.Is(notFoundErr, ErrProductNotFound) {
+		t.Errorf("Expected ErrProductNotFound, got %v", notFoundErr)
+	}
+}
+
+func TestProductPatch_UnmarshalJSON_Price(t *testing.T) {
+	// Arrange
+	var valid, omitted, invalid ProductPatch
+
+	// Act
+	validErr := json.Unmarshal([]byte(`{"price": 19.990}`), &valid)
+	omittedErr := json.Unmarshal([]byte(`{"quantity": 0}`), &omitted)
+	invalidErr := json.Unmarshal([]byte(`{"price": 1e3}`), &invalid)
+
+	// Assert
+	if validErr != nil || valid.Price == nil || *valid.Price != 19.99 {
+		t.Errorf("Expected price 19.99, got %v (%v)", valid.Price, validErr)
+	}
+
+	if omittedErr != nil || omitted.Price != nil {
+		t.Errorf("Expected no price when omitted, got %v (%v)", omitted.Price, omittedErr)
+	}
+
+	if !errors.Is(invalidErr, ErrInvalidPrice) {
+		t.Errorf("Expected ErrInvalidPrice, got %v", invalidErr)
+	}
+}
+
+func TestProductService_UpdateProduct(t *testing.T) {
+	// Arrange
+	repo := NewInMemoryRepository()
+	service := NewService(repo)
+
+	req := ProductRequest{
+		Name:        "Updated Product",
+		Description: "This product has been updated for testing",
+		Price:       1299.99,
+		Category:    "Updated",
+		Quantity:    0,
+	}
+
+	// Act
+	product, err := service.UpdateProduct(context.Background(), "product-789", req)
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if product.Name != "Updated Product" {
+		t.Errorf("Expected updated name 'Updated Product', got %s", product.Name)
+	}
+
+	if product.Price != 1299.99 {
+		t.Errorf("Expected updated price 1299.99, got %.2f", product.Price)
+	}
+
+	if product.InStock() {
+		t.Error("Expected product to be out of stock")
+	}
+
+	// Verify changes persisted
+	retrievedProduct, err := service.GetProduct(context.Background(), "product-789")
+	if err != nil {
+		t.Fatalf("Expected no error retrieving product, got %v", err)
+	}
+
+	if retrievedProduct.Name != "Updated Product" {
+		t.Errorf("Expected persisted name 'Updated Product', got %s", retrievedProduct.Name)
+	}
+}
+
+func TestProductService_DeleteProduct(t *testing.T) {
+	// Arrange
+	repo := NewInMemoryRepository()
+	service := NewService(repo)
+
+	// Verify product exists first
+	_, err := service.GetProduct(context.Background(), "product-789")
+	if err != nil {
+		t.Fatalf("Expected product to exist, got error: %v", err)
+	}
+
+	// Act
+	err = service.DeleteProduct(context.Background(), "product-789")
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Verify product no longer exists
+	_, err = service.GetProduct(context.Background(), "product-789")
+	if err == nil {
+		t.Fatal("Expected error when getting deleted product, got nil")
+	}
+}
+
+func TestProductService_AuditFields(t *testing.T) {
+	// Arrange
+	createdAt := time.Date(2024, 6, 1, 8, 0, 0, 0, time.UTC)
+	updatedAt := createdAt.Add(time.Hour)
+	repo := NewInMemoryRepository()
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "catalog-sync"})
+	req := ProductRequest{Name: "Audit Lamp", Description: "Lamp used to test audit fields", Price: 30, Category: "Electronics"}
+
+	// Act
+	created, err := NewService(repo, WithClock(clock.Fixed(createdAt))).CreateProduct(ctx, req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	price := 35.0
+	patched, err := NewService(repo, WithClock(clock.Fixed(updatedAt))).PatchProduct(context.Background(), created.ProductID, ProductPatch{Price: &price})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !created.CreatedAt.Equal(createdAt) || created.CreatedBy != "catalog-sync" {
+		t.Errorf("Expected creation to be stamped at %s by catalog-sync, got %+v", createdAt, created)
+	}
+	if !patched.CreatedAt.Equal(createdAt) || patched.CreatedBy != "catalog-sync" {
+		t.Errorf("Expected creation audit to be kept, got %+v", patched)
+	}
+	if !patched.UpdatedAt.Equal(updatedAt) || patched.UpdatedBy != "" {
+		t.Errorf("Expected an anonymous update stamped at %s, got %+v", updatedAt, patched)
+	}
+}
+
+func TestProductService_RestoreProduct(t *testing.T) {
+	// Arrange
+	service := NewService(NewInMemoryRepository())
+	if err := service.DeleteProduct(context.Background(), "product-789"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Act
+	listed, _ := service.ListProducts(context.Background())
+	restored, err := service.RestoreProduct(context.Background(), "product-789")
+	_, againErr := service.RestoreProduct(context.Background(), "product-789")
+
+	// Assert
+	if len(listed) != 4 {
+		t.Errorf("Expected the deleted product to be hidden from lists, got %d products", len(listed))
+	}
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if restored.IsDeleted() {
+		t.Error("Expected DeletedAt to be cleared")
+	}
+	if !errors.Is(againErr, ErrProductNotDeleted) {
+		t.Errorf("Expected ErrProductNotDeleted, got %v", againErr)
+	}
+}
+
+func TestProductService_ListProducts(t *testing.T) {
+	// Arrange
+	repo := NewInMemoryRepository()
+	service := NewService(repo)
+
+	// Act
+	products, err := service.ListProducts(context.Background())
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(products) == 0 {
+		t.Fatal("Expected products to be returned")
+	}
+
+	// Should have the sample products from repository initialization
+	expectedCount := 5 // Based on NewInMemoryRepository sample data
+	if len(products) != expectedCount {
+		t.Errorf("Expected %d products, got %d", expectedCount, len(products))
+	}
+}
+
+func TestProductRequest_UnmarshalJSON_Price(t *testing.T) {
+	testCases := []struct {
+		name        string
+		price       string
+		expectErr   bool
+		expectPrice float64
+	}{
+		{name: "Scientific notation", price: "1e3", expectErr: true},
+		{name: "Trailing zeros are normalized", price: "999.000", expectPrice: 999.00},
+		{name: "Valid price", price: "999.00", expectPrice: 999.00},
+		{name: "Too many decimal places", price: "999.001", expectErr: true},
+		{name: "Quoted price", price: `"999.00"`, expectErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			body := `{"name":"Laptop","description":"14-inch ultrabook","price":` + tc.price + `,"category":"Electronics"}`
+
+			// Act
+			var req ProductRequest
+			err := json.Unmarshal([]byte(body), &req)
+
+			// Assert
+			if tc.expectErr {
+				if !errors.Is(err, ErrInvalidPrice) {
+					t.Fatalf("Expected ErrInvalidPrice, got %v", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			if req.Price != tc.expectPrice {
+				t.Errorf("Expected price %.2f, got %v", tc.expectPrice, req.Price)
+			}
+
+			if req.Name != "Laptop" || req.Category != "Electronics" {
+				t.Errorf("Expected other fields to decode, got %+v", req)
+			}
+		})
+	}
+}
+
+func TestProductService_ReserveAndReleaseStock(t *testing.T) {
+	// Arrange
+	repo := NewInMemoryRepository()
+	service := NewService(repo)
+
+	// Act
+	reserved, reserveErr := service.ReserveStock(context.Background(), "product-456", StockRequest{Quantity: 5})
+	_, insufficientErr := service.ReserveStock(context.Background(), "product-456", StockRequest{Quantity: 4})
+	released, releaseErr := service.ReleaseStock(context.Background(), "product-456", StockRequest{Quantity: 2})
+	_, invalidErr := service.ReserveStock(context.Background(), "product-456", StockRequest{Quantity: 0})
+	_, notFoundErr := service.ReleaseStock(context.Background(), "product-missing", StockRequest{Quantity: 1})
+
+	// Assert
+	if reserveErr != nil || reserved.Quantity != 3 {
+		t.Errorf("Expected 3 units left after reserving 5 of 8, got %+v (%v)", reserved, reserveErr)
+	}
+
+	if !errors.Is(insufficientErr, ErrInsufficientStock) {
+		t.Errorf("Expected ErrInsufficientStock, got %v", insufficientErr)
+	}
+
+	if releaseErr != nil || released.Quantity != 5 {
+		t.Errorf("Expected 5 units after releasing 2, got %+v (%v)", released, releaseErr)
+	}
+
+	if !errors.Is(invalidErr, ErrInvalidQuantity) {
+		t.Errorf("Expected ErrInvalidQuantity, got %v", invalidErr)
+	}
+
+	if !errors.Is(notFoundErr, ErrProductNotFound) {
+		t.Errorf("Expected ErrProductNotFound, got %v", notFoundErr)
+	}
+}
+
+func TestProductService_ReserveStock_Concurrent(t *testing.T) {
+	// Arrange
+	repo := NewInMemoryRepository()
+	service := NewService(repo)
+	var wg sync.WaitGroup
+	var succeeded atomic.Int32
+
+	// Act
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := service.ReserveStock(context.Background(), "product-456", StockRequest{Quantity: 1}); err == nil {
+				succeeded.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Assert
+	product, _ := service.GetProduct(context.Background(), "product-456")
+	if succeeded.Load() != 8 || product.Quantity != 0 {
+		t.Errorf("Expected exactly 8 reservations and no stock left, got %d and %d", succeeded.Load(), product.Quantity)
+	}
+}
+
+func TestProductService_StockMovementReasons(t *testing.T) {
+	// Arrange
+	repo := NewInMemoryRepository()
+	service := NewService(repo)
+	ctx := context.Background()
+
+	// Act
+	_, reserveErr := service.ReserveStock(ctx, "product-123", StockRequest{Quantity: 2, Reason: "  order-42 "})
+	_, releaseErr := service.ReleaseStock(ctx, "product-123", StockRequest{Quantity: 1})
+	movements, total, err := service.ListStockMovements(ctx, "product-123", pagination.Params{Limit: 10})
+
+	// Assert
+	if reserveErr != nil || releaseErr != nil || err != nil {
+		t.Fatalf("Expected no errors, got %v, %v and %v", reserveErr, releaseErr, err)
+	}
+
+	if total != 2 || movements[0].Reason != ReasonRelease || movements[1].Reason != "order-42" {
+		t.Errorf("Expected release then the given reason, got %d movements %+v %+v", total, movements[0], movements[1])
+	}
+}
+
+// stockRecorder records the availabilities a StockObserver is told about
+type stockRecorder []*Availability
+
+func (r *stockRecorder) StockChanged(_ context.Context, availability *Availability) {
+	*r = append(*r, availability)
+}
+
+func TestProductService_StockObserver(t *testing.T) {
+	// Arrange
+	var observed stockRecorder
+	service := NewService(NewInMemoryRepository(), WithStockObserver(&observed))
+	ctx := context.Background()
+	quantity := 30
+	name := "Renamed Product"
+
+	// Act
+	_, reserveErr := service.ReserveStock(ctx, "product-456", StockRequest{Quantity: 8})
+	_, renameErr := service.PatchProduct(ctx, "product-456", ProductPatch{Name: &name})
+	_, restockErr := service.PatchProduct(ctx, "product-456", ProductPatch{Quantity: &quantity})
+	_, failedErr := service.ReserveStock(ctx, "product-456", StockRequest{Quantity: 31})
+
+	// Assert
+	if reserveErr != nil || renameErr != nil || restockErr != nil || failedErr == nil {
+		t.Fatalf("Expected only the last reservation to fail, got %v, %v, %v and %v", reserveErr, renameErr, restockErr, failedErr)
+	}
+	if len(observed) != 2 {
+		t.Fatalf("Expected the two quantity changes observed, got %d", len(observed))
+	}
+	if a := observed[0]; a.ProductID != "product-456" || a.Quantity != 0 || a.InStock || a.Orderable {
+		t.Errorf("Expected product-456 sold out, got %+v", a)
+	}
+	if a := observed[1]; a.Quantity != 30 || !a.InStock || !a.Orderable {
+		t.Errorf("Expected product-456 restocked, got %+v", a)
+	}
+}
+
+func TestProductService_CreateProduct_Pricing(t *testing.T) {
+	base := ProductRequest{
+		Name:        "Shipping Box",
+		Description: "Corrugated box for parcel shipments",
+		Price:       4.99,
+		Category:    "Packaging",
+	}
+
+	tests := []struct {
+		name         string
+		currency     string
+		prices       map[string]float64
+		wantCurrency string
+		wantPrices   map[string]float64
+		wantErr      error
+	}{
+		{name: "defaults to the service currency", wantCurrency: "GBP"},
+		{name: "normalizes codes", currency: "eur", prices: map[string]float64{"usd": 5.5}, wantCurrency: "EUR", wantPrices: map[string]float64{"USD": 5.5}},
+		{name: "malformed currency", currency: "euro", wantErr: currency.ErrInvalidCode},
+		{name: "malformed price code", prices: map[string]float64{"US": 5}, wantErr: currency.ErrInvalidCode},
+		{name: "price repeats the currency", prices: map[string]float64{"gbp": 5}, wantErr: ErrInvalidPrice},
+		{name: "non-positive price", prices: map[string]float64{"EUR": 0}, wantErr: ErrInvalidPrice},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			service := NewService(NewInMemoryRepository(), WithCurrency("GBP", nil))
+			req := base
+			req.Currency, req.Prices = tt.currency, tt.prices
+
+			// Act
+			product, err := service.CreateProduct(context.Background(), req)
+
+			// Assert
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if product.Currency != tt.wantCurrency || len(product.Prices) != len(tt.wantPrices) {
+				t.Errorf("Expected %s %v, got %s %v", tt.wantCurrency, tt.wantPrices, product.Currency, product.Prices)
+			}
+			for code, price := range tt.wantPrices {
+				if product.Prices[code] != price {
+					t.Errorf("Expected %s price %v, got %v", code, price, product.Prices[code])
+				}
+			}
+		})
+	}
+}
+
+func TestProductService_PriceIn(t *testing.T) {
+	// Arrange
+	rates := currency.NewStaticRates("USD", map[string]float64{"EUR": 0.9, "GBP": 0.8})
+	service := NewService(NewInMemoryRepository(), WithCurrency("USD", rates))
+	product := &Product{ProductID: "product-1", Price: 100, Currency: "USD", Prices: map[string]float64{"EUR": 95}}
+	ctx := context.Background()
+
+	// Act
+	own, ownErr := service.PriceIn(ctx, product, "USD")
+	explicit, explicitErr := service.PriceIn(ctx, product, "EUR")
+	converted, convertedErr := service.PriceIn(ctx, product, "GBP")
+	_, missingErr := service.PriceIn(ctx, product, "JPY")
+
+	// Assert
+	if ownErr != nil || own != 100 {
+		t.Errorf("Expected the product's own price, got %v (%v)", own, ownErr)
+	}
+
+	if explicitErr != nil || explicit != 95 {
+		t.Errorf("Expected the explicit EUR price, got %v (%v)", explicit, explicitErr)
+	}
+
+	if convertedErr != nil || converted != 80 {
+		t.Errorf("Expected 80 GBP converted at the configured rate, got %v (%v)", converted, convertedErr)
+	}
+
+	if !errors.Is(missingErr, currency.ErrRateUnavailable) {
+		t.Errorf("Expected ErrRateUnavailable, got %v", missingErr)
+	}
+}
+
+func TestProductService_SchedulePrice(t *testing.T) {
+	// Arrange
+	now := time.Date(2024, 6, 1, 8, 0, 0, 0, time.UTC)
+	repo := NewInMemoryRepository()
+	service := NewService(repo, WithClock(clock.Fixed(now)))
+	created, err := service.CreateProduct(context.Background(), ProductRequest{
+		Name: "Scheduled Lamp", Description: "Lamp used to test price schedules", Price: 30, Category: "Electronics",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	saleStart := now.Add(24 * time.Hour)
+
+	// Act
+	change, err := service.SchedulePrice(context.Background(), created.ProductID, PriceChangeRequest{Price: 25, EffectiveAt: saleStart})
+	_, pastErr := service.SchedulePrice(context.Background(), created.ProductID, PriceChangeRequest{Price: 20, EffectiveAt: now})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !errors.Is(pastErr, ErrInvalidPriceChange) {
+		t.Errorf("Expected ErrInvalidPriceChange for a change effective now, got %v", pastErr)
+	}
+
+	current, err := service.GetProduct(context.Background(), created.ProductID)
+	if err != nil || current.Price != 30 {
+		t.Errorf("Expected the current price 30 before the sale, got %+v (%v)", current, err)
+	}
+	onSale, err := service.GetProductAt(context.Background(), created.ProductID, saleStart)
+	if err != nil || onSale.Price != 25 {
+		t.Errorf("Expected the sale price 25 at %s, got %+v (%v)", saleStart, onSale, err)
+	}
+
+	later := NewService(repo, WithClock(clock.Fixed(saleStart.Add(time.Hour))))
+	name := "Scheduled Lamp v2"
+	patched, err := later.PatchProduct(context.Background(), created.ProductID, ProductPatch{Name: &name})
+	if err != nil || patched.Price != 25 {
+		t.Errorf("Expected a patch without price to keep the sale price, got %+v (%v)", patched, err)
+	}
+	if err := later.CancelPriceChange(context.Background(), created.ProductID, change.ID); !errors.Is(err, ErrPriceChangeEffective) {
+		t.Errorf("Expected ErrPriceChangeEffective once the sale started, got %v", err)
+	}
+}
+
+func TestProductService_GetProductAsOf(t *testing.T) {
+	// Arrange
+	created := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	repaired := created.Add(24 * time.Hour)
+	deleted := repaired.Add(24 * time.Hour)
+	restored := deleted.Add(24 * time.Hour)
+	now := created
+	service := NewService(NewInMemoryRepository(), WithClock(clock.Func(func() time.Time { return now })))
+	ctx := context.Background()
+
+	product, err := service.CreateProduct(ctx, ProductRequest{
+		Name: "Versioned Lamp", Description: "Lamp used to test point-in-time reads", Price: 30, Category: "Electronics",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	now = repaired
+	if _, err := service.UpdateProduct(ctx, product.ProductID, ProductRequest{
+		Name: "Versioned Lamp", Description: "Lamp used to test point-in-time reads", Price: 35, Category: "Electronics",
+	}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	now = deleted
+	if err := service.DeleteProduct(ctx, product.ProductID); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	now = restored
+	if _, err := service.RestoreProduct(ctx, product.ProductID); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	now = restored.Add(time.Hour)
+
+	tests := []struct {
+		name          string
+		asOf          time.Time
+		expectedPrice float64
+		expectedErr   error
+	}{
+		{name: "before creation", asOf: created.Add(-time.Second), expectedErr: ErrProductNotFound},
+		{name: "at creation", asOf: created, expectedPrice: 30},
+		{name: "after the update", asOf: repaired.Add(time.Hour), expectedPrice: 35},
+		{name: "just before deletion", asOf: deleted.Add(-time.Second), expectedPrice: 35},
+		{name: "at deletion", asOf: deleted, expectedErr: ErrProductNotFound},
+		{name: "while deleted", asOf: restored.Add(-time.Second), expectedErr: ErrProductNotFound},
+		{name: "once restored", asOf: restored, expectedPrice: 35},
+		{name: "in the future", asOf: restored.Add(2 * time.Hour), expectedErr: apperr.ErrValidation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			got, err := service.GetProductAsOf(context.Background(), product.ProductID, tt.asOf)
+
+			// Assert
+			if tt.expectedErr != nil {
+				if !errors.Is(err, tt.expectedErr) {
+					t.Errorf("Expected %v, got %v", tt.expectedErr, err)
+				}
+				return
+			}
+			if err != nil || got.Price != tt.expectedPrice {
+				t.Errorf("Expected price %v, got %+v (%v)", tt.expectedPrice, got, err)
+			}
+		})
+	}
+}
+
+func TestProductService_Variants(t *testing.T) {
+	// Arrange
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "catalog-sync"})
+	service := NewService(NewInMemoryRepository())
+	req := VariantRequest{SKU: " laptop-14-silver ", Attributes: map[string]string{"color": "silver"}, Price: 1049, Quantity: 3}
+
+	// Act
+	created, err := service.CreateVariant(ctx, "product-789", req)
+	_, duplicateErr := service.CreateVariant(ctx, "product-789", req)
+	_, malformedErr := service.CreateVariant(ctx, "product-789", VariantRequest{SKU: "laptop/14", Price: 1})
+	_, renameErr := service.UpdateVariant(ctx, "LAPTOP-14-SILVER", VariantRequest{SKU: "LAPTOP-15", Price: 1})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if created.SKU != "LAPTOP-14-SILVER" || created.CreatedBy != "catalog-sync" {
+		t.Errorf("Expected an upper-case SKU created by catalog-sync, got %+v", created)
+	}
+	if !errors.Is(duplicateErr, ErrVariantExists) {
+		t.Errorf("Expected ErrVariantExists, got %v", duplicateErr)
+	}
+	if !errors.Is(malformedErr, ErrInvalidVariant) || !errors.Is(renameErr, ErrInvalidVariant) {
+		t.Errorf("Expected ErrInvalidVariant for a slash and a renamed SKU, got %v and %v", malformedErr, renameErr)
+	}
+
+	updated, err := service.UpdateVariant(ctx, "laptop-14-silver", VariantRequest{Price: 999, Quantity: 1})
+	if err != nil || updated.Price != 999 || updated.Attributes != nil || updated.ProductID != "product-789" {
+		t.Errorf("Expected the variant replaced by the update, got %+v (%v)", updated, err)
+	}
+
+	found, err := service.GetVariant(ctx, "Laptop-14-Silver")
+	if err != nil || found.Quantity != 1 {
+		t.Errorf("Expected a case-insensitive SKU lookup, got %+v (%v)", found, err)
+	}
+}
+
+func TestProductService_PatchProduct_MergesPrices(t *testing.T) {
+	// Arrange
+	service := NewService(NewInMemoryRepository())
+	gbp := 850.0
+
+	// Act
+	product, err := service.PatchProduct(context.Background(), "product-789", ProductPatch{
+		Prices: map[string]*float64{"gbp": &gbp, "EUR": nil},
+	})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(product.Prices) != 1 || product.Prices["GBP"] != 850 {
+		t.Errorf("Expected only the GBP price to remain, got %v", product.Prices)
+	}
+}
+
+func TestProductService_ExportProducts(t *testing.T) {
+	// Arrange
+	repo := NewInMemoryRepository()
+	service := NewService(repo)
+	total := ExportPageSize + 3
+	for i := 0; i < total; i++ {
+		err := repo.Create(&Product{
+			ProductID: fmt.Sprintf("product-export-%04d", i),
+			Name:      "Export Product",
+			Price:     10.00,
+			Category:  "Export",
+			Quantity:  1,
+		})
+		if err != nil {
+			t.Fatalf("Expected no error seeding product, got %v", err)
+		}
+	}
+
+	// Act
+	var pages []int
+	seen := make(map[string]bool)
+	err := service.ExportProducts(context.Background(), ProductFilter{Category: "Export", Limit: 1, Offset: 2}, func(products []*Product) error {
+		pages = append(pages, len(products))
+		for _, product := range products {
+			seen[product.ProductID] = true
+		}
+		return nil
+	})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(pages) != 2 || pages[0] != ExportPageSize || pages[1] != 3 {
+		t.Errorf("Expected pages of %d and 3 products, got %v", ExportPageSize, pages)
+	}
+	if len(seen) != total {
+		t.Errorf("Expected %d distinct products, got %d", total, len(seen))
+	}
+}
+
+func TestProductService_ExportProducts_InvalidFilter(t *testing.T) {
+	// Arrange
+	service := NewService(NewInMemoryRepository())
+	minPrice, maxPrice := 20.0, 10.0
+	emitted := false
+
+	// Act
+	err := service.ExportProducts(context.Background(), ProductFilter{MinPrice: &minPrice, MaxPrice: &maxPrice}, func([]*Product) error {
+		emitted = true
+		return nil
+	})
+
+	// Assert
+	if !errors.Is(err, ErrInvalidFilter) {
+		t.Errorf("Expected ErrInvalidFilter, got %v", err)
+	}
+	if emitted {
+		t.Error("Expected nothing to be exported")
+	}
+}
+
+// stubGallery answers ImagesOf with images, or fails with err
+type stubGallery struct {
+	images map[string][]Image
+	err    error
+}
+
+func (g stubGallery) ImagesOf(ctx context.Context, productIDs []string) (map[string][]Image, error) {
+	return g.images, g.err
+}
+
+func TestProductService_ProductImages(t *testing.T) {
+	laptop := []Image{{ImageID: "image-1", URL: "/media/products/product-789/image-1.jpg"}}
+	tests := []struct {
+		name    string
+		opts    []Option
+		wantLen int
+	}{
+		{name: "without gallery", wantLen: 0},
+		{name: "with gallery", opts: []Option{WithImageGallery(stubGallery{images: map[string][]Image{"product-789": laptop}})}, wantLen: 1},
+		{name: "failing gallery", opts: []Option{WithImageGallery(stubGallery{err: errors.New("database down")})}, wantLen: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			service := NewService(NewInMemoryRepository(), tt.opts...)
+
+			// Act
+			images := service.ProductImages(context.Background(), []string{"product-789", "product-123"})
+
+			// Assert
+			if len(images["product-789"]) != tt.wantLen {
+				t.Errorf("Expected %d images of product-789, got %+v", tt.wantLen, images)
+			}
+			if len(images["product-123"]) != 0 {
+				t.Errorf("Expected no images of product-123, got %+v", images["product-123"])
+			}
+		})
 	}
 }
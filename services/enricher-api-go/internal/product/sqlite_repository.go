@@ -0,0 +1,779 @@
+package product
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"enricher-api-go/internal/pagination"
+	"enricher-api-go/internal/sqlite"
+)
+
+// SQLiteSchema creates the products, stock_movements, price_changes,
+// product_deletions and product_variants tables used by SQLiteRepository.
+//
+// It mirrors the PostgreSQL schema: prices, weight, dimensions and variant
+// attributes are JSON in TEXT columns, and timestamps are stored as UTC text
+// so that they compare in time order.
+const SQLiteSchema = `
+CREATE TABLE IF NOT EXISTS products (
+	product_id  TEXT PRIMARY KEY,
+	name        TEXT NOT NULL,
+	description TEXT NOT NULL,
+	price       REAL NOT NULL,
+	currency    TEXT NOT NULL DEFAULT 'USD',
+	prices      TEXT,
+	category    TEXT NOT NULL,
+	quantity    INTEGER NOT NULL DEFAULT 0 CHECK (quantity >= 0),
+	weight      TEXT,
+	dimensions  TEXT,
+	barcode     TEXT NOT NULL DEFAULT '',
+	created_at  TIMESTAMP NOT NULL,
+	updated_at  TIMESTAMP NOT NULL,
+	created_by  TEXT NOT NULL DEFAULT '',
+	updated_by  TEXT NOT NULL DEFAULT '',
+	deleted_at  TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS products_category_idx ON products (category);
+CREATE INDEX IF NOT EXISTS products_barcode_idx ON products (barcode) WHERE barcode <> '';
+CREATE TABLE IF NOT EXISTS stock_movements (
+	id          INTEGER PRIMARY KEY AUTOINCREMENT,
+	product_id  TEXT NOT NULL REFERENCES products (product_id),
+	delta       INTEGER NOT NULL,
+	quantity    INTEGER NOT NULL,
+	reason      TEXT NOT NULL,
+	actor       TEXT NOT NULL DEFAULT '',
+	created_at  TIMESTAMP NOT NULL
+);
+CREATE INDEX IF NOT EXISTS stock_movements_product_idx ON stock_movements (product_id, id);
+CREATE TABLE IF NOT EXISTS price_changes (
+	id           INTEGER PRIMARY KEY AUTOINCREMENT,
+	product_id   TEXT NOT NULL REFERENCES products (product_id),
+	price        REAL NOT NULL,
+	effective_at TIMESTAMP NOT NULL,
+	created_at   TIMESTAMP NOT NULL,
+	created_by   TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS price_changes_product_idx ON price_changes (product_id, effective_at);
+CREATE TABLE IF NOT EXISTS product_deletions (
+	id          INTEGER PRIMARY KEY AUTOINCREMENT,
+	product_id  TEXT NOT NULL REFERENCES products (product_id),
+	deleted_at  TIMESTAMP NOT NULL,
+	restored_at TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS product_deletions_product_idx ON product_deletions (product_id, deleted_at);
+CREATE TABLE IF NOT EXISTS product_variants (
+	sku         TEXT PRIMARY KEY,
+	product_id  TEXT NOT NULL REFERENCES products (product_id),
+	attributes  TEXT,
+	price       REAL NOT NULL,
+	quantity    INTEGER NOT NULL DEFAULT 0 CHECK (quantity >= 0),
+	created_at  TIMESTAMP NOT NULL,
+	updated_at  TIMESTAMP NOT NULL,
+	created_by  TEXT NOT NULL DEFAULT '',
+	updated_by  TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS product_variants_product_idx ON product_variants (product_id);
+`
+
+// SQLiteRepository implements Repository on top of a SQLite database. It
+// does not record change events.
+type SQLiteRepository struct {
+	db *sql.DB
+}
+
+// NewSQLiteRepository creates a product repository backed by db, as opened
+// by sqlite.Open.
+//
+// The caller owns db and is responsible for closing it; the products table
+// must exist (see SQLiteSchema and EnsureSchema).
+func NewSQLiteRepository(db *sql.DB) *SQLiteRepository {
+	return &SQLiteRepository{db: db}
+}
+
+// EnsureSchema creates the products table and indexes if they do not exist
+func (r *SQLiteRepository) EnsureSchema() error {
+	if _, err := r.db.Exec(SQLiteSchema); err != nil {
+		return fmt.Errorf("failed to create products schema: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a live product by ID
+func (r *SQLiteRepository) GetByID(productID string) (*Product, error) {
+	return queryOne(r.db, `SELECT `+productColumns+` FROM products WHERE product_id = ? AND `+notDeleted, productID)
+}
+
+// GetByIDIncludingDeleted retrieves a product by ID even if it is soft-deleted
+func (r *SQLiteRepository) GetByIDIncludingDeleted(productID string) (*Product, error) {
+	return queryOne(r.db, `SELECT `+productColumns+` FROM products WHERE product_id = ?`, productID)
+}
+
+// GetByIDs retrieves the live products with the given IDs, skipping unknown ones
+func (r *SQLiteRepository) GetByIDs(productIDs []string) ([]*Product, error) {
+	if len(productIDs) == 0 {
+		return []*Product{}, nil
+	}
+	return r.query(
+		`SELECT `+productColumns+` FROM products
+		WHERE product_id IN (`+sqlite.Placeholders(len(productIDs))+`) AND `+notDeleted,
+		stringArgs(productIDs)...,
+	)
+}
+
+// Create adds a new product, recording its initial stock as a movement and
+// its price as a price change in the same transaction
+func (r *SQLiteRepository) Create(product *Product) error {
+	return r.transact("product write", func(tx *sql.Tx) error {
+		return insertSQLiteProduct(tx, product)
+	})
+}
+
+// Update modifies an existing product. A changed quantity, or a price other
+// than the one in effect, is recorded in the same transaction.
+func (r *SQLiteRepository) Update(product *Product) error {
+	return r.transact("product write", func(tx *sql.Tx) error {
+		return updateSQLiteProduct(tx, product)
+	})
+}
+
+// WriteAll applies every write in one transaction
+func (r *SQLiteRepository) WriteAll(writes []Write) error {
+	return r.transact("product bulk write", func(tx *sql.Tx) error {
+		for i, write := range writes {
+			store := updateSQLiteProduct
+			if write.Create {
+				store = insertSQLiteProduct
+			}
+			if err := store(tx, write.Product); err != nil {
+				return &WriteError{Index: i, Err: err}
+			}
+		}
+		return nil
+	})
+}
+
+// insertSQLiteProduct adds product on tx, recording its initial stock and price
+func insertSQLiteProduct(tx *sql.Tx, product *Product) error {
+	prices, weight, dimensions, err := marshalJSONColumns(product)
+	if err != nil {
+		return err
+	}
+	createdAt := sqlite.Time(product.CreatedAt)
+
+	_, err = tx.Exec(
+		`INSERT INTO products (product_id, name, description, price, currency, prices, category, quantity,
+			weight, dimensions, barcode, created_at, updated_at, created_by, updated_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		product.ProductID, product.Name, product.Description, product.Price, product.Currency, prices,
+		product.Category, product.Quantity, weight, dimensions, product.Barcode,
+		createdAt, sqlite.Time(product.UpdatedAt), product.CreatedBy, product.UpdatedBy,
+	)
+	if sqlite.IsUniqueViolation(err) {
+		return ErrProductExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to insert product: %w", err)
+	}
+
+	if product.Quantity != 0 {
+		err := insertStockMovement(tx, product.ProductID, product.Quantity, product.Quantity,
+			ReasonCreate, product.CreatedBy, createdAt)
+		if err != nil {
+			return err
+		}
+	}
+	return insertPriceChange(tx, product.ProductID, product.Price, createdAt, product.CreatedBy)
+}
+
+// updateSQLiteProduct replaces a live product on tx, recording a changed
+// quantity and a price other than the one in effect
+func updateSQLiteProduct(tx *sql.Tx, product *Product) error {
+	prices, weight, dimensions, err := marshalJSONColumns(product)
+	if err != nil {
+		return err
+	}
+	updatedAt := sqlite.Time(product.UpdatedAt)
+
+	var previousQuantity int
+	var previousPrice float64
+	err = tx.QueryRow(
+		`SELECT quantity, COALESCE((
+			SELECT price FROM price_changes
+			WHERE price_changes.product_id = products.product_id AND effective_at <= ?2
+			ORDER BY effective_at DESC, id DESC LIMIT 1
+		), price) FROM products WHERE product_id = ?1 AND `+notDeleted,
+		product.ProductID, updatedAt,
+	).Scan(&previousQuantity, &previousPrice)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrProductNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update product: %w", err)
+	}
+
+	_, err = tx.Exec(
+		`UPDATE products
+		SET name = ?, description = ?, price = ?, currency = ?, prices = ?, category = ?, quantity = ?,
+			weight = ?, dimensions = ?, barcode = ?, updated_at = ?, updated_by = ?
+		WHERE product_id = ?`,
+		product.Name, product.Description, product.Price, product.Currency, prices, product.Category,
+		product.Quantity, weight, dimensions, product.Barcode, updatedAt, product.UpdatedBy, product.ProductID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update product: %w", err)
+	}
+
+	if product.Quantity != previousQuantity {
+		err := insertStockMovement(tx, product.ProductID, product.Quantity-previousQuantity, product.Quantity,
+			ReasonUpdate, product.UpdatedBy, updatedAt)
+		if err != nil {
+			return err
+		}
+	}
+	if product.Price != previousPrice {
+		return insertPriceChange(tx, product.ProductID, product.Price, updatedAt, product.UpdatedBy)
+	}
+	return nil
+}
+
+// insertStockMovement records a stock movement on tx
+func insertStockMovement(tx *sql.Tx, productID string, delta, quantity int, reason, actor string, at time.Time) error {
+	_, err := tx.Exec(
+		`INSERT INTO stock_movements (product_id, delta, quantity, reason, actor, created_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		productID, delta, quantity, reason, actor, at,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record stock movement: %w", err)
+	}
+	return nil
+}
+
+// insertPriceChange records a price change effective at at on tx
+func insertPriceChange(tx *sql.Tx, productID string, price float64, at time.Time, actor string) error {
+	_, err := tx.Exec(
+		`INSERT INTO price_changes (product_id, price, effective_at, created_at, created_by) VALUES (?, ?, ?, ?, ?)`,
+		productID, price, at, at, actor,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record price change: %w", err)
+	}
+	return nil
+}
+
+// Delete soft-deletes a product by stamping deleted_at with now and opens
+// its deletion in the same transaction
+func (r *SQLiteRepository) Delete(productID string, now time.Time) error {
+	deletedAt := sqlite.Time(now)
+	return r.transact("product delete", func(tx *sql.Tx) error {
+		result, err := tx.Exec(
+			`UPDATE products SET deleted_at = ? WHERE product_id = ? AND `+notDeleted,
+			deletedAt, productID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to delete product: %w", err)
+		}
+		if err := requireRowAffected(result); err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(`INSERT INTO product_deletions (product_id, deleted_at) VALUES (?, ?)`, productID, deletedAt)
+		if err != nil {
+			return fmt.Errorf("failed to record product deletion: %w", err)
+		}
+		return nil
+	})
+}
+
+// Restore clears deleted_at on a soft-deleted product and closes its
+// deletion at now in the same transaction
+func (r *SQLiteRepository) Restore(productID string, now time.Time) error {
+	err := r.transact("product restore", func(tx *sql.Tx) error {
+		result, err := tx.Exec(
+			`UPDATE products SET deleted_at = NULL WHERE product_id = ? AND deleted_at IS NOT NULL`,
+			productID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to restore product: %w", err)
+		}
+		if err := requireRowAffected(result); err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(
+			`UPDATE product_deletions SET restored_at = ? WHERE product_id = ? AND restored_at IS NULL`,
+			sqlite.Time(now), productID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to record product restore: %w", err)
+		}
+		return nil
+	})
+	if !errors.Is(err, ErrProductNotFound) {
+		return err
+	}
+
+	// Nothing was restored: tell a live product apart from a missing one
+	if _, err := r.GetByID(productID); err != nil {
+		return err
+	}
+	return ErrProductNotDeleted
+}
+
+// Deletions returns the intervals a product was soft-deleted, oldest first
+func (r *SQLiteRepository) Deletions(productID string) ([]*Deletion, error) {
+	if _, err := r.GetByIDIncludingDeleted(productID); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(
+		`SELECT deleted_at, restored_at FROM product_deletions WHERE product_id = ? ORDER BY deleted_at, id`,
+		productID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query product deletions: %w", err)
+	}
+	defer rows.Close()
+
+	deletions := make([]*Deletion, 0)
+	for rows.Next() {
+		var deletion Deletion
+		if err := rows.Scan(&deletion.DeletedAt, &deletion.RestoredAt); err != nil {
+			return nil, fmt.Errorf("failed to scan product deletion: %w", err)
+		}
+		deletions = append(deletions, &deletion)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read product deletions: %w", err)
+	}
+	return deletions, nil
+}
+
+// AdjustQuantity adds change.Delta to a live product's quantity in a single
+// conditional UPDATE, so concurrent reservations cannot oversell, and records
+// the movement in the same transaction
+func (r *SQLiteRepository) AdjustQuantity(productID string, change StockChange) (*Product, error) {
+	var product *Product
+	err := r.transact("stock adjustment", func(tx *sql.Tx) error {
+		var err error
+		product, err = queryOne(tx,
+			`UPDATE products SET quantity = quantity + ?2, updated_at = ?3, updated_by = ?4
+			WHERE product_id = ?1 AND quantity + ?2 >= 0 AND `+notDeleted+`
+			RETURNING `+productColumns,
+			productID, change.Delta, sqlite.Time(change.UpdatedAt), change.UpdatedBy,
+		)
+		if errors.Is(err, ErrProductNotFound) {
+			// Nothing was updated: tell a short product apart from a missing one
+			if _, err := queryOne(tx, `SELECT `+productColumns+` FROM products WHERE product_id = ? AND `+notDeleted, productID); err != nil {
+				return err
+			}
+			return ErrInsufficientStock
+		}
+		if err != nil {
+			return err
+		}
+		return insertStockMovement(tx, productID, change.Delta, product.Quantity,
+			change.Reason, change.UpdatedBy, sqlite.Time(change.UpdatedAt))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+// transact runs change in a transaction, which holds the database write lock
+// from its start, describing failures to begin or commit as action
+func (r *SQLiteRepository) transact(action string, change func(tx *sql.Tx) error) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin %s: %w", action, err)
+	}
+	defer tx.Rollback()
+
+	if err := change(tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit %s: %w", action, err)
+	}
+	return nil
+}
+
+// StockMovements returns a page of a live product's stock movements, newest first
+func (r *SQLiteRepository) StockMovements(productID string, page pagination.Params) ([]*StockMovement, int, error) {
+	if _, err := r.GetByID(productID); err != nil {
+		return nil, 0, err
+	}
+
+	var total int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM stock_movements WHERE product_id = ?`, productID).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count stock movements: %w", err)
+	}
+
+	// SQLite only takes an OFFSET after a LIMIT, where -1 means none
+	limit := -1
+	if page.Limit > 0 {
+		limit = page.Limit
+	}
+	rows, err := r.db.Query(
+		`SELECT product_id, delta, quantity, reason, actor, created_at FROM stock_movements
+		WHERE product_id = ? ORDER BY id DESC LIMIT ? OFFSET ?`,
+		productID, limit, page.Offset,
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query stock movements: %w", err)
+	}
+	defer rows.Close()
+
+	movements := make([]*StockMovement, 0)
+	for rows.Next() {
+		var movement StockMovement
+		err := rows.Scan(&movement.ProductID, &movement.Delta, &movement.Quantity,
+			&movement.Reason, &movement.Actor, &movement.CreatedAt)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan stock movement: %w", err)
+		}
+		movements = append(movements, &movement)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to read stock movements: %w", err)
+	}
+	return movements, total, nil
+}
+
+// RecordPriceChange adds a price change to a live product's schedule,
+// assigning its ID
+func (r *SQLiteRepository) RecordPriceChange(change *PriceChange) error {
+	err := r.db.QueryRow(
+		`INSERT INTO price_changes (product_id, price, effective_at, created_at, created_by)
+		SELECT product_id, ?2, ?3, ?4, ?5 FROM products WHERE product_id = ?1 AND `+notDeleted+`
+		RETURNING id`,
+		change.ProductID, change.Price, sqlite.Time(change.EffectiveAt), sqlite.Time(change.CreatedAt), change.CreatedBy,
+	).Scan(&change.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrProductNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to record price change: %w", err)
+	}
+	return nil
+}
+
+// PriceChanges returns a live product's price changes in effective order
+func (r *SQLiteRepository) PriceChanges(productID string) ([]*PriceChange, error) {
+	if _, err := r.GetByID(productID); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(
+		`SELECT `+priceChangeColumns+` FROM price_changes WHERE product_id = ? ORDER BY effective_at, id`,
+		productID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query price changes: %w", err)
+	}
+	defer rows.Close()
+
+	changes := make([]*PriceChange, 0)
+	for rows.Next() {
+		var change PriceChange
+		err := rows.Scan(&change.ID, &change.ProductID, &change.Price,
+			&change.EffectiveAt, &change.CreatedAt, &change.CreatedBy)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan price change: %w", err)
+		}
+		changes = append(changes, &change)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read price changes: %w", err)
+	}
+	return changes, nil
+}
+
+// EffectivePrices returns the price valid at at for each product that has a
+// price change effective by then
+func (r *SQLiteRepository) EffectivePrices(productIDs []string, at time.Time) (map[string]float64, error) {
+	prices := make(map[string]float64, len(productIDs))
+	if len(productIDs) == 0 {
+		return prices, nil
+	}
+
+	// Each product's latest change effective by at is the one no later
+	// effective change supersedes
+	args := append(stringArgs(productIDs), sqlite.Time(at), sqlite.Time(at))
+	rows, err := r.db.Query(
+		`SELECT product_id, price FROM price_changes latest
+		WHERE product_id IN (`+sqlite.Placeholders(len(productIDs))+`) AND effective_at <= ?
+		AND NOT EXISTS (SELECT 1 FROM price_changes newer
+			WHERE newer.product_id = latest.product_id AND newer.effective_at <= ?
+			AND (newer.effective_at > latest.effective_at
+				OR (newer.effective_at = latest.effective_at AND newer.id > latest.id)))`,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query effective prices: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var productID string
+		var price float64
+		if err := rows.Scan(&productID, &price); err != nil {
+			return nil, fmt.Errorf("failed to scan effective price: %w", err)
+		}
+		prices[productID] = price
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read effective prices: %w", err)
+	}
+	return prices, nil
+}
+
+// CancelPriceChange removes a price change that is not yet effective at now
+func (r *SQLiteRepository) CancelPriceChange(productID string, changeID int64, now time.Time) error {
+	result, err := r.db.Exec(
+		`DELETE FROM price_changes WHERE id = ?1 AND product_id = ?2 AND effective_at > ?3
+		AND EXISTS (SELECT 1 FROM products WHERE product_id = ?2 AND `+notDeleted+`)`,
+		changeID, productID, sqlite.Time(now),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to cancel price change: %w", err)
+	}
+	if err := requireRowAffected(result); !errors.Is(err, ErrProductNotFound) {
+		return err
+	}
+
+	// Nothing was deleted: tell a missing product or change from an effective one
+	if _, err := r.GetByID(productID); err != nil {
+		return err
+	}
+	var effective bool
+	err = r.db.QueryRow(`SELECT effective_at <= ?3 FROM price_changes WHERE id = ?1 AND product_id = ?2`,
+		changeID, productID, sqlite.Time(now)).Scan(&effective)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrPriceChangeNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read price change: %w", err)
+	}
+	if effective {
+		return ErrPriceChangeEffective
+	}
+	return ErrPriceChangeNotFound
+}
+
+// Variants returns a live product's variants ordered by SKU
+func (r *SQLiteRepository) Variants(productID string) ([]*Variant, error) {
+	if _, err := r.GetByID(productID); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(
+		`SELECT `+variantColumns+` FROM product_variants WHERE product_id = ? ORDER BY sku`,
+		productID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query variants: %w", err)
+	}
+	defer rows.Close()
+
+	variants := make([]*Variant, 0)
+	for rows.Next() {
+		variant, err := scanVariant(rows)
+		if err != nil {
+			return nil, err
+		}
+		variants = append(variants, variant)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read variants: %w", err)
+	}
+	return variants, nil
+}
+
+// GetVariant retrieves a variant of a live product by SKU
+func (r *SQLiteRepository) GetVariant(sku string) (*Variant, error) {
+	variant, err := scanVariant(r.db.QueryRow(
+		`SELECT `+variantColumns+` FROM `+liveVariants+` WHERE product_variants.sku = ?`,
+		sku,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrVariantNotFound
+	}
+	return variant, err
+}
+
+// CreateVariant adds a variant to a live product
+func (r *SQLiteRepository) CreateVariant(variant *Variant) error {
+	attributes, err := marshalAttributes(variant)
+	if err != nil {
+		return err
+	}
+
+	result, err := r.db.Exec(
+		`INSERT INTO product_variants (sku, product_id, attributes, price, quantity,
+			created_at, updated_at, created_by, updated_by)
+		SELECT ?1, product_id, ?3, ?4, ?5, ?6, ?7, ?8, ?9 FROM products
+		WHERE product_id = ?2 AND `+notDeleted,
+		variant.SKU, variant.ProductID, attributes, variant.Price, variant.Quantity,
+		sqlite.Time(variant.CreatedAt), sqlite.Time(variant.UpdatedAt), variant.CreatedBy, variant.UpdatedBy,
+	)
+	if sqlite.IsUniqueViolation(err) {
+		return ErrVariantExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to insert variant: %w", err)
+	}
+	return requireRowAffected(result)
+}
+
+// liveVariantProduct restricts a variant UPDATE/DELETE to variants of live products
+const liveVariantProduct = `EXISTS (SELECT 1 FROM products
+	WHERE products.product_id = product_variants.product_id AND products.deleted_at IS NULL)`
+
+// UpdateVariant replaces an existing variant; its SKU and product do not change
+func (r *SQLiteRepository) UpdateVariant(variant *Variant) error {
+	attributes, err := marshalAttributes(variant)
+	if err != nil {
+		return err
+	}
+
+	result, err := r.db.Exec(
+		`UPDATE product_variants SET attributes = ?, price = ?, quantity = ?, updated_at = ?, updated_by = ?
+		WHERE sku = ? AND `+liveVariantProduct,
+		attributes, variant.Price, variant.Quantity, sqlite.Time(variant.UpdatedAt), variant.UpdatedBy, variant.SKU,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update variant: %w", err)
+	}
+	return requireVariantAffected(result)
+}
+
+// DeleteVariant removes a variant
+func (r *SQLiteRepository) DeleteVariant(sku string) error {
+	result, err := r.db.Exec(`DELETE FROM product_variants WHERE sku = ? AND `+liveVariantProduct, sku)
+	if err != nil {
+		return fmt.Errorf("failed to delete variant: %w", err)
+	}
+	return requireVariantAffected(result)
+}
+
+// List returns all live products ordered by ID
+func (r *SQLiteRepository) List() ([]*Product, error) {
+	return r.Find(ProductFilter{})
+}
+
+// Find returns the products matching filter, ordered by ProductID
+func (r *SQLiteRepository) Find(filter ProductFilter) ([]*Product, error) {
+	where, args := sqliteFilterClause(filter)
+	query := `SELECT ` + productColumns + ` FROM products` + where + filter.Sort.OrderBy(sortColumns, "product_id")
+
+	// SQLite only takes an OFFSET after a LIMIT, where -1 means none
+	if filter.Limit > 0 || filter.Offset > 0 {
+		limit := -1
+		if filter.Limit > 0 {
+			limit = filter.Limit
+		}
+		query += ` LIMIT ? OFFSET ?`
+		args = append(args, limit, filter.Offset)
+	}
+
+	return r.query(query, args...)
+}
+
+// Count returns the number of products matching filter, ignoring Limit and Offset
+func (r *SQLiteRepository) Count(filter ProductFilter) (int, error) {
+	where, args := sqliteFilterClause(filter)
+
+	var count int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM products`+where, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count products: %w", err)
+	}
+	return count, nil
+}
+
+// sqliteFilterClause builds the WHERE clause and its arguments for filter's
+// criteria. LIKE is case-insensitive for ASCII in SQLite, standing in for
+// ILIKE.
+func sqliteFilterClause(filter ProductFilter) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	if !filter.IncludeDeleted {
+		conditions = append(conditions, notDeleted)
+	}
+
+	if filter.Category != "" {
+		conditions = append(conditions, "category = ?")
+		args = append(args, filter.Category)
+	}
+	if len(filter.Categories) > 0 {
+		conditions = append(conditions, "category IN ("+sqlite.Placeholders(len(filter.Categories))+")")
+		args = append(args, stringArgs(filter.Categories)...)
+	}
+	if filter.Search != "" {
+		pattern := "%" + escapeLike(filter.Search) + "%"
+		conditions = append(conditions, `(name LIKE ? ESCAPE '\' OR description LIKE ? ESCAPE '\')`)
+		args = append(args, pattern, pattern)
+	}
+	if filter.MinPrice != nil {
+		conditions = append(conditions, "price >= ?")
+		args = append(args, *filter.MinPrice)
+	}
+	if filter.MaxPrice != nil {
+		conditions = append(conditions, "price <= ?")
+		args = append(args, *filter.MaxPrice)
+	}
+	if filter.InStock != nil {
+		conditions = append(conditions, "(quantity > 0) = ?")
+		args = append(args, *filter.InStock)
+	}
+	if filter.Barcode != "" {
+		conditions = append(conditions, "barcode = ?")
+		args = append(args, filter.Barcode)
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+	return ` WHERE ` + strings.Join(conditions, " AND "), args
+}
+
+// query runs a product SELECT and scans every row
+func (r *SQLiteRepository) query(query string, args ...interface{}) ([]*Product, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query products: %w", err)
+	}
+	defer rows.Close()
+
+	products := make([]*Product, 0)
+	for rows.Next() {
+		product, err := scanProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, product)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read products: %w", err)
+	}
+	return products, nil
+}
+
+// stringArgs converts values to query arguments, for IN lists
+func stringArgs(values []string) []interface{} {
+	args := make([]interface{}, len(values))
+	for i, value := range values {
+		args[i] = value
+	}
+	return args
+}
@@ -0,0 +1,230 @@
+package productimport
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"enricher-api-go/internal/product"
+)
+
+// Product fields a column can fill
+const (
+	fieldProductID      = "productId"
+	fieldName           = "name"
+	fieldDescription    = "description"
+	fieldPrice          = "price"
+	fieldCurrency       = "currency"
+	fieldCategory       = "category"
+	fieldQuantity       = "quantity"
+	fieldWeight         = "weight"
+	fieldWeightUnit     = "weightUnit"
+	fieldLength         = "length"
+	fieldWidth          = "width"
+	fieldHeight         = "height"
+	fieldDimensionsUnit = "dimensionsUnit"
+	fieldBarcode        = "barcode"
+)
+
+// fields lists the product fields a column can fill
+var fields = []string{
+	fieldProductID, fieldName, fieldDescription, fieldPrice, fieldCurrency, fieldCategory, fieldQuantity,
+	fieldWeight, fieldWeightUnit, fieldLength, fieldWidth, fieldHeight, fieldDimensionsUnit, fieldBarcode,
+}
+
+// requiredFields are the fields every row must fill, so a file without a
+// column for one of them is rejected before any row is written
+var requiredFields = []string{fieldName, fieldDescription, fieldPrice, fieldCategory}
+
+// row is one decoded row of the file
+type row struct {
+	line      int
+	productID string
+	request   product.ProductRequest
+}
+
+// decoder reads product rows from a CSV file one at a time, so files of any
+// size are imported without being held in memory
+type decoder struct {
+	reader  *csv.Reader
+	header  []string
+	columns []string // product field filled by each column, "" when ignored
+	ignored []string
+}
+
+// newDecoder reads the header of r and matches its columns to product
+// fields, using mapping before the column names
+func newDecoder(r io.Reader, mapping Mapping) (*decoder, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: the file is empty", ErrInvalidImport)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to read the header: %w", ErrInvalidImport, err)
+	}
+	// Spreadsheet exports often start with a UTF-8 byte order mark
+	header[0] = strings.TrimPrefix(header[0], "\ufeff")
+
+	for column, field := range mapping {
+		if !slices.Contains(fields, field) {
+			return nil, fmt.Errorf("%w: column %q maps to unknown field %q; fields must be among %s", ErrInvalidImport, column, field, strings.Join(fields, ", "))
+		}
+		if !slices.Contains(header, column) {
+			return nil, fmt.Errorf("%w: mapped column %q is not in the file", ErrInvalidImport, column)
+		}
+	}
+
+	d := &decoder{reader: reader, header: header, columns: make([]string, len(header))}
+	filledBy := make(map[string]string)
+	for i, column := range header {
+		field, ok := mapping[column]
+		if !ok {
+			field = matchField(column)
+		}
+		if field == "" {
+			d.ignored = append(d.ignored, column)
+			continue
+		}
+		if other, taken := filledBy[field]; taken {
+			return nil, fmt.Errorf("%w: columns %q and %q both fill %s", ErrInvalidImport, other, column, field)
+		}
+		filledBy[field] = column
+		d.columns[i] = field
+	}
+
+	var missing []string
+	for _, field := range requiredFields {
+		if _, ok := filledBy[field]; !ok {
+			missing = append(missing, field)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%w: no column fills %s", ErrInvalidImport, strings.Join(missing, ", "))
+	}
+	return d, nil
+}
+
+// matchField returns the product field named like column, ignoring case,
+// spaces, hyphens and underscores, or "" if there is none
+func matchField(column string) string {
+	normalized := strings.NewReplacer(" ", "", "-", "", "_", "").Replace(strings.ToLower(column))
+	for _, field := range fields {
+		if strings.ToLower(field) == normalized {
+			return field
+		}
+	}
+	return ""
+}
+
+// next decodes the next row of the file. A row that cannot be decoded is
+// returned as a RowError, and io.EOF once the file is exhausted. Any other
+// error means the file cannot be read further.
+func (d *decoder) next() (row, *RowError, error) {
+	for {
+		record, err := d.reader.Read()
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return row{}, &RowError{Line: parseErr.StartLine, Error: parseErr.Err.Error()}, nil
+		}
+		if err != nil {
+			return row{}, nil, err
+		}
+
+		line, _ := d.reader.FieldPos(0)
+		// Spreadsheets pad their exports with rows of empty cells
+		if isBlank(record) {
+			continue
+		}
+
+		r, err := d.decode(record)
+		if err != nil {
+			return row{}, &RowError{Line: line, ProductID: r.productID, Error: err.Error()}, nil
+		}
+		r.line = line
+		return r, nil, nil
+	}
+}
+
+// decode fills a product request from the cells of record
+func (d *decoder) decode(record []string) (row, error) {
+	var r row
+	var weight product.Weight
+	var dimensions product.Dimensions
+	hasWeight, hasDimensions := false, false
+
+	for i, cell := range record {
+		field := d.columns[i]
+		cell = strings.TrimSpace(cell)
+		if field == "" || cell == "" {
+			continue
+		}
+
+		var err error
+		switch field {
+		case fieldProductID:
+			r.productID = cell
+		case fieldName:
+			r.request.Name = cell
+		case fieldDescription:
+			r.request.Description = cell
+		case fieldPrice:
+			r.request.Price, err = product.ParsePrice(cell)
+		case fieldCurrency:
+			r.request.Currency = cell
+		case fieldCategory:
+			r.request.Category = cell
+		case fieldQuantity:
+			r.request.Quantity, err = strconv.Atoi(cell)
+		case fieldWeight:
+			weight.Value, err = strconv.ParseFloat(cell, 64)
+			hasWeight = true
+		case fieldWeightUnit:
+			weight.Unit = cell
+		case fieldLength:
+			dimensions.Length, err = strconv.ParseFloat(cell, 64)
+			hasDimensions = true
+		case fieldWidth:
+			dimensions.Width, err = strconv.ParseFloat(cell, 64)
+			hasDimensions = true
+		case fieldHeight:
+			dimensions.Height, err = strconv.ParseFloat(cell, 64)
+			hasDimensions = true
+		case fieldDimensionsUnit:
+			dimensions.Unit = cell
+		case fieldBarcode:
+			r.request.Barcode = cell
+		}
+		if err != nil {
+			var numErr *strconv.NumError
+			if errors.As(err, &numErr) {
+				err = fmt.Errorf("%q is not a number", cell)
+			}
+			return r, fmt.Errorf("column %q: %w", d.header[i], err)
+		}
+	}
+
+	if hasWeight {
+		r.request.Weight = &weight
+	}
+	if hasDimensions {
+		r.request.Dimensions = &dimensions
+	}
+	return r, nil
+}
+
+// isBlank reports whether every cell of record is empty
+func isBlank(record []string) bool {
+	for _, cell := range record {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,142 @@
+package productimport
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"enricher-api-go/internal/problem"
+	"enricher-api-go/internal/servertiming"
+
+	"github.com/labstack/echo/v4"
+)
+
+// maxFormValue bounds the form fields read before the file part
+const maxFormValue = 64 << 10
+
+// errNoFile is answered when an upload has no file part
+var errNoFile = errors.New("expected a multipart/form-data upload with a file field")
+
+// Handler handles HTTP requests for product imports
+type Handler struct {
+	service Service
+}
+
+// NewHandler creates a new product import handler
+func NewHandler(service Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+// ImportProducts handles POST /v1/products/import
+//
+// The body is a multipart/form-data upload whose file field holds the CSV.
+// It is read as it arrives, so the optional mapping and async fields must
+// come before the file. By default the rows are imported before the report
+// is answered with 200; with async=true the file is stored and the import is
+// answered with 202 while still QUEUED, with its URL in the Location header.
+//
+// Example request:
+//
+//	curl -X POST http://localhost:8080/v1/products/import \
+//		-F 'mapping={"Product Name": "name", "Cost": "price"}' \
+//		-F async=true \
+//		-F file=@catalog.csv
+func (h *Handler) ImportProducts(c echo.Context) error {
+	reader, err := c.Request().MultipartReader()
+	if err != nil {
+		return problem.Write(c, http.StatusBadRequest, errNoFile.Error())
+	}
+
+	var mapping Mapping
+	async := false
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			return problem.Write(c, http.StatusBadRequest, errNoFile.Error())
+		}
+		if err != nil {
+			return problem.Write(c, http.StatusBadRequest, "Invalid multipart body")
+		}
+
+		switch part.FormName() {
+		case "file":
+			defer part.Close()
+			if async {
+				return h.submitImport(c, part, mapping)
+			}
+			return h.importProducts(c, part, mapping)
+		case "mapping":
+			value, err := io.ReadAll(io.LimitReader(part, maxFormValue))
+			if err != nil || json.Unmarshal(value, &mapping) != nil {
+				return problem.Write(c, http.StatusBadRequest, `mapping must be a JSON object of column names to product fields, e.g. {"Cost": "price"}`)
+			}
+		case "async":
+			value, err := io.ReadAll(io.LimitReader(part, maxFormValue))
+			if err == nil {
+				async, err = strconv.ParseBool(string(value))
+			}
+			if err != nil {
+				return problem.Write(c, http.StatusBadRequest, "async must be true or false")
+			}
+		}
+		part.Close()
+	}
+}
+
+// importProducts imports file while the request waits
+func (h *Handler) importProducts(c echo.Context, file io.Reader, mapping Mapping) error {
+	stop := servertiming.Start(c, "service")
+	report, err := h.service.ImportProducts(c.Request().Context(), file, mapping)
+	stop()
+	if err != nil {
+		return importError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
+
+// submitImport queues file for import in the background
+func (h *Handler) submitImport(c echo.Context, file io.Reader, mapping Mapping) error {
+	stop := servertiming.Start(c, "service")
+	imp, err := h.service.SubmitImport(c.Request().Context(), file, mapping)
+	stop()
+	if err != nil {
+		return importError(c, err)
+	}
+
+	c.Response().Header().Set(echo.HeaderLocation, "/v1/product-imports/"+imp.ImportID)
+	return c.JSON(http.StatusAccepted, imp)
+}
+
+// GetImport handles GET /v1/product-imports/:id, reporting the progress of
+// a background import and the rows that failed so far
+func (h *Handler) GetImport(c echo.Context) error {
+	stop := servertiming.Start(c, "service")
+	imp, err := h.service.GetImport(c.Request().Context(), c.Param("id"))
+	stop()
+	if err != nil {
+		return importError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, imp)
+}
+
+// importError answers a failed import operation: 404 for an unknown or
+// expired import, 400 for an unusable file and 503 while the queue cannot
+// take more imports
+func importError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, ErrImportNotFound):
+		return problem.Write(c, http.StatusNotFound, "Product import not found")
+	case errors.Is(err, ErrInvalidImport):
+		return problem.Write(c, http.StatusBadRequest, err.Error())
+	case errors.Is(err, ErrQueueFull), errors.Is(err, ErrQueueStopped):
+		return problem.Write(c, http.StatusServiceUnavailable, err.Error())
+	default:
+		return problem.ServerError(c, err)
+	}
+}
@@ -0,0 +1,421 @@
+package productimport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"enricher-api-go/internal/auth"
+	"enricher-api-go/internal/clock"
+	"enricher-api-go/internal/idgen"
+	"enricher-api-go/internal/lifecycle"
+	"enricher-api-go/internal/logging"
+	"enricher-api-go/internal/product"
+	"enricher-api-go/internal/validation"
+)
+
+// Default importer settings
+const (
+	DefaultQueueSize = 10
+	DefaultMaxRows   = 100000
+	DefaultRetention = time.Hour
+)
+
+var (
+	// ErrImportNotFound is returned when an import does not exist or has expired
+	ErrImportNotFound = errors.New("product import not found")
+	// ErrInvalidImport is returned when a file or its mapping cannot be imported
+	ErrInvalidImport = errors.New("invalid product import")
+	// ErrQueueFull is returned when too many imports are already waiting
+	ErrQueueFull = errors.New("product import queue is full")
+	// ErrQueueStopped is returned when imports are submitted during shutdown
+	ErrQueueStopped = errors.New("product import queue is stopped")
+)
+
+// Config sizes the importer; zero values keep the package defaults
+type Config struct {
+	// QueueSize is how many background imports may wait before submissions are refused
+	QueueSize int
+	// MaxRows is the most rows one import may hold; later rows are not imported
+	MaxRows int
+	// Retention is how long completed background imports can still be fetched
+	Retention time.Duration
+	// Dir is where background uploads are kept until imported (the system
+	// temporary directory by default)
+	Dir string
+}
+
+// withDefaults fills in unset fields with the package defaults
+func (cfg Config) withDefaults() Config {
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = DefaultQueueSize
+	}
+	if cfg.MaxRows <= 0 {
+		cfg.MaxRows = DefaultMaxRows
+	}
+	if cfg.Retention <= 0 {
+		cfg.Retention = DefaultRetention
+	}
+	return cfg
+}
+
+// Service defines the business logic interface for product imports
+type Service interface {
+	ImportProducts(ctx context.Context, file io.Reader, mapping Mapping) (*Report, error)
+	SubmitImport(ctx context.Context, file io.Reader, mapping Mapping) (*Import, error)
+	GetImport(ctx context.Context, importID string) (*Import, error)
+}
+
+// Importer implements the Service interface, writing the rows of CSV files
+// through the product service so they are validated like API requests.
+// Background imports run one at a time and are kept in memory, so they do
+// not survive a restart.
+type Importer struct {
+	cfg         Config
+	products    product.Service
+	idGenerator idgen.Generator
+	clock       clock.Clock
+
+	mu      sync.Mutex
+	imports map[string]*Import
+	stopped bool
+
+	pending chan *upload
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+// upload is a background import waiting for the worker, with its file
+// spooled to disk and its header already read
+type upload struct {
+	imp     *Import
+	file    *os.File
+	decoder *decoder
+	// ctx carries the submitting request's caller and logger but not its
+	// cancellation
+	ctx    context.Context
+	logger *slog.Logger
+}
+
+// close removes the spooled file of u
+func (u *upload) close() {
+	removeFile(u.file)
+}
+
+// removeFile closes and deletes a spooled file
+func removeFile(file *os.File) {
+	file.Close()
+	os.Remove(file.Name())
+}
+
+// Option configures optional Importer behavior
+type Option func(*Importer)
+
+// WithIDGenerator sets the generator used for new import IDs (UUIDs by default)
+func WithIDGenerator(gen idgen.Generator) Option {
+	return func(im *Importer) {
+		im.idGenerator = gen
+	}
+}
+
+// WithClock sets the clock used for import timestamps and expiry (the UTC wall clock by default)
+func WithClock(c clock.Clock) Option {
+	return func(im *Importer) {
+		im.clock = c
+	}
+}
+
+// NewImporter creates an importer that writes products through products.
+// Background imports are accepted right away but only processed once Start
+// has been called.
+func NewImporter(cfg Config, products product.Service, opts ...Option) *Importer {
+	cfg = cfg.withDefaults()
+	im := &Importer{
+		cfg:         cfg,
+		products:    products,
+		idGenerator: idgen.UUIDGenerator{},
+		clock:       clock.System{},
+		imports:     make(map[string]*Import),
+		pending:     make(chan *upload, cfg.QueueSize),
+		done:        make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(im)
+	}
+	return im
+}
+
+// Start launches the worker that runs background imports in submission order
+func (im *Importer) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	im.cancel = cancel
+
+	go func() {
+		defer close(im.done)
+		for {
+			select {
+			case u := <-im.pending:
+				im.process(ctx, u)
+			case <-ctx.Done():
+				im.discardPending()
+				return
+			}
+		}
+	}()
+}
+
+// Stop refuses new imports, stops the running one after its current row
+// and waits for it until ctx ends. Imports still queued stay QUEUED.
+func (im *Importer) Stop(ctx context.Context) error {
+	im.mu.Lock()
+	im.stopped = true
+	im.mu.Unlock()
+
+	if im.cancel == nil {
+		return nil
+	}
+	im.cancel()
+	return lifecycle.WaitOrTimeout(ctx, im.done)
+}
+
+// ImportProducts imports the rows of file while the caller waits and
+// returns the report once every row has been attempted
+func (im *Importer) ImportProducts(ctx context.Context, file io.Reader, mapping Mapping) (*Report, error) {
+	logger := logging.FromContext(ctx)
+	logger.Info("Importing products")
+
+	d, err := newDecoder(file, mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	report := Report{IgnoredColumns: d.ignored, Errors: []RowError{}}
+	if err := im.run(ctx, ctx.Done(), d, func(update func(*Report)) { update(&report) }); err != nil {
+		logger.Warn("Product import stopped", "rows", report.Rows, "error", err)
+		return nil, fmt.Errorf("failed to import products: %w", err)
+	}
+
+	logger.Info("Imported products", "rows", report.Rows, "created", report.Created, "updated", report.Updated, "failed", report.Failed)
+	return &report, nil
+}
+
+// SubmitImport stores file and queues it for import in the background,
+// returning the QUEUED import. The header is checked before the import is
+// queued, so an unusable file or mapping is refused right away.
+func (im *Importer) SubmitImport(ctx context.Context, file io.Reader, mapping Mapping) (*Import, error) {
+	logger := logging.FromContext(ctx)
+	logger.Info("Submitting product import")
+
+	spooled, err := im.spool(file)
+	if err != nil {
+		logger.Error("Failed to store product import", "error", err)
+		return nil, fmt.Errorf("failed to submit product import: %w", err)
+	}
+	d, err := newDecoder(spooled, mapping)
+	if err != nil {
+		removeFile(spooled)
+		return nil, err
+	}
+
+	importID, err := im.idGenerator.NewID("import")
+	if err != nil {
+		removeFile(spooled)
+		logger.Error("Failed to generate import ID", "error", err)
+		return nil, fmt.Errorf("failed to submit product import: %w", err)
+	}
+
+	imp := &Import{
+		ImportID:  importID,
+		Status:    StatusQueued,
+		Report:    Report{IgnoredColumns: d.ignored, Errors: []RowError{}},
+		CreatedAt: im.clock.Now(),
+		CreatedBy: auth.Caller(ctx),
+	}
+	// The import's logs keep the submitting request's ID alongside its own
+	importLogger := logger.With("import_id", importID)
+	u := &upload{
+		imp:     imp,
+		file:    spooled,
+		decoder: d,
+		ctx:     logging.WithLogger(context.WithoutCancel(ctx), importLogger),
+		logger:  importLogger,
+	}
+
+	im.mu.Lock()
+	defer im.mu.Unlock()
+
+	if im.stopped {
+		u.close()
+		return nil, ErrQueueStopped
+	}
+	im.expire(imp.CreatedAt)
+
+	select {
+	case im.pending <- u:
+	default:
+		u.close()
+		logger.Warn("Product import queue is full", "queue_size", im.cfg.QueueSize)
+		return nil, ErrQueueFull
+	}
+	im.imports[importID] = imp
+
+	logger.Info("Product import queued", "import_id", importID)
+	return imp.snapshot(), nil
+}
+
+// GetImport returns the current progress of a background import
+func (im *Importer) GetImport(ctx context.Context, importID string) (*Import, error) {
+	logging.FromContext(ctx).Debug("Getting product import", "import_id", importID)
+
+	im.mu.Lock()
+	defer im.mu.Unlock()
+
+	im.expire(im.clock.Now())
+	imp, ok := im.imports[importID]
+	if !ok {
+		return nil, ErrImportNotFound
+	}
+	return imp.snapshot(), nil
+}
+
+// spool copies file to a temporary file and rewinds it, so the upload can
+// be answered before the rows are imported
+func (im *Importer) spool(file io.Reader) (*os.File, error) {
+	spooled, err := os.CreateTemp(im.cfg.Dir, "product-import-*.csv")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(spooled, file); err != nil {
+		removeFile(spooled)
+		return nil, err
+	}
+	if _, err := spooled.Seek(0, io.SeekStart); err != nil {
+		removeFile(spooled)
+		return nil, err
+	}
+	return spooled, nil
+}
+
+// process runs a background import, stopping after the current row once
+// stop ends
+func (im *Importer) process(stop context.Context, u *upload) {
+	defer u.close()
+
+	im.mu.Lock()
+	startedAt := im.clock.Now()
+	u.imp.Status = StatusRunning
+	u.imp.StartedAt = &startedAt
+	im.mu.Unlock()
+
+	err := im.run(u.ctx, stop.Done(), u.decoder, func(update func(*Report)) {
+		im.mu.Lock()
+		defer im.mu.Unlock()
+		update(&u.imp.Report)
+	})
+
+	im.mu.Lock()
+	defer im.mu.Unlock()
+
+	completedAt := im.clock.Now()
+	u.imp.CompletedAt = &completedAt
+	report := u.imp.Report
+	if err != nil {
+		u.imp.Status, u.imp.Error = StatusFailed, err.Error()
+		u.logger.Warn("Product import failed", "rows", report.Rows, "error", err)
+		return
+	}
+	u.imp.Status = StatusCompleted
+	u.logger.Info("Product import completed", "rows", report.Rows, "created", report.Created, "updated", report.Updated, "failed", report.Failed)
+}
+
+// run writes the rows of d through the product service until the file is
+// exhausted or stop ends, recording each outcome with record
+func (im *Importer) run(ctx context.Context, stop <-chan struct{}, d *decoder, record func(update func(*Report))) error {
+	for rows := 0; ; rows++ {
+		select {
+		case <-stop:
+			return errors.New("import interrupted before the end of the file")
+		default:
+		}
+
+		r, rowErr, err := d.next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%w: failed to read the file: %w", ErrInvalidImport, err)
+		}
+		if rows == im.cfg.MaxRows {
+			record(func(report *Report) { report.Truncated = true })
+			logging.FromContext(ctx).Warn("Product import truncated", "max_rows", im.cfg.MaxRows)
+			return nil
+		}
+
+		created := false
+		if rowErr == nil {
+			created, rowErr = im.write(ctx, r)
+		}
+
+		record(func(report *Report) {
+			report.Rows++
+			switch {
+			case rowErr != nil:
+				report.Failed++
+				report.Errors = append(report.Errors, *rowErr)
+			case created:
+				report.Created++
+			default:
+				report.Updated++
+			}
+		})
+	}
+}
+
+// write creates the product of r, or updates it when r names a product ID,
+// reporting whether it was created or why it failed
+func (im *Importer) write(ctx context.Context, r row) (bool, *RowError) {
+	var err error
+	if r.productID == "" {
+		_, err = im.products.CreateProduct(ctx, r.request)
+	} else {
+		_, err = im.products.UpdateProduct(ctx, r.productID, r.request)
+	}
+	if err == nil {
+		return r.productID == "", nil
+	}
+
+	rowErr := &RowError{Line: r.line, ProductID: r.productID, Error: err.Error()}
+	var validationErr *validation.Error
+	if errors.As(err, &validationErr) {
+		rowErr.Fields = validationErr.Fields
+	}
+	return false, rowErr
+}
+
+// discardPending removes the spooled files of imports still queued at
+// shutdown
+func (im *Importer) discardPending() {
+	for {
+		select {
+		case u := <-im.pending:
+			u.close()
+		default:
+			return
+		}
+	}
+}
+
+// expire forgets imports completed more than the retention period before
+// now. The caller must hold im.mu.
+func (im *Importer) expire(now time.Time) {
+	for importID, imp := range im.imports {
+		if imp.Done() && now.Sub(*imp.CompletedAt) > im.cfg.Retention {
+			delete(im.imports, importID)
+		}
+	}
+}
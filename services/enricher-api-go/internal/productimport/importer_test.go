@@ -0,0 +1,191 @@
+package productimport
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"enricher-api-go/internal/product"
+)
+
+// catalog is a spreadsheet export with a byte order mark, renamed columns,
+// an ignored column, a blank row and rows that fail for different reasons
+const catalog = "\ufeffProduct ID,Product Name,Description,Cost,Category,Quantity,Notes\n" +
+	"product-123,Wireless Mouse,Ergonomic wireless mouse,21.50,Electronics,40,restocked\n" +
+	",Desk Lamp,Adjustable LED desk lamp,34.99,Home,12,\n" +
+	",,,,,,\n" +
+	",Bad Price,Lamp with too precise a price,34.999,Home,1,\n" +
+	",X,Name too short for a product,5,Home,1,\n" +
+	"product-missing,Ghost Lamp,Lamp that does not exist,5,Home,1,\n" +
+	",Cable,\"USB-C cable,\n2 metres\",9.99,Electronics,200,\n"
+
+// newImporter returns an importer writing to an in-memory product service
+func newImporter(cfg Config) (*Importer, product.Service) {
+	products := product.NewService(product.NewInMemoryRepository())
+	return NewImporter(cfg, products), products
+}
+
+func TestImporter_ImportProducts(t *testing.T) {
+	// Arrange
+	importer, products := newImporter(Config{})
+	mapping := Mapping{"Product Name": "name", "Cost": "price"}
+
+	// Act
+	report, err := importer.ImportProducts(context.Background(), strings.NewReader(catalog), mapping)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if report.Rows != 6 || report.Created != 2 || report.Updated != 1 || report.Failed != 3 {
+		t.Errorf("Expected 2 created, 1 updated and 3 failed of 6 rows, got %+v", report)
+	}
+	if len(report.IgnoredColumns) != 1 || report.IgnoredColumns[0] != "Notes" {
+		t.Errorf("Expected the Notes column to be ignored, got %v", report.IgnoredColumns)
+	}
+
+	wantLines := []int{5, 6, 7}
+	for i, rowErr := range report.Errors {
+		if rowErr.Line != wantLines[i] {
+			t.Errorf("Expected error %d on line %d, got %+v", i, wantLines[i], rowErr)
+		}
+	}
+	if len(report.Errors) == 3 {
+		if !strings.Contains(report.Errors[0].Error, `column "Cost"`) {
+			t.Errorf("Expected the price error to name its column, got %q", report.Errors[0].Error)
+		}
+		if len(report.Errors[1].Fields) != 1 || report.Errors[1].Fields[0].Field != "name" {
+			t.Errorf("Expected a name validation error, got %+v", report.Errors[1])
+		}
+		if report.Errors[2].ProductID != "product-missing" {
+			t.Errorf("Expected the missing product to be named, got %+v", report.Errors[2])
+		}
+	}
+
+	updated, err := products.GetProduct(context.Background(), "product-123")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if updated.Name != "Wireless Mouse" || updated.Price != 21.5 || updated.Quantity != 40 {
+		t.Errorf("Expected product-123 updated from its row, got %+v", updated)
+	}
+}
+
+func TestImporter_ImportProducts_InvalidFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		mapping Mapping
+	}{
+		{name: "empty file", file: ""},
+		{name: "missing columns", file: "name,price\nLamp,5\n"},
+		{name: "unknown field", file: "name,description,price,category\n", mapping: Mapping{"price": "cost"}},
+		{name: "unknown column", file: "name,description,price,category\n", mapping: Mapping{"Cost": "price"}},
+		{name: "two columns for one field", file: "name,description,price,Cost,category\n", mapping: Mapping{"Cost": "price"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			importer, _ := newImporter(Config{})
+
+			// Act
+			_, err := importer.ImportProducts(context.Background(), strings.NewReader(tt.file), tt.mapping)
+
+			// Assert
+			if !errors.Is(err, ErrInvalidImport) {
+				t.Errorf("Expected ErrInvalidImport, got %v", err)
+			}
+		})
+	}
+}
+
+func TestImporter_ImportProducts_MaxRows(t *testing.T) {
+	// Arrange
+	importer, _ := newImporter(Config{MaxRows: 2})
+	file := "name,description,price,category\n" +
+		"Lamp One,First lamp of the file,5,Home\n" +
+		"Lamp Two,Second lamp of the file,5,Home\n" +
+		"Lamp Three,Third lamp of the file,5,Home\n"
+
+	// Act
+	report, err := importer.ImportProducts(context.Background(), strings.NewReader(file), nil)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if report.Rows != 2 || report.Created != 2 || !report.Truncated {
+		t.Errorf("Expected 2 rows imported and the rest truncated, got %+v", report)
+	}
+}
+
+func TestImporter_SubmitImport(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	importer, _ := newImporter(Config{Dir: dir})
+	importer.Start()
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := importer.Stop(ctx); err != nil {
+			t.Errorf("Expected the importer to stop, got %v", err)
+		}
+	})
+
+	// Act
+	submitted, err := importer.SubmitImport(context.Background(), strings.NewReader(catalog), Mapping{"Product Name": "name", "Cost": "price"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	imp := waitForImport(t, importer, submitted.ImportID)
+
+	// Assert
+	if submitted.Status != StatusQueued {
+		t.Errorf("Expected a QUEUED import, got %+v", submitted)
+	}
+	if imp.Status != StatusCompleted || imp.Report.Created != 2 || imp.Report.Failed != 3 {
+		t.Errorf("Expected a COMPLETED import with 2 created and 3 failed rows, got %+v", imp)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("Expected the uploaded file to be removed, got %d files", len(entries))
+	}
+}
+
+func TestImporter_SubmitImport_InvalidFile(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	importer, _ := newImporter(Config{Dir: dir})
+
+	// Act
+	_, err := importer.SubmitImport(context.Background(), strings.NewReader("name\nLamp\n"), nil)
+
+	// Assert
+	if !errors.Is(err, ErrInvalidImport) {
+		t.Errorf("Expected ErrInvalidImport, got %v", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("Expected the refused file to be removed, got %d files", len(entries))
+	}
+}
+
+// waitForImport polls the importer until the import stops
+func waitForImport(t *testing.T, importer *Importer, importID string) *Import {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		imp, err := importer.GetImport(context.Background(), importID)
+		if err != nil {
+			t.Fatalf("Expected the import, got %v", err)
+		}
+		if imp.Done() {
+			return imp
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("Import %s did not complete in time", importID)
+	return nil
+}
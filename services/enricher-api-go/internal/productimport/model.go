@@ -0,0 +1,88 @@
+// Package productimport loads product catalogs from CSV files for the
+// Resilient Order Enricher API.
+//
+// Merchandising teams keep catalogs in spreadsheets; an uploaded CSV is read
+// row by row, each row creating a product or, when it names a productId,
+// updating one. Rows that fail are listed in a report instead of stopping
+// the import. Large files can be imported in the background while callers
+// poll the import for progress.
+package productimport
+
+import (
+	"time"
+
+	"enricher-api-go/internal/validation"
+)
+
+// Import statuses
+const (
+	StatusQueued    = "QUEUED"
+	StatusRunning   = "RUNNING"
+	StatusCompleted = "COMPLETED"
+	StatusFailed    = "FAILED"
+)
+
+// Mapping maps CSV column headers to product fields, such as
+// {"Product Name": "name", "Cost": "price"}. Columns it does not name are
+// matched to the field of the same name, ignoring case, spaces, hyphens and
+// underscores, so a "Product ID" column fills productId.
+type Mapping map[string]string
+
+// RowError explains why one row of the file was not imported
+type RowError struct {
+	// Line is the line of the file the row starts on; the header is line 1
+	Line int `json:"line"`
+	// ProductID is the product the row names, if any
+	ProductID string `json:"productId,omitempty"`
+	Error     string `json:"error"`
+	// Fields lists the fields that failed validation
+	Fields []validation.FieldError `json:"fields,omitempty"`
+}
+
+// Report counts the outcome of the rows of an import
+type Report struct {
+	Rows    int `json:"rows"`
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+	Failed  int `json:"failed"`
+	// Errors lists every failed row in file order
+	Errors []RowError `json:"errors"`
+	// IgnoredColumns lists the columns that match no product field
+	IgnoredColumns []string `json:"ignoredColumns,omitempty"`
+	// Truncated is set when the file held more rows than an import may
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// snapshot returns a copy of the report that later rows do not change
+func (r Report) snapshot() Report {
+	r.Errors = append([]RowError{}, r.Errors...)
+	return r
+}
+
+// Import is a CSV file imported in the background. An import is COMPLETED
+// once every row has been attempted, whether or not some of them failed, and
+// FAILED when the file could not be read to the end.
+type Import struct {
+	ImportID string `json:"importId"`
+	Status   string `json:"status"`
+	Report   Report `json:"report"`
+	// Error explains why a FAILED import stopped
+	Error string `json:"error,omitempty"`
+
+	CreatedAt   time.Time  `json:"createdAt"`
+	StartedAt   *time.Time `json:"startedAt,omitempty"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+	CreatedBy   string     `json:"createdBy,omitempty"`
+}
+
+// Done reports whether the import has stopped
+func (i *Import) Done() bool {
+	return i.Status == StatusCompleted || i.Status == StatusFailed
+}
+
+// snapshot returns a copy of the import that later progress does not change
+func (i *Import) snapshot() *Import {
+	snapshot := *i
+	snapshot.Report = i.Report.snapshot()
+	return &snapshot
+}
@@ -0,0 +1,200 @@
+// Package ratelimit throttles clients with token buckets and reports the
+// limits in X-RateLimit-* headers.
+package ratelimit
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"enricher-api-go/internal/auth"
+	"enricher-api-go/internal/logging"
+	"enricher-api-go/internal/problem"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Limit sizes a token bucket
+type Limit struct {
+	// Rate is the sustained number of requests per second
+	Rate float64
+	// Burst is how many requests a client that has been idle may send at once
+	Burst int
+}
+
+// Decision is the outcome of taking a token
+type Decision struct {
+	Allowed bool
+	// Limit is the bucket size
+	Limit int
+	// Remaining is the number of whole tokens left
+	Remaining int
+	// RetryAfter is how long until a token is available when not allowed
+	RetryAfter time.Duration
+	// Reset is how long until the bucket is full again
+	Reset time.Duration
+}
+
+// Limiter keeps one token bucket per client key
+type Limiter struct {
+	limit Limit
+	now   func() time.Time
+
+	mutex     sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// NewLimiter creates a Limiter whose buckets start full
+func NewLimiter(limit Limit) *Limiter {
+	return &Limiter{
+		limit:   limit,
+		now:     time.Now,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// Allow takes a token from key's bucket if one is available
+func (l *Limiter) Allow(key string) Decision {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	burst := float64(l.limit.Burst)
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: burst}
+		l.buckets[key] = b
+	} else {
+		b.tokens = math.Min(burst, b.tokens+now.Sub(b.updated).Seconds()*l.limit.Rate)
+	}
+	b.updated = now
+
+	decision := Decision{Limit: l.limit.Burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		decision.Allowed = true
+	} else {
+		decision.RetryAfter = l.refillTime(1 - b.tokens)
+	}
+	decision.Remaining = int(b.tokens)
+	decision.Reset = l.refillTime(burst - b.tokens)
+	return decision
+}
+
+// sweep drops buckets that have refilled completely, which behave exactly
+// like the new full bucket a returning client would get
+func (l *Limiter) sweep(now time.Time) {
+	fullRefill := l.refillTime(float64(l.limit.Burst))
+	if now.Sub(l.lastSweep) < fullRefill {
+		return
+	}
+	for key, b := range l.buckets {
+		if now.Sub(b.updated) >= fullRefill {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastSweep = now
+}
+
+// refillTime is how long tokens take to accrue
+func (l *Limiter) refillTime(tokens float64) time.Duration {
+	return time.Duration(tokens / l.limit.Rate * float64(time.Second))
+}
+
+// Config configures Middleware
+type Config struct {
+	// Default limits routes not covered by Groups
+	Default Limit
+	// Groups overrides Default for routes under a path prefix, such as
+	// "/v1/enrich"; each group keeps separate buckets
+	Groups map[string]Limit
+	// Key identifies the client; KeyByClient if nil
+	Key func(c echo.Context) string
+}
+
+// groupLimiter applies a limiter to routes under prefix
+type groupLimiter struct {
+	prefix  string
+	limiter *Limiter
+}
+
+// Middleware rejects clients that exceed their limit with 429 and a
+// Retry-After header. Every response carries X-RateLimit-Limit,
+// X-RateLimit-Remaining and X-RateLimit-Reset (seconds until the bucket is full).
+func Middleware(cfg Config) echo.MiddlewareFunc {
+	key := cfg.Key
+	if key == nil {
+		key = KeyByClient
+	}
+
+	// Longest prefix first so nested groups win
+	groups := make([]groupLimiter, 0, len(cfg.Groups))
+	for prefix, limit := range cfg.Groups {
+		groups = append(groups, groupLimiter{prefix: prefix, limiter: NewLimiter(limit)})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return len(groups[i].prefix) > len(groups[j].prefix)
+	})
+	fallback := NewLimiter(cfg.Default)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			limiter := fallback
+			for _, group := range groups {
+				if strings.HasPrefix(c.Path(), group.prefix) {
+					limiter = group.limiter
+					break
+				}
+			}
+
+			client := key(c)
+			decision := limiter.Allow(client)
+			header := c.Response().Header()
+			header.Set("X-RateLimit-Limit", strconv.Itoa(decision.Limit))
+			header.Set("X-RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+			header.Set("X-RateLimit-Reset", seconds(decision.Reset))
+
+			if !decision.Allowed {
+				logging.FromContext(c.Request().Context()).Warn("Rate limit exceeded", "client", client, "route", c.Path())
+				header.Set(echo.HeaderRetryAfter, seconds(decision.RetryAfter))
+				return problem.Write(c, http.StatusTooManyRequests, "rate limit exceeded")
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// KeyByClient identifies authenticated callers by principal, API keys by
+// key name and tokens by subject, and everyone else, including tokens
+// without a subject, by client IP.
+// Unverified credentials are ignored so clients cannot escape their limit by
+// rotating made-up keys. The IP is what the server's IPExtractor trusts, so
+// configure one before honoring forwarding headers.
+func KeyByClient(c echo.Context) string {
+	principal, ok := auth.PrincipalFrom(c)
+	switch {
+	case ok && principal.APIKey:
+		return "apikey:" + principal.Subject
+	case ok && principal.Subject != "":
+		return "subject:" + principal.Subject
+	}
+	return "ip:" + c.RealIP()
+}
+
+// seconds rounds d up to whole seconds
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,146 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"enricher-api-go/internal/auth"
+	"enricher-api-go/internal/jwtauth"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+)
+
+// fakeClock is advanced manually by tests
+type fakeClock struct {
+	now time.Time
+}
+
+func (f *fakeClock) Now() time.Time { return f.now }
+
+func newTestLimiter(limit Limit) (*Limiter, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2025, 1, 8, 10, 0, 0, 0, time.UTC)}
+	limiter := NewLimiter(limit)
+	limiter.now = clock.Now
+	return limiter, clock
+}
+
+func TestLimiter_Allow(t *testing.T) {
+	// Arrange
+	limiter, clock := newTestLimiter(Limit{Rate: 2, Burst: 3})
+
+	// Act
+	var burst []Decision
+	for i := 0; i < 4; i++ {
+		burst = append(burst, limiter.Allow("client"))
+	}
+	clock.now = clock.now.Add(500 * time.Millisecond)
+	refilled := limiter.Allow("client")
+
+	// Assert
+	for i, decision := range burst[:3] {
+		if !decision.Allowed || decision.Remaining != 2-i {
+			t.Errorf("Expected request %d allowed with %d remaining, got %+v", i+1, 2-i, decision)
+		}
+	}
+	if burst[3].Allowed {
+		t.Error("Expected the request beyond the burst to be rejected")
+	}
+	if burst[3].RetryAfter != 500*time.Millisecond || burst[3].Reset != 1500*time.Millisecond {
+		t.Errorf("Expected retry after 500ms and reset after 1.5s, got %+v", burst[3])
+	}
+	if !refilled.Allowed {
+		t.Error("Expected a token to refill after 500ms at 2 per second")
+	}
+}
+
+func TestLimiter_SeparateClients(t *testing.T) {
+	// Arrange
+	limiter, _ := newTestLimiter(Limit{Rate: 1, Burst: 1})
+
+	// Act
+	first := limiter.Allow("a")
+	second := limiter.Allow("a")
+	other := limiter.Allow("b")
+
+	// Assert
+	if !first.Allowed || second.Allowed || !other.Allowed {
+		t.Errorf("Expected per-client buckets, got %+v %+v %+v", first, second, other)
+	}
+}
+
+func TestLimiter_SweepsFullBuckets(t *testing.T) {
+	// Arrange
+	limiter, clock := newTestLimiter(Limit{Rate: 1, Burst: 2})
+	limiter.Allow("idle")
+
+	// Act
+	clock.now = clock.now.Add(3 * time.Second)
+	limiter.Allow("active")
+
+	// Assert
+	if _, ok := limiter.buckets["idle"]; ok {
+		t.Error("Expected the refilled idle bucket to be dropped")
+	}
+	if len(limiter.buckets) != 1 {
+		t.Errorf("Expected only the active bucket, got %d", len(limiter.buckets))
+	}
+}
+
+func TestKeyByClient(t *testing.T) {
+	// Arrange
+	// The stub bearer middleware stands in for jwtauth, accepting the token
+	// "checkout-token" as the subject checkout
+	bearer := func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if c.Request().Header.Get(echo.HeaderAuthorization) == "Bearer checkout-token" {
+				c.Set("jwtauth.claims", &jwtauth.Claims{RegisteredClaims: jwt.RegisteredClaims{Subject: "checkout"}})
+			}
+			return next(c)
+		}
+	}
+	authorizer := auth.New(auth.Config{
+		APIKeys: []auth.APIKey{{Name: "ci", Key: "ci-key", Role: auth.RoleReader}},
+		Bearer:  bearer,
+	})
+	e := echo.New()
+	keyFor := func(c echo.Context) string {
+		var key string
+		authorizer.Authenticate()(func(c echo.Context) error {
+			key = KeyByClient(c)
+			return nil
+		})(c)
+		if key == "" {
+			key = KeyByClient(c)
+		}
+		return key
+	}
+	request := func(header, value string) echo.Context {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(echo.HeaderXRealIP, "10.0.0.7")
+		req.Header.Set(header, value)
+		return e.NewContext(req, httptest.NewRecorder())
+	}
+
+	// Act
+	madeUp := keyFor(request(auth.APIKeyHeader, "made-up"))
+	verified := keyFor(request(auth.APIKeyHeader, "ci-key"))
+	token := keyFor(request(echo.HeaderAuthorization, "Bearer checkout-token"))
+	badToken := keyFor(request(echo.HeaderAuthorization, "Bearer forged"))
+
+	// Assert
+	if madeUp != "ip:10.0.0.7" {
+		t.Errorf("Expected unverified API keys to be keyed by IP, got %q", madeUp)
+	}
+	if verified != "apikey:ci" {
+		t.Errorf("Expected verified API keys to be keyed by name, got %q", verified)
+	}
+	if token != "subject:checkout" {
+		t.Errorf("Expected verified tokens to be keyed by subject, got %q", token)
+	}
+	if badToken != "ip:10.0.0.7" {
+		t.Errorf("Expected unverified tokens to be keyed by IP, got %q", badToken)
+	}
+}
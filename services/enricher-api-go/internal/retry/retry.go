@@ -0,0 +1,178 @@
+// Package retry retries storage calls that fail transiently, so a dropped
+// connection or a lost serialization race does not reach the caller.
+//
+// A Retrier makes up to MaxAttempts attempts of a call, waiting an
+// exponentially growing, fully jittered delay between them. Calls that change
+// nothing are retried on any transient error, while writes are retried only
+// on errors showing that nothing was written: retrying a write whose outcome
+// is unknown could apply it twice.
+//
+// Example usage:
+//
+//	retrier := retry.New("postgres", retry.Policy{MaxAttempts: 3, BaseDelay: 50 * time.Millisecond, MaxDelay: 500 * time.Millisecond})
+//	err := retrier.Read(func() error {
+//		product, err = repo.GetByID(productID)
+//		return err
+//	})
+package retry
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"sync/atomic"
+	"time"
+)
+
+// Defaults applied to zero Policy fields
+const (
+	DefaultMaxAttempts = 3
+	DefaultBaseDelay   = 50 * time.Millisecond
+	DefaultMaxDelay    = 500 * time.Millisecond
+)
+
+// Policy configures a Retrier
+type Policy struct {
+	// MaxAttempts is the most attempts of a call, the first included
+	MaxAttempts int
+	// BaseDelay is the most the first retry waits; each retry may wait up to
+	// twice as long as the one before
+	BaseDelay time.Duration
+	// MaxDelay caps the wait before any retry
+	MaxDelay time.Duration
+	// Retryable reports whether a call that changes nothing is worth
+	// retrying after err; Transient if nil
+	Retryable func(err error) bool
+	// RetryableWrite reports whether a write is safe to retry after err;
+	// Unapplied if nil
+	RetryableWrite func(err error) bool
+}
+
+// Stats counts the calls of a Retrier since start
+type Stats struct {
+	Name string
+	// Retries counts the attempts after the first
+	Retries uint64
+	// Recovered counts the calls that succeeded after a retry
+	Recovered uint64
+	// Exhausted counts the calls that still failed transiently after the
+	// last attempt
+	Exhausted uint64
+}
+
+// Retrier retries the calls to one storage backend
+type Retrier struct {
+	name   string
+	policy Policy
+	sleep  func(time.Duration)
+	random func() float64
+
+	retries   atomic.Uint64
+	recovered atomic.Uint64
+	exhausted atomic.Uint64
+}
+
+// New creates a Retrier of the backend name, applying the defaults to zero
+// policy fields
+func New(name string, policy Policy) *Retrier {
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = DefaultMaxAttempts
+	}
+	if policy.BaseDelay <= 0 {
+		policy.BaseDelay = DefaultBaseDelay
+	}
+	if policy.MaxDelay <= 0 {
+		policy.MaxDelay = DefaultMaxDelay
+	}
+	if policy.Retryable == nil {
+		policy.Retryable = Transient
+	}
+	if policy.RetryableWrite == nil {
+		policy.RetryableWrite = Unapplied
+	}
+	return &Retrier{name: name, policy: policy, sleep: time.Sleep, random: rand.Float64}
+}
+
+// Name returns the backend whose calls are retried
+func (r *Retrier) Name() string {
+	return r.name
+}
+
+// Read calls fn, which changes nothing, until it succeeds, fails with an
+// error the policy does not retry or runs out of attempts
+func (r *Retrier) Read(fn func() error) error {
+	return r.do(r.policy.Retryable, fn)
+}
+
+// Write calls fn, which may change data, retrying it only while it fails
+// with an error showing nothing was written
+func (r *Retrier) Write(fn func() error) error {
+	return r.do(r.policy.RetryableWrite, fn)
+}
+
+// Stats returns the retry counters
+func (r *Retrier) Stats() Stats {
+	return Stats{
+		Name:      r.name,
+		Retries:   r.retries.Load(),
+		Recovered: r.recovered.Load(),
+		Exhausted: r.exhausted.Load(),
+	}
+}
+
+// do calls fn, retrying the errors retryable accepts
+func (r *Retrier) do(retryable func(error) bool, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		switch {
+		case err == nil:
+			if attempt > 1 {
+				r.recovered.Add(1)
+			}
+			return nil
+		case !retryable(err):
+			return err
+		case attempt == r.policy.MaxAttempts:
+			r.exhausted.Add(1)
+			return err
+		}
+
+		delay := r.backoff(attempt)
+		slog.Debug("Retrying storage call", "backend", r.name, "attempt", attempt+1, "delay", delay.String(), "error", err)
+		r.retries.Add(1)
+		r.sleep(delay)
+	}
+}
+
+// backoff returns the wait after the attempt-th failed attempt: a random
+// duration up to BaseDelay doubled for each earlier retry, capped at MaxDelay
+func (r *Retrier) backoff(attempt int) time.Duration {
+	ceiling := r.policy.MaxDelay
+	if shift := attempt - 1; shift < 32 && r.policy.BaseDelay<<shift < ceiling {
+		ceiling = r.policy.BaseDelay << shift
+	}
+	return time.Duration(r.random() * float64(ceiling))
+}
+
+// WriteMetrics writes the retry counters to w in the Prometheus text
+// exposition format
+func WriteMetrics(w io.Writer, retriers ...*Retrier) {
+	stats := make([]Stats, len(retriers))
+	for i, r := range retriers {
+		stats[i] = r.Stats()
+	}
+
+	metric := func(name, help string, value func(Stats) uint64) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+		for _, s := range stats {
+			fmt.Fprintf(w, "%s{name=%q} %d\n", name, s.Name, value(s))
+		}
+	}
+	metric("storage_retries_total", "Storage call attempts after the first.",
+		func(s Stats) uint64 { return s.Retries })
+	metric("storage_retry_recoveries_total", "Storage calls that succeeded after a retry.",
+		func(s Stats) uint64 { return s.Recovered })
+	metric("storage_retry_exhausted_total", "Storage calls that failed transiently on every attempt.",
+		func(s Stats) uint64 { return s.Exhausted })
+}
@@ -0,0 +1,169 @@
+package retry
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/mattn/go-sqlite3"
+)
+
+// errNotFound stands in for a domain error
+var errNotFound = errors.New("product not found")
+
+// newTestRetrier returns a Retrier that records its waits instead of
+// sleeping and always waits the longest it may
+func newTestRetrier(policy Policy) (*Retrier, *[]time.Duration) {
+	var waits []time.Duration
+	r := New("postgres", policy)
+	r.sleep = func(d time.Duration) { waits = append(waits, d) }
+	r.random = func() float64 { return 1 }
+	return r, &waits
+}
+
+// failing returns a call that fails with errs in turn, then succeeds, and
+// the count of its calls
+func failing(errs ...error) (func() error, *int) {
+	calls := 0
+	return func() error {
+		calls++
+		if calls <= len(errs) {
+			return errs[calls-1]
+		}
+		return nil
+	}, &calls
+}
+
+func TestRetrier_Read(t *testing.T) {
+	reset := fmt.Errorf("failed to query product: %w", syscall.ECONNRESET)
+	tests := []struct {
+		name          string
+		errs          []error
+		expectedErr   error
+		expectedCalls int
+	}{
+		{name: "recovers", errs: []error{reset, io.ErrUnexpectedEOF}, expectedCalls: 3},
+		{name: "exhausts attempts", errs: []error{reset, reset, reset, reset}, expectedErr: reset, expectedCalls: 3},
+		{name: "domain error", errs: []error{errNotFound}, expectedErr: errNotFound, expectedCalls: 1},
+		{name: "expired deadline", errs: []error{context.DeadlineExceeded}, expectedErr: context.DeadlineExceeded, expectedCalls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			r, _ := newTestRetrier(Policy{MaxAttempts: 3})
+			fn, calls := failing(tt.errs...)
+
+			// Act
+			err := r.Read(fn)
+
+			// Assert
+			if !errors.Is(err, tt.expectedErr) || (tt.expectedErr == nil && err != nil) {
+				t.Errorf("Expected %v, got %v", tt.expectedErr, err)
+			}
+			if *calls != tt.expectedCalls {
+				t.Errorf("Expected %d calls, got %d", tt.expectedCalls, *calls)
+			}
+		})
+	}
+}
+
+func TestRetrier_WriteRetriesOnlyUnapplied(t *testing.T) {
+	// Arrange
+	r, _ := newTestRetrier(Policy{MaxAttempts: 3})
+	refused, refusedCalls := failing(fmt.Errorf("dial tcp: %w", syscall.ECONNREFUSED))
+	reset, resetCalls := failing(syscall.ECONNRESET)
+
+	// Act
+	refusedErr := r.Write(refused)
+	resetErr := r.Write(reset)
+
+	// Assert
+	if refusedErr != nil || *refusedCalls != 2 {
+		t.Errorf("Expected a write refused a connection to be retried, got %v after %d calls", refusedErr, *refusedCalls)
+	}
+	if !errors.Is(resetErr, syscall.ECONNRESET) || *resetCalls != 1 {
+		t.Errorf("Expected a write whose outcome is unknown not to be retried, got %v after %d calls", resetErr, *resetCalls)
+	}
+}
+
+func TestRetrier_BacksOffExponentially(t *testing.T) {
+	// Arrange
+	r, waits := newTestRetrier(Policy{MaxAttempts: 5, BaseDelay: 10 * time.Millisecond, MaxDelay: 30 * time.Millisecond})
+	fn, _ := failing(io.EOF, io.EOF, io.EOF, io.EOF)
+
+	// Act
+	_ = r.Read(fn)
+
+	// Assert
+	expected := []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 30 * time.Millisecond, 30 * time.Millisecond}
+	if fmt.Sprint(*waits) != fmt.Sprint(expected) {
+		t.Errorf("Expected waits of %v, got %v", expected, *waits)
+	}
+	if stats := r.Stats(); stats.Retries != 4 || stats.Recovered != 1 || stats.Exhausted != 0 {
+		t.Errorf("Expected 4 retries and 1 recovery, got %+v", stats)
+	}
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		transient bool
+		unapplied bool
+	}{
+		{name: "bad connection", err: driver.ErrBadConn, transient: true, unapplied: true},
+		{name: "serialization failure", err: &pgconn.PgError{Code: "40001"}, transient: true, unapplied: true},
+		{name: "connection failure", err: &pgconn.PgError{Code: "08006"}, transient: true},
+		{name: "admin shutdown", err: fmt.Errorf("failed to update: %w", &pgconn.PgError{Code: "57P01"}), transient: true},
+		{name: "unique violation", err: &pgconn.PgError{Code: "23505"}},
+		{name: "sqlite busy", err: sqlite3.Error{Code: sqlite3.ErrBusy}, transient: true, unapplied: true},
+		{name: "broken pipe", err: syscall.EPIPE, transient: true},
+		{name: "canceled", err: context.Canceled},
+		{name: "domain error", err: errNotFound},
+		{name: "no error", err: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			transient, unapplied := Transient(tt.err), Unapplied(tt.err)
+
+			// Assert
+			if transient != tt.transient || unapplied != tt.unapplied {
+				t.Errorf("Expected transient %v and unapplied %v, got %v and %v", tt.transient, tt.unapplied, transient, unapplied)
+			}
+		})
+	}
+}
+
+func TestWriteMetrics(t *testing.T) {
+	// Arrange
+	r, _ := newTestRetrier(Policy{MaxAttempts: 2})
+	recovering, _ := failing(io.EOF)
+	exhausting, _ := failing(io.EOF, io.EOF)
+	_ = r.Read(recovering)
+	_ = r.Read(exhausting)
+
+	// Act
+	var body strings.Builder
+	WriteMetrics(&body, r)
+
+	// Assert
+	for _, line := range []string{
+		`storage_retries_total{name="postgres"} 2`,
+		`storage_retry_recoveries_total{name="postgres"} 1`,
+		`storage_retry_exhausted_total{name="postgres"} 1`,
+	} {
+		if !strings.Contains(body.String(), line) {
+			t.Errorf("Expected metrics to contain %q, got:\n%s", line, body.String())
+		}
+	}
+}
@@ -0,0 +1,160 @@
+// Package seed loads fixture data from a JSON or YAML file, for in-memory
+// repositories to start with or for any storage backend to be filled with.
+//
+// Records use the field names of the API responses:
+//
+//	categories:
+//	  - categoryId: category-electronics
+//	    name: Electronics
+//	customers:
+//	  - customerId: customer-456
+//	    name: Jane Doe
+//	    status: ACTIVE
+//	    creditLimit: 5000
+//	products:
+//	  - productId: product-789
+//	    name: Laptop
+//	    price: 999
+//	    category: Electronics
+//	    quantity: 25
+package seed
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"enricher-api-go/internal/category"
+	"enricher-api-go/internal/currency"
+	"enricher-api-go/internal/customer"
+	"enricher-api-go/internal/product"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Fixtures are the records of a seed file
+type Fixtures struct {
+	Categories []*category.Category `json:"categories"`
+	Customers  []*customer.Customer `json:"customers"`
+	Products   []*product.Product   `json:"products"`
+}
+
+// Load reads the fixtures in the file at path: YAML for a .yaml or .yml
+// file and JSON otherwise. Unknown fields and records without an ID are
+// rejected.
+func Load(path string) (*Fixtures, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read seed file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		// Records carry JSON field names, so YAML is decoded through JSON
+		var document interface{}
+		if err := yaml.Unmarshal(data, &document); err != nil {
+			return nil, fmt.Errorf("failed to parse seed file %s: %w", path, err)
+		}
+		if data, err = json.Marshal(document); err != nil {
+			return nil, fmt.Errorf("failed to parse seed file %s: %w", path, err)
+		}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	var fixtures Fixtures
+	if err := decoder.Decode(&fixtures); err != nil {
+		return nil, fmt.Errorf("failed to parse seed file %s: %w", path, err)
+	}
+	if err := fixtures.validate(); err != nil {
+		return nil, fmt.Errorf("invalid seed file %s: %w", path, err)
+	}
+	return &fixtures, nil
+}
+
+// validate reports records without an ID
+func (f *Fixtures) validate() error {
+	for i, c := range f.Categories {
+		if c.CategoryID == "" {
+			return fmt.Errorf("category %d has no categoryId", i+1)
+		}
+	}
+	for i, c := range f.Customers {
+		if c.CustomerID == "" {
+			return fmt.Errorf("customer %d has no customerId", i+1)
+		}
+	}
+	for i, p := range f.Products {
+		if p.ProductID == "" {
+			return fmt.Errorf("product %d has no productId", i+1)
+		}
+	}
+	return nil
+}
+
+// Result counts the records Apply wrote and those it found already stored
+type Result struct {
+	Created int
+	Skipped int
+}
+
+// Apply writes the fixtures to the repositories, categories first so products
+// can name them. Records whose ID is already taken are left as they are, so
+// seeding twice changes nothing.
+func Apply(fixtures *Fixtures, categories category.Repository, customers customer.Repository, products product.Repository) (Result, error) {
+	var result Result
+	count := func(err, exists error) error {
+		switch {
+		case err == nil:
+			result.Created++
+		case errors.Is(err, exists):
+			result.Skipped++
+		default:
+			return err
+		}
+		return nil
+	}
+	now := time.Now().UTC()
+
+	for _, fixture := range fixtures.Categories {
+		c := *fixture
+		c.CreatedAt, c.UpdatedAt = timestamps(c.CreatedAt, c.UpdatedAt, now)
+		if err := count(categories.Create(&c), category.ErrCategoryExists); err != nil {
+			return result, fmt.Errorf("failed to seed category %s: %w", c.CategoryID, err)
+		}
+	}
+	for _, fixture := range fixtures.Customers {
+		c := *fixture
+		c.CreatedAt, c.UpdatedAt = timestamps(c.CreatedAt, c.UpdatedAt, now)
+		if err := count(customers.Create(&c), customer.ErrCustomerExists); err != nil {
+			return result, fmt.Errorf("failed to seed customer %s: %w", c.CustomerID, err)
+		}
+	}
+	for _, fixture := range fixtures.Products {
+		p := *fixture
+		p.CreatedAt, p.UpdatedAt = timestamps(p.CreatedAt, p.UpdatedAt, now)
+		if p.Currency == "" {
+			p.Currency = currency.DefaultCode
+		}
+		if err := count(products.Create(&p), product.ErrProductExists); err != nil {
+			return result, fmt.Errorf("failed to seed product %s: %w", p.ProductID, err)
+		}
+	}
+	return result, nil
+}
+
+// timestamps fills in the creation and update times a fixture leaves out
+func timestamps(created, updated, now time.Time) (time.Time, time.Time) {
+	if created.IsZero() {
+		created = now
+	}
+	if updated.IsZero() {
+		updated = created
+	}
+	return created, updated
+}
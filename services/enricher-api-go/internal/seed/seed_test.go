@@ -0,0 +1,151 @@
+package seed
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"enricher-api-go/internal/category"
+	"enricher-api-go/internal/currency"
+	"enricher-api-go/internal/customer"
+	"enricher-api-go/internal/product"
+)
+
+// writeFile writes content to a file called name in a temporary directory
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Expected no error writing %s, got %v", name, err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{
+			name: "yaml",
+			file: "seed.yaml",
+			content: `
+categories:
+  - categoryId: category-toys
+    name: Toys
+customers:
+  - customerId: customer-1
+    name: Fox Mulder
+    status: ACTIVE
+    creditLimit: 1000
+    segments: [vip]
+products:
+  - productId: product-1
+    name: Kite
+    price: 19.99
+    category: Toys
+    quantity: 3
+`,
+		},
+		{
+			name: "json",
+			file: "seed.json",
+			content: `{
+  "categories": [{"categoryId": "category-toys", "name": "Toys"}],
+  "customers": [{"customerId": "customer-1", "name": "Fox Mulder", "status": "ACTIVE", "creditLimit": 1000, "segments": ["vip"]}],
+  "products": [{"productId": "product-1", "name": "Kite", "price": 19.99, "category": "Toys", "quantity": 3}]
+}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			path := writeFile(t, tt.file, tt.content)
+
+			// Act
+			fixtures, err := Load(path)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if len(fixtures.Categories) != 1 || fixtures.Categories[0].Name != "Toys" {
+				t.Errorf("Expected the Toys category, got %+v", fixtures.Categories)
+			}
+			if len(fixtures.Customers) != 1 || fixtures.Customers[0].CreditLimit != 1000 || !fixtures.Customers[0].HasSegment("vip") {
+				t.Errorf("Expected customer-1 with its credit limit and segment, got %+v", fixtures.Customers)
+			}
+			if len(fixtures.Products) != 1 || fixtures.Products[0].Price != 19.99 || fixtures.Products[0].Quantity != 3 {
+				t.Errorf("Expected product-1 with its price and quantity, got %+v", fixtures.Products)
+			}
+		})
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		content  string
+		expected string
+	}{
+		{name: "unknown field", file: "seed.yaml", content: "customers:\n  - customerId: customer-1\n    nickname: Spooky\n", expected: "unknown field"},
+		{name: "missing id", file: "seed.json", content: `{"products": [{"name": "Kite"}]}`, expected: "product 1 has no productId"},
+		{name: "malformed", file: "seed.json", content: `{"customers": [`, expected: "failed to parse"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			path := writeFile(t, tt.file, tt.content)
+
+			// Act
+			_, err := Load(path)
+
+			// Assert
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected an error containing %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	// Arrange
+	categories := category.NewInMemoryRepositoryWith(nil)
+	customers := customer.NewInMemoryRepositoryWith(nil)
+	products := product.NewInMemoryRepositoryWith(nil)
+	fixtures := &Fixtures{
+		Categories: []*category.Category{{CategoryID: "category-toys", Name: "Toys"}},
+		Customers:  []*customer.Customer{{CustomerID: "customer-1", Name: "Fox Mulder", Status: "ACTIVE"}},
+		Products:   []*product.Product{{ProductID: "product-1", Name: "Kite", Price: 19.99, Category: "Toys"}},
+	}
+
+	// Act
+	first, err := Apply(fixtures, categories, customers, products)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	second, err := Apply(fixtures, categories, customers, products)
+	if err != nil {
+		t.Fatalf("Expected no error seeding again, got %v", err)
+	}
+
+	// Assert
+	if first.Created != 3 || first.Skipped != 0 {
+		t.Errorf("Expected 3 records created, got %+v", first)
+	}
+	if second.Created != 0 || second.Skipped != 3 {
+		t.Errorf("Expected 3 records skipped the second time, got %+v", second)
+	}
+	stored, err := products.GetByID("product-1")
+	if err != nil {
+		t.Fatalf("Expected product-1 to be stored, got %v", err)
+	}
+	if stored.Currency != currency.DefaultCode || stored.CreatedAt.IsZero() || !stored.UpdatedAt.Equal(stored.CreatedAt) {
+		t.Errorf("Expected the default currency and creation time to be filled in, got %+v", stored)
+	}
+}
@@ -0,0 +1,127 @@
+// Package servertiming exposes per-request phase durations through the
+// Server-Timing response header so clients and browser dev tools can see
+// where request latency is spent.
+//
+// The middleware attaches a recorder to the Echo context; handlers measure
+// their phases with Start. When the middleware is disabled, or not installed,
+// Start is a no-op, so handlers can be instrumented unconditionally.
+//
+// Example usage:
+//
+//	e.Use(servertiming.Middleware(true))
+//
+//	func (h *Handler) GetProduct(c echo.Context) error {
+//		stop := servertiming.Start(c, "service")
+//		product, err := h.service.GetProduct(c.Param("id"))
+//		stop()
+//		...
+//	}
+package servertiming
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// HeaderServerTiming is the name of the Server-Timing response header
+const HeaderServerTiming = "Server-Timing"
+
+// TotalMetric is the name of the metric covering the whole request
+const TotalMetric = "total"
+
+// contextKey is the Echo context key holding the request's recorder
+const contextKey = "servertiming.recorder"
+
+// metric is a single named duration
+type metric struct {
+	name     string
+	duration time.Duration
+}
+
+// recorder collects the phase durations of one request
+type recorder struct {
+	mutex   sync.Mutex
+	metrics []metric
+}
+
+// add records a phase duration; repeated phases are summed
+func (r *recorder) add(name string, duration time.Duration) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for i := range r.metrics {
+		if r.metrics[i].name == name {
+			r.metrics[i].duration += duration
+			return
+		}
+	}
+	r.metrics = append(r.metrics, metric{name: name, duration: duration})
+}
+
+// header renders the recorded metrics as a Server-Timing header value
+func (r *recorder) header(total time.Duration) string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	parts := make([]string, 0, len(r.metrics)+1)
+	for _, m := range r.metrics {
+		parts = append(parts, formatMetric(m.name, m.duration))
+	}
+	parts = append(parts, formatMetric(TotalMetric, total))
+	return strings.Join(parts, ", ")
+}
+
+// formatMetric renders a metric with its duration in milliseconds
+func formatMetric(name string, duration time.Duration) string {
+	return fmt.Sprintf("%s;dur=%.3f", name, float64(duration.Microseconds())/1000)
+}
+
+// Middleware returns a middleware that adds a Server-Timing header to every
+// response. Timings reveal internal behavior, so the header is only emitted
+// when enabled is true.
+func Middleware(enabled bool) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		if !enabled {
+			return next
+		}
+
+		return func(c echo.Context) error {
+			start := time.Now()
+			rec := &recorder{}
+			c.Set(contextKey, rec)
+
+			c.Response().Before(func() {
+				c.Response().Header().Set(HeaderServerTiming, rec.header(time.Since(start)))
+			})
+
+			return next(c)
+		}
+	}
+}
+
+// Start begins timing a phase and returns a function that ends it.
+//
+// The phase must be ended before the response is written for it to appear
+// in the header.
+func Start(c echo.Context, name string) func() {
+	rec, ok := c.Get(contextKey).(*recorder)
+	if !ok {
+		return func() {}
+	}
+
+	start := time.Now()
+	return func() {
+		rec.add(name, time.Since(start))
+	}
+}
+
+// Measure runs fn as a timed phase and returns its error.
+func Measure(c echo.Context, name string, fn func() error) error {
+	stop := Start(c, name)
+	defer stop()
+	return fn()
+}
@@ -0,0 +1,72 @@
+package servertiming
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func newTestServer(enabled bool) *echo.Echo {
+	e := echo.New()
+	e.Use(Middleware(enabled))
+	e.POST("/items", func(c echo.Context) error {
+		var body map[string]string
+		if err := Measure(c, "bind", func() error { return c.Bind(&body) }); err != nil {
+			return err
+		}
+
+		stop := Start(c, "service")
+		stop()
+
+		return c.JSON(http.StatusCreated, body)
+	})
+	return e
+}
+
+func TestMiddleware_Enabled(t *testing.T) {
+	// Arrange
+	e := newTestServer(true)
+	req := httptest.NewRequest(http.MethodPost, "/items", strings.NewReader(`{"name":"mug"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+
+	// Act
+	e.ServeHTTP(rec, req)
+
+	// Assert
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d", rec.Code)
+	}
+
+	header := rec.Header().Get(HeaderServerTiming)
+	segments := strings.Split(header, ", ")
+	expected := []string{"bind;dur=", "service;dur=", "total;dur="}
+	if len(segments) != len(expected) {
+		t.Fatalf("Expected %d timing segments, got %q", len(expected), header)
+	}
+
+	for i, prefix := range expected {
+		if !strings.HasPrefix(segments[i], prefix) {
+			t.Errorf("Expected segment %d to start with %q, got %q", i, prefix, segments[i])
+		}
+	}
+}
+
+func TestMiddleware_Disabled(t *testing.T) {
+	// Arrange
+	e := newTestServer(false)
+	req := httptest.NewRequest(http.MethodPost, "/items", strings.NewReader(`{"name":"mug"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+
+	// Act
+	e.ServeHTTP(rec, req)
+
+	// Assert
+	if header := rec.Header().Get(HeaderServerTiming); header != "" {
+		t.Errorf("Expected no Server-Timing header, got %q", header)
+	}
+}
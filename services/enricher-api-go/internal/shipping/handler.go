@@ -0,0 +1,63 @@
+package shipping
+
+import (
+	"net/http"
+
+	"enricher-api-go/internal/problem"
+	"enricher-api-go/internal/servertiming"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Handler handles HTTP requests for shipping estimates
+type Handler struct {
+	service Service
+}
+
+// NewHandler creates a new shipping handler
+func NewHandler(service Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+// Estimate handles POST /v1/shipping/estimate
+//
+// Example request:
+//
+//	POST /v1/shipping/estimate
+//	Content-Type: application/json
+//
+//	{
+//		"customerId": "customer-123",
+//		"items": [{"productId": "product-123", "quantity": 2}]
+//	}
+//
+// Example response:
+//
+//	{
+//		"customerId": "customer-123",
+//		"addressId": "address-1",
+//		"shipment": {"destination": {"country": "US"}, "weightKg": 3.6, "volumetricWeightKg": 1.05, "billableWeightKg": 3.6},
+//		"options": [{"carrier": "ground", "service": "standard", "cost": 8.96, "minDays": 3, "maxDays": 5}]
+//	}
+//
+// Error responses:
+//   - 400: Invalid items, no shipping address or a product without a weight
+//   - 404: Unknown customer or product
+//   - 503: No carrier could rate the shipment
+func (h *Handler) Estimate(c echo.Context) error {
+	var req EstimateRequest
+	if err := servertiming.Measure(c, "bind", func() error { return c.Bind(&req) }); err != nil {
+		return problem.BindError(c, err)
+	}
+
+	stop := servertiming.Start(c, "service")
+	estimate, err := h.service.Estimate(c.Request().Context(), req)
+	stop()
+	if err != nil {
+		return problem.Error(c, err)
+	}
+
+	return c.JSON(http.StatusOK, estimate)
+}
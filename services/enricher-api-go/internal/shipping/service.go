@@ -0,0 +1,178 @@
+package shipping
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"enricher-api-go/internal/customer"
+	"enricher-api-go/internal/logging"
+	"enricher-api-go/internal/product"
+	"enricher-api-go/internal/validation"
+)
+
+// Service defines the business logic interface for shipping estimates
+type Service interface {
+	Estimate(ctx context.Context, req EstimateRequest) (*Estimate, error)
+}
+
+// ShippingService estimates shipping from the customer and product services
+// and prices it with its rate providers
+type ShippingService struct {
+	customers customer.Service
+	products  product.Service
+	providers []RateProvider
+}
+
+// NewService creates a new shipping service asking every provider for rates
+func NewService(customers customer.Service, products product.Service, providers ...RateProvider) *ShippingService {
+	return &ShippingService{
+		customers: customers,
+		products:  products,
+		providers: providers,
+	}
+}
+
+// Estimate weighs the request's items and prices shipping them to the
+// customer's shipping address.
+//
+// Every item's product needs a shipping weight; products with dimensions
+// also count toward the volumetric weight. The providers are asked
+// concurrently and a failing provider is logged and left out; the estimate
+// fails with ErrUnavailable only when none of them answers.
+func (s *ShippingService) Estimate(ctx context.Context, req EstimateRequest) (*Estimate, error) {
+	logger := logging.FromContext(ctx)
+	logger.Info("Estimating shipping", "customer_id", req.CustomerID, "items", len(req.Items))
+
+	if err := validation.Struct(&req); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	address, err := s.shippingAddress(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	shipment, err := s.weigh(ctx, req.Items)
+	if err != nil {
+		return nil, err
+	}
+	shipment.Destination = Destination{
+		Country:    address.Country,
+		Region:     address.Region,
+		PostalCode: address.PostalCode,
+		City:       address.City,
+	}
+
+	options, err := s.rate(ctx, shipment)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("Estimated shipping", "customer_id", req.CustomerID, "billable_weight_kg", shipment.BillableWeightKg, "options", len(options))
+	return &Estimate{CustomerID: req.CustomerID, AddressID: address.AddressID, Shipment: shipment, Options: options}, nil
+}
+
+// shippingAddress returns the shipping address named by the request, or the
+// customer's default shipping address when it names none
+func (s *ShippingService) shippingAddress(ctx context.Context, req EstimateRequest) (*customer.Address, error) {
+	if _, err := s.customers.GetCustomer(ctx, req.CustomerID); err != nil {
+		return nil, err
+	}
+
+	if req.ShippingAddressID == "" {
+		address, err := s.customers.DefaultAddress(ctx, req.CustomerID, customer.AddressShipping)
+		if errors.Is(err, customer.ErrAddressNotFound) {
+			return nil, fmt.Errorf("%w: customer %s has no shipping address", ErrInvalidEstimate, req.CustomerID)
+		}
+		return address, err
+	}
+
+	address, err := s.customers.GetAddress(ctx, req.CustomerID, req.ShippingAddressID)
+	if errors.Is(err, customer.ErrAddressNotFound) {
+		return nil, fmt.Errorf("%w: unknown shipping address %s", ErrInvalidEstimate, req.ShippingAddressID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if address.Type != customer.AddressShipping {
+		return nil, fmt.Errorf("%w: address %s is not a shipping address", ErrInvalidEstimate, req.ShippingAddressID)
+	}
+	return address, nil
+}
+
+// weigh returns the shipment of items, without its destination
+func (s *ShippingService) weigh(ctx context.Context, items []Item) (Shipment, error) {
+	var shipment Shipment
+	for _, item := range items {
+		p, err := s.products.GetProduct(ctx, item.ProductID)
+		if err != nil {
+			return Shipment{}, err
+		}
+		if p.Weight == nil {
+			return Shipment{}, fmt.Errorf("%w: product %s has no shipping weight", ErrInvalidEstimate, item.ProductID)
+		}
+
+		kg, err := p.Weight.Kilograms()
+		if err != nil {
+			return Shipment{}, err
+		}
+		shipment.WeightKg += kg * float64(item.Quantity)
+
+		if p.Dimensions != nil {
+			cm, err := p.Dimensions.Centimeters()
+			if err != nil {
+				return Shipment{}, err
+			}
+			shipment.VolumetricWeightKg += cm[0] * cm[1] * cm[2] / VolumetricDivisor * float64(item.Quantity)
+		}
+	}
+
+	shipment.WeightKg = roundWeight(shipment.WeightKg)
+	shipment.VolumetricWeightKg = roundWeight(shipment.VolumetricWeightKg)
+	shipment.BillableWeightKg = max(shipment.WeightKg, shipment.VolumetricWeightKg)
+	return shipment, nil
+}
+
+// rate asks every provider for options concurrently and returns them
+// cheapest first, then fastest
+func (s *ShippingService) rate(ctx context.Context, shipment Shipment) ([]Option, error) {
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		options  []Option
+		answered int
+	)
+	for _, provider := range s.providers {
+		wg.Add(1)
+		go func(provider RateProvider) {
+			defer wg.Done()
+			rates, err := provider.Rates(ctx, shipment)
+			if err != nil {
+				logging.FromContext(ctx).Warn("Estimating shipping without a carrier", "provider", fmt.Sprintf("%T", provider), "error", err)
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			options = append(options, rates...)
+			answered++
+		}(provider)
+	}
+	wg.Wait()
+
+	if answered == 0 {
+		return nil, fmt.Errorf("%w: no carrier rated the shipment", ErrUnavailable)
+	}
+
+	slices.SortStableFunc(options, func(a, b Option) int {
+		return cmp.Or(cmp.Compare(a.Cost, b.Cost), cmp.Compare(a.MaxDays, b.MaxDays))
+	})
+	if options == nil {
+		options = []Option{}
+	}
+	return options, nil
+}
@@ -0,0 +1,170 @@
+package shipping
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"enricher-api-go/internal/apperr"
+	"enricher-api-go/internal/customer"
+	"enricher-api-go/internal/product"
+	"enricher-api-go/internal/validation"
+)
+
+// failingProvider is a RateProvider whose carrier cannot be reached
+type failingProvider struct{}
+
+func (failingProvider) Rates(context.Context, Shipment) ([]Option, error) {
+	return nil, errors.New("carrier unreachable")
+}
+
+// testFixture holds services with a customer-456 shipping to the US and a
+// boxed product weighing 350 g
+type testFixture struct {
+	customers customer.Service
+	products  product.Service
+	boxID     string
+}
+
+func newTestFixture(t *testing.T) testFixture {
+	t.Helper()
+	ctx := context.Background()
+	customers := customer.NewService(customer.NewInMemoryRepository())
+	if _, err := customers.CreateAddress(ctx, "customer-456", customer.AddressRequest{
+		Type: customer.AddressShipping, Line1: "1 Market St", City: "San Francisco", Region: "CA", PostalCode: "94105", Country: "US",
+	}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	products := product.NewService(product.NewInMemoryRepository())
+	box, err := products.CreateProduct(ctx, product.ProductRequest{
+		Name:        "Shipping Box",
+		Description: "Corrugated box for parcel shipments",
+		Price:       4.99,
+		Category:    "Packaging",
+		Quantity:    10,
+		Weight:      &product.Weight{Value: 350, Unit: product.WeightUnitGram},
+		Dimensions:  &product.Dimensions{Length: 30, Width: 20, Height: 10},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return testFixture{customers: customers, products: products, boxID: box.ProductID}
+}
+
+func TestTableRate_Rates(t *testing.T) {
+	carrier := NewTableRate("ground", "us", DefaultDomesticRates, DefaultInternationalRates)
+
+	tests := []struct {
+		name      string
+		shipment  Shipment
+		wantCosts []float64
+	}{
+		{name: "domestic first kilogram", shipment: Shipment{Destination: Destination{Country: "US"}, BillableWeightKg: 0.4}, wantCosts: []float64{5.99, 14.99}},
+		{name: "domestic started kilograms", shipment: Shipment{Destination: Destination{Country: "US"}, BillableWeightKg: 2.4}, wantCosts: []float64{7.97, 18.97}},
+		{name: "international", shipment: Shipment{Destination: Destination{Country: "FR"}, BillableWeightKg: 2.4}, wantCosts: []float64{29.97}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			options, err := carrier.Rates(context.Background(), tt.shipment)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if len(options) != len(tt.wantCosts) {
+				t.Fatalf("Expected %d options, got %+v", len(tt.wantCosts), options)
+			}
+			for i, option := range options {
+				if option.Carrier != "ground" || option.Cost != tt.wantCosts[i] {
+					t.Errorf("Expected ground at %v, got %+v", tt.wantCosts[i], option)
+				}
+			}
+		})
+	}
+}
+
+func TestShippingService_Estimate(t *testing.T) {
+	// Arrange
+	fixture := newTestFixture(t)
+	service := NewService(fixture.customers, fixture.products,
+		NewTableRate("ground", "US", DefaultDomesticRates, DefaultInternationalRates),
+		NewTableRate("air", "US", []Rate{{Service: "overnight", Base: 29.99, PerKg: 5, MinDays: 1, MaxDays: 1}}, nil),
+		failingProvider{})
+
+	// Act
+	estimate, err := service.Estimate(context.Background(), EstimateRequest{
+		CustomerID: "customer-456",
+		Items:      []Item{{ProductID: fixture.boxID, Quantity: 2}},
+	})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if estimate.AddressID == "" || estimate.Shipment.Destination.Country != "US" || estimate.Shipment.Destination.Region != "CA" {
+		t.Errorf("Expected the default shipping address, got %+v", estimate)
+	}
+	if estimate.Shipment.WeightKg != 0.7 || estimate.Shipment.VolumetricWeightKg != 2.4 || estimate.Shipment.BillableWeightKg != 2.4 {
+		t.Errorf("Expected 0.7 kg billed at the volumetric 2.4 kg, got %+v", estimate.Shipment)
+	}
+	wantServices := []string{"standard", "express", "overnight"}
+	if len(estimate.Options) != len(wantServices) {
+		t.Fatalf("Expected %d options from the answering carriers, got %+v", len(wantServices), estimate.Options)
+	}
+	for i, option := range estimate.Options {
+		if option.Service != wantServices[i] {
+			t.Errorf("Expected option %d to be %s, cheapest first, got %+v", i, wantServices[i], option)
+		}
+	}
+}
+
+func TestShippingService_Estimate_Errors(t *testing.T) {
+	fixture := newTestFixture(t)
+	billing, err := fixture.customers.CreateAddress(context.Background(), "customer-456", customer.AddressRequest{
+		Type: customer.AddressBilling, Line1: "1 Market St", City: "San Francisco", PostalCode: "94105", Country: "US",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	carrier := NewTableRate("ground", "US", DefaultDomesticRates, DefaultInternationalRates)
+
+	tests := []struct {
+		name        string
+		providers   []RateProvider
+		req         EstimateRequest
+		wantErr     error
+		wantInvalid bool
+	}{
+		{name: "no items", providers: []RateProvider{carrier}, req: EstimateRequest{CustomerID: "customer-456"}, wantInvalid: true},
+		{name: "unknown customer", providers: []RateProvider{carrier}, req: EstimateRequest{CustomerID: "customer-missing", Items: []Item{{ProductID: fixture.boxID, Quantity: 1}}}, wantErr: apperr.ErrNotFound},
+		{name: "no shipping address", providers: []RateProvider{carrier}, req: EstimateRequest{CustomerID: "customer-123", Items: []Item{{ProductID: fixture.boxID, Quantity: 1}}}, wantErr: ErrInvalidEstimate},
+		{name: "billing address", providers: []RateProvider{carrier}, req: EstimateRequest{CustomerID: "customer-456", ShippingAddressID: billing.AddressID, Items: []Item{{ProductID: fixture.boxID, Quantity: 1}}}, wantErr: ErrInvalidEstimate},
+		{name: "unknown product", providers: []RateProvider{carrier}, req: EstimateRequest{CustomerID: "customer-456", Items: []Item{{ProductID: "product-missing", Quantity: 1}}}, wantErr: apperr.ErrNotFound},
+		{name: "product without weight", providers: []RateProvider{carrier}, req: EstimateRequest{CustomerID: "customer-456", Items: []Item{{ProductID: "product-123", Quantity: 1}}}, wantErr: ErrInvalidEstimate},
+		{name: "no carrier answers", providers: []RateProvider{failingProvider{}}, req: EstimateRequest{CustomerID: "customer-456", Items: []Item{{ProductID: fixture.boxID, Quantity: 1}}}, wantErr: ErrUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			service := NewService(fixture.customers, fixture.products, tt.providers...)
+
+			// Act
+			_, err := service.Estimate(context.Background(), tt.req)
+
+			// Assert
+			if tt.wantInvalid {
+				var validationErr *validation.Error
+				if !errors.As(err, &validationErr) {
+					t.Errorf("Expected a validation error, got %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+// Package shipping estimates the cost of shipping order lines to a customer
+// for the Resilient Order Enricher API.
+//
+// Estimates are built from the products' shipping weight and package size
+// and priced by pluggable RateProviders, one per carrier. TableRate prices
+// from fixed per-service rate tables.
+package shipping
+
+import (
+	"context"
+	"math"
+
+	"enricher-api-go/internal/apperr"
+)
+
+// VolumetricDivisor converts a package volume in cubic centimeters to the
+// weight in kilograms carriers bill for bulky, light parcels
+const VolumetricDivisor = 5000.0
+
+var (
+	// ErrInvalidEstimate is returned when an estimate request cannot be
+	// priced, such as one for a product without a shipping weight
+	ErrInvalidEstimate = apperr.New(apperr.ErrValidation, "invalid shipping estimate request")
+	// ErrUnavailable is returned when no carrier can rate the shipment
+	ErrUnavailable = apperr.New(apperr.ErrUnavailable, "shipping rates unavailable")
+)
+
+// RateProvider rates a shipment for one carrier. Implementations may call a
+// remote service, so Rates takes a context; a provider that cannot answer
+// returns an error and the estimate goes on with the other providers.
+type RateProvider interface {
+	Rates(ctx context.Context, shipment Shipment) ([]Option, error)
+}
+
+// Destination is where the shipment goes
+type Destination struct {
+	// Country is the ISO 3166-1 alpha-2 country code
+	Country    string `json:"country"`
+	Region     string `json:"region,omitempty"`
+	PostalCode string `json:"postalCode,omitempty"`
+	City       string `json:"city,omitempty"`
+}
+
+// Shipment is what a RateProvider prices
+type Shipment struct {
+	Destination Destination `json:"destination"`
+	// WeightKg is the actual weight of the order lines
+	WeightKg float64 `json:"weightKg"`
+	// VolumetricWeightKg is the weight of the lines' package volume, for the
+	// lines with dimensions
+	VolumetricWeightKg float64 `json:"volumetricWeightKg"`
+	// BillableWeightKg is the greater of the actual and volumetric weights
+	BillableWeightKg float64 `json:"billableWeightKg"`
+}
+
+// Option is one way to ship the shipment
+type Option struct {
+	Carrier string `json:"carrier"`
+	// Service is the carrier's service level, such as "standard"
+	Service string  `json:"service"`
+	Cost    float64 `json:"cost"`
+	// MinDays and MaxDays bound the delivery time in business days
+	MinDays int `json:"minDays"`
+	MaxDays int `json:"maxDays"`
+}
+
+// Item is an order line to ship
+type Item struct {
+	ProductID string `json:"productId" validate:"required"`
+	Quantity  int    `json:"quantity" validate:"gt=0"`
+}
+
+// EstimateRequest is the request body of POST /v1/shipping/estimate.
+//
+// Example usage:
+//
+//	req := EstimateRequest{
+//		CustomerID: "customer-123",
+//		Items:      []Item{{ProductID: "product-123", Quantity: 2}},
+//	}
+type EstimateRequest struct {
+	CustomerID string `json:"customerId" validate:"required"`
+	// ShippingAddressID names one of the customer's shipping addresses;
+	// empty uses the customer's default shipping address
+	ShippingAddressID string `json:"shippingAddressId,omitempty"`
+	// Items are the order lines (at least one)
+	Items []Item `json:"items" validate:"required,min=1,dive"`
+}
+
+// Estimate is the shipment of an EstimateRequest and its shipping options,
+// cheapest first
+type Estimate struct {
+	CustomerID string   `json:"customerId"`
+	AddressID  string   `json:"addressId"`
+	Shipment   Shipment `json:"shipment"`
+	Options    []Option `json:"options"`
+}
+
+// roundAmount rounds an amount to cents
+func roundAmount(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// roundWeight rounds a weight to grams
+func roundWeight(kg float64) float64 {
+	return math.Round(kg*1000) / 1000
+}
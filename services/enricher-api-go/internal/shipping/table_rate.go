@@ -0,0 +1,78 @@
+package shipping
+
+import (
+	"context"
+	"math"
+	"strings"
+)
+
+// Rate prices one service level of a TableRate carrier
+type Rate struct {
+	Service string
+	// Base is the cost of the first kilogram
+	Base float64
+	// PerKg is the cost of every further started kilogram
+	PerKg   float64
+	MinDays int
+	MaxDays int
+}
+
+// DefaultDomesticRates are the service levels of a carrier shipping within
+// its origin country
+var DefaultDomesticRates = []Rate{
+	{Service: "standard", Base: 5.99, PerKg: 0.99, MinDays: 3, MaxDays: 5},
+	{Service: "express", Base: 14.99, PerKg: 1.99, MinDays: 1, MaxDays: 2},
+}
+
+// DefaultInternationalRates are the service levels of a carrier shipping
+// outside its origin country
+var DefaultInternationalRates = []Rate{
+	{Service: "international", Base: 19.99, PerKg: 4.99, MinDays: 6, MaxDays: 12},
+}
+
+// TableRate is a RateProvider pricing shipments from fixed rate tables: one
+// for destinations in its origin country and one for the rest of the world.
+//
+// Example usage:
+//
+//	carrier := NewTableRate("ground", "US", DefaultDomesticRates, DefaultInternationalRates)
+//	options, err := carrier.Rates(ctx, shipment)
+type TableRate struct {
+	carrier       string
+	origin        string
+	domestic      []Rate
+	international []Rate
+}
+
+// NewTableRate creates a provider named carrier shipping from the origin
+// country code
+func NewTableRate(carrier, origin string, domestic, international []Rate) *TableRate {
+	return &TableRate{
+		carrier:       carrier,
+		origin:        strings.ToUpper(strings.TrimSpace(origin)),
+		domestic:      domestic,
+		international: international,
+	}
+}
+
+// Rates prices the shipment's billable weight, rounded up to the kilogram,
+// at every service level serving its destination
+func (t *TableRate) Rates(_ context.Context, shipment Shipment) ([]Option, error) {
+	rates := t.international
+	if strings.EqualFold(shipment.Destination.Country, t.origin) {
+		rates = t.domestic
+	}
+
+	extraKg := math.Max(math.Ceil(shipment.BillableWeightKg)-1, 0)
+	options := make([]Option, 0, len(rates))
+	for _, rate := range rates {
+		options = append(options, Option{
+			Carrier: t.carrier,
+			Service: rate.Service,
+			Cost:    roundAmount(rate.Base + rate.PerKg*extraKg),
+			MinDays: rate.MinDays,
+			MaxDays: rate.MaxDays,
+		})
+	}
+	return options, nil
+}
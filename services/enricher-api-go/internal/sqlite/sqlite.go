@@ -0,0 +1,77 @@
+// Package sqlite opens the SQLite databases of the sqlite storage backend,
+// which keeps single-binary deployments durable without a database server.
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// InMemory is the path of a database that lives only as long as the process
+const InMemory = ":memory:"
+
+// Open opens the SQLite database file at path, creating it if needed, or a
+// private in-memory database for InMemory.
+//
+// Foreign keys are enforced, transactions take the write lock as they begin
+// and the pool holds a single connection, so writes are serialized and an
+// in-memory database is shared by every query.
+func Open(path string) (*sql.DB, error) {
+	if path == "" {
+		return nil, errors.New("sqlite database path is required")
+	}
+
+	params := url.Values{}
+	params.Set("_foreign_keys", "on")
+	params.Set("_busy_timeout", "5000")
+	params.Set("_txlock", "immediate")
+	dsn := "file:" + path + "?" + params.Encode()
+	if path == InMemory {
+		dsn = "file::memory:?" + params.Encode()
+	}
+
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite database %s: %w", path, err)
+	}
+	db.SetMaxOpenConns(1)
+	db.SetMaxIdleConns(1)
+	db.SetConnMaxLifetime(0)
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open sqlite database %s: %w", path, err)
+	}
+	return db, nil
+}
+
+// IsUniqueViolation reports whether err is a SQLite unique or primary key
+// constraint violation
+func IsUniqueViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) &&
+		(sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique || sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey)
+}
+
+// IsUniqueViolationOf reports whether err is a unique constraint violation
+// on column, named as table.column
+func IsUniqueViolationOf(err error, column string) bool {
+	return IsUniqueViolation(err) && strings.Contains(err.Error(), column)
+}
+
+// Placeholders returns n comma-separated parameter placeholders, for IN lists
+func Placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+// Time converts t to UTC so that stored timestamps, kept as text, compare in
+// time order
+func Time(t time.Time) time.Time {
+	return t.UTC()
+}
@@ -0,0 +1,168 @@
+package tax
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"enricher-api-go/internal/apperr"
+)
+
+func testRequest(country, region string) QuoteRequest {
+	return QuoteRequest{
+		Address: Address{Country: country, Region: region},
+		Lines: []Line{
+			{ProductID: "product-123", UnitPrice: 19.99, Quantity: 2},
+			{ProductID: "product-456", UnitPrice: 5, Quantity: 1},
+		},
+	}
+}
+
+func TestFlatRate_Quote(t *testing.T) {
+	calculator := NewFlatRate(0.05, map[string]float64{"us-ca": 0.0725, "DE": 0.19})
+
+	tests := []struct {
+		name             string
+		country, region  string
+		wantJurisdiction string
+		wantRate         float64
+		wantTotal        float64
+	}{
+		{name: "region rate", country: "US", region: "CA", wantJurisdiction: "US-CA", wantRate: 0.0725, wantTotal: 3.26},
+		{name: "country rate", country: "de", region: "BY", wantJurisdiction: "DE", wantRate: 0.19, wantTotal: 8.55},
+		{name: "default rate", country: "FR", wantJurisdiction: "FR", wantRate: 0.05, wantTotal: 2.25},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			quote, err := calculator.Quote(context.Background(), testRequest(tt.country, tt.region))
+
+			// Assert
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if quote.Jurisdiction != tt.wantJurisdiction {
+				t.Errorf("Expected jurisdiction %s, got %s", tt.wantJurisdiction, quote.Jurisdiction)
+			}
+			if len(quote.Lines) != 2 || quote.Lines[0].Rate != tt.wantRate || quote.Lines[0].Amount != 39.98 {
+				t.Errorf("Expected two lines at rate %v, got %+v", tt.wantRate, quote.Lines)
+			}
+			if quote.TotalTax != tt.wantTotal {
+				t.Errorf("Expected total tax %v, got %v", tt.wantTotal, quote.TotalTax)
+			}
+		})
+	}
+}
+
+func TestFlatRate_Quote_WithoutCountry(t *testing.T) {
+	// Act
+	_, err := NewFlatRate(0.05, nil).Quote(context.Background(), testRequest("", "CA"))
+
+	// Assert
+	if !errors.Is(err, ErrInvalidQuote) || !errors.Is(err, apperr.ErrValidation) {
+		t.Errorf("Expected ErrInvalidQuote, got %v", err)
+	}
+}
+
+func TestHTTPCalculator_Quote(t *testing.T) {
+	// Arrange
+	var gotAuth string
+	var gotReq QuoteRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		_ = json.NewDecoder(r.Body).Decode(&gotReq)
+		_ = json.NewEncoder(w).Encode(Quote{
+			Jurisdiction: "US-CA",
+			Lines:        []LineTax{{ProductID: "product-123", Amount: 39.98, Rate: 0.0725, Tax: 2.9}, {ProductID: "product-456", Amount: 5, Rate: 0, Tax: 0}},
+			TotalTax:     2.9,
+		})
+	}))
+	defer server.Close()
+	calculator := NewHTTPCalculator(server.URL, "secret", server.Client())
+
+	// Act
+	quote, err := calculator.Quote(context.Background(), testRequest("US", "CA"))
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if gotAuth != "Bearer secret" {
+		t.Errorf("Expected the API key as a bearer token, got %q", gotAuth)
+	}
+	if gotReq.Address.Region != "CA" || len(gotReq.Lines) != 2 {
+		t.Errorf("Expected the quote request to be posted, got %+v", gotReq)
+	}
+	if quote.Jurisdiction != "US-CA" || quote.TotalTax != 2.9 || len(quote.Lines) != 2 {
+		t.Errorf("Expected the service's quote, got %+v", quote)
+	}
+}
+
+func TestHTTPCalculator_Quote_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		wantErr error
+	}{
+		{
+			name:    "server error",
+			handler: func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusBadGateway) },
+			wantErr: ErrUnavailable,
+		},
+		{
+			name: "rejected request",
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				http.Error(w, "unknown postal code", http.StatusUnprocessableEntity)
+			},
+			wantErr: ErrInvalidQuote,
+		},
+		{
+			name:    "unreadable quote",
+			handler: func(w http.ResponseWriter, _ *http.Request) { _, _ = w.Write([]byte("not json")) },
+			wantErr: ErrUnavailable,
+		},
+		{
+			name: "missing lines",
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				_ = json.NewEncoder(w).Encode(Quote{Lines: []LineTax{{ProductID: "product-123"}}})
+			},
+			wantErr: ErrUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			server := httptest.NewServer(tt.handler)
+			defer server.Close()
+			calculator := NewHTTPCalculator(server.URL, "", server.Client())
+
+			// Act
+			_, err := calculator.Quote(context.Background(), testRequest("US", "CA"))
+
+			// Assert
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestHTTPCalculator_Quote_Unreachable(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	// Act
+	_, err := NewHTTPCalculator(url, "", nil).Quote(context.Background(), testRequest("US", "CA"))
+
+	// Assert
+	if !errors.Is(err, ErrUnavailable) || !errors.Is(err, apperr.ErrUnavailable) {
+		t.Errorf("Expected ErrUnavailable, got %v", err)
+	}
+}
@@ -0,0 +1,57 @@
+package tax
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// FlatRate is a Calculator applying one rate per jurisdiction to every line,
+// such as rates loaded from configuration.
+//
+// Example usage:
+//
+//	calculator := NewFlatRate(0, map[string]float64{"US-CA": 0.0725, "DE": 0.19})
+//	quote, err := calculator.Quote(ctx, req) // 7.25% in California, 19% in Germany
+type FlatRate struct {
+	defaultRate float64
+	rates       map[string]float64
+}
+
+// NewFlatRate creates a calculator where rates maps a COUNTRY-REGION code,
+// such as "US-CA", or a COUNTRY code, such as "DE", to its rate. Addresses
+// in neither are taxed at defaultRate.
+func NewFlatRate(defaultRate float64, rates map[string]float64) *FlatRate {
+	table := make(map[string]float64, len(rates))
+	for code, rate := range rates {
+		table[strings.ToUpper(strings.TrimSpace(code))] = rate
+	}
+	return &FlatRate{defaultRate: defaultRate, rates: table}
+}
+
+// Quote taxes every line at the rate of the address's region, else of its
+// country, else at the default rate
+func (f *FlatRate) Quote(_ context.Context, req QuoteRequest) (*Quote, error) {
+	if strings.TrimSpace(req.Address.Country) == "" {
+		return nil, fmt.Errorf("%w: address country is required", ErrInvalidQuote)
+	}
+
+	code := jurisdiction(req.Address)
+	rate, ok := f.rates[code]
+	if !ok {
+		code = strings.ToUpper(strings.TrimSpace(req.Address.Country))
+		if rate, ok = f.rates[code]; !ok {
+			rate = f.defaultRate
+		}
+	}
+
+	quote := &Quote{Jurisdiction: code, Lines: make([]LineTax, 0, len(req.Lines))}
+	for _, line := range req.Lines {
+		amount := line.Amount()
+		lineTax := LineTax{ProductID: line.ProductID, Amount: amount, Rate: rate, Tax: roundAmount(amount * rate)}
+		quote.Lines = append(quote.Lines, lineTax)
+		quote.TotalTax += lineTax.Tax
+	}
+	quote.TotalTax = roundAmount(quote.TotalTax)
+	return quote, nil
+}
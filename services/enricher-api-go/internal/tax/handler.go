@@ -0,0 +1,66 @@
+package tax
+
+import (
+	"fmt"
+	"net/http"
+
+	"enricher-api-go/internal/problem"
+	"enricher-api-go/internal/servertiming"
+	"enricher-api-go/internal/validation"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Handler handles HTTP requests for tax quotes
+type Handler struct {
+	calculator Calculator
+}
+
+// NewHandler creates a new tax handler quoting with calculator
+func NewHandler(calculator Calculator) *Handler {
+	return &Handler{
+		calculator: calculator,
+	}
+}
+
+// Quote handles POST /v1/tax/quote
+//
+// Example request:
+//
+//	POST /v1/tax/quote
+//	Content-Type: application/json
+//
+//	{
+//		"address": {"country": "US", "region": "CA", "postalCode": "94105"},
+//		"lines": [{"productId": "product-123", "unitPrice": 19.99, "quantity": 2}]
+//	}
+//
+// Example response:
+//
+//	{
+//		"jurisdiction": "US-CA",
+//		"lines": [{"productId": "product-123", "amount": 39.98, "rate": 0.0725, "tax": 2.9}],
+//		"totalTax": 2.9
+//	}
+//
+// Error responses:
+//   - 400: Invalid address or lines
+//   - 503: The tax service is unavailable
+func (h *Handler) Quote(c echo.Context) error {
+	var req QuoteRequest
+	if err := servertiming.Measure(c, "bind", func() error { return c.Bind(&req) }); err != nil {
+		return problem.BindError(c, err)
+	}
+	if err := validation.Struct(&req); err != nil {
+		return problem.Error(c, fmt.Errorf("validation failed: %w", err))
+	}
+
+	stop := servertiming.Start(c, "service")
+	quote, err := h.calculator.Quote(c.Request().Context(), req)
+	stop()
+	if err != nil {
+		return problem.Error(c, err)
+	}
+
+	return c.JSON(http.StatusOK, quote)
+}
@@ -0,0 +1,79 @@
+package tax
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// maxResponseBytes bounds the quote read from the tax service
+const maxResponseBytes = 1 << 20
+
+// HTTPCalculator is a Calculator asking an external tax service.
+//
+// The service receives the QuoteRequest as JSON in a POST to its URL and
+// answers 200 with a Quote holding one line per request line. A 4xx answer
+// is reported as ErrInvalidQuote; failing to reach the service, a 5xx answer
+// or an unreadable quote as ErrUnavailable. Providers with a different API
+// are put behind a small translating proxy.
+type HTTPCalculator struct {
+	url    string
+	apiKey string
+	client *http.Client
+}
+
+// NewHTTPCalculator creates a calculator posting to url. A non-empty apiKey
+// is sent as a bearer token; a nil client gets one with a 5 second timeout.
+func NewHTTPCalculator(url, apiKey string, client *http.Client) *HTTPCalculator {
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Second}
+	}
+	return &HTTPCalculator{url: url, apiKey: apiKey, client: client}
+}
+
+// Quote asks the tax service for the tax of req
+func (h *HTTPCalculator) Quote(ctx context.Context, req QuoteRequest) (*Quote, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode tax quote request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build tax quote request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "application/json")
+	if h.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+h.apiKey)
+	}
+
+	resp, err := h.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= http.StatusInternalServerError:
+		return nil, fmt.Errorf("%w: tax service answered %d", ErrUnavailable, resp.StatusCode)
+	case resp.StatusCode >= http.StatusBadRequest:
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("%w: tax service answered %d: %s", ErrInvalidQuote, resp.StatusCode, bytes.TrimSpace(detail))
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("%w: tax service answered %d", ErrUnavailable, resp.StatusCode)
+	}
+
+	var quote Quote
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseBytes)).Decode(&quote); err != nil {
+		return nil, fmt.Errorf("%w: failed to decode tax quote: %w", ErrUnavailable, err)
+	}
+	if len(quote.Lines) != len(req.Lines) {
+		return nil, fmt.Errorf("%w: tax quote has %d lines for %d requested", ErrUnavailable, len(quote.Lines), len(req.Lines))
+	}
+	return &quote, nil
+}
@@ -0,0 +1,105 @@
+// Package tax quotes the sales tax of order lines shipped to an address for
+// the Resilient Order Enricher API.
+//
+// Quotes come from a pluggable Calculator: FlatRate applies configured rates
+// per country or region, and HTTPCalculator asks an external tax service.
+package tax
+
+import (
+	"context"
+	"math"
+	"strings"
+
+	"enricher-api-go/internal/apperr"
+)
+
+var (
+	// ErrInvalidQuote is returned when a quote request cannot be priced, such
+	// as one without a country
+	ErrInvalidQuote = apperr.New(apperr.ErrValidation, "invalid tax quote request")
+	// ErrUnavailable is returned when the tax service cannot quote right now
+	ErrUnavailable = apperr.New(apperr.ErrUnavailable, "tax calculator unavailable")
+)
+
+// Calculator quotes the tax of order lines. Implementations may call a
+// remote service, so Quote takes a context; a service that cannot answer
+// is reported with an error wrapping ErrUnavailable.
+type Calculator interface {
+	Quote(ctx context.Context, req QuoteRequest) (*Quote, error)
+}
+
+// Address is where the lines ship to, which decides the tax that applies
+type Address struct {
+	// Country is the two-letter ISO 3166 country code
+	Country string `json:"country" validate:"required,len=2"`
+	// Region is the state or province, such as "CA"
+	Region     string `json:"region,omitempty"`
+	PostalCode string `json:"postalCode,omitempty"`
+	City       string `json:"city,omitempty"`
+}
+
+// Line is an order line to tax
+type Line struct {
+	ProductID string `json:"productId,omitempty"`
+	// Category is the product's category, for services taxing some
+	// categories differently
+	Category  string  `json:"category,omitempty"`
+	UnitPrice float64 `json:"unitPrice" validate:"gte=0"`
+	Quantity  int     `json:"quantity" validate:"gt=0"`
+}
+
+// Amount returns the line's taxable amount, rounded to cents
+func (l Line) Amount() float64 {
+	return roundAmount(l.UnitPrice * float64(l.Quantity))
+}
+
+// QuoteRequest is the request body of POST /v1/tax/quote.
+//
+// Example usage:
+//
+//	req := QuoteRequest{
+//		Address: Address{Country: "US", Region: "CA", PostalCode: "94105"},
+//		Lines:   []Line{{ProductID: "product-123", UnitPrice: 19.99, Quantity: 2}},
+//	}
+type QuoteRequest struct {
+	Address Address `json:"address"`
+	// Lines are the order lines (at least one)
+	Lines []Line `json:"lines" validate:"required,min=1,dive"`
+}
+
+// LineTax is the tax of one line of a quote
+type LineTax struct {
+	ProductID string `json:"productId,omitempty"`
+	// Amount is the taxable amount of the line
+	Amount float64 `json:"amount"`
+	// Rate is the rate applied, such as 0.0725 for 7.25%
+	Rate float64 `json:"rate"`
+	// Tax is the tax of the line, rounded to cents
+	Tax float64 `json:"tax"`
+}
+
+// Quote is the tax breakdown of a QuoteRequest, with its lines in request
+// order
+type Quote struct {
+	// Jurisdiction names where the tax applies, such as "US-CA"
+	Jurisdiction string    `json:"jurisdiction,omitempty"`
+	Lines        []LineTax `json:"lines"`
+	// TotalTax is the sum of the lines' tax
+	TotalTax float64 `json:"totalTax"`
+}
+
+// jurisdiction returns the COUNTRY-REGION code of address, or its COUNTRY
+// code when it has no region, upper-cased
+func jurisdiction(address Address) string {
+	country := strings.ToUpper(strings.TrimSpace(address.Country))
+	region := strings.ToUpper(strings.TrimSpace(address.Region))
+	if region == "" {
+		return country
+	}
+	return country + "-" + region
+}
+
+// roundAmount rounds an amount to cents
+func roundAmount(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
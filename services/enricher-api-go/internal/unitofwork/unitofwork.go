@@ -0,0 +1,133 @@
+// Package unitofwork groups writes to several repositories into one unit
+// that is applied as a whole or not at all.
+//
+// A unit runs with its Tx in the context. On SQL storage the Tx holds a
+// database transaction that repositories join, so the unit commits
+// atomically. Repositories that cannot join it, and every repository under
+// Compensating, apply their writes right away and register a compensation
+// undoing them; when the unit fails those run newest first, on a best-effort
+// basis.
+package unitofwork
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"enricher-api-go/internal/logging"
+)
+
+// UnitOfWork runs units of work
+type UnitOfWork interface {
+	// Do runs work with a Tx in its context. The unit is committed when work
+	// returns nil and rolled back, returning work's error, otherwise. Do
+	// called within a unit joins it.
+	Do(ctx context.Context, work func(ctx context.Context) error) error
+}
+
+// Tx is the state of a running unit of work
+type Tx struct {
+	sql *sql.Tx
+	// undo holds the compensations of the writes applied so far, in order
+	undo []func() error
+	// committed holds the callbacks to run once the unit is committed
+	committed []func()
+}
+
+// SQL returns the database transaction of the unit, nil on storage without
+// transactions
+func (tx *Tx) SQL() *sql.Tx {
+	return tx.sql
+}
+
+// OnRollback registers undo to compensate a write applied outside the
+// database transaction, should the unit fail
+func (tx *Tx) OnRollback(undo func() error) {
+	tx.undo = append(tx.undo, undo)
+}
+
+// OnCommit registers fn to run once the unit is committed, for effects such
+// as notifications and cache invalidation that must not see a rolled-back
+// write
+func (tx *Tx) OnCommit(fn func()) {
+	tx.committed = append(tx.committed, fn)
+}
+
+type contextKey struct{}
+
+// FromContext returns the Tx of the unit of work running in ctx, or nil
+func FromContext(ctx context.Context) *Tx {
+	tx, _ := ctx.Value(contextKey{}).(*Tx)
+	return tx
+}
+
+// SQL runs units of work in transactions of a database
+type SQL struct {
+	db *sql.DB
+}
+
+// NewSQL creates a unit of work running in transactions of db, which
+// repositories joining it must share
+func NewSQL(db *sql.DB) *SQL {
+	return &SQL{db: db}
+}
+
+// Do runs work in a database transaction
+func (u *SQL) Do(ctx context.Context, work func(ctx context.Context) error) error {
+	if FromContext(ctx) != nil {
+		return work(ctx)
+	}
+
+	sqlTx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin unit of work: %w", err)
+	}
+	defer sqlTx.Rollback()
+
+	return run(ctx, &Tx{sql: sqlTx}, work, func() error {
+		if err := sqlTx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit unit of work: %w", err)
+		}
+		return nil
+	})
+}
+
+// Compensating runs units of work on storage without transactions
+type Compensating struct{}
+
+// Do runs work, compensating its writes if it fails
+func (Compensating) Do(ctx context.Context, work func(ctx context.Context) error) error {
+	if FromContext(ctx) != nil {
+		return work(ctx)
+	}
+	return run(ctx, &Tx{}, work, func() error { return nil })
+}
+
+// run runs work with tx and then commit, compensating tx's writes when
+// either fails and running its commit callbacks otherwise
+func run(ctx context.Context, tx *Tx, work func(ctx context.Context) error, commit func() error) error {
+	err := work(context.WithValue(ctx, contextKey{}, tx))
+	if err == nil {
+		err = commit()
+	}
+	if err != nil {
+		tx.compensate(ctx)
+		return err
+	}
+
+	for _, fn := range tx.committed {
+		fn()
+	}
+	return nil
+}
+
+// compensate undoes the writes applied outside the database transaction,
+// newest first. Failures are logged: the unit's own error is what callers
+// act on.
+func (tx *Tx) compensate(ctx context.Context) {
+	for i := len(tx.undo) - 1; i >= 0; i-- {
+		if err := tx.undo[i](); err != nil {
+			logging.FromContext(ctx).Error("Failed to compensate unit of work write", "error", err)
+		}
+	}
+}
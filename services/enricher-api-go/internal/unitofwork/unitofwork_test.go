@@ -0,0 +1,90 @@
+package unitofwork
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestCompensating_UndoesWritesNewestFirst(t *testing.T) {
+	// Arrange
+	errWork := errors.New("work failed")
+	var undone []string
+	committed := false
+
+	// Act
+	err := Compensating{}.Do(context.Background(), func(ctx context.Context) error {
+		tx := FromContext(ctx)
+		tx.OnRollback(func() error {
+			undone = append(undone, "first")
+			return nil
+		})
+		tx.OnRollback(func() error {
+			undone = append(undone, "second")
+			return errors.New("undo failed")
+		})
+		tx.OnCommit(func() { committed = true })
+		return errWork
+	})
+
+	// Assert
+	if !errors.Is(err, errWork) {
+		t.Errorf("Expected the work's error, got %v", err)
+	}
+	if want := []string{"second", "first"}; !reflect.DeepEqual(undone, want) {
+		t.Errorf("Expected compensations %v, got %v", want, undone)
+	}
+	if committed {
+		t.Error("Expected commit callbacks not to run for a failed unit")
+	}
+}
+
+func TestCompensating_RunsCommitCallbacks(t *testing.T) {
+	// Arrange
+	undone, committed := false, false
+
+	// Act
+	err := Compensating{}.Do(context.Background(), func(ctx context.Context) error {
+		tx := FromContext(ctx)
+		tx.OnRollback(func() error {
+			undone = true
+			return nil
+		})
+		tx.OnCommit(func() { committed = true })
+		return nil
+	})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if undone || !committed {
+		t.Errorf("Expected only the commit callback to run, got undone=%v committed=%v", undone, committed)
+	}
+}
+
+func TestCompensating_NestedUnitJoins(t *testing.T) {
+	// Arrange
+	var outer, inner *Tx
+
+	// Act
+	err := Compensating{}.Do(context.Background(), func(ctx context.Context) error {
+		outer = FromContext(ctx)
+		return Compensating{}.Do(ctx, func(ctx context.Context) error {
+			inner = FromContext(ctx)
+			return nil
+		})
+	})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if outer == nil || inner != outer {
+		t.Errorf("Expected the nested unit to join the outer one, got %p and %p", outer, inner)
+	}
+	if tx := FromContext(context.Background()); tx != nil {
+		t.Errorf("Expected no unit outside Do, got %p", tx)
+	}
+}
@@ -0,0 +1,203 @@
+// Package validation enforces the validate struct tags on request types
+// using go-playground/validator and reports failures per field.
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+)
+
+// FieldError describes one field that failed validation
+type FieldError struct {
+	// Field is the JSON path of the field, such as "name" or "segments[0]"
+	Field string `json:"field"`
+	// Rule is the validate tag rule that failed, such as "min"
+	Rule string `json:"rule"`
+	// Message explains the failure in plain language
+	Message string `json:"message"`
+}
+
+// Error reports every field of a value that failed validation
+type Error struct {
+	Fields []FieldError
+}
+
+// Error joins the field messages, e.g. "name must be at least 2 characters"
+func (e *Error) Error() string {
+	messages := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		messages[i] = field.Field + " " + field.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Validator checks values against their validate struct tags.
+// It implements echo.Validator.
+type Validator struct {
+	validate *validator.Validate
+}
+
+// New creates a Validator that names fields by their JSON names
+func New() *Validator {
+	validate := validator.New(validator.WithRequiredStructEnabled())
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+	return &Validator{validate: validate}
+}
+
+// defaultValidator backs Struct
+var defaultValidator = New()
+
+// Struct validates v with a shared Validator
+func Struct(v interface{}) error {
+	return defaultValidator.Validate(v)
+}
+
+// Validate checks v, returning *Error listing every failed field.
+// Values that are not structs or pointers to structs are not checked.
+func (v *Validator) Validate(i interface{}) error {
+	if !isStruct(i) {
+		return nil
+	}
+
+	err := v.validate.Struct(i)
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return err
+	}
+
+	fields := make([]FieldError, len(validationErrs))
+	for n, fieldErr := range validationErrs {
+		fields[n] = FieldError{
+			Field:   fieldPath(fieldErr),
+			Rule:    fieldErr.Tag(),
+			Message: message(fieldErr),
+		}
+	}
+	return &Error{Fields: fields}
+}
+
+// MIMEApplicationMergePatchJSON is the JSON Merge Patch (RFC 7396) media type
+const MIMEApplicationMergePatchJSON = "application/merge-patch+json"
+
+// Binder binds request data with Echo's default binder and then validates
+// the result, so handlers only see values that satisfy their tags
+type Binder struct {
+	echo.DefaultBinder
+	validator *Validator
+}
+
+// NewBinder creates a Binder that validates with v
+func NewBinder(v *Validator) *Binder {
+	return &Binder{validator: v}
+}
+
+// Bind implements echo.Binder. JSON Merge Patch bodies, which Echo's binder
+// does not recognise, are decoded as JSON.
+func (b *Binder) Bind(i interface{}, c echo.Context) error {
+	if isMergePatch(c.Request()) {
+		if err := b.bindMergePatch(i, c); err != nil {
+			return err
+		}
+		return b.validator.Validate(i)
+	}
+
+	if err := b.DefaultBinder.Bind(i, c); err != nil {
+		return err
+	}
+	return b.validator.Validate(i)
+}
+
+// bindMergePatch binds path parameters and the JSON body, reporting decode
+// errors the way Echo does for application/json
+func (b *Binder) bindMergePatch(i interface{}, c echo.Context) error {
+	if err := b.BindPathParams(c, i); err != nil {
+		return err
+	}
+	if c.Request().ContentLength == 0 {
+		return nil
+	}
+
+	err := c.Echo().JSONSerializer.Deserialize(c, i)
+	var httpErr *echo.HTTPError
+	if err != nil && !errors.As(err, &httpErr) {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+	return err
+}
+
+// isMergePatch reports whether r carries a JSON Merge Patch body
+func isMergePatch(r *http.Request) bool {
+	mediaType, _, _ := strings.Cut(r.Header.Get(echo.HeaderContentType), ";")
+	return strings.EqualFold(strings.TrimSpace(mediaType), MIMEApplicationMergePatchJSON)
+}
+
+// isStruct reports whether i is a struct or a non-nil pointer to one
+func isStruct(i interface{}) bool {
+	value := reflect.ValueOf(i)
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return false
+		}
+		value = value.Elem()
+	}
+	return value.Kind() == reflect.Struct
+}
+
+// fieldPath drops the top-level type name from the error's namespace
+func fieldPath(fieldErr validator.FieldError) string {
+	namespace := fieldErr.Namespace()
+	if _, rest, found := strings.Cut(namespace, "."); found {
+		return rest
+	}
+	return namespace
+}
+
+// message describes a failed rule in terms of the field's kind
+func message(fieldErr validator.FieldError) string {
+	param := fieldErr.Param()
+	unit := ""
+	switch fieldErr.Kind() {
+	case reflect.String:
+		unit = " characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		unit = " items"
+	}
+
+	switch fieldErr.Tag() {
+	case "required":
+		return "is required"
+	case "min", "gte":
+		return fmt.Sprintf("must be at least %s%s", param, unit)
+	case "max", "lte":
+		return fmt.Sprintf("must be at most %s%s", param, unit)
+	case "gt":
+		return fmt.Sprintf("must be greater than %s%s", param, unit)
+	case "lt":
+		return fmt.Sprintf("must be less than %s%s", param, unit)
+	case "len":
+		return fmt.Sprintf("must be exactly %s%s", param, unit)
+	case "oneof":
+		return "must be one of " + strings.Join(strings.Fields(param), ", ")
+	case "email":
+		return "must be a valid email address"
+	case "http_url":
+		return "must be an http or https URL"
+	default:
+		return fmt.Sprintf("failed the %q rule", fieldErr.Tag())
+	}
+}
@@ -0,0 +1,119 @@
+package validation
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+type testRequest struct {
+	Name   string   `json:"name" validate:"required,min=2,max=10"`
+	Price  float64  `json:"price" validate:"gt=0"`
+	Status string   `json:"status" validate:"oneof=ACTIVE INACTIVE"`
+	Tags   []string `json:"tags,omitempty" validate:"omitempty,dive,min=1"`
+}
+
+func TestValidator_Validate(t *testing.T) {
+	// Arrange
+	v := New()
+	req := testRequest{Name: "", Price: 0, Status: "PENDING", Tags: []string{"ok", ""}}
+
+	// Act
+	err := v.Validate(&req)
+
+	// Assert
+	var validationErr *Error
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Expected *Error, got %v", err)
+	}
+
+	expected := []FieldError{
+		{Field: "name", Rule: "required", Message: "is required"},
+		{Field: "price", Rule: "gt", Message: "must be greater than 0"},
+		{Field: "status", Rule: "oneof", Message: "must be one of ACTIVE, INACTIVE"},
+		{Field: "tags[1]", Rule: "min", Message: "must be at least 1 characters"},
+	}
+	if len(validationErr.Fields) != len(expected) {
+		t.Fatalf("Expected %d field errors, got %+v", len(expected), validationErr.Fields)
+	}
+	for i, field := range expected {
+		if validationErr.Fields[i] != field {
+			t.Errorf("Expected %+v, got %+v", field, validationErr.Fields[i])
+		}
+	}
+
+	if !strings.HasPrefix(err.Error(), "name is required; price must be greater than 0") {
+		t.Errorf("Expected joined field messages, got %q", err.Error())
+	}
+}
+
+func TestValidator_Validate_Valid(t *testing.T) {
+	// Arrange
+	v := New()
+
+	// Act
+	structErr := v.Validate(testRequest{Name: "Widget", Price: 1, Status: "ACTIVE"})
+	mapErr := v.Validate(map[string]string{"name": ""})
+
+	// Assert
+	if structErr != nil {
+		t.Errorf("Expected valid struct, got %v", structErr)
+	}
+	if mapErr != nil {
+		t.Errorf("Expected non-struct values to be skipped, got %v", mapErr)
+	}
+}
+
+func TestBinder_ValidatesAfterBinding(t *testing.T) {
+	// Arrange
+	e := echo.New()
+	body := `{"name":"A","price":5,"status":"ACTIVE"}`
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	c := e.NewContext(req, httptest.NewRecorder())
+	binder := NewBinder(New())
+
+	// Act
+	var bound testRequest
+	err := binder.Bind(&bound, c)
+
+	// Assert
+	var validationErr *Error
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Expected *Error, got %v", err)
+	}
+	if len(validationErr.Fields) != 1 || validationErr.Fields[0].Field != "name" {
+		t.Errorf("Expected only name to fail, got %+v", validationErr.Fields)
+	}
+	if bound.Price != 5 {
+		t.Errorf("Expected body to be bound before validation, got %+v", bound)
+	}
+}
+
+func TestBinder_MergePatch(t *testing.T) {
+	// Arrange
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPatch, "/", strings.NewReader(`{"status":"PENDING"}`))
+	req.Header.Set(echo.HeaderContentType, MIMEApplicationMergePatchJSON+"; charset=utf-8")
+	c := e.NewContext(req, httptest.NewRecorder())
+	binder := NewBinder(New())
+
+	// Act
+	var bound struct {
+		Status string `json:"status" validate:"omitempty,oneof=ACTIVE INACTIVE"`
+	}
+	err := binder.Bind(&bound, c)
+
+	// Assert
+	var validationErr *Error
+	if !errors.As(err, &validationErr) || validationErr.Fields[0].Field != "status" {
+		t.Fatalf("Expected the merge patch body to be bound and validated, got %v", err)
+	}
+	if bound.Status != "PENDING" {
+		t.Errorf("Expected status to be bound, got %+v", bound)
+	}
+}
@@ -0,0 +1,137 @@
+package webhook
+
+import (
+	"errors"
+	"time"
+
+	"enricher-api-go/internal/breaker"
+	"enricher-api-go/internal/outbox"
+)
+
+// BreakerRepository guards another Repository with a circuit breaker.
+//
+// Storage failures count against the breaker, while domain outcomes such as
+// ErrSubscriptionNotFound are returned unchanged without tripping it.
+type BreakerRepository struct {
+	repo    Repository
+	breaker *breaker.Breaker
+}
+
+// NewBreakerRepository wraps repo with b, typically the breaker shared by
+// every repository using the same backend
+func NewBreakerRepository(repo Repository, b *breaker.Breaker) *BreakerRepository {
+	return &BreakerRepository{repo: repo, breaker: b}
+}
+
+// GetSubscription retrieves a subscription by ID
+func (r *BreakerRepository) GetSubscription(subscriptionID string) (subscription *Subscription, err error) {
+	err = r.call(func() error {
+		subscription, err = r.repo.GetSubscription(subscriptionID)
+		return err
+	})
+	return subscription, err
+}
+
+// FindSubscriptions returns the subscriptions matching filter
+func (r *BreakerRepository) FindSubscriptions(filter SubscriptionFilter) (subscriptions []*Subscription, err error) {
+	err = r.call(func() error {
+		subscriptions, err = r.repo.FindSubscriptions(filter)
+		return err
+	})
+	return subscriptions, err
+}
+
+// CountSubscriptions returns the number of subscriptions matching filter
+func (r *BreakerRepository) CountSubscriptions(filter SubscriptionFilter) (count int, err error) {
+	err = r.call(func() error {
+		count, err = r.repo.CountSubscriptions(filter)
+		return err
+	})
+	return count, err
+}
+
+// CreateSubscription adds a new subscription
+func (r *BreakerRepository) CreateSubscription(subscription *Subscription) error {
+	return r.call(func() error { return r.repo.CreateSubscription(subscription) })
+}
+
+// UpdateSubscription replaces an existing subscription
+func (r *BreakerRepository) UpdateSubscription(subscription *Subscription) error {
+	return r.call(func() error { return r.repo.UpdateSubscription(subscription) })
+}
+
+// DeleteSubscription removes a subscription along with its deliveries
+func (r *BreakerRepository) DeleteSubscription(subscriptionID string) error {
+	return r.call(func() error { return r.repo.DeleteSubscription(subscriptionID) })
+}
+
+// FindDeliveries returns the deliveries matching filter
+func (r *BreakerRepository) FindDeliveries(filter DeliveryFilter) (deliveries []*Delivery, err error) {
+	err = r.call(func() error {
+		deliveries, err = r.repo.FindDeliveries(filter)
+		return err
+	})
+	return deliveries, err
+}
+
+// CountDeliveries returns the number of deliveries matching filter
+func (r *BreakerRepository) CountDeliveries(filter DeliveryFilter) (count int, err error) {
+	err = r.call(func() error {
+		count, err = r.repo.CountDeliveries(filter)
+		return err
+	})
+	return count, err
+}
+
+// CreateDelivery adds a new delivery
+func (r *BreakerRepository) CreateDelivery(delivery *Delivery) error {
+	return r.call(func() error { return r.repo.CreateDelivery(delivery) })
+}
+
+// UpdateDelivery replaces an existing delivery
+func (r *BreakerRepository) UpdateDelivery(delivery *Delivery) error {
+	return r.call(func() error { return r.repo.UpdateDelivery(delivery) })
+}
+
+// ClaimDue leases the PENDING deliveries due at now
+func (r *BreakerRepository) ClaimDue(now, leaseUntil time.Time, limit int) (deliveries []*Delivery, err error) {
+	err = r.call(func() error {
+		deliveries, err = r.repo.ClaimDue(now, leaseUntil, limit)
+		return err
+	})
+	return deliveries, err
+}
+
+// RedactDeliveries rewrites the data of the events of an aggregate in every delivery
+func (r *BreakerRepository) RedactDeliveries(aggregate, aggregateID string, redact outbox.RedactFunc) (redacted int, err error) {
+	err = r.call(func() error {
+		redacted, err = r.repo.RedactDeliveries(aggregate, aggregateID, redact)
+		return err
+	})
+	return redacted, err
+}
+
+// call runs fn through the breaker, passing domain errors through without
+// counting them as failures
+func (r *BreakerRepository) call(fn func() error) error {
+	var domainErr error
+	err := r.breaker.Execute(func() error {
+		err := fn()
+		if isDomainError(err) {
+			domainErr = err
+			return nil
+		}
+		return err
+	})
+	if domainErr != nil {
+		return domainErr
+	}
+	return err
+}
+
+// isDomainError reports whether err is an expected repository outcome rather
+// than a storage failure
+func isDomainError(err error) bool {
+	return errors.Is(err, ErrSubscriptionNotFound) || errors.Is(err, ErrSubscriptionExists) ||
+		errors.Is(err, ErrDeliveryNotFound) || errors.Is(err, ErrDeliveryExists)
+}
@@ -0,0 +1,256 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"enricher-api-go/internal/clock"
+	"enricher-api-go/internal/logging"
+)
+
+// Default dispatcher settings
+const (
+	DefaultPollInterval   = time.Second
+	DefaultBatchSize      = 50
+	DefaultMaxAttempts    = 10
+	DefaultInitialBackoff = 30 * time.Second
+	DefaultMaxBackoff     = time.Hour
+	DefaultTimeout        = 10 * time.Second
+)
+
+// Headers sent with every delivery
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// maxResponseBody bounds how much of an endpoint's response is read before
+// the connection is reused
+const maxResponseBody = 64 << 10
+
+// DispatcherConfig holds the dispatcher settings; zero values take the package defaults
+type DispatcherConfig struct {
+	// PollInterval is how long the dispatcher waits once no deliveries are due
+	PollInterval time.Duration
+	// BatchSize bounds the deliveries attempted at once
+	BatchSize int
+	// MaxAttempts is how many times a delivery is POSTed before it is FAILED
+	MaxAttempts int
+	// InitialBackoff is the wait after the first failed attempt, doubled after each further one
+	InitialBackoff time.Duration
+	// MaxBackoff caps the wait between attempts
+	MaxBackoff time.Duration
+	// Timeout bounds each POST
+	Timeout time.Duration
+}
+
+// withDefaults fills in unset fields with the package defaults
+func (cfg DispatcherConfig) withDefaults() DispatcherConfig {
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = DefaultPollInterval
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = DefaultBatchSize
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = DefaultMaxAttempts
+	}
+	if cfg.InitialBackoff <= 0 {
+		cfg.InitialBackoff = DefaultInitialBackoff
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = DefaultMaxBackoff
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	return cfg
+}
+
+// Dispatcher POSTs the deliveries queued in a repository until stopped
+type Dispatcher struct {
+	repo   Repository
+	client *http.Client
+	cfg    DispatcherConfig
+	clock  clock.Clock
+}
+
+// NewDispatcher creates a dispatcher attempting the deliveries of repo
+func NewDispatcher(repo Repository, cfg DispatcherConfig) *Dispatcher {
+	cfg = cfg.withDefaults()
+	return &Dispatcher{
+		repo:   repo,
+		client: &http.Client{Timeout: cfg.Timeout},
+		cfg:    cfg,
+		clock:  clock.System{},
+	}
+}
+
+// Run attempts due deliveries until ctx is cancelled, then returns nil.
+//
+// Batches are attempted back to back while a full batch was due, and every
+// PollInterval otherwise.
+func (d *Dispatcher) Run(ctx context.Context) error {
+	logger := logging.FromContext(ctx)
+	ticker := time.NewTicker(d.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		attempted, err := d.Poll(ctx)
+		if err != nil && ctx.Err() == nil {
+			logger.Warn("Failed to claim webhook deliveries, retrying", "error", err, "retry_in", d.cfg.PollInterval.String())
+		}
+		if err == nil && attempted == d.cfg.BatchSize && ctx.Err() == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll attempts one batch of due deliveries concurrently, returning how
+// many it attempted.
+//
+// Claimed deliveries are leased for twice the Timeout, so those of a
+// dispatcher that stops mid-batch are attempted again once it expires.
+func (d *Dispatcher) Poll(ctx context.Context) (int, error) {
+	now := d.clock.Now()
+	deliveries, err := d.repo.ClaimDue(now, now.Add(2*d.cfg.Timeout), d.cfg.BatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.attempt(ctx, delivery)
+		}()
+	}
+	wg.Wait()
+	return len(deliveries), nil
+}
+
+// attempt POSTs a delivery to its subscription and records the outcome:
+// DELIVERED on a 2xx response, otherwise PENDING until the next attempt
+// after the backoff, or FAILED once MaxAttempts are used up
+func (d *Dispatcher) attempt(ctx context.Context, delivery *Delivery) {
+	logger := logging.FromContext(ctx).With("delivery_id", delivery.DeliveryID, "subscription_id", delivery.SubscriptionID)
+
+	subscription, err := d.repo.GetSubscription(delivery.SubscriptionID)
+	if errors.Is(err, ErrSubscriptionNotFound) {
+		// Deleted along with its deliveries since this one was claimed
+		return
+	}
+	if err != nil {
+		logger.Warn("Failed to get webhook subscription, retrying after the lease", "error", err)
+		return
+	}
+
+	now := d.clock.Now()
+	if !subscription.Active {
+		delivery.Status, delivery.LastError = StatusFailed, "subscription is inactive"
+	} else {
+		responseStatus, postErr := d.post(ctx, subscription, delivery, now)
+		if postErr != nil && ctx.Err() != nil {
+			// Stopped mid-attempt; the delivery is attempted again after the lease
+			return
+		}
+
+		delivery.Attempts++
+		delivery.LastAttemptAt, delivery.ResponseStatus = &now, responseStatus
+		switch {
+		case postErr == nil:
+			delivery.Status, delivery.LastError, delivery.DeliveredAt = StatusDelivered, "", &now
+		case delivery.Attempts >= d.cfg.MaxAttempts:
+			delivery.Status, delivery.LastError = StatusFailed, postErr.Error()
+		default:
+			delivery.LastError, delivery.NextAttemptAt = postErr.Error(), now.Add(d.backoff(delivery.Attempts))
+		}
+	}
+
+	if err := d.repo.UpdateDelivery(delivery); err != nil {
+		if !errors.Is(err, ErrDeliveryNotFound) {
+			logger.Error("Failed to record webhook delivery attempt", "error", err)
+		}
+		return
+	}
+
+	switch delivery.Status {
+	case StatusDelivered:
+		logger.Debug("Webhook delivered", "attempts", delivery.Attempts)
+	case StatusFailed:
+		logger.Warn("Webhook delivery failed", "attempts", delivery.Attempts, "error", delivery.LastError)
+	default:
+		logger.Info("Webhook delivery attempt failed, retrying", "attempts", delivery.Attempts,
+			"next_attempt_at", delivery.NextAttemptAt, "error", delivery.LastError)
+	}
+}
+
+// post sends a delivery's payload signed with the subscription's secret,
+// returning the response status, if any, and why the attempt failed
+func (d *Dispatcher) post(ctx context.Context, subscription *Subscription, delivery *Delivery, now time.Time) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("invalid webhook request: %w", err)
+	}
+
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderDelivery, delivery.DeliveryID)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign(subscription.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseBody))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("endpoint answered %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff returns the wait after the given number of failed attempts
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	backoff := d.cfg.InitialBackoff
+	for i := 1; i < attempts && backoff < d.cfg.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, d.cfg.MaxBackoff)
+}
+
+// Sign returns the X-Webhook-Signature of a delivery: "sha256=" followed by
+// the hex HMAC-SHA256, keyed by secret, of the X-Webhook-Timestamp, a dot
+// and the body.
+//
+// Receivers recompute it over the raw body to authenticate a delivery, and
+// reject stale timestamps to guard against replays.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,161 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"enricher-api-go/internal/clock"
+	"enricher-api-go/internal/outbox"
+)
+
+// endpoint records the deliveries it receives, answering with the statuses
+// queued in responses and 204 once they run out
+type endpoint struct {
+	mu        sync.Mutex
+	responses []int
+	requests  []*http.Request
+	bodies    [][]byte
+}
+
+func (e *endpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.requests, e.bodies = append(e.requests, r), append(e.bodies, body)
+	status := http.StatusNoContent
+	if len(e.responses) > 0 {
+		status, e.responses = e.responses[0], e.responses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+// queueDelivery subscribes url to product.updated events and queues one
+func queueDelivery(t *testing.T, repo Repository, url string) *Subscription {
+	t.Helper()
+	service := NewService(repo)
+	subscription, err := service.CreateSubscription(context.Background(), SubscriptionRequest{URL: url, Events: []string{"product.updated"}})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := service.Publish(context.Background(), []*outbox.Event{productEvent("evt-1", "product.updated", "product-1")}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return subscription
+}
+
+// onlyDelivery returns the single delivery stored in repo
+func onlyDelivery(t *testing.T, repo Repository) *Delivery {
+	t.Helper()
+	deliveries, err := repo.FindDeliveries(DeliveryFilter{})
+	if err != nil || len(deliveries) != 1 {
+		t.Fatalf("Expected one delivery, got %v, %v", deliveries, err)
+	}
+	return deliveries[0]
+}
+
+func TestDispatcher_Poll_SignsDeliveries(t *testing.T) {
+	// Arrange
+	receiver := &endpoint{}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+	repo := NewInMemoryRepository()
+	subscription := queueDelivery(t, repo, server.URL)
+	dispatcher := NewDispatcher(repo, DispatcherConfig{})
+
+	// Act
+	attempted, err := dispatcher.Poll(context.Background())
+
+	// Assert
+	if err != nil || attempted != 1 {
+		t.Fatalf("Expected one delivery attempted, got %d, %v", attempted, err)
+	}
+	if len(receiver.requests) != 1 {
+		t.Fatalf("Expected one request, got %d", len(receiver.requests))
+	}
+	req, body := receiver.requests[0], receiver.bodies[0]
+	timestamp := req.Header.Get(HeaderTimestamp)
+	if got, want := req.Header.Get(HeaderSignature), Sign(subscription.Secret, timestamp, body); timestamp == "" || got != want {
+		t.Errorf("Expected signature %s, got %s", want, got)
+	}
+	if req.Header.Get(HeaderEvent) != "product.updated" || req.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("Expected the event type and JSON content type, got %v", req.Header)
+	}
+
+	delivery := onlyDelivery(t, repo)
+	if delivery.Status != StatusDelivered || delivery.Attempts != 1 || delivery.ResponseStatus != http.StatusNoContent ||
+		delivery.DeliveredAt == nil || req.Header.Get(HeaderDelivery) != delivery.DeliveryID {
+		t.Errorf("Expected the delivery recorded as delivered, got %+v", delivery)
+	}
+}
+
+func TestDispatcher_Poll_RetriesWithBackoff(t *testing.T) {
+	// Arrange
+	receiver := &endpoint{responses: []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable}}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+	repo := NewInMemoryRepository()
+	queueDelivery(t, repo, server.URL)
+	dispatcher := NewDispatcher(repo, DispatcherConfig{MaxAttempts: 3, InitialBackoff: time.Minute, MaxBackoff: 90 * time.Second})
+	now := time.Now().UTC()
+	dispatcher.clock = clock.Func(func() time.Time { return now })
+
+	// Act
+	var retries []time.Duration
+	for attempt := 0; attempt < 3; attempt++ {
+		if _, err := dispatcher.Poll(context.Background()); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		delivery := onlyDelivery(t, repo)
+		if delivery.Status == StatusPending {
+			retries = append(retries, delivery.NextAttemptAt.Sub(now))
+			now = delivery.NextAttemptAt
+		}
+	}
+
+	// Assert
+	if len(retries) != 2 || retries[0] != time.Minute || retries[1] != 90*time.Second {
+		t.Errorf("Expected retries after 1m and the capped 1m30s, got %v", retries)
+	}
+	delivery := onlyDelivery(t, repo)
+	if delivery.Status != StatusFailed || delivery.Attempts != 3 || delivery.ResponseStatus != http.StatusServiceUnavailable ||
+		delivery.LastError != "endpoint answered 503" {
+		t.Errorf("Expected the delivery failed after its last attempt, got %+v", delivery)
+	}
+	if attempted, _ := dispatcher.Poll(context.Background()); attempted != 0 {
+		t.Errorf("Expected a failed delivery not attempted again, got %d", attempted)
+	}
+}
+
+func TestDispatcher_Run(t *testing.T) {
+	// Arrange
+	receiver := &endpoint{}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+	repo := NewInMemoryRepository()
+	queueDelivery(t, repo, server.URL)
+	dispatcher := NewDispatcher(repo, DispatcherConfig{PollInterval: 5 * time.Millisecond})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+
+	// Act
+	go func() { done <- dispatcher.Run(ctx) }()
+	deadline := time.Now().Add(5 * time.Second)
+	for onlyDelivery(t, repo).Status != StatusDelivered && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+
+	// Assert
+	if err := <-done; err != nil {
+		t.Errorf("Expected Run to stop cleanly, got %v", err)
+	}
+	if status := onlyDelivery(t, repo).Status; status != StatusDelivered {
+		t.Errorf("Expected the delivery delivered, got %s", status)
+	}
+}
@@ -0,0 +1,181 @@
+package webhook
+
+import (
+	"errors"
+	"net/http"
+
+	"enricher-api-go/internal/pagination"
+	"enricher-api-go/internal/problem"
+	"enricher-api-go/internal/servertiming"
+	"enricher-api-go/internal/validation"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Handler handles HTTP requests for webhook subscriptions
+type Handler struct {
+	service Service
+}
+
+// NewHandler creates a new webhook handler
+func NewHandler(service Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+// ListSubscriptions handles GET /v1/webhooks
+//
+// Subscriptions are listed oldest first and may be narrowed with event to
+// the active ones receiving one event type.
+func (h *Handler) ListSubscriptions(c echo.Context) error {
+	page, err := pagination.FromQuery(c.QueryParams())
+	if err != nil {
+		return problem.Write(c, http.StatusBadRequest, err.Error())
+	}
+
+	filter := SubscriptionFilter{
+		EventType: c.QueryParam("event"),
+		Limit:     page.Limit,
+		Offset:    page.Offset,
+	}
+
+	stop := servertiming.Start(c, "service")
+	subscriptions, err := h.service.FindSubscriptions(c.Request().Context(), filter)
+	var total int
+	if err == nil {
+		total, err = h.service.CountSubscriptions(c.Request().Context(), filter)
+	}
+	stop()
+	if err != nil {
+		return subscriptionError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"subscriptions": subscriptions,
+		"count":         len(subscriptions),
+		"pagination":    pagination.NewMeta(c.Request().URL, page, total),
+	})
+}
+
+// GetSubscription handles GET /v1/webhooks/:id
+func (h *Handler) GetSubscription(c echo.Context) error {
+	stop := servertiming.Start(c, "service")
+	subscription, err := h.service.GetSubscription(c.Request().Context(), c.Param("id"))
+	stop()
+	if err != nil {
+		return subscriptionError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, subscription)
+}
+
+// CreateSubscription handles POST /v1/webhooks
+//
+// The response is the only one carrying the subscription's secret, which
+// deliveries are signed with.
+//
+// Example request:
+//
+//	POST /v1/webhooks
+//	Content-Type: application/json
+//
+//	{
+//		"url": "https://pricing.example.com/hooks/catalog",
+//		"events": ["product.updated", "product.stock_changed"]
+//	}
+func (h *Handler) CreateSubscription(c echo.Context) error {
+	var req SubscriptionRequest
+	if err := servertiming.Measure(c, "bind", func() error { return c.Bind(&req) }); err != nil {
+		return problem.BindError(c, err)
+	}
+
+	stop := servertiming.Start(c, "service")
+	subscription, err := h.service.CreateSubscription(c.Request().Context(), req)
+	stop()
+	if err != nil {
+		return subscriptionError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, subscription)
+}
+
+// UpdateSubscription handles PUT /v1/webhooks/:id
+func (h *Handler) UpdateSubscription(c echo.Context) error {
+	var req SubscriptionRequest
+	if err := servertiming.Measure(c, "bind", func() error { return c.Bind(&req) }); err != nil {
+		return problem.BindError(c, err)
+	}
+
+	stop := servertiming.Start(c, "service")
+	subscription, err := h.service.UpdateSubscription(c.Request().Context(), c.Param("id"), req)
+	stop()
+	if err != nil {
+		return subscriptionError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, subscription)
+}
+
+// DeleteSubscription handles DELETE /v1/webhooks/:id
+func (h *Handler) DeleteSubscription(c echo.Context) error {
+	stop := servertiming.Start(c, "service")
+	err := h.service.DeleteSubscription(c.Request().Context(), c.Param("id"))
+	stop()
+	if err != nil {
+		return subscriptionError(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ListDeliveries handles GET /v1/webhooks/:id/deliveries
+//
+// The delivery log of a subscription is listed newest first and may be
+// narrowed to one status with status.
+func (h *Handler) ListDeliveries(c echo.Context) error {
+	page, err := pagination.FromQuery(c.QueryParams())
+	if err != nil {
+		return problem.Write(c, http.StatusBadRequest, err.Error())
+	}
+
+	filter := DeliveryFilter{
+		SubscriptionID: c.Param("id"),
+		Status:         c.QueryParam("status"),
+		Limit:          page.Limit,
+		Offset:         page.Offset,
+	}
+
+	stop := servertiming.Start(c, "service")
+	deliveries, err := h.service.FindDeliveries(c.Request().Context(), filter)
+	var total int
+	if err == nil {
+		total, err = h.service.CountDeliveries(c.Request().Context(), filter)
+	}
+	stop()
+	if err != nil {
+		return subscriptionError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"deliveries": deliveries,
+		"count":      len(deliveries),
+		"pagination": pagination.NewMeta(c.Request().URL, page, total),
+	})
+}
+
+// subscriptionError answers a failed webhook operation: 404 for an unknown
+// subscription and 400 for an invalid request or filter
+func subscriptionError(c echo.Context, err error) error {
+	var validationErr *validation.Error
+	switch {
+	case errors.Is(err, ErrSubscriptionNotFound):
+		return problem.Write(c, http.StatusNotFound, "Webhook subscription not found")
+	case errors.As(err, &validationErr):
+		return problem.BindError(c, validationErr)
+	case errors.Is(err, ErrInvalidSubscription), errors.Is(err, ErrInvalidFilter):
+		return problem.Write(c, http.StatusBadRequest, err.Error())
+	default:
+		return problem.ServerError(c, err)
+	}
+}
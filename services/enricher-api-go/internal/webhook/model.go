@@ -0,0 +1,148 @@
+// Package webhook delivers customer and product change events to the
+// callback URLs clients subscribe for the Resilient Order Enricher API.
+//
+// Events come from the outbox: the relay hands every batch it publishes to
+// the service, which queues a delivery per subscription matching the event
+// type. A Dispatcher POSTs queued deliveries with an HMAC signature and
+// retries failed ones with exponential backoff until they succeed or run
+// out of attempts. Like the outbox, deliveries are at least once: receivers
+// must treat events as idempotent by eventId.
+package webhook
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Delivery statuses
+const (
+	// StatusPending is a delivery waiting for its next attempt
+	StatusPending = "PENDING"
+	// StatusDelivered is a delivery its endpoint answered with a 2xx status
+	StatusDelivered = "DELIVERED"
+	// StatusFailed is a delivery that ran out of attempts
+	StatusFailed = "FAILED"
+)
+
+// EventTypes lists the event types subscriptions can receive
+var EventTypes = []string{
+	"customer.created", "customer.updated", "customer.deleted", "customer.restored",
+	"product.created", "product.updated", "product.stock_changed", "product.deleted", "product.restored",
+}
+
+// Subscription registers a callback URL for change events.
+//
+// Example usage:
+//
+//	subscription := &Subscription{
+//		SubscriptionID: "whsub-12345",
+//		URL:            "https://pricing.example.com/hooks/catalog",
+//		Events:         []string{"product.updated", "product.stock_changed"},
+//		Active:         true,
+//	}
+type Subscription struct {
+	SubscriptionID string `json:"subscriptionId" db:"subscription_id"`
+	// URL receives a POST per matching event
+	URL string `json:"url" db:"url"`
+	// Events are the event types delivered, such as product.stock_changed
+	Events []string `json:"events" db:"events"`
+	// Secret signs the deliveries; it is only returned when the subscription is created
+	Secret string `json:"secret,omitempty" db:"secret"`
+	// Active subscriptions receive events; inactive ones keep their delivery log
+	Active    bool      `json:"active" db:"active"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+	// CreatedBy is the authenticated caller that created the subscription, if any
+	CreatedBy string `json:"createdBy,omitempty" db:"created_by"`
+	// UpdatedBy is the authenticated caller that last changed the subscription, if any
+	UpdatedBy string `json:"updatedBy,omitempty" db:"updated_by"`
+}
+
+// Receives reports whether the subscription is active and subscribed to eventType
+func (s *Subscription) Receives(eventType string) bool {
+	if !s.Active {
+		return false
+	}
+	for _, event := range s.Events {
+		if event == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// SubscriptionRequest is the request body for creating or replacing a subscription
+type SubscriptionRequest struct {
+	// URL is the http or https callback URL (required)
+	URL string `json:"url" validate:"required,http_url"`
+	// Events are the event types to deliver (at least one of EventTypes)
+	Events []string `json:"events" validate:"required,min=1,dive,required"`
+	// Secret signs the deliveries; one is generated on create when omitted,
+	// and the current one is kept on update
+	Secret string `json:"secret,omitempty" validate:"omitempty,min=16"`
+	// Active defaults to true on create and keeps its value on update when omitted
+	Active *bool `json:"active,omitempty"`
+}
+
+// Delivery is one event queued for, or delivered to, one subscription.
+//
+// A subscription has at most one delivery per event, so an event the relay
+// publishes again is not delivered twice.
+type Delivery struct {
+	DeliveryID     string `json:"deliveryId" db:"delivery_id"`
+	SubscriptionID string `json:"subscriptionId" db:"subscription_id"`
+	EventID        string `json:"eventId" db:"event_id"`
+	EventType      string `json:"eventType" db:"event_type"`
+	// Payload is the event as POSTed
+	Payload json.RawMessage `json:"payload" db:"payload"`
+	// Status is PENDING, DELIVERED or FAILED
+	Status string `json:"status" db:"status"`
+	// Attempts counts the POSTs made so far
+	Attempts int `json:"attempts" db:"attempts"`
+	// NextAttemptAt is when a PENDING delivery is attempted next
+	NextAttemptAt time.Time `json:"nextAttemptAt" db:"next_attempt_at"`
+	// LastAttemptAt is when the delivery was last POSTed
+	LastAttemptAt *time.Time `json:"lastAttemptAt,omitempty" db:"last_attempt_at"`
+	// ResponseStatus is the HTTP status of the last attempt; 0 if it got no response
+	ResponseStatus int `json:"responseStatus,omitempty" db:"response_status"`
+	// LastError explains why the last attempt failed
+	LastError   string     `json:"lastError,omitempty" db:"last_error"`
+	CreatedAt   time.Time  `json:"createdAt" db:"created_at"`
+	DeliveredAt *time.Time `json:"deliveredAt,omitempty" db:"delivered_at"`
+}
+
+// SubscriptionFilter describes the criteria for listing subscriptions.
+//
+// Zero values mean "no constraint". Limit and Offset page through the
+// matches, which are ordered oldest first.
+type SubscriptionFilter struct {
+	// EventType matches the active subscriptions receiving one event type
+	EventType string
+	Limit     int
+	Offset    int
+}
+
+// Matches reports whether a subscription satisfies the filter's criteria.
+// Limit and Offset are not considered.
+func (f SubscriptionFilter) Matches(subscription *Subscription) bool {
+	return f.EventType == "" || subscription.Receives(f.EventType)
+}
+
+// DeliveryFilter describes the criteria for listing deliveries.
+//
+// Zero values mean "no constraint". Limit and Offset page through the
+// matches, which are ordered newest first.
+type DeliveryFilter struct {
+	SubscriptionID string
+	// Status matches deliveries in one status
+	Status string
+	Limit  int
+	Offset int
+}
+
+// Matches reports whether a delivery satisfies the filter's criteria.
+// Limit and Offset are not considered.
+func (f DeliveryFilter) Matches(delivery *Delivery) bool {
+	return (f.SubscriptionID == "" || delivery.SubscriptionID == f.SubscriptionID) &&
+		(f.Status == "" || delivery.Status == f.Status)
+}
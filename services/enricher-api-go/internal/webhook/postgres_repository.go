@@ -0,0 +1,410 @@
+package webhook
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"enricher-api-go/internal/outbox"
+	"enricher-api-go/internal/postgres"
+)
+
+// subscriptionColumns lists the columns read by scanSubscription, in order
+const subscriptionColumns = `subscription_id, url, events, secret, active, created_at, updated_at, created_by, updated_by`
+
+// deliveryColumns lists the columns read by scanDelivery, in order
+const deliveryColumns = `delivery_id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at,
+	last_attempt_at, response_status, last_error, created_at, delivered_at`
+
+// PostgresRepository implements Repository on top of a PostgreSQL database
+type PostgresRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresRepository creates a webhook repository backed by db.
+//
+// The caller owns db and is responsible for opening and closing it; the
+// webhook tables must exist (see the migrations package).
+func NewPostgresRepository(db *sql.DB) *PostgresRepository {
+	return &PostgresRepository{db: db}
+}
+
+// GetSubscription retrieves a subscription by ID
+func (r *PostgresRepository) GetSubscription(subscriptionID string) (*Subscription, error) {
+	subscription, err := scanSubscription(r.db.QueryRow(
+		`SELECT `+subscriptionColumns+` FROM webhook_subscriptions WHERE subscription_id = $1`, subscriptionID,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSubscriptionNotFound
+	}
+	return subscription, err
+}
+
+// FindSubscriptions returns the subscriptions matching filter, oldest first
+func (r *PostgresRepository) FindSubscriptions(filter SubscriptionFilter) ([]*Subscription, error) {
+	where, args, err := subscriptionFilterClause(filter)
+	if err != nil {
+		return nil, err
+	}
+	query := `SELECT ` + subscriptionColumns + ` FROM webhook_subscriptions` + where + ` ORDER BY created_at, subscription_id`
+	query, args = pageClause(query, args, filter.Limit, filter.Offset)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhook subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	subscriptions := make([]*Subscription, 0)
+	for rows.Next() {
+		subscription, err := scanSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read webhook subscriptions: %w", err)
+	}
+	return subscriptions, nil
+}
+
+// CountSubscriptions returns the number of subscriptions matching filter, ignoring Limit and Offset
+func (r *PostgresRepository) CountSubscriptions(filter SubscriptionFilter) (int, error) {
+	where, args, err := subscriptionFilterClause(filter)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM webhook_subscriptions`+where, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count webhook subscriptions: %w", err)
+	}
+	return count, nil
+}
+
+// CreateSubscription adds a new subscription
+func (r *PostgresRepository) CreateSubscription(subscription *Subscription) error {
+	events, err := marshalEvents(subscription.Events)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec(
+		`INSERT INTO webhook_subscriptions (subscription_id, url, events, secret, active, created_at, updated_at, created_by, updated_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		subscription.SubscriptionID, subscription.URL, events, subscription.Secret, subscription.Active,
+		subscription.CreatedAt, subscription.UpdatedAt, subscription.CreatedBy, subscription.UpdatedBy,
+	)
+	if postgres.IsUniqueViolation(err) {
+		return ErrSubscriptionExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to insert webhook subscription: %w", err)
+	}
+	return nil
+}
+
+// UpdateSubscription replaces an existing subscription
+func (r *PostgresRepository) UpdateSubscription(subscription *Subscription) error {
+	events, err := marshalEvents(subscription.Events)
+	if err != nil {
+		return err
+	}
+
+	result, err := r.db.Exec(
+		`UPDATE webhook_subscriptions SET url = $2, events = $3, secret = $4, active = $5, updated_at = $6, updated_by = $7
+		WHERE subscription_id = $1`,
+		subscription.SubscriptionID, subscription.URL, events, subscription.Secret, subscription.Active,
+		subscription.UpdatedAt, subscription.UpdatedBy,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook subscription: %w", err)
+	}
+	return requireRowAffected(result, ErrSubscriptionNotFound)
+}
+
+// DeleteSubscription removes a subscription; its deliveries are removed by cascade
+func (r *PostgresRepository) DeleteSubscription(subscriptionID string) error {
+	result, err := r.db.Exec(`DELETE FROM webhook_subscriptions WHERE subscription_id = $1`, subscriptionID)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook subscription: %w", err)
+	}
+	return requireRowAffected(result, ErrSubscriptionNotFound)
+}
+
+// FindDeliveries returns the deliveries matching filter, newest first
+func (r *PostgresRepository) FindDeliveries(filter DeliveryFilter) ([]*Delivery, error) {
+	where, args := deliveryFilterClause(filter)
+	query := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries` + where + ` ORDER BY created_at DESC, delivery_id DESC`
+	query, args = pageClause(query, args, filter.Limit, filter.Offset)
+
+	return r.queryDeliveries(query, args...)
+}
+
+// CountDeliveries returns the number of deliveries matching filter, ignoring Limit and Offset
+func (r *PostgresRepository) CountDeliveries(filter DeliveryFilter) (int, error) {
+	where, args := deliveryFilterClause(filter)
+
+	var count int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM webhook_deliveries`+where, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count webhook deliveries: %w", err)
+	}
+	return count, nil
+}
+
+// CreateDelivery adds a new delivery
+func (r *PostgresRepository) CreateDelivery(delivery *Delivery) error {
+	_, err := r.db.Exec(
+		`INSERT INTO webhook_deliveries (delivery_id, subscription_id, event_id, event_type, payload, status, attempts,
+			next_attempt_at, last_attempt_at, response_status, last_error, created_at, delivered_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`,
+		delivery.DeliveryID, delivery.SubscriptionID, delivery.EventID, delivery.EventType, string(delivery.Payload),
+		delivery.Status, delivery.Attempts, delivery.NextAttemptAt, delivery.LastAttemptAt, delivery.ResponseStatus,
+		delivery.LastError, delivery.CreatedAt, delivery.DeliveredAt,
+	)
+	switch {
+	case postgres.IsUniqueViolation(err):
+		return ErrDeliveryExists
+	case postgres.IsForeignKeyViolation(err):
+		return ErrSubscriptionNotFound
+	case err != nil:
+		return fmt.Errorf("failed to insert webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// UpdateDelivery replaces the outcome of an existing delivery
+func (r *PostgresRepository) UpdateDelivery(delivery *Delivery) error {
+	result, err := r.db.Exec(
+		`UPDATE webhook_deliveries SET status = $2, attempts = $3, next_attempt_at = $4, last_attempt_at = $5,
+			response_status = $6, last_error = $7, delivered_at = $8
+		WHERE delivery_id = $1`,
+		delivery.DeliveryID, delivery.Status, delivery.Attempts, delivery.NextAttemptAt, delivery.LastAttemptAt,
+		delivery.ResponseStatus, delivery.LastError, delivery.DeliveredAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook delivery: %w", err)
+	}
+	return requireRowAffected(result, ErrDeliveryNotFound)
+}
+
+// ClaimDue returns up to limit PENDING deliveries due at now, the longest
+// overdue when more are due, leasing them until leaseUntil.
+//
+// Rows another dispatcher is claiming are skipped rather than waited for.
+func (r *PostgresRepository) ClaimDue(now, leaseUntil time.Time, limit int) ([]*Delivery, error) {
+	return r.queryDeliveries(
+		`UPDATE webhook_deliveries SET next_attempt_at = $2
+		WHERE delivery_id IN (
+			SELECT delivery_id FROM webhook_deliveries
+			WHERE status = $3 AND next_attempt_at <= $1
+			ORDER BY next_attempt_at, delivery_id
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+deliveryColumns,
+		now, leaseUntil, StatusPending, limit,
+	)
+}
+
+// RedactDeliveries rewrites the data of the events of an aggregate in every
+// delivery, in one transaction
+func (r *PostgresRepository) RedactDeliveries(aggregate, aggregateID string, redact outbox.RedactFunc) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin webhook transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(
+		`SELECT delivery_id, payload FROM webhook_deliveries
+		WHERE payload->>'aggregate' = $1 AND payload->>'aggregateId' = $2
+		FOR UPDATE`,
+		aggregate, aggregateID,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query webhook deliveries: %w", err)
+	}
+	type stored struct {
+		deliveryID string
+		payload    []byte
+	}
+	var deliveries []stored
+	for rows.Next() {
+		var delivery stored
+		if err := rows.Scan(&delivery.deliveryID, &delivery.payload); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to read webhook deliveries: %w", err)
+	}
+
+	for _, delivery := range deliveries {
+		redacted, _, err := redactPayload(delivery.payload, aggregate, aggregateID, redact)
+		if err != nil {
+			return 0, fmt.Errorf("failed to redact webhook delivery %s: %w", delivery.deliveryID, err)
+		}
+		if _, err := tx.Exec(`UPDATE webhook_deliveries SET payload = $2 WHERE delivery_id = $1`, delivery.deliveryID, []byte(redacted)); err != nil {
+			return 0, fmt.Errorf("failed to redact webhook delivery %s: %w", delivery.deliveryID, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit redacted webhook deliveries: %w", err)
+	}
+	return len(deliveries), nil
+}
+
+// queryDeliveries runs a query returning delivery rows in deliveryColumns order
+func (r *PostgresRepository) queryDeliveries(query string, args ...interface{}) ([]*Delivery, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := make([]*Delivery, 0)
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// subscriptionFilterClause builds the WHERE clause and its arguments for filter's criteria.
+// Event type filters use JSONB containment so they are served by the GIN index.
+func subscriptionFilterClause(filter SubscriptionFilter) (string, []interface{}, error) {
+	if filter.EventType == "" {
+		return "", nil, nil
+	}
+
+	events, err := marshalEvents([]string{filter.EventType})
+	if err != nil {
+		return "", nil, err
+	}
+	return ` WHERE active AND events @> $1::jsonb`, []interface{}{events}, nil
+}
+
+// deliveryFilterClause builds the WHERE clause and its arguments for filter's criteria
+func deliveryFilterClause(filter DeliveryFilter) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	if filter.SubscriptionID != "" {
+		args = append(args, filter.SubscriptionID)
+		conditions = append(conditions, fmt.Sprintf(`subscription_id = $%d`, len(args)))
+	}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conditions = append(conditions, fmt.Sprintf(`status = $%d`, len(args)))
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+	return ` WHERE ` + strings.Join(conditions, ` AND `), args
+}
+
+// pageClause appends the LIMIT and OFFSET of a page to query
+func pageClause(query string, args []interface{}, limit, offset int) (string, []interface{}) {
+	if limit > 0 {
+		args = append(args, limit)
+		query += fmt.Sprintf(` LIMIT $%d`, len(args))
+	}
+	if offset > 0 {
+		args = append(args, offset)
+		query += fmt.Sprintf(` OFFSET $%d`, len(args))
+	}
+	return query, args
+}
+
+// rowScanner is satisfied by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanSubscription reads one subscription row in subscriptionColumns order
+func scanSubscription(row rowScanner) (*Subscription, error) {
+	var subscription Subscription
+	var events []byte
+
+	err := row.Scan(
+		&subscription.SubscriptionID, &subscription.URL, &events, &subscription.Secret, &subscription.Active,
+		&subscription.CreatedAt, &subscription.UpdatedAt, &subscription.CreatedBy, &subscription.UpdatedBy,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan webhook subscription: %w", err)
+	}
+
+	if err := json.Unmarshal(events, &subscription.Events); err != nil {
+		return nil, fmt.Errorf("failed to decode webhook subscription events: %w", err)
+	}
+	return &subscription, nil
+}
+
+// scanDelivery reads one delivery row in deliveryColumns order
+func scanDelivery(row rowScanner) (*Delivery, error) {
+	var delivery Delivery
+	var payload []byte
+	var lastAttemptAt, deliveredAt sql.NullTime
+
+	err := row.Scan(
+		&delivery.DeliveryID, &delivery.SubscriptionID, &delivery.EventID, &delivery.EventType, &payload,
+		&delivery.Status, &delivery.Attempts, &delivery.NextAttemptAt, &lastAttemptAt, &delivery.ResponseStatus,
+		&delivery.LastError, &delivery.CreatedAt, &deliveredAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+	}
+
+	delivery.Payload = payload
+	if lastAttemptAt.Valid {
+		delivery.LastAttemptAt = &lastAttemptAt.Time
+	}
+	if deliveredAt.Valid {
+		delivery.DeliveredAt = &deliveredAt.Time
+	}
+	return &delivery, nil
+}
+
+// marshalEvents encodes event types as a JSON array, never null
+func marshalEvents(events []string) (string, error) {
+	if events == nil {
+		events = []string{}
+	}
+	data, err := json.Marshal(events)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode webhook subscription events: %w", err)
+	}
+	return string(data), nil
+}
+
+// requireRowAffected maps a statement that touched no rows to notFound
+func requireRowAffected(result sql.Result, notFound error) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to read affected rows: %w", err)
+	}
+	if affected == 0 {
+		return notFound
+	}
+	return nil
+}
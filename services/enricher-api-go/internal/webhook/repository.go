@@ -0,0 +1,332 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"enricher-api-go/internal/outbox"
+)
+
+var (
+	// ErrSubscriptionNotFound is returned when no subscription has the requested ID
+	ErrSubscriptionNotFound = errors.New("webhook subscription not found")
+	// ErrSubscriptionExists is returned when a subscription's ID is taken
+	ErrSubscriptionExists = errors.New("webhook subscription already exists")
+	// ErrDeliveryNotFound is returned when no delivery has the requested ID
+	ErrDeliveryNotFound = errors.New("webhook delivery not found")
+	// ErrDeliveryExists is returned when a delivery's ID is taken, or its
+	// subscription already has a delivery of the same event
+	ErrDeliveryExists = errors.New("webhook delivery already exists")
+)
+
+// Repository defines the interface for webhook data access.
+//
+// FindSubscriptions returns subscriptions oldest first and FindDeliveries
+// returns deliveries newest first, both breaking ties by ID so that
+// consecutive pages are stable.
+type Repository interface {
+	GetSubscription(subscriptionID string) (*Subscription, error)
+	FindSubscriptions(filter SubscriptionFilter) ([]*Subscription, error)
+	CountSubscriptions(filter SubscriptionFilter) (int, error)
+	CreateSubscription(subscription *Subscription) error
+	UpdateSubscription(subscription *Subscription) error
+	// DeleteSubscription removes a subscription along with its deliveries
+	DeleteSubscription(subscriptionID string) error
+
+	FindDeliveries(filter DeliveryFilter) ([]*Delivery, error)
+	CountDeliveries(filter DeliveryFilter) (int, error)
+	CreateDelivery(delivery *Delivery) error
+	UpdateDelivery(delivery *Delivery) error
+	// ClaimDue returns up to limit PENDING deliveries due at now, the
+	// longest overdue when more are due, and moves their NextAttemptAt to
+	// leaseUntil so that no other dispatcher claims them while they are
+	// attempted
+	ClaimDue(now, leaseUntil time.Time, limit int) ([]*Delivery, error)
+	// RedactDeliveries rewrites the data of the events of an aggregate in
+	// every delivery, whatever its status, with redact and returns how many
+	// deliveries it rewrote
+	RedactDeliveries(aggregate, aggregateID string, redact outbox.RedactFunc) (int, error)
+}
+
+// InMemoryRepository implements Repository interface using in-memory storage
+type InMemoryRepository struct {
+	subscriptions map[string]*Subscription
+	deliveries    map[string]*Delivery
+	mutex         sync.RWMutex
+}
+
+// NewInMemoryRepository creates a new, empty in-memory webhook repository
+func NewInMemoryRepository() *InMemoryRepository {
+	return &InMemoryRepository{
+		subscriptions: make(map[string]*Subscription),
+		deliveries:    make(map[string]*Delivery),
+		mutex:         sync.RWMutex{},
+	}
+}
+
+// Reset removes every subscription and delivery
+func (r *InMemoryRepository) Reset() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.subscriptions = make(map[string]*Subscription)
+	r.deliveries = make(map[string]*Delivery)
+}
+
+// GetSubscription retrieves a subscription by ID
+func (r *InMemoryRepository) GetSubscription(subscriptionID string) (*Subscription, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	subscription, exists := r.subscriptions[subscriptionID]
+	if !exists {
+		return nil, ErrSubscriptionNotFound
+	}
+	return copySubscription(subscription), nil
+}
+
+// FindSubscriptions returns the subscriptions matching filter, oldest first
+func (r *InMemoryRepository) FindSubscriptions(filter SubscriptionFilter) ([]*Subscription, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	subscriptions := make([]*Subscription, 0)
+	for _, subscription := range r.subscriptions {
+		if filter.Matches(subscription) {
+			subscriptions = append(subscriptions, copySubscription(subscription))
+		}
+	}
+	sort.Slice(subscriptions, func(i, j int) bool {
+		if !subscriptions[i].CreatedAt.Equal(subscriptions[j].CreatedAt) {
+			return subscriptions[i].CreatedAt.Before(subscriptions[j].CreatedAt)
+		}
+		return subscriptions[i].SubscriptionID < subscriptions[j].SubscriptionID
+	})
+	return paginate(subscriptions, filter.Limit, filter.Offset), nil
+}
+
+// CountSubscriptions returns the number of subscriptions matching filter, ignoring Limit and Offset
+func (r *InMemoryRepository) CountSubscriptions(filter SubscriptionFilter) (int, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	count := 0
+	for _, subscription := range r.subscriptions {
+		if filter.Matches(subscription) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// CreateSubscription adds a new subscription
+func (r *InMemoryRepository) CreateSubscription(subscription *Subscription) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.subscriptions[subscription.SubscriptionID]; exists {
+		return ErrSubscriptionExists
+	}
+	r.subscriptions[subscription.SubscriptionID] = copySubscription(subscription)
+	return nil
+}
+
+// UpdateSubscription replaces an existing subscription
+func (r *InMemoryRepository) UpdateSubscription(subscription *Subscription) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.subscriptions[subscription.SubscriptionID]; !exists {
+		return ErrSubscriptionNotFound
+	}
+	r.subscriptions[subscription.SubscriptionID] = copySubscription(subscription)
+	return nil
+}
+
+// DeleteSubscription removes a subscription along with its deliveries
+func (r *InMemoryRepository) DeleteSubscription(subscriptionID string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.subscriptions[subscriptionID]; !exists {
+		return ErrSubscriptionNotFound
+	}
+	delete(r.subscriptions, subscriptionID)
+	for deliveryID, delivery := range r.deliveries {
+		if delivery.SubscriptionID == subscriptionID {
+			delete(r.deliveries, deliveryID)
+		}
+	}
+	return nil
+}
+
+// FindDeliveries returns the deliveries matching filter, newest first
+func (r *InMemoryRepository) FindDeliveries(filter DeliveryFilter) ([]*Delivery, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	deliveries := make([]*Delivery, 0)
+	for _, delivery := range r.deliveries {
+		if filter.Matches(delivery) {
+			deliveries = append(deliveries, copyDelivery(delivery))
+		}
+	}
+	sort.Slice(deliveries, func(i, j int) bool {
+		if !deliveries[i].CreatedAt.Equal(deliveries[j].CreatedAt) {
+			return deliveries[i].CreatedAt.After(deliveries[j].CreatedAt)
+		}
+		return deliveries[i].DeliveryID > deliveries[j].DeliveryID
+	})
+	return paginate(deliveries, filter.Limit, filter.Offset), nil
+}
+
+// CountDeliveries returns the number of deliveries matching filter, ignoring Limit and Offset
+func (r *InMemoryRepository) CountDeliveries(filter DeliveryFilter) (int, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	count := 0
+	for _, delivery := range r.deliveries {
+		if filter.Matches(delivery) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// CreateDelivery adds a new delivery
+func (r *InMemoryRepository) CreateDelivery(delivery *Delivery) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.subscriptions[delivery.SubscriptionID]; !exists {
+		return ErrSubscriptionNotFound
+	}
+	if _, exists := r.deliveries[delivery.DeliveryID]; exists {
+		return ErrDeliveryExists
+	}
+	for _, existing := range r.deliveries {
+		if existing.SubscriptionID == delivery.SubscriptionID && existing.EventID == delivery.EventID {
+			return ErrDeliveryExists
+		}
+	}
+	r.deliveries[delivery.DeliveryID] = copyDelivery(delivery)
+	return nil
+}
+
+// UpdateDelivery replaces an existing delivery
+func (r *InMemoryRepository) UpdateDelivery(delivery *Delivery) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.deliveries[delivery.DeliveryID]; !exists {
+		return ErrDeliveryNotFound
+	}
+	r.deliveries[delivery.DeliveryID] = copyDelivery(delivery)
+	return nil
+}
+
+// ClaimDue returns up to limit PENDING deliveries due at now, the longest
+// overdue when more are due, leasing them until leaseUntil
+func (r *InMemoryRepository) ClaimDue(now, leaseUntil time.Time, limit int) ([]*Delivery, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	due := make([]*Delivery, 0)
+	for _, delivery := range r.deliveries {
+		if delivery.Status == StatusPending && !delivery.NextAttemptAt.After(now) {
+			due = append(due, delivery)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		if !due[i].NextAttemptAt.Equal(due[j].NextAttemptAt) {
+			return due[i].NextAttemptAt.Before(due[j].NextAttemptAt)
+		}
+		return due[i].DeliveryID < due[j].DeliveryID
+	})
+	if limit > 0 && limit < len(due) {
+		due = due[:limit]
+	}
+
+	claimed := make([]*Delivery, len(due))
+	for i, delivery := range due {
+		delivery.NextAttemptAt = leaseUntil
+		claimed[i] = copyDelivery(delivery)
+	}
+	return claimed, nil
+}
+
+// RedactDeliveries rewrites the data of the events of an aggregate in every
+// delivery
+func (r *InMemoryRepository) RedactDeliveries(aggregate, aggregateID string, redact outbox.RedactFunc) (int, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	redacted := 0
+	for _, delivery := range r.deliveries {
+		payload, matched, err := redactPayload(delivery.Payload, aggregate, aggregateID, redact)
+		if err != nil {
+			return redacted, fmt.Errorf("failed to redact webhook delivery %s: %w", delivery.DeliveryID, err)
+		}
+		if matched {
+			delivery.Payload = payload
+			redacted++
+		}
+	}
+	return redacted, nil
+}
+
+// redactPayload returns payload, an event as POSTed, with its data
+// rewritten by redact if the event is of the aggregate, and whether it is
+func redactPayload(payload json.RawMessage, aggregate, aggregateID string, redact outbox.RedactFunc) (json.RawMessage, bool, error) {
+	var event outbox.Event
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, false, fmt.Errorf("failed to decode payload: %w", err)
+	}
+	if event.Aggregate != aggregate || event.AggregateID != aggregateID {
+		return payload, false, nil
+	}
+
+	data, err := redact(event.Data)
+	if err != nil {
+		return nil, false, err
+	}
+	event.Data = data
+	redacted, err := json.Marshal(&event)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to encode payload: %w", err)
+	}
+	return redacted, true, nil
+}
+
+// paginate returns the window of items selected by limit and offset
+func paginate[T any](items []T, limit, offset int) []T {
+	if offset >= len(items) {
+		return items[:0]
+	}
+	items = items[offset:]
+
+	if limit > 0 && limit < len(items) {
+		items = items[:limit]
+	}
+	return items
+}
+
+// copySubscription copies a subscription so callers cannot change the stored events
+func copySubscription(subscription *Subscription) *Subscription {
+	subscriptionCopy := *subscription
+	subscriptionCopy.Events = slices.Clone(subscription.Events)
+	return &subscriptionCopy
+}
+
+// copyDelivery copies a delivery so callers cannot change the stored payload
+func copyDelivery(delivery *Delivery) *Delivery {
+	deliveryCopy := *delivery
+	deliveryCopy.Payload = slices.Clone(delivery.Payload)
+	return &deliveryCopy
+}
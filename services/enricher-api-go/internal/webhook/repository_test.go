@@ -0,0 +1,298 @@
+package webhook
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"enricher-api-go/internal/migrations"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// testRepositoryConformance runs the behavior every Repository implementation
+// must share against a repository created by newRepo.
+func testRepositoryConformance(t *testing.T, newRepo func(t *testing.T) Repository) {
+	created := time.Date(2024, 4, 1, 10, 0, 0, 0, time.UTC)
+	subscription := func(subscriptionID string, active bool, events ...string) *Subscription {
+		return &Subscription{SubscriptionID: subscriptionID, URL: "https://example.com/hooks/" + subscriptionID, Events: events,
+			Secret: "secret-" + subscriptionID, Active: active, CreatedAt: created, UpdatedAt: created}
+	}
+	delivery := func(deliveryID, subscriptionID, eventID string, due time.Time) *Delivery {
+		return &Delivery{DeliveryID: deliveryID, SubscriptionID: subscriptionID, EventID: eventID, EventType: "product.updated",
+			Payload: []byte(`{"eventId":"` + eventID + `"}`), Status: StatusPending, NextAttemptAt: due, CreatedAt: due}
+	}
+	mustCreate := func(t *testing.T, repo Repository, subscriptions ...*Subscription) {
+		t.Helper()
+		for _, subscription := range subscriptions {
+			if err := repo.CreateSubscription(subscription); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+		}
+	}
+
+	t.Run("Create, update and get subscriptions", func(t *testing.T) {
+		repo := newRepo(t)
+		stored := subscription("conformance-1", true, "product.updated", "product.stock_changed")
+		mustCreate(t, repo, stored)
+		stored.Events[0] = "customer.updated"
+
+		retrieved, err := repo.GetSubscription("conformance-1")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if retrieved.URL != "https://example.com/hooks/conformance-1" || retrieved.Secret != "secret-conformance-1" || !retrieved.Active ||
+			len(retrieved.Events) != 2 || retrieved.Events[0] != "product.updated" || !retrieved.CreatedAt.Equal(created) {
+			t.Errorf("Expected the stored subscription, got %+v", retrieved)
+		}
+		if err := repo.CreateSubscription(subscription("conformance-1", true, "product.updated")); !errors.Is(err, ErrSubscriptionExists) {
+			t.Errorf("Expected ErrSubscriptionExists, got %v", err)
+		}
+
+		retrieved.Active, retrieved.Events = false, []string{"customer.updated"}
+		if err := repo.UpdateSubscription(retrieved); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		updated, err := repo.GetSubscription("conformance-1")
+		if err != nil || updated.Active || len(updated.Events) != 1 || updated.Events[0] != "customer.updated" {
+			t.Errorf("Expected the update to be stored, got %+v, %v", updated, err)
+		}
+	})
+
+	t.Run("Find subscriptions by event type", func(t *testing.T) {
+		repo := newRepo(t)
+		later := subscription("conformance-b", true, "product.stock_changed")
+		later.CreatedAt = created.Add(time.Hour)
+		mustCreate(t, repo,
+			later,
+			subscription("conformance-a", true, "product.updated", "product.stock_changed"),
+			subscription("conformance-c", false, "product.stock_changed"),
+			subscription("conformance-d", true, "customer.updated"),
+		)
+
+		all, err := repo.FindSubscriptions(SubscriptionFilter{})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if got := subscriptionIDs(all); len(got) != 4 || got[3] != "conformance-b" {
+			t.Errorf("Expected every subscription oldest first, got %v", got)
+		}
+
+		receiving, err := repo.FindSubscriptions(SubscriptionFilter{EventType: "product.stock_changed"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if got := subscriptionIDs(receiving); len(got) != 2 || got[0] != "conformance-a" || got[1] != "conformance-b" {
+			t.Errorf("Expected the active subscriptions to the event type, got %v", got)
+		}
+
+		count, err := repo.CountSubscriptions(SubscriptionFilter{EventType: "product.stock_changed", Limit: 1})
+		if err != nil || count != 2 {
+			t.Errorf("Expected 2 subscriptions regardless of limit, got %d, %v", count, err)
+		}
+	})
+
+	t.Run("Deliveries", func(t *testing.T) {
+		repo := newRepo(t)
+		mustCreate(t, repo, subscription("conformance-1", true, "product.updated"), subscription("conformance-2", true, "product.updated"))
+
+		for _, d := range []*Delivery{
+			delivery("delivery-a", "conformance-1", "evt-1", created),
+			delivery("delivery-b", "conformance-1", "evt-2", created.Add(time.Minute)),
+			delivery("delivery-c", "conformance-2", "evt-1", created),
+		} {
+			if err := repo.CreateDelivery(d); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+		}
+		if err := repo.CreateDelivery(delivery("delivery-d", "conformance-1", "evt-1", created)); !errors.Is(err, ErrDeliveryExists) {
+			t.Errorf("Expected ErrDeliveryExists for a second delivery of an event, got %v", err)
+		}
+		if err := repo.CreateDelivery(delivery("delivery-e", "conformance-missing", "evt-1", created)); !errors.Is(err, ErrSubscriptionNotFound) {
+			t.Errorf("Expected ErrSubscriptionNotFound for an unknown subscription, got %v", err)
+		}
+
+		log, err := repo.FindDeliveries(DeliveryFilter{SubscriptionID: "conformance-1"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if got := deliveryIDs(log); len(got) != 2 || got[0] != "delivery-b" || string(log[1].Payload) != `{"eventId":"evt-1"}` {
+			t.Errorf("Expected the subscription's deliveries newest first, got %v", got)
+		}
+
+		delivered := log[1]
+		deliveredAt := created.Add(time.Second)
+		delivered.Status, delivered.Attempts, delivered.ResponseStatus, delivered.DeliveredAt, delivered.LastAttemptAt =
+			StatusDelivered, 1, 204, &deliveredAt, &deliveredAt
+		if err := repo.UpdateDelivery(delivered); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		count, err := repo.CountDeliveries(DeliveryFilter{SubscriptionID: "conformance-1", Status: StatusDelivered})
+		if err != nil || count != 1 {
+			t.Errorf("Expected 1 delivered delivery, got %d, %v", count, err)
+		}
+		if err := repo.UpdateDelivery(delivery("delivery-missing", "conformance-1", "evt-9", created)); !errors.Is(err, ErrDeliveryNotFound) {
+			t.Errorf("Expected ErrDeliveryNotFound, got %v", err)
+		}
+
+		if err := repo.DeleteSubscription("conformance-1"); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if count, err := repo.CountDeliveries(DeliveryFilter{}); err != nil || count != 1 {
+			t.Errorf("Expected the deliveries deleted with their subscription, got %d, %v", count, err)
+		}
+		if err := repo.DeleteSubscription("conformance-1"); !errors.Is(err, ErrSubscriptionNotFound) {
+			t.Errorf("Expected ErrSubscriptionNotFound, got %v", err)
+		}
+	})
+
+	t.Run("Claim due deliveries", func(t *testing.T) {
+		repo := newRepo(t)
+		mustCreate(t, repo, subscription("conformance-1", true, "product.updated"))
+		for _, d := range []*Delivery{
+			delivery("delivery-a", "conformance-1", "evt-1", created.Add(time.Minute)),
+			delivery("delivery-b", "conformance-1", "evt-2", created),
+			delivery("delivery-c", "conformance-1", "evt-3", created.Add(time.Hour)),
+		} {
+			if err := repo.CreateDelivery(d); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+		}
+		now, lease := created.Add(2*time.Minute), created.Add(3*time.Minute)
+
+		first, err := repo.ClaimDue(now, lease, 1)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		second, err := repo.ClaimDue(now, lease, 10)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		none, err := repo.ClaimDue(now, lease, 10)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if got := deliveryIDs(first); len(got) != 1 || got[0] != "delivery-b" || !first[0].NextAttemptAt.Equal(lease) {
+			t.Errorf("Expected the longest overdue delivery leased, got %v", first)
+		}
+		if got := deliveryIDs(second); len(got) != 1 || got[0] != "delivery-a" {
+			t.Errorf("Expected the other due delivery, got %v", got)
+		}
+		if len(none) != 0 {
+			t.Errorf("Expected leased and future deliveries not claimed, got %v", deliveryIDs(none))
+		}
+		if expired, err := repo.ClaimDue(lease, lease.Add(time.Minute), 10); err != nil || len(expired) != 2 {
+			t.Errorf("Expected both deliveries claimed again once their lease expired, got %v, %v", deliveryIDs(expired), err)
+		}
+	})
+
+	t.Run("Redact deliveries", func(t *testing.T) {
+		repo := newRepo(t)
+		mustCreate(t, repo, subscription("conformance-1", true, "customer.updated"))
+		for _, d := range []*Delivery{
+			delivery("delivery-a", "conformance-1", "evt-1", created),
+			delivery("delivery-b", "conformance-1", "evt-2", created),
+		} {
+			d.Payload = []byte(`{"eventId":"` + d.EventID + `","type":"customer.updated","aggregate":"customer",` +
+				`"aggregateId":"customer-` + d.EventID + `","data":{"name":"Jane Doe"}}`)
+			if err := repo.CreateDelivery(d); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+		}
+
+		redacted, err := repo.RedactDeliveries("customer", "customer-evt-1", func(json.RawMessage) (json.RawMessage, error) {
+			return json.RawMessage(`{"name":"ERASED"}`), nil
+		})
+		if err != nil || redacted != 1 {
+			t.Fatalf("Expected 1 delivery redacted, got %d, %v", redacted, err)
+		}
+
+		deliveries, err := repo.FindDeliveries(DeliveryFilter{SubscriptionID: "conformance-1"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		for _, d := range deliveries {
+			var payload struct {
+				Data struct{ Name string } `json:"data"`
+			}
+			if err := json.Unmarshal(d.Payload, &payload); err != nil {
+				t.Fatalf("Expected a JSON payload, got %s", d.Payload)
+			}
+			expected := map[string]string{"delivery-a": "ERASED", "delivery-b": "Jane Doe"}[d.DeliveryID]
+			if payload.Data.Name != expected {
+				t.Errorf("Expected delivery %s to hold %q, got %s", d.DeliveryID, expected, d.Payload)
+			}
+		}
+	})
+
+	t.Run("Missing", func(t *testing.T) {
+		repo := newRepo(t)
+
+		if _, err := repo.GetSubscription("conformance-missing"); !errors.Is(err, ErrSubscriptionNotFound) {
+			t.Errorf("Expected ErrSubscriptionNotFound, got %v", err)
+		}
+		if err := repo.UpdateSubscription(subscription("conformance-missing", true)); !errors.Is(err, ErrSubscriptionNotFound) {
+			t.Errorf("Expected ErrSubscriptionNotFound on update, got %v", err)
+		}
+	})
+}
+
+// subscriptionIDs returns the IDs of subscriptions, in order
+func subscriptionIDs(subscriptions []*Subscription) []string {
+	ids := make([]string, len(subscriptions))
+	for i, subscription := range subscriptions {
+		ids[i] = subscription.SubscriptionID
+	}
+	return ids
+}
+
+// deliveryIDs returns the IDs of deliveries, in order
+func deliveryIDs(deliveries []*Delivery) []string {
+	ids := make([]string, len(deliveries))
+	for i, delivery := range deliveries {
+		ids[i] = delivery.DeliveryID
+	}
+	return ids
+}
+
+func TestInMemoryRepository_Conformance(t *testing.T) {
+	testRepositoryConformance(t, func(t *testing.T) Repository {
+		return NewInMemoryRepository()
+	})
+}
+
+// TestPostgresRepository_Conformance runs against the database in
+// POSTGRES_TEST_DSN and is skipped when it is not set.
+func TestPostgresRepository_Conformance(t *testing.T) {
+	dsn := os.Getenv("POSTGRES_TEST_DSN")
+	if dsn == "" {
+		t.Skip("POSTGRES_TEST_DSN not set")
+	}
+
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := migrations.New(db)
+	if err != nil {
+		t.Fatalf("Failed to load migrations: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+
+	testRepositoryConformance(t, func(t *testing.T) Repository {
+		repo := NewPostgresRepository(db)
+		if _, err := db.Exec(`TRUNCATE webhook_subscriptions, webhook_deliveries`); err != nil {
+			t.Fatalf("Failed to reset webhooks: %v", err)
+		}
+		return repo
+	})
+}
@@ -0,0 +1,110 @@
+package webhook
+
+import (
+	"time"
+
+	"enricher-api-go/internal/outbox"
+	"enricher-api-go/internal/retry"
+)
+
+// RetryRepository retries the calls of another Repository that fail
+// transiently: reads on any transient failure, writes only on failures that
+// show nothing was written.
+type RetryRepository struct {
+	repo    Repository
+	retrier *retry.Retrier
+}
+
+// NewRetryRepository wraps repo with retrier, typically the retrier shared
+// by every repository using the same backend
+func NewRetryRepository(repo Repository, retrier *retry.Retrier) *RetryRepository {
+	return &RetryRepository{repo: repo, retrier: retrier}
+}
+
+// GetSubscription retrieves a subscription by ID
+func (r *RetryRepository) GetSubscription(subscriptionID string) (subscription *Subscription, err error) {
+	err = r.retrier.Read(func() error {
+		subscription, err = r.repo.GetSubscription(subscriptionID)
+		return err
+	})
+	return subscription, err
+}
+
+// FindSubscriptions returns the subscriptions matching filter
+func (r *RetryRepository) FindSubscriptions(filter SubscriptionFilter) (subscriptions []*Subscription, err error) {
+	err = r.retrier.Read(func() error {
+		subscriptions, err = r.repo.FindSubscriptions(filter)
+		return err
+	})
+	return subscriptions, err
+}
+
+// CountSubscriptions returns the number of subscriptions matching filter
+func (r *RetryRepository) CountSubscriptions(filter SubscriptionFilter) (count int, err error) {
+	err = r.retrier.Read(func() error {
+		count, err = r.repo.CountSubscriptions(filter)
+		return err
+	})
+	return count, err
+}
+
+// CreateSubscription adds a new subscription
+func (r *RetryRepository) CreateSubscription(subscription *Subscription) error {
+	return r.retrier.Write(func() error { return r.repo.CreateSubscription(subscription) })
+}
+
+// UpdateSubscription replaces an existing subscription
+func (r *RetryRepository) UpdateSubscription(subscription *Subscription) error {
+	return r.retrier.Write(func() error { return r.repo.UpdateSubscription(subscription) })
+}
+
+// DeleteSubscription removes a subscription along with its deliveries
+func (r *RetryRepository) DeleteSubscription(subscriptionID string) error {
+	return r.retrier.Write(func() error { return r.repo.DeleteSubscription(subscriptionID) })
+}
+
+// FindDeliveries returns the deliveries matching filter
+func (r *RetryRepository) FindDeliveries(filter DeliveryFilter) (deliveries []*Delivery, err error) {
+	err = r.retrier.Read(func() error {
+		deliveries, err = r.repo.FindDeliveries(filter)
+		return err
+	})
+	return deliveries, err
+}
+
+// CountDeliveries returns the number of deliveries matching filter
+func (r *RetryRepository) CountDeliveries(filter DeliveryFilter) (count int, err error) {
+	err = r.retrier.Read(func() error {
+		count, err = r.repo.CountDeliveries(filter)
+		return err
+	})
+	return count, err
+}
+
+// CreateDelivery adds a new delivery
+func (r *RetryRepository) CreateDelivery(delivery *Delivery) error {
+	return r.retrier.Write(func() error { return r.repo.CreateDelivery(delivery) })
+}
+
+// UpdateDelivery replaces an existing delivery
+func (r *RetryRepository) UpdateDelivery(delivery *Delivery) error {
+	return r.retrier.Write(func() error { return r.repo.UpdateDelivery(delivery) })
+}
+
+// ClaimDue leases the PENDING deliveries due at now
+func (r *RetryRepository) ClaimDue(now, leaseUntil time.Time, limit int) (deliveries []*Delivery, err error) {
+	err = r.retrier.Write(func() error {
+		deliveries, err = r.repo.ClaimDue(now, leaseUntil, limit)
+		return err
+	})
+	return deliveries, err
+}
+
+// RedactDeliveries rewrites the data of the events of an aggregate in every delivery
+func (r *RetryRepository) RedactDeliveries(aggregate, aggregateID string, redact outbox.RedactFunc) (redacted int, err error) {
+	err = r.retrier.Write(func() error {
+		redacted, err = r.repo.RedactDeliveries(aggregate, aggregateID, redact)
+		return err
+	})
+	return redacted, err
+}
@@ -0,0 +1,376 @@
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"enricher-api-go/internal/auth"
+	"enricher-api-go/internal/clock"
+	"enricher-api-go/internal/idgen"
+	"enricher-api-go/internal/logging"
+	"enricher-api-go/internal/outbox"
+	"enricher-api-go/internal/validation"
+)
+
+var (
+	// ErrInvalidSubscription is returned when a subscription request fails validation
+	ErrInvalidSubscription = errors.New("invalid webhook subscription")
+	// ErrInvalidFilter is returned when list filters or pagination values are out of range
+	ErrInvalidFilter = errors.New("invalid filter")
+)
+
+// statuses lists the statuses deliveries can be filtered by
+var statuses = []string{StatusPending, StatusDelivered, StatusFailed}
+
+// Service defines the business logic interface for webhook subscriptions
+type Service interface {
+	GetSubscription(ctx context.Context, subscriptionID string) (*Subscription, error)
+	FindSubscriptions(ctx context.Context, filter SubscriptionFilter) ([]*Subscription, error)
+	CountSubscriptions(ctx context.Context, filter SubscriptionFilter) (int, error)
+	CreateSubscription(ctx context.Context, req SubscriptionRequest) (*Subscription, error)
+	UpdateSubscription(ctx context.Context, subscriptionID string, req SubscriptionRequest) (*Subscription, error)
+	DeleteSubscription(ctx context.Context, subscriptionID string) error
+	FindDeliveries(ctx context.Context, filter DeliveryFilter) ([]*Delivery, error)
+	CountDeliveries(ctx context.Context, filter DeliveryFilter) (int, error)
+}
+
+// WebhookService implements the Service interface, and outbox.Publisher to
+// queue the deliveries of the events the relay publishes
+type WebhookService struct {
+	repo        Repository
+	idGenerator idgen.Generator
+	clock       clock.Clock
+}
+
+// Option configures optional WebhookService behavior
+type Option func(*WebhookService)
+
+// WithIDGenerator sets the generator used for new subscription and delivery IDs (UUIDs by default)
+func WithIDGenerator(gen idgen.Generator) Option {
+	return func(s *WebhookService) {
+		s.idGenerator = gen
+	}
+}
+
+// WithClock sets the clock used for audit and delivery times (the UTC wall clock by default)
+func WithClock(c clock.Clock) Option {
+	return func(s *WebhookService) {
+		s.clock = c
+	}
+}
+
+// NewService creates a new webhook service
+func NewService(repo Repository, opts ...Option) *WebhookService {
+	s := &WebhookService{
+		repo:        repo,
+		idGenerator: idgen.UUIDGenerator{},
+		clock:       clock.System{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// GetSubscription retrieves a subscription by ID, without its secret
+func (s *WebhookService) GetSubscription(ctx context.Context, subscriptionID string) (*Subscription, error) {
+	logger := logging.FromContext(ctx)
+	logger.Debug("Getting webhook subscription", "subscription_id", subscriptionID)
+
+	subscription, err := s.repo.GetSubscription(subscriptionID)
+	if err != nil {
+		if !errors.Is(err, ErrSubscriptionNotFound) {
+			logger.Error("Failed to get webhook subscription", "subscription_id", subscriptionID, "error", err)
+		}
+		return nil, err
+	}
+	return redact(subscription), nil
+}
+
+// FindSubscriptions returns the subscriptions matching filter, oldest first, without their secrets
+func (s *WebhookService) FindSubscriptions(ctx context.Context, filter SubscriptionFilter) ([]*Subscription, error) {
+	if err := validatePage(filter.Limit, filter.Offset); err != nil {
+		return nil, err
+	}
+
+	subscriptions, err := s.repo.FindSubscriptions(filter)
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to find webhook subscriptions", "error", err)
+		return nil, fmt.Errorf("failed to find webhook subscriptions: %w", err)
+	}
+	for i, subscription := range subscriptions {
+		subscriptions[i] = redact(subscription)
+	}
+	return subscriptions, nil
+}
+
+// CountSubscriptions returns the number of subscriptions matching filter
+func (s *WebhookService) CountSubscriptions(ctx context.Context, filter SubscriptionFilter) (int, error) {
+	if err := validatePage(filter.Limit, filter.Offset); err != nil {
+		return 0, err
+	}
+
+	count, err := s.repo.CountSubscriptions(filter)
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to count webhook subscriptions", "error", err)
+		return 0, fmt.Errorf("failed to count webhook subscriptions: %w", err)
+	}
+	return count, nil
+}
+
+// CreateSubscription registers a callback URL for events. The subscription
+// is returned with its secret, generated when the request has none; later
+// reads never return it.
+func (s *WebhookService) CreateSubscription(ctx context.Context, req SubscriptionRequest) (*Subscription, error) {
+	logger := logging.FromContext(ctx)
+	logger.Info("Creating webhook subscription", "url", req.URL, "events", req.Events)
+
+	if err := validateSubscriptionRequest(req); err != nil {
+		return nil, err
+	}
+
+	subscriptionID, err := s.idGenerator.NewID("webhook")
+	if err != nil {
+		logger.Error("Failed to generate webhook subscription ID", "error", err)
+		return nil, fmt.Errorf("failed to create webhook subscription: %w", err)
+	}
+	secret := req.Secret
+	if secret == "" {
+		if secret, err = newSecret(); err != nil {
+			logger.Error("Failed to generate webhook secret", "error", err)
+			return nil, fmt.Errorf("failed to create webhook subscription: %w", err)
+		}
+	}
+
+	now, caller := s.clock.Now(), auth.Caller(ctx)
+	subscription := &Subscription{
+		SubscriptionID: subscriptionID,
+		URL:            req.URL,
+		Events:         req.Events,
+		Secret:         secret,
+		Active:         req.Active == nil || *req.Active,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		CreatedBy:      caller,
+		UpdatedBy:      caller,
+	}
+
+	if err := s.repo.CreateSubscription(subscription); err != nil {
+		logger.Error("Failed to create webhook subscription", "error", err)
+		return nil, fmt.Errorf("failed to create webhook subscription: %w", err)
+	}
+
+	logger.Info("Created webhook subscription", "subscription_id", subscriptionID)
+	return subscription, nil
+}
+
+// UpdateSubscription replaces a subscription's URL and events, and its
+// secret and active flag when the request sets them. Deliveries already
+// queued keep their payload but are sent to the new URL.
+func (s *WebhookService) UpdateSubscription(ctx context.Context, subscriptionID string, req SubscriptionRequest) (*Subscription, error) {
+	logger := logging.FromContext(ctx)
+	logger.Info("Updating webhook subscription", "subscription_id", subscriptionID)
+
+	if err := validateSubscriptionRequest(req); err != nil {
+		return nil, err
+	}
+
+	subscription, err := s.repo.GetSubscription(subscriptionID)
+	if err != nil {
+		if !errors.Is(err, ErrSubscriptionNotFound) {
+			logger.Error("Failed to get webhook subscription", "subscription_id", subscriptionID, "error", err)
+		}
+		return nil, err
+	}
+
+	subscription.URL, subscription.Events = req.URL, req.Events
+	if req.Secret != "" {
+		subscription.Secret = req.Secret
+	}
+	if req.Active != nil {
+		subscription.Active = *req.Active
+	}
+	subscription.UpdatedAt, subscription.UpdatedBy = s.clock.Now(), auth.Caller(ctx)
+
+	if err := s.repo.UpdateSubscription(subscription); err != nil {
+		if errors.Is(err, ErrSubscriptionNotFound) {
+			return nil, err
+		}
+		logger.Error("Failed to update webhook subscription", "subscription_id", subscriptionID, "error", err)
+		return nil, fmt.Errorf("failed to update webhook subscription: %w", err)
+	}
+
+	logger.Info("Updated webhook subscription", "subscription_id", subscriptionID)
+	return redact(subscription), nil
+}
+
+// DeleteSubscription removes a subscription along with its delivery log;
+// its pending deliveries are dropped
+func (s *WebhookService) DeleteSubscription(ctx context.Context, subscriptionID string) error {
+	logger := logging.FromContext(ctx)
+	logger.Info("Deleting webhook subscription", "subscription_id", subscriptionID)
+
+	if err := s.repo.DeleteSubscription(subscriptionID); err != nil {
+		if errors.Is(err, ErrSubscriptionNotFound) {
+			return err
+		}
+		logger.Error("Failed to delete webhook subscription", "subscription_id", subscriptionID, "error", err)
+		return fmt.Errorf("failed to delete webhook subscription: %w", err)
+	}
+
+	logger.Info("Deleted webhook subscription", "subscription_id", subscriptionID)
+	return nil
+}
+
+// FindDeliveries returns the deliveries matching filter, newest first.
+// Filtering by an unknown subscription returns ErrSubscriptionNotFound.
+func (s *WebhookService) FindDeliveries(ctx context.Context, filter DeliveryFilter) ([]*Delivery, error) {
+	if err := s.validateDeliveryFilter(ctx, filter); err != nil {
+		return nil, err
+	}
+
+	deliveries, err := s.repo.FindDeliveries(filter)
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to find webhook deliveries", "error", err)
+		return nil, fmt.Errorf("failed to find webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// CountDeliveries returns the number of deliveries matching filter
+func (s *WebhookService) CountDeliveries(ctx context.Context, filter DeliveryFilter) (int, error) {
+	if err := s.validateDeliveryFilter(ctx, filter); err != nil {
+		return 0, err
+	}
+
+	count, err := s.repo.CountDeliveries(filter)
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to count webhook deliveries", "error", err)
+		return 0, fmt.Errorf("failed to count webhook deliveries: %w", err)
+	}
+	return count, nil
+}
+
+// Publish queues a delivery of each event to every active subscription
+// receiving its type, due right away.
+//
+// The relay publishes a batch again when any of its publishers fails, so
+// events a subscription already has a delivery of are skipped.
+func (s *WebhookService) Publish(ctx context.Context, events []*outbox.Event) error {
+	logger := logging.FromContext(ctx)
+	subscribers := make(map[string][]*Subscription)
+
+	queued := 0
+	for _, event := range events {
+		subscriptions, seen := subscribers[event.Type]
+		if !seen {
+			var err error
+			if subscriptions, err = s.repo.FindSubscriptions(SubscriptionFilter{EventType: event.Type}); err != nil {
+				return fmt.Errorf("failed to find webhook subscriptions: %w", err)
+			}
+			subscribers[event.Type] = subscriptions
+		}
+		if len(subscriptions) == 0 {
+			continue
+		}
+
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to encode event %s: %w", event.EventID, err)
+		}
+		for _, subscription := range subscriptions {
+			deliveryID, err := s.idGenerator.NewID("delivery")
+			if err != nil {
+				return fmt.Errorf("failed to queue webhook delivery: %w", err)
+			}
+
+			now := s.clock.Now()
+			err = s.repo.CreateDelivery(&Delivery{
+				DeliveryID:     deliveryID,
+				SubscriptionID: subscription.SubscriptionID,
+				EventID:        event.EventID,
+				EventType:      event.Type,
+				Payload:        payload,
+				Status:         StatusPending,
+				NextAttemptAt:  now,
+				CreatedAt:      now,
+			})
+			switch {
+			case err == nil:
+				queued++
+			case errors.Is(err, ErrDeliveryExists), errors.Is(err, ErrSubscriptionNotFound):
+				// Queued by an earlier attempt at this batch, or deleted since it was found
+			default:
+				return fmt.Errorf("failed to queue webhook delivery: %w", err)
+			}
+		}
+	}
+
+	if queued > 0 {
+		logger.Debug("Queued webhook deliveries", "deliveries", queued)
+	}
+	return nil
+}
+
+// validateDeliveryFilter checks pagination and status values, and that the
+// filtered subscription exists
+func (s *WebhookService) validateDeliveryFilter(ctx context.Context, filter DeliveryFilter) error {
+	if filter.Status != "" && !slices.Contains(statuses, filter.Status) {
+		return fmt.Errorf("%w: status must be one of %s", ErrInvalidFilter, strings.Join(statuses, ", "))
+	}
+	if err := validatePage(filter.Limit, filter.Offset); err != nil {
+		return err
+	}
+	if filter.SubscriptionID != "" {
+		if _, err := s.GetSubscription(ctx, filter.SubscriptionID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateSubscriptionRequest checks a subscription request, including
+// that every event type can be subscribed to
+func validateSubscriptionRequest(req SubscriptionRequest) error {
+	if err := validation.Struct(req); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSubscription, err)
+	}
+	for i, event := range req.Events {
+		if !slices.Contains(EventTypes, event) {
+			return fmt.Errorf("%w: events[%d] must be one of %s", ErrInvalidSubscription, i, strings.Join(EventTypes, ", "))
+		}
+	}
+	return nil
+}
+
+// validatePage checks pagination values
+func validatePage(limit, offset int) error {
+	if limit < 0 {
+		return fmt.Errorf("%w: limit must not be negative", ErrInvalidFilter)
+	}
+	if offset < 0 {
+		return fmt.Errorf("%w: offset must not be negative", ErrInvalidFilter)
+	}
+	return nil
+}
+
+// redact clears a subscription's secret before it is returned
+func redact(subscription *Subscription) *Subscription {
+	subscription.Secret = ""
+	return subscription
+}
+
+// newSecret generates a random signing secret
+func newSecret() (string, error) {
+	var b [32]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("failed to generate secret: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(b[:]), nil
+}
@@ -0,0 +1,161 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"enricher-api-go/internal/clock"
+	"enricher-api-go/internal/outbox"
+)
+
+// productEvent returns a recorded event of productID
+func productEvent(eventID, eventType, productID string) *outbox.Event {
+	return &outbox.Event{EventID: eventID, Type: eventType, Aggregate: outbox.AggregateProduct, AggregateID: productID,
+		Data: []byte(`{"productId":"` + productID + `"}`), OccurredAt: time.Date(2024, 4, 1, 10, 0, 0, 0, time.UTC)}
+}
+
+func TestWebhookService_CreateSubscription(t *testing.T) {
+	// Arrange
+	now := time.Date(2024, 4, 1, 10, 0, 0, 0, time.UTC)
+	service := NewService(NewInMemoryRepository(), WithClock(clock.Fixed(now)))
+	ctx := context.Background()
+	req := SubscriptionRequest{URL: "https://pricing.example.com/hooks", Events: []string{"product.stock_changed"}}
+
+	// Act
+	created, err := service.CreateSubscription(ctx, req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	retrieved, err := service.GetSubscription(ctx, created.SubscriptionID)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !strings.HasPrefix(created.Secret, "whsec_") || !created.Active || !created.CreatedAt.Equal(now) {
+		t.Errorf("Expected an active subscription with a generated secret, got %+v", created)
+	}
+	if retrieved.Secret != "" || retrieved.URL != req.URL {
+		t.Errorf("Expected the subscription without its secret, got %+v", retrieved)
+	}
+}
+
+func TestWebhookService_CreateSubscription_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		req  SubscriptionRequest
+	}{
+		{name: "missing URL", req: SubscriptionRequest{Events: []string{"product.updated"}}},
+		{name: "non-http URL", req: SubscriptionRequest{URL: "ftp://example.com/hooks", Events: []string{"product.updated"}}},
+		{name: "no events", req: SubscriptionRequest{URL: "https://example.com/hooks"}},
+		{name: "unknown event", req: SubscriptionRequest{URL: "https://example.com/hooks", Events: []string{"order.created"}}},
+		{name: "short secret", req: SubscriptionRequest{URL: "https://example.com/hooks", Events: []string{"product.updated"}, Secret: "short"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			service := NewService(NewInMemoryRepository())
+
+			// Act
+			_, err := service.CreateSubscription(context.Background(), tt.req)
+
+			// Assert
+			if !errors.Is(err, ErrInvalidSubscription) {
+				t.Errorf("Expected ErrInvalidSubscription, got %v", err)
+			}
+		})
+	}
+}
+
+func TestWebhookService_UpdateSubscription(t *testing.T) {
+	// Arrange
+	repo := NewInMemoryRepository()
+	service := NewService(repo)
+	ctx := context.Background()
+	created, err := service.CreateSubscription(ctx, SubscriptionRequest{URL: "https://example.com/hooks", Events: []string{"product.updated"},
+		Secret: "a-sixteen-char-secret"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	inactive := false
+
+	// Act
+	updated, err := service.UpdateSubscription(ctx, created.SubscriptionID, SubscriptionRequest{URL: "https://example.com/v2/hooks",
+		Events: []string{"customer.updated"}, Active: &inactive})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if updated.URL != "https://example.com/v2/hooks" || updated.Active || updated.Secret != "" {
+		t.Errorf("Expected the updated subscription without its secret, got %+v", updated)
+	}
+	if stored, _ := repo.GetSubscription(created.SubscriptionID); stored.Secret != "a-sixteen-char-secret" {
+		t.Errorf("Expected the secret kept when the request omits it, got %q", stored.Secret)
+	}
+	if _, err := service.UpdateSubscription(ctx, "webhook-missing", SubscriptionRequest{URL: "https://example.com/hooks",
+		Events: []string{"product.updated"}}); !errors.Is(err, ErrSubscriptionNotFound) {
+		t.Errorf("Expected ErrSubscriptionNotFound, got %v", err)
+	}
+}
+
+func TestWebhookService_Publish(t *testing.T) {
+	// Arrange
+	service := NewService(NewInMemoryRepository())
+	ctx := context.Background()
+	subscribe := func(events []string, active bool) *Subscription {
+		subscription, err := service.CreateSubscription(ctx, SubscriptionRequest{URL: "https://example.com/hooks", Events: events, Active: &active})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		return subscription
+	}
+	pricing := subscribe([]string{"product.updated", "product.stock_changed"}, true)
+	stock := subscribe([]string{"product.stock_changed"}, true)
+	paused := subscribe([]string{"product.stock_changed"}, false)
+	events := []*outbox.Event{
+		productEvent("evt-1", "product.updated", "product-1"),
+		productEvent("evt-2", "product.stock_changed", "product-1"),
+		productEvent("evt-3", "customer.created", "customer-1"),
+	}
+
+	// Act
+	err := service.Publish(ctx, events)
+	retryErr := service.Publish(ctx, events)
+
+	// Assert
+	if err != nil || retryErr != nil {
+		t.Fatalf("Expected no error, got %v and %v", err, retryErr)
+	}
+	count := func(subscriptionID string) int {
+		count, err := service.CountDeliveries(ctx, DeliveryFilter{SubscriptionID: subscriptionID})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		return count
+	}
+	if got := count(pricing.SubscriptionID); got != 2 {
+		t.Errorf("Expected both product events queued once for pricing, got %d", got)
+	}
+	if got := count(stock.SubscriptionID); got != 1 {
+		t.Errorf("Expected the stock event queued once for stock, got %d", got)
+	}
+	if got := count(paused.SubscriptionID); got != 0 {
+		t.Errorf("Expected nothing queued for an inactive subscription, got %d", got)
+	}
+
+	deliveries, err := service.FindDeliveries(ctx, DeliveryFilter{SubscriptionID: stock.SubscriptionID})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if d := deliveries[0]; d.EventID != "evt-2" || d.Status != StatusPending || !strings.Contains(string(d.Payload), `"type":"product.stock_changed"`) {
+		t.Errorf("Expected a pending delivery of the event, got %+v", d)
+	}
+	if _, err := service.FindDeliveries(ctx, DeliveryFilter{SubscriptionID: "webhook-missing"}); !errors.Is(err, ErrSubscriptionNotFound) {
+		t.Errorf("Expected ErrSubscriptionNotFound for an unknown subscription, got %v", err)
+	}
+}